@@ -0,0 +1,220 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"panoptic/internal/cloud"
+	"panoptic/internal/logger"
+	"panoptic/internal/selfupdate"
+
+	"github.com/spf13/cobra"
+)
+
+// UpdateKeyEnv holds the public release key agents verify updates with when
+// --update-key isn't given
+const UpdateKeyEnv = "PANOPTIC_UPDATE_KEY"
+
+// agentUpdater keeps an agent on the release of its channel, installing a
+// newer one while the agent is idle and its update window is open, then
+// restarting into it
+type agentUpdater struct {
+	updater  *selfupdate.Updater
+	running  string // the version of this process
+	interval time.Duration
+	window   selfupdate.Window
+	idle     *sync.Mutex // held while the agent works, e.g. the busy lock of its queue worker
+	retry    time.Duration
+	restart  func(executable string) error
+	now      func() time.Time
+	log      *logger.Logger
+
+	mu      sync.Mutex
+	health  string
+	message string
+}
+
+// agentUpdaterFromFlags builds the updater of an agent from its update
+// flags; it is nil without --update-channel. A channel named rather than
+// given as a URL is served by the registry.
+func agentUpdaterFromFlags(cmd *cobra.Command, registryURL, apiKey string, log *logger.Logger) (*agentUpdater, error) {
+	channel, _ := cmd.Flags().GetString("update-channel")
+	if channel == "" {
+		return nil, nil
+	}
+	encoded, _ := cmd.Flags().GetString("update-key")
+	if encoded == "" {
+		encoded = os.Getenv(UpdateKeyEnv)
+	}
+	if encoded == "" {
+		return nil, fmt.Errorf("--update-key flag or %s is required with --update-channel", UpdateKeyEnv)
+	}
+	key, err := selfupdate.ParsePublicKey(encoded)
+	if err != nil {
+		return nil, err
+	}
+	interval, _ := cmd.Flags().GetDuration("update-interval")
+	if interval <= 0 {
+		return nil, fmt.Errorf("--update-interval must be positive")
+	}
+	spec, _ := cmd.Flags().GetString("update-window")
+	window, err := selfupdate.ParseWindow(spec)
+	if err != nil {
+		return nil, err
+	}
+	exe, err := selfupdate.Executable()
+	if err != nil {
+		return nil, err
+	}
+	// The binary the last update replaced on Windows can go now
+	if err := selfupdate.RemoveReplaced(exe); err != nil {
+		log.Warnf("Failed to remove the replaced agent binary: %v", err)
+	}
+
+	manifestURL := channel
+	if !strings.Contains(channel, "://") {
+		manifestURL = strings.TrimRight(registryURL, "/") + "/releases/" + url.PathEscape(channel) + ".json"
+	} else if !sameHost(channel, registryURL) {
+		// The registry's token isn't for other servers
+		apiKey = ""
+	}
+
+	version := selfupdate.Version()
+	return &agentUpdater{
+		updater: &selfupdate.Updater{
+			ManifestURL: manifestURL,
+			APIKey:      apiKey,
+			PublicKey:   key,
+			Current:     version,
+			Executable:  exe,
+		},
+		running:  version,
+		interval: interval,
+		window:   window,
+		retry:    30 * time.Second,
+		restart:  selfupdate.Restart,
+		now:      time.Now,
+		log:      log,
+	}, nil
+}
+
+// status is what the agent reports to the registry: the version it runs and
+// its health. It is safe to call on a nil updater.
+func (a *agentUpdater) status() cloud.NodeStatus {
+	if a == nil {
+		return cloud.NodeStatus{Version: selfupdate.Version(), Health: cloud.NodeHealthy}
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	health := a.health
+	if health == "" {
+		health = cloud.NodeHealthy
+	}
+	return cloud.NodeStatus{Version: a.running, Health: health, Message: a.message}
+}
+
+func (a *agentUpdater) setHealth(health, message string) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.health = health
+	a.message = message
+}
+
+// run checks the channel every interval until ctx is done
+func (a *agentUpdater) run(ctx context.Context) {
+	for {
+		if err := a.update(ctx); err != nil && ctx.Err() == nil {
+			a.log.Warnf("Agent update failed: %v", err)
+			a.setHealth(cloud.NodeDegraded, err.Error())
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(a.interval):
+		}
+	}
+}
+
+// update installs the release of the channel when it is newer, once the
+// agent is idle within its window, and restarts into it
+func (a *agentUpdater) update(ctx context.Context) error {
+	manifest, binary, err := a.updater.Check(ctx)
+	if err != nil {
+		return err
+	}
+	if binary == nil {
+		if a.updater.Current == a.running {
+			a.setHealth(cloud.NodeHealthy, "")
+		}
+		return nil
+	}
+	staged, err := a.updater.Download(ctx, manifest, binary)
+	if err != nil {
+		return err
+	}
+	a.log.Infof("Release %s downloaded; installing when idle (%s)", manifest.Version, a.window)
+	if !a.waitIdle(ctx) {
+		os.Remove(staged)
+		return nil
+	}
+	defer a.release()
+
+	a.setHealth(cloud.NodeUpdating, "installing "+manifest.Version)
+	if err := a.updater.Install(staged, manifest.Version); err != nil {
+		os.Remove(staged)
+		return err
+	}
+	a.log.Infof("Installed %s; restarting", manifest.Version)
+	if err := a.restart(a.updater.Executable); err != nil {
+		return fmt.Errorf("installed %s, but %w", manifest.Version, err)
+	}
+	a.setHealth(cloud.NodeHealthy, "")
+	return nil
+}
+
+// waitIdle waits for the window to open and the agent to be idle, then
+// holds the idle lock until release; it is false when ctx is done first
+func (a *agentUpdater) waitIdle(ctx context.Context) bool {
+	for {
+		if a.window.Contains(a.now()) && (a.idle == nil || a.idle.TryLock()) {
+			return true
+		}
+		select {
+		case <-ctx.Done():
+			return false
+		case <-time.After(a.retry):
+		}
+	}
+}
+
+func (a *agentUpdater) release() {
+	if a.idle != nil {
+		a.idle.Unlock()
+	}
+}
+
+// sameHost tells whether two URLs name the same host
+func sameHost(a, b string) bool {
+	ua, err := url.Parse(a)
+	if err != nil {
+		return false
+	}
+	ub, err := url.Parse(b)
+	if err != nil {
+		return false
+	}
+	return ua.Host == ub.Host
+}
+
+// addAgentUpdateFlags defines the flags of an agent updating itself
+func addAgentUpdateFlags(c *cobra.Command) {
+	c.Flags().String("update-channel", "", "release channel to update from: a manifest URL, or a channel name the registry serves")
+	c.Flags().String("update-key", "", "public release key updates must be signed with (default $"+UpdateKeyEnv+")")
+	c.Flags().Duration("update-interval", time.Hour, "how often to check the release channel")
+	c.Flags().String("update-window", "", "local time updates may be installed in, e.g. 02:00-05:00 (default any time)")
+}
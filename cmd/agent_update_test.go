@@ -0,0 +1,164 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sync"
+	"testing"
+	"time"
+
+	"panoptic/internal/cloud"
+	"panoptic/internal/logger"
+	"panoptic/internal/selfupdate"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// agentTestUpdater is the updater of an agent running v1.1.0 whose
+// registry serves v1.2.0 on its stable channel; restarts are recorded
+func agentTestUpdater(t *testing.T) (*agentUpdater, *[]string) {
+	t.Helper()
+	public, private, err := selfupdate.GenerateKey()
+	require.NoError(t, err)
+	t.Setenv(selfupdate.DefaultKeyEnv, private)
+	key, err := selfupdate.ParsePublicKey(public)
+	require.NoError(t, err)
+	dir := t.TempDir()
+	signRelease(t, dir, "v1.2.0", runtime.GOOS, runtime.GOARCH, "v1.2.0 binary")
+
+	log := logger.NewLogger(false)
+	registry := cloud.NewNodeRegistry(*log, time.Minute)
+	registry.ReleaseDir = dir
+	server := httptest.NewServer(registry.Handler("secret"))
+	t.Cleanup(server.Close)
+
+	exe := filepath.Join(t.TempDir(), "panoptic")
+	require.NoError(t, os.WriteFile(exe, []byte("v1.1.0 binary"), 0755))
+	restarts := &[]string{}
+	return &agentUpdater{
+		updater: &selfupdate.Updater{
+			ManifestURL: server.URL + "/releases/stable.json",
+			APIKey:      "secret",
+			PublicKey:   key,
+			Current:     "v1.1.0",
+			Executable:  exe,
+		},
+		running:  "v1.1.0",
+		interval: time.Hour,
+		retry:    time.Millisecond,
+		restart: func(executable string) error {
+			*restarts = append(*restarts, executable)
+			return nil
+		},
+		now: time.Now,
+		log: log,
+	}, restarts
+}
+
+func TestAgentUpdater(t *testing.T) {
+	updater, restarts := agentTestUpdater(t)
+	require.NoError(t, updater.update(context.Background()))
+
+	data, err := os.ReadFile(updater.updater.Executable)
+	require.NoError(t, err)
+	assert.Equal(t, "v1.2.0 binary", string(data))
+	assert.Equal(t, []string{updater.updater.Executable}, *restarts)
+	assert.Equal(t, cloud.NodeStatus{Version: "v1.1.0", Health: cloud.NodeHealthy}, updater.status())
+}
+
+func TestAgentUpdater_WaitsForIdle(t *testing.T) {
+	updater, restarts := agentTestUpdater(t)
+	updater.idle = &sync.Mutex{}
+	updater.idle.Lock()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	require.NoError(t, updater.update(ctx))
+	assert.Empty(t, *restarts, "nothing is installed while the agent works")
+	entries, err := os.ReadDir(filepath.Dir(updater.updater.Executable))
+	require.NoError(t, err)
+	assert.Len(t, entries, 1, "the staged update is removed")
+
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		updater.idle.Unlock()
+	}()
+	require.NoError(t, updater.update(context.Background()))
+	assert.Len(t, *restarts, 1)
+	assert.True(t, updater.idle.TryLock(), "the agent may work again after the update")
+}
+
+func TestAgentUpdater_Window(t *testing.T) {
+	updater, restarts := agentTestUpdater(t)
+	var err error
+	updater.window, err = selfupdate.ParseWindow("02:00-04:00")
+	require.NoError(t, err)
+	updater.now = func() time.Time { return time.Date(2026, 1, 1, 12, 0, 0, 0, time.Local) }
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	require.NoError(t, updater.update(ctx))
+	assert.Empty(t, *restarts, "updates wait for the window")
+
+	updater.now = func() time.Time { return time.Date(2026, 1, 2, 3, 0, 0, 0, time.Local) }
+	require.NoError(t, updater.update(context.Background()))
+	assert.Len(t, *restarts, 1)
+}
+
+func TestAgentUpdater_Degraded(t *testing.T) {
+	updater, _ := agentTestUpdater(t)
+	updater.restart = func(string) error { return errors.New("restart the agent to run it") }
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		updater.run(ctx)
+	}()
+	require.Eventually(t, func() bool { return updater.status().Health == cloud.NodeDegraded }, time.Second, time.Millisecond)
+	cancel()
+	<-done
+	assert.Equal(t, cloud.NodeStatus{Version: "v1.1.0", Health: cloud.NodeDegraded, Message: "installed v1.2.0, but restart the agent to run it"}, updater.status())
+
+	var none *agentUpdater
+	assert.Equal(t, cloud.NodeHealthy, none.status().Health, "agents without an updater report their version")
+}
+
+func TestAgentUpdaterFromFlags(t *testing.T) {
+	public, _, err := selfupdate.GenerateKey()
+	require.NoError(t, err)
+	log := logger.NewLogger(false)
+	flags := func(values map[string]string) *cobra.Command {
+		cmd := &cobra.Command{Use: "agent"}
+		addAgentUpdateFlags(cmd)
+		for name, value := range values {
+			require.NoError(t, cmd.Flags().Set(name, value))
+		}
+		return cmd
+	}
+
+	updater, err := agentUpdaterFromFlags(flags(nil), "http://coordinator:8470", "secret", log)
+	require.NoError(t, err)
+	assert.Nil(t, updater, "agents update only with --update-channel")
+
+	t.Setenv(UpdateKeyEnv, "")
+	_, err = agentUpdaterFromFlags(flags(map[string]string{"update-channel": "stable"}), "http://coordinator:8470", "secret", log)
+	assert.EqualError(t, err, "--update-key flag or "+UpdateKeyEnv+" is required with --update-channel")
+
+	t.Setenv(UpdateKeyEnv, public)
+	updater, err = agentUpdaterFromFlags(flags(map[string]string{"update-channel": "stable", "update-window": "01:00-03:00"}), "http://coordinator:8470/", "secret", log)
+	require.NoError(t, err)
+	assert.Equal(t, "http://coordinator:8470/releases/stable.json", updater.updater.ManifestURL)
+	assert.Equal(t, "secret", updater.updater.APIKey)
+	assert.Equal(t, "01:00-03:00", updater.window.String())
+
+	updater, err = agentUpdaterFromFlags(flags(map[string]string{"update-channel": "https://downloads.example.com/stable.json"}), "http://coordinator:8470", "secret", log)
+	require.NoError(t, err)
+	assert.Empty(t, updater.updater.APIKey, "the registry token isn't sent to other hosts")
+}
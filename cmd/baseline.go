@@ -0,0 +1,120 @@
+package cmd
+
+import (
+	"fmt"
+	"path/filepath"
+	"text/tabwriter"
+
+	"panoptic/internal/executor"
+	"panoptic/internal/vision"
+	"panoptic/pkg/i18n"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+var baselineCmd = &cobra.Command{
+	Use:   "baseline",
+	Short: i18n.T("panoptic_cmd_baseline_short"),
+	Long: `Keep the approved look of every screen, as perceptual hashes of its
+screenshots, and check later runs against it.`,
+}
+
+var baselineUpdateCmd = &cobra.Command{
+	Use:               "update [results.json]",
+	Short:             i18n.T("panoptic_cmd_baseline_update_short"),
+	Args:              cobra.MaximumNArgs(1),
+	ValidArgsFunction: completeFiles("json"),
+	SilenceUsage:      true,
+	RunE:              runBaselineUpdate,
+}
+
+var baselineCheckCmd = &cobra.Command{
+	Use:   "check [results.json]",
+	Short: i18n.T("panoptic_cmd_baseline_check_short"),
+	Long: `List the screens of a run that look different from the baseline, that the
+baseline doesn't have yet, or that the run's apps no longer captured. Exits
+non-zero when there are any.`,
+	Args:              cobra.MaximumNArgs(1),
+	ValidArgsFunction: completeFiles("json"),
+	SilenceUsage:      true,
+	RunE:              runBaselineCheck,
+}
+
+// baselinePath is the --file baseline, by default in the output directory
+func baselinePath(cmd *cobra.Command) string {
+	if path, _ := cmd.Flags().GetString("file"); path != "" {
+		return path
+	}
+	return filepath.Join(viper.GetString("output"), executor.BaselineFileName)
+}
+
+func runBaselineUpdate(cmd *cobra.Command, args []string) error {
+	doc, err := executor.LoadResults(resultsArg(cmd, args))
+	if err != nil {
+		return err
+	}
+	path := baselinePath(cmd)
+	baseline, err := executor.LoadBaseline(path)
+	if err != nil {
+		return err
+	}
+	updated := baseline.Update(doc)
+	if err := baseline.Save(path); err != nil {
+		return fmt.Errorf("failed to save baseline: %w", err)
+	}
+	if jsonOutput(cmd) {
+		return printJSON(cmd, struct {
+			File    string `json:"file"`
+			Updated int    `json:"updated"`
+			Screens int    `json:"screens"`
+		}{path, updated, len(baseline.Screens)})
+	}
+	fmt.Fprintf(cmd.OutOrStdout(), "Approved %d new or changed screen(s); %s has %d screen(s)\n", updated, path, len(baseline.Screens))
+	return nil
+}
+
+func runBaselineCheck(cmd *cobra.Command, args []string) error {
+	doc, err := executor.LoadResults(resultsArg(cmd, args))
+	if err != nil {
+		return err
+	}
+	path := baselinePath(cmd)
+	baseline, err := executor.LoadBaseline(path)
+	if err != nil {
+		return err
+	}
+	distance, _ := cmd.Flags().GetInt("distance")
+	diffs := baseline.Compare(doc, distance)
+
+	if jsonOutput(cmd) {
+		if err := printJSON(cmd, diffs); err != nil {
+			return err
+		}
+	} else if len(diffs) == 0 {
+		fmt.Fprintf(cmd.OutOrStdout(), "Every screen matches %s\n", path)
+	} else {
+		tw := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 0, 2, ' ', 0)
+		fmt.Fprintln(tw, "APP\tSCREEN\tSTATUS\tDISTANCE\tSCREENSHOT\tBASELINE")
+		for _, d := range diffs {
+			fmt.Fprintf(tw, "%s\t%s\t%s\t%d\t%s\t%s\n", d.App, d.Screen, d.Status, d.Distance, d.Screenshot, d.Baseline)
+		}
+		if err := tw.Flush(); err != nil {
+			return err
+		}
+	}
+	if len(diffs) > 0 {
+		return fmt.Errorf("%d screen(s) differ from the baseline", len(diffs))
+	}
+	return nil
+}
+
+func init() {
+	for _, c := range []*cobra.Command{baselineUpdateCmd, baselineCheckCmd} {
+		c.Flags().String("file", "", "baseline file (default <output>/baseline.json)")
+	}
+	baselineCheckCmd.Flags().Int("distance", vision.NearDuplicateDistance, "largest hash distance, in bits, still the same look")
+
+	baselineCmd.AddCommand(baselineUpdateCmd, baselineCheckCmd)
+	rootCmd.AddCommand(baselineCmd)
+}
@@ -0,0 +1,42 @@
+package cmd
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"testing"
+
+	"panoptic/internal/executor"
+	"panoptic/internal/vision"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRunBaseline(t *testing.T) {
+	dir := t.TempDir()
+	baseline := filepath.Join(dir, "baseline.json")
+	flags := map[string]string{"file": baseline}
+	shot := func(screen string, hash uint64) executor.ScreenHash {
+		return executor.ScreenHash{Screen: screen, Screenshot: screen + ".png", PHash: vision.PHash(hash)}
+	}
+	approved := writeResults(t, filepath.Join(dir, "r1.json"), "r1", &executor.TestResult{AppName: "Shop", Success: true, ScreenHashes: []executor.ScreenHash{shot("home", 0xff00)}})
+
+	cmd, out := resultsTestCmd("update", false, flags)
+	require.NoError(t, runBaselineUpdate(cmd, []string{approved}))
+	assert.Equal(t, "Approved 1 new or changed screen(s); "+baseline+" has 1 screen(s)\n", out.String())
+
+	cmd, out = resultsTestCmd("check", false, flags)
+	require.NoError(t, runBaselineCheck(cmd, []string{approved}))
+	assert.Contains(t, out.String(), "Every screen matches")
+
+	changed := writeResults(t, filepath.Join(dir, "r2.json"), "r2", &executor.TestResult{AppName: "Shop", Success: true, ScreenHashes: []executor.ScreenHash{shot("home", 0x00ff)}})
+	cmd, out = resultsTestCmd("check", true, flags)
+	assert.EqualError(t, runBaselineCheck(cmd, []string{changed}), "1 screen(s) differ from the baseline")
+	var diffs []executor.ScreenDiff
+	require.NoError(t, json.Unmarshal(out.Bytes(), &diffs))
+	assert.Equal(t, []executor.ScreenDiff{{App: "Shop", Screen: "home", Status: executor.ScreenChanged, Distance: 16, Screenshot: "home.png", Baseline: "home.png"}}, diffs)
+
+	cmd, out = resultsTestCmd("check", false, flags)
+	assert.Error(t, runBaselineCheck(cmd, []string{changed}))
+	assert.Regexp(t, `Shop\s+home\s+changed\s+16\s+home.png\s+home.png`, out.String())
+}
@@ -0,0 +1,101 @@
+package cmd
+
+import (
+	"fmt"
+	"path/filepath"
+	"text/tabwriter"
+	"time"
+
+	"panoptic/internal/config"
+	"panoptic/internal/executor"
+	"panoptic/pkg/i18n"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+var cleanupCmd = &cobra.Command{
+	Use:   "cleanup <config-file>",
+	Short: i18n.T("panoptic_cmd_cleanup_short"),
+	Long: `Remove the artifacts of old runs from the output directory of a
+configuration under its settings.retention: screenshots, videos, traces and
+the other files of runs beyond max_runs, older than max_age_days, and the
+oldest ones past max_size_gb. Results, reports, the run history and
+baselines are kept.
+
+panoptic run does the same as it starts; --dry-run lists what would be
+removed without removing it.`,
+	Args:              cobra.ExactArgs(1),
+	ValidArgsFunction: completeFiles("yaml", "yml"),
+	SilenceUsage:      true,
+	RunE:              runCleanup,
+}
+
+func runCleanup(cmd *cobra.Command, args []string) error {
+	cfg, err := config.Load(args[0])
+	if err != nil {
+		return fmt.Errorf("failed to load configuration: %w", err)
+	}
+	if !cfg.Settings.Retention.Enabled() {
+		return fmt.Errorf("settings.retention sets no max_runs, max_size_gb or max_age_days in %s", args[0])
+	}
+	outputDir := viper.GetString("output")
+	if cfg.Output != "" {
+		outputDir = cfg.Output
+	}
+	dryRun, _ := cmd.Flags().GetBool("dry-run")
+	report, err := executor.ApplyRetention(outputDir, cfg.Settings.Retention, time.Now(), dryRun || cfg.Settings.Retention.DryRun)
+	if err != nil {
+		return err
+	}
+
+	if jsonOutput(cmd) {
+		return printJSON(cmd, report)
+	}
+	out := cmd.OutOrStdout()
+	if len(report.Removed) > 0 {
+		w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "REASON\tSIZE\tMODIFIED\tPATH")
+		for _, f := range report.Removed {
+			path := f.Path
+			if rel, err := filepath.Rel(outputDir, f.Path); err == nil {
+				path = rel
+			}
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", f.Reason, formatSize(f.Size), f.Modified.Format("2006-01-02 15:04"), path)
+		}
+		if err := w.Flush(); err != nil {
+			return err
+		}
+	}
+	verb := "Removed"
+	if report.DryRun {
+		verb = "Would remove"
+	}
+	fmt.Fprintf(out, "%s %d artifact(s), %s, from %s; %d kept, %s\n", verb, len(report.Removed), formatSize(report.RemovedBytes),
+		outputDir, report.KeptFiles, formatSize(report.KeptBytes))
+	return nil
+}
+
+// formatSize writes a byte count in the largest unit it fills
+func formatSize(bytes int64) string {
+	const unit = 1024
+	if bytes < unit {
+		return fmt.Sprintf("%d B", bytes)
+	}
+	div, exp := int64(unit), 0
+	for n := bytes / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %cB", float64(bytes)/float64(div), "KMGTPE"[exp])
+}
+
+// addCleanupFlags adds the flags of cleanup
+func addCleanupFlags(c *cobra.Command) {
+	c.Flags().Bool("dry-run", false, "list what would be removed without removing it")
+}
+
+func init() {
+	addCleanupFlags(cleanupCmd)
+	rootCmd.AddCommand(cleanupCmd)
+}
@@ -0,0 +1,89 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"panoptic/internal/executor"
+
+	"github.com/spf13/cobra"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func cleanupTestCmd(t *testing.T, asJSON bool, flags map[string]string) (*cobra.Command, *bytes.Buffer) {
+	t.Helper()
+	cmd := &cobra.Command{Use: "cleanup"}
+	addCleanupFlags(cmd)
+	cmd.Flags().Bool("json", asJSON, "")
+	for name, value := range flags {
+		require.NoError(t, cmd.Flags().Set(name, value))
+	}
+	cmd.SetContext(context.Background())
+	out := &bytes.Buffer{}
+	cmd.SetOut(out)
+	cmd.SetErr(&bytes.Buffer{})
+	return cmd, out
+}
+
+// cleanupTestConfig writes a configuration keeping a week of artifacts and
+// an old screenshot in its output directory
+func cleanupTestConfig(t *testing.T, retention string) (path, old string) {
+	dir := t.TempDir()
+	outputDir := filepath.Join(dir, "output")
+	old = filepath.Join(outputDir, "screenshots", "old.png")
+	require.NoError(t, os.MkdirAll(filepath.Dir(old), 0755))
+	require.NoError(t, os.WriteFile(old, make([]byte, 2048), 0644))
+	modified := time.Now().AddDate(0, 0, -10)
+	require.NoError(t, os.Chtimes(old, modified, modified))
+	path = filepath.Join(dir, "shop.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(`name: shop
+output: "`+outputDir+`"
+apps:
+  - name: Shop
+    type: web
+    url: https://shop.example.com
+settings:
+`+retention), 0644))
+	return path, old
+}
+
+func TestRunCleanup(t *testing.T) {
+	path, old := cleanupTestConfig(t, "  retention:\n    max_age_days: 7\n")
+	cmd, out := cleanupTestCmd(t, false, map[string]string{"dry-run": "true"})
+	require.NoError(t, runCleanup(cmd, []string{path}))
+	assert.Contains(t, out.String(), "max_age_days  2.0 KB")
+	assert.Contains(t, out.String(), filepath.Join("screenshots", "old.png"))
+	assert.Contains(t, out.String(), "Would remove 1 artifact(s), 2.0 KB")
+	assert.FileExists(t, old)
+
+	cmd, out = cleanupTestCmd(t, true, nil)
+	require.NoError(t, runCleanup(cmd, []string{path}))
+	var report executor.RetentionReport
+	require.NoError(t, json.Unmarshal(out.Bytes(), &report))
+	assert.False(t, report.DryRun)
+	require.Len(t, report.Removed, 1)
+	assert.Equal(t, executor.RetentionAge, report.Removed[0].Reason)
+	assert.NoFileExists(t, old)
+}
+
+func TestRunCleanup_Errors(t *testing.T) {
+	path, _ := cleanupTestConfig(t, "  headless: true\n")
+	cmd, _ := cleanupTestCmd(t, false, nil)
+	assert.ErrorContains(t, runCleanup(cmd, []string{path}), "settings.retention sets no max_runs, max_size_gb or max_age_days")
+
+	cmd, _ = cleanupTestCmd(t, false, nil)
+	assert.ErrorContains(t, runCleanup(cmd, []string{filepath.Join(t.TempDir(), "missing.yaml")}), "failed to load configuration")
+}
+
+func TestFormatSize(t *testing.T) {
+	assert.Equal(t, "512 B", formatSize(512))
+	assert.Equal(t, "1.5 KB", formatSize(1536))
+	assert.Equal(t, "2.0 GB", formatSize(2<<30))
+}
@@ -0,0 +1,167 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"text/tabwriter"
+
+	"panoptic/internal/cloud"
+	"panoptic/internal/config"
+	"panoptic/pkg/i18n"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"gopkg.in/yaml.v3"
+)
+
+var cloudCmd = &cobra.Command{
+	Use:   "cloud",
+	Short: i18n.T("panoptic_cmd_cloud_short"),
+	Long: `Work with the cloud storage a configuration's settings.cloud describes,
+outside of a run.`,
+}
+
+var cloudSyncCmd = &cobra.Command{
+	Use:               "sync <config-file> [dir]",
+	Short:             i18n.T("panoptic_cmd_cloud_sync_short"),
+	Args:              cobra.RangeArgs(1, 2),
+	ValidArgsFunction: completeFiles("yaml", "yml"),
+	SilenceUsage:      true,
+	RunE:              runCloudSync,
+}
+
+var cloudListCmd = &cobra.Command{
+	Use:               "list <config-file> [prefix]",
+	Short:             i18n.T("panoptic_cmd_cloud_list_short"),
+	Args:              cobra.RangeArgs(1, 2),
+	ValidArgsFunction: completeFiles("yaml", "yml"),
+	SilenceUsage:      true,
+	RunE:              runCloudList,
+}
+
+var cloudCleanupCmd = &cobra.Command{
+	Use:               "cleanup <config-file>",
+	Short:             i18n.T("panoptic_cmd_cloud_cleanup_short"),
+	Args:              cobra.ExactArgs(1),
+	ValidArgsFunction: completeFiles("yaml", "yml"),
+	SilenceUsage:      true,
+	RunE:              runCloudCleanup,
+}
+
+var cloudReportCmd = &cobra.Command{
+	Use:               "report <config-file>",
+	Short:             i18n.T("panoptic_cmd_cloud_report_short"),
+	Args:              cobra.ExactArgs(1),
+	ValidArgsFunction: completeFiles("yaml", "yml"),
+	SilenceUsage:      true,
+	RunE:              runCloudReport,
+}
+
+// cloudManager configures a cloud manager from the settings.cloud of a
+// configuration
+func cloudManager(cmd *cobra.Command, configFile string) (*cloud.CloudManager, error) {
+	cfg, err := config.Load(configFile)
+	if err != nil {
+		return nil, err
+	}
+	if cfg.Settings.Cloud == nil {
+		return nil, fmt.Errorf("%s has no settings.cloud", configFile)
+	}
+	data, err := yaml.Marshal(cfg.Settings.Cloud)
+	if err != nil {
+		return nil, err
+	}
+	var cloudConfig cloud.CloudConfig
+	if err := yaml.Unmarshal(data, &cloudConfig); err != nil {
+		return nil, fmt.Errorf("invalid settings.cloud in %s: %w", configFile, err)
+	}
+	manager := cloud.NewCloudManager(*commandLogger(cmd))
+	if err := manager.Configure(cloudConfig); err != nil {
+		return nil, err
+	}
+	if !manager.Enabled {
+		return nil, fmt.Errorf("settings.cloud in %s needs a provider and a bucket", configFile)
+	}
+	return manager, nil
+}
+
+func runCloudSync(cmd *cobra.Command, args []string) error {
+	manager, err := cloudManager(cmd, args[0])
+	if err != nil {
+		return err
+	}
+	dir := viper.GetString("output")
+	if len(args) > 1 {
+		dir = args[1]
+	}
+	if err := manager.SyncTestResults(context.Background(), dir); err != nil {
+		return err
+	}
+	if jsonOutput(cmd) {
+		return printJSON(cmd, struct {
+			Dir string `json:"dir"`
+		}{dir})
+	}
+	fmt.Fprintf(cmd.OutOrStdout(), "Synced %s to %s\n", dir, manager.Config.Bucket)
+	return nil
+}
+
+func runCloudList(cmd *cobra.Command, args []string) error {
+	manager, err := cloudManager(cmd, args[0])
+	if err != nil {
+		return err
+	}
+	prefix := ""
+	if len(args) > 1 {
+		prefix = args[1]
+	}
+	files, err := manager.Provider.ListFiles(context.Background(), prefix)
+	if err != nil {
+		return fmt.Errorf("failed to list cloud files: %w", err)
+	}
+	if jsonOutput(cmd) {
+		return printJSON(cmd, files)
+	}
+	tw := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "PATH\tSIZE\tMODIFIED")
+	for _, f := range files {
+		fmt.Fprintf(tw, "%s\t%d\t%s\n", f.Path, f.Size, f.LastModified.Format("2006-01-02 15:04:05"))
+	}
+	return tw.Flush()
+}
+
+func runCloudCleanup(cmd *cobra.Command, args []string) error {
+	manager, err := cloudManager(cmd, args[0])
+	if err != nil {
+		return err
+	}
+	if !manager.Config.RetentionPolicy.Enabled {
+		return fmt.Errorf("settings.cloud.retention_policy is not enabled in %s", args[0])
+	}
+	return manager.CleanupOldFiles(context.Background())
+}
+
+func runCloudReport(cmd *cobra.Command, args []string) error {
+	manager, err := cloudManager(cmd, args[0])
+	if err != nil {
+		return err
+	}
+	report, err := manager.GenerateCloudReport(context.Background())
+	if err != nil {
+		return err
+	}
+	if jsonOutput(cmd) {
+		return printJSON(cmd, report)
+	}
+	fmt.Fprintf(cmd.OutOrStdout(), "Provider: %s, bucket %s\n", report.Provider, report.Bucket)
+	fmt.Fprintf(cmd.OutOrStdout(), "Files: %d (%d bytes)\n", report.StorageStats.TotalFiles, report.StorageStats.TotalSize)
+	for _, r := range report.Recommendations {
+		fmt.Fprintf(cmd.OutOrStdout(), "- %s\n", r)
+	}
+	return nil
+}
+
+func init() {
+	cloudCmd.AddCommand(cloudSyncCmd, cloudListCmd, cloudCleanupCmd, cloudReportCmd)
+	rootCmd.AddCommand(cloudCmd)
+}
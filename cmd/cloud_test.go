@@ -0,0 +1,66 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"panoptic/internal/cloud"
+
+	"github.com/spf13/cobra"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func cloudTestCmd(asJSON bool) (*cobra.Command, *bytes.Buffer) {
+	cmd := &cobra.Command{Use: "cloud"}
+	cmd.Flags().Bool("json", asJSON, "")
+	out := &bytes.Buffer{}
+	cmd.SetOut(out)
+	cmd.SetErr(&bytes.Buffer{})
+	return cmd, out
+}
+
+func TestRunCloud(t *testing.T) {
+	dir := t.TempDir()
+	bucket := filepath.Join(dir, "bucket")
+	results := filepath.Join(dir, "output")
+	require.NoError(t, os.MkdirAll(results, 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(results, "report.html"), []byte("<html></html>"), 0644))
+
+	configFile := filepath.Join(dir, "config.yaml")
+	require.NoError(t, os.WriteFile(configFile, []byte(`name: Cloud
+apps:
+  - name: Site
+    type: web
+    url: https://example.com
+actions:
+  - name: wait
+    type: wait
+    wait_time: 1
+settings:
+  cloud:
+    provider: local
+    bucket: `+bucket+`
+`), 0644))
+
+	cmd, out := cloudTestCmd(false)
+	require.NoError(t, runCloudSync(cmd, []string{configFile, results}))
+	assert.Equal(t, "Synced "+results+" to "+bucket+"\n", out.String())
+
+	cmd, out = cloudTestCmd(true)
+	require.NoError(t, runCloudList(cmd, []string{configFile}))
+	var files []cloud.CloudFile
+	require.NoError(t, json.Unmarshal(out.Bytes(), &files))
+	assert.NotEmpty(t, files)
+
+	cmd, _ = cloudTestCmd(false)
+	assert.EqualError(t, runCloudCleanup(cmd, []string{configFile}), "settings.cloud.retention_policy is not enabled in "+configFile)
+
+	bare := filepath.Join(dir, "bare.yaml")
+	require.NoError(t, os.WriteFile(bare, []byte("name: Bare\napps:\n  - name: Site\n    type: web\n    url: https://example.com\nactions:\n  - name: wait\n    type: wait\n    wait_time: 1\n"), 0644))
+	assert.EqualError(t, runCloudList(cmd, []string{bare}), bare+" has no settings.cloud")
+}
@@ -7,6 +7,9 @@ import (
 	"testing"
 	"time"
 
+	"panoptic/internal/config"
+	"panoptic/internal/history"
+
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 	"github.com/stretchr/testify/assert"
@@ -90,6 +93,10 @@ actions:
 	require.NoError(t, err)
 
 	rootCmd := getRootCmd()
+	// runCmd keeps the --output flag of the first root it was added to, so
+	// the flag alone may not reach viper; set the temp directory there too
+	viper.Set("output", outputDir)
+	defer viper.Set("output", "./output")
 	
 	// Set up args
 	rootCmd.SetArgs([]string{"run", configFile, "--output", outputDir, "--verbose"})
@@ -178,6 +185,28 @@ func TestCommandChaining(t *testing.T) {
 	assert.Equal(t, "Execute automated testing and recording", runCmd.Short)
 }
 
+// TestSubcommands tests that every workflow is a subcommand of the one CLI,
+// sharing the global flags
+func TestSubcommands(t *testing.T) {
+	for _, name := range []string{"init", "run", "validate", "record", "serve", "agent", "schedule", "report", "merge", "baseline", "cloud", "enterprise"} {
+		cmd, _, err := rootCmd.Find([]string{name})
+		require.NoError(t, err, name)
+		assert.Equal(t, name, cmd.Name())
+		assert.NotEmpty(t, cmd.Short, name)
+	}
+	jsonFlag := rootCmd.PersistentFlags().Lookup("json")
+	require.NotNil(t, jsonFlag)
+	assert.Equal(t, "false", jsonFlag.DefValue)
+
+	// Cobra adds the completion command when the CLI runs
+	var out strings.Builder
+	rootCmd.SetOut(&out)
+	rootCmd.SetArgs([]string{"completion", "bash"})
+	defer rootCmd.SetArgs(nil)
+	require.NoError(t, rootCmd.Execute())
+	assert.Contains(t, out.String(), "__start_panoptic")
+}
+
 func TestViperBinding(t *testing.T) {
 	// Reset viper for clean test
 	viper.Reset()
@@ -255,4 +284,86 @@ and screenshot capture across web, desktop, and mobile applications.`,
 	cmd.AddCommand(runCmd)
 	
 	return cmd
-}
\ No newline at end of file
+}
+func TestLoggingOptions(t *testing.T) {
+	cfg := &config.Config{Settings: config.Settings{Logging: &config.LoggingSettings{Format: "json", Sink: "both", MaxBackups: 3}}}
+	cmd := &cobra.Command{Use: "run"}
+	cmd.Flags().String("log-format", "", "")
+	cmd.Flags().String("log-sink", "", "")
+	cmd.Flags().String("log-file", "", "")
+
+	opts := loggingOptions(cmd, cfg, "/out")
+	assert.Equal(t, "json", opts.Format)
+	assert.Equal(t, "both", opts.Sink)
+	assert.Equal(t, filepath.Join("/out", "logs", "panoptic.log"), opts.File, "file sinks default to the output logs directory")
+	assert.Equal(t, 3, opts.MaxBackups)
+
+	require.NoError(t, cmd.Flags().Set("log-format", "text"))
+	require.NoError(t, cmd.Flags().Set("log-sink", "stderr"))
+	opts = loggingOptions(cmd, cfg, "/out")
+	assert.Equal(t, "text", opts.Format, "flags override the config file")
+	assert.Equal(t, "stderr", opts.Sink)
+	assert.Empty(t, opts.File)
+}
+
+func TestGitHubSettings(t *testing.T) {
+	newCmd := func() *cobra.Command {
+		cmd := &cobra.Command{Use: "run"}
+		cmd.Flags().Bool("github", false, "")
+		cmd.Flags().Int("github-pr", 0, "")
+		cmd.Flags().String("report-url", "", "")
+		return cmd
+	}
+
+	cfg := &config.Config{}
+	assert.Nil(t, githubSettings(newCmd(), cfg), "off without settings or flags")
+
+	cfg.Settings.GitHub = &config.GitHubSettings{Enabled: true, Repository: "acme/shop", SHA: "abc"}
+	gh := githubSettings(newCmd(), cfg)
+	require.NotNil(t, gh)
+	assert.Equal(t, "acme/shop", gh.Repository)
+
+	cmd := newCmd()
+	require.NoError(t, cmd.Flags().Set("github-pr", "42"))
+	require.NoError(t, cmd.Flags().Set("report-url", "https://reports.example.com/{run_id}/"))
+	gh = githubSettings(cmd, &config.Config{})
+	require.NotNil(t, gh, "--github-pr implies --github")
+	assert.Equal(t, 42, gh.PullRequest)
+	assert.Equal(t, "https://reports.example.com/{run_id}/", gh.ReportURL)
+
+	gh = githubSettings(cmd, cfg)
+	assert.Empty(t, gh.SHA, "--github-pr replaces a configured commit")
+	assert.Equal(t, "abc", cfg.Settings.GitHub.SHA, "settings are not modified")
+}
+
+func TestImpactFromFlags(t *testing.T) {
+	newCmd := func() *cobra.Command {
+		cmd := &cobra.Command{Use: "run"}
+		cmd.Flags().StringSlice("changed", nil, "")
+		cmd.Flags().String("changed-file", "", "")
+		return cmd
+	}
+	dir := t.TempDir()
+	historyPath := filepath.Join(dir, history.FileName)
+
+	impact, err := impactFromFlags(newCmd(), historyPath)
+	require.NoError(t, err)
+	assert.Nil(t, impact, "off without --changed")
+
+	changesPath := filepath.Join(dir, "changes.txt")
+	require.NoError(t, os.WriteFile(changesPath, []byte("# from git diff\n/admin/*\n\n  billing \n"), 0644))
+	require.NoError(t, history.Append(historyPath, history.Record{RunID: "a", Entries: []history.Entry{{App: "Shop", Routes: []string{"/cart"}}}}))
+	cmd := newCmd()
+	require.NoError(t, cmd.Flags().Set("changed", "/checkout,/cart"))
+	require.NoError(t, cmd.Flags().Set("changed-file", changesPath))
+	impact, err = impactFromFlags(cmd, historyPath)
+	require.NoError(t, err)
+	require.NotNil(t, impact)
+	assert.Equal(t, []string{"/checkout", "/cart", "/admin/*", "billing"}, impact.Changes)
+	assert.Equal(t, map[string][]string{"Shop": {"/cart"}}, impact.Coverage)
+
+	cmd = newCmd()
+	require.NoError(t, cmd.Flags().Set("changed-file", filepath.Join(dir, "missing.txt")))
+	_, err = impactFromFlags(cmd, historyPath)
+	assert.ErrorContains(t, err, "failed to read changes")
+}
@@ -0,0 +1,213 @@
+package cmd
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"text/template"
+
+	"panoptic/internal/config"
+	"panoptic/internal/crawl"
+	"panoptic/pkg/i18n"
+
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+)
+
+var crawlCmd = &cobra.Command{
+	Use:   "crawl <url>",
+	Short: i18n.T("panoptic_cmd_crawl_short"),
+	Long: `Find the pages of the site at url, from its sitemaps (those robots.txt
+names, or /sitemap.xml) and by following its links up to --depth links
+away, and write a smoke test of them: each page is opened, screenshotted
+and checked for console errors.
+
+The smoke test is a configuration ready to run and commit; "--file -"
+prints it instead.`,
+	Args:         cobra.ExactArgs(1),
+	SilenceUsage: true,
+	RunE:         runCrawl,
+}
+
+// crawlConfigFile is the name of the smoke test crawl writes by default
+const crawlConfigFile = "smoke.yaml"
+
+// crawlPageTimeout is the seconds of an app timeout given to each page
+const crawlPageTimeout = 15
+
+// smokePage is a page of the smoke test
+type smokePage struct {
+	crawl.Page
+	Slug string `json:"slug"` // names its actions and screenshot
+}
+
+// crawlOutcome is the JSON output of crawl
+type crawlOutcome struct {
+	URL    string      `json:"url"`
+	Config string      `json:"config"`
+	Pages  []smokePage `json:"pages"`
+}
+
+var smokeConfigTemplate = template.Must(template.New("smoke").Funcs(template.FuncMap{"quote": strconv.Quote}).Parse(`# Smoke test of {{.URL}} written by panoptic crawl: every page is opened,
+# screenshotted and checked for console errors. Run it with:
+#   panoptic run {{.File}}
+name: {{quote .Name}}
+output: "./output"
+
+apps:
+  - name: {{quote .Name}}
+    type: "web"
+    url: {{quote .URL}}
+    tags: ["smoke"]
+    timeout: {{.Timeout}}
+
+actions:
+{{- range .Pages}}
+  - name: {{quote (printf "open_%s" .Slug)}}
+    type: "navigate"
+    url: {{quote .URL}}
+  - name: {{quote .Slug}}
+    type: "screenshot"
+    parameters:
+      filename: {{quote (printf "%s.png" .Slug)}}
+  - name: {{quote (printf "%s_console" .Slug)}}
+    type: "console_check"
+    parameters:
+      max_errors: 0
+{{- end}}
+
+settings:
+  screenshot_format: "png"
+  quality: 80
+  headless: true
+  window_width: 1280
+  window_height: 800
+`))
+
+// pageSlug names a page after its route and query: home for the root,
+// lower case words joined by underscores otherwise
+func pageSlug(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return "page"
+	}
+	var b strings.Builder
+	for _, r := range strings.ToLower(u.Path + " " + u.RawQuery) {
+		switch {
+		case r >= 'a' && r <= 'z', r >= '0' && r <= '9':
+			b.WriteRune(r)
+		case b.Len() > 0 && !strings.HasSuffix(b.String(), "_"):
+			b.WriteByte('_')
+		}
+	}
+	slug := strings.TrimSuffix(b.String(), "_")
+	if slug == "" {
+		return "home"
+	}
+	return slug
+}
+
+// smokePages names the pages, numbering the slugs two pages share
+func smokePages(pages []crawl.Page) []smokePage {
+	used := make(map[string]bool, len(pages))
+	smoke := make([]smokePage, len(pages))
+	for i, page := range pages {
+		slug := pageSlug(page.URL)
+		for n := 2; used[slug]; n++ {
+			slug = fmt.Sprintf("%s_%d", pageSlug(page.URL), n)
+		}
+		used[slug] = true
+		smoke[i] = smokePage{Page: page, Slug: slug}
+	}
+	return smoke
+}
+
+// renderSmokeConfig writes the smoke test of the pages as a configuration
+// run accepts
+func renderSmokeConfig(name, start, file string, pages []smokePage) ([]byte, error) {
+	var b strings.Builder
+	err := smokeConfigTemplate.Execute(&b, struct {
+		Name, URL, File string
+		Timeout         int
+		Pages           []smokePage
+	}{name, start, file, 30 + crawlPageTimeout*len(pages), pages})
+	if err != nil {
+		return nil, err
+	}
+	data := []byte(b.String())
+	var cfg config.Config
+	if err := yaml.Unmarshal(data, &cfg); err != nil {
+		return nil, fmt.Errorf("generated configuration is invalid: %w", err)
+	}
+	if err := cfg.Validate(); err != nil {
+		return nil, fmt.Errorf("generated configuration is invalid: %w", err)
+	}
+	return data, nil
+}
+
+func runCrawl(cmd *cobra.Command, args []string) error {
+	depth, _ := cmd.Flags().GetInt("depth")
+	maxPages, _ := cmd.Flags().GetInt("max-pages")
+	name, _ := cmd.Flags().GetString("name")
+	file, _ := cmd.Flags().GetString("file")
+	force, _ := cmd.Flags().GetBool("force")
+	if depth < 0 {
+		return fmt.Errorf("--depth must not be negative, got %d", depth)
+	}
+	if maxPages <= 0 {
+		return fmt.Errorf("--max-pages must be positive, got %d", maxPages)
+	}
+	if file != "-" && !force {
+		if _, err := os.Stat(file); err == nil {
+			return fmt.Errorf("%s already exists; use --force to overwrite it", file)
+		}
+	}
+
+	pages, err := crawl.Crawl(cmd.Context(), args[0], crawl.Options{Depth: depth, MaxPages: maxPages})
+	if err != nil {
+		return err
+	}
+	start := pages[0].URL
+	if name == "" {
+		u, _ := url.Parse(start)
+		name = u.Hostname()
+	}
+	smoke := smokePages(pages)
+	data, err := renderSmokeConfig(name, start, file, smoke)
+	if err != nil {
+		return err
+	}
+
+	if file == "-" {
+		_, err := cmd.OutOrStdout().Write(data)
+		return err
+	}
+	if err := os.WriteFile(file, data, 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", file, err)
+	}
+	if jsonOutput(cmd) {
+		return printJSON(cmd, crawlOutcome{URL: start, Config: file, Pages: smoke})
+	}
+	out := cmd.OutOrStdout()
+	for _, page := range smoke {
+		fmt.Fprintf(out, "%-8s %s\n", page.From, page.URL)
+	}
+	fmt.Fprintf(out, "\nWrote a smoke test of %d page(s) to %s\nRun it with:\n  panoptic run %s\n", len(smoke), file, file)
+	return nil
+}
+
+// addCrawlFlags adds the flags of crawl
+func addCrawlFlags(c *cobra.Command) {
+	c.Flags().Int("depth", crawl.DefaultDepth, "links to follow away from the start page; 0 takes only the sitemap pages")
+	c.Flags().Int("max-pages", crawl.DefaultMaxPages, "pages to test at most")
+	c.Flags().String("name", "", "configuration and app name (default the host)")
+	c.Flags().String("file", crawlConfigFile, `smoke test to write, or "-" for stdout`)
+	c.Flags().Bool("force", false, "overwrite an existing smoke test")
+}
+
+func init() {
+	addCrawlFlags(crawlCmd)
+	rootCmd.AddCommand(crawlCmd)
+}
@@ -0,0 +1,136 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"panoptic/internal/config"
+	"panoptic/internal/crawl"
+
+	"github.com/spf13/cobra"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func crawlTestCmd(t *testing.T, asJSON bool, flags map[string]string) (*cobra.Command, *bytes.Buffer) {
+	t.Helper()
+	cmd := &cobra.Command{Use: "crawl"}
+	addCrawlFlags(cmd)
+	cmd.Flags().Bool("json", asJSON, "")
+	for name, value := range flags {
+		require.NoError(t, cmd.Flags().Set(name, value))
+	}
+	cmd.SetContext(context.Background())
+	out := &bytes.Buffer{}
+	cmd.SetOut(out)
+	cmd.SetErr(&bytes.Buffer{})
+	return cmd, out
+}
+
+func crawlTestSite(t *testing.T) *httptest.Server {
+	pages := map[string]string{
+		"/":          `<a href="/products/">Products</a> <a href="/Products">Same slug</a>`,
+		"/products/": `<a href="/products?page=2">Next</a>`,
+		"/Products":  `Products`,
+		"/products":  ``,
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, ok := pages[r.URL.Path]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "text/html")
+		w.Write([]byte(body))
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestPageSlug(t *testing.T) {
+	for rawURL, want := range map[string]string{
+		"https://shop.example.com":                  "home",
+		"https://shop.example.com/":                 "home",
+		"https://shop.example.com/Cart/":            "cart",
+		"https://shop.example.com/help/faq.html":    "help_faq_html",
+		"https://shop.example.com/search?q=red+hat": "search_q_red_hat",
+	} {
+		assert.Equal(t, want, pageSlug(rawURL), rawURL)
+	}
+	pages := smokePages([]crawl.Page{{URL: "https://a/cart"}, {URL: "https://a/Cart"}, {URL: "https://a/cart/"}})
+	assert.Equal(t, "cart", pages[0].Slug)
+	assert.Equal(t, "cart_2", pages[1].Slug)
+	assert.Equal(t, "cart_3", pages[2].Slug)
+}
+
+func TestRunCrawl(t *testing.T) {
+	server := crawlTestSite(t)
+	file := filepath.Join(t.TempDir(), "smoke.yaml")
+	cmd, out := crawlTestCmd(t, false, map[string]string{"file": file, "name": "shop"})
+	require.NoError(t, runCrawl(cmd, []string{server.URL}))
+	assert.Contains(t, out.String(), "start    "+server.URL+"/\n")
+	assert.Contains(t, out.String(), "Wrote a smoke test of 4 page(s) to "+file)
+
+	cfg, err := config.Load(file)
+	require.NoError(t, err)
+	require.NoError(t, cfg.Validate())
+	assert.Equal(t, "shop", cfg.Name)
+	require.Len(t, cfg.Apps, 1)
+	assert.Equal(t, server.URL+"/", cfg.Apps[0].URL)
+	assert.Equal(t, []string{"smoke"}, cfg.Apps[0].Tags)
+	assert.Equal(t, 90, cfg.Apps[0].Timeout)
+	names := make([]string, 0)
+	for _, a := range cfg.Actions {
+		names = append(names, a.Name)
+	}
+	assert.Equal(t, []string{
+		"open_home", "home", "home_console",
+		"open_products", "products", "products_console",
+		"open_products_2", "products_2", "products_2_console",
+		"open_products_page_2", "products_page_2", "products_page_2_console",
+	}, names)
+	assert.Equal(t, server.URL+"/products?page=2", cfg.Actions[9].URL)
+	assert.Equal(t, "products.png", cfg.Actions[4].Parameters["filename"])
+	check, err := cfg.Actions[2].ConsoleCheck()
+	require.NoError(t, err)
+	assert.Zero(t, check.MaxErrors)
+
+	cmd, _ = crawlTestCmd(t, false, map[string]string{"file": file})
+	assert.ErrorContains(t, runCrawl(cmd, []string{server.URL}), "already exists; use --force")
+}
+
+func TestRunCrawl_Output(t *testing.T) {
+	server := crawlTestSite(t)
+	cmd, out := crawlTestCmd(t, false, map[string]string{"file": "-", "depth": "0"})
+	require.NoError(t, runCrawl(cmd, []string{server.URL}))
+	assert.Contains(t, out.String(), "name: \"127.0.0.1\"\n")
+	assert.Contains(t, out.String(), "type: \"console_check\"")
+	assert.NotContains(t, out.String(), "open_products")
+
+	file := filepath.Join(t.TempDir(), "smoke.yaml")
+	require.NoError(t, os.WriteFile(file, []byte("old"), 0644))
+	cmd, out = crawlTestCmd(t, true, map[string]string{"file": file, "force": "true", "max-pages": "2"})
+	require.NoError(t, runCrawl(cmd, []string{server.URL}))
+	var outcome crawlOutcome
+	require.NoError(t, json.Unmarshal(out.Bytes(), &outcome))
+	assert.Equal(t, file, outcome.Config)
+	require.Len(t, outcome.Pages, 2)
+	assert.Equal(t, "products", outcome.Pages[1].Slug)
+	assert.Equal(t, crawl.FromLink, outcome.Pages[1].From)
+}
+
+func TestRunCrawl_Errors(t *testing.T) {
+	cmd, _ := crawlTestCmd(t, false, map[string]string{"depth": "-1"})
+	assert.ErrorContains(t, runCrawl(cmd, []string{"https://shop.example.com"}), "--depth must not be negative")
+	cmd, _ = crawlTestCmd(t, false, map[string]string{"max-pages": "0"})
+	assert.ErrorContains(t, runCrawl(cmd, []string{"https://shop.example.com"}), "--max-pages must be positive")
+	cmd, _ = crawlTestCmd(t, false, map[string]string{"file": "-"})
+	assert.ErrorContains(t, runCrawl(cmd, []string{"shop.example.com"}), "invalid start URL")
+}
@@ -0,0 +1,143 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"panoptic/internal/enterprise"
+	"panoptic/pkg/i18n"
+
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+)
+
+var enterpriseCmd = &cobra.Command{
+	Use:   "enterprise",
+	Short: i18n.T("panoptic_cmd_enterprise_short"),
+	Long: `Run the enterprise management actions of a configuration's enterprise_*
+and *_create action types from the command line, against the enterprise
+configuration given with --enterprise-config.`,
+}
+
+// enterpriseActions are the enterprise subcommands and the actions they run
+var enterpriseActions = []struct{ name, action string }{
+	{"status", "enterprise_status"},
+	{"license", "license_info"},
+	{"compliance", "compliance_check"},
+	{"audit", "audit_report"},
+	{"backup", "backup_data"},
+	{"cleanup", "cleanup_data"},
+	{"user-create", "user_create"},
+	{"user-authenticate", "user_authenticate"},
+	{"project-create", "project_create"},
+	{"team-create", "team_create"},
+	{"api-key-create", "api_key_create"},
+	{"run-list", "run_list"},
+	{"usage", "usage_report"},
+	{"approval-list", "approval_list"},
+	{"approval-approve", "approval_approve"},
+	{"approval-reject", "approval_reject"},
+	{"audit verify", "audit_verify"},
+	{"user-invite", "user_invite"},
+	{"invitation-accept", "invitation_accept"},
+	{"password-reset-request", "password_reset_request"},
+	{"password-reset", "password_reset"},
+	{"password-change", "password_change"},
+	{"role-create", "role_create"},
+	{"role-update", "role_update"},
+	{"role-delete", "role_delete"},
+	{"role-list", "role_list"},
+	{"role-assign-teams", "role_assign_teams"},
+	{"permission-list", "permission_list"},
+	{"webhook-deliveries", "webhook_deliveries"},
+	{"webhook-redeliver", "webhook_redeliver"},
+	{"usage export", "usage_export"},
+	{"gdpr-erase", "gdpr_erase"},
+	{"storage-rotate-key", "storage_rotate_key"},
+	{"session-list", "session_list"},
+	{"session-revoke", "session_revoke"},
+	{"queue-submit", "queue_submit"},
+	{"queue-status", "queue_status"},
+	{"queue-list", "queue_list"},
+	{"queue-cancel", "queue_cancel"},
+	{"queue-claim", "queue_claim"},
+	{"queue-heartbeat", "queue_heartbeat"},
+	{"queue-complete", "queue_complete"},
+}
+
+// runEnterpriseAction returns the RunE of an enterprise subcommand
+func runEnterpriseAction(action string) func(*cobra.Command, []string) error {
+	return func(cmd *cobra.Command, args []string) error {
+		configPath, _ := cmd.Flags().GetString("enterprise-config")
+		integration, err := initEnterprise(cmd, configPath)
+		if err != nil {
+			return err
+		}
+
+		flagParams, _ := cmd.Flags().GetStringToString("param")
+		params := make(map[string]interface{}, len(flagParams))
+		for k, v := range flagParams {
+			params[k] = v
+		}
+		result, err := integration.ExecuteEnterpriseAction(context.Background(), action, params)
+		if err != nil {
+			// Report what failed, such as the problems of audit verify
+			if result != nil {
+				if printErr := printEnterpriseResult(cmd, result); printErr != nil {
+					return printErr
+				}
+			}
+			return fmt.Errorf("enterprise %s failed: %w", strings.ReplaceAll(action, "_", " "), err)
+		}
+		return printEnterpriseResult(cmd, result)
+	}
+}
+
+// initEnterprise initializes the enterprise management of a configuration,
+// which must enable it
+func initEnterprise(cmd *cobra.Command, configPath string) (*enterprise.EnterpriseIntegration, error) {
+	if configPath == "" {
+		return nil, fmt.Errorf("--enterprise-config flag is required")
+	}
+	integration := enterprise.NewEnterpriseIntegration(*commandLogger(cmd))
+	if err := integration.Initialize(configPath); err != nil {
+		return nil, err
+	}
+	if !integration.Initialized {
+		return nil, fmt.Errorf("enterprise management is disabled in %s", configPath)
+	}
+	return integration, nil
+}
+
+// printEnterpriseResult prints the result of an action as JSON or YAML
+func printEnterpriseResult(cmd *cobra.Command, result interface{}) error {
+	if jsonOutput(cmd) {
+		return printJSON(cmd, result)
+	}
+	data, err := yaml.Marshal(result)
+	if err != nil {
+		return err
+	}
+	_, err = cmd.OutOrStdout().Write(data)
+	return err
+}
+
+func init() {
+	enterpriseCmd.PersistentFlags().String("enterprise-config", "", "enterprise configuration file (organization, storage path, policies)")
+	for _, a := range enterpriseActions {
+		// "audit verify" is the verify subcommand of audit
+		words := strings.Fields(a.name)
+		parent, _, _ := enterpriseCmd.Find(words[:len(words)-1])
+		c := &cobra.Command{
+			Use:          words[len(words)-1],
+			Short:        i18n.T("panoptic_cmd_enterprise_" + strings.NewReplacer("-", "_", " ", "_").Replace(a.name) + "_short"),
+			Args:         cobra.NoArgs,
+			SilenceUsage: true,
+			RunE:         runEnterpriseAction(a.action),
+		}
+		c.Flags().StringToString("param", nil, "action parameters, e.g. --param username=jdoe,email=jdoe@example.com,role=tester")
+		parent.AddCommand(c)
+	}
+	rootCmd.AddCommand(enterpriseCmd)
+}
@@ -0,0 +1,81 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/spf13/cobra"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func enterpriseTestCmd(configPath string, asJSON bool) (*cobra.Command, *bytes.Buffer) {
+	cmd := &cobra.Command{Use: "status"}
+	cmd.Flags().String("enterprise-config", configPath, "")
+	cmd.Flags().StringToString("param", nil, "")
+	cmd.Flags().Bool("json", asJSON, "")
+	out := &bytes.Buffer{}
+	cmd.SetOut(out)
+	cmd.SetErr(&bytes.Buffer{})
+	return cmd, out
+}
+
+func TestRunEnterpriseAction(t *testing.T) {
+	for _, a := range enterpriseActions {
+		words := strings.Fields(a.name)
+		c, _, err := enterpriseCmd.Find(words)
+		require.NoError(t, err, a.name)
+		assert.Equal(t, words[len(words)-1], c.Name(), a.name)
+		assert.NotEmpty(t, c.Short, a.name)
+	}
+
+	cmd, _ := enterpriseTestCmd("", false)
+	assert.EqualError(t, runEnterpriseAction("enterprise_status")(cmd, nil), "--enterprise-config flag is required")
+
+	dir := t.TempDir()
+	disabled := filepath.Join(dir, "disabled.yaml")
+	require.NoError(t, os.WriteFile(disabled, []byte("enabled: false\n"), 0644))
+	cmd, _ = enterpriseTestCmd(disabled, false)
+	assert.EqualError(t, runEnterpriseAction("enterprise_status")(cmd, nil), "enterprise management is disabled in "+disabled)
+
+	configPath := filepath.Join(dir, "enterprise.yaml")
+	require.NoError(t, os.WriteFile(configPath, []byte(`enabled: true
+organization_name: "Test Corp"
+storage_path: "`+filepath.Join(dir, "data")+`"
+license:
+  key: "test-license-key"
+  type: "enterprise"
+  max_users: 100
+  max_projects: 50
+  expires_at: "2030-12-31T23:59:59Z"
+`), 0644))
+	cmd, out := enterpriseTestCmd(configPath, true)
+	require.NoError(t, runEnterpriseAction("enterprise_status")(cmd, nil))
+	var status map[string]interface{}
+	require.NoError(t, json.Unmarshal(out.Bytes(), &status))
+	assert.Equal(t, true, status["enabled"])
+	assert.Equal(t, "Test Corp", status["organization_name"])
+}
+
+func TestRunEnterpriseAction_AuditVerify(t *testing.T) {
+	dir := t.TempDir()
+	configPath := filepath.Join(dir, "enterprise.yaml")
+	dataDir := filepath.Join(dir, "data")
+	require.NoError(t, os.WriteFile(configPath, []byte("enabled: true\nstorage_path: \""+dataDir+"\"\n"), 0644))
+	cmd, out := enterpriseTestCmd(configPath, true)
+	require.NoError(t, runEnterpriseAction("audit_verify")(cmd, nil))
+	assert.Contains(t, out.String(), `"verified": true`)
+
+	// A tampered entry fails verification, with the problem printed
+	require.NoError(t, os.WriteFile(filepath.Join(dataDir, "audit.json"),
+		[]byte(`[{"id": "e1", "action": "user.login", "hash": "0000"}]`), 0600))
+	cmd, out = enterpriseTestCmd(configPath, true)
+	assert.EqualError(t, runEnterpriseAction("audit_verify")(cmd, nil),
+		"enterprise audit verify failed: audit log failed verification with 1 problem(s)")
+	assert.Contains(t, out.String(), "entry e1: contents don't match its hash")
+}
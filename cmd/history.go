@@ -0,0 +1,209 @@
+package cmd
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"panoptic/internal/history"
+	"panoptic/internal/logger"
+	"panoptic/internal/sitemap"
+	"panoptic/internal/vision"
+	"panoptic/pkg/i18n"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+var historyCmd = &cobra.Command{
+	Use:   "history",
+	Short: i18n.T("panoptic_cmd_history_short"),
+	Long: `Read the run history that panoptic run appends to <output>/history.jsonl and
+print the pass rate of every tag, so flaky or regressing subsets stand out.`,
+	Args: cobra.NoArgs,
+	RunE: runHistory,
+}
+
+func runHistory(cmd *cobra.Command, args []string) error {
+	path, _ := cmd.Flags().GetString("file")
+	if path == "" {
+		path = filepath.Join(viper.GetString("output"), history.FileName)
+	}
+	records, err := history.Load(path)
+	if err != nil {
+		return err
+	}
+
+	last, _ := cmd.Flags().GetInt("last")
+	stats := history.TagStats(records, last)
+
+	if jsonOutput(cmd) {
+		return printJSON(cmd, stats)
+	}
+
+	runs := len(records)
+	if last > 0 && runs > last {
+		runs = last
+	}
+	fmt.Fprintf(cmd.OutOrStdout(), "Tag pass rates over the last %d run(s) in %s\n\n", runs, path)
+	if len(stats) == 0 {
+		fmt.Fprintln(cmd.OutOrStdout(), "No tagged results recorded; add tags to apps or actions.")
+		return nil
+	}
+	return history.WriteTagStats(cmd.OutOrStdout(), stats)
+}
+
+var historyScreensCmd = &cobra.Command{
+	Use:   "screens",
+	Short: i18n.T("panoptic_cmd_history_screens_short"),
+	Long: `Compare the perceptual hashes of the screenshots recorded in the run history
+and list the runs in which a screen looked different from its previous capture,
+or, with --like, from a given screenshot.`,
+	Args: cobra.NoArgs,
+	RunE: runHistoryScreens,
+}
+
+func runHistoryScreens(cmd *cobra.Command, args []string) error {
+	path, _ := cmd.Flags().GetString("file")
+	if path == "" {
+		path = filepath.Join(viper.GetString("output"), history.FileName)
+	}
+	records, err := history.Load(path)
+	if err != nil {
+		return err
+	}
+	if last, _ := cmd.Flags().GetInt("last"); last > 0 && len(records) > last {
+		records = records[len(records)-last:]
+	}
+
+	query := history.ScreenQuery{}
+	query.App, _ = cmd.Flags().GetString("app")
+	query.Screen, _ = cmd.Flags().GetString("screen")
+	query.Distance, _ = cmd.Flags().GetInt("distance")
+	if like, _ := cmd.Flags().GetString("like"); like != "" {
+		hash, err := vision.NewElementDetector(*logger.NewLogger(false)).PerceptualHashFile(like)
+		if err != nil {
+			return fmt.Errorf("failed to hash %s: %w", like, err)
+		}
+		query.Like = &hash
+	}
+	changes := history.ScreenChanges(records, query)
+
+	if jsonOutput(cmd) {
+		return printJSON(cmd, changes)
+	}
+	if len(changes) == 0 {
+		fmt.Fprintf(cmd.OutOrStdout(), "No screen looked different in %d run(s) in %s\n", len(records), path)
+		return nil
+	}
+	fmt.Fprintf(cmd.OutOrStdout(), "Screens that looked different in %d run(s) in %s\n\n", len(records), path)
+	return history.WriteScreenChanges(cmd.OutOrStdout(), changes)
+}
+
+var historyCoverageCmd = &cobra.Command{
+	Use:   "coverage",
+	Short: i18n.T("panoptic_cmd_history_coverage_short"),
+	Long: `Show which routes the runs recorded in the run history opened, by how many
+runs and apps, and which elements were interacted with on each. With
+--sitemap, the routes of the sitemap that no run opened are listed too.`,
+	Args: cobra.NoArgs,
+	RunE: runHistoryCoverage,
+}
+
+func runHistoryCoverage(cmd *cobra.Command, args []string) error {
+	path, _ := cmd.Flags().GetString("file")
+	if path == "" {
+		path = filepath.Join(viper.GetString("output"), history.FileName)
+	}
+	records, err := history.Load(path)
+	if err != nil {
+		return err
+	}
+	if last, _ := cmd.Flags().GetInt("last"); last > 0 && len(records) > last {
+		records = records[len(records)-last:]
+	}
+
+	var known []string
+	sitemaps, _ := cmd.Flags().GetStringSlice("sitemap")
+	for _, file := range sitemaps {
+		s, err := sitemap.ParseFile(file)
+		if err != nil {
+			return err
+		}
+		if len(s.Sitemaps) > 0 {
+			return fmt.Errorf("%s is a sitemap index; pass the sitemaps it lists with --sitemap", file)
+		}
+		known = append(known, s.URLs...)
+	}
+	report := history.CoverageOf(records, known)
+
+	if jsonOutput(cmd) {
+		return printJSON(cmd, report)
+	}
+	fmt.Fprintf(cmd.OutOrStdout(), "Routes exercised in %d run(s) in %s\n\n", len(records), path)
+	return history.WriteCoverage(cmd.OutOrStdout(), report)
+}
+
+var historyMetricsCmd = &cobra.Command{
+	Use:   "metrics",
+	Short: i18n.T("panoptic_cmd_history_metrics_short"),
+	Long: `Follow the metrics that actions record across the runs in the run history:
+per app and browser, the value of every metric in the first and last run, its
+range and mean, and how much it changed.`,
+	Args: cobra.NoArgs,
+	RunE: runHistoryMetrics,
+}
+
+func runHistoryMetrics(cmd *cobra.Command, args []string) error {
+	path, _ := cmd.Flags().GetString("file")
+	if path == "" {
+		path = filepath.Join(viper.GetString("output"), history.FileName)
+	}
+	records, err := history.Load(path)
+	if err != nil {
+		return err
+	}
+	if last, _ := cmd.Flags().GetInt("last"); last > 0 && len(records) > last {
+		records = records[len(records)-last:]
+	}
+
+	query := history.MetricQuery{}
+	query.App, _ = cmd.Flags().GetString("app")
+	query.Metric, _ = cmd.Flags().GetString("metric")
+	trends := history.MetricTrends(records, query)
+
+	if jsonOutput(cmd) {
+		return printJSON(cmd, trends)
+	}
+	if len(trends) == 0 {
+		fmt.Fprintf(cmd.OutOrStdout(), "No metrics recorded in %d run(s) in %s; add metrics to actions.\n", len(records), path)
+		return nil
+	}
+	fmt.Fprintf(cmd.OutOrStdout(), "Metrics recorded in %d run(s) in %s\n\n", len(records), path)
+	return history.WriteMetricTrends(cmd.OutOrStdout(), trends)
+}
+
+func init() {
+	historyCmd.Flags().String("file", "", "history file to read (default <output>/history.jsonl)")
+	historyCmd.Flags().Int("last", 0, "only include the most recent N runs (0 for all)")
+
+	historyScreensCmd.Flags().String("file", "", "history file to read (default <output>/history.jsonl)")
+	historyScreensCmd.Flags().Int("last", 0, "only include the most recent N runs (0 for all)")
+	historyScreensCmd.Flags().String("app", "", "only compare screens of this app")
+	historyScreensCmd.Flags().String("screen", "", "only compare this screen (the action that took the screenshot)")
+	historyScreensCmd.Flags().String("like", "", "compare every capture with this screenshot instead of the previous capture")
+	historyScreensCmd.Flags().Int("distance", vision.NearDuplicateDistance, "largest hash distance, in bits, still the same look")
+
+	historyCoverageCmd.Flags().String("file", "", "history file to read (default <output>/history.jsonl)")
+	historyCoverageCmd.Flags().Int("last", 0, "only include the most recent N runs (0 for all)")
+	historyCoverageCmd.Flags().StringSlice("sitemap", nil, "sitemap.xml files whose routes no run opened are listed")
+
+	historyMetricsCmd.Flags().String("file", "", "history file to read (default <output>/history.jsonl)")
+	historyMetricsCmd.Flags().Int("last", 0, "only include the most recent N runs (0 for all)")
+	historyMetricsCmd.Flags().String("app", "", "only follow the metrics of this app")
+	historyMetricsCmd.Flags().String("metric", "", "only follow this metric")
+
+	historyCmd.AddCommand(historyScreensCmd)
+	historyCmd.AddCommand(historyCoverageCmd)
+	historyCmd.AddCommand(historyMetricsCmd)
+	rootCmd.AddCommand(historyCmd)
+}
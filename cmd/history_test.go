@@ -0,0 +1,189 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"panoptic/internal/history"
+	"panoptic/internal/vision"
+
+	"github.com/spf13/cobra"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func historyTestCmd(path string, asJSON bool) (*cobra.Command, *bytes.Buffer) {
+	cmd := &cobra.Command{Use: "history"}
+	cmd.Flags().String("file", path, "")
+	cmd.Flags().Int("last", 0, "")
+	cmd.Flags().Bool("json", asJSON, "")
+	out := &bytes.Buffer{}
+	cmd.SetOut(out)
+	return cmd, out
+}
+
+func TestHistoryCmd_Registered(t *testing.T) {
+	assert.Equal(t, "panoptic_cmd_history_short", historyCmd.Short)
+	found := false
+	for _, c := range rootCmd.Commands() {
+		if c.Name() == "history" {
+			found = true
+		}
+	}
+	assert.True(t, found)
+}
+
+func TestRunHistory(t *testing.T) {
+	path := filepath.Join(t.TempDir(), history.FileName)
+	require.NoError(t, history.Append(path, history.Record{RunID: "1", Time: time.Now(), Entries: []history.Entry{
+		{App: "Shop", Tags: []string{"smoke"}, Success: true},
+		{App: "Admin", Tags: []string{"smoke"}, Success: false},
+	}}))
+
+	cmd, out := historyTestCmd(path, false)
+	require.NoError(t, runHistory(cmd, nil))
+	assert.Contains(t, out.String(), "last 1 run(s)")
+	assert.Regexp(t, `smoke\s+1\s+2\s+1\s+1\s+50\.0%\s+failed`, out.String())
+
+	cmd, out = historyTestCmd(path, true)
+	require.NoError(t, runHistory(cmd, nil))
+	var stats []history.TagStat
+	require.NoError(t, json.Unmarshal(out.Bytes(), &stats))
+	require.Len(t, stats, 1)
+	assert.Equal(t, 50.0, stats[0].PassRate)
+
+	cmd, _ = historyTestCmd(filepath.Join(t.TempDir(), "none.jsonl"), false)
+	assert.Error(t, runHistory(cmd, nil))
+}
+
+func historyScreensTestCmd(path, like string, asJSON bool) (*cobra.Command, *bytes.Buffer) {
+	cmd := &cobra.Command{Use: "screens"}
+	cmd.Flags().String("file", path, "")
+	cmd.Flags().Int("last", 0, "")
+	cmd.Flags().String("app", "Shop", "")
+	cmd.Flags().String("screen", "", "")
+	cmd.Flags().String("like", like, "")
+	cmd.Flags().Int("distance", vision.NearDuplicateDistance, "")
+	cmd.Flags().Bool("json", asJSON, "")
+	out := &bytes.Buffer{}
+	cmd.SetOut(out)
+	return cmd, out
+}
+
+func TestRunHistoryScreens(t *testing.T) {
+	path := filepath.Join(t.TempDir(), history.FileName)
+	for i, hash := range []vision.PHash{0x0f, 0x0f, 0xf0f0} {
+		require.NoError(t, history.Append(path, history.Record{RunID: fmt.Sprint(i + 1), Time: time.Now(), Entries: []history.Entry{
+			{App: "Shop", Screens: []history.Screen{{Name: "home", Screenshot: "home.png", PHash: hash}}},
+		}}))
+	}
+
+	cmd, out := historyScreensTestCmd(path, "", false)
+	require.NoError(t, runHistoryScreens(cmd, nil))
+	assert.Contains(t, out.String(), "in 3 run(s)")
+	assert.Regexp(t, `3\s+\S+\s+Shop\s+home\s+12\s+2\s+home\.png`, out.String())
+
+	cmd, out = historyScreensTestCmd(path, "", true)
+	require.NoError(t, runHistoryScreens(cmd, nil))
+	var changes []history.ScreenChange
+	require.NoError(t, json.Unmarshal(out.Bytes(), &changes))
+	require.Len(t, changes, 1)
+	assert.Equal(t, "2", changes[0].ReferenceRun)
+
+	cmd, _ = historyScreensTestCmd(path, filepath.Join(t.TempDir(), "missing.png"), false)
+	assert.ErrorContains(t, runHistoryScreens(cmd, nil), "failed to hash")
+
+	cmd, _ = historyScreensTestCmd(filepath.Join(t.TempDir(), "none.jsonl"), "", false)
+	assert.Error(t, runHistoryScreens(cmd, nil))
+}
+
+func historyCoverageTestCmd(path string, sitemaps []string, asJSON bool) (*cobra.Command, *bytes.Buffer) {
+	cmd := &cobra.Command{Use: "coverage"}
+	cmd.Flags().String("file", path, "")
+	cmd.Flags().Int("last", 0, "")
+	cmd.Flags().StringSlice("sitemap", sitemaps, "")
+	cmd.Flags().Bool("json", asJSON, "")
+	out := &bytes.Buffer{}
+	cmd.SetOut(out)
+	return cmd, out
+}
+
+func TestRunHistoryCoverage(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, history.FileName)
+	require.NoError(t, history.Append(path, history.Record{RunID: "1", Time: time.Now(), Entries: []history.Entry{
+		{App: "Shop", Routes: []string{"/", "/cart"}, Elements: []history.Element{{Route: "/cart", Selector: "#checkout"}}},
+	}}))
+	sitemapPath := filepath.Join(dir, "sitemap.xml")
+	require.NoError(t, os.WriteFile(sitemapPath, []byte(`<urlset>
+  <url><loc>https://shop.example.com/</loc></url>
+  <url><loc>https://shop.example.com/about</loc></url>
+</urlset>`), 0644))
+
+	cmd, out := historyCoverageTestCmd(path, []string{sitemapPath}, false)
+	require.NoError(t, runHistoryCoverage(cmd, nil))
+	assert.Contains(t, out.String(), "in 1 run(s)")
+	assert.Regexp(t, `/cart\s+1\s+Shop\s+#checkout`, out.String())
+	assert.Contains(t, out.String(), "50.0% of 2 known route(s) exercised; never exercised:\n  /about\n")
+
+	cmd, out = historyCoverageTestCmd(path, nil, true)
+	require.NoError(t, runHistoryCoverage(cmd, nil))
+	var report history.CoverageReport
+	require.NoError(t, json.Unmarshal(out.Bytes(), &report))
+	require.Len(t, report.Routes, 2)
+	assert.Empty(t, report.Untouched)
+
+	indexPath := filepath.Join(dir, "index.xml")
+	require.NoError(t, os.WriteFile(indexPath, []byte(`<sitemapindex><sitemap><loc>https://shop.example.com/s1.xml</loc></sitemap></sitemapindex>`), 0644))
+	cmd, _ = historyCoverageTestCmd(path, []string{indexPath}, false)
+	assert.ErrorContains(t, runHistoryCoverage(cmd, nil), "is a sitemap index")
+
+	cmd, _ = historyCoverageTestCmd(filepath.Join(dir, "none.jsonl"), nil, false)
+	assert.Error(t, runHistoryCoverage(cmd, nil))
+}
+
+func historyMetricsTestCmd(path, metric string, asJSON bool) (*cobra.Command, *bytes.Buffer) {
+	cmd := &cobra.Command{Use: "metrics"}
+	cmd.Flags().String("file", path, "")
+	cmd.Flags().Int("last", 0, "")
+	cmd.Flags().String("app", "", "")
+	cmd.Flags().String("metric", metric, "")
+	cmd.Flags().Bool("json", asJSON, "")
+	out := &bytes.Buffer{}
+	cmd.SetOut(out)
+	return cmd, out
+}
+
+func TestRunHistoryMetrics(t *testing.T) {
+	path := filepath.Join(t.TempDir(), history.FileName)
+	for i, render := range []float64{200, 300} {
+		require.NoError(t, history.Append(path, history.Record{RunID: fmt.Sprint(i + 1), Time: time.Now(), Entries: []history.Entry{
+			{App: "Shop", Metrics: []history.Metric{{Name: "cart_render", Value: render, Unit: "ms"}, {Name: "items", Value: 12}}},
+		}}))
+	}
+
+	cmd, out := historyMetricsTestCmd(path, "", false)
+	require.NoError(t, runHistoryMetrics(cmd, nil))
+	assert.Contains(t, out.String(), "in 2 run(s)")
+	assert.Regexp(t, `Shop\s+cart_render\s+2\s+200 ms\s+300 ms\s+200 ms\s+300 ms\s+250 ms\s+\+50\.0%`, out.String())
+
+	cmd, out = historyMetricsTestCmd(path, "items", true)
+	require.NoError(t, runHistoryMetrics(cmd, nil))
+	var trends []history.MetricTrend
+	require.NoError(t, json.Unmarshal(out.Bytes(), &trends))
+	require.Len(t, trends, 1)
+	assert.Equal(t, 12.0, trends[0].Last)
+
+	cmd, out = historyMetricsTestCmd(path, "checkout", false)
+	require.NoError(t, runHistoryMetrics(cmd, nil))
+	assert.Contains(t, out.String(), "No metrics recorded in 2 run(s)")
+
+	cmd, _ = historyMetricsTestCmd(filepath.Join(t.TempDir(), "none.jsonl"), "", false)
+	assert.Error(t, runHistoryMetrics(cmd, nil))
+}
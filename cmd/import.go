@@ -0,0 +1,242 @@
+package cmd
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"panoptic/internal/config"
+	"panoptic/internal/importer"
+	"panoptic/pkg/i18n"
+
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+)
+
+var importCmd = &cobra.Command{
+	Use:   "import <recording>",
+	Short: i18n.T("panoptic_cmd_import_short"),
+	Long: `Convert a recorded session or a test suite into a Panoptic configuration:
+a HAR file saved from browser developer tools, a Chrome DevTools Recorder
+export, a Selenium IDE project (.side) or a Playwright test script. Pages
+opened become navigate actions and form posts http_request actions;
+clicks and typing become click, fill and submit actions. Each test of a
+suite becomes an app with its own actions.
+
+The format is detected from the file unless --format is given. What can't
+be converted is listed as comments at the top of the configuration, so it
+can be reviewed before the checks are committed.`,
+	Args:         cobra.ExactArgs(1),
+	SilenceUsage: true,
+	RunE:         runImport,
+}
+
+// App timeout of an imported configuration, in seconds: a base and a share
+// per action
+const (
+	importAppTimeout    = 30
+	importActionTimeout = 5
+)
+
+// importedConfig is the layout of an imported configuration, leaving out
+// what the import doesn't set
+type importedConfig struct {
+	Name     string           `yaml:"name"`
+	Output   string           `yaml:"output"`
+	Apps     []importedApp    `yaml:"apps"`
+	Actions  []importedAction `yaml:"actions,omitempty"`
+	Settings struct {
+		Headless     bool `yaml:"headless"`
+		WindowWidth  int  `yaml:"window_width"`
+		WindowHeight int  `yaml:"window_height"`
+	} `yaml:"settings"`
+}
+
+type importedApp struct {
+	Name    string           `yaml:"name"`
+	Type    string           `yaml:"type"`
+	URL     string           `yaml:"url"`
+	Timeout int              `yaml:"timeout"`
+	Actions []importedAction `yaml:"actions,omitempty"`
+}
+
+type importedAction struct {
+	Name       string                 `yaml:"name"`
+	Type       string                 `yaml:"type"`
+	URL        string                 `yaml:"url,omitempty"`
+	Selector   string                 `yaml:"selector,omitempty"`
+	Value      string                 `yaml:"value,omitempty"`
+	Parameters map[string]interface{} `yaml:"parameters,omitempty"`
+}
+
+// importOutcome is the JSON output of import
+type importOutcome struct {
+	Config   string   `json:"config"`
+	Format   string   `json:"format"`
+	Tests    int      `json:"tests"`
+	Actions  int      `json:"actions"`
+	Warnings []string `json:"warnings"`
+}
+
+// importedActions lays out the actions of a test
+func importedActions(actions []config.Action) []importedAction {
+	imported := make([]importedAction, len(actions))
+	for i, a := range actions {
+		imported[i] = importedAction{Name: a.Name, Type: a.Type, URL: a.URL, Selector: a.Selector, Value: a.Value, Parameters: a.Parameters}
+	}
+	return imported
+}
+
+// renderImport writes the imported tests as a configuration run accepts,
+// with what was left out as comments: a recorded session is the actions of
+// an app, and each test of a suite an app of its own
+func renderImport(name, source string, result *importer.Result) ([]byte, error) {
+	cfg := importedConfig{Name: name, Output: "./output"}
+	cfg.Settings.Headless = true
+	cfg.Settings.WindowWidth, cfg.Settings.WindowHeight = 1280, 800
+	if len(result.Tests) == 1 {
+		test := result.Tests[0]
+		cfg.Apps = []importedApp{{Name: name, Type: "web", URL: test.URL, Timeout: importAppTimeout + importActionTimeout*len(test.Actions)}}
+		cfg.Actions = importedActions(test.Actions)
+	} else {
+		used := make(map[string]bool, len(result.Tests))
+		for i, test := range result.Tests {
+			base := test.Name
+			if base == "" {
+				base = fmt.Sprintf("test %d", i+1)
+			}
+			app := base
+			for n := 2; used[app]; n++ {
+				app = fmt.Sprintf("%s (%d)", base, n)
+			}
+			used[app] = true
+			cfg.Apps = append(cfg.Apps, importedApp{
+				Name: app, Type: "web", URL: test.URL,
+				Timeout: importAppTimeout + importActionTimeout*len(test.Actions),
+				Actions: importedActions(test.Actions),
+			})
+		}
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "# Imported from %s (%s) by panoptic import.\n", source, result.Format)
+	if len(result.Warnings) > 0 {
+		b.WriteString("# Left out:\n")
+		for _, w := range result.Warnings {
+			fmt.Fprintf(&b, "#   %s\n", w)
+		}
+	}
+	if sendsRequests(result) {
+		b.WriteString("# Requests carry no cookies or credentials; add headers for those.\n")
+	}
+	enc := yaml.NewEncoder(&b)
+	enc.SetIndent(2)
+	if err := enc.Encode(&cfg); err != nil {
+		return nil, err
+	}
+	if err := enc.Close(); err != nil {
+		return nil, err
+	}
+	data := []byte(b.String())
+
+	var parsed config.Config
+	if err := yaml.Unmarshal(data, &parsed); err != nil {
+		return nil, fmt.Errorf("imported configuration is invalid: %w", err)
+	}
+	if err := parsed.Validate(); err != nil {
+		return nil, fmt.Errorf("imported configuration is invalid: %w", err)
+	}
+	return data, nil
+}
+
+// sendsRequests reports whether an imported test has http_request actions
+func sendsRequests(result *importer.Result) bool {
+	for _, test := range result.Tests {
+		for _, a := range test.Actions {
+			if a.Type == "http_request" {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func runImport(cmd *cobra.Command, args []string) error {
+	format, _ := cmd.Flags().GetString("format")
+	name, _ := cmd.Flags().GetString("name")
+	file, _ := cmd.Flags().GetString("file")
+	force, _ := cmd.Flags().GetBool("force")
+	if file != "-" && !force {
+		if _, err := os.Stat(file); err == nil {
+			return fmt.Errorf("%s already exists; use --force to overwrite it", file)
+		}
+	}
+
+	data, err := os.ReadFile(args[0])
+	if err != nil {
+		return fmt.Errorf("failed to read recording: %w", err)
+	}
+	result, err := importer.Import(data, format)
+	if err != nil {
+		return fmt.Errorf("%s: %w", args[0], err)
+	}
+	actions := 0
+	for _, test := range result.Tests {
+		if test.URL == "" {
+			return fmt.Errorf("%s: the recording opens no page to start the app at", args[0])
+		}
+		actions += len(test.Actions)
+	}
+	if name == "" {
+		name = result.Name
+	}
+	if name == "" {
+		if u, err := url.Parse(result.Tests[0].URL); err == nil {
+			name = u.Hostname()
+		}
+	}
+	out, err := renderImport(name, filepath.Base(args[0]), result)
+	if err != nil {
+		return err
+	}
+
+	if file == "-" {
+		_, err := cmd.OutOrStdout().Write(out)
+		return err
+	}
+	if err := os.WriteFile(file, out, 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", file, err)
+	}
+	if jsonOutput(cmd) {
+		warnings := result.Warnings
+		if warnings == nil {
+			warnings = []string{}
+		}
+		return printJSON(cmd, importOutcome{Config: file, Format: result.Format, Tests: len(result.Tests), Actions: actions, Warnings: warnings})
+	}
+	w := cmd.OutOrStdout()
+	fmt.Fprintf(w, "Imported %d test(s) with %d action(s) from %s to %s\n", len(result.Tests), actions, args[0], file)
+	if len(result.Warnings) > 0 {
+		fmt.Fprintf(w, "Left out:\n")
+		for _, warning := range result.Warnings {
+			fmt.Fprintf(w, "  %s\n", warning)
+		}
+	}
+	fmt.Fprintf(w, "Run it with:\n  panoptic run %s\n", file)
+	return nil
+}
+
+// addImportFlags adds the flags of import
+func addImportFlags(c *cobra.Command) {
+	c.Flags().String("format", "", "format of the recording: "+strings.Join(importer.Formats, " or ")+" (default detected)")
+	c.Flags().String("name", "", "configuration name, and app name of a recorded session (default the recording title or the host)")
+	c.Flags().String("file", "-", `configuration to write, or "-" for stdout`)
+	c.Flags().Bool("force", false, "overwrite an existing configuration")
+}
+
+func init() {
+	addImportFlags(importCmd)
+	rootCmd.AddCommand(importCmd)
+}
@@ -0,0 +1,143 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"panoptic/internal/config"
+
+	"github.com/spf13/cobra"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func importTestCmd(t *testing.T, asJSON bool, flags map[string]string) (*cobra.Command, *bytes.Buffer) {
+	t.Helper()
+	cmd := &cobra.Command{Use: "import"}
+	addImportFlags(cmd)
+	cmd.Flags().Bool("json", asJSON, "")
+	for name, value := range flags {
+		require.NoError(t, cmd.Flags().Set(name, value))
+	}
+	out := &bytes.Buffer{}
+	cmd.SetOut(out)
+	cmd.SetErr(&bytes.Buffer{})
+	return cmd, out
+}
+
+func writeRecording(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+	return path
+}
+
+const importTestHAR = `{"log": {"entries": [
+  {"_resourceType": "document", "request": {"method": "GET", "url": "https://shop.example.com/"}, "response": {"status": 200}},
+  {"_resourceType": "document", "request": {"method": "POST", "url": "https://shop.example.com/cart",
+    "postData": {"mimeType": "application/x-www-form-urlencoded", "text": "sku=42"}}, "response": {"status": 303}},
+  {"_resourceType": "ping", "request": {"method": "POST", "url": "https://analytics.example.net/collect"}, "response": {"status": 204}}
+]}}`
+
+func TestRunImport(t *testing.T) {
+	har := writeRecording(t, "shop.har", importTestHAR)
+	file := filepath.Join(t.TempDir(), "shop.yaml")
+	cmd, out := importTestCmd(t, false, map[string]string{"file": file})
+	require.NoError(t, runImport(cmd, []string{har}))
+	assert.Contains(t, out.String(), "Imported 1 test(s) with 2 action(s) from "+har+" to "+file)
+	assert.Contains(t, out.String(), "Left out:\n  POST https://analytics.example.net/collect: not on shop.example.com\n")
+
+	data, err := os.ReadFile(file)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), "# Imported from shop.har (har) by panoptic import.\n# Left out:\n#   POST https://analytics.example.net/collect")
+	assert.Contains(t, string(data), "# Requests carry no cookies or credentials")
+	cfg, err := config.Load(file)
+	require.NoError(t, err)
+	require.NoError(t, cfg.Validate())
+	assert.Equal(t, "shop.example.com", cfg.Name)
+	require.Len(t, cfg.Apps, 1)
+	assert.Equal(t, "https://shop.example.com/", cfg.Apps[0].URL)
+	assert.Equal(t, 40, cfg.Apps[0].Timeout)
+	require.Len(t, cfg.Actions, 2)
+	req, err := cfg.Actions[1].HTTPRequest()
+	require.NoError(t, err)
+	assert.Equal(t, "POST", req.Method)
+	assert.Equal(t, "sku=42", req.Body)
+	assert.Equal(t, 303, req.ExpectStatus)
+
+	cmd, _ = importTestCmd(t, false, map[string]string{"file": file})
+	assert.ErrorContains(t, runImport(cmd, []string{har}), "already exists; use --force")
+}
+
+func TestRunImport_DevTools(t *testing.T) {
+	recording := writeRecording(t, "login.json", `{"title": "Log in", "steps": [
+	  {"type": "navigate", "url": "https://shop.example.com/login"},
+	  {"type": "change", "value": "ada@example.com", "selectors": [["#email"]]},
+	  {"type": "keyDown", "key": "Enter"},
+	  {"type": "hover", "selectors": [["#menu"]]}
+	]}`)
+	cmd, out := importTestCmd(t, false, map[string]string{"format": "devtools"})
+	require.NoError(t, runImport(cmd, []string{recording}))
+	assert.Contains(t, out.String(), "# Imported from login.json (devtools) by panoptic import.\n")
+	assert.NotContains(t, out.String(), "Left out")
+	assert.NotContains(t, out.String(), "cookies")
+	assert.Contains(t, out.String(), "name: Log in\n")
+	assert.Contains(t, out.String(), "  - name: submit_email\n    type: submit\n    selector: '#email'\n")
+
+	file := filepath.Join(t.TempDir(), "login.yaml")
+	cmd, out = importTestCmd(t, true, map[string]string{"file": file, "name": "login"})
+	require.NoError(t, runImport(cmd, []string{recording}))
+	var outcome importOutcome
+	require.NoError(t, json.Unmarshal(out.Bytes(), &outcome))
+	assert.Equal(t, importOutcome{Config: file, Format: "devtools", Tests: 1, Actions: 3, Warnings: []string{}}, outcome)
+}
+
+func TestRunImport_Suite(t *testing.T) {
+	script := writeRecording(t, "checkout.spec.ts", `import { test } from '@playwright/test';
+
+test('cart', async ({ page }) => {
+  await page.goto('https://shop.example.com/cart');
+  await page.click('#checkout');
+});
+
+test('cart', async ({ page }) => {
+  await page.goto('https://shop.example.com/');
+  await page.getByText('Help').click();
+});
+`)
+	file := filepath.Join(t.TempDir(), "checkout.yaml")
+	cmd, out := importTestCmd(t, false, map[string]string{"file": file})
+	require.NoError(t, runImport(cmd, []string{script}))
+	assert.Contains(t, out.String(), "Imported 2 test(s) with 3 action(s)")
+	assert.Contains(t, out.String(), "Left out:\n  line 10 (page.getByText('Help').click()): no CSS selector or test id\n")
+
+	cfg, err := config.Load(file)
+	require.NoError(t, err)
+	require.NoError(t, cfg.Validate())
+	assert.Equal(t, "shop.example.com", cfg.Name)
+	assert.Empty(t, cfg.Actions)
+	require.Len(t, cfg.Apps, 2)
+	assert.Equal(t, "cart", cfg.Apps[0].Name)
+	assert.Equal(t, "https://shop.example.com/cart", cfg.Apps[0].URL)
+	assert.Len(t, cfg.Apps[0].Actions, 2)
+	assert.Equal(t, "cart (2)", cfg.Apps[1].Name)
+	assert.Equal(t, "https://shop.example.com/", cfg.Apps[1].URL)
+	assert.Len(t, cfg.Apps[1].Actions, 1)
+}
+
+func TestRunImport_Errors(t *testing.T) {
+	cmd, _ := importTestCmd(t, false, nil)
+	assert.ErrorContains(t, runImport(cmd, []string{filepath.Join(t.TempDir(), "missing.har")}), "failed to read recording")
+
+	unknown := writeRecording(t, "suite.json", `{"tests": []}`)
+	assert.ErrorContains(t, runImport(cmd, []string{unknown}), "unknown format")
+
+	posts := writeRecording(t, "api.har", `{"log": {"entries": [
+	  {"request": {"method": "POST", "url": "https://api.example.com/orders"}, "response": {"status": 201}}
+	]}}`)
+	assert.ErrorContains(t, runImport(cmd, []string{posts}), "opens no page")
+}
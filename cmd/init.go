@@ -0,0 +1,519 @@
+package cmd
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"text/template"
+
+	"panoptic/internal/config"
+	"panoptic/pkg/i18n"
+
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+)
+
+var initCmd = &cobra.Command{
+	Use:   "init [dir]",
+	Short: i18n.T("panoptic_cmd_init_short"),
+	Long: `Ask for the app to test, the checks to run and the CI system to run them
+in, then write a starter configuration, the output directory and a CI
+pipeline into dir (the current directory by default).
+
+Answers given as flags aren't asked for; --yes takes the defaults of the
+rest, for scripts.`,
+	Args:         cobra.MaximumNArgs(1),
+	SilenceUsage: true,
+	RunE:         runInit,
+}
+
+// Checks the init wizard can set up
+const (
+	checkScreenshots = "screenshots"
+	checkVideo       = "video"
+	checkA11y        = "a11y"
+	checkVisual      = "visual"
+)
+
+var initChecks = []string{checkScreenshots, checkVideo, checkA11y, checkVisual}
+
+// ciFiles are the pipeline files of the CI systems init writes for
+var ciFiles = map[string]string{
+	"github":  filepath.Join(".github", "workflows", "panoptic.yml"),
+	"gitlab":  ".gitlab-ci.yml",
+	"jenkins": "Jenkinsfile",
+}
+
+// initConfigFile is the name of the configuration init writes
+const initConfigFile = "panoptic.yaml"
+
+// starter is what the init wizard writes a project from
+type starter struct {
+	Name     string   `json:"name"`
+	Type     string   `json:"type"`               // web, desktop or mobile
+	URL      string   `json:"url,omitempty"`      // web
+	Path     string   `json:"path,omitempty"`     // desktop
+	Platform string   `json:"platform,omitempty"` // desktop and mobile
+	Checks   []string `json:"checks"`
+	CI       string   `json:"ci"` // github, gitlab, jenkins or none
+}
+
+// Has reports whether the check was chosen
+func (s starter) Has(check string) bool {
+	for _, c := range s.Checks {
+		if c == check {
+			return true
+		}
+	}
+	return false
+}
+
+// Screenshots reports whether the actions take a screenshot, which visual
+// regression compares
+func (s starter) Screenshots() bool {
+	return s.Has(checkScreenshots) || s.Has(checkVisual)
+}
+
+// initOutcome is the JSON output of init
+type initOutcome struct {
+	Dir     string   `json:"dir"`
+	Config  string   `json:"config"`
+	Files   []string `json:"files"`
+	Starter starter  `json:"starter"`
+}
+
+// prompter asks the wizard's questions, offering a default for an empty
+// answer and asking again after an invalid one
+type prompter struct {
+	in  *bufio.Reader
+	out io.Writer
+}
+
+func (p *prompter) ask(question, def string, check func(string) error) (string, error) {
+	for {
+		if def != "" {
+			fmt.Fprintf(p.out, "%s [%s]: ", question, def)
+		} else {
+			fmt.Fprintf(p.out, "%s: ", question)
+		}
+		line, err := p.in.ReadString('\n')
+		if err != nil && err != io.EOF {
+			return "", err
+		}
+		if err == io.EOF {
+			// Input ended: take the default, or give up
+			fmt.Fprintln(p.out)
+		}
+		answer := strings.TrimSpace(line)
+		if answer == "" {
+			answer = def
+		}
+		problem := check(answer)
+		if problem == nil {
+			return answer, nil
+		}
+		if err == io.EOF {
+			return "", fmt.Errorf("%s: %w", question, problem)
+		}
+		fmt.Fprintf(p.out, "  %s\n", problem)
+	}
+}
+
+// oneOf accepts one of the choices
+func oneOf(choices ...string) func(string) error {
+	return func(answer string) error {
+		for _, c := range choices {
+			if answer == c {
+				return nil
+			}
+		}
+		return fmt.Errorf("want %s", strings.Join(choices, ", "))
+	}
+}
+
+func required(what string) func(string) error {
+	return func(answer string) error {
+		if answer == "" {
+			return fmt.Errorf("%s is required", what)
+		}
+		return nil
+	}
+}
+
+func validURL(answer string) error {
+	u, err := url.Parse(answer)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("want an http:// or https:// URL")
+	}
+	return nil
+}
+
+// parseChecks splits a comma-separated list of checks, or none
+func parseChecks(answer string) ([]string, error) {
+	checks := make([]string, 0)
+	if answer == "none" {
+		return checks, nil
+	}
+	for _, c := range strings.Split(answer, ",") {
+		if c = strings.TrimSpace(c); c == "" {
+			continue
+		}
+		if err := oneOf(initChecks...)(c); err != nil {
+			return nil, fmt.Errorf("unknown check %q: %s or none", c, strings.Join(initChecks, ", "))
+		}
+		checks = append(checks, c)
+	}
+	return checks, nil
+}
+
+// defaultPlatform is the platform an app of the type most likely runs on
+func defaultPlatform(appType string) string {
+	if appType == "mobile" {
+		return "android"
+	}
+	if runtime.GOOS == "darwin" {
+		return "macos"
+	}
+	if runtime.GOOS == "windows" {
+		return "windows"
+	}
+	return "linux"
+}
+
+// askStarter fills in the answers the flags didn't give, asking the rest
+// unless yes takes their defaults
+func askStarter(cmd *cobra.Command, dir string, yes bool) (starter, error) {
+	var s starter
+	p := &prompter{in: bufio.NewReader(cmd.InOrStdin()), out: cmd.ErrOrStderr()}
+	if !jsonOutput(cmd) {
+		p.out = cmd.OutOrStdout()
+	}
+	answer := func(flag, question, def string, check func(string) error) (string, error) {
+		if cmd.Flags().Changed(flag) {
+			value, _ := cmd.Flags().GetString(flag)
+			if err := check(value); err != nil {
+				return "", fmt.Errorf("--%s: %w", flag, err)
+			}
+			return value, nil
+		}
+		if yes {
+			if err := check(def); err != nil {
+				return "", fmt.Errorf("--%s: %w", flag, err)
+			}
+			return def, nil
+		}
+		return p.ask(question, def, check)
+	}
+
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		return s, err
+	}
+	if s.Name, err = answer("name", "Project name", filepath.Base(abs), required("a name")); err != nil {
+		return s, err
+	}
+	if s.Type, err = answer("type", "App type (web, desktop, mobile)", "web", oneOf("web", "desktop", "mobile")); err != nil {
+		return s, err
+	}
+	switch s.Type {
+	case "web":
+		s.URL, err = answer("url", "URL of the app", "http://localhost:3000", validURL)
+	case "desktop":
+		if s.Path, err = answer("path", "Path of the app executable", "", required("the path of the app")); err == nil {
+			s.Platform, err = answer("platform", "Platform (linux, macos, windows)", defaultPlatform(s.Type), oneOf("linux", "macos", "windows"))
+		}
+	case "mobile":
+		s.Platform, err = answer("platform", "Platform (android, ios)", defaultPlatform(s.Type), oneOf("android", "ios"))
+	}
+	if err != nil {
+		return s, err
+	}
+
+	checks, err := answer("checks", "Checks ("+strings.Join(initChecks, ", ")+", or none)", checkScreenshots+","+checkVisual, func(a string) error {
+		_, err := parseChecks(a)
+		return err
+	})
+	if err != nil {
+		return s, err
+	}
+	s.Checks, _ = parseChecks(checks)
+	s.CI, err = answer("ci", "CI system (github, gitlab, jenkins, none)", "github", oneOf("github", "gitlab", "jenkins", "none"))
+	return s, err
+}
+
+var initConfigTemplate = template.Must(template.New("config").Funcs(template.FuncMap{"quote": strconv.Quote}).Parse(`# Starter configuration written by panoptic init. Run it with:
+#   panoptic run {{.File}}
+# and see docs/User_Manual.md for every app setting and action type.
+name: {{quote .Name}}
+output: "./output"
+
+apps:
+  - name: {{quote .Name}}
+    type: {{quote .Type}}
+{{- if eq .Type "web"}}
+    url: {{quote .URL}}
+    timeout: 30
+{{- else if eq .Type "desktop"}}
+    path: {{quote .Path}}
+    platform: {{quote .Platform}}
+    timeout: 60
+{{- else}}
+    platform: {{quote .Platform}}
+    emulator: true
+    timeout: 60
+{{- end}}
+
+actions:
+{{- if eq .Type "web"}}
+  - name: "open_home"
+    type: "navigate"
+    url: {{quote .URL}}
+{{- end}}
+  - name: "wait_for_load"
+    type: "wait"
+    wait_time: 2
+{{- if .Screenshots}}
+  - name: "home"
+    type: "screenshot"
+    parameters:
+      filename: "home.png"
+{{- end}}
+{{- if .Has "a11y"}}
+  # Flags text below the WCAG AA contrast ratio as an accessibility finding;
+  # add a region per heading, label or button to check
+  - name: "home_contrast"
+    type: "vision_contrast_check"
+    parameters:
+      level: "AA"
+      regions:
+{{- if eq .Type "web"}}
+        - selector: "h1"
+{{- else}}
+        - name: "title"
+          x: 0
+          y: 0
+          width: 400
+          height: 60
+{{- end}}
+{{- end}}
+{{- if .Has "video"}}
+  - name: "home_recording"
+    type: "record"
+    duration: 10
+    parameters:
+      filename: "home.mp4"
+{{- end}}
+
+settings:
+  screenshot_format: "png"
+  video_format: "mp4"
+  quality: 80
+  headless: true
+  window_width: 1280
+  window_height: 800
+`))
+
+// ciTemplates are the pipelines of the CI systems: they run the
+// configuration, check screens against baseline.json when visual
+// regression was chosen, and keep the output as an artifact
+var ciTemplates = map[string]*template.Template{
+	"github": template.Must(template.New("github").Parse(`name: Panoptic
+on: [push, pull_request]
+
+jobs:
+  panoptic:
+    runs-on: ubuntu-latest
+    steps:
+      - uses: actions/checkout@v4
+      - uses: actions/setup-go@v5
+        with:
+          go-version: stable
+      - name: Install Panoptic
+        run: go install github.com/your-org/panoptic@latest
+      - name: Run Panoptic
+        run: panoptic run {{.File}} --github --sarif output/results.sarif
+{{- if .Has "visual"}}
+      - name: Check screens against the baseline
+        run: panoptic baseline check output/results.json --file baseline.json
+{{- end}}
+      - name: Upload results
+        if: always()
+        uses: actions/upload-artifact@v4
+        with:
+          name: panoptic-results
+          path: output/
+`)),
+	"gitlab": template.Must(template.New("gitlab").Parse(`panoptic:
+  image: golang:latest
+  script:
+    - go install github.com/your-org/panoptic@latest
+    - panoptic run {{.File}}
+{{- if .Has "visual"}}
+    - panoptic baseline check output/results.json --file baseline.json
+{{- end}}
+  artifacts:
+    when: always
+    paths:
+      - output/
+`)),
+	"jenkins": template.Must(template.New("jenkins").Parse(`pipeline {
+    agent any
+    stages {
+        stage('Panoptic') {
+            steps {
+                sh 'go install github.com/your-org/panoptic@latest'
+                sh 'panoptic run {{.File}}'
+{{- if .Has "visual"}}
+                sh 'panoptic baseline check output/results.json --file baseline.json'
+{{- end}}
+            }
+        }
+    }
+    post {
+        always {
+            archiveArtifacts artifacts: 'output/**', allowEmptyArchive: true
+        }
+    }
+}
+`)),
+}
+
+// render executes a template for the starter
+func (s starter) render(t *template.Template) ([]byte, error) {
+	var b strings.Builder
+	err := t.Execute(&b, struct {
+		starter
+		File string
+	}{s, initConfigFile})
+	return []byte(b.String()), err
+}
+
+func runInit(cmd *cobra.Command, args []string) error {
+	dir := "."
+	if len(args) > 0 {
+		dir = args[0]
+	}
+	yes, _ := cmd.Flags().GetBool("yes")
+	force, _ := cmd.Flags().GetBool("force")
+	s, err := askStarter(cmd, dir, yes)
+	if err != nil {
+		return err
+	}
+
+	files := map[string][]byte{}
+	if files[initConfigFile], err = s.render(initConfigTemplate); err != nil {
+		return err
+	}
+	if t := ciTemplates[s.CI]; t != nil {
+		if files[ciFiles[s.CI]], err = s.render(t); err != nil {
+			return err
+		}
+	}
+	order := []string{initConfigFile}
+	if s.CI != "none" {
+		order = append(order, ciFiles[s.CI])
+	}
+	// What is written must be a configuration run accepts
+	var cfg config.Config
+	if err := yaml.Unmarshal(files[initConfigFile], &cfg); err != nil {
+		return fmt.Errorf("generated configuration is invalid: %w", err)
+	}
+	if err := cfg.Validate(); err != nil {
+		return fmt.Errorf("generated configuration is invalid: %w", err)
+	}
+	if !force {
+		for _, name := range order {
+			if _, err := os.Stat(filepath.Join(dir, name)); err == nil {
+				return fmt.Errorf("%s already exists; use --force to overwrite it", filepath.Join(dir, name))
+			}
+		}
+	}
+
+	written := make([]string, 0, len(order)+2)
+	for _, name := range order {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			return err
+		}
+		if err := os.WriteFile(path, files[name], 0644); err != nil {
+			return fmt.Errorf("failed to write %s: %w", path, err)
+		}
+		written = append(written, path)
+	}
+	if err := os.MkdirAll(filepath.Join(dir, "output"), 0755); err != nil {
+		return err
+	}
+	ignored, err := ignoreOutput(dir)
+	if err != nil {
+		return err
+	}
+	if ignored != "" {
+		written = append(written, ignored)
+	}
+
+	configPath := filepath.Join(dir, initConfigFile)
+	if jsonOutput(cmd) {
+		return printJSON(cmd, initOutcome{Dir: dir, Config: configPath, Files: written, Starter: s})
+	}
+	out := cmd.OutOrStdout()
+	fmt.Fprintln(out)
+	for _, path := range written {
+		fmt.Fprintf(out, "Wrote %s\n", path)
+	}
+	fmt.Fprintf(out, "\nNext steps:\n  panoptic validate %s\n  panoptic run %s\n", configPath, configPath)
+	if s.Has(checkVisual) {
+		fmt.Fprintf(out, "  panoptic baseline update %s --file %s\n",
+			filepath.Join(dir, "output", "results.json"), filepath.Join(dir, "baseline.json"))
+		fmt.Fprintln(out, "and commit baseline.json, which CI compares the screens of every run with.")
+	}
+	return nil
+}
+
+// ignoreOutput adds output/ to the .gitignore of dir, returning the path
+// of the .gitignore when it changed it
+func ignoreOutput(dir string) (string, error) {
+	path := filepath.Join(dir, ".gitignore")
+	data, err := os.ReadFile(path)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return "", err
+	}
+	for _, line := range strings.Split(string(data), "\n") {
+		switch strings.TrimSpace(line) {
+		case "output", "output/", "/output", "/output/":
+			return "", nil
+		}
+	}
+	if len(data) > 0 && !strings.HasSuffix(string(data), "\n") {
+		data = append(data, '\n')
+	}
+	data = append(data, "output/\n"...)
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return "", fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	return path, nil
+}
+
+// addInitFlags adds the answers init can be given as flags
+func addInitFlags(c *cobra.Command) {
+	c.Flags().String("name", "", "project and app name (default the directory name)")
+	c.Flags().String("type", "", "app type: web, desktop or mobile")
+	c.Flags().String("url", "", "URL of a web app")
+	c.Flags().String("path", "", "executable of a desktop app")
+	c.Flags().String("platform", "", "platform of a desktop (linux, macos, windows) or mobile (android, ios) app")
+	c.Flags().String("checks", "", "comma-separated checks: screenshots, video, a11y, visual, or none")
+	c.Flags().String("ci", "", "CI system to write a pipeline for: github, gitlab, jenkins or none")
+	c.Flags().BoolP("yes", "y", false, "take the default of every answer not given as a flag instead of asking")
+	c.Flags().Bool("force", false, "overwrite an existing configuration and pipeline")
+}
+
+func init() {
+	addInitFlags(initCmd)
+	rootCmd.AddCommand(initCmd)
+}
@@ -0,0 +1,105 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"panoptic/internal/config"
+
+	"github.com/spf13/cobra"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func initTestCmd(t *testing.T, input string, asJSON bool, flags map[string]string) (*cobra.Command, *bytes.Buffer) {
+	t.Helper()
+	cmd := &cobra.Command{Use: "init"}
+	addInitFlags(cmd)
+	cmd.Flags().Bool("json", asJSON, "")
+	for name, value := range flags {
+		require.NoError(t, cmd.Flags().Set(name, value))
+	}
+	out := &bytes.Buffer{}
+	cmd.SetIn(strings.NewReader(input))
+	cmd.SetOut(out)
+	cmd.SetErr(&bytes.Buffer{})
+	return cmd, out
+}
+
+func TestRunInit(t *testing.T) {
+	t.Run("interactive web app", func(t *testing.T) {
+		dir := filepath.Join(t.TempDir(), "shop")
+		// Default name, an invalid then a valid type, a URL, checks and CI
+		cmd, out := initTestCmd(t, "\nkiosk\nweb\nhttps://shop.example.com\nscreenshots,a11y,visual,video\n\n", false, nil)
+		require.NoError(t, runInit(cmd, []string{dir}))
+		assert.Contains(t, out.String(), "Project name [shop]: ")
+		assert.Contains(t, out.String(), "  want web, desktop, mobile\n")
+		assert.Contains(t, out.String(), "Wrote "+filepath.Join(dir, ".github", "workflows", "panoptic.yml"))
+		assert.Contains(t, out.String(), "panoptic baseline update")
+
+		cfg, err := config.Load(filepath.Join(dir, "panoptic.yaml"))
+		require.NoError(t, err)
+		require.NoError(t, cfg.Validate())
+		assert.Equal(t, "shop", cfg.Name)
+		require.Len(t, cfg.Apps, 1)
+		assert.Equal(t, "https://shop.example.com", cfg.Apps[0].URL)
+		types := make([]string, 0)
+		for _, a := range cfg.Actions {
+			types = append(types, a.Type)
+		}
+		assert.Equal(t, []string{"navigate", "wait", "screenshot", "vision_contrast_check", "record"}, types)
+		_, err = cfg.Actions[3].ContrastCheck()
+		assert.NoError(t, err)
+
+		workflow, err := os.ReadFile(filepath.Join(dir, ".github", "workflows", "panoptic.yml"))
+		require.NoError(t, err)
+		assert.Contains(t, string(workflow), "panoptic run panoptic.yaml --github")
+		assert.Contains(t, string(workflow), "panoptic baseline check output/results.json --file baseline.json")
+		assert.DirExists(t, filepath.Join(dir, "output"))
+		ignore, err := os.ReadFile(filepath.Join(dir, ".gitignore"))
+		require.NoError(t, err)
+		assert.Equal(t, "output/\n", string(ignore))
+
+		cmd, _ = initTestCmd(t, "", false, map[string]string{"yes": "true"})
+		assert.EqualError(t, runInit(cmd, []string{dir}), filepath.Join(dir, "panoptic.yaml")+" already exists; use --force to overwrite it")
+	})
+
+	t.Run("flags and defaults", func(t *testing.T) {
+		dir := t.TempDir()
+		require.NoError(t, os.WriteFile(filepath.Join(dir, ".gitignore"), []byte("bin"), 0644))
+		cmd, out := initTestCmd(t, "", true, map[string]string{"yes": "true", "type": "mobile", "platform": "ios", "checks": "none", "ci": "gitlab"})
+		require.NoError(t, runInit(cmd, []string{dir}))
+		var outcome initOutcome
+		require.NoError(t, json.Unmarshal(out.Bytes(), &outcome))
+		assert.Equal(t, starter{Name: filepath.Base(dir), Type: "mobile", Platform: "ios", Checks: []string{}, CI: "gitlab"}, outcome.Starter)
+		assert.Equal(t, []string{filepath.Join(dir, "panoptic.yaml"), filepath.Join(dir, ".gitlab-ci.yml"), filepath.Join(dir, ".gitignore")}, outcome.Files)
+
+		cfg, err := config.Load(outcome.Config)
+		require.NoError(t, err)
+		assert.True(t, cfg.Apps[0].Emulator)
+		require.Len(t, cfg.Actions, 1)
+		ignore, _ := os.ReadFile(filepath.Join(dir, ".gitignore"))
+		assert.Equal(t, "bin\noutput/\n", string(ignore))
+		pipeline, _ := os.ReadFile(filepath.Join(dir, ".gitlab-ci.yml"))
+		assert.NotContains(t, string(pipeline), "baseline")
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		dir := t.TempDir()
+		cmd, _ := initTestCmd(t, "", false, map[string]string{"yes": "true", "type": "desktop"})
+		assert.EqualError(t, runInit(cmd, []string{dir}), "--path: the path of the app is required")
+		cmd, _ = initTestCmd(t, "", false, map[string]string{"url": "shop.example.com"})
+		assert.EqualError(t, runInit(cmd, []string{dir}), "--url: want an http:// or https:// URL")
+		cmd, _ = initTestCmd(t, "", false, map[string]string{"yes": "true", "checks": "speed"})
+		assert.EqualError(t, runInit(cmd, []string{dir}), `--checks: unknown check "speed": screenshots, video, a11y, visual or none`)
+		// Input ending before a required answer
+		cmd, _ = initTestCmd(t, "\ndesktop\n", false, nil)
+		assert.EqualError(t, runInit(cmd, []string{dir}), "Path of the app executable: the path of the app is required")
+		assert.NoFileExists(t, filepath.Join(dir, "panoptic.yaml"))
+	})
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"gopkg.in/yaml.v3"
+
+	"panoptic/internal/launcher"
+)
+
+// runGenerate generates icons and splash screens from a master image, as
+// the manifest given or the default Assets layout describes
+func runGenerate(args []string) int {
+	flags := flag.NewFlagSet("generate", flag.ExitOnError)
+	manifestPath := flags.String("manifest", "", "YAML or JSON manifest of the files to generate (default: the Assets layout)")
+	source := flags.String("source", "", "Master PNG, JPEG or SVG image, overriding the manifest's (default Assets/Logo.jpeg)")
+	darkSource := flags.String("dark-source", "", "Master of the dark mode icon variants")
+	lightSource := flags.String("light-source", "", "Master of the light taskbar icon variants")
+	out := flags.String("out", "Assets", "Directory the manifest's paths are relative to")
+	printManifest := flags.Bool("print-manifest", false, "Print the default manifest to start a custom one from, and exit")
+	flags.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: %s generate [options]\n\n", os.Args[0])
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+
+	manifest := launcher.DefaultIconManifest("Assets/Logo.jpeg")
+	if *printManifest {
+		data, err := yaml.Marshal(manifest)
+		if err != nil {
+			fmt.Printf("❌ Error: %v\n", err)
+			return 1
+		}
+		os.Stdout.Write(data)
+		return 0
+	}
+	if *manifestPath != "" {
+		loaded, err := launcher.LoadIconManifest(*manifestPath)
+		if err != nil {
+			fmt.Printf("❌ Error: %v\n", err)
+			return 1
+		}
+		manifest = loaded
+	}
+	if *source != "" {
+		manifest.Source = *source
+	}
+	if *darkSource != "" {
+		manifest.DarkSource = *darkSource
+	}
+	if *lightSource != "" {
+		manifest.LightSource = *lightSource
+	}
+
+	fmt.Printf("🎨 Generating icons and splash screens from %s...\n", manifest.Source)
+	generated, err := launcher.GenerateIcons(manifest, *out)
+	if err != nil {
+		fmt.Printf("❌ Error generating icons: %v\n", err)
+		return 1
+	}
+	for _, g := range generated {
+		if g.Width > 0 {
+			fmt.Printf("   %s (%dx%d)\n", g.Path, g.Width, g.Height)
+		} else {
+			fmt.Printf("   %s\n", g.Path)
+		}
+	}
+	fmt.Printf("✅ Generated %d files in %s\n", len(generated), *out)
+	return 0
+}
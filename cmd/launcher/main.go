@@ -1,6 +1,7 @@
 package main
 
 import (
+	"encoding/json"
 	"flag"
 	"fmt"
 	"os"
@@ -9,13 +10,25 @@ import (
 )
 
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == "generate" {
+		os.Exit(runGenerate(os.Args[2:]))
+	}
+
 	var (
 		iconDir   = flag.String("icons", "Assets/icons", "Directory containing icons")
 		iconFile  = flag.String("icon", "", "Specific icon file to display")
 		splash    = flag.String("splash", "", "Splash screen file to display")
 		list      = flag.Bool("list", false, "List available icons")
-		info      = flag.Bool("info", false, "Show launcher information")
+		info      = flag.Bool("info", false, "Show launcher information and capabilities")
+		jsonOut   = flag.Bool("json", false, "Print --info as JSON")
 		platform  = flag.String("platform", "", "Override platform detection")
+		theme     = flag.String("appearance", "", "Override the light or dark appearance icon variants are chosen for")
+		appID     = flag.String("app-id", "panoptic", "Desktop entry and icon name the icon is installed under")
+		appName   = flag.String("name", "", "Application name of a new Linux desktop entry")
+		appExec   = flag.String("exec", "", "Command a new Linux desktop entry runs, or the Windows executable to embed the icon in")
+		bundle    = flag.String("bundle", "", "macOS .app bundle whose icon is set")
+		splashFor = flag.Duration("splash-timeout", launcher.DefaultSplashOptions.Timeout, "How long the splash screen stays open")
+		fade      = flag.Duration("splash-fade", launcher.DefaultSplashOptions.FadeIn, "How long the splash screen fades in and out")
 	)
 	
 	flag.Parse()
@@ -29,11 +42,26 @@ func main() {
 	
 	// Create launcher instance
 	lnchr := launcher.NewLauncher(*iconDir)
+	lnchr.SetTarget(launcher.IconTarget{AppID: *appID, Name: *appName, Exec: *appExec, Bundle: *bundle})
+	if err := lnchr.SetSplashOptions(launcher.SplashOptions{FadeIn: *fade, FadeOut: *fade, Timeout: *splashFor}); err != nil {
+		fmt.Printf("❌ Error: %v\n", err)
+		os.Exit(1)
+	}
+	
+	if err := lnchr.SetAppearance(*theme); err != nil {
+		fmt.Printf("❌ Error: %v\n", err)
+		os.Exit(1)
+	}
 	
 	// Override platform if specified
 	if *platform != "" {
-		fmt.Printf("📱 Using platform override: %s\n", *platform)
-		// Note: In a real implementation, you'd set the platform on the launcher
+		if err := lnchr.SetPlatform(*platform); err != nil {
+			fmt.Fprintf(os.Stderr, "❌ Error: %v\n", err)
+			os.Exit(1)
+		}
+		if !*jsonOut {
+			fmt.Printf("📱 Using platform override: %s\n", lnchr.GetPlatform())
+		}
 	}
 	
 	// Handle different commands
@@ -57,10 +85,32 @@ func main() {
 			os.Exit(1)
 		}
 		
+		if *jsonOut {
+			data, err := json.MarshalIndent(info, "", "  ")
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "❌ Error: %v\n", err)
+				os.Exit(1)
+			}
+			fmt.Println(string(data))
+			break
+		}
+
 		fmt.Printf("🎯 Launcher Information:\n")
 		fmt.Printf("   Platform: %s\n", info.Platform)
+		fmt.Printf("   Appearance: %s\n", info.Appearance)
 		fmt.Printf("   Default Icon: %s\n", info.IconPath)
 		fmt.Printf("   Available Icons: %d\n", len(info.Available))
+		caps := info.Capabilities
+		for _, c := range []struct {
+			name, key string
+			ok        bool
+		}{{"App icon", "app_icon", caps.AppIcon}, {"Splash screen", "splash", caps.Splash}, {"Tray icon", "tray_icon", caps.TrayIcon}} {
+			if c.ok {
+				fmt.Printf("   %s: ✅\n", c.name)
+			} else {
+				fmt.Printf("   %s: ❌ %s\n", c.name, caps.Missing[c.key])
+			}
+		}
 		
 	case *splash != "":
 		err := lnchr.ShowSplashScreen(*splash)
@@ -68,7 +118,7 @@ func main() {
 			fmt.Printf("❌ Error displaying splash screen: %v\n", err)
 			os.Exit(1)
 		}
-		fmt.Printf("✅ Splash screen displayed successfully\n")
+		fmt.Printf("✅ Splash screen shown for %s\n", *splashFor)
 		
 	case *iconFile != "":
 		err := lnchr.SetIcon(*iconFile)
@@ -83,7 +133,7 @@ func main() {
 			os.Exit(1)
 		}
 		
-		fmt.Printf("✅ Icon displayed successfully: %s\n", *iconFile)
+		fmt.Printf("✅ Icon %s installed: %s\n", *iconFile, lnchr.InstalledPath())
 		
 	default:
 		// Default action: display the platform-specific icon
@@ -99,7 +149,7 @@ func main() {
 			os.Exit(1)
 		}
 		
-		fmt.Printf("✅ Default icon displayed successfully: %s\n", iconPath)
+		fmt.Printf("✅ Default icon %s installed: %s\n", iconPath, lnchr.InstalledPath())
 		
 		// Also show splash screen
 		err = lnchr.ShowSplashScreen("")
@@ -119,9 +169,13 @@ func init() {
 		fmt.Fprintf(os.Stderr, "Options:\n")
 		flag.PrintDefaults()
 		fmt.Fprintf(os.Stderr, "\nExamples:\n")
-		fmt.Fprintf(os.Stderr, "  %s                    # Display default icon\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "  %s                    # Install the default icon as the application icon\n", os.Args[0])
 		fmt.Fprintf(os.Stderr, "  %s --list            # List available icons\n", os.Args[0])
-		fmt.Fprintf(os.Stderr, "  %s --icon web/icon.png  # Display specific icon\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "  %s --icon web/icon.png  # Install a specific icon\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "  %s --bundle /Applications/Panoptic.app  # Set a macOS bundle's icon\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "  %s --exec panoptic.exe  # Embed the icon in a Windows executable\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "  %s --info --json --platform windows  # What the launcher can do for Windows here\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "  %s generate            # Generate every icon and splash screen from Assets/Logo.jpeg\n", os.Args[0])
 		fmt.Fprintf(os.Stderr, "  %s --splash splash/android/portrait/xxxhdpi/splash_xxxhdpi_portrait.png\n", os.Args[0])
 		fmt.Fprintf(os.Stderr, "  %s --info             # Show launcher information\n", os.Args[0])
 		fmt.Fprintf(os.Stderr, "  %s --icons ./custom_icons  # Use custom icon directory\n", os.Args[0])
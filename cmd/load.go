@@ -0,0 +1,125 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"syscall"
+	"text/tabwriter"
+	"time"
+
+	"panoptic/internal/config"
+	"panoptic/internal/executor"
+	"panoptic/pkg/i18n"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+var loadCmd = &cobra.Command{
+	Use:   "load <config-file>",
+	Short: i18n.T("panoptic_cmd_load_short"),
+	Long: `Replay the actions of every web app of a configuration with --users virtual
+users at once, each in a headless browser of its own, and report the
+latency distribution and error rate of every action.
+
+Each user replays the actions --iterations times, or until --duration has
+passed. --ramp-up spreads the start of the users over a time, so the load
+builds up gradually. Record actions are left out and no video is kept.
+
+The results are written to load.json and load.html in the output
+directory, apart from the functional results of panoptic run.`,
+	Args:              cobra.ExactArgs(1),
+	ValidArgsFunction: completeFiles("yaml", "yml"),
+	SilenceUsage:      true,
+	RunE:              runLoad,
+}
+
+func runLoad(cmd *cobra.Command, args []string) error {
+	var opts executor.LoadOptions
+	opts.Users, _ = cmd.Flags().GetInt("users")
+	opts.Iterations, _ = cmd.Flags().GetInt("iterations")
+	opts.Duration, _ = cmd.Flags().GetDuration("duration")
+	opts.RampUp, _ = cmd.Flags().GetDuration("ramp-up")
+	// A duration alone replays until it passes
+	if opts.Duration > 0 && !cmd.Flags().Changed("iterations") {
+		opts.Iterations = 0
+	}
+	if err := opts.Validate(); err != nil {
+		return err
+	}
+
+	cfg, err := config.Load(args[0])
+	if err != nil {
+		return fmt.Errorf("failed to load configuration: %w", err)
+	}
+	outputDir := viper.GetString("output")
+	if cfg.Output != "" {
+		outputDir = cfg.Output
+	}
+	if err := os.MkdirAll(outputDir, 0755); err != nil {
+		return fmt.Errorf("failed to create output directory: %w", err)
+	}
+
+	log := commandLogger(cmd)
+	exec := executor.NewExecutor(cfg, outputDir, log)
+	if tags, _ := cmd.Flags().GetString("tags"); tags != "" {
+		filter, err := config.ParseTagFilter(tags)
+		if err != nil {
+			return fmt.Errorf("invalid --tags: %w", err)
+		}
+		exec.SetTagFilter(filter)
+	}
+
+	ctx, stop := signal.NotifyContext(cmd.Context(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+	report, runErr := exec.RunLoad(ctx, opts)
+	if report == nil {
+		return runErr
+	}
+	if err := executor.WriteLoadReport(outputDir, report); err != nil {
+		return err
+	}
+	if runErr != nil {
+		return fmt.Errorf("load run stopped: %w", runErr)
+	}
+
+	if jsonOutput(cmd) {
+		return printJSON(cmd, report)
+	}
+	out := cmd.OutOrStdout()
+	for _, app := range report.Apps {
+		fmt.Fprintf(out, "%s: %d replay(s) by %d user(s) in %s, %.2f/s, %d failed (%.1f%%)\n",
+			app.App, app.Replays, app.Users, (time.Duration(app.DurationMs) * time.Millisecond).Round(time.Millisecond),
+			app.ReplaysPerSecond, app.FailedReplays, app.ErrorRate*100)
+		w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "  ACTION\tTYPE\tSAMPLES\tERRORS\tP50 MS\tP90 MS\tP95 MS\tP99 MS\tMAX MS")
+		for _, a := range app.Actions {
+			fmt.Fprintf(w, "  %s\t%s\t%d\t%d\t%.0f\t%.0f\t%.0f\t%.0f\t%.0f\n", a.Action, a.Type, a.Samples, a.Errors, a.P50Ms, a.P90Ms, a.P95Ms, a.P99Ms, a.MaxMs)
+		}
+		if err := w.Flush(); err != nil {
+			return err
+		}
+		for _, e := range app.Errors {
+			fmt.Fprintf(out, "  %dx %s\n", e.Count, e.Error)
+		}
+		fmt.Fprintln(out)
+	}
+	fmt.Fprintf(out, "Load report: %s\n", filepath.Join(outputDir, executor.LoadReportFile))
+	return nil
+}
+
+// addLoadFlags adds the flags of load
+func addLoadFlags(c *cobra.Command) {
+	c.Flags().Int("users", 5, "virtual users replaying the actions at once")
+	c.Flags().Int("iterations", 1, "replays per user; given only --duration, users replay until it passes")
+	c.Flags().Duration("duration", 0, "stop starting replays after this long, e.g. 5m")
+	c.Flags().Duration("ramp-up", 0, "start the users evenly over this time, e.g. 30s")
+	c.Flags().String("tags", "", "Replay only apps and actions with these comma-separated tags; prefix a tag with ! to exclude it")
+}
+
+func init() {
+	addLoadFlags(loadCmd)
+	rootCmd.AddCommand(loadCmd)
+}
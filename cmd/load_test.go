@@ -0,0 +1,101 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"panoptic/internal/executor"
+
+	"github.com/spf13/cobra"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// loadTestDriver answers every call of the driver protocol successfully
+const loadTestDriver = `#!/bin/sh
+while read -r line; do
+	id=$(printf '%s' "$line" | sed 's/.*"id":\([0-9]*\).*/\1/')
+	echo '{"jsonrpc":"2.0","id":'$id',"result":{"name":"sh","protocol_version":1,"capabilities":["click"]}}'
+done
+`
+
+func loadTestCmd(t *testing.T, asJSON bool, flags map[string]string) (*cobra.Command, *bytes.Buffer) {
+	t.Helper()
+	cmd := &cobra.Command{Use: "load"}
+	addLoadFlags(cmd)
+	cmd.Flags().Bool("json", asJSON, "")
+	for name, value := range flags {
+		require.NoError(t, cmd.Flags().Set(name, value))
+	}
+	cmd.SetContext(context.Background())
+	out := &bytes.Buffer{}
+	cmd.SetOut(out)
+	cmd.SetErr(&bytes.Buffer{})
+	return cmd, out
+}
+
+// loadTestConfig writes a configuration of a driver app to load test
+func loadTestConfig(t *testing.T) (path, outputDir string) {
+	dir := t.TempDir()
+	driver := filepath.Join(dir, "driver")
+	require.NoError(t, os.WriteFile(driver, []byte(loadTestDriver), 0755))
+	outputDir = filepath.Join(dir, "output")
+	path = filepath.Join(dir, "kiosk.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(`name: kiosk
+output: "`+outputDir+`"
+apps:
+  - name: Kiosk
+    type: driver
+    driver:
+      command: "`+driver+`"
+    actions:
+      - name: tap
+        type: click
+        selector: "#start"
+        tags: [smoke]
+      - name: tap_again
+        type: click
+        selector: "#start"
+`), 0644))
+	return path, outputDir
+}
+
+func TestRunLoad(t *testing.T) {
+	path, outputDir := loadTestConfig(t)
+	cmd, out := loadTestCmd(t, false, map[string]string{"users": "2", "iterations": "3"})
+	require.NoError(t, runLoad(cmd, []string{path}))
+	assert.Contains(t, out.String(), "Kiosk: 6 replay(s) by 2 user(s)")
+	assert.Contains(t, out.String(), "0 failed (0.0%)")
+	assert.Contains(t, out.String(), "  tap_again  click")
+	assert.Contains(t, out.String(), "Load report: "+filepath.Join(outputDir, executor.LoadReportFile))
+	assert.FileExists(t, filepath.Join(outputDir, executor.LoadResultsFile))
+
+	cmd, out = loadTestCmd(t, true, map[string]string{"users": "1", "tags": "smoke"})
+	require.NoError(t, runLoad(cmd, []string{path}))
+	var report executor.LoadReport
+	require.NoError(t, json.Unmarshal(out.Bytes(), &report))
+	require.Len(t, report.Apps, 1)
+	assert.Equal(t, 1, report.Apps[0].Replays)
+	require.Len(t, report.Apps[0].Actions, 1)
+	assert.Equal(t, "tap", report.Apps[0].Actions[0].Action)
+}
+
+func TestRunLoad_Errors(t *testing.T) {
+	path, _ := loadTestConfig(t)
+	cmd, _ := loadTestCmd(t, false, map[string]string{"users": "0"})
+	assert.EqualError(t, runLoad(cmd, []string{path}), "a load run needs at least one user, got 0")
+
+	cmd, _ = loadTestCmd(t, false, map[string]string{"iterations": "0"})
+	assert.EqualError(t, runLoad(cmd, []string{path}), "a load run needs iterations or a duration to end")
+
+	cmd, _ = loadTestCmd(t, false, map[string]string{"tags": "!"})
+	assert.ErrorContains(t, runLoad(cmd, []string{path}), "invalid --tags")
+
+	cmd, _ = loadTestCmd(t, false, nil)
+	assert.ErrorContains(t, runLoad(cmd, []string{filepath.Join(t.TempDir(), "missing.yaml")}), "failed to load configuration")
+}
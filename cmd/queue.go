@@ -0,0 +1,237 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"panoptic/internal/enterprise"
+	"panoptic/internal/logger"
+	"panoptic/pkg/i18n"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+var enterpriseQueueWorkCmd = &cobra.Command{
+	Use:   "queue-work",
+	Short: i18n.T("panoptic_cmd_enterprise_queue_work_short"),
+	Long: `Pull runs from the enterprise run queue, highest priority first, and
+run each with panoptic run, sending heartbeats with its last output line
+until it finishes. A run cancelled while running is stopped. Interrupting
+the worker queues its run again.`,
+	Args:         cobra.NoArgs,
+	SilenceUsage: true,
+	RunE:         runQueueWork,
+}
+
+// queueWorker pulls runs from the run queue and runs them one at a time
+type queueWorker struct {
+	queue     *enterprise.RunQueueManagement
+	id        string
+	output    string        // the output directory; each run writes to queue/<run ID> in it
+	poll      time.Duration // how often to look for a run while the queue is empty
+	heartbeat time.Duration
+	once      bool // stop once the queue is empty
+	stdout    io.Writer
+	stderr    io.Writer
+	log       *logger.Logger
+	busy      sync.Mutex // held while claiming and running a run, so updates wait for idle moments
+}
+
+func runQueueWork(cmd *cobra.Command, args []string) error {
+	configPath, _ := cmd.Flags().GetString("enterprise-config")
+	integration, err := initEnterprise(cmd, configPath)
+	if err != nil {
+		return err
+	}
+	worker, err := queueWorkerFromFlags(cmd, integration)
+	if err != nil {
+		return err
+	}
+	worker.once, _ = cmd.Flags().GetBool("once")
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+	worker.log.Infof("Worker %s pulling runs from the run queue (every %s)", worker.id, worker.poll)
+	return worker.work(ctx)
+}
+
+// queueWorkerFromFlags builds a worker of the run queue from the flags of
+// queue-work or agent
+func queueWorkerFromFlags(cmd *cobra.Command, integration *enterprise.EnterpriseIntegration) (*queueWorker, error) {
+	id, _ := cmd.Flags().GetString("worker")
+	if id == "" {
+		if id, _ = cmd.Flags().GetString("id"); id == "" {
+			hostname, err := os.Hostname()
+			if err != nil {
+				return nil, fmt.Errorf("--worker flag is required: %w", err)
+			}
+			id = hostname
+		}
+	}
+	poll, _ := cmd.Flags().GetDuration("poll")
+	heartbeat, _ := cmd.Flags().GetDuration("queue-heartbeat")
+	if poll <= 0 || heartbeat <= 0 {
+		return nil, fmt.Errorf("--poll and --queue-heartbeat must be positive")
+	}
+	return &queueWorker{
+		queue:     integration.RunQueueManagement,
+		id:        id,
+		output:    viper.GetString("output"),
+		poll:      poll,
+		heartbeat: heartbeat,
+		stdout:    cmd.OutOrStdout(),
+		stderr:    cmd.ErrOrStderr(),
+		log:       commandLogger(cmd),
+	}, nil
+}
+
+// work claims and runs queued runs until ctx is done, or with once set
+// until there's none left to claim
+func (w *queueWorker) work(ctx context.Context) error {
+	for {
+		w.busy.Lock()
+		run, err := w.queue.ClaimRun(ctx, w.id)
+		if err != nil {
+			w.busy.Unlock()
+			return fmt.Errorf("failed to claim a queued run: %w", err)
+		}
+		if run != nil {
+			err := w.runQueued(ctx, run)
+			w.busy.Unlock()
+			if err != nil {
+				return err
+			}
+			continue
+		}
+		w.busy.Unlock()
+		if w.once {
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(w.poll):
+		}
+	}
+}
+
+// runQueued runs a claimed run and reports how it finished
+func (w *queueWorker) runQueued(ctx context.Context, run *enterprise.QueuedRun) error {
+	dir, err := os.MkdirTemp("", "panoptic-queue-")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(dir)
+	name := filepath.Base(run.Config)
+	if name == "." || name == string(filepath.Separator) {
+		name = "config.yaml"
+	}
+	configFile := filepath.Join(dir, name)
+	if err := os.WriteFile(configFile, []byte(run.ConfigData), 0600); err != nil {
+		return err
+	}
+
+	runCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	progress := &lastLine{}
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		ticker := time.NewTicker(w.heartbeat)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-runCtx.Done():
+				return
+			case <-ticker.C:
+			}
+			entry, err := w.queue.HeartbeatRun(ctx, enterprise.HeartbeatRunRequest{ID: run.ID, Worker: w.id, Progress: progress.String()})
+			if err != nil {
+				w.log.Warnf("Stopping queued run %s: %v", run.ID, err)
+				cancel()
+				return
+			}
+			if entry.CancelRequested {
+				w.log.Infof("Queued run %s was cancelled; stopping it", run.ID)
+				cancel()
+				return
+			}
+		}
+	}()
+
+	w.log.Infof("Running queued run %s of %s for project %s", run.ID, run.Config, run.ProjectID)
+	args := []string{"run", configFile,
+		"--output", filepath.Join(w.output, "queue", run.ID),
+		"--project", run.ProjectID,
+		"--run-id", run.ID}
+	runErr := scheduledRun(runCtx, args, io.MultiWriter(w.stdout, progress), io.MultiWriter(w.stderr, progress))
+	cancel()
+	<-done
+
+	req := enterprise.CompleteRunRequest{ID: run.ID, Worker: w.id, Success: runErr == nil, Release: ctx.Err() != nil}
+	if runErr != nil {
+		req.Error = runErr.Error()
+	}
+	entry, err := w.queue.CompleteRun(context.Background(), req)
+	if err != nil {
+		// Queued again by now, its heartbeats having stopped
+		w.log.Warnf("Failed to complete queued run %s: %v", run.ID, err)
+		return nil
+	}
+	fmt.Fprintf(w.stdout, "Queued run %s %s\n", entry.ID, entry.Status)
+	return nil
+}
+
+// lastLine keeps the last non-empty line written to it, the progress a
+// worker reports
+type lastLine struct {
+	mu      sync.Mutex
+	line    string
+	partial []byte
+}
+
+func (l *lastLine) Write(p []byte) (int, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.partial = append(l.partial, p...)
+	for {
+		i := bytes.IndexByte(l.partial, '\n')
+		if i < 0 {
+			break
+		}
+		if line := strings.TrimSpace(string(l.partial[:i])); line != "" {
+			l.line = line
+		}
+		l.partial = l.partial[i+1:]
+	}
+	return len(p), nil
+}
+
+func (l *lastLine) String() string {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.line
+}
+
+// addQueueWorkerFlags defines the flags of a worker of the run queue
+func addQueueWorkerFlags(c *cobra.Command) {
+	c.Flags().Duration("poll", 5*time.Second, "how often to look for a queued run while the queue is empty")
+	c.Flags().Duration("queue-heartbeat", 15*time.Second, "how often to report the progress of a queued run")
+}
+
+func init() {
+	enterpriseQueueWorkCmd.Flags().String("worker", "", "worker ID shown on the runs it claims (default the host name)")
+	enterpriseQueueWorkCmd.Flags().Bool("once", false, "stop once the queue is empty")
+	addQueueWorkerFlags(enterpriseQueueWorkCmd)
+	enterpriseCmd.AddCommand(enterpriseQueueWorkCmd)
+}
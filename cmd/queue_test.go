@@ -0,0 +1,119 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"panoptic/internal/enterprise"
+	"panoptic/internal/logger"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// queueTestWorker is a worker of a new enterprise storage with a checkout
+// project, heartbeating every few milliseconds
+func queueTestWorker(t *testing.T) (*queueWorker, *bytes.Buffer) {
+	t.Helper()
+	dir := t.TempDir()
+	configPath := filepath.Join(dir, "enterprise.yaml")
+	require.NoError(t, os.WriteFile(configPath, []byte("enabled: true\nstorage_path: "+filepath.Join(dir, "data")+"\n"), 0644))
+	cmd, _ := enterpriseTestCmd(configPath, false)
+	integration, err := initEnterprise(cmd, configPath)
+	require.NoError(t, err)
+	_, err = integration.ExecuteEnterpriseAction(context.Background(), "project_create", map[string]interface{}{
+		"name": "checkout", "owner_id": integration.Manager.Users["admin"].ID})
+	require.NoError(t, err)
+
+	out := &bytes.Buffer{}
+	return &queueWorker{
+		queue:     integration.RunQueueManagement,
+		id:        "w1",
+		output:    "/tmp/out",
+		poll:      time.Millisecond,
+		heartbeat: 5 * time.Millisecond,
+		once:      true,
+		stdout:    out,
+		stderr:    io.Discard,
+		log:       logger.NewLogger(false),
+	}, out
+}
+
+func TestQueueWorker(t *testing.T) {
+	worker, out := queueTestWorker(t)
+	ctx := context.Background()
+	queued, err := worker.queue.SubmitRun(ctx, enterprise.SubmitRunRequest{
+		Config: "smoke.yaml", ConfigData: "name: smoke\n", Project: "checkout", SubmittedBy: "admin"})
+	require.NoError(t, err)
+
+	original := scheduledRun
+	defer func() { scheduledRun = original }()
+	var args []string
+	var config string
+	scheduledRun = func(ctx context.Context, runArgs []string, stdout, stderr io.Writer) error {
+		args = runArgs
+		data, err := os.ReadFile(runArgs[1])
+		require.NoError(t, err)
+		config = string(data)
+		fmt.Fprintln(stdout, "Running app 1 of 2")
+		fmt.Fprint(stdout, "Running app 2 of 2\n\n")
+		time.Sleep(30 * time.Millisecond)
+		return nil
+	}
+
+	require.NoError(t, worker.work(ctx))
+	require.Len(t, args, 8)
+	assert.Equal(t, "smoke.yaml", filepath.Base(args[1]))
+	assert.Equal(t, []string{"--output", filepath.Join("/tmp/out", "queue", queued.ID), "--project", queued.ProjectID, "--run-id", queued.ID}, args[2:])
+	assert.Equal(t, "name: smoke\n", config)
+	assert.NoFileExists(t, args[1], "the configuration is removed after the run")
+	assert.Contains(t, out.String(), "Queued run "+queued.ID+" succeeded")
+
+	status, err := worker.queue.QueueStatus(ctx, queued.ID)
+	require.NoError(t, err)
+	assert.Equal(t, enterprise.QueuedRunSucceeded, status.Status)
+	assert.Equal(t, "w1", status.Worker)
+	assert.Equal(t, "Running app 2 of 2", status.Progress, "heartbeats report the last line of output")
+}
+
+func TestQueueWorker_Cancel(t *testing.T) {
+	worker, _ := queueTestWorker(t)
+	ctx := context.Background()
+	queued, err := worker.queue.SubmitRun(ctx, enterprise.SubmitRunRequest{
+		ConfigData: "name: soak\n", Project: "checkout", SubmittedBy: "admin"})
+	require.NoError(t, err)
+
+	original := scheduledRun
+	defer func() { scheduledRun = original }()
+	scheduledRun = func(runCtx context.Context, args []string, stdout, stderr io.Writer) error {
+		assert.Equal(t, "config.yaml", filepath.Base(args[1]))
+		_, err := worker.queue.CancelRun(ctx, enterprise.CancelRunRequest{ID: queued.ID, CancelledBy: "admin"})
+		require.NoError(t, err)
+		select {
+		case <-runCtx.Done():
+			return runCtx.Err()
+		case <-time.After(5 * time.Second):
+			return nil
+		}
+	}
+
+	require.NoError(t, worker.work(ctx))
+	status, err := worker.queue.QueueStatus(ctx, queued.ID)
+	require.NoError(t, err)
+	assert.Equal(t, enterprise.QueuedRunCancelled, status.Status)
+	assert.Equal(t, "context canceled", status.Error)
+}
+
+func TestLastLine(t *testing.T) {
+	progress := &lastLine{}
+	fmt.Fprint(progress, "one\ntw")
+	assert.Equal(t, "one", progress.String())
+	fmt.Fprint(progress, "o\n  \n")
+	assert.Equal(t, "two", progress.String())
+}
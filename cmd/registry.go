@@ -0,0 +1,199 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"panoptic/internal/cloud"
+	"panoptic/internal/logger"
+	"panoptic/pkg/i18n"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+var registryCmd = &cobra.Command{
+	Use:   "registry",
+	Short: i18n.T("panoptic_cmd_registry_short"),
+}
+
+var registryServeCmd = &cobra.Command{
+	Use:   "serve",
+	Short: i18n.T("panoptic_cmd_registry_serve_short"),
+	RunE:  runRegistryServe,
+}
+
+var registryJoinCmd = &cobra.Command{
+	Use:   "join",
+	Short: i18n.T("panoptic_cmd_registry_join_short"),
+	RunE:  runRegistryJoin,
+}
+
+// serveCmd and agentCmd are registry serve and registry join at the top
+// level: the coordinator and the worker of a distributed setup
+var serveCmd = &cobra.Command{
+	Use:   "serve",
+	Short: i18n.T("panoptic_cmd_serve_short"),
+	Args:  cobra.NoArgs,
+	RunE:  runRegistryServe,
+}
+
+var agentCmd = &cobra.Command{
+	Use:   "agent",
+	Short: i18n.T("panoptic_cmd_agent_short"),
+	Args:  cobra.NoArgs,
+	RunE:  runRegistryJoin,
+}
+
+func runRegistryServe(cmd *cobra.Command, args []string) error {
+	addr, _ := cmd.Flags().GetString("addr")
+	apiKey, _ := cmd.Flags().GetString("api-key")
+	staleAfter, _ := cmd.Flags().GetDuration("stale-after")
+	releases, _ := cmd.Flags().GetString("releases")
+
+	log := logger.NewLogger(viper.GetBool("verbose"))
+	registry := cloud.NewNodeRegistry(*log, staleAfter)
+	registry.ReleaseDir = releases
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	registry.StartPruning(ctx, registry.StaleAfter/3)
+
+	server := &http.Server{Addr: addr, Handler: registry.Handler(apiKey)}
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		server.Shutdown(shutdownCtx)
+	}()
+
+	log.Infof("Node registry listening on %s (stale after %s)", addr, registry.StaleAfter)
+	if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+		return fmt.Errorf("registry server failed: %w", err)
+	}
+	return nil
+}
+
+func runRegistryJoin(cmd *cobra.Command, args []string) error {
+	registryURL, _ := cmd.Flags().GetString("registry")
+	if registryURL == "" {
+		return fmt.Errorf("--registry flag is required")
+	}
+	reg, err := registrationFromFlags(cmd)
+	if err != nil {
+		return err
+	}
+	apiKey, _ := cmd.Flags().GetString("api-key")
+	interval, _ := cmd.Flags().GetDuration("interval")
+
+	log := logger.NewLogger(viper.GetBool("verbose"))
+	client := cloud.NewRegistryClient(registryURL, apiKey)
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	// With an enterprise configuration the node also works its run queue
+	var worker *queueWorker
+	if configPath, _ := cmd.Flags().GetString("enterprise-config"); configPath != "" {
+		integration, err := initEnterprise(cmd, configPath)
+		if err != nil {
+			return err
+		}
+		worker, err = queueWorkerFromFlags(cmd, integration)
+		if err != nil {
+			return err
+		}
+		go func() {
+			if err := worker.work(ctx); err != nil {
+				log.Errorf("Run queue worker stopped: %v", err)
+				stop()
+			}
+		}()
+		log.Infof("Node %s pulling runs from the run queue of %s", reg.Node.ID, configPath)
+	}
+
+	updater, err := agentUpdaterFromFlags(cmd, registryURL, apiKey, log)
+	if err != nil {
+		return err
+	}
+	if updater != nil {
+		if worker != nil {
+			updater.idle = &worker.busy
+		}
+		go updater.run(ctx)
+		log.Infof("Node %s updating from %s (window %s)", reg.Node.ID, updater.updater.ManifestURL, updater.window)
+	}
+	client.Status = updater.status
+
+	log.Infof("Joining registry %s as node %s (heartbeat every %s)", registryURL, reg.Node.ID, interval)
+	return client.RunHeartbeat(ctx, reg, interval)
+}
+
+// registrationFromFlags builds a node registration from the join flags
+func registrationFromFlags(cmd *cobra.Command) (cloud.NodeRegistration, error) {
+	id, _ := cmd.Flags().GetString("id")
+	endpoint, _ := cmd.Flags().GetString("endpoint")
+	if id == "" || endpoint == "" {
+		return cloud.NodeRegistration{}, fmt.Errorf("--id and --endpoint flags are required")
+	}
+	name, _ := cmd.Flags().GetString("name")
+	capacity, _ := cmd.Flags().GetString("capacity")
+	priority, _ := cmd.Flags().GetInt("priority")
+	labels, _ := cmd.Flags().GetStringToString("label")
+
+	if name == "" {
+		name = id
+	}
+
+	return cloud.NodeRegistration{
+		Node: cloud.DistributedNode{
+			ID:       id,
+			Name:     name,
+			Location: labels["region"],
+			Capacity: capacity,
+			Endpoint: endpoint,
+			Priority: priority,
+		},
+		Labels: labels,
+	}, nil
+}
+
+// addRegistryJoinFlags defines the flags accepted by registry join
+func addRegistryJoinFlags(c *cobra.Command) {
+	c.Flags().String("registry", "", "base URL of the node registry")
+	c.Flags().String("api-key", "", "bearer token for the registry")
+	c.Flags().String("id", "", "unique node ID")
+	c.Flags().String("name", "", "human-readable node name")
+	c.Flags().String("endpoint", "", "URL where this node accepts work")
+	c.Flags().String("capacity", "medium", "node capacity (low, medium, high or a slot count)")
+	c.Flags().Int("priority", 0, "scheduling priority (lower is preferred)")
+	c.Flags().StringToString("label", nil, "node labels, e.g. --label os=linux,region=eu-west-1,chrome=120")
+	c.Flags().Duration("interval", 30*time.Second, "heartbeat interval")
+	c.Flags().String("enterprise-config", "", "also pull runs from the run queue of this enterprise configuration")
+	addQueueWorkerFlags(c)
+	addAgentUpdateFlags(c)
+}
+
+// addRegistryServeFlags defines the flags accepted by registry serve
+func addRegistryServeFlags(c *cobra.Command) {
+	c.Flags().String("addr", ":8470", "address to listen on")
+	c.Flags().String("api-key", "", "bearer token required from clients")
+	c.Flags().Duration("stale-after", cloud.DefaultNodeStaleAfter, "remove nodes without a heartbeat for this long")
+	c.Flags().String("releases", "", "release directory to serve to agents at /releases/ (see release sign)")
+}
+
+func init() {
+	addRegistryServeFlags(registryServeCmd)
+	addRegistryServeFlags(serveCmd)
+	addRegistryJoinFlags(registryJoinCmd)
+	addRegistryJoinFlags(agentCmd)
+
+	registryCmd.AddCommand(registryServeCmd)
+	registryCmd.AddCommand(registryJoinCmd)
+	rootCmd.AddCommand(registryCmd, serveCmd, agentCmd)
+}
@@ -0,0 +1,38 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/spf13/cobra"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRegistryCmd_Subcommands(t *testing.T) {
+	names := make([]string, 0)
+	for _, c := range registryCmd.Commands() {
+		names = append(names, c.Name())
+	}
+	assert.ElementsMatch(t, []string{"serve", "join"}, names)
+	assert.Equal(t, "panoptic_cmd_registry_short", registryCmd.Short)
+}
+
+func TestRegistrationFromFlags(t *testing.T) {
+	cmd := &cobra.Command{Use: "join"}
+	addRegistryJoinFlags(cmd)
+
+	_, err := registrationFromFlags(cmd)
+	assert.Error(t, err, "id and endpoint are required")
+
+	require.NoError(t, cmd.Flags().Set("id", "agent-7"))
+	require.NoError(t, cmd.Flags().Set("endpoint", "http://10.0.0.7:9000"))
+	require.NoError(t, cmd.Flags().Set("label", "os=linux,region=eu-west-1"))
+
+	reg, err := registrationFromFlags(cmd)
+	require.NoError(t, err)
+	assert.Equal(t, "agent-7", reg.Node.Name, "name defaults to the ID")
+	assert.Equal(t, "eu-west-1", reg.Node.Location)
+	assert.Equal(t, "medium", reg.Node.Capacity)
+	assert.Equal(t, "linux", reg.Labels["os"])
+}
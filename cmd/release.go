@@ -0,0 +1,161 @@
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"time"
+
+	"panoptic/internal/selfupdate"
+	"panoptic/pkg/i18n"
+
+	"github.com/spf13/cobra"
+)
+
+var releaseCmd = &cobra.Command{
+	Use:   "release",
+	Short: i18n.T("panoptic_cmd_release_short"),
+	Long: `Publish agent binaries on a release channel. A channel is a manifest,
+<dir>/<channel>.json, naming a version and a signed binary per platform;
+agents started with --update-channel install its binary when it is newer
+than theirs. Serve the directory with serve --releases or any web server.`,
+}
+
+var releaseKeygenCmd = &cobra.Command{
+	Use:   "keygen",
+	Short: i18n.T("panoptic_cmd_release_keygen_short"),
+	Long: `Generate a release key pair. Give the public key to agents with
+--update-key, and keep the private one in ` + selfupdate.DefaultKeyEnv + ` for release sign.`,
+	Args: cobra.NoArgs,
+	RunE: runReleaseKeygen,
+}
+
+var releaseSignCmd = &cobra.Command{
+	Use:   "sign <binary>",
+	Short: i18n.T("panoptic_cmd_release_sign_short"),
+	Long: `Sign a panoptic binary for a version and platform and add it to the
+manifest of a channel. Without --url the binary is copied into the release
+directory next to the manifest. Signing a new version starts the manifest
+over, so sign the binary of every platform for each version.`,
+	Args:         cobra.ExactArgs(1),
+	SilenceUsage: true,
+	RunE:         runReleaseSign,
+}
+
+func runReleaseKeygen(cmd *cobra.Command, args []string) error {
+	public, private, err := selfupdate.GenerateKey()
+	if err != nil {
+		return fmt.Errorf("failed to generate a release key: %w", err)
+	}
+	if jsonOutput(cmd) {
+		return printJSON(cmd, map[string]string{"public_key": public, "private_key": private})
+	}
+	fmt.Fprintf(cmd.OutOrStdout(), "Public key:  %s\nPrivate key: %s\n", public, private)
+	return nil
+}
+
+func runReleaseSign(cmd *cobra.Command, args []string) error {
+	version, _ := cmd.Flags().GetString("version")
+	channel, _ := cmd.Flags().GetString("channel")
+	dir, _ := cmd.Flags().GetString("dir")
+	goos, _ := cmd.Flags().GetString("os")
+	goarch, _ := cmd.Flags().GetString("arch")
+	location, _ := cmd.Flags().GetString("url")
+	keyEnv, _ := cmd.Flags().GetString("key-env")
+	if version == "" || channel == "" {
+		return fmt.Errorf("--version and --channel flags are required")
+	}
+	encoded := os.Getenv(keyEnv)
+	if encoded == "" {
+		return fmt.Errorf("%s must hold the private release key (see release keygen)", keyEnv)
+	}
+	key, err := selfupdate.ParsePrivateKey(encoded)
+	if err != nil {
+		return err
+	}
+	contents, err := os.ReadFile(args[0])
+	if err != nil {
+		return fmt.Errorf("failed to read binary: %w", err)
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("failed to create release directory: %w", err)
+	}
+
+	if location == "" {
+		location = fmt.Sprintf("panoptic-%s-%s-%s", version, goos, goarch)
+		if goos == "windows" {
+			location += ".exe"
+		}
+		if err := os.WriteFile(filepath.Join(dir, location), contents, 0755); err != nil {
+			return fmt.Errorf("failed to copy binary: %w", err)
+		}
+	}
+
+	manifestPath := filepath.Join(dir, channel+".json")
+	manifest, err := loadManifest(manifestPath)
+	if err != nil {
+		return err
+	}
+	if manifest.Version != version {
+		manifest.Binaries = nil
+	}
+	manifest.Channel = channel
+	manifest.Version = version
+	manifest.Published = time.Now().UTC()
+
+	binary := selfupdate.Binary{OS: goos, Arch: goarch, URL: location}
+	selfupdate.Sign(key, version, &binary, contents)
+	if existing := manifest.Binary(goos, goarch); existing != nil {
+		*existing = binary
+	} else {
+		manifest.Binaries = append(manifest.Binaries, binary)
+	}
+
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(manifestPath, append(data, '\n'), 0644); err != nil {
+		return fmt.Errorf("failed to write manifest: %w", err)
+	}
+	fmt.Fprintf(cmd.OutOrStdout(), "Signed %s %s/%s on channel %s (%s)\n", version, goos, goarch, channel, manifestPath)
+	return nil
+}
+
+// loadManifest reads the manifest of a channel, empty when the channel has
+// none yet
+func loadManifest(path string) (*selfupdate.Manifest, error) {
+	manifest := &selfupdate.Manifest{}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return manifest, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read manifest: %w", err)
+	}
+	if err := json.Unmarshal(data, manifest); err != nil {
+		return nil, fmt.Errorf("invalid manifest %s: %w", path, err)
+	}
+	return manifest, nil
+}
+
+// addReleaseSignFlags defines the flags accepted by release sign
+func addReleaseSignFlags(c *cobra.Command) {
+	c.Flags().String("version", "", "version of the binary, e.g. v1.4.0")
+	c.Flags().String("channel", "stable", "release channel the binary is published on")
+	c.Flags().String("dir", "releases", "release directory holding the channel manifests")
+	c.Flags().String("os", runtime.GOOS, "operating system the binary runs on")
+	c.Flags().String("arch", runtime.GOARCH, "architecture the binary runs on")
+	c.Flags().String("url", "", "where agents download the binary, instead of copying it into the release directory")
+	c.Flags().String("key-env", selfupdate.DefaultKeyEnv, "environment variable holding the private release key")
+}
+
+func init() {
+	addReleaseSignFlags(releaseSignCmd)
+
+	releaseCmd.AddCommand(releaseKeygenCmd, releaseSignCmd)
+	rootCmd.AddCommand(releaseCmd)
+}
@@ -0,0 +1,87 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"panoptic/internal/selfupdate"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func releaseTestCmd(t *testing.T, flags map[string]string) (*cobra.Command, *bytes.Buffer) {
+	t.Helper()
+	cmd := &cobra.Command{Use: "sign"}
+	addReleaseSignFlags(cmd)
+	for name, value := range flags {
+		require.NoError(t, cmd.Flags().Set(name, value))
+	}
+	out := &bytes.Buffer{}
+	cmd.SetOut(out)
+	return cmd, out
+}
+
+// signRelease publishes contents as version on the stable channel of dir
+// for a platform
+func signRelease(t *testing.T, dir, version, goos, goarch, contents string) {
+	t.Helper()
+	binary := filepath.Join(t.TempDir(), "panoptic")
+	require.NoError(t, os.WriteFile(binary, []byte(contents), 0755))
+	cmd, _ := releaseTestCmd(t, map[string]string{"version": version, "dir": dir, "os": goos, "arch": goarch})
+	require.NoError(t, runReleaseSign(cmd, []string{binary}))
+}
+
+func TestRunReleaseSign(t *testing.T) {
+	public, private, err := selfupdate.GenerateKey()
+	require.NoError(t, err)
+	t.Setenv(selfupdate.DefaultKeyEnv, private)
+	key, err := selfupdate.ParsePublicKey(public)
+	require.NoError(t, err)
+	dir := filepath.Join(t.TempDir(), "releases")
+
+	signRelease(t, dir, "v1.2.0", "linux", "amd64", "linux binary")
+	binary := filepath.Join(t.TempDir(), "panoptic")
+	require.NoError(t, os.WriteFile(binary, []byte("mac binary"), 0755))
+	cmd, out := releaseTestCmd(t, map[string]string{"version": "v1.2.0", "dir": dir, "os": "darwin", "arch": "arm64",
+		"url": "https://downloads.example.com/panoptic-darwin-arm64"})
+	require.NoError(t, runReleaseSign(cmd, []string{binary}))
+	assert.Equal(t, "Signed v1.2.0 darwin/arm64 on channel stable ("+filepath.Join(dir, "stable.json")+")\n", out.String())
+
+	manifest, err := loadManifest(filepath.Join(dir, "stable.json"))
+	require.NoError(t, err)
+	assert.Equal(t, "stable", manifest.Channel)
+	require.Len(t, manifest.Binaries, 2)
+	linux := manifest.Binary("linux", "amd64")
+	require.NotNil(t, linux)
+	assert.Equal(t, "panoptic-v1.2.0-linux-amd64", linux.URL, "binaries without --url are copied next to the manifest")
+	contents, err := os.ReadFile(filepath.Join(dir, linux.URL))
+	require.NoError(t, err)
+	assert.NoError(t, selfupdate.Verify(key, "v1.2.0", *linux, contents))
+	assert.NoError(t, selfupdate.Verify(key, "v1.2.0", *manifest.Binary("darwin", "arm64"), []byte("mac binary")))
+
+	signRelease(t, dir, "v1.3.0", "linux", "amd64", "newer linux binary")
+	manifest, err = loadManifest(filepath.Join(dir, "stable.json"))
+	require.NoError(t, err)
+	assert.Equal(t, "v1.3.0", manifest.Version)
+	assert.Len(t, manifest.Binaries, 1, "a new version starts the manifest over")
+
+	t.Setenv(selfupdate.DefaultKeyEnv, "")
+	cmd, _ = releaseTestCmd(t, map[string]string{"version": "v1.3.1", "dir": dir})
+	assert.EqualError(t, runReleaseSign(cmd, []string{binary}), selfupdate.DefaultKeyEnv+" must hold the private release key (see release keygen)")
+}
+
+func TestRunReleaseKeygen(t *testing.T) {
+	cmd, out := enterpriseTestCmd("", true)
+	require.NoError(t, runReleaseKeygen(cmd, nil))
+	var keys map[string]string
+	require.NoError(t, json.Unmarshal(out.Bytes(), &keys))
+	_, err := selfupdate.ParsePublicKey(keys["public_key"])
+	assert.NoError(t, err)
+	_, err = selfupdate.ParsePrivateKey(keys["private_key"])
+	assert.NoError(t, err)
+}
@@ -0,0 +1,204 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
+
+	"panoptic/internal/artifactcrypt"
+	"panoptic/internal/executor"
+	"panoptic/pkg/i18n"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+var reportCmd = &cobra.Command{
+	Use:   "report [results.json]",
+	Short: i18n.T("panoptic_cmd_report_short"),
+	Long: `Regenerate the HTML report of a run from its results.json, for example after
+merging shards or on a machine that only has the uploaded results.
+
+With --pdf, the report is also printed to a paginated PDF next to it, with a
+cover page summarizing the run, to attach where a link won't do. Printing
+launches a headless Chromium.`,
+	Args:              cobra.MaximumNArgs(1),
+	ValidArgsFunction: completeFiles("json"),
+	SilenceUsage:      true,
+	RunE:              runReport,
+}
+
+var reportServeCmd = &cobra.Command{
+	Use:   "serve [output-dir]",
+	Short: i18n.T("panoptic_cmd_report_serve_short"),
+	Long: `Serve the report of a run and its artifacts over HTTP. Artifacts encrypted
+at rest under settings.encryption are decrypted as they're requested with
+the artifact key, read from --key-env or printed by --key-command, and are
+never written to disk decrypted.`,
+	Args:         cobra.MaximumNArgs(1),
+	SilenceUsage: true,
+	RunE:         runReportServe,
+}
+
+var mergeCmd = &cobra.Command{
+	Use:   "merge <results.json>...",
+	Short: i18n.T("panoptic_cmd_merge_short"),
+	Long: `Combine the results.json of runs split across machines or shards into one
+document, summing their summaries, and optionally write its HTML report.`,
+	Args:              cobra.MinimumNArgs(2),
+	ValidArgsFunction: completeFiles("json"),
+	SilenceUsage:      true,
+	RunE:              runMerge,
+}
+
+// reportOutcome is what report and merge print with --json
+type reportOutcome struct {
+	RunID   string              `json:"run_id"`
+	Summary executor.RunSummary `json:"summary"`
+	Results string              `json:"results,omitempty"`
+	Report  string              `json:"report,omitempty"`
+	PDF     string              `json:"pdf,omitempty"`
+	Pages   int                 `json:"pages,omitempty"` // of the PDF
+}
+
+// resultsArg is the results.json given, or the output directory's
+func resultsArg(cmd *cobra.Command, args []string) string {
+	if len(args) > 0 {
+		return args[0]
+	}
+	return filepath.Join(viper.GetString("output"), "results.json")
+}
+
+// printOutcome prints what report or merge wrote
+func printOutcome(cmd *cobra.Command, outcome reportOutcome) error {
+	if jsonOutput(cmd) {
+		return printJSON(cmd, outcome)
+	}
+	s := outcome.Summary
+	fmt.Fprintf(cmd.OutOrStdout(), "%d app(s): %d passed, %d failed, %d warning(s), %d quarantined\n", s.Total, s.Passed, s.Failed, s.Warnings, s.Quarantined)
+	if outcome.Results != "" {
+		fmt.Fprintf(cmd.OutOrStdout(), "Results written: %s\n", outcome.Results)
+	}
+	if outcome.Report != "" {
+		fmt.Fprintf(cmd.OutOrStdout(), "Report generated: %s\n", outcome.Report)
+	}
+	if outcome.PDF != "" {
+		fmt.Fprintf(cmd.OutOrStdout(), "PDF written: %s (%d page(s))\n", outcome.PDF, outcome.Pages)
+	}
+	return nil
+}
+
+func runReport(cmd *cobra.Command, args []string) error {
+	doc, err := executor.LoadResults(resultsArg(cmd, args))
+	if err != nil {
+		return err
+	}
+	out, _ := cmd.Flags().GetString("out")
+	if out == "" {
+		out = filepath.Join(filepath.Dir(resultsArg(cmd, args)), "report.html")
+	}
+	if err := executor.GenerateThemedReport(out, doc.TestResults(), doc.Theme); err != nil {
+		return fmt.Errorf("failed to generate report: %w", err)
+	}
+	outcome := reportOutcome{RunID: doc.RunID, Summary: doc.Summary, Report: out}
+	if printPDF, _ := cmd.Flags().GetBool("pdf"); printPDF {
+		paper, _ := cmd.Flags().GetString("paper")
+		landscape, _ := cmd.Flags().GetBool("landscape")
+		outcome.PDF = strings.TrimSuffix(out, filepath.Ext(out)) + ".pdf"
+		if outcome.Pages, err = executor.GenerateReportPDF(out, outcome.PDF, paper, landscape); err != nil {
+			return fmt.Errorf("failed to print the report to PDF: %w", err)
+		}
+	}
+	return printOutcome(cmd, outcome)
+}
+
+func runReportServe(cmd *cobra.Command, args []string) error {
+	dir := viper.GetString("output")
+	if len(args) > 0 {
+		dir = args[0]
+	}
+	if _, err := os.Stat(dir); err != nil {
+		return fmt.Errorf("no output directory to serve: %w", err)
+	}
+	log := commandLogger(cmd)
+	key, err := artifactKey(cmd)
+	if err != nil {
+		log.Warnf("Serving without decryption, encrypted artifacts will be refused: %v", err)
+	}
+
+	ctx, stop := signal.NotifyContext(cmd.Context(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	addr, _ := cmd.Flags().GetString("addr")
+	server := &http.Server{Addr: addr, Handler: artifactcrypt.Handler(dir, key)}
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		server.Shutdown(shutdownCtx)
+	}()
+
+	log.Infof("Report of %s at http://%s/ (Ctrl+C to stop)", dir, addr)
+	if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+		return fmt.Errorf("report server failed: %w", err)
+	}
+	return nil
+}
+
+// addArtifactKeyFlags adds the flags reading the key of encrypted artifacts
+func addArtifactKeyFlags(c *cobra.Command) {
+	c.Flags().String("key-env", artifactcrypt.DefaultKeyEnv, "environment variable holding the artifact key, base64 or hex encoded")
+	c.Flags().String("key-command", "", "command printing the artifact key, such as a KMS CLI; used instead of --key-env")
+}
+
+// artifactKey reads the key of encrypted artifacts as the flags of
+// addArtifactKeyFlags tell
+func artifactKey(cmd *cobra.Command) ([]byte, error) {
+	env, _ := cmd.Flags().GetString("key-env")
+	command, _ := cmd.Flags().GetString("key-command")
+	return artifactcrypt.LoadKey(env, strings.Fields(command))
+}
+
+func runMerge(cmd *cobra.Command, args []string) error {
+	docs := make([]*executor.ResultsDocument, 0, len(args))
+	for _, path := range args {
+		doc, err := executor.LoadResults(path)
+		if err != nil {
+			return err
+		}
+		docs = append(docs, doc)
+	}
+	merged := executor.MergeResults(docs...)
+
+	outcome := reportOutcome{RunID: merged.RunID, Summary: merged.Summary}
+	outcome.Results, _ = cmd.Flags().GetString("out")
+	if err := merged.Save(outcome.Results); err != nil {
+		return fmt.Errorf("failed to save merged results: %w", err)
+	}
+	if outcome.Report, _ = cmd.Flags().GetString("report"); outcome.Report != "" {
+		if err := executor.GenerateThemedReport(outcome.Report, merged.TestResults(), merged.Theme); err != nil {
+			return fmt.Errorf("failed to generate report: %w", err)
+		}
+	}
+	return printOutcome(cmd, outcome)
+}
+
+func init() {
+	reportCmd.Flags().String("out", "", "report file to write (default report.html next to the results)")
+	reportCmd.Flags().Bool("pdf", false, "also print the report to a PDF with a cover page, named after the report")
+	reportCmd.Flags().String("paper", "letter", "paper format of the PDF: letter, legal, tabloid, a3, a4 or a5")
+	reportCmd.Flags().Bool("landscape", false, "print the PDF in landscape")
+	reportServeCmd.Flags().String("addr", "127.0.0.1:9324", "address to serve the report on")
+	addArtifactKeyFlags(reportServeCmd)
+	reportCmd.AddCommand(reportServeCmd)
+	mergeCmd.Flags().String("out", "results.json", "merged results file to write")
+	mergeCmd.Flags().String("report", "", "also write the HTML report of the merged results to this file")
+
+	rootCmd.AddCommand(reportCmd, mergeCmd)
+}
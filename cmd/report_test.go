@@ -0,0 +1,115 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"panoptic/internal/artifactcrypt"
+	"panoptic/internal/executor"
+
+	"github.com/spf13/cobra"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writeResults saves a results.json with one result per app
+func writeResults(t *testing.T, path, runID string, results ...*executor.TestResult) string {
+	t.Helper()
+	doc := &executor.ResultsDocument{SchemaVersion: executor.ResultsSchemaVersion, RunID: runID, Results: results}
+	for _, r := range results {
+		doc.Summary.Total++
+		if r.Success {
+			doc.Summary.Passed++
+		} else {
+			doc.Summary.Failed++
+		}
+	}
+	require.NoError(t, doc.Save(path))
+	return path
+}
+
+func resultsTestCmd(use string, asJSON bool, flags map[string]string) (*cobra.Command, *bytes.Buffer) {
+	cmd := &cobra.Command{Use: use}
+	cmd.Flags().Bool("json", asJSON, "")
+	cmd.Flags().String("out", flags["out"], "")
+	cmd.Flags().String("report", flags["report"], "")
+	cmd.Flags().String("file", flags["file"], "")
+	cmd.Flags().Int("distance", 4, "")
+	out := &bytes.Buffer{}
+	cmd.SetOut(out)
+	return cmd, out
+}
+
+func TestRunReport(t *testing.T) {
+	dir := t.TempDir()
+	results := writeResults(t, filepath.Join(dir, "results.json"), "r1", &executor.TestResult{AppName: "Shop", Success: true})
+
+	cmd, out := resultsTestCmd("report", false, nil)
+	require.NoError(t, runReport(cmd, []string{results}))
+	assert.Contains(t, out.String(), "1 app(s): 1 passed, 0 failed")
+	html, err := os.ReadFile(filepath.Join(dir, "report.html"))
+	require.NoError(t, err)
+	assert.Contains(t, string(html), "Shop")
+
+	cmd, _ = resultsTestCmd("report", false, nil)
+	assert.Error(t, runReport(cmd, []string{filepath.Join(dir, "missing.json")}))
+
+	cmd, _ = resultsTestCmd("report", false, nil)
+	cmd.Flags().Bool("pdf", true, "")
+	cmd.Flags().String("paper", "b5", "")
+	cmd.Flags().Bool("landscape", false, "")
+	assert.ErrorContains(t, runReport(cmd, []string{results}), `unknown paper format "b5"`)
+}
+
+func TestReportCmd_Serve(t *testing.T) {
+	names := make([]string, 0)
+	for _, c := range reportCmd.Commands() {
+		names = append(names, c.Name())
+	}
+	assert.Equal(t, []string{"serve"}, names)
+	assert.Equal(t, "panoptic_cmd_report_serve_short", reportServeCmd.Short)
+	assert.NotNil(t, reportServeCmd.Flags().Lookup("key-command"))
+
+	cmd, _ := resultsTestCmd("serve", false, nil)
+	assert.ErrorContains(t, runReportServe(cmd, []string{filepath.Join(t.TempDir(), "missing")}), "no output directory to serve")
+}
+
+func TestArtifactKey(t *testing.T) {
+	cmd, _ := resultsTestCmd("serve", false, nil)
+	addArtifactKeyFlags(cmd)
+	require.NoError(t, cmd.Flags().Set("key-command", "echo "+strings.Repeat("ab", artifactcrypt.KeySize)))
+	key, err := artifactKey(cmd)
+	require.NoError(t, err)
+	assert.Equal(t, bytes.Repeat([]byte{0xab}, artifactcrypt.KeySize), key)
+
+	cmd, _ = resultsTestCmd("serve", false, nil)
+	addArtifactKeyFlags(cmd)
+	require.NoError(t, cmd.Flags().Set("key-env", "PANOPTIC_TEST_REPORT_KEY"))
+	t.Setenv("PANOPTIC_TEST_REPORT_KEY", "")
+	_, err = artifactKey(cmd)
+	assert.ErrorContains(t, err, "PANOPTIC_TEST_REPORT_KEY is empty")
+}
+
+func TestRunMerge(t *testing.T) {
+	dir := t.TempDir()
+	first := writeResults(t, filepath.Join(dir, "shard1.json"), "r1", &executor.TestResult{AppName: "Shop", Success: true})
+	second := writeResults(t, filepath.Join(dir, "shard2.json"), "r1", &executor.TestResult{AppName: "Admin", Error: "timeout"})
+	merged, report := filepath.Join(dir, "results.json"), filepath.Join(dir, "report.html")
+
+	cmd, out := resultsTestCmd("merge", true, map[string]string{"out": merged, "report": report})
+	require.NoError(t, runMerge(cmd, []string{first, second}))
+	var outcome reportOutcome
+	require.NoError(t, json.Unmarshal(out.Bytes(), &outcome))
+	assert.Equal(t, reportOutcome{RunID: "r1", Summary: executor.RunSummary{Total: 2, Passed: 1, Failed: 1}, Results: merged, Report: report}, outcome)
+
+	doc, err := executor.LoadResults(merged)
+	require.NoError(t, err)
+	require.Len(t, doc.Results, 2)
+	assert.Equal(t, "Admin", doc.Results[1].AppName)
+	assert.FileExists(t, report)
+}
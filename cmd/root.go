@@ -1,9 +1,12 @@
 package cmd
 
 import (
+	"encoding/json"
 	"fmt"
 	"os"
 
+	"panoptic/internal/logger"
+
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 )
@@ -26,6 +29,7 @@ func init() {
 	rootCmd.PersistentFlags().StringVar(&cfgFile, "config", "", "config file (default is $HOME/.panoptic.yaml)")
 	rootCmd.PersistentFlags().String("output", "./output", "output directory for screenshots and videos")
 	rootCmd.PersistentFlags().Bool("verbose", false, "enable verbose logging")
+	rootCmd.PersistentFlags().Bool("json", false, "print machine-readable JSON instead of text, for scripting")
 	
 	viper.BindPFlag("output", rootCmd.PersistentFlags().Lookup("output"))
 	viper.BindPFlag("verbose", rootCmd.PersistentFlags().Lookup("verbose"))
@@ -46,6 +50,37 @@ func initConfig() {
 	viper.AutomaticEnv()
 
 	if err := viper.ReadInConfig(); err == nil {
-		fmt.Println("Using config file:", viper.ConfigFileUsed())
+		fmt.Fprintln(os.Stderr, "Using config file:", viper.ConfigFileUsed())
+	}
+}
+
+// jsonOutput tells whether --json asked for machine-readable output
+func jsonOutput(cmd *cobra.Command) bool {
+	asJSON, _ := cmd.Flags().GetBool("json")
+	return asJSON
+}
+
+// commandLogger logs to stdout like panoptic run, or to stderr when stdout
+// carries the --json output
+func commandLogger(cmd *cobra.Command) *logger.Logger {
+	log := logger.NewLogger(viper.GetBool("verbose"))
+	if jsonOutput(cmd) {
+		log.SetOutput(cmd.ErrOrStderr())
+	}
+	return log
+}
+
+// printJSON writes v to the command's output as indented JSON
+func printJSON(cmd *cobra.Command, v interface{}) error {
+	enc := json.NewEncoder(cmd.OutOrStdout())
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
+
+// completeFiles completes positional arguments with files of the given
+// extensions, like configurations or results.json
+func completeFiles(extensions ...string) func(*cobra.Command, []string, string) ([]string, cobra.ShellCompDirective) {
+	return func(*cobra.Command, []string, string) ([]string, cobra.ShellCompDirective) {
+		return extensions, cobra.ShellCompDirectiveFilterFileExt
 	}
 }
\ No newline at end of file
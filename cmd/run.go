@@ -1,12 +1,21 @@
 package cmd
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	"os"
+	"os/signal"
 	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
 
 	"panoptic/internal/config"
 	"panoptic/internal/executor"
+	"panoptic/internal/history"
 	"panoptic/internal/logger"
+	"panoptic/internal/telemetry"
 
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
@@ -17,12 +26,16 @@ var runCmd = &cobra.Command{
 	Short: "Execute automated testing and recording",
 	Long: `Run the automated testing and recording process based on the provided configuration.
 The configuration file should define the applications to test and the actions to perform.`,
-	Args: cobra.ExactArgs(1),
-	Run: func(cmd *cobra.Command, args []string) {
+	Args:              cobra.ExactArgs(1),
+	ValidArgsFunction: completeFiles("yaml", "yml"),
+	// A failed run is reported by main; usage would only bury the log
+	SilenceUsage:  true,
+	SilenceErrors: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
 		configFile := args[0]
 		
 		// Initialize logger
-		log := logger.NewLogger(viper.GetBool("verbose"))
+		log := commandLogger(cmd)
 		log.Info("Starting Panoptic execution")
 		
 		// Load configuration
@@ -55,10 +68,194 @@ The configuration file should define the applications to test and the actions to
 		
 		log.Infof("Output directory: %s", outputDir)
 		
+		// Apply structured logging settings; flags override the config file
+		logOpts := loggingOptions(cmd, cfg, outputDir)
+		if jsonOutput(cmd) && (logOpts.Sink == "" || logOpts.Sink == logger.SinkStdout) {
+			logOpts.Sink = logger.SinkStderr
+		}
+		if err := log.Configure(logOpts); err != nil {
+			log.Fatalf("Invalid logging configuration: %v", err)
+		}
+		defer log.Close()
+		
 		// Execute the configuration
 		exec := executor.NewExecutor(cfg, outputDir, log)
-		if err := exec.Run(); err != nil {
-			log.Fatalf("Execution failed: %v", err)
+		if runID, _ := cmd.Flags().GetString("run-id"); runID != "" {
+			exec.SetRunID(runID)
+		}
+		if cmd.Flags().Changed("fake-seed") {
+			seed, _ := cmd.Flags().GetInt64("fake-seed")
+			exec.SetFakeSeed(seed)
+		}
+		if cmd.Flags().Changed("seed") {
+			seed, _ := cmd.Flags().GetInt64("seed")
+			exec.SetFakeSeed(seed)
+		}
+		if project, _ := cmd.Flags().GetString("project"); project != "" {
+			exec.SetProject(project)
+		}
+		if err := exec.SetConfigFile(configFile); err != nil {
+			log.Warnf("Configuration hash unavailable in results.json: %v", err)
+		}
+		if tags, _ := cmd.Flags().GetString("tags"); tags != "" {
+			filter, err := config.ParseTagFilter(tags)
+			if err != nil {
+				log.Fatalf("Invalid --tags: %v", err)
+			}
+			exec.SetTagFilter(filter)
+			log.Infof("Tag filter: %s", filter)
+		}
+		if manifestPath, _ := cmd.Flags().GetString("manifest"); manifestPath != "" {
+			if cmd.Flags().Changed("seed") || cmd.Flags().Changed("fake-seed") {
+				log.Fatalf("--manifest replays the seed of its run; drop --seed")
+			}
+			if resume, _ := cmd.Flags().GetBool("resume"); resume {
+				log.Fatalf("--manifest cannot be combined with --resume")
+			}
+			manifest, err := executor.LoadManifest(manifestPath)
+			if err != nil {
+				log.Fatalf("Cannot replay: %v", err)
+			}
+			if err := exec.Replay(manifest); err != nil {
+				log.Fatalf("Cannot replay: %v", err)
+			}
+			log.Infof("Replaying run %s with seed %d", manifest.RunID, manifest.Config.FakeSeed)
+		}
+		historyPath := historyFile(cmd, outputDir)
+		impact, err := impactFromFlags(cmd, historyPath)
+		if err != nil {
+			log.Fatalf("Invalid --changed: %v", err)
+		}
+		if impact != nil {
+			exec.SetImpact(impact)
+			log.Infof("Impact analysis: %d changed route(s) and component(s), coverage of %d app(s) from %s", len(impact.Changes), len(impact.Coverage), historyPath)
+		}
+		
+		// Save progress after every app, so --resume can continue an interrupted run
+		exec.EnableCheckpoints()
+		if resume, _ := cmd.Flags().GetBool("resume"); resume {
+			if err := exec.Resume(); err != nil {
+				log.Fatalf("Cannot resume: %v", err)
+			}
+		}
+		log.Infof("Run ID: %s", exec.RunID())
+		debug, _ := cmd.Flags().GetBool("debug")
+		step, _ := cmd.Flags().GetBool("step")
+		if debug || step {
+			if containerized, _ := cmd.Flags().GetBool("containerized"); containerized {
+				log.Fatalf("--debug cannot be combined with --containerized")
+			}
+			exec.EnableDebugMode(executor.DebugOptions{StepThrough: step})
+			log.Info("Debug mode enabled: headed browser, interactive prompt on pause actions and failures")
+		}
+		if containerized, _ := cmd.Flags().GetBool("containerized"); containerized {
+			image, _ := cmd.Flags().GetString("container-image")
+			exec.EnableContainerMode(executor.ContainerOptions{Image: image})
+			log.Infof("Containerized mode enabled (image: %s)", image)
+		}
+		if tracer := newTracer(cmd, cfg, log); tracer != nil {
+			exec.EnableTelemetry(tracer, os.Getenv(telemetry.TraceParentEnv))
+			defer func() {
+				ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+				defer cancel()
+				if err := tracer.Shutdown(ctx); err != nil {
+					log.Warnf("Failed to export remaining spans: %v", err)
+				}
+			}()
+		}
+		if tracing, _ := cmd.Flags().GetBool("trace"); tracing {
+			exec.EnableTracing()
+			log.Infof("Tracing enabled: archives in %s", filepath.Join(outputDir, "traces"))
+		}
+		if recording, _ := cmd.Flags().GetBool("record-contracts"); recording {
+			exec.RecordContracts()
+			log.Infof("Recording API contracts in %s", exec.ContractsPath())
+		}
+		if stream, _ := cmd.Flags().GetBool("stream-results"); stream {
+			streamPath := filepath.Join(outputDir, executor.ResultStreamFile)
+			if err := exec.StreamResults(streamPath); err != nil {
+				log.Fatalf("Failed to stream results: %v", err)
+			}
+			log.Infof("Streaming results to %s", streamPath)
+		}
+		// Encrypt the artifacts at rest however the run ends, the artifacts
+		// of each app having been encrypted as it finished
+		encrypted := false
+		encryptArtifacts := func() {
+			if encrypted {
+				return
+			}
+			encrypted = true
+			if _, err := exec.EncryptArtifacts(); err != nil {
+				log.Errorf("Failed to encrypt artifacts: %v", err)
+			}
+		}
+		defer encryptArtifacts()
+		
+		// An interrupt stops the run after the app it is running, so that
+		// app's artifacts are encrypted too; a second one quits at once
+		runCtx, stopRun := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+		go func() {
+			<-runCtx.Done()
+			stopRun()
+		}()
+		runErr := exec.RunContext(runCtx)
+		stopRun()
+		if runErr != nil {
+			encryptArtifacts()
+			log.Fatalf("Execution failed: %v", runErr)
+		}
+		
+		// File new failures and update the issues of known ones
+		reportURL, _ := cmd.Flags().GetString("report-url")
+		if cfg.Settings.Issues != nil {
+			if reportURL != "" {
+				cfg.Settings.Issues.ReportURL = reportURL
+			}
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+			if err := exec.FileIssues(ctx); err != nil {
+				log.Errorf("Failed to file issues: %v", err)
+			}
+			cancel()
+		}
+		
+		// Save machine-readable results
+		resultsPath := filepath.Join(outputDir, "results.json")
+		if err := exec.SaveResults(resultsPath); err != nil {
+			log.Errorf("Failed to save results: %v", err)
+		}
+		manifestPath := filepath.Join(outputDir, executor.ManifestFile)
+		if err := exec.SaveManifest(manifestPath); err != nil {
+			log.Errorf("Failed to save manifest: %v", err)
+		}
+		for _, difference := range exec.Manifest().Differences {
+			log.Warnf("Replay differs: %s", difference)
+		}
+		
+		// Export failures and detected errors for code scanning annotations
+		if sarifPath, _ := cmd.Flags().GetString("sarif"); sarifPath != "" {
+			if err := exec.SaveSARIF(sarifPath); err != nil {
+				log.Errorf("Failed to save SARIF log: %v", err)
+			} else {
+				log.Infof("SARIF log written: %s", sarifPath)
+			}
+		}
+		
+		// Hold the run's metrics to settings.slo before it joins the history
+		slo, err := exec.CheckSLOs(historyPath)
+		if err != nil {
+			log.Errorf("Failed to check SLOs: %v", err)
+		} else if slo != nil && !jsonOutput(cmd) {
+			fmt.Fprintf(cmd.OutOrStdout(), "SLOs against the recent runs in %s\n\n", historyPath)
+			if err := executor.WriteSLOReport(cmd.OutOrStdout(), slo); err != nil {
+				return err
+			}
+			fmt.Fprintln(cmd.OutOrStdout())
+		}
+		
+		// Record outcomes for per-tag pass rates across runs
+		if err := exec.AppendHistory(historyPath); err != nil {
+			log.Errorf("Failed to update run history: %v", err)
 		}
 		
 		// Generate report
@@ -69,10 +266,256 @@ The configuration file should define the applications to test and the actions to
 			log.Infof("Report generated: %s", reportPath)
 		}
 		
+		// Report the run on the commit or pull request
+		if gh := githubSettings(cmd, cfg); gh != nil {
+			ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+			url, err := exec.ReportToGitHub(ctx, *gh)
+			cancel()
+			switch {
+			case err != nil:
+				log.Errorf("Failed to report to GitHub: %v", err)
+			case url != "":
+				log.Infof("GitHub check run: %s", url)
+			default:
+				log.Info("GitHub commit status updated")
+			}
+		}
+		
+		// Post the run summary to Slack and Teams
+		if reportURL != "" {
+			for i := range cfg.Settings.Notifications {
+				cfg.Settings.Notifications[i].ReportURL = reportURL
+			}
+		}
+		notifyCtx, cancelNotify := context.WithTimeout(context.Background(), time.Minute)
+		if err := exec.Notify(notifyCtx); err != nil {
+			log.Errorf("Failed to send notifications: %v", err)
+		}
+		cancelNotify()
+		
+		// Page on failing scheduled runs and resolve once they pass again
+		if alerting, _ := cmd.Flags().GetBool("alert"); alerting {
+			if reportURL != "" {
+				for i := range cfg.Settings.Alerts {
+					cfg.Settings.Alerts[i].ReportURL = reportURL
+				}
+			}
+			ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+			if err := exec.Alert(ctx); err != nil {
+				log.Errorf("Failed to send alerts: %v", err)
+			}
+			cancel()
+		}
+		
+		// Encrypt the rest of the artifacts once nothing else reads them
+		encryptArtifacts()
+		
+		summary := exec.Summary()
+		if summary.Quarantined > 0 {
+			log.Warnf("%d quarantined app(s) failed; not failing the run", summary.Quarantined)
+		}
+		if summary.Warnings > 0 {
+			log.Warnf("%d app(s) failed with warning severity; not failing the run", summary.Warnings)
+		}
+		policyErr := errors.Join(exec.CheckFailurePolicy(), slo.Err())
+		if jsonOutput(cmd) {
+			outcome := runOutcome{RunID: exec.RunID(), Project: exec.ProjectID(), Summary: summary, Passed: policyErr == nil, SLO: slo, Results: resultsPath, Manifest: manifestPath, Report: reportPath}
+			if policyErr != nil {
+				outcome.Error = policyErr.Error()
+			}
+			if err := printJSON(cmd, outcome); err != nil {
+				return err
+			}
+		}
+		if policyErr != nil {
+			return policyErr
+		}
+		
 		log.Info("Execution completed successfully")
+		return nil
 	},
 }
 
+// runOutcome is what run prints with --json once the run is done
+type runOutcome struct {
+	RunID    string              `json:"run_id"`
+	Project  string              `json:"project_id,omitempty"` // enterprise project the run is attributed to
+	Summary  executor.RunSummary `json:"summary"`
+	Passed   bool                `json:"passed"` // under settings.failure_policy and settings.slo
+	Error    string              `json:"error,omitempty"`
+	SLO      *executor.SLOReport `json:"slo,omitempty"`
+	Results  string              `json:"results"`
+	Manifest string              `json:"manifest"`
+	Report   string              `json:"report"`
+}
+
+// historyFile is the run history of --history-file, by default in the
+// output directory
+func historyFile(cmd *cobra.Command, outputDir string) string {
+	if path, _ := cmd.Flags().GetString("history-file"); path != "" {
+		return path
+	}
+	return filepath.Join(outputDir, history.FileName)
+}
+
+// impactFromFlags reads the changes of --changed and --changed-file, one per
+// line, and the routes the apps opened in the run history. It returns nil
+// when neither flag is set.
+func impactFromFlags(cmd *cobra.Command, historyPath string) (*executor.Impact, error) {
+	if !cmd.Flags().Changed("changed") && !cmd.Flags().Changed("changed-file") {
+		return nil, nil
+	}
+	changes, _ := cmd.Flags().GetStringSlice("changed")
+	if file, _ := cmd.Flags().GetString("changed-file"); file != "" {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read changes: %w", err)
+		}
+		for _, line := range strings.Split(string(data), "\n") {
+			if line = strings.TrimSpace(line); line != "" && !strings.HasPrefix(line, "#") {
+				changes = append(changes, line)
+			}
+		}
+	}
+
+	impact := &executor.Impact{Changes: changes}
+	records, err := history.Load(historyPath)
+	switch {
+	case err == nil:
+		impact.Coverage = history.Coverage(records, 0)
+	case !errors.Is(err, os.ErrNotExist):
+		return nil, err
+	}
+	return impact, nil
+}
+
+// githubSettings merges settings.github with the --github* and --report-url
+// flags; nil when GitHub reporting is off
+func githubSettings(cmd *cobra.Command, cfg *config.Config) *config.GitHubSettings {
+	var gh config.GitHubSettings
+	if cfg.Settings.GitHub != nil {
+		gh = *cfg.Settings.GitHub
+	}
+	if enabled, _ := cmd.Flags().GetBool("github"); enabled {
+		gh.Enabled = true
+	}
+	if pr, _ := cmd.Flags().GetInt("github-pr"); pr > 0 {
+		gh.Enabled = true
+		gh.PullRequest = pr
+		gh.SHA = ""
+	}
+	if reportURL, _ := cmd.Flags().GetString("report-url"); reportURL != "" {
+		gh.ReportURL = reportURL
+	}
+	if !gh.Enabled {
+		return nil
+	}
+	return &gh
+}
+
+// loggingOptions merges the config file's logging settings with the --log-* flags
+func loggingOptions(cmd *cobra.Command, cfg *config.Config, outputDir string) logger.Options {
+	var opts logger.Options
+	if cfg.Settings.Logging != nil {
+		opts = logger.Options{
+			Format:     cfg.Settings.Logging.Format,
+			Sink:       cfg.Settings.Logging.Sink,
+			File:       cfg.Settings.Logging.File,
+			MaxSizeMB:  cfg.Settings.Logging.MaxSizeMB,
+			MaxBackups: cfg.Settings.Logging.MaxBackups,
+		}
+		for _, fwd := range cfg.Settings.Logging.Forward {
+			opts.Forward = append(opts.Forward, logger.ForwardConfig{
+				Type:          fwd.Type,
+				URL:           fwd.URL,
+				Index:         fwd.Index,
+				Labels:        fwd.Labels,
+				Username:      os.ExpandEnv(fwd.Username),
+				Password:      os.ExpandEnv(fwd.Password),
+				Token:         os.ExpandEnv(fwd.Token),
+				BatchSize:     fwd.BatchSize,
+				BufferSize:    fwd.BufferSize,
+				FlushInterval: fwd.FlushInterval,
+				Timeout:       fwd.Timeout,
+			})
+		}
+	}
+	if format, _ := cmd.Flags().GetString("log-format"); format != "" {
+		opts.Format = format
+	}
+	if sink, _ := cmd.Flags().GetString("log-sink"); sink != "" {
+		opts.Sink = sink
+	}
+	if file, _ := cmd.Flags().GetString("log-file"); file != "" {
+		opts.File = file
+	}
+	if opts.File == "" && (opts.Sink == logger.SinkFile || opts.Sink == logger.SinkBoth) {
+		opts.File = filepath.Join(outputDir, "logs", "panoptic.log")
+	}
+	return opts
+}
+
+// newTracer returns an OpenTelemetry tracer when telemetry is enabled by
+// settings.telemetry or --telemetry, or nil
+func newTracer(cmd *cobra.Command, cfg *config.Config, log *logger.Logger) *telemetry.Tracer {
+	settings := cfg.Settings.Telemetry
+	enabled, _ := cmd.Flags().GetBool("telemetry")
+	if settings != nil && settings.Enabled {
+		enabled = true
+	}
+	if !enabled {
+		return nil
+	}
+
+	var tcfg telemetry.Config
+	if settings != nil {
+		tcfg = telemetry.Config{
+			Endpoint:    settings.Endpoint,
+			ServiceName: settings.ServiceName,
+			Headers:     make(map[string]string, len(settings.Headers)),
+			Attributes:  settings.Attributes,
+		}
+		for k, v := range settings.Headers {
+			tcfg.Headers[k] = os.ExpandEnv(v)
+		}
+	}
+	tcfg = telemetry.ConfigFromEnv(tcfg)
+
+	tracer, err := telemetry.NewTracer(tcfg)
+	if err != nil {
+		log.Fatalf("Failed to start telemetry: %v", err)
+	}
+	log.Infof("Exporting OpenTelemetry spans to %s", tcfg.Endpoint)
+	return tracer
+}
+
 func init() {
 	rootCmd.AddCommand(runCmd)
+
+	runCmd.Flags().Bool("containerized", false, "Run each web app in a disposable Docker container")
+	runCmd.Flags().String("container-image", executor.DefaultContainerImage, "Pinned browser image used by --containerized")
+	runCmd.Flags().Bool("debug", false, "Run headed and open an interactive prompt on pause actions and failing steps")
+	runCmd.Flags().Bool("step", false, "Like --debug, but pause before every action")
+	runCmd.Flags().String("log-format", "", "Log format: text or json (overrides settings.logging.format)")
+	runCmd.Flags().String("log-sink", "", "Log destination: stdout, stderr, file or both (stderr and file)")
+	runCmd.Flags().String("log-file", "", "Log file for the file and both sinks (default <output>/logs/panoptic.log)")
+	runCmd.Flags().String("run-id", "", "Correlation ID for log entries (generated when empty)")
+	runCmd.Flags().Int64("seed", 0, "Seed of the run's {{fake.*}} test data, to replay a previous run (overrides settings.fake_seed)")
+	runCmd.Flags().Int64("fake-seed", 0, "Same as --seed")
+	runCmd.Flags().String("manifest", "", "Replay the inputs of the run of this manifest.json: its seed and tags, refusing a changed configuration")
+	runCmd.Flags().Bool("telemetry", false, "Export OpenTelemetry spans via OTLP (endpoint from settings.telemetry or OTEL_EXPORTER_OTLP_ENDPOINT)")
+	runCmd.Flags().String("project", "", "Enterprise project, by ID or name, to attribute the run to; overrides settings.enterprise.project")
+	runCmd.Flags().String("tags", "", "Run only apps and actions with these comma-separated tags; prefix a tag with ! to exclude it (e.g. smoke,!slow)")
+	runCmd.Flags().StringSlice("changed", nil, "Run only the apps these comma-separated changed routes (/checkout, /admin/*) or components (tags) can affect")
+	runCmd.Flags().String("changed-file", "", "File listing changed routes or components, one per line, for --changed (e.g. from CI)")
+	runCmd.Flags().String("history-file", "", "Run history used by panoptic history (default <output>/history.jsonl)")
+	runCmd.Flags().String("sarif", "", "Also write failures and detected errors as a SARIF 2.1.0 log for code scanning (e.g. results.sarif)")
+	runCmd.Flags().Bool("github", false, "Post a GitHub check run for the run (repository, commit and token from settings.github or GITHUB_* variables)")
+	runCmd.Flags().Int("github-pr", 0, "Pull request whose head commit gets the GitHub check run (implies --github)")
+	runCmd.Flags().String("report-url", "", "URL of the uploaded report, linked from the GitHub check run, notifications, issues and alerts; {run_id} is replaced")
+	runCmd.Flags().Bool("alert", false, "Raise and resolve PagerDuty/Opsgenie incidents under settings.alerts (for scheduled runs)")
+	runCmd.Flags().Bool("trace", false, "Record a trace archive per app (view with panoptic trace show)")
+	runCmd.Flags().Bool("record-contracts", false, "Save the JSON Schemas of the API responses web apps receive as the contracts later runs check (settings.contracts)")
+	runCmd.Flags().Bool("resume", false, "Continue the interrupted run of the output directory, skipping the apps that finished")
+	runCmd.Flags().Bool("stream-results", false, "Write each app's result to results.ndjson as it finishes instead of holding all of them in memory")
 }
\ No newline at end of file
@@ -0,0 +1,134 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"panoptic/pkg/i18n"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+var scheduleCmd = &cobra.Command{
+	Use:   "schedule <config-file> [-- run flags]",
+	Short: i18n.T("panoptic_cmd_schedule_short"),
+	Long: `Run a configuration with panoptic run at a fixed interval until interrupted
+or --times runs are done. Each run is a separate process, so a run that exits
+on a fatal error doesn't stop the schedule; flags after -- are passed to run,
+e.g. panoptic schedule smoke.yaml --every 15m -- --alert --tags smoke.`,
+	Args:              cobra.MinimumNArgs(1),
+	ValidArgsFunction: completeFiles("yaml", "yml"),
+	SilenceUsage:      true,
+	RunE:              runSchedule,
+}
+
+// scheduledRun runs one panoptic run with args; replaced in tests
+var scheduledRun = func(ctx context.Context, args []string, stdout, stderr io.Writer) error {
+	self, err := os.Executable()
+	if err != nil {
+		return fmt.Errorf("failed to find the panoptic executable: %w", err)
+	}
+	run := exec.CommandContext(ctx, self, args...)
+	run.Stdout, run.Stderr = stdout, stderr
+	return run.Run()
+}
+
+// scheduledOutcome is one run of a schedule, printed as a JSON line with --json
+type scheduledOutcome struct {
+	Run      int           `json:"run"`
+	Started  time.Time     `json:"started"`
+	Duration time.Duration `json:"duration"`
+	Passed   bool          `json:"passed"`
+	Error    string        `json:"error,omitempty"`
+}
+
+// scheduledArgs are the arguments of each run: the configuration, the
+// global flags of the schedule and the flags after --
+func scheduledArgs(cmd *cobra.Command, args []string) []string {
+	configFile, runFlags := args[0], args[1:]
+	if dash := cmd.ArgsLenAtDash(); dash > 1 || (dash < 0 && len(runFlags) > 0) {
+		return nil
+	}
+	runArgs := []string{"run", configFile, "--output", viper.GetString("output")}
+	if viper.GetBool("verbose") {
+		runArgs = append(runArgs, "--verbose")
+	}
+	if cfgFile != "" {
+		runArgs = append(runArgs, "--config", cfgFile)
+	}
+	return append(runArgs, runFlags...)
+}
+
+func runSchedule(cmd *cobra.Command, args []string) error {
+	every, _ := cmd.Flags().GetDuration("every")
+	if every <= 0 {
+		return fmt.Errorf("--every must be a positive interval, like 15m")
+	}
+	times, _ := cmd.Flags().GetInt("times")
+	runArgs := scheduledArgs(cmd, args)
+	if runArgs == nil {
+		return fmt.Errorf("schedule takes one configuration; pass run flags after --")
+	}
+
+	// Runs log to stdout; keep it for the JSON lines when scripting
+	runOutput := cmd.OutOrStdout()
+	if jsonOutput(cmd) {
+		runOutput = cmd.ErrOrStderr()
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+	ticker := time.NewTicker(every)
+	defer ticker.Stop()
+
+	failed := 0
+	for n := 1; times == 0 || n <= times; n++ {
+		if n > 1 {
+			select {
+			case <-ctx.Done():
+				return nil
+			case <-ticker.C:
+			}
+		}
+		outcome := scheduledOutcome{Run: n, Started: time.Now().UTC()}
+		err := scheduledRun(ctx, runArgs, runOutput, cmd.ErrOrStderr())
+		outcome.Duration = time.Since(outcome.Started)
+		if ctx.Err() != nil {
+			return nil
+		}
+		outcome.Passed = err == nil
+		if err != nil {
+			outcome.Error = err.Error()
+			failed++
+		}
+
+		if jsonOutput(cmd) {
+			if err := json.NewEncoder(cmd.OutOrStdout()).Encode(outcome); err != nil {
+				return err
+			}
+		} else if outcome.Passed {
+			fmt.Fprintf(cmd.OutOrStdout(), "Scheduled run %d passed in %s\n", n, outcome.Duration.Round(time.Second))
+		} else {
+			fmt.Fprintf(cmd.OutOrStdout(), "Scheduled run %d failed in %s: %s\n", n, outcome.Duration.Round(time.Second), outcome.Error)
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of %d scheduled run(s) failed", failed, times)
+	}
+	return nil
+}
+
+func init() {
+	scheduleCmd.Flags().Duration("every", 0, "interval between the starts of runs, like 15m or 1h (required)")
+	scheduleCmd.Flags().Int("times", 0, "stop after this many runs (0 runs until interrupted)")
+
+	rootCmd.AddCommand(scheduleCmd)
+}
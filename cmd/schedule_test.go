@@ -0,0 +1,77 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func scheduleTestCmd(every time.Duration, times int, asJSON bool) (*cobra.Command, *bytes.Buffer) {
+	cmd := &cobra.Command{Use: "schedule"}
+	cmd.Flags().Duration("every", every, "")
+	cmd.Flags().Int("times", times, "")
+	cmd.Flags().Bool("json", asJSON, "")
+	out := &bytes.Buffer{}
+	cmd.SetOut(out)
+	cmd.SetErr(io.Discard)
+	return cmd, out
+}
+
+func TestRunSchedule(t *testing.T) {
+	viper.Set("output", "/tmp/out")
+	defer viper.Set("output", "./output")
+	var calls [][]string
+	original := scheduledRun
+	defer func() { scheduledRun = original }()
+	scheduledRun = func(ctx context.Context, args []string, stdout, stderr io.Writer) error {
+		calls = append(calls, args)
+		if len(calls) == 2 {
+			return errors.New("exit status 1")
+		}
+		return nil
+	}
+
+	cmd, out := scheduleTestCmd(10*time.Millisecond, 3, true)
+	start := time.Now()
+	assert.EqualError(t, runSchedule(cmd, []string{"smoke.yaml"}), "1 of 3 scheduled run(s) failed")
+	assert.GreaterOrEqual(t, time.Since(start), 20*time.Millisecond, "runs wait for the interval")
+	require.Len(t, calls, 3)
+	assert.Equal(t, []string{"run", "smoke.yaml", "--output", "/tmp/out"}, calls[0])
+
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	require.Len(t, lines, 3)
+	var outcome scheduledOutcome
+	require.NoError(t, json.Unmarshal([]byte(lines[1]), &outcome))
+	assert.Equal(t, 2, outcome.Run)
+	assert.False(t, outcome.Passed)
+	assert.Equal(t, "exit status 1", outcome.Error)
+
+	cmd, out = scheduleTestCmd(time.Millisecond, 1, false)
+	require.NoError(t, runSchedule(cmd, []string{"smoke.yaml"}))
+	assert.Contains(t, out.String(), "Scheduled run 1 passed")
+
+	// Flags after -- go to run
+	calls = nil
+	rootCmd.SetArgs([]string{"schedule", "smoke.yaml", "--every", "1ms", "--times", "1", "--", "--alert", "--tags", "smoke"})
+	rootCmd.SetOut(io.Discard)
+	defer rootCmd.SetArgs(nil)
+	require.NoError(t, rootCmd.Execute())
+	require.Len(t, calls, 1)
+	assert.Equal(t, []string{"--alert", "--tags", "smoke"}, calls[0][len(calls[0])-3:])
+
+	cmd, _ = scheduleTestCmd(0, 1, false)
+	assert.EqualError(t, runSchedule(cmd, []string{"smoke.yaml"}), "--every must be a positive interval, like 15m")
+	cmd, _ = scheduleTestCmd(time.Minute, 1, false)
+	assert.EqualError(t, runSchedule(cmd, []string{"smoke.yaml", "other.yaml"}), "schedule takes one configuration; pass run flags after --")
+}
@@ -0,0 +1,131 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"syscall"
+	"time"
+
+	"panoptic/internal/config"
+	"panoptic/internal/executor"
+	"panoptic/pkg/i18n"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+var soakCmd = &cobra.Command{
+	Use:   "soak <config-file>",
+	Short: i18n.T("panoptic_cmd_soak_short"),
+	Long: `Replay the actions of every app of a configuration in a loop for --duration,
+e.g. 8h, in one long session of each, to find what only shows after hours:
+leaking memory and gradually slower pages.
+
+Every --snapshot-interval the resident memory of the browser or app, the
+JavaScript heap of web pages and the latencies since the previous
+snapshot are recorded. A line fitted through the snapshots tells their
+trend; memory growing more than --max-memory-growth percent or a pass or
+action slowing more than --max-latency-growth percent over the run marks
+the app degraded, and the command fails. A failing action ends the soak
+of an app.
+
+The results are written to soak.json and soak.html in the output
+directory, apart from the functional results of panoptic run.`,
+	Args:              cobra.ExactArgs(1),
+	ValidArgsFunction: completeFiles("yaml", "yml"),
+	SilenceUsage:      true,
+	RunE:              runSoak,
+}
+
+func runSoak(cmd *cobra.Command, args []string) error {
+	var opts executor.SoakOptions
+	opts.Duration, _ = cmd.Flags().GetDuration("duration")
+	opts.SnapshotInterval, _ = cmd.Flags().GetDuration("snapshot-interval")
+	opts.MaxMemoryGrowth, _ = cmd.Flags().GetFloat64("max-memory-growth")
+	opts.MaxLatencyGrowth, _ = cmd.Flags().GetFloat64("max-latency-growth")
+	if err := opts.Validate(); err != nil {
+		return err
+	}
+
+	cfg, err := config.Load(args[0])
+	if err != nil {
+		return fmt.Errorf("failed to load configuration: %w", err)
+	}
+	outputDir := viper.GetString("output")
+	if cfg.Output != "" {
+		outputDir = cfg.Output
+	}
+	if err := os.MkdirAll(outputDir, 0755); err != nil {
+		return fmt.Errorf("failed to create output directory: %w", err)
+	}
+
+	log := commandLogger(cmd)
+	exec := executor.NewExecutor(cfg, outputDir, log)
+	if tags, _ := cmd.Flags().GetString("tags"); tags != "" {
+		filter, err := config.ParseTagFilter(tags)
+		if err != nil {
+			return fmt.Errorf("invalid --tags: %w", err)
+		}
+		exec.SetTagFilter(filter)
+	}
+
+	ctx, stop := signal.NotifyContext(cmd.Context(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+	report, runErr := exec.RunSoak(ctx, opts)
+	if report == nil {
+		return runErr
+	}
+	if err := executor.WriteSoakReport(outputDir, report); err != nil {
+		return err
+	}
+	if runErr != nil {
+		return fmt.Errorf("soak run stopped: %w", runErr)
+	}
+
+	if jsonOutput(cmd) {
+		if err := printJSON(cmd, report); err != nil {
+			return err
+		}
+	} else {
+		out := cmd.OutOrStdout()
+		for _, app := range report.Apps {
+			verdict := "steady"
+			if app.Degraded {
+				verdict = "degraded"
+			}
+			fmt.Fprintf(out, "%s: %d pass(es) in %s, %d snapshot(s), %s\n", app.App, app.Passes,
+				(time.Duration(app.DurationMs) * time.Millisecond).Round(time.Second), len(app.Snapshots), verdict)
+			for _, finding := range app.Findings {
+				fmt.Fprintf(out, "  %s\n", finding)
+			}
+		}
+		fmt.Fprintf(out, "Soak report: %s\n", filepath.Join(outputDir, executor.SoakReportFile))
+	}
+
+	degraded := 0
+	for _, app := range report.Apps {
+		if app.Degraded {
+			degraded++
+		}
+	}
+	if degraded > 0 {
+		return fmt.Errorf("%d app(s) degraded over the soak run", degraded)
+	}
+	return nil
+}
+
+// addSoakFlags adds the flags of soak
+func addSoakFlags(c *cobra.Command) {
+	c.Flags().Duration("duration", 0, "replay the actions of each app for this long, e.g. 8h")
+	c.Flags().Duration("snapshot-interval", executor.DefaultSoakSnapshotInterval, "time between health snapshots")
+	c.Flags().Float64("max-memory-growth", executor.DefaultSoakMemoryGrowth, "percent resident memory or JavaScript heap may grow over the run; 0 to not check")
+	c.Flags().Float64("max-latency-growth", executor.DefaultSoakLatencyGrowth, "percent a pass or an action may slow down over the run; 0 to not check")
+	c.Flags().String("tags", "", "Replay only apps and actions with these comma-separated tags; prefix a tag with ! to exclude it")
+}
+
+func init() {
+	addSoakFlags(soakCmd)
+	rootCmd.AddCommand(soakCmd)
+}
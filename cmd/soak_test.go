@@ -0,0 +1,64 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"path/filepath"
+	"testing"
+
+	"panoptic/internal/executor"
+
+	"github.com/spf13/cobra"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func soakTestCmd(t *testing.T, asJSON bool, flags map[string]string) (*cobra.Command, *bytes.Buffer) {
+	t.Helper()
+	cmd := &cobra.Command{Use: "soak"}
+	addSoakFlags(cmd)
+	cmd.Flags().Bool("json", asJSON, "")
+	for name, value := range flags {
+		require.NoError(t, cmd.Flags().Set(name, value))
+	}
+	cmd.SetContext(context.Background())
+	out := &bytes.Buffer{}
+	cmd.SetOut(out)
+	cmd.SetErr(&bytes.Buffer{})
+	return cmd, out
+}
+
+func TestRunSoak(t *testing.T) {
+	path, outputDir := loadTestConfig(t)
+	cmd, out := soakTestCmd(t, false, map[string]string{"duration": "200ms", "snapshot-interval": "50ms", "max-latency-growth": "0", "max-memory-growth": "0"})
+	require.NoError(t, runSoak(cmd, []string{path}))
+	assert.Regexp(t, `Kiosk: \d+ pass\(es\) in .*, \d+ snapshot\(s\), steady`, out.String())
+	assert.Contains(t, out.String(), "Soak report: "+filepath.Join(outputDir, executor.SoakReportFile))
+	assert.FileExists(t, filepath.Join(outputDir, executor.SoakResultsFile))
+
+	cmd, out = soakTestCmd(t, true, map[string]string{"duration": "50ms", "tags": "smoke", "max-latency-growth": "0"})
+	require.NoError(t, runSoak(cmd, []string{path}))
+	var report executor.SoakReport
+	require.NoError(t, json.Unmarshal(out.Bytes(), &report))
+	require.Len(t, report.Apps, 1)
+	assert.GreaterOrEqual(t, report.Apps[0].Passes, 1)
+	assert.Contains(t, report.Apps[0].Snapshots[0].Actions, "tap")
+	assert.NotContains(t, report.Apps[0].Snapshots[0].Actions, "tap_again")
+}
+
+func TestRunSoak_Errors(t *testing.T) {
+	path, _ := loadTestConfig(t)
+	cmd, _ := soakTestCmd(t, false, nil)
+	assert.EqualError(t, runSoak(cmd, []string{path}), "a soak run needs a duration, got 0s")
+
+	cmd, _ = soakTestCmd(t, false, map[string]string{"duration": "1h", "max-memory-growth": "-5"})
+	assert.EqualError(t, runSoak(cmd, []string{path}), "memory and latency growth bounds must not be negative")
+
+	cmd, _ = soakTestCmd(t, false, map[string]string{"duration": "1h", "tags": "!"})
+	assert.ErrorContains(t, runSoak(cmd, []string{path}), "invalid --tags")
+
+	cmd, _ = soakTestCmd(t, false, map[string]string{"duration": "1h"})
+	assert.ErrorContains(t, runSoak(cmd, []string{filepath.Join(t.TempDir(), "missing.yaml")}), "failed to load configuration")
+}
@@ -0,0 +1,57 @@
+package cmd
+
+import (
+	"fmt"
+	"time"
+
+	"panoptic/internal/executor"
+	"panoptic/pkg/i18n"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+var statusCmd = &cobra.Command{
+	Use:   "status [output-dir]",
+	Short: i18n.T("panoptic_cmd_status_short"),
+	Long: `Read the status.json that panoptic run updates after every app and print how
+far the run got: apps finished and their outcomes, the app running now and the
+latest failures. A run that crashed stays "running"; check when it was last
+updated.`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: runStatus,
+}
+
+func runStatus(cmd *cobra.Command, args []string) error {
+	outputDir := viper.GetString("output")
+	if len(args) == 1 {
+		outputDir = args[0]
+	}
+	status, err := executor.ReadStatus(outputDir)
+	if err != nil {
+		return fmt.Errorf("failed to read run status: %w", err)
+	}
+	if jsonOutput(cmd) {
+		return printJSON(cmd, status)
+	}
+
+	out := cmd.OutOrStdout()
+	s := status.Summary
+	fmt.Fprintf(out, "Run %s: %s, %d of %d app(s) finished, updated %s ago\n",
+		status.RunID, status.State, s.Total, status.Apps, time.Since(status.UpdatedAt).Round(time.Second))
+	fmt.Fprintf(out, "  %d passed, %d failed, %d warnings, %d quarantined\n", s.Passed, s.Failed, s.Warnings, s.Quarantined)
+	if status.Running != "" {
+		fmt.Fprintf(out, "  Running: %s\n", status.Running)
+	}
+	if len(status.Failures) > 0 {
+		fmt.Fprintln(out, "\nLatest failures:")
+		for _, f := range status.Failures {
+			fmt.Fprintf(out, "  %s (%s): %s\n", f.App, f.Duration.Round(time.Millisecond), f.Error)
+		}
+	}
+	return nil
+}
+
+func init() {
+	rootCmd.AddCommand(statusCmd)
+}
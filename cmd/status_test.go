@@ -0,0 +1,57 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"panoptic/internal/executor"
+
+	"github.com/spf13/cobra"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func statusTestCmd(asJSON bool) (*cobra.Command, *bytes.Buffer) {
+	cmd := &cobra.Command{Use: "status"}
+	cmd.Flags().Bool("json", asJSON, "")
+	out := &bytes.Buffer{}
+	cmd.SetOut(out)
+	return cmd, out
+}
+
+func TestRunStatus(t *testing.T) {
+	dir := t.TempDir()
+	status := executor.RunStatus{
+		RunID:     "run-1",
+		State:     executor.RunStateRunning,
+		UpdatedAt: time.Now(),
+		Apps:      3,
+		Summary:   executor.RunSummary{Total: 2, Passed: 1, Failed: 1},
+		Running:   "Checkout",
+		Failures:  []executor.AppStatus{{App: "Admin", Error: "Action 'login' failed: timeout", Duration: time.Second}},
+	}
+	data, err := json.Marshal(status)
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(filepath.Join(dir, executor.StatusFile), data, 0644))
+
+	cmd, out := statusTestCmd(false)
+	require.NoError(t, runStatus(cmd, []string{dir}))
+	assert.Contains(t, out.String(), "Run run-1: running, 2 of 3 app(s) finished")
+	assert.Contains(t, out.String(), "1 passed, 1 failed")
+	assert.Contains(t, out.String(), "Running: Checkout")
+	assert.Contains(t, out.String(), "Admin (1s): Action 'login' failed: timeout")
+
+	cmd, out = statusTestCmd(true)
+	require.NoError(t, runStatus(cmd, []string{dir}))
+	var got executor.RunStatus
+	require.NoError(t, json.Unmarshal(out.Bytes(), &got))
+	assert.Equal(t, "Checkout", got.Running)
+
+	cmd, _ = statusTestCmd(false)
+	assert.ErrorContains(t, runStatus(cmd, []string{t.TempDir()}), "failed to read run status")
+}
@@ -0,0 +1,87 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"panoptic/internal/artifactcrypt"
+	"panoptic/internal/logger"
+	"panoptic/internal/trace"
+	"panoptic/pkg/i18n"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+var traceCmd = &cobra.Command{
+	Use:   "trace",
+	Short: i18n.T("panoptic_cmd_trace_short"),
+}
+
+var traceShowCmd = &cobra.Command{
+	Use:   "show <file>",
+	Short: i18n.T("panoptic_cmd_trace_show_short"),
+	Args:  cobra.ExactArgs(1),
+	RunE:  runTraceShow,
+}
+
+func runTraceShow(cmd *cobra.Command, args []string) error {
+	archive, err := loadTrace(cmd, args[0])
+	if err != nil {
+		return err
+	}
+
+	web, _ := cmd.Flags().GetBool("web")
+	if !web {
+		return trace.RenderText(cmd.OutOrStdout(), archive)
+	}
+
+	addr, _ := cmd.Flags().GetString("addr")
+	log := logger.NewLogger(viper.GetBool("verbose"))
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	server := &http.Server{Addr: addr, Handler: trace.Handler(archive)}
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		server.Shutdown(shutdownCtx)
+	}()
+
+	log.Infof("Trace viewer for %s at http://%s/ (Ctrl+C to stop)", archive.Trace.App, addr)
+	if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+		return fmt.Errorf("trace viewer failed: %w", err)
+	}
+	return nil
+}
+
+// loadTrace reads a trace archive, decrypting it if it was encrypted at rest
+func loadTrace(cmd *cobra.Command, path string) (*trace.Archive, error) {
+	if !artifactcrypt.IsEncryptedFile(path) {
+		return trace.Load(path)
+	}
+	key, err := artifactKey(cmd)
+	if err != nil {
+		return nil, fmt.Errorf("%s is encrypted: %w", path, err)
+	}
+	data, err := artifactcrypt.ReadFile(key, path)
+	if err != nil {
+		return nil, err
+	}
+	return trace.LoadBytes(data, path)
+}
+
+func init() {
+	traceShowCmd.Flags().Bool("web", false, "serve the trace as a local web page instead of printing it")
+	traceShowCmd.Flags().String("addr", "127.0.0.1:9323", "address for the --web viewer")
+	addArtifactKeyFlags(traceShowCmd)
+
+	traceCmd.AddCommand(traceShowCmd)
+	rootCmd.AddCommand(traceCmd)
+}
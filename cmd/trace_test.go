@@ -0,0 +1,72 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/base64"
+	"path/filepath"
+	"testing"
+
+	"panoptic/internal/artifactcrypt"
+	"panoptic/internal/trace"
+
+	"github.com/spf13/cobra"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTraceCmd_Subcommands(t *testing.T) {
+	names := make([]string, 0)
+	for _, c := range traceCmd.Commands() {
+		names = append(names, c.Name())
+	}
+	assert.Equal(t, []string{"show"}, names)
+	assert.Equal(t, "panoptic_cmd_trace_short", traceCmd.Short)
+}
+
+func TestRunTraceShow_Text(t *testing.T) {
+	recorder := trace.NewRecorder("shop", "web")
+	recorder.BeginAction(0, "open", "navigate", nil, nil)
+	recorder.EndAction(nil, nil)
+	recorder.Finish(true, "")
+	path := filepath.Join(t.TempDir(), "shop.zip")
+	require.NoError(t, recorder.Save(path))
+
+	cmd := &cobra.Command{Use: "show"}
+	cmd.Flags().Bool("web", false, "")
+	cmd.Flags().String("addr", "", "")
+	out := &bytes.Buffer{}
+	cmd.SetOut(out)
+
+	require.NoError(t, runTraceShow(cmd, []string{path}))
+	assert.Contains(t, out.String(), "Trace: shop (web) PASSED")
+
+	assert.Error(t, runTraceShow(cmd, []string{filepath.Join(t.TempDir(), "missing.zip")}))
+}
+
+// TestRunTraceShow_Encrypted tests that traces encrypted at rest are
+// decrypted with the artifact key
+func TestRunTraceShow_Encrypted(t *testing.T) {
+	recorder := trace.NewRecorder("shop", "web")
+	recorder.Finish(true, "")
+	path := filepath.Join(t.TempDir(), "shop.zip")
+	require.NoError(t, recorder.Save(path))
+	key := bytes.Repeat([]byte{5}, artifactcrypt.KeySize)
+	_, err := artifactcrypt.EncryptFile(key, path)
+	require.NoError(t, err)
+
+	cmd := &cobra.Command{Use: "show"}
+	cmd.Flags().Bool("web", false, "")
+	cmd.Flags().String("addr", "", "")
+	addArtifactKeyFlags(cmd)
+	require.NoError(t, cmd.Flags().Set("key-env", "PANOPTIC_TEST_TRACE_KEY"))
+	out := &bytes.Buffer{}
+	cmd.SetOut(out)
+
+	t.Setenv("PANOPTIC_TEST_TRACE_KEY", "")
+	assert.ErrorContains(t, runTraceShow(cmd, []string{path}), "is encrypted")
+
+	t.Setenv("PANOPTIC_TEST_TRACE_KEY", base64.StdEncoding.EncodeToString(key))
+	require.NoError(t, runTraceShow(cmd, []string{path}))
+	assert.Contains(t, out.String(), "Trace: shop (web) PASSED")
+}
@@ -0,0 +1,93 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"panoptic/internal/config"
+	"panoptic/internal/executor"
+	"panoptic/pkg/i18n"
+
+	"github.com/spf13/cobra"
+)
+
+var validateCmd = &cobra.Command{
+	Use:   "validate <config-file>...",
+	Short: i18n.T("panoptic_cmd_validate_short"),
+	Long: `Load each configuration the way panoptic run does and check it without
+starting a browser or recording, so CI can reject a broken configuration early.
+Actions an app's platform can't run, like navigate on a desktop app, make the
+configuration invalid.
+Exits non-zero when any configuration is invalid.`,
+	Args:              cobra.MinimumNArgs(1),
+	ValidArgsFunction: completeFiles("yaml", "yml"),
+	SilenceUsage:      true,
+	RunE:              runValidate,
+}
+
+// validation is the outcome of validating one configuration
+type validation struct {
+	File    string `json:"file"`
+	Valid   bool   `json:"valid"`
+	Error   string `json:"error,omitempty"`
+	Name    string `json:"name,omitempty"`
+	Apps    int    `json:"apps"`
+	Actions int    `json:"actions"`
+}
+
+// validateConfig loads and validates one configuration
+func validateConfig(file string) validation {
+	v := validation{File: file}
+	cfg, err := config.Load(file)
+	if err == nil {
+		err = cfg.Validate()
+	}
+	if err == nil {
+		err = executor.CheckCapabilities(cfg)
+	}
+	if err != nil {
+		v.Error = err.Error()
+		return v
+	}
+	v.Valid, v.Name, v.Apps, v.Actions = true, cfg.Name, len(cfg.Apps), len(cfg.Actions)
+	return v
+}
+
+func runValidate(cmd *cobra.Command, args []string) error {
+	results := make([]validation, 0, len(args))
+	invalid := 0
+	for _, file := range args {
+		v := validateConfig(file)
+		if !v.Valid {
+			invalid++
+		}
+		results = append(results, v)
+	}
+
+	if jsonOutput(cmd) {
+		if err := printJSON(cmd, results); err != nil {
+			return err
+		}
+	} else {
+		for _, v := range results {
+			if v.Valid {
+				fmt.Fprintf(cmd.OutOrStdout(), "%s: valid, %d app(s), %d action(s)\n", v.File, v.Apps, v.Actions)
+			} else if problems := strings.Split(v.Error, "\n"); len(problems) > 1 {
+				fmt.Fprintf(cmd.OutOrStdout(), "%s: invalid:\n", v.File)
+				for _, problem := range problems {
+					fmt.Fprintf(cmd.OutOrStdout(), "  %s\n", problem)
+				}
+			} else {
+				fmt.Fprintf(cmd.OutOrStdout(), "%s: invalid: %s\n", v.File, v.Error)
+			}
+		}
+	}
+	if invalid > 0 {
+		return fmt.Errorf("%d of %d configuration(s) invalid", invalid, len(args))
+	}
+	return nil
+}
+
+func init() {
+	rootCmd.AddCommand(validateCmd)
+}
@@ -0,0 +1,94 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/spf13/cobra"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func validateTestCmd(asJSON bool) (*cobra.Command, *bytes.Buffer) {
+	cmd := &cobra.Command{Use: "validate"}
+	cmd.Flags().Bool("json", asJSON, "")
+	out := &bytes.Buffer{}
+	cmd.SetOut(out)
+	return cmd, out
+}
+
+func TestRunValidate(t *testing.T) {
+	dir := t.TempDir()
+	valid := filepath.Join(dir, "valid.yaml")
+	require.NoError(t, os.WriteFile(valid, []byte(`name: Smoke
+apps:
+  - name: Shop
+    type: web
+    url: https://shop.example.com
+actions:
+  - name: home
+    type: screenshot
+`), 0644))
+	empty := filepath.Join(dir, "empty.yaml")
+	require.NoError(t, os.WriteFile(empty, []byte("name: Empty\n"), 0644))
+
+	cmd, out := validateTestCmd(false)
+	require.NoError(t, runValidate(cmd, []string{valid}))
+	assert.Equal(t, valid+": valid, 1 app(s), 1 action(s)\n", out.String())
+
+	cmd, out = validateTestCmd(true)
+	err := runValidate(cmd, []string{valid, empty, filepath.Join(dir, "missing.yaml")})
+	assert.EqualError(t, err, "2 of 3 configuration(s) invalid")
+	var results []validation
+	require.NoError(t, json.Unmarshal(out.Bytes(), &results))
+	require.Len(t, results, 3)
+	assert.True(t, results[0].Valid)
+	assert.Equal(t, "Smoke", results[0].Name)
+	assert.Equal(t, "at least one application must be configured", results[1].Error)
+	assert.Contains(t, results[2].Error, "failed to read config file")
+}
+
+func TestRunValidate_Capabilities(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "desktop.yaml")
+	require.NoError(t, os.WriteFile(file, []byte(`name: Desktop
+apps:
+  - name: Editor
+    type: desktop
+    path: /usr/bin/gedit
+actions:
+  - name: open
+    type: navigate
+    url: file:///tmp/notes.txt
+`), 0644))
+
+	cmd, out := validateTestCmd(false)
+	assert.Error(t, runValidate(cmd, []string{file}))
+	assert.Contains(t, out.String(), "invalid: app Editor: action 'open' (navigate) is unsupported on this platform (desktop)")
+}
+
+func TestRunValidate_ActionErrors(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "broken.yaml")
+	require.NoError(t, os.WriteFile(file, []byte(`name: Broken
+apps:
+  - name: Shop
+    type: web
+    url: https://shop.example.com
+actions:
+  - name: buy
+    type: click
+  - name: name
+    type: fill
+    selector: "#name"
+`), 0644))
+
+	cmd, out := validateTestCmd(false)
+	assert.Error(t, runValidate(cmd, []string{file}))
+	assert.Equal(t, file+`: invalid:
+  action buy: click needs a selector or target
+  action name: fill needs a value
+`, out.String())
+}
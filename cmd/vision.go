@@ -5,6 +5,8 @@ import (
 	"fmt"
 	"os"
 
+	"panoptic/internal/config"
+	"panoptic/internal/executor"
 	"panoptic/internal/logger"
 	"panoptic/internal/vision"
 	"panoptic/pkg/i18n"
@@ -46,6 +48,12 @@ var visionReportCmd = &cobra.Command{
 	RunE:  runVisionReport,
 }
 
+var visionCalibrateCmd = &cobra.Command{
+	Use:   "calibrate",
+	Short: i18n.T("panoptic_cmd_vision_calibrate_short"),
+	RunE:  runVisionCalibrate,
+}
+
 func runVisionDetect(cmd *cobra.Command, args []string) error {
 	screenshot, _ := cmd.Flags().GetString("screenshot")
 	if screenshot == "" {
@@ -60,6 +68,11 @@ func runVisionDetect(cmd *cobra.Command, args []string) error {
 
 	log := logger.NewLogger(viper.GetBool("verbose"))
 	detector := vision.NewElementDetector(*log)
+	maxDimension, _ := cmd.Flags().GetInt("max-dimension")
+	detector.SetMaxDimension(maxDimension)
+	if model, _ := cmd.Flags().GetString("model"); model != "" {
+		_ = detector.UseModel(vision.ModelConfig{Path: model})
+	}
 
 	elements, err := detector.DetectElements(screenshot)
 	if err != nil {
@@ -114,6 +127,11 @@ func runVisionReport(cmd *cobra.Command, args []string) error {
 
 	log := logger.NewLogger(viper.GetBool("verbose"))
 	detector := vision.NewElementDetector(*log)
+	maxDimension, _ := cmd.Flags().GetInt("max-dimension")
+	detector.SetMaxDimension(maxDimension)
+	if model, _ := cmd.Flags().GetString("model"); model != "" {
+		_ = detector.UseModel(vision.ModelConfig{Path: model})
+	}
 
 	elements, err := detector.DetectElements(screenshot)
 	if err != nil {
@@ -137,6 +155,53 @@ func runVisionReport(cmd *cobra.Command, args []string) error {
 	return nil
 }
 
+func runVisionCalibrate(cmd *cobra.Command, args []string) error {
+	labels, _ := cmd.Flags().GetString("labels")
+	if labels == "" {
+		return fmt.Errorf("--labels flag is required")
+	}
+	set, err := vision.LoadLabeledSet(labels)
+	if err != nil {
+		return err
+	}
+
+	log := logger.NewLogger(viper.GetBool("verbose"))
+	detector := vision.NewElementDetector(*log)
+	if settingsFile, _ := cmd.Flags().GetString("settings"); settingsFile != "" {
+		cfg, err := config.Load(settingsFile)
+		if err != nil {
+			return err
+		}
+		if err := cfg.Settings.Vision.Validate(); err != nil {
+			return fmt.Errorf("settings.vision: %w", err)
+		}
+		if settings := cfg.Settings.Vision; settings != nil {
+			if settings.ModelPath != "" {
+				_ = detector.UseModel(executor.VisionModelConfig(settings))
+			}
+			detector.SetThresholds(executor.VisionThresholds(settings))
+		}
+	}
+	if model, _ := cmd.Flags().GetString("model"); model != "" {
+		_ = detector.UseModel(vision.ModelConfig{Path: model})
+	}
+
+	matchIoU, _ := cmd.Flags().GetFloat64("match-iou")
+	calibration, err := detector.Calibrate(set, matchIoU)
+	if err != nil {
+		return err
+	}
+	if jsonOutput(cmd) {
+		data, err := json.MarshalIndent(calibration, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to marshal JSON: %w", err)
+		}
+		fmt.Fprintln(cmd.OutOrStdout(), string(data))
+		return nil
+	}
+	return vision.WriteCalibration(cmd.OutOrStdout(), calibration)
+}
+
 func init() {
 	visionDetectCmd.Flags().String(
 		"screenshot", "",
@@ -146,6 +211,14 @@ func init() {
 		"output", "",
 		"path to write JSON output (stdout if omitted)",
 	)
+	visionDetectCmd.Flags().Int(
+		"max-dimension", 0,
+		"downscale screenshots whose longer side exceeds this many pixels before detection (0 for full resolution)",
+	)
+	visionDetectCmd.Flags().String(
+		"model", "",
+		"ONNX element detection model replacing the heuristics (falls back to them when it can't run)",
+	)
 
 	visionReportCmd.Flags().String(
 		"screenshot", "",
@@ -155,8 +228,34 @@ func init() {
 		"output", "",
 		"output directory for the visual report",
 	)
+	visionReportCmd.Flags().Int(
+		"max-dimension", 0,
+		"downscale screenshots whose longer side exceeds this many pixels before detection (0 for full resolution)",
+	)
+	visionReportCmd.Flags().String(
+		"model", "",
+		"ONNX element detection model replacing the heuristics (falls back to them when it can't run)",
+	)
+
+	visionCalibrateCmd.Flags().String(
+		"labels", "",
+		"YAML or JSON file listing screenshots and the elements labeled in them",
+	)
+	visionCalibrateCmd.Flags().String(
+		"settings", "",
+		"configuration file whose settings.vision model and thresholds are calibrated",
+	)
+	visionCalibrateCmd.Flags().String(
+		"model", "",
+		"ONNX element detection model to calibrate instead of the heuristics",
+	)
+	visionCalibrateCmd.Flags().Float64(
+		"match-iou", vision.DefaultMatchIoU,
+		"overlap a detection needs with a labeled element to count as finding it",
+	)
 
 	visionCmd.AddCommand(visionDetectCmd)
 	visionCmd.AddCommand(visionReportCmd)
+	visionCmd.AddCommand(visionCalibrateCmd)
 	rootCmd.AddCommand(visionCmd)
 }
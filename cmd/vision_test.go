@@ -1,11 +1,15 @@
 package cmd
 
 import (
+	"encoding/json"
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 
 	"github.com/spf13/cobra"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 // newVisionTestRootCmd creates a fresh command tree for vision tests
@@ -30,6 +34,8 @@ func newVisionTestRootCmd() *cobra.Command {
 		"screenshot", "",
 		"path to the screenshot image file",
 	)
+	detect.Flags().Int("max-dimension", 0, "")
+	detect.Flags().String("model", "", "")
 	detect.Flags().String(
 		"output", "",
 		"path to write JSON output (stdout if omitted)",
@@ -44,13 +50,26 @@ func newVisionTestRootCmd() *cobra.Command {
 		"screenshot", "",
 		"path to the screenshot image file",
 	)
+	report.Flags().Int("max-dimension", 0, "")
+	report.Flags().String("model", "", "")
 	report.Flags().String(
 		"output", "",
 		"output directory for the visual report",
 	)
 
+	calibrate := &cobra.Command{
+		Use:  "calibrate",
+		RunE: runVisionCalibrate,
+	}
+	calibrate.Flags().String("labels", "", "")
+	calibrate.Flags().String("settings", "", "")
+	calibrate.Flags().String("model", "", "")
+	calibrate.Flags().Float64("match-iou", 0.5, "")
+	calibrate.Flags().Bool("json", false, "")
+
 	vis.AddCommand(detect)
 	vis.AddCommand(report)
+	vis.AddCommand(calibrate)
 	root.AddCommand(vis)
 
 	return root
@@ -100,3 +119,87 @@ func TestVisionReportCmd_NoScreenshot(t *testing.T) {
 	assert.Error(t, err)
 	assert.Contains(t, err.Error(), "--screenshot flag is required")
 }
+
+func TestVisionDetectCmd_WebPDownscaled(t *testing.T) {
+	cmd := newVisionTestRootCmd()
+	cmd.SetArgs([]string{
+		"vision", "detect",
+		"--screenshot", "../internal/vision/testdata/screen.lossy.webp",
+		"--max-dimension", "75",
+	})
+
+	out := &strings.Builder{}
+	cmd.SetOut(out)
+	cmd.SetErr(out)
+
+	assert.NoError(t, cmd.Execute())
+	assert.True(t, strings.HasPrefix(out.String(), "["), out.String())
+}
+
+// TestVisionDetectCmd_MissingModel tests that detection falls back to the
+// heuristics when the --model file doesn't exist
+func TestVisionDetectCmd_MissingModel(t *testing.T) {
+	cmd := newVisionTestRootCmd()
+	cmd.SetArgs([]string{
+		"vision", "detect",
+		"--screenshot", "../internal/vision/testdata/screen.lossy.webp",
+		"--model", "../internal/vision/testdata/missing.onnx",
+	})
+
+	out := &strings.Builder{}
+	cmd.SetOut(out)
+	cmd.SetErr(out)
+
+	assert.NoError(t, cmd.Execute())
+	assert.Contains(t, out.String(), `"selector"`)
+}
+
+// TestVisionCalibrateCmd tests calibrating the heuristics of a settings file
+// against a labeled screenshot
+func TestVisionCalibrateCmd(t *testing.T) {
+	dir := t.TempDir()
+	screenshot, err := filepath.Abs("../internal/vision/testdata/screen.lossy.webp")
+	require.NoError(t, err)
+	labels := filepath.Join(dir, "labels.yaml")
+	require.NoError(t, os.WriteFile(labels, []byte(`
+- screenshot: `+screenshot+`
+  elements:
+    - {type: button, x: 20, y: 20, width: 80, height: 30}
+`), 0644))
+	settings := filepath.Join(dir, "panoptic.yaml")
+	require.NoError(t, os.WriteFile(settings, []byte("settings:\n  vision:\n    thresholds: {link: 0.9}\n"), 0644))
+
+	cmd := newVisionTestRootCmd()
+	cmd.SetArgs([]string{"vision", "calibrate", "--labels", labels, "--settings", settings, "--json"})
+	out := &strings.Builder{}
+	cmd.SetOut(out)
+	assert.NoError(t, cmd.Execute())
+
+	var calibration struct {
+		Screenshots int `json:"screenshots"`
+		Types       []struct {
+			Type    string `json:"type"`
+			Labeled int    `json:"labeled"`
+			Current struct {
+				Threshold float64 `json:"threshold"`
+			} `json:"current"`
+		} `json:"types"`
+	}
+	require.NoError(t, json.Unmarshal([]byte(out.String()), &calibration), out.String())
+	assert.Equal(t, 1, calibration.Screenshots)
+	assert.NotEmpty(t, calibration.Types)
+	for _, tc := range calibration.Types {
+		if tc.Type == "button" {
+			assert.Equal(t, 1, tc.Labeled)
+		}
+		if tc.Type == "link" {
+			assert.Equal(t, 0.9, tc.Current.Threshold)
+		}
+	}
+
+	cmd = newVisionTestRootCmd()
+	cmd.SetArgs([]string{"vision", "calibrate"})
+	cmd.SetOut(&strings.Builder{})
+	cmd.SetErr(&strings.Builder{})
+	assert.EqualError(t, cmd.Execute(), "--labels flag is required")
+}
@@ -0,0 +1,95 @@
+// Package alert raises and resolves incidents in PagerDuty and Opsgenie. One
+// incident is kept per deduplication key: failing runs trigger (or re-trigger)
+// it and the next passing run resolves it.
+package alert
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+)
+
+// StateFileName is the open-incident state file kept in the output directory
+const StateFileName = "alert_state.json"
+
+// Event is an incident to raise
+type Event struct {
+	DedupKey  string
+	Summary   string
+	Source    string
+	Severity  string // critical, error, warning or info
+	Details   map[string]interface{}
+	ReportURL string
+}
+
+// Sink raises and resolves incidents
+type Sink interface {
+	Trigger(ctx context.Context, event Event) error
+	Resolve(ctx context.Context, dedupKey, note string) error
+}
+
+// State records which deduplication keys have an open incident
+type State struct {
+	Open map[string]bool `json:"open"`
+}
+
+// LoadState reads a state file; a missing file is an empty state
+func LoadState(path string) (*State, error) {
+	state := &State{Open: make(map[string]bool)}
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return state, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read alert state: %w", err)
+	}
+	if err := json.Unmarshal(data, state); err != nil {
+		return nil, fmt.Errorf("invalid alert state %s: %w", path, err)
+	}
+	if state.Open == nil {
+		state.Open = make(map[string]bool)
+	}
+	return state, nil
+}
+
+// Save writes the state file
+func (s *State) Save(path string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create alert state directory: %w", err)
+	}
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal alert state: %w", err)
+	}
+	return os.WriteFile(path, data, 0600)
+}
+
+func postJSON(ctx context.Context, client *http.Client, url string, headers map[string]string, body interface{}) error {
+	data, err := json.Marshal(body)
+	if err != nil {
+		return fmt.Errorf("failed to encode event: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(data))
+	if err != nil {
+		return fmt.Errorf("failed to build request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		respBody, _ := io.ReadAll(io.LimitReader(resp.Body, 1<<16))
+		return fmt.Errorf("status %d: %s", resp.StatusCode, bytes.TrimSpace(respBody))
+	}
+	return nil
+}
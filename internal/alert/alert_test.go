@@ -0,0 +1,66 @@
+package alert
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type request struct {
+	Path string
+	Auth string
+	Body map[string]interface{}
+}
+
+// fakeAPI records JSON requests and answers 202
+func fakeAPI(t *testing.T) (*httptest.Server, *[]request) {
+	var requests []request
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		req := request{Path: r.URL.RequestURI(), Auth: r.Header.Get("Authorization")}
+		require.NoError(t, json.Unmarshal(body, &req.Body))
+		requests = append(requests, req)
+		w.WriteHeader(http.StatusAccepted)
+		w.Write([]byte(`{"status":"success"}`))
+	}))
+	t.Cleanup(server.Close)
+	return server, &requests
+}
+
+// TestState tests loading, saving and a missing state file
+func TestState(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "out", StateFileName)
+	state, err := LoadState(path)
+	require.NoError(t, err)
+	assert.Empty(t, state.Open)
+
+	state.Open["pagerduty/panoptic/nightly"] = true
+	require.NoError(t, state.Save(path))
+	state, err = LoadState(path)
+	require.NoError(t, err)
+	assert.True(t, state.Open["pagerduty/panoptic/nightly"])
+
+	require.NoError(t, os.WriteFile(path, []byte("{"), 0600))
+	_, err = LoadState(path)
+	assert.ErrorContains(t, err, "invalid alert state")
+}
+
+// TestPostJSON_Error tests that API errors carry the status and body
+func TestPostJSON_Error(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, `{"message":"Invalid routing key"}`, http.StatusBadRequest)
+	}))
+	defer server.Close()
+
+	pd := NewPagerDuty("bad", server.URL)
+	err := pd.Trigger(t.Context(), Event{DedupKey: "k", Summary: "s"})
+	assert.ErrorContains(t, err, "pagerduty trigger: status 400")
+	assert.ErrorContains(t, err, "Invalid routing key")
+}
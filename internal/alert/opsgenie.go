@@ -0,0 +1,76 @@
+package alert
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// DefaultOpsgenieURL is the Opsgenie API; EU accounts use https://api.eu.opsgenie.com
+const DefaultOpsgenieURL = "https://api.opsgenie.com"
+
+// Opsgenie creates and closes Opsgenie alerts, using the deduplication key as
+// the alert alias
+type Opsgenie struct {
+	APIKey     string
+	URL        string
+	Priority   string // P1 to P5; defaults to P1 for critical events
+	HTTPClient *http.Client
+}
+
+// NewOpsgenie creates a sink for an API integration key
+func NewOpsgenie(apiKey, baseURL, priority string) *Opsgenie {
+	if baseURL == "" {
+		baseURL = DefaultOpsgenieURL
+	}
+	return &Opsgenie{APIKey: apiKey, URL: strings.TrimRight(baseURL, "/"), Priority: priority, HTTPClient: &http.Client{Timeout: 30 * time.Second}}
+}
+
+var opsgeniePriorities = map[string]string{"critical": "P1", "error": "P2", "warning": "P3", "info": "P5"}
+
+// Trigger creates the alert; Opsgenie deduplicates open alerts by alias
+func (o *Opsgenie) Trigger(ctx context.Context, event Event) error {
+	priority := o.Priority
+	if priority == "" {
+		priority = opsgeniePriorities[event.Severity]
+	}
+	if priority == "" {
+		priority = "P1"
+	}
+	details := make(map[string]string, len(event.Details)+1)
+	for k, v := range event.Details {
+		details[k] = fmt.Sprint(v)
+	}
+	if event.ReportURL != "" {
+		details["report"] = event.ReportURL
+	}
+	body := map[string]interface{}{
+		"message":     truncate(event.Summary, 130),
+		"alias":       truncate(event.DedupKey, 512),
+		"description": event.Summary,
+		"source":      event.Source,
+		"priority":    priority,
+		"tags":        []string{"panoptic"},
+		"details":     details,
+	}
+	if err := postJSON(ctx, o.HTTPClient, o.URL+"/v2/alerts", o.headers(), body); err != nil {
+		return fmt.Errorf("opsgenie create alert: %w", err)
+	}
+	return nil
+}
+
+// Resolve closes the open alert with the alias
+func (o *Opsgenie) Resolve(ctx context.Context, dedupKey, note string) error {
+	endpoint := o.URL + "/v2/alerts/" + url.PathEscape(truncate(dedupKey, 512)) + "/close?identifierType=alias"
+	if err := postJSON(ctx, o.HTTPClient, endpoint, o.headers(), map[string]string{"source": "panoptic", "note": note}); err != nil {
+		return fmt.Errorf("opsgenie close alert: %w", err)
+	}
+	return nil
+}
+
+func (o *Opsgenie) headers() map[string]string {
+	return map[string]string{"Authorization": "GenieKey " + o.APIKey}
+}
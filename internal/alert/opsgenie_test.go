@@ -0,0 +1,42 @@
+package alert
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestOpsgenie_TriggerResolve tests alert creation by alias and closing it
+func TestOpsgenie_TriggerResolve(t *testing.T) {
+	server, requests := fakeAPI(t)
+	og := NewOpsgenie("api-key", server.URL, "")
+
+	require.NoError(t, og.Trigger(t.Context(), Event{
+		DedupKey:  "panoptic/nightly run",
+		Summary:   "Panoptic nightly: 3 of 10 app(s) failed",
+		Source:    "ci-runner",
+		Severity:  "error",
+		Details:   map[string]interface{}{"failed": 3},
+		ReportURL: "https://ci.example.com/report.html",
+	}))
+	require.NoError(t, og.Resolve(t.Context(), "panoptic/nightly run", "The next run passed"))
+	require.Len(t, *requests, 2)
+
+	create := (*requests)[0]
+	assert.Equal(t, "/v2/alerts", create.Path)
+	assert.Equal(t, "GenieKey api-key", create.Auth)
+	assert.Equal(t, "panoptic/nightly run", create.Body["alias"])
+	assert.Equal(t, "P2", create.Body["priority"], "priority follows severity")
+	details := create.Body["details"].(map[string]interface{})
+	assert.Equal(t, "3", details["failed"])
+	assert.Equal(t, "https://ci.example.com/report.html", details["report"])
+
+	closed := (*requests)[1]
+	assert.Equal(t, "/v2/alerts/panoptic%2Fnightly%20run/close?identifierType=alias", closed.Path)
+	assert.Equal(t, "The next run passed", closed.Body["note"])
+
+	og.Priority = "P4"
+	require.NoError(t, og.Trigger(t.Context(), Event{DedupKey: "k", Summary: "s", Severity: "critical"}))
+	assert.Equal(t, "P4", (*requests)[2].Body["priority"], "a configured priority wins")
+}
@@ -0,0 +1,70 @@
+package alert
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// DefaultPagerDutyURL is the PagerDuty Events API
+const DefaultPagerDutyURL = "https://events.pagerduty.com"
+
+// PagerDuty sends events to a PagerDuty service integration
+type PagerDuty struct {
+	RoutingKey string
+	URL        string
+	HTTPClient *http.Client
+}
+
+// NewPagerDuty creates a sink for the integration's routing key
+func NewPagerDuty(routingKey, baseURL string) *PagerDuty {
+	if baseURL == "" {
+		baseURL = DefaultPagerDutyURL
+	}
+	return &PagerDuty{RoutingKey: routingKey, URL: strings.TrimRight(baseURL, "/"), HTTPClient: &http.Client{Timeout: 30 * time.Second}}
+}
+
+// Trigger opens the incident, or adds an alert to the open one with the same key
+func (p *PagerDuty) Trigger(ctx context.Context, event Event) error {
+	severity := event.Severity
+	if severity == "" {
+		severity = "critical"
+	}
+	body := map[string]interface{}{
+		"routing_key":  p.RoutingKey,
+		"event_action": "trigger",
+		"dedup_key":    event.DedupKey,
+		"payload": map[string]interface{}{
+			"summary":        truncate(event.Summary, 1024),
+			"source":         event.Source,
+			"severity":       severity,
+			"component":      "panoptic",
+			"custom_details": event.Details,
+		},
+	}
+	if event.ReportURL != "" {
+		body["links"] = []map[string]string{{"href": event.ReportURL, "text": "Panoptic report"}}
+	}
+	if err := postJSON(ctx, p.HTTPClient, p.URL+"/v2/enqueue", nil, body); err != nil {
+		return fmt.Errorf("pagerduty trigger: %w", err)
+	}
+	return nil
+}
+
+// Resolve resolves the incident with the key; PagerDuty resolve events carry no note
+func (p *PagerDuty) Resolve(ctx context.Context, dedupKey, _ string) error {
+	body := map[string]interface{}{"routing_key": p.RoutingKey, "event_action": "resolve", "dedup_key": dedupKey}
+	if err := postJSON(ctx, p.HTTPClient, p.URL+"/v2/enqueue", nil, body); err != nil {
+		return fmt.Errorf("pagerduty resolve: %w", err)
+	}
+	return nil
+}
+
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	return s[:n-3] + "..."
+}
@@ -0,0 +1,41 @@
+package alert
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestPagerDuty_TriggerResolve tests the Events API v2 trigger and resolve bodies
+func TestPagerDuty_TriggerResolve(t *testing.T) {
+	server, requests := fakeAPI(t)
+	pd := NewPagerDuty("routing-key", server.URL+"/")
+
+	require.NoError(t, pd.Trigger(t.Context(), Event{
+		DedupKey:  "panoptic/nightly",
+		Summary:   strings.Repeat("x", 2000),
+		Source:    "ci-runner",
+		Details:   map[string]interface{}{"failed": 3},
+		ReportURL: "https://ci.example.com/report.html",
+	}))
+	require.NoError(t, pd.Resolve(t.Context(), "panoptic/nightly", "passed"))
+	require.Len(t, *requests, 2)
+
+	trigger := (*requests)[0]
+	assert.Equal(t, "/v2/enqueue", trigger.Path)
+	assert.Equal(t, "trigger", trigger.Body["event_action"])
+	assert.Equal(t, "routing-key", trigger.Body["routing_key"])
+	assert.Equal(t, "panoptic/nightly", trigger.Body["dedup_key"])
+	payload := trigger.Body["payload"].(map[string]interface{})
+	assert.Equal(t, "critical", payload["severity"], "severity defaults to critical")
+	assert.Equal(t, "ci-runner", payload["source"])
+	assert.Len(t, payload["summary"], 1024)
+	assert.Equal(t, float64(3), payload["custom_details"].(map[string]interface{})["failed"])
+	assert.Equal(t, "https://ci.example.com/report.html", trigger.Body["links"].([]interface{})[0].(map[string]interface{})["href"])
+
+	resolve := (*requests)[1]
+	assert.Equal(t, "resolve", resolve.Body["event_action"])
+	assert.Equal(t, "panoptic/nightly", resolve.Body["dedup_key"])
+}
@@ -0,0 +1,191 @@
+// Package artifactcrypt encrypts run artifacts at rest with AES-256-GCM.
+//
+// An encrypted file starts with a magic header and the nonce, followed by
+// the sealed contents, so it can be told apart from a plain one and
+// decrypted transparently where it's served.
+package artifactcrypt
+
+import (
+	"bytes"
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// KeySize is the size of an artifact key: AES-256
+const KeySize = 32
+
+// DefaultKeyEnv is the environment variable holding the key by default
+const DefaultKeyEnv = "PANOPTIC_ARTIFACT_KEY"
+
+// magic starts every encrypted artifact
+var magic = []byte("PANOPTIC-ENC1\n")
+
+// ErrNotEncrypted is returned decrypting data that isn't an encrypted
+// artifact
+var ErrNotEncrypted = errors.New("not an encrypted artifact")
+
+// IsEncrypted tells whether data is an encrypted artifact
+func IsEncrypted(data []byte) bool {
+	return bytes.HasPrefix(data, magic)
+}
+
+// IsEncryptedFile tells whether the file at path is an encrypted artifact
+func IsEncryptedFile(path string) bool {
+	f, err := os.Open(path)
+	if err != nil {
+		return false
+	}
+	defer f.Close()
+	head := make([]byte, len(magic))
+	if _, err := io.ReadFull(f, head); err != nil {
+		return false
+	}
+	return IsEncrypted(head)
+}
+
+// ParseKey decodes a key given as base64 or hex
+func ParseKey(s string) ([]byte, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return nil, errors.New("empty artifact key")
+	}
+	for _, decode := range []func(string) ([]byte, error){
+		base64.StdEncoding.DecodeString,
+		base64.RawStdEncoding.DecodeString,
+		base64.URLEncoding.DecodeString,
+		hex.DecodeString,
+	} {
+		if key, err := decode(s); err == nil && len(key) == KeySize {
+			return key, nil
+		}
+	}
+	return nil, fmt.Errorf("artifact key must be %d bytes, base64 or hex encoded", KeySize)
+}
+
+// LoadKey reads the key from the environment variable env or, given a
+// command, from what it prints, such as a KMS CLI decrypting a data key
+func LoadKey(env string, command []string) ([]byte, error) {
+	if len(command) > 0 {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+		defer cancel()
+		var stderr bytes.Buffer
+		cmd := exec.CommandContext(ctx, command[0], command[1:]...)
+		cmd.Stderr = &stderr
+		out, err := cmd.Output()
+		if err != nil {
+			return nil, fmt.Errorf("artifact key command failed: %w: %s", err, strings.TrimSpace(stderr.String()))
+		}
+		return ParseKey(string(out))
+	}
+	if env == "" {
+		env = DefaultKeyEnv
+	}
+	value := os.Getenv(env)
+	if value == "" {
+		return nil, fmt.Errorf("artifact key not set: %s is empty", env)
+	}
+	key, err := ParseKey(value)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", env, err)
+	}
+	return key, nil
+}
+
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	if len(key) != KeySize {
+		return nil, fmt.Errorf("artifact key must be %d bytes", KeySize)
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// Encrypt seals plain with key
+func Encrypt(key, plain []byte) ([]byte, error) {
+	aead, err := newAEAD(key)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]byte, len(magic)+aead.NonceSize(), len(magic)+aead.NonceSize()+len(plain)+aead.Overhead())
+	copy(out, magic)
+	nonce := out[len(magic):]
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, fmt.Errorf("failed to generate nonce: %w", err)
+	}
+	return aead.Seal(out, nonce, plain, magic), nil
+}
+
+// Decrypt opens an encrypted artifact with key
+func Decrypt(key, data []byte) ([]byte, error) {
+	if !IsEncrypted(data) {
+		return nil, ErrNotEncrypted
+	}
+	aead, err := newAEAD(key)
+	if err != nil {
+		return nil, err
+	}
+	data = data[len(magic):]
+	if len(data) < aead.NonceSize() {
+		return nil, errors.New("truncated encrypted artifact")
+	}
+	plain, err := aead.Open(nil, data[:aead.NonceSize()], data[aead.NonceSize():], magic)
+	if err != nil {
+		return nil, errors.New("failed to decrypt artifact: wrong key or corrupted file")
+	}
+	return plain, nil
+}
+
+// EncryptFile encrypts the file at path in place. Files already encrypted
+// are left alone, so it reports whether it encrypted the file.
+func EncryptFile(key []byte, path string) (bool, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return false, err
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return false, err
+	}
+	if IsEncrypted(data) {
+		return false, nil
+	}
+	sealed, err := Encrypt(key, data)
+	if err != nil {
+		return false, err
+	}
+	// Replace the file whole so a crash never leaves it half encrypted
+	tmp := path + ".enc-tmp"
+	if err := os.WriteFile(tmp, sealed, info.Mode().Perm()); err != nil {
+		return false, err
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		os.Remove(tmp)
+		return false, err
+	}
+	return true, nil
+}
+
+// ReadFile reads the file at path, decrypting it if it's encrypted
+func ReadFile(key []byte, path string) ([]byte, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	if !IsEncrypted(data) {
+		return data, nil
+	}
+	return Decrypt(key, data)
+}
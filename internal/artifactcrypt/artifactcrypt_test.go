@@ -0,0 +1,105 @@
+package artifactcrypt
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testKey() []byte {
+	return bytes.Repeat([]byte{7}, KeySize)
+}
+
+// TestEncryptDecrypt tests that artifacts round-trip and are marked
+func TestEncryptDecrypt(t *testing.T) {
+	plain := []byte("\x89PNG screenshot")
+	sealed, err := Encrypt(testKey(), plain)
+	require.NoError(t, err)
+	assert.True(t, IsEncrypted(sealed))
+	assert.False(t, IsEncrypted(plain))
+	assert.NotContains(t, string(sealed), "screenshot")
+
+	again, err := Encrypt(testKey(), plain)
+	require.NoError(t, err)
+	assert.NotEqual(t, sealed, again, "every encryption takes a fresh nonce")
+
+	opened, err := Decrypt(testKey(), sealed)
+	require.NoError(t, err)
+	assert.Equal(t, plain, opened)
+
+	_, err = Decrypt(bytes.Repeat([]byte{8}, KeySize), sealed)
+	assert.ErrorContains(t, err, "wrong key")
+	_, err = Decrypt(testKey(), plain)
+	assert.ErrorIs(t, err, ErrNotEncrypted)
+	_, err = Encrypt([]byte("short"), plain)
+	assert.Error(t, err)
+}
+
+func TestParseKey(t *testing.T) {
+	key := testKey()
+	for _, encoded := range []string{
+		base64.StdEncoding.EncodeToString(key),
+		base64.RawStdEncoding.EncodeToString(key),
+		hex.EncodeToString(key) + "\n",
+	} {
+		parsed, err := ParseKey(encoded)
+		require.NoError(t, err, encoded)
+		assert.Equal(t, key, parsed)
+	}
+	_, err := ParseKey("")
+	assert.Error(t, err)
+	_, err = ParseKey(base64.StdEncoding.EncodeToString([]byte("too short")))
+	assert.ErrorContains(t, err, "must be 32 bytes")
+}
+
+func TestLoadKey(t *testing.T) {
+	encoded := base64.StdEncoding.EncodeToString(testKey())
+	t.Setenv(DefaultKeyEnv, encoded)
+	key, err := LoadKey("", nil)
+	require.NoError(t, err)
+	assert.Equal(t, testKey(), key)
+
+	t.Setenv("OTHER_KEY", "")
+	_, err = LoadKey("OTHER_KEY", nil)
+	assert.ErrorContains(t, err, "OTHER_KEY is empty")
+
+	key, err = LoadKey("", []string{"echo", encoded})
+	require.NoError(t, err)
+	assert.Equal(t, testKey(), key)
+
+	_, err = LoadKey("", []string{"false"})
+	assert.ErrorContains(t, err, "artifact key command failed")
+}
+
+// TestEncryptFile tests encrypting in place, once
+func TestEncryptFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "shot.png")
+	require.NoError(t, os.WriteFile(path, []byte("pixels"), 0644))
+
+	encrypted, err := EncryptFile(testKey(), path)
+	require.NoError(t, err)
+	assert.True(t, encrypted)
+	assert.True(t, IsEncryptedFile(path))
+
+	encrypted, err = EncryptFile(testKey(), path)
+	require.NoError(t, err)
+	assert.False(t, encrypted, "already encrypted files are left alone")
+
+	data, err := ReadFile(testKey(), path)
+	require.NoError(t, err)
+	assert.Equal(t, []byte("pixels"), data)
+
+	plain := filepath.Join(t.TempDir(), "plain.png")
+	require.NoError(t, os.WriteFile(plain, []byte("pixels"), 0644))
+	assert.False(t, IsEncryptedFile(plain))
+	data, err = ReadFile(testKey(), plain)
+	require.NoError(t, err)
+	assert.Equal(t, []byte("pixels"), data)
+	assert.False(t, IsEncryptedFile(filepath.Join(t.TempDir(), "missing")))
+}
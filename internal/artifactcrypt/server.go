@@ -0,0 +1,54 @@
+package artifactcrypt
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"path"
+)
+
+// Handler serves the files of dir, such as an output directory and its
+// report, decrypting encrypted artifacts with key as they're requested.
+// Without a key, encrypted artifacts are refused rather than served sealed.
+func Handler(dir string, key []byte) http.Handler {
+	root := http.Dir(dir)
+	files := http.FileServer(root)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		name := path.Clean("/" + r.URL.Path)
+		if name == "/" {
+			if f, err := root.Open("/report.html"); err == nil {
+				f.Close()
+				name = "/report.html"
+			}
+		}
+		f, err := root.Open(name)
+		if err != nil {
+			files.ServeHTTP(w, r)
+			return
+		}
+		defer f.Close()
+		info, err := f.Stat()
+		if err != nil || info.IsDir() {
+			files.ServeHTTP(w, r)
+			return
+		}
+		data, err := io.ReadAll(f)
+		if err != nil {
+			http.Error(w, "failed to read "+name, http.StatusInternalServerError)
+			return
+		}
+		if IsEncrypted(data) {
+			if key == nil {
+				http.Error(w, name+" is encrypted; serve the report with its artifact key", http.StatusForbidden)
+				return
+			}
+			if data, err = Decrypt(key, data); err != nil {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+			// Decrypted artifacts stay out of caches
+			w.Header().Set("Cache-Control", "no-store")
+		}
+		http.ServeContent(w, r, name, info.ModTime(), bytes.NewReader(data))
+	})
+}
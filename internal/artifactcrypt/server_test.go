@@ -0,0 +1,57 @@
+package artifactcrypt
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func get(t *testing.T, h http.Handler, target string) *http.Response {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, target, nil))
+	return rec.Result()
+}
+
+func body(t *testing.T, resp *http.Response) string {
+	t.Helper()
+	data, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	return string(data)
+}
+
+// TestHandler tests that encrypted artifacts are served decrypted next to
+// plain files
+func TestHandler(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "report.html"), []byte("<html>report</html>"), 0644))
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "screenshots"), 0755))
+	shot := filepath.Join(dir, "screenshots", "home.png")
+	require.NoError(t, os.WriteFile(shot, []byte("pixels"), 0644))
+	_, err := EncryptFile(testKey(), shot)
+	require.NoError(t, err)
+
+	h := Handler(dir, testKey())
+	resp := get(t, h, "/")
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "<html>report</html>", body(t, resp))
+
+	resp = get(t, h, "/screenshots/home.png")
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "pixels", body(t, resp))
+	assert.Equal(t, "image/png", resp.Header.Get("Content-Type"))
+	assert.Equal(t, "no-store", resp.Header.Get("Cache-Control"))
+
+	resp = get(t, h, "/../../etc/passwd")
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+
+	resp = get(t, Handler(dir, nil), "/screenshots/home.png")
+	assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+	assert.Contains(t, body(t, resp), "is encrypted")
+}
@@ -0,0 +1,86 @@
+// Package captcha gets runs past the captchas of protected staging sites:
+// a Solver is invoked when a captcha shows on the page, waiting for a
+// person to solve it, asking a solving service, or injecting a bypass token,
+// so the run neither hangs on it nor fails without saying why.
+package captcha
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// DefaultSelectors find reCAPTCHA, hCaptcha and Cloudflare Turnstile
+// widgets
+var DefaultSelectors = []string{
+	".g-recaptcha", `iframe[src*="recaptcha/api2/anchor"]`, `iframe[src*="recaptcha/enterprise/anchor"]`,
+	".h-captcha", `iframe[src*="hcaptcha.com"]`,
+	".cf-turnstile", `iframe[src*="challenges.cloudflare.com"]`,
+}
+
+// Challenge is a captcha shown on a page. Kind is recaptcha, hcaptcha or
+// turnstile, or empty for captchas only a selector identifies.
+type Challenge struct {
+	Kind     string `json:"kind"`
+	Selector string `json:"selector"`
+	SiteKey  string `json:"site_key,omitempty"`
+	PageURL  string `json:"page_url"`
+}
+
+// Page is the page of an app that captchas are detected on
+type Page interface {
+	// DetectCaptcha returns the first unsolved captcha matching one of
+	// selectors, or nil when none shows
+	DetectCaptcha(selectors []string) (*Challenge, error)
+	// InjectCaptchaToken fills the response fields of the page's captchas
+	// with token and calls their callbacks, as solving them would
+	InjectCaptchaToken(token string) error
+}
+
+// Solver gets past a captcha detected on a page; ctx bounds how long it
+// may take
+type Solver interface {
+	Solve(ctx context.Context, page Page, challenge Challenge) error
+}
+
+// Manual waits for a person to solve the captcha in the browser window,
+// which needs a headed browser
+type Manual struct {
+	Selectors []string
+	Interval  time.Duration // between checks, default 1s
+}
+
+// Solve waits until no captcha shows
+func (m *Manual) Solve(ctx context.Context, page Page, _ Challenge) error {
+	interval := m.Interval
+	if interval <= 0 {
+		interval = time.Second
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("captcha not solved in the browser before the timeout")
+		case <-ticker.C:
+		}
+		challenge, err := page.DetectCaptcha(m.Selectors)
+		if err != nil {
+			return err
+		}
+		if challenge == nil {
+			return nil
+		}
+	}
+}
+
+// Token injects a fixed token, such as the test keys' token of reCAPTCHA,
+// or a staging site's bypass token
+type Token struct {
+	Value string
+}
+
+// Solve injects the token
+func (t *Token) Solve(_ context.Context, page Page, _ Challenge) error {
+	return page.InjectCaptchaToken(t.Value)
+}
@@ -0,0 +1,49 @@
+package captcha
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakePage shows a captcha until it's solved by a token or after checks
+// detections
+type fakePage struct {
+	challenge Challenge
+	token     string
+	checks    int
+}
+
+func (p *fakePage) DetectCaptcha(selectors []string) (*Challenge, error) {
+	if p.token != "" || p.checks == 0 {
+		return nil, nil
+	}
+	p.checks--
+	return &p.challenge, nil
+}
+
+func (p *fakePage) InjectCaptchaToken(token string) error {
+	p.token = token
+	return nil
+}
+
+func TestManual_Solve(t *testing.T) {
+	page := &fakePage{checks: 2}
+	solver := &Manual{Selectors: DefaultSelectors, Interval: time.Millisecond}
+	require.NoError(t, solver.Solve(context.Background(), page, Challenge{}))
+	assert.Zero(t, page.checks)
+
+	page = &fakePage{checks: 1000}
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	assert.EqualError(t, solver.Solve(ctx, page, Challenge{}), "captcha not solved in the browser before the timeout")
+}
+
+func TestToken_Solve(t *testing.T) {
+	page := &fakePage{checks: 1}
+	require.NoError(t, (&Token{Value: "bypass"}).Solve(context.Background(), page, Challenge{Kind: "recaptcha"}))
+	assert.Equal(t, "bypass", page.token)
+}
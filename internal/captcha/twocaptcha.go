@@ -0,0 +1,127 @@
+package captcha
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// DefaultTwoCaptchaURL is the 2Captcha API
+const DefaultTwoCaptchaURL = "https://2captcha.com"
+
+// TwoCaptcha has captchas solved by 2Captcha, or a service with the same
+// API, and injects the token it returns
+type TwoCaptcha struct {
+	baseURL    string
+	apiKey     string
+	interval   time.Duration
+	httpClient *http.Client
+}
+
+// NewTwoCaptcha returns a solver using the API at baseURL, 2Captcha when
+// empty
+func NewTwoCaptcha(apiKey, baseURL string) *TwoCaptcha {
+	if baseURL == "" {
+		baseURL = DefaultTwoCaptchaURL
+	}
+	return &TwoCaptcha{
+		baseURL:    strings.TrimRight(baseURL, "/"),
+		apiKey:     apiKey,
+		interval:   5 * time.Second,
+		httpClient: &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+// twoCaptchaMethods are the API methods of the captcha kinds
+var twoCaptchaMethods = map[string]string{"recaptcha": "userrecaptcha", "hcaptcha": "hcaptcha", "turnstile": "turnstile"}
+
+// Solve submits the captcha, polls for its token and injects it
+func (s *TwoCaptcha) Solve(ctx context.Context, page Page, challenge Challenge) error {
+	method, ok := twoCaptchaMethods[challenge.Kind]
+	if !ok || challenge.SiteKey == "" {
+		return fmt.Errorf("2captcha can't solve the captcha at %s: needs a reCAPTCHA, hCaptcha or Turnstile site key", challenge.Selector)
+	}
+	params := url.Values{"key": {s.apiKey}, "method": {method}, "pageurl": {challenge.PageURL}, "json": {"1"}}
+	if method == "userrecaptcha" {
+		params.Set("googlekey", challenge.SiteKey)
+	} else {
+		params.Set("sitekey", challenge.SiteKey)
+	}
+	id, err := s.call(ctx, http.MethodPost, "/in.php", params)
+	if err != nil {
+		return fmt.Errorf("2captcha: %w", err)
+	}
+
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+	query := url.Values{"key": {s.apiKey}, "action": {"get"}, "id": {id}, "json": {"1"}}
+	for {
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("2captcha: captcha %s not solved before the timeout", id)
+		case <-ticker.C:
+		}
+		token, err := s.call(ctx, http.MethodGet, "/res.php", query)
+		if err == errNotReady {
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("2captcha: %w", err)
+		}
+		return page.InjectCaptchaToken(token)
+	}
+}
+
+var errNotReady = errors.New("CAPCHA_NOT_READY")
+
+// call sends a request and returns the request field of the reply, the
+// captcha ID or token
+func (s *TwoCaptcha) call(ctx context.Context, method, path string, params url.Values) (string, error) {
+	var body io.Reader
+	target := s.baseURL + path
+	if method == http.MethodPost {
+		body = strings.NewReader(params.Encode())
+	} else {
+		target += "?" + params.Encode()
+	}
+	req, err := http.NewRequestWithContext(ctx, method, target, body)
+	if err != nil {
+		return "", err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	}
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		// the error quotes the URL, and with it the API key
+		return "", fmt.Errorf("%s %s failed", method, path)
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return "", err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("%s %s: status %d", method, path, resp.StatusCode)
+	}
+	var reply struct {
+		Status  int    `json:"status"`
+		Request string `json:"request"`
+	}
+	if err := json.Unmarshal(data, &reply); err != nil {
+		return "", fmt.Errorf("%s %s: invalid response: %w", method, path, err)
+	}
+	if reply.Status != 1 {
+		if reply.Request == errNotReady.Error() {
+			return "", errNotReady
+		}
+		return "", fmt.Errorf("%s", reply.Request)
+	}
+	return reply.Request, nil
+}
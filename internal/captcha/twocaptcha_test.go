@@ -0,0 +1,56 @@
+package captcha
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTwoCaptcha_Solve(t *testing.T) {
+	polls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.NoError(t, r.ParseForm())
+		assert.Equal(t, "api-key", r.Form.Get("key"))
+		switch r.URL.Path {
+		case "/in.php":
+			assert.Equal(t, http.MethodPost, r.Method)
+			if r.Form.Get("method") == "hcaptcha" {
+				w.Write([]byte(`{"status":0,"request":"ERROR_ZERO_BALANCE"}`))
+				return
+			}
+			assert.Equal(t, "userrecaptcha", r.Form.Get("method"))
+			assert.Equal(t, "site-key", r.Form.Get("googlekey"))
+			assert.Equal(t, "https://shop.example.com/login", r.Form.Get("pageurl"))
+			w.Write([]byte(`{"status":1,"request":"42"}`))
+		case "/res.php":
+			assert.Equal(t, "42", r.Form.Get("id"))
+			if polls++; polls < 2 {
+				w.Write([]byte(`{"status":0,"request":"CAPCHA_NOT_READY"}`))
+				return
+			}
+			w.Write([]byte(`{"status":1,"request":"solved-token"}`))
+		}
+	}))
+	defer server.Close()
+	solver := NewTwoCaptcha("api-key", server.URL)
+	solver.interval = time.Millisecond
+	ctx := context.Background()
+
+	page := &fakePage{checks: 1}
+	challenge := Challenge{Kind: "recaptcha", Selector: ".g-recaptcha", SiteKey: "site-key", PageURL: "https://shop.example.com/login"}
+	require.NoError(t, solver.Solve(ctx, page, challenge))
+	assert.Equal(t, "solved-token", page.token)
+	assert.Equal(t, 2, polls)
+
+	challenge.Kind = "hcaptcha"
+	assert.EqualError(t, solver.Solve(ctx, &fakePage{}, challenge), "2captcha: ERROR_ZERO_BALANCE")
+
+	assert.EqualError(t, solver.Solve(ctx, &fakePage{}, Challenge{Selector: "#captcha"}),
+		"2captcha can't solve the captcha at #captcha: needs a reCAPTCHA, hCaptcha or Turnstile site key")
+	assert.Equal(t, DefaultTwoCaptchaURL, NewTwoCaptcha("key", "").baseURL)
+}
@@ -0,0 +1,441 @@
+package cloud
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"panoptic/internal/logger"
+	"panoptic/internal/telemetry"
+)
+
+// In-cluster service account locations used when no explicit credentials are configured
+const (
+	inClusterTokenFile = "/var/run/secrets/kubernetes.io/serviceaccount/token"
+	inClusterCAFile    = "/var/run/secrets/kubernetes.io/serviceaccount/ca.crt"
+)
+
+// KubernetesConfig configures the Kubernetes orchestration backend (Settings.Cloud.kubernetes)
+type KubernetesConfig struct {
+	Enabled            bool                `yaml:"enabled"`
+	APIServer          string              `yaml:"api_server"` // defaults to the in-cluster service address
+	Token              string              `yaml:"token"`
+	TokenFile          string              `yaml:"token_file"`
+	CAFile             string              `yaml:"ca_file"`
+	InsecureSkipVerify bool                `yaml:"insecure_skip_verify"`
+	Namespace          string              `yaml:"namespace"`
+	Image              string              `yaml:"image"`         // browser image with the panoptic agent installed
+	AgentCommand       string              `yaml:"agent_command"` // panoptic binary inside the image
+	ServiceAccount     string              `yaml:"service_account"`
+	Resources          KubernetesResources `yaml:"resources"`
+	Labels             map[string]string   `yaml:"labels"`
+	TimeoutSeconds     int                 `yaml:"timeout_seconds"`
+	PollInterval       int                 `yaml:"poll_interval"` // seconds
+}
+
+// KubernetesResources holds container resource requests and limits, e.g. {"cpu": "1", "memory": "2Gi"}
+type KubernetesResources struct {
+	Requests map[string]string `yaml:"requests" json:"requests,omitempty"`
+	Limits   map[string]string `yaml:"limits" json:"limits,omitempty"`
+}
+
+// KubernetesJobResult describes the outcome of an ephemeral test job
+type KubernetesJobResult struct {
+	JobName   string    `json:"job_name"`
+	PodName   string    `json:"pod_name"`
+	Namespace string    `json:"namespace"`
+	Succeeded bool      `json:"succeeded"`
+	Logs      string    `json:"logs"`
+	StartTime time.Time `json:"start_time"`
+	EndTime   time.Time `json:"end_time"`
+	Error     string    `json:"error,omitempty"`
+}
+
+// KubernetesRunner launches per-app test Jobs through the Kubernetes REST API
+type KubernetesRunner struct {
+	Logger logger.Logger
+	Config KubernetesConfig
+	Client *http.Client
+
+	baseURL string
+	token   string
+}
+
+// NewKubernetesRunner creates a runner, resolving in-cluster credentials when
+// no API server or token is configured
+func NewKubernetesRunner(log logger.Logger, config KubernetesConfig) (*KubernetesRunner, error) {
+	if config.Image == "" {
+		return nil, fmt.Errorf("kubernetes image is required")
+	}
+	if config.Namespace == "" {
+		config.Namespace = "default"
+	}
+	if config.AgentCommand == "" {
+		config.AgentCommand = "panoptic"
+	}
+	if config.TimeoutSeconds <= 0 {
+		config.TimeoutSeconds = 900
+	}
+	if config.PollInterval <= 0 {
+		config.PollInterval = 2
+	}
+
+	baseURL := config.APIServer
+	if baseURL == "" {
+		host, port := os.Getenv("KUBERNETES_SERVICE_HOST"), os.Getenv("KUBERNETES_SERVICE_PORT")
+		if host == "" {
+			return nil, fmt.Errorf("kubernetes api_server is required outside a cluster")
+		}
+		if port == "" {
+			port = "443"
+		}
+		baseURL = "https://" + host + ":" + port
+		if config.CAFile == "" {
+			config.CAFile = inClusterCAFile
+		}
+		if config.Token == "" && config.TokenFile == "" {
+			config.TokenFile = inClusterTokenFile
+		}
+	}
+
+	token := config.Token
+	if token == "" && config.TokenFile != "" {
+		data, err := os.ReadFile(config.TokenFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read kubernetes token: %w", err)
+		}
+		token = strings.TrimSpace(string(data))
+	}
+
+	tlsConfig := &tls.Config{InsecureSkipVerify: config.InsecureSkipVerify} // #nosec G402 -- opt-in for local clusters
+	if config.CAFile != "" {
+		caData, err := os.ReadFile(config.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read kubernetes CA file: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(caData) {
+			return nil, fmt.Errorf("no certificates found in kubernetes CA file %s", config.CAFile)
+		}
+		tlsConfig.RootCAs = pool
+	}
+
+	return &KubernetesRunner{
+		Logger:  log,
+		Config:  config,
+		Client:  &http.Client{Transport: &http.Transport{TLSClientConfig: tlsConfig}},
+		baseURL: strings.TrimRight(baseURL, "/"),
+		token:   token,
+	}, nil
+}
+
+var invalidJobNameChars = regexp.MustCompile(`[^a-z0-9-]+`)
+
+// JobName derives a valid, unique Kubernetes object name for an app run
+func JobName(appName string, now time.Time) string {
+	name := invalidJobNameChars.ReplaceAllString(strings.ToLower(appName), "-")
+	name = strings.Trim(name, "-")
+	if name == "" {
+		name = "app"
+	}
+	suffix := fmt.Sprintf("%x", now.UnixNano())
+	if len(suffix) > 10 {
+		suffix = suffix[len(suffix)-10:]
+	}
+	if max := 63 - len("panoptic--") - len(suffix); len(name) > max {
+		name = strings.Trim(name[:max], "-")
+	}
+	return "panoptic-" + name + "-" + suffix
+}
+
+// BuildJobManifest returns the batch/v1 Job that runs configYAML with the panoptic agent
+func (r *KubernetesRunner) BuildJobManifest(jobName string, configYAML []byte) map[string]interface{} {
+	return r.buildJobManifest(jobName, configYAML, nil)
+}
+
+// buildJobManifest is BuildJobManifest with extra container environment variables
+func (r *KubernetesRunner) buildJobManifest(jobName string, configYAML []byte, extraEnv map[string]string) map[string]interface{} {
+	labels := map[string]string{
+		"app.kubernetes.io/name":       "panoptic",
+		"app.kubernetes.io/managed-by": "panoptic",
+		"panoptic.io/job":              jobName,
+	}
+	for k, v := range r.Config.Labels {
+		labels[k] = v
+	}
+
+	script := fmt.Sprintf(`echo "$PANOPTIC_CONFIG" | base64 -d > /tmp/panoptic.yaml && exec %s run /tmp/panoptic.yaml --output /tmp/panoptic-output`, r.Config.AgentCommand)
+
+	env := []map[string]string{
+		{"name": "PANOPTIC_CONFIG", "value": base64.StdEncoding.EncodeToString(configYAML)},
+	}
+	names := make([]string, 0, len(extraEnv))
+	for name := range extraEnv {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		env = append(env, map[string]string{"name": name, "value": extraEnv[name]})
+	}
+
+	container := map[string]interface{}{
+		"name":    "panoptic",
+		"image":   r.Config.Image,
+		"command": []string{"/bin/sh", "-c", script},
+		"env":     env,
+	}
+	if len(r.Config.Resources.Requests) > 0 || len(r.Config.Resources.Limits) > 0 {
+		container["resources"] = r.Config.Resources
+	}
+
+	podSpec := map[string]interface{}{
+		"restartPolicy": "Never",
+		"containers":    []interface{}{container},
+	}
+	if r.Config.ServiceAccount != "" {
+		podSpec["serviceAccountName"] = r.Config.ServiceAccount
+	}
+
+	return map[string]interface{}{
+		"apiVersion": "batch/v1",
+		"kind":       "Job",
+		"metadata": map[string]interface{}{
+			"name":      jobName,
+			"namespace": r.Config.Namespace,
+			"labels":    labels,
+		},
+		"spec": map[string]interface{}{
+			"backoffLimit":          0,
+			"activeDeadlineSeconds": r.Config.TimeoutSeconds,
+			"template": map[string]interface{}{
+				"metadata": map[string]interface{}{"labels": labels},
+				"spec":     podSpec,
+			},
+		},
+	}
+}
+
+// RunJob creates a Job for configYAML, streams its pod logs back, waits for
+// completion and deletes the Job (and its pods) before returning
+func (r *KubernetesRunner) RunJob(ctx context.Context, appName string, configYAML []byte) (*KubernetesJobResult, error) {
+	ctx, cancel := context.WithTimeout(ctx, time.Duration(r.Config.TimeoutSeconds)*time.Second)
+	defer cancel()
+
+	result := &KubernetesJobResult{
+		JobName:   JobName(appName, time.Now()),
+		Namespace: r.Config.Namespace,
+		StartTime: time.Now(),
+	}
+
+	// Spans exported by the agent in the pod join the caller's trace
+	var extraEnv map[string]string
+	if sc, ok := telemetry.SpanContextFromContext(ctx); ok && sc.IsValid() {
+		extraEnv = map[string]string{telemetry.TraceParentEnv: sc.TraceParent()}
+	}
+
+	if err := r.request(ctx, http.MethodPost, r.jobsPath(""), r.buildJobManifest(result.JobName, configYAML, extraEnv), nil); err != nil {
+		return nil, fmt.Errorf("failed to create kubernetes job: %w", err)
+	}
+	r.Logger.Infof("Created kubernetes job %s/%s for app %s", r.Config.Namespace, result.JobName, appName)
+
+	defer r.deleteJob(result.JobName)
+
+	podName, err := r.waitForPod(ctx, result.JobName)
+	if err != nil {
+		return r.finish(result, err)
+	}
+	result.PodName = podName
+
+	logs, err := r.streamLogs(ctx, podName)
+	result.Logs = logs
+	if err != nil {
+		r.Logger.Warnf("Failed to stream logs for pod %s: %v", podName, err)
+	}
+
+	succeeded, err := r.waitForCompletion(ctx, result.JobName)
+	result.Succeeded = succeeded
+	if err == nil && !succeeded {
+		err = fmt.Errorf("kubernetes job %s failed", result.JobName)
+	}
+	return r.finish(result, err)
+}
+
+func (r *KubernetesRunner) finish(result *KubernetesJobResult, err error) (*KubernetesJobResult, error) {
+	result.EndTime = time.Now()
+	if err != nil {
+		result.Error = err.Error()
+	}
+	return result, err
+}
+
+func (r *KubernetesRunner) jobsPath(name string) string {
+	path := "/apis/batch/v1/namespaces/" + url.PathEscape(r.Config.Namespace) + "/jobs"
+	if name != "" {
+		path += "/" + url.PathEscape(name)
+	}
+	return path
+}
+
+// waitForPod waits until the job's pod has left the Pending phase and returns its name
+func (r *KubernetesRunner) waitForPod(ctx context.Context, jobName string) (string, error) {
+	path := "/api/v1/namespaces/" + url.PathEscape(r.Config.Namespace) + "/pods?labelSelector=" + url.QueryEscape("job-name="+jobName)
+
+	for {
+		var pods struct {
+			Items []struct {
+				Metadata struct {
+					Name string `json:"name"`
+				} `json:"metadata"`
+				Status struct {
+					Phase string `json:"phase"`
+				} `json:"status"`
+			} `json:"items"`
+		}
+		if err := r.request(ctx, http.MethodGet, path, nil, &pods); err != nil {
+			return "", fmt.Errorf("failed to list pods for job %s: %w", jobName, err)
+		}
+		for _, pod := range pods.Items {
+			if pod.Status.Phase != "" && pod.Status.Phase != "Pending" {
+				return pod.Metadata.Name, nil
+			}
+		}
+		if err := r.sleep(ctx); err != nil {
+			return "", fmt.Errorf("timed out waiting for pod of job %s: %w", jobName, err)
+		}
+	}
+}
+
+// streamLogs follows the pod's logs, forwarding each line to the logger
+func (r *KubernetesRunner) streamLogs(ctx context.Context, podName string) (string, error) {
+	path := "/api/v1/namespaces/" + url.PathEscape(r.Config.Namespace) + "/pods/" + url.PathEscape(podName) + "/log?follow=true"
+
+	resp, err := r.do(ctx, http.MethodGet, path, nil)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	var logs strings.Builder
+	scanner := bufio.NewScanner(resp.Body)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+		logs.WriteString(line)
+		logs.WriteByte('\n')
+		r.Logger.Infof("[%s] %s", podName, line)
+	}
+	return logs.String(), scanner.Err()
+}
+
+// waitForCompletion polls the job until it reports succeeded or failed pods
+func (r *KubernetesRunner) waitForCompletion(ctx context.Context, jobName string) (bool, error) {
+	for {
+		var job struct {
+			Status struct {
+				Succeeded int `json:"succeeded"`
+				Failed    int `json:"failed"`
+			} `json:"status"`
+		}
+		if err := r.request(ctx, http.MethodGet, r.jobsPath(jobName), nil, &job); err != nil {
+			return false, fmt.Errorf("failed to get job %s: %w", jobName, err)
+		}
+		if job.Status.Succeeded > 0 {
+			return true, nil
+		}
+		if job.Status.Failed > 0 {
+			return false, nil
+		}
+		if err := r.sleep(ctx); err != nil {
+			return false, fmt.Errorf("timed out waiting for job %s: %w", jobName, err)
+		}
+	}
+}
+
+// deleteJob tears down the job and its pods; uses a fresh context so cleanup
+// still happens after the run context expired
+func (r *KubernetesRunner) deleteJob(jobName string) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	body := map[string]interface{}{
+		"kind":              "DeleteOptions",
+		"apiVersion":        "v1",
+		"propagationPolicy": "Background",
+	}
+	if err := r.request(ctx, http.MethodDelete, r.jobsPath(jobName), body, nil); err != nil {
+		r.Logger.Errorf("Failed to delete kubernetes job %s: %v", jobName, err)
+		return
+	}
+	r.Logger.Debugf("Deleted kubernetes job %s", jobName)
+}
+
+func (r *KubernetesRunner) sleep(ctx context.Context) error {
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-time.After(time.Duration(r.Config.PollInterval) * time.Second):
+		return nil
+	}
+}
+
+func (r *KubernetesRunner) do(ctx context.Context, method, path string, body interface{}) (*http.Response, error) {
+	var payload io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal request: %w", err)
+		}
+		payload = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, r.baseURL+path, payload)
+	if err != nil {
+		return nil, err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	req.Header.Set("Accept", "application/json")
+	if r.token != "" {
+		req.Header.Set("Authorization", "Bearer "+r.token)
+	}
+
+	resp, err := r.Client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		defer resp.Body.Close()
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		return nil, fmt.Errorf("%s %s returned status %d: %s", method, path, resp.StatusCode, strings.TrimSpace(string(msg)))
+	}
+	return resp, nil
+}
+
+func (r *KubernetesRunner) request(ctx context.Context, method, path string, body, out interface{}) error {
+	resp, err := r.do(ctx, method, path, body)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if out != nil {
+		if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+			return fmt.Errorf("failed to decode response: %w", err)
+		}
+	}
+	return nil
+}
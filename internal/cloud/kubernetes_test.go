@@ -0,0 +1,241 @@
+package cloud
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"panoptic/internal/logger"
+	"panoptic/internal/telemetry"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeKubeAPI is a minimal in-memory stand-in for the Kubernetes API server
+type fakeKubeAPI struct {
+	mu      sync.Mutex
+	created map[string]interface{}
+	deleted []string
+	succeed bool
+	logs    string
+}
+
+func (f *fakeKubeAPI) handler(t *testing.T) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "Bearer kube-token", r.Header.Get("Authorization"))
+
+		f.mu.Lock()
+		defer f.mu.Unlock()
+
+		switch {
+		case r.Method == http.MethodPost && r.URL.Path == "/apis/batch/v1/namespaces/tests/jobs":
+			var job map[string]interface{}
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&job))
+			f.created = job
+			w.WriteHeader(http.StatusCreated)
+			_ = json.NewEncoder(w).Encode(job)
+		case r.Method == http.MethodGet && r.URL.Path == "/api/v1/namespaces/tests/pods":
+			assert.True(t, strings.HasPrefix(r.URL.Query().Get("labelSelector"), "job-name=panoptic-"))
+			fmt.Fprint(w, `{"items":[{"metadata":{"name":"pod-1"},"status":{"phase":"Running"}}]}`)
+		case r.Method == http.MethodGet && r.URL.Path == "/api/v1/namespaces/tests/pods/pod-1/log":
+			assert.Equal(t, "true", r.URL.Query().Get("follow"))
+			fmt.Fprint(w, f.logs)
+		case r.Method == http.MethodGet && strings.HasPrefix(r.URL.Path, "/apis/batch/v1/namespaces/tests/jobs/"):
+			if f.succeed {
+				fmt.Fprint(w, `{"status":{"succeeded":1}}`)
+			} else {
+				fmt.Fprint(w, `{"status":{"failed":1}}`)
+			}
+		case r.Method == http.MethodDelete && strings.HasPrefix(r.URL.Path, "/apis/batch/v1/namespaces/tests/jobs/"):
+			var opts map[string]interface{}
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&opts))
+			assert.Equal(t, "Background", opts["propagationPolicy"])
+			f.deleted = append(f.deleted, strings.TrimPrefix(r.URL.Path, "/apis/batch/v1/namespaces/tests/jobs/"))
+			fmt.Fprint(w, `{}`)
+		default:
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+			w.WriteHeader(http.StatusNotFound)
+		}
+	})
+}
+
+func newTestKubernetesRunner(t *testing.T, server *httptest.Server) *KubernetesRunner {
+	t.Helper()
+	runner, err := NewKubernetesRunner(*logger.NewLogger(false), KubernetesConfig{
+		APIServer:    server.URL,
+		Token:        "kube-token",
+		Namespace:    "tests",
+		Image:        "panoptic/agent:latest",
+		PollInterval: 1,
+		Resources: KubernetesResources{
+			Requests: map[string]string{"cpu": "500m", "memory": "1Gi"},
+			Limits:   map[string]string{"memory": "2Gi"},
+		},
+	})
+	require.NoError(t, err)
+	return runner
+}
+
+// TestNewKubernetesRunner_Validation tests required configuration and defaults
+func TestNewKubernetesRunner_Validation(t *testing.T) {
+	t.Setenv("KUBERNETES_SERVICE_HOST", "")
+
+	_, err := NewKubernetesRunner(*logger.NewLogger(false), KubernetesConfig{APIServer: "https://k8s"})
+	assert.Error(t, err, "image is required")
+
+	_, err = NewKubernetesRunner(*logger.NewLogger(false), KubernetesConfig{Image: "img"})
+	assert.Error(t, err, "api server is required outside a cluster")
+
+	runner, err := NewKubernetesRunner(*logger.NewLogger(false), KubernetesConfig{APIServer: "https://k8s/", Image: "img"})
+	require.NoError(t, err)
+	assert.Equal(t, "default", runner.Config.Namespace)
+	assert.Equal(t, "panoptic", runner.Config.AgentCommand)
+	assert.Equal(t, 900, runner.Config.TimeoutSeconds)
+	assert.Equal(t, "https://k8s", runner.baseURL)
+}
+
+// TestJobName tests that job names are valid DNS labels
+func TestJobName(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+
+	name := JobName("My Web_App!", now)
+	assert.True(t, strings.HasPrefix(name, "panoptic-my-web-app-"), name)
+	assert.Regexp(t, `^[a-z0-9-]+$`, name)
+
+	long := JobName(strings.Repeat("a", 100), now)
+	assert.LessOrEqual(t, len(long), 63)
+
+	assert.True(t, strings.HasPrefix(JobName("***", now), "panoptic-app-"))
+}
+
+// TestKubernetesRunner_BuildJobManifest tests the generated Job spec
+func TestKubernetesRunner_BuildJobManifest(t *testing.T) {
+	runner := newTestKubernetesRunner(t, httptest.NewServer(http.NotFoundHandler()))
+	runner.Config.ServiceAccount = "panoptic-runner"
+
+	manifest := runner.BuildJobManifest("panoptic-web-1", []byte("apps: []\n"))
+
+	data, err := json.Marshal(manifest)
+	require.NoError(t, err)
+
+	var job struct {
+		Kind     string `json:"kind"`
+		Metadata struct {
+			Name      string `json:"name"`
+			Namespace string `json:"namespace"`
+		} `json:"metadata"`
+		Spec struct {
+			BackoffLimit int `json:"backoffLimit"`
+			Template     struct {
+				Spec struct {
+					RestartPolicy      string `json:"restartPolicy"`
+					ServiceAccountName string `json:"serviceAccountName"`
+					Containers         []struct {
+						Image     string              `json:"image"`
+						Env       []map[string]string `json:"env"`
+						Resources KubernetesResources `json:"resources"`
+					} `json:"containers"`
+				} `json:"spec"`
+			} `json:"template"`
+		} `json:"spec"`
+	}
+	require.NoError(t, json.Unmarshal(data, &job))
+
+	assert.Equal(t, "Job", job.Kind)
+	assert.Equal(t, "panoptic-web-1", job.Metadata.Name)
+	assert.Equal(t, "tests", job.Metadata.Namespace)
+	assert.Equal(t, 0, job.Spec.BackoffLimit)
+	assert.Equal(t, "Never", job.Spec.Template.Spec.RestartPolicy)
+	assert.Equal(t, "panoptic-runner", job.Spec.Template.Spec.ServiceAccountName)
+
+	require.Len(t, job.Spec.Template.Spec.Containers, 1)
+	container := job.Spec.Template.Spec.Containers[0]
+	assert.Equal(t, "panoptic/agent:latest", container.Image)
+	assert.Equal(t, "2Gi", container.Resources.Limits["memory"])
+	assert.Equal(t, "500m", container.Resources.Requests["cpu"])
+
+	require.Len(t, container.Env, 1)
+	decoded, err := base64.StdEncoding.DecodeString(container.Env[0]["value"])
+	require.NoError(t, err)
+	assert.Equal(t, "apps: []\n", string(decoded))
+}
+
+// TestKubernetesRunner_RunJob tests job creation, log streaming and teardown
+func TestKubernetesRunner_RunJob(t *testing.T) {
+	api := &fakeKubeAPI{succeed: true, logs: "starting\nall tests passed\n"}
+	server := httptest.NewServer(api.handler(t))
+	defer server.Close()
+
+	runner := newTestKubernetesRunner(t, server)
+
+	result, err := runner.RunJob(context.Background(), "web", []byte("apps: []\n"))
+
+	require.NoError(t, err)
+	assert.True(t, result.Succeeded)
+	assert.Equal(t, "pod-1", result.PodName)
+	assert.Equal(t, "tests", result.Namespace)
+	assert.Contains(t, result.Logs, "all tests passed")
+	assert.NotNil(t, api.created)
+	assert.Equal(t, []string{result.JobName}, api.deleted, "job should be torn down")
+}
+
+// TestKubernetesRunner_RunJob_TraceParent tests that the caller's span context reaches the pod
+func TestKubernetesRunner_RunJob_TraceParent(t *testing.T) {
+	api := &fakeKubeAPI{succeed: true}
+	server := httptest.NewServer(api.handler(t))
+	defer server.Close()
+
+	runner := newTestKubernetesRunner(t, server)
+	traceParent := "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+	ctx, err := telemetry.ContextWithRemoteParent(context.Background(), traceParent)
+	require.NoError(t, err)
+
+	_, err = runner.RunJob(ctx, "web", []byte("apps: []\n"))
+	require.NoError(t, err)
+
+	data, err := json.Marshal(api.created)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), `{"name":"TRACEPARENT","value":"`+traceParent+`"}`)
+}
+
+// TestKubernetesRunner_RunJob_Failed tests that failed jobs are reported and still deleted
+func TestKubernetesRunner_RunJob_Failed(t *testing.T) {
+	api := &fakeKubeAPI{succeed: false, logs: "assertion failed\n"}
+	server := httptest.NewServer(api.handler(t))
+	defer server.Close()
+
+	runner := newTestKubernetesRunner(t, server)
+
+	result, err := runner.RunJob(context.Background(), "web", []byte("apps: []\n"))
+
+	assert.Error(t, err)
+	require.NotNil(t, result)
+	assert.False(t, result.Succeeded)
+	assert.Contains(t, result.Error, "failed")
+	assert.Contains(t, result.Logs, "assertion failed")
+	assert.Len(t, api.deleted, 1)
+}
+
+// TestKubernetesRunner_RunJob_CreateRejected tests API errors on job creation
+func TestKubernetesRunner_RunJob_CreateRejected(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+		fmt.Fprint(w, `jobs.batch is forbidden`)
+	}))
+	defer server.Close()
+
+	runner := newTestKubernetesRunner(t, server)
+
+	_, err := runner.RunJob(context.Background(), "web", nil)
+
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "forbidden")
+}
@@ -10,6 +10,7 @@ import (
 	"reflect"
 	"sort"
 	"strings"
+	"sync"
 	"time"
 
 	"panoptic/internal/logger"
@@ -33,6 +34,13 @@ type CloudManager struct {
 	Config      CloudConfig
 	Enabled     bool
 	TestResults []CloudTestResult
+	Registry    *NodeRegistry    // optional in-process node registry
+	Dispatch    NodeDispatchFunc // optional; runs a test on one node, defaults to the node's endpoint
+
+	// One scheduler for all distributed tests, so placement sees the
+	// load of the tests already running on each node
+	schedulerMu sync.Mutex
+	scheduler   *NodeScheduler
 }
 
 // CloudConfig contains cloud integration settings
@@ -53,6 +61,8 @@ type CloudConfig struct {
 	BackupLocations   []string          `yaml:"backup_locations"`
 	EnableDistributed bool              `yaml:"enable_distributed"`
 	DistributedNodes  []DistributedNode `yaml:"distributed_nodes"`
+	Scheduling        SchedulingConfig  `yaml:"scheduling"`
+	Registry          RegistryConfig    `yaml:"registry"`
 }
 
 // RetentionPolicy defines file retention settings
@@ -160,7 +170,22 @@ func NewCloudManager(log logger.Logger) *CloudManager {
 		Logger:      log,
 		Enabled:     false,
 		TestResults: make([]CloudTestResult, 0),
+		scheduler:   NewNodeScheduler(log, SchedulingConfig{}),
+	}
+}
+
+// nodeScheduler returns the manager's scheduler with the current scheduling
+// configuration applied, creating it for managers not built by NewCloudManager
+func (cm *CloudManager) nodeScheduler() *NodeScheduler {
+	cm.schedulerMu.Lock()
+	defer cm.schedulerMu.Unlock()
+
+	if cm.scheduler == nil {
+		cm.scheduler = NewNodeScheduler(cm.Logger, cm.Config.Scheduling)
+	} else {
+		cm.scheduler.Reconfigure(cm.Config.Scheduling)
 	}
+	return cm.scheduler
 }
 
 // Configure configures cloud manager with settings
@@ -254,27 +279,31 @@ func (cm *CloudManager) ExecuteDistributedTest(ctx context.Context, testConfig i
 		return nil, fmt.Errorf("distributed testing is not enabled")
 	}
 
+	// Merge static nodes with nodes discovered through the registry
+	nodes, err := cm.ResolveNodes(ctx, nodes)
+	if err != nil {
+		return nil, err
+	}
+
 	cm.Logger.Infof("Executing distributed test across %d nodes", len(nodes))
 
-	var results []CloudTestResult
 	testID := fmt.Sprintf("test_%d", time.Now().Unix())
 
-	// Execute test on each node
-	for _, node := range nodes {
-		nodeResult, err := cm.executeTestOnNode(ctx, testConfig, node, testID)
-		if err != nil {
-			cm.Logger.Errorf("Failed to execute test on node %s: %v", node.Name, err)
-			continue
-		}
-
-		results = append(results, *nodeResult)
+	// Place the test on nodes according to the scheduling strategy
+	dispatch := cm.Dispatch
+	if dispatch == nil {
+		dispatch = cm.executeTestOnNode
+	}
+	results, err := cm.nodeScheduler().Schedule(ctx, testConfig, nodes, testID, dispatch)
+	if err != nil {
+		return nil, fmt.Errorf("failed to schedule distributed test: %w", err)
 	}
 
 	// Store results in cloud storage
-	for i, result := range results {
-		resultPath := fmt.Sprintf("distributed_tests/%s/node_%s_result.json", testID, nodes[i].ID)
-		if err := cm.storeTestResult(ctx, &result, resultPath); err != nil {
-			cm.Logger.Errorf("Failed to store test result for node %s: %v", nodes[i].Name, err)
+	for i := range results {
+		resultPath := fmt.Sprintf("distributed_tests/%s/node_%s_result.json", testID, results[i].NodeID)
+		if err := cm.storeTestResult(ctx, &results[i], resultPath); err != nil {
+			cm.Logger.Errorf("Failed to store test result for node %s: %v", results[i].NodeName, err)
 		}
 	}
 
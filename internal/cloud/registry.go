@@ -0,0 +1,505 @@
+package cloud
+
+import (
+	"bytes"
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"panoptic/internal/logger"
+)
+
+// DefaultNodeStaleAfter is how long a node may go without a heartbeat before it is pruned
+const DefaultNodeStaleAfter = 90 * time.Second
+
+// RegistryConfig points CloudManager at a node registry service
+type RegistryConfig struct {
+	URL      string            `yaml:"url"`      // base URL of the registry service
+	APIKey   string            `yaml:"api_key"`  // bearer token for the registry
+	Selector map[string]string `yaml:"selector"` // only nodes carrying all these labels are used
+}
+
+// Node health an agent reports
+const (
+	NodeHealthy  = "ok"
+	NodeUpdating = "updating" // installing a release of its channel
+	NodeDegraded = "degraded" // running, but its last update failed
+)
+
+// NodeRegistration is the payload an agent sends to register itself
+type NodeRegistration struct {
+	Node   DistributedNode   `json:"node"`
+	Labels map[string]string `json:"labels"` // os, browser versions, region, ...
+	Status NodeStatus        `json:"status"`
+}
+
+// NodeStatus is the version and health an agent reports with its
+// heartbeats
+type NodeStatus struct {
+	Version    string    `json:"version,omitempty"`
+	Health     string    `json:"health,omitempty"`
+	Message    string    `json:"message,omitempty"` // such as why an update failed
+	ReportedAt time.Time `json:"reported_at,omitempty"`
+}
+
+// RegisteredNode is a node tracked by the registry
+type RegisteredNode struct {
+	Node          DistributedNode   `json:"node"`
+	Labels        map[string]string `json:"labels"`
+	Status        NodeStatus        `json:"status"`
+	RegisteredAt  time.Time         `json:"registered_at"`
+	LastHeartbeat time.Time         `json:"last_heartbeat"`
+}
+
+// NodeRegistry tracks self-registered agents and their heartbeats
+type NodeRegistry struct {
+	Logger     logger.Logger
+	StaleAfter time.Duration
+	ReleaseDir string // served under /releases/ for agents to update from
+
+	mu    sync.RWMutex
+	nodes map[string]*RegisteredNode
+	now   func() time.Time
+}
+
+// NewNodeRegistry creates an empty node registry
+func NewNodeRegistry(log logger.Logger, staleAfter time.Duration) *NodeRegistry {
+	if staleAfter <= 0 {
+		staleAfter = DefaultNodeStaleAfter
+	}
+	return &NodeRegistry{
+		Logger:     log,
+		StaleAfter: staleAfter,
+		nodes:      make(map[string]*RegisteredNode),
+		now:        time.Now,
+	}
+}
+
+// Register adds or replaces a node registration
+func (r *NodeRegistry) Register(reg NodeRegistration) error {
+	if reg.Node.ID == "" {
+		return fmt.Errorf("node ID is required")
+	}
+	if reg.Node.Endpoint == "" {
+		return fmt.Errorf("node endpoint is required")
+	}
+
+	labels := make(map[string]string, len(reg.Labels))
+	for k, v := range reg.Labels {
+		labels[k] = v
+	}
+	if reg.Node.Location == "" && labels["region"] != "" {
+		reg.Node.Location = labels["region"]
+	}
+
+	now := r.now()
+	r.mu.Lock()
+	entry := &RegisteredNode{
+		Node:          reg.Node,
+		Labels:        labels,
+		Status:        reg.Status,
+		RegisteredAt:  now,
+		LastHeartbeat: now,
+	}
+	if entry.Status != (NodeStatus{}) {
+		entry.Status.ReportedAt = now
+	}
+	if existing, ok := r.nodes[reg.Node.ID]; ok {
+		entry.RegisteredAt = existing.RegisteredAt
+	}
+	r.nodes[reg.Node.ID] = entry
+	r.mu.Unlock()
+
+	r.Logger.Infof("Registered node %s (%s)", reg.Node.ID, reg.Node.Endpoint)
+	return nil
+}
+
+// Heartbeat refreshes a node's liveness timestamp
+func (r *NodeRegistry) Heartbeat(nodeID string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	entry, ok := r.nodes[nodeID]
+	if !ok {
+		return fmt.Errorf("node not registered: %s", nodeID)
+	}
+	entry.LastHeartbeat = r.now()
+	return nil
+}
+
+// Report refreshes a node's liveness and records the status it reports
+func (r *NodeRegistry) Report(nodeID string, status NodeStatus) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	entry, ok := r.nodes[nodeID]
+	if !ok {
+		return fmt.Errorf("node not registered: %s", nodeID)
+	}
+	now := r.now()
+	if entry.Status.Version != "" && status.Version != "" && entry.Status.Version != status.Version {
+		r.Logger.Infof("Node %s now runs %s (was %s)", nodeID, status.Version, entry.Status.Version)
+	}
+	entry.LastHeartbeat = now
+	status.ReportedAt = now
+	entry.Status = status
+	return nil
+}
+
+// Deregister removes a node from the registry
+func (r *NodeRegistry) Deregister(nodeID string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.nodes[nodeID]; !ok {
+		return fmt.Errorf("node not registered: %s", nodeID)
+	}
+	delete(r.nodes, nodeID)
+	return nil
+}
+
+// Prune removes nodes whose last heartbeat is older than StaleAfter and
+// returns the IDs that were removed
+func (r *NodeRegistry) Prune() []string {
+	cutoff := r.now().Add(-r.StaleAfter)
+
+	r.mu.Lock()
+	var removed []string
+	for id, entry := range r.nodes {
+		if entry.LastHeartbeat.Before(cutoff) {
+			delete(r.nodes, id)
+			removed = append(removed, id)
+		}
+	}
+	r.mu.Unlock()
+
+	sort.Strings(removed)
+	for _, id := range removed {
+		r.Logger.Warnf("Removed stale node %s", id)
+	}
+	return removed
+}
+
+// List returns live registrations matching every label in selector, sorted by ID
+func (r *NodeRegistry) List(selector map[string]string) []RegisteredNode {
+	cutoff := r.now().Add(-r.StaleAfter)
+
+	r.mu.RLock()
+	list := make([]RegisteredNode, 0, len(r.nodes))
+	for _, entry := range r.nodes {
+		if entry.LastHeartbeat.Before(cutoff) || !labelsMatch(entry.Labels, selector) {
+			continue
+		}
+		list = append(list, *entry)
+	}
+	r.mu.RUnlock()
+
+	sort.Slice(list, func(i, j int) bool { return list[i].Node.ID < list[j].Node.ID })
+	return list
+}
+
+// Nodes returns the live nodes matching selector
+func (r *NodeRegistry) Nodes(selector map[string]string) []DistributedNode {
+	list := r.List(selector)
+	nodes := make([]DistributedNode, 0, len(list))
+	for _, entry := range list {
+		nodes = append(nodes, entry.Node)
+	}
+	return nodes
+}
+
+// StartPruning prunes stale nodes at the given interval until ctx is cancelled
+func (r *NodeRegistry) StartPruning(ctx context.Context, interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				r.Prune()
+			}
+		}
+	}()
+}
+
+// labelsMatch reports whether labels contains every key/value in selector
+func labelsMatch(labels, selector map[string]string) bool {
+	for k, v := range selector {
+		if labels[k] != v {
+			return false
+		}
+	}
+	return true
+}
+
+// Handler exposes the registry over HTTP:
+//
+//	POST   /nodes                  register (NodeRegistration body)
+//	POST   /nodes/{id}/heartbeat   refresh liveness, and status given a NodeStatus body
+//	DELETE /nodes/{id}             deregister
+//	GET    /nodes?label=value      list live nodes matching the query labels
+//	GET    /releases/{file}        the release manifests and binaries of ReleaseDir
+func (r *NodeRegistry) Handler(apiKey string) http.Handler {
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("/nodes", func(w http.ResponseWriter, req *http.Request) {
+		switch req.Method {
+		case http.MethodPost:
+			var reg NodeRegistration
+			if err := json.NewDecoder(req.Body).Decode(&reg); err != nil {
+				http.Error(w, fmt.Sprintf("invalid registration: %v", err), http.StatusBadRequest)
+				return
+			}
+			if err := r.Register(reg); err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			w.WriteHeader(http.StatusCreated)
+		case http.MethodGet:
+			selector := make(map[string]string)
+			for k, v := range req.URL.Query() {
+				if len(v) > 0 {
+					selector[k] = v[0]
+				}
+			}
+			w.Header().Set("Content-Type", "application/json")
+			json.NewEncoder(w).Encode(r.List(selector))
+		default:
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		}
+	})
+
+	mux.HandleFunc("/nodes/", func(w http.ResponseWriter, req *http.Request) {
+		rest := strings.Trim(strings.TrimPrefix(req.URL.Path, "/nodes/"), "/")
+		parts := strings.Split(rest, "/")
+
+		switch {
+		case len(parts) == 2 && parts[1] == "heartbeat" && req.Method == http.MethodPost:
+			var status NodeStatus
+			err := json.NewDecoder(req.Body).Decode(&status)
+			switch {
+			case err == io.EOF:
+				err = r.Heartbeat(parts[0])
+			case err != nil:
+				http.Error(w, fmt.Sprintf("invalid status: %v", err), http.StatusBadRequest)
+				return
+			default:
+				err = r.Report(parts[0], status)
+			}
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusNotFound)
+				return
+			}
+			w.WriteHeader(http.StatusNoContent)
+		case len(parts) == 1 && parts[0] != "" && req.Method == http.MethodDelete:
+			if err := r.Deregister(parts[0]); err != nil {
+				http.Error(w, err.Error(), http.StatusNotFound)
+				return
+			}
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			http.Error(w, "not found", http.StatusNotFound)
+		}
+	})
+
+	if r.ReleaseDir != "" {
+		mux.Handle("/releases/", http.StripPrefix("/releases/", http.FileServer(http.Dir(r.ReleaseDir))))
+	}
+
+	if apiKey == "" {
+		return mux
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if subtle.ConstantTimeCompare([]byte(req.Header.Get("Authorization")), []byte("Bearer "+apiKey)) != 1 {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		mux.ServeHTTP(w, req)
+	})
+}
+
+// RegistryClient talks to a remote NodeRegistry over HTTP
+type RegistryClient struct {
+	BaseURL string
+	APIKey  string
+	Client  *http.Client
+	Status  func() NodeStatus // reported by RunHeartbeat, when set
+}
+
+// NewRegistryClient creates a client for the registry at baseURL
+func NewRegistryClient(baseURL, apiKey string) *RegistryClient {
+	return &RegistryClient{
+		BaseURL: strings.TrimRight(baseURL, "/"),
+		APIKey:  apiKey,
+		Client:  &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+func (c *RegistryClient) do(ctx context.Context, method, path string, body interface{}, out interface{}) error {
+	var payload *bytes.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return fmt.Errorf("failed to marshal request: %w", err)
+		}
+		payload = bytes.NewReader(data)
+	} else {
+		payload = bytes.NewReader(nil)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, c.BaseURL+path, payload)
+	if err != nil {
+		return fmt.Errorf("failed to build registry request: %w", err)
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if c.APIKey != "" {
+		req.Header.Set("Authorization", "Bearer "+c.APIKey)
+	}
+
+	resp, err := c.Client.Do(req)
+	if err != nil {
+		return fmt.Errorf("registry request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("registry %s %s returned status %d", method, path, resp.StatusCode)
+	}
+	if out != nil {
+		if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+			return fmt.Errorf("failed to decode registry response: %w", err)
+		}
+	}
+	return nil
+}
+
+// Register registers a node with the remote registry
+func (c *RegistryClient) Register(ctx context.Context, reg NodeRegistration) error {
+	return c.do(ctx, http.MethodPost, "/nodes", reg, nil)
+}
+
+// Heartbeat refreshes a node's liveness with the remote registry
+func (c *RegistryClient) Heartbeat(ctx context.Context, nodeID string) error {
+	return c.do(ctx, http.MethodPost, "/nodes/"+nodeID+"/heartbeat", nil, nil)
+}
+
+// Report refreshes a node's liveness with the remote registry and reports
+// its status
+func (c *RegistryClient) Report(ctx context.Context, nodeID string, status NodeStatus) error {
+	return c.do(ctx, http.MethodPost, "/nodes/"+nodeID+"/heartbeat", status, nil)
+}
+
+// Deregister removes a node from the remote registry
+func (c *RegistryClient) Deregister(ctx context.Context, nodeID string) error {
+	return c.do(ctx, http.MethodDelete, "/nodes/"+nodeID, nil, nil)
+}
+
+// Nodes lists the live nodes matching selector
+func (c *RegistryClient) Nodes(ctx context.Context, selector map[string]string) ([]DistributedNode, error) {
+	path := "/nodes"
+	if len(selector) > 0 {
+		query := url.Values{}
+		for k, v := range selector {
+			query.Set(k, v)
+		}
+		path += "?" + query.Encode()
+	}
+
+	var list []RegisteredNode
+	if err := c.do(ctx, http.MethodGet, path, nil, &list); err != nil {
+		return nil, err
+	}
+
+	nodes := make([]DistributedNode, 0, len(list))
+	for _, entry := range list {
+		nodes = append(nodes, entry.Node)
+	}
+	return nodes, nil
+}
+
+// RunHeartbeat registers the node and then sends heartbeats at the given
+// interval until ctx is cancelled, re-registering if the registry forgot it.
+// With Status set, each heartbeat reports it. The node is deregistered on
+// shutdown.
+func (c *RegistryClient) RunHeartbeat(ctx context.Context, reg NodeRegistration, interval time.Duration) error {
+	if c.Status != nil {
+		reg.Status = c.Status()
+	}
+	if err := c.Register(ctx, reg); err != nil {
+		return err
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			c.Deregister(shutdownCtx, reg.Node.ID)
+			return nil
+		case <-ticker.C:
+			heartbeat := func() error { return c.Heartbeat(ctx, reg.Node.ID) }
+			if c.Status != nil {
+				reg.Status = c.Status()
+				heartbeat = func() error { return c.Report(ctx, reg.Node.ID, reg.Status) }
+			}
+			if err := heartbeat(); err != nil {
+				if regErr := c.Register(ctx, reg); regErr != nil && ctx.Err() == nil {
+					return fmt.Errorf("heartbeat failed and re-registration failed: %w", regErr)
+				}
+			}
+		}
+	}
+}
+
+// ResolveNodes returns the nodes a distributed test should run on: the
+// statically configured nodes merged with any live nodes from the in-process
+// registry or the configured registry service. Static entries win on ID clashes.
+// An unreachable registry service only fails when there are no other nodes.
+func (cm *CloudManager) ResolveNodes(ctx context.Context, static []DistributedNode) ([]DistributedNode, error) {
+	nodes := make([]DistributedNode, 0, len(static))
+	seen := make(map[string]bool, len(static))
+	add := func(list []DistributedNode) {
+		for _, node := range list {
+			if node.ID != "" && seen[node.ID] {
+				continue
+			}
+			seen[node.ID] = true
+			nodes = append(nodes, node)
+		}
+	}
+
+	add(static)
+
+	if cm.Registry != nil {
+		add(cm.Registry.Nodes(cm.Config.Registry.Selector))
+	}
+
+	if cm.Config.Registry.URL != "" {
+		client := NewRegistryClient(cm.Config.Registry.URL, cm.Config.Registry.APIKey)
+		discovered, err := client.Nodes(ctx, cm.Config.Registry.Selector)
+		if err != nil {
+			if len(nodes) == 0 {
+				return nil, fmt.Errorf("failed to resolve nodes from registry: %w", err)
+			}
+			cm.Logger.Warnf("Failed to resolve nodes from registry, using the %d configured node(s): %v", len(nodes), err)
+		}
+		add(discovered)
+	}
+
+	return nodes, nil
+}
@@ -0,0 +1,283 @@
+package cloud
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"panoptic/internal/logger"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestRegistry(t *testing.T) (*NodeRegistry, *time.Time) {
+	t.Helper()
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	registry := NewNodeRegistry(*logger.NewLogger(false), time.Minute)
+	registry.now = func() time.Time { return now }
+	return registry, &now
+}
+
+// TestNodeRegistry_RegisterAndList tests registration and label selection
+func TestNodeRegistry_RegisterAndList(t *testing.T) {
+	registry, _ := newTestRegistry(t)
+
+	require.NoError(t, registry.Register(NodeRegistration{
+		Node:   DistributedNode{ID: "b", Endpoint: "http://b"},
+		Labels: map[string]string{"os": "linux", "region": "eu-west-1"},
+	}))
+	require.NoError(t, registry.Register(NodeRegistration{
+		Node:   DistributedNode{ID: "a", Endpoint: "http://a"},
+		Labels: map[string]string{"os": "windows"},
+	}))
+
+	all := registry.Nodes(nil)
+	require.Len(t, all, 2)
+	assert.Equal(t, "a", all[0].ID, "nodes should be sorted by ID")
+
+	linux := registry.Nodes(map[string]string{"os": "linux"})
+	require.Len(t, linux, 1)
+	assert.Equal(t, "b", linux[0].ID)
+	assert.Equal(t, "eu-west-1", linux[0].Location, "region label should fill in the location")
+}
+
+// TestNodeRegistry_RegisterValidation tests required registration fields
+func TestNodeRegistry_RegisterValidation(t *testing.T) {
+	registry, _ := newTestRegistry(t)
+
+	assert.Error(t, registry.Register(NodeRegistration{Node: DistributedNode{Endpoint: "http://x"}}))
+	assert.Error(t, registry.Register(NodeRegistration{Node: DistributedNode{ID: "x"}}))
+}
+
+// TestNodeRegistry_HeartbeatAndPrune tests that stale nodes are hidden and pruned
+func TestNodeRegistry_HeartbeatAndPrune(t *testing.T) {
+	registry, now := newTestRegistry(t)
+
+	require.NoError(t, registry.Register(NodeRegistration{Node: DistributedNode{ID: "live", Endpoint: "http://live"}}))
+	require.NoError(t, registry.Register(NodeRegistration{Node: DistributedNode{ID: "stale", Endpoint: "http://stale"}}))
+
+	*now = now.Add(45 * time.Second)
+	require.NoError(t, registry.Heartbeat("live"))
+
+	*now = now.Add(30 * time.Second)
+	nodes := registry.Nodes(nil)
+	require.Len(t, nodes, 1, "stale node should not be listed")
+	assert.Equal(t, "live", nodes[0].ID)
+
+	assert.Equal(t, []string{"stale"}, registry.Prune())
+	assert.Error(t, registry.Heartbeat("stale"), "pruned node must re-register")
+}
+
+// TestNodeRegistry_ReRegisterKeepsRegisteredAt tests that re-registration only refreshes liveness
+func TestNodeRegistry_ReRegisterKeepsRegisteredAt(t *testing.T) {
+	registry, now := newTestRegistry(t)
+	first := *now
+
+	require.NoError(t, registry.Register(NodeRegistration{Node: DistributedNode{ID: "n", Endpoint: "http://n"}}))
+	*now = now.Add(10 * time.Second)
+	require.NoError(t, registry.Register(NodeRegistration{Node: DistributedNode{ID: "n", Endpoint: "http://n2"}}))
+
+	list := registry.List(nil)
+	require.Len(t, list, 1)
+	assert.Equal(t, first, list[0].RegisteredAt)
+	assert.Equal(t, "http://n2", list[0].Node.Endpoint)
+}
+
+// TestRegistryClient_RoundTrip tests the HTTP handler and client together
+func TestRegistryClient_RoundTrip(t *testing.T) {
+	registry := NewNodeRegistry(*logger.NewLogger(false), time.Minute)
+	server := httptest.NewServer(registry.Handler("secret"))
+	defer server.Close()
+
+	ctx := context.Background()
+	client := NewRegistryClient(server.URL, "secret")
+
+	reg := NodeRegistration{
+		Node:   DistributedNode{ID: "agent-1", Endpoint: "http://agent-1", Capacity: "high"},
+		Labels: map[string]string{"os": "linux", "chrome": "120"},
+	}
+	require.NoError(t, client.Register(ctx, reg))
+	require.NoError(t, client.Heartbeat(ctx, "agent-1"))
+
+	nodes, err := client.Nodes(ctx, map[string]string{"chrome": "120"})
+	require.NoError(t, err)
+	require.Len(t, nodes, 1)
+	assert.Equal(t, "high", nodes[0].Capacity)
+
+	nodes, err = client.Nodes(ctx, map[string]string{"chrome": "99"})
+	require.NoError(t, err)
+	assert.Empty(t, nodes)
+
+	require.NoError(t, client.Deregister(ctx, "agent-1"))
+	assert.Error(t, client.Heartbeat(ctx, "agent-1"))
+
+	unauthorized := NewRegistryClient(server.URL, "wrong")
+	assert.Error(t, unauthorized.Register(ctx, reg))
+}
+
+// TestRegistryClient_RunHeartbeat tests agent registration, heartbeats and deregistration on shutdown
+func TestRegistryClient_RunHeartbeat(t *testing.T) {
+	registry := NewNodeRegistry(*logger.NewLogger(false), time.Minute)
+	server := httptest.NewServer(registry.Handler(""))
+	defer server.Close()
+
+	client := NewRegistryClient(server.URL, "")
+	ctx, cancel := context.WithCancel(context.Background())
+
+	done := make(chan error, 1)
+	go func() {
+		done <- client.RunHeartbeat(ctx, NodeRegistration{Node: DistributedNode{ID: "agent", Endpoint: "http://agent"}}, 10*time.Millisecond)
+	}()
+
+	require.Eventually(t, func() bool { return len(registry.Nodes(nil)) == 1 }, time.Second, 5*time.Millisecond)
+
+	// The agent re-registers after the registry forgets it
+	require.NoError(t, registry.Deregister("agent"))
+	require.Eventually(t, func() bool { return len(registry.Nodes(nil)) == 1 }, time.Second, 5*time.Millisecond)
+
+	cancel()
+	require.NoError(t, <-done)
+	assert.Empty(t, registry.Nodes(nil), "agent should deregister on shutdown")
+}
+
+// TestRegistryClient_ReportStatus tests agents reporting their version and health with heartbeats
+func TestRegistryClient_ReportStatus(t *testing.T) {
+	registry := NewNodeRegistry(*logger.NewLogger(false), time.Minute)
+	server := httptest.NewServer(registry.Handler(""))
+	defer server.Close()
+
+	var health atomic.Value
+	health.Store(NodeHealthy)
+	client := NewRegistryClient(server.URL, "")
+	client.Status = func() NodeStatus { return NodeStatus{Version: "v1.2.0", Health: health.Load().(string)} }
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		done <- client.RunHeartbeat(ctx, NodeRegistration{Node: DistributedNode{ID: "agent", Endpoint: "http://agent"}}, 10*time.Millisecond)
+	}()
+
+	require.Eventually(t, func() bool { return len(registry.List(nil)) == 1 }, time.Second, 5*time.Millisecond)
+	status := registry.List(nil)[0].Status
+	assert.Equal(t, "v1.2.0", status.Version, "the status is registered")
+	assert.False(t, status.ReportedAt.IsZero())
+
+	health.Store(NodeUpdating)
+	require.Eventually(t, func() bool {
+		list := registry.List(nil)
+		return len(list) == 1 && list[0].Status.Health == NodeUpdating
+	}, time.Second, 5*time.Millisecond)
+	cancel()
+	require.NoError(t, <-done)
+
+	// Heartbeats without a status keep the last one
+	require.NoError(t, registry.Register(NodeRegistration{Node: DistributedNode{ID: "n", Endpoint: "http://n"}, Status: NodeStatus{Version: "v1.1.0"}}))
+	require.NoError(t, client.Heartbeat(context.Background(), "n"))
+	assert.Equal(t, "v1.1.0", registry.List(nil)[0].Status.Version)
+	assert.Error(t, client.Report(context.Background(), "gone", NodeStatus{Version: "v1.2.0"}))
+}
+
+// TestNodeRegistry_Releases tests serving release files to agents behind the API key
+func TestNodeRegistry_Releases(t *testing.T) {
+	registry := NewNodeRegistry(*logger.NewLogger(false), time.Minute)
+	registry.ReleaseDir = t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(registry.ReleaseDir, "stable.json"), []byte(`{"version":"v1.2.0"}`), 0644))
+	server := httptest.NewServer(registry.Handler("secret"))
+	defer server.Close()
+
+	get := func(key string) *http.Response {
+		req, err := http.NewRequest(http.MethodGet, server.URL+"/releases/stable.json", nil)
+		require.NoError(t, err)
+		req.Header.Set("Authorization", "Bearer "+key)
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		t.Cleanup(func() { resp.Body.Close() })
+		return resp
+	}
+	resp := get("secret")
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	assert.Equal(t, `{"version":"v1.2.0"}`, string(body))
+	assert.Equal(t, http.StatusUnauthorized, get("wrong").StatusCode)
+}
+
+// TestCloudManager_ResolveNodes tests merging static and registry nodes
+func TestCloudManager_ResolveNodes(t *testing.T) {
+	remote := NewNodeRegistry(*logger.NewLogger(false), time.Minute)
+	require.NoError(t, remote.Register(NodeRegistration{
+		Node:   DistributedNode{ID: "remote", Endpoint: "http://remote"},
+		Labels: map[string]string{"os": "linux"},
+	}))
+	require.NoError(t, remote.Register(NodeRegistration{
+		Node:   DistributedNode{ID: "static", Endpoint: "http://shadowed"},
+		Labels: map[string]string{"os": "linux"},
+	}))
+	server := httptest.NewServer(remote.Handler(""))
+	defer server.Close()
+
+	local := NewNodeRegistry(*logger.NewLogger(false), time.Minute)
+	require.NoError(t, local.Register(NodeRegistration{
+		Node:   DistributedNode{ID: "local", Endpoint: "http://local"},
+		Labels: map[string]string{"os": "linux"},
+	}))
+
+	manager := &CloudManager{
+		Logger:   *logger.NewLogger(false),
+		Registry: local,
+		Config: CloudConfig{
+			Registry: RegistryConfig{URL: server.URL, Selector: map[string]string{"os": "linux"}},
+		},
+	}
+
+	nodes, err := manager.ResolveNodes(context.Background(), []DistributedNode{{ID: "static", Endpoint: "http://static"}})
+	require.NoError(t, err)
+
+	ids := make([]string, 0, len(nodes))
+	for _, n := range nodes {
+		ids = append(ids, n.ID)
+	}
+	assert.Equal(t, []string{"static", "local", "remote"}, ids)
+	assert.Equal(t, "http://static", nodes[0].Endpoint, "static config should win on ID clashes")
+}
+
+// TestCloudManager_ResolveNodes_RegistryUnavailable tests the error when the registry cannot be reached and no nodes are configured
+func TestCloudManager_ResolveNodes_RegistryUnavailable(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	manager := &CloudManager{
+		Logger: *logger.NewLogger(false),
+		Config: CloudConfig{Registry: RegistryConfig{URL: server.URL}},
+	}
+
+	_, err := manager.ResolveNodes(context.Background(), nil)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "registry")
+}
+
+// TestCloudManager_ResolveNodes_RegistryUnavailableFallsBack tests that static nodes are used when the registry cannot be reached
+func TestCloudManager_ResolveNodes_RegistryUnavailableFallsBack(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	manager := &CloudManager{
+		Logger: *logger.NewLogger(false),
+		Config: CloudConfig{Registry: RegistryConfig{URL: server.URL}},
+	}
+
+	nodes, err := manager.ResolveNodes(context.Background(), []DistributedNode{{ID: "static", Endpoint: "http://static"}})
+	require.NoError(t, err)
+	require.Len(t, nodes, 1)
+	assert.Equal(t, "static", nodes[0].ID)
+}
@@ -0,0 +1,352 @@
+package cloud
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"panoptic/internal/logger"
+)
+
+// Scheduling strategies understood by NodeScheduler
+const (
+	StrategyBroadcast     = "broadcast"      // run on every healthy node
+	StrategyLeastLoaded   = "least-loaded"   // run on the healthy node with the most spare capacity
+	StrategyRegionPinning = "region-pinning" // run on a healthy node in the configured region
+)
+
+// SchedulingConfig controls how distributed tests are placed on nodes
+type SchedulingConfig struct {
+	Strategy           string `yaml:"strategy"`             // broadcast, least-loaded, region-pinning
+	Region             string `yaml:"region"`               // required for region-pinning
+	MaxRetries         int    `yaml:"max_retries"`          // alternate nodes tried after the first failure
+	HealthCheck        bool   `yaml:"health_check"`         // probe nodes before dispatch
+	HealthCheckPath    string `yaml:"health_check_path"`    // defaults to /health
+	HealthCheckTimeout int    `yaml:"health_check_timeout"` // seconds
+}
+
+// NodeHealthChecker reports whether a node is able to accept work
+type NodeHealthChecker interface {
+	CheckHealth(ctx context.Context, node DistributedNode) error
+}
+
+// NodeDispatchFunc executes a test on a single node
+type NodeDispatchFunc func(ctx context.Context, testConfig interface{}, node DistributedNode, testID string) (*CloudTestResult, error)
+
+// HTTPHealthChecker probes a node by issuing a GET against its endpoint
+type HTTPHealthChecker struct {
+	Client *http.Client
+	Path   string
+}
+
+// NewHTTPHealthChecker creates a health checker with the given path and timeout
+func NewHTTPHealthChecker(path string, timeout time.Duration) *HTTPHealthChecker {
+	if path == "" {
+		path = "/health"
+	}
+	if timeout <= 0 {
+		timeout = 5 * time.Second
+	}
+	return &HTTPHealthChecker{
+		Client: &http.Client{Timeout: timeout},
+		Path:   path,
+	}
+}
+
+// CheckHealth returns nil when the node answers its health endpoint with a 2xx status
+func (hc *HTTPHealthChecker) CheckHealth(ctx context.Context, node DistributedNode) error {
+	if node.Endpoint == "" {
+		return fmt.Errorf("node %s has no endpoint", node.ID)
+	}
+
+	url := strings.TrimRight(node.Endpoint, "/") + "/" + strings.TrimLeft(hc.Path, "/")
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return fmt.Errorf("failed to build health request for node %s: %w", node.ID, err)
+	}
+	if node.APIKey != "" {
+		req.Header.Set("Authorization", "Bearer "+node.APIKey)
+	}
+
+	resp, err := hc.Client.Do(req)
+	if err != nil {
+		return fmt.Errorf("health check failed for node %s: %w", node.ID, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("health check failed for node %s: status %d", node.ID, resp.StatusCode)
+	}
+	return nil
+}
+
+// NodeScheduler places distributed tests on nodes according to a strategy,
+// weighing node priority and capacity and tracking in-flight load. Config
+// and Checker may be set before it is used, and changed with Reconfigure
+// after.
+type NodeScheduler struct {
+	Logger  logger.Logger
+	Config  SchedulingConfig
+	Checker NodeHealthChecker
+
+	mu       sync.Mutex
+	inFlight map[string]int
+}
+
+// NewNodeScheduler creates a scheduler for the given configuration
+func NewNodeScheduler(log logger.Logger, config SchedulingConfig) *NodeScheduler {
+	if config.Strategy == "" {
+		config.Strategy = StrategyBroadcast
+	}
+
+	scheduler := &NodeScheduler{
+		Logger:   log,
+		Config:   config,
+		inFlight: make(map[string]int),
+	}
+
+	if config.HealthCheck {
+		scheduler.Checker = NewHTTPHealthChecker(config.HealthCheckPath, time.Duration(config.HealthCheckTimeout)*time.Second)
+	}
+
+	return scheduler
+}
+
+// Reconfigure applies a new configuration, keeping the in-flight load. The
+// configuration and health checker are only replaced when it changed; tests
+// being scheduled keep the ones they started with.
+func (s *NodeScheduler) Reconfigure(config SchedulingConfig) {
+	fresh := NewNodeScheduler(s.Logger, config)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if fresh.Config == s.Config {
+		return
+	}
+	s.Config = fresh.Config
+	s.Checker = fresh.Checker
+}
+
+// settings returns the configuration and health checker together, as
+// Reconfigure may replace them while tests are scheduled
+func (s *NodeScheduler) settings() (SchedulingConfig, NodeHealthChecker) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.Config, s.Checker
+}
+
+// capacityWeight converts a node capacity label into a number of concurrent slots
+func capacityWeight(capacity string) int {
+	switch strings.ToLower(strings.TrimSpace(capacity)) {
+	case "high":
+		return 4
+	case "medium", "":
+		return 2
+	case "low":
+		return 1
+	}
+	if n, err := strconv.Atoi(strings.TrimSpace(capacity)); err == nil && n > 0 {
+		return n
+	}
+	return 1
+}
+
+// Load returns the number of tests currently dispatched to a node
+func (s *NodeScheduler) Load(nodeID string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.inFlight[nodeID]
+}
+
+func (s *NodeScheduler) acquire(nodeID string) {
+	s.mu.Lock()
+	s.inFlight[nodeID]++
+	s.mu.Unlock()
+}
+
+func (s *NodeScheduler) release(nodeID string) {
+	s.mu.Lock()
+	if s.inFlight[nodeID] > 0 {
+		s.inFlight[nodeID]--
+	}
+	s.mu.Unlock()
+}
+
+// Rank orders nodes by preference for the configured strategy. Nodes in the
+// pinned region come first for region-pinning; within a group, nodes with the
+// lowest load relative to capacity win, then lower Priority values, then ID.
+func (s *NodeScheduler) Rank(nodes []DistributedNode) []DistributedNode {
+	config, _ := s.settings()
+	return s.rank(config, nodes)
+}
+
+func (s *NodeScheduler) rank(config SchedulingConfig, nodes []DistributedNode) []DistributedNode {
+	ranked := make([]DistributedNode, len(nodes))
+	copy(ranked, nodes)
+
+	s.mu.Lock()
+	utilization := make(map[string]float64, len(ranked))
+	for _, node := range ranked {
+		utilization[node.ID] = float64(s.inFlight[node.ID]) / float64(capacityWeight(node.Capacity))
+	}
+	s.mu.Unlock()
+
+	pinned := func(node DistributedNode) bool {
+		return config.Strategy == StrategyRegionPinning && strings.EqualFold(node.Location, config.Region)
+	}
+
+	sort.SliceStable(ranked, func(i, j int) bool {
+		a, b := ranked[i], ranked[j]
+		if pinned(a) != pinned(b) {
+			return pinned(a)
+		}
+		if utilization[a.ID] != utilization[b.ID] {
+			return utilization[a.ID] < utilization[b.ID]
+		}
+		if a.Priority != b.Priority {
+			return a.Priority < b.Priority
+		}
+		if capacityWeight(a.Capacity) != capacityWeight(b.Capacity) {
+			return capacityWeight(a.Capacity) > capacityWeight(b.Capacity)
+		}
+		return a.ID < b.ID
+	})
+
+	return ranked
+}
+
+// healthyNodes filters out nodes that fail their health check
+func (s *NodeScheduler) healthyNodes(ctx context.Context, checker NodeHealthChecker, nodes []DistributedNode) []DistributedNode {
+	if checker == nil {
+		return nodes
+	}
+
+	healthy := make([]DistributedNode, 0, len(nodes))
+	for _, node := range nodes {
+		if err := checker.CheckHealth(ctx, node); err != nil {
+			s.Logger.Warnf("Skipping unhealthy node %s: %v", node.ID, err)
+			continue
+		}
+		healthy = append(healthy, node)
+	}
+	return healthy
+}
+
+// Schedule dispatches a test across nodes using the configured strategy.
+// Broadcast returns one result per healthy node, recording failures as
+// unsuccessful results. Single-target strategies try ranked candidates in
+// order, moving to an alternate node on failure up to MaxRetries times.
+func (s *NodeScheduler) Schedule(ctx context.Context, testConfig interface{}, nodes []DistributedNode, testID string, dispatch NodeDispatchFunc) ([]CloudTestResult, error) {
+	if dispatch == nil {
+		return nil, fmt.Errorf("no dispatch function provided")
+	}
+
+	config, checker := s.settings()
+	candidates := s.healthyNodes(ctx, checker, nodes)
+	if len(nodes) > 0 && len(candidates) == 0 {
+		return nil, fmt.Errorf("no healthy nodes available (%d configured)", len(nodes))
+	}
+
+	switch config.Strategy {
+	case StrategyBroadcast:
+		return s.broadcast(ctx, testConfig, s.rank(config, candidates), testID, dispatch), nil
+	case StrategyLeastLoaded:
+		return s.dispatchWithRetry(ctx, config, testConfig, s.rank(config, candidates), testID, dispatch)
+	case StrategyRegionPinning:
+		if config.Region == "" {
+			return nil, fmt.Errorf("region-pinning strategy requires a region")
+		}
+		regional := make([]DistributedNode, 0, len(candidates))
+		for _, node := range candidates {
+			if strings.EqualFold(node.Location, config.Region) {
+				regional = append(regional, node)
+			}
+		}
+		if len(regional) == 0 {
+			return nil, fmt.Errorf("no healthy nodes available in region %s", config.Region)
+		}
+		return s.dispatchWithRetry(ctx, config, testConfig, s.rank(config, regional), testID, dispatch)
+	default:
+		return nil, fmt.Errorf("unknown scheduling strategy: %s", config.Strategy)
+	}
+}
+
+// broadcast runs the test on every node concurrently
+func (s *NodeScheduler) broadcast(ctx context.Context, testConfig interface{}, nodes []DistributedNode, testID string, dispatch NodeDispatchFunc) []CloudTestResult {
+	results := make([]CloudTestResult, len(nodes))
+
+	var wg sync.WaitGroup
+	for i, node := range nodes {
+		wg.Add(1)
+		go func(i int, node DistributedNode) {
+			defer wg.Done()
+			results[i] = s.runOnNode(ctx, testConfig, node, testID, dispatch)
+		}(i, node)
+	}
+	wg.Wait()
+
+	return results
+}
+
+// dispatchWithRetry runs the test on the first candidate and falls back to
+// alternates until one succeeds or the retry budget is exhausted
+func (s *NodeScheduler) dispatchWithRetry(ctx context.Context, config SchedulingConfig, testConfig interface{}, candidates []DistributedNode, testID string, dispatch NodeDispatchFunc) ([]CloudTestResult, error) {
+	if len(candidates) == 0 {
+		return nil, fmt.Errorf("no nodes available for test %s", testID)
+	}
+
+	attempts := config.MaxRetries + 1
+	if attempts > len(candidates) {
+		attempts = len(candidates)
+	}
+
+	var lastErr string
+	for i := 0; i < attempts; i++ {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		result := s.runOnNode(ctx, testConfig, candidates[i], testID, dispatch)
+		if result.Success {
+			return []CloudTestResult{result}, nil
+		}
+
+		lastErr = result.Error
+		if i+1 < attempts {
+			s.Logger.Warnf("Node %s failed (%s), retrying on alternate node %s", candidates[i].ID, lastErr, candidates[i+1].ID)
+		}
+	}
+
+	return nil, fmt.Errorf("test %s failed on %d node(s): %s", testID, attempts, lastErr)
+}
+
+// runOnNode dispatches to a single node, tracking load for the duration
+func (s *NodeScheduler) runOnNode(ctx context.Context, testConfig interface{}, node DistributedNode, testID string, dispatch NodeDispatchFunc) CloudTestResult {
+	s.acquire(node.ID)
+	defer s.release(node.ID)
+
+	start := time.Now()
+	result, err := dispatch(ctx, testConfig, node, testID)
+	if err != nil {
+		s.Logger.Errorf("Failed to execute test on node %s: %v", node.Name, err)
+		end := time.Now()
+		return CloudTestResult{
+			TestID:    testID,
+			NodeID:    node.ID,
+			NodeName:  node.Name,
+			Location:  node.Location,
+			StartTime: start,
+			EndTime:   end,
+			Duration:  end.Sub(start),
+			Success:   false,
+			Error:     err.Error(),
+			Timestamp: end,
+		}
+	}
+
+	return *result
+}
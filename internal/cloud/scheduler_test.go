@@ -0,0 +1,310 @@
+package cloud
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"panoptic/internal/logger"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// recordingDispatcher records the nodes it was called for and fails for the listed IDs
+type recordingDispatcher struct {
+	mu     sync.Mutex
+	calls  []string
+	failOn map[string]bool
+}
+
+func (d *recordingDispatcher) dispatch(ctx context.Context, testConfig interface{}, node DistributedNode, testID string) (*CloudTestResult, error) {
+	d.mu.Lock()
+	d.calls = append(d.calls, node.ID)
+	d.mu.Unlock()
+
+	if d.failOn[node.ID] {
+		return nil, fmt.Errorf("node %s unavailable", node.ID)
+	}
+	return &CloudTestResult{
+		TestID:   testID,
+		NodeID:   node.ID,
+		NodeName: node.Name,
+		Location: node.Location,
+		Success:  true,
+	}, nil
+}
+
+func schedulerTestNodes() []DistributedNode {
+	return []DistributedNode{
+		{ID: "node-low", Name: "Low", Location: "us-east-1", Capacity: "low", Priority: 3},
+		{ID: "node-high", Name: "High", Location: "us-east-1", Capacity: "high", Priority: 1},
+		{ID: "node-eu", Name: "EU", Location: "eu-west-1", Capacity: "medium", Priority: 2},
+	}
+}
+
+// TestCapacityWeight tests capacity label parsing
+func TestCapacityWeight(t *testing.T) {
+	assert.Equal(t, 4, capacityWeight("high"))
+	assert.Equal(t, 2, capacityWeight("Medium"))
+	assert.Equal(t, 2, capacityWeight(""))
+	assert.Equal(t, 1, capacityWeight("low"))
+	assert.Equal(t, 8, capacityWeight("8"))
+	assert.Equal(t, 1, capacityWeight("bogus"))
+}
+
+// TestNodeScheduler_RankByPriority tests that idle nodes are ordered by priority
+func TestNodeScheduler_RankByPriority(t *testing.T) {
+	scheduler := NewNodeScheduler(*logger.NewLogger(false), SchedulingConfig{Strategy: StrategyLeastLoaded})
+
+	ranked := scheduler.Rank(schedulerTestNodes())
+
+	require.Len(t, ranked, 3)
+	assert.Equal(t, "node-high", ranked[0].ID)
+	assert.Equal(t, "node-eu", ranked[1].ID)
+	assert.Equal(t, "node-low", ranked[2].ID)
+}
+
+// TestNodeScheduler_RankByLoad tests that busy nodes drop behind ones with spare capacity
+func TestNodeScheduler_RankByLoad(t *testing.T) {
+	scheduler := NewNodeScheduler(*logger.NewLogger(false), SchedulingConfig{Strategy: StrategyLeastLoaded})
+	for i := 0; i < 4; i++ {
+		scheduler.acquire("node-high")
+	}
+
+	ranked := scheduler.Rank(schedulerTestNodes())
+
+	assert.Equal(t, "node-eu", ranked[0].ID, "saturated high-priority node should not be preferred")
+	assert.Equal(t, 4, scheduler.Load("node-high"))
+}
+
+// TestNodeScheduler_Broadcast tests that broadcast runs on every node and records failures
+func TestNodeScheduler_Broadcast(t *testing.T) {
+	scheduler := NewNodeScheduler(*logger.NewLogger(false), SchedulingConfig{})
+	dispatcher := &recordingDispatcher{failOn: map[string]bool{"node-eu": true}}
+
+	results, err := scheduler.Schedule(context.Background(), nil, schedulerTestNodes(), "test_1", dispatcher.dispatch)
+
+	require.NoError(t, err)
+	require.Len(t, results, 3)
+	assert.Len(t, dispatcher.calls, 3)
+
+	byNode := make(map[string]CloudTestResult)
+	for _, r := range results {
+		byNode[r.NodeID] = r
+	}
+	assert.True(t, byNode["node-high"].Success)
+	assert.False(t, byNode["node-eu"].Success)
+	assert.Contains(t, byNode["node-eu"].Error, "unavailable")
+	assert.Equal(t, 0, scheduler.Load("node-eu"), "load should be released after dispatch")
+}
+
+// TestNodeScheduler_LeastLoadedRetriesAlternate tests failover to the next ranked node
+func TestNodeScheduler_LeastLoadedRetriesAlternate(t *testing.T) {
+	scheduler := NewNodeScheduler(*logger.NewLogger(false), SchedulingConfig{
+		Strategy:   StrategyLeastLoaded,
+		MaxRetries: 2,
+	})
+	dispatcher := &recordingDispatcher{failOn: map[string]bool{"node-high": true}}
+
+	results, err := scheduler.Schedule(context.Background(), nil, schedulerTestNodes(), "test_2", dispatcher.dispatch)
+
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+	assert.Equal(t, "node-eu", results[0].NodeID)
+	assert.Equal(t, []string{"node-high", "node-eu"}, dispatcher.calls)
+}
+
+// TestNodeScheduler_RetryBudgetExhausted tests the error once all allowed attempts fail
+func TestNodeScheduler_RetryBudgetExhausted(t *testing.T) {
+	scheduler := NewNodeScheduler(*logger.NewLogger(false), SchedulingConfig{
+		Strategy:   StrategyLeastLoaded,
+		MaxRetries: 1,
+	})
+	dispatcher := &recordingDispatcher{failOn: map[string]bool{"node-high": true, "node-eu": true}}
+
+	results, err := scheduler.Schedule(context.Background(), nil, schedulerTestNodes(), "test_3", dispatcher.dispatch)
+
+	assert.Error(t, err)
+	assert.Nil(t, results)
+	assert.Contains(t, err.Error(), "failed on 2 node(s)")
+	assert.Equal(t, []string{"node-high", "node-eu"}, dispatcher.calls, "node-low should not be tried beyond the retry budget")
+}
+
+// TestNodeScheduler_RegionPinning tests that only nodes in the pinned region are used
+func TestNodeScheduler_RegionPinning(t *testing.T) {
+	scheduler := NewNodeScheduler(*logger.NewLogger(false), SchedulingConfig{
+		Strategy: StrategyRegionPinning,
+		Region:   "eu-west-1",
+	})
+	dispatcher := &recordingDispatcher{}
+
+	results, err := scheduler.Schedule(context.Background(), nil, schedulerTestNodes(), "test_4", dispatcher.dispatch)
+
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+	assert.Equal(t, "node-eu", results[0].NodeID)
+
+	scheduler.Config.Region = "ap-south-1"
+	_, err = scheduler.Schedule(context.Background(), nil, schedulerTestNodes(), "test_5", dispatcher.dispatch)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "ap-south-1")
+
+	scheduler.Config.Region = ""
+	_, err = scheduler.Schedule(context.Background(), nil, schedulerTestNodes(), "test_6", dispatcher.dispatch)
+	assert.Error(t, err)
+}
+
+// TestNodeScheduler_UnknownStrategy tests rejection of unknown strategies
+func TestNodeScheduler_UnknownStrategy(t *testing.T) {
+	scheduler := NewNodeScheduler(*logger.NewLogger(false), SchedulingConfig{Strategy: "round-robin"})
+	dispatcher := &recordingDispatcher{}
+
+	_, err := scheduler.Schedule(context.Background(), nil, schedulerTestNodes(), "test_7", dispatcher.dispatch)
+
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "unknown scheduling strategy")
+}
+
+// TestNodeScheduler_HealthCheck tests that unhealthy nodes are skipped before dispatch
+func TestNodeScheduler_HealthCheck(t *testing.T) {
+	healthy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/health", r.URL.Path)
+		assert.Equal(t, "Bearer key-1", r.Header.Get("Authorization"))
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer healthy.Close()
+
+	unhealthy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer unhealthy.Close()
+
+	nodes := []DistributedNode{
+		{ID: "down", Endpoint: unhealthy.URL, Priority: 1},
+		{ID: "up", Endpoint: healthy.URL, APIKey: "key-1", Priority: 2},
+	}
+
+	scheduler := NewNodeScheduler(*logger.NewLogger(false), SchedulingConfig{
+		Strategy:           StrategyLeastLoaded,
+		HealthCheck:        true,
+		HealthCheckTimeout: 2,
+	})
+	dispatcher := &recordingDispatcher{}
+
+	results, err := scheduler.Schedule(context.Background(), nil, nodes, "test_8", dispatcher.dispatch)
+
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+	assert.Equal(t, "up", results[0].NodeID)
+	assert.Equal(t, []string{"up"}, dispatcher.calls)
+
+	_, err = scheduler.Schedule(context.Background(), nil, nodes[:1], "test_9", dispatcher.dispatch)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "no healthy nodes")
+}
+
+// TestNodeScheduler_ReconfigureWhileScheduling tests that tests can be
+// scheduled while the configuration changes (run with -race)
+func TestNodeScheduler_ReconfigureWhileScheduling(t *testing.T) {
+	scheduler := NewNodeScheduler(*logger.NewLogger(false), SchedulingConfig{Strategy: StrategyLeastLoaded})
+	dispatcher := &recordingDispatcher{}
+
+	var wg sync.WaitGroup
+	for w := 0; w < 4; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 50; i++ {
+				results, err := scheduler.Schedule(context.Background(), nil, schedulerTestNodes(), "test", dispatcher.dispatch)
+				assert.NoError(t, err)
+				assert.NotEmpty(t, results)
+				scheduler.Rank(schedulerTestNodes())
+			}
+		}()
+	}
+	for i := 0; i < 50; i++ {
+		strategy := StrategyBroadcast
+		if i%2 == 0 {
+			strategy = StrategyLeastLoaded
+		}
+		scheduler.Reconfigure(SchedulingConfig{Strategy: strategy, MaxRetries: i % 3})
+	}
+	wg.Wait()
+}
+
+// TestHTTPHealthChecker_NoEndpoint tests the error for nodes without endpoints
+func TestHTTPHealthChecker_NoEndpoint(t *testing.T) {
+	checker := NewHTTPHealthChecker("", time.Second)
+
+	err := checker.CheckHealth(context.Background(), DistributedNode{ID: "n"})
+
+	assert.Error(t, err)
+	assert.Equal(t, "/health", checker.Path)
+}
+
+// TestExecuteDistributedTest_NotWiredNodesReportFailure tests that broadcast
+// surfaces ErrDistributedNotWired per node instead of fabricating success
+func TestExecuteDistributedTest_NotWiredNodesReportFailure(t *testing.T) {
+	manager := &CloudManager{
+		Logger:  *logger.NewLogger(false),
+		Enabled: true,
+		Config:  CloudConfig{EnableDistributed: true},
+	}
+
+	results, err := manager.ExecuteDistributedTest(context.Background(), nil, schedulerTestNodes())
+
+	require.NoError(t, err)
+	require.Len(t, results, 3)
+	for _, r := range results {
+		assert.False(t, r.Success)
+		assert.Contains(t, r.Error, "not wired")
+	}
+}
+
+// TestExecuteDistributedTest_ConcurrentTestsSpreadAcrossNodes tests that the
+// manager's scheduler sees the load of a distributed test still running when
+// placing the next one
+func TestExecuteDistributedTest_ConcurrentTestsSpreadAcrossNodes(t *testing.T) {
+	started := make(chan string, 2)
+	release := make(chan struct{})
+	manager := NewCloudManager(*logger.NewLogger(false))
+	manager.Enabled = true
+	manager.Config = CloudConfig{
+		EnableDistributed: true,
+		Scheduling:        SchedulingConfig{Strategy: StrategyLeastLoaded},
+	}
+	manager.Dispatch = func(ctx context.Context, testConfig interface{}, node DistributedNode, testID string) (*CloudTestResult, error) {
+		started <- node.ID
+		<-release
+		return &CloudTestResult{TestID: testID, NodeID: node.ID, NodeName: node.Name, Success: true}, nil
+	}
+	nodes := []DistributedNode{
+		{ID: "node-a", Name: "A", Capacity: "low", Priority: 1},
+		{ID: "node-b", Name: "B", Capacity: "low", Priority: 2},
+	}
+
+	var wg sync.WaitGroup
+	run := func() {
+		defer wg.Done()
+		results, err := manager.ExecuteDistributedTest(context.Background(), nil, nodes)
+		assert.NoError(t, err)
+		assert.Len(t, results, 1)
+	}
+
+	wg.Add(2)
+	go run()
+	first := <-started
+	go run()
+	second := <-started
+	close(release)
+	wg.Wait()
+
+	assert.Equal(t, "node-a", first)
+	assert.Equal(t, "node-b", second)
+	assert.Equal(t, 0, manager.nodeScheduler().Load("node-a"))
+}
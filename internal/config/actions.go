@@ -0,0 +1,140 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// ActionTypes are the action types the executor runs
+var ActionTypes = map[string]bool{
+	"navigate": true, "click": true, "fill": true, "submit": true,
+	"pause": true, "wait": true, "screenshot": true, "record": true,
+	"performance_assert": true, "network": true, "set_feature_flag": true,
+	"wait_for_email": true, "console_check": true, "http_request": true, "graphql": true, "login": true, "totp": true,
+	"wait_for_popup": true, "switch_tab": true, "close_tab": true,
+	"set_clipboard": true, "assert_clipboard": true,
+	"scroll_to": true, "scroll_by": true, "scroll_until": true,
+	"save_pdf": true, "assert_pdf": true, "assert_websocket": true,
+	"unregister_service_worker": true, "update_service_worker": true, "assert_service_worker": true,
+	"assert_cache": true, "assert_installable": true, "assert_offline_fallback": true,
+	"restart_app": true, "clear_browser_cache": true, "expire_session": true, "disconnect_network": true,
+	"vision_click": true, "vision_report": true, "vision_decode_qr": true,
+	"vision_contrast_check": true, "vision_layout_check": true, "vision_design_compare": true,
+	"ai_test_generation": true, "smart_error_detection": true, "ai_enhanced_testing": true,
+	"cloud_sync": true, "cloud_analytics": true, "distributed_test": true, "cloud_cleanup": true,
+	"enterprise_status": true, "user_create": true, "user_authenticate": true,
+	"project_create": true, "team_create": true, "api_key_create": true,
+	"audit_report": true, "compliance_check": true, "license_info": true,
+	"backup_data": true, "cleanup_data": true,
+}
+
+// MaxRecordDuration bounds the duration of record actions, in seconds
+const MaxRecordDuration = 3600
+
+// validateActions checks every action and returns all the problems found,
+// so a configuration is fixed in one go rather than one error per run
+func (c *Config) validateActions() error {
+	var errs []error
+	for _, app := range c.Apps {
+		for _, action := range app.Actions {
+			if action.Name == "" {
+				errs = append(errs, fmt.Errorf("action name is required in app %s", app.Name))
+				continue
+			}
+			if err := c.validateAction(action); err != nil {
+				errs = append(errs, fmt.Errorf("action %s in app %s: %w", action.Name, app.Name, err))
+			}
+		}
+	}
+	for _, action := range c.Actions {
+		if err := c.validateAction(action); err != nil {
+			errs = append(errs, fmt.Errorf("action %s: %w", action.Name, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// validateAction checks that an action has the fields and parameters its
+// type requires
+func (c *Config) validateAction(action Action) error {
+	switch {
+	case action.Type == "":
+		return fmt.Errorf("action type is required")
+	case !ActionTypes[action.Type]:
+		return fmt.Errorf("unknown action type %s", action.Type)
+	}
+
+	switch action.Type {
+	case "navigate":
+		if action.GetNavigateURL() == "" {
+			return fmt.Errorf("URL or value is required for navigate actions")
+		}
+	case "click":
+		if action.Selector == "" && action.Target == "" {
+			return fmt.Errorf("click needs a selector or target")
+		}
+	case "fill":
+		if action.Selector == "" {
+			return fmt.Errorf("fill needs a selector")
+		}
+		if action.Value == "" {
+			return fmt.Errorf("fill needs a value")
+		}
+	case "wait":
+		if action.WaitTime < 0 {
+			return fmt.Errorf("wait_time can't be negative")
+		}
+	case "record":
+		if action.Duration < 0 || action.Duration > MaxRecordDuration {
+			return fmt.Errorf("record duration must be between 1 and %d seconds, or 0 for the default of 30", MaxRecordDuration)
+		}
+	case "network":
+		if _, err := action.NetworkConditions(); err != nil {
+			return err
+		}
+	}
+	if action.Type == "screenshot" || action.Type == "record" {
+		if err := validateArtifactName(action.Parameters["filename"]); err != nil {
+			return err
+		}
+	}
+
+	if err := action.Quarantine.Validate(); err != nil {
+		return err
+	}
+	if err := action.Dialog.Validate(); err != nil {
+		return fmt.Errorf("dialog: %w", err)
+	}
+	if err := validateMetricOutputs(action.Metrics); err != nil {
+		return err
+	}
+	for _, validate := range []func(Action) error{
+		c.validateChaosAction, c.validateFeatureFlagAction, c.validateEmailAction, c.validateVisionAction,
+		c.validateConsoleAction, c.validateHTTPRequestAction, c.validateLoginAction, c.validateTabAction,
+		c.validateClipboardAction, c.validateScrollAction, c.validatePDFAction,
+		c.validateWebSocketAction, c.validatePWAAction, c.validateGraphQLAction,
+	} {
+		if err := validate(action); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// validateArtifactName checks parameters.filename of screenshots and
+// recordings, which is relative to their output directory
+func validateArtifactName(value interface{}) error {
+	if value == nil {
+		return nil
+	}
+	name, ok := value.(string)
+	if !ok || name == "" {
+		return fmt.Errorf("parameters.filename must be a file name")
+	}
+	if filepath.IsAbs(name) || strings.HasPrefix(filepath.Clean(name), "..") {
+		return fmt.Errorf("parameters.filename %s must stay in the output directory", name)
+	}
+	return nil
+}
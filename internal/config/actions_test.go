@@ -0,0 +1,70 @@
+package config
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateAction(t *testing.T) {
+	cfg := &Config{}
+	tests := []struct {
+		action Action
+		errMsg string
+	}{
+		{Action{Name: "a", Type: "click", Selector: "#buy"}, ""},
+		{Action{Name: "a", Type: "click", Target: "Buy"}, ""},
+		{Action{Name: "a", Type: "click"}, "click needs a selector or target"},
+		{Action{Name: "a", Type: "fill", Selector: "#name", Value: "Ada"}, ""},
+		{Action{Name: "a", Type: "fill", Value: "Ada"}, "fill needs a selector"},
+		{Action{Name: "a", Type: "fill", Selector: "#name"}, "fill needs a value"},
+		{Action{Name: "a", Type: "submit"}, ""},
+		{Action{Name: "a", Type: "navigate"}, "URL or value is required for navigate actions"},
+		{Action{Name: "a", Type: "wait", WaitTime: -1}, "wait_time can't be negative"},
+		{Action{Name: "a", Type: "record"}, ""},
+		{Action{Name: "a", Type: "record", Duration: MaxRecordDuration}, ""},
+		{Action{Name: "a", Type: "record", Duration: MaxRecordDuration + 1}, "record duration must be between 1 and 3600 seconds, or 0 for the default of 30"},
+		{Action{Name: "a", Type: "record", Duration: -5}, "record duration must be between"},
+		{Action{Name: "a", Type: "screenshot", Parameters: map[string]interface{}{"filename": "home/hero.png"}}, ""},
+		{Action{Name: "a", Type: "screenshot", Parameters: map[string]interface{}{"filename": 3}}, "parameters.filename must be a file name"},
+		{Action{Name: "a", Type: "screenshot", Parameters: map[string]interface{}{"filename": "../../etc/passwd"}}, "must stay in the output directory"},
+		{Action{Name: "a", Type: "record", Parameters: map[string]interface{}{"filename": "/tmp/out.mp4"}}, "must stay in the output directory"},
+		{Action{Name: "a"}, "action type is required"},
+		{Action{Name: "a", Type: "teleport"}, "unknown action type teleport"},
+		{Action{Name: "a", Type: "restart_app"}, "chaos action type restart_app requires settings.chaos.enabled"},
+	}
+	for _, tt := range tests {
+		err := cfg.validateAction(tt.action)
+		if tt.errMsg == "" {
+			assert.NoError(t, err, "%+v", tt.action)
+		} else {
+			assert.ErrorContains(t, err, tt.errMsg, "%+v", tt.action)
+		}
+	}
+}
+
+// TestValidate_AllActionErrors tests that every action problem is reported at once
+func TestValidate_AllActionErrors(t *testing.T) {
+	cfg := &Config{
+		Apps: []AppConfig{
+			{Name: "Shop", Type: "web", URL: "https://shop.example.com", Actions: []Action{
+				{Name: "buy", Type: "click"},
+				{Name: "home", Type: "screenshot"},
+				{Type: "wait"},
+				{Name: "name", Type: "fill", Selector: "#name"},
+			}},
+		},
+		Actions: []Action{{Name: "clip", Type: "record", Duration: 7200}},
+	}
+
+	err := cfg.Validate()
+	require.Error(t, err)
+	assert.Equal(t, []string{
+		"action buy in app Shop: click needs a selector or target",
+		"action name is required in app Shop",
+		"action name in app Shop: fill needs a value",
+		"action clip: record duration must be between 1 and 3600 seconds, or 0 for the default of 30",
+	}, strings.Split(err.Error(), "\n"))
+}
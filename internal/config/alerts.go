@@ -0,0 +1,50 @@
+package config
+
+import "fmt"
+
+// AlertSettings raises a PagerDuty or Opsgenie incident when a run crosses the
+// failure threshold or an app tagged with one of CriticalTags fails, and
+// resolves it when a later run passes. RoutingKey and APIKey are expanded from
+// the environment, e.g. "${PAGERDUTY_ROUTING_KEY}".
+type AlertSettings struct {
+	Type             string   `yaml:"type"`              // pagerduty or opsgenie
+	RoutingKey       string   `yaml:"routing_key"`       // PagerDuty Events API v2 integration key
+	APIKey           string   `yaml:"api_key"`           // Opsgenie API integration key
+	URL              string   `yaml:"url"`               // API base override, e.g. https://api.eu.opsgenie.com
+	FailureThreshold float64  `yaml:"failure_threshold"` // percent of apps failed above which to alert; 0 alerts on any failure
+	CriticalTags     []string `yaml:"critical_tags"`     // a failure of an app with one of these tags alerts regardless of the threshold
+	Severity         string   `yaml:"severity"`          // critical (default), error, warning or info
+	Priority         string   `yaml:"priority"`          // Opsgenie P1 to P5; derived from severity by default
+	DedupKey         string   `yaml:"dedup_key"`         // incident key; default panoptic/<configuration name>
+	ReportURL        string   `yaml:"report_url"`        // linked from the incident; {run_id} is replaced
+}
+
+// Validate checks the destination, threshold and severity of an alert
+func (a AlertSettings) Validate() error {
+	switch a.Type {
+	case "pagerduty":
+		if a.RoutingKey == "" {
+			return fmt.Errorf("pagerduty needs routing_key")
+		}
+	case "opsgenie":
+		if a.APIKey == "" {
+			return fmt.Errorf("opsgenie needs api_key")
+		}
+	default:
+		return fmt.Errorf("type must be pagerduty or opsgenie, got %q", a.Type)
+	}
+	if a.FailureThreshold < 0 || a.FailureThreshold >= 100 {
+		return fmt.Errorf("failure_threshold must be from 0 to below 100, got %v", a.FailureThreshold)
+	}
+	switch a.Severity {
+	case "", "critical", "error", "warning", "info":
+	default:
+		return fmt.Errorf("severity must be critical, error, warning or info, got %q", a.Severity)
+	}
+	switch a.Priority {
+	case "", "P1", "P2", "P3", "P4", "P5":
+	default:
+		return fmt.Errorf("priority must be P1 to P5, got %q", a.Priority)
+	}
+	return nil
+}
@@ -0,0 +1,35 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestAlertSettings_Validate tests destinations, thresholds and severities
+func TestAlertSettings_Validate(t *testing.T) {
+	valid := []AlertSettings{
+		{Type: "pagerduty", RoutingKey: "${PAGERDUTY_ROUTING_KEY}", FailureThreshold: 20, CriticalTags: []string{"critical"}},
+		{Type: "opsgenie", APIKey: "${OPSGENIE_API_KEY}", Severity: "error", Priority: "P2"},
+	}
+	for _, a := range valid {
+		assert.NoError(t, a.Validate(), a.Type)
+	}
+
+	for want, a := range map[string]AlertSettings{
+		"pagerduty or opsgenie": {Type: "email"},
+		"needs routing_key":     {Type: "pagerduty"},
+		"needs api_key":         {Type: "opsgenie"},
+		"failure_threshold":     {Type: "pagerduty", RoutingKey: "k", FailureThreshold: 100},
+		"severity must":         {Type: "pagerduty", RoutingKey: "k", Severity: "fatal"},
+		"priority must":         {Type: "opsgenie", APIKey: "k", Priority: "P9"},
+	} {
+		assert.ErrorContains(t, a.Validate(), want)
+	}
+
+	cfg := &Config{
+		Apps:     []AppConfig{{Name: "Shop", Type: "web", URL: "https://example.com"}},
+		Settings: Settings{Alerts: []AlertSettings{valid[0], {Type: "opsgenie"}}},
+	}
+	assert.ErrorContains(t, cfg.Validate(), "settings.alerts[1]")
+}
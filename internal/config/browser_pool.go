@@ -0,0 +1,48 @@
+package config
+
+import "fmt"
+
+// Browser pool defaults
+const (
+	DefaultBrowserPoolSize     = 2
+	DefaultBrowserRecycleAfter = 50
+)
+
+// BrowserPoolSettings reuses launched browsers across web apps instead of
+// launching one per app. Each app still gets a fresh incognito context, so
+// cookies, storage and cache don't carry over between apps.
+type BrowserPoolSettings struct {
+	Enabled      bool `yaml:"enabled"`
+	Size         int  `yaml:"size"`          // idle browsers kept, default 2
+	RecycleAfter int  `yaml:"recycle_after"` // apps a browser runs before it's relaunched, default 50
+}
+
+// PoolSize is the number of idle browsers to keep
+func (p *BrowserPoolSettings) PoolSize() int {
+	if p.Size == 0 {
+		return DefaultBrowserPoolSize
+	}
+	return p.Size
+}
+
+// RecycleLimit is the number of apps a browser runs before it's relaunched
+func (p *BrowserPoolSettings) RecycleLimit() int {
+	if p.RecycleAfter == 0 {
+		return DefaultBrowserRecycleAfter
+	}
+	return p.RecycleAfter
+}
+
+// Validate checks the pool size and recycling limit
+func (p *BrowserPoolSettings) Validate() error {
+	if p == nil {
+		return nil
+	}
+	if p.Size < 0 {
+		return fmt.Errorf("size can't be negative")
+	}
+	if p.RecycleAfter < 0 {
+		return fmt.Errorf("recycle_after can't be negative")
+	}
+	return nil
+}
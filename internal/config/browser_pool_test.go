@@ -0,0 +1,31 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBrowserPoolSettings_Defaults(t *testing.T) {
+	pool := &BrowserPoolSettings{Enabled: true}
+	assert.Equal(t, DefaultBrowserPoolSize, pool.PoolSize())
+	assert.Equal(t, DefaultBrowserRecycleAfter, pool.RecycleLimit())
+
+	pool = &BrowserPoolSettings{Enabled: true, Size: 4, RecycleAfter: 10}
+	assert.Equal(t, 4, pool.PoolSize())
+	assert.Equal(t, 10, pool.RecycleLimit())
+}
+
+func TestBrowserPoolSettings_Validate(t *testing.T) {
+	var unset *BrowserPoolSettings
+	assert.NoError(t, unset.Validate())
+	assert.NoError(t, (&BrowserPoolSettings{Enabled: true}).Validate())
+	assert.ErrorContains(t, (&BrowserPoolSettings{Size: -1}).Validate(), "size can't be negative")
+	assert.ErrorContains(t, (&BrowserPoolSettings{RecycleAfter: -1}).Validate(), "recycle_after can't be negative")
+
+	cfg := &Config{
+		Apps:     []AppConfig{{Name: "Shop", Type: "web", URL: "https://shop.example.com"}},
+		Settings: Settings{BrowserPool: &BrowserPoolSettings{Size: -2}},
+	}
+	assert.ErrorContains(t, cfg.Validate(), "settings.browser_pool: size can't be negative")
+}
@@ -0,0 +1,63 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// DefaultCaptchaTimeout bounds how long a captcha may take to solve
+const DefaultCaptchaTimeout = 2 * time.Minute
+
+// CaptchaSettings decides what happens when a captcha shows on a web app's
+// page before an action interacts with it. Mode fail (the default) fails
+// the action naming the captcha; manual waits for a person to solve it in
+// a headed browser; solver has it solved by 2Captcha or a service with the
+// same API; token injects a bypass token, such as the one reCAPTCHA's test
+// keys accept. APIKey and Token are expanded from the environment, e.g.
+// "${TWOCAPTCHA_API_KEY}".
+type CaptchaSettings struct {
+	Mode      string        `yaml:"mode"`      // fail, manual, solver or token
+	Selectors []string      `yaml:"selectors"` // default: reCAPTCHA, hCaptcha and Turnstile widgets
+	Timeout   time.Duration `yaml:"timeout"`   // manual and solver, default 2m
+	URL       string        `yaml:"url"`       // solver API, default https://2captcha.com
+	APIKey    string        `yaml:"api_key"`   // solver API key
+	Token     string        `yaml:"token"`     // token mode's token
+}
+
+// Validate checks the mode and what it needs
+func (s *CaptchaSettings) Validate() error {
+	if s == nil {
+		return nil
+	}
+	switch s.Mode {
+	case "", "fail", "manual":
+	case "solver":
+		if s.APIKey == "" {
+			return fmt.Errorf("solver mode needs api_key")
+		}
+	case "token":
+		if s.Token == "" {
+			return fmt.Errorf("token mode needs token")
+		}
+	default:
+		return fmt.Errorf("mode must be fail, manual, solver or token, got %q", s.Mode)
+	}
+	for _, selector := range s.Selectors {
+		if strings.TrimSpace(selector) == "" {
+			return fmt.Errorf("selectors can't be empty")
+		}
+	}
+	if s.Timeout < 0 {
+		return fmt.Errorf("timeout must not be negative")
+	}
+	return nil
+}
+
+// TimeoutOrDefault returns the timeout, or DefaultCaptchaTimeout
+func (s *CaptchaSettings) TimeoutOrDefault() time.Duration {
+	if s != nil && s.Timeout > 0 {
+		return s.Timeout
+	}
+	return DefaultCaptchaTimeout
+}
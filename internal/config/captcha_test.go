@@ -0,0 +1,28 @@
+package config
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCaptchaSettings_Validate(t *testing.T) {
+	var unset *CaptchaSettings
+	assert.NoError(t, unset.Validate())
+	assert.Equal(t, DefaultCaptchaTimeout, unset.TimeoutOrDefault())
+	assert.NoError(t, (&CaptchaSettings{}).Validate())
+	assert.NoError(t, (&CaptchaSettings{Mode: "manual", Timeout: 5 * time.Minute}).Validate())
+	assert.Equal(t, 5*time.Minute, (&CaptchaSettings{Timeout: 5 * time.Minute}).TimeoutOrDefault())
+	assert.NoError(t, (&CaptchaSettings{Mode: "solver", APIKey: "${TWOCAPTCHA_API_KEY}"}).Validate())
+	assert.ErrorContains(t, (&CaptchaSettings{Mode: "solver"}).Validate(), "solver mode needs api_key")
+	assert.ErrorContains(t, (&CaptchaSettings{Mode: "token"}).Validate(), "token mode needs token")
+	assert.ErrorContains(t, (&CaptchaSettings{Mode: "skip"}).Validate(), "mode must be fail, manual, solver or token")
+	assert.ErrorContains(t, (&CaptchaSettings{Selectors: []string{""}}).Validate(), "selectors can't be empty")
+
+	cfg := &Config{
+		Apps:     []AppConfig{{Name: "Shop", Type: "web", URL: "https://shop.example.com"}},
+		Settings: Settings{Captcha: &CaptchaSettings{Timeout: -time.Second}},
+	}
+	assert.ErrorContains(t, cfg.Validate(), "settings.captcha: timeout must not be negative")
+}
@@ -0,0 +1,37 @@
+package config
+
+import "fmt"
+
+// ChaosSettings gates the chaos actions, which break an app on purpose to
+// test its recovery paths. They are refused unless Enabled is set, so a
+// configuration copied from a chaos suite can't disrupt a normal run.
+type ChaosSettings struct {
+	Enabled bool `yaml:"enabled"`
+}
+
+// ChaosActionTypes are the action types that require settings.chaos.enabled
+var ChaosActionTypes = map[string]bool{
+	"restart_app":         true,
+	"clear_browser_cache": true,
+	"expire_session":      true,
+	"disconnect_network":  true,
+}
+
+// IsEnabled reports whether chaos actions may run; nil settings disable them
+func (c *ChaosSettings) IsEnabled() bool {
+	return c != nil && c.Enabled
+}
+
+// validateChaosAction rejects chaos actions unless chaos is enabled
+func (c *Config) validateChaosAction(action Action) error {
+	if !ChaosActionTypes[action.Type] {
+		return nil
+	}
+	if !c.Settings.Chaos.IsEnabled() {
+		return fmt.Errorf("chaos action type %s requires settings.chaos.enabled", action.Type)
+	}
+	if action.Type == "disconnect_network" && action.Duration <= 0 {
+		return fmt.Errorf("disconnect_network needs a duration in seconds")
+	}
+	return nil
+}
@@ -0,0 +1,26 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestConfig_ChaosActions tests that chaos actions need settings.chaos.enabled
+func TestConfig_ChaosActions(t *testing.T) {
+	cfg := &Config{
+		Apps: []AppConfig{{Name: "Shop", Type: "web", URL: "https://example.com", Actions: []Action{
+			{Name: "crash", Type: "restart_app"},
+		}}},
+	}
+	assert.ErrorContains(t, cfg.Validate(), "action crash in app Shop: chaos action type restart_app requires settings.chaos.enabled")
+
+	cfg.Settings.Chaos = &ChaosSettings{Enabled: true}
+	assert.NoError(t, cfg.Validate())
+
+	cfg.Actions = []Action{{Name: "outage", Type: "disconnect_network"}}
+	assert.ErrorContains(t, cfg.Validate(), "action outage: disconnect_network needs a duration")
+	cfg.Actions[0].Duration = 10
+	assert.NoError(t, cfg.Validate())
+	assert.False(t, (*ChaosSettings)(nil).IsEnabled())
+}
@@ -0,0 +1,50 @@
+package config
+
+import (
+	"fmt"
+	"regexp"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ClipboardCheck is an assert_clipboard action's parameters: the text the
+// clipboard must hold exactly (the action's value), contain or match, and
+// a variable receiving it
+type ClipboardCheck struct {
+	Equals   string `yaml:"-"`
+	Contains string `yaml:"contains"`
+	Matches  string `yaml:"matches"`
+	Variable string `yaml:"variable"`
+}
+
+// ClipboardCheck reads an assert_clipboard action's parameters
+func (a *Action) ClipboardCheck() (*ClipboardCheck, error) {
+	check := &ClipboardCheck{}
+	data, err := yaml.Marshal(a.Parameters)
+	if err != nil {
+		return nil, err
+	}
+	if err := yaml.Unmarshal(data, check); err != nil {
+		return nil, fmt.Errorf("invalid assert_clipboard parameters: %w", err)
+	}
+	check.Equals = a.Value
+	if check.Equals == "" && check.Contains == "" && check.Matches == "" {
+		return nil, fmt.Errorf("assert_clipboard needs a value, or parameters.contains or parameters.matches")
+	}
+	if _, err := regexp.Compile(check.Matches); err != nil {
+		return nil, fmt.Errorf("matches: %w", err)
+	}
+	if check.Variable != "" && !variableName.MatchString(check.Variable) {
+		return nil, fmt.Errorf("invalid variable name %q", check.Variable)
+	}
+	return check, nil
+}
+
+// validateClipboardAction checks the parameters of assert_clipboard actions
+func (c *Config) validateClipboardAction(action Action) error {
+	if action.Type == "assert_clipboard" {
+		_, err := action.ClipboardCheck()
+		return err
+	}
+	return nil
+}
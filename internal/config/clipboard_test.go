@@ -0,0 +1,34 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAction_ClipboardCheck(t *testing.T) {
+	action := Action{Name: "copied", Type: "assert_clipboard", Value: "https://shop.example.com/p/1"}
+	check, err := action.ClipboardCheck()
+	require.NoError(t, err)
+	assert.Equal(t, &ClipboardCheck{Equals: "https://shop.example.com/p/1"}, check)
+
+	action = Action{Name: "code", Type: "assert_clipboard", Parameters: map[string]interface{}{"matches": `^[A-Z0-9]{8}$`, "variable": "invite_code"}}
+	check, err = action.ClipboardCheck()
+	require.NoError(t, err)
+	assert.Equal(t, &ClipboardCheck{Matches: `^[A-Z0-9]{8}$`, Variable: "invite_code"}, check)
+
+	cases := []struct {
+		params map[string]interface{}
+		want   string
+	}{
+		{nil, "assert_clipboard needs a value, or parameters.contains or parameters.matches"},
+		{map[string]interface{}{"matches": "("}, "matches:"},
+		{map[string]interface{}{"contains": "x", "variable": "invite code"}, `invalid variable name "invite code"`},
+	}
+	for _, c := range cases {
+		cfg := &Config{Apps: []AppConfig{{Name: "Shop", Type: "web", URL: "https://shop.example.com",
+			Actions: []Action{{Name: "copied", Type: "assert_clipboard", Parameters: c.params}}}}}
+		assert.ErrorContains(t, cfg.Validate(), c.want)
+	}
+}
@@ -4,6 +4,8 @@ import (
 	"crypto/sha256"
 	"fmt"
 	"os"
+	"path/filepath"
+	"strings"
 	"sync"
 	"time"
 
@@ -16,11 +18,14 @@ type Config struct {
 	Apps     []AppConfig  `yaml:"apps"`
 	Actions  []Action     `yaml:"actions"`
 	Settings Settings     `yaml:"settings"`
+
+	// Gherkin front-end: Load expands its scenarios into apps
+	Gherkin *GherkinConfig `yaml:"gherkin,omitempty"`
 }
 
 type AppConfig struct {
 	Name        string            `yaml:"name"`
-	Type        string            `yaml:"type"` // web, desktop, mobile
+	Type        string            `yaml:"type"` // web, desktop, mobile, driver
 	URL         string            `yaml:"url"`
 	Path        string            `yaml:"path"`
 	Platform    string            `yaml:"platform"` // ios, android, windows, macos, linux
@@ -29,11 +34,143 @@ type AppConfig struct {
 	Timeout     int               `yaml:"timeout"`
 	Environment map[string]string `yaml:"environment"`
 	Actions     []Action          `yaml:"actions"` // Per-app actions (takes precedence over global actions)
+	Tags        []string          `yaml:"tags,omitempty"` // Inherited by every action of the app, e.g. smoke, checkout
+	Quarantine  *Quarantine       `yaml:"quarantine,omitempty"`
+	WaitFor     *WaitFor          `yaml:"wait_for,omitempty"` // readiness checks polled before the app's actions start
+	Dialogs     *DialogPolicy     `yaml:"dialogs,omitempty"`  // how native JS dialogs are answered; dismissed by default
+	Locale      string            `yaml:"locale,omitempty"`   // language tag web pages are shown in, e.g. de-DE
+	I18n        *I18nSettings     `yaml:"i18n,omitempty"`     // localization audit, run once per locale
+
+	// Flag values served while the app runs, through settings.feature_flags
+	FeatureFlags map[string]interface{} `yaml:"feature_flags,omitempty"`
+
+	// Parameter matrix: Load expands the app into one instance per combination,
+	// and MatrixValues holds the combination of an expanded instance
+	Matrix       Matrix            `yaml:"matrix,omitempty"`
+	MatrixValues map[string]string `yaml:"-"`
+	MatrixApp    string            `yaml:"-"` // name of the app an instance was expanded from
+
+	// Gherkin scenario an app was expanded from, by Load
+	Scenario *Scenario `yaml:"-"`
+
+	// Web browser selection
+	Browser        string          `yaml:"browser"`         // chromium (default), chrome, edge
+	BrowserChannel string          `yaml:"browser_channel"` // stable, beta, dev, canary
+	BrowserVersion string          `yaml:"browser_version"` // chromium snapshot revision
+	Browsers       []BrowserConfig `yaml:"browsers"`        // Matrix: repeat the app's actions once per browser
+
+	// Remote WebDriver endpoint used instead of a local browser
+	Remote *RemoteWebDriverConfig `yaml:"remote,omitempty"`
+
+	// External platform driver of a driver app
+	Driver *DriverConfig `yaml:"driver,omitempty"`
+}
+
+// DriverConfig starts the external driver of a driver app: a program, in any
+// language, that implements a platform (Flutter, Unity, kiosk hardware) and
+// speaks the JSON-RPC protocol of docs/DRIVER_PROTOCOL.md on its stdin and
+// stdout
+type DriverConfig struct {
+	Command     string                 `yaml:"command"`
+	Args        []string               `yaml:"args"`
+	Dir         string                 `yaml:"dir"`          // working directory of the driver
+	Env         map[string]string      `yaml:"env"`          // added to the environment, after the app's environment
+	Options     map[string]interface{} `yaml:"options"`      // passed to the driver's initialize
+	CallTimeout int                    `yaml:"call_timeout"` // seconds a call may take; 60 by default
+}
+
+// RemoteWebDriverConfig points a web app at a remote WebDriver endpoint
+// (Selenium Grid, Moon, BrowserStack, Sauce Labs). Username, AccessKey and
+// Tunnel are expanded from the environment, e.g. "${BROWSERSTACK_ACCESS_KEY}".
+type RemoteWebDriverConfig struct {
+	URL          string                 `yaml:"url"`
+	Provider     string                 `yaml:"provider"` // selenium (default), moon, browserstack, saucelabs
+	Username     string                 `yaml:"username"`
+	AccessKey    string                 `yaml:"access_key"`
+	Tunnel       string                 `yaml:"tunnel"`       // BrowserStack Local identifier / Sauce Connect tunnel name
+	Capabilities map[string]interface{} `yaml:"capabilities"` // passed through into alwaysMatch
+}
+
+// BrowserConfig is one entry of an app's browser matrix. It can be written as
+// a plain engine name ("edge") or a mapping with engine, channel and version.
+type BrowserConfig struct {
+	Engine  string `yaml:"engine"`
+	Channel string `yaml:"channel"`
+	Version string `yaml:"version"`
+}
+
+// UnmarshalYAML accepts either a scalar engine name or a full mapping
+func (b *BrowserConfig) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.ScalarNode {
+		b.Engine = node.Value
+		return nil
+	}
+	type plain BrowserConfig
+	return node.Decode((*plain)(b))
+}
+
+// Label returns a short identifier such as "edge-beta" or "chromium@1300313"
+func (b BrowserConfig) Label() string {
+	label := b.Engine
+	if label == "" {
+		label = "chromium"
+	}
+	if b.Channel != "" {
+		label += "-" + b.Channel
+	}
+	if b.Version != "" {
+		label += "@" + b.Version
+	}
+	return label
+}
+
+// unsupportedBrowserEngines can't be driven by the web platform, which
+// speaks the Chrome DevTools Protocol that only Chromium-family browsers do
+var unsupportedBrowserEngines = map[string]bool{"firefox": true, "webkit": true, "safari": true}
+
+// validateBrowsers rejects the engines the web platform can't drive, so a
+// browser matrix doesn't report failures that have nothing to do with the app
+func (a AppConfig) validateBrowsers() error {
+	engines := []string{a.Browser}
+	for _, b := range a.Browsers {
+		engines = append(engines, b.Engine)
+	}
+	for _, engine := range engines {
+		if unsupportedBrowserEngines[strings.ToLower(strings.TrimSpace(engine))] {
+			return fmt.Errorf("browser %s is not supported: web apps run in Chromium-family browsers (chromium, chrome, edge), driven over the DevTools protocol", engine)
+		}
+	}
+	return nil
+}
+
+// BrowserVariants expands the app's browser matrix into one app per browser,
+// suffixing names with the browser label. Apps without a matrix are returned as-is.
+func (a AppConfig) BrowserVariants() []AppConfig {
+	if len(a.Browsers) == 0 {
+		return []AppConfig{a}
+	}
+
+	variants := make([]AppConfig, 0, len(a.Browsers))
+	for _, b := range a.Browsers {
+		variant := a
+		variant.Browsers = nil
+		variant.Browser = b.Engine
+		variant.BrowserChannel = b.Channel
+		variant.BrowserVersion = b.Version
+		variant.Name = fmt.Sprintf("%s [%s]", a.Name, b.Label())
+		variants = append(variants, variant)
+	}
+	return variants
+}
+
+// BrowserLabel returns the label of the browser a (non-matrix) web app runs in
+func (a AppConfig) BrowserLabel() string {
+	return BrowserConfig{Engine: a.Browser, Channel: a.BrowserChannel, Version: a.BrowserVersion}.Label()
 }
 
 type Action struct {
 	Name        string                 `yaml:"name"`
-	Type        string                 `yaml:"type"` // navigate, click, fill, submit, wait, screenshot, record
+	Type        string                 `yaml:"type"` // navigate, click, fill, submit, wait, screenshot, record, performance_assert, network, restart_app, ...
 	URL         string                 `yaml:"url"`  // URL for navigate actions
 	Target      string                 `yaml:"target"`
 	Value       string                 `yaml:"value"`
@@ -43,6 +180,11 @@ type Action struct {
 	Screenshot  bool                   `yaml:"screenshot"`
 	Record      bool                   `yaml:"record"`
 	Duration    int                    `yaml:"duration"`
+	Tags        []string               `yaml:"tags,omitempty"`
+	Quarantine  *Quarantine            `yaml:"quarantine,omitempty"`
+	Category    string                 `yaml:"category,omitempty"` // failure category for settings.failure_policy; derived from the type when empty
+	Dialog      *DialogPolicy          `yaml:"dialog,omitempty"`   // dialogs opened while the action runs; overrides the app's dialogs
+	Metrics     []MetricOutput         `yaml:"metrics,omitempty"`  // numbers recorded once the action succeeds
 }
 
 // GetNavigateURL returns the URL for a navigate action, checking URL first then Value for backward compatibility.
@@ -72,6 +214,133 @@ type Settings struct {
 	
 	// Enterprise Management Settings
 	Enterprise        map[string]interface{}     `yaml:"enterprise,omitempty"`
+
+	// Log format, sink and rotation
+	Logging          *LoggingSettings        `yaml:"logging,omitempty"`
+
+	// OpenTelemetry span export
+	Telemetry        *TelemetrySettings      `yaml:"telemetry,omitempty"`
+
+	// Browser/app process CPU and memory sampling
+	Resources        *ResourceSettings       `yaml:"resources,omitempty"`
+
+	// Seed for {{fake.*}} test data; random per run when unset
+	FakeSeed         *int64                  `yaml:"fake_seed,omitempty"`
+
+	// Default budgets for performance_assert actions (lcp, cls, inp, fid, ttfb, fcp, load in ms; transfer_kb)
+	PerformanceBudgets map[string]float64    `yaml:"performance_budgets,omitempty"`
+
+	// Network throttling applied to web apps when they start
+	Network          *NetworkConditions      `yaml:"network,omitempty"`
+
+	// Allow the chaos actions (restart_app, clear_browser_cache, expire_session, disconnect_network)
+	Chaos            *ChaosSettings          `yaml:"chaos,omitempty"`
+
+	// LaunchDarkly, Unleash or ConfigCat project for feature flag overrides
+	FeatureFlags     *FeatureFlagSettings    `yaml:"feature_flags,omitempty"`
+
+	// MailHog or Mailosaur inbox read by wait_for_email actions
+	Inbox            *InboxSettings          `yaml:"inbox,omitempty"`
+
+	// When failed apps fail the process exit code
+	FailurePolicy    *FailurePolicy          `yaml:"failure_policy,omitempty"`
+
+	// Post a GitHub check run or commit status after each run
+	GitHub           *GitHubSettings         `yaml:"github,omitempty"`
+
+	// Slack and Microsoft Teams run summaries
+	Notifications    []NotificationSettings  `yaml:"notifications,omitempty"`
+
+	// File failures in Jira, GitHub Issues or GitLab
+	Issues           *IssueSettings          `yaml:"issues,omitempty"`
+
+	// PagerDuty and Opsgenie incidents for failing scheduled runs
+	Alerts           []AlertSettings         `yaml:"alerts,omitempty"`
+
+	// ONNX model replacing the heuristic element detectors
+	Vision           *VisionSettings         `yaml:"vision,omitempty"`
+
+	// Reuse launched browsers across web apps
+	BrowserPool      *BrowserPoolSettings    `yaml:"browser_pool,omitempty"`
+
+	// Pace actions, cap navigations and space out apps against the same host
+	RateLimit        *RateLimitSettings      `yaml:"rate_limit,omitempty"`
+
+	// Remove the artifacts of old runs from the output directory
+	Retention        *RetentionSettings      `yaml:"retention,omitempty"`
+
+	// Encrypt screenshots, videos, traces, bundles, PDFs and WebSocket logs at rest
+	Encryption       *EncryptionSettings     `yaml:"encryption,omitempty"`
+
+	// Mask personal data in logs, traces, results and screenshots
+	Redaction        *RedactionSettings      `yaml:"redaction,omitempty"`
+
+	// Vault, AWS Secrets Manager or environment credentials for login and totp actions
+	Secrets          *SecretsSettings        `yaml:"secrets,omitempty"`
+
+	// Fail on, wait out, solve or bypass captchas shown on web pages
+	Captcha          *CaptchaSettings        `yaml:"captcha,omitempty"`
+
+	// Figma file vision_design_compare actions compare components against
+	Figma            *FigmaSettings          `yaml:"figma,omitempty"`
+
+	// JSON Schemas of the API responses web apps receive, checked by later runs
+	Contracts        *ContractSettings       `yaml:"contracts,omitempty"`
+
+	// Objectives the run's metrics are held to against the recent runs
+	SLO              *SLOSettings            `yaml:"slo,omitempty"`
+
+	// Branding of the HTML report and notifications
+	Report           *ReportSettings         `yaml:"report,omitempty"`
+}
+
+// ResourceSettings samples the CPU, RSS and GPU memory of each app's browser or
+// app process. A threshold fails the app when its peak exceeds it; zero disables it.
+type ResourceSettings struct {
+	Enabled        bool          `yaml:"enabled"`
+	Interval       time.Duration `yaml:"interval"`          // default 1s
+	MaxCPUPercent  float64       `yaml:"max_cpu_percent"`   // share of one core, e.g. 250 = 2.5 cores
+	MaxRSSMB       int           `yaml:"max_rss_mb"`        // whole process tree
+	MaxGPUMemoryMB int           `yaml:"max_gpu_memory_mb"` // needs nvidia-smi
+}
+
+// TelemetrySettings configures OpenTelemetry tracing. Unset fields fall back
+// to the standard OTEL_EXPORTER_OTLP_* and OTEL_SERVICE_NAME variables; header
+// values are expanded from the environment.
+type TelemetrySettings struct {
+	Enabled     bool              `yaml:"enabled"`
+	Endpoint    string            `yaml:"endpoint"`     // OTLP/HTTP base URL, e.g. http://localhost:4318
+	ServiceName string            `yaml:"service_name"` // default panoptic
+	Headers     map[string]string `yaml:"headers,omitempty"`
+	Attributes  map[string]string `yaml:"attributes,omitempty"` // extra resource attributes
+}
+
+// LoggingSettings configures structured log output
+type LoggingSettings struct {
+	Format     string `yaml:"format"`       // text (default) or json
+	Sink       string `yaml:"sink"`         // stdout (default), stderr, file or both
+	File       string `yaml:"file"`         // defaults to <output>/logs/panoptic.log
+	MaxSizeMB  int    `yaml:"max_size_mb"`  // rotate after this size (default 100)
+	MaxBackups int    `yaml:"max_backups"`  // rotated files to keep (default 5)
+
+	// Ship logs to Loki, Elasticsearch or syslog
+	Forward []LogForwardSettings `yaml:"forward,omitempty"`
+}
+
+// LogForwardSettings configures one log forwarding destination. Username,
+// Password and Token are expanded from the environment, e.g. "${LOKI_TOKEN}".
+type LogForwardSettings struct {
+	Type          string            `yaml:"type"`  // loki, elasticsearch or syslog
+	URL           string            `yaml:"url"`   // syslog: udp://host:514 or tcp://host:514
+	Index         string            `yaml:"index"` // elasticsearch index (default panoptic-logs)
+	Labels        map[string]string `yaml:"labels,omitempty"` // loki stream labels
+	Username      string            `yaml:"username"`
+	Password      string            `yaml:"password"`
+	Token         string            `yaml:"token"`
+	BatchSize     int               `yaml:"batch_size"`
+	BufferSize    int               `yaml:"buffer_size"`
+	FlushInterval time.Duration     `yaml:"flush_interval"`
+	Timeout       time.Duration     `yaml:"timeout"`
 }
 
 type AITestingSettings struct {
@@ -128,10 +397,47 @@ func Load(configFile string) (*Config, error) {
 	}
 	
 	// Cache miss - load and parse config
+	config, err := ParseRelative(data, filepath.Dir(configFile))
+	if err != nil {
+		return nil, err
+	}
+	// Feature files change without the configuration changing
+	if config.Gherkin != nil {
+		return config, nil
+	}
+
+	// Cache the loaded config
+	cacheEntry := &ConfigCacheEntry{
+		Config:   config,
+		ModTime:  fileInfo.ModTime(),
+		Checksum: checksum,
+		LoadedAt: time.Now(),
+	}
+	configCache.Store(configFile, cacheEntry)
+
+	return config, nil
+}
+
+// Parse reads a configuration from YAML, expanding Gherkin features and app
+// matrices and filling in setting defaults as Load does. Feature files are
+// read relative to the working directory.
+func Parse(data []byte) (*Config, error) {
+	return ParseRelative(data, ".")
+}
+
+// ParseRelative is Parse reading feature files relative to dir, the
+// directory of the configuration
+func ParseRelative(data []byte, dir string) (*Config, error) {
 	var config Config
 	if err := yaml.Unmarshal(data, &config); err != nil {
 		return nil, fmt.Errorf("failed to parse config file: %w", err)
 	}
+	if err := config.ExpandFeatures(dir); err != nil {
+		return nil, fmt.Errorf("failed to expand gherkin features: %w", err)
+	}
+	if err := config.ExpandMatrix(); err != nil {
+		return nil, fmt.Errorf("failed to expand app matrix: %w", err)
+	}
 
 	// Set defaults
 	if config.Settings.ScreenshotFormat == "" {
@@ -153,15 +459,6 @@ func Load(configFile string) (*Config, error) {
 		config.Settings.LogLevel = "info"
 	}
 
-	// Cache the loaded config
-	cacheEntry := &ConfigCacheEntry{
-		Config:   &config,
-		ModTime:  fileInfo.ModTime(),
-		Checksum: checksum,
-		LoadedAt: time.Now(),
-	}
-	configCache.Store(configFile, cacheEntry)
-
 	return &config, nil
 }
 
@@ -191,32 +488,122 @@ func (c *Config) Validate() error {
 			if app.Platform == "" {
 				return fmt.Errorf("platform is required for mobile applications")
 			}
+		case "driver":
+			if app.Driver == nil || app.Driver.Command == "" {
+				return fmt.Errorf("driver.command is required for driver applications")
+			}
 		default:
 			return fmt.Errorf("unknown application type: %s", app.Type)
 		}
 
-		// Validate per-app actions
-		for _, action := range app.Actions {
-			if action.Name == "" {
-				return fmt.Errorf("action name is required in app %s", app.Name)
-			}
-			if action.Type == "" {
-				return fmt.Errorf("action type is required for action %s in app %s", action.Name, app.Name)
-			}
-			if action.Type == "navigate" && action.GetNavigateURL() == "" {
-				return fmt.Errorf("URL or value is required for navigate action %s in app %s", action.Name, app.Name)
+		if err := app.validateBrowsers(); err != nil {
+			return fmt.Errorf("app %s: %w", app.Name, err)
+		}
+		if err := app.Quarantine.Validate(); err != nil {
+			return fmt.Errorf("app %s: %w", app.Name, err)
+		}
+		if err := app.Dialogs.Validate(); err != nil {
+			return fmt.Errorf("app %s: dialogs: %w", app.Name, err)
+		}
+		if err := app.I18n.Validate(); err != nil {
+			return fmt.Errorf("app %s: i18n: %w", app.Name, err)
+		}
+		if app.I18n != nil && app.Type != "web" {
+			return fmt.Errorf("app %s: i18n is only supported on web apps", app.Name)
+		}
+		if app.Locale != "" && !localeTag.MatchString(app.Locale) {
+			return fmt.Errorf("app %s: locale %q is not a language tag such as de-DE", app.Name, app.Locale)
+		}
+		if app.Dialogs != nil && app.Dialogs.Expect != "" {
+			return fmt.Errorf("app %s: dialogs: expect is set on the dialog of an action", app.Name)
+		}
+		if err := app.WaitFor.Validate(app); err != nil {
+			return fmt.Errorf("app %s: %w", app.Name, err)
+		}
+		if len(app.FeatureFlags) > 0 && c.Settings.FeatureFlags == nil {
+			return fmt.Errorf("app %s: feature_flags requires settings.feature_flags", app.Name)
+		}
+		if dim := unexpandedMatrix(app.Name, app.URL, app.Path); dim != "" {
+			return fmt.Errorf("app %s uses {{matrix.%s}}, which its matrix doesn't define", app.Name, dim)
+		}
+		for _, action := range c.GetActionsForApp(app) {
+			if dim := unexpandedMatrix(action.URL, action.Value, action.Selector, action.Target); dim != "" {
+				return fmt.Errorf("action %s in app %s uses {{matrix.%s}}, which the app's matrix doesn't define", action.Name, app.Name, dim)
 			}
 		}
 	}
 
-	// Validate global actions
-	for _, action := range c.Actions {
-		if action.Type == "navigate" && action.GetNavigateURL() == "" {
-			return fmt.Errorf("URL or value is required for navigate action %s", action.Name)
+	if err := c.Settings.Network.Validate(); err != nil {
+		return fmt.Errorf("settings.network: %w", err)
+	}
+	if err := c.Settings.FeatureFlags.Validate(); err != nil {
+		return fmt.Errorf("settings.feature_flags: %w", err)
+	}
+	if err := c.Settings.Inbox.Validate(); err != nil {
+		return fmt.Errorf("settings.inbox: %w", err)
+	}
+	if err := c.Settings.Vision.Validate(); err != nil {
+		return fmt.Errorf("settings.vision: %w", err)
+	}
+	switch c.Settings.ScreenshotFormat {
+	case "", "png", "jpg", "jpeg", "webp":
+	default:
+		return fmt.Errorf("settings.screenshot_format must be png, jpg or webp, got %q", c.Settings.ScreenshotFormat)
+	}
+	if err := c.Settings.BrowserPool.Validate(); err != nil {
+		return fmt.Errorf("settings.browser_pool: %w", err)
+	}
+	if err := c.Settings.RateLimit.Validate(); err != nil {
+		return fmt.Errorf("settings.rate_limit: %w", err)
+	}
+	if err := c.Settings.Retention.Validate(); err != nil {
+		return fmt.Errorf("settings.retention: %w", err)
+	}
+	if err := c.Settings.Encryption.Validate(); err != nil {
+		return fmt.Errorf("settings.encryption: %w", err)
+	}
+	if err := c.Settings.Redaction.Validate(); err != nil {
+		return fmt.Errorf("settings.redaction: %w", err)
+	}
+	if err := c.Settings.Secrets.Validate(); err != nil {
+		return fmt.Errorf("settings.secrets: %w", err)
+	}
+	if err := c.Settings.Captcha.Validate(); err != nil {
+		return fmt.Errorf("settings.captcha: %w", err)
+	}
+	if err := c.Settings.Figma.Validate(); err != nil {
+		return fmt.Errorf("settings.figma: %w", err)
+	}
+	if err := c.Settings.Contracts.Validate(); err != nil {
+		return fmt.Errorf("settings.contracts: %w", err)
+	}
+	if err := c.Settings.SLO.Validate(); err != nil {
+		return fmt.Errorf("settings.slo: %w", err)
+	}
+	if err := c.Settings.Report.Validate(); err != nil {
+		return fmt.Errorf("settings.report: %w", err)
+	}
+	if err := c.Settings.FailurePolicy.Validate(); err != nil {
+		return err
+	}
+	if err := c.Settings.GitHub.Validate(); err != nil {
+		return err
+	}
+	if err := c.Settings.Issues.Validate(); err != nil {
+		return err
+	}
+	for i, n := range c.Settings.Notifications {
+		if err := n.Validate(); err != nil {
+			return fmt.Errorf("settings.notifications[%d]: %w", i, err)
+		}
+	}
+	for i, a := range c.Settings.Alerts {
+		if err := a.Validate(); err != nil {
+			return fmt.Errorf("settings.alerts[%d]: %w", i, err)
 		}
 	}
 
-	return nil
+	return c.validateActions()
 }
 
 // GetActionsForApp returns per-app actions if defined, otherwise falls back to global actions.
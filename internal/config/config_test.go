@@ -3,9 +3,11 @@ package config
 import (
 	"os"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
 )
 
 func TestLoad(t *testing.T) {
@@ -203,6 +205,32 @@ func TestValidate(t *testing.T) {
 			},
 			expectErr: false,
 		},
+		{
+			name: "Valid driver app",
+			config: Config{
+				Apps: []AppConfig{
+					{
+						Name:   "Test Kiosk",
+						Type:   "driver",
+						Driver: &DriverConfig{Command: "kiosk-driver"},
+					},
+				},
+			},
+			expectErr: false,
+		},
+		{
+			name: "Driver app without command",
+			config: Config{
+				Apps: []AppConfig{
+					{
+						Name: "Test Kiosk",
+						Type: "driver",
+					},
+				},
+			},
+			expectErr: true,
+			errMsg:    "driver.command is required for driver applications",
+		},
 		{
 			name: "Multiple valid apps",
 			config: Config{
@@ -506,6 +534,19 @@ func TestGetActionsForApp(t *testing.T) {
 	})
 }
 
+// TestParse tests reading a configuration from memory with Load's defaults
+func TestParse(t *testing.T) {
+	cfg, err := Parse([]byte("name: Inline\napps:\n  - name: Site\n    type: web\n    url: https://example.com\nsettings:\n  quality: 50\n"))
+	require.NoError(t, err)
+	assert.Equal(t, "Inline", cfg.Name)
+	assert.Equal(t, 50, cfg.Settings.Quality)
+	assert.Equal(t, "png", cfg.Settings.ScreenshotFormat)
+	assert.Equal(t, 1920, cfg.Settings.WindowWidth)
+
+	_, err = Parse([]byte("apps: {"))
+	assert.ErrorContains(t, err, "failed to parse config file")
+}
+
 func TestLoadConfigWithURLField(t *testing.T) {
 	configContent := `
 name: "URL Field Test"
@@ -567,4 +608,165 @@ apps:
 		assert.Contains(t, config.Name, "&")
 		assert.Contains(t, config.Output, "spaces")
 	})
-}
\ No newline at end of file
+}
+// TestBrowserVariants tests browser matrix parsing and expansion
+func TestBrowserVariants(t *testing.T) {
+	var app AppConfig
+	err := yaml.Unmarshal([]byte(`
+name: Shop
+type: web
+url: https://shop.example.com
+browsers:
+  - chromium
+  - engine: edge
+    channel: beta
+  - engine: chromium
+    version: "1300313"
+`), &app)
+	require.NoError(t, err)
+	require.Len(t, app.Browsers, 3)
+	assert.Equal(t, "chromium", app.Browsers[0].Engine)
+
+	variants := app.BrowserVariants()
+	require.Len(t, variants, 3)
+	assert.Equal(t, "Shop [chromium]", variants[0].Name)
+	assert.Equal(t, "Shop [edge-beta]", variants[1].Name)
+	assert.Equal(t, "edge", variants[1].Browser)
+	assert.Equal(t, "beta", variants[1].BrowserChannel)
+	assert.Equal(t, "Shop [chromium@1300313]", variants[2].Name)
+	assert.Equal(t, "1300313", variants[2].BrowserVersion)
+	for _, v := range variants {
+		assert.Empty(t, v.Browsers)
+		assert.Equal(t, "https://shop.example.com", v.URL)
+	}
+
+	single := AppConfig{Name: "Single", Browser: "firefox"}
+	assert.Equal(t, []AppConfig{single}, single.BrowserVariants())
+	assert.Equal(t, "firefox", single.BrowserLabel())
+	assert.Equal(t, "chromium", AppConfig{}.BrowserLabel())
+}
+
+// TestValidate_UnsupportedBrowsers tests that engines the web platform
+// can't drive fail validation, as the browser or in the matrix
+func TestValidate_UnsupportedBrowsers(t *testing.T) {
+	app := AppConfig{Name: "Shop", Type: "web", URL: "https://shop.example.com",
+		Browsers: []BrowserConfig{{Engine: "chromium"}, {Engine: "edge", Channel: "beta"}}}
+	cfg := &Config{Apps: []AppConfig{app}}
+	assert.NoError(t, cfg.Validate())
+
+	app.Browsers = append(app.Browsers, BrowserConfig{Engine: "Firefox"})
+	cfg.Apps[0] = app
+	assert.ErrorContains(t, cfg.Validate(), "app Shop: browser Firefox is not supported")
+
+	cfg.Apps[0] = AppConfig{Name: "Shop", Type: "web", URL: "https://shop.example.com", Browser: "safari"}
+	assert.ErrorContains(t, cfg.Validate(), "browser safari is not supported")
+}
+
+// TestLoggingSettings tests parsing structured logging and forwarding settings
+func TestLoggingSettings(t *testing.T) {
+	var settings Settings
+	err := yaml.Unmarshal([]byte(`
+logging:
+  format: json
+  sink: both
+  max_size_mb: 50
+  forward:
+    - type: loki
+      url: http://loki:3100
+      labels: {env: ci}
+      flush_interval: 5s
+    - type: syslog
+      url: udp://logs.internal:514
+`), &settings)
+	require.NoError(t, err)
+
+	require.NotNil(t, settings.Logging)
+	assert.Equal(t, "json", settings.Logging.Format)
+	assert.Equal(t, 50, settings.Logging.MaxSizeMB)
+	require.Len(t, settings.Logging.Forward, 2)
+	assert.Equal(t, "ci", settings.Logging.Forward[0].Labels["env"])
+	assert.Equal(t, 5*time.Second, settings.Logging.Forward[0].FlushInterval)
+	assert.Equal(t, "syslog", settings.Logging.Forward[1].Type)
+}
+
+func TestTelemetrySettings(t *testing.T) {
+	var settings Settings
+	err := yaml.Unmarshal([]byte(`
+telemetry:
+  enabled: true
+  endpoint: http://otel-collector:4318
+  service_name: panoptic-nightly
+  headers:
+    authorization: "Bearer ${OTLP_TOKEN}"
+  attributes:
+    deployment.environment: staging
+`), &settings)
+	require.NoError(t, err)
+
+	require.NotNil(t, settings.Telemetry)
+	assert.True(t, settings.Telemetry.Enabled)
+	assert.Equal(t, "http://otel-collector:4318", settings.Telemetry.Endpoint)
+	assert.Equal(t, "panoptic-nightly", settings.Telemetry.ServiceName)
+	assert.Equal(t, "Bearer ${OTLP_TOKEN}", settings.Telemetry.Headers["authorization"], "expansion happens at run time")
+	assert.Equal(t, "staging", settings.Telemetry.Attributes["deployment.environment"])
+}
+
+func TestResourceSettings(t *testing.T) {
+	var settings Settings
+	err := yaml.Unmarshal([]byte(`
+resources:
+  enabled: true
+  interval: 500ms
+  max_cpu_percent: 250
+  max_rss_mb: 1024
+  max_gpu_memory_mb: 512
+`), &settings)
+	require.NoError(t, err)
+
+	require.NotNil(t, settings.Resources)
+	assert.True(t, settings.Resources.Enabled)
+	assert.Equal(t, 500*time.Millisecond, settings.Resources.Interval)
+	assert.Equal(t, 250.0, settings.Resources.MaxCPUPercent)
+	assert.Equal(t, 1024, settings.Resources.MaxRSSMB)
+	assert.Equal(t, 512, settings.Resources.MaxGPUMemoryMB)
+}
+
+func TestPerformanceBudgetSettings(t *testing.T) {
+	var cfg Config
+	err := yaml.Unmarshal([]byte(`
+settings:
+  performance_budgets:
+    lcp: 2500
+    cls: 0.1
+actions:
+  - name: home budget
+    type: performance_assert
+    parameters: {inp: 200}
+`), &cfg)
+	require.NoError(t, err)
+
+	assert.Equal(t, map[string]float64{"lcp": 2500, "cls": 0.1}, cfg.Settings.PerformanceBudgets)
+	require.Len(t, cfg.Actions, 1)
+	assert.Equal(t, 200, cfg.Actions[0].Parameters["inp"])
+}
+
+func TestFakeSeedSetting(t *testing.T) {
+	var settings Settings
+	require.NoError(t, yaml.Unmarshal([]byte("fake_seed: 0\n"), &settings))
+	require.NotNil(t, settings.FakeSeed, "zero is a valid seed")
+	assert.Equal(t, int64(0), *settings.FakeSeed)
+
+	settings = Settings{}
+	require.NoError(t, yaml.Unmarshal([]byte("headless: true\n"), &settings))
+	assert.Nil(t, settings.FakeSeed)
+}
+
+func TestValidate_ScreenshotFormat(t *testing.T) {
+	cfg := &Config{Apps: []AppConfig{{Name: "Shop", Type: "web", URL: "https://shop.example.com"}}}
+	for _, format := range []string{"", "png", "jpg", "jpeg", "webp"} {
+		cfg.Settings.ScreenshotFormat = format
+		assert.NoError(t, cfg.Validate(), format)
+	}
+	cfg.Settings.ScreenshotFormat = "bmp"
+	assert.EqualError(t, cfg.Validate(), `settings.screenshot_format must be png, jpg or webp, got "bmp"`)
+}
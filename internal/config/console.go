@@ -0,0 +1,46 @@
+package config
+
+import (
+	"fmt"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ConsoleCheck is a console_check action's parameters. The action fails when
+// the page logged more than MaxErrors console errors or uncaught exceptions
+// since the app started or the previous console_check. Errors containing
+// one of the Ignore texts don't count.
+type ConsoleCheck struct {
+	MaxErrors int      `yaml:"max_errors"`
+	Ignore    []string `yaml:"ignore"`
+}
+
+// ConsoleCheck reads a console_check action's parameters
+func (a *Action) ConsoleCheck() (*ConsoleCheck, error) {
+	check := &ConsoleCheck{}
+	data, err := yaml.Marshal(a.Parameters)
+	if err != nil {
+		return nil, err
+	}
+	if err := yaml.Unmarshal(data, check); err != nil {
+		return nil, fmt.Errorf("invalid console_check parameters: %w", err)
+	}
+	if check.MaxErrors < 0 {
+		return nil, fmt.Errorf("max_errors must not be negative, got %d", check.MaxErrors)
+	}
+	for i, text := range check.Ignore {
+		if text == "" {
+			return nil, fmt.Errorf("ignore[%d] is empty", i)
+		}
+	}
+	return check, nil
+}
+
+// validateConsoleAction checks the parameters of console_check actions
+func (c *Config) validateConsoleAction(action Action) error {
+	if action.Type != "console_check" {
+		return nil
+	}
+	_, err := action.ConsoleCheck()
+	return err
+}
@@ -0,0 +1,35 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAction_ConsoleCheck(t *testing.T) {
+	check, err := (&Action{Type: "console_check"}).ConsoleCheck()
+	require.NoError(t, err)
+	assert.Equal(t, &ConsoleCheck{}, check)
+
+	check, err = (&Action{Type: "console_check", Parameters: map[string]interface{}{
+		"max_errors": 2, "ignore": []interface{}{"favicon.ico"},
+	}}).ConsoleCheck()
+	require.NoError(t, err)
+	assert.Equal(t, &ConsoleCheck{MaxErrors: 2, Ignore: []string{"favicon.ico"}}, check)
+
+	_, err = (&Action{Parameters: map[string]interface{}{"max_errors": -1}}).ConsoleCheck()
+	assert.ErrorContains(t, err, "max_errors must not be negative")
+	_, err = (&Action{Parameters: map[string]interface{}{"ignore": []interface{}{""}}}).ConsoleCheck()
+	assert.ErrorContains(t, err, "ignore[0] is empty")
+	_, err = (&Action{Parameters: map[string]interface{}{"max_errors": "many"}}).ConsoleCheck()
+	assert.ErrorContains(t, err, "invalid console_check parameters")
+}
+
+func TestConfig_Validate_ConsoleCheck(t *testing.T) {
+	cfg := &Config{
+		Apps:    []AppConfig{{Name: "Shop", Type: "web", URL: "https://shop.example.com"}},
+		Actions: []Action{{Name: "no errors", Type: "console_check", Parameters: map[string]interface{}{"max_errors": -1}}},
+	}
+	assert.ErrorContains(t, cfg.Validate(), "action no errors: max_errors must not be negative")
+}
@@ -0,0 +1,53 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ContractSettings turn on contract testing of the JSON APIs web apps
+// call: `panoptic run --record-contracts` saves the JSON Schemas of the
+// responses their pages receive, by endpoint, and later runs fail the apps
+// whose responses break them, e.g. by dropping a field or changing its
+// type. URLs and Ignore match parts of response URLs.
+type ContractSettings struct {
+	File   string   `yaml:"file"`   // default <output>/contracts.json
+	URLs   []string `yaml:"urls"`   // the responses covered; every JSON response to fetch and XHR requests by default
+	Ignore []string `yaml:"ignore"` // responses left out, e.g. analytics
+}
+
+// Validate checks no URL part is empty
+func (s *ContractSettings) Validate() error {
+	if s == nil {
+		return nil
+	}
+	for name, parts := range map[string][]string{"urls": s.URLs, "ignore": s.Ignore} {
+		for _, part := range parts {
+			if strings.TrimSpace(part) == "" {
+				return fmt.Errorf("%s must not have empty entries", name)
+			}
+		}
+	}
+	return nil
+}
+
+// Covers tells whether contracts cover responses from url
+func (s *ContractSettings) Covers(url string) bool {
+	if s == nil {
+		return true
+	}
+	for _, part := range s.Ignore {
+		if strings.Contains(url, part) {
+			return false
+		}
+	}
+	if len(s.URLs) == 0 {
+		return true
+	}
+	for _, part := range s.URLs {
+		if strings.Contains(url, part) {
+			return true
+		}
+	}
+	return false
+}
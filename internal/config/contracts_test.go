@@ -0,0 +1,29 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestContractSettings_Covers(t *testing.T) {
+	var unset *ContractSettings
+	assert.True(t, unset.Covers("https://shop.test/config.json"), "--record-contracts without settings covers every response")
+
+	s := &ContractSettings{URLs: []string{"/api/", "graphql"}, Ignore: []string{"/api/telemetry"}}
+	assert.True(t, s.Covers("https://shop.test/api/orders/1"))
+	assert.True(t, s.Covers("https://gql.shop.test/graphql"))
+	assert.False(t, s.Covers("https://shop.test/api/telemetry/events"))
+	assert.False(t, s.Covers("https://shop.test/config.json"))
+}
+
+func TestContractSettings_Validate(t *testing.T) {
+	assert.NoError(t, (&ContractSettings{}).Validate())
+	assert.EqualError(t, (&ContractSettings{Ignore: []string{" "}}).Validate(), "ignore must not have empty entries")
+
+	cfg := &Config{
+		Apps:     []AppConfig{{Name: "Shop", Type: "web", URL: "https://shop.example.com", Actions: []Action{{Name: "home", Type: "navigate", Value: "https://shop.example.com"}}}},
+		Settings: Settings{Contracts: &ContractSettings{URLs: []string{""}}},
+	}
+	assert.EqualError(t, cfg.Validate(), "settings.contracts: urls must not have empty entries")
+}
@@ -0,0 +1,62 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+)
+
+// DialogPolicy is how native alert, confirm, prompt and beforeunload dialogs
+// are answered: accepted, or dismissed as by pressing Cancel, with
+// PromptText typed into prompts before they're accepted. Expect, on an
+// action, fails the action unless a dialog whose message contains it, ignoring
+// case, opens while it runs.
+type DialogPolicy struct {
+	Response   string `yaml:"response"`              // accept or dismiss (the default)
+	PromptText string `yaml:"prompt_text,omitempty"` // typed into accepted prompts
+	Expect     string `yaml:"expect,omitempty"`      // action dialogs only
+}
+
+// Accepts tells whether dialogs are accepted
+func (d *DialogPolicy) Accepts() bool {
+	return d != nil && d.Response == "accept"
+}
+
+// Validate checks the response
+func (d *DialogPolicy) Validate() error {
+	if d == nil {
+		return nil
+	}
+	switch d.Response {
+	case "", "accept", "dismiss":
+	default:
+		return fmt.Errorf("response must be accept or dismiss, got %q", d.Response)
+	}
+	if d.PromptText != "" && d.Response != "accept" {
+		return fmt.Errorf("prompt_text needs response accept")
+	}
+	return nil
+}
+
+// DialogPolicyFor is the policy dialogs opened by action get: the action's
+// own response, or the app's, dismissing them when neither has one
+func DialogPolicyFor(app AppConfig, action Action) DialogPolicy {
+	var policy DialogPolicy
+	if app.Dialogs != nil {
+		policy.Response, policy.PromptText = app.Dialogs.Response, app.Dialogs.PromptText
+	}
+	if d := action.Dialog; d != nil {
+		if d.Response != "" {
+			policy.Response, policy.PromptText = d.Response, d.PromptText
+		}
+		policy.Expect = d.Expect
+	}
+	if policy.Response == "" {
+		policy.Response = "dismiss"
+	}
+	return policy
+}
+
+// ExpectedBy tells whether a dialog message satisfies Expect
+func (d *DialogPolicy) ExpectedBy(message string) bool {
+	return strings.Contains(strings.ToLower(message), strings.ToLower(d.Expect))
+}
@@ -0,0 +1,43 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDialogPolicy_Validate(t *testing.T) {
+	var unset *DialogPolicy
+	assert.NoError(t, unset.Validate())
+	assert.False(t, unset.Accepts())
+	assert.NoError(t, (&DialogPolicy{Response: "accept", PromptText: "Ada"}).Validate())
+	assert.True(t, (&DialogPolicy{Response: "accept"}).Accepts())
+	assert.ErrorContains(t, (&DialogPolicy{Response: "ignore"}).Validate(), "response must be accept or dismiss")
+	assert.ErrorContains(t, (&DialogPolicy{PromptText: "Ada"}).Validate(), "prompt_text needs response accept")
+
+	app := AppConfig{Name: "Shop", Type: "web", URL: "https://shop.example.com", Dialogs: &DialogPolicy{Response: "accept", Expect: "Sure?"}}
+	cfg := &Config{Apps: []AppConfig{app}}
+	assert.ErrorContains(t, cfg.Validate(), "app Shop: dialogs: expect is set on the dialog of an action")
+
+	app.Dialogs = nil
+	app.Actions = []Action{{Name: "delete", Type: "click", Selector: "#delete", Dialog: &DialogPolicy{Response: "yes"}}}
+	cfg.Apps = []AppConfig{app}
+	assert.ErrorContains(t, cfg.Validate(), "action delete in app Shop: dialog: response must be accept or dismiss")
+}
+
+func TestDialogPolicyFor(t *testing.T) {
+	app := AppConfig{Name: "Shop"}
+	click := Action{Name: "delete", Type: "click"}
+	assert.Equal(t, DialogPolicy{Response: "dismiss"}, DialogPolicyFor(app, click))
+
+	app.Dialogs = &DialogPolicy{Response: "accept", PromptText: "Ada"}
+	assert.Equal(t, DialogPolicy{Response: "accept", PromptText: "Ada"}, DialogPolicyFor(app, click))
+
+	click.Dialog = &DialogPolicy{Expect: "delete this order?"}
+	assert.Equal(t, DialogPolicy{Response: "accept", PromptText: "Ada", Expect: "delete this order?"}, DialogPolicyFor(app, click))
+	click.Dialog.Response = "dismiss"
+	policy := DialogPolicyFor(app, click)
+	assert.Equal(t, DialogPolicy{Response: "dismiss", Expect: "delete this order?"}, policy)
+	assert.True(t, policy.ExpectedBy("Delete this order? It can't be undone."))
+	assert.False(t, policy.ExpectedBy("Leave site?"))
+}
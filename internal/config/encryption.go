@@ -0,0 +1,51 @@
+package config
+
+import (
+	"fmt"
+	"slices"
+)
+
+// EncryptableArtifacts are the artifact kinds settings.encryption encrypts
+var EncryptableArtifacts = []string{"screenshots", "videos", "traces", "bundles", "pdfs", "websockets"}
+
+// EncryptionSettings encrypts the artifacts of a run at rest with AES-256-GCM
+// as each app finishes. The 32 byte key, base64 or hex encoded, is read from
+// key_env or printed by key_command, such as a KMS CLI decrypting a data key.
+type EncryptionSettings struct {
+	Enabled    bool     `yaml:"enabled"`
+	KeyEnv     string   `yaml:"key_env,omitempty"`     // defaults to PANOPTIC_ARTIFACT_KEY
+	KeyCommand []string `yaml:"key_command,omitempty"` // run instead of reading key_env
+	Artifacts  []string `yaml:"artifacts,omitempty"`   // kinds to encrypt; all of them by default
+}
+
+// IsEnabled tells whether artifacts are encrypted
+func (s *EncryptionSettings) IsEnabled() bool {
+	return s != nil && s.Enabled
+}
+
+// Kinds are the artifact kinds to encrypt
+func (s *EncryptionSettings) Kinds() []string {
+	if !s.IsEnabled() {
+		return nil
+	}
+	if len(s.Artifacts) == 0 {
+		return EncryptableArtifacts
+	}
+	return s.Artifacts
+}
+
+// Validate checks the artifact kinds
+func (s *EncryptionSettings) Validate() error {
+	if s == nil {
+		return nil
+	}
+	for _, kind := range s.Artifacts {
+		if !slices.Contains(EncryptableArtifacts, kind) {
+			return fmt.Errorf("unknown artifact kind %q; use one of %v", kind, EncryptableArtifacts)
+		}
+	}
+	if len(s.KeyCommand) > 0 && s.KeyCommand[0] == "" {
+		return fmt.Errorf("key_command needs a program")
+	}
+	return nil
+}
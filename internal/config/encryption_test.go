@@ -0,0 +1,30 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEncryptionSettings_Kinds(t *testing.T) {
+	var unset *EncryptionSettings
+	assert.False(t, unset.IsEnabled())
+	assert.Empty(t, unset.Kinds())
+	assert.Empty(t, (&EncryptionSettings{Artifacts: []string{"videos"}}).Kinds())
+	assert.Equal(t, EncryptableArtifacts, (&EncryptionSettings{Enabled: true}).Kinds())
+	assert.Equal(t, []string{"videos"}, (&EncryptionSettings{Enabled: true, Artifacts: []string{"videos"}}).Kinds())
+}
+
+func TestEncryptionSettings_Validate(t *testing.T) {
+	var unset *EncryptionSettings
+	assert.NoError(t, unset.Validate())
+	assert.NoError(t, (&EncryptionSettings{Enabled: true, Artifacts: []string{"screenshots", "traces"}}).Validate())
+	assert.ErrorContains(t, (&EncryptionSettings{Artifacts: []string{"logs"}}).Validate(), `unknown artifact kind "logs"`)
+	assert.ErrorContains(t, (&EncryptionSettings{KeyCommand: []string{""}}).Validate(), "key_command needs a program")
+
+	cfg := &Config{
+		Apps:     []AppConfig{{Name: "Shop", Type: "web", URL: "https://shop.example.com"}},
+		Settings: Settings{Encryption: &EncryptionSettings{Artifacts: []string{"dom"}}},
+	}
+	assert.ErrorContains(t, cfg.Validate(), "settings.encryption: unknown artifact kind")
+}
@@ -0,0 +1,115 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Failure policy modes
+const (
+	FailOnAny       = "any"       // any failed app fails the run (default)
+	FailOnThreshold = "threshold" // fail when more than MaxFailurePercent of apps failed
+	FailNever       = "never"     // report only; the exit code ignores failures
+)
+
+// Failure severities
+const (
+	SeverityError   = "error"   // counts towards the failure policy (default)
+	SeverityWarning = "warning" // reported, but never fails the run
+)
+
+// Failure categories assigned by Panoptic. Actions may set any other category,
+// e.g. "security", and give it a severity.
+const (
+	CategoryFunctional     = "functional"
+	CategoryPerformance    = "performance"
+	CategoryResources      = "resources"
+	CategoryAI             = "ai"
+	CategoryCloud          = "cloud"
+	CategoryEnterprise     = "enterprise"
+	CategoryAccessibility  = "accessibility"
+	CategoryLayout         = "layout"
+	CategoryDesign         = "design"
+	CategoryLocalization   = "localization"
+	CategoryContract       = "contract"
+	CategoryInfrastructure = "infrastructure" // platform start-up, containers, Kubernetes jobs
+)
+
+// FailurePolicy decides whether failed apps fail the process exit code.
+// Quarantined failures never do.
+type FailurePolicy struct {
+	Mode              string            `yaml:"mode"`                 // any (default), threshold or never
+	MaxFailurePercent float64           `yaml:"max_failure_percent"`  // threshold mode
+	Severities        map[string]string `yaml:"severities,omitempty"` // category -> error or warning
+}
+
+// Validate checks the mode and severities; a nil policy is valid
+func (p *FailurePolicy) Validate() error {
+	if p == nil {
+		return nil
+	}
+	switch p.Mode {
+	case "", FailOnAny, FailNever:
+	case FailOnThreshold:
+		if p.MaxFailurePercent < 0 || p.MaxFailurePercent > 100 {
+			return fmt.Errorf("failure_policy.max_failure_percent must be between 0 and 100")
+		}
+	default:
+		return fmt.Errorf("unknown failure_policy mode %q (use any, threshold or never)", p.Mode)
+	}
+	for category, severity := range p.Severities {
+		if severity != SeverityError && severity != SeverityWarning {
+			return fmt.Errorf("failure_policy.severities.%s must be error or warning, got %q", category, severity)
+		}
+	}
+	return nil
+}
+
+// SeverityOf returns the configured severity of a failure category
+func (p *FailurePolicy) SeverityOf(category string) string {
+	if p != nil {
+		if severity, ok := p.Severities[category]; ok {
+			return severity
+		}
+	}
+	return SeverityError
+}
+
+// FailureCategory is the action's category, or the one its type belongs to
+func (a *Action) FailureCategory() string {
+	if a.Category != "" {
+		return strings.ToLower(a.Category)
+	}
+	switch {
+	case a.Type == "performance_assert":
+		return CategoryPerformance
+	case a.Type == "vision_contrast_check":
+		return CategoryAccessibility
+	case a.Type == "vision_layout_check":
+		return CategoryLayout
+	case a.Type == "vision_design_compare":
+		return CategoryDesign
+	case a.Type == "vision_report" || a.Type == "smart_error_detection" || strings.HasPrefix(a.Type, "ai_"):
+		return CategoryAI
+	case strings.HasPrefix(a.Type, "cloud_") || a.Type == "distributed_test":
+		return CategoryCloud
+	case enterpriseActionTypes[a.Type]:
+		return CategoryEnterprise
+	default:
+		return CategoryFunctional
+	}
+}
+
+var enterpriseActionTypes = map[string]bool{
+	"enterprise_status": true,
+	"user_create":       true,
+	"user_authenticate": true,
+	"project_create":    true,
+	"team_create":       true,
+	"api_key_create":    true,
+	"audit_report":      true,
+	"compliance_check":  true,
+	"license_info":      true,
+	"backup_data":       true,
+	"cleanup_data":      true,
+}
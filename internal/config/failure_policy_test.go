@@ -0,0 +1,74 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+// TestFailurePolicy_Validate tests mode, threshold and severity checks
+func TestFailurePolicy_Validate(t *testing.T) {
+	var none *FailurePolicy
+	assert.NoError(t, none.Validate())
+	assert.NoError(t, (&FailurePolicy{}).Validate())
+	assert.NoError(t, (&FailurePolicy{Mode: FailOnThreshold, MaxFailurePercent: 10}).Validate())
+	assert.NoError(t, (&FailurePolicy{Mode: FailNever, Severities: map[string]string{"accessibility": "warning"}}).Validate())
+
+	assert.ErrorContains(t, (&FailurePolicy{Mode: "sometimes"}).Validate(), "unknown failure_policy mode")
+	assert.ErrorContains(t, (&FailurePolicy{Mode: FailOnThreshold, MaxFailurePercent: 150}).Validate(), "between 0 and 100")
+	assert.ErrorContains(t, (&FailurePolicy{Severities: map[string]string{"performance": "fatal"}}).Validate(), "severities.performance")
+
+	cfg := &Config{
+		Apps:     []AppConfig{{Name: "Shop", Type: "web", URL: "https://example.com"}},
+		Settings: Settings{FailurePolicy: &FailurePolicy{Mode: "sometimes"}},
+	}
+	assert.Error(t, cfg.Validate())
+}
+
+// TestFailurePolicy_SeverityOf tests the error default
+func TestFailurePolicy_SeverityOf(t *testing.T) {
+	var none *FailurePolicy
+	assert.Equal(t, SeverityError, none.SeverityOf(CategoryFunctional))
+
+	var cfg Config
+	require.NoError(t, yaml.Unmarshal([]byte(`
+settings:
+  failure_policy:
+    mode: threshold
+    max_failure_percent: 5
+    severities:
+      accessibility: warning
+`), &cfg))
+	policy := cfg.Settings.FailurePolicy
+	require.NotNil(t, policy)
+	assert.Equal(t, FailOnThreshold, policy.Mode)
+	assert.Equal(t, 5.0, policy.MaxFailurePercent)
+	assert.Equal(t, SeverityWarning, policy.SeverityOf("accessibility"))
+	assert.Equal(t, SeverityError, policy.SeverityOf(CategoryFunctional))
+}
+
+// TestAction_FailureCategory tests categories derived from action types
+func TestAction_FailureCategory(t *testing.T) {
+	cases := map[string]string{
+		"click":                 CategoryFunctional,
+		"navigate":              CategoryFunctional,
+		"performance_assert":    CategoryPerformance,
+		"ai_enhanced_testing":   CategoryAI,
+		"smart_error_detection": CategoryAI,
+		"cloud_sync":            CategoryCloud,
+		"distributed_test":      CategoryCloud,
+		"compliance_check":      CategoryEnterprise,
+		"vision_contrast_check": CategoryAccessibility,
+		"vision_layout_check":   CategoryLayout,
+		"vision_design_compare": CategoryDesign,
+	}
+	for actionType, category := range cases {
+		action := Action{Type: actionType}
+		assert.Equal(t, category, action.FailureCategory(), actionType)
+	}
+
+	custom := Action{Type: "ai_enhanced_testing", Category: "Accessibility"}
+	assert.Equal(t, "accessibility", custom.FailureCategory())
+}
@@ -0,0 +1,90 @@
+package config
+
+import (
+	"fmt"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// FeatureFlagSettings connects to the flag provider whose flags the apps
+// override with feature_flags and set_feature_flag actions. Overrides change
+// the flag for everyone using the environment and are restored when the app
+// finishes, so point this at a test environment. Token is expanded from the
+// environment, e.g. "${LAUNCHDARKLY_API_TOKEN}".
+type FeatureFlagSettings struct {
+	Provider         string        `yaml:"provider"`          // launchdarkly, unleash or configcat
+	URL              string        `yaml:"url"`               // API base; required for Unleash, optional for the hosted services
+	Token            string        `yaml:"token"`             // LaunchDarkly API token, Unleash admin token, or ConfigCat "username:password"
+	Project          string        `yaml:"project"`           // LaunchDarkly project key, Unleash project (default "default"), ConfigCat config ID
+	Environment      string        `yaml:"environment"`       // LaunchDarkly environment key, Unleash environment, ConfigCat environment ID
+	PropagationDelay time.Duration `yaml:"propagation_delay"` // wait after changing flags for SDKs to pick the change up, e.g. 5s
+	Capture          []string      `yaml:"capture"`           // flags recorded in results; all flags when empty
+}
+
+// Validate checks the provider and the IDs it needs
+func (f *FeatureFlagSettings) Validate() error {
+	if f == nil {
+		return nil
+	}
+	switch f.Provider {
+	case "launchdarkly", "configcat":
+		if f.Project == "" {
+			return fmt.Errorf("%s needs project", f.Provider)
+		}
+	case "unleash":
+		if f.URL == "" {
+			return fmt.Errorf("unleash needs url")
+		}
+	default:
+		return fmt.Errorf("provider must be launchdarkly, unleash or configcat, got %q", f.Provider)
+	}
+	if f.Token == "" {
+		return fmt.Errorf("%s needs token", f.Provider)
+	}
+	if f.Environment == "" {
+		return fmt.Errorf("%s needs environment", f.Provider)
+	}
+	if f.PropagationDelay < 0 {
+		return fmt.Errorf("propagation_delay must not be negative")
+	}
+	return nil
+}
+
+// FeatureFlag returns the flag a set_feature_flag action changes and the
+// value to serve: parameters flag and value, or target and value, where
+// value is read as YAML so true, 3 and dark become a boolean, number and
+// string
+func (a *Action) FeatureFlag() (string, interface{}, error) {
+	key := a.Target
+	if flag, ok := a.Parameters["flag"].(string); ok {
+		key = flag
+	}
+	if key == "" {
+		return "", nil, fmt.Errorf("set_feature_flag needs the flag as target or parameters.flag")
+	}
+	if value, ok := a.Parameters["value"]; ok {
+		return key, value, nil
+	}
+	if a.Value == "" {
+		return "", nil, fmt.Errorf("set_feature_flag needs a value or parameters.value")
+	}
+	var value interface{}
+	if err := yaml.Unmarshal([]byte(a.Value), &value); err != nil {
+		return key, a.Value, nil
+	}
+	return key, value, nil
+}
+
+// validateFeatureFlagAction checks set_feature_flag actions have a flag, a
+// value and a provider to set them with
+func (c *Config) validateFeatureFlagAction(action Action) error {
+	if action.Type != "set_feature_flag" {
+		return nil
+	}
+	if c.Settings.FeatureFlags == nil {
+		return fmt.Errorf("set_feature_flag requires settings.feature_flags")
+	}
+	_, _, err := action.FeatureFlag()
+	return err
+}
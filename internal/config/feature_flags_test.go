@@ -0,0 +1,59 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestFeatureFlagSettings_Validate tests the fields each provider needs
+func TestFeatureFlagSettings_Validate(t *testing.T) {
+	assert.NoError(t, (*FeatureFlagSettings)(nil).Validate())
+	assert.NoError(t, (&FeatureFlagSettings{Provider: "launchdarkly", Token: "t", Project: "web", Environment: "staging"}).Validate())
+	assert.NoError(t, (&FeatureFlagSettings{Provider: "unleash", URL: "https://unleash.example.com", Token: "t", Environment: "staging"}).Validate())
+
+	assert.ErrorContains(t, (&FeatureFlagSettings{Provider: "flagsmith"}).Validate(), "provider must be")
+	assert.EqualError(t, (&FeatureFlagSettings{Provider: "configcat", Token: "t", Environment: "e"}).Validate(), "configcat needs project")
+	assert.EqualError(t, (&FeatureFlagSettings{Provider: "unleash", Token: "t", Environment: "e"}).Validate(), "unleash needs url")
+	assert.EqualError(t, (&FeatureFlagSettings{Provider: "configcat", Project: "c", Environment: "e"}).Validate(), "configcat needs token")
+	assert.EqualError(t, (&FeatureFlagSettings{Provider: "configcat", Project: "c", Token: "t"}).Validate(), "configcat needs environment")
+}
+
+// TestAction_FeatureFlag tests reading the flag and a typed value
+func TestAction_FeatureFlag(t *testing.T) {
+	key, value, err := (&Action{Target: "new-checkout", Value: "true"}).FeatureFlag()
+	require.NoError(t, err)
+	assert.Equal(t, "new-checkout", key)
+	assert.Equal(t, true, value)
+
+	_, value, err = (&Action{Target: "retries", Value: "3"}).FeatureFlag()
+	require.NoError(t, err)
+	assert.Equal(t, 3, value)
+
+	key, value, err = (&Action{Parameters: map[string]interface{}{"flag": "theme", "value": "dark"}}).FeatureFlag()
+	require.NoError(t, err)
+	assert.Equal(t, "theme", key)
+	assert.Equal(t, "dark", value)
+
+	_, _, err = (&Action{Value: "true"}).FeatureFlag()
+	assert.ErrorContains(t, err, "needs the flag")
+	_, _, err = (&Action{Target: "theme"}).FeatureFlag()
+	assert.ErrorContains(t, err, "needs a value")
+}
+
+// TestConfig_FeatureFlags tests that overrides and actions need a provider
+func TestConfig_FeatureFlags(t *testing.T) {
+	cfg := &Config{
+		Apps: []AppConfig{{Name: "Shop", Type: "web", URL: "https://example.com", FeatureFlags: map[string]interface{}{"new-checkout": true}}},
+	}
+	assert.EqualError(t, cfg.Validate(), "app Shop: feature_flags requires settings.feature_flags")
+
+	cfg.Settings.FeatureFlags = &FeatureFlagSettings{Provider: "launchdarkly", Token: "t", Project: "web", Environment: "staging"}
+	assert.NoError(t, cfg.Validate())
+
+	cfg.Actions = []Action{{Name: "flip", Type: "set_feature_flag", Target: "new-checkout"}}
+	assert.ErrorContains(t, cfg.Validate(), "action flip: set_feature_flag needs a value")
+	cfg.Settings.FeatureFlags.Provider = "split"
+	assert.ErrorContains(t, cfg.Validate(), "settings.feature_flags: provider must be")
+}
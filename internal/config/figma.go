@@ -0,0 +1,133 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	// DefaultDesignTolerance is the share of a component's pixels that may
+	// differ from its design before it counts as drifted
+	DefaultDesignTolerance = 0.02
+	// DefaultDesignThreshold is the color distance, from 0 to 1, below which
+	// a pixel matches its design
+	DefaultDesignThreshold = 0.1
+)
+
+// FigmaSettings is the Figma file vision_design_compare actions compare
+// components against. Token, a personal access token, is expanded from
+// the environment, e.g. "${FIGMA_TOKEN}". Scale is the scale designs are
+// rendered at, matching the device pixel ratio of screenshots (default 1).
+type FigmaSettings struct {
+	Token     string  `yaml:"token"`
+	FileKey   string  `yaml:"file_key"` // from the file's URL: figma.com/design/<file_key>/...
+	URL       string  `yaml:"url"`      // API, default https://api.figma.com
+	Scale     float64 `yaml:"scale"`
+	Tolerance float64 `yaml:"tolerance"` // default 0.02
+	Threshold float64 `yaml:"threshold"` // default 0.1
+}
+
+// Validate checks the token, scale and bounds
+func (s *FigmaSettings) Validate() error {
+	if s == nil {
+		return nil
+	}
+	if s.Token == "" {
+		return fmt.Errorf("token is required")
+	}
+	if s.Scale != 0 && (s.Scale < 0.01 || s.Scale > 4) {
+		return fmt.Errorf("scale must be between 0.01 and 4, got %v", s.Scale)
+	}
+	return validateDesignBounds(s.Tolerance, s.Threshold)
+}
+
+// ScaleOrDefault returns the scale, or 1
+func (s *FigmaSettings) ScaleOrDefault() float64 {
+	if s != nil && s.Scale > 0 {
+		return s.Scale
+	}
+	return 1
+}
+
+func validateDesignBounds(tolerance, threshold float64) error {
+	if tolerance < 0 || tolerance > 1 {
+		return fmt.Errorf("tolerance must be between 0 and 1, got %v", tolerance)
+	}
+	if threshold < 0 || threshold > 1 {
+		return fmt.Errorf("threshold must be between 0 and 1, got %v", threshold)
+	}
+	return nil
+}
+
+// DesignCompare is a vision_design_compare action's parameters: the Figma
+// node of the component the element of the action's selector implements,
+// in the file of settings.figma unless File is set, the component's name in
+// reports, and the tolerance and threshold overriding settings.figma's.
+// Fail turns drift into an action failure; otherwise it is reported as a
+// finding only.
+type DesignCompare struct {
+	Node      string  `yaml:"node"` // node ID, as in the node-id of a Figma link, 12:34 or 12-34
+	File      string  `yaml:"file"`
+	Component string  `yaml:"component"`
+	Tolerance float64 `yaml:"tolerance"`
+	Threshold float64 `yaml:"threshold"`
+	Fail      bool    `yaml:"fail"`
+}
+
+// DesignCompare reads a vision_design_compare action's parameters, with the
+// defaults of settings
+func (a *Action) DesignCompare(settings *FigmaSettings) (*DesignCompare, error) {
+	compare := &DesignCompare{}
+	data, err := yaml.Marshal(a.Parameters)
+	if err != nil {
+		return nil, err
+	}
+	if err := yaml.Unmarshal(data, compare); err != nil {
+		return nil, fmt.Errorf("invalid vision_design_compare parameters: %w", err)
+	}
+	if settings == nil {
+		return nil, fmt.Errorf("vision_design_compare needs settings.figma")
+	}
+	if a.Selector == "" {
+		return nil, fmt.Errorf("vision_design_compare needs the selector of the component's element")
+	}
+	if compare.Node == "" {
+		return nil, fmt.Errorf("vision_design_compare needs parameters.node, the component's Figma node ID")
+	}
+	compare.Node = figmaNodeID(compare.Node)
+	if compare.File == "" {
+		compare.File = settings.FileKey
+	}
+	if compare.File == "" {
+		return nil, fmt.Errorf("vision_design_compare needs parameters.file or settings.figma.file_key")
+	}
+	if compare.Component == "" {
+		compare.Component = a.Name
+	}
+	if err := validateDesignBounds(compare.Tolerance, compare.Threshold); err != nil {
+		return nil, err
+	}
+	if compare.Tolerance == 0 {
+		compare.Tolerance = settings.Tolerance
+	}
+	if compare.Tolerance == 0 {
+		compare.Tolerance = DefaultDesignTolerance
+	}
+	if compare.Threshold == 0 {
+		compare.Threshold = settings.Threshold
+	}
+	if compare.Threshold == 0 {
+		compare.Threshold = DefaultDesignThreshold
+	}
+	return compare, nil
+}
+
+// figmaNodeID turns the node-id of a Figma link, 12-34, into the API's 12:34
+func figmaNodeID(id string) string {
+	if strings.Contains(id, ":") {
+		return id
+	}
+	return strings.Replace(id, "-", ":", 1)
+}
@@ -0,0 +1,52 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFigmaSettings_Validate(t *testing.T) {
+	var unset *FigmaSettings
+	assert.NoError(t, unset.Validate())
+	assert.Equal(t, 1.0, unset.ScaleOrDefault())
+	assert.NoError(t, (&FigmaSettings{Token: "${FIGMA_TOKEN}", Scale: 2}).Validate())
+	assert.Equal(t, 2.0, (&FigmaSettings{Scale: 2}).ScaleOrDefault())
+	assert.EqualError(t, (&FigmaSettings{}).Validate(), "token is required")
+	assert.EqualError(t, (&FigmaSettings{Token: "t", Scale: 8}).Validate(), "scale must be between 0.01 and 4, got 8")
+	assert.EqualError(t, (&FigmaSettings{Token: "t", Tolerance: 5}).Validate(), "tolerance must be between 0 and 1, got 5")
+
+	cfg := &Config{Apps: []AppConfig{{Name: "Shop", Type: "web", URL: "https://shop.example.com"}}, Settings: Settings{Figma: &FigmaSettings{}}}
+	assert.EqualError(t, cfg.Validate(), "settings.figma: token is required")
+}
+
+func TestAction_DesignCompare(t *testing.T) {
+	settings := &FigmaSettings{Token: "t", FileKey: "FiLeKeY", Tolerance: 0.05}
+	action := Action{Name: "buy_button", Type: "vision_design_compare", Selector: "#buy", Parameters: map[string]interface{}{"node": "12-34"}}
+	compare, err := action.DesignCompare(settings)
+	require.NoError(t, err)
+	assert.Equal(t, &DesignCompare{Node: "12:34", File: "FiLeKeY", Component: "buy_button", Tolerance: 0.05, Threshold: DefaultDesignThreshold}, compare)
+
+	action.Parameters = map[string]interface{}{"node": "1:2", "file": "Other", "component": "Button/Primary", "threshold": 0.2, "fail": true}
+	compare, err = action.DesignCompare(&FigmaSettings{Token: "t"})
+	require.NoError(t, err)
+	assert.Equal(t, &DesignCompare{Node: "1:2", File: "Other", Component: "Button/Primary", Tolerance: DefaultDesignTolerance, Threshold: 0.2, Fail: true}, compare)
+
+	cases := []struct {
+		action   Action
+		settings *FigmaSettings
+		want     string
+	}{
+		{Action{Selector: "#buy"}, nil, "vision_design_compare needs settings.figma"},
+		{Action{}, settings, "vision_design_compare needs the selector"},
+		{Action{Selector: "#buy"}, settings, "vision_design_compare needs parameters.node"},
+		{Action{Selector: "#buy", Parameters: map[string]interface{}{"node": "1:2"}}, &FigmaSettings{Token: "t"}, "needs parameters.file or settings.figma.file_key"},
+		{Action{Selector: "#buy", Parameters: map[string]interface{}{"node": "1:2", "threshold": 2}}, settings, "threshold must be between 0 and 1"},
+	}
+	for _, c := range cases {
+		c.action.Name, c.action.Type = "buy_button", "vision_design_compare"
+		cfg := &Config{Apps: []AppConfig{{Name: "Shop", Type: "web", URL: "https://shop.example.com", Actions: []Action{c.action}}}, Settings: Settings{Figma: c.settings}}
+		assert.ErrorContains(t, cfg.Validate(), c.want)
+	}
+}
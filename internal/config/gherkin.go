@@ -0,0 +1,337 @@
+package config
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"panoptic/internal/gherkin"
+
+	"gopkg.in/yaml.v3"
+)
+
+// GherkinConfig writes the checks of an app as Gherkin .feature files: each
+// scenario becomes an app, whose actions are those of the step definitions
+// its steps match
+type GherkinConfig struct {
+	Features []string         `yaml:"features"` // files or globs, relative to the configuration
+	App      string           `yaml:"app"`      // app the scenarios run against; the first app by default
+	Steps    []StepDefinition `yaml:"steps"`
+}
+
+// StepDefinition maps the Gherkin steps its expression matches to actions.
+// The expression is a Cucumber expression, e.g. `I add {string} to the
+// cart`, or a regular expression between ^ and $. {{step.N}} in the actions
+// is replaced by the Nth value the step matched, {{step.doc}} by its doc
+// string and {{step.<name>}} by the second cell of the row of its data
+// table whose first cell is name.
+type StepDefinition struct {
+	Step    string    `yaml:"step"`
+	Actions yaml.Node `yaml:"actions"` // decoded once the placeholders are replaced
+}
+
+// Scenario is the Gherkin scenario an app was expanded from
+type Scenario struct {
+	Feature string
+	Name    string
+	File    string
+	Line    int
+	Steps   []ScenarioStep
+}
+
+// ScenarioStep is a step of a scenario and the names of the actions it
+// runs, in order
+type ScenarioStep struct {
+	Keyword string
+	Text    string
+	Line    int
+	Actions []string
+}
+
+// stepPlaceholder matches {{step.<value>}}
+var stepPlaceholder = regexp.MustCompile(`{{\s*step\.([A-Za-z0-9_ -]+?)\s*}}`)
+
+// Cucumber expression parameter types and the text they match
+var cucumberParameters = map[string]string{
+	"string": `("[^"]*"|'[^']*')`,
+	"int":    `(-?\d+)`,
+	"float":  `(-?\d*\.?\d+)`,
+	"word":   `(\S+)`,
+	"":       `(.*)`,
+}
+
+var cucumberToken = regexp.MustCompile(`\{([a-z]*)\}|\(([^()]*)\)`)
+
+// stepMatcher is a compiled step definition
+type stepMatcher struct {
+	definition *StepDefinition
+	re         *regexp.Regexp
+	unquote    []bool // values of {string} parameters lose their quotes
+}
+
+// compileStep compiles the expression of a step definition
+func compileStep(def *StepDefinition) (*stepMatcher, error) {
+	m := &stepMatcher{definition: def}
+	if strings.HasPrefix(def.Step, "^") && strings.HasSuffix(def.Step, "$") {
+		re, err := regexp.Compile(def.Step)
+		if err != nil {
+			return nil, fmt.Errorf("step %q: %w", def.Step, err)
+		}
+		m.re = re
+		m.unquote = make([]bool, re.NumSubexp())
+		return m, nil
+	}
+
+	var b strings.Builder
+	b.WriteString("^")
+	last := 0
+	for _, loc := range cucumberToken.FindAllStringSubmatchIndex(def.Step, -1) {
+		b.WriteString(regexp.QuoteMeta(def.Step[last:loc[0]]))
+		last = loc[1]
+		if loc[2] >= 0 {
+			name := def.Step[loc[2]:loc[3]]
+			pattern, ok := cucumberParameters[name]
+			if !ok {
+				return nil, fmt.Errorf("step %q: unknown parameter type {%s}; use {string}, {int}, {float}, {word} or {}", def.Step, name)
+			}
+			b.WriteString(pattern)
+			m.unquote = append(m.unquote, name == "string")
+		} else {
+			// Optional text, e.g. item(s)
+			b.WriteString("(?:" + regexp.QuoteMeta(def.Step[loc[4]:loc[5]]) + ")?")
+		}
+	}
+	b.WriteString(regexp.QuoteMeta(def.Step[last:]) + "$")
+	re, err := regexp.Compile(b.String())
+	if err != nil {
+		return nil, fmt.Errorf("step %q: %w", def.Step, err)
+	}
+	m.re = re
+	return m, nil
+}
+
+// match returns the values the step matched, or false
+func (m *stepMatcher) match(text string) ([]string, bool) {
+	sub := m.re.FindStringSubmatch(text)
+	if sub == nil {
+		return nil, false
+	}
+	values := sub[1:]
+	for i := range values {
+		if m.unquote[i] && len(values[i]) >= 2 {
+			values[i] = values[i][1 : len(values[i])-1]
+		}
+	}
+	return values, true
+}
+
+// ExpandFeatures replaces the app of the gherkin section with one app per
+// scenario of its features, read relative to dir. A scenario app is named
+// "<feature>: <scenario>", has the tags of the app, the feature and the
+// scenario, and runs the actions of the step definitions its steps match,
+// each named after its step.
+func (c *Config) ExpandFeatures(dir string) error {
+	g := c.Gherkin
+	if g == nil {
+		return nil
+	}
+	if len(g.Features) == 0 {
+		return fmt.Errorf("gherkin.features must name at least one .feature file")
+	}
+	if len(c.Apps) == 0 {
+		return fmt.Errorf("gherkin needs an app to run the scenarios against")
+	}
+	template := -1
+	for i, app := range c.Apps {
+		if g.App == "" || app.Name == g.App {
+			template = i
+			break
+		}
+	}
+	if template < 0 {
+		return fmt.Errorf("gherkin.app %s is not an app", g.App)
+	}
+
+	matchers := make([]*stepMatcher, len(g.Steps))
+	for i := range g.Steps {
+		m, err := compileStep(&g.Steps[i])
+		if err != nil {
+			return fmt.Errorf("gherkin.steps: %w", err)
+		}
+		if actions := m.definition.Actions; actions.Kind != yaml.SequenceNode || len(actions.Content) == 0 {
+			return fmt.Errorf("gherkin.steps: step %q has no actions", m.definition.Step)
+		}
+		matchers[i] = m
+	}
+
+	var files []string
+	seen := make(map[string]bool)
+	for _, pattern := range g.Features {
+		if !filepath.IsAbs(pattern) {
+			pattern = filepath.Join(dir, pattern)
+		}
+		matches, err := filepath.Glob(pattern)
+		if err != nil {
+			return fmt.Errorf("gherkin.features: %w", err)
+		}
+		if len(matches) == 0 {
+			return fmt.Errorf("gherkin.features: no file matches %s", pattern)
+		}
+		for _, file := range matches {
+			if !seen[file] {
+				seen[file] = true
+				files = append(files, file)
+			}
+		}
+	}
+
+	base := c.Apps[template]
+	var scenarios []AppConfig
+	names := make(map[string]bool)
+	for _, file := range files {
+		feature, err := gherkin.ParseFile(file)
+		if err != nil {
+			return err
+		}
+		for _, scenario := range feature.Scenarios {
+			app, err := scenarioApp(base, feature, scenario, matchers)
+			if err != nil {
+				return err
+			}
+			name := app.Name
+			for n := 2; names[app.Name]; n++ {
+				app.Name = fmt.Sprintf("%s (%d)", name, n)
+			}
+			names[app.Name] = true
+			scenarios = append(scenarios, app)
+		}
+	}
+	if len(scenarios) == 0 {
+		return fmt.Errorf("gherkin.features have no scenarios")
+	}
+
+	apps := make([]AppConfig, 0, len(c.Apps)-1+len(scenarios))
+	apps = append(apps, c.Apps[:template]...)
+	apps = append(apps, scenarios...)
+	c.Apps = append(apps, c.Apps[template+1:]...)
+	return nil
+}
+
+// scenarioApp builds the app of a scenario from the template app
+func scenarioApp(base AppConfig, feature *gherkin.Feature, scenario gherkin.Scenario, matchers []*stepMatcher) (AppConfig, error) {
+	app := base
+	app.Name = feature.Name + ": " + scenario.Name
+	app.Tags = MergeTags(base.Tags, scenario.Tags)
+	app.Actions = nil
+	info := &Scenario{Feature: feature.Name, Name: scenario.Name, File: feature.File, Line: scenario.Line}
+
+	used := make(map[string]bool)
+	for _, step := range scenario.Steps {
+		where := fmt.Sprintf("%s:%d", feature.File, step.Line)
+		var found *stepMatcher
+		var values []string
+		for _, m := range matchers {
+			v, ok := m.match(step.Text)
+			if !ok {
+				continue
+			}
+			if found != nil {
+				return AppConfig{}, fmt.Errorf("%s: step %q matches both %q and %q", where, step.Text, found.definition.Step, m.definition.Step)
+			}
+			found, values = m, v
+		}
+		if found == nil {
+			return AppConfig{}, fmt.Errorf("%s: no step definition matches %q", where, step.Text)
+		}
+		actions, err := stepActions(&found.definition.Actions, step, values)
+		if err != nil {
+			return AppConfig{}, fmt.Errorf("%s: %w", where, err)
+		}
+
+		label := step.Keyword + " " + step.Text
+		planned := ScenarioStep{Keyword: step.Keyword, Text: step.Text, Line: step.Line}
+		for i := range actions {
+			name := label
+			if len(actions) > 1 {
+				detail := actions[i].Name
+				if detail == "" {
+					detail = actions[i].Type
+				}
+				name = fmt.Sprintf("%s (%s)", label, detail)
+			}
+			unique := name
+			for n := 2; used[unique]; n++ {
+				unique = fmt.Sprintf("%s #%d", name, n)
+			}
+			used[unique] = true
+			actions[i].Name = unique
+			planned.Actions = append(planned.Actions, unique)
+		}
+		app.Actions = append(app.Actions, actions...)
+		info.Steps = append(info.Steps, planned)
+	}
+	app.Scenario = info
+	return app, nil
+}
+
+// stepActions copies the actions of a step definition with the {{step.*}}
+// placeholders replaced by what the step matched
+func stepActions(actions *yaml.Node, step gherkin.Step, values []string) ([]Action, error) {
+	var missing string
+	var replace func(n *yaml.Node) *yaml.Node
+	replace = func(n *yaml.Node) *yaml.Node {
+		copied := *n
+		if n.Kind == yaml.ScalarNode && strings.Contains(n.Value, "step.") {
+			copied.Value = stepPlaceholder.ReplaceAllStringFunc(n.Value, func(m string) string {
+				key := stepPlaceholder.FindStringSubmatch(m)[1]
+				if value, ok := stepValue(step, values, key); ok {
+					return value
+				}
+				if missing == "" {
+					missing = key
+				}
+				return m
+			})
+			// A placeholder standing alone takes the type of its value, so
+			// {int} parameters fill numeric fields
+			if stepPlaceholder.FindString(n.Value) == n.Value {
+				copied.Tag, copied.Style = "", 0
+			}
+		}
+		copied.Content = make([]*yaml.Node, len(n.Content))
+		for i, child := range n.Content {
+			copied.Content[i] = replace(child)
+		}
+		return &copied
+	}
+	replaced := replace(actions)
+	if missing != "" {
+		return nil, fmt.Errorf("step %q has no value for {{step.%s}}", step.Text, missing)
+	}
+	var expanded []Action
+	if err := replaced.Decode(&expanded); err != nil {
+		return nil, err
+	}
+	return expanded, nil
+}
+
+// stepValue looks up a {{step.*}} placeholder
+func stepValue(step gherkin.Step, values []string, key string) (string, bool) {
+	if key == "doc" {
+		return step.DocString, step.DocString != ""
+	}
+	if n, err := strconv.Atoi(key); err == nil {
+		if n >= 1 && n <= len(values) {
+			return values[n-1], true
+		}
+		return "", false
+	}
+	for _, row := range step.Table {
+		if len(row) == 2 && row[0] == key {
+			return row[1], true
+		}
+	}
+	return "", false
+}
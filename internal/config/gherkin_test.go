@@ -0,0 +1,162 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const gherkinConfig = `
+name: shop
+apps:
+  - name: Shop
+    type: web
+    url: https://shop.test
+    timeout: 60
+    tags: [web]
+    matrix:
+      locale: [en, de]
+  - name: Admin
+    type: web
+    url: https://admin.test
+gherkin:
+  features: ["features/*.feature"]
+  app: Shop
+  steps:
+    - step: I open the shop
+      actions:
+        - type: navigate
+          url: "https://shop.test/{{matrix.locale}}/"
+    - step: I add {string} to the cart
+      actions:
+        - name: search
+          type: fill
+          selector: "#q"
+          value: "{{step.1}}"
+        - type: click
+          selector: "button.add"
+    - step: I wait {int} second(s)
+      actions:
+        - type: wait
+          wait_time: "{{step.1}}"
+    - step: ^I pay with (card|cash)$
+      actions:
+        - type: fill
+          selector: "#card"
+          value: "{{step.number}} {{step.1}}"
+`
+
+const cartFeature = `@shop
+Feature: Cart
+  Background:
+    Given I open the shop
+
+  @smoke
+  Scenario: Add socks
+    When I add "socks" to the cart
+    And I add 'hats' to the cart
+    And I wait 2 seconds
+    Then I pay with card
+      | number | 4242 |
+`
+
+func writeGherkin(t *testing.T, config, feature string) string {
+	dir := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "features"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "features", "cart.feature"), []byte(feature), 0600))
+	path := filepath.Join(dir, "shop.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(config), 0600))
+	return path
+}
+
+// TestLoad_Gherkin tests scenario apps, step matching, action naming and
+// placeholders, with the app matrix expanded after the scenarios
+func TestLoad_Gherkin(t *testing.T) {
+	cfg, err := Load(writeGherkin(t, gherkinConfig, cartFeature))
+	require.NoError(t, err)
+	require.NoError(t, cfg.Validate())
+	require.Len(t, cfg.Apps, 3)
+	assert.Equal(t, "Cart: Add socks (locale=en)", cfg.Apps[0].Name)
+	assert.Equal(t, "Cart: Add socks (locale=de)", cfg.Apps[1].Name)
+	assert.Equal(t, "Admin", cfg.Apps[2].Name)
+
+	app := cfg.Apps[1]
+	assert.Equal(t, []string{"web", "shop", "smoke"}, app.Tags)
+	assert.Equal(t, 60, app.Timeout)
+	var names []string
+	for _, a := range app.Actions {
+		names = append(names, a.Name)
+	}
+	assert.Equal(t, []string{
+		"Given I open the shop",
+		`When I add "socks" to the cart (search)`, `When I add "socks" to the cart (click)`,
+		"And I add 'hats' to the cart (search)", "And I add 'hats' to the cart (click)",
+		"And I wait 2 seconds",
+		"Then I pay with card",
+	}, names)
+	assert.Equal(t, "https://shop.test/de/", app.Actions[0].URL)
+	assert.Equal(t, "socks", app.Actions[1].Value)
+	assert.Equal(t, "hats", app.Actions[3].Value)
+	assert.Equal(t, 2, app.Actions[5].WaitTime)
+	assert.Equal(t, "4242 card", app.Actions[6].Value)
+
+	require.NotNil(t, app.Scenario)
+	assert.Equal(t, "Cart", app.Scenario.Feature)
+	assert.Equal(t, "Add socks", app.Scenario.Name)
+	assert.Equal(t, 7, app.Scenario.Line)
+	assert.Equal(t, "cart.feature", filepath.Base(app.Scenario.File))
+	require.Len(t, app.Scenario.Steps, 5)
+	assert.Equal(t, ScenarioStep{Keyword: "When", Text: `I add "socks" to the cart`, Line: 8,
+		Actions: []string{`When I add "socks" to the cart (search)`, `When I add "socks" to the cart (click)`}}, app.Scenario.Steps[1])
+}
+
+// TestExpandFeatures_Names tests numbering of repeated actions and apps
+func TestExpandFeatures_Names(t *testing.T) {
+	path := writeGherkin(t, gherkinConfig, `Feature: Cart
+  Scenario: Twice
+    Given I open the shop
+    And I open the shop
+  Scenario: Twice
+    Given I open the shop
+`)
+	cfg, err := Load(path)
+	require.NoError(t, err)
+	assert.Equal(t, "Cart: Twice (locale=en)", cfg.Apps[0].Name)
+	assert.Equal(t, "Cart: Twice (2) (locale=en)", cfg.Apps[2].Name)
+	assert.Equal(t, "And I open the shop", cfg.Apps[0].Actions[1].Name)
+
+	path = writeGherkin(t, gherkinConfig, `Feature: Cart
+  Scenario: Again
+    Given I open the shop
+    Given I open the shop
+`)
+	cfg, err = Load(path)
+	require.NoError(t, err)
+	assert.Equal(t, "Given I open the shop #2", cfg.Apps[0].Actions[1].Name)
+}
+
+func TestExpandFeatures_Errors(t *testing.T) {
+	tests := []struct {
+		name, config, feature, err string
+	}{
+		{"undefined step", gherkinConfig, "Feature: a\n  Scenario: b\n    Given I fly\n", `cart.feature:3: no step definition matches "I fly"`},
+		{"ambiguous step", gherkinConfig + "    - step: I open the {word}\n      actions: [{type: wait}]\n", "Feature: a\n  Scenario: b\n    Given I open the shop\n", `matches both "I open the shop" and "I open the {word}"`},
+		{"missing value", gherkinConfig, "Feature: a\n  Scenario: b\n    Given I pay with cash\n", `cart.feature:3: step "I pay with cash" has no value for {{step.number}}`},
+		{"parse error", gherkinConfig, "Feature: a\n  Given x\n", "cart.feature:2: step outside a scenario or background"},
+		{"unknown app", "apps: [{name: Shop, type: web, url: https://shop.test}]\ngherkin:\n  features: [features/*.feature]\n  app: Nope\n", "Feature: a\n", "gherkin.app Nope is not an app"},
+		{"no features", "apps: [{name: Shop, type: web, url: https://shop.test}]\ngherkin:\n  features: [missing/*.feature]\n", "Feature: a\n", "gherkin.features: no file matches"},
+		{"no scenarios", "apps: [{name: Shop, type: web, url: https://shop.test}]\ngherkin:\n  features: [features/*.feature]\n", "Feature: a\n", "gherkin.features have no scenarios"},
+		{"unknown parameter", "apps: [{name: Shop, type: web, url: https://shop.test}]\ngherkin:\n  features: [features/*.feature]\n  steps: [{step: 'I pay {money}', actions: [{type: wait}]}]\n", "Feature: a\n", "unknown parameter type {money}"},
+		{"no actions", "apps: [{name: Shop, type: web, url: https://shop.test}]\ngherkin:\n  features: [features/*.feature]\n  steps: [{step: 'I pay'}]\n", "Feature: a\n", `step "I pay" has no actions`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Load(writeGherkin(t, tt.config, tt.feature))
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.err)
+		})
+	}
+}
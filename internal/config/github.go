@@ -0,0 +1,41 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+)
+
+// GitHubSettings reports each run to GitHub as a check run, or as a commit
+// status for tokens that can't create check runs. Unset fields fall back to
+// the GITHUB_* variables of GitHub Actions; Token is expanded from the
+// environment and defaults to "${GITHUB_TOKEN}".
+type GitHubSettings struct {
+	Enabled     bool   `yaml:"enabled"`
+	Repository  string `yaml:"repository"`   // owner/name
+	SHA         string `yaml:"sha"`          // commit to report on; defaults to the pull request head
+	PullRequest int    `yaml:"pull_request"` // resolves SHA when it is unset
+	Token       string `yaml:"token"`
+	APIURL      string `yaml:"api_url"`    // default https://api.github.com
+	Mode        string `yaml:"mode"`       // check (default) or status
+	Name        string `yaml:"name"`       // check run name or status context (default Panoptic)
+	ReportURL   string `yaml:"report_url"` // where the uploaded report is served; {run_id} is replaced
+}
+
+// Validate checks the reporting mode and repository format
+func (g *GitHubSettings) Validate() error {
+	if g == nil {
+		return nil
+	}
+	switch g.Mode {
+	case "", "check", "status":
+	default:
+		return fmt.Errorf("settings.github.mode must be check or status, got %q", g.Mode)
+	}
+	if g.Repository != "" && strings.Count(g.Repository, "/") != 1 {
+		return fmt.Errorf("settings.github.repository must be owner/name, got %q", g.Repository)
+	}
+	if g.PullRequest < 0 {
+		return fmt.Errorf("settings.github.pull_request must be positive")
+	}
+	return nil
+}
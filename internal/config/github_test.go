@@ -0,0 +1,25 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestGitHubSettings_Validate tests mode, repository and pull request checks
+func TestGitHubSettings_Validate(t *testing.T) {
+	var unset *GitHubSettings
+	assert.NoError(t, unset.Validate())
+	assert.NoError(t, (&GitHubSettings{}).Validate())
+	assert.NoError(t, (&GitHubSettings{Mode: "status", Repository: "acme/shop", PullRequest: 3}).Validate())
+
+	assert.ErrorContains(t, (&GitHubSettings{Mode: "comment"}).Validate(), "check or status")
+	assert.ErrorContains(t, (&GitHubSettings{Repository: "shop"}).Validate(), "owner/name")
+	assert.ErrorContains(t, (&GitHubSettings{PullRequest: -1}).Validate(), "positive")
+
+	cfg := &Config{
+		Apps:     []AppConfig{{Name: "Shop", Type: "web", URL: "https://example.com"}},
+		Settings: Settings{GitHub: &GitHubSettings{Mode: "comment"}},
+	}
+	assert.ErrorContains(t, cfg.Validate(), "settings.github.mode")
+}
@@ -0,0 +1,142 @@
+package config
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+
+	"panoptic/internal/graphql"
+	"panoptic/internal/jsonpath"
+
+	"gopkg.in/yaml.v3"
+)
+
+// GraphQLRequest is a graphql action's parameters. The query is POSTed as
+// JSON with its variables, by Panoptic rather than the app, like
+// http_request; header values are expanded from the environment. The action
+// fails on an HTTP error or a response with errors, unless expect_errors
+// asks for them. Validating against the schema introspects the endpoint, or
+// reads schema, a saved introspection result, and checks the fields the
+// query selects exist and the data returned has their types.
+type GraphQLRequest struct {
+	URL            string                 `yaml:"url"` // the action's url when empty
+	Query          string                 `yaml:"query"`
+	OperationName  string                 `yaml:"operation_name"` // which of the query's operations runs
+	Variables      map[string]interface{} `yaml:"variables"`
+	Headers        map[string]string      `yaml:"headers"`
+	ExpectErrors   bool                   `yaml:"expect_errors"`
+	ValidateSchema bool                   `yaml:"validate_schema"`
+	Schema         string                 `yaml:"schema"` // implies validate_schema
+	Assert         []GraphQLAssertion     `yaml:"assert"`
+	Extract        map[string]string      `yaml:"extract"` // variable names to JSON paths in the response
+	Timeout        time.Duration          `yaml:"timeout"`
+
+	Document *graphql.Document         `yaml:"-"`
+	Extracts map[string]*jsonpath.Path `yaml:"-"`
+}
+
+// GraphQLAssertion checks a value of a graphql action's response, at a JSON
+// path such as $.data.order.status: it's there, equals a value or matches a
+// regular expression
+type GraphQLAssertion struct {
+	Path    string      `yaml:"path"`
+	Equals  interface{} `yaml:"equals"`
+	Matches string      `yaml:"matches"`
+	Exists  *bool       `yaml:"exists"` // false checks nothing is there
+
+	JSONPath *jsonpath.Path `yaml:"-"`
+}
+
+// ExpectsValue tells whether the path must name a value
+func (a *GraphQLAssertion) ExpectsValue() bool {
+	return a.Exists == nil || *a.Exists
+}
+
+// Validates tells whether the response is checked against the schema
+func (r *GraphQLRequest) Validates() bool {
+	return r.ValidateSchema || r.Schema != ""
+}
+
+// GraphQLRequest reads a graphql action's parameters
+func (a *Action) GraphQLRequest() (*GraphQLRequest, error) {
+	req := &GraphQLRequest{}
+	data, err := yaml.Marshal(a.Parameters)
+	if err != nil {
+		return nil, err
+	}
+	if err := yaml.Unmarshal(data, req); err != nil {
+		return nil, fmt.Errorf("invalid graphql parameters: %w", err)
+	}
+	if req.URL == "" {
+		req.URL = a.URL
+	}
+	if req.URL == "" {
+		return nil, fmt.Errorf("graphql needs a url or parameters.url")
+	}
+	// URLs and queries built from variables are checked once they're filled in
+	if !strings.Contains(req.URL, "{{") {
+		u, err := url.Parse(req.URL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return nil, fmt.Errorf("url must be an http(s) URL, got %q", req.URL)
+		}
+	}
+	if strings.TrimSpace(req.Query) == "" {
+		return nil, fmt.Errorf("graphql needs parameters.query")
+	}
+	if !strings.Contains(req.Query, "{{") {
+		if req.Document, err = graphql.ParseQuery(req.Query); err != nil {
+			return nil, fmt.Errorf("invalid query: %w", err)
+		}
+		if _, err := req.Document.Operation(req.OperationName); err != nil {
+			return nil, fmt.Errorf("invalid query: %w", err)
+		}
+	}
+
+	for i := range req.Assert {
+		check := &req.Assert[i]
+		if check.Path == "" {
+			return nil, fmt.Errorf("assert %d needs a path", i+1)
+		}
+		if check.JSONPath, err = jsonpath.Parse(check.Path); err != nil {
+			return nil, fmt.Errorf("assert %d: %w", i+1, err)
+		}
+		if _, err := regexp.Compile(check.Matches); err != nil {
+			return nil, fmt.Errorf("assert %d matches: %w", i+1, err)
+		}
+		if !check.ExpectsValue() && (check.Equals != nil || check.Matches != "") {
+			return nil, fmt.Errorf("assert %d can't check the value of a path that mustn't exist", i+1)
+		}
+	}
+	for name, path := range req.Extract {
+		if !variableName.MatchString(name) {
+			return nil, fmt.Errorf("invalid variable name %q", name)
+		}
+		p, err := jsonpath.Parse(path)
+		if err != nil {
+			return nil, fmt.Errorf("extract %s: %w", name, err)
+		}
+		if req.Extracts == nil {
+			req.Extracts = make(map[string]*jsonpath.Path)
+		}
+		req.Extracts[name] = p
+	}
+
+	if req.Timeout < 0 {
+		return nil, fmt.Errorf("timeout must not be negative")
+	}
+	if req.Timeout == 0 {
+		req.Timeout = DefaultHTTPRequestTimeout
+	}
+	return req, nil
+}
+
+// validateGraphQLAction checks the parameters of graphql actions
+func (c *Config) validateGraphQLAction(action Action) error {
+	if action.Type != "graphql" {
+		return nil
+	}
+	_, err := action.GraphQLRequest()
+	return err
+}
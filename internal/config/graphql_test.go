@@ -0,0 +1,62 @@
+package config
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAction_GraphQLRequest(t *testing.T) {
+	req, err := (&Action{Type: "graphql", URL: "https://api.example.com/graphql", Parameters: map[string]interface{}{
+		"query":          "query A { me { id } } query B { me { name } }",
+		"operation_name": "B",
+		"variables":      map[string]interface{}{"first": 10},
+		"schema":         "schema.json",
+		"assert":         []interface{}{map[string]interface{}{"path": "$.data.me.name", "matches": "^A"}},
+		"extract":        map[string]interface{}{"user_name": "$.data.me.name"},
+		"timeout":        "5s",
+	}}).GraphQLRequest()
+	require.NoError(t, err)
+	assert.Equal(t, "https://api.example.com/graphql", req.URL)
+	require.Len(t, req.Document.Operations, 2)
+	assert.True(t, req.Validates())
+	assert.True(t, req.Assert[0].ExpectsValue())
+	assert.Equal(t, "$.data.me.name", req.Assert[0].JSONPath.String())
+	assert.Equal(t, "$.data.me.name", req.Extracts["user_name"].String())
+	assert.Equal(t, 5*time.Second, req.Timeout)
+
+	req, err = (&Action{Parameters: map[string]interface{}{"url": "{{var.api}}", "query": `{ order(id: "{{var.order_id}}") { id } }`}}).GraphQLRequest()
+	require.NoError(t, err)
+	assert.Equal(t, DefaultHTTPRequestTimeout, req.Timeout)
+	assert.False(t, req.Validates())
+
+	for want, params := range map[string]map[string]interface{}{
+		"needs a url":                   {"query": "{ me { id } }"},
+		"needs parameters.query":        {"url": "https://a.example.com"},
+		"must be an http(s) URL":        {"url": "a.example.com", "query": "{ me { id } }"},
+		"invalid query: unclosed {":     {"url": "https://a.example.com", "query": "{ me { id }"},
+		"name the one to run":           {"url": "https://a.example.com", "query": "query A { a } query B { b }"},
+		"assert 1 needs a path":         {"url": "https://a.example.com", "query": "{ a }", "assert": []interface{}{map[string]interface{}{"equals": 1}}},
+		"assert 1: invalid JSON path":   {"url": "https://a.example.com", "query": "{ a }", "assert": []interface{}{map[string]interface{}{"path": "$.data["}}},
+		"assert 1 matches":              {"url": "https://a.example.com", "query": "{ a }", "assert": []interface{}{map[string]interface{}{"path": "$.a", "matches": "("}}},
+		"mustn't exist":                 {"url": "https://a.example.com", "query": "{ a }", "assert": []interface{}{map[string]interface{}{"path": "$.a", "exists": false, "equals": 1}}},
+		`invalid variable name "a b"`:   {"url": "https://a.example.com", "query": "{ a }", "extract": map[string]interface{}{"a b": "$.data.a"}},
+		"timeout must not be negative":  {"url": "https://a.example.com", "query": "{ a }", "timeout": "-1s"},
+		"invalid graphql parameters":    {"url": "https://a.example.com", "query": "{ a }", "variables": "id=1"},
+		"extract a: invalid JSON path":  {"url": "https://a.example.com", "query": "{ a }", "extract": map[string]interface{}{"a": "$..a"}},
+		"the document has no operation": {"url": "https://a.example.com", "query": "fragment F on Query { a }"},
+	} {
+		_, err := (&Action{Parameters: params}).GraphQLRequest()
+		assert.ErrorContains(t, err, want)
+	}
+}
+
+func TestConfig_Validate_GraphQL(t *testing.T) {
+	cfg := &Config{
+		Apps:    []AppConfig{{Name: "Shop", Type: "web", URL: "https://shop.example.com"}},
+		Actions: []Action{{Name: "orders", Type: "graphql", URL: "https://shop.example.com/graphql", Parameters: map[string]interface{}{"query": "{ orders { id }"}}},
+	}
+	assert.ErrorContains(t, cfg.Validate(), "action orders: invalid query")
+}
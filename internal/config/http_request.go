@@ -0,0 +1,84 @@
+package config
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// DefaultHTTPRequestTimeout bounds http_request actions without a timeout
+const DefaultHTTPRequestTimeout = 30 * time.Second
+
+// HTTPRequest is an http_request action's parameters. The request is sent
+// by Panoptic, not the app, so it carries none of the browser's cookies;
+// header values are expanded from the environment, e.g.
+// "Bearer ${API_TOKEN}". Redirects aren't followed, so ExpectStatus can
+// check them; without it any status below 400 passes.
+type HTTPRequest struct {
+	Method       string            `yaml:"method"` // GET by default, POST with a body
+	URL          string            `yaml:"url"`    // the action's url when empty
+	Headers      map[string]string `yaml:"headers"`
+	Body         string            `yaml:"body"`
+	ExpectStatus int               `yaml:"expect_status"`
+	Timeout      time.Duration     `yaml:"timeout"`
+}
+
+// HTTPRequest reads an http_request action's parameters
+func (a *Action) HTTPRequest() (*HTTPRequest, error) {
+	req := &HTTPRequest{}
+	data, err := yaml.Marshal(a.Parameters)
+	if err != nil {
+		return nil, err
+	}
+	if err := yaml.Unmarshal(data, req); err != nil {
+		return nil, fmt.Errorf("invalid http_request parameters: %w", err)
+	}
+	if req.URL == "" {
+		req.URL = a.URL
+	}
+	if req.URL == "" {
+		return nil, fmt.Errorf("http_request needs a url or parameters.url")
+	}
+	// URLs built from variables are checked once they're filled in
+	if !strings.Contains(req.URL, "{{") {
+		u, err := url.Parse(req.URL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return nil, fmt.Errorf("url must be an http(s) URL, got %q", req.URL)
+		}
+	}
+	req.Method = strings.ToUpper(req.Method)
+	if req.Method == "" {
+		req.Method = http.MethodGet
+		if req.Body != "" {
+			req.Method = http.MethodPost
+		}
+	}
+	switch req.Method {
+	case http.MethodGet, http.MethodHead, http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete, http.MethodOptions:
+	default:
+		return nil, fmt.Errorf("unknown method %q", req.Method)
+	}
+	if req.ExpectStatus != 0 && (req.ExpectStatus < 100 || req.ExpectStatus > 599) {
+		return nil, fmt.Errorf("expect_status must be an HTTP status, got %d", req.ExpectStatus)
+	}
+	if req.Timeout < 0 {
+		return nil, fmt.Errorf("timeout must not be negative")
+	}
+	if req.Timeout == 0 {
+		req.Timeout = DefaultHTTPRequestTimeout
+	}
+	return req, nil
+}
+
+// validateHTTPRequestAction checks the parameters of http_request actions
+func (c *Config) validateHTTPRequestAction(action Action) error {
+	if action.Type != "http_request" {
+		return nil
+	}
+	_, err := action.HTTPRequest()
+	return err
+}
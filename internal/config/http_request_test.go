@@ -0,0 +1,52 @@
+package config
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAction_HTTPRequest(t *testing.T) {
+	req, err := (&Action{Type: "http_request", URL: "https://shop.example.com/health"}).HTTPRequest()
+	require.NoError(t, err)
+	assert.Equal(t, &HTTPRequest{Method: "GET", URL: "https://shop.example.com/health", Timeout: DefaultHTTPRequestTimeout}, req)
+
+	req, err = (&Action{Type: "http_request", URL: "https://ignored.example.com", Parameters: map[string]interface{}{
+		"url":           "https://shop.example.com/cart",
+		"headers":       map[string]interface{}{"Content-Type": "application/x-www-form-urlencoded"},
+		"body":          "sku=42&qty=1",
+		"expect_status": 302,
+		"timeout":       "5s",
+	}}).HTTPRequest()
+	require.NoError(t, err)
+	assert.Equal(t, "POST", req.Method)
+	assert.Equal(t, "https://shop.example.com/cart", req.URL)
+	assert.Equal(t, 302, req.ExpectStatus)
+	assert.Equal(t, 5*time.Second, req.Timeout)
+
+	req, err = (&Action{Parameters: map[string]interface{}{"method": "delete", "url": "{{var.order_url}}"}}).HTTPRequest()
+	require.NoError(t, err)
+	assert.Equal(t, "DELETE", req.Method)
+
+	for params, want := range map[string]map[string]interface{}{
+		"needs a url":                    {},
+		"must be an http(s) URL":         {"url": "shop.example.com/cart"},
+		"unknown method":                 {"url": "https://a.example.com", "method": "FETCH"},
+		"expect_status must be an HTTP":  {"url": "https://a.example.com", "expect_status": 42},
+		"timeout must not be negative":   {"url": "https://a.example.com", "timeout": "-1s"},
+		"invalid http_request parameter": {"url": "https://a.example.com", "headers": "yes"},
+	} {
+		_, err := (&Action{Parameters: want}).HTTPRequest()
+		assert.ErrorContains(t, err, params)
+	}
+}
+
+func TestConfig_Validate_HTTPRequest(t *testing.T) {
+	cfg := &Config{
+		Apps:    []AppConfig{{Name: "Shop", Type: "web", URL: "https://shop.example.com"}},
+		Actions: []Action{{Name: "add to cart", Type: "http_request", Parameters: map[string]interface{}{"method": "FETCH", "url": "https://shop.example.com"}}},
+	}
+	assert.ErrorContains(t, cfg.Validate(), "action add to cart: unknown method")
+}
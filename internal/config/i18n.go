@@ -0,0 +1,175 @@
+package config
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Localization audit defaults
+const (
+	DefaultI18nMinLength = 4
+	DefaultI18nTolerance = 2
+)
+
+// DefaultI18nKeyPatterns match text that is a translation key rather than a
+// translation: dotted keys (checkout.button.pay), unreplaced {{placeholders}}
+// and the markers i18n libraries show for missing translations
+var DefaultI18nKeyPatterns = []string{
+	`^[a-z][a-z0-9_]*(\.[a-z][a-zA-Z0-9_-]*)+$`,
+	`{{\s*[\w.]+\s*}}`,
+	`^(__|\[)?(MISSING|missing)[ _:-]`,
+}
+
+// DefaultI18nActions are the action types after which the page's text is
+// audited
+var DefaultI18nActions = []string{"navigate", "click", "submit", "screenshot"}
+
+// localeTag matches BCP 47 tags such as de, de-DE and zh-Hant-TW
+var localeTag = regexp.MustCompile(`^[A-Za-z]{2,3}([_-][A-Za-z0-9]{2,8})*$`)
+
+// I18nSettings turn a web app into a localization audit: the app runs once
+// per locale, the source locale first, and after its page actions the
+// visible text is checked for raw translation keys, text cut off by its box
+// and, in the other locales, strings left in the source language. Findings
+// fail the action when Fail is set.
+type I18nSettings struct {
+	Locales      []string `yaml:"locales"`
+	SourceLocale string   `yaml:"source_locale,omitempty"` // the locale the others are compared to; the first by default
+	KeyPatterns  []string `yaml:"key_patterns,omitempty"`  // regular expressions of raw keys; DefaultI18nKeyPatterns by default
+	Ignore       []string `yaml:"ignore,omitempty"`        // texts that are the same in every locale, e.g. brand names
+	Exclude      []string `yaml:"exclude,omitempty"`       // selectors of elements left out of the audit
+	Actions      []string `yaml:"actions,omitempty"`       // action types audited; DefaultI18nActions by default
+	MinLength    int      `yaml:"min_length,omitempty"`    // shorter texts are not compared across locales; 4 by default
+	Overflow     *bool    `yaml:"overflow,omitempty"`      // detect text cut off by its box; on by default
+	Tolerance    int      `yaml:"tolerance,omitempty"`     // pixels text may exceed its box by; 2 by default
+	Fail         bool     `yaml:"fail,omitempty"`
+
+	keyPatterns []*regexp.Regexp
+}
+
+// Validate requires locales and valid key patterns
+func (s *I18nSettings) Validate() error {
+	if s == nil {
+		return nil
+	}
+	if len(s.Locales) == 0 {
+		return fmt.Errorf("locales is required")
+	}
+	seen := make(map[string]bool, len(s.Locales))
+	for _, locale := range s.Locales {
+		if !localeTag.MatchString(locale) {
+			return fmt.Errorf("locale %q is not a language tag such as de-DE", locale)
+		}
+		if seen[locale] {
+			return fmt.Errorf("locale %s is listed twice", locale)
+		}
+		seen[locale] = true
+	}
+	if s.SourceLocale != "" && !seen[s.SourceLocale] {
+		return fmt.Errorf("source_locale %s is not one of the locales", s.SourceLocale)
+	}
+	for _, actionType := range s.Actions {
+		if !ActionTypes[actionType] {
+			return fmt.Errorf("unknown action type %q in actions", actionType)
+		}
+	}
+	if s.MinLength < 0 || s.Tolerance < 0 {
+		return fmt.Errorf("min_length and tolerance must not be negative")
+	}
+	if _, err := s.KeyRegexps(); err != nil {
+		return err
+	}
+	return nil
+}
+
+// Source is the locale the others are compared to
+func (s *I18nSettings) Source() string {
+	if s.SourceLocale != "" {
+		return s.SourceLocale
+	}
+	return s.Locales[0]
+}
+
+// KeyRegexps compiles the key patterns
+func (s *I18nSettings) KeyRegexps() ([]*regexp.Regexp, error) {
+	if s.keyPatterns != nil {
+		return s.keyPatterns, nil
+	}
+	patterns := s.KeyPatterns
+	if len(patterns) == 0 {
+		patterns = DefaultI18nKeyPatterns
+	}
+	compiled := make([]*regexp.Regexp, 0, len(patterns))
+	for _, p := range patterns {
+		re, err := regexp.Compile(p)
+		if err != nil {
+			return nil, fmt.Errorf("invalid key pattern %q: %w", p, err)
+		}
+		compiled = append(compiled, re)
+	}
+	s.keyPatterns = compiled
+	return compiled, nil
+}
+
+// Audits tells whether the text is audited after actions of actionType
+func (s *I18nSettings) Audits(actionType string) bool {
+	actions := s.Actions
+	if len(actions) == 0 {
+		actions = DefaultI18nActions
+	}
+	for _, a := range actions {
+		if a == actionType {
+			return true
+		}
+	}
+	return false
+}
+
+// Ignores tells whether text is the same in every locale by design
+func (s *I18nSettings) Ignores(text string) bool {
+	for _, ignored := range s.Ignore {
+		if strings.EqualFold(strings.TrimSpace(text), ignored) {
+			return true
+		}
+	}
+	return false
+}
+
+// MinLengthOrDefault is the length below which texts aren't compared
+func (s *I18nSettings) MinLengthOrDefault() int {
+	if s.MinLength > 0 {
+		return s.MinLength
+	}
+	return DefaultI18nMinLength
+}
+
+// ToleranceOrDefault is how many pixels text may exceed its box by
+func (s *I18nSettings) ToleranceOrDefault() int {
+	if s.Tolerance > 0 {
+		return s.Tolerance
+	}
+	return DefaultI18nTolerance
+}
+
+// ChecksOverflow tells whether text cut off by its box is detected
+func (s *I18nSettings) ChecksOverflow() bool {
+	return s.Overflow == nil || *s.Overflow
+}
+
+// matrix is the app matrix with a locale dimension added in front, listing
+// the source locale first so its text is known when the others run
+func (s *I18nSettings) matrix(m Matrix) (Matrix, error) {
+	for _, dim := range m {
+		if dim.Name == "locale" {
+			return nil, fmt.Errorf("matrix dimension locale is set by i18n.locales")
+		}
+	}
+	locales := []string{s.Source()}
+	for _, locale := range s.Locales {
+		if locale != s.Source() {
+			locales = append(locales, locale)
+		}
+	}
+	return append(Matrix{{Name: "locale", Values: locales}}, m...), nil
+}
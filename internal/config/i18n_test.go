@@ -0,0 +1,107 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestI18nSettings_Validate(t *testing.T) {
+	var unset *I18nSettings
+	assert.NoError(t, unset.Validate())
+
+	for want, settings := range map[string]I18nSettings{
+		"locales is required":       {},
+		"not a language tag":        {Locales: []string{"en", "german"}},
+		"listed twice":              {Locales: []string{"en", "en"}},
+		"source_locale fr is not":   {Locales: []string{"en", "de"}, SourceLocale: "fr"},
+		`unknown action type "tap"`: {Locales: []string{"en"}, Actions: []string{"tap"}},
+		"must not be negative":      {Locales: []string{"en"}, Tolerance: -1},
+		"invalid key pattern":       {Locales: []string{"en"}, KeyPatterns: []string{"("}},
+	} {
+		assert.ErrorContains(t, settings.Validate(), want)
+	}
+
+	settings := I18nSettings{Locales: []string{"de-DE", "en"}, SourceLocale: "en", Ignore: []string{"Panoptic"}}
+	require.NoError(t, settings.Validate())
+	assert.Equal(t, "en", settings.Source())
+	assert.True(t, settings.Audits("navigate"))
+	assert.False(t, settings.Audits("fill"))
+	assert.True(t, settings.Ignores(" panoptic "))
+	assert.Equal(t, DefaultI18nMinLength, settings.MinLengthOrDefault())
+	assert.Equal(t, DefaultI18nTolerance, settings.ToleranceOrDefault())
+	assert.True(t, settings.ChecksOverflow())
+}
+
+// TestI18nSettings_KeyPatterns tests that the default patterns catch raw
+// keys and leave sentences alone
+func TestI18nSettings_KeyPatterns(t *testing.T) {
+	settings := I18nSettings{Locales: []string{"en"}}
+	patterns, err := settings.KeyRegexps()
+	require.NoError(t, err)
+	matches := func(text string) bool {
+		for _, re := range patterns {
+			if re.MatchString(text) {
+				return true
+			}
+		}
+		return false
+	}
+	for _, key := range []string{"checkout.button.pay", "nav.signIn", "Hello {{ user.name }}", "__MISSING__ cart.title", "[missing: de.cart]"} {
+		assert.True(t, matches(key), key)
+	}
+	for _, text := range []string{"Pay now", "Total: 12.50", "Version 2.1", "In den Warenkorb."} {
+		assert.False(t, matches(text), text)
+	}
+}
+
+const i18nConfig = `
+name: i18n
+apps:
+  - name: Shop
+    type: web
+    url: https://shop.test
+    i18n:
+      locales: [de-DE, en, fr]
+      source_locale: en
+    matrix:
+      env: [qa]
+    actions:
+      - name: home
+        type: navigate
+        url: "https://shop.test/{{matrix.locale}}/"
+`
+
+// TestLoad_I18n tests that an audited app runs once per locale, the source
+// locale first
+func TestLoad_I18n(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "i18n.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(i18nConfig), 0600))
+	cfg, err := Load(path)
+	require.NoError(t, err)
+	require.Len(t, cfg.Apps, 3)
+
+	var locales []string
+	for _, app := range cfg.Apps {
+		locales = append(locales, app.Locale)
+		assert.Equal(t, "Shop", app.MatrixApp)
+		require.NotNil(t, app.I18n)
+	}
+	assert.Equal(t, []string{"en", "de-DE", "fr"}, locales)
+	assert.Equal(t, "Shop (locale=de-DE, env=qa)", cfg.Apps[1].Name)
+	assert.Equal(t, "https://shop.test/de-DE/", cfg.Apps[1].Actions[0].URL)
+	assert.NoError(t, cfg.Validate())
+
+	cfg = &Config{Apps: []AppConfig{{Name: "Shop", Type: "web", I18n: &I18nSettings{Locales: []string{"en"}}, Matrix: Matrix{{Name: "locale", Values: []string{"en"}}}}}}
+	assert.ErrorContains(t, cfg.ExpandMatrix(), "app Shop: matrix dimension locale is set by i18n.locales")
+	cfg = &Config{Apps: []AppConfig{{Name: "Shop", Type: "web", I18n: &I18nSettings{}}}}
+	assert.ErrorContains(t, cfg.ExpandMatrix(), "app Shop: i18n: locales is required")
+
+	cfg = &Config{Apps: []AppConfig{{Name: "Phone", Type: "mobile", Platform: "android", I18n: &I18nSettings{Locales: []string{"en"}}}}}
+	assert.ErrorContains(t, cfg.Validate(), "app Phone: i18n is only supported on web apps")
+	cfg = &Config{Apps: []AppConfig{{Name: "Shop", Type: "web", URL: "https://shop.test", Locale: "de DE"}}}
+	assert.ErrorContains(t, cfg.Validate(), `app Shop: locale "de DE" is not a language tag`)
+}
@@ -0,0 +1,122 @@
+package config
+
+import (
+	"fmt"
+	"regexp"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Default wait_for_email polling
+const (
+	DefaultEmailTimeout  = 2 * time.Minute
+	DefaultEmailInterval = 2 * time.Second
+)
+
+// InboxSettings is the test mailbox wait_for_email actions read: a MailHog
+// server catching the app's outgoing mail, or a Mailosaur server, which also
+// receives SMS. APIKey is expanded from the environment, e.g.
+// "${MAILOSAUR_API_KEY}".
+type InboxSettings struct {
+	Provider string        `yaml:"provider"` // mailhog or mailosaur
+	URL      string        `yaml:"url"`      // MailHog API, e.g. http://localhost:8025; optional for Mailosaur
+	APIKey   string        `yaml:"api_key"`  // Mailosaur API key
+	Server   string        `yaml:"server"`   // Mailosaur server ID
+	Timeout  time.Duration `yaml:"timeout"`  // default wait per action (default 2m)
+	Interval time.Duration `yaml:"interval"` // between inbox polls (default 2s)
+}
+
+// Validate checks the provider and what it needs to connect
+func (s *InboxSettings) Validate() error {
+	if s == nil {
+		return nil
+	}
+	switch s.Provider {
+	case "mailhog":
+		if s.URL == "" {
+			return fmt.Errorf("mailhog needs url")
+		}
+	case "mailosaur":
+		if s.APIKey == "" || s.Server == "" {
+			return fmt.Errorf("mailosaur needs api_key and server")
+		}
+	default:
+		return fmt.Errorf("provider must be mailhog or mailosaur, got %q", s.Provider)
+	}
+	if s.Timeout < 0 || s.Interval < 0 {
+		return fmt.Errorf("timeout and interval must not be negative")
+	}
+	return nil
+}
+
+// IntervalOrDefault returns the polling interval, or DefaultEmailInterval
+func (s *InboxSettings) IntervalOrDefault() time.Duration {
+	if s != nil && s.Interval > 0 {
+		return s.Interval
+	}
+	return DefaultEmailInterval
+}
+
+// EmailWait is what a wait_for_email action waits for. From, To and Subject
+// match case-insensitive substrings of messages received since the app
+// started; To may be a phone number for Mailosaur SMS. Extract names
+// variables filled from the message by regular expression, the first capture
+// group or the whole match, for {{var.<name>}} in later actions.
+type EmailWait struct {
+	From    string            `yaml:"from"`
+	To      string            `yaml:"to"`
+	Subject string            `yaml:"subject"`
+	Extract map[string]string `yaml:"extract"`
+	Timeout time.Duration     `yaml:"-"`
+}
+
+// variableName is the syntax of {{var.<name>}} names
+var variableName = regexp.MustCompile(`^[A-Za-z0-9_.-]+$`)
+
+// EmailWait reads a wait_for_email action's parameters; the timeout is the
+// action's duration in seconds, or settings.inbox.timeout
+func (a *Action) EmailWait(inbox *InboxSettings) (*EmailWait, error) {
+	wait := &EmailWait{}
+	data, err := yaml.Marshal(a.Parameters)
+	if err != nil {
+		return nil, err
+	}
+	if err := yaml.Unmarshal(data, wait); err != nil {
+		return nil, fmt.Errorf("invalid wait_for_email parameters: %w", err)
+	}
+	if wait.From == "" && wait.To == "" && wait.Subject == "" {
+		return nil, fmt.Errorf("wait_for_email needs from, to or subject")
+	}
+	for name, pattern := range wait.Extract {
+		if !variableName.MatchString(name) {
+			return nil, fmt.Errorf("invalid variable name %q", name)
+		}
+		if _, err := regexp.Compile(pattern); err != nil {
+			return nil, fmt.Errorf("extract %s: %w", name, err)
+		}
+	}
+
+	switch {
+	case a.Duration > 0:
+		wait.Timeout = time.Duration(a.Duration) * time.Second
+	case inbox != nil && inbox.Timeout > 0:
+		wait.Timeout = inbox.Timeout
+	default:
+		wait.Timeout = DefaultEmailTimeout
+	}
+	return wait, nil
+}
+
+// validateEmailAction checks wait_for_email actions have an inbox to read
+// and valid parameters
+func (c *Config) validateEmailAction(action Action) error {
+	if action.Type != "wait_for_email" {
+		return nil
+	}
+	if c.Settings.Inbox == nil {
+		return fmt.Errorf("wait_for_email requires settings.inbox")
+	}
+	_, err := action.EmailWait(c.Settings.Inbox)
+	return err
+}
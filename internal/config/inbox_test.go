@@ -0,0 +1,57 @@
+package config
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestInboxSettings_Validate tests the fields each provider needs
+func TestInboxSettings_Validate(t *testing.T) {
+	assert.NoError(t, (*InboxSettings)(nil).Validate())
+	assert.NoError(t, (&InboxSettings{Provider: "mailhog", URL: "http://localhost:8025"}).Validate())
+	assert.NoError(t, (&InboxSettings{Provider: "mailosaur", APIKey: "k", Server: "srv1"}).Validate())
+
+	assert.EqualError(t, (&InboxSettings{Provider: "mailhog"}).Validate(), "mailhog needs url")
+	assert.EqualError(t, (&InboxSettings{Provider: "mailosaur", APIKey: "k"}).Validate(), "mailosaur needs api_key and server")
+	assert.ErrorContains(t, (&InboxSettings{Provider: "gmail"}).Validate(), "provider must be")
+	assert.Equal(t, DefaultEmailInterval, (*InboxSettings)(nil).IntervalOrDefault())
+}
+
+// TestAction_EmailWait tests reading parameters and the timeout
+func TestAction_EmailWait(t *testing.T) {
+	action := &Action{Parameters: map[string]interface{}{"subject": "Verify", "extract": map[string]interface{}{"link": `https://\S+`}}}
+	wait, err := action.EmailWait(nil)
+	require.NoError(t, err)
+	assert.Equal(t, "Verify", wait.Subject)
+	assert.Equal(t, DefaultEmailTimeout, wait.Timeout)
+
+	wait, _ = action.EmailWait(&InboxSettings{Timeout: time.Minute})
+	assert.Equal(t, time.Minute, wait.Timeout)
+	action.Duration = 30
+	wait, _ = action.EmailWait(&InboxSettings{Timeout: time.Minute})
+	assert.Equal(t, 30*time.Second, wait.Timeout)
+
+	_, err = (&Action{}).EmailWait(nil)
+	assert.EqualError(t, err, "wait_for_email needs from, to or subject")
+	_, err = (&Action{Parameters: map[string]interface{}{"to": "a@b.test", "extract": map[string]interface{}{"otp": "(["}}}).EmailWait(nil)
+	assert.ErrorContains(t, err, "extract otp:")
+	_, err = (&Action{Parameters: map[string]interface{}{"to": "a@b.test", "extract": map[string]interface{}{"my otp": `\d+`}}}).EmailWait(nil)
+	assert.EqualError(t, err, `invalid variable name "my otp"`)
+}
+
+// TestConfig_EmailActions tests that wait_for_email needs settings.inbox
+func TestConfig_EmailActions(t *testing.T) {
+	cfg := &Config{
+		Apps:    []AppConfig{{Name: "Shop", Type: "web", URL: "https://example.com"}},
+		Actions: []Action{{Name: "otp", Type: "wait_for_email", Parameters: map[string]interface{}{"subject": "code"}}},
+	}
+	assert.EqualError(t, cfg.Validate(), "action otp: wait_for_email requires settings.inbox")
+
+	cfg.Settings.Inbox = &InboxSettings{Provider: "mailhog", URL: "http://localhost:8025"}
+	assert.NoError(t, cfg.Validate())
+	cfg.Settings.Inbox.URL = ""
+	assert.EqualError(t, cfg.Validate(), "settings.inbox: mailhog needs url")
+}
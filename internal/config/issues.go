@@ -0,0 +1,38 @@
+package config
+
+import "fmt"
+
+// IssueSettings files failures in an issue tracker, one open issue per failure
+// fingerprint. Token is expanded from the environment, e.g. "${JIRA_TOKEN}".
+type IssueSettings struct {
+	Type      string   `yaml:"type"`       // jira, github or gitlab
+	URL       string   `yaml:"url"`        // Jira site, GitLab instance (default https://gitlab.com) or GitHub API URL
+	Project   string   `yaml:"project"`    // Jira project key, GitLab project path or ID, GitHub owner/name
+	Username  string   `yaml:"username"`   // Jira Cloud account email; empty sends token as a bearer token
+	Token     string   `yaml:"token"`      // GitHub defaults to "${GITHUB_TOKEN}"
+	IssueType string   `yaml:"issue_type"` // Jira issue type (default Bug)
+	Labels    []string `yaml:"labels,omitempty"`
+	Warnings  bool     `yaml:"warnings"`   // also file warning-severity failures
+	ReportURL string   `yaml:"report_url"` // linked from issues; {run_id} is replaced
+}
+
+// Validate checks the tracker type and the settings it requires
+func (s *IssueSettings) Validate() error {
+	if s == nil {
+		return nil
+	}
+	switch s.Type {
+	case "jira":
+		if s.URL == "" || s.Project == "" {
+			return fmt.Errorf("settings.issues: jira needs url and project")
+		}
+	case "gitlab":
+		if s.Project == "" {
+			return fmt.Errorf("settings.issues: gitlab needs project")
+		}
+	case "github":
+	default:
+		return fmt.Errorf("settings.issues.type must be jira, github or gitlab, got %q", s.Type)
+	}
+	return nil
+}
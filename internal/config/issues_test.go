@@ -0,0 +1,26 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestIssueSettings_Validate tests the tracker types and required settings
+func TestIssueSettings_Validate(t *testing.T) {
+	var unset *IssueSettings
+	assert.NoError(t, unset.Validate())
+	assert.NoError(t, (&IssueSettings{Type: "github"}).Validate())
+	assert.NoError(t, (&IssueSettings{Type: "gitlab", Project: "acme/shop"}).Validate())
+	assert.NoError(t, (&IssueSettings{Type: "jira", URL: "https://acme.atlassian.net", Project: "SHOP"}).Validate())
+
+	assert.ErrorContains(t, (&IssueSettings{Type: "jira", Project: "SHOP"}).Validate(), "url and project")
+	assert.ErrorContains(t, (&IssueSettings{Type: "gitlab"}).Validate(), "needs project")
+	assert.ErrorContains(t, (&IssueSettings{Type: "trello"}).Validate(), "jira, github or gitlab")
+
+	cfg := &Config{
+		Apps:     []AppConfig{{Name: "Shop", Type: "web", URL: "https://example.com"}},
+		Settings: Settings{Issues: &IssueSettings{Type: "trello"}},
+	}
+	assert.ErrorContains(t, cfg.Validate(), "settings.issues.type")
+}
@@ -0,0 +1,199 @@
+package config
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// MatrixDimension is one axis of an app matrix, e.g. locale: [en, de]
+type MatrixDimension struct {
+	Name   string
+	Values []string
+}
+
+// Matrix expands an app into one instance per combination of its dimensions.
+// It is written as a mapping and keeps the dimensions in the order written.
+type Matrix []MatrixDimension
+
+// matrixPlaceholder matches {{matrix.<dimension>}}
+var matrixPlaceholder = regexp.MustCompile(`{{\s*matrix\.([A-Za-z0-9_-]+)\s*}}`)
+
+var matrixDimensionName = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+// UnmarshalYAML reads a mapping of dimension names to value lists
+func (m *Matrix) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind != yaml.MappingNode {
+		return fmt.Errorf("line %d: matrix must map dimension names to lists of values", node.Line)
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		var dim MatrixDimension
+		dim.Name = node.Content[i].Value
+		if err := node.Content[i+1].Decode(&dim.Values); err != nil {
+			return fmt.Errorf("line %d: values of matrix dimension %s must be a list: %w", node.Content[i+1].Line, dim.Name, err)
+		}
+		*m = append(*m, dim)
+	}
+	return nil
+}
+
+// MarshalYAML writes the mapping form
+func (m Matrix) MarshalYAML() (interface{}, error) {
+	node := &yaml.Node{Kind: yaml.MappingNode}
+	for _, dim := range m {
+		var values yaml.Node
+		if err := values.Encode(dim.Values); err != nil {
+			return nil, err
+		}
+		node.Content = append(node.Content, &yaml.Node{Kind: yaml.ScalarNode, Value: dim.Name}, &values)
+	}
+	return node, nil
+}
+
+// Validate requires named, distinct dimensions with at least one value each
+func (m Matrix) Validate() error {
+	seen := make(map[string]bool)
+	for _, dim := range m {
+		if !matrixDimensionName.MatchString(dim.Name) {
+			return fmt.Errorf("matrix dimension %q must be letters, digits, - or _", dim.Name)
+		}
+		if seen[dim.Name] {
+			return fmt.Errorf("matrix dimension %s is defined twice", dim.Name)
+		}
+		seen[dim.Name] = true
+		if len(dim.Values) == 0 {
+			return fmt.Errorf("matrix dimension %s has no values", dim.Name)
+		}
+	}
+	return nil
+}
+
+// combinations lists every assignment of a value to each dimension, varying
+// the last dimension fastest
+func (m Matrix) combinations() []map[string]string {
+	combos := []map[string]string{{}}
+	for _, dim := range m {
+		next := make([]map[string]string, 0, len(combos)*len(dim.Values))
+		for _, combo := range combos {
+			for _, value := range dim.Values {
+				c := make(map[string]string, len(combo)+1)
+				for k, v := range combo {
+					c[k] = v
+				}
+				c[dim.Name] = value
+				next = append(next, c)
+			}
+		}
+		combos = next
+	}
+	return combos
+}
+
+// label names a combination in dimension order, e.g. "locale=de, flag=on"
+func (m Matrix) label(values map[string]string) string {
+	parts := make([]string, len(m))
+	for i, dim := range m {
+		parts[i] = dim.Name + "=" + values[dim.Name]
+	}
+	return strings.Join(parts, ", ")
+}
+
+// ExpandMatrix replaces every app that has a matrix with one app per
+// combination of its dimensions. {{matrix.<dimension>}} is replaced in all of
+// the app's fields and in its actions; an app without actions of its own gets
+// a copy of the global actions, so they can use the placeholders too. Apps
+// whose name has no placeholder are named "<name> (<dimension>=<value>, ...)".
+// The locales of an app's i18n audit are a locale dimension in front of its
+// matrix, shown in unless the app sets its own locale.
+func (c *Config) ExpandMatrix() error {
+	apps := make([]AppConfig, 0, len(c.Apps))
+	for _, app := range c.Apps {
+		if len(app.Matrix) == 0 && app.I18n == nil {
+			apps = append(apps, app)
+			continue
+		}
+		template := app
+		if app.I18n != nil {
+			if err := app.I18n.Validate(); err != nil {
+				return fmt.Errorf("app %s: i18n: %w", app.Name, err)
+			}
+			matrix, err := app.I18n.matrix(app.Matrix)
+			if err != nil {
+				return fmt.Errorf("app %s: %w", app.Name, err)
+			}
+			app.Matrix = matrix
+			if template.Locale == "" {
+				template.Locale = "{{matrix.locale}}"
+			}
+		}
+		if err := app.Matrix.Validate(); err != nil {
+			return fmt.Errorf("app %s: %w", app.Name, err)
+		}
+
+		template.Matrix = nil
+		if len(template.Actions) == 0 && len(c.Actions) > 0 {
+			template.Actions = append([]Action(nil), c.Actions...)
+		}
+		var node yaml.Node
+		if err := node.Encode(template); err != nil {
+			return fmt.Errorf("app %s: %w", app.Name, err)
+		}
+
+		for _, values := range app.Matrix.combinations() {
+			instance, err := interpolateMatrix(&node, values)
+			if err != nil {
+				return fmt.Errorf("app %s: %w", app.Name, err)
+			}
+			if instance.Name == app.Name {
+				instance.Name = fmt.Sprintf("%s (%s)", app.Name, app.Matrix.label(values))
+			}
+			instance.MatrixValues = values
+			instance.MatrixApp = app.Name
+			instance.Scenario = app.Scenario
+			apps = append(apps, instance)
+		}
+	}
+	c.Apps = apps
+	return nil
+}
+
+// interpolateMatrix decodes a copy of the app template with the matrix
+// placeholders of its string values replaced. Placeholders of other
+// dimensions are left for Validate to report: global actions are shared by
+// apps with different matrices.
+func interpolateMatrix(template *yaml.Node, values map[string]string) (AppConfig, error) {
+	var replace func(n *yaml.Node) *yaml.Node
+	replace = func(n *yaml.Node) *yaml.Node {
+		copied := *n
+		if n.Kind == yaml.ScalarNode && strings.Contains(n.Value, "matrix.") {
+			copied.Value = matrixPlaceholder.ReplaceAllStringFunc(n.Value, func(m string) string {
+				if value, ok := values[matrixPlaceholder.FindStringSubmatch(m)[1]]; ok {
+					return value
+				}
+				return m
+			})
+		}
+		copied.Content = make([]*yaml.Node, len(n.Content))
+		for i, child := range n.Content {
+			copied.Content[i] = replace(child)
+		}
+		return &copied
+	}
+
+	var app AppConfig
+	err := replace(template).Decode(&app)
+	return app, err
+}
+
+// unexpandedMatrix returns the dimension of the first matrix placeholder left
+// in the fields that drive an app, or "" when there is none
+func unexpandedMatrix(fields ...string) string {
+	for _, field := range fields {
+		if m := matrixPlaceholder.FindStringSubmatch(field); m != nil {
+			return m[1]
+		}
+	}
+	return ""
+}
@@ -0,0 +1,101 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const matrixConfig = `
+name: matrix
+apps:
+  - name: Shop
+    type: web
+    url: "https://{{matrix.env}}.shop.test/?lang={{matrix.locale}}"
+    browsers: [chromium, edge]
+    tags: ["locale-{{matrix.locale}}"]
+    actions:
+      - name: home
+        type: navigate
+        value: "https://{{matrix.env}}.shop.test/{{matrix.locale}}/"
+    matrix:
+      env: [staging, prod]
+      locale: [en, de]
+  - name: "Admin {{matrix.flag}}"
+    type: web
+    url: https://admin.test
+    matrix:
+      flag: ["new-nav", "old-nav"]
+actions:
+  - name: open
+    type: navigate
+    url: "https://admin.test/?flag={{matrix.flag}}"
+  - name: toggle
+    type: click
+    selector: "[data-flag='{{ matrix.flag }}']"
+    parameters:
+      note: "flag: {{matrix.flag}}"
+`
+
+// TestLoad_Matrix tests cartesian expansion, naming and interpolation
+func TestLoad_Matrix(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "matrix.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(matrixConfig), 0600))
+	cfg, err := Load(path)
+	require.NoError(t, err)
+	require.Len(t, cfg.Apps, 6)
+
+	var names []string
+	for _, app := range cfg.Apps {
+		names = append(names, app.Name)
+		assert.Nil(t, app.Matrix)
+	}
+	assert.Equal(t, []string{
+		"Shop (env=staging, locale=en)", "Shop (env=staging, locale=de)",
+		"Shop (env=prod, locale=en)", "Shop (env=prod, locale=de)",
+		"Admin new-nav", "Admin old-nav",
+	}, names)
+
+	shop := cfg.Apps[1]
+	assert.Equal(t, "https://staging.shop.test/?lang=de", shop.URL)
+	assert.Equal(t, []string{"locale-de"}, shop.Tags)
+	assert.Equal(t, map[string]string{"env": "staging", "locale": "de"}, shop.MatrixValues)
+	assert.Len(t, shop.BrowserVariants(), 2, "browser matrices still apply")
+	assert.Equal(t, "https://staging.shop.test/de/", shop.Actions[0].Value)
+	assert.NoError(t, cfg.Validate())
+
+	admin := cfg.Apps[5]
+	require.Len(t, admin.Actions, 2)
+	assert.Equal(t, "https://admin.test/?flag=old-nav", admin.Actions[0].URL)
+	assert.Equal(t, "[data-flag='old-nav']", admin.Actions[1].Selector, "global actions are copied so they can be interpolated")
+	assert.Equal(t, "flag: old-nav", admin.Actions[1].Parameters["note"])
+	assert.Equal(t, "https://admin.test/?flag={{matrix.flag}}", cfg.Actions[0].URL, "global actions are left as written")
+}
+
+// TestExpandMatrix_Errors tests invalid dimensions and unknown placeholders
+func TestExpandMatrix_Errors(t *testing.T) {
+	for want, app := range map[string]AppConfig{
+		"has no values":   {Name: "a", Matrix: Matrix{{Name: "env"}}},
+		"defined twice":   {Name: "a", Matrix: Matrix{{Name: "env", Values: []string{"x"}}, {Name: "env", Values: []string{"y"}}}},
+		"letters, digits": {Name: "a", Matrix: Matrix{{Name: "e nv", Values: []string{"x"}}}},
+	} {
+		cfg := &Config{Apps: []AppConfig{app}}
+		assert.ErrorContains(t, cfg.ExpandMatrix(), want)
+	}
+
+	cfg := &Config{
+		Apps:    []AppConfig{{Name: "Shop", Type: "web", URL: "https://{{matrix.env}}.shop.test", Matrix: Matrix{{Name: "env", Values: []string{"qa"}}}}},
+		Actions: []Action{{Name: "open", Type: "navigate", URL: "https://shop.test/?flag={{matrix.flag}}"}},
+	}
+	require.NoError(t, cfg.ExpandMatrix())
+	assert.Equal(t, "https://qa.shop.test", cfg.Apps[0].URL)
+	assert.ErrorContains(t, cfg.Validate(), "action open in app Shop (env=qa) uses {{matrix.flag}}, which the app's matrix doesn't define")
+
+	path := filepath.Join(t.TempDir(), "bad.yaml")
+	require.NoError(t, os.WriteFile(path, []byte("apps:\n  - name: a\n    matrix: [x]\n"), 0600))
+	_, err := Load(path)
+	assert.ErrorContains(t, err, "matrix must map dimension names")
+}
@@ -0,0 +1,65 @@
+package config
+
+import (
+	"fmt"
+	"regexp"
+)
+
+// MetricOutput is a number an action records under a name once it
+// succeeds, kept with the app's results and in the run history so it can
+// be followed across runs. It's read from one source: the text of the
+// element Selector matches, the value of the JavaScript expression Script,
+// Value with its {{var.*}} placeholders filled, or, with Duration, how
+// long the action took in milliseconds. Pattern picks the number out of
+// the text, by its first group when it has one; otherwise the first number
+// in the text is taken, ignoring thousands separators.
+type MetricOutput struct {
+	Name     string `yaml:"name"`
+	Selector string `yaml:"selector,omitempty"`
+	Script   string `yaml:"script,omitempty"`
+	Value    string `yaml:"value,omitempty"`
+	Duration bool   `yaml:"duration,omitempty"`
+	Pattern  string `yaml:"pattern,omitempty"`
+	Unit     string `yaml:"unit,omitempty"` // e.g. ms or items; the unit of Duration is ms
+}
+
+// Validate checks the name, that exactly one source is set and the pattern
+func (m MetricOutput) Validate() error {
+	if !variableName.MatchString(m.Name) {
+		return fmt.Errorf("name must be letters, digits, '_', '.' or '-', got %q", m.Name)
+	}
+	sources := 0
+	for _, set := range []bool{m.Selector != "", m.Script != "", m.Value != "", m.Duration} {
+		if set {
+			sources++
+		}
+	}
+	if sources != 1 {
+		return fmt.Errorf("needs exactly one of selector, script, value or duration")
+	}
+	if m.Pattern != "" {
+		if m.Duration {
+			return fmt.Errorf("pattern doesn't apply to duration")
+		}
+		if _, err := regexp.Compile(m.Pattern); err != nil {
+			return fmt.Errorf("invalid pattern: %w", err)
+		}
+	}
+	return nil
+}
+
+// validateMetricOutputs checks the metrics of an action, whose names must
+// be unique
+func validateMetricOutputs(metrics []MetricOutput) error {
+	seen := make(map[string]bool, len(metrics))
+	for i, m := range metrics {
+		if err := m.Validate(); err != nil {
+			return fmt.Errorf("metrics[%d]: %w", i, err)
+		}
+		if seen[m.Name] {
+			return fmt.Errorf("metrics[%d]: %s is recorded twice", i, m.Name)
+		}
+		seen[m.Name] = true
+	}
+	return nil
+}
@@ -0,0 +1,33 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateAction_Metrics(t *testing.T) {
+	cfg := &Config{}
+	tests := []struct {
+		metrics []MetricOutput
+		errMsg  string
+	}{
+		{[]MetricOutput{{Name: "cart.total", Selector: "#total", Pattern: `\$([\d.]+)`}, {Name: "cart_render", Duration: true}}, ""},
+		{[]MetricOutput{{Name: "items", Script: "document.querySelectorAll('.item').length", Unit: "items"}}, ""},
+		{[]MetricOutput{{Name: "orders", Value: "{{var.orders}}"}}, ""},
+		{[]MetricOutput{{Name: "cart total", Selector: "#total"}}, `metrics[0]: name must be letters, digits, '_', '.' or '-', got "cart total"`},
+		{[]MetricOutput{{Name: "total"}}, "metrics[0]: needs exactly one of selector, script, value or duration"},
+		{[]MetricOutput{{Name: "total", Selector: "#total", Script: "1"}}, "metrics[0]: needs exactly one of"},
+		{[]MetricOutput{{Name: "render", Duration: true, Pattern: `\d+`}}, "metrics[0]: pattern doesn't apply to duration"},
+		{[]MetricOutput{{Name: "total", Selector: "#total", Pattern: `(`}}, "metrics[0]: invalid pattern"},
+		{[]MetricOutput{{Name: "total", Selector: "#total"}, {Name: "total", Duration: true}}, "metrics[1]: total is recorded twice"},
+	}
+	for _, tt := range tests {
+		err := cfg.validateAction(Action{Name: "checkout", Type: "click", Selector: "#checkout", Metrics: tt.metrics})
+		if tt.errMsg == "" {
+			assert.NoError(t, err, "%+v", tt.metrics)
+		} else {
+			assert.ErrorContains(t, err, tt.errMsg, "%+v", tt.metrics)
+		}
+	}
+}
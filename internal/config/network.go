@@ -0,0 +1,139 @@
+package config
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// NetworkConditions throttles or disconnects a web app's network through the
+// browser. A Profile supplies defaults that the other fields override;
+// throughputs of zero leave that direction unthrottled.
+type NetworkConditions struct {
+	Profile      string  `yaml:"profile"` // slow-3g, fast-3g, fast-4g, offline or online
+	Offline      bool    `yaml:"offline"`
+	LatencyMS    float64 `yaml:"latency_ms"`    // added to every request
+	DownloadKbps float64 `yaml:"download_kbps"` // kilobits per second
+	UploadKbps   float64 `yaml:"upload_kbps"`
+	PacketLoss   float64 `yaml:"packet_loss"` // percent of packets dropped; ignored by older Chromium releases
+}
+
+// NetworkProfiles are the throttling presets of the Chrome DevTools network panel
+var NetworkProfiles = map[string]NetworkConditions{
+	"slow-3g": {LatencyMS: 2000, DownloadKbps: 400, UploadKbps: 400},
+	"fast-3g": {LatencyMS: 562.5, DownloadKbps: 1440, UploadKbps: 675},
+	"fast-4g": {LatencyMS: 165, DownloadKbps: 8100, UploadKbps: 1350},
+	"offline": {Offline: true},
+	"online":  {},
+}
+
+// Resolve applies the fields set on top of the profile's values
+func (n NetworkConditions) Resolve() (NetworkConditions, error) {
+	resolved := NetworkConditions{}
+	if n.Profile != "" {
+		profile, ok := NetworkProfiles[n.Profile]
+		if !ok {
+			return NetworkConditions{}, fmt.Errorf("unknown network profile %q (known: %s)", n.Profile, strings.Join(networkProfileNames(), ", "))
+		}
+		resolved = profile
+		resolved.Profile = n.Profile
+	}
+	if n.Offline {
+		resolved.Offline = true
+	}
+	if n.LatencyMS != 0 {
+		resolved.LatencyMS = n.LatencyMS
+	}
+	if n.DownloadKbps != 0 {
+		resolved.DownloadKbps = n.DownloadKbps
+	}
+	if n.UploadKbps != 0 {
+		resolved.UploadKbps = n.UploadKbps
+	}
+	if n.PacketLoss != 0 {
+		resolved.PacketLoss = n.PacketLoss
+	}
+	return resolved, nil
+}
+
+// Label names resolved conditions for metrics: the profile when used as is,
+// otherwise the values
+func (n NetworkConditions) Label() string {
+	if profile, ok := NetworkProfiles[n.Profile]; ok {
+		profile.Profile = n.Profile
+		if n == profile {
+			return n.Profile
+		}
+	}
+	if n.Offline {
+		return "offline"
+	}
+	var parts []string
+	if n.LatencyMS != 0 {
+		parts = append(parts, fmt.Sprintf("latency %gms", n.LatencyMS))
+	}
+	if n.DownloadKbps != 0 || n.UploadKbps != 0 {
+		parts = append(parts, fmt.Sprintf("%g/%g kbps down/up", n.DownloadKbps, n.UploadKbps))
+	}
+	if n.PacketLoss != 0 {
+		parts = append(parts, fmt.Sprintf("%g%% packet loss", n.PacketLoss))
+	}
+	if len(parts) == 0 {
+		return "online"
+	}
+	name := n.Profile
+	if name == "" {
+		name = "custom"
+	}
+	return name + " (" + strings.Join(parts, ", ") + ")"
+}
+
+// Validate checks the profile and that values are in range; a nil value is valid
+func (n *NetworkConditions) Validate() error {
+	if n == nil {
+		return nil
+	}
+	if _, err := n.Resolve(); err != nil {
+		return err
+	}
+	if n.LatencyMS < 0 || n.DownloadKbps < 0 || n.UploadKbps < 0 {
+		return fmt.Errorf("network latency and throughput must not be negative")
+	}
+	if n.PacketLoss < 0 || n.PacketLoss > 100 {
+		return fmt.Errorf("packet_loss must be a percentage from 0 to 100, got %v", n.PacketLoss)
+	}
+	return nil
+}
+
+// NetworkConditions reads the conditions of a network action: a profile
+// name as value and NetworkConditions fields as parameters
+func (a *Action) NetworkConditions() (*NetworkConditions, error) {
+	conditions := &NetworkConditions{}
+	if len(a.Parameters) > 0 {
+		data, err := yaml.Marshal(a.Parameters)
+		if err != nil {
+			return nil, err
+		}
+		if err := yaml.Unmarshal(data, conditions); err != nil {
+			return nil, fmt.Errorf("invalid network parameters: %w", err)
+		}
+	}
+	if a.Value != "" {
+		conditions.Profile = a.Value
+	}
+	if *conditions == (NetworkConditions{}) {
+		return nil, fmt.Errorf("network action needs a profile as value, or parameters")
+	}
+	return conditions, conditions.Validate()
+}
+
+func networkProfileNames() []string {
+	names := make([]string, 0, len(NetworkProfiles))
+	for name := range NetworkProfiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
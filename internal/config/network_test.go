@@ -0,0 +1,54 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestNetworkConditions_Resolve tests profiles, overrides and labels
+func TestNetworkConditions_Resolve(t *testing.T) {
+	resolved, err := NetworkConditions{Profile: "slow-3g"}.Resolve()
+	require.NoError(t, err)
+	assert.Equal(t, NetworkConditions{Profile: "slow-3g", LatencyMS: 2000, DownloadKbps: 400, UploadKbps: 400}, resolved)
+	assert.Equal(t, "slow-3g", resolved.Label())
+
+	resolved, err = NetworkConditions{Profile: "fast-3g", PacketLoss: 2}.Resolve()
+	require.NoError(t, err)
+	assert.Equal(t, "fast-3g (latency 562.5ms, 1440/675 kbps down/up, 2% packet loss)", resolved.Label())
+
+	resolved, _ = NetworkConditions{Profile: "offline"}.Resolve()
+	assert.Equal(t, "offline", resolved.Label())
+	resolved, _ = NetworkConditions{Profile: "online"}.Resolve()
+	assert.Equal(t, "online", resolved.Label())
+	assert.Equal(t, "custom (latency 100ms)", NetworkConditions{LatencyMS: 100}.Label())
+
+	_, err = NetworkConditions{Profile: "5g"}.Resolve()
+	assert.ErrorContains(t, err, `unknown network profile "5g" (known: fast-3g, fast-4g, offline, online, slow-3g)`)
+}
+
+// TestAction_NetworkConditions tests reading network actions and validation
+func TestAction_NetworkConditions(t *testing.T) {
+	conditions, err := (&Action{Type: "network", Value: "offline"}).NetworkConditions()
+	require.NoError(t, err)
+	assert.Equal(t, "offline", conditions.Profile)
+
+	conditions, err = (&Action{Type: "network", Parameters: map[string]interface{}{"latency_ms": 300, "packet_loss": 1.5}}).NetworkConditions()
+	require.NoError(t, err)
+	assert.Equal(t, &NetworkConditions{LatencyMS: 300, PacketLoss: 1.5}, conditions)
+
+	_, err = (&Action{Type: "network"}).NetworkConditions()
+	assert.ErrorContains(t, err, "needs a profile")
+	_, err = (&Action{Type: "network", Parameters: map[string]interface{}{"packet_loss": 150}}).NetworkConditions()
+	assert.ErrorContains(t, err, "packet_loss must be a percentage")
+
+	cfg := &Config{
+		Apps:    []AppConfig{{Name: "Shop", Type: "web", URL: "https://example.com"}},
+		Actions: []Action{{Name: "go_offline", Type: "network", Value: "airplane"}},
+	}
+	assert.ErrorContains(t, cfg.Validate(), "action go_offline: unknown network profile")
+	cfg.Actions = nil
+	cfg.Settings.Network = &NetworkConditions{DownloadKbps: -1}
+	assert.ErrorContains(t, cfg.Validate(), "settings.network: network latency and throughput must not be negative")
+}
@@ -0,0 +1,50 @@
+package config
+
+import (
+	"fmt"
+	"time"
+)
+
+// NotificationSettings posts a run summary to Slack or Microsoft Teams.
+// WebhookURL and Token are expanded from the environment, e.g.
+// "${SLACK_WEBHOOK_URL}".
+type NotificationSettings struct {
+	Type        string        `yaml:"type"`         // slack or teams
+	WebhookURL  string        `yaml:"webhook_url"`  // incoming webhook (Teams: incoming webhook or Workflows URL)
+	Token       string        `yaml:"token"`        // Slack bot token with chat:write, instead of a webhook
+	Channel     string        `yaml:"channel"`      // Slack channel ID, with token
+	On          string        `yaml:"on"`           // always (default), failure or change
+	MaxFailures int           `yaml:"max_failures"` // failures listed (default 5)
+	Thread      bool          `yaml:"thread"`       // Slack with token: reply to one thread per configuration
+	ThreadTTL   time.Duration `yaml:"thread_ttl"`   // start a new thread after this long (default 24h)
+	Screenshots bool          `yaml:"screenshots"`  // thumbnails of failing apps, uploaded via settings.cloud
+	ReportURL   string        `yaml:"report_url"`   // linked from the message; {run_id} is replaced
+}
+
+// Validate checks the destination and options of a notification
+func (n NotificationSettings) Validate() error {
+	switch n.On {
+	case "", "always", "failure", "change":
+	default:
+		return fmt.Errorf("on must be always, failure or change, got %q", n.On)
+	}
+	switch n.Type {
+	case "slack":
+		if n.WebhookURL == "" && (n.Token == "" || n.Channel == "") {
+			return fmt.Errorf("slack needs webhook_url, or token and channel")
+		}
+		if n.Thread && n.Token == "" {
+			return fmt.Errorf("slack threading needs token and channel; incoming webhooks can't reply to a thread")
+		}
+	case "teams":
+		if n.WebhookURL == "" {
+			return fmt.Errorf("teams needs webhook_url")
+		}
+		if n.Thread {
+			return fmt.Errorf("teams webhooks can't reply to a thread")
+		}
+	default:
+		return fmt.Errorf("type must be slack or teams, got %q", n.Type)
+	}
+	return nil
+}
@@ -0,0 +1,36 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestNotificationSettings_Validate tests destinations, threading and on:
+func TestNotificationSettings_Validate(t *testing.T) {
+	valid := []NotificationSettings{
+		{Type: "slack", WebhookURL: "${SLACK_WEBHOOK_URL}"},
+		{Type: "slack", Token: "${SLACK_TOKEN}", Channel: "C123", Thread: true, On: "change"},
+		{Type: "teams", WebhookURL: "https://example.webhook.office.com/x", On: "failure"},
+	}
+	for _, n := range valid {
+		assert.NoError(t, n.Validate(), n.Type)
+	}
+
+	for want, n := range map[string]NotificationSettings{
+		"slack or teams":     {Type: "email"},
+		"webhook_url, or":    {Type: "slack", Token: "x"},
+		"incoming webhooks":  {Type: "slack", WebhookURL: "u", Thread: true},
+		"teams needs":        {Type: "teams"},
+		"can't reply":        {Type: "teams", WebhookURL: "u", Thread: true},
+		"always, failure or": {Type: "teams", WebhookURL: "u", On: "sometimes"},
+	} {
+		assert.ErrorContains(t, n.Validate(), want)
+	}
+
+	cfg := &Config{
+		Apps:     []AppConfig{{Name: "Shop", Type: "web", URL: "https://example.com"}},
+		Settings: Settings{Notifications: []NotificationSettings{valid[0], {Type: "teams"}}},
+	}
+	assert.ErrorContains(t, cfg.Validate(), "settings.notifications[1]")
+}
@@ -0,0 +1,135 @@
+package config
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// PaperSizes are the paper formats save_pdf prints on, width and height in
+// inches
+var PaperSizes = map[string][2]float64{
+	"letter":  {8.5, 11},
+	"legal":   {8.5, 14},
+	"tabloid": {11, 17},
+	"a3":      {11.69, 16.54},
+	"a4":      {8.27, 11.69},
+	"a5":      {5.83, 8.27},
+}
+
+// PDFPrint is a save_pdf action's parameters: the page is printed to
+// pdfs/<value> in the output directory, pdfs/<app>_<action>_<timestamp>.pdf
+// without a value, and the path stored in a variable when one is named
+type PDFPrint struct {
+	File            string  `yaml:"-"`
+	Format          string  `yaml:"format"` // a paper size; letter by default
+	Landscape       bool    `yaml:"landscape"`
+	PrintBackground bool    `yaml:"print_background"`
+	Scale           float64 `yaml:"scale"`       // 0.1 to 2; 1 by default
+	PageRanges      string  `yaml:"page_ranges"` // e.g. "1-3, 5"; all pages by default
+	Variable        string  `yaml:"variable"`
+}
+
+// Paper is the width and height of the print's paper in inches
+func (p *PDFPrint) Paper() (width, height float64) {
+	size := PaperSizes[p.Format]
+	if p.Landscape {
+		return size[1], size[0]
+	}
+	return size[0], size[1]
+}
+
+// PDFPrint reads a save_pdf action's parameters
+func (a *Action) PDFPrint() (*PDFPrint, error) {
+	spec := &PDFPrint{}
+	data, err := yaml.Marshal(a.Parameters)
+	if err != nil {
+		return nil, err
+	}
+	if err := yaml.Unmarshal(data, spec); err != nil {
+		return nil, fmt.Errorf("invalid save_pdf parameters: %w", err)
+	}
+	spec.File = a.Value
+	spec.Format = strings.ToLower(spec.Format)
+	if spec.Format == "" {
+		spec.Format = "letter"
+	}
+	if _, ok := PaperSizes[spec.Format]; !ok {
+		return nil, fmt.Errorf("unknown paper format %q; use letter, legal, tabloid, a3, a4 or a5", spec.Format)
+	}
+	if spec.Scale == 0 {
+		spec.Scale = 1
+	}
+	if spec.Scale < 0.1 || spec.Scale > 2 {
+		return nil, fmt.Errorf("scale must be between 0.1 and 2, got %g", spec.Scale)
+	}
+	if spec.Variable != "" && !variableName.MatchString(spec.Variable) {
+		return nil, fmt.Errorf("invalid variable name %q", spec.Variable)
+	}
+	return spec, nil
+}
+
+// PDFCheck is an assert_pdf action's parameters: the PDF read, which is the
+// action's value, a path, the document at URL or else the one the last
+// save_pdf printed, and the text and page count it must have. Text is
+// compared with runs of whitespace as single spaces.
+type PDFCheck struct {
+	File        string   `yaml:"-"`
+	URL         string   `yaml:"url"` // fetched with the browser's cookies on web apps
+	Contains    []string `yaml:"contains"`
+	NotContains []string `yaml:"not_contains"`
+	Matches     string   `yaml:"matches"`
+	Page        int      `yaml:"page"`  // text of this page only, from 1
+	Pages       int      `yaml:"pages"` // exact page count
+	MinPages    int      `yaml:"min_pages"`
+	MaxPages    int      `yaml:"max_pages"`
+	Variable    string   `yaml:"variable"` // receives the text
+}
+
+// PDFCheck reads an assert_pdf action's parameters
+func (a *Action) PDFCheck() (*PDFCheck, error) {
+	check := &PDFCheck{}
+	data, err := yaml.Marshal(a.Parameters)
+	if err != nil {
+		return nil, err
+	}
+	if err := yaml.Unmarshal(data, check); err != nil {
+		return nil, fmt.Errorf("invalid assert_pdf parameters: %w", err)
+	}
+	check.File = a.Value
+	if check.File != "" && check.URL != "" {
+		return nil, fmt.Errorf("assert_pdf reads a file (its value) or a url, not both")
+	}
+	if len(check.Contains) == 0 && len(check.NotContains) == 0 && check.Matches == "" &&
+		check.Pages == 0 && check.MinPages == 0 && check.MaxPages == 0 && check.Variable == "" {
+		return nil, fmt.Errorf("assert_pdf needs parameters.contains, not_contains, matches, pages, min_pages, max_pages or variable")
+	}
+	if _, err := regexp.Compile(check.Matches); err != nil {
+		return nil, fmt.Errorf("matches: %w", err)
+	}
+	if check.Page < 0 || check.Pages < 0 || check.MinPages < 0 || check.MaxPages < 0 {
+		return nil, fmt.Errorf("page, pages, min_pages and max_pages must not be negative")
+	}
+	if check.MaxPages > 0 && check.MinPages > check.MaxPages {
+		return nil, fmt.Errorf("min_pages %d is above max_pages %d", check.MinPages, check.MaxPages)
+	}
+	if check.Variable != "" && !variableName.MatchString(check.Variable) {
+		return nil, fmt.Errorf("invalid variable name %q", check.Variable)
+	}
+	return check, nil
+}
+
+// validatePDFAction checks the parameters of save_pdf and assert_pdf actions
+func (c *Config) validatePDFAction(action Action) error {
+	switch action.Type {
+	case "save_pdf":
+		_, err := action.PDFPrint()
+		return err
+	case "assert_pdf":
+		_, err := action.PDFCheck()
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,59 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAction_PDFPrint(t *testing.T) {
+	action := Action{Name: "invoice", Type: "save_pdf"}
+	spec, err := action.PDFPrint()
+	require.NoError(t, err)
+	assert.Equal(t, &PDFPrint{Format: "letter", Scale: 1}, spec)
+
+	action = Action{Name: "invoice", Type: "save_pdf", Value: "out/invoice.pdf",
+		Parameters: map[string]interface{}{"format": "A4", "landscape": true, "print_background": true, "page_ranges": "1-2", "variable": "invoice_pdf"}}
+	spec, err = action.PDFPrint()
+	require.NoError(t, err)
+	assert.Equal(t, &PDFPrint{File: "out/invoice.pdf", Format: "a4", Landscape: true, PrintBackground: true, Scale: 1, PageRanges: "1-2", Variable: "invoice_pdf"}, spec)
+	width, height := spec.Paper()
+	assert.Equal(t, [2]float64{11.69, 8.27}, [2]float64{width, height})
+
+	for want, params := range map[string]map[string]interface{}{
+		`unknown paper format "b5"`:    {"format": "b5"},
+		"scale must be between":        {"scale": 3},
+		`invalid variable name "a b"`:  {"variable": "a b"},
+		"invalid save_pdf parameters:": {"landscape": "sideways"},
+	} {
+		action := Action{Name: "invoice", Type: "save_pdf", Parameters: params}
+		_, err := action.PDFPrint()
+		assert.ErrorContains(t, err, want)
+	}
+}
+
+func TestAction_PDFCheck(t *testing.T) {
+	action := Action{Name: "invoice", Type: "assert_pdf", Value: "downloads/invoice.pdf",
+		Parameters: map[string]interface{}{"contains": []string{"Invoice #1042", "Total"}, "pages": 2}}
+	check, err := action.PDFCheck()
+	require.NoError(t, err)
+	assert.Equal(t, &PDFCheck{File: "downloads/invoice.pdf", Contains: []string{"Invoice #1042", "Total"}, Pages: 2}, check)
+
+	cases := []struct {
+		value  string
+		params map[string]interface{}
+		want   string
+	}{
+		{"", nil, "assert_pdf needs parameters.contains"},
+		{"a.pdf", map[string]interface{}{"url": "https://shop.test/a.pdf", "pages": 1}, "a file (its value) or a url, not both"},
+		{"", map[string]interface{}{"matches": "("}, "matches:"},
+		{"", map[string]interface{}{"min_pages": 3, "max_pages": 2}, "min_pages 3 is above max_pages 2"},
+		{"", map[string]interface{}{"pages": -1}, "must not be negative"},
+	}
+	for _, c := range cases {
+		cfg := &Config{Apps: []AppConfig{{Name: "Shop", Type: "web", URL: "https://shop.example.com",
+			Actions: []Action{{Name: "invoice", Type: "assert_pdf", Value: c.value, Parameters: c.params}}}}}
+		assert.ErrorContains(t, cfg.Validate(), c.want)
+	}
+}
@@ -0,0 +1,193 @@
+package config
+
+import (
+	"fmt"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// DefaultServiceWorkerTimeout is how long assert_service_worker waits for a
+// worker to register and reach its state
+const DefaultServiceWorkerTimeout = 10 * time.Second
+
+// ServiceWorkerStates are the states of a service worker, in order
+var ServiceWorkerStates = []string{"installing", "installed", "activating", "activated", "redundant"}
+
+// ServiceWorkerControl is the parameters of unregister_service_worker and
+// update_service_worker: the registrations whose scope contains Scope, every
+// one of the page's origin by default, are unregistered or fetch their
+// script again
+type ServiceWorkerControl struct {
+	Scope       string `yaml:"scope"`
+	ClearCaches bool   `yaml:"clear_caches"` // unregister_service_worker deletes the origin's Cache Storage too
+}
+
+// ServiceWorkerCheck is an assert_service_worker action's parameters: a
+// worker whose scope and script URL contain Scope and Script must reach
+// State within Timeout or, when Registered is false, none may be registered
+type ServiceWorkerCheck struct {
+	Scope      string        `yaml:"scope"`
+	Script     string        `yaml:"script"`
+	State      string        `yaml:"state"` // activated by default
+	Registered *bool         `yaml:"registered"`
+	Timeout    time.Duration `yaml:"timeout"`
+}
+
+// ExpectsRegistered tells whether a matching worker must be registered
+func (c *ServiceWorkerCheck) ExpectsRegistered() bool {
+	return c.Registered == nil || *c.Registered
+}
+
+// CacheCheck is an assert_cache action's parameters: a Cache Storage cache
+// whose name contains Cache must hold at least MinEntries requests, and one
+// whose URL contains each of Contains
+type CacheCheck struct {
+	Cache      string   `yaml:"cache"`
+	Contains   []string `yaml:"contains"`
+	MinEntries int      `yaml:"min_entries"`
+}
+
+// InstallabilityCheck is an assert_installable action's parameters: the
+// browser must offer to install the page as an app, but for the
+// installability errors listed in Ignore
+type InstallabilityCheck struct {
+	Ignore []string `yaml:"ignore"` // error IDs, e.g. manifest-missing-suitable-icon
+	Name   string   `yaml:"name"`   // part of the manifest's name
+}
+
+// OfflineFallback is an assert_offline_fallback action's parameters: with
+// the network offline, the action's url, or the current page reloaded,
+// must show the element of its selector and the texts of Contains, as the
+// service worker's offline page would. The network conditions are restored
+// afterwards.
+type OfflineFallback struct {
+	URL      string   `yaml:"-"`
+	Selector string   `yaml:"-"`
+	Contains []string `yaml:"contains"`
+}
+
+// pwaParameters reads the parameters of a PWA action into v
+func (a *Action) pwaParameters(v interface{}) error {
+	data, err := yaml.Marshal(a.Parameters)
+	if err != nil {
+		return err
+	}
+	if err := yaml.Unmarshal(data, v); err != nil {
+		return fmt.Errorf("invalid %s parameters: %w", a.Type, err)
+	}
+	return nil
+}
+
+// ServiceWorkerControl reads the parameters of an unregister_service_worker
+// or update_service_worker action
+func (a *Action) ServiceWorkerControl() (*ServiceWorkerControl, error) {
+	control := &ServiceWorkerControl{}
+	if err := a.pwaParameters(control); err != nil {
+		return nil, err
+	}
+	if control.ClearCaches && a.Type != "unregister_service_worker" {
+		return nil, fmt.Errorf("clear_caches is only supported on unregister_service_worker")
+	}
+	return control, nil
+}
+
+// ServiceWorkerCheck reads an assert_service_worker action's parameters
+func (a *Action) ServiceWorkerCheck() (*ServiceWorkerCheck, error) {
+	check := &ServiceWorkerCheck{}
+	if err := a.pwaParameters(check); err != nil {
+		return nil, err
+	}
+	if !check.ExpectsRegistered() && check.State != "" {
+		return nil, fmt.Errorf("state can't be checked when registered is false")
+	}
+	if check.State == "" && check.ExpectsRegistered() {
+		check.State = "activated"
+	}
+	if check.State != "" && serviceWorkerStateIndex(check.State) < 0 {
+		return nil, fmt.Errorf("unknown service worker state %q; use one of %v", check.State, ServiceWorkerStates)
+	}
+	if check.Timeout < 0 {
+		return nil, fmt.Errorf("timeout must not be negative")
+	}
+	if check.Timeout == 0 {
+		check.Timeout = DefaultServiceWorkerTimeout
+	}
+	return check, nil
+}
+
+// Reached tells whether a worker in state has reached the check's state:
+// a later state counts, except redundant, which only counts when expected
+func (c *ServiceWorkerCheck) Reached(state string) bool {
+	if state == "redundant" || c.State == "redundant" {
+		return state == c.State
+	}
+	i := serviceWorkerStateIndex(state)
+	return i >= 0 && i >= serviceWorkerStateIndex(c.State)
+}
+
+func serviceWorkerStateIndex(state string) int {
+	for i, s := range ServiceWorkerStates {
+		if s == state {
+			return i
+		}
+	}
+	return -1
+}
+
+// CacheCheck reads an assert_cache action's parameters
+func (a *Action) CacheCheck() (*CacheCheck, error) {
+	check := &CacheCheck{}
+	if err := a.pwaParameters(check); err != nil {
+		return nil, err
+	}
+	if check.MinEntries < 0 {
+		return nil, fmt.Errorf("min_entries must not be negative")
+	}
+	return check, nil
+}
+
+// InstallabilityCheck reads an assert_installable action's parameters
+func (a *Action) InstallabilityCheck() (*InstallabilityCheck, error) {
+	check := &InstallabilityCheck{}
+	if err := a.pwaParameters(check); err != nil {
+		return nil, err
+	}
+	return check, nil
+}
+
+// OfflineFallback reads an assert_offline_fallback action's parameters
+func (a *Action) OfflineFallback() (*OfflineFallback, error) {
+	fallback := &OfflineFallback{}
+	if err := a.pwaParameters(fallback); err != nil {
+		return nil, err
+	}
+	fallback.URL = a.URL
+	fallback.Selector = a.Selector
+	if fallback.Selector == "" {
+		fallback.Selector = a.Target
+	}
+	if fallback.Selector == "" && len(fallback.Contains) == 0 {
+		return nil, fmt.Errorf("assert_offline_fallback needs a selector or parameters.contains")
+	}
+	return fallback, nil
+}
+
+// validatePWAAction checks the parameters of the service worker, cache and
+// installability actions
+func (c *Config) validatePWAAction(action Action) error {
+	var err error
+	switch action.Type {
+	case "unregister_service_worker", "update_service_worker":
+		_, err = action.ServiceWorkerControl()
+	case "assert_service_worker":
+		_, err = action.ServiceWorkerCheck()
+	case "assert_cache":
+		_, err = action.CacheCheck()
+	case "assert_installable":
+		_, err = action.InstallabilityCheck()
+	case "assert_offline_fallback":
+		_, err = action.OfflineFallback()
+	}
+	return err
+}
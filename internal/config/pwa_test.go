@@ -0,0 +1,60 @@
+package config
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAction_ServiceWorkerCheck(t *testing.T) {
+	check, err := (&Action{Name: "sw", Type: "assert_service_worker"}).ServiceWorkerCheck()
+	require.NoError(t, err)
+	assert.Equal(t, &ServiceWorkerCheck{State: "activated", Timeout: DefaultServiceWorkerTimeout}, check)
+	assert.True(t, check.ExpectsRegistered())
+	assert.True(t, check.Reached("activated"))
+	assert.False(t, check.Reached("installed"))
+	assert.False(t, check.Reached("redundant"))
+
+	check, err = (&Action{Name: "sw", Type: "assert_service_worker", Parameters: map[string]interface{}{
+		"scope": "/app/", "script": "sw.js", "state": "installed", "timeout": "30s",
+	}}).ServiceWorkerCheck()
+	require.NoError(t, err)
+	assert.Equal(t, 30*time.Second, check.Timeout)
+	assert.True(t, check.Reached("activating"))
+	assert.False(t, check.Reached("installing"))
+
+	check, err = (&Action{Name: "gone", Type: "assert_service_worker", Parameters: map[string]interface{}{"registered": false}}).ServiceWorkerCheck()
+	require.NoError(t, err)
+	assert.False(t, check.ExpectsRegistered())
+	assert.Empty(t, check.State)
+}
+
+func TestAction_OfflineFallback(t *testing.T) {
+	fallback, err := (&Action{Name: "offline", Type: "assert_offline_fallback", URL: "https://app.test/news", Selector: "#offline",
+		Parameters: map[string]interface{}{"contains": []string{"You're offline"}}}).OfflineFallback()
+	require.NoError(t, err)
+	assert.Equal(t, &OfflineFallback{URL: "https://app.test/news", Selector: "#offline", Contains: []string{"You're offline"}}, fallback)
+}
+
+func TestConfig_ValidatePWAAction(t *testing.T) {
+	cases := []struct {
+		action Action
+		want   string
+	}{
+		{Action{Type: "update_service_worker", Parameters: map[string]interface{}{"clear_caches": true}}, "clear_caches is only supported on unregister_service_worker"},
+		{Action{Type: "unregister_service_worker", Parameters: map[string]interface{}{"scope": []string{"a"}}}, "invalid unregister_service_worker parameters"},
+		{Action{Type: "assert_service_worker", Parameters: map[string]interface{}{"state": "running"}}, `unknown service worker state "running"`},
+		{Action{Type: "assert_service_worker", Parameters: map[string]interface{}{"registered": false, "state": "activated"}}, "state can't be checked when registered is false"},
+		{Action{Type: "assert_service_worker", Parameters: map[string]interface{}{"timeout": "-1s"}}, "timeout must not be negative"},
+		{Action{Type: "assert_cache", Parameters: map[string]interface{}{"min_entries": -1}}, "min_entries must not be negative"},
+		{Action{Type: "assert_installable", Parameters: map[string]interface{}{"ignore": "no-manifest"}}, "invalid assert_installable parameters"},
+		{Action{Type: "assert_offline_fallback"}, "assert_offline_fallback needs a selector or parameters.contains"},
+	}
+	for _, c := range cases {
+		c.action.Name = "pwa"
+		cfg := &Config{Apps: []AppConfig{{Name: "News", Type: "web", URL: "https://news.example.com", Actions: []Action{c.action}}}}
+		assert.ErrorContains(t, cfg.Validate(), c.want, c.action.Type)
+	}
+}
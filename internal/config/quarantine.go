@@ -0,0 +1,48 @@
+package config
+
+import (
+	"fmt"
+	"time"
+)
+
+// Quarantine marks a known-broken app or action. While active, its failures
+// are still executed and reported but don't fail the run; after Until the
+// quarantine lapses on its own, so it can't silently outlive the fix.
+type Quarantine struct {
+	Reason string `yaml:"reason"`
+	Until  string `yaml:"until"` // last quarantined day (YYYY-MM-DD, local time) or an RFC 3339 instant
+}
+
+// Expiry returns the instant the quarantine lapses
+func (q *Quarantine) Expiry() (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, q.Until); err == nil {
+		return t, nil
+	}
+	day, err := time.ParseInLocation("2006-01-02", q.Until, time.Local)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid quarantine expiry %q: use YYYY-MM-DD or RFC 3339", q.Until)
+	}
+	return day.AddDate(0, 0, 1), nil
+}
+
+// Active reports whether the quarantine is configured and not yet expired
+func (q *Quarantine) Active(now time.Time) bool {
+	if q == nil {
+		return false
+	}
+	expiry, err := q.Expiry()
+	return err == nil && now.Before(expiry)
+}
+
+// Validate requires an expiry, so every quarantine lapses eventually; a nil
+// quarantine is valid
+func (q *Quarantine) Validate() error {
+	if q == nil {
+		return nil
+	}
+	if q.Until == "" {
+		return fmt.Errorf("quarantine requires an until date")
+	}
+	_, err := q.Expiry()
+	return err
+}
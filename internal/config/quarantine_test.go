@@ -0,0 +1,57 @@
+package config
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+// TestQuarantine_Active tests that quarantines lapse after their expiry
+func TestQuarantine_Active(t *testing.T) {
+	q := &Quarantine{Reason: "flaky", Until: "2026-03-31"}
+	expiry, err := q.Expiry()
+	require.NoError(t, err)
+	assert.Equal(t, time.Date(2026, 4, 1, 0, 0, 0, 0, time.Local), expiry, "the until day is still quarantined")
+
+	assert.True(t, q.Active(time.Date(2026, 3, 31, 23, 0, 0, 0, time.Local)))
+	assert.False(t, q.Active(time.Date(2026, 4, 1, 0, 0, 0, 0, time.Local)))
+
+	instant := &Quarantine{Until: "2026-03-31T12:00:00Z"}
+	assert.True(t, instant.Active(time.Date(2026, 3, 31, 11, 0, 0, 0, time.UTC)))
+	assert.False(t, instant.Active(time.Date(2026, 3, 31, 12, 0, 0, 0, time.UTC)))
+
+	var none *Quarantine
+	assert.False(t, none.Active(time.Now()))
+	assert.False(t, (&Quarantine{Until: "soon"}).Active(time.Now()))
+}
+
+// TestQuarantine_Validate tests that an expiry is required
+func TestQuarantine_Validate(t *testing.T) {
+	var none *Quarantine
+	assert.NoError(t, none.Validate())
+	assert.NoError(t, (&Quarantine{Until: "2026-01-02"}).Validate())
+	assert.ErrorContains(t, (&Quarantine{Reason: "flaky"}).Validate(), "until")
+	assert.ErrorContains(t, (&Quarantine{Until: "next week"}).Validate(), "invalid quarantine expiry")
+
+	cfg := &Config{
+		Apps:    []AppConfig{{Name: "Shop", Type: "web", URL: "https://example.com"}},
+		Actions: []Action{{Name: "pay", Type: "click", Quarantine: &Quarantine{Reason: "flaky"}}},
+	}
+	assert.ErrorContains(t, cfg.Validate(), "action pay")
+
+	cfg.Actions = nil
+	cfg.Apps[0].Quarantine = &Quarantine{Until: "bad"}
+	assert.ErrorContains(t, cfg.Validate(), "app Shop")
+}
+
+// TestQuarantine_YAML tests that unquoted dates load
+func TestQuarantine_YAML(t *testing.T) {
+	var app AppConfig
+	require.NoError(t, yaml.Unmarshal([]byte("name: shop\nquarantine:\n  reason: checkout flake\n  until: 2026-11-30\n"), &app))
+	require.NotNil(t, app.Quarantine)
+	assert.Equal(t, "checkout flake", app.Quarantine.Reason)
+	assert.Equal(t, "2026-11-30", app.Quarantine.Until)
+}
@@ -0,0 +1,40 @@
+package config
+
+import (
+	"fmt"
+	"time"
+)
+
+// RateLimitSettings keeps runs polite towards shared environments such as
+// staging: actions are paced, navigations in flight are capped and apps
+// against the same host are spaced out. Zero leaves a limit off.
+type RateLimitSettings struct {
+	ActionsPerSecond float64       `yaml:"actions_per_second"` // actions an app runs per second at most
+	MaxNavigations   int           `yaml:"max_navigations"`    // navigations in flight at once across the run
+	HostDelay        time.Duration `yaml:"host_delay"`         // from one app finishing to the next app against its host starting
+}
+
+// ActionInterval is the least time between two actions of an app
+func (r *RateLimitSettings) ActionInterval() time.Duration {
+	if r == nil || r.ActionsPerSecond <= 0 {
+		return 0
+	}
+	return time.Duration(float64(time.Second) / r.ActionsPerSecond)
+}
+
+// Validate checks that no limit is negative
+func (r *RateLimitSettings) Validate() error {
+	if r == nil {
+		return nil
+	}
+	if r.ActionsPerSecond < 0 {
+		return fmt.Errorf("actions_per_second can't be negative")
+	}
+	if r.MaxNavigations < 0 {
+		return fmt.Errorf("max_navigations can't be negative")
+	}
+	if r.HostDelay < 0 {
+		return fmt.Errorf("host_delay can't be negative")
+	}
+	return nil
+}
@@ -0,0 +1,31 @@
+package config
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRateLimitSettings_ActionInterval(t *testing.T) {
+	var unset *RateLimitSettings
+	assert.Zero(t, unset.ActionInterval())
+	assert.Zero(t, (&RateLimitSettings{MaxNavigations: 2}).ActionInterval())
+	assert.Equal(t, 250*time.Millisecond, (&RateLimitSettings{ActionsPerSecond: 4}).ActionInterval())
+	assert.Equal(t, 2*time.Second, (&RateLimitSettings{ActionsPerSecond: 0.5}).ActionInterval())
+}
+
+func TestRateLimitSettings_Validate(t *testing.T) {
+	var unset *RateLimitSettings
+	assert.NoError(t, unset.Validate())
+	assert.NoError(t, (&RateLimitSettings{ActionsPerSecond: 2, MaxNavigations: 1, HostDelay: time.Second}).Validate())
+	assert.ErrorContains(t, (&RateLimitSettings{ActionsPerSecond: -1}).Validate(), "actions_per_second can't be negative")
+	assert.ErrorContains(t, (&RateLimitSettings{MaxNavigations: -1}).Validate(), "max_navigations can't be negative")
+	assert.ErrorContains(t, (&RateLimitSettings{HostDelay: -time.Second}).Validate(), "host_delay can't be negative")
+
+	cfg := &Config{
+		Apps:     []AppConfig{{Name: "Shop", Type: "web", URL: "https://shop.example.com"}},
+		Settings: Settings{RateLimit: &RateLimitSettings{MaxNavigations: -2}},
+	}
+	assert.ErrorContains(t, cfg.Validate(), "settings.rate_limit: max_navigations can't be negative")
+}
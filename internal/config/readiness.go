@@ -0,0 +1,102 @@
+package config
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"time"
+)
+
+// Default readiness polling
+const (
+	DefaultWaitTimeout  = time.Minute
+	DefaultWaitInterval = time.Second
+)
+
+// WaitFor holds an app back until the services it depends on are up, so a run
+// started together with the app under test doesn't fail while it boots. With
+// no checks, the app's own URL is polled.
+type WaitFor struct {
+	Timeout  time.Duration    `yaml:"timeout"`  // give up after this long (default 1m); the app then fails as infrastructure
+	Interval time.Duration    `yaml:"interval"` // between attempts (default 1s)
+	Checks   []ReadinessCheck `yaml:"checks"`
+}
+
+// ReadinessCheck is one HTTP endpoint or TCP port that must be ready. An HTTP
+// check is ready on a status below 400, or exactly Status when it is set.
+type ReadinessCheck struct {
+	URL      string `yaml:"url"`      // http(s) URL, e.g. a health endpoint
+	TCP      string `yaml:"tcp"`      // host:port accepting connections
+	Status   int    `yaml:"status"`   // expected HTTP status
+	Contains string `yaml:"contains"` // text the HTTP response body must include
+}
+
+// TimeoutOrDefault returns the timeout, or DefaultWaitTimeout when unset
+func (w *WaitFor) TimeoutOrDefault() time.Duration {
+	if w.Timeout > 0 {
+		return w.Timeout
+	}
+	return DefaultWaitTimeout
+}
+
+// IntervalOrDefault returns the interval, or DefaultWaitInterval when unset
+func (w *WaitFor) IntervalOrDefault() time.Duration {
+	if w.Interval > 0 {
+		return w.Interval
+	}
+	return DefaultWaitInterval
+}
+
+// ChecksFor returns the checks for an app: the configured ones, or a check of
+// the app's URL
+func (w *WaitFor) ChecksFor(app AppConfig) []ReadinessCheck {
+	if len(w.Checks) > 0 {
+		return w.Checks
+	}
+	return []ReadinessCheck{{URL: app.URL}}
+}
+
+// Validate checks each readiness check of an app; a nil WaitFor is valid
+func (w *WaitFor) Validate(app AppConfig) error {
+	if w == nil {
+		return nil
+	}
+	if w.Timeout < 0 || w.Interval < 0 {
+		return fmt.Errorf("wait_for timeout and interval must not be negative")
+	}
+	if len(w.Checks) == 0 && app.URL == "" {
+		return fmt.Errorf("wait_for needs checks, or an app url to poll")
+	}
+	for i, check := range w.ChecksFor(app) {
+		if err := check.Validate(); err != nil {
+			return fmt.Errorf("wait_for check %d: %w", i, err)
+		}
+	}
+	return nil
+}
+
+// Validate requires exactly one of url or tcp
+func (c ReadinessCheck) Validate() error {
+	switch {
+	case c.URL != "" && c.TCP != "":
+		return fmt.Errorf("set url or tcp, not both")
+	case c.URL != "":
+		u, err := url.Parse(c.URL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("url must be an http(s) URL, got %q", c.URL)
+		}
+		if c.Status != 0 && (c.Status < 100 || c.Status > 599) {
+			return fmt.Errorf("status must be an HTTP status code, got %d", c.Status)
+		}
+	case c.TCP != "":
+		if _, _, err := net.SplitHostPort(c.TCP); err != nil {
+			return fmt.Errorf("tcp must be host:port, got %q", c.TCP)
+		}
+		if c.Status != 0 || c.Contains != "" {
+			return fmt.Errorf("status and contains only apply to url checks")
+		}
+	default:
+		return fmt.Errorf("set url or tcp")
+	}
+	return nil
+}
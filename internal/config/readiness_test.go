@@ -0,0 +1,56 @@
+package config
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+// TestWaitFor_Validate tests url and tcp checks and polling the app URL
+func TestWaitFor_Validate(t *testing.T) {
+	app := AppConfig{Name: "Shop", Type: "web", URL: "http://localhost:8080"}
+	assert.NoError(t, (*WaitFor)(nil).Validate(app))
+	assert.NoError(t, (&WaitFor{}).Validate(app), "polls the app url")
+	assert.ErrorContains(t, (&WaitFor{}).Validate(AppConfig{Name: "Desk", Type: "desktop"}), "needs checks")
+	assert.NoError(t, (&WaitFor{Checks: []ReadinessCheck{
+		{URL: "http://localhost:8080/health", Status: 200, Contains: "UP"},
+		{TCP: "localhost:5432"},
+	}}).Validate(app))
+
+	for want, check := range map[string]ReadinessCheck{
+		"set url or tcp":    {},
+		"not both":          {URL: "http://a", TCP: "a:1"},
+		"http(s) URL":       {URL: "ftp://a"},
+		"HTTP status code":  {URL: "http://a", Status: 999},
+		"host:port":         {TCP: "localhost"},
+		"only apply to url": {TCP: "a:1", Contains: "UP"},
+	} {
+		assert.ErrorContains(t, (&WaitFor{Checks: []ReadinessCheck{check}}).Validate(app), want)
+	}
+	assert.ErrorContains(t, (&WaitFor{Timeout: -time.Second}).Validate(app), "negative")
+
+	cfg := &Config{Apps: []AppConfig{{Name: "Shop", Type: "web", URL: "https://example.com", WaitFor: &WaitFor{Checks: []ReadinessCheck{{TCP: "db"}}}}}}
+	assert.ErrorContains(t, cfg.Validate(), "app Shop: wait_for check 0")
+}
+
+// TestWaitFor_YAML tests parsing and defaults
+func TestWaitFor_YAML(t *testing.T) {
+	var app AppConfig
+	require.NoError(t, yaml.Unmarshal([]byte(`
+name: Shop
+type: web
+url: http://localhost:8080
+wait_for:
+  timeout: 2m
+  checks:
+    - tcp: localhost:5432
+`), &app))
+	require.NotNil(t, app.WaitFor)
+	assert.Equal(t, 2*time.Minute, app.WaitFor.TimeoutOrDefault())
+	assert.Equal(t, DefaultWaitInterval, app.WaitFor.IntervalOrDefault())
+	assert.Equal(t, []ReadinessCheck{{TCP: "localhost:5432"}}, app.WaitFor.ChecksFor(app))
+	assert.Equal(t, []ReadinessCheck{{URL: app.URL}}, (&WaitFor{}).ChecksFor(app))
+}
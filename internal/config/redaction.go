@@ -0,0 +1,53 @@
+package config
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// RedactionSettings masks personal data in what a run writes. Matches of
+// patterns, regular expressions, are replaced in logs, traces and results;
+// so are the values filled into fields matching selectors, whose elements
+// are also blurred in web screenshots before they're saved or uploaded.
+type RedactionSettings struct {
+	Patterns    []string `yaml:"patterns,omitempty"`
+	Selectors   []string `yaml:"selectors,omitempty"`
+	Replacement string   `yaml:"replacement,omitempty"` // defaults to [REDACTED]
+}
+
+// Enabled tells whether anything is redacted
+func (r *RedactionSettings) Enabled() bool {
+	return r != nil && (len(r.Patterns) > 0 || len(r.Selectors) > 0)
+}
+
+// Masks tells whether values filled into selector are redacted
+func (r *RedactionSettings) Masks(selector string) bool {
+	if r == nil {
+		return false
+	}
+	for _, s := range r.Selectors {
+		if s == selector {
+			return true
+		}
+	}
+	return false
+}
+
+// Validate checks that patterns compile and selectors aren't empty
+func (r *RedactionSettings) Validate() error {
+	if r == nil {
+		return nil
+	}
+	for _, p := range r.Patterns {
+		if _, err := regexp.Compile(p); err != nil {
+			return fmt.Errorf("invalid pattern %q: %w", p, err)
+		}
+	}
+	for _, s := range r.Selectors {
+		if strings.TrimSpace(s) == "" {
+			return fmt.Errorf("selectors can't be empty")
+		}
+	}
+	return nil
+}
@@ -0,0 +1,34 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRedactionSettings_Enabled(t *testing.T) {
+	var unset *RedactionSettings
+	assert.False(t, unset.Enabled())
+	assert.False(t, unset.Masks("#card"))
+	assert.False(t, (&RedactionSettings{Replacement: "***"}).Enabled())
+	assert.True(t, (&RedactionSettings{Patterns: []string{`\d{16}`}}).Enabled())
+
+	masked := &RedactionSettings{Selectors: []string{"#card", ".email"}}
+	assert.True(t, masked.Enabled())
+	assert.True(t, masked.Masks("#card"))
+	assert.False(t, masked.Masks("#name"))
+}
+
+func TestRedactionSettings_Validate(t *testing.T) {
+	var unset *RedactionSettings
+	assert.NoError(t, unset.Validate())
+	assert.NoError(t, (&RedactionSettings{Patterns: []string{`[\w.]+@[\w.]+`}, Selectors: []string{"#card"}}).Validate())
+	assert.ErrorContains(t, (&RedactionSettings{Patterns: []string{"("}}).Validate(), `invalid pattern "("`)
+	assert.ErrorContains(t, (&RedactionSettings{Selectors: []string{" "}}).Validate(), "selectors can't be empty")
+
+	cfg := &Config{
+		Apps:     []AppConfig{{Name: "Shop", Type: "web", URL: "https://shop.example.com"}},
+		Settings: Settings{Redaction: &RedactionSettings{Patterns: []string{"[a-"}}},
+	}
+	assert.ErrorContains(t, cfg.Validate(), "settings.redaction: invalid pattern")
+}
@@ -0,0 +1,61 @@
+package config
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// ReportSettings brand the HTML report of a run, and the Slack and Teams
+// messages about it, for the organization running it. Colors are CSS hex
+// colors; unset ones keep the default dark theme.
+type ReportSettings struct {
+	Title           string `yaml:"title"`            // default "Panoptic Test Report"
+	Organization    string `yaml:"organization"`     // default the organization_name of settings.enterprise
+	Logo            string `yaml:"logo"`             // image file embedded in the report, or an https URL
+	PrimaryColor    string `yaml:"primary_color"`    // title and accents
+	BackgroundColor string `yaml:"background_color"` // page
+	SurfaceColor    string `yaml:"surface_color"`    // cards and tables
+	TextColor       string `yaml:"text_color"`
+	Footer          string `yaml:"footer"` // replaces the default footer line
+}
+
+// hexColor matches #rgb and #rrggbb colors
+var hexColor = regexp.MustCompile(`^#([0-9a-fA-F]{3}|[0-9a-fA-F]{6})$`)
+
+// LogoTypes are the MIME types of logo files by extension
+var LogoTypes = map[string]string{
+	".png":  "image/png",
+	".jpg":  "image/jpeg",
+	".jpeg": "image/jpeg",
+	".gif":  "image/gif",
+	".svg":  "image/svg+xml",
+	".webp": "image/webp",
+}
+
+// LogoIsURL tells whether the logo is linked rather than embedded
+func (s *ReportSettings) LogoIsURL() bool {
+	return strings.HasPrefix(s.Logo, "https://") || strings.HasPrefix(s.Logo, "http://")
+}
+
+// Validate checks the colors and the logo's type
+func (s *ReportSettings) Validate() error {
+	if s == nil {
+		return nil
+	}
+	for _, c := range []struct{ name, value string }{
+		{"primary_color", s.PrimaryColor}, {"background_color", s.BackgroundColor},
+		{"surface_color", s.SurfaceColor}, {"text_color", s.TextColor},
+	} {
+		if c.value != "" && !hexColor.MatchString(c.value) {
+			return fmt.Errorf("%s must be a hex color like #1a73e8, got %q", c.name, c.value)
+		}
+	}
+	if s.Logo != "" && !s.LogoIsURL() {
+		if _, ok := LogoTypes[strings.ToLower(filepath.Ext(s.Logo))]; !ok {
+			return fmt.Errorf("logo %s must be a PNG, JPEG, GIF, SVG or WebP file, or a URL", s.Logo)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,26 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReportSettings_Validate(t *testing.T) {
+	assert.NoError(t, (*ReportSettings)(nil).Validate())
+	assert.NoError(t, (&ReportSettings{
+		Title: "Nightly QA", Organization: "Acme", Logo: "branding/acme.SVG",
+		PrimaryColor: "#1a73e8", BackgroundColor: "#fff", SurfaceColor: "#f5f5f5", TextColor: "#202124",
+	}).Validate())
+	assert.NoError(t, (&ReportSettings{Logo: "https://cdn.acme.test/logo"}).Validate())
+
+	assert.EqualError(t, (&ReportSettings{PrimaryColor: "blue"}).Validate(), `primary_color must be a hex color like #1a73e8, got "blue"`)
+	assert.EqualError(t, (&ReportSettings{TextColor: "#12345"}).Validate(), `text_color must be a hex color like #1a73e8, got "#12345"`)
+	assert.EqualError(t, (&ReportSettings{Logo: "acme.bmp"}).Validate(), "logo acme.bmp must be a PNG, JPEG, GIF, SVG or WebP file, or a URL")
+
+	cfg := &Config{
+		Apps:     []AppConfig{{Name: "Shop", Type: "web", URL: "https://shop.example.com", Actions: []Action{{Name: "home", Type: "navigate", Value: "https://shop.example.com"}}}},
+		Settings: Settings{Report: &ReportSettings{SurfaceColor: "grey"}},
+	}
+	assert.ErrorContains(t, cfg.Validate(), "settings.report: surface_color must be a hex color")
+}
@@ -0,0 +1,36 @@
+package config
+
+import "fmt"
+
+// RetentionSettings bounds the artifacts a run keeps in the output
+// directory, like settings.cloud.retention_policy does for the bucket: the
+// screenshots, videos, traces and other files of old runs are removed when
+// a run starts. Zero leaves a bound off.
+type RetentionSettings struct {
+	MaxRuns    int     `yaml:"max_runs"`     // runs whose artifacts are kept, the starting one included
+	MaxSizeGB  float64 `yaml:"max_size_gb"`  // artifacts kept in total, the oldest removed first
+	MaxAgeDays int     `yaml:"max_age_days"` // artifacts older than this are removed
+	DryRun     bool    `yaml:"dry_run"`      // only log what would be removed
+}
+
+// Enabled reports whether any bound is set
+func (r *RetentionSettings) Enabled() bool {
+	return r != nil && (r.MaxRuns > 0 || r.MaxSizeGB > 0 || r.MaxAgeDays > 0)
+}
+
+// Validate checks that no bound is negative
+func (r *RetentionSettings) Validate() error {
+	if r == nil {
+		return nil
+	}
+	if r.MaxRuns < 0 {
+		return fmt.Errorf("max_runs can't be negative")
+	}
+	if r.MaxSizeGB < 0 {
+		return fmt.Errorf("max_size_gb can't be negative")
+	}
+	if r.MaxAgeDays < 0 {
+		return fmt.Errorf("max_age_days can't be negative")
+	}
+	return nil
+}
@@ -0,0 +1,31 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRetentionSettings_Enabled(t *testing.T) {
+	var unset *RetentionSettings
+	assert.False(t, unset.Enabled())
+	assert.False(t, (&RetentionSettings{DryRun: true}).Enabled())
+	assert.True(t, (&RetentionSettings{MaxRuns: 10}).Enabled())
+	assert.True(t, (&RetentionSettings{MaxSizeGB: 0.5}).Enabled())
+	assert.True(t, (&RetentionSettings{MaxAgeDays: 30}).Enabled())
+}
+
+func TestRetentionSettings_Validate(t *testing.T) {
+	var unset *RetentionSettings
+	assert.NoError(t, unset.Validate())
+	assert.NoError(t, (&RetentionSettings{MaxRuns: 5, MaxSizeGB: 2, MaxAgeDays: 14}).Validate())
+	assert.ErrorContains(t, (&RetentionSettings{MaxRuns: -1}).Validate(), "max_runs can't be negative")
+	assert.ErrorContains(t, (&RetentionSettings{MaxSizeGB: -1}).Validate(), "max_size_gb can't be negative")
+	assert.ErrorContains(t, (&RetentionSettings{MaxAgeDays: -1}).Validate(), "max_age_days can't be negative")
+
+	cfg := &Config{
+		Apps:     []AppConfig{{Name: "Shop", Type: "web", URL: "https://shop.example.com"}},
+		Settings: Settings{Retention: &RetentionSettings{MaxRuns: -2}},
+	}
+	assert.ErrorContains(t, cfg.Validate(), "settings.retention: max_runs can't be negative")
+}
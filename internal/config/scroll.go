@@ -0,0 +1,104 @@
+package config
+
+import (
+	"fmt"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	// DefaultScrollIterations bounds scroll_until actions without
+	// parameters.max_iterations
+	DefaultScrollIterations = 20
+	// MaxScrollIterations bounds parameters.max_iterations of scroll_until
+	MaxScrollIterations = 1000
+	// DefaultScrollInterval is how long scroll_until lets content load
+	// after each scroll
+	DefaultScrollInterval = 500 * time.Millisecond
+)
+
+// Scroll is a scroll action's parameters. scroll_to scrolls the element of
+// the action's selector into view, or to X and Y; scroll_by scrolls by X
+// and Y pixels; scroll_until scrolls by Step pixels, to the end of the page
+// when 0, until the action's selector matches an element, up to
+// MaxIterations times, waiting Interval after each scroll.
+type Scroll struct {
+	Selector      string        `yaml:"-"`
+	X             *float64      `yaml:"x"`
+	Y             *float64      `yaml:"y"`
+	Step          float64       `yaml:"step"`
+	MaxIterations int           `yaml:"max_iterations"`
+	Interval      time.Duration `yaml:"interval"`
+}
+
+// Coordinates are the scroll's X and Y, 0 when unset
+func (s *Scroll) Coordinates() (float64, float64) {
+	var x, y float64
+	if s.X != nil {
+		x = *s.X
+	}
+	if s.Y != nil {
+		y = *s.Y
+	}
+	return x, y
+}
+
+// Scroll reads the parameters of a scroll_to, scroll_by or scroll_until
+// action
+func (a *Action) Scroll() (*Scroll, error) {
+	scroll := &Scroll{}
+	data, err := yaml.Marshal(a.Parameters)
+	if err != nil {
+		return nil, err
+	}
+	if err := yaml.Unmarshal(data, scroll); err != nil {
+		return nil, fmt.Errorf("invalid %s parameters: %w", a.Type, err)
+	}
+	scroll.Selector = a.Selector
+	if scroll.Selector == "" {
+		scroll.Selector = a.Target
+	}
+	coordinates := scroll.X != nil || scroll.Y != nil
+
+	switch a.Type {
+	case "scroll_to":
+		if (scroll.Selector == "") == !coordinates {
+			return nil, fmt.Errorf("scroll_to needs a selector or parameters.x and parameters.y, not both")
+		}
+	case "scroll_by":
+		if x, y := scroll.Coordinates(); x == 0 && y == 0 {
+			return nil, fmt.Errorf("scroll_by needs parameters.x or parameters.y")
+		}
+	case "scroll_until":
+		if scroll.Selector == "" {
+			return nil, fmt.Errorf("scroll_until needs the selector of the element to scroll to")
+		}
+		if coordinates {
+			return nil, fmt.Errorf("scroll_until scrolls by parameters.step, not to parameters.x and parameters.y")
+		}
+		if scroll.MaxIterations < 0 || scroll.MaxIterations > MaxScrollIterations {
+			return nil, fmt.Errorf("max_iterations must be between 1 and %d, or 0 for the default of %d", MaxScrollIterations, DefaultScrollIterations)
+		}
+		if scroll.MaxIterations == 0 {
+			scroll.MaxIterations = DefaultScrollIterations
+		}
+		if scroll.Interval < 0 {
+			return nil, fmt.Errorf("interval can't be negative")
+		}
+		if scroll.Interval == 0 {
+			scroll.Interval = DefaultScrollInterval
+		}
+	}
+	return scroll, nil
+}
+
+// validateScrollAction checks the parameters of scroll actions
+func (c *Config) validateScrollAction(action Action) error {
+	switch action.Type {
+	case "scroll_to", "scroll_by", "scroll_until":
+		_, err := action.Scroll()
+		return err
+	}
+	return nil
+}
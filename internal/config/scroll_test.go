@@ -0,0 +1,50 @@
+package config
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAction_Scroll(t *testing.T) {
+	scroll, err := (&Action{Type: "scroll_to", Selector: "#reviews"}).Scroll()
+	require.NoError(t, err)
+	assert.Equal(t, "#reviews", scroll.Selector)
+
+	scroll, err = (&Action{Type: "scroll_to", Parameters: map[string]interface{}{"y": 0}}).Scroll()
+	require.NoError(t, err)
+	x, y := scroll.Coordinates()
+	assert.Equal(t, []float64{0, 0}, []float64{x, y}, "scrolling back to the top")
+
+	scroll, err = (&Action{Type: "scroll_by", Parameters: map[string]interface{}{"y": 600}}).Scroll()
+	require.NoError(t, err)
+	x, y = scroll.Coordinates()
+	assert.Equal(t, []float64{0, 600}, []float64{x, y})
+
+	scroll, err = (&Action{Type: "scroll_until", Target: ".product:nth-child(60)"}).Scroll()
+	require.NoError(t, err)
+	assert.Equal(t, &Scroll{Selector: ".product:nth-child(60)", MaxIterations: DefaultScrollIterations, Interval: DefaultScrollInterval}, scroll)
+
+	scroll, err = (&Action{Type: "scroll_until", Selector: "footer", Parameters: map[string]interface{}{"step": 400, "max_iterations": 50, "interval": "1s"}}).Scroll()
+	require.NoError(t, err)
+	assert.Equal(t, &Scroll{Selector: "footer", Step: 400, MaxIterations: 50, Interval: time.Second}, scroll)
+
+	cases := []struct {
+		action Action
+		want   string
+	}{
+		{Action{Type: "scroll_to"}, "scroll_to needs a selector or parameters.x and parameters.y, not both"},
+		{Action{Type: "scroll_to", Selector: "#reviews", Parameters: map[string]interface{}{"y": 10}}, "not both"},
+		{Action{Type: "scroll_by"}, "scroll_by needs parameters.x or parameters.y"},
+		{Action{Type: "scroll_until"}, "scroll_until needs the selector"},
+		{Action{Type: "scroll_until", Selector: "footer", Parameters: map[string]interface{}{"y": 10}}, "scrolls by parameters.step"},
+		{Action{Type: "scroll_until", Selector: "footer", Parameters: map[string]interface{}{"max_iterations": 5000}}, "max_iterations must be between 1 and 1000"},
+	}
+	for _, c := range cases {
+		c.action.Name = "scroll"
+		cfg := &Config{Apps: []AppConfig{{Name: "Shop", Type: "web", URL: "https://shop.example.com", Actions: []Action{c.action}}}}
+		assert.ErrorContains(t, cfg.Validate(), c.want)
+	}
+}
@@ -0,0 +1,177 @@
+package config
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// SecretsSettings is where login and totp actions and
+// {{secret.<name>.<field>}} placeholders read credentials at execution time. Vault's address and
+// token default to VAULT_ADDR and VAULT_TOKEN; AWS credentials and region
+// come from the standard AWS_* environment variables.
+type SecretsSettings struct {
+	Provider  string `yaml:"provider"`   // env, vault or aws
+	URL       string `yaml:"url"`        // Vault address, or an AWS endpoint override such as LocalStack
+	Token     string `yaml:"token"`      // Vault token, expanded from the environment (default "${VAULT_TOKEN}")
+	Namespace string `yaml:"namespace"`  // Vault Enterprise namespace
+	Mount     string `yaml:"mount"`      // Vault KV engine mount (default "secret")
+	KVVersion int    `yaml:"kv_version"` // Vault KV engine version, 1 or 2 (default 2)
+	Region    string `yaml:"region"`     // AWS region (default AWS_REGION)
+}
+
+// Validate checks the provider and its options
+func (s *SecretsSettings) Validate() error {
+	if s == nil {
+		return nil
+	}
+	switch s.Provider {
+	case "env", "aws":
+	case "vault":
+		if s.KVVersion != 0 && s.KVVersion != 1 && s.KVVersion != 2 {
+			return fmt.Errorf("kv_version must be 1 or 2, got %d", s.KVVersion)
+		}
+	default:
+		return fmt.Errorf("provider must be env, vault or aws, got %q", s.Provider)
+	}
+	return nil
+}
+
+// SecretPattern matches {{secret.<name>.<field>}} placeholders; names may
+// hold slashes, e.g. {{secret.shop/admin.password}}
+var SecretPattern = regexp.MustCompile(`\{\{\s*secret\.([A-Za-z0-9_./-]+)\.([A-Za-z0-9_-]+)\s*\}\}`)
+
+// Login is a login action's parameters: the secret holding the credentials,
+// the fields they're filled into, and the submit button, the form's submit
+// button when empty. UsernameKey and PasswordKey name the secret's fields.
+type Login struct {
+	Secret           string `yaml:"secret"`
+	UsernameSelector string `yaml:"username_selector"`
+	PasswordSelector string `yaml:"password_selector"`
+	SubmitSelector   string `yaml:"submit_selector"`
+	UsernameKey      string `yaml:"username_key"` // default username
+	PasswordKey      string `yaml:"password_key"` // default password
+}
+
+// Login reads a login action's parameters; the secret defaults to the
+// action's target
+func (a *Action) Login() (*Login, error) {
+	login := &Login{}
+	data, err := yaml.Marshal(a.Parameters)
+	if err != nil {
+		return nil, err
+	}
+	if err := yaml.Unmarshal(data, login); err != nil {
+		return nil, fmt.Errorf("invalid login parameters: %w", err)
+	}
+	if login.Secret == "" {
+		login.Secret = a.Target
+	}
+	if strings.Trim(login.Secret, "/") == "" {
+		return nil, fmt.Errorf("login needs the secret as target or parameters.secret")
+	}
+	if login.UsernameSelector == "" || login.PasswordSelector == "" {
+		return nil, fmt.Errorf("login needs username_selector and password_selector")
+	}
+	if login.UsernameKey == "" {
+		login.UsernameKey = "username"
+	}
+	if login.PasswordKey == "" {
+		login.PasswordKey = "password"
+	}
+	return login, nil
+}
+
+// validateLoginAction checks login and totp actions, and actions with
+// secret placeholders, have a secrets provider to read
+func (c *Config) validateLoginAction(action Action) error {
+	switch action.Type {
+	case "login", "totp":
+		if c.Settings.Secrets == nil {
+			return fmt.Errorf("%s requires settings.secrets", action.Type)
+		}
+		var err error
+		if action.Type == "login" {
+			_, err = action.Login()
+		} else {
+			_, err = action.TOTP()
+		}
+		return err
+	}
+	if c.Settings.Secrets == nil {
+		for _, field := range []string{action.URL, action.Target, action.Value, action.Selector} {
+			if SecretPattern.MatchString(field) {
+				return fmt.Errorf("{{secret.*}} placeholders require settings.secrets")
+			}
+		}
+	}
+	return nil
+}
+
+// DefaultTOTPMinValidity is how long a totp code must stay valid; closer to
+// the end of its period, the next code is waited for
+const DefaultTOTPMinValidity = 5 * time.Second
+
+// TOTP is a totp action's parameters: the secret whose Key field holds the
+// shared TOTP secret, a base32 key or otpauth:// URI, and the variable the
+// current code is stored in for {{var.<name>}}. Digits, Period and
+// Algorithm override those of an otpauth:// URI; the defaults are those of
+// authenticator apps, 6 digits every 30s with SHA1.
+type TOTP struct {
+	Secret      string        `yaml:"secret"`
+	Key         string        `yaml:"key"`      // default totp
+	Variable    string        `yaml:"variable"` // default otp
+	Digits      int           `yaml:"digits"`
+	Period      time.Duration `yaml:"period"`
+	Algorithm   string        `yaml:"algorithm"`    // SHA1, SHA256 or SHA512
+	MinValidity time.Duration `yaml:"min_validity"` // default 5s
+}
+
+// TOTP reads a totp action's parameters; the secret defaults to the
+// action's target
+func (a *Action) TOTP() (*TOTP, error) {
+	totp := &TOTP{}
+	data, err := yaml.Marshal(a.Parameters)
+	if err != nil {
+		return nil, err
+	}
+	if err := yaml.Unmarshal(data, totp); err != nil {
+		return nil, fmt.Errorf("invalid totp parameters: %w", err)
+	}
+	if totp.Secret == "" {
+		totp.Secret = a.Target
+	}
+	if strings.Trim(totp.Secret, "/") == "" {
+		return nil, fmt.Errorf("totp needs the secret as target or parameters.secret")
+	}
+	if totp.Key == "" {
+		totp.Key = "totp"
+	}
+	if totp.Variable == "" {
+		totp.Variable = "otp"
+	}
+	if !variableName.MatchString(totp.Variable) {
+		return nil, fmt.Errorf("invalid variable name %q", totp.Variable)
+	}
+	if totp.Digits != 0 && (totp.Digits < 6 || totp.Digits > 10) {
+		return nil, fmt.Errorf("digits must be between 6 and 10, got %d", totp.Digits)
+	}
+	if totp.Period < 0 || totp.MinValidity < 0 {
+		return nil, fmt.Errorf("period and min_validity must not be negative")
+	}
+	switch strings.ToUpper(totp.Algorithm) {
+	case "", "SHA1", "SHA256", "SHA512":
+	default:
+		return nil, fmt.Errorf("algorithm must be SHA1, SHA256 or SHA512, got %q", totp.Algorithm)
+	}
+	if totp.MinValidity == 0 {
+		totp.MinValidity = DefaultTOTPMinValidity
+	}
+	if totp.Period != 0 && totp.MinValidity >= totp.Period {
+		return nil, fmt.Errorf("min_validity must be shorter than period")
+	}
+	return totp, nil
+}
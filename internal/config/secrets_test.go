@@ -0,0 +1,90 @@
+package config
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSecretsSettings_Validate(t *testing.T) {
+	var unset *SecretsSettings
+	assert.NoError(t, unset.Validate())
+	assert.NoError(t, (&SecretsSettings{Provider: "env"}).Validate())
+	assert.NoError(t, (&SecretsSettings{Provider: "vault", KVVersion: 1}).Validate())
+	assert.NoError(t, (&SecretsSettings{Provider: "aws", Region: "eu-west-1"}).Validate())
+	assert.ErrorContains(t, (&SecretsSettings{Provider: "vault", KVVersion: 3}).Validate(), "kv_version must be 1 or 2")
+	assert.ErrorContains(t, (&SecretsSettings{Provider: "1password"}).Validate(), "provider must be env, vault or aws")
+}
+
+func TestAction_Login(t *testing.T) {
+	action := Action{Name: "sign_in", Type: "login", Target: "shop/admin", Parameters: map[string]interface{}{
+		"username_selector": "#email",
+		"password_selector": "#password",
+	}}
+	login, err := action.Login()
+	require.NoError(t, err)
+	assert.Equal(t, &Login{Secret: "shop/admin", UsernameSelector: "#email", PasswordSelector: "#password",
+		UsernameKey: "username", PasswordKey: "password"}, login)
+
+	action.Parameters["secret"] = "shop/support"
+	action.Parameters["username_key"] = "email"
+	login, err = action.Login()
+	require.NoError(t, err)
+	assert.Equal(t, "shop/support", login.Secret)
+	assert.Equal(t, "email", login.UsernameKey)
+
+	_, err = (&Action{Type: "login", Parameters: map[string]interface{}{"username_selector": "#email", "password_selector": "#pw"}}).Login()
+	assert.ErrorContains(t, err, "login needs the secret")
+	_, err = (&Action{Type: "login", Target: "shop/admin"}).Login()
+	assert.ErrorContains(t, err, "login needs username_selector and password_selector")
+}
+
+func TestValidate_LoginAction(t *testing.T) {
+	login := Action{Name: "sign_in", Type: "login", Target: "shop/admin", Parameters: map[string]interface{}{
+		"username_selector": "#email", "password_selector": "#password",
+	}}
+	fill := Action{Name: "card", Type: "fill", Selector: "#card", Value: "{{secret.shop/cards.visa}}"}
+	cfg := &Config{Apps: []AppConfig{{Name: "Shop", Type: "web", URL: "https://shop.example.com", Actions: []Action{login, fill}}}}
+	err := cfg.Validate()
+	assert.ErrorContains(t, err, "login requires settings.secrets")
+	assert.ErrorContains(t, err, "{{secret.*}} placeholders require settings.secrets")
+
+	cfg.Settings.Secrets = &SecretsSettings{Provider: "vault"}
+	assert.NoError(t, cfg.Validate())
+
+	m := SecretPattern.FindStringSubmatch("{{ secret.shop/admin.password }}")
+	assert.Equal(t, []string{"{{ secret.shop/admin.password }}", "shop/admin", "password"}, m)
+	m = SecretPattern.FindStringSubmatch("{{secret.prod.db.password}}")
+	assert.Equal(t, "prod.db", m[1])
+}
+
+func TestAction_TOTP(t *testing.T) {
+	action := Action{Name: "otp", Type: "totp", Target: "shop/admin"}
+	totp, err := action.TOTP()
+	require.NoError(t, err)
+	assert.Equal(t, &TOTP{Secret: "shop/admin", Key: "totp", Variable: "otp", MinValidity: DefaultTOTPMinValidity}, totp)
+
+	action.Parameters = map[string]interface{}{"key": "mfa", "variable": "code", "digits": 8, "period": "60s", "algorithm": "sha256"}
+	totp, err = action.TOTP()
+	require.NoError(t, err)
+	assert.Equal(t, "mfa", totp.Key)
+	assert.Equal(t, "code", totp.Variable)
+	assert.Equal(t, 8, totp.Digits)
+	assert.Equal(t, time.Minute, totp.Period)
+
+	for want, params := range map[string]map[string]interface{}{
+		"totp needs the secret":                    {"secret": "/"},
+		`invalid variable name "a b"`:              {"variable": "a b"},
+		"digits must be between 6 and 10":          {"digits": 4},
+		"algorithm must be SHA1, SHA256":           {"algorithm": "md5"},
+		"min_validity must be shorter than period": {"period": "10s", "min_validity": "10s"},
+	} {
+		_, err := (&Action{Type: "totp", Target: "shop/admin", Parameters: params}).TOTP()
+		assert.ErrorContains(t, err, want)
+	}
+
+	cfg := &Config{Apps: []AppConfig{{Name: "Shop", Type: "web", URL: "https://shop.example.com", Actions: []Action{action}}}}
+	assert.ErrorContains(t, cfg.Validate(), "totp requires settings.secrets")
+}
@@ -0,0 +1,87 @@
+package config
+
+import "fmt"
+
+// SLO metrics besides the custom metrics actions record
+const (
+	SLODuration    = "duration"     // how long an app took, in ms
+	SLOSuccessRate = "success_rate" // percent of results that passed
+)
+
+// Default SLO baseline windows
+const (
+	DefaultSLOBaselineRuns = 10
+	DefaultSLOMinRuns      = 3
+)
+
+// SLOSettings gate a run on its metrics: every objective compares a metric
+// of the run with its baseline, the median over the recent runs in the run
+// history, and fails the run, or with severity warning only reports it,
+// when the metric got worse than max_regression allows.
+type SLOSettings struct {
+	BaselineRuns int            `yaml:"baseline_runs"` // recent runs the baseline is taken from; default 10
+	MinRuns      int            `yaml:"min_runs"`      // runs with the metric a baseline needs before it's checked; default 3
+	Objectives   []SLOObjective `yaml:"objectives"`
+}
+
+// SLOObjective is how much worse than its baseline a metric may get: in
+// percent of the baseline, or in percentage points for success_rate.
+// Without App, the metric is checked per app, and success_rate over the
+// whole run.
+type SLOObjective struct {
+	Metric        string  `yaml:"metric"` // duration, success_rate or the name of a custom metric
+	App           string  `yaml:"app,omitempty"`
+	MaxRegression float64 `yaml:"max_regression"`
+	Better        string  `yaml:"better,omitempty"`   // lower (the default) or higher; custom metrics only
+	Severity      string  `yaml:"severity,omitempty"` // error (the default) or warning
+}
+
+// HigherIsBetter tells whether the metric improves as it grows
+func (o SLOObjective) HigherIsBetter() bool {
+	return o.Metric == SLOSuccessRate || o.Better == "higher"
+}
+
+// Windows returns the baseline runs and minimum runs, with their defaults
+func (s *SLOSettings) Windows() (baselineRuns, minRuns int) {
+	baselineRuns, minRuns = s.BaselineRuns, s.MinRuns
+	if baselineRuns == 0 {
+		baselineRuns = DefaultSLOBaselineRuns
+	}
+	if minRuns == 0 {
+		minRuns = DefaultSLOMinRuns
+	}
+	return baselineRuns, minRuns
+}
+
+// Validate checks the windows and objectives
+func (s *SLOSettings) Validate() error {
+	if s == nil {
+		return nil
+	}
+	if s.BaselineRuns < 0 || s.MinRuns < 0 {
+		return fmt.Errorf("baseline_runs and min_runs can't be negative")
+	}
+	if baselineRuns, minRuns := s.Windows(); minRuns > baselineRuns {
+		return fmt.Errorf("min_runs %d is more than the %d baseline_runs", minRuns, baselineRuns)
+	}
+	if len(s.Objectives) == 0 {
+		return fmt.Errorf("objectives are required")
+	}
+	for i, o := range s.Objectives {
+		switch {
+		case o.Metric == "":
+			return fmt.Errorf("objectives[%d]: metric is required", i)
+		case o.Metric != SLODuration && o.Metric != SLOSuccessRate && !variableName.MatchString(o.Metric):
+			return fmt.Errorf("objectives[%d]: metric %q is neither duration, success_rate nor a custom metric name", i, o.Metric)
+		case o.MaxRegression < 0:
+			return fmt.Errorf("objectives[%d]: max_regression can't be negative", i)
+		case o.Better != "" && o.Better != "lower" && o.Better != "higher":
+			return fmt.Errorf("objectives[%d]: better must be lower or higher, got %q", i, o.Better)
+		case o.Better != "" && (o.Metric == SLODuration || o.Metric == SLOSuccessRate):
+			return fmt.Errorf("objectives[%d]: better only applies to custom metrics", i)
+		case o.Severity != "" && o.Severity != SeverityError && o.Severity != SeverityWarning:
+			return fmt.Errorf("objectives[%d]: severity must be error or warning, got %q", i, o.Severity)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,42 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSLOSettings_Validate(t *testing.T) {
+	valid := &SLOSettings{Objectives: []SLOObjective{
+		{Metric: SLODuration, MaxRegression: 20},
+		{Metric: SLOSuccessRate, MaxRegression: 5, Severity: SeverityWarning},
+		{Metric: "items_listed", App: "Shop", Better: "higher", MaxRegression: 10},
+	}}
+	assert.NoError(t, valid.Validate())
+	assert.NoError(t, (*SLOSettings)(nil).Validate())
+
+	tests := []struct {
+		settings SLOSettings
+		errMsg   string
+	}{
+		{SLOSettings{}, "objectives are required"},
+		{SLOSettings{BaselineRuns: 2, Objectives: valid.Objectives}, "min_runs 3 is more than the 2 baseline_runs"},
+		{SLOSettings{MinRuns: -1, Objectives: valid.Objectives}, "baseline_runs and min_runs can't be negative"},
+		{SLOSettings{Objectives: []SLOObjective{{MaxRegression: 5}}}, "objectives[0]: metric is required"},
+		{SLOSettings{Objectives: []SLOObjective{{Metric: "cart render"}}}, `objectives[0]: metric "cart render" is neither duration, success_rate nor a custom metric name`},
+		{SLOSettings{Objectives: []SLOObjective{{Metric: SLODuration, MaxRegression: -1}}}, "objectives[0]: max_regression can't be negative"},
+		{SLOSettings{Objectives: []SLOObjective{{Metric: "items", Better: "more"}}}, `objectives[0]: better must be lower or higher, got "more"`},
+		{SLOSettings{Objectives: []SLOObjective{{Metric: SLODuration, Better: "higher"}}}, "objectives[0]: better only applies to custom metrics"},
+		{SLOSettings{Objectives: []SLOObjective{{Metric: SLODuration, Severity: "fatal"}}}, `objectives[0]: severity must be error or warning, got "fatal"`},
+	}
+	for _, tt := range tests {
+		assert.EqualError(t, tt.settings.Validate(), tt.errMsg)
+	}
+}
+
+func TestSLOObjective_HigherIsBetter(t *testing.T) {
+	assert.True(t, SLOObjective{Metric: SLOSuccessRate}.HigherIsBetter())
+	assert.True(t, SLOObjective{Metric: "items", Better: "higher"}.HigherIsBetter())
+	assert.False(t, SLOObjective{Metric: SLODuration}.HigherIsBetter())
+	assert.False(t, SLOObjective{Metric: "cart_render"}.HigherIsBetter())
+}
@@ -0,0 +1,79 @@
+package config
+
+import (
+	"fmt"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// DefaultPopupTimeout bounds wait_for_popup actions without a duration
+const DefaultPopupTimeout = 10 * time.Second
+
+// Tab is the tab a switch_tab or close_tab action picks: Index counts tabs
+// in the order they opened, from 0 for the app's own; Title matches a
+// case-insensitive substring of a tab's title. Index is -1 when unset.
+type Tab struct {
+	Index int
+	Title string
+}
+
+// Tab reads the tab of a switch_tab or close_tab action: parameters.index,
+// or parameters.title or the target for switch_tab. A close_tab without one
+// closes the active tab.
+func (a *Action) Tab() (*Tab, error) {
+	var params struct {
+		Index *int   `yaml:"index"`
+		Title string `yaml:"title"`
+	}
+	data, err := yaml.Marshal(a.Parameters)
+	if err != nil {
+		return nil, err
+	}
+	if err := yaml.Unmarshal(data, &params); err != nil {
+		return nil, fmt.Errorf("invalid %s parameters: %w", a.Type, err)
+	}
+	tab := &Tab{Index: -1, Title: params.Title}
+	if params.Index != nil {
+		if *params.Index < 0 {
+			return nil, fmt.Errorf("index must not be negative, got %d", *params.Index)
+		}
+		tab.Index = *params.Index
+	}
+	if a.Type == "close_tab" {
+		if tab.Title != "" {
+			return nil, fmt.Errorf("close_tab picks its tab by index, or closes the active one")
+		}
+		return tab, nil
+	}
+	if tab.Title == "" {
+		tab.Title = a.Target
+	}
+	if (tab.Index < 0) == (tab.Title == "") {
+		return nil, fmt.Errorf("switch_tab needs parameters.index or a title, as parameters.title or target")
+	}
+	return tab, nil
+}
+
+// PopupTimeout is how long a wait_for_popup action waits: its duration in
+// seconds, or DefaultPopupTimeout
+func (a *Action) PopupTimeout() time.Duration {
+	if a.Duration > 0 {
+		return time.Duration(a.Duration) * time.Second
+	}
+	return DefaultPopupTimeout
+}
+
+// validateTabAction checks the tab switch_tab and close_tab actions pick
+func (c *Config) validateTabAction(action Action) error {
+	switch action.Type {
+	case "switch_tab", "close_tab":
+		_, err := action.Tab()
+		return err
+	case "wait_for_popup":
+		if action.Duration < 0 {
+			return fmt.Errorf("duration can't be negative")
+		}
+	}
+	return nil
+}
@@ -0,0 +1,42 @@
+package config
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAction_Tab(t *testing.T) {
+	tab, err := (&Action{Type: "switch_tab", Parameters: map[string]interface{}{"index": 0}}).Tab()
+	require.NoError(t, err)
+	assert.Equal(t, &Tab{Index: 0}, tab)
+
+	tab, err = (&Action{Type: "switch_tab", Target: "Sign in with Google"}).Tab()
+	require.NoError(t, err)
+	assert.Equal(t, &Tab{Index: -1, Title: "Sign in with Google"}, tab)
+
+	tab, err = (&Action{Type: "close_tab"}).Tab()
+	require.NoError(t, err)
+	assert.Equal(t, &Tab{Index: -1}, tab)
+
+	_, err = (&Action{Type: "switch_tab"}).Tab()
+	assert.ErrorContains(t, err, "switch_tab needs parameters.index or a title")
+	_, err = (&Action{Type: "switch_tab", Target: "Help", Parameters: map[string]interface{}{"index": 1}}).Tab()
+	assert.ErrorContains(t, err, "switch_tab needs parameters.index or a title")
+	_, err = (&Action{Type: "switch_tab", Parameters: map[string]interface{}{"index": -1}}).Tab()
+	assert.ErrorContains(t, err, "index must not be negative")
+	_, err = (&Action{Type: "close_tab", Parameters: map[string]interface{}{"title": "Help"}}).Tab()
+	assert.ErrorContains(t, err, "close_tab picks its tab by index")
+}
+
+func TestAction_PopupTimeout(t *testing.T) {
+	assert.Equal(t, DefaultPopupTimeout, (&Action{Type: "wait_for_popup"}).PopupTimeout())
+	assert.Equal(t, 30*time.Second, (&Action{Type: "wait_for_popup", Duration: 30}).PopupTimeout())
+
+	cfg := &Config{Apps: []AppConfig{{Name: "Shop", Type: "web", URL: "https://shop.example.com", Actions: []Action{
+		{Name: "back", Type: "switch_tab"},
+	}}}}
+	assert.ErrorContains(t, cfg.Validate(), "action back in app Shop: switch_tab needs parameters.index")
+}
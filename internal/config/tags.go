@@ -0,0 +1,111 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+)
+
+// TagFilter selects apps and actions by tag. An action matches when it has at
+// least one included tag (or no tags are included) and none of the excluded ones.
+type TagFilter struct {
+	Include []string
+	Exclude []string
+}
+
+// ParseTagFilter parses a comma-separated expression such as "smoke,!slow";
+// a leading "!" excludes the tag. Tags are case-insensitive.
+func ParseTagFilter(expr string) (TagFilter, error) {
+	var filter TagFilter
+	for _, part := range strings.Split(expr, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		exclude := strings.HasPrefix(part, "!")
+		tag := normalizeTag(strings.TrimPrefix(part, "!"))
+		if tag == "" {
+			return TagFilter{}, fmt.Errorf("invalid tag filter %q: empty tag after '!'", expr)
+		}
+		if exclude {
+			filter.Exclude = append(filter.Exclude, tag)
+		} else {
+			filter.Include = append(filter.Include, tag)
+		}
+	}
+	return filter, nil
+}
+
+// IsEmpty reports whether the filter selects everything
+func (f TagFilter) IsEmpty() bool {
+	return len(f.Include) == 0 && len(f.Exclude) == 0
+}
+
+// Match reports whether an item with the given tags is selected
+func (f TagFilter) Match(tags []string) bool {
+	set := make(map[string]bool, len(tags))
+	for _, tag := range tags {
+		set[normalizeTag(tag)] = true
+	}
+	for _, tag := range f.Exclude {
+		if set[tag] {
+			return false
+		}
+	}
+	if len(f.Include) == 0 {
+		return true
+	}
+	for _, tag := range f.Include {
+		if set[tag] {
+			return true
+		}
+	}
+	return false
+}
+
+// String formats the filter the way ParseTagFilter reads it
+func (f TagFilter) String() string {
+	parts := make([]string, 0, len(f.Include)+len(f.Exclude))
+	parts = append(parts, f.Include...)
+	for _, tag := range f.Exclude {
+		parts = append(parts, "!"+tag)
+	}
+	return strings.Join(parts, ",")
+}
+
+// MergeTags returns the normalized union of tag lists, in first-seen order
+func MergeTags(lists ...[]string) []string {
+	var merged []string
+	seen := make(map[string]bool)
+	for _, list := range lists {
+		for _, tag := range list {
+			tag = normalizeTag(tag)
+			if tag == "" || seen[tag] {
+				continue
+			}
+			seen[tag] = true
+			merged = append(merged, tag)
+		}
+	}
+	return merged
+}
+
+// SelectActions splits an app's actions into those the filter selects and the
+// names of those it skips. An action's tags are its own plus the app's.
+func (c *Config) SelectActions(app AppConfig, filter TagFilter) (selected []Action, skipped []string) {
+	actions := c.GetActionsForApp(app)
+	if filter.IsEmpty() {
+		return actions, nil
+	}
+	for _, action := range actions {
+		if filter.Match(MergeTags(app.Tags, action.Tags)) {
+			selected = append(selected, action)
+		} else {
+			skipped = append(skipped, action.Name)
+		}
+	}
+	return selected, skipped
+}
+
+func normalizeTag(tag string) string {
+	return strings.ToLower(strings.TrimSpace(tag))
+}
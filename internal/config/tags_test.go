@@ -0,0 +1,87 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+// TestParseTagFilter tests parsing of include and exclude tags
+func TestParseTagFilter(t *testing.T) {
+	filter, err := ParseTagFilter(" Smoke, !slow ,,checkout")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"smoke", "checkout"}, filter.Include)
+	assert.Equal(t, []string{"slow"}, filter.Exclude)
+	assert.Equal(t, "smoke,checkout,!slow", filter.String())
+	assert.False(t, filter.IsEmpty())
+
+	empty, err := ParseTagFilter("")
+	require.NoError(t, err)
+	assert.True(t, empty.IsEmpty())
+
+	_, err = ParseTagFilter("smoke,!")
+	assert.Error(t, err)
+}
+
+// TestTagFilter_Match tests include/exclude semantics
+func TestTagFilter_Match(t *testing.T) {
+	filter, _ := ParseTagFilter("smoke,!slow")
+	assert.True(t, filter.Match([]string{"smoke"}))
+	assert.True(t, filter.Match([]string{"SMOKE", "checkout"}))
+	assert.False(t, filter.Match([]string{"smoke", "slow"}), "exclusions win")
+	assert.False(t, filter.Match([]string{"checkout"}))
+	assert.False(t, filter.Match(nil))
+
+	excludeOnly, _ := ParseTagFilter("!slow")
+	assert.True(t, excludeOnly.Match(nil))
+	assert.False(t, excludeOnly.Match([]string{"slow"}))
+
+	assert.True(t, TagFilter{}.Match([]string{"anything"}))
+}
+
+// TestConfig_SelectActions tests that actions inherit app tags
+func TestConfig_SelectActions(t *testing.T) {
+	var cfg Config
+	require.NoError(t, yaml.Unmarshal([]byte(`
+apps:
+  - name: shop
+    type: web
+    url: https://example.com
+    tags: [checkout]
+actions:
+  - name: open
+    type: navigate
+    url: https://example.com
+    tags: [smoke]
+  - name: soak
+    type: wait
+    tags: [slow]
+  - name: pay
+    type: click
+`), &cfg))
+	app := cfg.Apps[0]
+	assert.Equal(t, []string{"checkout"}, app.Tags)
+
+	filter, _ := ParseTagFilter("smoke")
+	selected, skipped := cfg.SelectActions(app, filter)
+	require.Len(t, selected, 1)
+	assert.Equal(t, "open", selected[0].Name)
+	assert.Equal(t, []string{"soak", "pay"}, skipped)
+
+	filter, _ = ParseTagFilter("checkout,!slow")
+	selected, skipped = cfg.SelectActions(app, filter)
+	assert.Len(t, selected, 2)
+	assert.Equal(t, []string{"soak"}, skipped)
+
+	selected, skipped = cfg.SelectActions(app, TagFilter{})
+	assert.Len(t, selected, 3)
+	assert.Empty(t, skipped)
+}
+
+// TestMergeTags tests normalized, de-duplicated tag unions
+func TestMergeTags(t *testing.T) {
+	assert.Equal(t, []string{"smoke", "checkout", "slow"}, MergeTags([]string{"Smoke", "checkout"}, []string{"smoke", " slow", ""}))
+	assert.Nil(t, MergeTags(nil, nil))
+}
@@ -0,0 +1,219 @@
+package config
+
+import (
+	"fmt"
+	"regexp"
+
+	"gopkg.in/yaml.v3"
+)
+
+// CodeDecode is a vision_decode_qr action's parameters: the variable that
+// receives the decoded text, a pattern the chosen code must match, and the
+// formats to consider (QR codes only by default)
+type CodeDecode struct {
+	Variable string   `yaml:"variable"`
+	Matches  string   `yaml:"matches"`
+	Formats  []string `yaml:"formats"`
+}
+
+// CodeDecode reads a vision_decode_qr action's parameters
+func (a *Action) CodeDecode() (*CodeDecode, error) {
+	decode := &CodeDecode{}
+	data, err := yaml.Marshal(a.Parameters)
+	if err != nil {
+		return nil, err
+	}
+	if err := yaml.Unmarshal(data, decode); err != nil {
+		return nil, fmt.Errorf("invalid vision_decode_qr parameters: %w", err)
+	}
+	if decode.Variable == "" {
+		decode.Variable = "qr"
+	}
+	if !variableName.MatchString(decode.Variable) {
+		return nil, fmt.Errorf("invalid variable name %q", decode.Variable)
+	}
+	if _, err := regexp.Compile(decode.Matches); err != nil {
+		return nil, fmt.Errorf("matches: %w", err)
+	}
+	if len(decode.Formats) == 0 {
+		decode.Formats = []string{"qr"}
+	}
+	for _, f := range decode.Formats {
+		switch f {
+		case "qr", "ean13", "upc_a", "code128":
+		default:
+			return nil, fmt.Errorf("formats must be qr, ean13, upc_a or code128, got %q", f)
+		}
+	}
+	return decode, nil
+}
+
+// ContrastCheck is a vision_contrast_check action's parameters: the WCAG
+// level text must meet (AA by default) and the regions holding text. Fail
+// turns violations into an action failure; otherwise they are reported as
+// findings only.
+type ContrastCheck struct {
+	Level   string           `yaml:"level"`
+	Regions []ContrastRegion `yaml:"regions"`
+	Fail    bool             `yaml:"fail"`
+}
+
+// ContrastRegion is text whose contrast is measured: the box of the element
+// matching Selector on web apps, or a rectangle of screenshot pixels. Large
+// text (18pt, or 14pt bold) needs less contrast.
+type ContrastRegion struct {
+	Name     string `yaml:"name"`
+	Selector string `yaml:"selector"`
+	X        int    `yaml:"x"`
+	Y        int    `yaml:"y"`
+	Width    int    `yaml:"width"`
+	Height   int    `yaml:"height"`
+	Large    bool   `yaml:"large"`
+}
+
+// ContrastCheck reads a vision_contrast_check action's parameters
+func (a *Action) ContrastCheck() (*ContrastCheck, error) {
+	check := &ContrastCheck{}
+	data, err := yaml.Marshal(a.Parameters)
+	if err != nil {
+		return nil, err
+	}
+	if err := yaml.Unmarshal(data, check); err != nil {
+		return nil, fmt.Errorf("invalid vision_contrast_check parameters: %w", err)
+	}
+	if check.Level == "" {
+		check.Level = "AA"
+	}
+	if check.Level != "AA" && check.Level != "AAA" {
+		return nil, fmt.Errorf("level must be AA or AAA, got %q", check.Level)
+	}
+	if len(check.Regions) == 0 {
+		return nil, fmt.Errorf("vision_contrast_check needs regions")
+	}
+	for i := range check.Regions {
+		r := &check.Regions[i]
+		if r.Selector == "" && (r.Width <= 0 || r.Height <= 0 || r.X < 0 || r.Y < 0) {
+			return nil, fmt.Errorf("regions[%d] needs a selector, or x, y, width and height", i)
+		}
+		if r.Name == "" {
+			r.Name = r.Selector
+		}
+		if r.Name == "" {
+			r.Name = fmt.Sprintf("%dx%d+%d+%d", r.Width, r.Height, r.X, r.Y)
+		}
+	}
+	return check, nil
+}
+
+// DefaultLayoutTolerance is the movement in pixels vision_layout_check
+// ignores
+const DefaultLayoutTolerance = 4
+
+// LayoutCheck is a vision_layout_check action's parameters. Capture stores
+// the layout under a name, and Compare reports what moved since the layout
+// of that name was captured. Layouts are the boxes of Selectors on web apps,
+// or the elements vision detects in the screenshot. Overlaps reports
+// selector boxes that partly cover each other.
+type LayoutCheck struct {
+	Capture   string   `yaml:"capture"`
+	Compare   string   `yaml:"compare"`
+	Selectors []string `yaml:"selectors"`
+	Overlaps  bool     `yaml:"overlaps"`
+	Tolerance *int     `yaml:"tolerance"` // DefaultLayoutTolerance when unset
+	Ignore    []string `yaml:"ignore"`    // selectors expected to move or overlap
+	Fail      bool     `yaml:"fail"`
+}
+
+// LayoutCheck reads a vision_layout_check action's parameters
+func (a *Action) LayoutCheck() (*LayoutCheck, error) {
+	check := &LayoutCheck{}
+	data, err := yaml.Marshal(a.Parameters)
+	if err != nil {
+		return nil, err
+	}
+	if err := yaml.Unmarshal(data, check); err != nil {
+		return nil, fmt.Errorf("invalid vision_layout_check parameters: %w", err)
+	}
+	if check.Capture == "" && check.Compare == "" && !check.Overlaps {
+		return nil, fmt.Errorf("vision_layout_check needs capture, compare or overlaps")
+	}
+	if check.Overlaps && len(check.Selectors) == 0 {
+		return nil, fmt.Errorf("overlaps needs selectors")
+	}
+	if check.Tolerance == nil {
+		tolerance := DefaultLayoutTolerance
+		check.Tolerance = &tolerance
+	}
+	if *check.Tolerance < 0 {
+		return nil, fmt.Errorf("tolerance must not be negative, got %d", *check.Tolerance)
+	}
+	return check, nil
+}
+
+// VisionSettings configure element detection. ModelPath selects a UI element
+// detection model in ONNX format that replaces the heuristic detectors; it
+// runs through the onnxruntime package of a Python interpreter, and
+// detection falls back to the heuristics when the model or runtime is
+// missing. MinConfidence, Thresholds and NMS decide which detections are
+// kept, whichever detector found them.
+type VisionSettings struct {
+	ModelPath     string             `yaml:"model_path"`
+	Runtime       string             `yaml:"runtime"`        // Python interpreter with onnxruntime; default python3
+	InputSize     int                `yaml:"input_size"`     // square model input in pixels; default 640
+	Labels        []string           `yaml:"labels"`         // element type of each model class; default button, textfield, image, link
+	MinConfidence float64            `yaml:"min_confidence"` // every element type; models default to 0.25
+	Thresholds    map[string]float64 `yaml:"thresholds"`     // minimum confidence by element type, overriding min_confidence
+	NMS           *NMSSettings       `yaml:"nms,omitempty"`
+}
+
+// NMSSettings configure non-max suppression: of two elements overlapping by
+// more than IoU (intersection over union), the less confident is dropped.
+// Without them, model detections of a type are suppressed at 0.45 and
+// heuristic detections are all kept.
+type NMSSettings struct {
+	IoU         float64 `yaml:"iou"`
+	AcrossTypes bool    `yaml:"across_types"` // also suppress overlapping elements of different types
+}
+
+// Validate checks the model and threshold settings
+func (v *VisionSettings) Validate() error {
+	if v == nil {
+		return nil
+	}
+	if v.InputSize < 0 || v.InputSize%32 != 0 {
+		return fmt.Errorf("input_size must be a multiple of 32, got %d", v.InputSize)
+	}
+	if v.MinConfidence < 0 || v.MinConfidence > 1 {
+		return fmt.Errorf("min_confidence must be from 0 to 1, got %v", v.MinConfidence)
+	}
+	for i, label := range v.Labels {
+		if label == "" {
+			return fmt.Errorf("labels[%d] is empty", i)
+		}
+	}
+	for elementType, threshold := range v.Thresholds {
+		if threshold < 0 || threshold > 1 {
+			return fmt.Errorf("thresholds.%s must be from 0 to 1, got %v", elementType, threshold)
+		}
+	}
+	if v.NMS != nil && (v.NMS.IoU <= 0 || v.NMS.IoU > 1) {
+		return fmt.Errorf("nms.iou must be above 0 and at most 1, got %v", v.NMS.IoU)
+	}
+	return nil
+}
+
+// validateVisionAction checks the parameters of vision actions
+func (c *Config) validateVisionAction(action Action) error {
+	var err error
+	switch action.Type {
+	case "vision_decode_qr":
+		_, err = action.CodeDecode()
+	case "vision_contrast_check":
+		_, err = action.ContrastCheck()
+	case "vision_layout_check":
+		_, err = action.LayoutCheck()
+	case "vision_design_compare":
+		_, err = action.DesignCompare(c.Settings.Figma)
+	}
+	return err
+}
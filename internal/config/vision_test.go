@@ -0,0 +1,119 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+// TestAction_CodeDecode tests defaults and parameter checks
+func TestAction_CodeDecode(t *testing.T) {
+	decode, err := (&Action{}).CodeDecode()
+	require.NoError(t, err)
+	assert.Equal(t, &CodeDecode{Variable: "qr", Formats: []string{"qr"}}, decode)
+
+	decode, err = (&Action{Parameters: map[string]interface{}{"variable": "pairing", "matches": `^PAIR-\d+$`, "formats": []interface{}{"qr", "code128"}}}).CodeDecode()
+	require.NoError(t, err)
+	assert.Equal(t, "pairing", decode.Variable)
+	assert.Equal(t, []string{"qr", "code128"}, decode.Formats)
+
+	_, err = (&Action{Parameters: map[string]interface{}{"matches": "(["}}).CodeDecode()
+	assert.ErrorContains(t, err, "matches:")
+	_, err = (&Action{Parameters: map[string]interface{}{"formats": []interface{}{"datamatrix"}}}).CodeDecode()
+	assert.EqualError(t, err, `formats must be qr, ean13, upc_a or code128, got "datamatrix"`)
+}
+
+// TestAction_ContrastCheck tests defaults and region checks
+func TestAction_ContrastCheck(t *testing.T) {
+	check, err := (&Action{Parameters: map[string]interface{}{"regions": []interface{}{
+		map[string]interface{}{"selector": "#price", "large": true},
+		map[string]interface{}{"x": 10, "y": 20, "width": 200, "height": 32},
+	}}}).ContrastCheck()
+	require.NoError(t, err)
+	assert.Equal(t, "AA", check.Level)
+	assert.Equal(t, []ContrastRegion{
+		{Name: "#price", Selector: "#price", Large: true},
+		{Name: "200x32+10+20", X: 10, Y: 20, Width: 200, Height: 32},
+	}, check.Regions)
+
+	_, err = (&Action{}).ContrastCheck()
+	assert.EqualError(t, err, "vision_contrast_check needs regions")
+	_, err = (&Action{Parameters: map[string]interface{}{"level": "A", "regions": []interface{}{map[string]interface{}{"selector": "h1"}}}}).ContrastCheck()
+	assert.EqualError(t, err, `level must be AA or AAA, got "A"`)
+	_, err = (&Action{Parameters: map[string]interface{}{"regions": []interface{}{map[string]interface{}{"name": "banner", "width": 10}}}}).ContrastCheck()
+	assert.EqualError(t, err, "regions[0] needs a selector, or x, y, width and height")
+}
+
+// TestAction_LayoutCheck tests the tolerance default and mode checks
+func TestAction_LayoutCheck(t *testing.T) {
+	check, err := (&Action{Parameters: map[string]interface{}{"capture": "before_promo"}}).LayoutCheck()
+	require.NoError(t, err)
+	assert.Equal(t, DefaultLayoutTolerance, *check.Tolerance)
+
+	check, err = (&Action{Parameters: map[string]interface{}{"compare": "before_promo", "tolerance": 0}}).LayoutCheck()
+	require.NoError(t, err)
+	assert.Equal(t, 0, *check.Tolerance)
+
+	_, err = (&Action{}).LayoutCheck()
+	assert.EqualError(t, err, "vision_layout_check needs capture, compare or overlaps")
+	_, err = (&Action{Parameters: map[string]interface{}{"overlaps": true}}).LayoutCheck()
+	assert.EqualError(t, err, "overlaps needs selectors")
+	_, err = (&Action{Parameters: map[string]interface{}{"capture": "a", "tolerance": -1}}).LayoutCheck()
+	assert.EqualError(t, err, "tolerance must not be negative, got -1")
+}
+
+// TestConfig_VisionActions tests that vision action parameters are validated
+func TestConfig_VisionActions(t *testing.T) {
+	cfg := &Config{
+		Apps:    []AppConfig{{Name: "Shop", Type: "web", URL: "https://example.com"}},
+		Actions: []Action{{Name: "pair", Type: "vision_decode_qr", Parameters: map[string]interface{}{"variable": "pairing code"}}},
+	}
+	assert.EqualError(t, cfg.Validate(), `action pair: invalid variable name "pairing code"`)
+	cfg.Actions[0].Parameters["variable"] = "pairing"
+	assert.NoError(t, cfg.Validate())
+
+	cfg.Apps[0].Actions = []Action{{Name: "contrast", Type: "vision_contrast_check"}}
+	assert.EqualError(t, cfg.Validate(), "action contrast in app Shop: vision_contrast_check needs regions")
+}
+
+// TestVisionSettings_Validate tests the model settings checks
+func TestVisionSettings_Validate(t *testing.T) {
+	assert.NoError(t, (*VisionSettings)(nil).Validate())
+
+	var cfg Config
+	require.NoError(t, yaml.Unmarshal([]byte(`
+apps: [{name: Shop, type: web, url: "https://example.com"}]
+settings:
+  vision:
+    model_path: models/ui.onnx
+    input_size: 1024
+    labels: [button, textfield, checkbox]
+`), &cfg))
+	require.NoError(t, cfg.Validate())
+	assert.Equal(t, &VisionSettings{ModelPath: "models/ui.onnx", InputSize: 1024, Labels: []string{"button", "textfield", "checkbox"}}, cfg.Settings.Vision)
+
+	cfg.Settings.Vision.InputSize = 600
+	assert.EqualError(t, cfg.Validate(), "settings.vision: input_size must be a multiple of 32, got 600")
+	assert.EqualError(t, (&VisionSettings{MinConfidence: 1.5}).Validate(), "min_confidence must be from 0 to 1, got 1.5")
+	assert.EqualError(t, (&VisionSettings{Labels: []string{"button", ""}}).Validate(), "labels[1] is empty")
+}
+
+// TestVisionSettings_Thresholds tests the per-type thresholds and
+// suppression settings
+func TestVisionSettings_Thresholds(t *testing.T) {
+	var settings VisionSettings
+	require.NoError(t, yaml.Unmarshal([]byte(`
+min_confidence: 0.3
+thresholds: {button: 0.6, link: 0.2}
+nms: {iou: 0.5, across_types: true}
+`), &settings))
+	require.NoError(t, settings.Validate())
+	assert.Equal(t, map[string]float64{"button": 0.6, "link": 0.2}, settings.Thresholds)
+	assert.Equal(t, &NMSSettings{IoU: 0.5, AcrossTypes: true}, settings.NMS)
+
+	settings.Thresholds["link"] = 1.2
+	assert.EqualError(t, settings.Validate(), "thresholds.link must be from 0 to 1, got 1.2")
+	assert.EqualError(t, (&VisionSettings{NMS: &NMSSettings{}}).Validate(), "nms.iou must be above 0 and at most 1, got 0")
+}
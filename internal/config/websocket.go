@@ -0,0 +1,121 @@
+package config
+
+import (
+	"fmt"
+	"regexp"
+	"time"
+
+	"panoptic/internal/jsonpath"
+
+	"gopkg.in/yaml.v3"
+)
+
+// DefaultWebSocketTimeout is how long assert_websocket waits for the
+// messages it expects
+const DefaultWebSocketTimeout = 5 * time.Second
+
+// WebSocketCheck is an assert_websocket action's parameters: the messages
+// counted, out of those the app's pages sent or received over WebSockets
+// since it started, and how many there must be. Without count, min_count or
+// max_count at least one must match. The action waits up to its timeout for
+// messages still to arrive.
+type WebSocketCheck struct {
+	Direction string        `yaml:"direction"` // sent or received; both by default
+	URL       string        `yaml:"url"`       // part of the socket's URL
+	Contains  string        `yaml:"contains"`  // part of the message
+	Matches   string        `yaml:"matches"`   // a regular expression the message, or its json_path value, matches
+	JSONPath  string        `yaml:"json_path"` // e.g. $.type, messages that are JSON with a value there
+	Equals    interface{}   `yaml:"equals"`    // the json_path value
+	Count     *int          `yaml:"count"`
+	MinCount  *int          `yaml:"min_count"`
+	MaxCount  *int          `yaml:"max_count"`
+	Timeout   time.Duration `yaml:"timeout"`  // 5s by default
+	Variable  string        `yaml:"variable"` // receives the last match, or its json_path value
+
+	Path *jsonpath.Path `yaml:"-"`
+}
+
+// Counts tells whether n matching messages are as many as expected
+func (c *WebSocketCheck) Counts(n int) bool {
+	switch {
+	case c.Count != nil:
+		return n == *c.Count
+	case c.MinCount != nil && n < *c.MinCount:
+		return false
+	case c.MaxCount != nil && n > *c.MaxCount:
+		return false
+	}
+	return c.MinCount != nil || c.MaxCount != nil || n > 0
+}
+
+// Expected describes the count of matching messages that passes
+func (c *WebSocketCheck) Expected() string {
+	switch {
+	case c.Count != nil:
+		return fmt.Sprintf("exactly %d", *c.Count)
+	case c.MinCount != nil && c.MaxCount != nil:
+		return fmt.Sprintf("%d to %d", *c.MinCount, *c.MaxCount)
+	case c.MinCount != nil:
+		return fmt.Sprintf("at least %d", *c.MinCount)
+	case c.MaxCount != nil:
+		return fmt.Sprintf("at most %d", *c.MaxCount)
+	}
+	return "at least 1"
+}
+
+// WebSocketCheck reads an assert_websocket action's parameters
+func (a *Action) WebSocketCheck() (*WebSocketCheck, error) {
+	check := &WebSocketCheck{}
+	data, err := yaml.Marshal(a.Parameters)
+	if err != nil {
+		return nil, err
+	}
+	if err := yaml.Unmarshal(data, check); err != nil {
+		return nil, fmt.Errorf("invalid assert_websocket parameters: %w", err)
+	}
+	switch check.Direction {
+	case "", "sent", "received":
+	default:
+		return nil, fmt.Errorf("direction must be sent or received, got %q", check.Direction)
+	}
+	if _, err := regexp.Compile(check.Matches); err != nil {
+		return nil, fmt.Errorf("matches: %w", err)
+	}
+	if check.JSONPath != "" {
+		if check.Path, err = jsonpath.Parse(check.JSONPath); err != nil {
+			return nil, err
+		}
+	} else if check.Equals != nil {
+		return nil, fmt.Errorf("equals needs a json_path")
+	}
+	for name, n := range map[string]*int{"count": check.Count, "min_count": check.MinCount, "max_count": check.MaxCount} {
+		if n != nil && *n < 0 {
+			return nil, fmt.Errorf("%s must not be negative", name)
+		}
+	}
+	if check.Count != nil && (check.MinCount != nil || check.MaxCount != nil) {
+		return nil, fmt.Errorf("count can't be combined with min_count or max_count")
+	}
+	if check.MinCount != nil && check.MaxCount != nil && *check.MinCount > *check.MaxCount {
+		return nil, fmt.Errorf("min_count %d is above max_count %d", *check.MinCount, *check.MaxCount)
+	}
+	if check.Timeout < 0 {
+		return nil, fmt.Errorf("timeout must not be negative")
+	}
+	if check.Timeout == 0 {
+		check.Timeout = DefaultWebSocketTimeout
+	}
+	if check.Variable != "" && !variableName.MatchString(check.Variable) {
+		return nil, fmt.Errorf("invalid variable name %q", check.Variable)
+	}
+	return check, nil
+}
+
+// validateWebSocketAction checks the parameters of assert_websocket actions
+func (c *Config) validateWebSocketAction(action Action) error {
+	if action.Type != "assert_websocket" {
+		return nil
+	}
+	_, err := action.WebSocketCheck()
+	return err
+}
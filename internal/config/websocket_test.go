@@ -0,0 +1,59 @@
+package config
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAction_WebSocketCheck(t *testing.T) {
+	action := Action{Name: "reply", Type: "assert_websocket", Parameters: map[string]interface{}{
+		"direction": "received", "url": "/chat", "json_path": "$.type", "equals": "message", "min_count": 2, "timeout": "10s",
+	}}
+	check, err := action.WebSocketCheck()
+	require.NoError(t, err)
+	assert.Equal(t, "received", check.Direction)
+	assert.Equal(t, "$.type", check.Path.String())
+	assert.Equal(t, 10*time.Second, check.Timeout)
+	assert.Equal(t, "at least 2", check.Expected())
+	assert.False(t, check.Counts(1))
+	assert.True(t, check.Counts(3))
+
+	check, err = (&Action{Name: "any", Type: "assert_websocket"}).WebSocketCheck()
+	require.NoError(t, err)
+	assert.Equal(t, DefaultWebSocketTimeout, check.Timeout)
+	assert.Equal(t, "at least 1", check.Expected())
+	assert.False(t, check.Counts(0))
+	assert.True(t, check.Counts(1))
+
+	check, err = (&Action{Name: "no_errors", Type: "assert_websocket", Parameters: map[string]interface{}{"contains": "error", "max_count": 0}}).WebSocketCheck()
+	require.NoError(t, err)
+	assert.Equal(t, "at most 0", check.Expected())
+	assert.True(t, check.Counts(0))
+	assert.False(t, check.Counts(1))
+
+	check, err = (&Action{Name: "once", Type: "assert_websocket", Parameters: map[string]interface{}{"count": 1, "variable": "hello"}}).WebSocketCheck()
+	require.NoError(t, err)
+	assert.Equal(t, "exactly 1", check.Expected())
+	assert.False(t, check.Counts(2))
+}
+
+func TestConfig_ValidateWebSocketAction(t *testing.T) {
+	for want, params := range map[string]map[string]interface{}{
+		`direction must be sent or received, got "both"`: {"direction": "both"},
+		"matches:":                                            {"matches": "("},
+		`invalid JSON path "$.": empty field`:                 {"json_path": "$."},
+		"equals needs a json_path":                            {"equals": "message"},
+		"min_count must not be negative":                      {"min_count": -1},
+		"count can't be combined with min_count or max_count": {"count": 1, "max_count": 2},
+		"min_count 3 is above max_count 1":                    {"min_count": 3, "max_count": 1},
+		"timeout must not be negative":                        {"timeout": "-1s"},
+		`invalid variable name "a b"`:                         {"variable": "a b"},
+	} {
+		cfg := &Config{Apps: []AppConfig{{Name: "Chat", Type: "web", URL: "https://chat.example.com",
+			Actions: []Action{{Name: "reply", Type: "assert_websocket", Parameters: params}}}}}
+		assert.ErrorContains(t, cfg.Validate(), want)
+	}
+}
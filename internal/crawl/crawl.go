@@ -0,0 +1,251 @@
+// Package crawl discovers the pages of a site for smoke tests: those its
+// sitemaps list and those its links lead to, up to a depth.
+package crawl
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"panoptic/internal/sitemap"
+)
+
+// Crawl defaults
+const (
+	DefaultDepth    = 2
+	DefaultMaxPages = 50
+)
+
+// Limits on what is read of a site
+const (
+	maxPageSize = 10 << 20
+	maxSitemaps = 20 // sitemaps read, including those of sitemap indexes
+)
+
+// Where a page was found
+const (
+	FromStart   = "start"
+	FromSitemap = "sitemap"
+	FromLink    = "link"
+)
+
+// Options bound a crawl
+type Options struct {
+	Depth    int          // links followed away from the start page; 0 follows none
+	MaxPages int          // pages found at most
+	Client   *http.Client // http.DefaultClient when nil
+}
+
+// Page is a page of the site
+type Page struct {
+	URL   string `json:"url"`
+	From  string `json:"from"`  // start, sitemap or link
+	Depth int    `json:"depth"` // links away from the start page; 0 for sitemap pages
+}
+
+// hrefPattern finds the targets of links in HTML
+var hrefPattern = regexp.MustCompile(`(?is)<a\s[^>]*?\bhref\s*=\s*(?:"([^"]*)"|'([^']*)'|([^\s"'>]+))`)
+
+// crawler is the state of one crawl
+type crawler struct {
+	ctx    context.Context
+	client *http.Client
+	host   string
+	max    int
+	pages  []Page
+	seen   map[string]bool
+}
+
+// Crawl finds the pages of the site of start, on its host: start itself, the
+// pages of the sitemaps robots.txt names or of /sitemap.xml, and the HTML
+// pages its links lead to within opts.Depth, in the order they're found
+func Crawl(ctx context.Context, start string, opts Options) ([]Page, error) {
+	startURL, err := url.Parse(start)
+	if err != nil || (startURL.Scheme != "http" && startURL.Scheme != "https") || startURL.Host == "" {
+		return nil, fmt.Errorf("invalid start URL %q: it must be an http(s) URL", start)
+	}
+	startURL.Fragment = ""
+	if startURL.Path == "" {
+		startURL.Path = "/"
+	}
+	if opts.MaxPages <= 0 {
+		opts.MaxPages = DefaultMaxPages
+	}
+	c := &crawler{
+		ctx:    ctx,
+		client: opts.Client,
+		host:   startURL.Host,
+		max:    opts.MaxPages,
+		seen:   make(map[string]bool),
+	}
+	if c.client == nil {
+		c.client = http.DefaultClient
+	}
+
+	body, err := c.fetchHTML(startURL.String())
+	if err != nil {
+		return nil, err
+	}
+	c.add(Page{URL: startURL.String(), From: FromStart})
+
+	// Follow links breadth first, so the pages closest to the start are
+	// kept when the crawl hits its limit
+	type queued struct {
+		page *url.URL
+		body string
+	}
+	level := []queued{{startURL, body}}
+	for depth := 1; depth <= opts.Depth && len(level) > 0 && !c.full(); depth++ {
+		var next []queued
+		for _, q := range level {
+			for _, link := range c.links(q.page, q.body) {
+				if c.full() {
+					break
+				}
+				if c.seen[link] {
+					continue
+				}
+				c.seen[link] = true
+				body, err := c.fetchHTML(link)
+				if err != nil {
+					continue // broken links and non-HTML files aren't pages to test
+				}
+				c.add(Page{URL: link, From: FromLink, Depth: depth})
+				u, _ := url.Parse(link)
+				next = append(next, queued{u, body})
+			}
+		}
+		level = next
+	}
+
+	// Sitemap pages fill what the links left
+	for _, loc := range c.sitemapURLs(startURL) {
+		if c.full() {
+			break
+		}
+		if link, ok := c.resolve(startURL, loc); ok && !c.seen[link] {
+			c.add(Page{URL: link, From: FromSitemap})
+		}
+	}
+	return c.pages, nil
+}
+
+func (c *crawler) full() bool {
+	return len(c.pages) >= c.max
+}
+
+func (c *crawler) add(page Page) {
+	c.seen[page.URL] = true
+	c.pages = append(c.pages, page)
+}
+
+// links returns the targets of a page's links on the site, once each
+func (c *crawler) links(page *url.URL, body string) []string {
+	var links []string
+	found := make(map[string]bool)
+	for _, m := range hrefPattern.FindAllStringSubmatch(body, -1) {
+		href := m[1] + m[2] + m[3]
+		if link, ok := c.resolve(page, href); ok && !found[link] {
+			found[link] = true
+			links = append(links, link)
+		}
+	}
+	return links
+}
+
+// resolve returns the URL of href on page without its fragment, when it's
+// an http(s) URL on the site's host
+func (c *crawler) resolve(page *url.URL, href string) (string, bool) {
+	href = strings.TrimSpace(href)
+	if href == "" || strings.HasPrefix(href, "#") {
+		return "", false
+	}
+	u, err := page.Parse(href)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host != c.host {
+		return "", false
+	}
+	u.Fragment = ""
+	if u.Path == "" {
+		u.Path = "/"
+	}
+	return u.String(), true
+}
+
+// get requests a URL of the site
+func (c *crawler) get(rawURL string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(c.ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", "Panoptic-Crawler")
+	return c.client.Do(req)
+}
+
+// fetchHTML returns the body of an HTML page
+func (c *crawler) fetchHTML(rawURL string) (string, error) {
+	resp, err := c.get(rawURL)
+	if err != nil {
+		return "", fmt.Errorf("failed to fetch %s: %w", rawURL, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("failed to fetch %s: %s", rawURL, resp.Status)
+	}
+	if mediaType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type")); mediaType != "text/html" && mediaType != "application/xhtml+xml" {
+		return "", fmt.Errorf("%s is not an HTML page", rawURL)
+	}
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxPageSize))
+	if err != nil {
+		return "", fmt.Errorf("failed to read %s: %w", rawURL, err)
+	}
+	return string(body), nil
+}
+
+// sitemapURLs returns the pages of the site's sitemaps: those robots.txt
+// names, or /sitemap.xml, following sitemap indexes. A site without
+// sitemaps has none.
+func (c *crawler) sitemapURLs(start *url.URL) []string {
+	root := &url.URL{Scheme: start.Scheme, Host: start.Host}
+	queue := c.robotsSitemaps(root)
+	if len(queue) == 0 {
+		queue = []string{root.JoinPath("sitemap.xml").String()}
+	}
+	var urls []string
+	for read := 0; len(queue) > 0 && read < maxSitemaps; read++ {
+		s, err := sitemap.Fetch(c.ctx, c.client, queue[0])
+		queue = queue[1:]
+		if err != nil {
+			continue
+		}
+		urls = append(urls, s.URLs...)
+		queue = append(queue, s.Sitemaps...)
+	}
+	return urls
+}
+
+// robotsSitemaps returns the sitemaps the site's robots.txt names
+func (c *crawler) robotsSitemaps(root *url.URL) []string {
+	resp, err := c.get(root.JoinPath("robots.txt").String())
+	if err != nil {
+		return nil
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil
+	}
+	var sitemaps []string
+	scanner := bufio.NewScanner(io.LimitReader(resp.Body, maxPageSize))
+	for scanner.Scan() {
+		key, value, ok := strings.Cut(scanner.Text(), ":")
+		if ok && strings.EqualFold(strings.TrimSpace(key), "sitemap") {
+			sitemaps = append(sitemaps, strings.TrimSpace(value))
+		}
+	}
+	return sitemaps
+}
@@ -0,0 +1,96 @@
+package crawl
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// site serves a small shop: the home page links to the cart and, through
+// the cart, to the checkout; the sitemap adds the about page
+func site(t *testing.T, robots bool) *httptest.Server {
+	var server *httptest.Server
+	pages := map[string]string{
+		"/":         `<a href="/cart">Cart</a> <a href='#top'>Top</a> <a href="https://elsewhere.example.com/">Out</a> <a href="mailto:shop@example.com">Mail</a> <a href="/missing">Gone</a> <a href=/brochure.pdf>PDF</a>`,
+		"/cart":     `<A class="btn" HREF="checkout?step=1#form">Checkout</A> <a href="/">Home</a>`,
+		"/checkout": `<a href="/thanks">Done</a>`,
+		"/thanks":   `Thank you`,
+		"/about":    `About us`,
+	}
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/robots.txt":
+			if !robots {
+				http.NotFound(w, r)
+				return
+			}
+			w.Write([]byte("User-agent: *\nSitemap: " + server.URL + "/sitemaps/index.xml\n"))
+			return
+		case "/sitemap.xml", "/sitemaps/pages.xml":
+			w.Write([]byte(`<urlset><url><loc>` + server.URL + `/about</loc></url><url><loc>` + server.URL + `/cart</loc></url></urlset>`))
+			return
+		case "/sitemaps/index.xml":
+			w.Write([]byte(`<sitemapindex><sitemap><loc>` + server.URL + `/sitemaps/pages.xml</loc></sitemap></sitemapindex>`))
+			return
+		case "/brochure.pdf":
+			w.Header().Set("Content-Type", "application/pdf")
+			w.Write([]byte("%PDF"))
+			return
+		}
+		body, ok := pages[r.URL.Path]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Write([]byte(body))
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func urls(pages []Page, base string) []string {
+	var list []string
+	for _, p := range pages {
+		list = append(list, strings.TrimPrefix(p.URL, base))
+	}
+	return list
+}
+
+func TestCrawl(t *testing.T) {
+	server := site(t, false)
+	pages, err := Crawl(context.Background(), server.URL, Options{Depth: 2, Client: server.Client()})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"/", "/cart", "/checkout?step=1", "/about"}, urls(pages, server.URL))
+	assert.Equal(t, Page{URL: server.URL + "/", From: FromStart}, pages[0])
+	assert.Equal(t, 2, pages[2].Depth)
+	assert.Equal(t, FromSitemap, pages[3].From)
+}
+
+func TestCrawl_RobotsSitemapIndex(t *testing.T) {
+	server := site(t, true)
+	pages, err := Crawl(context.Background(), server.URL+"/", Options{Depth: 0, Client: server.Client()})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"/", "/about", "/cart"}, urls(pages, server.URL))
+}
+
+func TestCrawl_MaxPages(t *testing.T) {
+	server := site(t, false)
+	pages, err := Crawl(context.Background(), server.URL, Options{Depth: 5, MaxPages: 2, Client: server.Client()})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"/", "/cart"}, urls(pages, server.URL))
+}
+
+func TestCrawl_Errors(t *testing.T) {
+	_, err := Crawl(context.Background(), "ftp://shop.example.com", Options{})
+	assert.ErrorContains(t, err, "must be an http(s) URL")
+
+	server := site(t, false)
+	_, err = Crawl(context.Background(), server.URL+"/missing", Options{Client: server.Client()})
+	assert.ErrorContains(t, err, "404 Not Found")
+}
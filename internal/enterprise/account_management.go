@@ -0,0 +1,471 @@
+package enterprise
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"time"
+
+	"panoptic/internal/logger"
+	"panoptic/internal/notify"
+)
+
+// Account token kinds
+const (
+	TokenInvitation    = "invitation"
+	TokenPasswordReset = "password_reset"
+)
+
+// Default account token lifetimes
+const (
+	DefaultInvitationExpiry    = 72 // hours
+	DefaultPasswordResetExpiry = 60 // minutes
+)
+
+// AccountToken is an invitation or password reset. Only the hash of the
+// token is kept; the token itself goes to the user.
+type AccountToken struct {
+	Kind      string     `json:"kind"` // invitation, password_reset
+	UserID    string     `json:"user_id,omitempty"`
+	Email     string     `json:"email"`
+	Role      string     `json:"role,omitempty"`
+	FirstName string     `json:"first_name,omitempty"`
+	LastName  string     `json:"last_name,omitempty"`
+	CreatedBy string     `json:"created_by,omitempty"`
+	CreatedAt time.Time  `json:"created_at"`
+	ExpiresAt time.Time  `json:"expires_at"`
+	UsedAt    *time.Time `json:"used_at,omitempty"`
+}
+
+// Mailer sends the invitation and password reset emails
+type Mailer interface {
+	Send(ctx context.Context, to []string, subject, body string) error
+}
+
+// EmailConfig contains the SMTP server account emails are sent through
+type EmailConfig struct {
+	Enabled  bool   `yaml:"enabled"`
+	Host     string `yaml:"host"`
+	Port     int    `yaml:"port"` // defaults to 587
+	Username string `yaml:"username"`
+	Password string `yaml:"password"`
+	From     string `yaml:"from"`
+}
+
+// AccountManagement invites users, resets forgotten passwords by email and
+// changes passwords, holding them to the password policy and history
+type AccountManagement struct {
+	Manager *EnterpriseManager
+	Logger  logger.Logger
+}
+
+// NewAccountManagement creates new account management handler
+func NewAccountManagement(manager *EnterpriseManager) *AccountManagement {
+	return &AccountManagement{
+		Manager: manager,
+		Logger:  manager.Logger,
+	}
+}
+
+// Invite invites someone to sign up with the given role. The invitation
+// token is emailed when email is set up, and returned otherwise for the
+// inviter to pass on.
+func (am *AccountManagement) Invite(ctx context.Context, req InviteUserRequest) (*InvitationResponse, error) {
+	if req.Email == "" {
+		return nil, fmt.Errorf("email is required")
+	}
+	inviter, err := NewUserManagement(am.Manager).GetUser(ctx, req.InvitedBy)
+	if err != nil {
+		return nil, fmt.Errorf("invited_by: %w", err)
+	}
+	if !inviter.Active || (!am.Manager.hasPermission(inviter, "user.create") && !am.Manager.hasPermission(inviter, "system.admin")) {
+		return nil, fmt.Errorf("user %s may not invite users", inviter.Username)
+	}
+	if am.findByEmail(req.Email) != nil {
+		return nil, fmt.Errorf("user with email '%s' already exists", req.Email)
+	}
+	role := req.Role
+	if role == "" {
+		role = am.Manager.Config.DefaultRole
+	}
+	if _, exists := am.Manager.Roles[role]; !exists {
+		return nil, fmt.Errorf("role not found: %s", role)
+	}
+
+	expiry := am.Manager.Config.InvitationExpiry
+	if expiry <= 0 {
+		expiry = DefaultInvitationExpiry
+	}
+	token, record := am.issueToken(AccountToken{
+		Kind:      TokenInvitation,
+		Email:     req.Email,
+		Role:      role,
+		FirstName: req.FirstName,
+		LastName:  req.LastName,
+		CreatedBy: inviter.Username,
+	}, time.Duration(expiry)*time.Hour)
+
+	response := &InvitationResponse{Email: req.Email, Role: role, ExpiresAt: record.ExpiresAt}
+	if mailer := am.Manager.mailer(); mailer != nil {
+		body := fmt.Sprintf("%s invited you to Panoptic%s as %s.\n\nAccept the invitation, choosing your username and password, with\n\n"+
+			"    panoptic enterprise invitation-accept --param token=%s,username=<username>,password=<password>\n\n"+
+			"The invitation expires at %s.\n",
+			inviter.Username, am.organization(), role, token, record.ExpiresAt.Format(time.RFC1123))
+		if err := mailer.Send(ctx, []string{req.Email}, "You're invited to Panoptic", body); err != nil {
+			return nil, fmt.Errorf("failed to send the invitation: %w", err)
+		}
+		response.Sent = true
+	} else {
+		response.Token = token
+	}
+
+	am.Manager.logAuditEntry(AuditEntry{
+		Timestamp: time.Now(),
+		UserID:    inviter.ID,
+		Username:  inviter.Username,
+		Action:    "user.invite",
+		Resource:  "user",
+		Details:   map[string]string{"email": req.Email, "role": role},
+		Success:   true,
+		Severity:  "medium",
+		Category:  "auth",
+	})
+	if err := am.Manager.saveData(); err != nil {
+		return nil, fmt.Errorf("failed to save invitation: %w", err)
+	}
+	return response, nil
+}
+
+// AcceptInvitation creates the account of an invitation with the username
+// and password the invitee chose
+func (am *AccountManagement) AcceptInvitation(ctx context.Context, req AcceptInvitationRequest) (*User, error) {
+	key, invitation, err := am.redeem(req.Token, TokenInvitation)
+	if err != nil {
+		return nil, err
+	}
+	firstName, lastName := req.FirstName, req.LastName
+	if firstName == "" {
+		firstName = invitation.FirstName
+	}
+	if lastName == "" {
+		lastName = invitation.LastName
+	}
+	user, err := NewUserManagement(am.Manager).CreateUser(ctx, CreateUserRequest{
+		Username:  req.Username,
+		Email:     invitation.Email,
+		FirstName: firstName,
+		LastName:  lastName,
+		Password:  req.Password,
+		Role:      invitation.Role,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	am.markUsed(key)
+	am.Manager.logAuditEntry(AuditEntry{
+		Timestamp:  time.Now(),
+		UserID:     user.ID,
+		Username:   user.Username,
+		Action:     "user.invite_accept",
+		Resource:   "user",
+		ResourceID: user.ID,
+		Details:    map[string]string{"email": user.Email, "role": user.Role, "invited_by": invitation.CreatedBy},
+		Success:    true,
+		Severity:   "medium",
+		Category:   "auth",
+	})
+	if err := am.Manager.saveData(); err != nil {
+		return nil, fmt.Errorf("failed to save user data: %w", err)
+	}
+	return user, nil
+}
+
+// RequestPasswordReset emails an active user, found by username or email,
+// a token to reset their password with. Unknown users get no email and no
+// error, so the request doesn't tell who has an account.
+func (am *AccountManagement) RequestPasswordReset(ctx context.Context, identifier string) error {
+	if identifier == "" {
+		return fmt.Errorf("user is required")
+	}
+	mailer := am.Manager.mailer()
+	if mailer == nil {
+		return fmt.Errorf("password reset needs integration.email to send the reset token")
+	}
+	user := am.findByEmail(identifier)
+	if user == nil {
+		user, _ = NewUserManagement(am.Manager).GetUser(ctx, identifier)
+	}
+	if user == nil || !user.Active || user.Email == "" {
+		am.Logger.Infof("Password reset requested for unknown or inactive user %s", identifier)
+		return nil
+	}
+
+	// A new request replaces the user's earlier ones
+	for key, record := range am.Manager.AccountTokens {
+		if record.Kind == TokenPasswordReset && record.UserID == user.ID {
+			delete(am.Manager.AccountTokens, key)
+		}
+	}
+	expiry := am.Manager.Config.PasswordResetExpiry
+	if expiry <= 0 {
+		expiry = DefaultPasswordResetExpiry
+	}
+	token, record := am.issueToken(AccountToken{Kind: TokenPasswordReset, UserID: user.ID, Email: user.Email}, time.Duration(expiry)*time.Minute)
+
+	body := fmt.Sprintf("Someone asked to reset the Panoptic password of %s%s. If it wasn't you, ignore this email.\n\n"+
+		"Reset it with\n\n    panoptic enterprise password-reset --param token=%s,password=<new password>\n\n"+
+		"The token expires at %s.\n",
+		user.Username, am.organization(), token, record.ExpiresAt.Format(time.RFC1123))
+	if err := mailer.Send(ctx, []string{user.Email}, "Reset your Panoptic password", body); err != nil {
+		return fmt.Errorf("failed to send the reset email: %w", err)
+	}
+
+	am.Manager.logAuditEntry(AuditEntry{
+		Timestamp:  time.Now(),
+		UserID:     user.ID,
+		Username:   user.Username,
+		Action:     "auth.password_reset_request",
+		Resource:   "user",
+		ResourceID: user.ID,
+		Details:    map[string]string{"email": user.Email},
+		Success:    true,
+		Severity:   "medium",
+		Category:   "auth",
+	})
+	if err := am.Manager.saveData(); err != nil {
+		return fmt.Errorf("failed to save password reset: %w", err)
+	}
+	return nil
+}
+
+// ResetPassword sets a new password with a reset token and signs the user
+// out of their sessions
+func (am *AccountManagement) ResetPassword(ctx context.Context, req ResetPasswordRequest) error {
+	key, reset, err := am.redeem(req.Token, TokenPasswordReset)
+	if err != nil {
+		return err
+	}
+	user, err := NewUserManagement(am.Manager).GetUser(ctx, reset.UserID)
+	if err != nil {
+		return err
+	}
+	if !user.Active {
+		return fmt.Errorf("account is inactive")
+	}
+	if err := am.setPassword(user, req.Password); err != nil {
+		return err
+	}
+	am.markUsed(key)
+	am.Manager.revokeSessions(user, RevokeReasonPasswordReset)
+
+	am.logPasswordChange("auth.password_reset", user)
+	if err := am.Manager.saveData(); err != nil {
+		return fmt.Errorf("failed to save user data: %w", err)
+	}
+	return nil
+}
+
+// ChangePassword changes the password of a user who knows the current one,
+// as users whose password must change do before they can sign in
+func (am *AccountManagement) ChangePassword(ctx context.Context, req ChangePasswordRequest) error {
+	user, err := NewUserManagement(am.Manager).GetUser(ctx, req.Username)
+	if err != nil || !am.Manager.verifyPassword(req.CurrentPassword, user.PasswordHash) {
+		details := map[string]string{"reason": "invalid_password"}
+		entry := AuditEntry{Timestamp: time.Now(), Username: req.Username, Action: "auth.password_change", Resource: "user",
+			Details: details, Success: false, Severity: "medium", Category: "auth"}
+		if user != nil {
+			entry.UserID, entry.ResourceID = user.ID, user.ID
+		} else {
+			details["reason"] = "user_not_found"
+		}
+		am.Manager.logAuditEntry(entry)
+		return fmt.Errorf("invalid credentials")
+	}
+	if !user.Active {
+		return fmt.Errorf("account is inactive")
+	}
+	if err := am.setPassword(user, req.NewPassword); err != nil {
+		return err
+	}
+	am.Manager.revokeSessions(user, RevokeReasonPasswordChange)
+
+	am.logPasswordChange("auth.password_change", user)
+	if err := am.Manager.saveData(); err != nil {
+		return fmt.Errorf("failed to save user data: %w", err)
+	}
+	return nil
+}
+
+// Request types
+
+type InviteUserRequest struct {
+	Email     string `json:"email"`
+	Role      string `json:"role,omitempty"` // the default role when empty
+	FirstName string `json:"first_name,omitempty"`
+	LastName  string `json:"last_name,omitempty"`
+	InvitedBy string `json:"invited_by"` // username or ID
+}
+
+type InvitationResponse struct {
+	Email     string    `json:"email"`
+	Role      string    `json:"role"`
+	ExpiresAt time.Time `json:"expires_at"`
+	Sent      bool      `json:"sent"`            // emailed to the invitee
+	Token     string    `json:"token,omitempty"` // when not emailed
+}
+
+type AcceptInvitationRequest struct {
+	Token     string `json:"token"`
+	Username  string `json:"username"`
+	Password  string `json:"password"`
+	FirstName string `json:"first_name,omitempty"` // those of the invitation by default
+	LastName  string `json:"last_name,omitempty"`
+}
+
+type ResetPasswordRequest struct {
+	Token    string `json:"token"`
+	Password string `json:"password"`
+}
+
+type ChangePasswordRequest struct {
+	Username        string `json:"username"`
+	CurrentPassword string `json:"current_password"`
+	NewPassword     string `json:"new_password"`
+}
+
+// Helper methods
+
+// setPassword sets a new password that meets the password policy and is
+// neither the current one nor one of the max_history before it
+func (am *AccountManagement) setPassword(user *User, password string) error {
+	if password == "" {
+		return fmt.Errorf("password is required")
+	}
+	if err := am.Manager.validatePassword(password); err != nil {
+		return err
+	}
+	if am.Manager.verifyPassword(password, user.PasswordHash) {
+		return fmt.Errorf("the new password must differ from the current one")
+	}
+	maxHistory := am.Manager.Config.PasswordPolicy.MaxHistory
+	history := user.PasswordHistory[:min(maxHistory, len(user.PasswordHistory))]
+	for _, previous := range history {
+		if am.Manager.verifyPassword(password, previous) {
+			return fmt.Errorf("the new password was used recently; choose one that isn't among the last %d", maxHistory)
+		}
+	}
+
+	hash, err := am.Manager.hashPassword(password)
+	if err != nil {
+		return fmt.Errorf("failed to hash password: %w", err)
+	}
+	if maxHistory > 0 && user.PasswordHash != "" {
+		user.PasswordHistory = append([]string{user.PasswordHash}, history...)[:min(maxHistory, len(history)+1)]
+	} else {
+		user.PasswordHistory = nil
+	}
+	user.PasswordHash = hash
+	user.MustChangePassword = false
+	user.UpdatedAt = time.Now()
+	return nil
+}
+
+// issueToken stores a new account token, dropping the used and expired ones
+func (am *AccountManagement) issueToken(record AccountToken, expiry time.Duration) (string, *AccountToken) {
+	now := time.Now()
+	if am.Manager.AccountTokens == nil {
+		am.Manager.AccountTokens = make(map[string]*AccountToken)
+	}
+	for key, existing := range am.Manager.AccountTokens {
+		if existing.UsedAt != nil || now.After(existing.ExpiresAt) {
+			delete(am.Manager.AccountTokens, key)
+		}
+	}
+
+	secret := make([]byte, 32)
+	rand.Read(secret)
+	token := base64.RawURLEncoding.EncodeToString(secret)
+	record.CreatedAt = now
+	record.ExpiresAt = now.Add(expiry)
+	am.Manager.AccountTokens[tokenKey(token)] = &record
+	return token, &record
+}
+
+// redeem finds an unused, unexpired token of a kind
+func (am *AccountManagement) redeem(token, kind string) (string, *AccountToken, error) {
+	if token == "" {
+		return "", nil, fmt.Errorf("token is required")
+	}
+	key := tokenKey(token)
+	record, exists := am.Manager.AccountTokens[key]
+	if !exists || record.Kind != kind {
+		return "", nil, fmt.Errorf("invalid %s token", strings.ReplaceAll(kind, "_", " "))
+	}
+	if record.UsedAt != nil {
+		return "", nil, fmt.Errorf("%s token was already used", strings.ReplaceAll(kind, "_", " "))
+	}
+	if time.Now().After(record.ExpiresAt) {
+		return "", nil, fmt.Errorf("%s token expired at %s", strings.ReplaceAll(kind, "_", " "), record.ExpiresAt.Format(time.RFC3339))
+	}
+	return key, record, nil
+}
+
+func (am *AccountManagement) markUsed(key string) {
+	now := time.Now()
+	am.Manager.AccountTokens[key].UsedAt = &now
+}
+
+// findByEmail finds the user with an email address, nil when there's none
+func (am *AccountManagement) findByEmail(email string) *User {
+	for _, user := range am.Manager.Users {
+		if user.Email != "" && strings.EqualFold(user.Email, email) {
+			return user
+		}
+	}
+	return nil
+}
+
+func (am *AccountManagement) organization() string {
+	if name := am.Manager.Config.OrganizationName; name != "" {
+		return " at " + name
+	}
+	return ""
+}
+
+func (am *AccountManagement) logPasswordChange(action string, user *User) {
+	am.Manager.logAuditEntry(AuditEntry{
+		Timestamp:  time.Now(),
+		UserID:     user.ID,
+		Username:   user.Username,
+		Action:     action,
+		Resource:   "user",
+		ResourceID: user.ID,
+		Success:    true,
+		Severity:   "medium",
+		Category:   "auth",
+	})
+}
+
+// mailer is the mailer account emails are sent with: the one set on the
+// manager, or the SMTP server of integration.email; nil without either
+func (em *EnterpriseManager) mailer() Mailer {
+	if em.Mailer != nil {
+		return em.Mailer
+	}
+	email := em.Config.Integration.Email
+	if !email.Enabled {
+		return nil
+	}
+	return notify.NewEmail(email.Host, email.Port, email.Username, email.Password, email.From)
+}
+
+// tokenKey is the hash an account token is stored under
+func tokenKey(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,216 @@
+package enterprise
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"panoptic/internal/logger"
+)
+
+// sentEmail is an email the recordingMailer was asked to send
+type sentEmail struct {
+	to            []string
+	subject, body string
+}
+
+type recordingMailer struct {
+	sent []sentEmail
+	err  error
+}
+
+func (m *recordingMailer) Send(ctx context.Context, to []string, subject, body string) error {
+	if m.err != nil {
+		return m.err
+	}
+	m.sent = append(m.sent, sentEmail{to, subject, body})
+	return nil
+}
+
+var emailToken = regexp.MustCompile(`token=([A-Za-z0-9_-]+),`)
+
+// lastToken is the token of the last email sent
+func (m *recordingMailer) lastToken(t *testing.T) string {
+	t.Helper()
+	require.NotEmpty(t, m.sent)
+	match := emailToken.FindStringSubmatch(m.sent[len(m.sent)-1].body)
+	require.NotNil(t, match, m.sent[len(m.sent)-1].body)
+	return match[1]
+}
+
+// newAccountManagement is an account manager with an admin, a developer and
+// a mailer recording what it sends
+func newAccountManagement(t *testing.T) (*AccountManagement, *recordingMailer) {
+	t.Helper()
+	em := NewEnterpriseManager(*logger.NewLogger(false))
+	em.StoragePath = t.TempDir()
+	em.Enabled = true
+	em.Config.DefaultRole = "developer"
+	em.Config.OrganizationName = "Shop"
+	em.Config.PasswordPolicy = PasswordPolicy{MinLength: 8, MaxHistory: 2}
+	require.NoError(t, em.initializeDefaultRoles())
+	mailer := &recordingMailer{}
+	em.Mailer = mailer
+
+	um := NewUserManagement(em)
+	for _, req := range []CreateUserRequest{
+		{Username: "root", Email: "root@shop.example", FirstName: "Ro", LastName: "Ot", Password: "rootpass1", Role: "admin"},
+		{Username: "ana", Email: "ana@shop.example", FirstName: "Ana", LastName: "Lee", Password: "anapass1", Role: "developer"},
+	} {
+		_, err := um.CreateUser(context.Background(), req)
+		require.NoError(t, err)
+	}
+	return NewAccountManagement(em), mailer
+}
+
+func TestAccountManagement_Invite(t *testing.T) {
+	am, mailer := newAccountManagement(t)
+	ctx := context.Background()
+
+	invitation, err := am.Invite(ctx, InviteUserRequest{Email: "bo@shop.example", FirstName: "Bo", LastName: "Berg", InvitedBy: "root"})
+	require.NoError(t, err)
+	assert.True(t, invitation.Sent)
+	assert.Empty(t, invitation.Token, "emailed tokens aren't returned")
+	assert.Equal(t, "developer", invitation.Role)
+	assert.WithinDuration(t, time.Now().Add(72*time.Hour), invitation.ExpiresAt, time.Minute)
+	assert.Equal(t, []string{"bo@shop.example"}, mailer.sent[0].to)
+	assert.Contains(t, mailer.sent[0].body, "root invited you to Panoptic at Shop as developer.")
+
+	token := mailer.lastToken(t)
+	user, err := am.AcceptInvitation(ctx, AcceptInvitationRequest{Token: token, Username: "bo", Password: "bopass12"})
+	require.NoError(t, err)
+	assert.Equal(t, "bo@shop.example", user.Email)
+	assert.Equal(t, "Bo", user.FirstName, "the names of the invitation are the defaults")
+	assert.True(t, user.Permissions["test.run"])
+	_, err = NewUserManagement(am.Manager).AuthenticateUser(ctx, "bo", "bopass12")
+	assert.NoError(t, err)
+
+	_, err = am.AcceptInvitation(ctx, AcceptInvitationRequest{Token: token, Username: "bo2", Password: "bopass12"})
+	assert.EqualError(t, err, "invitation token was already used")
+	_, err = am.AcceptInvitation(ctx, AcceptInvitationRequest{Token: "forged", Username: "eve", Password: "evepass1"})
+	assert.EqualError(t, err, "invalid invitation token")
+
+	// Without email the inviter gets the token
+	am.Manager.Mailer = nil
+	invitation, err = am.Invite(ctx, InviteUserRequest{Email: "cy@shop.example", Role: "viewer", InvitedBy: "u-none"})
+	assert.EqualError(t, err, "invited_by: user not found: u-none")
+	invitation, err = am.Invite(ctx, InviteUserRequest{Email: "cy@shop.example", Role: "viewer", InvitedBy: "root"})
+	require.NoError(t, err)
+	assert.False(t, invitation.Sent)
+	assert.NotEmpty(t, invitation.Token)
+
+	// Expired invitations can't be accepted
+	am.Manager.AccountTokens[tokenKey(invitation.Token)].ExpiresAt = time.Now().Add(-time.Minute)
+	_, err = am.AcceptInvitation(ctx, AcceptInvitationRequest{Token: invitation.Token, Username: "cy", FirstName: "Cy", LastName: "Sun", Password: "cypass12"})
+	assert.ErrorContains(t, err, "invitation token expired at ")
+
+	_, err = am.Invite(ctx, InviteUserRequest{Email: "dee@shop.example", InvitedBy: "ana"})
+	assert.EqualError(t, err, "user ana may not invite users")
+	_, err = am.Invite(ctx, InviteUserRequest{Email: "ANA@shop.example", InvitedBy: "root"})
+	assert.EqualError(t, err, "user with email 'ANA@shop.example' already exists")
+	_, err = am.Invite(ctx, InviteUserRequest{Email: "dee@shop.example", Role: "owner", InvitedBy: "root"})
+	assert.EqualError(t, err, "role not found: owner")
+}
+
+func TestAccountManagement_ResetPassword(t *testing.T) {
+	am, mailer := newAccountManagement(t)
+	ctx := context.Background()
+	um := NewUserManagement(am.Manager)
+	session, err := um.AuthenticateUser(ctx, "ana", "anapass1")
+	require.NoError(t, err)
+
+	require.NoError(t, am.RequestPasswordReset(ctx, "nobody@shop.example"))
+	assert.Empty(t, mailer.sent, "unknown users get no email, and no error")
+
+	require.NoError(t, am.RequestPasswordReset(ctx, "ana@shop.example"))
+	first := mailer.lastToken(t)
+	require.NoError(t, am.RequestPasswordReset(ctx, "ana"))
+	token := mailer.lastToken(t)
+	assert.Equal(t, "Reset your Panoptic password", mailer.sent[1].subject)
+
+	assert.EqualError(t, am.ResetPassword(ctx, ResetPasswordRequest{Token: first, Password: "newpass12"}),
+		"invalid password reset token", "a new request replaces the earlier one")
+	assert.EqualError(t, am.ResetPassword(ctx, ResetPasswordRequest{Token: token, Password: "short"}),
+		"password must be at least 8 characters")
+	assert.EqualError(t, am.ResetPassword(ctx, ResetPasswordRequest{Token: token, Password: "anapass1"}),
+		"the new password must differ from the current one")
+	require.NoError(t, am.ResetPassword(ctx, ResetPasswordRequest{Token: token, Password: "newpass12"}))
+
+	assert.False(t, am.Manager.Sessions[session.ID].Active, "resetting signs the user out")
+	_, err = um.AuthenticateUser(ctx, "ana", "newpass12")
+	assert.NoError(t, err)
+	assert.EqualError(t, am.ResetPassword(ctx, ResetPasswordRequest{Token: token, Password: "other123"}),
+		"password reset token was already used")
+
+	am.Manager.Mailer = &recordingMailer{err: errors.New("connection refused")}
+	assert.EqualError(t, am.RequestPasswordReset(ctx, "ana"), "failed to send the reset email: connection refused")
+	am.Manager.Mailer = nil
+	assert.EqualError(t, am.RequestPasswordReset(ctx, "ana"), "password reset needs integration.email to send the reset token")
+}
+
+func TestAccountManagement_ChangePassword(t *testing.T) {
+	am, _ := newAccountManagement(t)
+	ctx := context.Background()
+	um := NewUserManagement(am.Manager)
+
+	// Temporary passwords must be changed before signing in
+	_, err := um.CreateUser(ctx, CreateUserRequest{Username: "bo", Email: "bo@shop.example", FirstName: "Bo", LastName: "Berg",
+		Password: "temporary1", Role: "developer", MustChangePassword: true})
+	require.NoError(t, err)
+	_, err = um.AuthenticateUser(ctx, "bo", "temporary1")
+	assert.EqualError(t, err, "user bo must change their password before signing in")
+
+	assert.EqualError(t, am.ChangePassword(ctx, ChangePasswordRequest{Username: "bo", CurrentPassword: "wrong", NewPassword: "bopass12"}),
+		"invalid credentials")
+	require.NoError(t, am.ChangePassword(ctx, ChangePasswordRequest{Username: "bo", CurrentPassword: "temporary1", NewPassword: "bopass12"}))
+	_, err = um.AuthenticateUser(ctx, "bo", "bopass12")
+	require.NoError(t, err)
+
+	// Neither of the last max_history passwords can be reused
+	require.NoError(t, am.ChangePassword(ctx, ChangePasswordRequest{Username: "bo", CurrentPassword: "bopass12", NewPassword: "bopass34"}))
+	assert.EqualError(t, am.ChangePassword(ctx, ChangePasswordRequest{Username: "bo", CurrentPassword: "bopass34", NewPassword: "temporary1"}),
+		"the new password was used recently; choose one that isn't among the last 2")
+	require.NoError(t, am.ChangePassword(ctx, ChangePasswordRequest{Username: "bo", CurrentPassword: "bopass34", NewPassword: "bopass56"}))
+	require.NoError(t, am.ChangePassword(ctx, ChangePasswordRequest{Username: "bo", CurrentPassword: "bopass56", NewPassword: "temporary1"}),
+		"passwords older than max_history can be used again")
+	assert.Len(t, am.Manager.Users["bo"].PasswordHistory, 2)
+
+	entry := am.Manager.AuditLog[len(am.Manager.AuditLog)-1]
+	assert.Equal(t, "auth.password_change", entry.Action)
+	assert.True(t, entry.Success)
+}
+
+func TestAccountManagement_Actions(t *testing.T) {
+	am, mailer := newAccountManagement(t)
+	ctx := context.Background()
+	ei := &EnterpriseIntegration{Manager: am.Manager, AccountManagement: am, Initialized: true}
+
+	result, err := ei.ExecuteEnterpriseAction(ctx, "user_invite", map[string]interface{}{"email": "bo@shop.example", "invited_by": "root"})
+	require.NoError(t, err)
+	assert.True(t, result.(*InvitationResponse).Sent)
+	result, err = ei.ExecuteEnterpriseAction(ctx, "invitation_accept", map[string]interface{}{
+		"token": mailer.lastToken(t), "username": "bo", "first_name": "Bo", "last_name": "Berg", "password": "bopass12",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "bo", result.(map[string]interface{})["username"])
+
+	_, err = ei.ExecuteEnterpriseAction(ctx, "password_reset_request", map[string]interface{}{"user": "bo"})
+	require.NoError(t, err)
+	_, err = ei.ExecuteEnterpriseAction(ctx, "password_reset", map[string]interface{}{"token": mailer.lastToken(t), "password": "bopass34"})
+	require.NoError(t, err)
+	_, err = ei.ExecuteEnterpriseAction(ctx, "password_change", map[string]interface{}{
+		"username": "bo", "current_password": "bopass34", "new_password": "bopass56",
+	})
+	require.NoError(t, err)
+
+	// The tokens are kept with the rest of the enterprise data
+	require.NoError(t, am.Manager.saveData())
+	loaded := NewEnterpriseManager(*logger.NewLogger(false))
+	loaded.StoragePath = am.Manager.StoragePath
+	require.NoError(t, loaded.loadData())
+	assert.Len(t, loaded.AccountTokens, 1, "the used invitation was dropped when the reset was issued")
+}
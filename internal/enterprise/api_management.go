@@ -323,6 +323,11 @@ func (am *APIManagement) ValidateAPIKey(ctx context.Context, key, secret string)
 		return nil, fmt.Errorf("invalid API key secret")
 	}
 
+	// Meter the call against the user's subscription
+	if err := NewUsageManagement(am.Manager).RecordAPICall(ctx, apiKey.UserID); err != nil {
+		return nil, err
+	}
+
 	// Update usage statistics
 	now := time.Now()
 	apiKey.LastUsed = &now
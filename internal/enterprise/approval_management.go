@@ -0,0 +1,255 @@
+package enterprise
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"panoptic/internal/logger"
+)
+
+// Approval statuses
+const (
+	ApprovalPending  = "pending"
+	ApprovalApproved = "approved"
+	ApprovalRejected = "rejected"
+	ApprovalExecuted = "executed"
+)
+
+// Approval is a run against protected URLs, held until an approver decides
+// on it. An approved run executes once; running it again asks anew.
+type Approval struct {
+	ID          string     `json:"id"`
+	Config      string     `json:"config"`
+	ProjectID   string     `json:"project_id,omitempty"`
+	URLs        []string   `json:"urls"` // the protected URLs the run targets
+	RequestedBy string     `json:"requested_by,omitempty"`
+	RequestedAt time.Time  `json:"requested_at"`
+	Status      string     `json:"status"`
+	DecidedBy   string     `json:"decided_by,omitempty"`
+	DecidedAt   *time.Time `json:"decided_at,omitempty"`
+	Comment     string     `json:"comment,omitempty"`
+	RunID       string     `json:"run_id,omitempty"` // the run that executed it
+	ExecutedAt  *time.Time `json:"executed_at,omitempty"`
+}
+
+// ApprovalManagement holds the runs that target the protected_urls of the
+// compliance settings for approval when require_approval is set
+type ApprovalManagement struct {
+	Manager *EnterpriseManager
+	Logger  logger.Logger
+}
+
+// NewApprovalManagement creates new approval management handler
+func NewApprovalManagement(manager *EnterpriseManager) *ApprovalManagement {
+	return &ApprovalManagement{
+		Manager: manager,
+		Logger:  manager.Logger,
+	}
+}
+
+// ProtectedURLs returns the URLs among targets that need approval, none
+// unless require_approval is set
+func (am *ApprovalManagement) ProtectedURLs(targets []string) []string {
+	compliance := am.Manager.Config.Compliance
+	if !compliance.RequireApproval {
+		return nil
+	}
+	var protected []string
+	for _, target := range targets {
+		for _, pattern := range compliance.ProtectedURLs {
+			if matchURLPattern(pattern, target) {
+				protected = append(protected, target)
+				break
+			}
+		}
+	}
+	return protected
+}
+
+// StartRun lets a run against protected URLs start if an approver approved
+// it, marking the approval executed. Otherwise the run is queued for
+// approval, or left waiting on its pending request, and an error says so.
+func (am *ApprovalManagement) StartRun(ctx context.Context, req RunApprovalRequest) (*Approval, error) {
+	if len(req.URLs) == 0 {
+		return nil, fmt.Errorf("run targets no protected URLs")
+	}
+	urls := append([]string(nil), req.URLs...)
+	sort.Strings(urls)
+
+	var pending *Approval
+	for _, approval := range am.Manager.Approvals {
+		if approval.Config != req.Config || approval.ProjectID != req.Project || approval.RequestedBy != req.User || !slices.Equal(approval.URLs, urls) {
+			continue
+		}
+		switch approval.Status {
+		case ApprovalApproved:
+			now := time.Now()
+			approval.Status = ApprovalExecuted
+			approval.RunID = req.RunID
+			approval.ExecutedAt = &now
+			am.logApproval("approval.execute", approval, req.User, "low")
+			if err := am.Manager.saveData(); err != nil {
+				return nil, fmt.Errorf("failed to save approval data: %w", err)
+			}
+			return approval, nil
+		case ApprovalPending:
+			pending = approval
+		}
+	}
+	if pending != nil {
+		return nil, fmt.Errorf("run of %s targets protected URLs and awaits approval %s", req.Config, pending.ID)
+	}
+
+	approval := &Approval{
+		ID:          uuid.New().String(),
+		Config:      req.Config,
+		ProjectID:   req.Project,
+		URLs:        urls,
+		RequestedBy: req.User,
+		RequestedAt: time.Now(),
+		Status:      ApprovalPending,
+	}
+	am.Manager.Approvals[approval.ID] = approval
+	am.logApproval("approval.request", approval, req.User, "medium")
+	if err := am.Manager.saveData(); err != nil {
+		return nil, fmt.Errorf("failed to save approval data: %w", err)
+	}
+	am.Logger.Infof("Run of %s queued for approval %s", req.Config, approval.ID)
+	return nil, fmt.Errorf("run of %s targets protected URLs %s and was queued for approval %s", req.Config, strings.Join(urls, ", "), approval.ID)
+}
+
+// Decide approves or rejects a pending approval. The approver needs the
+// run.approve permission and can't decide on their own request.
+func (am *ApprovalManagement) Decide(ctx context.Context, req DecideApprovalRequest) (*Approval, error) {
+	approval, exists := am.Manager.Approvals[req.ID]
+	if !exists {
+		return nil, fmt.Errorf("approval not found: %s", req.ID)
+	}
+	if approval.Status != ApprovalPending {
+		return nil, fmt.Errorf("approval %s is already %s", approval.ID, approval.Status)
+	}
+	approver, err := am.findApprover(req.Approver)
+	if err != nil {
+		return nil, err
+	}
+	if approval.RequestedBy != "" && (approval.RequestedBy == approver.Username || approval.RequestedBy == approver.ID) {
+		return nil, fmt.Errorf("user %s can't decide on their own run", approver.Username)
+	}
+
+	now := time.Now()
+	approval.Status = ApprovalRejected
+	action := "approval.reject"
+	if req.Approve {
+		approval.Status = ApprovalApproved
+		action = "approval.approve"
+	}
+	approval.DecidedBy = approver.Username
+	approval.DecidedAt = &now
+	approval.Comment = req.Comment
+	am.logApproval(action, approval, approver.Username, "high")
+
+	if err := am.Manager.saveData(); err != nil {
+		return nil, fmt.Errorf("failed to save approval data: %w", err)
+	}
+	return approval, nil
+}
+
+// ListApprovals lists approvals, newest first, of one status or all
+func (am *ApprovalManagement) ListApprovals(ctx context.Context, status string) []*Approval {
+	approvals := []*Approval{}
+	for _, approval := range am.Manager.Approvals {
+		if status == "" || approval.Status == status {
+			approvals = append(approvals, approval)
+		}
+	}
+	sort.Slice(approvals, func(i, j int) bool { return approvals[i].RequestedAt.After(approvals[j].RequestedAt) })
+	return approvals
+}
+
+// Request types
+
+type RunApprovalRequest struct {
+	Config  string   `json:"config"`
+	Project string   `json:"project,omitempty"` // project ID
+	User    string   `json:"user,omitempty"`
+	URLs    []string `json:"urls"`
+	RunID   string   `json:"run_id"`
+}
+
+type DecideApprovalRequest struct {
+	ID       string `json:"id"`
+	Approver string `json:"approver"` // username or ID
+	Approve  bool   `json:"approve"`
+	Comment  string `json:"comment,omitempty"`
+}
+
+// Helper methods
+
+// findApprover finds an active user allowed to approve runs
+func (am *ApprovalManagement) findApprover(identifier string) (*User, error) {
+	if identifier == "" {
+		return nil, fmt.Errorf("approver is required")
+	}
+	user, exists := am.Manager.Users[identifier]
+	if !exists {
+		for _, candidate := range am.Manager.Users {
+			if candidate.ID == identifier {
+				user = candidate
+				break
+			}
+		}
+	}
+	if user == nil {
+		return nil, fmt.Errorf("user not found: %s", identifier)
+	}
+	if !user.Active {
+		return nil, fmt.Errorf("user %s is inactive", user.Username)
+	}
+	if !am.Manager.hasPermission(user, "run.approve") && !am.Manager.hasPermission(user, "system.admin") {
+		return nil, fmt.Errorf("user %s may not approve runs; give them the approver role", user.Username)
+	}
+	return user, nil
+}
+
+// logApproval audits a step of an approval
+func (am *ApprovalManagement) logApproval(action string, approval *Approval, username, severity string) {
+	details := map[string]string{
+		"config": approval.Config,
+		"urls":   strings.Join(approval.URLs, ","),
+		"status": approval.Status,
+	}
+	if approval.ProjectID != "" {
+		details["project"] = approval.ProjectID
+	}
+	if approval.RunID != "" {
+		details["run_id"] = approval.RunID
+	}
+	if approval.Comment != "" {
+		details["comment"] = approval.Comment
+	}
+	am.Manager.logAuditEntry(AuditEntry{
+		Timestamp:  time.Now(),
+		Username:   username,
+		Action:     action,
+		Resource:   "approval",
+		ResourceID: approval.ID,
+		Details:    details,
+		Success:    true,
+		Severity:   severity,
+		Category:   "compliance",
+	})
+}
+
+// matchURLPattern matches a URL against a pattern in which * stands for
+// any run of characters, e.g. https://*.example.com/*
+func matchURLPattern(pattern, url string) bool {
+	expr := "^" + strings.ReplaceAll(regexp.QuoteMeta(pattern), `\*`, ".*") + "$"
+	matched, err := regexp.MatchString(expr, url)
+	return err == nil && matched
+}
@@ -0,0 +1,111 @@
+package enterprise
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"panoptic/internal/logger"
+)
+
+// newApprovalManagement protects the production shop, with ana running the
+// tests and the approver kim
+func newApprovalManagement(t *testing.T) *ApprovalManagement {
+	t.Helper()
+	em := NewEnterpriseManager(*logger.NewLogger(false))
+	em.StoragePath = t.TempDir()
+	em.Enabled = true
+	em.Config.Compliance = ComplianceConfig{RequireApproval: true, ProtectedURLs: []string{"https://shop.example.com/*", "https://*.prod.example.com*"}}
+	require.NoError(t, em.initializeDefaultRoles())
+	em.Users["ana"] = &User{ID: "u-ana", Username: "ana", Active: true, Permissions: em.Roles["developer"].Permissions}
+	em.Users["kim"] = &User{ID: "u-kim", Username: "kim", Active: true, Permissions: em.Roles["approver"].Permissions}
+	em.Users["root"] = &User{ID: "u-root", Username: "root", Active: true, Permissions: map[string]bool{"system.admin": true}}
+	return NewApprovalManagement(em)
+}
+
+func TestApprovalManagement_ProtectedURLs(t *testing.T) {
+	am := newApprovalManagement(t)
+	targets := []string{"https://shop.example.com/cart", "https://staging.example.com/", "https://api.prod.example.com", "https://shop.example.com"}
+	assert.Equal(t, []string{"https://shop.example.com/cart", "https://api.prod.example.com"}, am.ProtectedURLs(targets))
+
+	am.Manager.Config.Compliance.RequireApproval = false
+	assert.Empty(t, am.ProtectedURLs(targets), "nothing is protected without require_approval")
+}
+
+func TestApprovalManagement_Workflow(t *testing.T) {
+	am := newApprovalManagement(t)
+	ctx := context.Background()
+	req := RunApprovalRequest{Config: "Shop", User: "ana", URLs: []string{"https://shop.example.com/cart", "https://api.prod.example.com"}, RunID: "run-1"}
+
+	_, err := am.StartRun(ctx, req)
+	require.Error(t, err)
+	pending := am.ListApprovals(ctx, ApprovalPending)
+	require.Len(t, pending, 1)
+	id := pending[0].ID
+	assert.EqualError(t, err, "run of Shop targets protected URLs https://api.prod.example.com, https://shop.example.com/cart and was queued for approval "+id)
+	assert.Equal(t, "ana", pending[0].RequestedBy)
+
+	_, err = am.StartRun(ctx, req)
+	assert.EqualError(t, err, "run of Shop targets protected URLs and awaits approval "+id)
+	assert.Len(t, am.ListApprovals(ctx, ""), 1, "a pending run isn't queued twice")
+
+	_, err = am.Decide(ctx, DecideApprovalRequest{ID: id, Approver: "ana", Approve: true})
+	assert.EqualError(t, err, "user ana may not approve runs; give them the approver role")
+	am.Manager.Users["ana"].Permissions = map[string]bool{"run.approve": true}
+	_, err = am.Decide(ctx, DecideApprovalRequest{ID: id, Approver: "ana", Approve: true})
+	assert.EqualError(t, err, "user ana can't decide on their own run")
+
+	approval, err := am.Decide(ctx, DecideApprovalRequest{ID: id, Approver: "u-kim", Approve: true, Comment: "release 4.2"})
+	require.NoError(t, err)
+	assert.Equal(t, ApprovalApproved, approval.Status)
+	assert.Equal(t, "kim", approval.DecidedBy)
+	_, err = am.Decide(ctx, DecideApprovalRequest{ID: id, Approver: "root"})
+	assert.EqualError(t, err, "approval "+id+" is already approved")
+
+	approval, err = am.StartRun(ctx, req)
+	require.NoError(t, err)
+	assert.Equal(t, ApprovalExecuted, approval.Status)
+	assert.Equal(t, "run-1", approval.RunID)
+
+	_, err = am.StartRun(ctx, req)
+	assert.ErrorContains(t, err, "was queued for approval", "an approval executes a single run")
+
+	var actions []string
+	for _, entry := range am.Manager.AuditLog {
+		if entry.ResourceID == id {
+			actions = append(actions, entry.Action)
+		}
+	}
+	assert.Equal(t, []string{"approval.request", "approval.approve", "approval.execute"}, actions)
+
+	data, err := os.ReadFile(filepath.Join(am.Manager.StoragePath, "approvals.json"))
+	require.NoError(t, err)
+	assert.Contains(t, string(data), id)
+}
+
+func TestApprovalManagement_Reject(t *testing.T) {
+	am := newApprovalManagement(t)
+	ctx := context.Background()
+	req := RunApprovalRequest{Config: "Shop", URLs: []string{"https://shop.example.com/"}}
+	_, err := am.StartRun(ctx, req)
+	require.Error(t, err)
+	id := am.ListApprovals(ctx, "")[0].ID
+
+	ei := &EnterpriseIntegration{Manager: am.Manager, ApprovalManagement: am, Initialized: true}
+	result, err := ei.ExecuteEnterpriseAction(ctx, "approval_reject", map[string]interface{}{"id": id, "approver": "root", "comment": "freeze"})
+	require.NoError(t, err)
+	assert.Equal(t, ApprovalRejected, result.(*Approval).Status)
+	assert.Equal(t, "freeze", result.(*Approval).Comment)
+
+	_, err = am.StartRun(ctx, req)
+	assert.ErrorContains(t, err, "was queued for approval", "a rejected run asks again")
+	assert.Len(t, am.ListApprovals(ctx, ApprovalPending), 1)
+
+	_, err = am.Decide(ctx, DecideApprovalRequest{ID: "missing", Approver: "kim"})
+	assert.EqualError(t, err, "approval not found: missing")
+	_, err = am.Decide(ctx, DecideApprovalRequest{ID: am.ListApprovals(ctx, ApprovalPending)[0].ID})
+	assert.EqualError(t, err, "approver is required")
+}
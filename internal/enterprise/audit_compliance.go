@@ -22,70 +22,17 @@ func NewAuditManagement(manager *EnterpriseManager) *AuditManagement {
 	}
 }
 
-// GetAuditLog retrieves audit log entries with filtering
+// GetAuditLog queries the audit log of the audit storage backend, newest
+// first, a page at a time
 func (am *AuditManagement) GetAuditLog(ctx context.Context, req GetAuditLogRequest) (*GetAuditLogResponse, error) {
-	var entries []AuditEntry
-
-	for _, entry := range am.Manager.AuditLog {
-		// Apply filters
-		if req.UserID != "" && entry.UserID != req.UserID {
-			continue
-		}
-		if req.Username != "" && entry.Username != req.Username {
-			continue
-		}
-		if req.Action != "" && entry.Action != req.Action {
-			continue
-		}
-		if req.Resource != "" && entry.Resource != req.Resource {
-			continue
-		}
-		if req.Category != "" && entry.Category != req.Category {
-			continue
-		}
-		if req.Severity != "" && entry.Severity != req.Severity {
-			continue
-		}
-		if !req.StartTime.IsZero() && entry.Timestamp.Before(req.StartTime) {
-			continue
-		}
-		if !req.EndTime.IsZero() && entry.Timestamp.After(req.EndTime) {
-			continue
-		}
-		if req.Success != nil && *req.Success != entry.Success {
-			continue
-		}
-
-		entries = append(entries, entry)
-	}
-
-	// Sort by timestamp (newest first)
-	am.sortAuditEntries(entries, false)
-
-	// Apply pagination
-	total := len(entries)
-	start := (req.Page - 1) * req.PageSize
-	end := start + req.PageSize
-	if end > total {
-		end = total
+	if !req.StartTime.IsZero() && !req.EndTime.IsZero() && req.EndTime.Before(req.StartTime) {
+		return nil, fmt.Errorf("end time %s is before start time %s", req.EndTime.Format(time.RFC3339), req.StartTime.Format(time.RFC3339))
 	}
-	if start >= total {
-		return &GetAuditLogResponse{
-			Entries: []AuditEntry{},
-			Total:   total,
-			Page:    req.Page,
-			PageSize: req.PageSize,
-		}, nil
+	response, err := am.Manager.auditStore().Query(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query audit log: %w", err)
 	}
-
-	pagedEntries := entries[start:end]
-
-	return &GetAuditLogResponse{
-		Entries: pagedEntries,
-		Total:   total,
-		Page:    req.Page,
-		PageSize: req.PageSize,
-	}, nil
+	return response, nil
 }
 
 // GetAuditSummary retrieves audit log summary statistics
@@ -183,6 +130,14 @@ func (am *AuditManagement) GetComplianceStatus(ctx context.Context, req GetCompl
 		}
 	}
 
+	if response.Status != "compliant" {
+		am.Manager.emitEvent(ctx, EventComplianceFailed, map[string]interface{}{
+			"standards": response.Standards,
+			"status":    response.Status,
+			"issues":    response.Issues,
+		})
+	}
+
 	return response, nil
 }
 
@@ -289,11 +244,7 @@ func (am *AuditManagement) GetRetentionStatus(ctx context.Context, req GetRetent
 	}
 
 	// Calculate data retention stats for different data types
-	dataTypes := []string{"users", "projects", "teams", "test_results", "reports"}
-	for _, dataType := range dataTypes {
-		stats := am.calculateDataRetentionStats(dataType)
-		response.DataStats[dataType] = stats
-	}
+	response.DataStats["runs"] = am.calculateDataRetentionStats()
 
 	return response, nil
 }
@@ -307,17 +258,14 @@ func (am *AuditManagement) ExecuteCleanup(ctx context.Context, req ExecuteCleanu
 
 	// Clean up audit log
 	if req.IncludeAudit {
-		result := am.cleanupAuditLog(req.DryRun)
+		result := am.Manager.purgeAuditEntries(am.Manager.auditRetentionCutoff(response.StartedAt), req.DryRun)
 		response.Results["audit"] = result
 	}
 
-	// Clean up data
-	dataTypes := []string{"users", "projects", "teams", "test_results", "reports"}
-	for _, dataType := range dataTypes {
-		if req.IncludeData {
-			result := am.cleanupData(dataType, req.DryRun)
-			response.Results[dataType] = result
-		}
+	// Clean up runs
+	if req.IncludeData {
+		result := am.Manager.purgeRuns(am.Manager.dataRetentionCutoff(response.StartedAt), req.DryRun)
+		response.Results["runs"] = result
 	}
 
 	response.CompletedAt = time.Now()
@@ -341,6 +289,12 @@ func (am *AuditManagement) ExecuteCleanup(ctx context.Context, req ExecuteCleanu
 	am.Logger.Infof("Data cleanup executed (dry_run: %t, duration: %s)",
 		req.DryRun, response.Duration.String())
 
+	if !req.DryRun {
+		if err := am.Manager.saveData(); err != nil {
+			return nil, fmt.Errorf("failed to save data after cleanup: %w", err)
+		}
+	}
+
 	return response, nil
 }
 
@@ -353,6 +307,7 @@ type GetAuditLogRequest struct {
 	Username  string     `json:"username,omitempty"`
 	Action    string     `json:"action,omitempty"`
 	Resource  string     `json:"resource,omitempty"`
+	ResourceID string    `json:"resource_id,omitempty"`
 	Category  string     `json:"category,omitempty"`
 	Severity  string     `json:"severity,omitempty"`
 	StartTime time.Time  `json:"start_time,omitempty"`
@@ -669,47 +624,22 @@ func (am *AuditManagement) getNewestAuditEntry() time.Time {
 	return newest
 }
 
-func (am *AuditManagement) calculateDataRetentionStats(dataType string) RetentionStats {
-	// Mock calculation for demo
-	return RetentionStats{
-		TotalEntries:    1000,
-		EntriesToRetain: 800,
-		EntriesToDelete: 200,
-		OldestEntry:    time.Now().AddDate(0, 0, -90),
-		NewestEntry:    time.Now().AddDate(0, 0, -1),
-	}
-}
-
-func (am *AuditManagement) cleanupAuditLog(dryRun bool) CleanupResult {
-	retentionDays := am.Manager.Config.Compliance.AuditRetention
-	cutoffTime := time.Now().AddDate(0, 0, -retentionDays)
-	
-	var entriesToDelete []AuditEntry
-	for _, entry := range am.Manager.AuditLog {
-		if entry.Timestamp.Before(cutoffTime) {
-			entriesToDelete = append(entriesToDelete, entry)
+// calculateDataRetentionStats counts the runs data_retention keeps and
+// purges
+func (am *AuditManagement) calculateDataRetentionStats() RetentionStats {
+	cutoff := am.Manager.dataRetentionCutoff(time.Now())
+	stats := RetentionStats{TotalEntries: len(am.Manager.Runs)}
+	for _, run := range am.Manager.Runs {
+		if !cutoff.IsZero() && run.FinishedAt.Before(cutoff) {
+			stats.EntriesToDelete++
+		}
+		if stats.OldestEntry.IsZero() || run.FinishedAt.Before(stats.OldestEntry) {
+			stats.OldestEntry = run.FinishedAt
+		}
+		if run.FinishedAt.After(stats.NewestEntry) {
+			stats.NewestEntry = run.FinishedAt
 		}
 	}
-
-	result := CleanupResult{
-		ProcessedCount: len(am.Manager.AuditLog),
-		DeletedCount:   len(entriesToDelete),
-		ErrorCount:     0,
-	}
-
-	if !dryRun {
-		// In a real implementation, actually delete entries
-		am.Logger.Infof("Would delete %d audit entries (dry_run: %t)", len(entriesToDelete), dryRun)
-	}
-
-	return result
+	stats.EntriesToRetain = stats.TotalEntries - stats.EntriesToDelete
+	return stats
 }
-
-func (am *AuditManagement) cleanupData(dataType string, dryRun bool) CleanupResult {
-	// Mock cleanup for demo
-	return CleanupResult{
-		ProcessedCount: 1000,
-		DeletedCount:   200,
-		ErrorCount:     0,
-	}
-}
\ No newline at end of file
@@ -0,0 +1,261 @@
+package enterprise
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+)
+
+// DefaultAuditKeyEnv holds the key signing audit checkpoints when
+// audit_integrity.key_env doesn't name another variable
+const DefaultAuditKeyEnv = "PANOPTIC_AUDIT_KEY"
+
+// DefaultCheckpointInterval is the number of audit entries between
+// checkpoints when audit_integrity.checkpoint_interval isn't set
+const DefaultCheckpointInterval = 100
+
+// minAuditKeySize is the shortest audit signing key, in bytes
+const minAuditKeySize = 32
+
+// AuditIntegrityConfig signs checkpoints of the hash chain of the audit log
+type AuditIntegrityConfig struct {
+	CheckpointInterval int    `yaml:"checkpoint_interval"` // entries between checkpoints
+	KeyEnv             string `yaml:"key_env"`             // defaults to PANOPTIC_AUDIT_KEY
+}
+
+// AuditCheckpoint signs the hash of an audit entry, and with it the chain
+// of every entry before it
+type AuditCheckpoint struct {
+	EntryID   string    `json:"entry_id"`
+	EntryTime time.Time `json:"entry_time"`
+	Hash      string    `json:"hash"`
+	Entries   int       `json:"entries"` // chained entries up to this one
+	CreatedAt time.Time `json:"created_at"`
+	Signature string    `json:"signature"` // HMAC-SHA256, hex encoded
+}
+
+// AuditVerification is the outcome of checking the audit log's hash chain
+// and checkpoints
+type AuditVerification struct {
+	Verified    bool     `json:"verified"`
+	Entries     int      `json:"entries"`
+	Unchained   int      `json:"unchained"` // entries logged before chaining, not verifiable
+	Checkpoints int      `json:"checkpoints"`
+	Expired     int      `json:"expired_checkpoints"` // of entries no longer kept
+	FirstEntry  string   `json:"first_entry,omitempty"`
+	LastEntry   string   `json:"last_entry,omitempty"`
+	Problems    []string `json:"problems"`
+}
+
+// chainAuditEntry links an entry to the previous one, signing a checkpoint
+// every checkpoint_interval entries when there's a key
+func (em *EnterpriseManager) chainAuditEntry(entry *AuditEntry) {
+	entry.PrevHash = em.lastAuditHash
+	entry.Hash = entry.computeHash()
+	em.lastAuditHash = entry.Hash
+	em.chainedEntries++
+
+	if em.auditKey == nil {
+		return
+	}
+	interval := em.Config.AuditIntegrity.CheckpointInterval
+	if interval <= 0 {
+		interval = DefaultCheckpointInterval
+	}
+	if em.chainedEntries%interval != 0 {
+		return
+	}
+	checkpoint := AuditCheckpoint{
+		EntryID:   entry.ID,
+		EntryTime: entry.Timestamp,
+		Hash:      entry.Hash,
+		Entries:   em.chainedEntries,
+		CreatedAt: time.Now(),
+	}
+	checkpoint.Signature = checkpoint.sign(em.auditKey)
+	em.AuditCheckpoints = append(em.AuditCheckpoints, checkpoint)
+
+	// saveData writes them with the in-memory log; others have the entry on disk
+	if _, ok := em.auditStore().(*memoryAuditStore); ok {
+		return
+	}
+	if err := em.saveJSON("audit_checkpoints.json", em.AuditCheckpoints); err != nil {
+		em.Logger.Errorf("Failed to save audit checkpoint: %v", err)
+	}
+}
+
+// loadAuditChain loads the checkpoints and resumes the hash chain after the
+// last entry of the log
+func (em *EnterpriseManager) loadAuditChain() {
+	if n := len(em.AuditLog); n > 0 {
+		em.lastAuditHash = em.AuditLog[n-1].Hash
+	}
+	if err := em.loadJSON("audit_checkpoints.json", &em.AuditCheckpoints); err != nil && !os.IsNotExist(err) {
+		em.Logger.Warnf("Failed to load audit checkpoints: %v", err)
+	}
+	em.chainedEntries = 0
+	if n := len(em.AuditCheckpoints); n > 0 {
+		last := em.AuditCheckpoints[n-1]
+		em.chainedEntries = last.Entries
+		for i := len(em.AuditLog) - 1; i >= 0 && em.AuditLog[i].ID != last.EntryID; i-- {
+			em.chainedEntries++
+		}
+	}
+}
+
+// rechainAuditEntries rehashes the chain from the entry at from on, after
+// those entries were changed, and re-signs their checkpoints, which needs
+// the audit key
+func (em *EnterpriseManager) rechainAuditEntries(entries []AuditEntry, from int) error {
+	checkpoints := make(map[string]int, len(em.AuditCheckpoints))
+	for i, checkpoint := range em.AuditCheckpoints {
+		checkpoints[checkpoint.EntryID] = i
+	}
+	if em.auditKey == nil {
+		for _, entry := range entries[from:] {
+			if _, signed := checkpoints[entry.ID]; signed {
+				return fmt.Errorf("%s must hold the audit key to re-sign the checkpoints of changed entries", auditKeyEnv(em.Config.AuditIntegrity.KeyEnv))
+			}
+		}
+	}
+
+	previous := entries[from].PrevHash
+	if from > 0 {
+		previous = entries[from-1].Hash
+	}
+	for i := from; i < len(entries); i++ {
+		if entries[i].Hash == "" {
+			continue // logged before chaining
+		}
+		entries[i].PrevHash = previous
+		entries[i].Hash = entries[i].computeHash()
+		previous = entries[i].Hash
+		if j, signed := checkpoints[entries[i].ID]; signed {
+			em.AuditCheckpoints[j].Hash = entries[i].Hash
+			em.AuditCheckpoints[j].Signature = em.AuditCheckpoints[j].sign(em.auditKey)
+		}
+	}
+	if previous != "" {
+		em.lastAuditHash = previous
+	}
+	return nil
+}
+
+// VerifyAuditLog checks every entry of the audit log hashes to its Hash and
+// links to the one before it, and that the checkpoints are signed with the
+// audit key and match the entries they sign. Entries dropped from the head
+// of the in-memory log leave the checkpoints of their time expired.
+func (am *AuditManagement) VerifyAuditLog(ctx context.Context) (*AuditVerification, error) {
+	entries, err := am.Manager.auditStore().Entries()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read audit log: %w", err)
+	}
+	result := &AuditVerification{Entries: len(entries), Problems: []string{}}
+	if len(entries) > 0 {
+		result.FirstEntry, result.LastEntry = entries[0].ID, entries[len(entries)-1].ID
+	}
+
+	byID := make(map[string]AuditEntry, len(entries))
+	var previous *AuditEntry
+	for i := range entries {
+		entry := entries[i]
+		byID[entry.ID] = entry
+		if entry.Hash == "" {
+			if previous != nil {
+				result.Problems = append(result.Problems, fmt.Sprintf("entry %s: not chained, after chained entries", entry.ID))
+			} else {
+				result.Unchained++
+			}
+			continue
+		}
+		if entry.computeHash() != entry.Hash {
+			result.Problems = append(result.Problems, fmt.Sprintf("entry %s: contents don't match its hash", entry.ID))
+		}
+		if previous != nil && entry.PrevHash != previous.Hash {
+			result.Problems = append(result.Problems, fmt.Sprintf("entry %s: doesn't follow entry %s; entries were removed or reordered", entry.ID, previous.ID))
+		}
+		previous = &entries[i]
+	}
+
+	if len(am.Manager.AuditCheckpoints) > 0 {
+		key, err := loadAuditKey(am.Manager.Config.AuditIntegrity.KeyEnv)
+		if err != nil {
+			return nil, err
+		}
+		if key == nil {
+			result.Problems = append(result.Problems, fmt.Sprintf("%d checkpoint(s) not verified: %s is empty", len(am.Manager.AuditCheckpoints), auditKeyEnv(am.Manager.Config.AuditIntegrity.KeyEnv)))
+		}
+		for _, checkpoint := range am.Manager.AuditCheckpoints {
+			if key == nil {
+				break
+			}
+			if !hmac.Equal([]byte(checkpoint.Signature), []byte(checkpoint.sign(key))) {
+				result.Problems = append(result.Problems, fmt.Sprintf("checkpoint at entry %s: signature doesn't match", checkpoint.EntryID))
+				continue
+			}
+			entry, exists := byID[checkpoint.EntryID]
+			switch {
+			case !exists && len(entries) > 0 && checkpoint.EntryTime.Before(entries[0].Timestamp):
+				result.Expired++
+			case !exists:
+				result.Problems = append(result.Problems, fmt.Sprintf("checkpoint at entry %s: the entry is missing; the log was truncated", checkpoint.EntryID))
+			case entry.Hash != checkpoint.Hash:
+				result.Problems = append(result.Problems, fmt.Sprintf("checkpoint at entry %s: the entry's hash changed", checkpoint.EntryID))
+			default:
+				result.Checkpoints++
+			}
+		}
+	}
+	result.Verified = len(result.Problems) == 0
+	return result, nil
+}
+
+// computeHash is the SHA-256 of the entry's JSON without its own hash
+func (entry AuditEntry) computeHash() string {
+	entry.Hash = ""
+	data, _ := json.Marshal(entry)
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// sign is the HMAC-SHA256 of what the checkpoint vouches for
+func (c AuditCheckpoint) sign(key []byte) string {
+	mac := hmac.New(sha256.New, key)
+	fmt.Fprintf(mac, "%s\n%s\n%s\n%d", c.EntryID, c.EntryTime.UTC().Format(time.RFC3339Nano), c.Hash, c.Entries)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// loadAuditKey reads the audit signing key, base64 or hex encoded, from
+// the environment; nil when it's not set
+func loadAuditKey(env string) ([]byte, error) {
+	env = auditKeyEnv(env)
+	value := strings.TrimSpace(os.Getenv(env))
+	if value == "" {
+		return nil, nil
+	}
+	for _, decode := range []func(string) ([]byte, error){
+		hex.DecodeString,
+		base64.StdEncoding.DecodeString,
+		base64.RawStdEncoding.DecodeString,
+		base64.URLEncoding.DecodeString,
+	} {
+		if key, err := decode(value); err == nil && len(key) >= minAuditKeySize {
+			return key, nil
+		}
+	}
+	return nil, fmt.Errorf("%s: audit key must be at least %d bytes, base64 or hex encoded", env, minAuditKeySize)
+}
+
+func auditKeyEnv(env string) string {
+	if env == "" {
+		return DefaultAuditKeyEnv
+	}
+	return env
+}
@@ -0,0 +1,148 @@
+package enterprise
+
+import (
+	"context"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"panoptic/internal/logger"
+)
+
+// testAuditKey is a hex encoded 32 byte audit signing key
+var testAuditKey = strings.Repeat("ab", 32)
+
+func logTestEntries(em *EnterpriseManager, n int) {
+	for i := 0; i < n; i++ {
+		em.logAuditEntry(AuditEntry{Timestamp: time.Now(), Action: "user.login", Resource: "user", Success: true, Severity: "low", Category: "auth"})
+	}
+}
+
+func TestAuditManagement_VerifyAuditLog(t *testing.T) {
+	em := NewEnterpriseManager(*logger.NewLogger(false))
+	am := NewAuditManagement(em)
+	ctx := context.Background()
+
+	em.AuditLog = []AuditEntry{{ID: "legacy", Action: "user.create"}}
+	logTestEntries(em, 3)
+	assert.Equal(t, "", em.AuditLog[1].PrevHash, "the chain starts after unchained entries")
+	assert.Equal(t, em.AuditLog[1].Hash, em.AuditLog[2].PrevHash)
+
+	result, err := am.VerifyAuditLog(ctx)
+	require.NoError(t, err)
+	assert.True(t, result.Verified, result.Problems)
+	assert.Equal(t, 4, result.Entries)
+	assert.Equal(t, 1, result.Unchained)
+
+	// Edited entries no longer match their hash
+	em.AuditLog[2].Details = map[string]string{"username": "mallory"}
+	result, err = am.VerifyAuditLog(ctx)
+	require.NoError(t, err)
+	assert.False(t, result.Verified)
+	assert.Equal(t, []string{"entry " + em.AuditLog[2].ID + ": contents don't match its hash"}, result.Problems)
+	em.AuditLog[2].Details = nil
+
+	// Removed entries break the chain
+	removed := append([]AuditEntry(nil), em.AuditLog[:2]...)
+	em.AuditLog = append(removed, em.AuditLog[3])
+	result, err = am.VerifyAuditLog(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"entry " + em.AuditLog[2].ID + ": doesn't follow entry " + em.AuditLog[1].ID + "; entries were removed or reordered"}, result.Problems)
+
+	// Trimming the head of the log doesn't
+	em.AuditLog = em.AuditLog[2:]
+	result, err = am.VerifyAuditLog(ctx)
+	require.NoError(t, err)
+	assert.True(t, result.Verified, result.Problems)
+}
+
+func TestAuditManagement_VerifyCheckpoints(t *testing.T) {
+	t.Setenv(DefaultAuditKeyEnv, testAuditKey)
+	dir := t.TempDir()
+	em := NewEnterpriseManager(*logger.NewLogger(false))
+	require.NoError(t, em.Initialize(EnterpriseConfig{Enabled: true, StoragePath: dir, AuditStorage: AuditStorageFile,
+		AuditIntegrity: AuditIntegrityConfig{CheckpointInterval: 2}}))
+	logTestEntries(em, 5)
+	require.Len(t, em.AuditCheckpoints, 2)
+	assert.Equal(t, em.AuditLog[3].ID, em.AuditCheckpoints[1].EntryID)
+	assert.Equal(t, 4, em.AuditCheckpoints[1].Entries)
+	_, err := os.Stat(filepath.Join(dir, "audit_checkpoints.json"))
+	require.NoError(t, err, "the file store's checkpoints are saved as they're signed")
+
+	// Another manager resumes the chain and the checkpoint count
+	resumed := NewEnterpriseManager(*logger.NewLogger(false))
+	require.NoError(t, resumed.Initialize(EnterpriseConfig{Enabled: true, StoragePath: dir, AuditStorage: AuditStorageFile,
+		AuditIntegrity: AuditIntegrityConfig{CheckpointInterval: 2}}))
+	logTestEntries(resumed, 1)
+	require.Len(t, resumed.AuditCheckpoints, 3)
+	assert.Equal(t, 6, resumed.AuditCheckpoints[2].Entries)
+
+	am := NewAuditManagement(resumed)
+	result, err := am.VerifyAuditLog(context.Background())
+	require.NoError(t, err)
+	assert.True(t, result.Verified, result.Problems)
+	assert.Equal(t, 6, result.Entries)
+	assert.Equal(t, 3, result.Checkpoints)
+
+	// Rewriting the chain after a checkpoint is caught by its signature
+	resumed.AuditCheckpoints[0].Hash = strings.Repeat("0", 64)
+	result, err = am.VerifyAuditLog(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, []string{"checkpoint at entry " + resumed.AuditCheckpoints[0].EntryID + ": signature doesn't match"}, result.Problems)
+
+	// Checkpoints can't be checked without the key
+	t.Setenv(DefaultAuditKeyEnv, "")
+	result, err = am.VerifyAuditLog(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, []string{"3 checkpoint(s) not verified: PANOPTIC_AUDIT_KEY is empty"}, result.Problems)
+}
+
+func TestAuditManagement_VerifyTruncatedLog(t *testing.T) {
+	t.Setenv("SHOP_AUDIT_KEY", testAuditKey)
+	em := NewEnterpriseManager(*logger.NewLogger(false))
+	em.Config.AuditIntegrity = AuditIntegrityConfig{CheckpointInterval: 2, KeyEnv: "SHOP_AUDIT_KEY"}
+	key, err := loadAuditKey("SHOP_AUDIT_KEY")
+	require.NoError(t, err)
+	em.auditKey = key
+	logTestEntries(em, 4)
+	am := NewAuditManagement(em)
+
+	// Dropping the tail of the log and its last checkpoint entry is noticed
+	em.AuditLog = em.AuditLog[:3]
+	result, err := am.VerifyAuditLog(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, []string{"checkpoint at entry " + em.AuditCheckpoints[1].EntryID + ": the entry is missing; the log was truncated"}, result.Problems)
+
+	// Checkpoints of entries older than those kept have expired
+	em.AuditLog = nil
+	logTestEntries(em, 1)
+	result, err = am.VerifyAuditLog(context.Background())
+	require.NoError(t, err)
+	assert.True(t, result.Verified, result.Problems)
+	assert.Equal(t, 2, result.Expired)
+}
+
+func TestLoadAuditKey(t *testing.T) {
+	t.Setenv(DefaultAuditKeyEnv, "")
+	key, err := loadAuditKey("")
+	require.NoError(t, err)
+	assert.Nil(t, key)
+
+	t.Setenv(DefaultAuditKeyEnv, testAuditKey)
+	key, err = loadAuditKey("")
+	require.NoError(t, err)
+	assert.Equal(t, testAuditKey, hex.EncodeToString(key))
+
+	t.Setenv(DefaultAuditKeyEnv, "c2hvcnQ=")
+	_, err = loadAuditKey("")
+	assert.EqualError(t, err, "PANOPTIC_AUDIT_KEY: audit key must be at least 32 bytes, base64 or hex encoded")
+
+	em := NewEnterpriseManager(*logger.NewLogger(false))
+	assert.EqualError(t, em.Initialize(EnterpriseConfig{Enabled: true, StoragePath: t.TempDir()}),
+		"PANOPTIC_AUDIT_KEY: audit key must be at least 32 bytes, base64 or hex encoded")
+}
@@ -0,0 +1,265 @@
+package enterprise
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+)
+
+// Audit storage backends of the audit_storage setting
+const (
+	AuditStorageMemory = "memory" // the audit.json snapshot of the recent entries
+	AuditStorageFile   = "file"   // every entry, appended to audit.jsonl
+)
+
+// Audit log paging of the audit_report action
+const (
+	DefaultAuditPageSize = 50
+	MaxAuditPageSize     = 1000
+)
+
+// maxRecentAuditEntries bounds the recent audit entries kept in memory
+const maxRecentAuditEntries = 10000
+
+// AuditStore keeps the audit log and answers queries of it. The manager
+// opens the backend audit_storage names, or uses the one set on its
+// AuditStore field before Initialize.
+type AuditStore interface {
+	Append(entry AuditEntry) error
+	Query(req GetAuditLogRequest) (*GetAuditLogResponse, error)
+	Entries() ([]AuditEntry, error) // all of them, in the order appended
+}
+
+// auditRewriter is an audit store whose entries can be replaced, for
+// retention to drop expired entries and erasure to anonymize a user's
+type auditRewriter interface {
+	Rewrite(entries []AuditEntry) error
+}
+
+// openAuditStore opens the audit storage backend of the configuration
+func (em *EnterpriseManager) openAuditStore() (AuditStore, error) {
+	switch em.Config.AuditStorage {
+	case "", AuditStorageMemory:
+		return &memoryAuditStore{manager: em}, nil
+	case AuditStorageFile:
+		return &fileAuditStore{path: filepath.Join(em.StoragePath, "audit.jsonl"), manager: em}, nil
+	default:
+		return nil, fmt.Errorf("unknown audit_storage %q; use memory or file", em.Config.AuditStorage)
+	}
+}
+
+// auditStore is the audit storage backend, the in-memory one unless
+// another was opened
+func (em *EnterpriseManager) auditStore() AuditStore {
+	if em.AuditStore == nil {
+		em.AuditStore = &memoryAuditStore{manager: em}
+	}
+	return em.AuditStore
+}
+
+// memoryAuditStore queries the recent entries the manager keeps, which
+// saveData writes to audit.json
+type memoryAuditStore struct {
+	manager *EnterpriseManager
+}
+
+func (s *memoryAuditStore) Append(entry AuditEntry) error {
+	return nil // logAuditEntry keeps it in the manager's AuditLog
+}
+
+func (s *memoryAuditStore) Query(req GetAuditLogRequest) (*GetAuditLogResponse, error) {
+	var entries []AuditEntry
+	for _, entry := range s.manager.AuditLog {
+		if req.matches(entry) {
+			entries = append(entries, entry)
+		}
+	}
+	return pageAuditEntries(entries, req), nil
+}
+
+func (s *memoryAuditStore) Entries() ([]AuditEntry, error) {
+	return append([]AuditEntry(nil), s.manager.AuditLog...), nil
+}
+
+func (s *memoryAuditStore) Rewrite(entries []AuditEntry) error {
+	s.manager.AuditLog = entries
+	return nil
+}
+
+// fileAuditStore appends every entry to a JSON lines file, queried in full.
+// Under audit_encryption each line is encrypted with the organization key.
+type fileAuditStore struct {
+	path    string
+	manager *EnterpriseManager // encrypts the lines; plain without one
+	mu      sync.Mutex
+}
+
+func (s *fileAuditStore) Append(entry AuditEntry) error {
+	line, err := s.marshal(entry)
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	f, err := os.OpenFile(s.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(append(line, '\n')); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+func (s *fileAuditStore) Query(req GetAuditLogRequest) (*GetAuditLogResponse, error) {
+	var entries []AuditEntry
+	err := s.scan(func(entry AuditEntry) {
+		if req.matches(entry) {
+			entries = append(entries, entry)
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+	return pageAuditEntries(entries, req), nil
+}
+
+func (s *fileAuditStore) Entries() ([]AuditEntry, error) {
+	var entries []AuditEntry
+	err := s.scan(func(entry AuditEntry) { entries = append(entries, entry) })
+	return entries, err
+}
+
+// Rewrite replaces the file, through a temporary one renamed over it
+func (s *fileAuditStore) Rewrite(entries []AuditEntry) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	tmp := s.path + ".tmp"
+	f, err := os.OpenFile(tmp, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	w := bufio.NewWriter(f)
+	for _, entry := range entries {
+		line, err := s.marshal(entry)
+		if err != nil {
+			f.Close()
+			os.Remove(tmp)
+			return err
+		}
+		w.Write(append(line, '\n'))
+	}
+	if err := w.Flush(); err != nil {
+		f.Close()
+		os.Remove(tmp)
+		return err
+	}
+	if err := f.Close(); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	return os.Rename(tmp, s.path)
+}
+
+// scan reads the entries of the file in order
+func (s *fileAuditStore) scan(read func(entry AuditEntry)) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	f, err := os.Open(s.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		data := scanner.Bytes()
+		if s.manager != nil {
+			if data, err = s.manager.openLine(filepath.Base(s.path), data); err != nil {
+				return fmt.Errorf("%s:%d: %w", s.path, line, err)
+			}
+		}
+		var entry AuditEntry
+		if err := json.Unmarshal(data, &entry); err != nil {
+			return fmt.Errorf("%s:%d: %w", s.path, line, err)
+		}
+		read(entry)
+	}
+	return scanner.Err()
+}
+
+// marshal is the line of an entry, encrypted when the audit log is
+func (s *fileAuditStore) marshal(entry AuditEntry) ([]byte, error) {
+	line, err := json.Marshal(entry)
+	if err != nil || s.manager == nil {
+		return line, err
+	}
+	return s.manager.sealLine(filepath.Base(s.path), line)
+}
+
+// matches tells whether an entry passes the filters of a query
+func (req GetAuditLogRequest) matches(entry AuditEntry) bool {
+	switch {
+	case req.UserID != "" && entry.UserID != req.UserID,
+		req.Username != "" && entry.Username != req.Username,
+		req.Action != "" && entry.Action != req.Action,
+		req.Resource != "" && entry.Resource != req.Resource,
+		req.ResourceID != "" && entry.ResourceID != req.ResourceID,
+		req.Category != "" && entry.Category != req.Category,
+		req.Severity != "" && entry.Severity != req.Severity,
+		!req.StartTime.IsZero() && entry.Timestamp.Before(req.StartTime),
+		!req.EndTime.IsZero() && entry.Timestamp.After(req.EndTime),
+		req.Success != nil && *req.Success != entry.Success:
+		return false
+	}
+	return true
+}
+
+// pageAuditEntries sorts matching entries newest first and returns the page
+// the request asks for
+func pageAuditEntries(entries []AuditEntry, req GetAuditLogRequest) *GetAuditLogResponse {
+	page, pageSize := req.Page, req.PageSize
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 {
+		pageSize = DefaultAuditPageSize
+	}
+	sort.SliceStable(entries, func(i, j int) bool { return entries[i].Timestamp.After(entries[j].Timestamp) })
+
+	total := len(entries)
+	start := min((page-1)*pageSize, total)
+	end := min(start+pageSize, total)
+	paged := make([]AuditEntry, end-start)
+	copy(paged, entries[start:end])
+	return &GetAuditLogResponse{Entries: paged, Total: total, Page: page, PageSize: pageSize}
+}
+
+// loadAuditLog loads the recent entries of the audit log into memory
+func (em *EnterpriseManager) loadAuditLog() error {
+	if _, ok := em.auditStore().(*memoryAuditStore); ok {
+		return em.loadJSON("audit.json", &em.AuditLog)
+	}
+	recent, err := em.auditStore().Query(GetAuditLogRequest{PageSize: maxRecentAuditEntries})
+	if err != nil {
+		return err
+	}
+	em.AuditLog = make([]AuditEntry, 0, len(recent.Entries))
+	for i := len(recent.Entries) - 1; i >= 0; i-- {
+		em.AuditLog = append(em.AuditLog, recent.Entries[i])
+	}
+	return nil
+}
@@ -0,0 +1,153 @@
+package enterprise
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"panoptic/internal/logger"
+)
+
+// auditEntries are a day of logins and project changes, an hour apart
+func auditEntries(day time.Time) []AuditEntry {
+	return []AuditEntry{
+		{Timestamp: day.Add(1 * time.Hour), UserID: "u-ana", Username: "ana", Action: "user.login", Resource: "user", ResourceID: "u-ana", Category: "auth", Severity: "low", Success: true},
+		{Timestamp: day.Add(2 * time.Hour), UserID: "u-bo", Username: "bo", Action: "user.login", Resource: "user", ResourceID: "u-bo", Category: "auth", Severity: "medium", Success: false},
+		{Timestamp: day.Add(3 * time.Hour), UserID: "u-ana", Username: "ana", Action: "project.update", Resource: "project", ResourceID: "p-checkout", Category: "data", Severity: "low", Success: true},
+		{Timestamp: day.Add(26 * time.Hour), UserID: "u-ana", Username: "ana", Action: "project.delete", Resource: "project", ResourceID: "p-checkout", Category: "data", Severity: "high", Success: true},
+	}
+}
+
+func newFileAuditManager(t *testing.T, dir string) *EnterpriseManager {
+	t.Helper()
+	em := NewEnterpriseManager(*logger.NewLogger(false))
+	require.NoError(t, em.Initialize(EnterpriseConfig{Enabled: true, StoragePath: dir, AuditStorage: AuditStorageFile}))
+	return em
+}
+
+func TestAuditStore_Query(t *testing.T) {
+	day := time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)
+	failed := false
+	for name, store := range map[string]func(t *testing.T) *EnterpriseManager{
+		"memory": func(t *testing.T) *EnterpriseManager { return NewEnterpriseManager(*logger.NewLogger(false)) },
+		"file":   func(t *testing.T) *EnterpriseManager { return newFileAuditManager(t, t.TempDir()) },
+	} {
+		t.Run(name, func(t *testing.T) {
+			em := store(t)
+			for _, entry := range auditEntries(day) {
+				em.logAuditEntry(entry)
+			}
+			am := NewAuditManagement(em)
+			ctx := context.Background()
+
+			response, err := am.GetAuditLog(ctx, GetAuditLogRequest{Username: "ana", Category: "data"})
+			require.NoError(t, err)
+			assert.Equal(t, 2, response.Total)
+			assert.Equal(t, "project.delete", response.Entries[0].Action, "newest first")
+			assert.Equal(t, DefaultAuditPageSize, response.PageSize)
+
+			response, err = am.GetAuditLog(ctx, GetAuditLogRequest{StartTime: day, EndTime: day.Add(24 * time.Hour), Page: 2, PageSize: 2})
+			require.NoError(t, err)
+			assert.Equal(t, 3, response.Total)
+			require.Len(t, response.Entries, 1)
+			assert.Equal(t, "u-ana", response.Entries[0].ResourceID)
+
+			response, err = am.GetAuditLog(ctx, GetAuditLogRequest{Success: &failed, Resource: "user", Severity: "medium"})
+			require.NoError(t, err)
+			assert.Equal(t, 1, response.Total)
+			response, err = am.GetAuditLog(ctx, GetAuditLogRequest{ResourceID: "p-checkout", Action: "project.update"})
+			require.NoError(t, err)
+			assert.Equal(t, 1, response.Total)
+
+			_, err = am.GetAuditLog(ctx, GetAuditLogRequest{StartTime: day.Add(time.Hour), EndTime: day})
+			assert.EqualError(t, err, "end time 2026-10-01T00:00:00Z is before start time 2026-10-01T01:00:00Z")
+		})
+	}
+}
+
+func TestFileAuditStore(t *testing.T) {
+	dir := t.TempDir()
+	em := newFileAuditManager(t, dir)
+	for _, entry := range auditEntries(time.Now().Add(-48 * time.Hour)) {
+		em.logAuditEntry(entry)
+	}
+	require.NoError(t, em.saveData())
+	assert.FileExists(t, filepath.Join(dir, "audit.jsonl"))
+	assert.NoFileExists(t, filepath.Join(dir, "audit.json"), "the file backend keeps no snapshot")
+
+	// Entries outlive the in-memory log, which reloads the recent ones
+	em.AuditLog = nil
+	response, err := NewAuditManagement(em).GetAuditLog(context.Background(), GetAuditLogRequest{})
+	require.NoError(t, err)
+	assert.Equal(t, 4, response.Total)
+	reloaded := newFileAuditManager(t, dir)
+	require.Len(t, reloaded.AuditLog, 4)
+	assert.Equal(t, "user.login", reloaded.AuditLog[0].Action, "oldest first, as they were logged")
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "audit.jsonl"), []byte("{}\nnot json\n"), 0600))
+	_, err = NewAuditManagement(em).GetAuditLog(context.Background(), GetAuditLogRequest{})
+	assert.ErrorContains(t, err, "audit.jsonl:2:")
+}
+
+// recordingAuditStore is an audit backend plugged in before Initialize
+type recordingAuditStore struct {
+	entries []AuditEntry
+}
+
+func (s *recordingAuditStore) Append(entry AuditEntry) error {
+	s.entries = append(s.entries, entry)
+	return nil
+}
+
+func (s *recordingAuditStore) Entries() ([]AuditEntry, error) {
+	return s.entries, nil
+}
+
+func (s *recordingAuditStore) Query(req GetAuditLogRequest) (*GetAuditLogResponse, error) {
+	return pageAuditEntries(append([]AuditEntry(nil), s.entries...), req), nil
+}
+
+func TestEnterpriseManager_AuditStorage(t *testing.T) {
+	em := NewEnterpriseManager(*logger.NewLogger(false))
+	err := em.Initialize(EnterpriseConfig{Enabled: true, StoragePath: t.TempDir(), AuditStorage: "syslog"})
+	assert.EqualError(t, err, `unknown audit_storage "syslog"; use memory or file`)
+
+	store := &recordingAuditStore{}
+	em = NewEnterpriseManager(*logger.NewLogger(false))
+	em.AuditStore = store
+	require.NoError(t, em.Initialize(EnterpriseConfig{Enabled: true, StoragePath: t.TempDir()}))
+	em.logAuditEntry(AuditEntry{Action: "user.login", Timestamp: time.Now()})
+	require.Len(t, store.entries, 1)
+	assert.NotEmpty(t, store.entries[0].ID)
+}
+
+func TestEnterpriseIntegration_AuditReport(t *testing.T) {
+	em := NewEnterpriseManager(*logger.NewLogger(false))
+	day := time.Date(2026, 10, 1, 0, 0, 0, 0, time.Local)
+	for _, entry := range auditEntries(day) {
+		em.logAuditEntry(entry)
+	}
+	ei := &EnterpriseIntegration{Manager: em, AuditManagement: NewAuditManagement(em), Initialized: true}
+	ctx := context.Background()
+
+	result, err := ei.ExecuteEnterpriseAction(ctx, "audit_report", map[string]interface{}{
+		"start": "2026-10-01", "end": "2026-10-01", "success": "true", "page_size": "5000",
+	})
+	require.NoError(t, err)
+	report := result.(map[string]interface{})
+	assert.Equal(t, 2, report["total"], "the end date takes in its whole day")
+	assert.Equal(t, MaxAuditPageSize, report["page_size"])
+
+	result, err = ei.ExecuteEnterpriseAction(ctx, "audit_report", map[string]interface{}{"start": day.Add(150 * time.Minute).Format(time.RFC3339), "resource": "project"})
+	require.NoError(t, err)
+	assert.Equal(t, 2, result.(map[string]interface{})["total"])
+
+	_, err = ei.ExecuteEnterpriseAction(ctx, "audit_report", map[string]interface{}{"start": "yesterday"})
+	assert.EqualError(t, err, `invalid start "yesterday"; use RFC 3339 or YYYY-MM-DD`)
+	_, err = ei.ExecuteEnterpriseAction(ctx, "audit_report", map[string]interface{}{"success": "maybe"})
+	assert.EqualError(t, err, `invalid success "maybe"; use true or false`)
+}
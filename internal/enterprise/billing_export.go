@@ -0,0 +1,245 @@
+package enterprise
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"panoptic/internal/cloud"
+)
+
+// Billing export formats
+const (
+	ExportCSV  = "csv"
+	ExportJSON = "json"
+)
+
+// Billing export row scopes
+const (
+	ScopeProject      = "project"
+	ScopeOrganization = "organization"
+)
+
+// BillingExportConfig configures the monthly usage export
+type BillingExportConfig struct {
+	Format string             `yaml:"format"` // csv, json; csv by default
+	Path   string             `yaml:"path"`   // directory, storage_path/exports by default
+	Cloud  *cloud.CloudConfig `yaml:"cloud"`  // provider exports are uploaded to, as settings.cloud
+}
+
+// BillingReport is the usage of the projects of an organization in a month
+type BillingReport struct {
+	Organization string       `json:"organization"`
+	Period       string       `json:"period"` // 2006-01
+	GeneratedAt  time.Time    `json:"generated_at"`
+	Projects     []BillingRow `json:"projects"`
+	Total        BillingRow   `json:"total"` // of the projects
+}
+
+// BillingRow is what a project, or the organization, used in the month
+type BillingRow struct {
+	Scope        string  `json:"scope"` // project, organization
+	ProjectID    string  `json:"project_id,omitempty"`
+	Project      string  `json:"project,omitempty"`
+	Owner        string  `json:"owner,omitempty"`
+	Plan         string  `json:"plan,omitempty"`
+	TestRuns     int     `json:"test_runs"`
+	NodeMinutes  float64 `json:"node_minutes"`
+	StorageBytes int64   `json:"storage_bytes"`
+}
+
+// ExportUsage writes the usage of a month, the last full one by default, per
+// project and for the organization, as CSV or JSON for chargeback and
+// billing systems, and uploads it to the billing_export.cloud provider
+func (um *UsageManagement) ExportUsage(ctx context.Context, req UsageExportRequest) (*UsageExportResponse, error) {
+	config := um.Manager.Config.BillingExport
+	period := req.Period
+	if period == "" {
+		now := time.Now()
+		period = now.AddDate(0, 0, 1-now.Day()).AddDate(0, -1, 0).Format(usagePeriod)
+	} else if _, err := time.Parse(usagePeriod, period); err != nil {
+		return nil, fmt.Errorf("invalid period %q; use YYYY-MM", period)
+	}
+	format := strings.ToLower(req.Format)
+	if format == "" {
+		format = strings.ToLower(config.Format)
+	}
+	if format == "" {
+		format = ExportCSV
+	}
+	if format != ExportCSV && format != ExportJSON {
+		return nil, fmt.Errorf("unsupported export format %q; use csv or json", format)
+	}
+	var projectID string
+	if req.Project != "" {
+		project, err := NewRunManagement(um.Manager).FindProject(req.Project)
+		if err != nil {
+			return nil, err
+		}
+		projectID = project.ID
+	}
+
+	report := um.billingReport(period, projectID)
+	dir := req.Output
+	if dir == "" {
+		dir = config.Path
+		if dir == "" {
+			dir = "exports"
+		}
+		if !filepath.IsAbs(dir) {
+			dir = filepath.Join(um.Manager.StoragePath, dir)
+		}
+	}
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, fmt.Errorf("failed to create export directory: %w", err)
+	}
+	name := "usage_" + period
+	if projectID != "" {
+		name += "_" + projectID
+	}
+	file := filepath.Join(dir, name+"."+format)
+	if err := writeBillingReport(file, format, report); err != nil {
+		return nil, fmt.Errorf("failed to write usage export: %w", err)
+	}
+	response := &UsageExportResponse{Report: report, Format: format, Path: file}
+
+	if config.Cloud != nil && !req.NoUpload {
+		manager := cloud.NewCloudManager(um.Logger)
+		if err := manager.Configure(*config.Cloud); err != nil {
+			return response, fmt.Errorf("usage export written to %s, not uploaded: %w", file, err)
+		}
+		if !manager.Enabled {
+			return response, fmt.Errorf("usage export written to %s, not uploaded: billing_export.cloud needs a provider and a bucket", file)
+		}
+		remote := path.Join("billing", period, filepath.Base(file))
+		result, err := manager.Provider.UploadFile(ctx, file, remote)
+		if err != nil {
+			return response, fmt.Errorf("usage export written to %s, not uploaded: %w", file, err)
+		}
+		response.RemotePath, response.URL = remote, result.URL
+	}
+
+	um.Manager.logAuditEntry(AuditEntry{
+		Timestamp: time.Now(),
+		Action:    "usage.export",
+		Resource:  "usage",
+		Details: map[string]string{
+			"period":   period,
+			"format":   format,
+			"projects": strconv.Itoa(len(report.Projects)),
+			"file":     file,
+			"remote":   response.RemotePath,
+		},
+		Success:  true,
+		Severity: "low",
+		Category: "data",
+	})
+	return response, nil
+}
+
+// Request types
+
+type UsageExportRequest struct {
+	Period   string `json:"period,omitempty"`  // YYYY-MM
+	Format   string `json:"format,omitempty"`  // csv, json
+	Project  string `json:"project,omitempty"` // ID or name; all projects by default
+	Output   string `json:"output,omitempty"`  // directory, instead of billing_export.path
+	NoUpload bool   `json:"no_upload,omitempty"`
+}
+
+type UsageExportResponse struct {
+	Report     *BillingReport `json:"report"`
+	Format     string         `json:"format"`
+	Path       string         `json:"path"`
+	RemotePath string         `json:"remote_path,omitempty"`
+	URL        string         `json:"url,omitempty"`
+}
+
+// Helper methods
+
+// billingReport is the metered usage of the projects in a period, of one
+// project when projectID is set
+func (um *UsageManagement) billingReport(period, projectID string) *BillingReport {
+	report := &BillingReport{
+		Organization: um.Manager.Config.OrganizationName,
+		Period:       period,
+		GeneratedAt:  time.Now(),
+		Projects:     []BillingRow{},
+		Total:        BillingRow{Scope: ScopeOrganization},
+	}
+	for _, usage := range um.Manager.Usage {
+		if usage.Subject != UsageProject || usage.Period != period || (projectID != "" && usage.SubjectID != projectID) {
+			continue
+		}
+		subject := um.subject(UsageProject, usage.SubjectID)
+		row := BillingRow{
+			Scope:        ScopeProject,
+			ProjectID:    subject.id,
+			Project:      subject.name,
+			TestRuns:     usage.TestRuns,
+			NodeMinutes:  usage.NodeMinutes,
+			StorageBytes: usage.StorageBytes,
+		}
+		if subject.ownerID != "" {
+			row.Owner = um.Manager.getUsername(subject.ownerID)
+		}
+		if subscription := um.subscriptionOf(subject); subscription != nil {
+			row.Plan = subscription.Plan
+		}
+		report.Projects = append(report.Projects, row)
+	}
+	if projectID != "" && len(report.Projects) == 0 {
+		subject := um.subject(UsageProject, projectID)
+		report.Projects = append(report.Projects, BillingRow{Scope: ScopeProject, ProjectID: subject.id, Project: subject.name})
+	}
+	sort.Slice(report.Projects, func(i, j int) bool { return report.Projects[i].Project < report.Projects[j].Project })
+
+	for _, row := range report.Projects {
+		report.Total.TestRuns += row.TestRuns
+		report.Total.NodeMinutes += row.NodeMinutes
+		report.Total.StorageBytes += row.StorageBytes
+	}
+	return report
+}
+
+// writeBillingReport writes a report as JSON, or as CSV rows of the projects
+// followed by the organization total
+func writeBillingReport(file, format string, report *BillingReport) error {
+	if format == ExportJSON {
+		data, err := json.MarshalIndent(report, "", "  ")
+		if err != nil {
+			return err
+		}
+		return os.WriteFile(file, data, 0600)
+	}
+
+	f, err := os.OpenFile(file, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0600)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	w := csv.NewWriter(f)
+	w.Write([]string{"organization", "period", "scope", "project_id", "project", "owner", "plan", "test_runs", "node_minutes", "storage_bytes", "storage_gb"})
+	for _, row := range append(report.Projects, report.Total) {
+		w.Write([]string{
+			report.Organization, report.Period, row.Scope, row.ProjectID, row.Project, row.Owner, row.Plan,
+			strconv.Itoa(row.TestRuns),
+			strconv.FormatFloat(row.NodeMinutes, 'f', 2, 64),
+			strconv.FormatInt(row.StorageBytes, 10),
+			strconv.FormatFloat(float64(row.StorageBytes)/(1<<30), 'f', 3, 64),
+		})
+	}
+	w.Flush()
+	if err := w.Error(); err != nil {
+		return err
+	}
+	return f.Close()
+}
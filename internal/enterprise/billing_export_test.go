@@ -0,0 +1,103 @@
+package enterprise
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"panoptic/internal/cloud"
+)
+
+// newBillingUsage has the checkout and search projects metered in September
+// 2026, and checkout again in October
+func newBillingUsage(t *testing.T) *UsageManagement {
+	t.Helper()
+	um := newUsageManagement(t)
+	um.Manager.Config.OrganizationName = "Shop"
+	um.Manager.Projects["p-search"] = &Project{ID: "p-search", Name: "search", OwnerID: "u-bo", Status: "active"}
+	um.Manager.Usage = map[string]*Usage{
+		usageKey(UsageProject, "p-checkout", "2026-09"): {Subject: UsageProject, SubjectID: "p-checkout", Period: "2026-09",
+			TestRuns: 4, NodeMinutes: 12.5, StorageBytes: 3 << 30},
+		usageKey(UsageProject, "p-search", "2026-09"): {Subject: UsageProject, SubjectID: "p-search", Period: "2026-09",
+			TestRuns: 1, NodeMinutes: 2, StorageBytes: 1024},
+		usageKey(UsageUser, "u-bo", "2026-09"):          {Subject: UsageUser, SubjectID: "u-bo", Period: "2026-09", TestRuns: 5},
+		usageKey(UsageProject, "p-checkout", "2026-10"): {Subject: UsageProject, SubjectID: "p-checkout", Period: "2026-10", TestRuns: 9},
+	}
+	return um
+}
+
+func TestUsageManagement_ExportUsageCSV(t *testing.T) {
+	um := newBillingUsage(t)
+
+	export, err := um.ExportUsage(context.Background(), UsageExportRequest{Period: "2026-09"})
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(um.Manager.StoragePath, "exports", "usage_2026-09.csv"), export.Path)
+	assert.Empty(t, export.RemotePath, "uploading needs billing_export.cloud")
+
+	f, err := os.Open(export.Path)
+	require.NoError(t, err)
+	defer f.Close()
+	rows, err := csv.NewReader(f).ReadAll()
+	require.NoError(t, err)
+	assert.Equal(t, [][]string{
+		{"organization", "period", "scope", "project_id", "project", "owner", "plan", "test_runs", "node_minutes", "storage_bytes", "storage_gb"},
+		{"Shop", "2026-09", "project", "p-checkout", "checkout", "ana", "trial", "4", "12.50", "3221225472", "3.000"},
+		{"Shop", "2026-09", "project", "p-search", "search", "bo", "", "1", "2.00", "1024", "0.000"},
+		{"Shop", "2026-09", "organization", "", "", "", "", "5", "14.50", "3221226496", "3.000"},
+	}, rows, "user usage isn't billed twice")
+
+	entry := um.Manager.AuditLog[len(um.Manager.AuditLog)-1]
+	assert.Equal(t, "usage.export", entry.Action)
+	assert.Equal(t, "2", entry.Details["projects"])
+
+	_, err = um.ExportUsage(context.Background(), UsageExportRequest{Period: "Sept"})
+	assert.EqualError(t, err, `invalid period "Sept"; use YYYY-MM`)
+	_, err = um.ExportUsage(context.Background(), UsageExportRequest{Format: "xlsx"})
+	assert.EqualError(t, err, `unsupported export format "xlsx"; use csv or json`)
+	_, err = um.ExportUsage(context.Background(), UsageExportRequest{Project: "cart"})
+	assert.EqualError(t, err, "project not found: cart")
+}
+
+func TestUsageManagement_ExportUsageUpload(t *testing.T) {
+	um := newBillingUsage(t)
+	bucket := t.TempDir()
+	um.Manager.Config.BillingExport = BillingExportConfig{Format: "json", Cloud: &cloud.CloudConfig{Provider: "local", Bucket: bucket}}
+	output := t.TempDir()
+
+	export, err := um.ExportUsage(context.Background(), UsageExportRequest{Period: "2026-09", Project: "checkout", Output: output})
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(output, "usage_2026-09_p-checkout.json"), export.Path)
+	assert.Equal(t, "billing/2026-09/usage_2026-09_p-checkout.json", export.RemotePath)
+
+	data, err := os.ReadFile(filepath.Join(bucket, export.RemotePath))
+	require.NoError(t, err)
+	var report BillingReport
+	require.NoError(t, json.Unmarshal(data, &report))
+	require.Len(t, report.Projects, 1)
+	assert.Equal(t, 12.5, report.Projects[0].NodeMinutes)
+	assert.Equal(t, BillingRow{Scope: ScopeOrganization, TestRuns: 4, NodeMinutes: 12.5, StorageBytes: 3 << 30}, report.Total)
+
+	// A failed upload still leaves the export on disk
+	um.Manager.Config.BillingExport.Cloud = &cloud.CloudConfig{Provider: "s3", Bucket: bucket}
+	export, err = um.ExportUsage(context.Background(), UsageExportRequest{Period: "2026-09", Output: output})
+	assert.ErrorContains(t, err, "not uploaded: failed to create cloud provider: unsupported cloud provider: s3")
+	assert.FileExists(t, export.Path)
+}
+
+func TestUsageManagement_ExportUsageAction(t *testing.T) {
+	um := newBillingUsage(t)
+	ei := &EnterpriseIntegration{Manager: um.Manager, UsageManagement: um, Initialized: true}
+
+	result, err := ei.ExecuteEnterpriseAction(context.Background(), "usage_export", map[string]interface{}{"format": "json"})
+	require.NoError(t, err)
+	export := result.(*UsageExportResponse)
+	lastMonth := time.Now().AddDate(0, 0, 1-time.Now().Day()).AddDate(0, -1, 0).Format("2006-01")
+	assert.Equal(t, lastMonth, export.Report.Period, "the last full month by default")
+	assert.Equal(t, ExportJSON, export.Format)
+}
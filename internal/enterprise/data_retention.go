@@ -0,0 +1,133 @@
+package enterprise
+
+import (
+	"strconv"
+	"time"
+)
+
+// dataRetentionCutoff is when runs finished before expire under the
+// compliance data_retention; zero when it isn't set
+func (em *EnterpriseManager) dataRetentionCutoff(now time.Time) time.Time {
+	if days := em.Config.Compliance.DataRetention; days > 0 {
+		return now.AddDate(0, 0, -days)
+	}
+	return time.Time{}
+}
+
+// auditRetentionCutoff is when audit entries logged before expire under the
+// compliance audit_retention, else data_retention; zero when neither is set
+func (em *EnterpriseManager) auditRetentionCutoff(now time.Time) time.Time {
+	if days := em.Config.Compliance.AuditRetention; days > 0 {
+		return now.AddDate(0, 0, -days)
+	}
+	return em.dataRetentionCutoff(now)
+}
+
+// applyDataRetention purges the runs and audit entries past the compliance
+// retention, when the manager starts and then daily
+func (em *EnterpriseManager) applyDataRetention(now time.Time) {
+	runs := em.purgeRuns(em.dataRetentionCutoff(now), false)
+	audit := em.purgeAuditEntries(em.auditRetentionCutoff(now), false)
+	for _, message := range audit.Errors {
+		em.Logger.Warnf("Audit retention: %s", message)
+	}
+	if runs.DeletedCount == 0 && audit.DeletedCount == 0 {
+		return
+	}
+	em.Logger.Infof("Retention purged %d run(s) and %d audit entries", runs.DeletedCount, audit.DeletedCount)
+	em.logAuditEntry(AuditEntry{
+		Timestamp: now,
+		Action:    "retention.purge",
+		Resource:  "retention_policy",
+		Details: map[string]string{
+			"runs":          strconv.Itoa(runs.DeletedCount),
+			"audit_entries": strconv.Itoa(audit.DeletedCount),
+		},
+		Success:  true,
+		Severity: "medium",
+		Category: "data",
+	})
+	if err := em.saveData(); err != nil {
+		em.Logger.Errorf("Failed to save data after retention: %v", err)
+	}
+}
+
+// purgeRuns drops the runs that finished before the cutoff; none when it's
+// zero
+func (em *EnterpriseManager) purgeRuns(cutoff time.Time, dryRun bool) CleanupResult {
+	result := CleanupResult{ProcessedCount: len(em.Runs)}
+	if cutoff.IsZero() {
+		return result
+	}
+	kept := make([]RunRecord, 0, len(em.Runs))
+	for _, run := range em.Runs {
+		if run.FinishedAt.Before(cutoff) {
+			result.DeletedCount++
+			continue
+		}
+		kept = append(kept, run)
+	}
+	if !dryRun {
+		em.Runs = kept
+	}
+	return result
+}
+
+// purgeAuditEntries drops the entries at the head of the audit log logged
+// before the cutoff, and their checkpoints. The rest of the chain still
+// verifies from its first entry on.
+func (em *EnterpriseManager) purgeAuditEntries(cutoff time.Time, dryRun bool) CleanupResult {
+	if cutoff.IsZero() {
+		return CleanupResult{ProcessedCount: len(em.AuditLog)}
+	}
+	store := em.auditStore()
+	entries, err := store.Entries()
+	if err != nil {
+		return CleanupResult{ErrorCount: 1, Errors: []string{"failed to read audit log: " + err.Error()}}
+	}
+	result := CleanupResult{ProcessedCount: len(entries)}
+	for result.DeletedCount < len(entries) && entries[result.DeletedCount].Timestamp.Before(cutoff) {
+		result.DeletedCount++
+	}
+	if dryRun || result.DeletedCount == 0 {
+		return result
+	}
+	rewriter, ok := store.(auditRewriter)
+	if !ok {
+		result.DeletedCount, result.ErrorCount = 0, 1
+		result.Errors = []string{"the audit store can't remove entries"}
+		return result
+	}
+
+	dropped := make(map[string]bool, result.DeletedCount)
+	for _, entry := range entries[:result.DeletedCount] {
+		dropped[entry.ID] = true
+	}
+	if err := rewriter.Rewrite(append([]AuditEntry(nil), entries[result.DeletedCount:]...)); err != nil {
+		return CleanupResult{ProcessedCount: len(entries), ErrorCount: 1, Errors: []string{"failed to rewrite audit log: " + err.Error()}}
+	}
+	em.AuditLog = withoutAuditEntries(em.AuditLog, dropped)
+	checkpoints := em.AuditCheckpoints[:0]
+	for _, checkpoint := range em.AuditCheckpoints {
+		if !dropped[checkpoint.EntryID] {
+			checkpoints = append(checkpoints, checkpoint)
+		}
+	}
+	em.AuditCheckpoints = checkpoints
+	if err := em.saveJSON("audit_checkpoints.json", em.AuditCheckpoints); err != nil {
+		result.ErrorCount++
+		result.Errors = append(result.Errors, "failed to save audit checkpoints: "+err.Error())
+	}
+	return result
+}
+
+// withoutAuditEntries is the entries but the dropped ones
+func withoutAuditEntries(entries []AuditEntry, dropped map[string]bool) []AuditEntry {
+	kept := make([]AuditEntry, 0, len(entries))
+	for _, entry := range entries {
+		if !dropped[entry.ID] {
+			kept = append(kept, entry)
+		}
+	}
+	return kept
+}
@@ -0,0 +1,127 @@
+package enterprise
+
+import (
+	"context"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+	"panoptic/internal/logger"
+)
+
+// logDatedEntries logs audit entries days ago, oldest first
+func logDatedEntries(em *EnterpriseManager, days ...int) {
+	for _, ago := range days {
+		em.logAuditEntry(AuditEntry{Timestamp: time.Now().AddDate(0, 0, -ago), Action: "user.login", Resource: "user", Success: true})
+	}
+}
+
+func TestDataRetention_Initialize(t *testing.T) {
+	storage := t.TempDir()
+	config := EnterpriseConfig{Enabled: true, StoragePath: storage, Compliance: ComplianceConfig{DataRetention: 30, AuditRetention: 60}}
+
+	// What the manager finds on disk as it starts
+	em := NewEnterpriseManager(*logger.NewLogger(false))
+	em.StoragePath = storage
+	em.auditKey, _ = hex.DecodeString(testAuditKey)
+	em.Config.AuditIntegrity.CheckpointInterval = 2
+	logDatedEntries(em, 90, 80, 40, 10)
+	em.Runs = []RunRecord{
+		{ID: "run-old", FinishedAt: time.Now().AddDate(0, 0, -31)},
+		{ID: "run-new", FinishedAt: time.Now().AddDate(0, 0, -29)},
+	}
+	require.Len(t, em.AuditCheckpoints, 2)
+	require.NoError(t, em.saveData())
+
+	t.Setenv(DefaultAuditKeyEnv, testAuditKey)
+	em = NewEnterpriseManager(*logger.NewLogger(false))
+	require.NoError(t, em.Initialize(config))
+	require.Len(t, em.Runs, 1)
+	assert.Equal(t, "run-new", em.Runs[0].ID)
+	require.Len(t, em.AuditLog, 3, "the two entries past audit_retention are purged, and the purge is audited")
+	assert.Equal(t, "retention.purge", em.AuditLog[2].Action)
+	assert.Equal(t, map[string]string{"runs": "1", "audit_entries": "2"}, em.AuditLog[2].Details)
+	assert.Len(t, em.AuditCheckpoints, 1, "with the checkpoint of the purged entries")
+
+	result, err := NewAuditManagement(em).VerifyAuditLog(context.Background())
+	require.NoError(t, err)
+	assert.True(t, result.Verified, result.Problems)
+	assert.Equal(t, 1, result.Checkpoints)
+
+	// The purge was saved
+	loaded := NewEnterpriseManager(*logger.NewLogger(false))
+	loaded.StoragePath = storage
+	require.NoError(t, loaded.loadData())
+	assert.Len(t, loaded.Runs, 1)
+	assert.Len(t, loaded.AuditLog, 3)
+}
+
+func TestDataRetention_FileAuditStore(t *testing.T) {
+	em := NewEnterpriseManager(*logger.NewLogger(false))
+	em.StoragePath = t.TempDir()
+	em.Config.AuditStorage = AuditStorageFile
+	em.Config.Compliance.DataRetention = 30
+	store, err := em.openAuditStore()
+	require.NoError(t, err)
+	em.AuditStore = store
+	logDatedEntries(em, 45, 31, 5)
+
+	result := em.purgeAuditEntries(em.auditRetentionCutoff(time.Now()), true)
+	assert.Equal(t, CleanupResult{ProcessedCount: 3, DeletedCount: 2}, result, "audit entries fall back on data_retention")
+	entries, err := store.Entries()
+	require.NoError(t, err)
+	assert.Len(t, entries, 3, "a dry run removes nothing")
+
+	result = em.purgeAuditEntries(em.auditRetentionCutoff(time.Now()), false)
+	assert.Equal(t, 2, result.DeletedCount)
+	entries, err = store.Entries()
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	require.Len(t, em.AuditLog, 1)
+	assert.Equal(t, entries[0].ID, em.AuditLog[0].ID)
+	_, err = os.Stat(filepath.Join(em.StoragePath, "audit.jsonl.tmp"))
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestDataRetention_Cleanup(t *testing.T) {
+	em := NewEnterpriseManager(*logger.NewLogger(false))
+	em.StoragePath = t.TempDir()
+	em.Runs = []RunRecord{{ID: "run-old", FinishedAt: time.Now().AddDate(0, 0, -8)}, {ID: "run-new", FinishedAt: time.Now()}}
+	logDatedEntries(em, 10, 1)
+	am := NewAuditManagement(em)
+	ctx := context.Background()
+
+	response, err := am.ExecuteCleanup(ctx, ExecuteCleanupRequest{IncludeAudit: true, IncludeData: true})
+	require.NoError(t, err)
+	assert.Zero(t, response.Results["runs"].DeletedCount, "nothing expires without a retention")
+	assert.Len(t, em.Runs, 2)
+
+	em.Config.Compliance.DataRetention = 7
+	status, err := am.GetRetentionStatus(ctx, GetRetentionStatusRequest{})
+	require.NoError(t, err)
+	assert.Equal(t, 1, status.DataStats["runs"].EntriesToDelete)
+
+	response, err = am.ExecuteCleanup(ctx, ExecuteCleanupRequest{IncludeAudit: true, IncludeData: true})
+	require.NoError(t, err)
+	assert.Equal(t, CleanupResult{ProcessedCount: 2, DeletedCount: 1}, response.Results["runs"])
+	assert.Equal(t, 1, response.Results["audit"].DeletedCount)
+	assert.Equal(t, "run-new", em.Runs[0].ID)
+
+	data, err := os.ReadFile(filepath.Join(em.StoragePath, "runs.json"))
+	require.NoError(t, err)
+	assert.NotContains(t, string(data), "run-old")
+}
+
+func TestComplianceConfig_DataRetention(t *testing.T) {
+	var config EnterpriseConfig
+	require.NoError(t, yaml.Unmarshal([]byte("compliance:\n  data_retention: 90\n  audit_retention: 365\n"), &config))
+	assert.Equal(t, 90, config.Compliance.DataRetention)
+	now := time.Now()
+	em := &EnterpriseManager{Config: config}
+	assert.Equal(t, now.AddDate(0, 0, -365), em.auditRetentionCutoff(now))
+}
@@ -5,7 +5,10 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"strconv"
+	"strings"
 	"time"
+	"unicode"
 
 	"gopkg.in/yaml.v3"
 
@@ -20,6 +23,16 @@ type EnterpriseIntegration struct {
 	TeamManagement         *TeamManagement
 	AuditManagement        *AuditManagement
 	APIManagement          *APIManagement
+	RunManagement          *RunManagement
+	ApprovalManagement     *ApprovalManagement
+	UsageManagement        *UsageManagement
+	AccountManagement      *AccountManagement
+	RoleManagement         *RoleManagement
+	WebhookManagement      *WebhookManagement
+	PrivacyManagement      *PrivacyManagement
+	EncryptionManagement   *EncryptionManagement
+	SessionManagement      *SessionManagement
+	RunQueueManagement     *RunQueueManagement
 	Logger                 logger.Logger
 	Initialized           bool
 }
@@ -35,6 +48,16 @@ func NewEnterpriseIntegration(log logger.Logger) *EnterpriseIntegration {
 		TeamManagement:     NewTeamManagement(manager),
 		AuditManagement:    NewAuditManagement(manager),
 		APIManagement:      NewAPIManagement(manager),
+		RunManagement:      NewRunManagement(manager),
+		ApprovalManagement: NewApprovalManagement(manager),
+		UsageManagement:    NewUsageManagement(manager),
+		AccountManagement:  NewAccountManagement(manager),
+		RoleManagement:     NewRoleManagement(manager),
+		WebhookManagement:  NewWebhookManagement(manager),
+		PrivacyManagement:  NewPrivacyManagement(manager),
+		EncryptionManagement: NewEncryptionManagement(manager),
+		SessionManagement:  NewSessionManagement(manager),
+		RunQueueManagement: NewRunQueueManagement(manager),
 		Logger:            log,
 		Initialized:       false,
 	}
@@ -102,6 +125,153 @@ func (ei *EnterpriseIntegration) ExecuteEnterpriseAction(ctx context.Context, ac
 		return ei.backupData(ctx, params)
 	case "cleanup_data":
 		return ei.cleanupData(ctx, params)
+	case "run_list":
+		return ei.listRuns(ctx, params)
+	case "usage_report":
+		return ei.UsageManagement.Report(ctx, UsageReportRequest{
+			User:    getString(params, "user"),
+			Project: getString(params, "project"),
+			Period:  getString(params, "period"),
+		})
+	case "usage_export":
+		return ei.UsageManagement.ExportUsage(ctx, UsageExportRequest{
+			Period:   getString(params, "period"),
+			Format:   getString(params, "format"),
+			Project:  getString(params, "project"),
+			Output:   getString(params, "output"),
+			NoUpload: getBool(params, "no_upload", false),
+		})
+	case "approval_list":
+		return ei.ApprovalManagement.ListApprovals(ctx, getString(params, "status")), nil
+	case "approval_approve":
+		return ei.decideApproval(ctx, params, true)
+	case "approval_reject":
+		return ei.decideApproval(ctx, params, false)
+	case "audit_verify":
+		return ei.verifyAuditLog(ctx, params)
+	case "user_invite":
+		return ei.AccountManagement.Invite(ctx, InviteUserRequest{
+			Email:     getString(params, "email"),
+			Role:      getString(params, "role"),
+			FirstName: getString(params, "first_name"),
+			LastName:  getString(params, "last_name"),
+			InvitedBy: getString(params, "invited_by"),
+		})
+	case "invitation_accept":
+		return ei.acceptInvitation(ctx, params)
+	case "password_reset_request":
+		if err := ei.AccountManagement.RequestPasswordReset(ctx, getString(params, "user")); err != nil {
+			return nil, err
+		}
+		return map[string]interface{}{"requested": true}, nil
+	case "password_reset":
+		if err := ei.AccountManagement.ResetPassword(ctx, ResetPasswordRequest{
+			Token:    getString(params, "token"),
+			Password: getString(params, "password"),
+		}); err != nil {
+			return nil, err
+		}
+		return map[string]interface{}{"reset": true}, nil
+	case "password_change":
+		if err := ei.AccountManagement.ChangePassword(ctx, ChangePasswordRequest{
+			Username:        getString(params, "username"),
+			CurrentPassword: getString(params, "current_password"),
+			NewPassword:     getString(params, "new_password"),
+		}); err != nil {
+			return nil, err
+		}
+		return map[string]interface{}{"changed": true}, nil
+	case "role_create":
+		return ei.RoleManagement.CreateRole(ctx, CreateRoleRequest{
+			ID:          getString(params, "id"),
+			Name:        getString(params, "name"),
+			Description: getString(params, "description"),
+			Permissions: getStringSlice(params, "permissions"),
+			Inherits:    getStringSlice(params, "inherits"),
+			CreatedBy:   getString(params, "created_by"),
+		})
+	case "role_update":
+		return ei.updateRole(ctx, params)
+	case "role_delete":
+		if err := ei.RoleManagement.DeleteRole(ctx, getString(params, "id"), getString(params, "deleted_by")); err != nil {
+			return nil, err
+		}
+		return map[string]interface{}{"deleted": getString(params, "id")}, nil
+	case "role_list":
+		return ei.RoleManagement.ListRoles(ctx), nil
+	case "role_assign_teams":
+		return ei.RoleManagement.AssignTeams(ctx, AssignTeamRolesRequest{
+			Teams:      getStringSlice(params, "teams"),
+			Roles:      getStringSlice(params, "roles"),
+			Remove:     getBool(params, "remove", false),
+			AssignedBy: getString(params, "assigned_by"),
+		})
+	case "permission_list":
+		return Permissions, nil
+	case "webhook_deliveries":
+		return ei.WebhookManagement.ListDeliveries(ctx, ListDeliveriesRequest{
+			Event:    getString(params, "event"),
+			Status:   getString(params, "status"),
+			Endpoint: getString(params, "endpoint"),
+			Page:     getInt(params, "page", 1),
+			PageSize: getInt(params, "page_size", DefaultDeliveryPageSize),
+		}), nil
+	case "webhook_redeliver":
+		return ei.WebhookManagement.Redeliver(ctx, getString(params, "id"))
+	case "gdpr_erase":
+		return ei.PrivacyManagement.EraseUser(ctx, EraseUserRequest{
+			User:     getString(params, "user"),
+			ErasedBy: getString(params, "erased_by"),
+		})
+	case "session_list":
+		return ei.SessionManagement.ListSessions(ctx, ListSessionsRequest{
+			User:     getString(params, "user"),
+			ListedBy: getString(params, "listed_by"),
+		})
+	case "session_revoke":
+		return ei.SessionManagement.RevokeSession(ctx, RevokeSessionRequest{
+			ID:        getString(params, "id"),
+			User:      getString(params, "user"),
+			RevokedBy: getString(params, "revoked_by"),
+		})
+	case "storage_rotate_key":
+		return ei.EncryptionManagement.RotateKey(ctx, RotateKeyRequest{
+			RotatedBy: getString(params, "rotated_by"),
+		})
+	case "queue_submit":
+		return ei.submitRun(ctx, params)
+	case "queue_status":
+		return ei.RunQueueManagement.QueueStatus(ctx, getString(params, "id"))
+	case "queue_list":
+		return ei.RunQueueManagement.ListQueue(ctx, ListQueueRequest{
+			Status:  getString(params, "status"),
+			Project: getString(params, "project"),
+		})
+	case "queue_cancel":
+		return ei.RunQueueManagement.CancelRun(ctx, CancelRunRequest{
+			ID:          getString(params, "id"),
+			CancelledBy: getString(params, "cancelled_by"),
+		})
+	case "queue_claim":
+		run, err := ei.RunQueueManagement.ClaimRun(ctx, getString(params, "worker"))
+		if err != nil || run == nil {
+			return map[string]interface{}{"claimed": false}, err
+		}
+		return run, nil
+	case "queue_heartbeat":
+		return ei.RunQueueManagement.HeartbeatRun(ctx, HeartbeatRunRequest{
+			ID:       getString(params, "id"),
+			Worker:   getString(params, "worker"),
+			Progress: getString(params, "progress"),
+		})
+	case "queue_complete":
+		return ei.RunQueueManagement.CompleteRun(ctx, CompleteRunRequest{
+			ID:      getString(params, "id"),
+			Worker:  getString(params, "worker"),
+			Success: getBool(params, "success", false),
+			Error:   getString(params, "error"),
+			Release: getBool(params, "release", false),
+		})
 	default:
 		return nil, fmt.Errorf("unsupported enterprise action: %s", actionType)
 	}
@@ -117,6 +287,7 @@ func (ei *EnterpriseIntegration) createUser(ctx context.Context, params map[stri
 		LastName:  getString(params, "last_name"),
 		Password:  getString(params, "password"),
 		Role:      getString(params, "role"),
+		MustChangePassword: getBool(params, "must_change_password", false),
 	}
 
 	if teams, ok := params["team_ids"].([]string); ok {
@@ -142,9 +313,51 @@ func (ei *EnterpriseIntegration) createUser(ctx context.Context, params map[stri
 	}, nil
 }
 
+func (ei *EnterpriseIntegration) acceptInvitation(ctx context.Context, params map[string]interface{}) (interface{}, error) {
+	user, err := ei.AccountManagement.AcceptInvitation(ctx, AcceptInvitationRequest{
+		Token:     getString(params, "token"),
+		Username:  getString(params, "username"),
+		Password:  getString(params, "password"),
+		FirstName: getString(params, "first_name"),
+		LastName:  getString(params, "last_name"),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return map[string]interface{}{
+		"user_id":    user.ID,
+		"username":   user.Username,
+		"email":      user.Email,
+		"role":       user.Role,
+		"created_at": user.CreatedAt,
+	}, nil
+}
+
+// updateRole changes only the permissions and inherited roles given
+func (ei *EnterpriseIntegration) updateRole(ctx context.Context, params map[string]interface{}) (interface{}, error) {
+	req := UpdateRoleRequest{
+		ID:        getString(params, "id"),
+		Name:      getString(params, "name"),
+		UpdatedBy: getString(params, "updated_by"),
+	}
+	if _, ok := params["description"]; ok {
+		description := getString(params, "description")
+		req.Description = &description
+	}
+	if _, ok := params["permissions"]; ok {
+		req.Permissions = getStringSlice(params, "permissions")
+	}
+	if _, ok := params["inherits"]; ok {
+		req.Inherits = getStringSlice(params, "inherits")
+	}
+	return ei.RoleManagement.UpdateRole(ctx, req)
+}
+
 func (ei *EnterpriseIntegration) authenticateUser(ctx context.Context, params map[string]interface{}) (interface{}, error) {
 	username := getString(params, "username")
 	password := getString(params, "password")
+	ctx = WithClient(ctx, ClientInfo{IPAddress: getString(params, "ip_address"), UserAgent: getString(params, "user_agent")})
 
 	session, err := ei.UserManagement.AuthenticateUser(ctx, username, password)
 	if err != nil {
@@ -164,6 +377,11 @@ func (ei *EnterpriseIntegration) createProject(ctx context.Context, params map[s
 		Name:        getString(params, "name"),
 		Description: getString(params, "description"),
 		OwnerID:     getString(params, "owner_id"),
+		Settings: ProjectSettings{
+			TestRetention: getInt(params, "test_retention", 0),
+			MaxTestRuns:   getInt(params, "max_test_runs", 0),
+			MaxConcurrentRuns: getInt(params, "max_concurrent_runs", 0),
+		},
 	}
 
 	if teams, ok := params["team_ids"].([]string); ok {
@@ -187,6 +405,47 @@ func (ei *EnterpriseIntegration) createProject(ctx context.Context, params map[s
 	}, nil
 }
 
+func (ei *EnterpriseIntegration) listRuns(ctx context.Context, params map[string]interface{}) (interface{}, error) {
+	return ei.RunManagement.ListRuns(ctx, ListRunsRequest{
+		User:     getString(params, "user"),
+		Project:  getString(params, "project"),
+		Page:     getInt(params, "page", 1),
+		PageSize: getInt(params, "page_size", DefaultRunPageSize),
+	})
+}
+
+// submitRun queues the configuration file given as config, or the
+// configuration itself given as config_data
+func (ei *EnterpriseIntegration) submitRun(ctx context.Context, params map[string]interface{}) (interface{}, error) {
+	req := SubmitRunRequest{
+		Config:      getString(params, "config"),
+		ConfigData:  getString(params, "config_data"),
+		Project:     getString(params, "project"),
+		Priority:    getInt(params, "priority", 0),
+		SubmittedBy: getString(params, "submitted_by"),
+	}
+	if req.ConfigData == "" && req.Config != "" {
+		data, err := os.ReadFile(req.Config)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read configuration: %w", err)
+		}
+		req.ConfigData = string(data)
+	}
+	if req.Config != "" {
+		req.Config = filepath.Base(req.Config)
+	}
+	return ei.RunQueueManagement.SubmitRun(ctx, req)
+}
+
+func (ei *EnterpriseIntegration) decideApproval(ctx context.Context, params map[string]interface{}, approve bool) (interface{}, error) {
+	return ei.ApprovalManagement.Decide(ctx, DecideApprovalRequest{
+		ID:       getString(params, "id"),
+		Approver: getString(params, "approver"),
+		Approve:  approve,
+		Comment:  getString(params, "comment"),
+	})
+}
+
 func (ei *EnterpriseIntegration) createTeam(ctx context.Context, params map[string]interface{}) (interface{}, error) {
 	req := CreateTeamRequest{
 		Name:        getString(params, "name"),
@@ -249,11 +508,32 @@ func (ei *EnterpriseIntegration) createAPIKey(ctx context.Context, params map[st
 
 func (ei *EnterpriseIntegration) getAuditReport(ctx context.Context, params map[string]interface{}) (interface{}, error) {
 	req := GetAuditLogRequest{
-		Page:     getInt(params, "page", 1),
-		PageSize: getInt(params, "page_size", 50),
-		UserID:   getString(params, "user_id"),
-		Action:   getString(params, "action"),
-		Severity: getString(params, "severity"),
+		Page:       getInt(params, "page", 1),
+		PageSize:   min(getInt(params, "page_size", DefaultAuditPageSize), MaxAuditPageSize),
+		UserID:     getString(params, "user_id"),
+		Username:   getString(params, "username"),
+		Action:     getString(params, "action"),
+		Resource:   getString(params, "resource"),
+		ResourceID: getString(params, "resource_id"),
+		Category:   getString(params, "category"),
+		Severity:   getString(params, "severity"),
+	}
+	var err error
+	if req.StartTime, err = getTime(params, "start", false); err != nil {
+		return nil, err
+	}
+	if req.EndTime, err = getTime(params, "end", true); err != nil {
+		return nil, err
+	}
+	if success := getString(params, "success"); success != "" {
+		value, err := strconv.ParseBool(success)
+		if err != nil {
+			return nil, fmt.Errorf("invalid success %q; use true or false", success)
+		}
+		req.Success = &value
+	} else if _, ok := params["success"].(bool); ok {
+		value := getBool(params, "success", false)
+		req.Success = &value
 	}
 
 	response, err := ei.AuditManagement.GetAuditLog(ctx, req)
@@ -269,6 +549,19 @@ func (ei *EnterpriseIntegration) getAuditReport(ctx context.Context, params map[
 	}, nil
 }
 
+// verifyAuditLog returns the verification with an error when the audit log
+// fails it, for the command to report the problems and exit non-zero
+func (ei *EnterpriseIntegration) verifyAuditLog(ctx context.Context, params map[string]interface{}) (interface{}, error) {
+	result, err := ei.AuditManagement.VerifyAuditLog(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if !result.Verified {
+		return result, fmt.Errorf("audit log failed verification with %d problem(s)", len(result.Problems))
+	}
+	return result, nil
+}
+
 func (ei *EnterpriseIntegration) getComplianceStatus(ctx context.Context, params map[string]interface{}) (interface{}, error) {
 	req := GetComplianceStatusRequest{
 		Standards: getStringSlice(params, "standards"),
@@ -524,9 +817,22 @@ func getString(params map[string]interface{}, key string) string {
 	return ""
 }
 
+// getStringSlice also splits strings on commas and spaces, as the command
+// line passes lists like permissions="test.read test.run"
 func getStringSlice(params map[string]interface{}, key string) []string {
-	if val, ok := params[key].([]string); ok {
+	switch val := params[key].(type) {
+	case []string:
 		return val
+	case []interface{}:
+		values := make([]string, 0, len(val))
+		for _, item := range val {
+			if s, ok := item.(string); ok {
+				values = append(values, s)
+			}
+		}
+		return values
+	case string:
+		return strings.FieldsFunc(val, func(r rune) bool { return r == ',' || unicode.IsSpace(r) })
 	}
 	return []string{}
 }
@@ -538,12 +844,45 @@ func getInt(params map[string]interface{}, key string, defaultValue int) int {
 	if val, ok := params[key].(float64); ok {
 		return int(val)
 	}
+	if val, ok := params[key].(string); ok {
+		if n, err := strconv.Atoi(val); err == nil {
+			return n
+		}
+	}
 	return defaultValue
 }
 
+// getTime reads a time given in RFC 3339 or as a date, which stands for the
+// start of the day, or its end when endOfDay is set
+func getTime(params map[string]interface{}, key string, endOfDay bool) (time.Time, error) {
+	if val, ok := params[key].(time.Time); ok {
+		return val, nil
+	}
+	val := getString(params, key)
+	if val == "" {
+		return time.Time{}, nil
+	}
+	if t, err := time.Parse(time.RFC3339, val); err == nil {
+		return t, nil
+	}
+	day, err := time.ParseInLocation("2006-01-02", val, time.Local)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid %s %q; use RFC 3339 or YYYY-MM-DD", key, val)
+	}
+	if endOfDay {
+		day = day.AddDate(0, 0, 1).Add(-time.Nanosecond)
+	}
+	return day, nil
+}
+
 func getBool(params map[string]interface{}, key string, defaultValue bool) bool {
 	if val, ok := params[key].(bool); ok {
 		return val
 	}
+	if val, ok := params[key].(string); ok {
+		if b, err := strconv.ParseBool(val); err == nil {
+			return b
+		}
+	}
 	return defaultValue
 }
\ No newline at end of file
@@ -8,6 +8,7 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+	"sync"
 	"time"
 
 	"golang.org/x/crypto/bcrypt"
@@ -24,12 +25,29 @@ type EnterpriseManager struct {
 	Roles            map[string]*Role
 	Teams            map[string]*Team
 	Projects         map[string]*Project
-	AuditLog         []AuditEntry
+	AuditLog         []AuditEntry // the recent entries
+	AuditStore       AuditStore
+	AuditCheckpoints []AuditCheckpoint
 	Subscriptions    map[string]*Subscription
 	APIKeys          map[string]*APIKey
 	Sessions         map[string]*Session
+	Runs             []RunRecord
+	Approvals        map[string]*Approval
+	Usage            map[string]*Usage
+	AccountTokens    map[string]*AccountToken // by token hash
+	Mailer           Mailer                   // integration.email unless set
+	WebhookDeliveries []WebhookDelivery       // the recent ones
 	StoragePath      string
 	Initialized      bool
+
+	lastAuditHash  string // of the last audit entry, chained to the next
+	chainedEntries int    // audit entries chained, for the checkpoint interval
+	auditKey       []byte // signs audit checkpoints; none without a key
+
+	storageKey         []byte // the organization key encrypting storage files
+	previousStorageKey []byte // the one being rotated out, still decrypting
+
+	queueMu sync.Mutex // with the lock file, serializes run queue changes
 }
 
 // EnterpriseConfig contains enterprise configuration
@@ -45,10 +63,17 @@ type EnterpriseConfig struct {
 	MaxAPIKeys      int                  `yaml:"max_api_keys"`
 	APIRateLimit    int                  `yaml:"api_rate_limit"`
 	SessionTimeout  int                  `yaml:"session_timeout"`   // minutes
+	InvitationExpiry    int              `yaml:"invitation_expiry"`     // hours, 72 by default
+	PasswordResetExpiry int              `yaml:"password_reset_expiry"` // minutes, 60 by default
 	PasswordPolicy  PasswordPolicy        `yaml:"password_policy"`
 	License         LicenseConfig        `yaml:"license"`
 	BackupConfig    BackupConfig         `yaml:"backup_config"`
 	Compliance      ComplianceConfig     `yaml:"compliance"`
+	AuditStorage    string               `yaml:"audit_storage"`     // memory, file
+	AuditIntegrity  AuditIntegrityConfig `yaml:"audit_integrity"`
+	StorageEncryption StorageEncryptionConfig `yaml:"storage_encryption"`
+	BillingExport   BillingExportConfig  `yaml:"billing_export"`
+	RunQueue        RunQueueConfig       `yaml:"run_queue"`
 	Integration     IntegrationConfig    `yaml:"integration"`
 }
 
@@ -94,6 +119,7 @@ type ComplianceConfig struct {
 	DataEncryption    bool   `yaml:"data_encryption"`
 	AuditEncryption   bool   `yaml:"audit_encryption"`
 	RequireApproval   bool   `yaml:"require_approval"`
+	ProtectedURLs     []string `yaml:"protected_urls"`   // runs targeting these need approval; * is a wildcard
 	ApprovalWorkflow string `yaml:"approval_workflow"`
 }
 
@@ -105,6 +131,7 @@ type IntegrationConfig struct {
 	Webhook    WebhookConfig    `yaml:"webhook"`
 	SIEM       SIEMConfig       `yaml:"siem"`
 	Monitoring MonitoringConfig `yaml:"monitoring"`
+	Email      EmailConfig      `yaml:"email"`
 }
 
 // LDAPConfig contains LDAP configuration
@@ -147,6 +174,9 @@ type WebhookConfig struct {
 	Headers   map[string]string `yaml:"headers"`
 	Events    []string `yaml:"events"`
 	Secret    string `yaml:"secret"`
+	MaxAttempts int           `yaml:"max_attempts"` // 3 by default
+	Backoff     time.Duration `yaml:"backoff"`      // before the second attempt, doubling; 1s by default
+	Timeout     time.Duration `yaml:"timeout"`      // per attempt, 10s by default
 }
 
 // SIEMConfig contains SIEM integration configuration
@@ -186,6 +216,8 @@ type User struct {
 	UpdatedAt       time.Time         `json:"updated_at"`
 	Active          bool              `json:"active"`
 	Metadata        map[string]string `json:"metadata"`
+	MustChangePassword bool           `json:"must_change_password,omitempty"` // before signing in
+	PasswordHistory []string          `json:"password_history,omitempty"`     // previous hashes, newest first
 }
 
 // UserPreferences contains user preferences
@@ -220,6 +252,7 @@ type Team struct {
 	LeadID      string            `json:"lead_id"`
 	MemberIDs   []string          `json:"member_ids"`
 	ProjectIDs  []string          `json:"project_ids"`
+	RoleIDs     []string          `json:"role_ids,omitempty"` // granted to the members
 	CreatedAt   time.Time         `json:"created_at"`
 	UpdatedAt   time.Time         `json:"updated_at"`
 	Active      bool              `json:"active"`
@@ -247,6 +280,7 @@ type ProjectSettings struct {
 	Privacy         string `json:"privacy"`         // public, private, team
 	TestRetention   int    `json:"test_retention"` // days
 	MaxTestRuns    int    `json:"max_test_runs"`
+	MaxConcurrentRuns int `json:"max_concurrent_runs"` // queued runs running at once; 0 defers to run_queue
 	AllowSharing    bool   `json:"allow_sharing"`
 	RequireApproval bool   `json:"require_approval"`
 	BackupEnabled  bool   `json:"backup_enabled"`
@@ -268,6 +302,8 @@ type AuditEntry struct {
 	ErrorCode   string            `json:"error_code,omitempty"`
 	Severity    string            `json:"severity"`       // low, medium, high, critical
 	Category    string            `json:"category"`       // auth, access, data, system, security
+	PrevHash    string            `json:"prev_hash,omitempty"` // of the entry before
+	Hash        string            `json:"hash,omitempty"`      // SHA-256 of the entry and PrevHash
 }
 
 // Subscription represents an enterprise subscription
@@ -294,6 +330,7 @@ type SubscriptionLimits struct {
 	MaxAPIKeys    int `json:"max_api_keys"`
 	MaxStorageGB  int `json:"max_storage_gb"`
 	MaxBandwidthGB int `json:"max_bandwidth_gb"`
+	MaxAPICalls   int `json:"max_api_calls"` // a month
 }
 
 // APIKey represents an API key for programmatic access
@@ -318,10 +355,11 @@ type APIKey struct {
 type Session struct {
 	ID        string            `json:"id"`
 	UserID    string            `json:"user_id"`
-	Token     string            `json:"token"`
+	Token     string            `json:"-"` // never written to storage
 	IPAddress string            `json:"ip_address"`
 	UserAgent string            `json:"user_agent"`
 	CreatedAt time.Time         `json:"created_at"`
+	LastSeen  time.Time         `json:"last_seen,omitempty"`
 	ExpiresAt time.Time         `json:"expires_at"`
 	Active    bool              `json:"active"`
 	Metadata  map[string]string `json:"metadata"`
@@ -339,6 +377,9 @@ func NewEnterpriseManager(log logger.Logger) *EnterpriseManager {
 		Subscriptions: make(map[string]*Subscription),
 		APIKeys:       make(map[string]*APIKey),
 		Sessions:      make(map[string]*Session),
+		Approvals:     make(map[string]*Approval),
+		Usage:         make(map[string]*Usage),
+		AccountTokens: make(map[string]*AccountToken),
 		Initialized:   false,
 	}
 }
@@ -359,6 +400,27 @@ func (em *EnterpriseManager) Initialize(config EnterpriseConfig) error {
 		return fmt.Errorf("failed to create enterprise storage directory: %w", err)
 	}
 
+	// Load the organization key, failing closed without the one needed
+	if err := em.unlockStorage(); err != nil {
+		return err
+	}
+
+	// Open the audit log, unless a backend was plugged in
+	if em.AuditStore == nil {
+		store, err := em.openAuditStore()
+		if err != nil {
+			return err
+		}
+		em.AuditStore = store
+	}
+
+	// Load the key signing audit checkpoints
+	key, err := loadAuditKey(config.AuditIntegrity.KeyEnv)
+	if err != nil {
+		return err
+	}
+	em.auditKey = key
+
 	// Initialize default roles
 	if err := em.initializeDefaultRoles(); err != nil {
 		return fmt.Errorf("failed to initialize default roles: %w", err)
@@ -369,6 +431,9 @@ func (em *EnterpriseManager) Initialize(config EnterpriseConfig) error {
 		em.Logger.Warnf("Failed to load enterprise data: %v", err)
 	}
 
+	// Purge what's past the compliance retention
+	em.applyDataRetention(time.Now())
+
 	// Validate license
 	if err := em.validateLicense(); err != nil {
 		em.Logger.Warnf("License validation failed: %v", err)
@@ -412,6 +477,7 @@ func (em *EnterpriseManager) initializeDefaultRoles() error {
 			"analytics.read":    true,
 			"settings.read":     true,
 			"settings.update":   true,
+			"run.approve":       true,
 			"system.admin":      true,
 		},
 		"manager": {
@@ -450,6 +516,14 @@ func (em *EnterpriseManager) initializeDefaultRoles() error {
 			"report.create":      true,
 			"analytics.read":    true,
 		},
+		"approver": {
+			"user.read":            true,
+			"team.read":          true,
+			"project.read":       true,
+			"test.read":         true,
+			"report.read":       true,
+			"run.approve":       true,
+		},
 		"viewer": {
 			"user.read":            true,
 			"team.read":          true,
@@ -499,10 +573,11 @@ func (em *EnterpriseManager) loadData() error {
 		em.Logger.Warnf("Failed to load projects: %v", err)
 	}
 
-	// Load audit log
-	if err := em.loadJSON("audit.json", &em.AuditLog); err != nil {
+	// Load the recent audit entries
+	if err := em.loadAuditLog(); err != nil {
 		em.Logger.Warnf("Failed to load audit log: %v", err)
 	}
+	em.loadAuditChain()
 
 	// Load subscriptions
 	if err := em.loadJSON("subscriptions.json", &em.Subscriptions); err != nil {
@@ -514,6 +589,36 @@ func (em *EnterpriseManager) loadData() error {
 		em.Logger.Warnf("Failed to load API keys: %v", err)
 	}
 
+	// Load runs; there are none before the first project run
+	if err := em.loadJSON("runs.json", &em.Runs); err != nil && !os.IsNotExist(err) {
+		em.Logger.Warnf("Failed to load runs: %v", err)
+	}
+
+	// Load sessions; there are none before the first sign-in
+	if err := em.loadJSON("sessions.json", &em.Sessions); err != nil && !os.IsNotExist(err) {
+		em.Logger.Warnf("Failed to load sessions: %v", err)
+	}
+
+	// Load approvals; there are none before the first protected run
+	if err := em.loadJSON("approvals.json", &em.Approvals); err != nil && !os.IsNotExist(err) {
+		em.Logger.Warnf("Failed to load approvals: %v", err)
+	}
+
+	// Load usage; there is none before the first metered run or API call
+	if err := em.loadJSON("usage.json", &em.Usage); err != nil && !os.IsNotExist(err) {
+		em.Logger.Warnf("Failed to load usage: %v", err)
+	}
+
+	// Load account tokens; there are none before the first invitation or reset
+	if err := em.loadJSON("account_tokens.json", &em.AccountTokens); err != nil && !os.IsNotExist(err) {
+		em.Logger.Warnf("Failed to load account tokens: %v", err)
+	}
+
+	// Load webhook deliveries; there are none before the first event
+	if err := em.loadJSON("webhook_deliveries.json", &em.WebhookDeliveries); err != nil && !os.IsNotExist(err) {
+		em.Logger.Warnf("Failed to load webhook deliveries: %v", err)
+	}
+
 	em.Logger.Info("Enterprise data loaded successfully")
 	return nil
 }
@@ -540,9 +645,16 @@ func (em *EnterpriseManager) saveData() error {
 		return fmt.Errorf("failed to save projects: %w", err)
 	}
 
-	// Save audit log
-	if err := em.saveJSON("audit.json", em.AuditLog); err != nil {
-		return fmt.Errorf("failed to save audit log: %w", err)
+	// Save audit log; other backends keep it as it's written
+	if _, ok := em.auditStore().(*memoryAuditStore); ok {
+		if err := em.saveJSON("audit.json", em.AuditLog); err != nil {
+			return fmt.Errorf("failed to save audit log: %w", err)
+		}
+		if len(em.AuditCheckpoints) > 0 {
+			if err := em.saveJSON("audit_checkpoints.json", em.AuditCheckpoints); err != nil {
+				return fmt.Errorf("failed to save audit checkpoints: %w", err)
+			}
+		}
 	}
 
 	// Save subscriptions
@@ -555,6 +667,31 @@ func (em *EnterpriseManager) saveData() error {
 		return fmt.Errorf("failed to save API keys: %w", err)
 	}
 
+	// Save runs
+	if err := em.saveJSON("runs.json", em.Runs); err != nil {
+		return fmt.Errorf("failed to save runs: %w", err)
+	}
+
+	// Save sessions
+	if err := em.saveJSON("sessions.json", em.Sessions); err != nil {
+		return fmt.Errorf("failed to save sessions: %w", err)
+	}
+
+	// Save approvals
+	if err := em.saveJSON("approvals.json", em.Approvals); err != nil {
+		return fmt.Errorf("failed to save approvals: %w", err)
+	}
+
+	// Save usage
+	if err := em.saveJSON("usage.json", em.Usage); err != nil {
+		return fmt.Errorf("failed to save usage: %w", err)
+	}
+
+	// Save account tokens
+	if err := em.saveJSON("account_tokens.json", em.AccountTokens); err != nil {
+		return fmt.Errorf("failed to save account tokens: %w", err)
+	}
+
 	return nil
 }
 
@@ -565,6 +702,9 @@ func (em *EnterpriseManager) loadJSON(filename string, target interface{}) error
 	if err != nil {
 		return err
 	}
+	if data, err = em.openStored(filename, data); err != nil {
+		return err
+	}
 	return json.Unmarshal(data, target)
 }
 
@@ -575,6 +715,9 @@ func (em *EnterpriseManager) saveJSON(filename string, data interface{}) error {
 	if err != nil {
 		return err
 	}
+	if jsonData, err = em.sealStored(filename, jsonData); err != nil {
+		return err
+	}
 	return os.WriteFile(filePath, jsonData, 0600)
 }
 
@@ -607,11 +750,12 @@ func (em *EnterpriseManager) startCleanupRoutines() {
 		}
 	}()
 
-	// Clean up old backups daily
+	// Clean up old backups and purge expired data daily
 	go func() {
 		ticker := time.NewTicker(24 * time.Hour)
 		for range ticker.C {
 			em.cleanupOldBackups()
+			em.applyDataRetention(time.Now())
 		}
 	}()
 }
@@ -0,0 +1,343 @@
+package enterprise
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"panoptic/internal/logger"
+)
+
+// PrivacyManagement erases users on request, as the GDPR right to erasure
+// asks: their account and what only it needs are removed, and their
+// records everything else keeps, the audit log included, are anonymized
+type PrivacyManagement struct {
+	Manager *EnterpriseManager
+	Logger  logger.Logger
+}
+
+// NewPrivacyManagement creates new privacy management handler
+func NewPrivacyManagement(manager *EnterpriseManager) *PrivacyManagement {
+	return &PrivacyManagement{
+		Manager: manager,
+		Logger:  manager.Logger,
+	}
+}
+
+// EraseUser removes the user, their sessions, API keys, invitations and
+// password resets, and the webhook deliveries that name them, and puts a
+// pseudonym in their place in runs, projects, teams, approvals,
+// subscriptions, usage and the audit log. Audit entries changed are
+// chained and their checkpoints signed again, so the log still verifies.
+func (pm *PrivacyManagement) EraseUser(ctx context.Context, req EraseUserRequest) (*EraseUserResponse, error) {
+	em := pm.Manager
+	if req.ErasedBy == "" {
+		return nil, fmt.Errorf("the acting user is required")
+	}
+	runs := NewRunManagement(em)
+	actor, err := runs.findUser(req.ErasedBy)
+	if err != nil {
+		return nil, err
+	}
+	if !em.hasPermission(actor, "user.delete") {
+		return nil, fmt.Errorf("user %s may not erase users", actor.Username)
+	}
+	user, err := runs.findUser(req.User)
+	if err != nil {
+		return nil, err
+	}
+	if user.ID == actor.ID {
+		return nil, fmt.Errorf("user %s can't erase themselves", actor.Username)
+	}
+	if user.Role == "admin" && pm.activeAdmins() <= 1 && user.Active {
+		return nil, fmt.Errorf("user %s is the last active admin", user.Username)
+	}
+
+	erasure := &erasure{user: user, pseudonym: pseudonymOf(user.ID)}
+	response := &EraseUserResponse{
+		UserID:     user.ID,
+		Pseudonym:  erasure.pseudonym,
+		Removed:    map[string]int{},
+		Anonymized: map[string]int{},
+		ErasedAt:   time.Now(),
+	}
+
+	// The audit log first, since re-signing its checkpoints can fail
+	entries, err := pm.anonymizeAuditLog(erasure)
+	if err != nil {
+		return nil, err
+	}
+	response.Anonymized["audit_entries"] = entries
+
+	delete(em.Users, user.Username)
+	response.Removed["users"] = 1
+	for id, session := range em.Sessions {
+		if session.UserID == user.ID {
+			delete(em.Sessions, id)
+			response.Removed["sessions"]++
+		}
+	}
+	for id, key := range em.APIKeys {
+		if key.UserID == user.ID {
+			delete(em.APIKeys, id)
+			response.Removed["api_keys"]++
+		}
+	}
+	for hash, token := range em.AccountTokens {
+		switch {
+		case token.UserID == user.ID || (user.Email != "" && strings.EqualFold(token.Email, user.Email)):
+			delete(em.AccountTokens, hash)
+			response.Removed["account_tokens"]++
+		case erasure.names(token.CreatedBy):
+			token.CreatedBy = erasure.pseudonym
+			response.Anonymized["account_tokens"]++
+		}
+	}
+	deliveries := em.WebhookDeliveries[:0]
+	for _, delivery := range em.WebhookDeliveries {
+		if erasure.inPayload(delivery.Payload) {
+			response.Removed["webhook_deliveries"]++
+			continue
+		}
+		deliveries = append(deliveries, delivery)
+	}
+	em.WebhookDeliveries = deliveries
+
+	for _, team := range em.Teams {
+		changed := contains(team.MemberIDs, user.ID) || team.LeadID == user.ID
+		team.MemberIDs = remove(team.MemberIDs, user.ID)
+		if team.LeadID == user.ID {
+			team.LeadID = ""
+		}
+		if changed {
+			response.Anonymized["teams"]++
+		}
+	}
+	for _, project := range em.Projects {
+		changed := contains(project.MemberIDs, user.ID) || project.OwnerID == user.ID
+		project.MemberIDs = remove(project.MemberIDs, user.ID)
+		if project.OwnerID == user.ID {
+			project.OwnerID = erasure.pseudonym
+		}
+		if changed {
+			response.Anonymized["projects"]++
+		}
+	}
+	for i := range em.Runs {
+		if em.Runs[i].UserID == user.ID {
+			em.Runs[i].UserID = erasure.pseudonym
+			response.Anonymized["runs"]++
+		}
+	}
+	for _, approval := range em.Approvals {
+		changed := false
+		if erasure.names(approval.RequestedBy) {
+			approval.RequestedBy, changed = erasure.pseudonym, true
+		}
+		if erasure.names(approval.DecidedBy) {
+			approval.DecidedBy, changed = erasure.pseudonym, true
+		}
+		if changed {
+			response.Anonymized["approvals"]++
+		}
+	}
+	for _, subscription := range em.Subscriptions {
+		if subscription.UserID == user.ID {
+			subscription.UserID = erasure.pseudonym
+			response.Anonymized["subscriptions"]++
+		}
+	}
+	for key, usage := range em.Usage {
+		if usage.Subject == UsageUser && usage.SubjectID == user.ID {
+			delete(em.Usage, key)
+			usage.SubjectID = erasure.pseudonym
+			em.Usage[usageKey(UsageUser, usage.SubjectID, usage.Period)] = usage
+			response.Anonymized["usage"]++
+		}
+	}
+	if err := em.withQueue(func(queue *[]*QueuedRun) error {
+		for _, run := range *queue {
+			if run.SubmittedBy == user.ID {
+				run.SubmittedBy = erasure.pseudonym
+				response.Anonymized["queued_runs"]++
+			}
+		}
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+
+	details := map[string]string{"pseudonym": erasure.pseudonym}
+	for kind, n := range response.Removed {
+		details["removed_"+kind] = strconv.Itoa(n)
+	}
+	for kind, n := range response.Anonymized {
+		details["anonymized_"+kind] = strconv.Itoa(n)
+	}
+	em.logAuditEntry(AuditEntry{
+		Timestamp:  response.ErasedAt,
+		UserID:     actor.ID,
+		Username:   actor.Username,
+		Action:     "user.erase",
+		Resource:   "user",
+		ResourceID: erasure.pseudonym,
+		Details:    details,
+		Success:    true,
+		Severity:   "high",
+		Category:   "data",
+	})
+
+	if err := em.saveData(); err != nil {
+		return nil, fmt.Errorf("failed to save data after erasure: %w", err)
+	}
+	if err := em.saveJSON("webhook_deliveries.json", em.WebhookDeliveries); err != nil {
+		return nil, fmt.Errorf("failed to save webhook deliveries after erasure: %w", err)
+	}
+	pm.Logger.Infof("User %s erased as %s", user.ID, erasure.pseudonym)
+	return response, nil
+}
+
+// Request types
+
+type EraseUserRequest struct {
+	User     string `json:"user"`      // username or ID
+	ErasedBy string `json:"erased_by"` // username or ID
+}
+
+type EraseUserResponse struct {
+	UserID     string         `json:"user_id"`
+	Pseudonym  string         `json:"pseudonym"`
+	Removed    map[string]int `json:"removed"`
+	Anonymized map[string]int `json:"anonymized"`
+	ErasedAt   time.Time      `json:"erased_at"`
+}
+
+// Helper methods
+
+// erasure is a user being erased and the pseudonym replacing them
+type erasure struct {
+	user      *User
+	pseudonym string
+}
+
+// names tells whether a value is the user's ID, username or email
+func (e *erasure) names(value string) bool {
+	return value != "" && (value == e.user.ID || value == e.user.Username || strings.EqualFold(value, e.user.Email))
+}
+
+// inPayload tells whether a JSON payload names the user
+func (e *erasure) inPayload(payload string) bool {
+	for _, value := range []string{e.user.ID, e.user.Username, e.user.Email} {
+		if value != "" && strings.Contains(payload, strconv.Quote(value)) {
+			return true
+		}
+	}
+	return false
+}
+
+// anonymize puts the pseudonym in place of the user in an audit entry,
+// telling whether it changed; the entry's details are copied, not changed
+func (e *erasure) anonymize(entry *AuditEntry) bool {
+	changed := false
+	if (e.user.ID != "" && entry.UserID == e.user.ID) || (e.user.Username != "" && entry.Username == e.user.Username) {
+		entry.UserID, entry.Username, entry.IPAddress, entry.UserAgent = e.pseudonym, e.pseudonym, "", ""
+		changed = true
+	}
+	if e.names(entry.ResourceID) {
+		entry.ResourceID, changed = e.pseudonym, true
+	}
+	var details map[string]string
+	for key, value := range entry.Details {
+		anonymized := value
+		if e.names(value) {
+			anonymized = e.pseudonym
+		} else if e.user.Email != "" && strings.Contains(value, e.user.Email) {
+			anonymized = strings.ReplaceAll(value, e.user.Email, e.pseudonym)
+		}
+		if anonymized == value {
+			continue
+		}
+		if details == nil {
+			details = make(map[string]string, len(entry.Details))
+			for k, v := range entry.Details {
+				details[k] = v
+			}
+		}
+		details[key] = anonymized
+	}
+	if details != nil {
+		entry.Details, changed = details, true
+	}
+	return changed
+}
+
+// anonymizeAuditLog anonymizes the user's audit entries and chains them
+// again, returning how many changed
+func (pm *PrivacyManagement) anonymizeAuditLog(e *erasure) (int, error) {
+	em := pm.Manager
+	store := em.auditStore()
+	entries, err := store.Entries()
+	if err != nil {
+		return 0, fmt.Errorf("failed to read audit log: %w", err)
+	}
+	changed, first := 0, -1
+	for i := range entries {
+		if e.anonymize(&entries[i]) {
+			changed++
+			if first < 0 {
+				first = i
+			}
+		}
+	}
+	if changed == 0 {
+		return 0, nil
+	}
+	rewriter, ok := store.(auditRewriter)
+	if !ok {
+		return 0, fmt.Errorf("the audit store can't anonymize entries")
+	}
+	if err := em.rechainAuditEntries(entries, first); err != nil {
+		return 0, err
+	}
+	if err := rewriter.Rewrite(entries); err != nil {
+		return 0, fmt.Errorf("failed to rewrite audit log: %w", err)
+	}
+
+	// Other stores keep the recent entries in memory too
+	rewritten := make(map[string]AuditEntry, len(entries)-first)
+	for _, entry := range entries[first:] {
+		rewritten[entry.ID] = entry
+	}
+	for i, entry := range em.AuditLog {
+		if replacement, exists := rewritten[entry.ID]; exists {
+			em.AuditLog[i] = replacement
+		}
+	}
+	if len(em.AuditCheckpoints) > 0 {
+		if err := em.saveJSON("audit_checkpoints.json", em.AuditCheckpoints); err != nil {
+			return 0, fmt.Errorf("failed to save audit checkpoints: %w", err)
+		}
+	}
+	return changed, nil
+}
+
+// activeAdmins counts the active admins
+func (pm *PrivacyManagement) activeAdmins() int {
+	count := 0
+	for _, user := range pm.Manager.Users {
+		if user.Active && user.Role == "admin" {
+			count++
+		}
+	}
+	return count
+}
+
+// pseudonymOf is the stable pseudonym of an erased user ID
+func pseudonymOf(userID string) string {
+	sum := sha256.Sum256([]byte(userID))
+	return "erased-" + hex.EncodeToString(sum[:6])
+}
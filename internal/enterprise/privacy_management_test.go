@@ -0,0 +1,136 @@
+package enterprise
+
+import (
+	"context"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"panoptic/internal/logger"
+)
+
+// newPrivacyManagement has the admin root and ana, who runs, approves,
+// subscribes and is audited across the checkout project and qa team
+func newPrivacyManagement(t *testing.T) *PrivacyManagement {
+	t.Helper()
+	em := NewEnterpriseManager(*logger.NewLogger(false))
+	em.StoragePath = t.TempDir()
+	em.Enabled = true
+	em.Users["root"] = &User{ID: "u-root", Username: "root", Role: "admin", Active: true, Permissions: map[string]bool{"user.delete": true}}
+	em.Users["ana"] = &User{ID: "u-ana", Username: "ana", Email: "ana@example.com", Role: "user", Active: true}
+	em.Sessions["s-1"] = &Session{ID: "s-1", UserID: "u-ana"}
+	em.Sessions["s-2"] = &Session{ID: "s-2", UserID: "u-root"}
+	em.APIKeys["k-1"] = &APIKey{ID: "k-1", UserID: "u-ana"}
+	em.AccountTokens["t-1"] = &AccountToken{Kind: "password_reset", UserID: "u-ana", Email: "ana@example.com"}
+	em.AccountTokens["t-2"] = &AccountToken{Kind: "invitation", Email: "cy@example.com", CreatedBy: "u-ana"}
+	em.Teams["t-qa"] = &Team{ID: "t-qa", Name: "qa", LeadID: "u-ana", MemberIDs: []string{"u-ana", "u-root"}, Active: true}
+	em.Projects["p-checkout"] = &Project{ID: "p-checkout", Name: "checkout", OwnerID: "u-ana", MemberIDs: []string{"u-ana"}, Status: "active"}
+	em.Runs = []RunRecord{{ID: "run-1", ProjectID: "p-checkout", UserID: "u-ana", FinishedAt: time.Now()}}
+	em.Approvals["a-1"] = &Approval{ID: "a-1", RequestedBy: "ana", DecidedBy: "root", Status: "approved"}
+	em.Subscriptions["s-pro"] = &Subscription{ID: "s-pro", UserID: "u-ana", Plan: "pro", Status: "active"}
+	em.Usage[usageKey(UsageUser, "u-ana", "2026-10")] = &Usage{Subject: UsageUser, SubjectID: "u-ana", Period: "2026-10", TestRuns: 3}
+	em.WebhookDeliveries = []WebhookDelivery{
+		{ID: "d-1", Event: "user.created", Payload: `{"data":{"username":"ana"}}`},
+		{ID: "d-2", Event: "run.finished", Payload: `{"data":{"run_id":"run-1"}}`},
+	}
+	return NewPrivacyManagement(em)
+}
+
+func TestPrivacyManagement_EraseUser(t *testing.T) {
+	pm := newPrivacyManagement(t)
+	em := pm.Manager
+	em.auditKey, _ = hex.DecodeString(testAuditKey)
+	em.Config.AuditIntegrity.CheckpointInterval = 2
+	em.logAuditEntry(AuditEntry{UserID: "u-ana", Username: "ana", IPAddress: "10.0.0.1", Action: "user.login", Resource: "user", Success: true})
+	em.logAuditEntry(AuditEntry{UserID: "u-root", Username: "root", Action: "user.update", Resource: "user", ResourceID: "u-ana",
+		Details: map[string]string{"email": "ana@example.com"}, Success: true})
+	em.logAuditEntry(AuditEntry{UserID: "u-root", Username: "root", Action: "project.create", Resource: "project", Success: true})
+	require.Len(t, em.AuditCheckpoints, 1)
+	require.NoError(t, em.withQueue(func(queue *[]*QueuedRun) error {
+		*queue = append(*queue, &QueuedRun{ID: "q-1", ProjectID: "p-checkout", SubmittedBy: "u-ana", Status: QueuedRunQueued})
+		return nil
+	}))
+	pseudonym := pseudonymOf("u-ana")
+
+	response, err := pm.EraseUser(context.Background(), EraseUserRequest{User: "ana", ErasedBy: "root"})
+	require.NoError(t, err)
+	assert.Equal(t, pseudonym, response.Pseudonym)
+	assert.Equal(t, map[string]int{"users": 1, "sessions": 1, "api_keys": 1, "account_tokens": 1, "webhook_deliveries": 1}, response.Removed)
+	assert.Equal(t, map[string]int{"audit_entries": 2, "account_tokens": 1, "teams": 1, "projects": 1, "runs": 1,
+		"approvals": 1, "subscriptions": 1, "usage": 1, "queued_runs": 1}, response.Anonymized)
+
+	assert.NotContains(t, em.Users, "ana")
+	assert.Len(t, em.Sessions, 1)
+	assert.Equal(t, pseudonym, em.AccountTokens["t-2"].CreatedBy)
+	assert.Equal(t, []string{"u-root"}, em.Teams["t-qa"].MemberIDs)
+	assert.Empty(t, em.Teams["t-qa"].LeadID)
+	assert.Equal(t, pseudonym, em.Projects["p-checkout"].OwnerID)
+	assert.Equal(t, pseudonym, em.Runs[0].UserID)
+	assert.Equal(t, pseudonym, em.Approvals["a-1"].RequestedBy)
+	assert.Equal(t, "root", em.Approvals["a-1"].DecidedBy)
+	assert.Equal(t, pseudonym, em.Subscriptions["s-pro"].UserID)
+	assert.Equal(t, 3, em.Usage[usageKey(UsageUser, pseudonym, "2026-10")].TestRuns)
+	require.Len(t, em.WebhookDeliveries, 1)
+	assert.Equal(t, "d-2", em.WebhookDeliveries[0].ID)
+
+	login := em.AuditLog[0]
+	assert.Equal(t, pseudonym, login.Username)
+	assert.Empty(t, login.IPAddress)
+	assert.Equal(t, pseudonym, em.AuditLog[1].ResourceID)
+	assert.Equal(t, pseudonym, em.AuditLog[1].Details["email"])
+	erase := em.AuditLog[len(em.AuditLog)-1]
+	assert.Equal(t, "user.erase", erase.Action)
+	assert.Equal(t, "root", erase.Username)
+	assert.Equal(t, pseudonym, erase.ResourceID)
+
+	t.Setenv(DefaultAuditKeyEnv, testAuditKey)
+	result, err := NewAuditManagement(em).VerifyAuditLog(context.Background())
+	require.NoError(t, err)
+	assert.True(t, result.Verified, result.Problems)
+
+	for _, file := range []string{"users.json", "runs.json", "audit.json", "webhook_deliveries.json", "run_queue.json"} {
+		data, err := os.ReadFile(filepath.Join(em.StoragePath, file))
+		require.NoError(t, err)
+		assert.NotContains(t, string(data), "ana@example.com", file)
+		assert.NotContains(t, string(data), `"u-ana"`, file)
+	}
+}
+
+func TestPrivacyManagement_EraseUserErrors(t *testing.T) {
+	pm := newPrivacyManagement(t)
+	ctx := context.Background()
+
+	_, err := pm.EraseUser(ctx, EraseUserRequest{User: "ana"})
+	assert.EqualError(t, err, "the acting user is required")
+	_, err = pm.EraseUser(ctx, EraseUserRequest{User: "root", ErasedBy: "ana"})
+	assert.EqualError(t, err, "user ana may not erase users")
+	_, err = pm.EraseUser(ctx, EraseUserRequest{User: "root", ErasedBy: "root"})
+	assert.EqualError(t, err, "user root can't erase themselves")
+
+	pm.Manager.Users["ana"].Permissions = map[string]bool{"user.delete": true}
+	_, err = pm.EraseUser(ctx, EraseUserRequest{User: "root", ErasedBy: "ana"})
+	assert.EqualError(t, err, "user root is the last active admin")
+
+	// Checkpoints of changed entries can't be signed again without the key
+	pm.Manager.auditKey, _ = hex.DecodeString(testAuditKey)
+	pm.Manager.Config.AuditIntegrity.CheckpointInterval = 1
+	pm.Manager.logAuditEntry(AuditEntry{UserID: "u-ana", Username: "ana", Action: "user.login", Resource: "user", Success: true})
+	pm.Manager.auditKey = nil
+	_, err = pm.EraseUser(ctx, EraseUserRequest{User: "ana", ErasedBy: "root"})
+	assert.ErrorContains(t, err, "must hold the audit key to re-sign the checkpoints of changed entries")
+	assert.Contains(t, pm.Manager.Users, "ana", "nothing is erased")
+}
+
+func TestPrivacyManagement_EraseUserAction(t *testing.T) {
+	pm := newPrivacyManagement(t)
+	ei := &EnterpriseIntegration{Manager: pm.Manager, PrivacyManagement: pm, Initialized: true}
+
+	result, err := ei.ExecuteEnterpriseAction(context.Background(), "gdpr_erase", map[string]interface{}{"user": "u-ana", "erased_by": "root"})
+	require.NoError(t, err)
+	assert.Equal(t, "u-ana", result.(*EraseUserResponse).UserID)
+	assert.NotContains(t, pm.Manager.Users, "ana")
+}
@@ -197,6 +197,13 @@ func (pm *ProjectManagement) DeleteProject(ctx context.Context, projectID string
 		pm.Logger.Errorf("Failed to save project data: %v", err)
 	}
 
+	pm.Manager.emitEvent(ctx, EventProjectArchived, map[string]interface{}{
+		"project_id":  project.ID,
+		"name":        project.Name,
+		"owner_id":    project.OwnerID,
+		"archived_at": now,
+	})
+
 	pm.Logger.Infof("Project archived successfully: %s", project.Name)
 	return nil
 }
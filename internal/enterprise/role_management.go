@@ -0,0 +1,530 @@
+package enterprise
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"panoptic/internal/logger"
+)
+
+// Permission is a permission of the catalog roles are made of
+type Permission struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+}
+
+// Permissions is the catalog of the permissions roles can grant
+var Permissions = []Permission{
+	{"user.create", "Create and invite users"},
+	{"user.read", "View users"},
+	{"user.update", "Edit users"},
+	{"user.delete", "Delete users"},
+	{"role.create", "Create custom roles"},
+	{"role.read", "View roles"},
+	{"role.update", "Edit custom roles"},
+	{"role.delete", "Delete custom roles"},
+	{"team.create", "Create teams"},
+	{"team.read", "View teams"},
+	{"team.update", "Edit teams, their members and roles"},
+	{"team.delete", "Delete teams"},
+	{"project.create", "Create projects"},
+	{"project.read", "View projects"},
+	{"project.update", "Edit projects"},
+	{"project.delete", "Delete projects"},
+	{"test.create", "Create tests"},
+	{"test.read", "View tests"},
+	{"test.update", "Edit tests"},
+	{"test.delete", "Delete tests"},
+	{"test.run", "Run tests"},
+	{"report.read", "View reports"},
+	{"report.create", "Create reports"},
+	{"analytics.read", "View analytics"},
+	{"settings.read", "View settings"},
+	{"settings.update", "Edit settings"},
+	{"run.approve", "Approve runs against protected URLs"},
+	{"system.admin", "Administer everything"},
+}
+
+// roleID is the form of custom role IDs
+var roleID = regexp.MustCompile(`^[a-z][a-z0-9_-]*$`)
+
+// RoleManagement creates the custom roles beside the system ones, resolving
+// the roles they inherit, and grants roles to the members of teams
+type RoleManagement struct {
+	Manager *EnterpriseManager
+	Logger  logger.Logger
+}
+
+// NewRoleManagement creates new role management handler
+func NewRoleManagement(manager *EnterpriseManager) *RoleManagement {
+	return &RoleManagement{
+		Manager: manager,
+		Logger:  manager.Logger,
+	}
+}
+
+// CreateRole creates a custom role of catalog permissions and inherited
+// roles. Its creator needs role.create and can't grant more than they hold.
+func (rm *RoleManagement) CreateRole(ctx context.Context, req CreateRoleRequest) (*Role, error) {
+	actor, err := rm.authorize(req.CreatedBy, "role.create")
+	if err != nil {
+		return nil, err
+	}
+	if req.Name == "" {
+		return nil, fmt.Errorf("name is required")
+	}
+	id := req.ID
+	if id == "" {
+		id = strings.ToLower(strings.Join(strings.Fields(req.Name), "-"))
+	}
+	if !roleID.MatchString(id) {
+		return nil, fmt.Errorf("invalid role ID %q; use lowercase letters, digits, - and _", id)
+	}
+	if _, exists := rm.Manager.Roles[id]; exists {
+		return nil, fmt.Errorf("role %s already exists", id)
+	}
+
+	now := time.Now()
+	role := &Role{ID: id, Name: req.Name, Description: req.Description, CreatedAt: now, UpdatedAt: now}
+	if err := rm.setGrants(role, req.Permissions, req.Inherits, actor); err != nil {
+		return nil, err
+	}
+	rm.Manager.Roles[id] = role
+
+	rm.logRole("role.create", role, actor)
+	if err := rm.Manager.saveData(); err != nil {
+		return nil, fmt.Errorf("failed to save role data: %w", err)
+	}
+	return role, nil
+}
+
+// UpdateRole changes a custom role and the permissions of the users it's
+// granted to. System roles can't be changed.
+func (rm *RoleManagement) UpdateRole(ctx context.Context, req UpdateRoleRequest) (*Role, error) {
+	actor, err := rm.authorize(req.UpdatedBy, "role.update")
+	if err != nil {
+		return nil, err
+	}
+	role, err := rm.customRole(req.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	permissions, inherits := req.Permissions, req.Inherits
+	if permissions == nil {
+		permissions = grantedPermissions(role.Permissions)
+	}
+	if inherits == nil {
+		inherits = role.Inherits
+	}
+	updated := *role
+	if err := rm.setGrants(&updated, permissions, inherits, actor); err != nil {
+		return nil, err
+	}
+	if req.Name != "" {
+		updated.Name = req.Name
+	}
+	if req.Description != nil {
+		updated.Description = *req.Description
+	}
+	updated.UpdatedAt = time.Now()
+	before := rm.Manager.permissionSnapshot()
+	*role = updated
+	rm.refreshUsers()
+	rm.Manager.revokeDowngraded(before)
+
+	rm.logRole("role.update", role, actor)
+	if err := rm.Manager.saveData(); err != nil {
+		return nil, fmt.Errorf("failed to save role data: %w", err)
+	}
+	return role, nil
+}
+
+// DeleteRole deletes a custom role that no user has and no role inherits,
+// taking it from the teams granted it
+func (rm *RoleManagement) DeleteRole(ctx context.Context, id, deletedBy string) error {
+	actor, err := rm.authorize(deletedBy, "role.delete")
+	if err != nil {
+		return err
+	}
+	role, err := rm.customRole(id)
+	if err != nil {
+		return err
+	}
+	if users := rm.usersWithRole(role.ID); users > 0 {
+		return fmt.Errorf("role %s is the role of %d user(s); give them another first", role.ID, users)
+	}
+	for _, other := range rm.Manager.Roles {
+		if contains(other.Inherits, role.ID) {
+			return fmt.Errorf("role %s is inherited by role %s", role.ID, other.ID)
+		}
+	}
+
+	delete(rm.Manager.Roles, role.ID)
+	for _, team := range rm.Manager.Teams {
+		team.RoleIDs = remove(team.RoleIDs, role.ID)
+	}
+
+	rm.logRole("role.delete", role, actor)
+	if err := rm.Manager.saveData(); err != nil {
+		return fmt.Errorf("failed to save role data: %w", err)
+	}
+	return nil
+}
+
+// ListRoles lists the roles by ID with the permissions they resolve to
+func (rm *RoleManagement) ListRoles(ctx context.Context) []RoleSummary {
+	summaries := []RoleSummary{}
+	for _, role := range rm.Manager.Roles {
+		summary := RoleSummary{
+			ID:          role.ID,
+			Name:        role.Name,
+			Description: role.Description,
+			System:      role.System,
+			Inherits:    role.Inherits,
+			Permissions: grantedPermissions(rm.Manager.resolveRole(role.ID)),
+			Users:       rm.usersWithRole(role.ID),
+			Teams:       []string{},
+		}
+		for _, team := range rm.Manager.Teams {
+			if contains(team.RoleIDs, role.ID) {
+				summary.Teams = append(summary.Teams, team.Name)
+			}
+		}
+		sort.Strings(summary.Teams)
+		summaries = append(summaries, summary)
+	}
+	sort.Slice(summaries, func(i, j int) bool { return summaries[i].ID < summaries[j].ID })
+	return summaries
+}
+
+// AssignTeams grants roles to, or with Remove takes them from, the members
+// of teams, by team ID or name. The assigner needs team.update and the
+// permissions of the roles.
+func (rm *RoleManagement) AssignTeams(ctx context.Context, req AssignTeamRolesRequest) ([]*Team, error) {
+	actor, err := rm.authorize(req.AssignedBy, "team.update")
+	if err != nil {
+		return nil, err
+	}
+	if len(req.Teams) == 0 || len(req.Roles) == 0 {
+		return nil, fmt.Errorf("teams and roles are required")
+	}
+	for _, id := range req.Roles {
+		if _, exists := rm.Manager.Roles[id]; !exists {
+			return nil, fmt.Errorf("role not found: %s", id)
+		}
+		if !req.Remove {
+			if err := rm.checkGrant(actor, rm.Manager.resolveRole(id)); err != nil {
+				return nil, err
+			}
+		}
+	}
+	teams := make([]*Team, 0, len(req.Teams))
+	for _, identifier := range req.Teams {
+		team, err := rm.findTeam(identifier)
+		if err != nil {
+			return nil, err
+		}
+		teams = append(teams, team)
+	}
+
+	action := "role.assign"
+	if req.Remove {
+		action = "role.unassign"
+	}
+	before := rm.Manager.permissionSnapshot()
+	for _, team := range teams {
+		for _, id := range req.Roles {
+			if req.Remove {
+				team.RoleIDs = remove(team.RoleIDs, id)
+			} else if !contains(team.RoleIDs, id) {
+				team.RoleIDs = append(team.RoleIDs, id)
+			}
+		}
+		team.UpdatedAt = time.Now()
+		rm.Manager.logAuditEntry(AuditEntry{
+			Timestamp:  time.Now(),
+			UserID:     actor.ID,
+			Username:   actor.Username,
+			Action:     action,
+			Resource:   "team",
+			ResourceID: team.ID,
+			Details:    map[string]string{"team": team.Name, "roles": strings.Join(req.Roles, ",")},
+			Success:    true,
+			Severity:   "high",
+			Category:   "access",
+		})
+	}
+	rm.Manager.revokeDowngraded(before)
+	if err := rm.Manager.saveData(); err != nil {
+		return nil, fmt.Errorf("failed to save team data: %w", err)
+	}
+	return teams, nil
+}
+
+// Request types
+
+type CreateRoleRequest struct {
+	ID          string   `json:"id,omitempty"` // from the name by default
+	Name        string   `json:"name"`
+	Description string   `json:"description,omitempty"`
+	Permissions []string `json:"permissions"`
+	Inherits    []string `json:"inherits,omitempty"` // role IDs
+	CreatedBy   string   `json:"created_by"`         // username or ID
+}
+
+type UpdateRoleRequest struct {
+	ID          string   `json:"id"`
+	Name        string   `json:"name,omitempty"`
+	Description *string  `json:"description,omitempty"`
+	Permissions []string `json:"permissions,omitempty"` // unchanged when nil
+	Inherits    []string `json:"inherits,omitempty"`    // unchanged when nil
+	UpdatedBy   string   `json:"updated_by"`
+}
+
+type AssignTeamRolesRequest struct {
+	Teams      []string `json:"teams"` // IDs or names
+	Roles      []string `json:"roles"`
+	Remove     bool     `json:"remove,omitempty"`
+	AssignedBy string   `json:"assigned_by"`
+}
+
+type RoleSummary struct {
+	ID          string   `json:"id"`
+	Name        string   `json:"name"`
+	Description string   `json:"description"`
+	System      bool     `json:"system"`
+	Inherits    []string `json:"inherits,omitempty"`
+	Permissions []string `json:"permissions"` // with those inherited
+	Users       int      `json:"users"`
+	Teams       []string `json:"teams"`
+}
+
+// Helper methods
+
+// authorize finds the active user acting, who needs the permission
+func (rm *RoleManagement) authorize(identifier, permission string) (*User, error) {
+	if identifier == "" {
+		return nil, fmt.Errorf("the acting user is required")
+	}
+	user, err := NewUserManagement(rm.Manager).GetUser(context.Background(), identifier)
+	if err != nil {
+		return nil, err
+	}
+	if !user.Active || !rm.Manager.hasPermission(user, permission) {
+		return nil, fmt.Errorf("user %s may not %s", user.Username, Permission{Name: permission}.verb())
+	}
+	return user, nil
+}
+
+// setGrants sets the permissions and inherited roles of a role, checking
+// they're known, inherit no cycle and don't exceed those of the actor
+func (rm *RoleManagement) setGrants(role *Role, permissions, inherits []string, actor *User) error {
+	granted := make(map[string]bool, len(permissions))
+	for _, name := range permissions {
+		if !isPermission(name) {
+			return fmt.Errorf("unknown permission %q; see enterprise permission-list", name)
+		}
+		granted[name] = true
+	}
+	for _, id := range inherits {
+		if _, exists := rm.Manager.Roles[id]; !exists {
+			return fmt.Errorf("inherited role not found: %s", id)
+		}
+	}
+	if path := rm.inheritanceCycle(role.ID, inherits, []string{role.ID}); path != nil {
+		return fmt.Errorf("role inheritance cycle: %s", strings.Join(path, " -> "))
+	}
+
+	resolved := make(map[string]bool, len(granted))
+	for name := range granted {
+		resolved[name] = true
+	}
+	for _, id := range inherits {
+		for name := range rm.Manager.resolveRole(id) {
+			resolved[name] = true
+		}
+	}
+	if err := rm.checkGrant(actor, resolved); err != nil {
+		return err
+	}
+	role.Permissions = granted
+	role.Inherits = append([]string(nil), inherits...)
+	return nil
+}
+
+// inheritanceCycle is the path back to the role if inheriting leads to it
+func (rm *RoleManagement) inheritanceCycle(id string, inherits, path []string) []string {
+	for _, parent := range inherits {
+		next := append(append([]string(nil), path...), parent)
+		if parent == id {
+			return next
+		}
+		if role, exists := rm.Manager.Roles[parent]; exists && !contains(path, parent) {
+			if cycle := rm.inheritanceCycle(id, role.Inherits, next); cycle != nil {
+				return cycle
+			}
+		}
+	}
+	return nil
+}
+
+// checkGrant keeps users who aren't admins from granting permissions they
+// don't hold themselves
+func (rm *RoleManagement) checkGrant(actor *User, permissions map[string]bool) error {
+	if rm.Manager.hasPermission(actor, "system.admin") {
+		return nil
+	}
+	for _, name := range grantedPermissions(permissions) {
+		if !rm.Manager.hasPermission(actor, name) {
+			return fmt.Errorf("user %s can't grant %s, which they don't hold", actor.Username, name)
+		}
+	}
+	return nil
+}
+
+// customRole finds a role that isn't a system role
+func (rm *RoleManagement) customRole(id string) (*Role, error) {
+	role, exists := rm.Manager.Roles[id]
+	if !exists {
+		return nil, fmt.Errorf("role not found: %s", id)
+	}
+	if role.System {
+		return nil, fmt.Errorf("role %s is a system role and can't be changed", id)
+	}
+	return role, nil
+}
+
+func (rm *RoleManagement) findTeam(identifier string) (*Team, error) {
+	if team, exists := rm.Manager.Teams[identifier]; exists {
+		return team, nil
+	}
+	var found *Team
+	for _, team := range rm.Manager.Teams {
+		if team.Name == identifier {
+			if found != nil {
+				return nil, fmt.Errorf("several teams are named %s; use the team ID", identifier)
+			}
+			found = team
+		}
+	}
+	if found == nil {
+		return nil, fmt.Errorf("team not found: %s", identifier)
+	}
+	return found, nil
+}
+
+func (rm *RoleManagement) usersWithRole(id string) int {
+	count := 0
+	for _, user := range rm.Manager.Users {
+		if user.Role == id {
+			count++
+		}
+	}
+	return count
+}
+
+// refreshUsers resolves again the permissions of the users of known roles
+func (rm *RoleManagement) refreshUsers() {
+	for _, user := range rm.Manager.Users {
+		if _, exists := rm.Manager.Roles[user.Role]; exists {
+			user.Permissions = rm.Manager.resolveRole(user.Role)
+		}
+	}
+}
+
+func (rm *RoleManagement) logRole(action string, role *Role, actor *User) {
+	rm.Manager.logAuditEntry(AuditEntry{
+		Timestamp:  time.Now(),
+		UserID:     actor.ID,
+		Username:   actor.Username,
+		Action:     action,
+		Resource:   "role",
+		ResourceID: role.ID,
+		Details: map[string]string{
+			"permissions": strings.Join(grantedPermissions(role.Permissions), ","),
+			"inherits":    strings.Join(role.Inherits, ","),
+		},
+		Success:  true,
+		Severity: "high",
+		Category: "access",
+	})
+}
+
+// resolveRole is the permissions of a role and of the roles it inherits
+func (em *EnterpriseManager) resolveRole(id string) map[string]bool {
+	permissions := map[string]bool{}
+	seen := map[string]bool{}
+	var resolve func(id string)
+	resolve = func(id string) {
+		role, exists := em.Roles[id]
+		if !exists || seen[id] {
+			return
+		}
+		seen[id] = true
+		for name, granted := range role.Permissions {
+			if granted {
+				permissions[name] = true
+			}
+		}
+		for _, parent := range role.Inherits {
+			resolve(parent)
+		}
+	}
+	resolve(id)
+	return permissions
+}
+
+// hasPermission tells whether a user holds a permission through their role,
+// or a role of one of their active teams
+func (em *EnterpriseManager) hasPermission(user *User, permission string) bool {
+	if user.Permissions[permission] {
+		return true
+	}
+	for _, team := range em.Teams {
+		if len(team.RoleIDs) == 0 || !team.Active || !contains(team.MemberIDs, user.ID) {
+			continue
+		}
+		for _, id := range team.RoleIDs {
+			if em.resolveRole(id)[permission] {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// verb is what a permission lets users do, for errors
+func (p Permission) verb() string {
+	for _, known := range Permissions {
+		if known.Name == p.Name {
+			return strings.ToLower(known.Description[:1]) + known.Description[1:]
+		}
+	}
+	return "use " + p.Name
+}
+
+func isPermission(name string) bool {
+	for _, known := range Permissions {
+		if known.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// grantedPermissions are the names of the granted permissions, sorted
+func grantedPermissions(permissions map[string]bool) []string {
+	names := []string{}
+	for name, granted := range permissions {
+		if granted {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,164 @@
+package enterprise
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"panoptic/internal/logger"
+)
+
+// newRoleManagement is a role manager with an admin, a manager, a viewer in
+// the QA team and a developer
+func newRoleManagement(t *testing.T) *RoleManagement {
+	t.Helper()
+	em := NewEnterpriseManager(*logger.NewLogger(false))
+	em.StoragePath = t.TempDir()
+	em.Enabled = true
+	require.NoError(t, em.initializeDefaultRoles())
+
+	for username, role := range map[string]string{"root": "admin", "mia": "manager", "vic": "viewer", "ana": "developer"} {
+		em.Users[username] = &User{ID: "u-" + username, Username: username, Role: role, Active: true, Permissions: em.resolveRole(role)}
+	}
+	em.Teams["t-qa"] = &Team{ID: "t-qa", Name: "QA", MemberIDs: []string{"u-vic"}, Active: true}
+	em.Teams["t-ops"] = &Team{ID: "t-ops", Name: "Ops", Active: true}
+	return NewRoleManagement(em)
+}
+
+func TestRoleManagement_CreateRole(t *testing.T) {
+	rm := newRoleManagement(t)
+	ctx := context.Background()
+
+	role, err := rm.CreateRole(ctx, CreateRoleRequest{Name: "QA Runner", Permissions: []string{"test.run"}, Inherits: []string{"viewer"}, CreatedBy: "root"})
+	require.NoError(t, err)
+	assert.Equal(t, "qa-runner", role.ID)
+	assert.False(t, role.System)
+	assert.Equal(t, map[string]bool{"test.run": true}, role.Permissions)
+	assert.Equal(t, []string{"analytics.read", "project.read", "report.read", "team.read", "test.read", "test.run", "user.read"},
+		grantedPermissions(rm.Manager.resolveRole("qa-runner")), "inherited permissions resolve")
+
+	_, err = rm.CreateRole(ctx, CreateRoleRequest{Name: "QA Runner", CreatedBy: "root"})
+	assert.EqualError(t, err, "role qa-runner already exists")
+	_, err = rm.CreateRole(ctx, CreateRoleRequest{Name: "Deployer", Permissions: []string{"test.deploy"}, CreatedBy: "root"})
+	assert.EqualError(t, err, `unknown permission "test.deploy"; see enterprise permission-list`)
+	_, err = rm.CreateRole(ctx, CreateRoleRequest{Name: "Auditor", Inherits: []string{"auditing"}, CreatedBy: "root"})
+	assert.EqualError(t, err, "inherited role not found: auditing")
+	_, err = rm.CreateRole(ctx, CreateRoleRequest{ID: "QA!", Name: "QA", CreatedBy: "root"})
+	assert.EqualError(t, err, `invalid role ID "QA!"; use lowercase letters, digits, - and _`)
+	_, err = rm.CreateRole(ctx, CreateRoleRequest{Name: "Runner", Permissions: []string{"test.run"}, CreatedBy: "ana"})
+	assert.EqualError(t, err, "user ana may not create custom roles")
+	_, err = rm.CreateRole(ctx, CreateRoleRequest{Name: "Runner", CreatedBy: ""})
+	assert.EqualError(t, err, "the acting user is required")
+
+	// Managers with role.create can't grant what they don't hold
+	rm.Manager.Users["mia"].Permissions["role.create"] = true
+	_, err = rm.CreateRole(ctx, CreateRoleRequest{Name: "Superuser", Inherits: []string{"admin"}, CreatedBy: "mia"})
+	assert.EqualError(t, err, "user mia can't grant role.delete, which they don't hold")
+	_, err = rm.CreateRole(ctx, CreateRoleRequest{Name: "Lead", Permissions: []string{"team.update", "project.read"}, CreatedBy: "mia"})
+	assert.NoError(t, err)
+}
+
+func TestRoleManagement_UpdateRole(t *testing.T) {
+	rm := newRoleManagement(t)
+	ctx := context.Background()
+	_, err := rm.CreateRole(ctx, CreateRoleRequest{ID: "runner", Name: "Runner", Permissions: []string{"test.run"}, CreatedBy: "root"})
+	require.NoError(t, err)
+	_, err = rm.CreateRole(ctx, CreateRoleRequest{ID: "lead", Name: "Lead", Inherits: []string{"runner"}, CreatedBy: "root"})
+	require.NoError(t, err)
+	rm.Manager.Users["lee"] = &User{ID: "u-lee", Username: "lee", Role: "lead", Active: true, Permissions: rm.Manager.resolveRole("lead")}
+
+	_, err = rm.UpdateRole(ctx, UpdateRoleRequest{ID: "runner", Inherits: []string{"lead"}, UpdatedBy: "root"})
+	assert.EqualError(t, err, "role inheritance cycle: runner -> lead -> runner")
+
+	role, err := rm.UpdateRole(ctx, UpdateRoleRequest{ID: "runner", Permissions: []string{"test.run", "report.read"}, UpdatedBy: "root"})
+	require.NoError(t, err)
+	assert.Equal(t, "Runner", role.Name)
+	assert.True(t, rm.Manager.Users["lee"].Permissions["report.read"], "users of roles inheriting it are updated")
+
+	_, err = rm.UpdateRole(ctx, UpdateRoleRequest{ID: "admin", Permissions: []string{}, UpdatedBy: "root"})
+	assert.EqualError(t, err, "role admin is a system role and can't be changed")
+
+	assert.EqualError(t, rm.DeleteRole(ctx, "runner", "root"), "role runner is inherited by role lead")
+	assert.EqualError(t, rm.DeleteRole(ctx, "lead", "root"), "role lead is the role of 1 user(s); give them another first")
+	rm.Manager.Users["lee"].Role = "developer"
+	_, err = rm.AssignTeams(ctx, AssignTeamRolesRequest{Teams: []string{"QA"}, Roles: []string{"lead"}, AssignedBy: "root"})
+	require.NoError(t, err)
+	require.NoError(t, rm.DeleteRole(ctx, "lead", "root"))
+	assert.Empty(t, rm.Manager.Teams["t-qa"].RoleIDs, "deleted roles are taken from teams")
+}
+
+func TestRoleManagement_AssignTeams(t *testing.T) {
+	rm := newRoleManagement(t)
+	ctx := context.Background()
+	vic := rm.Manager.Users["vic"]
+	assert.False(t, rm.Manager.hasPermission(vic, "test.run"))
+
+	teams, err := rm.AssignTeams(ctx, AssignTeamRolesRequest{Teams: []string{"QA", "t-ops"}, Roles: []string{"developer"}, AssignedBy: "mia"})
+	require.NoError(t, err)
+	assert.Len(t, teams, 2)
+	assert.True(t, rm.Manager.hasPermission(vic, "test.run"), "members hold the roles of their teams")
+	project := &Project{ID: "p-shop", Name: "shop", TeamIDs: []string{"t-qa"}, Status: "active"}
+	rm.Manager.Projects[project.ID] = project
+	_, err = NewRunManagement(rm.Manager).StartRun(ctx, StartRunRequest{Project: "shop", User: "vic"})
+	assert.NoError(t, err, "so vic may now run the team's project")
+
+	_, err = rm.AssignTeams(ctx, AssignTeamRolesRequest{Teams: []string{"QA"}, Roles: []string{"admin"}, AssignedBy: "mia"})
+	assert.EqualError(t, err, "user mia can't grant role.create, which they don't hold")
+	_, err = rm.AssignTeams(ctx, AssignTeamRolesRequest{Teams: []string{"QA"}, Roles: []string{"tester"}, AssignedBy: "mia"})
+	assert.EqualError(t, err, "role not found: tester")
+	_, err = rm.AssignTeams(ctx, AssignTeamRolesRequest{Teams: []string{"Dev"}, Roles: []string{"viewer"}, AssignedBy: "mia"})
+	assert.EqualError(t, err, "team not found: Dev")
+	_, err = rm.AssignTeams(ctx, AssignTeamRolesRequest{Teams: []string{"QA"}, Roles: []string{"viewer"}, AssignedBy: "vic"})
+	assert.EqualError(t, err, "user vic may not edit teams, their members and roles")
+
+	_, err = rm.AssignTeams(ctx, AssignTeamRolesRequest{Teams: []string{"QA"}, Roles: []string{"developer"}, Remove: true, AssignedBy: "mia"})
+	require.NoError(t, err)
+	assert.False(t, rm.Manager.hasPermission(vic, "test.run"))
+	assert.Equal(t, []string{"developer"}, rm.Manager.Teams["t-ops"].RoleIDs)
+}
+
+func TestRoleManagement_Actions(t *testing.T) {
+	rm := newRoleManagement(t)
+	ctx := context.Background()
+	ei := &EnterpriseIntegration{Manager: rm.Manager, RoleManagement: rm, Initialized: true}
+
+	_, err := ei.ExecuteEnterpriseAction(ctx, "role_create", map[string]interface{}{
+		"name": "Runner", "permissions": "test.read test.run", "created_by": "root",
+	})
+	require.NoError(t, err)
+	_, err = ei.ExecuteEnterpriseAction(ctx, "role_update", map[string]interface{}{"id": "runner", "description": "Runs tests", "updated_by": "root"})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]bool{"test.read": true, "test.run": true}, rm.Manager.Roles["runner"].Permissions, "permissions not given are kept")
+	_, err = ei.ExecuteEnterpriseAction(ctx, "role_assign_teams", map[string]interface{}{"teams": "QA,Ops", "roles": "runner", "assigned_by": "root"})
+	require.NoError(t, err)
+
+	result, err := ei.ExecuteEnterpriseAction(ctx, "role_list", nil)
+	require.NoError(t, err)
+	var runner RoleSummary
+	for _, summary := range result.([]RoleSummary) {
+		if summary.ID == "runner" {
+			runner = summary
+		}
+	}
+	assert.Equal(t, RoleSummary{ID: "runner", Name: "Runner", Description: "Runs tests", Permissions: []string{"test.read", "test.run"},
+		Teams: []string{"Ops", "QA"}}, runner)
+
+	result, err = ei.ExecuteEnterpriseAction(ctx, "permission_list", nil)
+	require.NoError(t, err)
+	assert.Contains(t, result, Permission{Name: "run.approve", Description: "Approve runs against protected URLs"})
+
+	_, err = ei.ExecuteEnterpriseAction(ctx, "role_delete", map[string]interface{}{"id": "runner", "deleted_by": "root"})
+	require.NoError(t, err)
+	assert.NotContains(t, rm.Manager.Roles, "runner")
+}
+
+func TestPermissions_CoverDefaultRoles(t *testing.T) {
+	em := NewEnterpriseManager(*logger.NewLogger(false))
+	require.NoError(t, em.initializeDefaultRoles())
+	for _, role := range em.Roles {
+		for name := range role.Permissions {
+			assert.True(t, isPermission(name), "%s of role %s is in the catalog", name, role.ID)
+		}
+	}
+}
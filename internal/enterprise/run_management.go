@@ -0,0 +1,277 @@
+package enterprise
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"panoptic/internal/logger"
+)
+
+// DefaultRunPageSize is the page size of ListRuns without one
+const DefaultRunPageSize = 50
+
+// RunRecord is a test run attributed to the project it ran for
+type RunRecord struct {
+	ID         string    `json:"id"` // the run ID
+	ProjectID  string    `json:"project_id"`
+	UserID     string    `json:"user_id,omitempty"`
+	Config     string    `json:"config"`
+	StartedAt  time.Time `json:"started_at"`
+	FinishedAt time.Time `json:"finished_at"`
+	Total      int       `json:"total"`
+	Passed     int       `json:"passed"`
+	Failed     int       `json:"failed"`
+	Success    bool      `json:"success"`
+}
+
+// RunManagement attributes test runs to projects, holding them to the
+// retention and max_test_runs of their project settings
+type RunManagement struct {
+	Manager *EnterpriseManager
+	Logger  logger.Logger
+}
+
+// NewRunManagement creates new run management handler
+func NewRunManagement(manager *EnterpriseManager) *RunManagement {
+	return &RunManagement{
+		Manager: manager,
+		Logger:  manager.Logger,
+	}
+}
+
+// StartRun checks a run may start for a project, given by ID or name, and
+// returns it: the project must be active, within its max_test_runs once the
+// runs past its test_retention are dropped, and open to the user starting
+// the run, when there is one
+func (rm *RunManagement) StartRun(ctx context.Context, req StartRunRequest) (*Project, error) {
+	project, err := rm.FindProject(req.Project)
+	if err != nil {
+		return nil, err
+	}
+	if project.Status != "active" {
+		return nil, fmt.Errorf("project %s is %s", project.Name, project.Status)
+	}
+	if req.User != "" {
+		user, err := rm.findUser(req.User)
+		if err != nil {
+			return nil, err
+		}
+		if !user.Active {
+			return nil, fmt.Errorf("user %s is inactive", user.Username)
+		}
+		if !rm.Manager.hasPermission(user, "test.run") {
+			return nil, fmt.Errorf("user %s may not run tests", user.Username)
+		}
+		if !rm.canAccess(user, project) {
+			return nil, fmt.Errorf("user %s has no access to project %s", user.Username, project.Name)
+		}
+	}
+
+	if expired := rm.applyRetention(project, time.Now()); expired > 0 {
+		rm.Logger.Infof("Dropped %d run(s) of project %s past its %d-day retention", expired, project.Name, project.Settings.TestRetention)
+		if err := rm.Manager.saveData(); err != nil {
+			rm.Logger.Errorf("Failed to save run data: %v", err)
+		}
+	}
+	if limit := project.Settings.MaxTestRuns; limit > 0 {
+		if runs := rm.countRuns(project.ID); runs >= limit {
+			return nil, fmt.Errorf("project %s has used its quota of %d test run(s); raise its max_test_runs or let test_retention expire older runs", project.Name, limit)
+		}
+	}
+	return project, nil
+}
+
+// RecordRun adds a finished run to the runs of its project
+func (rm *RunManagement) RecordRun(ctx context.Context, record RunRecord) error {
+	project, exists := rm.Manager.Projects[record.ProjectID]
+	if !exists {
+		return fmt.Errorf("project not found: %s", record.ProjectID)
+	}
+	rm.Manager.Runs = append(rm.Manager.Runs, record)
+
+	rm.Manager.logAuditEntry(AuditEntry{
+		Timestamp:  time.Now(),
+		UserID:     record.UserID,
+		Username:   rm.Manager.getUsername(record.UserID),
+		Action:     "run.complete",
+		Resource:   "run",
+		ResourceID: record.ID,
+		Details: map[string]string{
+			"project": project.Name,
+			"config":  record.Config,
+			"passed":  fmt.Sprintf("%d/%d", record.Passed, record.Total),
+		},
+		Success:  record.Success,
+		Severity: "low",
+		Category: "data",
+	})
+
+	if err := rm.Manager.saveData(); err != nil {
+		return fmt.Errorf("failed to save run data: %w", err)
+	}
+	rm.Manager.emitEvent(ctx, EventRunCompleted, map[string]interface{}{
+		"run_id":      record.ID,
+		"project_id":  project.ID,
+		"project":     project.Name,
+		"user_id":     record.UserID,
+		"config":      record.Config,
+		"total":       record.Total,
+		"passed":      record.Passed,
+		"failed":      record.Failed,
+		"success":     record.Success,
+		"finished_at": record.FinishedAt,
+	})
+	return nil
+}
+
+// ListRuns lists the runs of the projects the user can access, newest
+// first; admins see every project's runs
+func (rm *RunManagement) ListRuns(ctx context.Context, req ListRunsRequest) (*ListRunsResponse, error) {
+	if req.User == "" {
+		return nil, fmt.Errorf("user is required to list runs")
+	}
+	user, err := rm.findUser(req.User)
+	if err != nil {
+		return nil, err
+	}
+	projectID := ""
+	if req.Project != "" {
+		project, err := rm.FindProject(req.Project)
+		if err != nil {
+			return nil, err
+		}
+		if !rm.canAccess(user, project) {
+			return nil, fmt.Errorf("user %s has no access to project %s", user.Username, project.Name)
+		}
+		projectID = project.ID
+	}
+
+	runs := []RunRecord{}
+	for _, run := range rm.Manager.Runs {
+		if projectID != "" && run.ProjectID != projectID {
+			continue
+		}
+		if project, exists := rm.Manager.Projects[run.ProjectID]; !exists || !rm.canAccess(user, project) {
+			continue
+		}
+		runs = append(runs, run)
+	}
+	sort.SliceStable(runs, func(i, j int) bool { return runs[i].StartedAt.After(runs[j].StartedAt) })
+
+	page, pageSize := req.Page, req.PageSize
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 {
+		pageSize = DefaultRunPageSize
+	}
+	total := len(runs)
+	start := min((page-1)*pageSize, total)
+	end := min(start+pageSize, total)
+	return &ListRunsResponse{Runs: runs[start:end], Total: total, Page: page, PageSize: pageSize}, nil
+}
+
+// FindProject finds a project by ID or, failing that, by its unique name
+func (rm *RunManagement) FindProject(ref string) (*Project, error) {
+	if project, exists := rm.Manager.Projects[ref]; exists {
+		return project, nil
+	}
+	var found *Project
+	for _, project := range rm.Manager.Projects {
+		if project.Name != ref {
+			continue
+		}
+		if found != nil {
+			return nil, fmt.Errorf("several projects are named %s; use the project ID", ref)
+		}
+		found = project
+	}
+	if found == nil {
+		return nil, fmt.Errorf("project not found: %s", ref)
+	}
+	return found, nil
+}
+
+// Request types
+
+type StartRunRequest struct {
+	Project string `json:"project"`        // ID or name
+	User    string `json:"user,omitempty"` // username or ID
+}
+
+type ListRunsRequest struct {
+	Page     int    `json:"page"`
+	PageSize int    `json:"page_size"`
+	User     string `json:"user"`              // the caller, username or ID
+	Project  string `json:"project,omitempty"` // ID or name
+}
+
+type ListRunsResponse struct {
+	Runs     []RunRecord `json:"runs"`
+	Total    int         `json:"total"`
+	Page     int         `json:"page"`
+	PageSize int         `json:"page_size"`
+}
+
+// Helper methods
+
+// findUser finds a user by username or ID
+func (rm *RunManagement) findUser(identifier string) (*User, error) {
+	if user, exists := rm.Manager.Users[identifier]; exists {
+		return user, nil
+	}
+	for _, user := range rm.Manager.Users {
+		if user.ID == identifier {
+			return user, nil
+		}
+	}
+	return nil, fmt.Errorf("user not found: %s", identifier)
+}
+
+// canAccess tells whether a user is an admin, or the owner, a member or in
+// a team of the project
+func (rm *RunManagement) canAccess(user *User, project *Project) bool {
+	if rm.Manager.hasPermission(user, "system.admin") || project.OwnerID == user.ID || contains(project.MemberIDs, user.ID) {
+		return true
+	}
+	for _, teamID := range project.TeamIDs {
+		if team, exists := rm.Manager.Teams[teamID]; exists && contains(team.MemberIDs, user.ID) {
+			return true
+		}
+	}
+	return false
+}
+
+// applyRetention drops the runs of a project that finished more than its
+// test_retention days ago, returning how many
+func (rm *RunManagement) applyRetention(project *Project, now time.Time) int {
+	days := project.Settings.TestRetention
+	if days <= 0 {
+		return 0
+	}
+	cutoff := now.AddDate(0, 0, -days)
+	kept := rm.Manager.Runs[:0]
+	expired := 0
+	for _, run := range rm.Manager.Runs {
+		if run.ProjectID == project.ID && run.FinishedAt.Before(cutoff) {
+			expired++
+			continue
+		}
+		kept = append(kept, run)
+	}
+	rm.Manager.Runs = kept
+	return expired
+}
+
+// countRuns counts the runs kept for a project
+func (rm *RunManagement) countRuns(projectID string) int {
+	count := 0
+	for _, run := range rm.Manager.Runs {
+		if run.ProjectID == projectID {
+			count++
+		}
+	}
+	return count
+}
@@ -0,0 +1,152 @@
+package enterprise
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"panoptic/internal/logger"
+)
+
+// newRunManagement is a run manager with an admin, two testers, one of them
+// in the checkout team, and the checkout and search projects
+func newRunManagement(t *testing.T) *RunManagement {
+	t.Helper()
+	em := NewEnterpriseManager(*logger.NewLogger(false))
+	em.StoragePath = t.TempDir()
+	em.Enabled = true
+	require.NoError(t, em.initializeDefaultRoles())
+
+	em.Users["root"] = &User{ID: "u-root", Username: "root", Active: true, Permissions: em.Roles["admin"].Permissions}
+	em.Users["ana"] = &User{ID: "u-ana", Username: "ana", Active: true, Permissions: em.Roles["developer"].Permissions}
+	em.Users["bo"] = &User{ID: "u-bo", Username: "bo", Active: true, Permissions: em.Roles["developer"].Permissions}
+	em.Users["vic"] = &User{ID: "u-vic", Username: "vic", Active: true, Permissions: em.Roles["viewer"].Permissions}
+	em.Teams["t-checkout"] = &Team{ID: "t-checkout", Name: "Checkout", MemberIDs: []string{"u-bo"}}
+	em.Projects["p-checkout"] = &Project{ID: "p-checkout", Name: "checkout", OwnerID: "u-ana", TeamIDs: []string{"t-checkout"},
+		Status: "active", Settings: ProjectSettings{MaxTestRuns: 2, TestRetention: 30}}
+	em.Projects["p-search"] = &Project{ID: "p-search", Name: "search", OwnerID: "u-root", MemberIDs: []string{"u-vic"}, Status: "active"}
+	em.Projects["p-legacy"] = &Project{ID: "p-legacy", Name: "legacy", OwnerID: "u-ana", Status: "archived"}
+	return NewRunManagement(em)
+}
+
+func TestRunManagement_StartRun(t *testing.T) {
+	rm := newRunManagement(t)
+	ctx := context.Background()
+
+	project, err := rm.StartRun(ctx, StartRunRequest{Project: "checkout", User: "bo"})
+	require.NoError(t, err)
+	assert.Equal(t, "p-checkout", project.ID, "projects are found by name, and team members can run them")
+	_, err = rm.StartRun(ctx, StartRunRequest{Project: "p-search"})
+	assert.NoError(t, err, "runs without a user aren't checked for access")
+
+	_, err = rm.StartRun(ctx, StartRunRequest{Project: "legacy"})
+	assert.EqualError(t, err, "project legacy is archived")
+	_, err = rm.StartRun(ctx, StartRunRequest{Project: "billing"})
+	assert.EqualError(t, err, "project not found: billing")
+	_, err = rm.StartRun(ctx, StartRunRequest{Project: "search", User: "bo"})
+	assert.EqualError(t, err, "user bo has no access to project search")
+	_, err = rm.StartRun(ctx, StartRunRequest{Project: "search", User: "vic"})
+	assert.EqualError(t, err, "user vic may not run tests")
+
+	// Two runs use up the quota, until the older one is past retention
+	now := time.Now()
+	rm.Manager.Runs = []RunRecord{
+		{ID: "r1", ProjectID: "p-checkout", FinishedAt: now.AddDate(0, 0, -31)},
+		{ID: "r2", ProjectID: "p-checkout", FinishedAt: now.AddDate(0, 0, -1)},
+		{ID: "r3", ProjectID: "p-search", FinishedAt: now.AddDate(0, 0, -400)},
+	}
+	_, err = rm.StartRun(ctx, StartRunRequest{Project: "checkout"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"r2", "r3"}, runIDs(rm.Manager.Runs), "search keeps its runs without a retention")
+
+	rm.Manager.Runs = append(rm.Manager.Runs, RunRecord{ID: "r4", ProjectID: "p-checkout", FinishedAt: now})
+	_, err = rm.StartRun(ctx, StartRunRequest{Project: "checkout"})
+	assert.EqualError(t, err, "project checkout has used its quota of 2 test run(s); raise its max_test_runs or let test_retention expire older runs")
+}
+
+func TestRunManagement_RecordRun(t *testing.T) {
+	rm := newRunManagement(t)
+	record := RunRecord{ID: "run-1", ProjectID: "p-checkout", UserID: "u-bo", Config: "Shop", Total: 3, Passed: 2, Failed: 1}
+	require.NoError(t, rm.RecordRun(context.Background(), record))
+
+	assert.Equal(t, []RunRecord{record}, rm.Manager.Runs)
+	entry := rm.Manager.AuditLog[len(rm.Manager.AuditLog)-1]
+	assert.Equal(t, "run.complete", entry.Action)
+	assert.Equal(t, "run-1", entry.ResourceID)
+	assert.Equal(t, "bo", entry.Username)
+	assert.Equal(t, map[string]string{"project": "checkout", "config": "Shop", "passed": "2/3"}, entry.Details)
+	assert.False(t, entry.Success)
+
+	data, err := os.ReadFile(filepath.Join(rm.Manager.StoragePath, "runs.json"))
+	require.NoError(t, err)
+	assert.Contains(t, string(data), `"id": "run-1"`)
+
+	// The runs are loaded back with the rest of the enterprise data
+	loaded := NewEnterpriseManager(*logger.NewLogger(false))
+	loaded.StoragePath = rm.Manager.StoragePath
+	require.NoError(t, loaded.loadData())
+	assert.Equal(t, "run-1", loaded.Runs[0].ID)
+
+	assert.EqualError(t, rm.RecordRun(context.Background(), RunRecord{ID: "run-2", ProjectID: "p-gone"}), "project not found: p-gone")
+}
+
+func TestRunManagement_ListRuns(t *testing.T) {
+	rm := newRunManagement(t)
+	ctx := context.Background()
+	now := time.Now()
+	rm.Manager.Runs = []RunRecord{
+		{ID: "c1", ProjectID: "p-checkout", StartedAt: now.Add(-3 * time.Hour)},
+		{ID: "s1", ProjectID: "p-search", StartedAt: now.Add(-2 * time.Hour)},
+		{ID: "c2", ProjectID: "p-checkout", StartedAt: now.Add(-time.Hour)},
+	}
+
+	list, err := rm.ListRuns(ctx, ListRunsRequest{User: "bo"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"c2", "c1"}, runIDs(list.Runs), "the newest first, of the projects bo can access")
+	assert.Equal(t, 2, list.Total)
+	assert.Equal(t, DefaultRunPageSize, list.PageSize)
+
+	list, err = rm.ListRuns(ctx, ListRunsRequest{User: "u-root", Page: 2, PageSize: 2})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"c1"}, runIDs(list.Runs), "admins see every project")
+	assert.Equal(t, 3, list.Total)
+
+	list, err = rm.ListRuns(ctx, ListRunsRequest{User: "vic", Project: "search"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"s1"}, runIDs(list.Runs))
+
+	_, err = rm.ListRuns(ctx, ListRunsRequest{User: "vic", Project: "checkout"})
+	assert.EqualError(t, err, "user vic has no access to project checkout")
+	_, err = rm.ListRuns(ctx, ListRunsRequest{})
+	assert.EqualError(t, err, "user is required to list runs")
+	_, err = rm.ListRuns(ctx, ListRunsRequest{User: "eve"})
+	assert.EqualError(t, err, "user not found: eve")
+
+	// The run_list action takes the parameters of the command line
+	ei := &EnterpriseIntegration{Manager: rm.Manager, RunManagement: rm, Initialized: true}
+	result, err := ei.ExecuteEnterpriseAction(ctx, "run_list", map[string]interface{}{"user": "ana", "page_size": "1"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"c2"}, runIDs(result.(*ListRunsResponse).Runs))
+}
+
+func TestRunManagement_FindProject(t *testing.T) {
+	rm := newRunManagement(t)
+	rm.Manager.Projects["p-search-2"] = &Project{ID: "p-search-2", Name: "search", Status: "active"}
+	_, err := rm.FindProject("search")
+	assert.EqualError(t, err, "several projects are named search; use the project ID")
+	project, err := rm.FindProject("p-search-2")
+	require.NoError(t, err)
+	assert.Equal(t, "p-search-2", project.ID)
+}
+
+func runIDs(runs []RunRecord) []string {
+	ids := make([]string, len(runs))
+	for i, run := range runs {
+		ids[i] = run.ID
+	}
+	return ids
+}
@@ -0,0 +1,552 @@
+package enterprise
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	"panoptic/internal/logger"
+)
+
+// Queued run statuses
+const (
+	QueuedRunQueued    = "queued"
+	QueuedRunRunning   = "running"
+	QueuedRunSucceeded = "succeeded"
+	QueuedRunFailed    = "failed"
+	QueuedRunCancelled = "cancelled"
+)
+
+// DefaultQueueHeartbeatTimeout is how long, in seconds, a run may go
+// without a heartbeat from its worker before it is queued again
+const DefaultQueueHeartbeatTimeout = 120
+
+const (
+	runQueueFile      = "run_queue.json"
+	runQueueLock      = "run_queue.lock"
+	queueLockWait     = 10 * time.Second
+	queueLockStale    = 30 * time.Second // a lock this old was left by a process that died
+	maxFinishedQueued = 1000             // finished runs kept for their status
+)
+
+// RunQueueConfig sets how the run queue hands runs to workers
+type RunQueueConfig struct {
+	MaxConcurrentRuns int `yaml:"max_concurrent_runs"` // per project without its own; 0 is unlimited
+	HeartbeatTimeout  int `yaml:"heartbeat_timeout"`   // seconds, 120 by default
+}
+
+// QueuedRun is a run submitted to the queue and, once a worker claims it,
+// its live status
+type QueuedRun struct {
+	ID              string    `json:"id"`
+	ProjectID       string    `json:"project_id"`
+	Config          string    `json:"config"`                // the file name it was submitted as
+	ConfigData      string    `json:"config_data,omitempty"` // the configuration workers run
+	Priority        int       `json:"priority"`              // higher runs first
+	Status          string    `json:"status"`
+	SubmittedBy     string    `json:"submitted_by"` // user ID
+	SubmittedAt     time.Time `json:"submitted_at"`
+	Worker          string    `json:"worker,omitempty"`
+	Attempts        int       `json:"attempts"`
+	StartedAt       time.Time `json:"started_at,omitempty"`
+	HeartbeatAt     time.Time `json:"heartbeat_at,omitempty"`
+	FinishedAt      time.Time `json:"finished_at,omitempty"`
+	Progress        string    `json:"progress,omitempty"` // the last the worker reported
+	CancelRequested bool      `json:"cancel_requested,omitempty"`
+	Error           string    `json:"error,omitempty"`
+}
+
+// RunQueueManagement queues runs by priority for workers, local or on
+// distributed nodes, to pull. The queue is kept in run_queue.json under a
+// lock file, so every process sharing the storage path sees one queue.
+type RunQueueManagement struct {
+	Manager *EnterpriseManager
+	Logger  logger.Logger
+}
+
+// NewRunQueueManagement creates new run queue management handler
+func NewRunQueueManagement(manager *EnterpriseManager) *RunQueueManagement {
+	return &RunQueueManagement{
+		Manager: manager,
+		Logger:  manager.Logger,
+	}
+}
+
+// SubmitRun queues a configuration to run for a project. The submitter must
+// be able to start the run now, as StartRun checks; runs of a project wait
+// in the queue while its max_concurrent_runs are running.
+func (qm *RunQueueManagement) SubmitRun(ctx context.Context, req SubmitRunRequest) (*QueueEntry, error) {
+	if req.SubmittedBy == "" {
+		return nil, fmt.Errorf("the acting user is required")
+	}
+	if req.ConfigData == "" {
+		return nil, fmt.Errorf("the configuration is required")
+	}
+	runs := NewRunManagement(qm.Manager)
+	user, err := runs.findUser(req.SubmittedBy)
+	if err != nil {
+		return nil, err
+	}
+	project, err := runs.StartRun(ctx, StartRunRequest{Project: req.Project, User: user.ID})
+	if err != nil {
+		return nil, err
+	}
+
+	run := &QueuedRun{
+		ID:          uuid.New().String(),
+		ProjectID:   project.ID,
+		Config:      req.Config,
+		ConfigData:  req.ConfigData,
+		Priority:    req.Priority,
+		Status:      QueuedRunQueued,
+		SubmittedBy: user.ID,
+		SubmittedAt: time.Now(),
+	}
+	var entry QueueEntry
+	if err := qm.Manager.withQueue(func(queue *[]*QueuedRun) error {
+		*queue = append(*queue, run)
+		entry = queueEntry(*queue, run)
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+
+	qm.Manager.logAuditEntry(AuditEntry{
+		Timestamp:  run.SubmittedAt,
+		UserID:     user.ID,
+		Username:   user.Username,
+		Action:     "run.queue",
+		Resource:   "run",
+		ResourceID: run.ID,
+		Details: map[string]string{
+			"project":  project.Name,
+			"config":   run.Config,
+			"priority": strconv.Itoa(run.Priority),
+		},
+		Success:  true,
+		Severity: "low",
+		Category: "data",
+	})
+	if err := qm.Manager.saveData(); err != nil {
+		return nil, fmt.Errorf("failed to save run data: %w", err)
+	}
+	qm.Logger.Infof("Queued run %s of %s for project %s at position %d", run.ID, run.Config, project.Name, entry.Position)
+	return &entry, nil
+}
+
+// QueueStatus is the status of a queued run and its position in the queue
+func (qm *RunQueueManagement) QueueStatus(ctx context.Context, id string) (*QueueEntry, error) {
+	var entry QueueEntry
+	err := qm.Manager.withQueue(func(queue *[]*QueuedRun) error {
+		run := findQueued(*queue, id)
+		if run == nil {
+			return fmt.Errorf("queued run not found: %s", id)
+		}
+		entry = queueEntry(*queue, run)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &entry, nil
+}
+
+// ListQueue lists the runs of the queue, optionally of a status or project:
+// those running, then those queued in the order workers get them, then
+// those finished, newest first
+func (qm *RunQueueManagement) ListQueue(ctx context.Context, req ListQueueRequest) ([]QueueEntry, error) {
+	projectID := ""
+	if req.Project != "" {
+		project, err := NewRunManagement(qm.Manager).FindProject(req.Project)
+		if err != nil {
+			return nil, err
+		}
+		projectID = project.ID
+	}
+	entries := []QueueEntry{}
+	err := qm.Manager.withQueue(func(queue *[]*QueuedRun) error {
+		for _, run := range *queue {
+			if (req.Status == "" || run.Status == req.Status) && (projectID == "" || run.ProjectID == projectID) {
+				entries = append(entries, queueEntry(*queue, run))
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	order := func(entry QueueEntry) int {
+		switch entry.Status {
+		case QueuedRunRunning:
+			return 0
+		case QueuedRunQueued:
+			return 1
+		}
+		return 2
+	}
+	sort.SliceStable(entries, func(i, j int) bool {
+		a, b := entries[i], entries[j]
+		if order(a) != order(b) {
+			return order(a) < order(b)
+		}
+		switch order(a) {
+		case 0:
+			return a.StartedAt.Before(b.StartedAt)
+		case 1:
+			return a.Position < b.Position
+		}
+		return a.FinishedAt.After(b.FinishedAt)
+	})
+	return entries, nil
+}
+
+// CancelRun cancels a queued run at once and asks the worker of a running
+// one to stop it. Users cancel their own runs; those of others need
+// test.update.
+func (qm *RunQueueManagement) CancelRun(ctx context.Context, req CancelRunRequest) (*QueueEntry, error) {
+	if req.CancelledBy == "" {
+		return nil, fmt.Errorf("the acting user is required")
+	}
+	actor, err := NewRunManagement(qm.Manager).findUser(req.CancelledBy)
+	if err != nil {
+		return nil, err
+	}
+	var entry QueueEntry
+	err = qm.Manager.withQueue(func(queue *[]*QueuedRun) error {
+		run := findQueued(*queue, req.ID)
+		if run == nil {
+			return fmt.Errorf("queued run not found: %s", req.ID)
+		}
+		if run.SubmittedBy != actor.ID && !qm.Manager.hasPermission(actor, "test.update") {
+			return fmt.Errorf("user %s may not %s", actor.Username, Permission{Name: "test.update"}.verb())
+		}
+		switch run.Status {
+		case QueuedRunQueued:
+			run.Status, run.FinishedAt = QueuedRunCancelled, time.Now()
+		case QueuedRunRunning:
+			run.CancelRequested = true
+		default:
+			return fmt.Errorf("run %s has finished (%s)", run.ID, run.Status)
+		}
+		entry = queueEntry(*queue, run)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	qm.Manager.logAuditEntry(AuditEntry{
+		Timestamp:  time.Now(),
+		UserID:     actor.ID,
+		Username:   actor.Username,
+		Action:     "run.cancel",
+		Resource:   "run",
+		ResourceID: entry.ID,
+		Details:    map[string]string{"status": entry.Status},
+		Success:    true,
+		Severity:   "low",
+		Category:   "data",
+	})
+	if err := qm.Manager.saveData(); err != nil {
+		return nil, fmt.Errorf("failed to save run data: %w", err)
+	}
+	qm.Logger.Infof("User %s cancelled queued run %s", actor.Username, entry.ID)
+	return &entry, nil
+}
+
+// ClaimRun hands a worker the next run: the queued one of highest priority,
+// submitted first, whose project is below its max_concurrent_runs. Runs
+// whose worker stopped sending heartbeats are queued again first. It
+// returns nil when there's no run to claim.
+func (qm *RunQueueManagement) ClaimRun(ctx context.Context, worker string) (*QueuedRun, error) {
+	if worker == "" {
+		return nil, fmt.Errorf("the worker is required")
+	}
+	var claimed *QueuedRun
+	err := qm.Manager.withQueue(func(queue *[]*QueuedRun) error {
+		now := time.Now()
+		qm.requeueStale(*queue, now)
+		running := map[string]int{}
+		for _, run := range *queue {
+			if run.Status == QueuedRunRunning {
+				running[run.ProjectID]++
+			}
+		}
+		for _, run := range queuedInOrder(*queue) {
+			if limit := qm.concurrencyLimit(run.ProjectID); limit > 0 && running[run.ProjectID] >= limit {
+				continue
+			}
+			run.Status, run.Worker, run.Attempts = QueuedRunRunning, worker, run.Attempts+1
+			run.StartedAt, run.HeartbeatAt, run.Progress = now, now, ""
+			copied := *run
+			claimed = &copied
+			return nil
+		}
+		return nil
+	})
+	if err != nil || claimed == nil {
+		return nil, err
+	}
+	qm.Logger.Infof("Worker %s claimed queued run %s", worker, claimed.ID)
+	return claimed, nil
+}
+
+// HeartbeatRun keeps the claim of a worker on its run, recording its
+// progress, and returns the run, asking the worker to stop it when it's
+// cancelled
+func (qm *RunQueueManagement) HeartbeatRun(ctx context.Context, req HeartbeatRunRequest) (*QueueEntry, error) {
+	var entry QueueEntry
+	err := qm.Manager.withQueue(func(queue *[]*QueuedRun) error {
+		run, err := claimedBy(*queue, req.ID, req.Worker)
+		if err != nil {
+			return err
+		}
+		run.HeartbeatAt = time.Now()
+		if req.Progress != "" {
+			run.Progress = req.Progress
+		}
+		entry = queueEntry(*queue, run)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &entry, nil
+}
+
+// CompleteRun records how the run of a worker finished; a run cancelled
+// while running finishes cancelled. With release set the run is queued
+// again instead, as when its worker shuts down.
+func (qm *RunQueueManagement) CompleteRun(ctx context.Context, req CompleteRunRequest) (*QueueEntry, error) {
+	var entry QueueEntry
+	err := qm.Manager.withQueue(func(queue *[]*QueuedRun) error {
+		run, err := claimedBy(*queue, req.ID, req.Worker)
+		if err != nil {
+			return err
+		}
+		switch {
+		case run.CancelRequested:
+			run.Status = QueuedRunCancelled
+		case req.Release:
+			run.Status, run.Worker = QueuedRunQueued, ""
+		case req.Success:
+			run.Status = QueuedRunSucceeded
+		default:
+			run.Status = QueuedRunFailed
+		}
+		if run.Status != QueuedRunQueued {
+			run.FinishedAt = time.Now()
+			run.Error = req.Error
+		}
+		entry = queueEntry(*queue, run)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	qm.Logger.Infof("Queued run %s is %s", entry.ID, entry.Status)
+	return &entry, nil
+}
+
+// Request types
+
+type SubmitRunRequest struct {
+	Config      string `json:"config"`       // the file name, shown in the queue
+	ConfigData  string `json:"config_data"`  // its contents
+	Project     string `json:"project"`      // ID or name
+	Priority    int    `json:"priority"`     // higher runs first; 0 by default
+	SubmittedBy string `json:"submitted_by"` // username or ID
+}
+
+type ListQueueRequest struct {
+	Status  string `json:"status,omitempty"`
+	Project string `json:"project,omitempty"` // ID or name
+}
+
+type CancelRunRequest struct {
+	ID          string `json:"id"`
+	CancelledBy string `json:"cancelled_by"` // username or ID
+}
+
+type HeartbeatRunRequest struct {
+	ID       string `json:"id"`
+	Worker   string `json:"worker"`
+	Progress string `json:"progress,omitempty"`
+}
+
+type CompleteRunRequest struct {
+	ID      string `json:"id"`
+	Worker  string `json:"worker"`
+	Success bool   `json:"success"`
+	Error   string `json:"error,omitempty"`
+	Release bool   `json:"release,omitempty"` // queue the run again
+}
+
+// QueueEntry is a queued run, without its configuration, and its position
+// among the queued runs
+type QueueEntry struct {
+	QueuedRun
+	Position int `json:"position,omitempty"` // 1 is claimed next; 0 once claimed
+}
+
+// Helper methods
+
+// withQueue runs fn on the queue under its lock, saving it unless fn fails
+func (em *EnterpriseManager) withQueue(fn func(queue *[]*QueuedRun) error) error {
+	em.queueMu.Lock()
+	defer em.queueMu.Unlock()
+	unlock, err := em.lockQueue()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	queue := []*QueuedRun{}
+	if err := em.loadJSON(runQueueFile, &queue); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to load the run queue: %w", err)
+	}
+	if err := fn(&queue); err != nil {
+		return err
+	}
+	queue = pruneFinished(queue)
+	if err := em.saveJSON(runQueueFile, queue); err != nil {
+		return fmt.Errorf("failed to save the run queue: %w", err)
+	}
+	return nil
+}
+
+// lockQueue takes the lock file of the run queue, waiting for the process
+// holding it, and returns its release
+func (em *EnterpriseManager) lockQueue() (func(), error) {
+	path := filepath.Join(em.StoragePath, runQueueLock)
+	deadline := time.Now().Add(queueLockWait)
+	for {
+		file, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0600)
+		if err == nil {
+			file.Close()
+			return func() { os.Remove(path) }, nil
+		}
+		if !os.IsExist(err) {
+			return nil, fmt.Errorf("failed to lock the run queue: %w", err)
+		}
+		if info, err := os.Stat(path); err == nil && time.Since(info.ModTime()) > queueLockStale {
+			os.Remove(path)
+			continue
+		}
+		if time.Now().After(deadline) {
+			return nil, fmt.Errorf("the run queue is locked; remove %s if no panoptic process holds it", path)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+// requeueStale queues again the runs of workers silent for longer than the
+// heartbeat timeout; those cancelled meanwhile are cancelled
+func (qm *RunQueueManagement) requeueStale(queue []*QueuedRun, now time.Time) {
+	timeout := qm.Manager.Config.RunQueue.HeartbeatTimeout
+	if timeout <= 0 {
+		timeout = DefaultQueueHeartbeatTimeout
+	}
+	for _, run := range queue {
+		if run.Status != QueuedRunRunning || now.Sub(run.HeartbeatAt) <= time.Duration(timeout)*time.Second {
+			continue
+		}
+		qm.Logger.Warnf("Worker %s of queued run %s sent no heartbeat for %ds; queuing it again", run.Worker, run.ID, timeout)
+		if run.CancelRequested {
+			run.Status, run.FinishedAt = QueuedRunCancelled, now
+			continue
+		}
+		run.Status, run.Worker = QueuedRunQueued, ""
+	}
+}
+
+// concurrencyLimit is how many runs of a project may run at once: its
+// max_concurrent_runs, or else that of the run queue
+func (qm *RunQueueManagement) concurrencyLimit(projectID string) int {
+	if project, exists := qm.Manager.Projects[projectID]; exists && project.Settings.MaxConcurrentRuns > 0 {
+		return project.Settings.MaxConcurrentRuns
+	}
+	return qm.Manager.Config.RunQueue.MaxConcurrentRuns
+}
+
+// queuedInOrder are the queued runs in the order workers claim them
+func queuedInOrder(queue []*QueuedRun) []*QueuedRun {
+	var queued []*QueuedRun
+	for _, run := range queue {
+		if run.Status == QueuedRunQueued {
+			queued = append(queued, run)
+		}
+	}
+	sort.SliceStable(queued, func(i, j int) bool {
+		if queued[i].Priority != queued[j].Priority {
+			return queued[i].Priority > queued[j].Priority
+		}
+		return queued[i].SubmittedAt.Before(queued[j].SubmittedAt)
+	})
+	return queued
+}
+
+// queueEntry is a run of the queue with its position, without its
+// configuration
+func queueEntry(queue []*QueuedRun, run *QueuedRun) QueueEntry {
+	entry := QueueEntry{QueuedRun: *run}
+	entry.ConfigData = ""
+	if run.Status == QueuedRunQueued {
+		for i, queued := range queuedInOrder(queue) {
+			if queued.ID == run.ID {
+				entry.Position = i + 1
+				break
+			}
+		}
+	}
+	return entry
+}
+
+// findQueued finds a run of the queue by ID
+func findQueued(queue []*QueuedRun, id string) *QueuedRun {
+	for _, run := range queue {
+		if run.ID == id {
+			return run
+		}
+	}
+	return nil
+}
+
+// claimedBy finds the run a worker is running
+func claimedBy(queue []*QueuedRun, id, worker string) (*QueuedRun, error) {
+	run := findQueued(queue, id)
+	if run == nil {
+		return nil, fmt.Errorf("queued run not found: %s", id)
+	}
+	if run.Status != QueuedRunRunning || run.Worker != worker {
+		return nil, fmt.Errorf("run %s is not running on worker %s", id, worker)
+	}
+	return run, nil
+}
+
+// pruneFinished keeps the most recently finished runs of the queue
+func pruneFinished(queue []*QueuedRun) []*QueuedRun {
+	var finished []*QueuedRun
+	for _, run := range queue {
+		if !run.FinishedAt.IsZero() {
+			finished = append(finished, run)
+		}
+	}
+	if len(finished) <= maxFinishedQueued {
+		return queue
+	}
+	sort.Slice(finished, func(i, j int) bool { return finished[i].FinishedAt.After(finished[j].FinishedAt) })
+	cutoff := finished[maxFinishedQueued-1].FinishedAt
+	kept := queue[:0]
+	for _, run := range queue {
+		if run.FinishedAt.IsZero() || !run.FinishedAt.Before(cutoff) {
+			kept = append(kept, run)
+		}
+	}
+	return kept
+}
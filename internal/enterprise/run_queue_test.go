@@ -0,0 +1,259 @@
+package enterprise
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newRunQueueManagement has the users and projects of newRunManagement,
+// checkout running a single queued run at a time
+func newRunQueueManagement(t *testing.T) *RunQueueManagement {
+	t.Helper()
+	em := newRunManagement(t).Manager
+	em.Projects["p-checkout"].Settings.MaxConcurrentRuns = 1
+	return NewRunQueueManagement(em)
+}
+
+// submit queues a run, failing the test if it can't
+func submit(t *testing.T, qm *RunQueueManagement, project string, priority int) *QueueEntry {
+	t.Helper()
+	entry, err := qm.SubmitRun(context.Background(), SubmitRunRequest{Config: project + ".yaml", ConfigData: "name: " + project + "\n",
+		Project: project, Priority: priority, SubmittedBy: "root"})
+	require.NoError(t, err)
+	return entry
+}
+
+func TestRunQueueManagement_SubmitRun(t *testing.T) {
+	qm := newRunQueueManagement(t)
+	ctx := context.Background()
+
+	_, err := qm.SubmitRun(ctx, SubmitRunRequest{ConfigData: "name: smoke\n", Project: "checkout"})
+	assert.EqualError(t, err, "the acting user is required")
+	_, err = qm.SubmitRun(ctx, SubmitRunRequest{Project: "checkout", SubmittedBy: "ana"})
+	assert.EqualError(t, err, "the configuration is required")
+	_, err = qm.SubmitRun(ctx, SubmitRunRequest{ConfigData: "name: smoke\n", Project: "search", SubmittedBy: "vic"})
+	assert.EqualError(t, err, "user vic may not run tests")
+	_, err = qm.SubmitRun(ctx, SubmitRunRequest{ConfigData: "name: smoke\n", Project: "legacy", SubmittedBy: "ana"})
+	assert.EqualError(t, err, "project legacy is archived")
+
+	first := submit(t, qm, "checkout", 0)
+	assert.Equal(t, QueuedRunQueued, first.Status)
+	assert.Equal(t, 1, first.Position)
+	assert.Equal(t, "p-checkout", first.ProjectID)
+	assert.Equal(t, "u-root", first.SubmittedBy)
+	assert.Empty(t, first.ConfigData, "entries leave out the configuration")
+
+	// Higher priorities go first, equal ones in the order they came
+	urgent := submit(t, qm, "search", 5)
+	assert.Equal(t, 1, urgent.Position)
+	last := submit(t, qm, "checkout", 0)
+	assert.Equal(t, 3, last.Position)
+	status, err := qm.QueueStatus(ctx, first.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 2, status.Position)
+	_, err = qm.QueueStatus(ctx, "q-none")
+	assert.EqualError(t, err, "queued run not found: q-none")
+
+	entry := qm.Manager.AuditLog[len(qm.Manager.AuditLog)-1]
+	assert.Equal(t, "run.queue", entry.Action)
+	assert.Equal(t, last.ID, entry.ResourceID)
+	assert.Equal(t, map[string]string{"project": "checkout", "config": "checkout.yaml", "priority": "0"}, entry.Details)
+	data, err := os.ReadFile(filepath.Join(qm.Manager.StoragePath, "run_queue.json"))
+	require.NoError(t, err)
+	assert.Contains(t, string(data), "name: search")
+}
+
+func TestRunQueueManagement_ClaimRun(t *testing.T) {
+	qm := newRunQueueManagement(t)
+	ctx := context.Background()
+	checkout := submit(t, qm, "checkout", 5)
+	waiting := submit(t, qm, "checkout", 1)
+	search := submit(t, qm, "search", 0)
+
+	_, err := qm.ClaimRun(ctx, "")
+	assert.EqualError(t, err, "the worker is required")
+	run, err := qm.ClaimRun(ctx, "w1")
+	require.NoError(t, err)
+	assert.Equal(t, checkout.ID, run.ID)
+	assert.Equal(t, "name: checkout\n", run.ConfigData, "workers get the configuration")
+	assert.Equal(t, 1, run.Attempts)
+
+	// Checkout runs one at a time, so search goes next; another process
+	// sharing the storage path sees the same queue
+	other := newRunQueueManagement(t)
+	other.Manager.StoragePath = qm.Manager.StoragePath
+	run, err = other.ClaimRun(ctx, "w2")
+	require.NoError(t, err)
+	assert.Equal(t, search.ID, run.ID)
+	run, err = qm.ClaimRun(ctx, "w3")
+	require.NoError(t, err)
+	assert.Nil(t, run)
+
+	_, err = qm.HeartbeatRun(ctx, HeartbeatRunRequest{ID: checkout.ID, Worker: "w2"})
+	assert.EqualError(t, err, "run "+checkout.ID+" is not running on worker w2")
+	entry, err := qm.HeartbeatRun(ctx, HeartbeatRunRequest{ID: checkout.ID, Worker: "w1", Progress: "2/5 actions"})
+	require.NoError(t, err)
+	assert.Equal(t, "2/5 actions", entry.Progress)
+	entry, err = qm.CompleteRun(ctx, CompleteRunRequest{ID: checkout.ID, Worker: "w1", Success: true})
+	require.NoError(t, err)
+	assert.Equal(t, QueuedRunSucceeded, entry.Status)
+	assert.False(t, entry.FinishedAt.IsZero())
+
+	run, err = qm.ClaimRun(ctx, "w3")
+	require.NoError(t, err)
+	assert.Equal(t, waiting.ID, run.ID)
+	entry, err = qm.CompleteRun(ctx, CompleteRunRequest{ID: waiting.ID, Worker: "w3", Error: "exit status 1"})
+	require.NoError(t, err)
+	assert.Equal(t, QueuedRunFailed, entry.Status)
+	assert.Equal(t, "exit status 1", entry.Error)
+}
+
+func TestRunQueueManagement_Requeue(t *testing.T) {
+	qm := newRunQueueManagement(t)
+	ctx := context.Background()
+	qm.Manager.Config.RunQueue.HeartbeatTimeout = 60
+	queued := submit(t, qm, "search", 0)
+	_, err := qm.ClaimRun(ctx, "w1")
+	require.NoError(t, err)
+
+	// A worker shutting down releases its run
+	entry, err := qm.CompleteRun(ctx, CompleteRunRequest{ID: queued.ID, Worker: "w1", Release: true})
+	require.NoError(t, err)
+	assert.Equal(t, QueuedRunQueued, entry.Status)
+	assert.Equal(t, 1, entry.Position)
+
+	// One that stops sending heartbeats loses it
+	_, err = qm.ClaimRun(ctx, "w1")
+	require.NoError(t, err)
+	require.NoError(t, qm.Manager.withQueue(func(queue *[]*QueuedRun) error {
+		(*queue)[0].HeartbeatAt = time.Now().Add(-2 * time.Minute)
+		return nil
+	}))
+	run, err := qm.ClaimRun(ctx, "w2")
+	require.NoError(t, err)
+	require.NotNil(t, run)
+	assert.Equal(t, queued.ID, run.ID)
+	assert.Equal(t, 3, run.Attempts)
+	_, err = qm.HeartbeatRun(ctx, HeartbeatRunRequest{ID: queued.ID, Worker: "w1"})
+	assert.EqualError(t, err, "run "+queued.ID+" is not running on worker w1")
+}
+
+func TestRunQueueManagement_CancelRun(t *testing.T) {
+	qm := newRunQueueManagement(t)
+	ctx := context.Background()
+	running := submit(t, qm, "checkout", 1)
+	queued := submit(t, qm, "checkout", 0)
+	_, err := qm.ClaimRun(ctx, "w1")
+	require.NoError(t, err)
+
+	_, err = qm.CancelRun(ctx, CancelRunRequest{ID: queued.ID})
+	assert.EqualError(t, err, "the acting user is required")
+	_, err = qm.CancelRun(ctx, CancelRunRequest{ID: queued.ID, CancelledBy: "vic"})
+	assert.EqualError(t, err, "user vic may not edit tests")
+
+	entry, err := qm.CancelRun(ctx, CancelRunRequest{ID: queued.ID, CancelledBy: "root"})
+	require.NoError(t, err)
+	assert.Equal(t, QueuedRunCancelled, entry.Status)
+	_, err = qm.CancelRun(ctx, CancelRunRequest{ID: queued.ID, CancelledBy: "root"})
+	assert.EqualError(t, err, "run "+queued.ID+" has finished (cancelled)")
+
+	// The worker of a running one is told to stop it
+	entry, err = qm.CancelRun(ctx, CancelRunRequest{ID: running.ID, CancelledBy: "bo"})
+	require.NoError(t, err)
+	assert.Equal(t, QueuedRunRunning, entry.Status)
+	entry, err = qm.HeartbeatRun(ctx, HeartbeatRunRequest{ID: running.ID, Worker: "w1"})
+	require.NoError(t, err)
+	assert.True(t, entry.CancelRequested)
+	entry, err = qm.CompleteRun(ctx, CompleteRunRequest{ID: running.ID, Worker: "w1", Error: "signal: killed"})
+	require.NoError(t, err)
+	assert.Equal(t, QueuedRunCancelled, entry.Status)
+
+	audit := qm.Manager.AuditLog[len(qm.Manager.AuditLog)-1]
+	assert.Equal(t, "run.cancel", audit.Action)
+	assert.Equal(t, "bo", audit.Username)
+}
+
+func TestRunQueueManagement_ListQueue(t *testing.T) {
+	qm := newRunQueueManagement(t)
+	ctx := context.Background()
+	done := submit(t, qm, "search", 9)
+	low := submit(t, qm, "search", 0)
+	high := submit(t, qm, "checkout", 3)
+	_, err := qm.ClaimRun(ctx, "w1")
+	require.NoError(t, err)
+	_, err = qm.CompleteRun(ctx, CompleteRunRequest{ID: done.ID, Worker: "w1", Success: true})
+	require.NoError(t, err)
+	running := submit(t, qm, "search", 5)
+	_, err = qm.ClaimRun(ctx, "w1")
+	require.NoError(t, err)
+
+	entries, err := qm.ListQueue(ctx, ListQueueRequest{})
+	require.NoError(t, err)
+	var ids []string
+	for _, entry := range entries {
+		ids = append(ids, entry.ID)
+	}
+	assert.Equal(t, []string{running.ID, high.ID, low.ID, done.ID}, ids)
+	assert.Equal(t, []int{0, 1, 2, 0}, []int{entries[0].Position, entries[1].Position, entries[2].Position, entries[3].Position})
+
+	entries, err = qm.ListQueue(ctx, ListQueueRequest{Status: QueuedRunQueued, Project: "search"})
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	assert.Equal(t, low.ID, entries[0].ID)
+	_, err = qm.ListQueue(ctx, ListQueueRequest{Project: "billing"})
+	assert.EqualError(t, err, "project not found: billing")
+}
+
+func TestRunQueueManagement_StaleLock(t *testing.T) {
+	qm := newRunQueueManagement(t)
+	lock := filepath.Join(qm.Manager.StoragePath, "run_queue.lock")
+	require.NoError(t, os.WriteFile(lock, nil, 0600))
+	old := time.Now().Add(-time.Minute)
+	require.NoError(t, os.Chtimes(lock, old, old))
+
+	submit(t, qm, "search", 0)
+	assert.NoFileExists(t, lock, "a lock left by a process that died is taken over and released")
+}
+
+func TestRunQueueManagement_Actions(t *testing.T) {
+	qm := newRunQueueManagement(t)
+	ei := &EnterpriseIntegration{Manager: qm.Manager, RunQueueManagement: qm, Initialized: true}
+	ctx := context.Background()
+	config := filepath.Join(t.TempDir(), "smoke.yaml")
+	require.NoError(t, os.WriteFile(config, []byte("name: smoke\n"), 0644))
+
+	result, err := ei.ExecuteEnterpriseAction(ctx, "queue_claim", map[string]interface{}{"worker": "w1"})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"claimed": false}, result)
+
+	result, err = ei.ExecuteEnterpriseAction(ctx, "queue_submit", map[string]interface{}{
+		"config": config, "project": "checkout", "priority": "2", "submitted_by": "ana"})
+	require.NoError(t, err)
+	submitted := result.(*QueueEntry)
+	assert.Equal(t, "smoke.yaml", submitted.Config)
+	assert.Equal(t, 2, submitted.Priority)
+
+	result, err = ei.ExecuteEnterpriseAction(ctx, "queue_claim", map[string]interface{}{"worker": "w1"})
+	require.NoError(t, err)
+	assert.Equal(t, "name: smoke\n", result.(*QueuedRun).ConfigData)
+	_, err = ei.ExecuteEnterpriseAction(ctx, "queue_heartbeat", map[string]interface{}{"id": submitted.ID, "worker": "w1", "progress": "login"})
+	require.NoError(t, err)
+	result, err = ei.ExecuteEnterpriseAction(ctx, "queue_complete", map[string]interface{}{"id": submitted.ID, "worker": "w1", "success": "true"})
+	require.NoError(t, err)
+	assert.Equal(t, QueuedRunSucceeded, result.(*QueueEntry).Status)
+
+	result, err = ei.ExecuteEnterpriseAction(ctx, "queue_list", map[string]interface{}{"status": QueuedRunSucceeded})
+	require.NoError(t, err)
+	assert.Len(t, result.([]QueueEntry), 1)
+	result, err = ei.ExecuteEnterpriseAction(ctx, "queue_status", map[string]interface{}{"id": submitted.ID})
+	require.NoError(t, err)
+	assert.Equal(t, "login", result.(*QueueEntry).Progress)
+	_, err = ei.ExecuteEnterpriseAction(ctx, "queue_cancel", map[string]interface{}{"id": submitted.ID, "cancelled_by": "ana"})
+	assert.EqualError(t, err, "run "+submitted.ID+" has finished (succeeded)")
+}
@@ -0,0 +1,324 @@
+package enterprise
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"panoptic/internal/logger"
+)
+
+// Reasons sessions are revoked, in the audit log
+const (
+	RevokeReasonUser           = "revoked"
+	RevokeReasonPasswordReset  = "password_reset"
+	RevokeReasonPasswordChange = "password_change"
+	RevokeReasonRoleDowngrade  = "role_downgrade"
+)
+
+// ClientInfo is where a request comes from, recorded on the session it
+// opens
+type ClientInfo struct {
+	IPAddress string
+	UserAgent string
+}
+
+type clientKey struct{}
+
+// WithClient carries the client of a request to the session it opens
+func WithClient(ctx context.Context, client ClientInfo) context.Context {
+	return context.WithValue(ctx, clientKey{}, client)
+}
+
+// clientFrom is the client WithClient carries, if any
+func clientFrom(ctx context.Context) ClientInfo {
+	client, _ := ctx.Value(clientKey{}).(ClientInfo)
+	return client
+}
+
+// SessionManagement lists the devices users are signed in on and signs
+// them out of sessions
+type SessionManagement struct {
+	Manager *EnterpriseManager
+	Logger  logger.Logger
+}
+
+// NewSessionManagement creates new session management handler
+func NewSessionManagement(manager *EnterpriseManager) *SessionManagement {
+	return &SessionManagement{
+		Manager: manager,
+		Logger:  manager.Logger,
+	}
+}
+
+// ListSessions lists the active sessions of a user, most recently seen
+// first. Users list their own; those of others need user.read.
+func (sm *SessionManagement) ListSessions(ctx context.Context, req ListSessionsRequest) ([]SessionInfo, error) {
+	actor, user, err := sm.authorize(req.ListedBy, req.User, "user.read")
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	sessions := []SessionInfo{}
+	for _, session := range sm.Manager.Sessions {
+		if session.UserID != user.ID || !session.Active || now.After(session.ExpiresAt) {
+			continue
+		}
+		sessions = append(sessions, SessionInfo{
+			ID:        session.ID,
+			UserID:    user.ID,
+			Username:  user.Username,
+			IPAddress: session.IPAddress,
+			UserAgent: session.UserAgent,
+			Device:    deviceOf(session.UserAgent),
+			CreatedAt: session.CreatedAt,
+			LastSeen:  session.lastSeen(),
+			ExpiresAt: session.ExpiresAt,
+		})
+	}
+	sort.Slice(sessions, func(i, j int) bool {
+		if !sessions[i].LastSeen.Equal(sessions[j].LastSeen) {
+			return sessions[i].LastSeen.After(sessions[j].LastSeen)
+		}
+		return sessions[i].ID < sessions[j].ID
+	})
+	sm.Logger.Debugf("User %s listed %d session(s) of %s", actor.Username, len(sessions), user.Username)
+	return sessions, nil
+}
+
+// RevokeSession signs a user out of one session, by ID, or of all of them
+// given the user instead. Users revoke their own; those of others need
+// user.update.
+func (sm *SessionManagement) RevokeSession(ctx context.Context, req RevokeSessionRequest) (*RevokeSessionResponse, error) {
+	if (req.ID == "") == (req.User == "") {
+		return nil, fmt.Errorf("either the session ID or the user is required")
+	}
+	target := req.User
+	if req.ID != "" {
+		session, exists := sm.Manager.Sessions[req.ID]
+		if !exists || !session.Active {
+			return nil, fmt.Errorf("session not found: %s", req.ID)
+		}
+		target = session.UserID
+	}
+	actor, user, err := sm.authorize(req.RevokedBy, target, "user.update")
+	if err != nil {
+		return nil, err
+	}
+
+	response := &RevokeSessionResponse{UserID: user.ID, Sessions: []string{}}
+	for id, session := range sm.Manager.Sessions {
+		if session.UserID == user.ID && session.Active && (req.ID == "" || id == req.ID) {
+			session.Active = false
+			response.Sessions = append(response.Sessions, id)
+		}
+	}
+	sort.Strings(response.Sessions)
+
+	resourceID := req.ID
+	if resourceID == "" {
+		resourceID = user.ID
+	}
+	sm.Manager.logAuditEntry(AuditEntry{
+		Timestamp:  time.Now(),
+		UserID:     actor.ID,
+		Username:   actor.Username,
+		Action:     "auth.session_revoke",
+		Resource:   "session",
+		ResourceID: resourceID,
+		Details: map[string]string{
+			"user":     user.Username,
+			"sessions": strconv.Itoa(len(response.Sessions)),
+			"reason":   RevokeReasonUser,
+		},
+		Success:  true,
+		Severity: "medium",
+		Category: "auth",
+	})
+	if err := sm.Manager.saveData(); err != nil {
+		return nil, fmt.Errorf("failed to save session data: %w", err)
+	}
+	sm.Logger.Infof("Revoked %d session(s) of %s", len(response.Sessions), user.Username)
+	return response, nil
+}
+
+// Request types
+
+type ListSessionsRequest struct {
+	User     string `json:"user"`      // username or ID; the acting user by default
+	ListedBy string `json:"listed_by"` // username or ID
+}
+
+type RevokeSessionRequest struct {
+	ID        string `json:"id,omitempty"`   // the session to revoke
+	User      string `json:"user,omitempty"` // or the user whose sessions to revoke
+	RevokedBy string `json:"revoked_by"`     // username or ID
+}
+
+type RevokeSessionResponse struct {
+	UserID   string   `json:"user_id"`
+	Sessions []string `json:"sessions"` // the IDs of those revoked
+}
+
+// SessionInfo is an active session of a user and the device it's on
+type SessionInfo struct {
+	ID        string    `json:"id"`
+	UserID    string    `json:"user_id"`
+	Username  string    `json:"username"`
+	IPAddress string    `json:"ip_address"`
+	UserAgent string    `json:"user_agent"`
+	Device    string    `json:"device"` // as "Chrome on macOS"
+	CreatedAt time.Time `json:"created_at"`
+	LastSeen  time.Time `json:"last_seen"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// Helper methods
+
+// authorize finds the acting user and the user acted on, the acting one
+// when not given, who needs the permission to act on others
+func (sm *SessionManagement) authorize(actorID, userID, permission string) (*User, *User, error) {
+	if actorID == "" {
+		return nil, nil, fmt.Errorf("the acting user is required")
+	}
+	runs := NewRunManagement(sm.Manager)
+	actor, err := runs.findUser(actorID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if !actor.Active {
+		return nil, nil, fmt.Errorf("account is inactive")
+	}
+	user := actor
+	if userID != "" {
+		if user, err = runs.findUser(userID); err != nil {
+			return nil, nil, err
+		}
+	}
+	if user.ID != actor.ID && !sm.Manager.hasPermission(actor, permission) {
+		return nil, nil, fmt.Errorf("user %s may not %s", actor.Username, Permission{Name: permission}.verb())
+	}
+	return actor, user, nil
+}
+
+// lastSeen is when the session was last validated, or else opened
+func (s *Session) lastSeen() time.Time {
+	if s.LastSeen.IsZero() {
+		return s.CreatedAt
+	}
+	return s.LastSeen
+}
+
+// revokeSessions signs a user out of their active sessions, auditing why,
+// and returns how many it revoked
+func (em *EnterpriseManager) revokeSessions(user *User, reason string) int {
+	revoked := 0
+	for _, session := range em.Sessions {
+		if session.UserID == user.ID && session.Active {
+			session.Active = false
+			revoked++
+		}
+	}
+	if revoked == 0 {
+		return 0
+	}
+	em.logAuditEntry(AuditEntry{
+		Timestamp:  time.Now(),
+		UserID:     user.ID,
+		Username:   user.Username,
+		Action:     "auth.session_revoke",
+		Resource:   "session",
+		ResourceID: user.ID,
+		Details:    map[string]string{"user": user.Username, "sessions": strconv.Itoa(revoked), "reason": reason},
+		Success:    true,
+		Severity:   "medium",
+		Category:   "auth",
+	})
+	em.Logger.Infof("Signed %s out of %d session(s): %s", user.Username, revoked, reason)
+	return revoked
+}
+
+// permissionSnapshot is the effective permissions of every user, by ID, to
+// tell who a change of roles downgrades
+func (em *EnterpriseManager) permissionSnapshot() map[string]map[string]bool {
+	snapshot := make(map[string]map[string]bool, len(em.Users))
+	for _, user := range em.Users {
+		snapshot[user.ID] = em.effectivePermissions(user)
+	}
+	return snapshot
+}
+
+// revokeDowngraded signs out the users who lost a permission they had in
+// the snapshot
+func (em *EnterpriseManager) revokeDowngraded(before map[string]map[string]bool) {
+	for _, user := range em.Users {
+		after := em.effectivePermissions(user)
+		for permission := range before[user.ID] {
+			if !after[permission] {
+				em.revokeSessions(user, RevokeReasonRoleDowngrade)
+				break
+			}
+		}
+	}
+}
+
+// effectivePermissions is what hasPermission grants a user: their role's
+// permissions and those of their teams' roles
+func (em *EnterpriseManager) effectivePermissions(user *User) map[string]bool {
+	permissions := make(map[string]bool, len(user.Permissions))
+	for name, granted := range user.Permissions {
+		if granted {
+			permissions[name] = true
+		}
+	}
+	for _, team := range em.Teams {
+		if len(team.RoleIDs) == 0 || !team.Active || !contains(team.MemberIDs, user.ID) {
+			continue
+		}
+		for _, id := range team.RoleIDs {
+			for name, granted := range em.resolveRole(id) {
+				if granted {
+					permissions[name] = true
+				}
+			}
+		}
+	}
+	return permissions
+}
+
+// deviceOf names the browser and system of a user agent, as "Chrome on
+// macOS"; the user agent itself when neither is known
+func deviceOf(userAgent string) string {
+	browser := firstMatch(userAgent, [][2]string{
+		{"Edg/", "Edge"}, {"OPR/", "Opera"}, {"Firefox/", "Firefox"}, {"Chrome/", "Chrome"},
+		{"Safari/", "Safari"}, {"curl/", "curl"}, {"panoptic", "Panoptic"},
+	})
+	system := firstMatch(userAgent, [][2]string{
+		{"Windows", "Windows"}, {"iPhone", "iOS"}, {"iPad", "iPadOS"}, {"Mac OS X", "macOS"},
+		{"Android", "Android"}, {"CrOS", "ChromeOS"}, {"Linux", "Linux"},
+	})
+	switch {
+	case browser != "" && system != "":
+		return browser + " on " + system
+	case browser != "":
+		return browser
+	case system != "":
+		return system
+	case userAgent == "":
+		return "unknown"
+	}
+	return userAgent
+}
+
+// firstMatch is the name of the first token a user agent contains
+func firstMatch(userAgent string, tokens [][2]string) string {
+	for _, token := range tokens {
+		if strings.Contains(userAgent, token[0]) {
+			return token[1]
+		}
+	}
+	return ""
+}
@@ -0,0 +1,171 @@
+package enterprise
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	laptopAgent = "Mozilla/5.0 (Macintosh; Intel Mac OS X 14_0) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0 Safari/537.36"
+	phoneAgent  = "Mozilla/5.0 (iPhone; CPU iPhone OS 17_0 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/17.0 Mobile/15E148 Safari/604.1"
+)
+
+// newSessionManagement has the accounts of newAccountManagement, ana
+// signed in on a laptop and a phone, and bo, a user without permissions
+func newSessionManagement(t *testing.T) (*SessionManagement, *Session, *Session) {
+	t.Helper()
+	am, _ := newAccountManagement(t)
+	em := am.Manager
+	em.Config.SessionTimeout = 60
+	em.Users["bo"] = &User{ID: "u-bo", Username: "bo", Active: true}
+	um := NewUserManagement(em)
+	laptop, err := um.AuthenticateUser(WithClient(context.Background(), ClientInfo{IPAddress: "203.0.113.7", UserAgent: laptopAgent}), "ana", "anapass1")
+	require.NoError(t, err)
+	phone, err := um.AuthenticateUser(WithClient(context.Background(), ClientInfo{IPAddress: "198.51.100.4", UserAgent: phoneAgent}), "ana", "anapass1")
+	require.NoError(t, err)
+	return NewSessionManagement(em), laptop, phone
+}
+
+func TestSessionManagement_ListSessions(t *testing.T) {
+	sm, laptop, phone := newSessionManagement(t)
+	ctx := context.Background()
+	_, err := NewUserManagement(sm.Manager).ValidateSession(ctx, laptop.ID)
+	require.NoError(t, err)
+
+	sessions, err := sm.ListSessions(ctx, ListSessionsRequest{ListedBy: "ana"})
+	require.NoError(t, err)
+	require.Len(t, sessions, 2)
+	assert.Equal(t, laptop.ID, sessions[0].ID, "the session seen last comes first")
+	assert.Equal(t, "203.0.113.7", sessions[0].IPAddress)
+	assert.Equal(t, "Chrome on macOS", sessions[0].Device)
+	assert.Equal(t, phone.ID, sessions[1].ID)
+	assert.Equal(t, "Safari on iOS", sessions[1].Device)
+
+	sessions, err = sm.ListSessions(ctx, ListSessionsRequest{User: sm.Manager.Users["ana"].ID, ListedBy: "root"})
+	require.NoError(t, err)
+	assert.Len(t, sessions, 2)
+	_, err = sm.ListSessions(ctx, ListSessionsRequest{User: "ana", ListedBy: "bo"})
+	assert.EqualError(t, err, "user bo may not view users")
+	_, err = sm.ListSessions(ctx, ListSessionsRequest{User: "ana"})
+	assert.EqualError(t, err, "the acting user is required")
+
+	// Sessions are kept, without their tokens
+	data, err := os.ReadFile(filepath.Join(sm.Manager.StoragePath, "sessions.json"))
+	require.NoError(t, err)
+	assert.Contains(t, string(data), laptop.ID)
+	assert.NotContains(t, string(data), laptop.Token)
+}
+
+func TestSessionManagement_RevokeSession(t *testing.T) {
+	sm, laptop, phone := newSessionManagement(t)
+	ctx := context.Background()
+
+	_, err := sm.RevokeSession(ctx, RevokeSessionRequest{RevokedBy: "ana"})
+	assert.EqualError(t, err, "either the session ID or the user is required")
+	_, err = sm.RevokeSession(ctx, RevokeSessionRequest{ID: "s-none", RevokedBy: "ana"})
+	assert.EqualError(t, err, "session not found: s-none")
+	_, err = sm.RevokeSession(ctx, RevokeSessionRequest{ID: phone.ID, RevokedBy: "bo"})
+	assert.EqualError(t, err, "user bo may not edit users")
+
+	response, err := sm.RevokeSession(ctx, RevokeSessionRequest{ID: phone.ID, RevokedBy: "ana"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{phone.ID}, response.Sessions)
+	_, err = NewUserManagement(sm.Manager).ValidateSession(ctx, phone.ID)
+	assert.EqualError(t, err, "session is inactive")
+	sessions, err := sm.ListSessions(ctx, ListSessionsRequest{ListedBy: "ana"})
+	require.NoError(t, err)
+	require.Len(t, sessions, 1)
+	assert.Equal(t, laptop.ID, sessions[0].ID)
+
+	response, err = sm.RevokeSession(ctx, RevokeSessionRequest{User: "ana", RevokedBy: "root"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{laptop.ID}, response.Sessions)
+	entry := sm.Manager.AuditLog[len(sm.Manager.AuditLog)-1]
+	assert.Equal(t, "auth.session_revoke", entry.Action)
+	assert.Equal(t, "root", entry.Username)
+	assert.Equal(t, map[string]string{"user": "ana", "sessions": "1", "reason": RevokeReasonUser}, entry.Details)
+}
+
+func TestSessionManagement_ForcedLogout(t *testing.T) {
+	sm, laptop, phone := newSessionManagement(t)
+	em := sm.Manager
+	ctx := context.Background()
+	um := NewUserManagement(em)
+	lastRevoke := func() AuditEntry {
+		for i := len(em.AuditLog) - 1; i >= 0; i-- {
+			if em.AuditLog[i].Action == "auth.session_revoke" {
+				return em.AuditLog[i]
+			}
+		}
+		return AuditEntry{}
+	}
+
+	require.NoError(t, NewAccountManagement(em).ChangePassword(ctx, ChangePasswordRequest{Username: "ana", CurrentPassword: "anapass1", NewPassword: "anapass2"}))
+	assert.False(t, laptop.Active)
+	assert.False(t, phone.Active)
+	assert.Equal(t, RevokeReasonPasswordChange, lastRevoke().Details["reason"])
+	assert.Equal(t, "2", lastRevoke().Details["sessions"])
+
+	// A role with fewer permissions signs the user out; one with more doesn't
+	session, err := um.AuthenticateUser(ctx, "ana", "anapass2")
+	require.NoError(t, err)
+	viewer, developer := "viewer", "developer"
+	_, err = um.UpdateUser(ctx, "ana", UpdateUserRequest{Role: &viewer})
+	require.NoError(t, err)
+	assert.False(t, session.Active)
+	assert.Equal(t, RevokeReasonRoleDowngrade, lastRevoke().Details["reason"])
+	session, err = um.AuthenticateUser(ctx, "ana", "anapass2")
+	require.NoError(t, err)
+	_, err = um.UpdateUser(ctx, "ana", UpdateUserRequest{Role: &developer})
+	require.NoError(t, err)
+	assert.True(t, session.Active)
+
+	// So does taking a role from a team of the user
+	ana := em.Users["ana"]
+	em.Teams["t-qa"] = &Team{ID: "t-qa", Name: "QA", MemberIDs: []string{ana.ID}, RoleIDs: []string{"admin"}, Active: true}
+	_, err = NewRoleManagement(em).AssignTeams(ctx, AssignTeamRolesRequest{Teams: []string{"QA"}, Roles: []string{"admin"}, Remove: true, AssignedBy: "root"})
+	require.NoError(t, err)
+	assert.False(t, session.Active)
+	assert.Equal(t, "ana", lastRevoke().Username)
+}
+
+func TestSessionManagement_Actions(t *testing.T) {
+	sm, _, _ := newSessionManagement(t)
+	ei := &EnterpriseIntegration{Manager: sm.Manager, UserManagement: NewUserManagement(sm.Manager), SessionManagement: sm, Initialized: true}
+	ctx := context.Background()
+
+	signedIn, err := ei.ExecuteEnterpriseAction(ctx, "user_authenticate", map[string]interface{}{
+		"username": "root", "password": "rootpass1", "ip_address": "192.0.2.1", "user_agent": "curl/8.4.0"})
+	require.NoError(t, err)
+	result, err := ei.ExecuteEnterpriseAction(ctx, "session_list", map[string]interface{}{"listed_by": "root"})
+	require.NoError(t, err)
+	sessions := result.([]SessionInfo)
+	require.Len(t, sessions, 1)
+	assert.Equal(t, "192.0.2.1", sessions[0].IPAddress)
+	assert.Equal(t, "curl", sessions[0].Device)
+
+	result, err = ei.ExecuteEnterpriseAction(ctx, "session_revoke", map[string]interface{}{
+		"id": signedIn.(map[string]interface{})["session_id"], "revoked_by": "root"})
+	require.NoError(t, err)
+	assert.Len(t, result.(*RevokeSessionResponse).Sessions, 1)
+}
+
+func TestDeviceOf(t *testing.T) {
+	for agent, device := range map[string]string{
+		laptopAgent: "Chrome on macOS",
+		phoneAgent:  "Safari on iOS",
+		"Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0 Safari/537.36 Edg/120.0": "Edge on Windows",
+		"Mozilla/5.0 (Linux; Android 14) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0 Mobile Safari/537.36":              "Chrome on Android",
+		"Mozilla/5.0 (X11; Linux x86_64; rv:121.0) Gecko/20100101 Firefox/121.0":                                                "Firefox on Linux",
+		"panoptic-client": "Panoptic",
+		"RandomBot/1.0":   "RandomBot/1.0",
+		"":                "unknown",
+	} {
+		assert.Equal(t, device, deviceOf(agent), agent)
+	}
+}
@@ -0,0 +1,331 @@
+package enterprise
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"panoptic/internal/artifactcrypt"
+	"panoptic/internal/logger"
+)
+
+// DefaultStorageKeyEnv holds the organization key encrypting the storage
+// files when storage_encryption.key_env doesn't name another variable
+const DefaultStorageKeyEnv = "PANOPTIC_ENTERPRISE_KEY"
+
+// StorageEncryptionConfig keys the at-rest encryption of the storage files
+// that compliance data_encryption and audit_encryption turn on. The 32 byte
+// organization key, base64 or hex encoded, is read from key_env or printed
+// by key_command; previous_key_env holds the key being rotated out.
+type StorageEncryptionConfig struct {
+	KeyEnv         string   `yaml:"key_env"`          // defaults to PANOPTIC_ENTERPRISE_KEY
+	KeyCommand     []string `yaml:"key_command"`      // run instead of reading key_env
+	PreviousKeyEnv string   `yaml:"previous_key_env"` // still decrypts files until they're rotated
+}
+
+// EncryptionManagement rotates the organization key of the storage files
+type EncryptionManagement struct {
+	Manager *EnterpriseManager
+	Logger  logger.Logger
+}
+
+// NewEncryptionManagement creates new encryption management handler
+func NewEncryptionManagement(manager *EnterpriseManager) *EncryptionManagement {
+	return &EncryptionManagement{
+		Manager: manager,
+		Logger:  manager.Logger,
+	}
+}
+
+// RotateKey re-encrypts every storage file with the organization key, so
+// the key in previous_key_env can be retired. Files are written as
+// data_encryption and audit_encryption say, so turning either on or off
+// takes effect on the files at once too.
+func (enc *EncryptionManagement) RotateKey(ctx context.Context, req RotateKeyRequest) (*RotateKeyResponse, error) {
+	em := enc.Manager
+	if em.storageKey == nil {
+		return nil, fmt.Errorf("the organization key is required to rotate: %s is empty", storageKeyEnv(em.Config.StorageEncryption.KeyEnv))
+	}
+	files, err := em.storageFiles()
+	if err != nil {
+		return nil, err
+	}
+	response := &RotateKeyResponse{Files: []string{}, RotatedAt: time.Now()}
+	for _, name := range files {
+		if err := em.reencryptFile(name); err != nil {
+			return response, fmt.Errorf("failed to re-encrypt %s: %w", name, err)
+		}
+		response.Files = append(response.Files, name)
+		if em.encryptsFile(name) {
+			response.Encrypted++
+		}
+	}
+
+	entry := AuditEntry{
+		Timestamp: response.RotatedAt,
+		Action:    "storage.rotate_key",
+		Resource:  "storage",
+		Details: map[string]string{
+			"files":     strconv.Itoa(len(response.Files)),
+			"encrypted": strconv.Itoa(response.Encrypted),
+		},
+		Success:  true,
+		Severity: "high",
+		Category: "security",
+	}
+	if req.RotatedBy != "" {
+		if user, err := NewRunManagement(em).findUser(req.RotatedBy); err == nil {
+			entry.UserID, entry.Username = user.ID, user.Username
+		}
+	}
+	em.logAuditEntry(entry)
+	if err := em.saveData(); err != nil {
+		return response, fmt.Errorf("failed to save data after rotation: %w", err)
+	}
+	enc.Logger.Infof("Organization key rotated over %d storage file(s), %d encrypted", len(response.Files), response.Encrypted)
+	return response, nil
+}
+
+// Request types
+
+type RotateKeyRequest struct {
+	RotatedBy string `json:"rotated_by"` // username or ID, for the audit log
+}
+
+type RotateKeyResponse struct {
+	Files     []string  `json:"files"`
+	Encrypted int       `json:"encrypted"` // of the files, those now encrypted
+	RotatedAt time.Time `json:"rotated_at"`
+}
+
+// Helper methods
+
+// unlockStorage loads the organization key and checks every encrypted
+// storage file opens with it. It fails closed: without the key encryption
+// needs, or with files it can't decrypt, the manager doesn't start rather
+// than run on empty data it would then save over them.
+func (em *EnterpriseManager) unlockStorage() error {
+	config := em.Config.StorageEncryption
+	key, err := loadStorageKey(config.KeyEnv, config.KeyCommand)
+	if err != nil {
+		return err
+	}
+	em.storageKey, em.previousStorageKey = key, nil
+	if config.PreviousKeyEnv != "" {
+		if em.previousStorageKey, err = loadStorageKey(config.PreviousKeyEnv, nil); err != nil {
+			return err
+		}
+	}
+	if em.storageKey == nil {
+		for _, flag := range []struct {
+			name string
+			set  bool
+		}{
+			{"compliance.data_encryption", em.Config.Compliance.DataEncryption},
+			{"compliance.audit_encryption", em.Config.Compliance.AuditEncryption},
+		} {
+			if flag.set {
+				return fmt.Errorf("%s needs the organization key: %s is empty", flag.name, storageKeyEnv(config.KeyEnv))
+			}
+		}
+	}
+
+	files, err := em.storageFiles()
+	if err != nil {
+		return err
+	}
+	for _, name := range files {
+		data, err := os.ReadFile(filepath.Join(em.StoragePath, name))
+		if err != nil {
+			return err
+		}
+		if sealed := firstSealed(name, data); sealed != nil {
+			if _, err := em.openStored(name, sealed); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// encryptsFile tells whether a storage file is encrypted at rest: the audit
+// log and its checkpoints under audit_encryption, the rest under
+// data_encryption
+func (em *EnterpriseManager) encryptsFile(name string) bool {
+	if strings.HasPrefix(name, "audit") {
+		return em.Config.Compliance.AuditEncryption
+	}
+	return em.Config.Compliance.DataEncryption
+}
+
+// sealStored encrypts the contents of a storage file encrypted at rest
+func (em *EnterpriseManager) sealStored(name string, data []byte) ([]byte, error) {
+	if !em.encryptsFile(name) {
+		return data, nil
+	}
+	if em.storageKey == nil {
+		return nil, fmt.Errorf("%s is encrypted at rest and %s is empty", name, storageKeyEnv(em.Config.StorageEncryption.KeyEnv))
+	}
+	return artifactcrypt.Encrypt(em.storageKey, data)
+}
+
+// openStored decrypts the contents of a storage file encrypted at rest,
+// with the organization key or else the previous one. Plain contents are
+// returned as they are.
+func (em *EnterpriseManager) openStored(name string, data []byte) ([]byte, error) {
+	if !artifactcrypt.IsEncrypted(data) {
+		return data, nil
+	}
+	env := storageKeyEnv(em.Config.StorageEncryption.KeyEnv)
+	if em.storageKey == nil && em.previousStorageKey == nil {
+		return nil, fmt.Errorf("%s is encrypted at rest; set %s to the organization key", name, env)
+	}
+	for _, key := range [][]byte{em.storageKey, em.previousStorageKey} {
+		if key == nil {
+			continue
+		}
+		if plain, err := artifactcrypt.Decrypt(key, data); err == nil {
+			return plain, nil
+		}
+	}
+	return nil, fmt.Errorf("%s doesn't decrypt with the organization key in %s; is the key rotated out set in storage_encryption.previous_key_env?", name, env)
+}
+
+// sealLine encrypts a line of a JSON lines storage file, base64 encoded so
+// it stays a line
+func (em *EnterpriseManager) sealLine(name string, line []byte) ([]byte, error) {
+	if !em.encryptsFile(name) {
+		return line, nil
+	}
+	sealed, err := em.sealStored(name, line)
+	if err != nil {
+		return nil, err
+	}
+	return []byte(base64.StdEncoding.EncodeToString(sealed)), nil
+}
+
+// openLine decrypts a line sealLine encrypted; JSON lines are plain
+func (em *EnterpriseManager) openLine(name string, line []byte) ([]byte, error) {
+	if len(line) == 0 || line[0] == '{' {
+		return line, nil
+	}
+	sealed, err := base64.StdEncoding.DecodeString(string(line))
+	if err != nil {
+		return nil, fmt.Errorf("%s: malformed encrypted line", name)
+	}
+	return em.openStored(name, sealed)
+}
+
+// reencryptFile writes a storage file again as encryption says, through a
+// temporary file renamed over it
+func (em *EnterpriseManager) reencryptFile(name string) error {
+	if filepath.Ext(name) == ".jsonl" {
+		store, ok := em.auditStore().(*fileAuditStore)
+		if !ok || filepath.Base(store.path) != name {
+			store = &fileAuditStore{path: filepath.Join(em.StoragePath, name), manager: em}
+		}
+		entries, err := store.Entries()
+		if err != nil {
+			return err
+		}
+		return store.Rewrite(entries)
+	}
+
+	path := filepath.Join(em.StoragePath, name)
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	plain, err := em.openStored(name, data)
+	if err != nil {
+		return err
+	}
+	sealed, err := em.sealStored(name, plain)
+	if err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, sealed, 0600); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	return nil
+}
+
+// storageFiles are the JSON and JSON lines files of the storage path
+func (em *EnterpriseManager) storageFiles() ([]string, error) {
+	entries, err := os.ReadDir(em.StoragePath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	var files []string
+	for _, entry := range entries {
+		if ext := filepath.Ext(entry.Name()); entry.Type().IsRegular() && (ext == ".json" || ext == ".jsonl") {
+			files = append(files, entry.Name())
+		}
+	}
+	sort.Strings(files)
+	return files, nil
+}
+
+// firstSealed is the encrypted contents of a storage file, or of its first
+// encrypted line for JSON lines; nil when it's plain
+func firstSealed(name string, data []byte) []byte {
+	if filepath.Ext(name) != ".jsonl" {
+		if artifactcrypt.IsEncrypted(data) {
+			return data
+		}
+		return nil
+	}
+	for _, line := range bytes.Split(data, []byte("\n")) {
+		if len(line) == 0 || line[0] == '{' {
+			continue
+		}
+		if sealed, err := base64.StdEncoding.DecodeString(string(line)); err == nil {
+			return sealed
+		}
+	}
+	return nil
+}
+
+// loadStorageKey reads the organization key from the environment variable
+// env or, given a command, from what it prints; nil when env is empty
+func loadStorageKey(env string, command []string) ([]byte, error) {
+	if len(command) > 0 {
+		key, err := artifactcrypt.LoadKey("", command)
+		if err != nil {
+			return nil, fmt.Errorf("storage_encryption.key_command: %w", err)
+		}
+		return key, nil
+	}
+	env = storageKeyEnv(env)
+	value := os.Getenv(env)
+	if strings.TrimSpace(value) == "" {
+		return nil, nil
+	}
+	key, err := artifactcrypt.ParseKey(value)
+	if err != nil {
+		return nil, fmt.Errorf("%s: organization key must be %d bytes, base64 or hex encoded", env, artifactcrypt.KeySize)
+	}
+	return key, nil
+}
+
+func storageKeyEnv(env string) string {
+	if env == "" {
+		return DefaultStorageKeyEnv
+	}
+	return env
+}
@@ -0,0 +1,160 @@
+package enterprise
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"panoptic/internal/artifactcrypt"
+	"panoptic/internal/logger"
+)
+
+var (
+	testStorageKey    = strings.Repeat("cd", 32)
+	testNewStorageKey = strings.Repeat("ef", 32)
+)
+
+// encryptedConfig encrypts both the data and the audit log of storage
+func encryptedConfig(storage string) EnterpriseConfig {
+	return EnterpriseConfig{Enabled: true, StoragePath: storage,
+		Compliance: ComplianceConfig{DataEncryption: true, AuditEncryption: true}}
+}
+
+// initializedWithUser is a manager started on storage, with ana saved in it
+func initializedWithUser(t *testing.T, config EnterpriseConfig) *EnterpriseManager {
+	t.Helper()
+	em := NewEnterpriseManager(*logger.NewLogger(false))
+	require.NoError(t, em.Initialize(config))
+	em.Users["ana"] = &User{ID: "u-ana", Username: "ana", Email: "ana@example.com", Active: true}
+	em.logAuditEntry(AuditEntry{Timestamp: time.Now(), UserID: "u-ana", Username: "ana", Action: "user.login", Resource: "user", Success: true})
+	require.NoError(t, em.saveData())
+	return em
+}
+
+func TestStorageEncryption_Initialize(t *testing.T) {
+	storage := t.TempDir()
+	config := encryptedConfig(storage)
+
+	t.Setenv(DefaultStorageKeyEnv, "")
+	err := NewEnterpriseManager(*logger.NewLogger(false)).Initialize(config)
+	assert.EqualError(t, err, "compliance.data_encryption needs the organization key: PANOPTIC_ENTERPRISE_KEY is empty")
+	t.Setenv(DefaultStorageKeyEnv, "short")
+	err = NewEnterpriseManager(*logger.NewLogger(false)).Initialize(config)
+	assert.EqualError(t, err, "PANOPTIC_ENTERPRISE_KEY: organization key must be 32 bytes, base64 or hex encoded")
+
+	t.Setenv(DefaultStorageKeyEnv, testStorageKey)
+	initializedWithUser(t, config)
+	for _, name := range []string{"users.json", "audit.json"} {
+		data, err := os.ReadFile(filepath.Join(storage, name))
+		require.NoError(t, err)
+		assert.True(t, artifactcrypt.IsEncrypted(data), name)
+		assert.NotContains(t, string(data), "ana@example.com", name)
+	}
+
+	loaded := NewEnterpriseManager(*logger.NewLogger(false))
+	require.NoError(t, loaded.Initialize(config))
+	assert.Equal(t, "u-ana", loaded.Users["ana"].ID)
+	require.Len(t, loaded.AuditLog, 1)
+	assert.Equal(t, "ana", loaded.AuditLog[0].Username)
+
+	// Encrypted files keep the manager from starting without their key,
+	// even once encryption is turned off
+	t.Setenv(DefaultStorageKeyEnv, "")
+	err = NewEnterpriseManager(*logger.NewLogger(false)).Initialize(EnterpriseConfig{Enabled: true, StoragePath: storage})
+	assert.EqualError(t, err, "account_tokens.json is encrypted at rest; set PANOPTIC_ENTERPRISE_KEY to the organization key")
+	t.Setenv(DefaultStorageKeyEnv, testNewStorageKey)
+	err = NewEnterpriseManager(*logger.NewLogger(false)).Initialize(config)
+	assert.ErrorContains(t, err, "account_tokens.json doesn't decrypt with the organization key in PANOPTIC_ENTERPRISE_KEY")
+}
+
+func TestStorageEncryption_DataOnly(t *testing.T) {
+	storage := t.TempDir()
+	t.Setenv(DefaultStorageKeyEnv, testStorageKey)
+	initializedWithUser(t, EnterpriseConfig{Enabled: true, StoragePath: storage, Compliance: ComplianceConfig{DataEncryption: true}})
+
+	data, err := os.ReadFile(filepath.Join(storage, "users.json"))
+	require.NoError(t, err)
+	assert.True(t, artifactcrypt.IsEncrypted(data))
+	data, err = os.ReadFile(filepath.Join(storage, "audit.json"))
+	require.NoError(t, err)
+	assert.Contains(t, string(data), `"user.login"`, "the audit log is encrypted under audit_encryption")
+}
+
+func TestStorageEncryption_FileAuditStore(t *testing.T) {
+	storage := t.TempDir()
+	config := encryptedConfig(storage)
+	config.AuditStorage = AuditStorageFile
+	t.Setenv(DefaultStorageKeyEnv, testStorageKey)
+	t.Setenv(DefaultAuditKeyEnv, testAuditKey)
+	config.AuditIntegrity.CheckpointInterval = 2
+	em := initializedWithUser(t, config)
+	em.logAuditEntry(AuditEntry{Timestamp: time.Now(), UserID: "u-ana", Username: "ana", Action: "user.logout", Resource: "user", Success: true})
+
+	data, err := os.ReadFile(filepath.Join(storage, "audit.jsonl"))
+	require.NoError(t, err)
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	assert.Len(t, lines, 2)
+	assert.NotContains(t, string(data), "user.login")
+
+	resumed := NewEnterpriseManager(*logger.NewLogger(false))
+	require.NoError(t, resumed.Initialize(config))
+	require.Len(t, resumed.AuditLog, 2)
+	assert.Equal(t, "user.logout", resumed.AuditLog[1].Action)
+	result, err := NewAuditManagement(resumed).VerifyAuditLog(context.Background())
+	require.NoError(t, err)
+	assert.True(t, result.Verified, result.Problems)
+	assert.Equal(t, 1, result.Checkpoints)
+}
+
+func TestEncryptionManagement_RotateKey(t *testing.T) {
+	storage := t.TempDir()
+	config := encryptedConfig(storage)
+	config.AuditStorage = AuditStorageFile
+	t.Setenv(DefaultStorageKeyEnv, testStorageKey)
+	initializedWithUser(t, config)
+
+	// The new key in key_env, the old one in previous_key_env
+	t.Setenv(DefaultStorageKeyEnv, testNewStorageKey)
+	t.Setenv("PANOPTIC_ENTERPRISE_OLD_KEY", testStorageKey)
+	config.StorageEncryption.PreviousKeyEnv = "PANOPTIC_ENTERPRISE_OLD_KEY"
+	em := NewEnterpriseManager(*logger.NewLogger(false))
+	require.NoError(t, em.Initialize(config))
+	ei := &EnterpriseIntegration{Manager: em, EncryptionManagement: NewEncryptionManagement(em), Initialized: true}
+
+	result, err := ei.ExecuteEnterpriseAction(context.Background(), "storage_rotate_key", map[string]interface{}{"rotated_by": "ana"})
+	require.NoError(t, err)
+	response := result.(*RotateKeyResponse)
+	assert.Contains(t, response.Files, "users.json")
+	assert.Contains(t, response.Files, "audit.jsonl")
+	assert.Equal(t, len(response.Files), response.Encrypted)
+	entry := em.AuditLog[len(em.AuditLog)-1]
+	assert.Equal(t, "storage.rotate_key", entry.Action)
+	assert.Equal(t, "u-ana", entry.UserID)
+
+	// The old key is retired
+	config.StorageEncryption.PreviousKeyEnv = ""
+	rotated := NewEnterpriseManager(*logger.NewLogger(false))
+	require.NoError(t, rotated.Initialize(config))
+	assert.Contains(t, rotated.Users, "ana")
+	require.Len(t, rotated.AuditLog, 2)
+	assert.Equal(t, "storage.rotate_key", rotated.AuditLog[1].Action)
+
+	// Rotating with encryption turned off decrypts the files
+	config.Compliance = ComplianceConfig{}
+	require.NoError(t, rotated.Initialize(config))
+	_, err = NewEncryptionManagement(rotated).RotateKey(context.Background(), RotateKeyRequest{})
+	require.NoError(t, err)
+	data, err := os.ReadFile(filepath.Join(storage, "users.json"))
+	require.NoError(t, err)
+	assert.Contains(t, string(data), "ana@example.com")
+
+	t.Setenv(DefaultStorageKeyEnv, "")
+	require.NoError(t, rotated.Initialize(config))
+	_, err = NewEncryptionManagement(rotated).RotateKey(context.Background(), RotateKeyRequest{})
+	assert.EqualError(t, err, "the organization key is required to rotate: PANOPTIC_ENTERPRISE_KEY is empty")
+}
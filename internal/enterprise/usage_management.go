@@ -0,0 +1,285 @@
+package enterprise
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"panoptic/internal/logger"
+)
+
+// Usage subjects
+const (
+	UsageUser    = "user"
+	UsageProject = "project"
+)
+
+// usagePeriod is the layout of usage periods, calendar months
+const usagePeriod = "2006-01"
+
+// Usage is what a user, or the runs of a project, used in a month
+type Usage struct {
+	Subject      string    `json:"subject"` // user or project
+	SubjectID    string    `json:"subject_id"`
+	Period       string    `json:"period"` // 2006-01
+	TestRuns     int       `json:"test_runs"`
+	NodeMinutes  float64   `json:"node_minutes"`  // run minutes times the nodes each ran on
+	StorageBytes int64     `json:"storage_bytes"` // artifacts the runs wrote
+	APICalls     int       `json:"api_calls"`
+	UpdatedAt    time.Time `json:"updated_at"`
+}
+
+// UsageManagement meters test runs, artifact storage and API calls per user
+// and project, holding them to the limits of the active subscription of
+// the user, or of the project owner
+type UsageManagement struct {
+	Manager *EnterpriseManager
+	Logger  logger.Logger
+}
+
+// NewUsageManagement creates new usage management handler
+func NewUsageManagement(manager *EnterpriseManager) *UsageManagement {
+	return &UsageManagement{
+		Manager: manager,
+		Logger:  manager.Logger,
+	}
+}
+
+// CheckRun checks neither the user nor the project starting a run has used
+// up the test runs or storage of its subscription this month
+func (um *UsageManagement) CheckRun(ctx context.Context, userID, projectID string) error {
+	now := time.Now()
+	for _, subject := range um.subjects(userID, projectID) {
+		subscription := um.subscriptionOf(subject)
+		if subscription == nil {
+			continue
+		}
+		usage := um.usage(subject.kind, subject.id, now)
+		limits := subscription.Limits
+		if limits.MaxTestRuns > 0 && usage.TestRuns >= limits.MaxTestRuns {
+			return um.overLimit(subject, subscription, fmt.Sprintf("the %d test run(s)", limits.MaxTestRuns), now)
+		}
+		if limits.MaxStorageGB > 0 && usage.StorageBytes >= int64(limits.MaxStorageGB)<<30 {
+			return um.overLimit(subject, subscription, fmt.Sprintf("the %d GB of storage", limits.MaxStorageGB), now)
+		}
+	}
+	return nil
+}
+
+// RecordRun meters a finished run, the node-minutes it took and the
+// artifact bytes it wrote
+func (um *UsageManagement) RecordRun(ctx context.Context, userID, projectID string, storageBytes int64, nodeMinutes float64) error {
+	now := time.Now()
+	for _, subject := range um.subjects(userID, projectID) {
+		usage := um.usage(subject.kind, subject.id, now)
+		usage.TestRuns++
+		usage.NodeMinutes += nodeMinutes
+		usage.StorageBytes += storageBytes
+		usage.UpdatedAt = now
+	}
+	if err := um.Manager.saveJSON("usage.json", um.Manager.Usage); err != nil {
+		return fmt.Errorf("failed to save usage data: %w", err)
+	}
+	return nil
+}
+
+// RecordAPICall meters an API call of a user, refusing it once the user has
+// made the max_api_calls of their subscription this month
+func (um *UsageManagement) RecordAPICall(ctx context.Context, userID string) error {
+	now := time.Now()
+	subject := um.subject(UsageUser, userID)
+	usage := um.usage(UsageUser, userID, now)
+	if subscription := um.subscriptionOf(subject); subscription != nil {
+		if limit := subscription.Limits.MaxAPICalls; limit > 0 && usage.APICalls >= limit {
+			return um.overLimit(subject, subscription, fmt.Sprintf("the %d API call(s)", limit), now)
+		}
+	}
+	usage.APICalls++
+	usage.UpdatedAt = now
+	if err := um.Manager.saveJSON("usage.json", um.Manager.Usage); err != nil {
+		um.Logger.Warnf("Failed to save usage data: %v", err)
+	}
+	return nil
+}
+
+// Report reports the usage of a month, this one by default, against the
+// subscription limits: of one user or project, or of all of them
+func (um *UsageManagement) Report(ctx context.Context, req UsageReportRequest) (*UsageReport, error) {
+	period := req.Period
+	if period == "" {
+		period = time.Now().Format(usagePeriod)
+	} else if _, err := time.Parse(usagePeriod, period); err != nil {
+		return nil, fmt.Errorf("invalid period %q; use YYYY-MM", period)
+	}
+
+	var subjects []usageSubject
+	switch {
+	case req.User != "" || req.Project != "":
+		subjectUser, subjectProject := "", ""
+		if req.User != "" {
+			user, err := NewRunManagement(um.Manager).findUser(req.User)
+			if err != nil {
+				return nil, err
+			}
+			subjectUser = user.ID
+		}
+		if req.Project != "" {
+			project, err := NewRunManagement(um.Manager).FindProject(req.Project)
+			if err != nil {
+				return nil, err
+			}
+			subjectProject = project.ID
+		}
+		subjects = um.subjects(subjectUser, subjectProject)
+	default:
+		for _, usage := range um.Manager.Usage {
+			if usage.Period == period {
+				subjects = append(subjects, um.subject(usage.Subject, usage.SubjectID))
+			}
+		}
+		sort.Slice(subjects, func(i, j int) bool {
+			if subjects[i].kind != subjects[j].kind {
+				return subjects[i].kind == UsageUser
+			}
+			return subjects[i].name < subjects[j].name
+		})
+	}
+
+	report := &UsageReport{Period: period, Entries: []UsageReportEntry{}}
+	for _, subject := range subjects {
+		entry := UsageReportEntry{Subject: subject.kind, SubjectID: subject.id, Name: subject.name}
+		if usage, exists := um.Manager.Usage[usageKey(subject.kind, subject.id, period)]; exists {
+			entry.TestRuns, entry.NodeMinutes = usage.TestRuns, usage.NodeMinutes
+			entry.StorageBytes, entry.APICalls = usage.StorageBytes, usage.APICalls
+		}
+		if subscription := um.subscriptionOf(subject); subscription != nil {
+			entry.Plan = subscription.Plan
+			entry.Limits = &subscription.Limits
+		}
+		report.Entries = append(report.Entries, entry)
+	}
+	return report, nil
+}
+
+// Request types
+
+type UsageReportRequest struct {
+	User    string `json:"user,omitempty"`    // username or ID
+	Project string `json:"project,omitempty"` // ID or name
+	Period  string `json:"period,omitempty"`  // YYYY-MM
+}
+
+type UsageReport struct {
+	Period  string             `json:"period"`
+	Entries []UsageReportEntry `json:"entries"`
+}
+
+type UsageReportEntry struct {
+	Subject      string              `json:"subject"`
+	SubjectID    string              `json:"subject_id"`
+	Name         string              `json:"name"`
+	Plan         string              `json:"plan,omitempty"`
+	TestRuns     int                 `json:"test_runs"`
+	NodeMinutes  float64             `json:"node_minutes"`
+	StorageBytes int64               `json:"storage_bytes"`
+	APICalls     int                 `json:"api_calls"`
+	Limits       *SubscriptionLimits `json:"limits,omitempty"` // none without a subscription
+}
+
+// Helper methods
+
+// usageSubject is a user or project usage is metered for
+type usageSubject struct {
+	kind, id, name string
+	ownerID        string // the user whose subscription applies
+}
+
+// subjects are the user and project of a run that has them
+func (um *UsageManagement) subjects(userID, projectID string) []usageSubject {
+	var subjects []usageSubject
+	if userID != "" {
+		subjects = append(subjects, um.subject(UsageUser, userID))
+	}
+	if projectID != "" {
+		subjects = append(subjects, um.subject(UsageProject, projectID))
+	}
+	return subjects
+}
+
+func (um *UsageManagement) subject(kind, id string) usageSubject {
+	if kind == UsageProject {
+		subject := usageSubject{kind: kind, id: id, name: id}
+		if project, exists := um.Manager.Projects[id]; exists {
+			subject.name, subject.ownerID = project.Name, project.OwnerID
+		}
+		return subject
+	}
+	return usageSubject{kind: kind, id: id, name: um.Manager.getUsername(id), ownerID: id}
+}
+
+// subscriptionOf is the active subscription of the user, or project owner,
+// that started most recently; nil when there is none
+func (um *UsageManagement) subscriptionOf(subject usageSubject) *Subscription {
+	if subject.ownerID == "" {
+		return nil
+	}
+	now := time.Now()
+	var found *Subscription
+	for _, subscription := range um.Manager.Subscriptions {
+		if subscription.UserID != subject.ownerID || subscription.Status != "active" {
+			continue
+		}
+		if !subscription.EndDate.IsZero() && now.After(subscription.EndDate) {
+			continue
+		}
+		if found == nil || subscription.StartDate.After(found.StartDate) {
+			found = subscription
+		}
+	}
+	return found
+}
+
+// usage is the usage of a subject in the month of now, created empty
+func (um *UsageManagement) usage(kind, id string, now time.Time) *Usage {
+	period := now.Format(usagePeriod)
+	key := usageKey(kind, id, period)
+	usage, exists := um.Manager.Usage[key]
+	if !exists {
+		if um.Manager.Usage == nil {
+			um.Manager.Usage = make(map[string]*Usage)
+		}
+		usage = &Usage{Subject: kind, SubjectID: id, Period: period}
+		um.Manager.Usage[key] = usage
+	}
+	return usage
+}
+
+// overLimit is the error of a subject over a limit, audited
+func (um *UsageManagement) overLimit(subject usageSubject, subscription *Subscription, limit string, now time.Time) error {
+	period := now.Format(usagePeriod)
+	um.Manager.logAuditEntry(AuditEntry{
+		Timestamp:  now,
+		UserID:     subject.ownerID,
+		Username:   um.Manager.getUsername(subject.ownerID),
+		Action:     "usage.limit",
+		Resource:   subject.kind,
+		ResourceID: subject.id,
+		Details:    map[string]string{"plan": subscription.Plan, "limit": limit, "period": period},
+		Success:    false,
+		Severity:   "medium",
+		Category:   "access",
+	})
+	owner := "their"
+	if subject.kind == UsageProject {
+		owner = "its owner's"
+	}
+	next := now.AddDate(0, 1, 1-now.Day()).Format("2006-01-02")
+	return fmt.Errorf("%s %s has used %s of %s %s plan in %s; upgrade the subscription or wait until %s",
+		subject.kind, subject.name, limit, owner, subscription.Plan, period, next)
+}
+
+// usageKey is the key of the usage of a subject in a period
+func usageKey(kind, id, period string) string {
+	return kind + "/" + id + "/" + period
+}
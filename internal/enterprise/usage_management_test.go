@@ -0,0 +1,105 @@
+package enterprise
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"panoptic/internal/logger"
+)
+
+// newUsageManagement has ana on a trial plan of two runs, 1 GB and three API
+// calls a month, owning the checkout project, and bo without a subscription
+func newUsageManagement(t *testing.T) *UsageManagement {
+	t.Helper()
+	em := NewEnterpriseManager(*logger.NewLogger(false))
+	em.StoragePath = t.TempDir()
+	em.Enabled = true
+	em.Users["ana"] = &User{ID: "u-ana", Username: "ana", Active: true}
+	em.Users["bo"] = &User{ID: "u-bo", Username: "bo", Active: true}
+	em.Projects["p-checkout"] = &Project{ID: "p-checkout", Name: "checkout", OwnerID: "u-ana", Status: "active"}
+	em.Subscriptions["s-old"] = &Subscription{ID: "s-old", UserID: "u-ana", Plan: "pro", Status: "active",
+		StartDate: time.Now().AddDate(-1, 0, 0), EndDate: time.Now().AddDate(0, 0, -1)}
+	em.Subscriptions["s-trial"] = &Subscription{ID: "s-trial", UserID: "u-ana", Plan: "trial", Status: "active",
+		StartDate: time.Now().AddDate(0, 0, -1), Limits: SubscriptionLimits{MaxTestRuns: 2, MaxStorageGB: 1, MaxAPICalls: 3}}
+	return NewUsageManagement(em)
+}
+
+func TestUsageManagement_Runs(t *testing.T) {
+	um := newUsageManagement(t)
+	ctx := context.Background()
+
+	require.NoError(t, um.CheckRun(ctx, "u-bo", "p-checkout"))
+	require.NoError(t, um.RecordRun(ctx, "u-bo", "p-checkout", 100, 1))
+	require.NoError(t, um.RecordRun(ctx, "u-bo", "p-checkout", 200, 1))
+	assert.NoError(t, um.CheckRun(ctx, "u-bo", ""), "bo's own runs are unlimited without a subscription")
+
+	next := time.Now().AddDate(0, 1, 1-time.Now().Day()).Format("2006-01-02")
+	period := time.Now().Format("2006-01")
+	err := um.CheckRun(ctx, "u-bo", "p-checkout")
+	assert.EqualError(t, err, "project checkout has used the 2 test run(s) of its owner's trial plan in "+period+"; upgrade the subscription or wait until "+next)
+	entry := um.Manager.AuditLog[len(um.Manager.AuditLog)-1]
+	assert.Equal(t, "usage.limit", entry.Action)
+	assert.Equal(t, "p-checkout", entry.ResourceID)
+
+	require.NoError(t, um.RecordRun(ctx, "u-ana", "", 1<<30, 1))
+	err = um.CheckRun(ctx, "u-ana", "")
+	assert.EqualError(t, err, "user ana has used the 1 GB of storage of their trial plan in "+period+"; upgrade the subscription or wait until "+next)
+
+	data, err := os.ReadFile(filepath.Join(um.Manager.StoragePath, "usage.json"))
+	require.NoError(t, err)
+	assert.Contains(t, string(data), `"user/u-bo/`+period+`"`)
+}
+
+func TestUsageManagement_APICalls(t *testing.T) {
+	um := newUsageManagement(t)
+	ctx := context.Background()
+	for i := 0; i < 3; i++ {
+		require.NoError(t, um.RecordAPICall(ctx, "u-ana"))
+	}
+	assert.ErrorContains(t, um.RecordAPICall(ctx, "u-ana"), "user ana has used the 3 API call(s) of their trial plan")
+
+	// API keys are metered as they're validated
+	am := NewAPIManagement(um.Manager)
+	um.Manager.APIKeys["k-ana"] = &APIKey{ID: "k-ana", UserID: "u-ana", Key: "pk_ana", Secret: "s3cret", Enabled: true}
+	_, err := am.ValidateAPIKey(ctx, "pk_ana", "s3cret")
+	assert.ErrorContains(t, err, "of their trial plan")
+	um.Manager.APIKeys["k-bo"] = &APIKey{ID: "k-bo", UserID: "u-bo", Key: "pk_bo", Secret: "s3cret", Enabled: true}
+	_, err = am.ValidateAPIKey(ctx, "pk_bo", "s3cret")
+	require.NoError(t, err)
+	assert.Equal(t, 1, um.usage(UsageUser, "u-bo", time.Now()).APICalls)
+}
+
+func TestUsageManagement_Report(t *testing.T) {
+	um := newUsageManagement(t)
+	ctx := context.Background()
+	require.NoError(t, um.RecordRun(ctx, "u-bo", "p-checkout", 2048, 7.5))
+	require.NoError(t, um.RecordAPICall(ctx, "u-ana"))
+
+	report, err := um.Report(ctx, UsageReportRequest{})
+	require.NoError(t, err)
+	require.Len(t, report.Entries, 3)
+	assert.Equal(t, []string{"ana", "bo", "checkout"}, []string{report.Entries[0].Name, report.Entries[1].Name, report.Entries[2].Name})
+	assert.Equal(t, "trial", report.Entries[0].Plan)
+	assert.Equal(t, 1, report.Entries[0].APICalls)
+	assert.Nil(t, report.Entries[1].Limits, "bo has no subscription")
+	assert.Equal(t, UsageReportEntry{Subject: UsageProject, SubjectID: "p-checkout", Name: "checkout", Plan: "trial",
+		TestRuns: 1, NodeMinutes: 7.5, StorageBytes: 2048, Limits: &um.Manager.Subscriptions["s-trial"].Limits}, report.Entries[2])
+
+	ei := &EnterpriseIntegration{Manager: um.Manager, UsageManagement: um, Initialized: true}
+	result, err := ei.ExecuteEnterpriseAction(ctx, "usage_report", map[string]interface{}{"project": "checkout"})
+	require.NoError(t, err)
+	assert.Len(t, result.(*UsageReport).Entries, 1)
+
+	report, err = um.Report(ctx, UsageReportRequest{User: "bo", Period: "2020-01"})
+	require.NoError(t, err)
+	assert.Zero(t, report.Entries[0].TestRuns)
+	_, err = um.Report(ctx, UsageReportRequest{Period: "January"})
+	assert.EqualError(t, err, `invalid period "January"; use YYYY-MM`)
+	_, err = um.Report(ctx, UsageReportRequest{User: "eve"})
+	assert.EqualError(t, err, "user not found: eve")
+}
@@ -78,6 +78,7 @@ func (um *UserManagement) CreateUser(ctx context.Context, req CreateUserRequest)
 		UpdatedAt: time.Now(),
 		Active:    true,
 		Metadata:  req.Metadata,
+		MustChangePassword: req.MustChangePassword,
 	}
 
 	// Store user
@@ -128,6 +129,13 @@ func (um *UserManagement) CreateUser(ctx context.Context, req CreateUserRequest)
 		um.Logger.Errorf("Failed to save user data: %v", err)
 	}
 
+	um.Manager.emitEvent(ctx, EventUserCreated, map[string]interface{}{
+		"user_id":  user.ID,
+		"username": user.Username,
+		"email":    user.Email,
+		"role":     user.Role,
+	})
+
 	um.Logger.Infof("User created successfully: %s (%s)", req.Username, req.Email)
 	return user, nil
 }
@@ -171,8 +179,10 @@ func (um *UserManagement) UpdateUser(ctx context.Context, identifier string, req
 		user.Email = *req.Email
 	}
 	if req.Role != nil && *req.Role != "" {
+		before := um.Manager.permissionSnapshot()
 		user.Role = *req.Role
 		user.Permissions = um.getRolePermissions(*req.Role)
+		um.Manager.revokeDowngraded(before)
 	}
 	if req.TeamIDs != nil {
 		user.TeamIDs = req.TeamIDs
@@ -303,6 +313,21 @@ func (um *UserManagement) AuthenticateUser(ctx context.Context, username, passwo
 		return nil, fmt.Errorf("invalid credentials")
 	}
 
+	if user.MustChangePassword {
+		um.Manager.logAuditEntry(AuditEntry{
+			Timestamp:  time.Now(),
+			UserID:     user.ID,
+			Username:   username,
+			Action:     "auth.login",
+			Resource:   "user",
+			Details:    map[string]string{"reason": "password_change_required"},
+			Success:    false,
+			Severity:   "low",
+			Category:   "auth",
+		})
+		return nil, fmt.Errorf("user %s must change their password before signing in", user.Username)
+	}
+
 	// Create session
 	session := &Session{
 		ID:        um.Manager.generateID(),
@@ -391,6 +416,7 @@ func (um *UserManagement) ValidateSession(ctx context.Context, sessionID string)
 		delete(um.Manager.Sessions, sessionID)
 		return nil, fmt.Errorf("session expired")
 	}
+	session.LastSeen = time.Now()
 
 	return session, nil
 }
@@ -454,6 +480,7 @@ type CreateUserRequest struct {
 	TeamIDs   []string          `json:"team_ids"`
 	ProjectIDs []string         `json:"project_ids"`
 	Metadata  map[string]string `json:"metadata"`
+	MustChangePassword bool     `json:"must_change_password"` // a temporary password
 }
 
 type UpdateUserRequest struct {
@@ -509,11 +536,7 @@ func (um *UserManagement) validateCreateUserRequest(req CreateUserRequest) error
 }
 
 func (um *UserManagement) getRolePermissions(roleName string) map[string]bool {
-	role, exists := um.Manager.Roles[roleName]
-	if !exists {
-		return map[string]bool{}
-	}
-	return role.Permissions
+	return um.Manager.resolveRole(roleName)
 }
 
 func (um *UserManagement) getAdminCount() int {
@@ -554,12 +577,16 @@ func (um *UserManagement) generateSessionToken() string {
 }
 
 func (um *UserManagement) getClientIP(ctx context.Context) string {
-	// In a real implementation, extract from request context
+	if client := clientFrom(ctx); client.IPAddress != "" {
+		return client.IPAddress
+	}
 	return "127.0.0.1"
 }
 
 func (um *UserManagement) getUserAgent(ctx context.Context) string {
-	// In a real implementation, extract from request context
+	if client := clientFrom(ctx); client.UserAgent != "" {
+		return client.UserAgent
+	}
 	return "panoptic-client"
 }
 
@@ -574,13 +601,18 @@ func (um *UserManagement) getUsername(userID string) string {
 
 func (em *EnterpriseManager) logAuditEntry(entry AuditEntry) {
 	entry.ID = em.generateID()
+	em.chainAuditEntry(&entry)
 	em.AuditLog = append(em.AuditLog, entry)
 
 	// Trim audit log if too large
-	if len(em.AuditLog) > 10000 {
+	if len(em.AuditLog) > maxRecentAuditEntries {
 		em.AuditLog = em.AuditLog[1000:] // Keep last 9000 entries
 	}
 
+	if err := em.auditStore().Append(entry); err != nil {
+		em.Logger.Errorf("Failed to store audit entry %s: %v", entry.Action, err)
+	}
+
 	// Log to SIEM if configured
 	if em.Config.Integration.SIEM.Enabled {
 		em.sendToSIEM(entry)
@@ -0,0 +1,288 @@
+package enterprise
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	"panoptic/internal/logger"
+)
+
+// Webhook events
+const (
+	EventUserCreated      = "user.created"
+	EventProjectArchived  = "project.archived"
+	EventRunCompleted     = "run.completed"
+	EventComplianceFailed = "compliance.failed"
+)
+
+// Webhook delivery statuses
+const (
+	DeliveryDelivered = "delivered"
+	DeliveryFailed    = "failed"
+)
+
+// Webhook delivery defaults
+const (
+	DefaultWebhookAttempts = 3
+	DefaultWebhookBackoff  = time.Second
+	DefaultWebhookTimeout  = 10 * time.Second
+)
+
+// DefaultDeliveryPageSize is the page size of the webhook delivery log
+const DefaultDeliveryPageSize = 50
+
+// maxWebhookDeliveries bounds the delivery log
+const maxWebhookDeliveries = 1000
+
+// WebhookEvent is the JSON body of a webhook
+type WebhookEvent struct {
+	ID           string                 `json:"id"`
+	Type         string                 `json:"type"`
+	Timestamp    time.Time              `json:"timestamp"`
+	Organization string                 `json:"organization,omitempty"`
+	Data         map[string]interface{} `json:"data"`
+}
+
+// WebhookDelivery is the outcome of sending an event to an endpoint
+type WebhookDelivery struct {
+	ID          string     `json:"id"`
+	EventID     string     `json:"event_id"`
+	Event       string     `json:"event"`
+	Endpoint    string     `json:"endpoint"`
+	Status      string     `json:"status"` // delivered, failed
+	Attempts    int        `json:"attempts"`
+	StatusCode  int        `json:"status_code,omitempty"` // of the last attempt
+	Error       string     `json:"error,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+	DeliveredAt *time.Time `json:"delivered_at,omitempty"`
+	Payload     string     `json:"payload"` // the body, for redelivery
+}
+
+// WebhookManagement sends lifecycle events to the endpoints of
+// integration.webhook, signed with its secret and retried with backoff,
+// and keeps a log of the deliveries
+type WebhookManagement struct {
+	Manager *EnterpriseManager
+	Logger  logger.Logger
+}
+
+// NewWebhookManagement creates new webhook management handler
+func NewWebhookManagement(manager *EnterpriseManager) *WebhookManagement {
+	return &WebhookManagement{
+		Manager: manager,
+		Logger:  manager.Logger,
+	}
+}
+
+// Emit sends an event to every endpoint when webhooks are enabled and it's
+// one of their events, an empty list meaning all of them. Failed deliveries
+// are logged, not returned, so they never fail what emitted the event.
+func (wm *WebhookManagement) Emit(ctx context.Context, eventType string, data map[string]interface{}) []WebhookDelivery {
+	config := wm.Manager.Config.Integration.Webhook
+	if !config.Enabled || len(config.Endpoints) == 0 {
+		return nil
+	}
+	if len(config.Events) > 0 && !contains(config.Events, eventType) {
+		return nil
+	}
+
+	event := WebhookEvent{
+		ID:           uuid.New().String(),
+		Type:         eventType,
+		Timestamp:    time.Now(),
+		Organization: wm.Manager.Config.OrganizationName,
+		Data:         data,
+	}
+	payload, err := json.Marshal(event)
+	if err != nil {
+		wm.Logger.Errorf("Failed to encode webhook event %s: %v", eventType, err)
+		return nil
+	}
+
+	deliveries := make([]WebhookDelivery, 0, len(config.Endpoints))
+	for _, endpoint := range config.Endpoints {
+		delivery := WebhookDelivery{
+			ID:        uuid.New().String(),
+			EventID:   event.ID,
+			Event:     eventType,
+			Endpoint:  endpoint,
+			CreatedAt: event.Timestamp,
+			Payload:   string(payload),
+		}
+		wm.deliver(ctx, &delivery)
+		deliveries = append(deliveries, delivery)
+	}
+	wm.Manager.WebhookDeliveries = append(wm.Manager.WebhookDeliveries, deliveries...)
+	wm.save()
+	return deliveries
+}
+
+// Redeliver sends a logged delivery's event again, with fresh attempts
+func (wm *WebhookManagement) Redeliver(ctx context.Context, id string) (*WebhookDelivery, error) {
+	for i := range wm.Manager.WebhookDeliveries {
+		delivery := &wm.Manager.WebhookDeliveries[i]
+		if delivery.ID != id {
+			continue
+		}
+		delivery.Attempts, delivery.StatusCode, delivery.Error = 0, 0, ""
+		wm.deliver(ctx, delivery)
+		wm.save()
+		result := *delivery
+		return &result, nil
+	}
+	return nil, fmt.Errorf("webhook delivery not found: %s", id)
+}
+
+// ListDeliveries lists the delivery log, newest first
+func (wm *WebhookManagement) ListDeliveries(ctx context.Context, req ListDeliveriesRequest) *ListDeliveriesResponse {
+	var deliveries []WebhookDelivery
+	for _, delivery := range wm.Manager.WebhookDeliveries {
+		if (req.Event == "" || delivery.Event == req.Event) &&
+			(req.Status == "" || delivery.Status == req.Status) &&
+			(req.Endpoint == "" || delivery.Endpoint == req.Endpoint) {
+			deliveries = append(deliveries, delivery)
+		}
+	}
+	sort.SliceStable(deliveries, func(i, j int) bool { return deliveries[i].CreatedAt.After(deliveries[j].CreatedAt) })
+
+	page, pageSize := max(req.Page, 1), req.PageSize
+	if pageSize < 1 {
+		pageSize = DefaultDeliveryPageSize
+	}
+	start := min((page-1)*pageSize, len(deliveries))
+	end := min(start+pageSize, len(deliveries))
+	return &ListDeliveriesResponse{
+		Deliveries: append([]WebhookDelivery{}, deliveries[start:end]...),
+		Total:      len(deliveries),
+		Page:       page,
+		PageSize:   pageSize,
+	}
+}
+
+// Request types
+
+type ListDeliveriesRequest struct {
+	Event    string `json:"event,omitempty"`
+	Status   string `json:"status,omitempty"`
+	Endpoint string `json:"endpoint,omitempty"`
+	Page     int    `json:"page"`
+	PageSize int    `json:"page_size"`
+}
+
+type ListDeliveriesResponse struct {
+	Deliveries []WebhookDelivery `json:"deliveries"`
+	Total      int               `json:"total"`
+	Page       int               `json:"page"`
+	PageSize   int               `json:"page_size"`
+}
+
+// Helper methods
+
+// deliver posts the payload, backing off between attempts until one gets a
+// 2xx response or the attempts run out
+func (wm *WebhookManagement) deliver(ctx context.Context, delivery *WebhookDelivery) {
+	config := wm.Manager.Config.Integration.Webhook
+	attempts := config.MaxAttempts
+	if attempts <= 0 {
+		attempts = DefaultWebhookAttempts
+	}
+	backoff := config.Backoff
+	if backoff <= 0 {
+		backoff = DefaultWebhookBackoff
+	}
+	timeout := config.Timeout
+	if timeout <= 0 {
+		timeout = DefaultWebhookTimeout
+	}
+	client := &http.Client{Timeout: timeout}
+
+	for attempt := 1; attempt <= attempts; attempt++ {
+		if attempt > 1 {
+			select {
+			case <-ctx.Done():
+				delivery.Status, delivery.Error = DeliveryFailed, ctx.Err().Error()
+				return
+			case <-time.After(backoff):
+			}
+			backoff *= 2
+		}
+		delivery.Attempts++
+		code, err := wm.post(ctx, client, delivery)
+		delivery.StatusCode = code
+		if err == nil {
+			now := time.Now()
+			delivery.Status, delivery.Error, delivery.DeliveredAt = DeliveryDelivered, "", &now
+			return
+		}
+		delivery.Status, delivery.Error = DeliveryFailed, err.Error()
+	}
+	wm.Logger.Warnf("Webhook %s to %s failed after %d attempt(s): %s", delivery.Event, delivery.Endpoint, delivery.Attempts, delivery.Error)
+}
+
+// post sends one attempt, signed over the timestamp and body
+func (wm *WebhookManagement) post(ctx context.Context, client *http.Client, delivery *WebhookDelivery) (int, error) {
+	config := wm.Manager.Config.Integration.Webhook
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, delivery.Endpoint, bytes.NewReader([]byte(delivery.Payload)))
+	if err != nil {
+		return 0, err
+	}
+	for name, value := range config.Headers {
+		req.Header.Set(name, os.ExpandEnv(value))
+	}
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "Panoptic-Webhook")
+	req.Header.Set("X-Panoptic-Event", delivery.Event)
+	req.Header.Set("X-Panoptic-Delivery", delivery.ID)
+	req.Header.Set("X-Panoptic-Timestamp", timestamp)
+	if secret := os.ExpandEnv(config.Secret); secret != "" {
+		req.Header.Set("X-Panoptic-Signature", "sha256="+SignWebhook(secret, timestamp, []byte(delivery.Payload)))
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64*1024))
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp.StatusCode, fmt.Errorf("status %d", resp.StatusCode)
+	}
+	return resp.StatusCode, nil
+}
+
+// save keeps the most recent deliveries of the log
+func (wm *WebhookManagement) save() {
+	if n := len(wm.Manager.WebhookDeliveries); n > maxWebhookDeliveries {
+		wm.Manager.WebhookDeliveries = wm.Manager.WebhookDeliveries[n-maxWebhookDeliveries:]
+	}
+	if err := wm.Manager.saveJSON("webhook_deliveries.json", wm.Manager.WebhookDeliveries); err != nil {
+		wm.Logger.Errorf("Failed to save webhook deliveries: %v", err)
+	}
+}
+
+// SignWebhook is the hex HMAC-SHA256 of "<timestamp>.<body>" that receivers
+// compare with the X-Panoptic-Signature header
+func SignWebhook(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp + "."))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// emitEvent sends a lifecycle event to the webhooks
+func (em *EnterpriseManager) emitEvent(ctx context.Context, eventType string, data map[string]interface{}) {
+	NewWebhookManagement(em).Emit(ctx, eventType, data)
+}
@@ -0,0 +1,157 @@
+package enterprise
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"panoptic/internal/logger"
+)
+
+// webhookReceiver records the webhooks it gets, failing the first fail
+// requests of each event
+type webhookReceiver struct {
+	mu       sync.Mutex
+	fail     int
+	requests []*http.Request
+	bodies   [][]byte
+}
+
+func (r *webhookReceiver) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	body, _ := io.ReadAll(req.Body)
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.requests = append(r.requests, req)
+	r.bodies = append(r.bodies, body)
+	if r.fail > 0 {
+		r.fail--
+		w.WriteHeader(http.StatusServiceUnavailable)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func newWebhookManager(t *testing.T, endpoints ...string) *EnterpriseManager {
+	t.Helper()
+	em := NewEnterpriseManager(*logger.NewLogger(false))
+	em.StoragePath = t.TempDir()
+	em.Enabled = true
+	em.Config.OrganizationName = "Shop"
+	em.Config.Integration.Webhook = WebhookConfig{
+		Enabled:   true,
+		Endpoints: endpoints,
+		Secret:    "whsec",
+		Headers:   map[string]string{"X-Team": "qa"},
+		Backoff:   time.Millisecond,
+	}
+	require.NoError(t, em.initializeDefaultRoles())
+	return em
+}
+
+func TestWebhookManagement_Emit(t *testing.T) {
+	receiver := &webhookReceiver{fail: 1}
+	server := httptest.NewServer(receiver)
+	defer server.Close()
+	em := newWebhookManager(t, server.URL+"/hooks")
+	wm := NewWebhookManagement(em)
+
+	deliveries := wm.Emit(context.Background(), EventUserCreated, map[string]interface{}{"username": "ana"})
+	require.Len(t, deliveries, 1)
+	assert.Equal(t, DeliveryDelivered, deliveries[0].Status)
+	assert.Equal(t, 2, deliveries[0].Attempts, "the failed first attempt is retried")
+	assert.Equal(t, http.StatusNoContent, deliveries[0].StatusCode)
+
+	require.Len(t, receiver.requests, 2)
+	req, body := receiver.requests[1], receiver.bodies[1]
+	assert.Equal(t, "user.created", req.Header.Get("X-Panoptic-Event"))
+	assert.Equal(t, deliveries[0].ID, req.Header.Get("X-Panoptic-Delivery"))
+	assert.Equal(t, "qa", req.Header.Get("X-Team"))
+	assert.Equal(t, "sha256="+SignWebhook("whsec", req.Header.Get("X-Panoptic-Timestamp"), body), req.Header.Get("X-Panoptic-Signature"))
+	var event WebhookEvent
+	require.NoError(t, json.Unmarshal(body, &event))
+	assert.Equal(t, "user.created", event.Type)
+	assert.Equal(t, "Shop", event.Organization)
+	assert.Equal(t, "ana", event.Data["username"])
+
+	// Events not listed aren't sent
+	em.Config.Integration.Webhook.Events = []string{EventRunCompleted}
+	assert.Empty(t, wm.Emit(context.Background(), EventUserCreated, nil))
+	em.Config.Integration.Webhook.Enabled = false
+	assert.Empty(t, wm.Emit(context.Background(), EventRunCompleted, nil))
+}
+
+func TestWebhookManagement_FailedDelivery(t *testing.T) {
+	receiver := &webhookReceiver{fail: 10}
+	server := httptest.NewServer(receiver)
+	defer server.Close()
+	em := newWebhookManager(t, server.URL)
+	em.Config.Integration.Webhook.MaxAttempts = 2
+	wm := NewWebhookManagement(em)
+
+	deliveries := wm.Emit(context.Background(), EventProjectArchived, map[string]interface{}{"name": "legacy"})
+	require.Len(t, deliveries, 1)
+	assert.Equal(t, DeliveryFailed, deliveries[0].Status)
+	assert.Equal(t, 2, deliveries[0].Attempts)
+	assert.Equal(t, "status 503", deliveries[0].Error)
+
+	receiver.fail = 0
+	redelivered, err := wm.Redeliver(context.Background(), deliveries[0].ID)
+	require.NoError(t, err)
+	assert.Equal(t, DeliveryDelivered, redelivered.Status)
+	assert.Equal(t, 1, redelivered.Attempts)
+	assert.Equal(t, receiver.bodies[0], receiver.bodies[2], "the same event is sent again")
+	_, err = wm.Redeliver(context.Background(), "d-none")
+	assert.EqualError(t, err, "webhook delivery not found: d-none")
+
+	// The log is kept with the rest of the enterprise data
+	loaded := NewEnterpriseManager(*logger.NewLogger(false))
+	loaded.StoragePath = em.StoragePath
+	require.NoError(t, loaded.loadData())
+	require.Len(t, loaded.WebhookDeliveries, 1)
+	assert.Equal(t, DeliveryDelivered, loaded.WebhookDeliveries[0].Status)
+}
+
+func TestWebhookManagement_LifecycleEvents(t *testing.T) {
+	receiver := &webhookReceiver{}
+	server := httptest.NewServer(receiver)
+	defer server.Close()
+	em := newWebhookManager(t, server.URL)
+	ctx := context.Background()
+
+	_, err := NewUserManagement(em).CreateUser(ctx, CreateUserRequest{Username: "ana", Email: "ana@shop.example",
+		FirstName: "Ana", LastName: "Lee", Password: "anapass1", Role: "developer"})
+	require.NoError(t, err)
+	ana := em.Users["ana"]
+	em.Projects["p-shop"] = &Project{ID: "p-shop", Name: "shop", OwnerID: ana.ID, Status: "active"}
+	require.NoError(t, NewRunManagement(em).RecordRun(ctx, RunRecord{ID: "run-1", ProjectID: "p-shop", Total: 2, Passed: 2, Success: true}))
+	require.NoError(t, NewProjectManagement(em).DeleteProject(ctx, "p-shop"))
+
+	var events []string
+	for _, delivery := range em.WebhookDeliveries {
+		events = append(events, delivery.Event)
+	}
+	assert.Equal(t, []string{EventUserCreated, EventRunCompleted, EventProjectArchived}, events)
+
+	// The delivery log is queried through the webhook_deliveries action
+	ei := &EnterpriseIntegration{Manager: em, WebhookManagement: NewWebhookManagement(em), Initialized: true}
+	result, err := ei.ExecuteEnterpriseAction(ctx, "webhook_deliveries", map[string]interface{}{"event": "run.completed"})
+	require.NoError(t, err)
+	list := result.(*ListDeliveriesResponse)
+	require.Equal(t, 1, list.Total)
+	var event WebhookEvent
+	require.NoError(t, json.Unmarshal([]byte(list.Deliveries[0].Payload), &event))
+	assert.Equal(t, "run-1", event.Data["run_id"])
+
+	result, err = ei.ExecuteEnterpriseAction(ctx, "webhook_deliveries", map[string]interface{}{"page_size": "2"})
+	require.NoError(t, err)
+	list = result.(*ListDeliveriesResponse)
+	assert.Equal(t, 3, list.Total)
+	assert.Len(t, list.Deliveries, 2)
+}
@@ -0,0 +1,159 @@
+package executor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"panoptic/internal/alert"
+	"panoptic/internal/config"
+)
+
+// maxAlertFailures bounds the failures listed in an incident's details
+const maxAlertFailures = 20
+
+// Alert raises an incident for each of settings.alerts whose condition the
+// run meets and resolves incidents opened by earlier runs that no longer meet
+// it. Open incidents are tracked in <output>/alert_state.json; the dedup key
+// makes repeated failing runs update one incident. Errors are returned together.
+func (e *Executor) Alert(ctx context.Context) error {
+	alerts := e.config.Settings.Alerts
+	if len(alerts) == 0 {
+		return nil
+	}
+
+	statePath := filepath.Join(e.outputDir, alert.StateFileName)
+	state, err := alert.LoadState(statePath)
+	if err != nil {
+		return err
+	}
+
+	var errs []error
+	for i, a := range alerts {
+		sink := newAlertSink(a)
+		key := e.alertDedupKey(a)
+		stateKey := a.Type + "/" + key
+		reason := e.alertReason(a)
+
+		if reason == "" {
+			if !state.Open[stateKey] {
+				continue
+			}
+			if err := sink.Resolve(ctx, key, "The next Panoptic run passed"); err != nil {
+				errs = append(errs, fmt.Errorf("alert %d (%s): %w", i, a.Type, err))
+				continue
+			}
+			delete(state.Open, stateKey)
+			e.logger.Infof("Resolved %s incident %s", a.Type, key)
+			continue
+		}
+
+		if err := sink.Trigger(ctx, e.alertEvent(a, key, reason)); err != nil {
+			errs = append(errs, fmt.Errorf("alert %d (%s): %w", i, a.Type, err))
+			continue
+		}
+		state.Open[stateKey] = true
+		e.logger.Warnf("Raised %s incident %s: %s", a.Type, key, reason)
+	}
+
+	if err := state.Save(statePath); err != nil {
+		errs = append(errs, err)
+	}
+	return errors.Join(errs...)
+}
+
+func newAlertSink(a config.AlertSettings) alert.Sink {
+	if a.Type == "opsgenie" {
+		return alert.NewOpsgenie(os.ExpandEnv(a.APIKey), a.URL, a.Priority)
+	}
+	return alert.NewPagerDuty(os.ExpandEnv(a.RoutingKey), a.URL)
+}
+
+func (e *Executor) alertDedupKey(a config.AlertSettings) string {
+	if a.DedupKey != "" {
+		return a.DedupKey
+	}
+	return "panoptic/" + e.config.Name
+}
+
+// alertReason describes why the run should alert, or is empty when it
+// shouldn't. Quarantined failures and warnings never alert.
+func (e *Executor) alertReason(a config.AlertSettings) string {
+	summary := e.Summary()
+	if summary.Failed == 0 {
+		return ""
+	}
+
+	var reasons []string
+	var critical []string
+	for _, r := range e.results {
+		if countsAsFailure(r) && hasAnyTag(r.Tags, a.CriticalTags) {
+			critical = append(critical, r.AppName)
+		}
+	}
+	if len(critical) > 0 {
+		reasons = append(reasons, "critical app(s) failed: "+strings.Join(critical, ", "))
+	}
+	if percent := summary.FailurePercent(); percent > a.FailureThreshold {
+		reason := fmt.Sprintf("%d of %d app(s) failed (%.1f%%)", summary.Failed, summary.Total, percent)
+		if a.FailureThreshold > 0 {
+			reason += fmt.Sprintf(", above the %.1f%% threshold", a.FailureThreshold)
+		}
+		reasons = append(reasons, reason)
+	}
+	return strings.Join(reasons, "; ")
+}
+
+func (e *Executor) alertEvent(a config.AlertSettings, key, reason string) alert.Event {
+	summary := e.Summary()
+	var failures []string
+	for _, r := range e.results {
+		if !countsAsFailure(r) {
+			continue
+		}
+		if len(failures) == maxAlertFailures {
+			failures = append(failures, fmt.Sprintf("... and %d more", summary.Failed-maxAlertFailures))
+			break
+		}
+		failures = append(failures, r.AppName+": "+r.Error)
+	}
+
+	source, err := os.Hostname()
+	if err != nil || source == "" {
+		source = "panoptic"
+	}
+	return alert.Event{
+		DedupKey: key,
+		Summary:  fmt.Sprintf("Panoptic %s: %s", e.config.Name, reason),
+		Source:   source,
+		Severity: a.Severity,
+		Details: map[string]interface{}{
+			"run_id":      e.runID,
+			"total":       summary.Total,
+			"failed":      summary.Failed,
+			"quarantined": summary.Quarantined,
+			"failures":    failures,
+		},
+		ReportURL: strings.ReplaceAll(a.ReportURL, "{run_id}", e.runID),
+	}
+}
+
+// countsAsFailure reports whether a result failed the run, i.e. it failed
+// without being quarantined or having warning severity
+func countsAsFailure(r TestResult) bool {
+	return !r.Success && !r.Quarantined && r.Severity != config.SeverityWarning
+}
+
+func hasAnyTag(tags, want []string) bool {
+	for _, t := range tags {
+		for _, w := range want {
+			if t == w {
+				return true
+			}
+		}
+	}
+	return false
+}
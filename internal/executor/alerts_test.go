@@ -0,0 +1,77 @@
+package executor
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"panoptic/internal/config"
+	"panoptic/internal/logger"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestExecutor_Alert_TriggerResolve tests triggering on failure, updating the
+// same incident and resolving it once when runs pass again
+func TestExecutor_Alert_TriggerResolve(t *testing.T) {
+	var events []map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]interface{}
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		events = append(events, body)
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer server.Close()
+	t.Setenv("PANOPTIC_TEST_PD", "routing-key")
+
+	cfg := &config.Config{Name: "nightly", Settings: config.Settings{Alerts: []config.AlertSettings{
+		{Type: "pagerduty", RoutingKey: "${PANOPTIC_TEST_PD}", URL: server.URL, ReportURL: "https://ci.example.com/{run_id}"},
+	}}}
+	dir := t.TempDir()
+	run := func(success bool) {
+		executor := NewExecutor(cfg, dir, logger.NewLogger(false))
+		executor.SetRunID("run-1")
+		executor.results = []TestResult{{AppName: "Shop", Success: success, Error: map[bool]string{false: "boom"}[success]}}
+		require.NoError(t, executor.Alert(context.Background()))
+	}
+
+	run(true)
+	assert.Empty(t, events, "nothing to resolve before an incident was raised")
+	run(false)
+	run(false)
+	run(true)
+	run(true)
+	require.Len(t, events, 3)
+	assert.Equal(t, "trigger", events[0]["event_action"])
+	assert.Equal(t, "routing-key", events[0]["routing_key"])
+	assert.Equal(t, "panoptic/nightly", events[0]["dedup_key"])
+	assert.Equal(t, "Panoptic nightly: 1 of 1 app(s) failed (100.0%)", events[0]["payload"].(map[string]interface{})["summary"])
+	assert.Equal(t, "https://ci.example.com/run-1", events[0]["links"].([]interface{})[0].(map[string]interface{})["href"])
+	assert.Equal(t, events[0]["dedup_key"], events[1]["dedup_key"], "repeated failures update one incident")
+	assert.Equal(t, "resolve", events[2]["event_action"])
+}
+
+// TestExecutor_AlertReason tests the threshold, critical tags and failures
+// that never alert
+func TestExecutor_AlertReason(t *testing.T) {
+	executor := NewExecutor(&config.Config{Name: "nightly"}, t.TempDir(), logger.NewLogger(false))
+	executor.results = []TestResult{
+		{AppName: "Checkout", Tags: []string{"critical"}, Severity: config.SeverityError},
+		{AppName: "Blog", Success: true}, {AppName: "Docs", Success: true}, {AppName: "Help", Success: true},
+		{AppName: "Flaky", Tags: []string{"critical"}, Quarantined: true},
+		{AppName: "A11y", Tags: []string{"critical"}, Severity: config.SeverityWarning},
+	}
+
+	assert.Equal(t, "1 of 6 app(s) failed (16.7%)", executor.alertReason(config.AlertSettings{}))
+	assert.Empty(t, executor.alertReason(config.AlertSettings{FailureThreshold: 20}))
+	assert.Equal(t, "critical app(s) failed: Checkout",
+		executor.alertReason(config.AlertSettings{FailureThreshold: 20, CriticalTags: []string{"critical"}}))
+	assert.Equal(t, "critical app(s) failed: Checkout; 1 of 6 app(s) failed (16.7%), above the 10.0% threshold",
+		executor.alertReason(config.AlertSettings{FailureThreshold: 10, CriticalTags: []string{"critical"}}))
+
+	executor.results = executor.results[1:]
+	assert.Empty(t, executor.alertReason(config.AlertSettings{CriticalTags: []string{"critical"}}), "quarantined and warning failures never alert")
+}
@@ -0,0 +1,87 @@
+package executor
+
+import (
+	"fmt"
+	"image/color"
+	"path/filepath"
+	"strings"
+
+	"panoptic/internal/platforms"
+	"panoptic/internal/vision"
+)
+
+// AnnotatedScreenshot is a copy of a screenshot with the regions an action
+// targeted or found at fault outlined and labelled; the HTML report shows it
+// in place of the original
+type AnnotatedScreenshot struct {
+	Screenshot string   `json:"screenshot"`
+	Path       string   `json:"path"`
+	Labels     []string `json:"labels"`
+}
+
+// annotatedPath is the annotated copy's path beside the screenshot
+func annotatedPath(screenshot string) string {
+	ext := filepath.Ext(screenshot)
+	return strings.TrimSuffix(screenshot, ext) + "_annotated.png"
+}
+
+// annotateScreenshot writes an annotated copy of a screenshot and records it
+// in the result. Annotating is best effort: a failure is logged and doesn't
+// fail the action.
+func (e *Executor) annotateScreenshot(result *TestResult, screenshot string, annotations []vision.Annotation) {
+	if len(annotations) == 0 {
+		return
+	}
+	path := annotatedPath(screenshot)
+	if err := vision.NewElementDetector(*e.componentLogger()).AnnotateFile(screenshot, path, annotations); err != nil {
+		e.logger.Warnf("Failed to annotate %s: %v", screenshot, err)
+		return
+	}
+	labels := make([]string, len(annotations))
+	for i, a := range annotations {
+		labels[i] = a.Label
+	}
+	result.Annotated = append(result.Annotated, AnnotatedScreenshot{Screenshot: screenshot, Path: path, Labels: labels})
+}
+
+// highlightAnnotations outlines the elements matching the selectors of a
+// screenshot action's highlight parameter, a selector or a list of them
+func highlightAnnotations(platform platforms.Platform, highlight interface{}) ([]vision.Annotation, error) {
+	var selectors []string
+	switch h := highlight.(type) {
+	case nil:
+		return nil, nil
+	case string:
+		selectors = []string{h}
+	case []interface{}:
+		for _, s := range h {
+			selector, ok := s.(string)
+			if !ok {
+				return nil, fmt.Errorf("highlight must be a selector or a list of selectors")
+			}
+			selectors = append(selectors, selector)
+		}
+	default:
+		return nil, fmt.Errorf("highlight must be a selector or a list of selectors")
+	}
+
+	annotations := make([]vision.Annotation, 0, len(selectors))
+	for _, selector := range selectors {
+		box, err := elementBox(platform, selector)
+		if err != nil {
+			return nil, fmt.Errorf("highlight %s: %w", selector, err)
+		}
+		annotations = append(annotations, vision.Annotation{Bounds: box, Label: selector, Color: vision.AnnotationBlue})
+	}
+	return annotations, nil
+}
+
+// severityColor colors the annotation of a finding by its severity
+func severityColor(severity string) color.RGBA {
+	switch severity {
+	case "high", "error", "critical":
+		return vision.AnnotationRed
+	default:
+		return vision.AnnotationOrange
+	}
+}
@@ -0,0 +1,71 @@
+package executor
+
+import (
+	"image"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"panoptic/internal/config"
+	"panoptic/internal/logger"
+	"panoptic/internal/vision"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestAnnotatedPath tests the annotated copy's name beside the screenshot
+func TestAnnotatedPath(t *testing.T) {
+	assert.Equal(t, "out/screenshots/Shop_home_1_annotated.png", annotatedPath("out/screenshots/Shop_home_1.png"))
+	assert.Equal(t, "shot_annotated.png", annotatedPath("shot.jpg"))
+}
+
+// TestExecutor_ScreenshotHighlight tests outlining elements on a screenshot
+// and the error for selectors on platforms without scripts
+func TestExecutor_ScreenshotHighlight(t *testing.T) {
+	executor := NewExecutor(&config.Config{}, t.TempDir(), logger.NewLogger(false))
+	result := &TestResult{StartTime: time.Now(), Metrics: map[string]interface{}{}}
+	recording := ""
+	platform := &evaluatingPlatform{
+		screenPlatform: &screenPlatform{MockPlatform: &MockPlatform{metrics: map[string]interface{}{}}, fixture: writeContrastScreen(t)},
+		boxes:          map[string]map[string]interface{}{"#total": {"x": 100.0, "y": 0.0, "width": 100.0, "height": 40.0}},
+	}
+
+	shot := config.Action{Name: "home", Type: "screenshot", Parameters: map[string]interface{}{"highlight": "#total"}}
+	require.NoError(t, executor.executeAction(platform, shot, config.AppConfig{Name: "Shop"}, result, &recording))
+	require.Len(t, result.Screenshots, 1)
+	require.Len(t, result.Annotated, 1)
+	assert.Equal(t, AnnotatedScreenshot{Screenshot: result.Screenshots[0], Path: annotatedPath(result.Screenshots[0]), Labels: []string{"#total"}}, result.Annotated[0])
+	assert.FileExists(t, result.Annotated[0].Path)
+
+	shot.Parameters["highlight"] = []interface{}{"#total", 3}
+	err := executor.executeAction(platform, shot, config.AppConfig{Name: "Shop"}, result, &recording)
+	assert.EqualError(t, err, "highlight must be a selector or a list of selectors")
+
+	shot.Parameters["highlight"] = "#total"
+	err = executor.executeAction(platform.screenPlatform, shot, config.AppConfig{Name: "Shop"}, result, &recording)
+	assert.ErrorContains(t, err, "highlight #total: selectors are only supported on web apps")
+}
+
+// TestExecutor_AnnotateContrastViolations tests that regions below the WCAG
+// minimum are outlined on an annotated copy
+func TestExecutor_AnnotateContrastViolations(t *testing.T) {
+	executor := NewExecutor(&config.Config{}, t.TempDir(), logger.NewLogger(false))
+	result := &TestResult{StartTime: time.Now(), Metrics: map[string]interface{}{}}
+	recording := ""
+	platform := &screenPlatform{MockPlatform: &MockPlatform{metrics: map[string]interface{}{}}, fixture: writeContrastScreen(t)}
+
+	check := config.Action{Name: "contrast", Type: "vision_contrast_check", Parameters: map[string]interface{}{"regions": []interface{}{
+		map[string]interface{}{"name": "hint", "x": 0, "y": 0, "width": 100, "height": 40},
+		map[string]interface{}{"name": "total", "x": 100, "y": 0, "width": 100, "height": 40},
+	}}}
+	require.NoError(t, executor.executeAction(platform, check, config.AppConfig{Name: "Shop"}, result, &recording))
+	require.Len(t, result.Annotated, 1)
+	assert.Equal(t, []string{"hint 4.48:1 < 4.5:1"}, result.Annotated[0].Labels, "only the violation is outlined")
+	assert.FileExists(t, result.Annotated[0].Path)
+
+	// A screenshot that can't be read is logged, not failed
+	result.Annotated = nil
+	executor.annotateScreenshot(result, filepath.Join(t.TempDir(), "missing.png"), []vision.Annotation{{Bounds: image.Rect(0, 0, 10, 10), Label: "x"}})
+	assert.Empty(t, result.Annotated)
+}
@@ -0,0 +1,57 @@
+package executor
+
+import (
+	"context"
+	"fmt"
+
+	"panoptic/internal/config"
+	"panoptic/internal/enterprise"
+)
+
+// approveRun holds a run whose apps target the protected_urls of the
+// enterprise compliance settings until an approver approved it. The first
+// run queues the request; the run after the approval executes it.
+func (e *Executor) approveRun(ctx context.Context, apps []config.AppConfig) error {
+	integration := e.getEnterpriseIntegration()
+	if integration == nil || !integration.Initialized {
+		return nil
+	}
+	protected := integration.ApprovalManagement.ProtectedURLs(e.targetURLs(apps))
+	if len(protected) == 0 {
+		return nil
+	}
+	approval, err := integration.ApprovalManagement.StartRun(ctx, enterprise.RunApprovalRequest{
+		Config:  e.config.Name,
+		Project: e.ProjectID(),
+		User:    getStringFromMap(e.config.Settings.Enterprise, "user"),
+		URLs:    protected,
+		RunID:   e.runID,
+	})
+	if err != nil {
+		return fmt.Errorf("run needs approval: %w", err)
+	}
+	e.logger.Infof("Run against protected URLs approved by %s (approval %s)", approval.DecidedBy, approval.ID)
+	return nil
+}
+
+// targetURLs are the URLs of the apps and of the navigate actions they run
+func (e *Executor) targetURLs(apps []config.AppConfig) []string {
+	seen := make(map[string]bool)
+	var urls []string
+	add := func(url string) {
+		if url != "" && !seen[url] {
+			seen[url] = true
+			urls = append(urls, url)
+		}
+	}
+	for _, app := range apps {
+		add(app.URL)
+		actions, _ := e.config.SelectActions(app, e.tagFilter)
+		for _, action := range actions {
+			if action.Type == "navigate" {
+				add(action.GetNavigateURL())
+			}
+		}
+	}
+	return urls
+}
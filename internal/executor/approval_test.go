@@ -0,0 +1,58 @@
+package executor
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"panoptic/internal/config"
+	"panoptic/internal/enterprise"
+	"panoptic/internal/logger"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExecutor_ApproveRun(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "enterprise.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(`enabled: true
+storage_path: `+filepath.Join(dir, "data")+`
+compliance:
+  require_approval: true
+  protected_urls: ["https://shop.example.com/*"]
+`), 0644))
+	cfg := &config.Config{
+		Name: "Shop",
+		Apps: []config.AppConfig{
+			{Name: "Staging", Type: "web", URL: "https://staging.example.com/"},
+			{Name: "Production", Type: "web", URL: "https://shop.example.com/", Actions: []config.Action{
+				{Name: "cart", Type: "navigate", URL: "https://shop.example.com/cart"},
+			}},
+		},
+		Settings: config.Settings{Enterprise: map[string]interface{}{"config_path": path, "user": "ana"}},
+	}
+	ctx := context.Background()
+
+	executor := NewExecutor(cfg, t.TempDir(), logger.NewLogger(false))
+	err := executor.approveRun(ctx, cfg.Apps)
+	assert.ErrorContains(t, err, "run needs approval: run of Shop targets protected URLs https://shop.example.com/, https://shop.example.com/cart and was queued for approval")
+	assert.NoError(t, executor.approveRun(ctx, cfg.Apps[:1]), "apps off the protected URLs run freely")
+
+	approvals := executor.getEnterpriseIntegration().ApprovalManagement
+	pending := approvals.ListApprovals(ctx, enterprise.ApprovalPending)
+	require.Len(t, pending, 1)
+	_, err = approvals.Decide(ctx, enterprise.DecideApprovalRequest{ID: pending[0].ID, Approver: "admin", Approve: true})
+	require.NoError(t, err)
+
+	// A later run, with its own enterprise integration, executes the approval
+	executor = NewExecutor(cfg, t.TempDir(), logger.NewLogger(false))
+	require.NoError(t, executor.approveRun(ctx, cfg.Apps))
+	executed := executor.getEnterpriseIntegration().ApprovalManagement.ListApprovals(ctx, enterprise.ApprovalExecuted)
+	require.Len(t, executed, 1)
+	assert.Equal(t, executor.runID, executed[0].RunID)
+
+	assert.NoError(t, NewExecutor(&config.Config{Apps: cfg.Apps}, t.TempDir(), logger.NewLogger(false)).approveRun(ctx, cfg.Apps),
+		"runs without enterprise management need no approval")
+}
@@ -0,0 +1,150 @@
+package executor
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"time"
+
+	"panoptic/internal/vision"
+)
+
+// BaselineFileName is the default name of the approved screen baseline
+const BaselineFileName = "baseline.json"
+
+// Baseline is the approved look of every screen: the perceptual hash of its
+// last capture when the baseline was updated
+type Baseline struct {
+	Updated time.Time        `json:"updated"`
+	RunID   string           `json:"run_id,omitempty"` // run the baseline was last updated from
+	Screens []BaselineScreen `json:"screens"`
+}
+
+// BaselineScreen is the approved look of one screen of an app
+type BaselineScreen struct {
+	App        string       `json:"app"`
+	Screen     string       `json:"screen"`
+	PHash      vision.PHash `json:"phash"`
+	Screenshot string       `json:"screenshot"` // capture the hash is of
+}
+
+// Baseline screen statuses
+const (
+	ScreenChanged = "changed"
+	ScreenNew     = "new"
+	ScreenMissing = "missing"
+)
+
+// ScreenDiff is a screen of a run that doesn't match the baseline
+type ScreenDiff struct {
+	App        string `json:"app"`
+	Screen     string `json:"screen"`
+	Status     string `json:"status"`     // changed, new or missing
+	Distance   int    `json:"distance"`   // bits the hashes differ in, for changed screens
+	Screenshot string `json:"screenshot"` // capture of the run; empty for missing screens
+	Baseline   string `json:"baseline"`   // approved capture; empty for new screens
+}
+
+// LoadBaseline reads a baseline; a missing file is an empty baseline
+func LoadBaseline(path string) (*Baseline, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return &Baseline{Screens: make([]BaselineScreen, 0)}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var b Baseline
+	if err := json.Unmarshal(data, &b); err != nil {
+		return nil, fmt.Errorf("invalid baseline %s: %w", path, err)
+	}
+	return &b, nil
+}
+
+// Save writes the baseline
+func (b *Baseline) Save(path string) error {
+	data, err := json.MarshalIndent(b, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal baseline: %w", err)
+	}
+	return os.WriteFile(path, append(data, '\n'), 0644)
+}
+
+// runScreens is the last capture of every screen of a run, by app and screen
+func runScreens(doc *ResultsDocument) map[[2]string]ScreenHash {
+	screens := make(map[[2]string]ScreenHash)
+	for _, r := range doc.Results {
+		for _, h := range r.ScreenHashes {
+			screens[[2]string{r.AppName, h.Screen}] = h
+		}
+	}
+	return screens
+}
+
+// Update approves the screens of a run, returning how many it added or
+// changed. Screens of apps the run didn't capture keep their look.
+func (b *Baseline) Update(doc *ResultsDocument) int {
+	index := make(map[[2]string]int, len(b.Screens))
+	for i, s := range b.Screens {
+		index[[2]string{s.App, s.Screen}] = i
+	}
+	updated := 0
+	for key, h := range runScreens(doc) {
+		screen := BaselineScreen{App: key[0], Screen: key[1], PHash: h.PHash, Screenshot: h.Screenshot}
+		i, ok := index[key]
+		switch {
+		case !ok:
+			b.Screens = append(b.Screens, screen)
+		case b.Screens[i].PHash != h.PHash:
+			b.Screens[i] = screen
+		default:
+			continue
+		}
+		updated++
+	}
+	sort.Slice(b.Screens, func(i, j int) bool {
+		if b.Screens[i].App != b.Screens[j].App {
+			return b.Screens[i].App < b.Screens[j].App
+		}
+		return b.Screens[i].Screen < b.Screens[j].Screen
+	})
+	b.Updated, b.RunID = time.Now().UTC(), doc.RunID
+	return updated
+}
+
+// Compare lists the screens of a run that differ from the baseline by more
+// than distance bits, that the baseline doesn't have, and that the run's
+// apps no longer captured
+func (b *Baseline) Compare(doc *ResultsDocument, distance int) []ScreenDiff {
+	screens := runScreens(doc)
+	apps := make(map[string]bool)
+	for _, r := range doc.Results {
+		apps[r.AppName] = true
+	}
+	diffs := make([]ScreenDiff, 0)
+	known := make(map[[2]string]bool, len(b.Screens))
+	for _, s := range b.Screens {
+		key := [2]string{s.App, s.Screen}
+		known[key] = true
+		h, ok := screens[key]
+		switch {
+		case !ok && apps[s.App]:
+			diffs = append(diffs, ScreenDiff{App: s.App, Screen: s.Screen, Status: ScreenMissing, Baseline: s.Screenshot})
+		case ok && h.PHash.Distance(s.PHash) > distance:
+			diffs = append(diffs, ScreenDiff{App: s.App, Screen: s.Screen, Status: ScreenChanged, Distance: h.PHash.Distance(s.PHash), Screenshot: h.Screenshot, Baseline: s.Screenshot})
+		}
+	}
+	for key, h := range screens {
+		if !known[key] {
+			diffs = append(diffs, ScreenDiff{App: key[0], Screen: key[1], Status: ScreenNew, Screenshot: h.Screenshot})
+		}
+	}
+	sort.SliceStable(diffs, func(i, j int) bool {
+		if diffs[i].App != diffs[j].App {
+			return diffs[i].App < diffs[j].App
+		}
+		return diffs[i].Screen < diffs[j].Screen
+	})
+	return diffs
+}
@@ -0,0 +1,68 @@
+package executor
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"panoptic/internal/vision"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// screensRun is a run whose apps captured screens with the given hashes
+func screensRun(runID string, screens map[string]map[string]uint64) *ResultsDocument {
+	doc := &ResultsDocument{RunID: runID}
+	for app, hashes := range screens {
+		r := &TestResult{AppName: app}
+		for screen, hash := range hashes {
+			r.ScreenHashes = append(r.ScreenHashes, ScreenHash{Screen: screen, Screenshot: runID + "/" + screen + ".png", PHash: vision.PHash(hash)})
+		}
+		doc.Results = append(doc.Results, r)
+	}
+	return doc
+}
+
+// TestBaseline tests approving runs and comparing later ones
+func TestBaseline(t *testing.T) {
+	path := filepath.Join(t.TempDir(), BaselineFileName)
+	b, err := LoadBaseline(path)
+	require.NoError(t, err)
+	assert.Empty(t, b.Screens)
+
+	first := screensRun("r1", map[string]map[string]uint64{
+		"Shop":  {"home": 0xff00, "cart": 0x0f0f},
+		"Admin": {"login": 0x1},
+	})
+	assert.Equal(t, 3, b.Update(first))
+	assert.Equal(t, "r1", b.RunID)
+	assert.Equal(t, []string{"Admin/login", "Shop/cart", "Shop/home"}, []string{
+		b.Screens[0].App + "/" + b.Screens[0].Screen, b.Screens[1].App + "/" + b.Screens[1].Screen, b.Screens[2].App + "/" + b.Screens[2].Screen,
+	})
+	require.NoError(t, b.Save(path))
+	b, err = LoadBaseline(path)
+	require.NoError(t, err)
+	assert.Empty(t, b.Compare(first, 4))
+
+	// Home shifted by 2 bits is the same look; cart by 8 is not, checkout
+	// is new, and Admin wasn't run
+	second := screensRun("r2", map[string]map[string]uint64{"Shop": {"home": 0xff03, "checkout": 0x2}})
+	diffs := b.Compare(second, 4)
+	assert.Equal(t, []ScreenDiff{
+		{App: "Shop", Screen: "cart", Status: ScreenMissing, Baseline: "r1/cart.png"},
+		{App: "Shop", Screen: "checkout", Status: ScreenNew, Screenshot: "r2/checkout.png"},
+	}, diffs)
+
+	third := screensRun("r3", map[string]map[string]uint64{"Shop": {"home": 0xff00, "cart": 0xf0f0}})
+	assert.Equal(t, []ScreenDiff{
+		{App: "Shop", Screen: "cart", Status: ScreenChanged, Distance: 16, Screenshot: "r3/cart.png", Baseline: "r1/cart.png"},
+	}, b.Compare(third, 4))
+	assert.Equal(t, 1, b.Update(third), "only cart changed")
+	assert.Empty(t, b.Compare(third, 4))
+	assert.Len(t, b.Screens, 3, "Admin kept")
+
+	require.NoError(t, os.WriteFile(path, []byte("not json"), 0644))
+	_, err = LoadBaseline(path)
+	assert.ErrorContains(t, err, "invalid baseline")
+}
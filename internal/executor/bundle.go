@@ -0,0 +1,71 @@
+package executor
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+)
+
+// writeFailureBundle zips the results sharing a failure fingerprint with their
+// screenshots, traces and logs into <output>/bundles/<fingerprint>.zip.
+// Videos are left out to keep the bundle small enough to attach to an issue.
+func (e *Executor) writeFailureBundle(fingerprint string, results []*TestResult) (string, error) {
+	dir := filepath.Join(e.outputDir, "bundles")
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", fmt.Errorf("failed to create bundle directory: %w", err)
+	}
+	bundlePath := filepath.Join(dir, fingerprint+".zip")
+	f, err := os.Create(bundlePath)
+	if err != nil {
+		return "", fmt.Errorf("failed to create failure bundle: %w", err)
+	}
+	defer f.Close()
+	zw := zip.NewWriter(f)
+
+	w, err := zw.Create("results.json")
+	if err != nil {
+		return "", err
+	}
+	data, err := json.MarshalIndent(results, "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal bundle results: %w", err)
+	}
+	if _, err := w.Write(data); err != nil {
+		return "", err
+	}
+
+	for i, r := range results {
+		for _, a := range r.Artifacts() {
+			if a.Type == "video" {
+				continue
+			}
+			name := path.Join("artifacts", fmt.Sprintf("%d-%s", i, a.Type), filepath.Base(a.Path))
+			if err := e.addArtifactToZip(zw, name, a.Path); err != nil {
+				e.logger.Debugf("Leaving %s out of the failure bundle: %v", a.Path, err)
+			}
+		}
+	}
+
+	if err := zw.Close(); err != nil {
+		return "", fmt.Errorf("failed to write failure bundle: %w", err)
+	}
+	return bundlePath, nil
+}
+
+// addArtifactToZip adds an artifact to a bundle, decrypted: the bundle is
+// encrypted in turn with the rest of the run's artifacts
+func (e *Executor) addArtifactToZip(zw *zip.Writer, name, src string) error {
+	data, err := e.readArtifact(src)
+	if err != nil {
+		return err
+	}
+	w, err := zw.Create(name)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(data)
+	return err
+}
@@ -0,0 +1,89 @@
+package executor
+
+import (
+	"errors"
+	"fmt"
+
+	"panoptic/internal/config"
+	"panoptic/internal/platforms"
+)
+
+// actionCapabilities are the platform capabilities action types need;
+// action types not listed run on any platform
+var actionCapabilities = map[string]platforms.Capability{
+	"navigate":                  platforms.CapabilityNavigate,
+	"click":                     platforms.CapabilityClick,
+	"fill":                      platforms.CapabilityFill,
+	"login":                     platforms.CapabilityFill,
+	"submit":                    platforms.CapabilitySubmit,
+	"screenshot":                platforms.CapabilityScreenshot,
+	"record":                    platforms.CapabilityRecording,
+	"vision_click":              platforms.CapabilityVision,
+	"vision_report":             platforms.CapabilityVision,
+	"vision_decode_qr":          platforms.CapabilityScreenshot,
+	"vision_contrast_check":     platforms.CapabilityScreenshot,
+	"vision_layout_check":       platforms.CapabilityScreenshot,
+	"vision_design_compare":     platforms.CapabilityScriptEval,
+	"ai_test_generation":        platforms.CapabilityVision,
+	"smart_error_detection":     platforms.CapabilityVision,
+	"performance_assert":        platforms.CapabilityWebVitals,
+	"network":                   platforms.CapabilityNetworkEmulation,
+	"disconnect_network":        platforms.CapabilityNetworkEmulation,
+	"clear_browser_cache":       platforms.CapabilityBrowserStorage,
+	"expire_session":            platforms.CapabilityBrowserStorage,
+	"console_check":             platforms.CapabilityConsole,
+	"wait_for_popup":            platforms.CapabilityTabs,
+	"switch_tab":                platforms.CapabilityTabs,
+	"close_tab":                 platforms.CapabilityTabs,
+	"set_clipboard":             platforms.CapabilityClipboard,
+	"assert_clipboard":          platforms.CapabilityClipboard,
+	"scroll_to":                 platforms.CapabilityScroll,
+	"scroll_by":                 platforms.CapabilityScroll,
+	"scroll_until":              platforms.CapabilityScroll,
+	"save_pdf":                  platforms.CapabilityPDF,
+	"assert_websocket":          platforms.CapabilityWebSockets,
+	"unregister_service_worker": platforms.CapabilityPWA,
+	"update_service_worker":     platforms.CapabilityPWA,
+	"assert_service_worker":     platforms.CapabilityPWA,
+	"assert_cache":              platforms.CapabilityPWA,
+	"assert_installable":        platforms.CapabilityPWA,
+	"assert_offline_fallback":   platforms.CapabilityPWA,
+}
+
+// unsupportedActions lists the actions a platform lacks the capabilities
+// for, one error each
+func unsupportedActions(platform platforms.Platform, app config.AppConfig, actions []config.Action) []error {
+	capabilities := platform.Capabilities()
+	var errs []error
+	for _, action := range actions {
+		needed, ok := actionCapabilities[action.Type]
+		if !ok || capabilities.Has(needed) {
+			continue
+		}
+		errs = append(errs, fmt.Errorf("app %s: action '%s' (%s) is unsupported on this platform (%s): it needs %s, the platform supports %s",
+			app.Name, action.Name, action.Type, app.Type, needed, capabilities))
+	}
+	return errs
+}
+
+// checkCapabilities fails when any app has actions its platform can't
+// run, listing all of them, so they're fixed before the run starts
+func (e *Executor) checkCapabilities(apps []config.AppConfig) error {
+	var errs []error
+	for _, app := range apps {
+		platform, err := e.factory.CreatePlatform(app.Type)
+		if err != nil {
+			continue // the app fails once it runs
+		}
+		actions, _ := e.config.SelectActions(app, e.tagFilter)
+		errs = append(errs, unsupportedActions(platform, app, actions)...)
+	}
+	return errors.Join(errs...)
+}
+
+// CheckCapabilities reports, like panoptic run does before starting, the
+// actions of a configuration its apps' platforms can't run
+func CheckCapabilities(cfg *config.Config) error {
+	e := &Executor{config: cfg, factory: platforms.NewPlatformFactory()}
+	return e.checkCapabilities(cfg.Apps)
+}
@@ -0,0 +1,99 @@
+package executor
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"panoptic/internal/config"
+	"panoptic/internal/logger"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// navigationFailingDriver is a driver, announcing capabilities, whose
+// navigate calls fail and whose other calls succeed
+const navigationFailingDriver = `#!/bin/sh
+while read -r line; do
+	id=$(printf '%s' "$line" | sed 's/.*"id":\([0-9]*\).*/\1/')
+	case "$line" in
+	*'"method":"navigate"'*) echo '{"jsonrpc":"2.0","id":'$id',"error":{"code":1,"message":"no such screen"}}' ;;
+	*) echo '{"jsonrpc":"2.0","id":'$id',"result":{"name":"sh","protocol_version":1,"capabilities":[%CAPABILITIES%]}}' ;;
+	esac
+done
+`
+
+// scriptDriver writes navigationFailingDriver, announcing capabilities
+func scriptDriver(t *testing.T, capabilities string) *config.DriverConfig {
+	path := filepath.Join(t.TempDir(), "driver")
+	script := strings.ReplaceAll(navigationFailingDriver, "%CAPABILITIES%", capabilities)
+	require.NoError(t, os.WriteFile(path, []byte(script), 0755))
+	return &config.DriverConfig{Command: path}
+}
+
+// TestExecutor_CheckCapabilities tests that actions a platform can't run
+// are rejected before any app runs
+func TestExecutor_CheckCapabilities(t *testing.T) {
+	appPath := filepath.Join(t.TempDir(), "app")
+	require.NoError(t, os.WriteFile(appPath, []byte("#!/bin/sh\n"), 0755))
+	cfg := &config.Config{
+		Apps: []config.AppConfig{
+			{Name: "Desk", Type: "desktop", Path: appPath, Actions: []config.Action{
+				{Name: "shot", Type: "screenshot"},
+				{Name: "go", Type: "navigate", Value: "app://home"},
+				{Name: "name", Type: "fill", Selector: "#name", Value: "Ada"},
+			}},
+			{Name: "Phone", Type: "mobile", Platform: "android", Actions: []config.Action{
+				{Name: "find", Type: "vision_click", Parameters: map[string]interface{}{"type": "button"}},
+				{Name: "hold", Type: "pause"},
+			}},
+		},
+	}
+
+	err := CheckCapabilities(cfg)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "app Desk: action 'go' (navigate) is unsupported on this platform (desktop): it needs navigate, the platform supports click, recording, screenshot")
+	assert.Contains(t, err.Error(), "app Desk: action 'name' (fill) is unsupported on this platform (desktop)")
+	assert.Contains(t, err.Error(), "app Phone: action 'find' (vision_click) is unsupported on this platform (mobile): it needs vision")
+	assert.NotContains(t, err.Error(), "'shot'")
+	assert.NotContains(t, err.Error(), "'hold'")
+
+	executor := NewExecutor(cfg, t.TempDir(), logger.NewLogger(false))
+	err = executor.Run()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "configuration validation failed: app Desk: action 'go' (navigate)")
+	assert.Empty(t, executor.results, "no app runs")
+
+	// Actions left out by --tags aren't checked
+	cfg.Apps = cfg.Apps[:1]
+	cfg.Apps[0].Actions[1].Tags = []string{"nav"}
+	cfg.Apps[0].Actions[2].Tags = []string{"nav"}
+	executor = NewExecutor(cfg, t.TempDir(), logger.NewLogger(false))
+	filter, err := config.ParseTagFilter("!nav")
+	require.NoError(t, err)
+	executor.SetTagFilter(filter)
+	assert.NoError(t, executor.checkCapabilities(executor.expandApps()))
+
+	assert.NoError(t, CheckCapabilities(&config.Config{Apps: []config.AppConfig{{Name: "Shop", Type: "web", URL: "https://example.com",
+		Actions: []config.Action{{Name: "find", Type: "vision_click"}, {Name: "perf", Type: "performance_assert"}}}}}))
+}
+
+// TestExecutor_DriverCapabilities tests that the capabilities a driver
+// announces are checked once it's running
+func TestExecutor_DriverCapabilities(t *testing.T) {
+	cfg := &config.Config{Apps: []config.AppConfig{
+		{Name: "Kiosk", Type: "driver", Driver: scriptDriver(t, `"click","screenshot"`), Actions: []config.Action{
+			{Name: "go", Type: "navigate", Value: "kiosk://home"},
+		}},
+	}}
+	executor := NewExecutor(cfg, t.TempDir(), logger.NewLogger(false))
+	require.NoError(t, executor.Run(), "drivers may navigate until they tell otherwise")
+	require.Len(t, executor.results, 1)
+
+	result := executor.results[0]
+	assert.False(t, result.Success)
+	assert.Equal(t, "app Kiosk: action 'go' (navigate) is unsupported on this platform (driver): it needs navigate, the platform supports click, screenshot", result.Error)
+	assert.Equal(t, config.CategoryInfrastructure, result.FailureCategory)
+}
@@ -0,0 +1,97 @@
+package executor
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"panoptic/internal/captcha"
+	"panoptic/internal/config"
+	"panoptic/internal/platforms"
+)
+
+// captchaActions interact with the page, so settings.captcha looks for a
+// captcha before they run
+var captchaActions = map[string]bool{
+	"click": true, "fill": true, "submit": true, "login": true, "vision_click": true,
+}
+
+// CaptchaEncounter is recorded in the captchas metric for every captcha
+// found before an action
+type CaptchaEncounter struct {
+	Action   string        `json:"action"`
+	Kind     string        `json:"kind,omitempty"`
+	Selector string        `json:"selector"`
+	PageURL  string        `json:"page_url"`
+	Mode     string        `json:"mode"`
+	Solved   bool          `json:"solved"`
+	Duration time.Duration `json:"duration"`
+}
+
+// newCaptchaSolver returns the solver of the mode in settings.captcha, nil
+// for fail
+func newCaptchaSolver(s *config.CaptchaSettings, selectors []string) captcha.Solver {
+	switch s.Mode {
+	case "manual":
+		return &captcha.Manual{Selectors: selectors}
+	case "solver":
+		return captcha.NewTwoCaptcha(os.ExpandEnv(s.APIKey), s.URL)
+	case "token":
+		return &captcha.Token{Value: os.ExpandEnv(s.Token)}
+	}
+	return nil
+}
+
+// handleCaptcha looks for a captcha on the page before an action interacts
+// with it and gets past it as settings.captcha says, so a run never hangs on
+// one. Platforms that can't detect captchas are left alone.
+func (e *Executor) handleCaptcha(ctx context.Context, platform platforms.Platform, action config.Action, result *TestResult) error {
+	settings := e.config.Settings.Captcha
+	if settings == nil || !captchaActions[action.Type] {
+		return nil
+	}
+	page, ok := platform.(captcha.Page)
+	if !ok {
+		return nil
+	}
+	selectors := settings.Selectors
+	if len(selectors) == 0 {
+		selectors = captcha.DefaultSelectors
+	}
+	challenge, err := page.DetectCaptcha(selectors)
+	if err != nil || challenge == nil {
+		// a page that can't be inspected is left to the action to report
+		return nil
+	}
+
+	mode := settings.Mode
+	if mode == "" {
+		mode = "fail"
+	}
+	encounter := CaptchaEncounter{Action: action.Name, Kind: challenge.Kind, Selector: challenge.Selector, PageURL: challenge.PageURL, Mode: mode}
+	defer func() {
+		if result.Metrics == nil {
+			result.Metrics = make(map[string]interface{})
+		}
+		captchas, _ := result.Metrics["captchas"].([]CaptchaEncounter)
+		result.Metrics["captchas"] = append(captchas, encounter)
+	}()
+
+	solver := newCaptchaSolver(settings, selectors)
+	if solver == nil {
+		return fmt.Errorf("captcha %s shown at %s; set settings.captcha.mode to manual, solver or token to get past it",
+			challenge.Selector, challenge.PageURL)
+	}
+	e.logger.Warnf("Captcha %s shown at %s, solving it in %s mode (timeout %s)", challenge.Selector, challenge.PageURL, mode, settings.TimeoutOrDefault())
+	start := time.Now()
+	solveCtx, cancel := context.WithTimeout(ctx, settings.TimeoutOrDefault())
+	defer cancel()
+	err = solver.Solve(solveCtx, page, *challenge)
+	encounter.Duration = time.Since(start)
+	if err != nil {
+		return fmt.Errorf("captcha %s at %s: %w", challenge.Selector, challenge.PageURL, err)
+	}
+	encounter.Solved = true
+	return nil
+}
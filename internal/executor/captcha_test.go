@@ -0,0 +1,75 @@
+package executor
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"panoptic/internal/captcha"
+	"panoptic/internal/config"
+	"panoptic/internal/logger"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// captchaPlatform is a mock platform showing a captcha until a token is
+// injected
+type captchaPlatform struct {
+	*MockPlatform
+	challenge *captcha.Challenge
+	token     string
+}
+
+func (p *captchaPlatform) DetectCaptcha(selectors []string) (*captcha.Challenge, error) {
+	if p.token != "" {
+		return nil, nil
+	}
+	return p.challenge, nil
+}
+
+func (p *captchaPlatform) InjectCaptchaToken(token string) error {
+	p.token = token
+	return nil
+}
+
+func newCaptchaPlatform() *captchaPlatform {
+	return &captchaPlatform{
+		MockPlatform: &MockPlatform{metrics: map[string]interface{}{}},
+		challenge:    &captcha.Challenge{Kind: "recaptcha", Selector: ".g-recaptcha", SiteKey: "site-key", PageURL: "https://staging.shop.test/login"},
+	}
+}
+
+func TestExecutor_HandleCaptcha(t *testing.T) {
+	cfg := &config.Config{Settings: config.Settings{Captcha: &config.CaptchaSettings{}}}
+	executor := NewExecutor(cfg, t.TempDir(), logger.NewLogger(false))
+	result := &TestResult{Metrics: map[string]interface{}{}}
+	fill := config.Action{Name: "email", Type: "fill", Selector: "#email", Value: "ada@shop.test"}
+	ctx := context.Background()
+
+	platform := newCaptchaPlatform()
+	err := executor.handleCaptcha(ctx, platform, fill, result)
+	assert.EqualError(t, err, "captcha .g-recaptcha shown at https://staging.shop.test/login; set settings.captcha.mode to manual, solver or token to get past it")
+	require.NoError(t, executor.handleCaptcha(ctx, platform, config.Action{Name: "wait", Type: "wait"}, result), "only interactions look for captchas")
+
+	t.Setenv("CAPTCHA_BYPASS", "bypass-token")
+	cfg.Settings.Captcha = &config.CaptchaSettings{Mode: "token", Token: "${CAPTCHA_BYPASS}"}
+	require.NoError(t, executor.handleCaptcha(ctx, platform, fill, result))
+	assert.Equal(t, "bypass-token", platform.token)
+	require.NoError(t, executor.handleCaptcha(ctx, platform, fill, result), "solved captchas aren't solved again")
+
+	encounters := result.Metrics["captchas"].([]CaptchaEncounter)
+	require.Len(t, encounters, 2)
+	assert.Equal(t, "fail", encounters[0].Mode)
+	assert.False(t, encounters[0].Solved)
+	assert.Equal(t, "token", encounters[1].Mode)
+	assert.True(t, encounters[1].Solved)
+	assert.Equal(t, "https://staging.shop.test/login", encounters[1].PageURL)
+
+	cfg.Settings.Captcha = &config.CaptchaSettings{Mode: "manual", Timeout: 10 * time.Millisecond}
+	err = executor.handleCaptcha(ctx, newCaptchaPlatform(), fill, result)
+	assert.EqualError(t, err, "captcha .g-recaptcha at https://staging.shop.test/login: captcha not solved in the browser before the timeout")
+
+	require.NoError(t, executor.handleCaptcha(ctx, &MockPlatform{metrics: map[string]interface{}{}}, fill, result),
+		"platforms that can't detect captchas are left alone")
+}
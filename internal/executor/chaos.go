@@ -0,0 +1,159 @@
+package executor
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"panoptic/internal/config"
+	"panoptic/internal/platforms"
+)
+
+// Platforms implementing these support the browser chaos actions
+type (
+	cacheClearer interface {
+		ClearBrowserCache() error
+	}
+	sessionExpirer interface {
+		ExpireSession(cookies []string, storage bool) error
+	}
+	urlReporter interface {
+		CurrentURL() (string, error)
+	}
+)
+
+// chaosParameters are the parameters accepted by the chaos actions
+type chaosParameters struct {
+	Kill    bool     `yaml:"kill"`    // restart_app: SIGKILL the process instead of closing it
+	Cookies []string `yaml:"cookies"` // expire_session: only these cookies, default all
+	Storage bool     `yaml:"storage"` // expire_session: also clear local and session storage
+}
+
+// ChaosEvent is recorded in the chaos_events metric for every chaos action
+type ChaosEvent struct {
+	Action string    `json:"action"`
+	Type   string    `json:"type"`
+	At     time.Time `json:"at"`
+}
+
+// runChaosAction breaks the app on purpose so later actions exercise its
+// recovery; refused unless settings.chaos.enabled is set
+func (e *Executor) runChaosAction(ctx context.Context, platform platforms.Platform, action config.Action, app config.AppConfig, result *TestResult, recordingFile *string) error {
+	if !e.config.Settings.Chaos.IsEnabled() {
+		return fmt.Errorf("chaos action type %s requires settings.chaos.enabled", action.Type)
+	}
+	var params chaosParameters
+	if err := decodeSettingsMap(action.Parameters, &params); err != nil {
+		return fmt.Errorf("invalid %s parameters: %w", action.Type, err)
+	}
+
+	var err error
+	switch action.Type {
+	case "restart_app":
+		err = e.restartApp(ctx, platform, app, params.Kill, *recordingFile != "")
+	case "clear_browser_cache":
+		clearer, ok := platform.(cacheClearer)
+		if !ok {
+			return fmt.Errorf("clear_browser_cache is only supported on the web platform")
+		}
+		err = clearer.ClearBrowserCache()
+	case "expire_session":
+		expirer, ok := platform.(sessionExpirer)
+		if !ok {
+			return fmt.Errorf("expire_session is only supported on the web platform")
+		}
+		err = expirer.ExpireSession(params.Cookies, params.Storage)
+	case "disconnect_network":
+		err = e.disconnectNetwork(platform, time.Duration(action.Duration)*time.Second)
+	}
+	if err != nil {
+		return err
+	}
+
+	if result.Metrics == nil {
+		result.Metrics = make(map[string]interface{})
+	}
+	events, _ := result.Metrics["chaos_events"].([]ChaosEvent)
+	result.Metrics["chaos_events"] = append(events, ChaosEvent{Action: action.Name, Type: action.Type, At: time.Now()})
+	return nil
+}
+
+// restartApp closes, or kills, the app's browser or process and starts it
+// again. Web apps return to the page they were on, under the same network
+// conditions.
+func (e *Executor) restartApp(ctx context.Context, platform platforms.Platform, app config.AppConfig, kill, recording bool) error {
+	if recording {
+		return fmt.Errorf("can't restart %s while recording; stop the recording first", app.Name)
+	}
+	var lastURL string
+	if reporter, ok := platform.(urlReporter); ok {
+		lastURL, _ = reporter.CurrentURL()
+	}
+
+	if identifier, ok := platform.(processIdentifier); ok && kill && identifier.ProcessID() > 0 {
+		process, err := os.FindProcess(identifier.ProcessID())
+		if err == nil {
+			err = process.Kill()
+		}
+		if err != nil {
+			return fmt.Errorf("failed to kill %s: %w", app.Name, err)
+		}
+		e.logger.Warnf("Killed process %d of %s", identifier.ProcessID(), app.Name)
+	}
+	// Closing a killed browser fails; the restart is what matters
+	if err := e.platformCall(ctx, app, "Close", platform.Close); err != nil {
+		e.logger.Debugf("Close before restart: %v", err)
+	}
+	if err := e.platformCall(ctx, app, "Initialize", func() error { return platform.Initialize(app) }); err != nil {
+		return fmt.Errorf("failed to restart %s: %w", app.Name, err)
+	}
+	e.logger.Infof("Restarted %s", app.Name)
+
+	if e.network != (config.NetworkConditions{}) {
+		if emulator, ok := platform.(networkEmulator); ok {
+			if err := emulator.EmulateNetwork(e.network); err != nil {
+				return fmt.Errorf("failed to restore network conditions after restart: %w", err)
+			}
+		}
+	}
+	if strings.HasPrefix(lastURL, "http://") || strings.HasPrefix(lastURL, "https://") {
+		return e.platformCall(ctx, app, "Navigate", func() error { return platform.Navigate(lastURL) })
+	}
+	return nil
+}
+
+// disconnectNetwork takes the app offline and restores its network
+// conditions after d, while the following actions run
+func (e *Executor) disconnectNetwork(platform platforms.Platform, d time.Duration) error {
+	emulator, ok := platform.(networkEmulator)
+	if !ok {
+		return fmt.Errorf("disconnect_network is only supported on the web platform")
+	}
+	if d <= 0 {
+		return fmt.Errorf("disconnect_network needs a duration in seconds")
+	}
+	if err := emulator.EmulateNetwork(config.NetworkConditions{Profile: "offline", Offline: true}); err != nil {
+		return err
+	}
+	restore := e.network
+	log := e.logger
+	log.Warnf("Network disconnected for %s", d)
+	e.chaosTimers = append(e.chaosTimers, time.AfterFunc(d, func() {
+		if err := emulator.EmulateNetwork(restore); err != nil {
+			log.Warnf("Failed to reconnect network: %v", err)
+			return
+		}
+		log.Infof("Network reconnected")
+	}))
+	return nil
+}
+
+// stopChaos cancels pending chaos timers when an app finishes
+func (e *Executor) stopChaos() {
+	for _, timer := range e.chaosTimers {
+		timer.Stop()
+	}
+	e.chaosTimers = nil
+}
@@ -0,0 +1,119 @@
+package executor
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"panoptic/internal/config"
+	"panoptic/internal/logger"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// chaosPlatform is a MockPlatform supporting the browser chaos actions
+type chaosPlatform struct {
+	*MockPlatform
+	mu       sync.Mutex
+	network  []config.NetworkConditions
+	cleared  bool
+	cookies  []string
+	storage  bool
+	restarts int
+}
+
+func newChaosPlatform() *chaosPlatform {
+	return &chaosPlatform{MockPlatform: &MockPlatform{metrics: map[string]interface{}{}}}
+}
+
+func (p *chaosPlatform) Initialize(app config.AppConfig) error {
+	p.restarts++
+	return p.MockPlatform.Initialize(app)
+}
+
+func (p *chaosPlatform) CurrentURL() (string, error) { return "https://shop.test/cart", nil }
+func (p *chaosPlatform) ClearBrowserCache() error    { p.cleared = true; return nil }
+
+func (p *chaosPlatform) ExpireSession(cookies []string, storage bool) error {
+	p.cookies, p.storage = cookies, storage
+	return nil
+}
+
+func (p *chaosPlatform) EmulateNetwork(conditions config.NetworkConditions) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.network = append(p.network, conditions)
+	return nil
+}
+
+func (p *chaosPlatform) applied() []config.NetworkConditions {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return append([]config.NetworkConditions(nil), p.network...)
+}
+
+func chaosExecutor(t *testing.T, enabled bool) *Executor {
+	return NewExecutor(&config.Config{Settings: config.Settings{Chaos: &config.ChaosSettings{Enabled: enabled}}}, t.TempDir(), logger.NewLogger(false))
+}
+
+// TestExecutor_ChaosActions tests restart_app, clear_browser_cache and
+// expire_session, and the recorded events
+func TestExecutor_ChaosActions(t *testing.T) {
+	executor := chaosExecutor(t, true)
+	platform := newChaosPlatform()
+	result := &TestResult{}
+	recording := ""
+	run := func(action config.Action) error {
+		return executor.runAction(t.Context(), platform, action, config.AppConfig{Name: "Shop", Type: "web"}, result, &recording)
+	}
+
+	require.NoError(t, executor.emulateNetwork(platform, &config.NetworkConditions{Profile: "fast-3g"}, "settings.network", result))
+	require.NoError(t, run(config.Action{Name: "crash", Type: "restart_app"}))
+	assert.Equal(t, 1, platform.restarts)
+	assert.Equal(t, true, platform.metrics["closed"])
+	assert.Equal(t, "fast-3g", platform.applied()[1].Profile, "network conditions survive the restart")
+	assert.Equal(t, config.Action{Type: "navigate", Value: "https://shop.test/cart"}, platform.executedActions[0], "returns to the last page")
+
+	require.NoError(t, run(config.Action{Name: "cache", Type: "clear_browser_cache"}))
+	assert.True(t, platform.cleared)
+	require.NoError(t, run(config.Action{Name: "logout", Type: "expire_session", Parameters: map[string]interface{}{"cookies": []interface{}{"sid"}, "storage": true}}))
+	assert.Equal(t, []string{"sid"}, platform.cookies)
+	assert.True(t, platform.storage)
+
+	events := result.Metrics["chaos_events"].([]ChaosEvent)
+	require.Len(t, events, 3)
+	assert.Equal(t, "restart_app", events[0].Type)
+	assert.Equal(t, "logout", events[2].Action)
+
+	recording = "video.mp4"
+	assert.ErrorContains(t, run(config.Action{Name: "crash", Type: "restart_app"}), "while recording")
+}
+
+// TestExecutor_DisconnectNetwork tests reconnecting after the duration
+func TestExecutor_DisconnectNetwork(t *testing.T) {
+	executor := chaosExecutor(t, true)
+	platform := newChaosPlatform()
+	require.NoError(t, executor.disconnectNetwork(platform, 20*time.Millisecond))
+	assert.True(t, platform.applied()[0].Offline)
+
+	assert.Eventually(t, func() bool { return len(platform.applied()) == 2 }, time.Second, 5*time.Millisecond)
+	assert.Equal(t, config.NetworkConditions{}, platform.applied()[1], "reconnects to the unthrottled network")
+
+	require.NoError(t, executor.disconnectNetwork(platform, time.Hour))
+	executor.stopChaos()
+	assert.Empty(t, executor.chaosTimers)
+	assert.ErrorContains(t, executor.disconnectNetwork(platform, 0), "needs a duration")
+}
+
+// TestExecutor_ChaosDisabled tests that chaos actions need settings.chaos.enabled
+func TestExecutor_ChaosDisabled(t *testing.T) {
+	result := &TestResult{}
+	recording := ""
+	err := chaosExecutor(t, false).runAction(t.Context(), newChaosPlatform(), config.Action{Name: "crash", Type: "restart_app"}, config.AppConfig{}, result, &recording)
+	assert.ErrorContains(t, err, "requires settings.chaos.enabled")
+	assert.Nil(t, result.Metrics["chaos_events"])
+
+	err = chaosExecutor(t, true).runAction(t.Context(), &MockPlatform{metrics: map[string]interface{}{}}, config.Action{Name: "c", Type: "clear_browser_cache"}, config.AppConfig{}, result, &recording)
+	assert.ErrorContains(t, err, "only supported on the web platform")
+}
@@ -0,0 +1,190 @@
+package executor
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+
+	"panoptic/internal/config"
+)
+
+// Checkpoint files a run keeps in its output directory until it finishes, so
+// a crashed or interrupted run can be resumed
+const (
+	CheckpointFile        = "checkpoint.json"
+	checkpointResultsFile = "checkpoint.ndjson" // results of the apps that finished, one per line
+)
+
+// ErrNoCheckpoint is returned when resuming where no run was interrupted
+var ErrNoCheckpoint = errors.New("no interrupted run to resume")
+
+// Checkpoint is what a run needs to continue where it stopped: the apps to
+// run, in order, and what makes the apps run the same again. Which apps
+// finished is read from the results saved beside it.
+type Checkpoint struct {
+	RunID        string    `json:"run_id"`
+	ConfigSHA256 string    `json:"config_sha256,omitempty"`
+	TagFilter    string    `json:"tag_filter,omitempty"`
+	FakeSeed     int64     `json:"fake_seed"`
+	StartedAt    time.Time `json:"started_at"`
+	Apps         []string  `json:"apps"`
+}
+
+// checkpointer saves the progress of a run, and what a resumed run carries over
+type checkpointer struct {
+	dir     string
+	resume  *Checkpoint   // the interrupted run, when resuming
+	results *ResultStream // appends the results of finished apps
+}
+
+// EnableCheckpoints saves the progress of the run in the output directory
+// after every app, for Resume
+func (e *Executor) EnableCheckpoints() {
+	if e.checkpoints == nil {
+		e.checkpoints = &checkpointer{dir: e.outputDir}
+	}
+}
+
+// Resume continues the interrupted run of the output directory: its run ID,
+// start and test data seed carry over, the apps that finished are skipped
+// and their results merged with the new ones. Call it before Run; it
+// enables checkpoints.
+func (e *Executor) Resume() error {
+	e.EnableCheckpoints()
+	data, err := os.ReadFile(filepath.Join(e.outputDir, CheckpointFile))
+	if errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("%w in %s", ErrNoCheckpoint, e.outputDir)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read checkpoint: %w", err)
+	}
+	var checkpoint Checkpoint
+	if err := json.Unmarshal(data, &checkpoint); err != nil {
+		return fmt.Errorf("invalid checkpoint %s: %w", CheckpointFile, err)
+	}
+	if checkpoint.ConfigSHA256 != "" && e.configSHA256 != "" && checkpoint.ConfigSHA256 != e.configSHA256 {
+		return fmt.Errorf("the configuration changed since run %s was interrupted; run it again without resuming", checkpoint.RunID)
+	}
+	if checkpoint.TagFilter != e.tagFilter.String() {
+		return fmt.Errorf("run %s was interrupted with tags %q; resume it with the same tags", checkpoint.RunID, checkpoint.TagFilter)
+	}
+	e.checkpoints.resume = &checkpoint
+	e.SetRunID(checkpoint.RunID)
+	e.SetFakeSeed(checkpoint.FakeSeed)
+	return nil
+}
+
+// startCheckpoint saves the checkpoint of a run of apps and returns how many
+// of them already finished in the run being resumed, whose results it adds
+func (e *Executor) startCheckpoint(apps []config.AppConfig) (int, error) {
+	c := e.checkpoints
+	if c == nil {
+		return 0, nil
+	}
+	names := make([]string, len(apps))
+	for i, app := range apps {
+		names[i] = app.Name
+	}
+	resultsPath := filepath.Join(c.dir, checkpointResultsFile)
+
+	// Results of the interrupted run, before the stream file is recreated
+	var done []TestResult
+	if c.resume != nil {
+		if !slices.Equal(c.resume.Apps, names) {
+			return 0, fmt.Errorf("the apps to run changed since run %s was interrupted; run it again without resuming", c.resume.RunID)
+		}
+		err := ReadResultStream(resultsPath, func(r *TestResult) error {
+			if len(done) == len(names) || r.AppName != names[len(done)] {
+				return fmt.Errorf("checkpoint results don't match the apps of run %s", c.resume.RunID)
+			}
+			done = append(done, *r)
+			return nil
+		})
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			return 0, err
+		}
+		e.startedAt = c.resume.StartedAt
+		e.logger.Infof("Resuming run %s: %d of %d app(s) already finished", c.resume.RunID, len(done), len(names))
+	}
+
+	checkpoint := Checkpoint{
+		RunID:        e.runID,
+		ConfigSHA256: e.configSHA256,
+		TagFilter:    e.tagFilter.String(),
+		FakeSeed:     e.fakeSeed,
+		StartedAt:    e.startedAt,
+		Apps:         names,
+	}
+	data, err := json.MarshalIndent(&checkpoint, "", "  ")
+	if err != nil {
+		return 0, fmt.Errorf("failed to marshal checkpoint: %w", err)
+	}
+	if err := os.WriteFile(filepath.Join(c.dir, CheckpointFile), data, 0600); err != nil {
+		return 0, fmt.Errorf("failed to save checkpoint: %w", err)
+	}
+
+	// Rewrite the results that finished, leaving out a line a crash cut
+	// short, then add to them
+	tmp := resultsPath + ".tmp"
+	rewrite, err := CreateResultStream(tmp)
+	if err != nil {
+		return 0, err
+	}
+	for i := range done {
+		if err = rewrite.Append(&done[i]); err != nil {
+			break
+		}
+	}
+	if closeErr := rewrite.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(tmp, resultsPath)
+	}
+	if err != nil {
+		os.Remove(tmp)
+		return 0, fmt.Errorf("failed to save checkpoint: %w", err)
+	}
+	if c.results, err = appendResultStream(resultsPath); err != nil {
+		return 0, err
+	}
+	c.results.redactor = e.redactor
+	for i := range done {
+		e.addResult(done[i])
+	}
+	return len(done), nil
+}
+
+// checkpointApp saves the result of an app that finished
+func (e *Executor) checkpointApp(result *TestResult) {
+	if e.checkpoints == nil || e.checkpoints.results == nil {
+		return
+	}
+	if err := e.checkpoints.results.Append(result); err != nil {
+		e.logger.Warnf("Failed to save checkpoint: %v", err)
+	}
+}
+
+// endCheckpoint closes the checkpoint of a run, removing it once every app
+// ran: only unfinished runs can be resumed
+func (e *Executor) endCheckpoint(finished bool) {
+	c := e.checkpoints
+	if c == nil || c.results == nil {
+		return
+	}
+	if err := c.results.Close(); err != nil {
+		e.logger.Warnf("Failed to save checkpoint: %v", err)
+	}
+	c.results = nil
+	c.resume = nil
+	if !finished {
+		e.logger.Infof("Resume the run with panoptic run --resume")
+		return
+	}
+	os.Remove(filepath.Join(c.dir, CheckpointFile))
+	os.Remove(filepath.Join(c.dir, checkpointResultsFile))
+}
@@ -0,0 +1,99 @@
+package executor
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"panoptic/internal/config"
+	"panoptic/internal/logger"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// checkpointConfig has three driver apps that pass
+func checkpointConfig(t *testing.T) *config.Config {
+	driver := scriptDriver(t, "")
+	cfg := &config.Config{}
+	for _, name := range []string{"One", "Two", "Three"} {
+		cfg.Apps = append(cfg.Apps, config.AppConfig{
+			Name: name, Type: "driver", Driver: driver,
+			Actions: []config.Action{{Name: "settle", Type: "wait"}},
+		})
+	}
+	return cfg
+}
+
+// TestExecutor_Resume tests that a resumed run skips the apps that finished,
+// keeps their results and ends without a checkpoint
+func TestExecutor_Resume(t *testing.T) {
+	outputDir := t.TempDir()
+	checkpoint := Checkpoint{RunID: "run-1", FakeSeed: 42, Apps: []string{"One", "Two", "Three"}}
+	data, err := json.Marshal(checkpoint)
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(filepath.Join(outputDir, CheckpointFile), data, 0600))
+	// One finished; the crash cut the result of Two short
+	results := `{"app_name":"One","success":true,"error":"from the first run"}` + "\n" + `{"app_name":"Tw`
+	require.NoError(t, os.WriteFile(filepath.Join(outputDir, checkpointResultsFile), []byte(results), 0600))
+
+	executor := NewExecutor(checkpointConfig(t), outputDir, logger.NewLogger(false))
+	require.NoError(t, executor.Resume())
+	assert.Equal(t, "run-1", executor.RunID())
+	assert.Equal(t, int64(42), executor.FakeSeed())
+	require.NoError(t, executor.Run())
+
+	require.Len(t, executor.results, 3)
+	assert.Equal(t, "from the first run", executor.results[0].Error)
+	assert.Equal(t, "Two", executor.results[1].AppName)
+	assert.True(t, executor.results[2].Success, executor.results[2].Error)
+	assert.NoFileExists(t, filepath.Join(outputDir, CheckpointFile))
+	assert.NoFileExists(t, filepath.Join(outputDir, checkpointResultsFile))
+}
+
+// TestExecutor_Checkpoint_Stopped tests that a stopped run leaves a
+// checkpoint that a later run resumes
+func TestExecutor_Checkpoint_Stopped(t *testing.T) {
+	outputDir := t.TempDir()
+	cfg := checkpointConfig(t)
+	stopped := NewExecutor(cfg, outputDir, logger.NewLogger(false))
+	stopped.EnableCheckpoints()
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	require.Error(t, stopped.RunContext(ctx))
+
+	resumed := NewExecutor(cfg, outputDir, logger.NewLogger(false))
+	require.NoError(t, resumed.Resume())
+	assert.Equal(t, stopped.RunID(), resumed.RunID())
+	assert.Equal(t, stopped.FakeSeed(), resumed.FakeSeed())
+	require.NoError(t, resumed.Run())
+	assert.Len(t, resumed.results, 3)
+}
+
+func TestExecutor_Resume_Errors(t *testing.T) {
+	outputDir := t.TempDir()
+	cfg := checkpointConfig(t)
+	executor := NewExecutor(cfg, outputDir, logger.NewLogger(false))
+	assert.ErrorIs(t, executor.Resume(), ErrNoCheckpoint)
+
+	write := func(c Checkpoint) {
+		data, err := json.Marshal(c)
+		require.NoError(t, err)
+		require.NoError(t, os.WriteFile(filepath.Join(outputDir, CheckpointFile), data, 0600))
+	}
+
+	write(Checkpoint{RunID: "run-1", TagFilter: "smoke", Apps: []string{"One", "Two", "Three"}})
+	assert.ErrorContains(t, NewExecutor(cfg, outputDir, logger.NewLogger(false)).Resume(), `run run-1 was interrupted with tags "smoke"`)
+
+	write(Checkpoint{RunID: "run-1", ConfigSHA256: "abc", Apps: []string{"One", "Two", "Three"}})
+	changed := NewExecutor(cfg, outputDir, logger.NewLogger(false))
+	changed.configSHA256 = "def"
+	assert.ErrorContains(t, changed.Resume(), "the configuration changed since run run-1 was interrupted")
+
+	write(Checkpoint{RunID: "run-1", Apps: []string{"One", "Two"}})
+	fewer := NewExecutor(cfg, outputDir, logger.NewLogger(false))
+	require.NoError(t, fewer.Resume())
+	assert.ErrorContains(t, fewer.Run(), "the apps to run changed since run run-1 was interrupted")
+}
@@ -0,0 +1,59 @@
+package executor
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"panoptic/internal/config"
+	"panoptic/internal/platforms"
+)
+
+// clipboardAccess is implemented by platforms whose apps can read and
+// write the clipboard
+type clipboardAccess interface {
+	WriteClipboard(text string) error
+	ReadClipboard() (string, error)
+}
+
+// runClipboardAction runs set_clipboard, putting the action's value on the
+// clipboard for a paste to pick up, and assert_clipboard, checking what a
+// copy button put there
+func (e *Executor) runClipboardAction(platform platforms.Platform, action config.Action) error {
+	clipboard, ok := platform.(clipboardAccess)
+	if !ok {
+		return fmt.Errorf("%s is not supported on this platform", action.Type)
+	}
+	if action.Type == "set_clipboard" {
+		if err := clipboard.WriteClipboard(action.Value); err != nil {
+			return err
+		}
+		e.logger.Infof("Put %d character(s) on the clipboard", len(action.Value))
+		return nil
+	}
+
+	check, err := action.ClipboardCheck()
+	if err != nil {
+		return err
+	}
+	text, err := clipboard.ReadClipboard()
+	if err != nil {
+		return err
+	}
+	switch {
+	case check.Equals != "" && text != check.Equals:
+		return fmt.Errorf("clipboard holds %q, expected %q", text, check.Equals)
+	case check.Contains != "" && !strings.Contains(text, check.Contains):
+		return fmt.Errorf("clipboard holds %q, expected it to contain %q", text, check.Contains)
+	case check.Matches != "" && !regexp.MustCompile(check.Matches).MatchString(text): // compiled by ClipboardCheck
+		return fmt.Errorf("clipboard holds %q, expected it to match %q", text, check.Matches)
+	}
+	if check.Variable != "" {
+		if e.vars == nil {
+			e.vars = make(map[string]string)
+		}
+		e.vars[check.Variable] = text
+	}
+	e.logger.Infof("Clipboard holds %q as expected", text)
+	return nil
+}
@@ -0,0 +1,57 @@
+package executor
+
+import (
+	"testing"
+
+	"panoptic/internal/config"
+	"panoptic/internal/logger"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// clipboardPlatform is a mock platform whose clicks copy a share link
+type clipboardPlatform struct {
+	*MockPlatform
+	clipboard string
+}
+
+func (p *clipboardPlatform) Click(selector string) error {
+	p.clipboard = "https://shop.test/p/42?ref=share"
+	return nil
+}
+
+func (p *clipboardPlatform) WriteClipboard(text string) error {
+	p.clipboard = text
+	return nil
+}
+
+func (p *clipboardPlatform) ReadClipboard() (string, error) {
+	return p.clipboard, nil
+}
+
+func TestExecutor_RunClipboardAction(t *testing.T) {
+	executor := NewExecutor(&config.Config{}, t.TempDir(), logger.NewLogger(false))
+	platform := &clipboardPlatform{MockPlatform: &MockPlatform{metrics: map[string]interface{}{}}}
+	app := config.AppConfig{Name: "Shop", Type: "web"}
+	result := &TestResult{Metrics: map[string]interface{}{}}
+
+	require.NoError(t, executor.executeAction(platform, config.Action{Name: "copy_link", Type: "click", Selector: "#share"}, app, result, nil))
+	copied := config.Action{Name: "copied", Type: "assert_clipboard", Parameters: map[string]interface{}{
+		"matches": `^https://shop\.test/p/\d+`, "variable": "share_link",
+	}}
+	require.NoError(t, executor.executeAction(platform, copied, app, result, nil))
+	assert.Equal(t, "https://shop.test/p/42?ref=share", executor.vars["share_link"])
+
+	require.NoError(t, executor.executeAction(platform, config.Action{Name: "paste_code", Type: "set_clipboard", Value: "{{var.share_link}}#reviews"}, app, result, nil))
+	assert.Equal(t, "https://shop.test/p/42?ref=share#reviews", platform.clipboard)
+	require.NoError(t, executor.runClipboardAction(platform, config.Action{Name: "exact", Type: "assert_clipboard", Value: "https://shop.test/p/42?ref=share#reviews"}))
+
+	err := executor.runClipboardAction(platform, config.Action{Name: "ref", Type: "assert_clipboard", Parameters: map[string]interface{}{"contains": "ref=email"}})
+	assert.EqualError(t, err, `clipboard holds "https://shop.test/p/42?ref=share#reviews", expected it to contain "ref=email"`)
+	err = executor.runClipboardAction(platform, config.Action{Name: "exact", Type: "assert_clipboard", Value: "https://shop.test/p/42"})
+	assert.EqualError(t, err, `clipboard holds "https://shop.test/p/42?ref=share#reviews", expected "https://shop.test/p/42"`)
+
+	err = executor.runClipboardAction(&MockPlatform{metrics: map[string]interface{}{}}, config.Action{Name: "paste", Type: "set_clipboard", Value: "code"})
+	assert.EqualError(t, err, "set_clipboard is not supported on this platform")
+}
@@ -0,0 +1,86 @@
+package executor
+
+import (
+	"context"
+	"fmt"
+	"image"
+	"path/filepath"
+	"regexp"
+	"time"
+
+	"panoptic/internal/config"
+	"panoptic/internal/platforms"
+	"panoptic/internal/vision"
+)
+
+// DecodedCode is recorded in the decoded_codes metric for every
+// vision_decode_qr action; the text is left out, as pairing codes and
+// payment links are often secrets
+type DecodedCode struct {
+	Action   string          `json:"action"`
+	Format   string          `json:"format"`
+	Variable string          `json:"variable"`
+	Bounds   image.Rectangle `json:"bounds"`
+	Found    int             `json:"found"` // codes of the wanted formats in the screenshot
+}
+
+// decodeQR screenshots the app, decodes the QR codes and barcodes shown and
+// stores the text of the first one of the wanted formats matching the
+// action's pattern as a variable
+func (e *Executor) decodeQR(ctx context.Context, platform platforms.Platform, action config.Action, app config.AppConfig, result *TestResult) error {
+	decode, err := action.CodeDecode()
+	if err != nil {
+		return err
+	}
+	matches := regexp.MustCompile(decode.Matches) // compiled by CodeDecode
+
+	filename := filepath.Join(e.outputDir, "screenshots", fmt.Sprintf("%s_%s_%d.png", app.Name, action.Name, time.Now().Unix()))
+	if err := e.platformCall(ctx, app, "Screenshot", func() error { return platform.Screenshot(filename) }); err != nil {
+		return err
+	}
+	result.Screenshots = append(result.Screenshots, filename)
+
+	codes, err := vision.NewElementDetector(*e.componentLogger()).DecodeCodesFromFile(filename)
+	if err != nil {
+		return fmt.Errorf("failed to read screenshot %s: %w", filename, err)
+	}
+	var wanted []vision.Code
+	for _, code := range codes {
+		for _, f := range decode.Formats {
+			if code.Format == f {
+				wanted = append(wanted, code)
+			}
+		}
+	}
+	if len(wanted) == 0 {
+		return fmt.Errorf("no %v code found in screenshot %s", decode.Formats, filename)
+	}
+	var chosen *vision.Code
+	for i := range wanted {
+		if matches.MatchString(wanted[i].Text) {
+			chosen = &wanted[i]
+			break
+		}
+	}
+	if chosen == nil {
+		return fmt.Errorf("none of the %d code(s) decoded match %q", len(wanted), decode.Matches)
+	}
+
+	if e.vars == nil {
+		e.vars = make(map[string]string)
+	}
+	e.vars[decode.Variable] = chosen.Text
+	e.annotateScreenshot(result, filename, []vision.Annotation{
+		{Bounds: chosen.Bounds, Label: chosen.Format + ": " + decode.Variable, Color: vision.AnnotationBlue},
+	})
+	e.logger.Infof("Decoded %s code at %v; set %s", chosen.Format, chosen.Bounds, decode.Variable)
+
+	if result.Metrics == nil {
+		result.Metrics = make(map[string]interface{})
+	}
+	decoded, _ := result.Metrics["decoded_codes"].([]DecodedCode)
+	result.Metrics["decoded_codes"] = append(decoded, DecodedCode{
+		Action: action.Name, Format: chosen.Format, Variable: decode.Variable, Bounds: chosen.Bounds, Found: len(wanted),
+	})
+	return nil
+}
@@ -0,0 +1,60 @@
+package executor
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"panoptic/internal/config"
+	"panoptic/internal/logger"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// screenPlatform takes screenshots by copying a fixture image
+type screenPlatform struct {
+	*MockPlatform
+	fixture string
+}
+
+func (p *screenPlatform) Screenshot(filename string) error {
+	data, err := os.ReadFile(p.fixture)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(filename), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(filename, data, 0644)
+}
+
+// TestExecutor_DecodeQR tests storing a QR code's and a barcode's text as
+// variables and the failures when no code qualifies
+func TestExecutor_DecodeQR(t *testing.T) {
+	executor := NewExecutor(&config.Config{}, t.TempDir(), logger.NewLogger(false))
+	result := &TestResult{StartTime: time.Now(), Metrics: map[string]interface{}{}}
+	recording := ""
+	platform := &screenPlatform{MockPlatform: &MockPlatform{metrics: map[string]interface{}{}}, fixture: filepath.Join("testdata", "pairing_screen.png")}
+
+	decode := config.Action{Name: "pairing", Type: "vision_decode_qr", Parameters: map[string]interface{}{"matches": `^PAIR-\d+$`}}
+	require.NoError(t, executor.executeAction(platform, decode, config.AppConfig{Name: "TV"}, result, &recording))
+	invoice := config.Action{Name: "invoice", Type: "vision_decode_qr", Parameters: map[string]interface{}{"variable": "invoice", "formats": []interface{}{"code128"}}}
+	require.NoError(t, executor.executeAction(platform, invoice, config.AppConfig{Name: "TV"}, result, &recording))
+
+	assert.Equal(t, map[string]string{"qr": "PAIR-4821", "invoice": "INV-202610"}, executor.vars)
+	assert.Len(t, result.Screenshots, 2)
+	decoded := result.Metrics["decoded_codes"].([]DecodedCode)
+	require.Len(t, decoded, 2)
+	assert.Equal(t, DecodedCode{Action: "pairing", Format: "qr", Variable: "qr", Bounds: decoded[0].Bounds, Found: 1}, decoded[0])
+	assert.Equal(t, "code128", decoded[1].Format)
+
+	decode.Parameters["matches"] = `^https://`
+	err := executor.executeAction(platform, decode, config.AppConfig{Name: "TV"}, result, &recording)
+	assert.EqualError(t, err, `none of the 1 code(s) decoded match "^https://"`)
+
+	decode.Parameters = map[string]interface{}{"formats": []interface{}{"ean13"}}
+	err = executor.executeAction(platform, decode, config.AppConfig{Name: "TV"}, result, &recording)
+	assert.ErrorContains(t, err, "no [ean13] code found in screenshot")
+}
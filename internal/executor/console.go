@@ -0,0 +1,65 @@
+package executor
+
+import (
+	"fmt"
+	"strings"
+
+	"panoptic/internal/config"
+	"panoptic/internal/platforms"
+)
+
+// consoleReader is implemented by platforms that collect the errors their
+// pages log to the console
+type consoleReader interface {
+	TakeConsoleErrors() ([]platforms.ConsoleError, error)
+}
+
+// ConsoleFinding is recorded in the console_errors metric for every error a
+// console_check action counted
+type ConsoleFinding struct {
+	Action string `json:"action"`
+	platforms.ConsoleError
+}
+
+// checkConsole fails when the page logged more console errors than the
+// action allows since the app started or the previous console_check
+func (e *Executor) checkConsole(platform platforms.Platform, action config.Action, result *TestResult) error {
+	check, err := action.ConsoleCheck()
+	if err != nil {
+		return err
+	}
+	reader, ok := platform.(consoleReader)
+	if !ok {
+		return fmt.Errorf("console_check is not supported on this platform")
+	}
+	logged, err := reader.TakeConsoleErrors()
+	if err != nil {
+		return err
+	}
+
+	findings, _ := result.Metrics["console_errors"].([]ConsoleFinding)
+	var counted []platforms.ConsoleError
+	for _, err := range logged {
+		if consoleIgnored(err.Message, check.Ignore) {
+			continue
+		}
+		counted = append(counted, err)
+		findings = append(findings, ConsoleFinding{Action: action.Name, ConsoleError: err})
+	}
+	if len(findings) > 0 {
+		result.Metrics["console_errors"] = findings
+	}
+	if len(counted) > check.MaxErrors {
+		return fmt.Errorf("%d console error(s), at most %d allowed: %s", len(counted), check.MaxErrors, counted[0].Message)
+	}
+	return nil
+}
+
+func consoleIgnored(message string, ignore []string) bool {
+	for _, text := range ignore {
+		if strings.Contains(message, text) {
+			return true
+		}
+	}
+	return false
+}
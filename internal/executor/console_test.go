@@ -0,0 +1,57 @@
+package executor
+
+import (
+	"testing"
+
+	"panoptic/internal/config"
+	"panoptic/internal/logger"
+	"panoptic/internal/platforms"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// consolePlatform is a mock platform whose page logged console errors
+type consolePlatform struct {
+	*MockPlatform
+	logged []platforms.ConsoleError
+}
+
+func (p *consolePlatform) TakeConsoleErrors() ([]platforms.ConsoleError, error) {
+	logged := p.logged
+	p.logged = nil
+	return logged, nil
+}
+
+func TestExecutor_CheckConsole(t *testing.T) {
+	executor := NewExecutor(&config.Config{}, t.TempDir(), logger.NewLogger(false))
+	platform := &consolePlatform{MockPlatform: &MockPlatform{metrics: map[string]interface{}{}}}
+	result := &TestResult{Metrics: map[string]interface{}{}}
+	check := config.Action{Name: "home clean", Type: "console_check", Parameters: map[string]interface{}{"ignore": []interface{}{"favicon"}}}
+
+	platform.logged = []platforms.ConsoleError{{Message: "GET /favicon.ico 404", Source: "console"}}
+	require.NoError(t, executor.checkConsole(platform, check, result), "ignored errors don't count")
+	assert.Nil(t, result.Metrics["console_errors"])
+
+	platform.logged = []platforms.ConsoleError{{Message: "TypeError: cart is undefined", Source: "exception", URL: "https://shop.test/app.js"}}
+	err := executor.checkConsole(platform, check, result)
+	assert.EqualError(t, err, "1 console error(s), at most 0 allowed: TypeError: cart is undefined")
+	findings := result.Metrics["console_errors"].([]ConsoleFinding)
+	require.Len(t, findings, 1)
+	assert.Equal(t, "home clean", findings[0].Action)
+	assert.Equal(t, "https://shop.test/app.js", findings[0].URL)
+
+	require.NoError(t, executor.checkConsole(platform, check, result), "errors are counted by one check only")
+
+	check.Parameters = map[string]interface{}{"max_errors": 1}
+	platform.logged = []platforms.ConsoleError{{Message: "deprecated API", Source: "console"}}
+	require.NoError(t, executor.checkConsole(platform, check, result))
+	assert.Len(t, result.Metrics["console_errors"], 2)
+}
+
+func TestExecutor_CheckConsole_Unsupported(t *testing.T) {
+	executor := NewExecutor(&config.Config{}, t.TempDir(), logger.NewLogger(false))
+	platform := &MockPlatform{metrics: map[string]interface{}{}}
+	err := executor.checkConsole(platform, config.Action{Name: "clean", Type: "console_check"}, &TestResult{Metrics: map[string]interface{}{}})
+	assert.ErrorContains(t, err, "not supported")
+}
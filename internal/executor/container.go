@@ -0,0 +1,224 @@
+package executor
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"panoptic/internal/config"
+	"panoptic/internal/telemetry"
+)
+
+// DefaultContainerImage is the pinned browser image used by containerized mode
+// (built from docker/browser.Dockerfile)
+const DefaultContainerImage = "panoptic-browser:chromium-124"
+
+// containerOutputDir is where the output directory is mounted inside the container
+const containerOutputDir = "/output"
+
+// ContainerOptions configures containerized execution of web apps
+type ContainerOptions struct {
+	Image     string   // browser image with the panoptic agent as entrypoint
+	Docker    string   // docker CLI binary, defaults to "docker"
+	ExtraArgs []string // additional `docker run` arguments
+}
+
+// EnableContainerMode runs each web app in a disposable Docker container instead
+// of driving a host browser
+func (e *Executor) EnableContainerMode(opts ContainerOptions) {
+	if opts.Image == "" {
+		opts.Image = DefaultContainerImage
+	}
+	if opts.Docker == "" {
+		opts.Docker = "docker"
+	}
+	e.container = &opts
+}
+
+var unsafeDirChars = regexp.MustCompile(`[^A-Za-z0-9._-]+`)
+
+// executeAppInContainer runs a single web app inside `docker run --rm`, mounting
+// a per-app output directory and reading the agent's results.json back
+func (e *Executor) executeAppInContainer(app config.AppConfig) TestResult {
+	result := TestResult{
+		AppName:     app.Name,
+		AppType:     app.Type,
+		StartTime:   time.Now(),
+		Screenshots: make([]string, 0),
+		Videos:      make([]string, 0),
+		Metrics:     make(map[string]interface{}),
+		Success:     false,
+	}
+
+	// Failures before the agent reports a result are the container's, not the app's
+	finish := func() TestResult {
+		if !result.Success && result.FailureCategory == "" {
+			result.FailureCategory = config.CategoryInfrastructure
+		}
+		result.EndTime = time.Now()
+		result.Duration = result.EndTime.Sub(result.StartTime)
+		return result
+	}
+
+	dirName := strings.Trim(unsafeDirChars.ReplaceAllString(app.Name, "_"), "_")
+	if dirName == "" {
+		dirName = "app"
+	}
+	hostDir, err := filepath.Abs(filepath.Join(e.outputDir, "containers", dirName))
+	if err != nil {
+		result.Error = fmt.Sprintf("Failed to resolve container output directory: %v", err)
+		return finish()
+	}
+	if err := os.MkdirAll(hostDir, 0755); err != nil {
+		result.Error = fmt.Sprintf("Failed to create container output directory: %v", err)
+		return finish()
+	}
+
+	appConfig := e.singleAppConfig(app)
+	appConfig.Settings.Headless = true
+
+	data, err := yaml.Marshal(appConfig)
+	if err != nil {
+		result.Error = fmt.Sprintf("Failed to marshal app config for container: %v", err)
+		return finish()
+	}
+	configPath := filepath.Join(hostDir, "panoptic.yaml")
+	if err := os.WriteFile(configPath, data, 0600); err != nil {
+		result.Error = fmt.Sprintf("Failed to write container config: %v", err)
+		return finish()
+	}
+
+	args := e.containerRunArgs(hostDir)
+	e.logger.Infof("Running app %s in container %s", app.Name, e.container.Image)
+	e.logger.Debugf("%s %s", e.container.Docker, strings.Join(args, " "))
+
+	timeout := time.Duration(app.Timeout) * time.Second
+	if timeout <= 0 {
+		timeout = 30 * time.Minute
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	var output bytes.Buffer
+	cmd := exec.CommandContext(ctx, e.container.Docker, args...)
+	cmd.Stdout = &output
+	cmd.Stderr = &output
+	runErr := cmd.Run()
+
+	result.Metrics["container_image"] = e.container.Image
+	result.Metrics["container_output"] = hostDir
+	if output.Len() > 0 {
+		logPath := filepath.Join(hostDir, "container.log")
+		if err := os.WriteFile(logPath, output.Bytes(), 0600); err != nil {
+			e.logger.Warnf("Failed to save container log: %v", err)
+		} else {
+			result.Metrics["container_log"] = logPath
+		}
+	}
+
+	inner, err := readContainerResult(filepath.Join(hostDir, "results.json"), app.Name)
+	if err != nil {
+		if runErr != nil {
+			result.Error = fmt.Sprintf("Container run failed: %v: %s", runErr, lastLines(output.String(), 5))
+		} else {
+			result.Error = fmt.Sprintf("Container produced no results: %v", err)
+		}
+		return finish()
+	}
+
+	for k, v := range inner.Metrics {
+		result.Metrics[k] = v
+	}
+	if tracePath, ok := inner.Metrics["trace"].(string); ok {
+		result.Metrics["trace"] = hostArtifactPath(tracePath, hostDir)
+	}
+	for _, p := range inner.Screenshots {
+		result.Screenshots = append(result.Screenshots, hostArtifactPath(p, hostDir))
+	}
+	for _, p := range inner.Videos {
+		result.Videos = append(result.Videos, hostArtifactPath(p, hostDir))
+	}
+	result.Success = inner.Success && runErr == nil
+	result.Error = inner.Error
+	result.FailureCategory = inner.FailureCategory
+	if runErr != nil && result.Error == "" {
+		result.Error = fmt.Sprintf("Container run failed: %v", runErr)
+	} else if !result.Success && result.FailureCategory == "" {
+		result.FailureCategory = config.CategoryFunctional
+	}
+
+	return finish()
+}
+
+// containerRunArgs builds the `docker run` arguments for a per-app output directory
+func (e *Executor) containerRunArgs(hostDir string) []string {
+	args := []string{"run", "--rm", "--shm-size", "1g",
+		"-v", hostDir + ":" + containerOutputDir,
+		"-e", "HOME=/tmp",
+	}
+	if traceParent := e.traceParent(); traceParent != "" {
+		// Spans from the agent in the container join this app's trace
+		args = append(args, "-e", telemetry.TraceParentEnv+"="+traceParent)
+	}
+	if runtime.GOOS != "windows" {
+		// Keep artifacts owned by the invoking user rather than root
+		args = append(args, "--user", fmt.Sprintf("%d:%d", os.Getuid(), os.Getgid()))
+	}
+	args = append(args, e.container.ExtraArgs...)
+	args = append(args, e.container.Image,
+		"run", containerOutputDir+"/panoptic.yaml", "--output", containerOutputDir,
+		"--run-id", e.runID, "--fake-seed", strconv.FormatInt(e.fakeSeed, 10))
+	if e.tracing {
+		args = append(args, "--trace")
+	}
+	return args
+}
+
+// readContainerResult loads the agent's results.json and returns the entry for appName
+func readContainerResult(path, appName string) (*TestResult, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	results, err := parseResults(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	for i := range results {
+		if results[i].AppName == appName {
+			return &results[i], nil
+		}
+	}
+	return nil, fmt.Errorf("no result for app %s in %s", appName, path)
+}
+
+// hostArtifactPath maps an artifact path reported inside the container to the host
+func hostArtifactPath(p, hostDir string) string {
+	if rel, err := filepath.Rel(containerOutputDir, filepath.ToSlash(p)); err == nil && !strings.HasPrefix(rel, "..") {
+		return filepath.Join(hostDir, rel)
+	}
+	if !filepath.IsAbs(p) {
+		return filepath.Join(hostDir, p)
+	}
+	return p
+}
+
+// lastLines returns the final n non-empty lines of s
+func lastLines(s string, n int) string {
+	lines := strings.Split(strings.TrimSpace(s), "\n")
+	if len(lines) > n {
+		lines = lines[len(lines)-n:]
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,161 @@
+package executor
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"panoptic/internal/config"
+	"panoptic/internal/logger"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeDocker writes a shell script standing in for the docker CLI. It finds the
+// host side of the /output mount and runs body with $OUT set to it.
+func fakeDocker(t *testing.T, body string) string {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("fake docker script requires a POSIX shell")
+	}
+
+	script := `#!/bin/sh
+OUT=""
+prev=""
+for arg in "$@"; do
+  if [ "$prev" = "-v" ]; then OUT="${arg%%:/output}"; fi
+  prev="$arg"
+done
+` + body + "\n"
+
+	path := filepath.Join(t.TempDir(), "docker")
+	require.NoError(t, os.WriteFile(path, []byte(script), 0755))
+	return path
+}
+
+func containerTestConfig() *config.Config {
+	return &config.Config{
+		Name:    "containerized",
+		Output:  "ignored-in-container",
+		Apps:    []config.AppConfig{{Name: "Web App", Type: "web", URL: "https://example.com"}},
+		Actions: []config.Action{{Name: "nav", Type: "navigate", Value: "https://example.com"}},
+	}
+}
+
+// TestExecutor_SaveResults tests that saved results round-trip through JSON
+func TestExecutor_SaveResults(t *testing.T) {
+	executor := NewExecutor(containerTestConfig(), t.TempDir(), logger.NewLogger(false))
+	executor.results = []TestResult{{
+		AppName:     "Web App",
+		AppType:     "web",
+		StartTime:   time.Now(),
+		Duration:    time.Second,
+		Screenshots: []string{"/output/screenshots/a.png"},
+		Metrics:     map[string]interface{}{"clicks": 2},
+		Success:     true,
+	}}
+
+	path := filepath.Join(t.TempDir(), "results.json")
+	require.NoError(t, executor.SaveResults(path))
+
+	result, err := readContainerResult(path, "Web App")
+	require.NoError(t, err)
+	assert.True(t, result.Success)
+	assert.Equal(t, time.Second, result.Duration)
+	assert.Equal(t, []string{"/output/screenshots/a.png"}, result.Screenshots)
+
+	_, err = readContainerResult(path, "Other")
+	assert.Error(t, err)
+}
+
+// TestExecutor_ContainerRunArgs tests the docker invocation
+func TestExecutor_ContainerRunArgs(t *testing.T) {
+	executor := NewExecutor(containerTestConfig(), t.TempDir(), logger.NewLogger(false))
+	executor.EnableContainerMode(ContainerOptions{ExtraArgs: []string{"--network", "host"}})
+
+	args := executor.containerRunArgs("/host/out")
+	joined := strings.Join(args, " ")
+
+	assert.Equal(t, "run", args[0])
+	assert.Contains(t, joined, "--rm")
+	assert.Contains(t, joined, "-v /host/out:/output")
+	assert.Contains(t, joined, "--network host")
+	assert.True(t, strings.HasSuffix(joined, DefaultContainerImage+" run /output/panoptic.yaml --output /output --run-id "+executor.RunID()+" --fake-seed "+strconv.FormatInt(executor.FakeSeed(), 10)), joined)
+
+	executor.EnableTracing()
+	args = executor.containerRunArgs("/host/out")
+	assert.Equal(t, "--trace", args[len(args)-1], "tracing is forwarded to the agent in the container")
+}
+
+// TestHostArtifactPath tests mapping container artifact paths to the host
+func TestHostArtifactPath(t *testing.T) {
+	assert.Equal(t, filepath.Join("/host", "screenshots", "a.png"), hostArtifactPath("/output/screenshots/a.png", "/host"))
+	assert.Equal(t, filepath.Join("/host", "videos", "v.mp4"), hostArtifactPath("videos/v.mp4", "/host"))
+	assert.Equal(t, "/elsewhere/x.png", hostArtifactPath("/elsewhere/x.png", "/host"))
+}
+
+// TestExecutor_Run_Containerized tests that web apps run in the container and results are mapped back
+func TestExecutor_Run_Containerized(t *testing.T) {
+	docker := fakeDocker(t, `
+grep -q "headless: true" "$OUT/panoptic.yaml" || exit 3
+grep -q "ignored-in-container" "$OUT/panoptic.yaml" && exit 4
+mkdir -p "$OUT/screenshots" && touch "$OUT/screenshots/home.png"
+cat > "$OUT/results.json" <<'JSON'
+[{"app_name":"Web App","app_type":"web","duration":5,"metrics":{"pages":1},"screenshots":["/output/screenshots/home.png"],"videos":[],"success":true}]
+JSON
+echo "browser started"`)
+
+	outputDir := t.TempDir()
+	executor := NewExecutor(containerTestConfig(), outputDir, logger.NewLogger(false))
+	executor.EnableContainerMode(ContainerOptions{Docker: docker})
+
+	require.NoError(t, executor.Run())
+	require.Len(t, executor.results, 1)
+
+	result := executor.results[0]
+	require.True(t, result.Success, result.Error)
+	assert.Equal(t, DefaultContainerImage, result.Metrics["container_image"])
+	assert.EqualValues(t, 1, result.Metrics["pages"])
+	require.Len(t, result.Screenshots, 1)
+	assert.FileExists(t, result.Screenshots[0])
+	assert.True(t, strings.HasPrefix(result.Screenshots[0], outputDir), "artifacts should live under the host output dir")
+
+	logData, err := os.ReadFile(result.Metrics["container_log"].(string))
+	require.NoError(t, err)
+	assert.Contains(t, string(logData), "browser started")
+}
+
+// TestExecutor_Run_ContainerizedFailure tests that container failures are reported, not masked
+func TestExecutor_Run_ContainerizedFailure(t *testing.T) {
+	docker := fakeDocker(t, `echo "Unable to find image" >&2; exit 125`)
+
+	executor := NewExecutor(containerTestConfig(), t.TempDir(), logger.NewLogger(false))
+	executor.EnableContainerMode(ContainerOptions{Docker: docker})
+
+	require.NoError(t, executor.Run())
+	require.Len(t, executor.results, 1)
+	assert.False(t, executor.results[0].Success)
+	assert.Contains(t, executor.results[0].Error, "Unable to find image")
+}
+
+// TestExecutor_Run_ContainerizedAppFailure tests that failures reported by the agent propagate
+func TestExecutor_Run_ContainerizedAppFailure(t *testing.T) {
+	results, err := json.Marshal([]*TestResult{{AppName: "Web App", AppType: "web", Error: "Action 'nav' failed"}})
+	require.NoError(t, err)
+	docker := fakeDocker(t, `cat > "$OUT/results.json" <<'JSON'
+`+string(results)+`
+JSON`)
+
+	executor := NewExecutor(containerTestConfig(), t.TempDir(), logger.NewLogger(false))
+	executor.EnableContainerMode(ContainerOptions{Docker: docker})
+
+	require.NoError(t, executor.Run())
+	assert.False(t, executor.results[0].Success)
+	assert.Equal(t, "Action 'nav' failed", executor.results[0].Error)
+}
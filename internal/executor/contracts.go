@@ -0,0 +1,380 @@
+package executor
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"panoptic/internal/config"
+	"panoptic/internal/jsonschema"
+	"panoptic/internal/platforms"
+)
+
+// ContractsFileName is the default name of the recorded API contracts
+const ContractsFileName = "contracts.json"
+
+// maxContractProblems bounds the problems kept of each endpoint, and
+// contractErrorProblems those a failed app's error lists
+const (
+	maxContractProblems   = 20
+	contractErrorProblems = 3
+)
+
+// responseObserver is implemented by platforms that report the JSON
+// responses their pages receive
+type responseObserver interface {
+	ObserveResponses(covers func(url string) bool, fn func(platforms.APIResponse)) (func(), error)
+}
+
+// Contracts are the JSON Schemas of the API responses of every app, as a
+// --record-contracts run last saw them
+type Contracts struct {
+	Updated   time.Time          `json:"updated"`
+	RunID     string             `json:"run_id,omitempty"` // run the contracts were last recorded by
+	Endpoints []ContractEndpoint `json:"endpoints"`
+}
+
+// ContractEndpoint is the schema of an app's responses of one status to
+// requests of a method and path; path segments that are IDs read {id}
+type ContractEndpoint struct {
+	App     string             `json:"app"`
+	Method  string             `json:"method"`
+	Path    string             `json:"path"`
+	Status  int                `json:"status"`
+	Samples int                `json:"samples"` // responses the schema was inferred from
+	Schema  *jsonschema.Schema `json:"schema"`
+}
+
+// ContractViolation is an endpoint whose responses broke its contract
+type ContractViolation struct {
+	Method   string   `json:"method"`
+	Path     string   `json:"path"`
+	Status   int      `json:"status"`
+	URL      string   `json:"url"` // of the first breaking response
+	Problems []string `json:"problems"`
+}
+
+// String names the endpoint, e.g. "GET /api/orders/{id} 200"
+func (v ContractViolation) String() string {
+	return fmt.Sprintf("%s %s %d", v.Method, v.Path, v.Status)
+}
+
+// LoadContracts reads recorded contracts; a missing file has none
+func LoadContracts(path string) (*Contracts, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return &Contracts{Endpoints: make([]ContractEndpoint, 0)}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var c Contracts
+	if err := json.Unmarshal(data, &c); err != nil {
+		return nil, fmt.Errorf("invalid contracts %s: %w", path, err)
+	}
+	return &c, nil
+}
+
+// Save writes the contracts
+func (c *Contracts) Save(path string) error {
+	data, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal contracts: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0644)
+}
+
+// find is the contract of an app's endpoint, or nil
+func (c *Contracts) find(app string, key contractKey) *ContractEndpoint {
+	for i := range c.Endpoints {
+		ep := &c.Endpoints[i]
+		if ep.App == app && ep.Method == key.method && ep.Path == key.path && ep.Status == key.status {
+			return ep
+		}
+	}
+	return nil
+}
+
+// update replaces the contracts of the endpoints an app's responses were
+// recorded for, keeping those of endpoints it didn't call
+func (c *Contracts) update(app string, recorded []ContractEndpoint, runID string) {
+	for _, ep := range recorded {
+		key := contractKey{ep.Method, ep.Path, ep.Status}
+		if existing := c.find(app, key); existing != nil {
+			*existing = ep
+		} else {
+			c.Endpoints = append(c.Endpoints, ep)
+		}
+	}
+	sort.Slice(c.Endpoints, func(i, j int) bool {
+		a, b := c.Endpoints[i], c.Endpoints[j]
+		if a.App != b.App {
+			return a.App < b.App
+		}
+		if a.Path != b.Path {
+			return a.Path < b.Path
+		}
+		if a.Method != b.Method {
+			return a.Method < b.Method
+		}
+		return a.Status < b.Status
+	})
+	c.Updated, c.RunID = time.Now().UTC(), runID
+}
+
+// contractKey is an endpoint of an app
+type contractKey struct {
+	method string
+	path   string
+	status int
+}
+
+// idSegment matches path segments that are IDs: numbers, UUIDs and long
+// hex strings
+var idSegment = regexp.MustCompile(`^(\d+|[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}|[0-9a-fA-F]{16,})$`)
+
+// contractPath is the endpoint path of a response URL, without its query
+// and with ID segments as {id}, so /api/orders/42 and /api/orders/43 share
+// a contract
+func contractPath(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return rawURL
+	}
+	segments := strings.Split(u.Path, "/")
+	for i, s := range segments {
+		if idSegment.MatchString(s) {
+			segments[i] = "{id}"
+		}
+	}
+	if path := strings.Join(segments, "/"); path != "" {
+		return path
+	}
+	return "/"
+}
+
+// contractSession collects the API responses of the running app: their
+// schemas when recording, else how they break the app's contracts
+type contractSession struct {
+	app       string
+	contracts *Contracts // read only while the app runs
+	recording bool
+	stop      func()
+
+	mu         sync.Mutex
+	finished   bool
+	responses  int
+	recorded   map[contractKey]*ContractEndpoint
+	violations map[contractKey]*ContractViolation
+	unknown    map[contractKey]bool
+}
+
+// observe records or checks a response
+func (s *contractSession) observe(resp platforms.APIResponse) {
+	key := contractKey{resp.Method, contractPath(resp.URL), resp.Status}
+	var body interface{}
+	decodeErr := json.Unmarshal(resp.Body, &body)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.finished {
+		return
+	}
+	s.responses++
+	if s.recording {
+		if decodeErr != nil {
+			return
+		}
+		ep, ok := s.recorded[key]
+		if !ok {
+			ep = &ContractEndpoint{App: s.app, Method: key.method, Path: key.path, Status: key.status}
+			s.recorded[key] = ep
+		}
+		ep.Schema = jsonschema.Merge(ep.Schema, jsonschema.Infer(body))
+		ep.Samples++
+		return
+	}
+
+	contract := s.contracts.find(s.app, key)
+	if contract == nil {
+		s.unknown[key] = true
+		return
+	}
+	var problems []string
+	if decodeErr != nil {
+		problems = []string{"the response isn't JSON"}
+	} else {
+		problems = contract.Schema.Check(body)
+	}
+	if len(problems) == 0 {
+		return
+	}
+	v, ok := s.violations[key]
+	if !ok {
+		v = &ContractViolation{Method: key.method, Path: key.path, Status: key.status, URL: resp.URL}
+		s.violations[key] = v
+	}
+	for _, p := range problems {
+		if len(v.Problems) < maxContractProblems && !containsString(v.Problems, p) {
+			v.Problems = append(v.Problems, p)
+		}
+	}
+}
+
+func containsString(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
+
+// contractsEnabled tells whether the run records or checks contracts
+func (e *Executor) contractsEnabled() bool {
+	return e.recordContracts || e.config.Settings.Contracts != nil
+}
+
+// RecordContracts has the run save the JSON Schemas of the API responses
+// of its web apps as their contracts instead of checking them
+func (e *Executor) RecordContracts() {
+	e.recordContracts = true
+}
+
+// ContractsPath is the file contracts are recorded in and checked against
+func (e *Executor) ContractsPath() string {
+	if s := e.config.Settings.Contracts; s != nil && s.File != "" {
+		return s.File
+	}
+	return filepath.Join(e.outputDir, ContractsFileName)
+}
+
+// loadContracts reads the contracts as the run starts
+func (e *Executor) loadContracts() error {
+	if !e.contractsEnabled() {
+		return nil
+	}
+	contracts, err := LoadContracts(e.ContractsPath())
+	if err != nil {
+		return err
+	}
+	if !e.recordContracts && len(contracts.Endpoints) == 0 {
+		e.logger.Warnf("No API contracts in %s yet; record them with --record-contracts", e.ContractsPath())
+	}
+	e.contracts = contracts
+	return nil
+}
+
+// saveContracts writes the contracts a --record-contracts run recorded
+func (e *Executor) saveContracts() {
+	if !e.recordContracts || e.contracts == nil {
+		return
+	}
+	path := e.ContractsPath()
+	if err := e.contracts.Save(path); err != nil {
+		e.logger.Errorf("Failed to save API contracts: %v", err)
+		return
+	}
+	e.logger.Infof("API contracts saved: %s (%d endpoint(s))", path, len(e.contracts.Endpoints))
+}
+
+// startContracts observes the JSON responses of a web app's pages, or
+// returns nil when contracts are off or the platform can't report them
+func (e *Executor) startContracts(platform platforms.Platform, app config.AppConfig) *contractSession {
+	if e.contracts == nil {
+		return nil
+	}
+	observer, ok := platform.(responseObserver)
+	if !ok {
+		return nil
+	}
+	session := &contractSession{
+		app:        app.Name,
+		contracts:  e.contracts,
+		recording:  e.recordContracts,
+		recorded:   make(map[contractKey]*ContractEndpoint),
+		violations: make(map[contractKey]*ContractViolation),
+		unknown:    make(map[contractKey]bool),
+	}
+	stop, err := observer.ObserveResponses(e.config.Settings.Contracts.Covers, session.observe)
+	if err != nil {
+		e.logger.Warnf("API contracts unavailable for %s: %v", app.Name, err)
+		return nil
+	}
+	session.stop = stop
+	return session
+}
+
+// finishContracts stops observing, saves what a recording run saw into the
+// contracts and attaches the metrics, returning the endpoints whose
+// responses broke their contracts. Calling it again re-attaches the same.
+func (e *Executor) finishContracts(session *contractSession, result *TestResult) []ContractViolation {
+	if session == nil {
+		return nil
+	}
+	session.mu.Lock()
+	defer session.mu.Unlock()
+	if !session.finished {
+		session.finished = true
+		session.stop()
+		if session.recording {
+			recorded := make([]ContractEndpoint, 0, len(session.recorded))
+			for _, ep := range session.recorded {
+				recorded = append(recorded, *ep)
+			}
+			e.contracts.update(session.app, recorded, e.runID)
+			e.logger.Infof("Recorded API contracts of %d endpoint(s) for %s", len(recorded), session.app)
+		}
+	}
+
+	if result.Metrics == nil {
+		result.Metrics = make(map[string]interface{})
+	}
+	result.Metrics["contract_responses"] = session.responses
+	if session.recording {
+		result.Metrics["contract_endpoints"] = len(session.recorded)
+		return nil
+	}
+	if len(session.unknown) > 0 {
+		unknown := make([]string, 0, len(session.unknown))
+		for key := range session.unknown {
+			unknown = append(unknown, ContractViolation{Method: key.method, Path: key.path, Status: key.status}.String())
+		}
+		sort.Strings(unknown)
+		result.Metrics["contract_new_endpoints"] = unknown
+	}
+	if len(session.violations) == 0 {
+		return nil
+	}
+	violations := make([]ContractViolation, 0, len(session.violations))
+	for _, v := range session.violations {
+		violations = append(violations, *v)
+	}
+	sort.Slice(violations, func(i, j int) bool { return violations[i].String() < violations[j].String() })
+	result.Metrics["contract_violations"] = violations
+	return violations
+}
+
+// contractError formats broken contracts for TestResult.Error
+func contractError(violations []ContractViolation) string {
+	parts := make([]string, len(violations))
+	for i, v := range violations {
+		problems := v.Problems
+		more := ""
+		if len(problems) > contractErrorProblems {
+			problems, more = problems[:contractErrorProblems], fmt.Sprintf(" (and %d more)", len(v.Problems)-contractErrorProblems)
+		}
+		parts[i] = fmt.Sprintf("%s: %s%s", v, strings.Join(problems, ", "), more)
+	}
+	return fmt.Sprintf("API contract broken: %s", strings.Join(parts, "; "))
+}
@@ -0,0 +1,110 @@
+package executor
+
+import (
+	"path/filepath"
+	"testing"
+
+	"panoptic/internal/config"
+	"panoptic/internal/logger"
+	"panoptic/internal/platforms"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// apiPlatform is a mock web platform whose pages receive the responses
+// the test sends
+type apiPlatform struct {
+	*MockPlatform
+	covers  func(url string) bool
+	fn      func(platforms.APIResponse)
+	stopped bool
+}
+
+func (p *apiPlatform) ObserveResponses(covers func(url string) bool, fn func(platforms.APIResponse)) (func(), error) {
+	p.covers, p.fn = covers, fn
+	return func() { p.stopped = true }, nil
+}
+
+func (p *apiPlatform) respond(method, url string, status int, body string) {
+	if p.covers(url) {
+		p.fn(platforms.APIResponse{Method: method, URL: url, Status: status, MimeType: "application/json", Body: []byte(body)})
+	}
+}
+
+func TestContractPath(t *testing.T) {
+	for url, want := range map[string]string{
+		"https://shop.test/api/orders/42?expand=items":                            "/api/orders/{id}",
+		"https://shop.test/api/users/3f2b8c1e-9d4a-4c6b-8e2f-1a7d5c9b0e3f/avatar": "/api/users/{id}/avatar",
+		"https://shop.test/api/carts/5f4dcc3b5aa765d61d8327deb882cf99":            "/api/carts/{id}",
+		"https://shop.test/api/v2/products":                                       "/api/v2/products",
+		"https://shop.test":                                                       "/",
+	} {
+		assert.Equal(t, want, contractPath(url), url)
+	}
+}
+
+func TestExecutor_Contracts(t *testing.T) {
+	dir := t.TempDir()
+	cfg := &config.Config{Settings: config.Settings{Contracts: &config.ContractSettings{URLs: []string{"/api/"}, Ignore: []string{"/api/telemetry"}}}}
+	app := config.AppConfig{Name: "Shop", Type: "web"}
+
+	// The baseline run records the schemas of the responses
+	recorder := NewExecutor(cfg, dir, logger.NewLogger(false))
+	recorder.RecordContracts()
+	require.NoError(t, recorder.loadContracts())
+	platform := &apiPlatform{MockPlatform: &MockPlatform{metrics: map[string]interface{}{}}}
+	session := recorder.startContracts(platform, app)
+	require.NotNil(t, session)
+	platform.respond("GET", "https://shop.test/api/orders/1", 200, `{"id": 1, "total": 9.5, "coupon": null, "items": [{"sku": "mug"}]}`)
+	platform.respond("GET", "https://shop.test/api/orders/2", 200, `{"id": 2, "total": 3, "coupon": "SAVE", "items": []}`)
+	platform.respond("GET", "https://shop.test/api/orders/9", 404, `{"error": "not found"}`)
+	platform.respond("POST", "https://shop.test/api/telemetry", 200, `{"ok": true}`)
+	platform.respond("GET", "https://shop.test/config.json", 200, `{"theme": "dark"}`)
+	result := &TestResult{}
+	assert.Empty(t, recorder.finishContracts(session, result))
+	assert.True(t, platform.stopped)
+	assert.Equal(t, 3, result.Metrics["contract_responses"])
+	assert.Equal(t, 2, result.Metrics["contract_endpoints"])
+	recorder.saveContracts()
+
+	contracts, err := LoadContracts(filepath.Join(dir, ContractsFileName))
+	require.NoError(t, err)
+	require.Len(t, contracts.Endpoints, 2)
+	assert.Equal(t, "/api/orders/{id}", contracts.Endpoints[0].Path)
+	assert.Equal(t, 200, contracts.Endpoints[0].Status)
+	assert.Equal(t, 2, contracts.Endpoints[0].Samples)
+	assert.Equal(t, []string{"coupon", "id", "items", "total"}, contracts.Endpoints[0].Schema.Required)
+	assert.Equal(t, 404, contracts.Endpoints[1].Status)
+
+	// A later run checks the responses against them
+	checker := NewExecutor(cfg, dir, logger.NewLogger(false))
+	require.NoError(t, checker.loadContracts())
+	platform = &apiPlatform{MockPlatform: &MockPlatform{metrics: map[string]interface{}{}}}
+	session = checker.startContracts(platform, app)
+	platform.respond("GET", "https://shop.test/api/orders/3", 200, `{"id": 3, "total": 1, "coupon": null, "items": [], "gift": true}`)
+	platform.respond("GET", "https://shop.test/api/orders/4", 200, `{"id": "4", "total": "1.00", "items": [{"sku": 7}]}`)
+	platform.respond("GET", "https://shop.test/api/orders/5", 200, `{"id": "5", "coupon": null, "total": 2, "items": []}`)
+	platform.respond("GET", "https://shop.test/api/orders/6", 404, `<html>`)
+	platform.respond("GET", "https://shop.test/api/reviews", 200, `[]`)
+	result = &TestResult{}
+	broken := checker.finishContracts(session, result)
+	require.Len(t, broken, 2)
+	assert.Equal(t, ContractViolation{
+		Method: "GET", Path: "/api/orders/{id}", Status: 200, URL: "https://shop.test/api/orders/4",
+		Problems: []string{"$.coupon: missing", "$.id: expected number, got string", "$.items[0].sku: expected string, got number", "$.total: expected number, got string"},
+	}, broken[0])
+	assert.Equal(t, []string{"the response isn't JSON"}, broken[1].Problems)
+	assert.Equal(t, []string{"GET /api/reviews 200"}, result.Metrics["contract_new_endpoints"])
+	assert.Equal(t, broken, result.Metrics["contract_violations"])
+	assert.Equal(t, broken, checker.finishContracts(session, result), "finishing again keeps the same")
+
+	assert.Equal(t, "API contract broken: GET /api/orders/{id} 200: $.coupon: missing, $.id: expected number, got string, "+
+		"$.items[0].sku: expected string, got number (and 1 more); GET /api/orders/{id} 404: the response isn't JSON", contractError(broken))
+
+	// Platforms that can't report responses, and runs without contracts, skip them
+	assert.Nil(t, checker.startContracts(&MockPlatform{metrics: map[string]interface{}{}}, app))
+	off := NewExecutor(&config.Config{}, dir, logger.NewLogger(false))
+	require.NoError(t, off.loadContracts())
+	assert.Nil(t, off.startContracts(platform, app))
+}
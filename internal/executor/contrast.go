@@ -0,0 +1,140 @@
+package executor
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"image"
+	"image/color"
+	"math"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"panoptic/internal/config"
+	"panoptic/internal/platforms"
+	"panoptic/internal/vision"
+)
+
+// ContrastMeasurement is recorded in the contrast metric for every region a
+// vision_contrast_check action measured
+type ContrastMeasurement struct {
+	Action     string          `json:"action"`
+	Region     string          `json:"region"`
+	Bounds     image.Rectangle `json:"bounds"`
+	Foreground string          `json:"foreground"` // #rrggbb
+	Background string          `json:"background"`
+	Ratio      float64         `json:"ratio"`
+	Required   float64         `json:"required"`
+	Passed     bool            `json:"passed"`
+}
+
+// elementBoxScript returns the box of the element matching a selector in
+// full-page screenshot pixels
+const elementBoxScript = `() => {
+	const el = document.querySelector(%s);
+	if (!el) return null;
+	const r = el.getBoundingClientRect(), d = window.devicePixelRatio || 1;
+	return {x: (r.left + window.scrollX) * d, y: (r.top + window.scrollY) * d, width: r.width * d, height: r.height * d};
+}`
+
+// checkContrast screenshots the app and measures the WCAG contrast of text in
+// the action's regions. Regions below the level's minimum are reported as
+// accessibility findings, and fail the action when it sets fail.
+func (e *Executor) checkContrast(ctx context.Context, platform platforms.Platform, action config.Action, app config.AppConfig, result *TestResult) error {
+	check, err := action.ContrastCheck()
+	if err != nil {
+		return err
+	}
+	regions := make([]image.Rectangle, len(check.Regions))
+	for i, r := range check.Regions {
+		if r.Selector == "" {
+			regions[i] = image.Rect(r.X, r.Y, r.X+r.Width, r.Y+r.Height)
+			continue
+		}
+		if regions[i], err = elementBox(platform, r.Selector); err != nil {
+			return fmt.Errorf("region %s: %w", r.Name, err)
+		}
+	}
+
+	filename := filepath.Join(e.outputDir, "screenshots", fmt.Sprintf("%s_%s_%d.png", app.Name, action.Name, time.Now().Unix()))
+	if err := e.platformCall(ctx, app, "Screenshot", func() error { return platform.Screenshot(filename) }); err != nil {
+		return err
+	}
+	result.Screenshots = append(result.Screenshots, filename)
+	contrasts, err := vision.NewElementDetector(*e.componentLogger()).TextContrastFromFile(filename, regions)
+	if err != nil {
+		return fmt.Errorf("failed to measure contrast in %s: %w", filename, err)
+	}
+
+	severity := config.SeverityWarning
+	if check.Fail {
+		severity = config.SeverityError
+	}
+	if result.Metrics == nil {
+		result.Metrics = make(map[string]interface{})
+	}
+	measured, _ := result.Metrics["contrast"].([]ContrastMeasurement)
+	var violations []string
+	var annotations []vision.Annotation
+	for i, c := range contrasts {
+		region := check.Regions[i]
+		required, _ := vision.RequiredContrast(check.Level, region.Large) // level checked by ContrastCheck
+		m := ContrastMeasurement{
+			Action: action.Name, Region: region.Name, Bounds: c.Region,
+			Foreground: hexColor(c.Foreground), Background: hexColor(c.Background),
+			Ratio: math.Round(c.Ratio*100) / 100, Required: required, Passed: c.Ratio >= required,
+		}
+		measured = append(measured, m)
+		if m.Passed {
+			continue
+		}
+		message := fmt.Sprintf("%s: text %s on %s has contrast %.2f:1, below the WCAG %s minimum of %g:1",
+			region.Name, m.Foreground, m.Background, m.Ratio, check.Level, required)
+		violations = append(violations, message)
+		annotations = append(annotations, vision.Annotation{
+			Bounds: c.Region, Label: fmt.Sprintf("%s %.2f:1 < %g:1", region.Name, m.Ratio, required), Color: severityColor(severity),
+		})
+		result.Findings = append(result.Findings, Finding{
+			Action: action.Name, Type: "contrast", Category: config.CategoryAccessibility, Severity: severity, Message: message,
+			Suggestions: []string{fmt.Sprintf("Darken or lighten the text or background of %s to reach %g:1", region.Name, required)},
+		})
+	}
+	result.Metrics["contrast"] = measured
+	e.annotateScreenshot(result, filename, annotations)
+	e.logger.Infof("Contrast check %s: %d of %d region(s) below WCAG %s", action.Name, len(violations), len(contrasts), check.Level)
+
+	if check.Fail && len(violations) > 0 {
+		return fmt.Errorf("%d region(s) below WCAG %s contrast: %s", len(violations), check.Level, strings.Join(violations, "; "))
+	}
+	return nil
+}
+
+// elementBox finds the screenshot pixels of the element matching selector
+func elementBox(platform platforms.Platform, selector string) (image.Rectangle, error) {
+	evaluator, ok := platform.(scriptEvaluator)
+	if !ok {
+		return image.Rectangle{}, fmt.Errorf("selectors are only supported on web apps; give x, y, width and height")
+	}
+	quoted, _ := json.Marshal(selector)
+	value, err := evaluator.Evaluate(fmt.Sprintf(elementBoxScript, quoted))
+	if err != nil {
+		return image.Rectangle{}, err
+	}
+	box, ok := value.(map[string]interface{})
+	if !ok {
+		return image.Rectangle{}, fmt.Errorf("no element matches %s", selector)
+	}
+	x, _ := box["x"].(float64)
+	y, _ := box["y"].(float64)
+	w, _ := box["width"].(float64)
+	h, _ := box["height"].(float64)
+	if w < 1 || h < 1 {
+		return image.Rectangle{}, fmt.Errorf("element %s is not visible", selector)
+	}
+	return image.Rect(int(math.Floor(x)), int(math.Floor(y)), int(math.Ceil(x+w)), int(math.Ceil(y+h))), nil
+}
+
+func hexColor(c color.RGBA) string {
+	return fmt.Sprintf("#%02x%02x%02x", c.R, c.G, c.B)
+}
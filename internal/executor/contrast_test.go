@@ -0,0 +1,97 @@
+package executor
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"panoptic/internal/config"
+	"panoptic/internal/logger"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// evaluatingPlatform answers element box scripts for known selectors
+type evaluatingPlatform struct {
+	*screenPlatform
+	boxes map[string]map[string]interface{}
+}
+
+func (p *evaluatingPlatform) Evaluate(js string) (interface{}, error) {
+	for selector, box := range p.boxes {
+		if strings.Contains(js, `"`+selector+`"`) {
+			return box, nil
+		}
+	}
+	return nil, nil
+}
+
+// writeContrastScreen saves a screenshot with #777 text on white at the
+// left and black text on white at the right
+func writeContrastScreen(t *testing.T) string {
+	img := image.NewRGBA(image.Rect(0, 0, 200, 40))
+	draw.Draw(img, img.Bounds(), &image.Uniform{color.White}, image.Point{}, draw.Src)
+	for x := 4; x < 196; x += 6 {
+		ink := color.RGBA{0x77, 0x77, 0x77, 255}
+		if x >= 100 {
+			ink = color.RGBA{0, 0, 0, 255}
+		}
+		draw.Draw(img, image.Rect(x, 8, x+2, 32), &image.Uniform{ink}, image.Point{}, draw.Src)
+	}
+	path := filepath.Join(t.TempDir(), "screen.png")
+	f, err := os.Create(path)
+	require.NoError(t, err)
+	defer f.Close()
+	require.NoError(t, png.Encode(f, img))
+	return path
+}
+
+// TestExecutor_CheckContrast tests findings for regions below the WCAG
+// minimum, selector regions, and failing the action on request
+func TestExecutor_CheckContrast(t *testing.T) {
+	executor := NewExecutor(&config.Config{}, t.TempDir(), logger.NewLogger(false))
+	result := &TestResult{StartTime: time.Now(), Metrics: map[string]interface{}{}}
+	recording := ""
+	platform := &evaluatingPlatform{
+		screenPlatform: &screenPlatform{MockPlatform: &MockPlatform{metrics: map[string]interface{}{}}, fixture: writeContrastScreen(t)},
+		boxes:          map[string]map[string]interface{}{"#total": {"x": 100.0, "y": 0.0, "width": 100.0, "height": 40.0}},
+	}
+
+	check := config.Action{Name: "contrast", Type: "vision_contrast_check", Parameters: map[string]interface{}{"regions": []interface{}{
+		map[string]interface{}{"name": "hint", "x": 0, "y": 0, "width": 100, "height": 40},
+		map[string]interface{}{"name": "hint_large", "x": 0, "y": 0, "width": 100, "height": 40, "large": true},
+		map[string]interface{}{"selector": "#total"},
+	}}}
+	require.NoError(t, executor.executeAction(platform, check, config.AppConfig{Name: "Shop"}, result, &recording))
+
+	measured := result.Metrics["contrast"].([]ContrastMeasurement)
+	require.Len(t, measured, 3)
+	assert.Equal(t, ContrastMeasurement{Action: "contrast", Region: "hint", Bounds: image.Rect(0, 0, 100, 40),
+		Foreground: "#777777", Background: "#ffffff", Ratio: 4.48, Required: 4.5}, measured[0])
+	assert.True(t, measured[1].Passed, "large text needs 3:1")
+	assert.Equal(t, image.Rect(100, 0, 200, 40), measured[2].Bounds)
+	assert.Equal(t, 21.0, measured[2].Ratio)
+
+	require.Len(t, result.Findings, 1)
+	assert.Equal(t, Finding{Action: "contrast", Type: "contrast", Category: "accessibility", Severity: "warning",
+		Message:     "hint: text #777777 on #ffffff has contrast 4.48:1, below the WCAG AA minimum of 4.5:1",
+		Suggestions: []string{"Darken or lighten the text or background of hint to reach 4.5:1"}}, result.Findings[0])
+
+	check.Parameters["fail"] = true
+	err := executor.executeAction(platform, check, config.AppConfig{Name: "Shop"}, result, &recording)
+	assert.EqualError(t, err, "1 region(s) below WCAG AA contrast: hint: text #777777 on #ffffff has contrast 4.48:1, below the WCAG AA minimum of 4.5:1")
+	assert.Equal(t, "error", result.Findings[1].Severity)
+
+	check.Parameters = map[string]interface{}{"regions": []interface{}{map[string]interface{}{"selector": "#missing"}}}
+	err = executor.executeAction(platform, check, config.AppConfig{Name: "Shop"}, result, &recording)
+	assert.EqualError(t, err, "region #missing: no element matches #missing")
+	err = executor.executeAction(platform.screenPlatform, check, config.AppConfig{Name: "Shop"}, result, &recording)
+	assert.ErrorContains(t, err, "selectors are only supported on web apps")
+}
@@ -0,0 +1,58 @@
+package executor
+
+import (
+	"panoptic/internal/config"
+	"panoptic/internal/history"
+	"panoptic/internal/platforms"
+)
+
+// visitRoute records that the running app's page opened a URL
+func (e *Executor) visitRoute(result *TestResult, rawURL string) {
+	route := history.Route(rawURL)
+	if route == "" {
+		return
+	}
+	e.coverageMu.Lock()
+	defer e.coverageMu.Unlock()
+	e.pageRoute = route
+	result.Routes = addRoute(result.Routes, rawURL)
+}
+
+// recordCoverage adds the element an action interacted with, on the route
+// the page was on, and the route the page is on after it, which a click or
+// a redirect may have changed
+func (e *Executor) recordCoverage(platform platforms.Platform, action config.Action, result *TestResult) {
+	if selector := interactedSelector(action); selector != "" {
+		e.coverageMu.Lock()
+		element := history.Element{Route: e.pageRoute, Selector: selector}
+		if !containsElement(result.Elements, element) {
+			result.Elements = append(result.Elements, element)
+		}
+		e.coverageMu.Unlock()
+	}
+	if reporter, ok := platform.(urlReporter); ok {
+		if pageURL, err := reporter.CurrentURL(); err == nil {
+			e.visitRoute(result, pageURL)
+		}
+	}
+}
+
+// interactedSelector is the selector of the element an action used, if any
+func interactedSelector(action config.Action) string {
+	if action.Selector != "" {
+		return action.Selector
+	}
+	if action.Type == "click" {
+		return action.Target
+	}
+	return ""
+}
+
+func containsElement(elements []history.Element, element history.Element) bool {
+	for _, e := range elements {
+		if e == element {
+			return true
+		}
+	}
+	return false
+}
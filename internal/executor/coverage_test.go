@@ -0,0 +1,75 @@
+package executor
+
+import (
+	"testing"
+
+	"panoptic/internal/config"
+	"panoptic/internal/history"
+	"panoptic/internal/logger"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// pagePlatform is a mock platform whose page follows navigations and moves
+// to the checkout when its button is clicked
+type pagePlatform struct {
+	*MockPlatform
+	url string
+}
+
+func (p *pagePlatform) Navigate(url string) error {
+	p.url = url
+	return p.MockPlatform.Navigate(url)
+}
+
+func (p *pagePlatform) Click(selector string) error {
+	if selector == "#checkout" {
+		p.url = "https://shop.test/checkout/?step=1"
+	}
+	return p.MockPlatform.Click(selector)
+}
+
+func (p *pagePlatform) CurrentURL() (string, error) { return p.url, nil }
+
+// TestExecutor_RecordCoverage tests that the routes an app opened and the
+// elements it interacted with, on the route they were on, are recorded
+func TestExecutor_RecordCoverage(t *testing.T) {
+	executor := NewExecutor(&config.Config{}, t.TempDir(), logger.NewLogger(false))
+	platform := &pagePlatform{MockPlatform: &MockPlatform{metrics: map[string]interface{}{}}}
+	app := config.AppConfig{Name: "Shop", Type: "web", URL: "https://shop.test/"}
+	result := &TestResult{Metrics: map[string]interface{}{}}
+	recording := ""
+
+	executor.visitRoute(result, app.URL)
+	for _, action := range []config.Action{
+		{Name: "cart", Type: "navigate", URL: "https://shop.test/cart"},
+		{Name: "coupon", Type: "fill", Selector: "#coupon", Value: "SAVE10"},
+		{Name: "checkout", Type: "click", Target: "#checkout"},
+		{Name: "checkout again", Type: "click", Target: "#checkout"},
+	} {
+		require.NoError(t, executor.executeAction(platform, action, app, result, &recording))
+	}
+
+	assert.Equal(t, []string{"/", "/cart", "/checkout"}, result.Routes)
+	assert.Equal(t, []history.Element{
+		{Route: "/cart", Selector: "#coupon"},
+		{Route: "/cart", Selector: "#checkout"},
+		{Route: "/checkout", Selector: "#checkout"},
+	}, result.Elements)
+}
+
+// TestExecutor_RecordCoverage_NoPageURL tests that platforms that can't tell
+// their page's URL keep the route of the last navigation
+func TestExecutor_RecordCoverage_NoPageURL(t *testing.T) {
+	executor := NewExecutor(&config.Config{}, t.TempDir(), logger.NewLogger(false))
+	platform := &MockPlatform{metrics: map[string]interface{}{}}
+	app := config.AppConfig{Name: "Shop", Type: "web"}
+	result := &TestResult{Metrics: map[string]interface{}{}}
+	recording := ""
+
+	require.NoError(t, executor.executeAction(platform, config.Action{Name: "cart", Type: "navigate", URL: "https://shop.test/cart"}, app, result, &recording))
+	require.NoError(t, executor.executeAction(platform, config.Action{Name: "pay", Type: "submit", Selector: "form#pay"}, app, result, &recording))
+	assert.Equal(t, []string{"/cart"}, result.Routes)
+	assert.Equal(t, []history.Element{{Route: "/cart", Selector: "form#pay"}}, result.Elements)
+}
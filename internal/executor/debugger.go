@@ -0,0 +1,218 @@
+package executor
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"panoptic/internal/config"
+	"panoptic/internal/platforms"
+)
+
+// debugDecision tells the action loop how to proceed after a debugger prompt
+type debugDecision int
+
+const (
+	debugContinue debugDecision = iota // run until the next pause or failure
+	debugStep                          // run the next action, then prompt again
+	debugRetry                         // re-run the failed (or previous) action
+	debugSkip                          // ignore the failure and move on
+	debugAbort                         // stop the app with an error
+)
+
+// DebugOptions configures interactive debug mode
+type DebugOptions struct {
+	StepThrough bool      // prompt before every action
+	In          io.Reader // defaults to os.Stdin
+	Out         io.Writer // defaults to os.Stdout
+}
+
+// scriptEvaluator is implemented by platforms that can evaluate JavaScript
+type scriptEvaluator interface {
+	Evaluate(js string) (interface{}, error)
+}
+
+// debugger drives the interactive REPL shown on pause actions, failing
+// steps, and (when stepping) before every action
+type debugger struct {
+	in       *bufio.Scanner
+	out      io.Writer
+	stepping bool
+}
+
+// EnableDebugMode runs web apps headed and opens an interactive prompt on
+// pause actions and failing steps
+func (e *Executor) EnableDebugMode(opts DebugOptions) {
+	if opts.In == nil {
+		opts.In = os.Stdin
+	}
+	if opts.Out == nil {
+		opts.Out = os.Stdout
+	}
+	e.debugger = &debugger{
+		in:       bufio.NewScanner(opts.In),
+		out:      opts.Out,
+		stepping: opts.StepThrough,
+	}
+}
+
+const debugHelp = `Commands:
+  c, continue        resume until the next pause or failure
+  n, next            run the next action and pause again
+  r, retry           re-run the failed step (or the previous step at a pause)
+  s, skip            ignore the failure and continue with the next step
+  shot [file]        take a screenshot (default: screenshots/debug_<time>.png)
+  eval <js>          evaluate JavaScript in the page (web apps)
+  q, quit            abort this app
+  h, help            show this help
+`
+
+// prompt shows the REPL until the user chooses how to proceed. failure is nil
+// at pauses and step boundaries.
+func (d *debugger) prompt(e *Executor, platform platforms.Platform, app config.AppConfig, index int, action config.Action, failure error) debugDecision {
+	if failure != nil {
+		fmt.Fprintf(d.out, "\n[debug] %s: step %d %q (%s) failed: %v\n", app.Name, index+1, action.Name, action.Type, failure)
+	} else {
+		fmt.Fprintf(d.out, "\n[debug] %s: paused at step %d %q (%s)\n", app.Name, index+1, action.Name, action.Type)
+	}
+
+	for {
+		fmt.Fprint(d.out, "(panoptic) ")
+		if !d.in.Scan() {
+			// Input closed: behave like quit on failure, continue at pauses
+			fmt.Fprintln(d.out)
+			d.stepping = false
+			if failure != nil {
+				return debugAbort
+			}
+			return debugContinue
+		}
+
+		line := strings.TrimSpace(d.in.Text())
+		cmd, arg, _ := strings.Cut(line, " ")
+		arg = strings.TrimSpace(arg)
+
+		switch cmd {
+		case "":
+			continue
+		case "c", "continue":
+			d.stepping = false
+			if failure != nil {
+				return debugSkip
+			}
+			return debugContinue
+		case "n", "next":
+			d.stepping = true
+			if failure != nil {
+				return debugSkip
+			}
+			return debugStep
+		case "r", "retry":
+			return debugRetry
+		case "s", "skip":
+			return debugSkip
+		case "q", "quit", "exit":
+			d.stepping = false
+			return debugAbort
+		case "shot", "screenshot":
+			d.screenshot(e, platform, app, arg)
+		case "eval", "e":
+			d.evaluate(platform, arg)
+		case "h", "help", "?":
+			fmt.Fprint(d.out, debugHelp)
+		default:
+			fmt.Fprintf(d.out, "unknown command %q (type help)\n", cmd)
+		}
+	}
+}
+
+func (d *debugger) screenshot(e *Executor, platform platforms.Platform, app config.AppConfig, name string) {
+	if platform == nil {
+		fmt.Fprintln(d.out, "no platform initialized")
+		return
+	}
+	if name == "" {
+		name = fmt.Sprintf("debug_%s_%d.png", app.Name, time.Now().Unix())
+	}
+	path := name
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(e.outputDir, "screenshots", name)
+	}
+	if err := platform.Screenshot(path); err != nil {
+		fmt.Fprintf(d.out, "screenshot failed: %v\n", err)
+		return
+	}
+	fmt.Fprintf(d.out, "screenshot saved: %s\n", path)
+}
+
+func (d *debugger) evaluate(platform platforms.Platform, js string) {
+	if js == "" {
+		fmt.Fprintln(d.out, "usage: eval <javascript>")
+		return
+	}
+	evaluator, ok := platform.(scriptEvaluator)
+	if !ok {
+		fmt.Fprintln(d.out, "eval is only supported on web apps")
+		return
+	}
+	value, err := evaluator.Evaluate(js)
+	if err != nil {
+		fmt.Fprintf(d.out, "error: %v\n", err)
+		return
+	}
+	fmt.Fprintf(d.out, "%v\n", value)
+}
+
+// debugBeforeAction prompts at pause actions and, when stepping, before every
+// action. Retrying at a pause re-runs the previous action. Returns false when
+// the user aborts.
+func (e *Executor) debugBeforeAction(platform platforms.Platform, app config.AppConfig, actions []config.Action, index int, result *TestResult, recordingFile *string) bool {
+	action := actions[index]
+	if !e.debugger.stepping && action.Type != "pause" {
+		return true
+	}
+
+	for {
+		switch e.debugger.prompt(e, platform, app, index, action, nil) {
+		case debugAbort:
+			return false
+		case debugRetry:
+			if index == 0 {
+				fmt.Fprintln(e.debugger.out, "no previous step to retry")
+				continue
+			}
+			previous := actions[index-1]
+			if err := e.executeAction(platform, previous, app, result, recordingFile); err != nil {
+				fmt.Fprintf(e.debugger.out, "step %q failed again: %v\n", previous.Name, err)
+			} else {
+				fmt.Fprintf(e.debugger.out, "step %q succeeded\n", previous.Name)
+			}
+		default:
+			return true
+		}
+	}
+}
+
+// debugOnFailure prompts after a failing action until it is retried
+// successfully, skipped (returns nil) or the user aborts (returns the error)
+func (e *Executor) debugOnFailure(platform platforms.Platform, app config.AppConfig, action config.Action, index int, err error, result *TestResult, recordingFile *string) error {
+	for err != nil {
+		switch e.debugger.prompt(e, platform, app, index, action, err) {
+		case debugRetry:
+			err = e.executeAction(platform, action, app, result, recordingFile)
+			if err == nil {
+				fmt.Fprintf(e.debugger.out, "step %q succeeded\n", action.Name)
+			}
+		case debugAbort:
+			return err
+		default:
+			e.logger.Warnf("Skipping failed action '%s' in debugger: %v", action.Name, err)
+			return nil
+		}
+	}
+	return nil
+}
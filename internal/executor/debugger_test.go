@@ -0,0 +1,137 @@
+package executor
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+
+	"panoptic/internal/config"
+	"panoptic/internal/logger"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// flakyPlatform fails the first clicks and evaluates scripts by echoing them
+type flakyPlatform struct {
+	*MockPlatform
+	clickFailures int
+}
+
+func (f *flakyPlatform) Click(selector string) error {
+	if f.clickFailures > 0 {
+		f.clickFailures--
+		return fmt.Errorf("element %s not found", selector)
+	}
+	return f.MockPlatform.Click(selector)
+}
+
+func (f *flakyPlatform) Evaluate(js string) (interface{}, error) {
+	return "evaluated:" + js, nil
+}
+
+func newDebugExecutor(t *testing.T, input string, step bool) (*Executor, *bytes.Buffer) {
+	t.Helper()
+	executor := NewExecutor(&config.Config{}, t.TempDir(), logger.NewLogger(false))
+	out := &bytes.Buffer{}
+	executor.EnableDebugMode(DebugOptions{StepThrough: step, In: strings.NewReader(input), Out: out})
+	return executor, out
+}
+
+func newFlakyPlatform(failures int) *flakyPlatform {
+	return &flakyPlatform{MockPlatform: &MockPlatform{metrics: map[string]interface{}{}}, clickFailures: failures}
+}
+
+// TestDebugger_RetryFailedStep tests retrying a failing action until it succeeds
+func TestDebugger_RetryFailedStep(t *testing.T) {
+	executor, out := newDebugExecutor(t, "retry\nretry\n", false)
+	platform := newFlakyPlatform(2)
+	action := config.Action{Name: "login", Type: "click", Selector: "#login"}
+	result := &TestResult{}
+	recording := ""
+
+	err := executor.executeAction(platform, action, config.AppConfig{Name: "app"}, result, &recording)
+	require.Error(t, err)
+
+	err = executor.debugOnFailure(platform, config.AppConfig{Name: "app"}, action, 0, err, result, &recording)
+
+	assert.NoError(t, err)
+	assert.Len(t, platform.executedActions, 1, "click should succeed on the second retry")
+	assert.Contains(t, out.String(), `step "login" succeeded`)
+}
+
+// TestDebugger_SkipAndAbort tests skipping and aborting failing actions
+func TestDebugger_SkipAndAbort(t *testing.T) {
+	action := config.Action{Name: "login", Type: "click", Selector: "#login"}
+	failure := fmt.Errorf("boom")
+
+	executor, _ := newDebugExecutor(t, "skip\n", false)
+	assert.NoError(t, executor.debugOnFailure(newFlakyPlatform(0), config.AppConfig{}, action, 0, failure, &TestResult{}, new(string)))
+
+	executor, _ = newDebugExecutor(t, "quit\n", false)
+	assert.Equal(t, failure, executor.debugOnFailure(newFlakyPlatform(0), config.AppConfig{}, action, 0, failure, &TestResult{}, new(string)))
+
+	executor, _ = newDebugExecutor(t, "", false)
+	assert.Equal(t, failure, executor.debugOnFailure(newFlakyPlatform(0), config.AppConfig{}, action, 0, failure, &TestResult{}, new(string)),
+		"closed input should abort on failure rather than hang")
+}
+
+// TestDebugger_PauseCommands tests the REPL at a pause action
+func TestDebugger_PauseCommands(t *testing.T) {
+	executor, out := newDebugExecutor(t, "help\neval document.title\nshot here.png\nbogus\nretry\ncontinue\n", false)
+	platform := newFlakyPlatform(0)
+	actions := []config.Action{
+		{Name: "open", Type: "click", Selector: "#menu"},
+		{Name: "inspect", Type: "pause"},
+	}
+
+	ok := executor.debugBeforeAction(platform, config.AppConfig{Name: "app"}, actions, 1, &TestResult{}, new(string))
+
+	assert.True(t, ok)
+	output := out.String()
+	assert.Contains(t, output, "paused at step 2")
+	assert.Contains(t, output, "Commands:")
+	assert.Contains(t, output, "evaluated:document.title")
+	assert.Contains(t, output, "screenshot saved:")
+	assert.Contains(t, output, `unknown command "bogus"`)
+	assert.Contains(t, output, `step "open" succeeded`, "retry at a pause re-runs the previous step")
+
+	require.Len(t, platform.executedActions, 2)
+	assert.Equal(t, "screenshot", platform.executedActions[0].Type)
+	assert.Equal(t, "#menu", platform.executedActions[1].Selector)
+}
+
+// TestDebugger_StepThrough tests prompting before every action and leaving step mode
+func TestDebugger_StepThrough(t *testing.T) {
+	executor, out := newDebugExecutor(t, "next\ncontinue\n", true)
+	platform := newFlakyPlatform(0)
+	actions := []config.Action{
+		{Name: "a", Type: "click", Selector: "#a"},
+		{Name: "b", Type: "click", Selector: "#b"},
+		{Name: "c", Type: "click", Selector: "#c"},
+	}
+
+	for i := range actions {
+		require.True(t, executor.debugBeforeAction(platform, config.AppConfig{Name: "app"}, actions, i, &TestResult{}, new(string)))
+	}
+
+	assert.Equal(t, 2, strings.Count(out.String(), "paused at step"), "continue should leave step mode")
+}
+
+// TestDebugger_QuitAtPause tests aborting from a pause
+func TestDebugger_QuitAtPause(t *testing.T) {
+	executor, _ := newDebugExecutor(t, "q\n", false)
+	actions := []config.Action{{Name: "wait-here", Type: "pause"}}
+
+	assert.False(t, executor.debugBeforeAction(newFlakyPlatform(0), config.AppConfig{}, actions, 0, &TestResult{}, new(string)))
+}
+
+// TestExecuteAction_PauseWithoutDebugger tests that pause is a no-op outside debug mode
+func TestExecuteAction_PauseWithoutDebugger(t *testing.T) {
+	executor := NewExecutor(&config.Config{}, t.TempDir(), logger.NewLogger(false))
+
+	err := executor.executeAction(nil, config.Action{Name: "p", Type: "pause"}, config.AppConfig{}, &TestResult{}, new(string))
+
+	assert.NoError(t, err)
+}
@@ -0,0 +1,155 @@
+package executor
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"image"
+	"image/png"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"panoptic/internal/config"
+	"panoptic/internal/figma"
+	"panoptic/internal/platforms"
+	"panoptic/internal/vision"
+)
+
+// designRenderer renders the nodes of design files as PNGs
+type designRenderer interface {
+	Render(ctx context.Context, fileKey, nodeID string, scale float64) ([]byte, error)
+}
+
+// DesignDrift is recorded in the design_drift metric for every component a
+// vision_design_compare action compared against its design
+type DesignDrift struct {
+	Action    string  `json:"action"`
+	Component string  `json:"component"`
+	File      string  `json:"file"`
+	Node      string  `json:"node"`
+	Selector  string  `json:"selector"`
+	DiffRatio float64 `json:"diff_ratio"` // share of the design's pixels the element differs in
+	Tolerance float64 `json:"tolerance"`
+	Distance  int     `json:"phash_distance"`
+	SizeDrift string  `json:"size_drift,omitempty"` // design and element sizes, when they differ
+	Passed    bool    `json:"passed"`
+	Element   string  `json:"element"` // element screenshot
+	Design    string  `json:"design"`  // design render
+	Diff      string  `json:"diff"`    // differing pixels in red
+}
+
+// designRendererFor returns the renderer of the Figma settings, created
+// once per run
+func (e *Executor) designRendererFor(settings *config.FigmaSettings) designRenderer {
+	if e.figma == nil {
+		e.figma = figma.NewClient(os.ExpandEnv(settings.Token), settings.URL)
+	}
+	return e.figma
+}
+
+// compareDesign screenshots the element of the action's selector and
+// compares it against the render of its Figma component. Drift beyond the
+// tolerance is reported as a design finding, and fails the action when it
+// sets fail.
+func (e *Executor) compareDesign(ctx context.Context, platform platforms.Platform, action config.Action, app config.AppConfig, result *TestResult) error {
+	settings := e.config.Settings.Figma
+	compare, err := action.DesignCompare(settings)
+	if err != nil {
+		return err
+	}
+	box, err := elementBox(platform, action.Selector)
+	if err != nil {
+		return err
+	}
+	render, err := e.designRendererFor(settings).Render(ctx, compare.File, compare.Node, settings.ScaleOrDefault())
+	if err != nil {
+		return err
+	}
+	designImage, _, err := image.Decode(bytes.NewReader(render))
+	if err != nil {
+		return fmt.Errorf("figma: invalid render of node %s: %w", compare.Node, err)
+	}
+
+	stamp := time.Now().Unix()
+	filename := filepath.Join(e.outputDir, "screenshots", fmt.Sprintf("%s_%s_%d.png", app.Name, action.Name, stamp))
+	if err := e.platformCall(ctx, app, "Screenshot", func() error { return platform.Screenshot(filename) }); err != nil {
+		return err
+	}
+	result.Screenshots = append(result.Screenshots, filename)
+	page, err := decodePNGFile(filename)
+	if err != nil {
+		return err
+	}
+	element := vision.Crop(page, box)
+	diff := vision.CompareImages(designImage, element, compare.Threshold)
+
+	prefix := filepath.Join(e.outputDir, "screenshots", "design", fmt.Sprintf("%s_%s_%d", app.Name, action.Name, stamp))
+	drift := DesignDrift{
+		Action: action.Name, Component: compare.Component, File: compare.File, Node: compare.Node, Selector: action.Selector,
+		DiffRatio: math.Round(diff.Ratio*10000) / 10000, Tolerance: compare.Tolerance, Distance: diff.Distance,
+		Passed:  diff.Ratio <= compare.Tolerance,
+		Element: prefix + "_element.png", Design: prefix + "_design.png", Diff: prefix + "_diff.png",
+	}
+	if diff.Scaled {
+		drift.SizeDrift = fmt.Sprintf("design %dx%d, element %dx%d", designImage.Bounds().Dx(), designImage.Bounds().Dy(), element.Bounds().Dx(), element.Bounds().Dy())
+	}
+	for path, img := range map[string]image.Image{drift.Element: element, drift.Design: designImage, drift.Diff: diff.Image} {
+		if err := writePNGFile(path, img); err != nil {
+			return err
+		}
+	}
+	if result.Metrics == nil {
+		result.Metrics = make(map[string]interface{})
+	}
+	drifts, _ := result.Metrics["design_drift"].([]DesignDrift)
+	result.Metrics["design_drift"] = append(drifts, drift)
+	e.logger.Infof("Design check %s: %s differs from its design in %.2f%% of pixels (tolerance %.2f%%)",
+		action.Name, compare.Component, diff.Ratio*100, compare.Tolerance*100)
+	if drift.Passed {
+		return nil
+	}
+
+	severity := config.SeverityWarning
+	if compare.Fail {
+		severity = config.SeverityError
+	}
+	details := []string{fmt.Sprintf("%.2f%% of pixels differ, above the tolerance of %.2f%%", diff.Ratio*100, compare.Tolerance*100)}
+	if drift.SizeDrift != "" {
+		details = append(details, drift.SizeDrift)
+	}
+	message := fmt.Sprintf("%s drifted from its Figma design (node %s): %s", compare.Component, compare.Node, strings.Join(details, "; "))
+	result.Findings = append(result.Findings, Finding{
+		Action: action.Name, Type: "design_drift", Category: config.CategoryDesign, Severity: severity, Message: message,
+		Suggestions: []string{fmt.Sprintf("Compare %s with %s, or update the design if the change is intended", drift.Element, drift.Design)},
+	})
+	if compare.Fail {
+		return fmt.Errorf("%s", message)
+	}
+	return nil
+}
+
+func decodePNGFile(path string) (image.Image, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode %s: %w", path, err)
+	}
+	return img, nil
+}
+
+func writePNGFile(path string, img image.Image) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return fmt.Errorf("failed to encode %s: %w", path, err)
+	}
+	return os.WriteFile(path, buf.Bytes(), 0644)
+}
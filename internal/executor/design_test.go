@@ -0,0 +1,90 @@
+package executor
+
+import (
+	"bytes"
+	"context"
+	"image"
+	"image/png"
+	"testing"
+	"time"
+
+	"panoptic/internal/config"
+	"panoptic/internal/logger"
+	"panoptic/internal/vision"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeRenderer renders every node as the same image
+type fakeRenderer struct {
+	render  []byte
+	renders []string
+}
+
+func (r *fakeRenderer) Render(ctx context.Context, fileKey, nodeID string, scale float64) ([]byte, error) {
+	r.renders = append(r.renders, fileKey+"/"+nodeID)
+	return r.render, nil
+}
+
+// cropPNG encodes a region of an image file, as a design of it
+func cropPNG(t *testing.T, path string, region image.Rectangle) []byte {
+	img, err := decodePNGFile(path)
+	require.NoError(t, err)
+	var buf bytes.Buffer
+	require.NoError(t, png.Encode(&buf, vision.Crop(img, region)))
+	return buf.Bytes()
+}
+
+// TestExecutor_CompareDesign tests that an element matching its design
+// passes, and one that drifted is reported, failing the action on request
+func TestExecutor_CompareDesign(t *testing.T) {
+	screen := writeContrastScreen(t)
+	cfg := &config.Config{Settings: config.Settings{Figma: &config.FigmaSettings{Token: "t", FileKey: "FiLeKeY"}}}
+	executor := NewExecutor(cfg, t.TempDir(), logger.NewLogger(false))
+	renderer := &fakeRenderer{render: cropPNG(t, screen, image.Rect(100, 0, 200, 40))}
+	executor.figma = renderer
+	result := &TestResult{StartTime: time.Now(), Metrics: map[string]interface{}{}}
+	platform := &evaluatingPlatform{
+		screenPlatform: &screenPlatform{MockPlatform: &MockPlatform{metrics: map[string]interface{}{}}, fixture: screen},
+		boxes:          map[string]map[string]interface{}{"#total": {"x": 100.0, "y": 0.0, "width": 100.0, "height": 40.0}},
+	}
+	app := config.AppConfig{Name: "Shop"}
+
+	compare := config.Action{Name: "total", Type: "vision_design_compare", Selector: "#total", Parameters: map[string]interface{}{"node": "12-34", "component": "Cart/Total"}}
+	require.NoError(t, executor.executeAction(platform, compare, app, result, nil))
+	assert.Equal(t, []string{"FiLeKeY/12:34"}, renderer.renders)
+	drifts := result.Metrics["design_drift"].([]DesignDrift)
+	require.Len(t, drifts, 1)
+	assert.True(t, drifts[0].Passed)
+	assert.Zero(t, drifts[0].DiffRatio)
+	for _, path := range []string{drifts[0].Element, drifts[0].Design, drifts[0].Diff} {
+		assert.FileExists(t, path)
+	}
+	assert.Empty(t, result.Findings)
+
+	renderer.render = cropPNG(t, screen, image.Rect(0, 0, 100, 40))
+	require.NoError(t, executor.executeAction(platform, compare, app, result, nil))
+	drifts = result.Metrics["design_drift"].([]DesignDrift)
+	require.Len(t, drifts, 2)
+	assert.False(t, drifts[1].Passed)
+	assert.Greater(t, drifts[1].DiffRatio, config.DefaultDesignTolerance)
+	require.Len(t, result.Findings, 1)
+	assert.Equal(t, "design_drift", result.Findings[0].Type)
+	assert.Equal(t, "design", result.Findings[0].Category)
+	assert.Equal(t, "warning", result.Findings[0].Severity)
+	assert.Contains(t, result.Findings[0].Message, "Cart/Total drifted from its Figma design (node 12:34)")
+
+	renderer.render = cropPNG(t, screen, image.Rect(0, 0, 50, 20))
+	compare.Parameters["fail"] = true
+	err := executor.executeAction(platform, compare, app, result, nil)
+	assert.ErrorContains(t, err, "Cart/Total drifted from its Figma design (node 12:34)")
+	assert.ErrorContains(t, err, "design 50x20, element 100x40")
+	assert.Equal(t, "error", result.Findings[1].Severity)
+
+	compare.Selector = "#missing"
+	assert.EqualError(t, executor.executeAction(platform, compare, app, result, nil), "no element matches #missing")
+	renderer.render = []byte("not a png")
+	compare.Selector = "#total"
+	assert.ErrorContains(t, executor.executeAction(platform, compare, app, result, nil), "figma: invalid render of node 12:34")
+}
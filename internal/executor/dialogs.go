@@ -0,0 +1,85 @@
+package executor
+
+import (
+	"fmt"
+	"time"
+
+	"panoptic/internal/config"
+	"panoptic/internal/platforms"
+)
+
+// dialogWait is how long an expected dialog may take to open once its
+// action is done, since pages open dialogs from their own event handlers
+var dialogWait = 2 * time.Second
+
+// dialogHandler is implemented by platforms that answer the native dialogs
+// their pages open
+type dialogHandler interface {
+	SetDialogPolicy(accept bool, promptText string) error
+	TakeDialogs() ([]platforms.Dialog, error)
+}
+
+// DialogRecord is recorded in the dialogs metric for every dialog a page
+// opened during an action
+type DialogRecord struct {
+	Action string `json:"action"`
+	platforms.Dialog
+}
+
+// setDialogPolicy tells the platform how to answer the dialogs the action
+// opens, as its dialog or the app's dialogs say
+func (e *Executor) setDialogPolicy(platform platforms.Platform, action config.Action, app config.AppConfig) error {
+	handler, ok := platform.(dialogHandler)
+	if !ok {
+		return nil
+	}
+	policy := config.DialogPolicyFor(app, action)
+	return handler.SetDialogPolicy(policy.Accepts(), policy.PromptText)
+}
+
+// collectDialogs records the dialogs the action opened and, when its dialog
+// expects one, fails unless one with the expected text opened. The error
+// of the action itself wins over a missed dialog.
+func (e *Executor) collectDialogs(platform platforms.Platform, action config.Action, app config.AppConfig, result *TestResult, actionErr error) error {
+	policy := config.DialogPolicyFor(app, action)
+	handler, ok := platform.(dialogHandler)
+	if !ok {
+		if actionErr == nil && policy.Expect != "" {
+			return fmt.Errorf("dialog expectations are not supported on this platform")
+		}
+		return actionErr
+	}
+
+	expected := policy.Expect == ""
+	deadline := time.Now().Add(dialogWait)
+	for {
+		opened, err := handler.TakeDialogs()
+		if err != nil {
+			if actionErr != nil {
+				return actionErr
+			}
+			return err
+		}
+		for _, dialog := range opened {
+			e.recordDialog(action, dialog, result)
+			expected = expected || policy.ExpectedBy(dialog.Message)
+		}
+		if expected || actionErr != nil || time.Now().After(deadline) {
+			break
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+	if actionErr != nil {
+		return actionErr
+	}
+	if !expected {
+		return fmt.Errorf("action '%s' expected a dialog containing %q, none opened", action.Name, policy.Expect)
+	}
+	return nil
+}
+
+func (e *Executor) recordDialog(action config.Action, dialog platforms.Dialog, result *TestResult) {
+	e.logger.Infof("Action %s opened a %s dialog: %q (accepted: %t)", action.Name, dialog.Type, dialog.Message, dialog.Accepted)
+	dialogs, _ := result.Metrics["dialogs"].([]DialogRecord)
+	result.Metrics["dialogs"] = append(dialogs, DialogRecord{Action: action.Name, Dialog: dialog})
+}
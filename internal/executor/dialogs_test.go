@@ -0,0 +1,70 @@
+package executor
+
+import (
+	"testing"
+	"time"
+
+	"panoptic/internal/config"
+	"panoptic/internal/logger"
+	"panoptic/internal/platforms"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// dialogPlatform is a mock platform whose clicks open a confirm dialog,
+// answered under the policy set
+type dialogPlatform struct {
+	*MockPlatform
+	message    string
+	accept     bool
+	promptText string
+	opened     []platforms.Dialog
+}
+
+func (p *dialogPlatform) Click(selector string) error {
+	if p.message != "" {
+		p.opened = append(p.opened, platforms.Dialog{Type: "confirm", Message: p.message, Accepted: p.accept})
+	}
+	return nil
+}
+
+func (p *dialogPlatform) SetDialogPolicy(accept bool, promptText string) error {
+	p.accept, p.promptText = accept, promptText
+	return nil
+}
+
+func (p *dialogPlatform) TakeDialogs() ([]platforms.Dialog, error) {
+	opened := p.opened
+	p.opened = nil
+	return opened, nil
+}
+
+func TestExecutor_Dialogs(t *testing.T) {
+	dialogWait = 50 * time.Millisecond
+	t.Cleanup(func() { dialogWait = 2 * time.Second })
+
+	executor := NewExecutor(&config.Config{}, t.TempDir(), logger.NewLogger(false))
+	result := &TestResult{Metrics: map[string]interface{}{}}
+	platform := &dialogPlatform{MockPlatform: &MockPlatform{metrics: map[string]interface{}{}}, message: "Delete this order?"}
+	app := config.AppConfig{Name: "Shop", Type: "web", Dialogs: &config.DialogPolicy{Response: "accept", PromptText: "Ada"}}
+
+	deleteOrder := config.Action{Name: "delete", Type: "click", Selector: "#delete", Dialog: &config.DialogPolicy{Expect: "delete this order"}}
+	require.NoError(t, executor.executeAction(platform, deleteOrder, app, result, nil))
+	assert.True(t, platform.accept)
+	assert.Equal(t, "Ada", platform.promptText)
+
+	cancel := config.Action{Name: "cancel", Type: "click", Selector: "#cancel", Dialog: &config.DialogPolicy{Response: "dismiss"}}
+	require.NoError(t, executor.executeAction(platform, cancel, app, result, nil))
+	assert.Equal(t, []DialogRecord{
+		{Action: "delete", Dialog: platforms.Dialog{Type: "confirm", Message: "Delete this order?", Accepted: true}},
+		{Action: "cancel", Dialog: platforms.Dialog{Type: "confirm", Message: "Delete this order?"}},
+	}, result.Metrics["dialogs"])
+
+	platform.message = ""
+	err := executor.executeAction(platform, deleteOrder, app, result, nil)
+	assert.EqualError(t, err, `action 'delete' expected a dialog containing "delete this order", none opened`)
+
+	err = executor.executeAction(&MockPlatform{metrics: map[string]interface{}{}}, deleteOrder, app, result, nil)
+	assert.EqualError(t, err, "dialog expectations are not supported on this platform")
+}
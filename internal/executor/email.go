@@ -0,0 +1,93 @@
+package executor
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"time"
+
+	"panoptic/internal/config"
+	"panoptic/internal/inbox"
+)
+
+// EmailReceived is recorded in the emails metric for every wait_for_email
+// action; extracted values are left out, as they are often secrets
+type EmailReceived struct {
+	Action    string    `json:"action"`
+	From      string    `json:"from"`
+	Subject   string    `json:"subject"`
+	Received  time.Time `json:"received"`
+	Variables []string  `json:"variables,omitempty"`
+}
+
+// newInbox connects to the inbox in settings.inbox
+func newInbox(s *config.InboxSettings) (inbox.Inbox, error) {
+	switch s.Provider {
+	case "mailhog":
+		return inbox.NewMailHog(s.URL), nil
+	case "mailosaur":
+		return inbox.NewMailosaur(os.ExpandEnv(s.APIKey), s.URL, s.Server), nil
+	}
+	return nil, fmt.Errorf("unknown inbox provider %q", s.Provider)
+}
+
+// waitForEmail waits for a message received since the app started that no
+// earlier action consumed, and stores the values extracted from it as
+// variables
+func (e *Executor) waitForEmail(ctx context.Context, action config.Action, result *TestResult) error {
+	settings := e.config.Settings.Inbox
+	if settings == nil {
+		return fmt.Errorf("wait_for_email requires settings.inbox")
+	}
+	wait, err := action.EmailWait(settings)
+	if err != nil {
+		return err
+	}
+	if e.inbox == nil {
+		if e.inbox, err = newInbox(settings); err != nil {
+			return err
+		}
+	}
+	patterns := make(map[string]*regexp.Regexp, len(wait.Extract))
+	for name, pattern := range wait.Extract {
+		patterns[name] = regexp.MustCompile(pattern) // compiled by EmailWait
+	}
+
+	if e.seenMessages == nil {
+		e.seenMessages = make(map[string]bool)
+	}
+	query := inbox.Query{From: wait.From, To: wait.To, Subject: wait.Subject, Since: result.StartTime, Seen: e.seenMessages}
+	waitCtx, cancel := context.WithTimeout(ctx, wait.Timeout)
+	defer cancel()
+	msg, err := inbox.Wait(waitCtx, e.inbox, query, settings.IntervalOrDefault())
+	if err != nil {
+		return fmt.Errorf("wait_for_email after %s: %w", wait.Timeout, err)
+	}
+	e.seenMessages[msg.ID] = true
+
+	values, err := inbox.Extract(msg, patterns)
+	if err != nil {
+		return err
+	}
+	if e.vars == nil {
+		e.vars = make(map[string]string)
+	}
+	names := make([]string, 0, len(values))
+	for name, value := range values {
+		e.vars[name] = value
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	e.logger.Infof("Received %q from %s; set %v", msg.Subject, msg.From, names)
+
+	if result.Metrics == nil {
+		result.Metrics = make(map[string]interface{})
+	}
+	emails, _ := result.Metrics["emails"].([]EmailReceived)
+	result.Metrics["emails"] = append(emails, EmailReceived{
+		Action: action.Name, From: msg.From, Subject: msg.Subject, Received: msg.Received, Variables: names,
+	})
+	return nil
+}
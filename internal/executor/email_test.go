@@ -0,0 +1,54 @@
+package executor
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"panoptic/internal/config"
+	"panoptic/internal/logger"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestExecutor_WaitForEmail tests waiting for a MailHog message, extracting
+// variables and using them in a later action
+func TestExecutor_WaitForEmail(t *testing.T) {
+	created := time.Now().Add(time.Second).UTC().Format(time.RFC3339Nano)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"items":[{"ID":"m1","Created":"` + created + `","Raw":{"From":"no-reply@shop.test","To":["alice@example.com"],
+			"Data":"Subject: Your code\r\n\r\nCode: 482913\r\nVerify: https://shop.test/verify?token=abc\r\n"}}]}`))
+	}))
+	defer server.Close()
+
+	cfg := &config.Config{Settings: config.Settings{Inbox: &config.InboxSettings{Provider: "mailhog", URL: server.URL, Interval: time.Millisecond}}}
+	executor := NewExecutor(cfg, t.TempDir(), logger.NewLogger(false))
+	result := &TestResult{StartTime: time.Now(), Metrics: map[string]interface{}{}}
+	recording := ""
+	platform := &MockPlatform{metrics: map[string]interface{}{}}
+
+	wait := config.Action{Name: "otp", Type: "wait_for_email", Duration: 5, Parameters: map[string]interface{}{
+		"to": "alice@example.com", "subject": "code",
+		"extract": map[string]interface{}{"otp": `Code: (\d{6})`, "verify_link": `https://shop\.test/verify\S+`},
+	}}
+	require.NoError(t, executor.executeAction(platform, wait, config.AppConfig{}, result, &recording))
+	assert.Equal(t, map[string]string{"otp": "482913", "verify_link": "https://shop.test/verify?token=abc"}, executor.vars)
+	emails := result.Metrics["emails"].([]EmailReceived)
+	require.Len(t, emails, 1)
+	assert.Equal(t, "Your code", emails[0].Subject)
+	assert.Equal(t, []string{"otp", "verify_link"}, emails[0].Variables)
+
+	fill, err := executor.interpolateAction(config.Action{Name: "enter", Type: "fill", Value: "{{var.otp}}", URL: "{{ var.verify_link }}"})
+	require.NoError(t, err)
+	assert.Equal(t, "482913", fill.Value)
+	assert.Equal(t, "https://shop.test/verify?token=abc", fill.URL)
+
+	wait.Duration = 1
+	err = executor.executeAction(platform, wait, config.AppConfig{}, result, &recording)
+	assert.ErrorContains(t, err, "no matching message before the timeout", "a consumed message isn't matched again")
+
+	_, err = executor.interpolateAction(config.Action{Name: "enter", Value: "{{var.missing}}"})
+	assert.ErrorContains(t, err, "variable missing is not set")
+}
@@ -0,0 +1,130 @@
+package executor
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"panoptic/internal/artifactcrypt"
+)
+
+// loadArtifactKey reads the key of settings.encryption as a run starts, so
+// a missing key fails the run before it writes artifacts it can't encrypt
+func (e *Executor) loadArtifactKey() error {
+	settings := e.config.Settings.Encryption
+	if !settings.IsEnabled() {
+		return nil
+	}
+	key, err := artifactcrypt.LoadKey(settings.KeyEnv, settings.KeyCommand)
+	if err != nil {
+		return fmt.Errorf("settings.encryption: %w", err)
+	}
+	e.artifactKey = key
+	return nil
+}
+
+// EncryptArtifacts encrypts the artifacts of the output directory in place
+// under settings.encryption, returning how many it encrypted. The run
+// encrypts the artifacts of each app as it finishes; this catches the rest,
+// such as failure bundles and the files of an app a crash interrupted, so
+// call it however the run ends. Artifacts already encrypted are left alone.
+func (e *Executor) EncryptArtifacts() (int, error) {
+	settings := e.config.Settings.Encryption
+	if !settings.IsEnabled() {
+		return 0, nil
+	}
+	if e.artifactKey == nil {
+		if err := e.loadArtifactKey(); err != nil {
+			return 0, err
+		}
+	}
+	encrypted := 0
+	for _, kind := range settings.Kinds() {
+		err := filepath.WalkDir(filepath.Join(e.outputDir, kind), func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				if errors.Is(err, fs.ErrNotExist) {
+					return nil
+				}
+				return err
+			}
+			if !d.Type().IsRegular() {
+				return nil
+			}
+			done, err := artifactcrypt.EncryptFile(e.artifactKey, path)
+			if err != nil {
+				return fmt.Errorf("failed to encrypt %s: %w", path, err)
+			}
+			if done {
+				encrypted++
+			}
+			return nil
+		})
+		if err != nil {
+			return encrypted, err
+		}
+	}
+	e.logger.Infof("Encrypted %d artifact(s) at rest", encrypted)
+	return encrypted, nil
+}
+
+// encryptResultArtifacts encrypts the artifacts of an app as it finishes,
+// so an interrupted run leaves at most those of the app it was running in
+// plaintext. Artifacts of other kinds, and those not on this machine, are
+// left as they are.
+func (e *Executor) encryptResultArtifacts(result *TestResult) {
+	if e.artifactKey == nil {
+		return
+	}
+	kinds := e.config.Settings.Encryption.Kinds()
+	for _, a := range result.Artifacts() {
+		if !e.encryptedKind(a.Path, kinds) {
+			continue
+		}
+		if _, err := artifactcrypt.EncryptFile(e.artifactKey, a.Path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			e.logger.Warnf("Failed to encrypt %s: %v", a.Path, err)
+		}
+	}
+}
+
+// encryptedKind tells whether path lies in the directory of one of kinds
+// under the output directory
+func (e *Executor) encryptedKind(path string, kinds []string) bool {
+	rel, err := filepath.Rel(absPath(e.outputDir), absPath(path))
+	if err != nil {
+		return false
+	}
+	kind, _, _ := strings.Cut(filepath.ToSlash(rel), "/")
+	return slices.Contains(kinds, kind)
+}
+
+func absPath(path string) string {
+	if abs, err := filepath.Abs(path); err == nil {
+		return abs
+	}
+	return path
+}
+
+// readArtifact reads an artifact, decrypting it when the run encrypted it
+func (e *Executor) readArtifact(path string) ([]byte, error) {
+	if e.artifactKey == nil {
+		return os.ReadFile(path)
+	}
+	return artifactcrypt.ReadFile(e.artifactKey, path)
+}
+
+// encryptedArtifacts tells whether any artifact of results is encrypted,
+// for reports regenerated from results
+func encryptedArtifacts(results []TestResult) bool {
+	for _, r := range results {
+		for _, a := range r.Artifacts() {
+			if artifactcrypt.IsEncryptedFile(a.Path) {
+				return true
+			}
+		}
+	}
+	return false
+}
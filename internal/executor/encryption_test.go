@@ -0,0 +1,161 @@
+package executor
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/base64"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"panoptic/internal/artifactcrypt"
+	"panoptic/internal/config"
+	"panoptic/internal/logger"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testArtifactKeyEnv = "PANOPTIC_TEST_ARTIFACT_KEY"
+
+func testArtifactKey(t *testing.T) []byte {
+	t.Helper()
+	key := bytes.Repeat([]byte{3}, artifactcrypt.KeySize)
+	t.Setenv(testArtifactKeyEnv, base64.StdEncoding.EncodeToString(key))
+	return key
+}
+
+// TestExecutor_EncryptArtifacts tests that the artifact kinds configured are
+// encrypted in place, once, and the rest of the output is left alone
+func TestExecutor_EncryptArtifacts(t *testing.T) {
+	key := testArtifactKey(t)
+	outputDir := t.TempDir()
+	shot := writeArtifact(t, outputDir, "screenshots/home.png", 10, time.Now())
+	video := writeArtifact(t, outputDir, "videos/session.mp4", 10, time.Now())
+	results := writeArtifact(t, outputDir, "results.json", 10, time.Now())
+
+	cfg, _ := manifestConfig(t)
+	cfg.Settings.Encryption = &config.EncryptionSettings{Enabled: true, KeyEnv: testArtifactKeyEnv, Artifacts: []string{"screenshots"}}
+	executor := NewExecutor(cfg, outputDir, logger.NewLogger(false))
+	encrypted, err := executor.EncryptArtifacts()
+	require.NoError(t, err)
+	assert.Equal(t, 1, encrypted)
+	assert.True(t, artifactcrypt.IsEncryptedFile(shot))
+	assert.False(t, artifactcrypt.IsEncryptedFile(video))
+	assert.False(t, artifactcrypt.IsEncryptedFile(results))
+
+	data, err := artifactcrypt.ReadFile(key, shot)
+	require.NoError(t, err)
+	assert.Equal(t, make([]byte, 10), data)
+
+	encrypted, err = executor.EncryptArtifacts()
+	require.NoError(t, err)
+	assert.Zero(t, encrypted, "encrypted artifacts aren't encrypted again")
+}
+
+// TestExecutor_Run_EncryptionKeyMissing tests that a run without its
+// artifact key fails before it writes artifacts
+func TestExecutor_Run_EncryptionKeyMissing(t *testing.T) {
+	t.Setenv(testArtifactKeyEnv, "")
+	cfg, _ := manifestConfig(t)
+	cfg.Settings.Encryption = &config.EncryptionSettings{Enabled: true, KeyEnv: testArtifactKeyEnv}
+	executor := NewExecutor(cfg, t.TempDir(), logger.NewLogger(false))
+	assert.ErrorContains(t, executor.Run(), "settings.encryption: artifact key not set")
+
+	var unset Executor
+	unset.config = &config.Config{}
+	encrypted, err := unset.EncryptArtifacts()
+	assert.NoError(t, err)
+	assert.Zero(t, encrypted)
+}
+
+// TestGenerateReport_EncryptedWarning tests that reports warn when their
+// artifacts are encrypted, whether generated by the run or from results
+func TestGenerateReport_EncryptedWarning(t *testing.T) {
+	testArtifactKey(t)
+	outputDir := t.TempDir()
+	shot := writeArtifact(t, outputDir, "screenshots/home.png", 10, time.Now())
+	results := []TestResult{{AppName: "Shop", AppType: "web", Success: true, Screenshots: []string{shot}}}
+
+	reportPath := filepath.Join(outputDir, "report.html")
+	require.NoError(t, GenerateComprehensiveReport(reportPath, results))
+	html, err := os.ReadFile(reportPath)
+	require.NoError(t, err)
+	assert.NotContains(t, string(html), "Artifacts are encrypted")
+
+	cfg, _ := manifestConfig(t)
+	cfg.Settings.Encryption = &config.EncryptionSettings{Enabled: true, KeyEnv: testArtifactKeyEnv}
+	executor := NewExecutor(cfg, outputDir, logger.NewLogger(false))
+	require.NoError(t, executor.GenerateReport(reportPath))
+	html, err = os.ReadFile(reportPath)
+	require.NoError(t, err)
+	assert.Contains(t, string(html), "Artifacts are encrypted")
+
+	_, err = executor.EncryptArtifacts()
+	require.NoError(t, err)
+	require.NoError(t, GenerateComprehensiveReport(reportPath, results))
+	html, err = os.ReadFile(reportPath)
+	require.NoError(t, err)
+	assert.Contains(t, string(html), "panoptic report serve")
+	assert.Contains(t, string(html), `src="screenshots/home.png"`)
+}
+
+// screenshotDriver writes a screenshot when asked and fails navigation
+const screenshotDriver = `#!/bin/sh
+while read -r line; do
+	id=$(printf '%s' "$line" | sed 's/.*"id":\([0-9]*\).*/\1/')
+	case "$line" in
+	*'"method":"screenshot"'*)
+		printf 'png' > "$(printf '%s' "$line" | sed 's/.*"path":"\([^"]*\)".*/\1/')"
+		echo '{"jsonrpc":"2.0","id":'$id',"result":{}}' ;;
+	*'"method":"navigate"'*) echo '{"jsonrpc":"2.0","id":'$id',"error":{"code":1,"message":"no such screen"}}' ;;
+	*) echo '{"jsonrpc":"2.0","id":'$id',"result":{"name":"sh","protocol_version":1,"capabilities":["screenshot","navigate"]}}' ;;
+	esac
+done
+`
+
+// TestExecutor_Run_EncryptsEachApp tests that the artifacts of an app are
+// encrypted as it finishes, so a run ending early leaves none in plaintext
+func TestExecutor_Run_EncryptsEachApp(t *testing.T) {
+	testArtifactKey(t)
+	driver := filepath.Join(t.TempDir(), "driver")
+	require.NoError(t, os.WriteFile(driver, []byte(screenshotDriver), 0755))
+	cfg := &config.Config{Apps: []config.AppConfig{
+		{Name: "Kiosk", Type: "driver", Driver: &config.DriverConfig{Command: driver}, Actions: []config.Action{
+			{Name: "home", Type: "screenshot"},
+			{Name: "go", Type: "navigate", Value: "app://missing"},
+		}},
+	}}
+	cfg.Settings.Encryption = &config.EncryptionSettings{Enabled: true, KeyEnv: testArtifactKeyEnv}
+	executor := NewExecutor(cfg, t.TempDir(), logger.NewLogger(false))
+	require.NoError(t, executor.Run())
+
+	require.Len(t, executor.results, 1)
+	result := executor.results[0]
+	assert.False(t, result.Success)
+	require.Len(t, result.Screenshots, 1)
+	assert.True(t, artifactcrypt.IsEncryptedFile(result.Screenshots[0]), "encrypted without EncryptArtifacts")
+	data, err := executor.readArtifact(result.Screenshots[0])
+	require.NoError(t, err)
+	assert.Equal(t, "png", string(data))
+
+	// Failure bundles hold the artifacts decrypted, to be encrypted in turn
+	bundle, err := executor.writeFailureBundle("abc", []*TestResult{&result})
+	require.NoError(t, err)
+	zr, err := zip.OpenReader(bundle)
+	require.NoError(t, err)
+	defer zr.Close()
+	require.Len(t, zr.File, 2)
+	f, err := zr.File[1].Open()
+	require.NoError(t, err)
+	shot, err := io.ReadAll(f)
+	f.Close()
+	require.NoError(t, err)
+	assert.Equal(t, "png", string(shot))
+	encrypted, err := executor.EncryptArtifacts()
+	require.NoError(t, err)
+	assert.Equal(t, 1, encrypted, "only the bundle is left to encrypt")
+	assert.True(t, artifactcrypt.IsEncryptedFile(bundle))
+}
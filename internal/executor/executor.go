@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
@@ -13,23 +14,137 @@ import (
 	"time"
 	"unicode/utf8"
 
+	"github.com/google/uuid"
 	"gopkg.in/yaml.v3"
 
 	"panoptic/internal/ai"
 	"panoptic/internal/cloud"
 	"panoptic/internal/config"
 	"panoptic/internal/enterprise"
+	"panoptic/internal/flags"
+	"panoptic/internal/graphql"
+	"panoptic/internal/history"
+	"panoptic/internal/inbox"
 	"panoptic/internal/logger"
 	"panoptic/internal/platforms"
+	"panoptic/internal/redact"
+	"panoptic/internal/secrets"
+	"panoptic/internal/telemetry"
+	"panoptic/internal/testdata"
+	"panoptic/internal/trace"
 	"panoptic/internal/vision"
 )
 
 type Executor struct {
 	config    *config.Config
 	outputDir string
-	logger    *logger.Logger
+	logger    *logger.Logger // narrowed to the current app and action while running
+	runLogger *logger.Logger // carries run_id; set for the duration of Run
+	runID     string
 	factory   *platforms.PlatformFactory
 	results   []TestResult
+	stream    *ResultStream     // non-nil when results are streamed to disk
+	container *ContainerOptions // non-nil when web apps run in Docker containers
+	debugger  *debugger         // non-nil in interactive debug mode
+	tracing   bool              // record per-app trace archives
+	fakeSeed  int64             // seeds {{fake.*}} test data; each app derives its own
+	fake      *testdata.Faker   // test data for the running app
+	tagFilter config.TagFilter  // --tags selection; empty runs everything
+	impact    *Impact           // --changed selection; nil runs every app
+	notReady  map[string]error  // apps whose wait_for checks timed out in the preflight
+	vars      map[string]string // {{var.*}} values set by the running app's actions
+
+	// Results the stream failed to take, by index in results; they are
+	// held in full instead of as summaries
+	unstreamed map[int]bool
+
+	// settings.browser_pool browsers the run's web apps share
+	browserPool *platforms.BrowserPool
+
+	// settings.rate_limit pacing of the run; nil when unset
+	rateLimits *rateLimiter
+
+	// Writes the run's screenshots off the action loop
+	screenshots *screenshotWriter
+
+	// Progress written to status.json while the apps run
+	status *RunStatus
+
+	// Saves progress for resuming an interrupted run; nil when disabled
+	checkpoints *checkpointer
+
+	// Enterprise project the run is attributed to: --project, else
+	// settings.enterprise.project; project is set once the run may start,
+	// usage once it's metered for the subscriptions of its user and project
+	projectRef string
+	project    *projectRun
+	usage      *usageRun
+
+	// Told how long each action took and how it ended; set by load runs
+	onAction func(action config.Action, took time.Duration, err error)
+
+	// Loops the running app's actions until a soak run ends; nil replays them once
+	soak *soakSession
+
+	// What the run's apps ran with and on, for its manifest, and the run it replays
+	manifestApps   []ManifestApp
+	browserVersion string // of the running app's browser
+	nodes          []string
+	replay         *Manifest
+
+	// The settings.encryption key and settings.redaction, set up as the run starts
+	artifactKey []byte
+	redactor    *redact.Redactor
+
+	// Route the running app's page is on and the guard of its coverage, which
+	// parallel action groups record at once
+	pageRoute  string
+	coverageMu sync.Mutex
+
+	// Layouts captured by the running app's vision_layout_check actions
+	layouts map[string]layoutSnapshot
+
+	// Network conditions and pending disconnect_network restores of the running app
+	network     config.NetworkConditions
+	chaosTimers []*time.Timer
+
+	// Feature flag provider and the undo of the running app's overrides
+	flags        flags.Provider
+	flagRestores []func(context.Context) error
+
+	// settings.inbox mailbox and the messages wait_for_email already consumed
+	inbox        inbox.Inbox
+	seenMessages map[string]bool
+
+	// settings.secrets provider login and totp actions and {{secret.*}} read
+	secrets secrets.Provider
+
+	// settings.figma client vision_design_compare actions render designs with
+	figma designRenderer
+
+	// Texts the source locale of an i18n audit showed, by localeGroup
+	localeTexts map[string]map[string]bool
+
+	// WebSocket messages of the running app, for assert_websocket
+	webSockets []WebSocketMessage
+
+	// Schemas graphql actions validated against, by endpoint or schema file
+	graphqlSchemas map[string]*graphql.Schema
+
+	// API contracts the run checks, or records with --record-contracts
+	contracts       *Contracts
+	recordContracts bool
+
+	// Run metadata for results.json
+	configPath   string
+	configSHA256 string
+	startedAt    time.Time
+	finishedAt   time.Time
+
+	// OpenTelemetry spans; spanCtx holds the innermost run or app span
+	tracer      *telemetry.Tracer
+	rootSpanCtx context.Context
+	spanCtx     context.Context
 
 	// Lazy-initialized components with sync.Once for thread safety
 	testGen               *ai.TestGenerator
@@ -38,6 +153,9 @@ type Executor struct {
 	cloudManager          *cloud.CloudManager
 	cloudAnalytics        *cloud.CloudAnalytics
 	enterpriseIntegration *enterprise.EnterpriseIntegration
+	kubernetesRunner      *cloud.KubernetesRunner
+	kubernetesErr         error
+	visionModel           *vision.Model // settings.vision ONNX model; nil for heuristics
 
 	// sync.Once for lazy initialization
 	testGenOnce        sync.Once
@@ -46,19 +164,38 @@ type Executor struct {
 	cloudManagerOnce   sync.Once
 	cloudAnalyticsOnce sync.Once
 	enterpriseOnce     sync.Once
+	kubernetesOnce     sync.Once
+	visionModelOnce    sync.Once
 }
 
 type TestResult struct {
-	AppName     string                 `json:"app_name"`
-	AppType     string                 `json:"app_type"`
-	StartTime   time.Time              `json:"start_time"`
-	EndTime     time.Time              `json:"end_time"`
-	Duration    time.Duration          `json:"duration"`
-	Metrics     map[string]interface{} `json:"metrics"`
-	Screenshots []string               `json:"screenshots"`
-	Videos      []string               `json:"videos"`
-	Success     bool                   `json:"success"`
-	Error       string                 `json:"error,omitempty"`
+	AppName          string                 `json:"app_name"`
+	AppType          string                 `json:"app_type"`
+	StartTime        time.Time              `json:"start_time"`
+	EndTime          time.Time              `json:"end_time"`
+	Duration         time.Duration          `json:"duration"`
+	Metrics          map[string]interface{} `json:"metrics"`
+	Screenshots      []string               `json:"screenshots"`
+	Videos           []string               `json:"videos"`
+	Success          bool                   `json:"success"`
+	Error            string                 `json:"error,omitempty"`
+	Browser          string                 `json:"browser,omitempty"`     // browser label for web apps
+	ProjectID        string                 `json:"project_id,omitempty"`  // enterprise project the run is attributed to
+	Tags             []string               `json:"tags,omitempty"`        // app tags plus those of the actions that ran
+	Quarantined      bool                   `json:"quarantined,omitempty"` // failed under an active quarantine; doesn't fail the run
+	QuarantineReason string                 `json:"quarantine_reason,omitempty"`
+	FailureCategory  string                 `json:"failure_category,omitempty"` // functional, performance, resources, infrastructure, ...
+	Severity         string                 `json:"severity,omitempty"`         // error or warning, from settings.failure_policy
+	Findings         []Finding              `json:"findings,omitempty"`         // errors detected on pages that passed
+	Fingerprint      string                 `json:"fingerprint,omitempty"`      // identifies the failure across runs and browsers
+	Matrix           map[string]string      `json:"matrix,omitempty"`           // dimension values of an app matrix instance
+	FeatureFlags     map[string]interface{} `json:"feature_flags,omitempty"`    // flag values the app ran with
+	Annotated        []AnnotatedScreenshot  `json:"annotated_screenshots,omitempty"`
+	ScreenHashes     []ScreenHash           `json:"screen_hashes,omitempty"`  // perceptual hashes of the screenshots
+	Routes           []string               `json:"routes,omitempty"`         // URL paths the app opened
+	Elements         []history.Element      `json:"elements,omitempty"`       // elements the app's actions interacted with
+	CustomMetrics    []history.Metric       `json:"custom_metrics,omitempty"` // numbers the app's actions recorded
+	Scenario         *ScenarioResult        `json:"scenario,omitempty"`       // steps of the Gherkin scenario the app was expanded from
 }
 
 // JSON optimization pools for performance
@@ -236,6 +373,24 @@ func (tr *TestResult) MarshalJSON() ([]byte, error) {
 	buf = appendJSONString(buf, tr.AppName)
 	buf = append(buf, `,"app_type":`...)
 	buf = appendJSONString(buf, tr.AppType)
+	if tr.Browser != "" {
+		buf = append(buf, `,"browser":`...)
+		buf = appendJSONString(buf, tr.Browser)
+	}
+	if tr.ProjectID != "" {
+		buf = append(buf, `,"project_id":`...)
+		buf = appendJSONString(buf, tr.ProjectID)
+	}
+	if len(tr.Tags) > 0 {
+		buf = append(buf, `,"tags":[`...)
+		for i, tag := range tr.Tags {
+			if i > 0 {
+				buf = append(buf, ',')
+			}
+			buf = appendJSONString(buf, tag)
+		}
+		buf = append(buf, ']')
+	}
 	buf = append(buf, `,"start_time":`...)
 	buf = appendJSONString(buf, tr.StartTime.Format(time.RFC3339Nano))
 	buf = append(buf, `,"end_time":`...)
@@ -290,6 +445,108 @@ func (tr *TestResult) MarshalJSON() ([]byte, error) {
 		buf = appendJSONString(buf, tr.Error)
 	}
 
+	if tr.FailureCategory != "" {
+		buf = append(buf, `,"failure_category":`...)
+		buf = appendJSONString(buf, tr.FailureCategory)
+	}
+	if tr.Severity != "" {
+		buf = append(buf, `,"severity":`...)
+		buf = appendJSONString(buf, tr.Severity)
+	}
+	if tr.Fingerprint != "" {
+		buf = append(buf, `,"fingerprint":`...)
+		buf = appendJSONString(buf, tr.Fingerprint)
+	}
+
+	if len(tr.Matrix) > 0 {
+		matrix, err := json.Marshal(tr.Matrix)
+		if err != nil {
+			return nil, err
+		}
+		buf = append(buf, `,"matrix":`...)
+		buf = append(buf, matrix...)
+	}
+
+	if len(tr.FeatureFlags) > 0 {
+		featureFlags, err := json.Marshal(tr.FeatureFlags)
+		if err != nil {
+			return nil, err
+		}
+		buf = append(buf, `,"feature_flags":`...)
+		buf = append(buf, featureFlags...)
+	}
+
+	if len(tr.Annotated) > 0 {
+		annotated, err := json.Marshal(tr.Annotated)
+		if err != nil {
+			return nil, err
+		}
+		buf = append(buf, `,"annotated_screenshots":`...)
+		buf = append(buf, annotated...)
+	}
+
+	if len(tr.ScreenHashes) > 0 {
+		hashes, err := json.Marshal(tr.ScreenHashes)
+		if err != nil {
+			return nil, err
+		}
+		buf = append(buf, `,"screen_hashes":`...)
+		buf = append(buf, hashes...)
+	}
+
+	if len(tr.Routes) > 0 {
+		routes, err := json.Marshal(tr.Routes)
+		if err != nil {
+			return nil, err
+		}
+		buf = append(buf, `,"routes":`...)
+		buf = append(buf, routes...)
+	}
+
+	if len(tr.Elements) > 0 {
+		elements, err := json.Marshal(tr.Elements)
+		if err != nil {
+			return nil, err
+		}
+		buf = append(buf, `,"elements":`...)
+		buf = append(buf, elements...)
+	}
+
+	if len(tr.CustomMetrics) > 0 {
+		metrics, err := json.Marshal(tr.CustomMetrics)
+		if err != nil {
+			return nil, err
+		}
+		buf = append(buf, `,"custom_metrics":`...)
+		buf = append(buf, metrics...)
+	}
+
+	if tr.Scenario != nil {
+		scenario, err := json.Marshal(tr.Scenario)
+		if err != nil {
+			return nil, err
+		}
+		buf = append(buf, `,"scenario":`...)
+		buf = append(buf, scenario...)
+	}
+
+	if len(tr.Findings) > 0 {
+		findings, err := json.Marshal(tr.Findings)
+		if err != nil {
+			return nil, err
+		}
+		buf = append(buf, `,"findings":`...)
+		buf = append(buf, findings...)
+	}
+
+	if tr.Quarantined {
+		buf = append(buf, `,"quarantined":true`...)
+		if tr.QuarantineReason != "" {
+			buf = append(buf, `,"quarantine_reason":`...)
+			buf = appendJSONString(buf, tr.QuarantineReason)
+		}
+	}
+
 	buf = append(buf, '}')
 
 	return buf, nil
@@ -301,22 +558,21 @@ func (tr *TestResult) MarshalJSON() ([]byte, error) {
 
 func (e *Executor) getTestGen() *ai.TestGenerator {
 	e.testGenOnce.Do(func() {
-		visionDetector := vision.NewElementDetector(*e.logger)
-		e.testGen = ai.NewTestGenerator(*e.logger, visionDetector)
+		e.testGen = ai.NewTestGenerator(*e.componentLogger(), e.newElementDetector())
 	})
 	return e.testGen
 }
 
 func (e *Executor) getErrorDet() *ai.OptimizedErrorDetector {
 	e.errorDetOnce.Do(func() {
-		e.errorDet = ai.NewOptimizedErrorDetector(*e.logger)
+		e.errorDet = ai.NewOptimizedErrorDetector(*e.componentLogger())
 	})
 	return e.errorDet
 }
 
 func (e *Executor) getAITester() *ai.OptimizedAIEnhancedTester {
 	e.aiTesterOnce.Do(func() {
-		e.aiTester = ai.NewOptimizedAIEnhancedTester(*e.logger)
+		e.aiTester = ai.NewOptimizedAIEnhancedTester(*e.componentLogger())
 	})
 	return e.aiTester
 }
@@ -324,7 +580,7 @@ func (e *Executor) getAITester() *ai.OptimizedAIEnhancedTester {
 func (e *Executor) getCloudManager() *cloud.CloudManager {
 	e.cloudManagerOnce.Do(func() {
 		if e.config.Settings.Cloud != nil {
-			e.cloudManager = cloud.NewCloudManager(*e.logger)
+			e.cloudManager = cloud.NewCloudManager(*e.componentLogger())
 		}
 	})
 	return e.cloudManager
@@ -333,7 +589,7 @@ func (e *Executor) getCloudManager() *cloud.CloudManager {
 func (e *Executor) getCloudAnalytics() *cloud.CloudAnalytics {
 	e.cloudAnalyticsOnce.Do(func() {
 		if e.getCloudManager() != nil {
-			e.cloudAnalytics = cloud.NewCloudAnalytics(*e.logger, e.getCloudManager())
+			e.cloudAnalytics = cloud.NewCloudAnalytics(*e.componentLogger(), e.getCloudManager())
 		}
 	})
 	return e.cloudAnalytics
@@ -342,7 +598,7 @@ func (e *Executor) getCloudAnalytics() *cloud.CloudAnalytics {
 func (e *Executor) getEnterpriseIntegration() *enterprise.EnterpriseIntegration {
 	e.enterpriseOnce.Do(func() {
 		if e.config.Settings.Enterprise != nil {
-			e.enterpriseIntegration = enterprise.NewEnterpriseIntegration(*e.logger)
+			e.enterpriseIntegration = enterprise.NewEnterpriseIntegration(*e.componentLogger())
 
 			// Load enterprise configuration from file or use inline config
 			enterpriseConfigPath := ""
@@ -405,49 +661,338 @@ func NewExecutor(cfg *config.Config, outputDir string, log *logger.Logger) *Exec
 		config:    cfg,
 		outputDir: outputDir,
 		logger:    log,
+		runID:     uuid.New().String(),
 		factory:   platforms.NewPlatformFactory(),
 		results:   make([]TestResult, 0),
+		fakeSeed:  testdata.RandomSeed(),
 	}
+	if cfg != nil && cfg.Settings.FakeSeed != nil {
+		executor.fakeSeed = *cfg.Settings.FakeSeed
+	}
+	executor.fake = testdata.New(executor.fakeSeed)
 
 	// No eager initialization - components created on-demand
 
 	return executor
 }
 
+// RunID returns the correlation ID attached to every log entry of this run
+func (e *Executor) RunID() string {
+	return e.runID
+}
+
+// SetRunID replaces the generated run ID, e.g. to correlate a containerized
+// agent's logs with the run that launched it
+func (e *Executor) SetRunID(id string) {
+	if id != "" {
+		e.runID = id
+	}
+}
+
+// FakeSeed returns the seed of the run's {{fake.*}} test data
+func (e *Executor) FakeSeed() int64 {
+	return e.fakeSeed
+}
+
+// SetFakeSeed replays a run's test data; it overrides settings.fake_seed
+func (e *Executor) SetFakeSeed(seed int64) {
+	e.fakeSeed = seed
+	e.fake = testdata.New(seed)
+}
+
+// componentLogger is the run-scoped logger handed to lazily created components,
+// so they don't inherit the app that happened to be running when they were built
+func (e *Executor) componentLogger() *logger.Logger {
+	if e.runLogger != nil {
+		return e.runLogger
+	}
+	return e.logger
+}
+
 func (e *Executor) Run() error {
+	return e.RunContext(context.Background())
+}
+
+// RunContext runs the configuration like Run, stopping before the next app
+// once ctx is done. The apps that ran keep their results.
+func (e *Executor) RunContext(runCtx context.Context) error {
+	ctx, span := e.tracer.Start(e.spanContext(), "panoptic.run",
+		telemetry.String("panoptic.run_id", e.runID),
+		telemetry.Int("panoptic.apps", len(e.config.Apps)))
+	e.spanCtx = ctx
+	defer func() {
+		span.End(nil)
+		e.spanCtx = nil
+	}()
+	if err := e.startRedaction(); err != nil {
+		span.End(err)
+		return fmt.Errorf("configuration validation failed: %w", err)
+	}
+
+	base := e.logger
+	runFields := logger.Fields{"run_id": e.runID}
+	if span != nil {
+		runFields["trace_id"] = span.SpanContext().TraceIDString()
+	}
+	e.runLogger = base.WithFields(runFields)
+	e.logger = e.runLogger
+	defer func() {
+		e.logger = base
+		e.runLogger = nil
+	}()
+
+	e.startedAt = time.Now()
+	defer func() { e.finishedAt = time.Now() }()
+	e.screenshots = newScreenshotWriter(screenshotWorkers, screenshotQueueSize)
+	defer func() {
+		if err := e.screenshots.close(); err != nil {
+			e.logger.Errorf("Failed to save screenshots: %v", err)
+		}
+		e.screenshots = nil
+	}()
+	if pool := e.config.Settings.BrowserPool; pool != nil && pool.Enabled {
+		e.browserPool = platforms.NewBrowserPool(pool.PoolSize(), pool.RecycleLimit())
+		defer func() {
+			launches, reuses := e.browserPool.Stats()
+			e.logger.Infof("Browser pool: %d browsers launched, %d reused", launches, reuses)
+			e.browserPool.Close()
+			e.browserPool = nil
+		}()
+	}
+	if limits := e.config.Settings.RateLimit; limits != nil {
+		e.rateLimits = newRateLimiter(limits)
+		defer func() { e.rateLimits = nil }()
+	}
+	if e.stream != nil {
+		defer func() {
+			if err := e.stream.Close(); err != nil {
+				e.logger.Warnf("Failed to close result stream: %v", err)
+			}
+		}()
+	}
+
 	e.logger.Info("Starting execution")
+	e.logger.Infof("Test data seed: %d", e.fakeSeed)
 	// e.logger.SetOutputDirectory(e.outputDir)  // Temporarily disabled
 
 	e.logger.Info("Validating configuration...")
 
 	// Validate configuration
 	if err := e.config.Validate(); err != nil {
+		span.End(err)
 		return fmt.Errorf("configuration validation failed: %w", err)
 	}
 
 	e.logger.Info("Configuration validated, starting app processing...")
 
+	// Wait for the apps under test to come up before running any of them
+	apps := e.expandApps()
+	if err := e.checkCapabilities(apps); err != nil {
+		span.End(err)
+		return fmt.Errorf("configuration validation failed: %w", err)
+	}
+	if err := e.loadArtifactKey(); err != nil {
+		span.End(err)
+		return err
+	}
+	if err := e.loadContracts(); err != nil {
+		span.End(err)
+		return err
+	}
+	defer e.saveContracts()
+	if err := e.startProject(ctx); err != nil {
+		span.End(err)
+		return err
+	}
+	if err := e.startUsage(ctx); err != nil {
+		span.End(err)
+		return err
+	}
+	if err := e.approveRun(ctx, apps); err != nil {
+		span.End(err)
+		return err
+	}
+	if e.project != nil {
+		defer e.recordProjectRun()
+	}
+	if e.usage != nil {
+		defer e.recordUsage()
+	}
+	e.enforceRetention()
+	finished, err := e.startCheckpoint(apps)
+	if err != nil {
+		span.End(err)
+		return err
+	}
+	remaining := apps[finished:]
+	e.notReady = e.preflight(ctx, remaining)
+
 	// Execute tests for each application
-	for _, app := range e.config.Apps {
+	e.startStatus(len(apps))
+	for i, app := range remaining {
+		e.waitForHost(runCtx, app)
+		if err := runCtx.Err(); err != nil {
+			e.logger.Warnf("Run stopped before %d of %d app(s): %v", len(remaining)-i, len(apps), err)
+			e.endStatus(RunStateStopped)
+			e.endCheckpoint(false)
+			span.End(err)
+			return fmt.Errorf("run stopped: %w", err)
+		}
 		e.logger.Infof("Processing application: %s (%s)", app.Name, app.Type)
+		e.statusRunning(app.Name)
 
-		result := e.executeApp(app)
-		e.results = append(e.results, result)
+		result := e.dispatchApp(app)
+		e.redactResult(&result)
+		e.hostDone(app)
+		e.encryptResultArtifacts(&result)
+		e.checkpointApp(&result)
+		e.addResult(result)
+		e.statusFinished(&result)
 
 		e.logger.Infof("Application processing completed for %s", app.Name)
 
 		if result.Success {
 			e.logger.Infof("Successfully completed app: %s", app.Name)
+		} else if result.Quarantined {
+			e.logger.Warnf("Failed quarantined app: %s - %s", app.Name, result.Error)
+		} else if result.Severity == config.SeverityWarning {
+			e.logger.Warnf("Failed app (%s warning): %s - %s", result.FailureCategory, app.Name, result.Error)
 		} else {
 			e.logger.Errorf("Failed app: %s - %s", app.Name, result.Error)
 		}
 	}
 
+	e.endStatus(RunStateFinished)
+	e.endCheckpoint(true)
 	e.logger.Info("Execution completed")
 	e.logger.Info("Generating report...")
 	return nil
 }
 
+// expandApps returns the apps to run, with web browser matrices expanded into
+// one app per browser. Apps without an action selected by --tags, and apps
+// the changes of an impact run can't affect, are left out.
+func (e *Executor) expandApps() []config.AppConfig {
+	apps := make([]config.AppConfig, 0, len(e.config.Apps))
+	for _, app := range e.config.Apps {
+		if selected, _ := e.config.SelectActions(app, e.tagFilter); len(selected) == 0 && !e.tagFilter.IsEmpty() {
+			e.logger.Infof("Skipping app %s: no actions match --tags %s", app.Name, e.tagFilter)
+			continue
+		}
+		if e.impact != nil {
+			reason := e.impact.affects(e.config, app)
+			if reason == "" {
+				e.logger.Infof("Skipping app %s: not affected by the changes", app.Name)
+				continue
+			}
+			e.logger.Infof("Running app %s: %s", app.Name, reason)
+		}
+		if app.Type == "web" {
+			apps = append(apps, app.BrowserVariants()...)
+		} else {
+			apps = append(apps, app)
+		}
+	}
+	return apps
+}
+
+// dispatchApp runs an app on Kubernetes, in a container, or locally depending on
+// configuration, tagging web results with the browser they ran in
+func (e *Executor) dispatchApp(app config.AppConfig) TestResult {
+	parent := e.logger
+	fields := logger.Fields{"app": app.Name}
+	if app.Type == "web" {
+		fields["browser"] = app.BrowserLabel()
+	}
+	e.logger = parent.WithFields(fields)
+	defer func() { e.logger = parent }()
+
+	parentCtx := e.spanCtx
+	ctx, span := e.tracer.Start(e.spanContext(), "panoptic.app",
+		telemetry.String("panoptic.app.name", app.Name),
+		telemetry.String("panoptic.app.type", app.Type))
+	e.spanCtx = ctx
+	defer func() { e.spanCtx = parentCtx }()
+
+	e.browserVersion = ""
+	e.vars = make(map[string]string)
+	result := e.runApp(app)
+	e.recordManifestApp(app, e.browserVersion)
+	e.flushScreenshots(&result)
+	result.Tags = e.resultTags(app)
+	e.applyQuarantine(app, &result)
+	e.applyFailurePolicy(&result)
+	if len(result.Tags) > 0 {
+		span.SetAttributes(telemetry.String("panoptic.tags", strings.Join(result.Tags, ",")))
+	}
+	if app.Type == "web" {
+		result.Browser = app.BrowserLabel()
+		span.SetAttributes(telemetry.String("panoptic.browser", result.Browser))
+	}
+	result.Matrix = app.MatrixValues
+	result.ProjectID = e.ProjectID()
+	result.Scenario = scenarioResult(app, &result)
+	result.Fingerprint = failureFingerprint(&result)
+	result.ScreenHashes = e.hashScreenshots(&result)
+
+	span.SetAttributes(telemetry.Bool("panoptic.success", result.Success))
+	if !result.Success {
+		span.End(errors.New(result.Error))
+	} else {
+		span.End(nil)
+	}
+	return result
+}
+
+func (e *Executor) runApp(app config.AppConfig) TestResult {
+	if err := e.notReady[app.Name]; err != nil {
+		now := time.Now()
+		return TestResult{
+			AppName:         app.Name,
+			AppType:         app.Type,
+			StartTime:       now,
+			EndTime:         now,
+			Screenshots:     make([]string, 0),
+			Videos:          make([]string, 0),
+			Metrics:         make(map[string]interface{}),
+			Error:           fmt.Sprintf("Service not ready: %v", err),
+			FailureCategory: config.CategoryInfrastructure,
+		}
+	}
+
+	runner, err := e.getKubernetesRunner()
+	if err != nil {
+		now := time.Now()
+		return TestResult{
+			AppName:         app.Name,
+			AppType:         app.Type,
+			StartTime:       now,
+			EndTime:         now,
+			Screenshots:     make([]string, 0),
+			Videos:          make([]string, 0),
+			Metrics:         make(map[string]interface{}),
+			Error:           fmt.Sprintf("Failed to initialize kubernetes runner: %v", err),
+			FailureCategory: config.CategoryInfrastructure,
+		}
+	}
+	if runner != nil {
+		return e.executeAppOnKubernetes(runner, app)
+	}
+
+	if e.container != nil {
+		switch {
+		case app.Type == "web" && app.Remote != nil:
+			e.logger.Infof("App %s uses a remote WebDriver endpoint; not containerizing", app.Name)
+		case app.Type == "web":
+			return e.executeAppInContainer(app)
+		default:
+			e.logger.Warnf("Containerized mode only supports web apps; running %s (%s) on the host", app.Name, app.Type)
+		}
+	}
+
+	return e.executeApp(app)
+}
+
 func (e *Executor) executeApp(app config.AppConfig) TestResult {
 	result := TestResult{
 		AppName:     app.Name,
@@ -459,40 +1004,176 @@ func (e *Executor) executeApp(app config.AppConfig) TestResult {
 		Success:     false,
 	}
 
+	// Each app draws {{fake.*}} values from its own reproducible sequence
+	runFake := e.fake
+	e.fake = testdata.New(testdata.DeriveSeed(e.fakeSeed, app.Name))
+	defer func() { e.fake = runFake }()
+	e.vars = make(map[string]string)
+	e.layouts = make(map[string]layoutSnapshot)
+	e.webSockets = nil
+	if e.rateLimits != nil {
+		e.rateLimits.startApp()
+	}
+
 	// Create platform instance
 	platform, err := e.factory.CreatePlatform(app.Type)
 	if err != nil {
 		result.Error = fmt.Sprintf("Failed to create platform: %v", err)
+		result.FailureCategory = config.CategoryInfrastructure
 		result.EndTime = time.Now()
 		result.Duration = result.EndTime.Sub(result.StartTime)
 		return result
 	}
 
+	// settings.vision models and thresholds apply to vision clicks and reports
+	if e.config.Settings.Vision != nil {
+		if webPlatform, ok := platform.(*platforms.WebPlatform); ok {
+			webPlatform.SetVision(e.getVisionModel(), VisionThresholds(e.config.Settings.Vision))
+		}
+	}
+
+	// settings.redaction blurs masked elements before screenshots are saved
+	e.redactPlatform(platform)
+
+	// Debug mode drives a visible browser so authors can watch each step
+	if e.debugger != nil {
+		if webPlatform, ok := platform.(*platforms.WebPlatform); ok {
+			webPlatform.SetHeaded(true)
+		}
+	}
+
+	// settings.browser_pool hands out warm browsers instead of launching one
+	if e.browserPool != nil {
+		if webPlatform, ok := platform.(*platforms.WebPlatform); ok {
+			webPlatform.SetBrowserPool(e.browserPool)
+		}
+	}
+
 	// Initialize platform
-	if err := platform.Initialize(app); err != nil {
+	appCtx := e.spanContext()
+	if err := e.platformCall(appCtx, app, "Initialize", func() error { return platform.Initialize(app) }); err != nil {
 		result.Error = fmt.Sprintf("Failed to initialize platform: %v", err)
+		result.FailureCategory = config.CategoryInfrastructure
 		result.EndTime = time.Now()
 		result.Duration = result.EndTime.Sub(result.StartTime)
 		platform.Close()
 		return result
 	}
 
-	defer platform.Close()
+	defer e.platformCall(appCtx, app, "Close", platform.Close)
+	if versioner, ok := platform.(browserVersioner); ok {
+		if version, err := versioner.BrowserVersion(); err == nil {
+			e.browserVersion = version
+		}
+	}
+	e.pageRoute = ""
+	e.visitRoute(&result, app.URL)
 
-	// Execute actions - use per-app actions if defined, otherwise global actions
-	actions := e.config.GetActionsForApp(app)
-	currentRecordingFile := ""
-	for i, action := range actions {
-		e.logger.Debugf("Executing action %d: %s (%s)", i, action.Name, action.Type)
+	// Drivers only tell their capabilities once they're running
+	selected, _ := e.config.SelectActions(app, e.tagFilter)
+	if errs := unsupportedActions(platform, app, selected); len(errs) > 0 {
+		result.Error = errors.Join(errs...).Error()
+		result.FailureCategory = config.CategoryInfrastructure
+		result.EndTime = time.Now()
+		result.Duration = result.EndTime.Sub(result.StartTime)
+		return result
+	}
+	e.network = config.NetworkConditions{}
+	defer e.stopChaos()
 
-		if err := e.executeAction(platform, action, app, &result, &currentRecordingFile); err != nil {
-			result.Error = fmt.Sprintf("Action '%s' failed: %v", action.Name, err)
+	// Start web apps under the configured network conditions
+	if network := e.config.Settings.Network; network != nil && app.Type == "web" {
+		if err := e.emulateNetwork(platform, network, "settings.network", &result); err != nil {
+			result.Error = fmt.Sprintf("Failed to emulate network conditions: %v", err)
+			result.FailureCategory = config.CategoryInfrastructure
 			result.EndTime = time.Now()
 			result.Duration = result.EndTime.Sub(result.StartTime)
 			return result
 		}
 	}
 
+	// Serve the app's feature flag overrides until it finishes
+	defer e.restoreFeatureFlags()
+	if err := e.applyFeatureFlags(appCtx, app, &result); err != nil {
+		result.Error = fmt.Sprintf("Failed to apply feature flags: %v", err)
+		result.FailureCategory = config.CategoryInfrastructure
+		result.EndTime = time.Now()
+		result.Duration = result.EndTime.Sub(result.StartTime)
+		return result
+	}
+
+	// Sample the browser/app process while actions run; early returns still
+	// attach what was collected
+	monitor := e.startResourceMonitor(platform, app)
+	defer e.finishResourceMonitor(monitor, &result)
+
+	// Tracing writes <output>/traces/<app>.zip once the app's result is final
+	var recorder *trace.Recorder
+	if e.tracing {
+		var stopNetwork func()
+		recorder, stopNetwork = e.startTrace(platform, app)
+		defer e.finishTrace(recorder, stopNetwork, app, &result)
+	}
+
+	// API contracts check, or record, the JSON responses of the app's pages
+	contracts := e.startContracts(platform, app)
+	defer e.finishContracts(contracts, &result)
+
+	// Execute actions - use per-app actions if defined, otherwise global actions,
+	// narrowed to those selected by --tags
+	actions, skipped := e.config.SelectActions(app, e.tagFilter)
+	if len(skipped) > 0 {
+		result.Metrics["skipped_actions"] = skipped
+	}
+	currentRecordingFile := ""
+	appLogger := e.logger
+	defer func() { e.logger = appLogger }()
+	for {
+		for i, action := range actions {
+			e.logger = appLogger.WithFields(logger.Fields{"action": action.Name})
+			e.logger.Debugf("Executing action %d: %s (%s)", i, action.Name, action.Type)
+
+			if e.debugger != nil && !e.debugBeforeAction(platform, app, actions, i, &result, &currentRecordingFile) {
+				result.Error = fmt.Sprintf("Aborted in debugger at action '%s'", action.Name)
+				result.EndTime = time.Now()
+				result.Duration = result.EndTime.Sub(result.StartTime)
+				return result
+			}
+
+			if recorder != nil {
+				recorder.BeginAction(i, action.Name, action.Type, action.Parameters, traceScreenshot(platform))
+			}
+			started := time.Now()
+			err := e.executeAction(platform, action, app, &result, &currentRecordingFile)
+			if e.onAction != nil {
+				e.onAction(action, time.Since(started), err)
+			}
+			if err != nil && e.debugger != nil {
+				err = e.debugOnFailure(platform, app, action, i, err, &result, &currentRecordingFile)
+			}
+			if recorder != nil {
+				recorder.EndAction(err, traceScreenshot(platform))
+			}
+			if err != nil {
+				result.Error = fmt.Sprintf("Action '%s' failed: %v", action.Name, err)
+				result.Metrics["failed_action"] = action.Name
+				result.FailureCategory = action.FailureCategory()
+				var audit *localizationError
+				if errors.As(err, &audit) {
+					result.FailureCategory = config.CategoryLocalization
+				}
+				result.EndTime = time.Now()
+				result.Duration = result.EndTime.Sub(result.StartTime)
+				return result
+			}
+		}
+		if e.soak == nil || !e.soak.passDone(platform) {
+			break
+		}
+	}
+
+	e.logger = appLogger
+
 	// Stop recording if still active
 	if currentRecordingFile != "" {
 		if err := platform.StopRecording(); err != nil {
@@ -500,10 +1181,28 @@ func (e *Executor) executeApp(app config.AppConfig) TestResult {
 		}
 	}
 
-	// Get final metrics
+	// Get final metrics, keeping those recorded by actions
+	actionMetrics := result.Metrics
 	result.Metrics = platform.GetMetrics()
+	if result.Metrics == nil {
+		result.Metrics = make(map[string]interface{})
+	}
+	for k, v := range actionMetrics {
+		result.Metrics[k] = v
+	}
+	violations := e.finishResourceMonitor(monitor, &result)
 	result.EndTime = time.Now()
 	result.Duration = result.EndTime.Sub(result.StartTime)
+	if len(violations) > 0 {
+		result.Error = resourceError(violations)
+		result.FailureCategory = config.CategoryResources
+		return result
+	}
+	if broken := e.finishContracts(contracts, &result); len(broken) > 0 {
+		result.Error = contractError(broken)
+		result.FailureCategory = config.CategoryContract
+		return result
+	}
 	result.Success = true
 
 	e.logger.Infof("executeApp completed successfully for %s", app.Name)
@@ -512,6 +1211,71 @@ func (e *Executor) executeApp(app config.AppConfig) TestResult {
 }
 
 func (e *Executor) executeAction(platform platforms.Platform, action config.Action, app config.AppConfig, result *TestResult, recordingFile *string) error {
+	ctx, span := e.tracer.Start(e.spanContext(), "panoptic.action",
+		telemetry.String("panoptic.app.name", app.Name),
+		telemetry.String("panoptic.action.name", action.Name),
+		telemetry.String("panoptic.action.type", action.Type))
+	action, err := e.interpolateAction(action)
+	if err == nil {
+		action, err = e.interpolateSecrets(ctx, action)
+	}
+	if err == nil {
+		e.maskFilledValue(action)
+		err = e.throttleAction(ctx)
+	}
+	if err == nil {
+		err = e.handleCaptcha(ctx, platform, action, result)
+	}
+	if err == nil {
+		err = e.setDialogPolicy(platform, action, app)
+	}
+	var took time.Duration
+	if err == nil {
+		started := time.Now()
+		err = e.runAction(ctx, platform, action, app, result, recordingFile)
+		took = time.Since(started)
+		err = e.collectDialogs(platform, action, app, result, err)
+		e.collectWebSockets(platform, action, app, result)
+	}
+	if err == nil {
+		err = e.auditLocale(ctx, platform, action, app, result)
+	}
+	if err == nil {
+		e.recordCoverage(platform, action, result)
+		err = e.recordMetrics(platform, action, took, result)
+	}
+	span.End(err)
+	return err
+}
+
+// interpolateAction fills {{fake.*}} and {{var.*}} placeholders in an
+// action's URL, target, value, selector and parameters
+func (e *Executor) interpolateAction(action config.Action) (config.Action, error) {
+	fields := []*string{&action.URL, &action.Target, &action.Value, &action.Selector}
+	for _, field := range fields {
+		value, err := e.fake.Interpolate(*field)
+		if err == nil {
+			value, err = e.interpolateVars(value)
+		}
+		if err != nil {
+			return action, fmt.Errorf("action '%s': %w", action.Name, err)
+		}
+		*field = value
+	}
+	if action.Parameters != nil {
+		params, err := e.fake.InterpolateValue(action.Parameters)
+		if err == nil {
+			params, err = e.interpolateVarsValue(params)
+		}
+		if err != nil {
+			return action, fmt.Errorf("action '%s': %w", action.Name, err)
+		}
+		action.Parameters = params.(map[string]interface{})
+	}
+	return action, nil
+}
+
+func (e *Executor) runAction(ctx context.Context, platform platforms.Platform, action config.Action, app config.AppConfig, result *TestResult, recordingFile *string) error {
 	// Check if platform is initialized for platform-specific actions
 	if platform == nil && actionRequiresPlatform(action.Type) {
 		return fmt.Errorf("platform not initialized")
@@ -523,22 +1287,38 @@ func (e *Executor) executeAction(platform platforms.Platform, action config.Acti
 		if navURL == "" {
 			return fmt.Errorf("navigate action '%s' requires a URL or value", action.Name)
 		}
-		return platform.Navigate(navURL)
+		err := e.throttleNavigation(ctx, func() error {
+			return e.platformCall(ctx, app, "Navigate", func() error { return platform.Navigate(navURL) })
+		})
+		if err == nil {
+			e.visitRoute(result, navURL)
+		}
+		return err
 
 	case "click":
 		if action.Selector != "" {
-			return platform.Click(action.Selector)
+			return e.platformCall(ctx, app, "Click", func() error { return platform.Click(action.Selector) })
 		} else if action.Target != "" {
-			return platform.Click(action.Target)
+			return e.platformCall(ctx, app, "Click", func() error { return platform.Click(action.Target) })
 		}
+		return fmt.Errorf("click action '%s' requires a selector or target", action.Name)
 
 	case "fill":
-		if action.Selector != "" && action.Value != "" {
-			return platform.Fill(action.Selector, action.Value)
+		if action.Selector == "" || action.Value == "" {
+			return fmt.Errorf("fill action '%s' requires a selector and value", action.Name)
 		}
+		return e.platformCall(ctx, app, "Fill", func() error { return platform.Fill(action.Selector, action.Value) })
 
 	case "submit":
-		return platform.Submit(action.Selector)
+		return e.platformCall(ctx, app, "Submit", func() error { return platform.Submit(action.Selector) })
+
+	case "pause":
+		// Handled by the debugger before the action runs; a no-op otherwise so
+		// unattended runs never block on input
+		if e.debugger == nil {
+			e.logger.Infof("Ignoring pause action '%s' (run with --debug to stop here)", action.Name)
+		}
+		return nil
 
 	case "wait":
 		waitTime := action.WaitTime
@@ -549,18 +1329,45 @@ func (e *Executor) executeAction(platform platforms.Platform, action config.Acti
 		return nil
 
 	case "screenshot":
-		filename := filepath.Join(e.outputDir, "screenshots", fmt.Sprintf("%s_%s_%d.png", app.Name, action.Name, time.Now().Unix()))
+		// Platforms capturing into memory leave the write to the screenshot workers
+		encoder, async := platform.(screenshotEncoder)
+		async = async && e.screenshots != nil
+		ext := ".png"
+		if async {
+			ext = platforms.ScreenshotExtension(e.config.Settings.ScreenshotFormat)
+		}
+		filename := filepath.Join(e.outputDir, "screenshots", fmt.Sprintf("%s_%s_%d%s", app.Name, action.Name, time.Now().Unix(), ext))
 		if action.Parameters != nil {
 			if name, ok := action.Parameters["filename"].(string); ok {
 				filename = filepath.Join(e.outputDir, "screenshots", name)
 			}
 		}
 
-		if err := platform.Screenshot(filename); err != nil {
+		// Outline the highlighted elements, located before the capture
+		highlights, err := highlightAnnotations(platform, action.Parameters["highlight"])
+		if err != nil {
+			return err
+		}
+
+		if async {
+			var data []byte
+			err := e.platformCall(ctx, app, "Screenshot", func() (err error) {
+				data, err = encoder.EncodeScreenshot(filename, e.config.Settings.ScreenshotFormat, e.config.Settings.Quality)
+				return err
+			})
+			if err != nil {
+				return err
+			}
+			e.screenshots.write(filename, data)
+			if len(highlights) > 0 {
+				e.screenshots.wait(filename)
+			}
+		} else if err := e.platformCall(ctx, app, "Screenshot", func() error { return platform.Screenshot(filename) }); err != nil {
 			return err
 		}
 		result.Screenshots = append(result.Screenshots, filename)
 		e.logger.Infof("Screenshot saved: %s", filename)
+		e.annotateScreenshot(result, filename, highlights)
 
 	case "record":
 		duration := action.Duration
@@ -575,7 +1382,7 @@ func (e *Executor) executeAction(platform platforms.Platform, action config.Acti
 			}
 		}
 
-		if err := platform.StartRecording(filename); err != nil {
+		if err := e.platformCall(ctx, app, "StartRecording", func() error { return platform.StartRecording(filename) }); err != nil {
 			return err
 		}
 
@@ -618,6 +1425,84 @@ func (e *Executor) executeAction(platform platforms.Platform, action config.Acti
 		}
 		return fmt.Errorf("vision actions only supported on web platform")
 
+	case "performance_assert":
+		// Check Core Web Vitals of the current page against budgets
+		return e.assertPerformance(platform, action, result)
+
+	case "restart_app", "clear_browser_cache", "expire_session", "disconnect_network":
+		return e.runChaosAction(ctx, platform, action, app, result, recordingFile)
+
+	case "wait_for_email":
+		return e.waitForEmail(ctx, action, result)
+
+	case "vision_decode_qr":
+		return e.decodeQR(ctx, platform, action, app, result)
+
+	case "vision_contrast_check":
+		return e.checkContrast(ctx, platform, action, app, result)
+
+	case "vision_layout_check":
+		return e.checkLayout(ctx, platform, action, app, result)
+
+	case "vision_design_compare":
+		return e.compareDesign(ctx, platform, action, app, result)
+
+	case "console_check":
+		return e.checkConsole(platform, action, result)
+
+	case "http_request":
+		return e.sendHTTPRequest(ctx, action, result)
+
+	case "graphql":
+		return e.sendGraphQL(ctx, action, result)
+
+	case "login":
+		return e.login(ctx, platform, action, app)
+
+	case "totp":
+		return e.generateTOTP(ctx, action)
+
+	case "wait_for_popup", "switch_tab", "close_tab":
+		return e.runTabAction(platform, action)
+
+	case "set_clipboard", "assert_clipboard":
+		return e.runClipboardAction(platform, action)
+
+	case "scroll_to", "scroll_by", "scroll_until":
+		return e.runScrollAction(ctx, platform, action, result)
+
+	case "save_pdf", "assert_pdf":
+		return e.runPDFAction(ctx, platform, action, app, result)
+
+	case "assert_websocket":
+		return e.assertWebSocket(platform, action, app, result)
+
+	case "unregister_service_worker", "update_service_worker", "assert_service_worker",
+		"assert_cache", "assert_installable", "assert_offline_fallback":
+		return e.runPWAAction(platform, action, result)
+
+	case "set_feature_flag":
+		key, value, err := action.FeatureFlag()
+		if err != nil {
+			return err
+		}
+		if e.config.Settings.FeatureFlags == nil {
+			return fmt.Errorf("set_feature_flag requires settings.feature_flags")
+		}
+		if err := e.setFeatureFlag(ctx, key, value, result); err != nil {
+			return err
+		}
+		e.awaitFlagPropagation()
+		return nil
+
+	case "network":
+		// Throttle or disconnect the network from this action on
+		conditions, err := action.NetworkConditions()
+		if err != nil {
+			return fmt.Errorf("network action '%s': %w", action.Name, err)
+		}
+		return e.emulateNetwork(platform, conditions, action.Name, result)
+
 	case "vision_report":
 		// Generate computer vision report
 		if webPlatform, ok := platform.(*platforms.WebPlatform); ok {
@@ -635,7 +1520,12 @@ func (e *Executor) executeAction(platform platforms.Platform, action config.Acti
 	case "smart_error_detection":
 		// Generate smart error detection report
 		if webPlatform, ok := platform.(*platforms.WebPlatform); ok {
-			return e.generateSmartErrorDetection(webPlatform)
+			detected, err := e.smartErrorDetection(webPlatform)
+			if err != nil {
+				return err
+			}
+			recordDetectedErrors(result, action.Name, detected)
+			return nil
 		}
 		return fmt.Errorf("Smart error detection only supported on web platform")
 
@@ -645,7 +1535,7 @@ func (e *Executor) executeAction(platform platforms.Platform, action config.Acti
 
 	case "cloud_sync":
 		// Sync test results to cloud storage
-		return e.executeCloudSync(app)
+		return e.syncToCloud(ctx, app)
 
 	case "cloud_analytics":
 		// Generate cloud analytics report
@@ -1094,32 +1984,38 @@ func (e *Executor) generateAITests(platform *platforms.WebPlatform) error {
 
 // generateSmartErrorDetection performs smart error detection
 func (e *Executor) generateSmartErrorDetection(platform *platforms.WebPlatform) error {
+	_, err := e.smartErrorDetection(platform)
+	return err
+}
+
+// smartErrorDetection saves the smart error report and returns the detected errors
+func (e *Executor) smartErrorDetection(platform *platforms.WebPlatform) ([]interface{}, error) {
 	e.logger.Info("Performing smart error detection...")
 
 	if e.aiTester == nil {
-		return fmt.Errorf("AI tester not initialized")
+		return nil, fmt.Errorf("AI tester not initialized")
 	}
 
 	// Get current page state
 	pageState, err := platform.GetPageState()
 	if err != nil {
-		return fmt.Errorf("failed to get page state: %w", err)
+		return nil, fmt.Errorf("failed to get page state: %w", err)
 	}
 
 	// Detect errors using AI
 	errors, err := e.aiTester.DetectErrors(pageState)
 	if err != nil {
-		return fmt.Errorf("failed to detect errors: %w", err)
+		return nil, fmt.Errorf("failed to detect errors: %w", err)
 	}
 
 	// Save error report
 	reportPath := filepath.Join(e.outputDir, "smart_error_report.json")
 	if err := e.aiTester.SaveErrorReport(errors, reportPath); err != nil {
-		return fmt.Errorf("failed to save error report: %w", err)
+		return nil, fmt.Errorf("failed to save error report: %w", err)
 	}
 
 	e.logger.Infof("Detected %d potential errors, report saved to %s", len(errors), reportPath)
-	return nil
+	return errors, nil
 }
 
 // executeAIEnhancedTesting executes AI-enhanced testing
@@ -1153,6 +2049,22 @@ func (e *Executor) executeAIEnhancedTesting(platform platforms.Platform, app con
 
 // executeCloudSync syncs test results to cloud storage
 func (e *Executor) executeCloudSync(app config.AppConfig) error {
+	return e.syncToCloud(e.spanContext(), app)
+}
+
+// uploadArtifact uploads one file inside a cloud upload span
+func (e *Executor) uploadArtifact(ctx context.Context, path string) error {
+	_, span := e.tracer.StartClient(ctx, "cloud.upload", telemetry.String("panoptic.artifact", path))
+	if info, err := os.Stat(path); err == nil {
+		span.SetAttributes(telemetry.Int("panoptic.artifact.bytes", int(info.Size())))
+	}
+	err := e.cloudManager.Upload(path)
+	span.End(err)
+	return err
+}
+
+// syncToCloud uploads the output directory's files, tracing each upload under ctx
+func (e *Executor) syncToCloud(ctx context.Context, app config.AppConfig) error {
 	e.logger.Info("Syncing test results to cloud...")
 
 	if e.cloudManager == nil {
@@ -1177,7 +2089,7 @@ func (e *Executor) executeCloudSync(app config.AppConfig) error {
 
 			for _, subFile := range subFiles {
 				if stat, err := os.Stat(subFile); err == nil && !stat.IsDir() {
-					if err := e.cloudManager.Upload(subFile); err != nil {
+					if err := e.uploadArtifact(ctx, subFile); err != nil {
 						e.logger.Warnf("Failed to upload %s: %v", subFile, err)
 						continue
 					}
@@ -1187,7 +2099,7 @@ func (e *Executor) executeCloudSync(app config.AppConfig) error {
 		} else {
 			// Upload file directly
 			fullPath := filepath.Join(e.outputDir, fileInfo.Name())
-			if err := e.cloudManager.Upload(fullPath); err != nil {
+			if err := e.uploadArtifact(ctx, fullPath); err != nil {
 				e.logger.Warnf("Failed to upload %s: %v", fullPath, err)
 				continue
 			}
@@ -1249,6 +2161,33 @@ func (e *Executor) executeDistributedCloudTest(app config.AppConfig, action conf
 				}
 			}
 		}
+
+		// Apply scheduling strategy from config
+		if scheduling, ok := e.config.Settings.Cloud["scheduling"].(map[string]interface{}); ok {
+			e.cloudManager.Config.Scheduling = cloud.SchedulingConfig{
+				Strategy:           getStringFromMap(scheduling, "strategy"),
+				Region:             getStringFromMap(scheduling, "region"),
+				MaxRetries:         getIntFromMap(scheduling, "max_retries"),
+				HealthCheck:        getBoolFromMap(scheduling, "health_check"),
+				HealthCheckPath:    getStringFromMap(scheduling, "health_check_path"),
+				HealthCheckTimeout: getIntFromMap(scheduling, "health_check_timeout"),
+			}
+		}
+
+		// Resolve additional nodes from a registry service
+		if registry, ok := e.config.Settings.Cloud["registry"].(map[string]interface{}); ok {
+			registryConfig := cloud.RegistryConfig{
+				URL:    getStringFromMap(registry, "url"),
+				APIKey: getStringFromMap(registry, "api_key"),
+			}
+			if selector, ok := registry["selector"].(map[string]interface{}); ok {
+				registryConfig.Selector = make(map[string]string, len(selector))
+				for k, v := range selector {
+					registryConfig.Selector[k] = fmt.Sprintf("%v", v)
+				}
+			}
+			e.cloudManager.Config.Registry = registryConfig
+		}
 	}
 
 	// Execute distributed test across nodes
@@ -1257,6 +2196,10 @@ func (e *Executor) executeDistributedCloudTest(app config.AppConfig, action conf
 		return fmt.Errorf("distributed test failed: %w", err)
 	}
 
+	for _, result := range results {
+		e.recordNode(result.NodeID)
+	}
+
 	// Save results
 	reportPath := filepath.Join(e.outputDir, "distributed_test_report.json")
 	data, err := json.MarshalIndent(results, "", "  ")
@@ -1285,7 +2228,7 @@ func (e *Executor) saveEnterpriseReport(report interface{}, filePath string) err
 // GenerateReport generates an HTML report from test results
 func (e *Executor) GenerateReport(outputPath string) error {
 	e.logger.Infof("Generating report: %s", outputPath)
-	return GenerateComprehensiveReport(outputPath, e.results)
+	return writeReport(outputPath, e.results, e.reportTheme(), e.config.Settings.Encryption.IsEnabled(), e.eachResult)
 }
 
 // FastGenerateReport optimized version using strings.Builder and pre-allocated buffer
@@ -1426,14 +2369,38 @@ func StreamGenerateReport(outputPath string, results []TestResult) error {
 // actionRequiresPlatform returns true if the action type requires a platform
 func actionRequiresPlatform(actionType string) bool {
 	platformActions := map[string]bool{
-		"navigate":      true,
-		"click":         true,
-		"fill":          true,
-		"submit":        true,
-		"screenshot":    true,
-		"record":        true,
-		"vision_click":  true,
-		"vision_report": true,
+		"navigate":                  true,
+		"click":                     true,
+		"fill":                      true,
+		"submit":                    true,
+		"screenshot":                true,
+		"record":                    true,
+		"vision_click":              true,
+		"vision_report":             true,
+		"performance_assert":        true,
+		"network":                   true,
+		"restart_app":               true,
+		"clear_browser_cache":       true,
+		"expire_session":            true,
+		"disconnect_network":        true,
+		"console_check":             true,
+		"login":                     true,
+		"wait_for_popup":            true,
+		"switch_tab":                true,
+		"close_tab":                 true,
+		"set_clipboard":             true,
+		"assert_clipboard":          true,
+		"scroll_to":                 true,
+		"scroll_by":                 true,
+		"scroll_until":              true,
+		"save_pdf":                  true,
+		"assert_websocket":          true,
+		"unregister_service_worker": true,
+		"update_service_worker":     true,
+		"assert_service_worker":     true,
+		"assert_cache":              true,
+		"assert_installable":        true,
+		"assert_offline_fallback":   true,
 	}
 	return platformActions[actionType]
 }
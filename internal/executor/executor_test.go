@@ -1,6 +1,7 @@
 package executor
 
 import (
+	"context"
 	"encoding/json"
 	"os"
 	"path/filepath"
@@ -11,6 +12,8 @@ import (
 	"panoptic/internal/logger"
 	"panoptic/internal/cloud"
 
+	"github.com/sirupsen/logrus"
+	logtest "github.com/sirupsen/logrus/hooks/test"
 	"github.com/stretchr/testify/assert"
 
 )
@@ -1730,3 +1733,86 @@ func TestExecutor_ExecuteAction_VisionReport(t *testing.T) {
 	assert.Error(t, err)
 	assert.Contains(t, err.Error(), "platform not initialized")
 }
+
+// TestExecutor_ExpandApps tests that web browser matrices are expanded and results tagged
+func TestExecutor_ExpandApps(t *testing.T) {
+	cfg := &config.Config{
+		Apps: []config.AppConfig{
+			{Name: "Shop", Type: "web", Browsers: []config.BrowserConfig{{Engine: "chromium"}, {Engine: "firefox"}}},
+			{Name: "Desk", Type: "desktop", Browsers: []config.BrowserConfig{{Engine: "chromium"}}},
+		},
+	}
+	executor := NewExecutor(cfg, t.TempDir(), logger.NewLogger(false))
+
+	apps := executor.expandApps()
+
+	if assert.Len(t, apps, 3) {
+		assert.Equal(t, "Shop [chromium]", apps[0].Name)
+		assert.Equal(t, "Shop [firefox]", apps[1].Name)
+		assert.Equal(t, "Desk", apps[2].Name, "browser matrix only applies to web apps")
+	}
+
+	// firefox is rejected by the CDP web platform; the result must say so and carry the browser label
+	result := executor.dispatchApp(config.AppConfig{Name: "Shop [firefox]", Type: "web", Timeout: 5, Browser: "firefox"})
+	assert.False(t, result.Success)
+	assert.Equal(t, "firefox", result.Browser)
+	assert.Contains(t, result.Error, "not supported")
+
+	data, err := json.Marshal(&result)
+	assert.NoError(t, err)
+	assert.Contains(t, string(data), `"browser":"firefox"`)
+}
+
+// TestExecutor_RunContext tests stopping a run before its next app
+func TestExecutor_RunContext(t *testing.T) {
+	appPath := filepath.Join(t.TempDir(), "app")
+	assert.NoError(t, os.WriteFile(appPath, []byte("#!/bin/sh\n"), 0755))
+	cfg := &config.Config{
+		Apps:    []config.AppConfig{{Name: "Desk", Type: "desktop", Path: appPath}},
+		Actions: []config.Action{{Name: "hold", Type: "pause"}},
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	executor := NewExecutor(cfg, t.TempDir(), logger.NewLogger(false))
+	err := executor.RunContext(ctx)
+	assert.EqualError(t, err, "run stopped: context canceled")
+	assert.Zero(t, executor.Summary().Total, "no app ran")
+}
+
+// TestExecutor_Run_LogContext tests that log entries carry run, app and action fields
+func TestExecutor_Run_LogContext(t *testing.T) {
+	appPath := filepath.Join(t.TempDir(), "app")
+	assert.NoError(t, os.WriteFile(appPath, []byte("#!/bin/sh\n"), 0755))
+
+	cfg := &config.Config{
+		Apps: []config.AppConfig{{Name: "Desk", Type: "desktop", Path: appPath}},
+		Actions: []config.Action{{Name: "hold", Type: "pause"}},
+	}
+	log := logger.NewLogger(false)
+	hook := logtest.NewLocal(log.Logger)
+	executor := NewExecutor(cfg, t.TempDir(), log)
+	executor.SetRunID("run-42")
+
+	assert.NoError(t, executor.Run())
+	assert.Equal(t, "run-42", executor.RunID())
+	assert.Same(t, log, executor.logger, "Run restores the caller's logger")
+
+	var runEntry, actionEntry *logrus.Entry
+	for _, entry := range hook.AllEntries() {
+		switch entry.Message {
+		case "Starting execution":
+			runEntry = entry
+		case "Ignoring pause action 'hold' (run with --debug to stop here)":
+			actionEntry = entry
+		}
+	}
+	if assert.NotNil(t, runEntry) {
+		assert.Equal(t, "run-42", runEntry.Data["run_id"])
+		assert.NotContains(t, runEntry.Data, "app")
+	}
+	if assert.NotNil(t, actionEntry) {
+		assert.Equal(t, "run-42", actionEntry.Data["run_id"])
+		assert.Equal(t, "Desk", actionEntry.Data["app"])
+		assert.Equal(t, "hold", actionEntry.Data["action"])
+	}
+}
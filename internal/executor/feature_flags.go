@@ -0,0 +1,141 @@
+package executor
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+	"time"
+
+	"panoptic/internal/config"
+	"panoptic/internal/flags"
+)
+
+// newFlagProvider connects to the provider in settings.feature_flags
+func newFlagProvider(s *config.FeatureFlagSettings) (flags.Provider, error) {
+	token := os.ExpandEnv(s.Token)
+	switch s.Provider {
+	case "launchdarkly":
+		return flags.NewLaunchDarkly(token, s.URL, s.Project, s.Environment), nil
+	case "unleash":
+		return flags.NewUnleash(token, s.URL, s.Project, s.Environment), nil
+	case "configcat":
+		return flags.NewConfigCat(token, s.URL, s.Project, s.Environment), nil
+	}
+	return nil, fmt.Errorf("unknown feature flag provider %q", s.Provider)
+}
+
+// flagProvider returns the run's feature flag provider, connecting on first use
+func (e *Executor) flagProvider() (flags.Provider, error) {
+	if e.flags == nil {
+		provider, err := newFlagProvider(e.config.Settings.FeatureFlags)
+		if err != nil {
+			return nil, err
+		}
+		e.flags = provider
+	}
+	return e.flags, nil
+}
+
+// applyFeatureFlags serves the app's feature_flags overrides, in flag order,
+// and records the flags the app starts with
+func (e *Executor) applyFeatureFlags(ctx context.Context, app config.AppConfig, result *TestResult) error {
+	if e.config.Settings.FeatureFlags == nil {
+		return nil
+	}
+	keys := make([]string, 0, len(app.FeatureFlags))
+	for key := range app.FeatureFlags {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		if err := e.setFeatureFlag(ctx, key, app.FeatureFlags[key], result); err != nil {
+			return err
+		}
+	}
+	if len(keys) > 0 {
+		e.awaitFlagPropagation()
+	}
+	e.captureFeatureFlags(ctx, result)
+	return nil
+}
+
+// setFeatureFlag overrides one flag until the app finishes
+func (e *Executor) setFeatureFlag(ctx context.Context, key string, value interface{}, result *TestResult) error {
+	provider, err := e.flagProvider()
+	if err != nil {
+		return err
+	}
+	restore, err := provider.Set(ctx, key, value)
+	if err != nil {
+		return fmt.Errorf("failed to set feature flag %s: %w", key, err)
+	}
+	e.flagRestores = append(e.flagRestores, func(ctx context.Context) error {
+		if err := restore(ctx); err != nil {
+			return fmt.Errorf("failed to restore feature flag %s: %w", key, err)
+		}
+		return nil
+	})
+	if result.FeatureFlags == nil {
+		result.FeatureFlags = make(map[string]interface{})
+	}
+	result.FeatureFlags[key] = value
+	e.logger.Infof("Feature flag %s set to %v", key, value)
+	return nil
+}
+
+// captureFeatureFlags records the value each flag serves, limited to
+// settings.feature_flags.capture when set. A provider that can't be read
+// only costs the record, not the run.
+func (e *Executor) captureFeatureFlags(ctx context.Context, result *TestResult) {
+	provider, err := e.flagProvider()
+	if err != nil {
+		e.logger.Warnf("Feature flags not captured: %v", err)
+		return
+	}
+	values, err := provider.Values(ctx)
+	if err != nil {
+		e.logger.Warnf("Feature flags not captured: %v", err)
+		return
+	}
+	if capture := e.config.Settings.FeatureFlags.Capture; len(capture) > 0 {
+		selected := make(map[string]interface{}, len(capture))
+		for _, key := range capture {
+			if value, ok := values[key]; ok {
+				selected[key] = value
+			}
+		}
+		values = selected
+	}
+	if result.FeatureFlags == nil {
+		result.FeatureFlags = make(map[string]interface{}, len(values))
+	}
+	for key, value := range values {
+		if _, overridden := result.FeatureFlags[key]; !overridden {
+			result.FeatureFlags[key] = value
+		}
+	}
+}
+
+// awaitFlagPropagation gives SDKs time to pick up changed flags
+func (e *Executor) awaitFlagPropagation() {
+	if delay := e.config.Settings.FeatureFlags.PropagationDelay; delay > 0 {
+		time.Sleep(delay)
+	}
+}
+
+// restoreFeatureFlags undoes the app's overrides, newest first
+func (e *Executor) restoreFeatureFlags() {
+	restores := e.flagRestores
+	e.flagRestores = nil
+	if len(restores) == 0 {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	for i := len(restores) - 1; i >= 0; i-- {
+		if err := restores[i](ctx); err != nil {
+			e.logger.Warnf("%v", err)
+		}
+	}
+}
@@ -0,0 +1,106 @@
+package executor
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"panoptic/internal/config"
+	"panoptic/internal/flags"
+	"panoptic/internal/logger"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeFlags is an in-memory flags.Provider that logs changes
+type fakeFlags struct {
+	values  map[string]interface{}
+	changes []string
+}
+
+func (f *fakeFlags) Set(_ context.Context, key string, value interface{}) (func(context.Context) error, error) {
+	previous, ok := f.values[key]
+	if !ok {
+		return nil, fmt.Errorf("no flag %s", key)
+	}
+	f.values[key] = value
+	f.changes = append(f.changes, fmt.Sprintf("%s=%v", key, value))
+	return func(context.Context) error {
+		f.values[key] = previous
+		f.changes = append(f.changes, fmt.Sprintf("%s=%v", key, previous))
+		return nil
+	}, nil
+}
+
+func (f *fakeFlags) Values(context.Context) (map[string]interface{}, error) {
+	values := make(map[string]interface{}, len(f.values))
+	for k, v := range f.values {
+		values[k] = v
+	}
+	return values, nil
+}
+
+// TestExecutor_FeatureFlags tests overrides, set_feature_flag, capture and
+// restoring the flags after the app
+func TestExecutor_FeatureFlags(t *testing.T) {
+	appPath := filepath.Join(t.TempDir(), "app")
+	require.NoError(t, os.WriteFile(appPath, []byte("#!/bin/sh\n"), 0755))
+	cfg := &config.Config{
+		Apps: []config.AppConfig{{
+			Name: "Shop", Type: "desktop", Path: appPath,
+			FeatureFlags: map[string]interface{}{"new-checkout": true, "theme": "dark"},
+			Actions:      []config.Action{{Name: "beta search", Type: "set_feature_flag", Target: "search", Value: "true"}},
+		}},
+		Settings: config.Settings{FeatureFlags: &config.FeatureFlagSettings{Provider: "unleash", Capture: []string{"search", "legacy"}}},
+	}
+	provider := &fakeFlags{values: map[string]interface{}{"new-checkout": false, "theme": "light", "search": false, "legacy": true, "other": 1}}
+	executor := NewExecutor(cfg, t.TempDir(), logger.NewLogger(false))
+	executor.flags = provider
+
+	result := executor.executeApp(cfg.Apps[0])
+	assert.True(t, result.Success, result.Error)
+	assert.Equal(t, map[string]interface{}{"new-checkout": true, "theme": "dark", "search": true, "legacy": true}, result.FeatureFlags)
+	assert.Equal(t, []string{
+		"new-checkout=true", "theme=dark", "search=true",
+		"search=false", "theme=light", "new-checkout=false",
+	}, provider.changes, "overrides apply in flag order and are restored newest first")
+	assert.Empty(t, executor.flagRestores)
+}
+
+// TestExecutor_FeatureFlags_Failure tests that a failed override fails the
+// app as infrastructure and undoes the overrides already made
+func TestExecutor_FeatureFlags_Failure(t *testing.T) {
+	appPath := filepath.Join(t.TempDir(), "app")
+	require.NoError(t, os.WriteFile(appPath, []byte("#!/bin/sh\n"), 0755))
+	cfg := &config.Config{
+		Apps: []config.AppConfig{{
+			Name: "Shop", Type: "desktop", Path: appPath,
+			FeatureFlags: map[string]interface{}{"a": true, "missing": true},
+		}},
+		Settings: config.Settings{FeatureFlags: &config.FeatureFlagSettings{Provider: "unleash"}},
+	}
+	provider := &fakeFlags{values: map[string]interface{}{"a": false}}
+	executor := NewExecutor(cfg, t.TempDir(), logger.NewLogger(false))
+	executor.flags = provider
+
+	result := executor.executeApp(cfg.Apps[0])
+	assert.False(t, result.Success)
+	assert.Equal(t, "Failed to apply feature flags: failed to set feature flag missing: no flag missing", result.Error)
+	assert.Equal(t, config.CategoryInfrastructure, result.FailureCategory)
+	assert.Equal(t, []string{"a=true", "a=false"}, provider.changes)
+}
+
+// TestNewFlagProvider tests choosing the provider
+func TestNewFlagProvider(t *testing.T) {
+	provider, err := newFlagProvider(&config.FeatureFlagSettings{Provider: "launchdarkly", Token: "${LD_TOKEN}"})
+	require.NoError(t, err)
+	assert.IsType(t, &flags.LaunchDarkly{}, provider)
+	provider, err = newFlagProvider(&config.FeatureFlagSettings{Provider: "configcat"})
+	require.NoError(t, err)
+	assert.IsType(t, &flags.ConfigCat{}, provider)
+	_, err = newFlagProvider(&config.FeatureFlagSettings{Provider: "split"})
+	assert.Error(t, err)
+}
@@ -0,0 +1,41 @@
+package executor
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"regexp"
+	"strings"
+)
+
+// Volatile parts of error messages that differ between runs of the same failure
+var (
+	uuidPattern   = regexp.MustCompile(`(?i)\b[0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{12}\b`)
+	hexPattern    = regexp.MustCompile(`(?i)\b(?:0x)?[0-9a-f]{8,}\b`)
+	numberPattern = regexp.MustCompile(`\d+(?:\.\d+)?`)
+)
+
+// normalizeError replaces IDs, hashes and numbers so that timings, ports and
+// request IDs don't split one failure into many fingerprints
+func normalizeError(msg string) string {
+	msg = uuidPattern.ReplaceAllString(msg, "<uuid>")
+	msg = hexPattern.ReplaceAllStringFunc(msg, func(s string) string {
+		if !strings.ContainsAny(s, "0123456789") {
+			return s // a word such as "deadbeef"
+		}
+		return "<hex>"
+	})
+	msg = numberPattern.ReplaceAllString(msg, "<n>")
+	return strings.Join(strings.Fields(msg), " ")
+}
+
+// failureFingerprint identifies a failure across runs and browsers: the app,
+// the action that failed, the failure category and the normalized error
+func failureFingerprint(r *TestResult) string {
+	if r.Success {
+		return ""
+	}
+	app := strings.TrimSuffix(r.AppName, " ["+r.Browser+"]")
+	action, _ := r.Metrics["failed_action"].(string)
+	sum := sha256.Sum256([]byte(strings.Join([]string{app, action, r.FailureCategory, normalizeError(r.Error)}, "\x00")))
+	return hex.EncodeToString(sum[:6])
+}
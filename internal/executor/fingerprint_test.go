@@ -0,0 +1,29 @@
+package executor
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestNormalizeError tests that volatile values are replaced
+func TestNormalizeError(t *testing.T) {
+	assert.Equal(t, "timeout after <n>ms waiting for #pay (request <uuid>, trace <hex>)",
+		normalizeError("timeout after 5012ms  waiting for #pay (request 0b8d5b8e-6f2d-4bd4-9a51-6f46f3c1d3a1, trace 4bf92f3577b34da6)"))
+	assert.Equal(t, "deadbeef port <n>", normalizeError("deadbeef port 8080"))
+}
+
+// TestFailureFingerprint tests stability across runs and browsers
+func TestFailureFingerprint(t *testing.T) {
+	failed := func(app, browser, action, err string) *TestResult {
+		return &TestResult{AppName: app, Browser: browser, Error: err, FailureCategory: "functional",
+			Metrics: map[string]interface{}{"failed_action": action}}
+	}
+	base := failureFingerprint(failed("Shop [chromium]", "chromium", "pay", "timeout after 5012ms"))
+	assert.Len(t, base, 12)
+	assert.Equal(t, base, failureFingerprint(failed("Shop [firefox]", "firefox", "pay", "timeout after 4870ms")))
+	assert.Equal(t, base, failureFingerprint(failed("Shop", "", "pay", "timeout after 1ms")))
+	assert.NotEqual(t, base, failureFingerprint(failed("Shop", "", "login", "timeout after 1ms")))
+	assert.NotEqual(t, base, failureFingerprint(failed("Shop", "", "pay", "element not found")))
+	assert.Empty(t, failureFingerprint(&TestResult{AppName: "Shop", Success: true}))
+}
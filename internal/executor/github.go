@@ -0,0 +1,226 @@
+package executor
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"panoptic/internal/config"
+	"panoptic/internal/github"
+)
+
+// maxSummaryFailures caps the failures listed in a check run summary; every
+// failure is still annotated
+const maxSummaryFailures = 20
+
+// githubTarget is a resolved settings.github
+type githubTarget struct {
+	repo, sha, token, apiURL, name, mode, reportURL string
+	pullRequest                                     int
+}
+
+// resolveGitHub fills unset settings from the GitHub Actions environment
+func (e *Executor) resolveGitHub(settings config.GitHubSettings) (githubTarget, error) {
+	t := githubTarget{
+		repo:        firstNonEmpty(settings.Repository, os.Getenv("GITHUB_REPOSITORY")),
+		sha:         settings.SHA,
+		token:       os.ExpandEnv(firstNonEmpty(settings.Token, "${GITHUB_TOKEN}")),
+		apiURL:      firstNonEmpty(settings.APIURL, os.Getenv("GITHUB_API_URL"), github.DefaultAPIURL),
+		name:        firstNonEmpty(settings.Name, "Panoptic"),
+		mode:        firstNonEmpty(settings.Mode, "check"),
+		pullRequest: settings.PullRequest,
+	}
+	if t.sha == "" && t.pullRequest == 0 {
+		// GITHUB_SHA is the test merge commit on pull_request events; report on the head
+		t.sha = firstNonEmpty(eventHeadSHA(), os.Getenv("GITHUB_SHA"))
+	}
+
+	switch {
+	case settings.ReportURL != "":
+		t.reportURL = strings.ReplaceAll(settings.ReportURL, "{run_id}", e.runID)
+	case os.Getenv("GITHUB_RUN_ID") != "" && t.repo != "":
+		t.reportURL = fmt.Sprintf("%s/%s/actions/runs/%s",
+			firstNonEmpty(os.Getenv("GITHUB_SERVER_URL"), "https://github.com"), t.repo, os.Getenv("GITHUB_RUN_ID"))
+	}
+
+	if t.repo == "" {
+		return t, fmt.Errorf("settings.github.repository is not set and GITHUB_REPOSITORY is empty")
+	}
+	if t.token == "" {
+		return t, fmt.Errorf("settings.github.token is empty and GITHUB_TOKEN is not set")
+	}
+	if t.sha == "" && t.pullRequest == 0 {
+		return t, fmt.Errorf("no commit to report on: set settings.github.sha or pull_request")
+	}
+	return t, nil
+}
+
+// eventHeadSHA returns the pull request head from the GitHub Actions event payload
+func eventHeadSHA() string {
+	if !strings.HasPrefix(os.Getenv("GITHUB_EVENT_NAME"), "pull_request") {
+		return ""
+	}
+	data, err := os.ReadFile(os.Getenv("GITHUB_EVENT_PATH"))
+	if err != nil {
+		return ""
+	}
+	var event struct {
+		PullRequest struct {
+			Head struct {
+				SHA string `json:"sha"`
+			} `json:"head"`
+		} `json:"pull_request"`
+	}
+	if json.Unmarshal(data, &event) != nil {
+		return ""
+	}
+	return event.PullRequest.Head.SHA
+}
+
+// ReportToGitHub posts the run as a check run with failure annotations, or as
+// a commit status in status mode, and returns the check run URL
+func (e *Executor) ReportToGitHub(ctx context.Context, settings config.GitHubSettings) (string, error) {
+	target, err := e.resolveGitHub(settings)
+	if err != nil {
+		return "", err
+	}
+	client := github.NewClient(target.apiURL, target.token)
+	if target.sha == "" {
+		if target.sha, err = client.PullRequestHead(ctx, target.repo, target.pullRequest); err != nil {
+			return "", err
+		}
+	}
+
+	_, policyErr := e.policyVerdict()
+	title := e.githubTitle()
+
+	if target.mode == "status" {
+		state := github.StateSuccess
+		if policyErr != nil {
+			state = github.StateFailure
+		}
+		return "", client.CreateStatus(ctx, target.repo, target.sha, github.Status{
+			State:       state,
+			TargetURL:   target.reportURL,
+			Description: title,
+			Context:     target.name,
+		})
+	}
+
+	conclusion := github.ConclusionSuccess
+	if policyErr != nil {
+		conclusion = github.ConclusionFailure
+	}
+	run := github.CheckRun{
+		Name:       target.name,
+		HeadSHA:    target.sha,
+		Status:     "completed",
+		Conclusion: conclusion,
+		DetailsURL: target.reportURL,
+		ExternalID: e.runID,
+		Output: &github.Output{
+			Title:       title,
+			Summary:     e.githubSummary(target.reportURL),
+			Annotations: e.githubAnnotations(),
+		},
+	}
+	if !e.startedAt.IsZero() {
+		run.StartedAt = &e.startedAt
+	}
+	if !e.finishedAt.IsZero() {
+		run.CompletedAt = &e.finishedAt
+	}
+	return client.CreateCheckRun(ctx, target.repo, run)
+}
+
+func (e *Executor) githubTitle() string {
+	s := e.Summary()
+	if s.Failed == 0 {
+		return fmt.Sprintf("%d of %d app(s) passed", s.Passed, s.Total)
+	}
+	return fmt.Sprintf("%d of %d app(s) failed", s.Failed, s.Total)
+}
+
+// githubSummary renders the check run summary as Markdown
+func (e *Executor) githubSummary(reportURL string) string {
+	s := e.Summary()
+	var b strings.Builder
+	fmt.Fprintf(&b, "| Total | Passed | Failed | Warnings | Quarantined |\n|---|---|---|---|---|\n| %d | %d | %d | %d | %d |\n",
+		s.Total, s.Passed, s.Failed, s.Warnings, s.Quarantined)
+
+	listed := 0
+	for _, r := range e.results {
+		if r.Success {
+			continue
+		}
+		if listed == 0 {
+			b.WriteString("\n### Failures\n\n")
+		}
+		if listed == maxSummaryFailures {
+			fmt.Fprintf(&b, "- ...and %d more\n", s.Total-s.Passed-listed)
+			break
+		}
+		note := ""
+		switch {
+		case r.Quarantined:
+			note = " _(quarantined)_"
+		case r.Severity == config.SeverityWarning:
+			note = " _(warning)_"
+		}
+		fmt.Fprintf(&b, "- **%s**%s: %s\n", r.AppName, note, strings.ReplaceAll(r.Error, "\n", " "))
+		listed++
+	}
+
+	if reportURL != "" {
+		fmt.Fprintf(&b, "\n[Full report](%s)\n", reportURL)
+	}
+	if e.runID != "" {
+		fmt.Fprintf(&b, "\nRun ID: `%s`\n", e.runID)
+	}
+	return b.String()
+}
+
+// githubAnnotations annotates the configuration file at each failed app or
+// action and at each detected error; without a configuration path GitHub has
+// nothing to attach annotations to
+func (e *Executor) githubAnnotations() []github.Annotation {
+	locator := newConfigLocator(e.configPath)
+	if locator.uri == "" {
+		return nil
+	}
+	annotation := func(app, action, level, title, message string) github.Annotation {
+		line := 1
+		if loc := locator.locations(app, action)[0].PhysicalLocation; loc.Region != nil {
+			line = loc.Region.StartLine
+		}
+		return github.Annotation{Path: locator.uri, StartLine: line, EndLine: line, AnnotationLevel: level, Title: title, Message: message}
+	}
+
+	var annotations []github.Annotation
+	for _, r := range e.results {
+		app := strings.TrimSuffix(r.AppName, " ["+r.Browser+"]")
+		if !r.Success {
+			level := github.LevelFailure
+			if r.Quarantined || r.Severity == config.SeverityWarning {
+				level = github.LevelWarning
+			}
+			action, _ := r.Metrics["failed_action"].(string)
+			annotations = append(annotations, annotation(app, action, level, r.AppName+" failed", r.Error))
+		}
+		for _, f := range r.Findings {
+			annotations = append(annotations, annotation(app, f.Action, github.LevelNotice, f.Type, f.Message))
+		}
+	}
+	return annotations
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
@@ -0,0 +1,122 @@
+package executor
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"panoptic/internal/config"
+	"panoptic/internal/logger"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// clearGitHubEnv unsets the GitHub Actions variables for the test
+func clearGitHubEnv(t *testing.T) {
+	for _, v := range []string{"GITHUB_REPOSITORY", "GITHUB_SHA", "GITHUB_TOKEN", "GITHUB_API_URL", "GITHUB_RUN_ID",
+		"GITHUB_SERVER_URL", "GITHUB_EVENT_NAME", "GITHUB_EVENT_PATH"} {
+		t.Setenv(v, "")
+	}
+}
+
+// TestExecutor_ResolveGitHub tests defaults from the GitHub Actions environment
+func TestExecutor_ResolveGitHub(t *testing.T) {
+	clearGitHubEnv(t)
+	executor := NewExecutor(&config.Config{}, t.TempDir(), logger.NewLogger(false))
+	executor.SetRunID("run-1")
+
+	_, err := executor.resolveGitHub(config.GitHubSettings{})
+	assert.ErrorContains(t, err, "GITHUB_REPOSITORY")
+	_, err = executor.resolveGitHub(config.GitHubSettings{Repository: "acme/shop"})
+	assert.ErrorContains(t, err, "GITHUB_TOKEN")
+	t.Setenv("GITHUB_TOKEN", "tok")
+	_, err = executor.resolveGitHub(config.GitHubSettings{Repository: "acme/shop"})
+	assert.ErrorContains(t, err, "no commit")
+
+	event := filepath.Join(t.TempDir(), "event.json")
+	require.NoError(t, os.WriteFile(event, []byte(`{"pull_request":{"head":{"sha":"head"}}}`), 0600))
+	t.Setenv("GITHUB_REPOSITORY", "acme/shop")
+	t.Setenv("GITHUB_SHA", "merge")
+	t.Setenv("GITHUB_EVENT_NAME", "pull_request")
+	t.Setenv("GITHUB_EVENT_PATH", event)
+	t.Setenv("GITHUB_RUN_ID", "99")
+	target, err := executor.resolveGitHub(config.GitHubSettings{})
+	require.NoError(t, err)
+	assert.Equal(t, "head", target.sha, "pull request events report on the head, not the merge commit")
+	assert.Equal(t, "https://github.com/acme/shop/actions/runs/99", target.reportURL)
+	assert.Equal(t, "Panoptic", target.name)
+	assert.Equal(t, "check", target.mode)
+
+	t.Setenv("PANOPTIC_GH", "other")
+	target, err = executor.resolveGitHub(config.GitHubSettings{Token: "${PANOPTIC_GH}", SHA: "fixed", ReportURL: "https://r.example.com/{run_id}"})
+	require.NoError(t, err)
+	assert.Equal(t, "other", target.token)
+	assert.Equal(t, "fixed", target.sha)
+	assert.Equal(t, "https://r.example.com/run-1", target.reportURL)
+}
+
+// TestExecutor_ReportToGitHub tests the check run and commit status requests
+func TestExecutor_ReportToGitHub(t *testing.T) {
+	clearGitHubEnv(t)
+	var bodies []map[string]interface{}
+	var paths []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		paths = append(paths, r.Method+" "+r.URL.Path)
+		if r.Method == http.MethodGet {
+			fmt.Fprint(w, `{"head":{"sha":"prhead"}}`)
+			return
+		}
+		var body map[string]interface{}
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		bodies = append(bodies, body)
+		fmt.Fprint(w, `{"id":1,"html_url":"https://github.com/acme/shop/runs/1"}`)
+	}))
+	defer server.Close()
+
+	dir := t.TempDir()
+	configPath := filepath.Join(dir, "panoptic.yaml")
+	require.NoError(t, os.WriteFile(configPath, []byte(sarifConfig), 0600))
+	executor := NewExecutor(&config.Config{}, dir, logger.NewLogger(false))
+	require.NoError(t, executor.SetConfigFile(configPath))
+	executor.results = []TestResult{
+		{AppName: "Shop", Metrics: map[string]interface{}{"failed_action": "checkout"}, Error: "timeout", Severity: config.SeverityError},
+		{AppName: "Admin", Success: true, Findings: []Finding{{Action: "home", Type: "ResourceError", Message: "404"}}},
+	}
+
+	settings := config.GitHubSettings{Repository: "acme/shop", PullRequest: 5, Token: "tok", APIURL: server.URL}
+	url, err := executor.ReportToGitHub(context.Background(), settings)
+	require.NoError(t, err)
+	assert.Equal(t, "https://github.com/acme/shop/runs/1", url)
+	assert.Equal(t, []string{"GET /repos/acme/shop/pulls/5", "POST /repos/acme/shop/check-runs"}, paths)
+
+	run := bodies[0]
+	assert.Equal(t, "prhead", run["head_sha"])
+	assert.Equal(t, "failure", run["conclusion"])
+	output := run["output"].(map[string]interface{})
+	assert.Equal(t, "1 of 2 app(s) failed", output["title"])
+	assert.Contains(t, output["summary"], "- **Shop**: timeout")
+	annotations := output["annotations"].([]interface{})
+	require.Len(t, annotations, 2)
+	first := annotations[0].(map[string]interface{})
+	assert.Equal(t, "failure", first["annotation_level"])
+	assert.EqualValues(t, 8, first["start_line"])
+	assert.Equal(t, "notice", annotations[1].(map[string]interface{})["annotation_level"])
+
+	paths, bodies = nil, nil
+	settings.Mode = "status"
+	settings.SHA = "abc"
+	settings.PullRequest = 0
+	executor.results = executor.results[1:]
+	url, err = executor.ReportToGitHub(context.Background(), settings)
+	require.NoError(t, err)
+	assert.Empty(t, url)
+	assert.Equal(t, []string{"POST /repos/acme/shop/statuses/abc"}, paths)
+	assert.Equal(t, "success", bodies[0]["state"])
+	assert.Equal(t, "1 of 1 app(s) passed", bodies[0]["description"])
+}
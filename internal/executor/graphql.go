@@ -0,0 +1,241 @@
+package executor
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+
+	"panoptic/internal/config"
+	"panoptic/internal/graphql"
+	"panoptic/internal/jsonpath"
+)
+
+// maxGraphQLResponse bounds the responses graphql actions read
+const maxGraphQLResponse = 10 << 20
+
+// maxSchemaProblems bounds the schema problems a failing graphql action
+// lists in its error; the metric has them all
+const maxSchemaProblems = 5
+
+// GraphQLRequestSent is recorded in the graphql_requests metric for every
+// graphql action, leaving out headers and variables, as they often carry
+// credentials
+type GraphQLRequestSent struct {
+	Action         string   `json:"action"`
+	URL            string   `json:"url"`
+	Operation      string   `json:"operation,omitempty"`
+	Status         int      `json:"status,omitempty"`
+	DurationMs     float64  `json:"duration_ms"`
+	Errors         []string `json:"errors,omitempty"`
+	SchemaProblems []string `json:"schema_problems,omitempty"`
+}
+
+// graphqlResponse is a GraphQL response, with the whole of it decoded for
+// assertions
+type graphqlResponse struct {
+	Data   interface{}     `json:"data"`
+	Errors []graphql.Error `json:"errors"`
+	doc    interface{}
+}
+
+// sendGraphQL sends a graphql action's query, checks the response's errors
+// and, when asked, its data against the endpoint's schema, then its
+// assertions and extracts its variables
+func (e *Executor) sendGraphQL(ctx context.Context, action config.Action, result *TestResult) error {
+	spec, err := action.GraphQLRequest()
+	if err != nil {
+		return err
+	}
+	sent := GraphQLRequestSent{Action: action.Name, URL: spec.URL, Operation: spec.OperationName}
+	defer func() {
+		if result.Metrics == nil {
+			result.Metrics = make(map[string]interface{})
+		}
+		requests, _ := result.Metrics["graphql_requests"].([]GraphQLRequestSent)
+		result.Metrics["graphql_requests"] = append(requests, sent)
+	}()
+
+	start := time.Now()
+	resp, err := e.postGraphQL(ctx, spec, spec.Query, spec.Variables, spec.OperationName, &sent.Status)
+	sent.DurationMs = float64(time.Since(start).Microseconds()) / 1000
+	if err != nil {
+		return err
+	}
+	for _, gqlErr := range resp.Errors {
+		sent.Errors = append(sent.Errors, gqlErr.String())
+	}
+	switch {
+	case len(resp.Errors) > 0 && !spec.ExpectErrors:
+		return fmt.Errorf("%s returned errors: %s", spec.URL, graphql.JoinErrors(resp.Errors))
+	case len(resp.Errors) == 0 && spec.ExpectErrors:
+		return fmt.Errorf("%s returned no errors, expected some", spec.URL)
+	case sent.Status >= 400 && len(resp.Errors) == 0:
+		return fmt.Errorf("%s returned %d", spec.URL, sent.Status)
+	}
+
+	if spec.Validates() {
+		if spec.Document == nil {
+			// the query was built from variables
+			if spec.Document, err = graphql.ParseQuery(spec.Query); err != nil {
+				return fmt.Errorf("invalid query: %w", err)
+			}
+		}
+		schema, err := e.graphqlSchema(ctx, spec)
+		if err != nil {
+			return err
+		}
+		if sent.SchemaProblems, err = schema.Validate(spec.Document, spec.OperationName, resp.Data); err != nil {
+			return err
+		}
+		if n := len(sent.SchemaProblems); n > 0 {
+			shown := sent.SchemaProblems
+			more := ""
+			if n > maxSchemaProblems {
+				shown, more = shown[:maxSchemaProblems], fmt.Sprintf(" (and %d more)", n-maxSchemaProblems)
+			}
+			return fmt.Errorf("the response doesn't match the schema: %s%s", strings.Join(shown, "; "), more)
+		}
+	}
+
+	for _, check := range spec.Assert {
+		if err := checkGraphQLAssertion(check, resp.doc); err != nil {
+			return err
+		}
+	}
+	for name, path := range spec.Extracts {
+		values := path.Select(resp.doc)
+		if len(values) == 0 || values[0] == nil {
+			return fmt.Errorf("extract %s: the response has nothing at %s", name, path)
+		}
+		if e.vars == nil {
+			e.vars = make(map[string]string)
+		}
+		e.vars[name] = jsonpath.Text(values[0])
+	}
+	e.logger.Infof("GraphQL %s returned %d", spec.URL, sent.Status)
+	return nil
+}
+
+// postGraphQL POSTs a query to the request's endpoint with its headers and
+// decodes the response. status receives the HTTP status.
+func (e *Executor) postGraphQL(ctx context.Context, spec *config.GraphQLRequest, query string, variables map[string]interface{}, operation string, status *int) (*graphqlResponse, error) {
+	ctx, cancel := context.WithTimeout(ctx, spec.Timeout)
+	defer cancel()
+	payload := map[string]interface{}{"query": query}
+	if len(variables) > 0 {
+		payload["variables"] = variables
+	}
+	if operation != "" {
+		payload["operationName"] = operation
+	}
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return nil, fmt.Errorf("invalid graphql variables: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, spec.URL, bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("invalid graphql request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/graphql-response+json, application/json")
+	for name, value := range spec.Headers {
+		req.Header.Set(name, os.ExpandEnv(value))
+	}
+
+	resp, err := httpRequestClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("POST %s: %w", spec.URL, err)
+	}
+	defer resp.Body.Close()
+	*status = resp.StatusCode
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxGraphQLResponse))
+	if err != nil {
+		return nil, fmt.Errorf("POST %s: %w", spec.URL, err)
+	}
+	out := &graphqlResponse{}
+	if json.Unmarshal(data, out) != nil || json.Unmarshal(data, &out.doc) != nil {
+		return nil, fmt.Errorf("%s returned %d, not a GraphQL response", spec.URL, resp.StatusCode)
+	}
+	if _, ok := out.doc.(map[string]interface{}); !ok {
+		return nil, fmt.Errorf("%s returned %d, not a GraphQL response", spec.URL, resp.StatusCode)
+	}
+	return out, nil
+}
+
+// graphqlSchema is the schema a graphql action validates against: its
+// schema file, or the endpoint's introspection. Each is read once a run.
+func (e *Executor) graphqlSchema(ctx context.Context, spec *config.GraphQLRequest) (*graphql.Schema, error) {
+	key := spec.Schema
+	if key == "" {
+		key = spec.URL
+	}
+	if schema, ok := e.graphqlSchemas[key]; ok {
+		return schema, nil
+	}
+
+	var schema *graphql.Schema
+	var err error
+	if spec.Schema != "" {
+		schema, err = graphql.LoadSchema(spec.Schema)
+	} else {
+		var status int
+		var resp *graphqlResponse
+		resp, err = e.postGraphQL(ctx, spec, graphql.IntrospectionQuery, nil, "", &status)
+		if err == nil {
+			var data []byte
+			data, err = json.Marshal(resp.doc)
+			if err == nil {
+				schema, err = graphql.ParseIntrospection(data)
+			}
+		}
+		if err != nil {
+			err = fmt.Errorf("failed to introspect %s: %w", spec.URL, err)
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+	if e.graphqlSchemas == nil {
+		e.graphqlSchemas = make(map[string]*graphql.Schema)
+	}
+	e.graphqlSchemas[key] = schema
+	return schema, nil
+}
+
+// checkGraphQLAssertion checks the first value at an assertion's path
+func checkGraphQLAssertion(check config.GraphQLAssertion, doc interface{}) error {
+	values := check.JSONPath.Select(doc)
+	if !check.ExpectsValue() {
+		if len(values) > 0 && values[0] != nil {
+			return fmt.Errorf("%s is %s, expected nothing", check.Path, jsonText(values[0]))
+		}
+		return nil
+	}
+	if len(values) == 0 {
+		return fmt.Errorf("the response has nothing at %s", check.Path)
+	}
+	value := values[0]
+	if check.Equals != nil && !jsonpath.Equal(value, check.Equals) {
+		return fmt.Errorf("%s is %s, expected %s", check.Path, jsonText(value), jsonText(check.Equals))
+	}
+	if check.Matches != "" && !regexp.MustCompile(check.Matches).MatchString(jsonpath.Text(value)) { // compiled by GraphQLRequest
+		return fmt.Errorf("%s is %s, which doesn't match %s", check.Path, jsonText(value), check.Matches)
+	}
+	return nil
+}
+
+// jsonText writes a value as JSON, quoting strings
+func jsonText(v interface{}) string {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	return string(data)
+}
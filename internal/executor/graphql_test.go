@@ -0,0 +1,131 @@
+package executor
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"panoptic/internal/config"
+	"panoptic/internal/logger"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// ordersSchema is the introspection result of an orders API
+const ordersSchema = `{"data": {"__schema": {
+	"queryType": {"name": "Query"}, "mutationType": {"name": "Mutation"},
+	"types": [
+		{"kind": "OBJECT", "name": "Query", "fields": [{"name": "order", "type": {"kind": "OBJECT", "name": "Order"}}]},
+		{"kind": "OBJECT", "name": "Mutation", "fields": [{"name": "placeOrder", "type": {"kind": "NON_NULL", "ofType": {"kind": "OBJECT", "name": "Order"}}}]},
+		{"kind": "OBJECT", "name": "Order", "fields": [
+			{"name": "id", "type": {"kind": "NON_NULL", "ofType": {"kind": "SCALAR", "name": "ID"}}},
+			{"name": "total", "type": {"kind": "NON_NULL", "ofType": {"kind": "SCALAR", "name": "Float"}}}
+		]},
+		{"kind": "SCALAR", "name": "ID"}, {"kind": "SCALAR", "name": "Float"}
+	]
+}}}`
+
+// ordersServer answers the introspection query with ordersSchema, and
+// orders with data the query names: "broken" ones return a string total
+func ordersServer(t *testing.T, introspections *int) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Query     string                 `json:"query"`
+			Variables map[string]interface{} `json:"variables"`
+		}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+		w.Header().Set("Content-Type", "application/json")
+		switch {
+		case strings.Contains(req.Query, "__schema"):
+			*introspections++
+			w.Write([]byte(ordersSchema))
+		case r.Header.Get("Authorization") != "Bearer s3cret":
+			w.WriteHeader(http.StatusUnauthorized)
+			w.Write([]byte(`{"errors": [{"message": "Not authenticated"}]}`))
+		case strings.Contains(req.Query, "broken"):
+			w.Write([]byte(`{"data": {"order": {"id": "7", "total": "12.50"}}}`))
+		case strings.Contains(req.Query, "placeOrder"):
+			if strings.Contains(req.Query, "$sku") {
+				assert.Equal(t, map[string]interface{}{"sku": "mug"}, req.Variables)
+			}
+			w.Write([]byte(`{"data": {"placeOrder": {"id": "42", "total": 12.5}}}`))
+		default:
+			w.Write([]byte(`{"data": {"order": null}, "errors": [{"message": "Order not found", "path": ["order"]}]}`))
+		}
+	}))
+}
+
+func TestExecutor_SendGraphQL(t *testing.T) {
+	introspections := 0
+	server := ordersServer(t, &introspections)
+	defer server.Close()
+	t.Setenv("ORDERS_TOKEN", "s3cret")
+
+	executor := NewExecutor(&config.Config{}, t.TempDir(), logger.NewLogger(false))
+	result := &TestResult{}
+	send := func(name string, params map[string]interface{}) error {
+		params["headers"] = map[string]interface{}{"Authorization": "Bearer ${ORDERS_TOKEN}"}
+		return executor.sendGraphQL(context.Background(), config.Action{Name: name, Type: "graphql", URL: server.URL, Parameters: params}, result)
+	}
+
+	require.NoError(t, send("place", map[string]interface{}{
+		"query":           `mutation Place($sku: ID!) { placeOrder(sku: $sku) { id total } }`,
+		"variables":       map[string]interface{}{"sku": "mug"},
+		"validate_schema": true,
+		"assert": []interface{}{
+			map[string]interface{}{"path": "$.data.placeOrder.total", "equals": 12.5},
+			map[string]interface{}{"path": "$.data.placeOrder.id", "matches": `^\d+$`},
+			map[string]interface{}{"path": "$.errors", "exists": false},
+		},
+		"extract": map[string]interface{}{"order_id": "$.data.placeOrder.id"},
+	}))
+	assert.Equal(t, "42", executor.vars["order_id"])
+
+	err := send("broken", map[string]interface{}{"query": `query broken { order(id: 7) { id total } }`, "validate_schema": true})
+	assert.EqualError(t, err, "the response doesn't match the schema: order.total: expected Float, got string")
+	assert.Equal(t, 1, introspections, "the schema is introspected once")
+
+	err = send("missing", map[string]interface{}{"query": `{ order(id: 1) { id } }`})
+	assert.EqualError(t, err, server.URL+" returned errors: order: Order not found")
+	require.NoError(t, send("missing", map[string]interface{}{"query": `{ order(id: 1) { id } }`, "expect_errors": true}))
+
+	err = send("total", map[string]interface{}{
+		"query":  `mutation { placeOrder(sku: "mug") { id total } }`,
+		"assert": []interface{}{map[string]interface{}{"path": "$.data.placeOrder.id", "equals": "43"}},
+	})
+	assert.EqualError(t, err, `$.data.placeOrder.id is "42", expected "43"`)
+
+	requests := result.Metrics["graphql_requests"].([]GraphQLRequestSent)
+	require.Len(t, requests, 5)
+	assert.Equal(t, 200, requests[0].Status)
+	assert.Equal(t, []string{"order.total: expected Float, got string"}, requests[1].SchemaProblems)
+	assert.Equal(t, []string{"order: Order not found"}, requests[2].Errors)
+}
+
+func TestExecutor_SendGraphQL_SchemaFile(t *testing.T) {
+	introspections := 0
+	server := ordersServer(t, &introspections)
+	defer server.Close()
+	schema := filepath.Join(t.TempDir(), "orders.json")
+	require.NoError(t, os.WriteFile(schema, []byte(ordersSchema), 0644))
+
+	executor := NewExecutor(&config.Config{}, t.TempDir(), logger.NewLogger(false))
+	result := &TestResult{}
+	err := executor.sendGraphQL(context.Background(), config.Action{Name: "unauthorized", Type: "graphql", URL: server.URL, Parameters: map[string]interface{}{
+		"query": `{ order(id: 1) { id owner } }`, "schema": schema, "expect_errors": true,
+	}}, result)
+	assert.EqualError(t, err, "the response doesn't match the schema: order.owner: Order has no field owner")
+	assert.Zero(t, introspections)
+
+	err = executor.sendGraphQL(context.Background(), config.Action{Name: "down", Type: "graphql", URL: "http://127.0.0.1:1", Parameters: map[string]interface{}{
+		"query": `{ order(id: 1) { id } }`,
+	}}, result)
+	assert.ErrorContains(t, err, "POST http://127.0.0.1:1")
+}
@@ -0,0 +1,39 @@
+package executor
+
+import (
+	"time"
+
+	"panoptic/internal/history"
+)
+
+// AppendHistory adds this run's outcomes and screenshot hashes to the history
+// file used for pass-rate analytics and screen comparisons across runs
+func (e *Executor) AppendHistory(path string) error {
+	return history.Append(path, e.historyRecord())
+}
+
+// historyRecord is the run as the history keeps it
+func (e *Executor) historyRecord() history.Record {
+	record := history.Record{RunID: e.runID, ProjectID: e.ProjectID(), Time: time.Now(), Entries: make([]history.Entry, 0, len(e.results))}
+	for _, r := range e.results {
+		var screens []history.Screen
+		for _, h := range r.ScreenHashes {
+			screens = append(screens, history.Screen{Name: h.Screen, Screenshot: h.Screenshot, PHash: h.PHash})
+		}
+		record.Entries = append(record.Entries, history.Entry{
+			App:         r.AppName,
+			AppType:     r.AppType,
+			Browser:     r.Browser,
+			Tags:        r.Tags,
+			Success:     r.Success,
+			Quarantined: r.Quarantined,
+			Duration:    r.Duration,
+			Error:       r.Error,
+			Screens:     screens,
+			Routes:      r.Routes,
+			Elements:    r.Elements,
+			Metrics:     r.CustomMetrics,
+		})
+	}
+	return record
+}
@@ -0,0 +1,39 @@
+package executor
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"panoptic/internal/config"
+	"panoptic/internal/history"
+	"panoptic/internal/logger"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestExecutor_AppendHistory tests that results are recorded with their tags
+// and screen hashes
+func TestExecutor_AppendHistory(t *testing.T) {
+	executor := NewExecutor(&config.Config{}, t.TempDir(), logger.NewLogger(false))
+	executor.SetRunID("run-1")
+	executor.results = []TestResult{
+		{AppName: "Shop [firefox]", AppType: "web", Browser: "firefox", Tags: []string{"smoke"}, Success: true, Duration: time.Second},
+		{AppName: "Admin", AppType: "web", Success: false, Error: "login failed", Routes: []string{"/admin"},
+			ScreenHashes: []ScreenHash{{Screenshot: "out/screenshots/Admin_login_1.png", Screen: "login", PHash: 0xbeef}}},
+	}
+
+	path := filepath.Join(t.TempDir(), history.FileName)
+	require.NoError(t, executor.AppendHistory(path))
+	require.NoError(t, executor.AppendHistory(path))
+
+	records, err := history.Load(path)
+	require.NoError(t, err)
+	require.Len(t, records, 2)
+	assert.Equal(t, "run-1", records[0].RunID)
+	assert.Equal(t, history.Entry{App: "Shop [firefox]", AppType: "web", Browser: "firefox", Tags: []string{"smoke"}, Success: true, Duration: time.Second}, records[0].Entries[0])
+	assert.Equal(t, "login failed", records[0].Entries[1].Error)
+	assert.Equal(t, []history.Screen{{Name: "login", Screenshot: "out/screenshots/Admin_login_1.png", PHash: 0xbeef}}, records[0].Entries[1].Screens)
+	assert.Equal(t, []string{"/admin"}, records[0].Entries[1].Routes)
+}
@@ -0,0 +1,80 @@
+package executor
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"panoptic/internal/config"
+)
+
+// HTTPRequestSent is recorded in the http_requests metric for every
+// http_request action; request headers are left out, as they often carry
+// credentials
+type HTTPRequestSent struct {
+	Action     string  `json:"action"`
+	Method     string  `json:"method"`
+	URL        string  `json:"url"`
+	Status     int     `json:"status,omitempty"`
+	DurationMs float64 `json:"duration_ms"`
+}
+
+// httpRequestClient sends http_request actions, leaving redirects to
+// expect_status
+var httpRequestClient = &http.Client{
+	CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse },
+}
+
+// sendHTTPRequest sends an http_request action's request and fails on an
+// unexpected status
+func (e *Executor) sendHTTPRequest(ctx context.Context, action config.Action, result *TestResult) error {
+	spec, err := action.HTTPRequest()
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(ctx, spec.Timeout)
+	defer cancel()
+	var body io.Reader
+	if spec.Body != "" {
+		body = strings.NewReader(spec.Body)
+	}
+	req, err := http.NewRequestWithContext(ctx, spec.Method, spec.URL, body)
+	if err != nil {
+		return fmt.Errorf("invalid http_request: %w", err)
+	}
+	for name, value := range spec.Headers {
+		req.Header.Set(name, os.ExpandEnv(value))
+	}
+
+	start := time.Now()
+	resp, err := httpRequestClient.Do(req)
+	sent := HTTPRequestSent{Action: action.Name, Method: spec.Method, URL: spec.URL}
+	if err == nil {
+		io.Copy(io.Discard, io.LimitReader(resp.Body, 1<<20))
+		resp.Body.Close()
+		sent.Status = resp.StatusCode
+	}
+	sent.DurationMs = float64(time.Since(start).Microseconds()) / 1000
+	if result.Metrics == nil {
+		result.Metrics = make(map[string]interface{})
+	}
+	requests, _ := result.Metrics["http_requests"].([]HTTPRequestSent)
+	result.Metrics["http_requests"] = append(requests, sent)
+	if err != nil {
+		return fmt.Errorf("%s %s: %w", spec.Method, spec.URL, err)
+	}
+
+	if spec.ExpectStatus != 0 {
+		if sent.Status != spec.ExpectStatus {
+			return fmt.Errorf("%s %s returned %d, want %d", spec.Method, spec.URL, sent.Status, spec.ExpectStatus)
+		}
+	} else if sent.Status >= 400 {
+		return fmt.Errorf("%s %s returned %d", spec.Method, spec.URL, sent.Status)
+	}
+	e.logger.Infof("%s %s returned %d", spec.Method, spec.URL, sent.Status)
+	return nil
+}
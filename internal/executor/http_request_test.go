@@ -0,0 +1,73 @@
+package executor
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"panoptic/internal/config"
+	"panoptic/internal/logger"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExecutor_SendHTTPRequest(t *testing.T) {
+	var got struct{ method, body, contentType, auth string }
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/cart":
+			data, _ := io.ReadAll(r.Body)
+			got.method, got.body = r.Method, string(data)
+			got.contentType, got.auth = r.Header.Get("Content-Type"), r.Header.Get("Authorization")
+			http.Redirect(w, r, "/cart/view", http.StatusSeeOther)
+		case "/health":
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+	t.Setenv("SHOP_TOKEN", "s3cret")
+
+	executor := NewExecutor(&config.Config{}, t.TempDir(), logger.NewLogger(false))
+	result := &TestResult{}
+	post := config.Action{Name: "add to cart", Type: "http_request", Parameters: map[string]interface{}{
+		"url":           server.URL + "/cart",
+		"headers":       map[string]interface{}{"Content-Type": "application/x-www-form-urlencoded", "Authorization": "Bearer ${SHOP_TOKEN}"},
+		"body":          "sku=42&qty=1",
+		"expect_status": 303,
+	}}
+	require.NoError(t, executor.sendHTTPRequest(context.Background(), post, result), "redirects aren't followed")
+	assert.Equal(t, "POST", got.method)
+	assert.Equal(t, "sku=42&qty=1", got.body)
+	assert.Equal(t, "application/x-www-form-urlencoded", got.contentType)
+	assert.Equal(t, "Bearer s3cret", got.auth)
+
+	health := config.Action{Name: "health", Type: "http_request", URL: server.URL + "/health"}
+	require.NoError(t, executor.sendHTTPRequest(context.Background(), health, result))
+
+	missing := config.Action{Name: "missing", Type: "http_request", URL: server.URL + "/missing"}
+	assert.EqualError(t, executor.sendHTTPRequest(context.Background(), missing, result), "GET "+server.URL+"/missing returned 404")
+
+	post.Parameters["expect_status"] = 201
+	assert.ErrorContains(t, executor.sendHTTPRequest(context.Background(), post, result), "returned 303, want 201")
+
+	sent := result.Metrics["http_requests"].([]HTTPRequestSent)
+	require.Len(t, sent, 4)
+	assert.Equal(t, HTTPRequestSent{Action: "health", Method: "GET", URL: server.URL + "/health", Status: 204, DurationMs: sent[1].DurationMs}, sent[1])
+}
+
+func TestExecutor_SendHTTPRequest_Unreachable(t *testing.T) {
+	server := httptest.NewServer(http.NotFoundHandler())
+	server.Close()
+	executor := NewExecutor(&config.Config{}, t.TempDir(), logger.NewLogger(false))
+	result := &TestResult{}
+	err := executor.sendHTTPRequest(context.Background(), config.Action{Name: "down", Type: "http_request", URL: server.URL}, result)
+	assert.ErrorContains(t, err, "GET "+server.URL+": ")
+	sent := result.Metrics["http_requests"].([]HTTPRequestSent)
+	require.Len(t, sent, 1)
+	assert.Zero(t, sent[0].Status)
+}
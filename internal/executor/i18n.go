@@ -0,0 +1,187 @@
+package executor
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"panoptic/internal/config"
+	"panoptic/internal/platforms"
+	"panoptic/internal/vision"
+)
+
+// textReader is a platform listing the text its page shows
+type textReader interface {
+	VisibleText(exclude []string) ([]platforms.TextElement, error)
+}
+
+// LocaleAudit is recorded in the i18n metric for every action whose page
+// text a localization audit checked
+type LocaleAudit struct {
+	Action       string                `json:"action"`
+	Locale       string                `json:"locale"`
+	Texts        int                   `json:"texts"`
+	Keys         []string              `json:"keys,omitempty"`         // raw translation keys shown
+	Untranslated []string              `json:"untranslated,omitempty"` // texts also shown in the source locale
+	Overflows    []vision.TextOverflow `json:"overflows,omitempty"`
+}
+
+// localizationError fails an action on the findings of its audit, so the
+// failure is categorized as localization rather than by the action's type
+type localizationError struct {
+	message string
+}
+
+func (e *localizationError) Error() string {
+	return e.message
+}
+
+// auditLocale checks the page text shown after an action of an app with
+// i18n settings: raw translation keys and text cut off by its box are
+// reported in every locale, and texts the source locale showed for the same
+// action are reported as untranslated in the others. The source locale runs
+// first, so its texts are known by then.
+func (e *Executor) auditLocale(ctx context.Context, platform platforms.Platform, action config.Action, app config.AppConfig, result *TestResult) error {
+	settings := app.I18n
+	if settings == nil || !settings.Audits(action.Type) {
+		return nil
+	}
+	reader, ok := platform.(textReader)
+	if !ok {
+		return nil
+	}
+	texts, err := reader.VisibleText(settings.Exclude)
+	if err != nil {
+		return fmt.Errorf("localization audit: %w", err)
+	}
+	keyPatterns, err := settings.KeyRegexps()
+	if err != nil {
+		return fmt.Errorf("localization audit: %w", err)
+	}
+
+	group := localeGroup(app, action)
+	source := app.Locale == settings.Source()
+	if source {
+		if e.localeTexts == nil {
+			e.localeTexts = make(map[string]map[string]bool)
+		}
+		e.localeTexts[group] = make(map[string]bool)
+	}
+	report := LocaleAudit{Action: action.Name, Locale: app.Locale, Texts: len(texts)}
+	var boxes []vision.TextBox
+	shown := make(map[string]string) // text by selector, for overflow messages
+	seen := make(map[string]bool)
+	for _, t := range texts {
+		if settings.ChecksOverflow() && !t.Clip.Empty() {
+			boxes = append(boxes, vision.TextBox{Name: t.Selector, Content: t.Content, Clip: t.Clip})
+			shown[t.Selector] = t.Text
+		}
+		if seen[t.Text] || settings.Ignores(t.Text) {
+			continue
+		}
+		seen[t.Text] = true
+		switch {
+		case matchesAny(keyPatterns, t.Text):
+			report.Keys = append(report.Keys, t.Text)
+		case source:
+			e.localeTexts[group][t.Text] = true
+		case translatable(t.Text, settings.MinLengthOrDefault()) && e.localeTexts[group][t.Text]:
+			report.Untranslated = append(report.Untranslated, t.Text)
+		}
+	}
+	if len(boxes) > 0 {
+		report.Overflows = vision.FindTextOverflows(boxes, settings.ToleranceOrDefault())
+	}
+
+	severity := config.SeverityWarning
+	if settings.Fail {
+		severity = config.SeverityError
+	}
+	for _, key := range report.Keys {
+		result.Findings = append(result.Findings, Finding{
+			Action: action.Name, Type: "untranslated_key", Category: config.CategoryLocalization, Severity: severity,
+			Message:     fmt.Sprintf("raw translation key %q shown in locale %s", key, app.Locale),
+			Suggestions: []string{fmt.Sprintf("Add a %s translation for %s", app.Locale, key)},
+		})
+	}
+	for _, text := range report.Untranslated {
+		result.Findings = append(result.Findings, Finding{
+			Action: action.Name, Type: "untranslated_string", Category: config.CategoryLocalization, Severity: severity,
+			Message:     fmt.Sprintf("%q is shown in locale %s as in the source locale %s", text, app.Locale, settings.Source()),
+			Suggestions: []string{"Translate it, or list it under i18n.ignore if it is the same in every locale"},
+		})
+	}
+	var annotations []vision.Annotation
+	for _, o := range report.Overflows {
+		annotations = append(annotations, vision.Annotation{Bounds: o.Content, Label: fmt.Sprintf("%s cut by %dpx", o.Name, o.Cut), Color: severityColor(o.Severity)})
+		s := o.Severity
+		if settings.Fail {
+			s = config.SeverityError
+		}
+		result.Findings = append(result.Findings, Finding{
+			Action: action.Name, Type: "text_overflow", Category: config.CategoryLocalization, Severity: s,
+			Message:     fmt.Sprintf("%q in %s is cut off by %dpx in locale %s (%.1f%% hidden)", shown[o.Name], o.Name, o.Cut, app.Locale, o.Share*100),
+			Suggestions: []string{fmt.Sprintf("Let %s grow with its text, or shorten the %s translation", o.Name, app.Locale)},
+		})
+	}
+	if len(annotations) > 0 {
+		screenshot := filepath.Join(e.outputDir, "screenshots", fmt.Sprintf("%s_%s_i18n_%d.png", app.Name, action.Name, time.Now().Unix()))
+		if err := e.platformCall(ctx, app, "Screenshot", func() error { return platform.Screenshot(screenshot) }); err != nil {
+			return err
+		}
+		result.Screenshots = append(result.Screenshots, screenshot)
+		e.annotateScreenshot(result, screenshot, annotations)
+	}
+
+	if result.Metrics == nil {
+		result.Metrics = make(map[string]interface{})
+	}
+	audits, _ := result.Metrics["i18n"].([]LocaleAudit)
+	result.Metrics["i18n"] = append(audits, report)
+	issues := len(report.Keys) + len(report.Untranslated) + len(report.Overflows)
+	e.logger.Infof("Localization audit %s (%s): %d text(s), %d raw key(s), %d untranslated, %d overflow(s)",
+		action.Name, app.Locale, len(texts), len(report.Keys), len(report.Untranslated), len(report.Overflows))
+
+	if settings.Fail && issues > 0 {
+		return &localizationError{message: fmt.Sprintf("%d localization issue(s) in locale %s: %d raw key(s), %d untranslated string(s), %d overflow(s)",
+			issues, app.Locale, len(report.Keys), len(report.Untranslated), len(report.Overflows))}
+	}
+	return nil
+}
+
+// localeGroup names the texts of an action across the locales of an app:
+// instances differing only in their locale share it
+func localeGroup(app config.AppConfig, action config.Action) string {
+	parts := []string{app.MatrixApp, app.BrowserLabel(), action.Name}
+	for name, value := range app.MatrixValues {
+		if name != "locale" {
+			parts = append(parts, name+"="+value)
+		}
+	}
+	sort.Strings(parts[3:])
+	return strings.Join(parts, "\x00")
+}
+
+// translatable tells whether text is long enough and has letters, so showing
+// it unchanged in another locale means it wasn't translated
+func translatable(text string, minLength int) bool {
+	if utf8.RuneCountInString(text) < minLength {
+		return false
+	}
+	return strings.IndexFunc(text, unicode.IsLetter) >= 0
+}
+
+func matchesAny(patterns []*regexp.Regexp, text string) bool {
+	for _, re := range patterns {
+		if re.MatchString(text) {
+			return true
+		}
+	}
+	return false
+}
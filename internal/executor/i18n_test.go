@@ -0,0 +1,87 @@
+package executor
+
+import (
+	"errors"
+	"image"
+	"os"
+	"testing"
+	"time"
+
+	"panoptic/internal/config"
+	"panoptic/internal/logger"
+	"panoptic/internal/platforms"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// textPlatform is a web page showing texts
+type textPlatform struct {
+	*screenPlatform
+	texts    []platforms.TextElement
+	excluded []string
+}
+
+func (p *textPlatform) VisibleText(exclude []string) ([]platforms.TextElement, error) {
+	p.excluded = exclude
+	return p.texts, nil
+}
+
+func shownText(text string) platforms.TextElement {
+	return platforms.TextElement{Text: text, Selector: "p", Bounds: image.Rect(0, 0, 100, 20), Content: image.Rect(0, 0, 100, 20)}
+}
+
+// TestExecutor_AuditLocale tests raw keys, strings left in the source
+// language and clipped text across the locales of an app
+func TestExecutor_AuditLocale(t *testing.T) {
+	executor := NewExecutor(&config.Config{}, t.TempDir(), logger.NewLogger(false))
+	recording := ""
+	settings := &config.I18nSettings{Locales: []string{"en", "de"}, Ignore: []string{"Panoptic Shop"}, Exclude: []string{".legal"}}
+	app := func(locale string) config.AppConfig {
+		return config.AppConfig{Name: "Shop (locale=" + locale + ")", Type: "web", Locale: locale, I18n: settings,
+			MatrixApp: "Shop", MatrixValues: map[string]string{"locale": locale}}
+	}
+	home := config.Action{Name: "home", Type: "navigate", URL: "https://shop.test"}
+	platform := &textPlatform{screenPlatform: &screenPlatform{MockPlatform: &MockPlatform{metrics: map[string]interface{}{}}, fixture: writeBannerScreen(t, 0)}}
+
+	platform.texts = []platforms.TextElement{shownText("Panoptic Shop"), shownText("Add to cart"), shownText("OK"), shownText("Free shipping on all orders")}
+	source := &TestResult{StartTime: time.Now(), Metrics: map[string]interface{}{}}
+	require.NoError(t, executor.executeAction(platform, home, app("en"), source, &recording))
+	assert.Empty(t, source.Findings)
+	assert.Equal(t, []string{".legal"}, platform.excluded)
+
+	button := shownText("In den Warenkorb legen")
+	button.Selector, button.Content, button.Clip = "#buy", image.Rect(10, 100, 190, 120), image.Rect(10, 100, 110, 120)
+	platform.texts = []platforms.TextElement{shownText("Panoptic Shop"), button, shownText("OK"), shownText("Free shipping on all orders"), shownText("cart.checkout.title")}
+	result := &TestResult{StartTime: time.Now(), Metrics: map[string]interface{}{}}
+	require.NoError(t, executor.executeAction(platform, home, app("de"), result, &recording))
+
+	require.Len(t, result.Findings, 3)
+	assert.Equal(t, Finding{Action: "home", Type: "untranslated_key", Category: "localization", Severity: "warning",
+		Message: `raw translation key "cart.checkout.title" shown in locale de`, Suggestions: []string{"Add a de translation for cart.checkout.title"}}, result.Findings[0])
+	assert.Equal(t, `"Free shipping on all orders" is shown in locale de as in the source locale en`, result.Findings[1].Message)
+	assert.Equal(t, "text_overflow", result.Findings[2].Type)
+	assert.Equal(t, "high", result.Findings[2].Severity)
+	assert.Equal(t, `"In den Warenkorb legen" in #buy is cut off by 80px in locale de (44.4% hidden)`, result.Findings[2].Message)
+
+	audits := result.Metrics["i18n"].([]LocaleAudit)
+	require.Len(t, audits, 1)
+	assert.Equal(t, 5, audits[0].Texts)
+	assert.Equal(t, []string{"Free shipping on all orders"}, audits[0].Untranslated)
+	require.Len(t, result.Screenshots, 1)
+	_, err := os.Stat(annotatedPath(result.Screenshots[0]))
+	assert.NoError(t, err, "overflows are annotated")
+
+	settings.Fail = true
+	result = &TestResult{StartTime: time.Now(), Metrics: map[string]interface{}{}}
+	err = executor.executeAction(platform, home, app("de"), result, &recording)
+	assert.EqualError(t, err, "3 localization issue(s) in locale de: 1 raw key(s), 1 untranslated string(s), 1 overflow(s)")
+	var audit *localizationError
+	assert.True(t, errors.As(err, &audit))
+	assert.Equal(t, "error", result.Findings[2].Severity)
+
+	fill := config.Action{Name: "email", Type: "fill", Selector: "#email", Value: "ada@example.com"}
+	result = &TestResult{StartTime: time.Now(), Metrics: map[string]interface{}{}}
+	require.NoError(t, executor.executeAction(platform, fill, app("de"), result, &recording))
+	assert.Nil(t, result.Metrics["i18n"], "only page actions are audited")
+}
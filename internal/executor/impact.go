@@ -0,0 +1,103 @@
+package executor
+
+import (
+	"strings"
+
+	"panoptic/internal/config"
+	"panoptic/internal/history"
+)
+
+// Impact narrows a run to the apps a change can affect, to shorten pull
+// request runs. Changes are routes, such as /checkout or /admin/*, or the
+// names of components, which are matched against tags and app names.
+type Impact struct {
+	Changes  []string
+	Coverage map[string][]string // routes each app opened in past runs, from the history
+}
+
+// SetImpact runs only the apps that impact selects
+func (e *Executor) SetImpact(impact *Impact) {
+	e.impact = impact
+}
+
+// affects returns why a change can affect app, or "" when none can. Apps
+// without routes or tags to match are always affected: nothing tells what
+// they cover.
+func (i *Impact) affects(cfg *config.Config, app config.AppConfig) string {
+	routes := appRoutes(cfg, app)
+	routes = append(routes, i.Coverage[app.Name]...)
+	var tags []string
+	for _, action := range cfg.GetActionsForApp(app) {
+		tags = config.MergeTags(tags, action.Tags)
+	}
+	tags = config.MergeTags(app.Tags, tags)
+	if len(routes) == 0 && len(tags) == 0 {
+		return "no coverage recorded"
+	}
+
+	for _, change := range i.Changes {
+		if route, ok := changedRoute(change); ok {
+			for _, r := range routes {
+				if routeMatches(route, r) {
+					return "route " + r + " changed"
+				}
+			}
+			continue
+		}
+		component := strings.ToLower(strings.TrimSpace(change))
+		if strings.ToLower(app.Name) == component {
+			return "app changed"
+		}
+		for _, tag := range tags {
+			if tag == component {
+				return "component " + tag + " changed"
+			}
+		}
+	}
+	return ""
+}
+
+// appRoutes are the routes an app's configuration opens: its URL and those
+// of its navigate actions. URLs built from placeholders are left out.
+func appRoutes(cfg *config.Config, app config.AppConfig) []string {
+	routes := addRoute(nil, app.URL)
+	for _, action := range cfg.GetActionsForApp(app) {
+		if action.Type == "navigate" && !strings.Contains(action.GetNavigateURL(), "{{") {
+			routes = addRoute(routes, action.GetNavigateURL())
+		}
+	}
+	return routes
+}
+
+// changedRoute returns the route of a change that names one: a path, or a
+// URL whose path is taken
+func changedRoute(change string) (string, bool) {
+	change = strings.TrimSpace(change)
+	if strings.Contains(change, "://") || strings.HasPrefix(change, "/") {
+		return history.Route(change), true
+	}
+	return "", false
+}
+
+// routeMatches reports whether a changed route covers route: the route
+// itself and the routes under it, or everything under a prefix ending in /*
+func routeMatches(changed, route string) bool {
+	if prefix, ok := strings.CutSuffix(changed, "/*"); ok {
+		return prefix == "" || route == prefix || strings.HasPrefix(route, prefix+"/")
+	}
+	return route == changed || strings.HasPrefix(route, changed+"/")
+}
+
+// addRoute adds the route of a URL to routes, once
+func addRoute(routes []string, rawURL string) []string {
+	route := history.Route(rawURL)
+	if route == "" {
+		return routes
+	}
+	for _, r := range routes {
+		if r == route {
+			return routes
+		}
+	}
+	return append(routes, route)
+}
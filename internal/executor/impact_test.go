@@ -0,0 +1,69 @@
+package executor
+
+import (
+	"testing"
+
+	"panoptic/internal/config"
+	"panoptic/internal/logger"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func impactConfig() *config.Config {
+	return &config.Config{Apps: []config.AppConfig{
+		{Name: "Shop", Type: "web", URL: "https://shop.example.com/", Actions: []config.Action{
+			{Name: "cart", Type: "navigate", URL: "https://shop.example.com/cart/"},
+			{Name: "item", Type: "navigate", URL: "https://shop.example.com/items/{{fake.uuid}}"},
+		}},
+		{Name: "Admin", Type: "web", URL: "https://shop.example.com/admin", Tags: []string{"Billing"}},
+		{Name: "Desktop", Type: "desktop", Path: "/usr/bin/app"},
+	}}
+}
+
+func TestImpact_Affects(t *testing.T) {
+	cfg := impactConfig()
+	shop, admin, desktop := cfg.Apps[0], cfg.Apps[1], cfg.Apps[2]
+	tests := []struct {
+		name   string
+		impact Impact
+		app    config.AppConfig
+		reason string
+	}{
+		{"app route", Impact{Changes: []string{"/cart"}}, shop, "route /cart changed"},
+		{"route under a change", Impact{Changes: []string{"/admin"}}, admin, "route /admin changed"},
+		{"route outside a change", Impact{Changes: []string{"/cart"}}, admin, ""},
+		{"root only", Impact{Changes: []string{"/"}}, admin, ""},
+		{"wildcard", Impact{Changes: []string{"/*"}}, admin, "route /admin changed"},
+		{"url", Impact{Changes: []string{"https://shop.example.com/cart"}}, shop, "route /cart changed"},
+		{"placeholder route unknown", Impact{Changes: []string{"/items"}}, shop, ""},
+		{"covered in history", Impact{Changes: []string{"/items/*"}, Coverage: map[string][]string{"Shop": {"/items/42"}}}, shop, "route /items/42 changed"},
+		{"component tag", Impact{Changes: []string{"billing"}}, admin, "component billing changed"},
+		{"app name", Impact{Changes: []string{"shop"}}, shop, "app changed"},
+		{"no coverage", Impact{Changes: []string{"/cart"}}, desktop, "no coverage recorded"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			impact := tt.impact
+			assert.Equal(t, tt.reason, impact.affects(cfg, tt.app))
+		})
+	}
+}
+
+func TestExecutor_ExpandApps_Impact(t *testing.T) {
+	executor := NewExecutor(impactConfig(), t.TempDir(), logger.NewLogger(false))
+	executor.SetImpact(&Impact{Changes: []string{"/cart"}})
+	apps := executor.expandApps()
+	require.Len(t, apps, 2)
+	assert.Equal(t, "Shop", apps[0].Name)
+	assert.Equal(t, "Desktop", apps[1].Name)
+}
+
+func TestAddRoute(t *testing.T) {
+	routes := addRoute(nil, "https://shop.example.com/cart/?id=1")
+	routes = addRoute(routes, "http://localhost:3000/cart")
+	routes = addRoute(routes, "https://shop.example.com")
+	routes = addRoute(routes, "")
+	routes = addRoute(routes, "file:///tmp/page.html")
+	assert.Equal(t, []string{"/cart", "/"}, routes)
+}
@@ -0,0 +1,125 @@
+package executor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"panoptic/internal/config"
+	"panoptic/internal/github"
+	"panoptic/internal/issues"
+)
+
+// newTracker builds the issue tracker for settings.issues
+func newTracker(s config.IssueSettings) (issues.Tracker, error) {
+	token := os.ExpandEnv(s.Token)
+	switch s.Type {
+	case "jira":
+		if token == "" {
+			return nil, fmt.Errorf("settings.issues.token is empty")
+		}
+		return issues.NewJira(s.URL, s.Project, s.IssueType, os.ExpandEnv(s.Username), token, s.Labels), nil
+	case "gitlab":
+		if token == "" {
+			return nil, fmt.Errorf("settings.issues.token is empty")
+		}
+		return issues.NewGitLab(s.URL, s.Project, token, s.Labels), nil
+	default:
+		repo := firstNonEmpty(s.Project, os.Getenv("GITHUB_REPOSITORY"))
+		if repo == "" {
+			return nil, fmt.Errorf("settings.issues.project is not set and GITHUB_REPOSITORY is empty")
+		}
+		if token == "" {
+			token = os.Getenv("GITHUB_TOKEN")
+		}
+		if token == "" {
+			return nil, fmt.Errorf("settings.issues.token is empty and GITHUB_TOKEN is not set")
+		}
+		apiURL := firstNonEmpty(s.URL, os.Getenv("GITHUB_API_URL"), github.DefaultAPIURL)
+		return issues.NewGitHub(apiURL, repo, token, s.Labels), nil
+	}
+}
+
+// FileIssues opens or updates an issue per unique failure fingerprint under
+// settings.issues, attaching a failure bundle. Quarantined failures are
+// already tracked and are not filed. Issue URLs are recorded in the results'
+// "issue" metric.
+func (e *Executor) FileIssues(ctx context.Context) error {
+	settings := e.config.Settings.Issues
+	if settings == nil {
+		return nil
+	}
+
+	var order []string
+	groups := make(map[string][]*TestResult)
+	for i := range e.results {
+		r := &e.results[i]
+		if r.Success || r.Quarantined || r.Fingerprint == "" {
+			continue
+		}
+		if r.Severity == config.SeverityWarning && !settings.Warnings {
+			continue
+		}
+		if _, ok := groups[r.Fingerprint]; !ok {
+			order = append(order, r.Fingerprint)
+		}
+		groups[r.Fingerprint] = append(groups[r.Fingerprint], r)
+	}
+	if len(order) == 0 {
+		return nil
+	}
+
+	tracker, err := newTracker(*settings)
+	if err != nil {
+		return err
+	}
+
+	var errs []error
+	for _, fingerprint := range order {
+		results := groups[fingerprint]
+		first := results[0]
+		bundle, err := e.writeFailureBundle(fingerprint, results)
+		if err != nil {
+			e.logger.Warnf("Filing %s without a failure bundle: %v", first.AppName, err)
+			bundle = ""
+		}
+
+		failure := issues.Failure{
+			Fingerprint: fingerprint,
+			App:         strings.TrimSuffix(first.AppName, " ["+first.Browser+"]"),
+			Category:    first.FailureCategory,
+			Error:       first.Error,
+			RunID:       e.runID,
+			ReportURL:   strings.ReplaceAll(settings.ReportURL, "{run_id}", e.runID),
+			Bundle:      bundle,
+		}
+		failure.Action, _ = first.Metrics["failed_action"].(string)
+		for _, r := range results {
+			if r.Browser != "" {
+				failure.Browsers = append(failure.Browsers, r.Browser)
+			}
+		}
+
+		issue, created, err := issues.File(ctx, tracker, failure)
+		if issue != nil {
+			for _, r := range results {
+				if r.Metrics == nil {
+					r.Metrics = make(map[string]interface{})
+				}
+				r.Metrics["issue"] = issue.URL
+			}
+		}
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", first.AppName, err))
+			continue
+		}
+		if created {
+			e.logger.Infof("Opened issue %s for %s", issue.URL, first.AppName)
+		} else {
+			e.logger.Infof("Updated issue %s for %s", issue.URL, first.AppName)
+		}
+	}
+	return errors.Join(errs...)
+}
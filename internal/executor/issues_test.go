@@ -0,0 +1,118 @@
+package executor
+
+import (
+	"archive/zip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"panoptic/internal/config"
+	"panoptic/internal/logger"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeIssues is a GitHub Issues API holding issues in memory
+type fakeIssues struct {
+	issues   []map[string]interface{}
+	comments map[string][]string
+}
+
+func (f *fakeIssues) handler(w http.ResponseWriter, r *http.Request) {
+	var body map[string]interface{}
+	_ = json.NewDecoder(r.Body).Decode(&body)
+	switch {
+	case r.Method == http.MethodGet:
+		_ = json.NewEncoder(w).Encode(f.issues)
+	case strings.HasSuffix(r.URL.Path, "/comments"):
+		f.comments[r.URL.Path] = append(f.comments[r.URL.Path], body["body"].(string))
+		w.WriteHeader(http.StatusCreated)
+	default:
+		n := len(f.issues) + 1
+		issue := map[string]interface{}{"number": n, "html_url": fmt.Sprintf("https://github.com/acme/shop/issues/%d", n), "body": body["body"]}
+		f.issues = append(f.issues, issue)
+		_ = json.NewEncoder(w).Encode(issue)
+	}
+}
+
+// TestExecutor_FileIssues tests opening, updating and skipping issues
+func TestExecutor_FileIssues(t *testing.T) {
+	clearGitHubEnv(t)
+	tracker := &fakeIssues{comments: make(map[string][]string)}
+	server := httptest.NewServer(http.HandlerFunc(tracker.handler))
+	defer server.Close()
+
+	dir := t.TempDir()
+	shot := filepath.Join(dir, "pay.png")
+	require.NoError(t, os.WriteFile(shot, []byte("png"), 0600))
+	cfg := &config.Config{Settings: config.Settings{Issues: &config.IssueSettings{
+		Type: "github", URL: server.URL, Project: "acme/shop", Token: "tok", ReportURL: "https://r.example.com/{run_id}",
+	}}}
+
+	run := func(runID string) *Executor {
+		executor := NewExecutor(cfg, dir, logger.NewLogger(false))
+		executor.SetRunID(runID)
+		executor.results = []TestResult{
+			{AppName: "Shop [chromium]", Browser: "chromium", Error: "timeout after 5000ms", Screenshots: []string{shot}, Metrics: map[string]interface{}{"failed_action": "pay"}},
+			{AppName: "Shop [firefox]", Browser: "firefox", Error: "timeout after 5100ms", Metrics: map[string]interface{}{"failed_action": "pay"}},
+			{AppName: "Flaky", Error: "boom", Quarantined: true},
+			{AppName: "A11y", Error: "contrast", Severity: config.SeverityWarning},
+			{AppName: "Ok", Success: true},
+		}
+		for i := range executor.results {
+			executor.results[i].Fingerprint = failureFingerprint(&executor.results[i])
+		}
+		require.NoError(t, executor.FileIssues(context.Background()))
+		return executor
+	}
+
+	executor := run("run-1")
+	require.Len(t, tracker.issues, 1, "browser variants share one issue; quarantined and warning failures are skipped")
+	body := tracker.issues[0]["body"].(string)
+	assert.Contains(t, body, "chromium, firefox")
+	assert.Contains(t, body, "https://r.example.com/run-1")
+	assert.Contains(t, body, "<!-- panoptic-fingerprint: "+executor.results[0].Fingerprint+" -->")
+	assert.Equal(t, "https://github.com/acme/shop/issues/1", executor.results[1].Metrics["issue"])
+
+	bundle, err := zip.OpenReader(filepath.Join(dir, "bundles", executor.results[0].Fingerprint+".zip"))
+	require.NoError(t, err)
+	var names []string
+	for _, f := range bundle.File {
+		names = append(names, f.Name)
+	}
+	bundle.Close()
+	assert.Equal(t, []string{"results.json", "artifacts/0-screenshot/pay.png"}, names)
+
+	run("run-2")
+	assert.Len(t, tracker.issues, 1, "a repeat updates the open issue")
+	comments := tracker.comments["/repos/acme/shop/issues/1/comments"]
+	require.Len(t, comments, 1)
+	assert.Contains(t, comments[0], "Failed again in run `run-2`")
+
+	cfg.Settings.Issues.Warnings = true
+	run("run-3")
+	assert.Len(t, tracker.issues, 2, "warnings are filed when enabled")
+}
+
+// TestNewTracker tests tracker selection and required credentials
+func TestNewTracker(t *testing.T) {
+	clearGitHubEnv(t)
+	_, err := newTracker(config.IssueSettings{Type: "github"})
+	assert.ErrorContains(t, err, "GITHUB_REPOSITORY")
+	_, err = newTracker(config.IssueSettings{Type: "github", Project: "acme/shop"})
+	assert.ErrorContains(t, err, "GITHUB_TOKEN")
+	_, err = newTracker(config.IssueSettings{Type: "jira", URL: "https://acme.atlassian.net", Project: "SHOP"})
+	assert.ErrorContains(t, err, "token is empty")
+
+	t.Setenv("PANOPTIC_TEST_GITLAB", "glpat")
+	tracker, err := newTracker(config.IssueSettings{Type: "gitlab", Project: "acme/shop", Token: "${PANOPTIC_TEST_GITLAB}"})
+	require.NoError(t, err)
+	assert.NotNil(t, tracker)
+}
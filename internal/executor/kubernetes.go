@@ -0,0 +1,127 @@
+package executor
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"panoptic/internal/cloud"
+	"panoptic/internal/config"
+)
+
+// decodeSettingsMap converts a free-form settings map into a typed struct via its yaml tags
+func decodeSettingsMap(in map[string]interface{}, out interface{}) error {
+	data, err := yaml.Marshal(in)
+	if err != nil {
+		return err
+	}
+	return yaml.Unmarshal(data, out)
+}
+
+// getKubernetesRunner returns the Kubernetes runner when Settings.Cloud.kubernetes
+// is enabled. A nil runner with a nil error means apps run locally.
+func (e *Executor) getKubernetesRunner() (*cloud.KubernetesRunner, error) {
+	e.kubernetesOnce.Do(func() {
+		if e.config.Settings.Cloud == nil {
+			return
+		}
+		raw, ok := e.config.Settings.Cloud["kubernetes"].(map[string]interface{})
+		if !ok {
+			return
+		}
+
+		var k8sConfig cloud.KubernetesConfig
+		if err := decodeSettingsMap(raw, &k8sConfig); err != nil {
+			e.kubernetesErr = fmt.Errorf("invalid kubernetes settings: %w", err)
+			return
+		}
+		if !k8sConfig.Enabled {
+			return
+		}
+
+		e.kubernetesRunner, e.kubernetesErr = cloud.NewKubernetesRunner(*e.componentLogger(), k8sConfig)
+	})
+	return e.kubernetesRunner, e.kubernetesErr
+}
+
+// singleAppConfig builds the configuration shipped to a pod or container: one
+// app, its resolved actions already narrowed by --tags, no output override (the agent's --output flag
+// decides), and settings without the kubernetes block so the agent runs the
+// app locally instead of scheduling another job
+func (e *Executor) singleAppConfig(app config.AppConfig) *config.Config {
+	actions, _ := e.config.SelectActions(app, e.tagFilter)
+	app.Actions = nil
+
+	settings := e.config.Settings
+	if settings.Cloud != nil {
+		cloudSettings := make(map[string]interface{}, len(settings.Cloud))
+		for k, v := range settings.Cloud {
+			if k != "kubernetes" {
+				cloudSettings[k] = v
+			}
+		}
+		settings.Cloud = cloudSettings
+	}
+
+	return &config.Config{
+		Name:     e.config.Name,
+		Apps:     []config.AppConfig{app},
+		Actions:  actions,
+		Settings: settings,
+	}
+}
+
+// executeAppOnKubernetes runs a single app inside an ephemeral Kubernetes Job
+// and maps the job outcome onto a TestResult
+func (e *Executor) executeAppOnKubernetes(runner *cloud.KubernetesRunner, app config.AppConfig) TestResult {
+	result := TestResult{
+		AppName:     app.Name,
+		AppType:     app.Type,
+		StartTime:   time.Now(),
+		Screenshots: make([]string, 0),
+		Videos:      make([]string, 0),
+		Metrics:     make(map[string]interface{}),
+		Success:     false,
+	}
+
+	finish := func() TestResult {
+		result.EndTime = time.Now()
+		result.Duration = result.EndTime.Sub(result.StartTime)
+		return result
+	}
+
+	data, err := yaml.Marshal(e.singleAppConfig(app))
+	if err != nil {
+		result.Error = fmt.Sprintf("Failed to marshal app config for kubernetes: %v", err)
+		return finish()
+	}
+
+	jobResult, err := runner.RunJob(e.spanContext(), app.Name, data)
+	if jobResult != nil {
+		result.Metrics["kubernetes_job"] = jobResult.JobName
+		result.Metrics["kubernetes_pod"] = jobResult.PodName
+		result.Metrics["kubernetes_namespace"] = jobResult.Namespace
+
+		if jobResult.Logs != "" {
+			logDir := filepath.Join(e.outputDir, "kubernetes")
+			logPath := filepath.Join(logDir, jobResult.JobName+".log")
+			if mkErr := os.MkdirAll(logDir, 0755); mkErr != nil {
+				e.logger.Warnf("Failed to create kubernetes log directory: %v", mkErr)
+			} else if wErr := os.WriteFile(logPath, []byte(jobResult.Logs), 0600); wErr != nil {
+				e.logger.Warnf("Failed to save kubernetes job logs: %v", wErr)
+			} else {
+				result.Metrics["kubernetes_log"] = logPath
+			}
+		}
+	}
+	if err != nil {
+		result.Error = fmt.Sprintf("Kubernetes job failed: %v", err)
+		return finish()
+	}
+
+	result.Success = jobResult.Succeeded
+	return finish()
+}
@@ -0,0 +1,115 @@
+package executor
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"panoptic/internal/config"
+	"panoptic/internal/logger"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func kubernetesTestConfig(apiServer string, enabled bool) *config.Config {
+	return &config.Config{
+		Name: "k8s",
+		Apps: []config.AppConfig{{Name: "web", Type: "web", URL: "https://example.com"}},
+		Actions: []config.Action{
+			{Name: "nav", Type: "navigate", Value: "https://example.com"},
+		},
+		Settings: config.Settings{
+			Cloud: map[string]interface{}{
+				"provider": "local",
+				"kubernetes": map[string]interface{}{
+					"enabled":       enabled,
+					"api_server":    apiServer,
+					"token":         "t",
+					"namespace":     "ci",
+					"image":         "panoptic/agent:1",
+					"poll_interval": 1,
+					"resources": map[string]interface{}{
+						"limits": map[string]interface{}{"memory": "2Gi"},
+					},
+				},
+			},
+		},
+	}
+}
+
+// TestExecutor_GetKubernetesRunner tests runner creation from Settings.Cloud.kubernetes
+func TestExecutor_GetKubernetesRunner(t *testing.T) {
+	log := logger.NewLogger(false)
+
+	disabled := NewExecutor(kubernetesTestConfig("https://k8s", false), t.TempDir(), log)
+	runner, err := disabled.getKubernetesRunner()
+	assert.NoError(t, err)
+	assert.Nil(t, runner)
+
+	enabled := NewExecutor(kubernetesTestConfig("https://k8s", true), t.TempDir(), log)
+	runner, err = enabled.getKubernetesRunner()
+	require.NoError(t, err)
+	require.NotNil(t, runner)
+	assert.Equal(t, "ci", runner.Config.Namespace)
+	assert.Equal(t, "2Gi", runner.Config.Resources.Limits["memory"])
+
+	cfg := kubernetesTestConfig("https://k8s", true)
+	delete(cfg.Settings.Cloud["kubernetes"].(map[string]interface{}), "image")
+	invalid := NewExecutor(cfg, t.TempDir(), log)
+	_, err = invalid.getKubernetesRunner()
+	assert.Error(t, err)
+}
+
+// TestExecutor_SingleAppConfig tests that the pod config carries no kubernetes block
+func TestExecutor_SingleAppConfig(t *testing.T) {
+	cfg := kubernetesTestConfig("https://k8s", true)
+	executor := NewExecutor(cfg, t.TempDir(), logger.NewLogger(false))
+
+	podConfig := executor.singleAppConfig(cfg.Apps[0])
+
+	require.Len(t, podConfig.Apps, 1)
+	require.Len(t, podConfig.Actions, 1)
+	assert.Equal(t, "local", podConfig.Settings.Cloud["provider"])
+	assert.NotContains(t, podConfig.Settings.Cloud, "kubernetes")
+	assert.Contains(t, cfg.Settings.Cloud, "kubernetes", "original config must not be modified")
+}
+
+// TestExecutor_Run_Kubernetes tests that apps are dispatched to kubernetes jobs when enabled
+func TestExecutor_Run_Kubernetes(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodPost:
+			w.WriteHeader(http.StatusCreated)
+			fmt.Fprint(w, `{}`)
+		case strings.HasSuffix(r.URL.Path, "/pods"):
+			fmt.Fprint(w, `{"items":[{"metadata":{"name":"pod-1"},"status":{"phase":"Succeeded"}}]}`)
+		case strings.HasSuffix(r.URL.Path, "/log"):
+			fmt.Fprint(w, "run complete\n")
+		case r.Method == http.MethodGet:
+			fmt.Fprint(w, `{"status":{"succeeded":1}}`)
+		default:
+			fmt.Fprint(w, `{}`)
+		}
+	}))
+	defer server.Close()
+
+	outputDir := t.TempDir()
+	executor := NewExecutor(kubernetesTestConfig(server.URL, true), outputDir, logger.NewLogger(false))
+
+	require.NoError(t, executor.Run())
+	require.Len(t, executor.results, 1)
+
+	result := executor.results[0]
+	assert.True(t, result.Success, result.Error)
+	assert.Equal(t, "pod-1", result.Metrics["kubernetes_pod"])
+
+	logPath, ok := result.Metrics["kubernetes_log"].(string)
+	require.True(t, ok)
+	data, err := os.ReadFile(logPath)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), "run complete")
+}
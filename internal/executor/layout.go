@@ -0,0 +1,129 @@
+package executor
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"time"
+
+	"panoptic/internal/config"
+	"panoptic/internal/platforms"
+	"panoptic/internal/vision"
+)
+
+// layoutSnapshot is a layout captured by a vision_layout_check action: the
+// selector boxes, or nil when elements are detected in the screenshot
+type layoutSnapshot struct {
+	screenshot string
+	boxes      []vision.Box
+}
+
+// LayoutReport is recorded in the layout metric for every
+// vision_layout_check action
+type LayoutReport struct {
+	Action   string               `json:"action"`
+	Captured string               `json:"captured,omitempty"`
+	Compared string               `json:"compared,omitempty"`
+	Shifts   []vision.LayoutShift `json:"shifts,omitempty"`
+	Overlaps []vision.Overlap     `json:"overlaps,omitempty"`
+}
+
+// checkLayout screenshots the app, captures its layout and reports elements
+// that moved since an earlier capture or that overlap. Offenders are
+// reported as layout findings, and fail the action when it sets fail.
+func (e *Executor) checkLayout(ctx context.Context, platform platforms.Platform, action config.Action, app config.AppConfig, result *TestResult) error {
+	check, err := action.LayoutCheck()
+	if err != nil {
+		return err
+	}
+	var baseline layoutSnapshot
+	if check.Compare != "" {
+		var ok bool
+		if baseline, ok = e.layouts[check.Compare]; !ok {
+			return fmt.Errorf("no layout captured as %q; an earlier vision_layout_check must capture it", check.Compare)
+		}
+		if (baseline.boxes != nil) != (len(check.Selectors) > 0) {
+			return fmt.Errorf("layout %q was captured with different selectors", check.Compare)
+		}
+	}
+
+	snapshot := layoutSnapshot{screenshot: filepath.Join(e.outputDir, "screenshots", fmt.Sprintf("%s_%s_%d.png", app.Name, action.Name, time.Now().Unix()))}
+	if len(check.Selectors) > 0 {
+		if _, ok := platform.(scriptEvaluator); !ok {
+			return fmt.Errorf("selectors are only supported on web apps; leave them out to detect elements in the screenshot")
+		}
+		snapshot.boxes = []vision.Box{}
+		for _, selector := range check.Selectors {
+			box, err := elementBox(platform, selector)
+			if err != nil {
+				e.logger.Debugf("Layout check %s: %v", action.Name, err)
+				continue
+			}
+			snapshot.boxes = append(snapshot.boxes, vision.Box{Name: selector, Bounds: box})
+		}
+	}
+	if err := e.platformCall(ctx, app, "Screenshot", func() error { return platform.Screenshot(snapshot.screenshot) }); err != nil {
+		return err
+	}
+	result.Screenshots = append(result.Screenshots, snapshot.screenshot)
+
+	report := LayoutReport{Action: action.Name, Captured: check.Capture, Compared: check.Compare}
+	ignored := make(map[string]bool, len(check.Ignore))
+	for _, name := range check.Ignore {
+		ignored[name] = true
+	}
+	if check.Compare != "" {
+		var shifts []vision.LayoutShift
+		if snapshot.boxes != nil {
+			shifts = vision.CompareLayouts(baseline.boxes, snapshot.boxes, *check.Tolerance)
+		} else if shifts, err = vision.NewElementDetector(*e.componentLogger()).ShiftsBetweenFiles(baseline.screenshot, snapshot.screenshot, *check.Tolerance); err != nil {
+			return fmt.Errorf("failed to compare layouts: %w", err)
+		}
+		for _, s := range shifts {
+			if !ignored[s.Name] {
+				report.Shifts = append(report.Shifts, s)
+			}
+		}
+	}
+	if check.Overlaps {
+		for _, o := range vision.FindOverlaps(snapshot.boxes, *check.Tolerance) {
+			if !ignored[o.A] && !ignored[o.B] {
+				report.Overlaps = append(report.Overlaps, o)
+			}
+		}
+	}
+	if check.Capture != "" {
+		if e.layouts == nil {
+			e.layouts = make(map[string]layoutSnapshot)
+		}
+		e.layouts[check.Capture] = snapshot
+	}
+
+	var annotations []vision.Annotation
+	for _, s := range report.Shifts {
+		annotations = append(annotations, vision.Annotation{Bounds: s.After, Label: fmt.Sprintf("%s %+d,%+d", s.Name, s.DX, s.DY), Color: severityColor(s.Severity)})
+		result.Findings = append(result.Findings, Finding{
+			Action: action.Name, Type: "layout_shift", Category: config.CategoryLayout, Severity: s.Severity,
+			Message: fmt.Sprintf("%s shifted from %v to %v (%+d,%+d px) since %s", s.Name, s.Before, s.After, s.DX, s.DY, check.Compare),
+		})
+	}
+	for _, o := range report.Overlaps {
+		annotations = append(annotations, vision.Annotation{Bounds: o.Area, Label: o.A + " / " + o.B, Color: severityColor(o.Severity)})
+		result.Findings = append(result.Findings, Finding{
+			Action: action.Name, Type: "overlap", Category: config.CategoryLayout, Severity: o.Severity,
+			Message: fmt.Sprintf("%s and %s overlap at %v, covering %.1f%% of the smaller", o.A, o.B, o.Area, o.Share*100),
+		})
+	}
+	e.annotateScreenshot(result, snapshot.screenshot, annotations)
+	if result.Metrics == nil {
+		result.Metrics = make(map[string]interface{})
+	}
+	reports, _ := result.Metrics["layout"].([]LayoutReport)
+	result.Metrics["layout"] = append(reports, report)
+	e.logger.Infof("Layout check %s: %d shift(s), %d overlap(s)", action.Name, len(report.Shifts), len(report.Overlaps))
+
+	if check.Fail && len(report.Shifts)+len(report.Overlaps) > 0 {
+		return fmt.Errorf("%d layout shift(s) and %d overlap(s) found", len(report.Shifts), len(report.Overlaps))
+	}
+	return nil
+}
@@ -0,0 +1,104 @@
+package executor
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"panoptic/internal/config"
+	"panoptic/internal/logger"
+	"panoptic/internal/vision"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writeBannerScreen saves a screenshot with a header and a button pushed
+// down by offset pixels, as by a late-loading banner
+func writeBannerScreen(t *testing.T, offset int) string {
+	img := image.NewRGBA(image.Rect(0, 0, 300, 240))
+	draw.Draw(img, img.Bounds(), &image.Uniform{color.White}, image.Point{}, draw.Src)
+	draw.Draw(img, image.Rect(0, 0, 300, 30), &image.Uniform{color.RGBA{30, 60, 120, 255}}, image.Point{}, draw.Src)
+	draw.Draw(img, image.Rect(20, 100+offset, 120, 140+offset), &image.Uniform{color.RGBA{200, 40, 40, 255}}, image.Point{}, draw.Src)
+	path := filepath.Join(t.TempDir(), "screen.png")
+	f, err := os.Create(path)
+	require.NoError(t, err)
+	defer f.Close()
+	require.NoError(t, png.Encode(f, img))
+	return path
+}
+
+// TestExecutor_CheckLayout_Vision tests layout shifts of detected elements
+func TestExecutor_CheckLayout_Vision(t *testing.T) {
+	executor := NewExecutor(&config.Config{}, t.TempDir(), logger.NewLogger(false))
+	result := &TestResult{StartTime: time.Now(), Metrics: map[string]interface{}{}}
+	recording := ""
+	platform := &screenPlatform{MockPlatform: &MockPlatform{metrics: map[string]interface{}{}}, fixture: writeBannerScreen(t, 0)}
+	app := config.AppConfig{Name: "Shop"}
+
+	capture := config.Action{Name: "before", Type: "vision_layout_check", Parameters: map[string]interface{}{"capture": "home"}}
+	require.NoError(t, executor.executeAction(platform, capture, app, result, &recording))
+	platform.fixture = writeBannerScreen(t, 60)
+	compare := config.Action{Name: "after", Type: "vision_layout_check", Parameters: map[string]interface{}{"compare": "home", "fail": true}}
+	err := executor.executeAction(platform, compare, app, result, &recording)
+	assert.EqualError(t, err, "1 layout shift(s) and 0 overlap(s) found")
+
+	require.Len(t, result.Findings, 1)
+	assert.Equal(t, Finding{Action: "after", Type: "layout_shift", Category: "layout", Severity: "medium",
+		Message: "element at 20,100 (100x40) shifted from (20,100)-(120,140) to (20,160)-(120,200) (+0,+60 px) since home"}, result.Findings[0])
+	reports := result.Metrics["layout"].([]LayoutReport)
+	require.Len(t, reports, 2)
+	assert.Equal(t, "home", reports[0].Captured)
+	assert.Len(t, reports[1].Shifts, 1)
+
+	compare.Parameters = map[string]interface{}{"compare": "missing"}
+	err = executor.executeAction(platform, compare, app, result, &recording)
+	assert.ErrorContains(t, err, `no layout captured as "missing"`)
+	compare.Parameters = map[string]interface{}{"compare": "home", "selectors": []interface{}{"#buy"}}
+	err = executor.executeAction(platform, compare, app, result, &recording)
+	assert.EqualError(t, err, `layout "home" was captured with different selectors`)
+}
+
+// TestExecutor_CheckLayout_Selectors tests selector boxes, overlaps and
+// ignored selectors
+func TestExecutor_CheckLayout_Selectors(t *testing.T) {
+	executor := NewExecutor(&config.Config{}, t.TempDir(), logger.NewLogger(false))
+	result := &TestResult{StartTime: time.Now(), Metrics: map[string]interface{}{}}
+	recording := ""
+	platform := &evaluatingPlatform{
+		screenPlatform: &screenPlatform{MockPlatform: &MockPlatform{metrics: map[string]interface{}{}}, fixture: writeBannerScreen(t, 0)},
+		boxes: map[string]map[string]interface{}{
+			"#buy":    {"x": 20.0, "y": 100.0, "width": 100.0, "height": 40.0},
+			"#banner": {"x": 0.0, "y": 30.0, "width": 300.0, "height": 20.0},
+		},
+	}
+	app := config.AppConfig{Name: "Shop"}
+	selectors := []interface{}{"#buy", "#banner", "#chat"}
+
+	capture := config.Action{Name: "before", Type: "vision_layout_check", Parameters: map[string]interface{}{"capture": "home", "selectors": selectors}}
+	require.NoError(t, executor.executeAction(platform, capture, app, result, &recording))
+	assert.Equal(t, []vision.Box{{Name: "#buy", Bounds: image.Rect(20, 100, 120, 140)}, {Name: "#banner", Bounds: image.Rect(0, 30, 300, 50)}},
+		executor.layouts["home"].boxes, "#chat isn't on the page")
+
+	platform.boxes["#buy"]["y"] = 40.0
+	platform.boxes["#banner"]["height"] = 30.0
+	compare := config.Action{Name: "after", Type: "vision_layout_check", Parameters: map[string]interface{}{
+		"compare": "home", "selectors": selectors, "overlaps": true, "ignore": []interface{}{"#banner"},
+	}}
+	require.NoError(t, executor.executeAction(platform, compare, app, result, &recording))
+	require.Len(t, result.Findings, 1, "the banner's growth and its overlap are ignored")
+	assert.Equal(t, "#buy shifted from (20,100)-(120,140) to (20,40)-(120,80) (+0,-60 px) since home", result.Findings[0].Message)
+
+	compare.Parameters["ignore"] = nil
+	require.NoError(t, executor.executeAction(platform, compare, app, result, &recording))
+	assert.Equal(t, Finding{Action: "after", Type: "overlap", Category: "layout", Severity: "high",
+		Message: "#buy and #banner overlap at (20,40)-(120,60), covering 50.0% of the smaller"}, result.Findings[len(result.Findings)-1])
+
+	err := executor.executeAction(platform.screenPlatform, capture, app, result, &recording)
+	assert.ErrorContains(t, err, "selectors are only supported on web apps")
+}
@@ -0,0 +1,381 @@
+package executor
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"panoptic/internal/config"
+	"panoptic/internal/logger"
+)
+
+// Files a load run writes to its output directory, apart from the
+// functional results
+const (
+	LoadResultsFile = "load.json"
+	LoadReportFile  = "load.html"
+)
+
+// maxLoadErrors bounds the distinct errors a load result lists per app
+const maxLoadErrors = 5
+
+// LoadOptions shapes a load run
+type LoadOptions struct {
+	Users      int           // virtual users replaying the actions at once
+	Iterations int           // replays per user; 0 replays until Duration
+	Duration   time.Duration // stop starting replays once this long has passed; 0 for no limit
+	RampUp     time.Duration // start the users evenly spread over this time
+}
+
+// Validate requires users and a way for the run to end
+func (o LoadOptions) Validate() error {
+	if o.Users < 1 {
+		return fmt.Errorf("a load run needs at least one user, got %d", o.Users)
+	}
+	if o.Iterations < 0 || o.Duration < 0 || o.RampUp < 0 {
+		return fmt.Errorf("iterations, duration and ramp-up must not be negative")
+	}
+	if o.Iterations == 0 && o.Duration == 0 {
+		return fmt.Errorf("a load run needs iterations or a duration to end")
+	}
+	return nil
+}
+
+// LoadReport is the layout of load.json
+type LoadReport struct {
+	RunID      string       `json:"run_id"`
+	StartedAt  time.Time    `json:"started_at"`
+	FinishedAt time.Time    `json:"finished_at"`
+	Users      int          `json:"users"`
+	Iterations int          `json:"iterations,omitempty"`
+	DurationMs int64        `json:"duration_ms,omitempty"` // the --duration asked for
+	Apps       []LoadResult `json:"apps"`
+}
+
+// LoadResult is how an app held up under load
+type LoadResult struct {
+	App              string          `json:"app"`
+	Users            int             `json:"users"`
+	Replays          int             `json:"replays"`
+	FailedReplays    int             `json:"failed_replays"`
+	ErrorRate        float64         `json:"error_rate"` // failed replays / replays
+	ReplaysPerSecond float64         `json:"replays_per_second"`
+	DurationMs       int64           `json:"duration_ms"`
+	Actions          []ActionLatency `json:"actions"`
+	Errors           []LoadError     `json:"errors,omitempty"` // the most frequent first
+}
+
+// ActionLatency is the latency distribution of an action across replays,
+// in milliseconds. Failed runs of the action count in it too.
+type ActionLatency struct {
+	Action    string  `json:"action"`
+	Type      string  `json:"type"`
+	Samples   int     `json:"samples"`
+	Errors    int     `json:"errors"`
+	ErrorRate float64 `json:"error_rate"`
+	MinMs     float64 `json:"min_ms"`
+	MeanMs    float64 `json:"mean_ms"`
+	P50Ms     float64 `json:"p50_ms"`
+	P90Ms     float64 `json:"p90_ms"`
+	P95Ms     float64 `json:"p95_ms"`
+	P99Ms     float64 `json:"p99_ms"`
+	MaxMs     float64 `json:"max_ms"`
+}
+
+// LoadError is an error replays failed with and how often
+type LoadError struct {
+	Error string `json:"error"`
+	Count int    `json:"count"`
+}
+
+// loadSamples collects what the users of an app measured
+type loadSamples struct {
+	mu      sync.Mutex
+	order   []string // action names in the order they first ran
+	actions map[string]*actionSamples
+	replays int
+	failed  int
+	errors  map[string]int
+}
+
+type actionSamples struct {
+	kind      string
+	durations []time.Duration
+	errors    int
+}
+
+func (s *loadSamples) action(action config.Action, took time.Duration, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	a, ok := s.actions[action.Name]
+	if !ok {
+		a = &actionSamples{kind: action.Type}
+		s.actions[action.Name] = a
+		s.order = append(s.order, action.Name)
+	}
+	a.durations = append(a.durations, took)
+	if err != nil {
+		a.errors++
+	}
+}
+
+func (s *loadSamples) replay(result *TestResult) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.replays++
+	if !result.Success {
+		s.failed++
+		s.errors[result.Error]++
+	}
+}
+
+// RunLoad replays the actions of every web and driver app with opts.Users
+// virtual users at once, each in a browser of its own, and measures the
+// latency of every action. Desktop and mobile apps drive one machine or
+// device, so they are left out. Users run headless without recording
+// video; their screenshots go to load/<app>/user-<n> in the output
+// directory. Results are returned for WriteLoadReport, not kept with the
+// functional results.
+func (e *Executor) RunLoad(ctx context.Context, opts LoadOptions) (*LoadReport, error) {
+	if err := opts.Validate(); err != nil {
+		return nil, err
+	}
+	if err := e.config.Validate(); err != nil {
+		return nil, fmt.Errorf("configuration validation failed: %w", err)
+	}
+	var apps []config.AppConfig
+	for _, app := range e.config.Apps {
+		if app.Type != "web" && app.Type != "driver" {
+			e.logger.Infof("Skipping app %s: %s apps can't have concurrent users", app.Name, app.Type)
+			continue
+		}
+		actions, _ := e.config.SelectActions(app, e.tagFilter)
+		app.Actions = nil
+		for _, action := range actions {
+			if action.Type == "record" {
+				continue
+			}
+			action.Record = false
+			app.Actions = append(app.Actions, action)
+		}
+		if len(app.Actions) == 0 {
+			e.logger.Infof("Skipping app %s: no actions to replay", app.Name)
+			continue
+		}
+		app.Browsers = nil
+		apps = append(apps, app)
+	}
+	if len(apps) == 0 {
+		return nil, errors.New("no web or driver app has actions to load test")
+	}
+	if err := e.checkCapabilities(apps); err != nil {
+		return nil, fmt.Errorf("configuration validation failed: %w", err)
+	}
+
+	report := &LoadReport{RunID: e.runID, StartedAt: time.Now(), Users: opts.Users, Iterations: opts.Iterations, DurationMs: opts.Duration.Milliseconds()}
+	for _, app := range apps {
+		if ctx.Err() != nil {
+			break
+		}
+		e.logger.Infof("Load testing %s with %d user(s)", app.Name, opts.Users)
+		report.Apps = append(report.Apps, e.loadApp(ctx, app, opts))
+	}
+	report.FinishedAt = time.Now()
+	return report, ctx.Err()
+}
+
+// loadApp runs the users of an app until each finished its replays
+func (e *Executor) loadApp(ctx context.Context, app config.AppConfig, opts LoadOptions) LoadResult {
+	samples := &loadSamples{actions: make(map[string]*actionSamples), errors: make(map[string]int)}
+	started := time.Now()
+	dirName := strings.Trim(unsafeDirChars.ReplaceAllString(app.Name, "_"), "_")
+	if dirName == "" {
+		dirName = "app"
+	}
+	dir := filepath.Join(e.outputDir, "load", dirName)
+
+	var users sync.WaitGroup
+	for u := 0; u < opts.Users; u++ {
+		delay := opts.RampUp * time.Duration(u) / time.Duration(opts.Users)
+		users.Add(1)
+		go func(u int) {
+			defer users.Done()
+			select {
+			case <-time.After(delay):
+			case <-ctx.Done():
+				return
+			}
+			user := NewExecutor(e.config, filepath.Join(dir, fmt.Sprintf("user-%d", u+1)), e.logger.WithFields(logger.Fields{"app": app.Name, "user": u + 1}))
+			user.SetRunID(e.runID)
+			// Users fill forms with test data of their own
+			user.SetFakeSeed(e.fakeSeed + int64(u))
+			user.tracer = e.tracer
+			user.onAction = samples.action
+			for i := 0; opts.Iterations == 0 || i < opts.Iterations; i++ {
+				if ctx.Err() != nil || opts.Duration > 0 && time.Since(started) >= opts.Duration {
+					return
+				}
+				result := user.executeApp(app)
+				samples.replay(&result)
+			}
+		}(u)
+	}
+	users.Wait()
+
+	elapsed := time.Since(started)
+	result := LoadResult{
+		App:           app.Name,
+		Users:         opts.Users,
+		Replays:       samples.replays,
+		FailedReplays: samples.failed,
+		DurationMs:    elapsed.Milliseconds(),
+	}
+	if samples.replays > 0 {
+		result.ErrorRate = float64(samples.failed) / float64(samples.replays)
+	}
+	if elapsed > 0 {
+		result.ReplaysPerSecond = float64(samples.replays) / elapsed.Seconds()
+	}
+	for _, name := range samples.order {
+		a := samples.actions[name]
+		result.Actions = append(result.Actions, latencyOf(name, a))
+	}
+	for message, count := range samples.errors {
+		result.Errors = append(result.Errors, LoadError{Error: message, Count: count})
+	}
+	sort.Slice(result.Errors, func(i, j int) bool {
+		if result.Errors[i].Count != result.Errors[j].Count {
+			return result.Errors[i].Count > result.Errors[j].Count
+		}
+		return result.Errors[i].Error < result.Errors[j].Error
+	})
+	if len(result.Errors) > maxLoadErrors {
+		result.Errors = result.Errors[:maxLoadErrors]
+	}
+	return result
+}
+
+// latencyOf summarizes the durations of an action
+func latencyOf(name string, a *actionSamples) ActionLatency {
+	sorted := append([]time.Duration(nil), a.durations...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	var total time.Duration
+	for _, d := range sorted {
+		total += d
+	}
+	return ActionLatency{
+		Action:    name,
+		Type:      a.kind,
+		Samples:   len(sorted),
+		Errors:    a.errors,
+		ErrorRate: float64(a.errors) / float64(len(sorted)),
+		MinMs:     millis(sorted[0]),
+		MeanMs:    millis(total / time.Duration(len(sorted))),
+		P50Ms:     millis(percentile(sorted, 50)),
+		P90Ms:     millis(percentile(sorted, 90)),
+		P95Ms:     millis(percentile(sorted, 95)),
+		P99Ms:     millis(percentile(sorted, 99)),
+		MaxMs:     millis(sorted[len(sorted)-1]),
+	}
+}
+
+// percentile is the nearest-rank percentile of sorted durations
+func percentile(sorted []time.Duration, p float64) time.Duration {
+	rank := int(math.Ceil(p / 100 * float64(len(sorted))))
+	return sorted[max(rank, 1)-1]
+}
+
+// millis converts a duration to milliseconds, to a hundredth
+func millis(d time.Duration) float64 {
+	return math.Round(float64(d)/float64(time.Millisecond)*100) / 100
+}
+
+// WriteLoadReport writes load.json and load.html to dir
+func WriteLoadReport(dir string, report *LoadReport) error {
+	data, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal load results: %w", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, LoadResultsFile), data, 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", LoadResultsFile, err)
+	}
+
+	var b strings.Builder
+	b.WriteString(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="UTF-8">
+<meta name="viewport" content="width=device-width, initial-scale=1.0">
+<title>Panoptic Load Report</title>
+<style>
+*{margin:0;padding:0;box-sizing:border-box}
+body{font-family:-apple-system,BlinkMacSystemFont,'Segoe UI',Roboto,sans-serif;background:#1a1a2e;color:#e0e0e0;padding:20px}
+.header{padding:30px 0;border-bottom:1px solid #16213e;margin-bottom:20px}
+.header h1{font-size:2em;color:#64b5f6}
+.header .subtitle{color:#888;margin-top:6px}
+.load-app{background:#16213e;border-radius:8px;padding:20px;margin-bottom:20px}
+.load-app h2{font-size:1.2em;margin-bottom:8px}
+.load-app .summary{color:#aaa;font-size:0.9em;margin-bottom:12px}
+.load-app .summary .fail{color:#f44336}
+table{width:100%;border-collapse:collapse;font-size:0.85em}
+th,td{padding:6px 10px;text-align:right;border-bottom:1px solid #0f3460}
+th:first-child,td:first-child{text-align:left}
+td.fail{color:#f44336}
+.errors{margin-top:12px;font-size:0.85em;color:#ef9a9a;font-family:monospace}
+.errors li{margin-left:20px;white-space:pre-wrap;word-break:break-all}
+</style>
+</head>
+<body>
+<div class="header">
+<h1>Panoptic Load Report</h1>
+`)
+	b.WriteString(fmt.Sprintf(`<div class="subtitle">%d user(s), run %s, %s to %s</div>
+</div>
+`, report.Users, html.EscapeString(report.RunID),
+		report.StartedAt.Format("2006-01-02 15:04:05"), report.FinishedAt.Format("15:04:05")))
+	for _, app := range report.Apps {
+		failClass := ""
+		if app.FailedReplays > 0 {
+			failClass = "fail"
+		}
+		b.WriteString(fmt.Sprintf(`<div class="load-app">
+<h2>%s</h2>
+<div class="summary">%d replay(s) by %d user(s) in %s, %.2f per second, <span class="%s">%d failed (%.1f%%)</span></div>
+<table>
+<tr><th>Action</th><th>Type</th><th>Samples</th><th>Errors</th><th>Min</th><th>Mean</th><th>p50</th><th>p90</th><th>p95</th><th>p99</th><th>Max</th></tr>
+`, html.EscapeString(app.App), app.Replays, app.Users, formatDuration(time.Duration(app.DurationMs)*time.Millisecond),
+			app.ReplaysPerSecond, failClass, app.FailedReplays, app.ErrorRate*100))
+		for _, a := range app.Actions {
+			errClass := ""
+			if a.Errors > 0 {
+				errClass = ` class="fail"`
+			}
+			b.WriteString(fmt.Sprintf("<tr><td>%s</td><td>%s</td><td>%d</td><td%s>%d</td><td>%.0f ms</td><td>%.0f ms</td><td>%.0f ms</td><td>%.0f ms</td><td>%.0f ms</td><td>%.0f ms</td><td>%.0f ms</td></tr>\n",
+				html.EscapeString(a.Action), html.EscapeString(a.Type), a.Samples, errClass, a.Errors,
+				a.MinMs, a.MeanMs, a.P50Ms, a.P90Ms, a.P95Ms, a.P99Ms, a.MaxMs))
+		}
+		b.WriteString("</table>\n")
+		if len(app.Errors) > 0 {
+			b.WriteString(`<ul class="errors">` + "\n")
+			for _, e := range app.Errors {
+				b.WriteString(fmt.Sprintf("<li>%d&times; %s</li>\n", e.Count, html.EscapeString(e.Error)))
+			}
+			b.WriteString("</ul>\n")
+		}
+		b.WriteString("</div>\n")
+	}
+	b.WriteString("</body>\n</html>\n")
+	if err := os.WriteFile(filepath.Join(dir, LoadReportFile), []byte(b.String()), 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", LoadReportFile, err)
+	}
+	return nil
+}
@@ -0,0 +1,112 @@
+package executor
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"panoptic/internal/config"
+	"panoptic/internal/logger"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestExecutor_RunLoad tests that every user replays the actions, that
+// latencies and errors are collected per action, and that apps without
+// concurrent users are left out
+func TestExecutor_RunLoad(t *testing.T) {
+	driver := scriptDriver(t, `"navigate","click"`)
+	cfg := &config.Config{Apps: []config.AppConfig{
+		{Name: "Kiosk", Type: "driver", Driver: driver, Actions: []config.Action{
+			{Name: "tap", Type: "click", Selector: "#start"},
+			{Name: "film", Type: "record", Duration: 1},
+			{Name: "settle", Type: "wait"},
+		}},
+		{Name: "Broken", Type: "driver", Driver: driver, Actions: []config.Action{
+			{Name: "tap", Type: "click", Selector: "#start"},
+			{Name: "go", Type: "navigate", URL: "app://home"},
+		}},
+		{Name: "Phone", Type: "mobile", Platform: "android", Actions: []config.Action{{Name: "tap", Type: "click", Selector: "#x"}}},
+	}}
+	outputDir := t.TempDir()
+	executor := NewExecutor(cfg, outputDir, logger.NewLogger(false))
+	report, err := executor.RunLoad(context.Background(), LoadOptions{Users: 3, Iterations: 2, RampUp: 30 * time.Millisecond})
+	require.NoError(t, err)
+	assert.Equal(t, executor.RunID(), report.RunID)
+	assert.Equal(t, 3, report.Users)
+	require.Len(t, report.Apps, 2)
+
+	kiosk := report.Apps[0]
+	assert.Equal(t, "Kiosk", kiosk.App)
+	assert.Equal(t, 6, kiosk.Replays)
+	assert.Zero(t, kiosk.FailedReplays)
+	require.Len(t, kiosk.Actions, 2, "record actions are left out")
+	assert.Equal(t, "tap", kiosk.Actions[0].Action)
+	assert.Equal(t, "click", kiosk.Actions[0].Type)
+	assert.Equal(t, 6, kiosk.Actions[0].Samples)
+	assert.Equal(t, "settle", kiosk.Actions[1].Action)
+	assert.GreaterOrEqual(t, kiosk.Actions[1].MinMs, 900.0, "wait of a second")
+	assert.LessOrEqual(t, kiosk.Actions[1].MinMs, kiosk.Actions[1].P50Ms)
+	assert.LessOrEqual(t, kiosk.Actions[1].P50Ms, kiosk.Actions[1].P99Ms)
+	assert.LessOrEqual(t, kiosk.Actions[1].P99Ms, kiosk.Actions[1].MaxMs)
+
+	broken := report.Apps[1]
+	assert.Equal(t, 6, broken.FailedReplays)
+	assert.Equal(t, 1.0, broken.ErrorRate)
+	assert.Equal(t, 6, broken.Actions[1].Errors)
+	assert.Zero(t, broken.Actions[0].Errors)
+	require.Len(t, broken.Errors, 1)
+	assert.Equal(t, 6, broken.Errors[0].Count)
+	assert.Contains(t, broken.Errors[0].Error, "no such screen")
+	assert.Empty(t, executor.results, "load runs keep no functional results")
+
+	require.NoError(t, WriteLoadReport(outputDir, report))
+	data, err := os.ReadFile(filepath.Join(outputDir, LoadResultsFile))
+	require.NoError(t, err)
+	var written LoadReport
+	require.NoError(t, json.Unmarshal(data, &written))
+	assert.Equal(t, 6, written.Apps[1].FailedReplays)
+	page, err := os.ReadFile(filepath.Join(outputDir, LoadReportFile))
+	require.NoError(t, err)
+	assert.Contains(t, string(page), "<h2>Broken</h2>")
+	assert.Contains(t, string(page), "6 failed (100.0%)")
+	assert.Contains(t, string(page), `<td>go</td><td>navigate</td><td>6</td><td class="fail">6</td>`)
+}
+
+// TestExecutor_RunLoad_Duration tests that users stop starting replays once
+// the duration passed
+func TestExecutor_RunLoad_Duration(t *testing.T) {
+	cfg := &config.Config{Apps: []config.AppConfig{
+		{Name: "Kiosk", Type: "driver", Driver: scriptDriver(t, ""), Actions: []config.Action{{Name: "settle", Type: "wait"}}},
+	}}
+	executor := NewExecutor(cfg, t.TempDir(), logger.NewLogger(false))
+	report, err := executor.RunLoad(context.Background(), LoadOptions{Users: 2, Duration: 1500 * time.Millisecond})
+	require.NoError(t, err)
+	assert.Equal(t, 4, report.Apps[0].Replays, "two replays of a second each per user")
+}
+
+func TestLoadOptions_Validate(t *testing.T) {
+	assert.EqualError(t, LoadOptions{Iterations: 1}.Validate(), "a load run needs at least one user, got 0")
+	assert.EqualError(t, LoadOptions{Users: 1}.Validate(), "a load run needs iterations or a duration to end")
+	assert.Error(t, LoadOptions{Users: 1, Iterations: -1}.Validate())
+	assert.NoError(t, LoadOptions{Users: 1, Duration: time.Minute}.Validate())
+
+	cfg := &config.Config{Apps: []config.AppConfig{{Name: "Phone", Type: "mobile", Platform: "android", Actions: []config.Action{{Name: "tap", Type: "click", Selector: "#x"}}}}}
+	_, err := NewExecutor(cfg, t.TempDir(), logger.NewLogger(false)).RunLoad(context.Background(), LoadOptions{Users: 1, Iterations: 1})
+	assert.EqualError(t, err, "no web or driver app has actions to load test")
+}
+
+func TestPercentile(t *testing.T) {
+	var sorted []time.Duration
+	for i := 1; i <= 100; i++ {
+		sorted = append(sorted, time.Duration(i)*time.Millisecond)
+	}
+	assert.Equal(t, 50*time.Millisecond, percentile(sorted, 50))
+	assert.Equal(t, 95*time.Millisecond, percentile(sorted, 95))
+	assert.Equal(t, 100*time.Millisecond, percentile(sorted, 100))
+	assert.Equal(t, time.Millisecond, percentile(sorted[:1], 99))
+}
@@ -0,0 +1,221 @@
+package executor
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"panoptic/internal/config"
+)
+
+// ManifestFile is the reproducibility manifest a run writes to its output
+// directory
+const ManifestFile = "manifest.json"
+
+// Manifest records the inputs of a run, so a flaky failure can be replayed
+// with the same ones: the configuration, selection and test data seed, and
+// what it ran on
+type Manifest struct {
+	RunID       string        `json:"run_id"`
+	CreatedAt   time.Time     `json:"created_at"`
+	Config      ConfigInfo    `json:"config"`
+	ConfigFile  string        `json:"config_file,omitempty"`
+	Environment Environment   `json:"environment"`
+	OSRelease   string        `json:"os_release,omitempty"` // distribution, from /etc/os-release
+	Apps        []ManifestApp `json:"apps"`
+	Nodes       []string      `json:"nodes,omitempty"` // IDs of the distributed nodes tests ran on
+
+	// The run this one replayed, and how their inputs differ
+	ReplayOf    string   `json:"replay_of,omitempty"`
+	Differences []string `json:"differences,omitempty"`
+}
+
+// ManifestApp is what an app ran with
+type ManifestApp struct {
+	Name           string            `json:"name"`
+	Type           string            `json:"type"`
+	Browser        string            `json:"browser,omitempty"`
+	BrowserVersion string            `json:"browser_version,omitempty"` // as the browser reported it
+	Variables      map[string]string `json:"variables,omitempty"`       // {{var.*}} values resolved by its actions
+}
+
+// key tells the runs of an app apart across browsers
+func (a ManifestApp) key() string {
+	if a.Browser == "" {
+		return a.Name
+	}
+	return a.Name + " [" + a.Browser + "]"
+}
+
+// browserVersioner is implemented by platforms that can tell the version of
+// their browser
+type browserVersioner interface {
+	BrowserVersion() (string, error)
+}
+
+// recordManifestApp notes what an app that finished ran with
+func (e *Executor) recordManifestApp(app config.AppConfig, browserVersion string) {
+	entry := ManifestApp{Name: app.Name, Type: app.Type, BrowserVersion: browserVersion}
+	if app.Type == "web" {
+		entry.Browser = app.BrowserLabel()
+	}
+	if len(e.vars) > 0 {
+		entry.Variables = maps.Clone(e.vars)
+	}
+	e.manifestApps = append(e.manifestApps, entry)
+}
+
+// recordNode notes a distributed node a test ran on
+func (e *Executor) recordNode(id string) {
+	if id != "" && !slices.Contains(e.nodes, id) {
+		e.nodes = append(e.nodes, id)
+	}
+}
+
+// Manifest is the reproducibility manifest of the run so far
+func (e *Executor) Manifest() *Manifest {
+	m := &Manifest{
+		RunID:     e.runID,
+		CreatedAt: time.Now(),
+		Config: ConfigInfo{
+			SHA256:    e.configSHA256,
+			TagFilter: e.tagFilter.String(),
+			FakeSeed:  e.fakeSeed,
+		},
+		ConfigFile:  e.configPath,
+		Environment: currentEnvironment(),
+		OSRelease:   osRelease("/etc/os-release"),
+		Apps:        append([]ManifestApp{}, e.manifestApps...),
+		Nodes:       slices.Clone(e.nodes),
+	}
+	if e.config != nil {
+		m.Config.Name = e.config.Name
+	}
+	if e.replay != nil {
+		m.ReplayOf = e.replay.RunID
+		m.Differences = manifestDifferences(e.replay, m)
+	}
+	return m
+}
+
+// SaveManifest writes the reproducibility manifest of the run
+func (e *Executor) SaveManifest(path string) error {
+	data, err := json.MarshalIndent(e.Manifest(), "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal manifest: %w", err)
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return fmt.Errorf("failed to save manifest: %w", err)
+	}
+	return nil
+}
+
+// LoadManifest reads the manifest a run wrote
+func LoadManifest(path string) (*Manifest, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read manifest: %w", err)
+	}
+	var m Manifest
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil, fmt.Errorf("invalid manifest %s: %w", path, err)
+	}
+	return &m, nil
+}
+
+// Replay runs with the inputs of the run of a manifest: its test data seed
+// and, unless tags were set, its tags. The configuration must be the one
+// that run used. What else differs, such as the browser versions, is listed
+// in the manifest of this run. Call it before Run.
+func (e *Executor) Replay(m *Manifest) error {
+	if m.Config.SHA256 != "" && e.configSHA256 != "" && m.Config.SHA256 != e.configSHA256 {
+		return fmt.Errorf("the configuration changed since run %s; replay it with the configuration it used", m.RunID)
+	}
+	if m.Config.TagFilter != "" {
+		if e.tagFilter.IsEmpty() {
+			filter, err := config.ParseTagFilter(m.Config.TagFilter)
+			if err != nil {
+				return fmt.Errorf("invalid tags in manifest: %w", err)
+			}
+			e.SetTagFilter(filter)
+		} else if e.tagFilter.String() != m.Config.TagFilter {
+			return fmt.Errorf("run %s ran with tags %q; replay it with the same tags", m.RunID, m.Config.TagFilter)
+		}
+	}
+	e.SetFakeSeed(m.Config.FakeSeed)
+	e.replay = m
+	return nil
+}
+
+// manifestDifferences lists how the inputs of a replay differ from those of
+// the run it replayed
+func manifestDifferences(was, now *Manifest) []string {
+	var diffs []string
+	differ := func(what, a, b string) {
+		if a != b {
+			diffs = append(diffs, fmt.Sprintf("%s was %s, now %s", what, orNone(a), orNone(b)))
+		}
+	}
+	differ("configuration", was.Config.SHA256, now.Config.SHA256)
+	differ("tags", was.Config.TagFilter, now.Config.TagFilter)
+	differ("panoptic version", was.Environment.PanopticVersion, now.Environment.PanopticVersion)
+	differ("OS", was.Environment.OS+"/"+was.Environment.Arch, now.Environment.OS+"/"+now.Environment.Arch)
+	differ("OS release", was.OSRelease, now.OSRelease)
+
+	before := make(map[string]ManifestApp, len(was.Apps))
+	for _, app := range was.Apps {
+		before[app.key()] = app
+	}
+	ran := make(map[string]bool, len(now.Apps))
+	for _, app := range now.Apps {
+		ran[app.key()] = true
+		prev, ok := before[app.key()]
+		if !ok {
+			diffs = append(diffs, fmt.Sprintf("app %s didn't run before", app.key()))
+			continue
+		}
+		differ("browser of "+app.key(), prev.BrowserVersion, app.BrowserVersion)
+	}
+	for _, app := range was.Apps {
+		if !ran[app.key()] {
+			diffs = append(diffs, fmt.Sprintf("app %s didn't run", app.key()))
+		}
+	}
+	differ("nodes", strings.Join(was.Nodes, ","), strings.Join(now.Nodes, ","))
+	return diffs
+}
+
+func orNone(s string) string {
+	if s == "" {
+		return "none"
+	}
+	return s
+}
+
+// osRelease is the PRETTY_NAME of an os-release file, or empty where there
+// is none
+func osRelease(path string) string {
+	f, err := os.Open(path)
+	if err != nil {
+		return ""
+	}
+	defer f.Close()
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		name, value, ok := strings.Cut(scanner.Text(), "=")
+		if !ok || name != "PRETTY_NAME" {
+			continue
+		}
+		if unquoted, err := strconv.Unquote(value); err == nil {
+			return unquoted
+		}
+		return strings.Trim(value, `'"`)
+	}
+	return ""
+}
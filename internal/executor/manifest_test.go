@@ -0,0 +1,132 @@
+package executor
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"panoptic/internal/config"
+	"panoptic/internal/logger"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func manifestConfig(t *testing.T) (*config.Config, string) {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "kiosk.yaml")
+	require.NoError(t, os.WriteFile(path, []byte("name: kiosk\n"), 0644))
+	cfg := &config.Config{Name: "kiosk", Apps: []config.AppConfig{
+		{Name: "Kiosk", Type: "driver", Driver: scriptDriver(t, `"click"`), Actions: []config.Action{
+			{Name: "tap", Type: "click", Selector: "#start", Tags: []string{"smoke"}},
+		}},
+	}}
+	return cfg, path
+}
+
+// TestExecutor_SaveManifest tests that a run records its inputs and replays
+// them from its manifest
+func TestExecutor_SaveManifest(t *testing.T) {
+	cfg, configFile := manifestConfig(t)
+	outputDir := t.TempDir()
+	executor := NewExecutor(cfg, outputDir, logger.NewLogger(false))
+	require.NoError(t, executor.SetConfigFile(configFile))
+	filter, err := config.ParseTagFilter("smoke")
+	require.NoError(t, err)
+	executor.SetTagFilter(filter)
+	executor.SetFakeSeed(42)
+	require.NoError(t, executor.Run())
+	path := filepath.Join(outputDir, ManifestFile)
+	require.NoError(t, executor.SaveManifest(path))
+
+	m, err := LoadManifest(path)
+	require.NoError(t, err)
+	assert.Equal(t, executor.RunID(), m.RunID)
+	assert.Equal(t, "kiosk", m.Config.Name)
+	assert.Equal(t, "smoke", m.Config.TagFilter)
+	assert.Equal(t, int64(42), m.Config.FakeSeed)
+	assert.Len(t, m.Config.SHA256, 64)
+	assert.Equal(t, configFile, m.ConfigFile)
+	assert.NotEmpty(t, m.Environment.OS)
+	require.Len(t, m.Apps, 1)
+	assert.Equal(t, ManifestApp{Name: "Kiosk", Type: "driver"}, m.Apps[0])
+	assert.Empty(t, m.ReplayOf)
+
+	replay := NewExecutor(cfg, t.TempDir(), logger.NewLogger(false))
+	require.NoError(t, replay.SetConfigFile(configFile))
+	require.NoError(t, replay.Replay(m))
+	assert.Equal(t, int64(42), replay.FakeSeed())
+	assert.Equal(t, "smoke", replay.tagFilter.String(), "tags carry over")
+	require.NoError(t, replay.Run())
+	again := replay.Manifest()
+	assert.Equal(t, m.RunID, again.ReplayOf)
+	assert.NotEqual(t, m.RunID, again.RunID)
+	assert.Empty(t, again.Differences)
+}
+
+func TestExecutor_Replay_Mismatch(t *testing.T) {
+	cfg, configFile := manifestConfig(t)
+	m := &Manifest{RunID: "run-1", Config: ConfigInfo{SHA256: "0123", FakeSeed: 7}}
+	executor := NewExecutor(cfg, t.TempDir(), logger.NewLogger(false))
+	require.NoError(t, executor.SetConfigFile(configFile))
+	assert.EqualError(t, executor.Replay(m), "the configuration changed since run run-1; replay it with the configuration it used")
+
+	m = &Manifest{RunID: "run-1", Config: ConfigInfo{TagFilter: "smoke"}}
+	filter, err := config.ParseTagFilter("slow")
+	require.NoError(t, err)
+	executor.SetTagFilter(filter)
+	assert.EqualError(t, executor.Replay(m), `run run-1 ran with tags "smoke"; replay it with the same tags`)
+
+	_, err = LoadManifest(filepath.Join(t.TempDir(), ManifestFile))
+	assert.ErrorContains(t, err, "failed to read manifest")
+}
+
+func TestManifestDifferences(t *testing.T) {
+	was := &Manifest{
+		Environment: Environment{PanopticVersion: "v1.2.0", OS: "linux", Arch: "amd64"},
+		Apps: []ManifestApp{
+			{Name: "Shop", Type: "web", Browser: "chromium", BrowserVersion: "HeadlessChrome/120.0.6099.109"},
+			{Name: "Admin", Type: "web", Browser: "chromium"},
+		},
+		Nodes: []string{"node-a"},
+	}
+	now := &Manifest{
+		Environment: Environment{PanopticVersion: "v1.2.0", OS: "linux", Arch: "arm64"},
+		Apps: []ManifestApp{
+			{Name: "Shop", Type: "web", Browser: "chromium", BrowserVersion: "HeadlessChrome/121.0.6167.85"},
+			{Name: "Kiosk", Type: "driver"},
+		},
+	}
+	assert.Equal(t, []string{
+		"OS was linux/amd64, now linux/arm64",
+		"browser of Shop [chromium] was HeadlessChrome/120.0.6099.109, now HeadlessChrome/121.0.6167.85",
+		"app Kiosk didn't run before",
+		"app Admin [chromium] didn't run",
+		"nodes was node-a, now none",
+	}, manifestDifferences(was, now))
+	assert.Empty(t, manifestDifferences(was, was))
+}
+
+func TestExecutor_RecordManifestApp(t *testing.T) {
+	executor := NewExecutor(&config.Config{}, t.TempDir(), logger.NewLogger(false))
+	executor.vars = map[string]string{"otp": "123456"}
+	executor.recordManifestApp(config.AppConfig{Name: "Shop", Type: "web", Browser: "firefox"}, "Firefox/121.0")
+	executor.vars["otp"] = "changed"
+	executor.recordNode("node-a")
+	executor.recordNode("node-a")
+	executor.recordNode("")
+
+	m := executor.Manifest()
+	require.Len(t, m.Apps, 1)
+	assert.Equal(t, "firefox", m.Apps[0].Browser)
+	assert.Equal(t, "Firefox/121.0", m.Apps[0].BrowserVersion)
+	assert.Equal(t, map[string]string{"otp": "123456"}, m.Apps[0].Variables)
+	assert.Equal(t, []string{"node-a"}, m.Nodes)
+}
+
+func TestOSRelease(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "os-release")
+	require.NoError(t, os.WriteFile(path, []byte("NAME=\"Ubuntu\"\nPRETTY_NAME=\"Ubuntu 24.04 LTS\"\n"), 0644))
+	assert.Equal(t, "Ubuntu 24.04 LTS", osRelease(path))
+	assert.Empty(t, osRelease(filepath.Join(t.TempDir(), "missing")))
+}
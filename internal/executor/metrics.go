@@ -0,0 +1,115 @@
+package executor
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"panoptic/internal/config"
+	"panoptic/internal/history"
+	"panoptic/internal/platforms"
+)
+
+// elementTextScript returns the text of the element matching a selector
+const elementTextScript = `() => {
+	const el = document.querySelector(%s);
+	return el ? (el.innerText || el.value || el.textContent || "") : null;
+}`
+
+// metricNumber matches numbers in text, with optional thousands separators
+var metricNumber = regexp.MustCompile(`[-+]?(?:\d[\d,]*(?:\.\d+)?|\.\d+)`)
+
+// recordMetrics records the metrics an action declares once it succeeded,
+// took being how long it ran. A metric that can't be read fails the action.
+func (e *Executor) recordMetrics(platform platforms.Platform, action config.Action, took time.Duration, result *TestResult) error {
+	for _, output := range action.Metrics {
+		value, err := e.readMetric(platform, output, took)
+		if err != nil {
+			return fmt.Errorf("metric %s: %w", output.Name, err)
+		}
+		unit := output.Unit
+		if output.Duration && unit == "" {
+			unit = "ms"
+		}
+		result.CustomMetrics = append(result.CustomMetrics, history.Metric{Name: output.Name, Value: value, Unit: unit, Action: action.Name})
+		e.logger.Infof("Metric %s: %s", output.Name, history.FormatMetric(value, unit))
+	}
+	return nil
+}
+
+// readMetric reads the value of a metric from its source
+func (e *Executor) readMetric(platform platforms.Platform, output config.MetricOutput, took time.Duration) (float64, error) {
+	if output.Duration {
+		return float64(took.Microseconds()) / 1000, nil
+	}
+	if output.Value != "" {
+		text, err := e.interpolateVars(output.Value)
+		if err != nil {
+			return 0, err
+		}
+		return parseMetric(text, output.Pattern)
+	}
+
+	evaluator, ok := platform.(scriptEvaluator)
+	if !ok {
+		return 0, fmt.Errorf("selector and script metrics are only supported on web apps")
+	}
+	js := output.Script
+	if output.Selector != "" {
+		quoted, _ := json.Marshal(output.Selector)
+		js = fmt.Sprintf(elementTextScript, quoted)
+	}
+	value, err := evaluator.Evaluate(js)
+	if err != nil {
+		return 0, err
+	}
+	switch v := value.(type) {
+	case float64:
+		if output.Pattern == "" {
+			return v, nil
+		}
+		return parseMetric(strconv.FormatFloat(v, 'f', -1, 64), output.Pattern)
+	case string:
+		return parseMetric(v, output.Pattern)
+	case nil:
+		if output.Selector != "" {
+			return 0, fmt.Errorf("no element matches %s", output.Selector)
+		}
+		return 0, fmt.Errorf("the script returned nothing")
+	default:
+		return 0, fmt.Errorf("the script returned %T, not a number", value)
+	}
+}
+
+// parseMetric picks the number out of a metric's text: the first one in
+// what pattern matches, by its first group when it has one, or in the
+// whole text without a pattern
+func parseMetric(text, pattern string) (float64, error) {
+	if pattern != "" {
+		match := regexp.MustCompile(pattern).FindStringSubmatch(text)
+		if match == nil {
+			return 0, fmt.Errorf("%q doesn't match %s", abbreviate(text), pattern)
+		}
+		text = match[0]
+		if len(match) > 1 {
+			text = match[1]
+		}
+	}
+	number := metricNumber.FindString(text)
+	if number == "" {
+		return 0, fmt.Errorf("no number in %q", abbreviate(text))
+	}
+	return strconv.ParseFloat(strings.ReplaceAll(number, ",", ""), 64)
+}
+
+// abbreviate shortens text quoted in errors
+func abbreviate(text string) string {
+	text = strings.TrimSpace(text)
+	if r := []rune(text); len(r) > 60 {
+		return string(r[:60]) + "…"
+	}
+	return text
+}
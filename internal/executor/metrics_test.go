@@ -0,0 +1,98 @@
+package executor
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"panoptic/internal/config"
+	"panoptic/internal/history"
+	"panoptic/internal/logger"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// scriptedPlatform is a mock web platform answering the scripts that
+// contain a key of values with its value
+type scriptedPlatform struct {
+	*MockPlatform
+	values map[string]interface{}
+}
+
+func (p *scriptedPlatform) Evaluate(js string) (interface{}, error) {
+	for part, value := range p.values {
+		if strings.Contains(js, part) {
+			return value, nil
+		}
+	}
+	return nil, nil
+}
+
+func TestParseMetric(t *testing.T) {
+	for _, tt := range []struct {
+		text, pattern string
+		want          float64
+	}{
+		{"Total: $1,234.50", "", 1234.5},
+		{"12 items", "", 12},
+		{"-3.5°C", "", -3.5},
+		{"Page 2 of 17", `of (\d+)`, 17},
+		{"Page 2 of 17", `of \d+`, 17},
+	} {
+		got, err := parseMetric(tt.text, tt.pattern)
+		require.NoError(t, err, tt.text)
+		assert.Equal(t, tt.want, got, tt.text)
+	}
+
+	_, err := parseMetric("Your cart is empty", "")
+	assert.EqualError(t, err, `no number in "Your cart is empty"`)
+	_, err = parseMetric("Page 2", `of (\d+)`)
+	assert.EqualError(t, err, `"Page 2" doesn't match of (\d+)`)
+}
+
+func TestExecutor_RecordMetrics(t *testing.T) {
+	executor := NewExecutor(&config.Config{}, t.TempDir(), logger.NewLogger(false))
+	executor.vars = map[string]string{"orders": "7 orders"}
+	platform := &scriptedPlatform{MockPlatform: &MockPlatform{metrics: map[string]interface{}{}}, values: map[string]interface{}{
+		`"#total"`:        "Total: $1,234.50",
+		`.item`:           float64(12),
+		`"#missing"`:      nil,
+		`location.search`: true,
+	}}
+	app := config.AppConfig{Name: "Shop", Type: "web"}
+	result := &TestResult{Metrics: map[string]interface{}{}}
+
+	open := config.Action{Name: "open_cart", Type: "click", Selector: "#cart", Metrics: []config.MetricOutput{
+		{Name: "cart_render", Duration: true},
+		{Name: "cart_total", Selector: "#total", Unit: "USD"},
+		{Name: "items", Script: "document.querySelectorAll('.item').length"},
+		{Name: "orders", Value: "{{var.orders}}"},
+	}}
+	require.NoError(t, executor.executeAction(platform, open, app, result, nil))
+	require.Len(t, result.CustomMetrics, 4)
+	assert.Equal(t, "ms", result.CustomMetrics[0].Unit)
+	assert.Equal(t, "open_cart", result.CustomMetrics[0].Action)
+	assert.Equal(t, []history.Metric{
+		{Name: "cart_total", Value: 1234.5, Unit: "USD", Action: "open_cart"},
+		{Name: "items", Value: 12, Action: "open_cart"},
+		{Name: "orders", Value: 7, Action: "open_cart"},
+	}, result.CustomMetrics[1:])
+
+	missing := config.Action{Name: "badge", Type: "click", Selector: "#cart", Metrics: []config.MetricOutput{{Name: "badge", Selector: "#missing"}}}
+	assert.EqualError(t, executor.executeAction(platform, missing, app, result, nil), "metric badge: no element matches #missing")
+	flag := config.Action{Name: "flag", Type: "click", Selector: "#cart", Metrics: []config.MetricOutput{{Name: "flag", Script: "location.search"}}}
+	assert.EqualError(t, executor.executeAction(platform, flag, app, result, nil), "metric flag: the script returned bool, not a number")
+
+	native := config.Action{Name: "count", Type: "click", Selector: "#cart", Metrics: []config.MetricOutput{{Name: "items", Script: "1"}}}
+	assert.EqualError(t, executor.executeAction(platform.MockPlatform, native, app, result, nil),
+		"metric items: selector and script metrics are only supported on web apps")
+	assert.Len(t, result.CustomMetrics, 4)
+
+	executor.results = []TestResult{*result}
+	path := filepath.Join(t.TempDir(), history.FileName)
+	require.NoError(t, executor.AppendHistory(path))
+	records, err := history.Load(path)
+	require.NoError(t, err)
+	assert.Equal(t, result.CustomMetrics, records[0].Entries[0].Metrics)
+}
@@ -0,0 +1,47 @@
+package executor
+
+import (
+	"fmt"
+
+	"panoptic/internal/config"
+	"panoptic/internal/platforms"
+)
+
+// networkEmulator is implemented by platforms that can throttle their network
+type networkEmulator interface {
+	EmulateNetwork(conditions config.NetworkConditions) error
+}
+
+// NetworkChange is recorded in the network_changes metric each time the
+// network conditions of an app change
+type NetworkChange struct {
+	Action  string `json:"action"` // settings.network for the conditions an app starts with
+	Profile string `json:"profile"`
+}
+
+// emulateNetwork applies network conditions and records them as the app's
+// network_profile metric
+func (e *Executor) emulateNetwork(platform platforms.Platform, conditions *config.NetworkConditions, source string, result *TestResult) error {
+	emulator, ok := platform.(networkEmulator)
+	if !ok {
+		return fmt.Errorf("network emulation is only supported on the web platform")
+	}
+	resolved, err := conditions.Resolve()
+	if err != nil {
+		return err
+	}
+	if err := emulator.EmulateNetwork(resolved); err != nil {
+		return err
+	}
+
+	e.network = resolved
+	label := resolved.Label()
+	if result.Metrics == nil {
+		result.Metrics = make(map[string]interface{})
+	}
+	changes, _ := result.Metrics["network_changes"].([]NetworkChange)
+	result.Metrics["network_changes"] = append(changes, NetworkChange{Action: source, Profile: label})
+	result.Metrics["network_profile"] = label
+	e.logger.Infof("Network conditions: %s", label)
+	return nil
+}
@@ -0,0 +1,47 @@
+package executor
+
+import (
+	"testing"
+
+	"panoptic/internal/config"
+	"panoptic/internal/logger"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// networkPlatform is a MockPlatform recording emulated network conditions
+type networkPlatform struct {
+	*MockPlatform
+	applied []config.NetworkConditions
+}
+
+func (p *networkPlatform) EmulateNetwork(conditions config.NetworkConditions) error {
+	p.applied = append(p.applied, conditions)
+	return nil
+}
+
+// TestExecutor_NetworkAction tests switching conditions mid-test and the
+// recorded metrics
+func TestExecutor_NetworkAction(t *testing.T) {
+	executor := NewExecutor(&config.Config{}, t.TempDir(), logger.NewLogger(false))
+	platform := &networkPlatform{MockPlatform: &MockPlatform{metrics: map[string]interface{}{}}}
+	result := &TestResult{}
+	recording := ""
+
+	require.NoError(t, executor.runAction(t.Context(), platform, config.Action{Name: "throttle", Type: "network", Value: "slow-3g"}, config.AppConfig{}, result, &recording))
+	require.NoError(t, executor.runAction(t.Context(), platform, config.Action{Name: "offline", Type: "network", Value: "offline"}, config.AppConfig{}, result, &recording))
+
+	require.Len(t, platform.applied, 2)
+	assert.Equal(t, float64(2000), platform.applied[0].LatencyMS, "profiles are resolved before emulation")
+	assert.True(t, platform.applied[1].Offline)
+	assert.Equal(t, "offline", result.Metrics["network_profile"])
+	assert.Equal(t, []NetworkChange{{Action: "throttle", Profile: "slow-3g"}, {Action: "offline", Profile: "offline"}}, result.Metrics["network_changes"])
+
+	err := executor.runAction(t.Context(), platform, config.Action{Name: "bad", Type: "network"}, config.AppConfig{}, result, &recording)
+	assert.ErrorContains(t, err, "network action 'bad': network action needs a profile")
+
+	mock := &MockPlatform{metrics: map[string]interface{}{}}
+	err = executor.emulateNetwork(mock, &config.NetworkConditions{Profile: "fast-4g"}, "settings.network", result)
+	assert.ErrorContains(t, err, "only supported on the web platform")
+}
@@ -0,0 +1,243 @@
+package executor
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"panoptic/internal/artifactcrypt"
+	"panoptic/internal/cloud"
+	"panoptic/internal/config"
+	"panoptic/internal/notify"
+)
+
+// slackAPIURL is the Slack Web API used for token posting
+var slackAPIURL = notify.DefaultSlackAPIURL
+
+// defaultThreadTTL is how long repeated runs reply to the same Slack thread
+const defaultThreadTTL = 24 * time.Hour
+
+// Notify posts the run summary to each of settings.notifications. Repeated
+// runs of the same configuration reply to one Slack thread, tracked in
+// <output>/notify_state.json. Posting errors are returned together.
+func (e *Executor) Notify(ctx context.Context) error {
+	notifications := e.config.Settings.Notifications
+	if len(notifications) == 0 {
+		return nil
+	}
+
+	statePath := filepath.Join(e.outputDir, notify.StateFileName)
+	state, err := notify.LoadState(statePath)
+	if err != nil {
+		e.logger.Warnf("Starting new notification threads: %v", err)
+		state = &notify.State{Targets: make(map[string]*notify.Target)}
+	}
+
+	_, policyErr := e.policyVerdict()
+	success := policyErr == nil
+	var screenshots map[int]string
+	now := time.Now()
+
+	var errs []error
+	for i, n := range notifications {
+		target := state.Target(e.notifyKey(n))
+		previous := target.LastSuccess
+		target.LastSuccess = &success
+		if !shouldNotify(n.On, previous, success) {
+			continue
+		}
+
+		if n.Screenshots && screenshots == nil {
+			screenshots = e.uploadScreenshots(ctx)
+		}
+		run := e.notifyRun(success, strings.ReplaceAll(n.ReportURL, "{run_id}", e.runID))
+		if n.Screenshots {
+			for j := range run.Failures {
+				run.Failures[j].Screenshot = screenshots[e.failureIndex(j)]
+			}
+		}
+
+		switch n.Type {
+		case "slack":
+			msg := notify.FormatSlack(run, n.MaxFailures)
+			ttl := n.ThreadTTL
+			if ttl <= 0 {
+				ttl = defaultThreadTTL
+			}
+			if n.Thread && target.Thread != nil && now.Sub(target.Thread.StartedAt) < ttl {
+				msg.ThreadTS = target.Thread.TS
+				// Surface status changes in the channel, not only in the thread
+				msg.ReplyBroadcast = previous != nil && *previous != success
+			}
+			slack := notify.NewSlack(os.ExpandEnv(n.WebhookURL), os.ExpandEnv(n.Token), n.Channel)
+			slack.APIURL = slackAPIURL
+			ts, err := slack.Post(ctx, msg)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("notification %d (slack): %w", i, err))
+				continue
+			}
+			if n.Thread && msg.ThreadTS == "" && ts != "" {
+				target.Thread = &notify.Thread{TS: ts, StartedAt: now}
+			}
+		case "teams":
+			if err := notify.NewTeams(os.ExpandEnv(n.WebhookURL)).Post(ctx, notify.FormatTeams(run, n.MaxFailures)); err != nil {
+				errs = append(errs, fmt.Errorf("notification %d (teams): %w", i, err))
+				continue
+			}
+		}
+		e.logger.Infof("Sent %s notification", n.Type)
+	}
+
+	if err := state.Save(statePath); err != nil {
+		errs = append(errs, err)
+	}
+	return errors.Join(errs...)
+}
+
+// notifyKey identifies a destination and configuration in the state file
+// without storing the webhook URL, which is a secret
+func (e *Executor) notifyKey(n config.NotificationSettings) string {
+	sum := sha256.Sum256([]byte(n.WebhookURL + "|" + n.Channel))
+	return n.Type + "/" + hex.EncodeToString(sum[:6]) + "/" + e.config.Name
+}
+
+// shouldNotify applies the on: setting given the previous run's outcome
+func shouldNotify(on string, previous *bool, success bool) bool {
+	switch on {
+	case "failure":
+		return !success
+	case "change":
+		return previous == nil || *previous != success
+	default:
+		return true
+	}
+}
+
+// notifyRun summarizes the results for a notification
+func (e *Executor) notifyRun(success bool, reportURL string) notify.Run {
+	s := e.Summary()
+	run := notify.Run{
+		Name:        e.config.Name,
+		RunID:       e.runID,
+		Total:       s.Total,
+		Passed:      s.Passed,
+		Failed:      s.Failed,
+		Warnings:    s.Warnings,
+		Quarantined: s.Quarantined,
+		Success:     success,
+		ReportURL:   reportURL,
+
+		Organization: e.reportOrganization(),
+	}
+	if settings := e.config.Settings.Report; settings != nil {
+		run.Footer = settings.Footer
+	}
+	if !e.startedAt.IsZero() && !e.finishedAt.IsZero() {
+		run.Duration = e.finishedAt.Sub(e.startedAt)
+	}
+	for _, r := range e.results {
+		if r.Success {
+			continue
+		}
+		run.Failures = append(run.Failures, notify.Failure{
+			App:         r.AppName,
+			Error:       r.Error,
+			Category:    r.FailureCategory,
+			Quarantined: r.Quarantined,
+			Warning:     r.Severity == config.SeverityWarning,
+		})
+	}
+	return run
+}
+
+// failureIndex maps the nth failure to its index in e.results
+func (e *Executor) failureIndex(n int) int {
+	for i, r := range e.results {
+		if r.Success {
+			continue
+		}
+		if n == 0 {
+			return i
+		}
+		n--
+	}
+	return -1
+}
+
+// uploadScreenshots uploads the last screenshot of each failed app through
+// the settings.cloud provider and returns public URLs by result index.
+// Providers without a public http(s) URL can't serve chat thumbnails.
+func (e *Executor) uploadScreenshots(ctx context.Context) map[int]string {
+	urls := make(map[int]string)
+	if e.config.Settings.Cloud == nil {
+		e.logger.Warn("Notification screenshots need settings.cloud; sending without thumbnails")
+		return urls
+	}
+	var cloudConfig cloud.CloudConfig
+	if err := decodeSettingsMap(e.config.Settings.Cloud, &cloudConfig); err != nil {
+		e.logger.Warnf("Invalid cloud settings, sending without thumbnails: %v", err)
+		return urls
+	}
+	manager := cloud.NewCloudManager(*e.componentLogger())
+	if err := manager.Configure(cloudConfig); err != nil || !manager.Enabled {
+		e.logger.Warnf("Cloud provider unavailable, sending without thumbnails: %v", err)
+		return urls
+	}
+
+	for i, r := range e.results {
+		if r.Success || len(r.Screenshots) == 0 {
+			continue
+		}
+		local := r.Screenshots[len(r.Screenshots)-1]
+		remote := path.Join("notifications", e.runID, fmt.Sprintf("%d-%s", i, filepath.Base(local)))
+		if err := e.uploadScreenshot(ctx, manager, local, remote); err != nil {
+			e.logger.Warnf("Failed to upload screenshot of %s: %v", r.AppName, err)
+			continue
+		}
+		url, err := manager.Provider.GetPublicURL(ctx, remote)
+		if err != nil {
+			e.logger.Warnf("No public URL for screenshot of %s: %v", r.AppName, err)
+			continue
+		}
+		if !strings.HasPrefix(url, "http://") && !strings.HasPrefix(url, "https://") {
+			e.logger.Warnf("Screenshot URL %s is not reachable from chat; set settings.cloud.endpoint", url)
+			continue
+		}
+		urls[i] = url
+	}
+	return urls
+}
+
+// uploadScreenshot uploads a screenshot for chat, decrypted through a
+// temporary file when the run encrypted it at rest
+func (e *Executor) uploadScreenshot(ctx context.Context, manager *cloud.CloudManager, local, remote string) error {
+	if e.artifactKey == nil || !artifactcrypt.IsEncryptedFile(local) {
+		_, err := manager.Provider.UploadFile(ctx, local, remote)
+		return err
+	}
+	data, err := e.readArtifact(local)
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp("", "panoptic-screenshot-*"+filepath.Ext(local))
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	_, err = manager.Provider.UploadFile(ctx, tmp.Name(), remote)
+	return err
+}
@@ -0,0 +1,137 @@
+package executor
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"panoptic/internal/config"
+	"panoptic/internal/logger"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeChat records chat messages by path
+type fakeChat struct {
+	server   *httptest.Server
+	messages map[string][]map[string]interface{}
+}
+
+func newFakeChat(t *testing.T) *fakeChat {
+	chat := &fakeChat{messages: make(map[string][]map[string]interface{})}
+	chat.server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]interface{}
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		chat.messages[r.URL.Path] = append(chat.messages[r.URL.Path], body)
+		if r.URL.Path == "/api/chat.postMessage" {
+			fmt.Fprintf(w, `{"ok":true,"ts":"ts-%d"}`, len(chat.messages[r.URL.Path]))
+			return
+		}
+		fmt.Fprint(w, "ok")
+	}))
+	t.Cleanup(chat.server.Close)
+	return chat
+}
+
+// TestExecutor_Notify_SlackThread tests threading of repeated runs and
+// broadcasting status changes
+func TestExecutor_Notify_SlackThread(t *testing.T) {
+	chat := newFakeChat(t)
+	oldURL := slackAPIURL
+	slackAPIURL = chat.server.URL + "/api"
+	defer func() { slackAPIURL = oldURL }()
+	t.Setenv("PANOPTIC_TEST_SLACK", "xoxb-1")
+
+	cfg := &config.Config{Name: "nightly", Settings: config.Settings{Notifications: []config.NotificationSettings{
+		{Type: "slack", Token: "${PANOPTIC_TEST_SLACK}", Channel: "C1", Thread: true},
+	}}}
+	dir := t.TempDir()
+	run := func(success bool) {
+		executor := NewExecutor(cfg, dir, logger.NewLogger(false))
+		executor.results = []TestResult{{AppName: "Shop", Success: success, Error: map[bool]string{false: "boom"}[success]}}
+		require.NoError(t, executor.Notify(context.Background()))
+	}
+
+	run(false)
+	run(false)
+	run(true)
+	posts := chat.messages["/api/chat.postMessage"]
+	require.Len(t, posts, 3)
+	assert.NotContains(t, posts[0], "thread_ts", "the first run starts the thread")
+	assert.Equal(t, "ts-1", posts[1]["thread_ts"])
+	assert.NotContains(t, posts[1], "reply_broadcast")
+	assert.Equal(t, "ts-1", posts[2]["thread_ts"])
+	assert.Equal(t, true, posts[2]["reply_broadcast"], "recoveries are broadcast to the channel")
+
+	state, err := os.ReadFile(filepath.Join(dir, "notify_state.json"))
+	require.NoError(t, err)
+	assert.NotContains(t, string(state), "xoxb", "tokens are not stored")
+}
+
+// TestExecutor_Notify_On tests the failure and change filters and Teams posting
+func TestExecutor_Notify_On(t *testing.T) {
+	chat := newFakeChat(t)
+	cfg := &config.Config{Name: "shop", Settings: config.Settings{Notifications: []config.NotificationSettings{
+		{Type: "teams", WebhookURL: chat.server.URL + "/failure", On: "failure"},
+		{Type: "slack", WebhookURL: chat.server.URL + "/change", On: "change"},
+	}}}
+	dir := t.TempDir()
+	for _, success := range []bool{true, true, false, false} {
+		executor := NewExecutor(cfg, dir, logger.NewLogger(false))
+		executor.results = []TestResult{{AppName: "Shop", Success: success}}
+		require.NoError(t, executor.Notify(context.Background()))
+	}
+	assert.Len(t, chat.messages["/failure"], 2)
+	assert.Len(t, chat.messages["/change"], 2, "first run, then the change to failing")
+	assert.Equal(t, "message", chat.messages["/failure"][0]["type"])
+
+	executor := NewExecutor(&config.Config{Settings: config.Settings{Notifications: []config.NotificationSettings{
+		{Type: "teams", WebhookURL: chat.server.URL + "/x"}, {Type: "teams", WebhookURL: "http://127.0.0.1:1/unreachable"},
+	}}}, t.TempDir(), logger.NewLogger(false))
+	err := executor.Notify(context.Background())
+	assert.ErrorContains(t, err, "notification 1 (teams)")
+	assert.NotContains(t, err.Error(), "notification 0")
+}
+
+// TestExecutor_Notify_Screenshots tests thumbnails uploaded via the cloud provider
+func TestExecutor_Notify_Screenshots(t *testing.T) {
+	chat := newFakeChat(t)
+	dir := t.TempDir()
+	shot := filepath.Join(dir, "fail.png")
+	require.NoError(t, os.WriteFile(shot, []byte("png"), 0600))
+	bucket := filepath.Join(dir, "bucket")
+
+	cfg := &config.Config{Name: "shop", Settings: config.Settings{
+		Cloud:         map[string]interface{}{"provider": "local", "bucket": bucket, "endpoint": "https://cdn.example.com"},
+		Notifications: []config.NotificationSettings{{Type: "slack", WebhookURL: chat.server.URL + "/hook", Screenshots: true}},
+	}}
+	executor := NewExecutor(cfg, dir, logger.NewLogger(false))
+	executor.SetRunID("run-7")
+	executor.results = []TestResult{
+		{AppName: "Ok", Success: true, Screenshots: []string{shot}},
+		{AppName: "Broken", Error: "boom", Screenshots: []string{shot}},
+	}
+	require.NoError(t, executor.Notify(context.Background()))
+
+	data, err := json.Marshal(chat.messages["/hook"])
+	require.NoError(t, err)
+	assert.Contains(t, string(data), `"image_url":"https://cdn.example.com/notifications/run-7/1-fail.png"`)
+	_, err = os.Stat(filepath.Join(bucket, "notifications", "run-7", "1-fail.png"))
+	assert.NoError(t, err)
+
+	// Without a public endpoint the message goes out without thumbnails
+	delete(cfg.Settings.Cloud, "endpoint")
+	executor = NewExecutor(cfg, dir, logger.NewLogger(false))
+	executor.results = []TestResult{{AppName: "Broken", Error: "boom", Screenshots: []string{shot}}}
+	require.NoError(t, executor.Notify(context.Background()))
+	last, err := json.Marshal(chat.messages["/hook"][1])
+	require.NoError(t, err)
+	assert.False(t, strings.Contains(string(last), "image_url"))
+}
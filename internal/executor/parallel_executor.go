@@ -9,6 +9,7 @@ import (
 
 	"panoptic/internal/config"
 	"panoptic/internal/platforms"
+	"panoptic/internal/testdata"
 )
 
 // ActionExecution represents an action being executed
@@ -151,6 +152,13 @@ func (e *Executor) executeAppParallel(ctx context.Context, app config.AppConfig)
 		Success:    false,
 	}
 	
+	runFake := e.fake
+	e.fake = testdata.New(testdata.DeriveSeed(e.fakeSeed, app.Name))
+	defer func() { e.fake = runFake }()
+	if e.rateLimits != nil {
+		e.rateLimits.startApp()
+	}
+	
 	// Create platform
 	platform, err := e.factory.CreatePlatform(app.Type)
 	if err != nil {
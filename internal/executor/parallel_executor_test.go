@@ -8,6 +8,7 @@ import (
 
 	"panoptic/internal/config"
 	"panoptic/internal/logger"
+	"panoptic/internal/platforms"
 )
 
 // TestAnalyzeActions tests action grouping for parallelization
@@ -223,6 +224,10 @@ func (m *MockPlatform) StartRecording(filename string) error {
 	return nil
 }
 
+func (m *MockPlatform) Capabilities() platforms.Capabilities {
+	return platforms.Capabilities{platforms.CapabilityNavigate, platforms.CapabilityClick, platforms.CapabilityFill, platforms.CapabilitySubmit, platforms.CapabilityScreenshot, platforms.CapabilityRecording}
+}
+
 func (m *MockPlatform) StopRecording() error {
 	return nil
 }
@@ -0,0 +1,225 @@
+package executor
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"panoptic/internal/config"
+	"panoptic/internal/pdf"
+	"panoptic/internal/platforms"
+)
+
+// maxDownloadedPDF bounds the documents assert_pdf downloads
+const maxDownloadedPDF = 50 << 20
+
+// pdfPrinter is implemented by platforms that print their page to PDF and
+// fetch documents as the page would
+type pdfPrinter interface {
+	SavePDF(path string, spec *config.PDFPrint) error
+	FetchDocument(url string) ([]byte, error)
+}
+
+// PDFRecord is recorded in the pdfs metric for every PDF save_pdf printed
+// or assert_pdf downloaded
+type PDFRecord struct {
+	Action string `json:"action"`
+	Path   string `json:"path"`
+	URL    string `json:"url,omitempty"` // the document was downloaded from
+	Pages  int    `json:"pages"`
+	Bytes  int64  `json:"bytes"`
+}
+
+// runPDFAction runs save_pdf, printing the page as the user would to keep
+// an invoice or report, and assert_pdf, checking the text and page count of
+// a printed or downloaded PDF
+func (e *Executor) runPDFAction(ctx context.Context, platform platforms.Platform, action config.Action, app config.AppConfig, result *TestResult) error {
+	if action.Type == "save_pdf" {
+		return e.savePDF(ctx, platform, action, app, result)
+	}
+	return e.assertPDF(ctx, platform, action, app, result)
+}
+
+// savePDF runs a save_pdf action, printing the page under pdfs/ in the
+// output directory
+func (e *Executor) savePDF(ctx context.Context, platform platforms.Platform, action config.Action, app config.AppConfig, result *TestResult) error {
+	printer, ok := platform.(pdfPrinter)
+	if !ok {
+		return fmt.Errorf("%s is not supported on this platform", action.Type)
+	}
+	spec, err := action.PDFPrint()
+	if err != nil {
+		return err
+	}
+	name := spec.File
+	if name == "" {
+		name = fmt.Sprintf("%s_%s_%d.pdf", app.Name, action.Name, time.Now().Unix())
+	}
+	path := filepath.Join(e.outputDir, "pdfs", name)
+	if err := e.platformCall(ctx, app, "SavePDF", func() error { return printer.SavePDF(path, spec) }); err != nil {
+		return err
+	}
+	record, err := pdfRecord(action, path, "")
+	if err != nil {
+		return err
+	}
+	recordPDF(result, record)
+	if spec.Variable != "" {
+		if e.vars == nil {
+			e.vars = make(map[string]string)
+		}
+		e.vars[spec.Variable] = path
+	}
+	e.logger.Infof("Printed the page to %s (%d page(s))", path, record.Pages)
+	return nil
+}
+
+// assertPDF runs an assert_pdf action on the file it names, the document
+// at its URL or the last PDF of the app
+func (e *Executor) assertPDF(ctx context.Context, platform platforms.Platform, action config.Action, app config.AppConfig, result *TestResult) error {
+	check, err := action.PDFCheck()
+	if err != nil {
+		return err
+	}
+	path := check.File
+	switch {
+	case check.URL != "":
+		if path, err = e.downloadPDF(ctx, platform, action, app, check.URL); err != nil {
+			return err
+		}
+		record, err := pdfRecord(action, path, check.URL)
+		if err != nil {
+			return err
+		}
+		recordPDF(result, record)
+	case path == "":
+		records, _ := result.Metrics["pdfs"].([]PDFRecord)
+		if len(records) == 0 {
+			return fmt.Errorf("no PDF to check: give a file or parameters.url, or print one with save_pdf first")
+		}
+		path = records[len(records)-1].Path
+	}
+
+	doc, err := pdf.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	pages := len(doc.Pages)
+	switch {
+	case check.Pages > 0 && pages != check.Pages:
+		return fmt.Errorf("%s has %d page(s), expected %d", path, pages, check.Pages)
+	case check.MinPages > 0 && pages < check.MinPages:
+		return fmt.Errorf("%s has %d page(s), expected at least %d", path, pages, check.MinPages)
+	case check.MaxPages > 0 && pages > check.MaxPages:
+		return fmt.Errorf("%s has %d page(s), expected at most %d", path, pages, check.MaxPages)
+	}
+	text := doc.Text()
+	where := path
+	if check.Page > 0 {
+		if check.Page > pages {
+			return fmt.Errorf("%s has no page %d, only %d page(s)", path, check.Page, pages)
+		}
+		text = doc.Pages[check.Page-1].Text
+		where = fmt.Sprintf("page %d of %s", check.Page, path)
+	}
+	text = strings.Join(strings.Fields(text), " ")
+	for _, want := range check.Contains {
+		if !strings.Contains(text, strings.Join(strings.Fields(want), " ")) {
+			return fmt.Errorf("%s doesn't contain %q", where, want)
+		}
+	}
+	for _, unwanted := range check.NotContains {
+		if strings.Contains(text, strings.Join(strings.Fields(unwanted), " ")) {
+			return fmt.Errorf("%s contains %q", where, unwanted)
+		}
+	}
+	if check.Matches != "" && !regexp.MustCompile(check.Matches).MatchString(text) { // compiled by PDFCheck
+		return fmt.Errorf("%s doesn't match %q", where, check.Matches)
+	}
+	if check.Variable != "" {
+		if e.vars == nil {
+			e.vars = make(map[string]string)
+		}
+		e.vars[check.Variable] = text
+	}
+	e.logger.Infof("%s has %d page(s) and the expected text", path, pages)
+	return nil
+}
+
+// downloadPDF saves the document at url under pdfs/: through the page on
+// platforms that fetch documents, so it's sent the session's cookies, and
+// with a plain GET otherwise
+func (e *Executor) downloadPDF(ctx context.Context, platform platforms.Platform, action config.Action, app config.AppConfig, url string) (string, error) {
+	var data []byte
+	var err error
+	if printer, ok := platform.(pdfPrinter); ok {
+		err = e.platformCall(ctx, app, "FetchDocument", func() error {
+			data, err = printer.FetchDocument(url)
+			return err
+		})
+	} else {
+		data, err = httpGet(ctx, url)
+	}
+	if err != nil {
+		return "", err
+	}
+	path := filepath.Join(e.outputDir, "pdfs", fmt.Sprintf("%s_%s_%d.pdf", app.Name, action.Name, time.Now().Unix()))
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return "", err
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return "", err
+	}
+	return path, nil
+}
+
+// httpGet downloads url, failing on error statuses
+func httpGet(ctx context.Context, url string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("invalid url: %w", err)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch %s: %w", url, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 400 {
+		return nil, fmt.Errorf("failed to fetch %s: HTTP %d", url, resp.StatusCode)
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxDownloadedPDF+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch %s: %w", url, err)
+	}
+	if len(data) > maxDownloadedPDF {
+		return nil, fmt.Errorf("failed to fetch %s: document is larger than %d bytes", url, maxDownloadedPDF)
+	}
+	return data, nil
+}
+
+// pdfRecord describes the PDF at path, failing when it can't be read
+func pdfRecord(action config.Action, path, url string) (PDFRecord, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return PDFRecord{}, err
+	}
+	doc, err := pdf.ReadFile(path)
+	if err != nil {
+		return PDFRecord{}, err
+	}
+	return PDFRecord{Action: action.Name, Path: path, URL: url, Pages: len(doc.Pages), Bytes: info.Size()}, nil
+}
+
+func recordPDF(result *TestResult, record PDFRecord) {
+	if result.Metrics == nil {
+		result.Metrics = make(map[string]interface{})
+	}
+	records, _ := result.Metrics["pdfs"].([]PDFRecord)
+	result.Metrics["pdfs"] = append(records, record)
+}
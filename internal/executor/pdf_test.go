@@ -0,0 +1,137 @@
+package executor
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"panoptic/internal/config"
+	"panoptic/internal/logger"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// invoicePDF is a PDF with a page per text
+func invoicePDF(pages ...string) []byte {
+	var kids []string
+	var objects []string
+	for i, text := range pages {
+		page, content := 4+2*i, 5+2*i
+		kids = append(kids, fmt.Sprintf("%d 0 R", page))
+		stream := fmt.Sprintf("BT /F1 12 Tf 72 720 Td (%s) Tj ET", text)
+		objects = append(objects,
+			fmt.Sprintf("%d 0 obj\n<< /Type /Page /Parent 2 0 R /Contents %d 0 R >>\nendobj\n", page, content),
+			fmt.Sprintf("%d 0 obj\n<< /Length %d >>\nstream\n%s\nendstream\nendobj\n", content, len(stream), stream))
+	}
+	return []byte("%PDF-1.4\n" +
+		"1 0 obj\n<< /Type /Catalog /Pages 2 0 R >>\nendobj\n" +
+		fmt.Sprintf("2 0 obj\n<< /Type /Pages /Kids [%s] /Count %d /Resources << /Font << /F1 3 0 R >> >> >>\nendobj\n", strings.Join(kids, " "), len(pages)) +
+		"3 0 obj\n<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica >>\nendobj\n" +
+		strings.Join(objects, "") +
+		"trailer\n<< /Root 1 0 R >>\n%%EOF\n")
+}
+
+// pdfPlatform is a mock platform printing an invoice
+type pdfPlatform struct {
+	*MockPlatform
+	printed *config.PDFPrint
+	fetched string
+}
+
+func (p *pdfPlatform) SavePDF(path string, spec *config.PDFPrint) error {
+	p.printed = spec
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, invoicePDF("Invoice INV-1042", "Total: 99.00 EUR"), 0644)
+}
+
+func (p *pdfPlatform) FetchDocument(url string) ([]byte, error) {
+	p.fetched = url
+	return invoicePDF("Receipt R-7"), nil
+}
+
+func TestExecutor_RunPDFAction(t *testing.T) {
+	outputDir := t.TempDir()
+	executor := NewExecutor(&config.Config{}, outputDir, logger.NewLogger(false))
+	platform := &pdfPlatform{MockPlatform: &MockPlatform{metrics: map[string]interface{}{}}}
+	app := config.AppConfig{Name: "Shop", Type: "web"}
+	result := &TestResult{AppName: "Shop", Metrics: map[string]interface{}{}}
+	ctx := context.Background()
+
+	save := config.Action{Name: "print", Type: "save_pdf", Value: "invoice.pdf", Parameters: map[string]interface{}{
+		"format": "A4", "variable": "invoice",
+	}}
+	require.NoError(t, executor.executeAction(platform, save, app, result, nil))
+	path := filepath.Join(outputDir, "pdfs", "invoice.pdf")
+	assert.Equal(t, "a4", platform.printed.Format)
+	assert.Equal(t, path, executor.vars["invoice"])
+	records := result.Metrics["pdfs"].([]PDFRecord)
+	require.Len(t, records, 1)
+	assert.Equal(t, "print", records[0].Action)
+	assert.Equal(t, 2, records[0].Pages)
+	assert.Positive(t, records[0].Bytes)
+	assert.Contains(t, result.Artifacts(), Artifact{App: "Shop", Type: "pdf", Path: path})
+
+	check := config.Action{Name: "invoice", Type: "assert_pdf", Parameters: map[string]interface{}{
+		"pages": 2, "contains": []interface{}{"INV-1042", "Total:  99.00"}, "not_contains": []interface{}{"DRAFT"},
+		"matches": `INV-\d+`, "variable": "text",
+	}}
+	require.NoError(t, executor.executeAction(platform, check, app, result, nil))
+	assert.Equal(t, "Invoice INV-1042 Total: 99.00 EUR", executor.vars["text"])
+
+	err := executor.runPDFAction(ctx, platform, config.Action{Name: "total", Type: "assert_pdf", Value: path, Parameters: map[string]interface{}{
+		"page": 1, "contains": []interface{}{"Total"},
+	}}, app, result)
+	assert.EqualError(t, err, fmt.Sprintf(`page 1 of %s doesn't contain "Total"`, path))
+	err = executor.runPDFAction(ctx, platform, config.Action{Name: "long", Type: "assert_pdf", Parameters: map[string]interface{}{"min_pages": 3}}, app, result)
+	assert.EqualError(t, err, fmt.Sprintf("%s has 2 page(s), expected at least 3", path))
+	err = executor.runPDFAction(ctx, platform, config.Action{Name: "third", Type: "assert_pdf", Parameters: map[string]interface{}{"page": 3, "contains": []interface{}{"x"}}}, app, result)
+	assert.EqualError(t, err, fmt.Sprintf("%s has no page 3, only 2 page(s)", path))
+
+	receipt := config.Action{Name: "receipt", Type: "assert_pdf", Parameters: map[string]interface{}{
+		"url": "https://shop.test/receipt.pdf", "pages": 1, "contains": []interface{}{"R-7"},
+	}}
+	require.NoError(t, executor.runPDFAction(ctx, platform, receipt, app, result))
+	assert.Equal(t, "https://shop.test/receipt.pdf", platform.fetched)
+	records = result.Metrics["pdfs"].([]PDFRecord)
+	require.Len(t, records, 2)
+	assert.Equal(t, "https://shop.test/receipt.pdf", records[1].URL)
+	assert.FileExists(t, records[1].Path)
+
+	err = executor.runPDFAction(ctx, &MockPlatform{metrics: map[string]interface{}{}}, save, app, result)
+	assert.EqualError(t, err, "save_pdf is not supported on this platform")
+	err = executor.runPDFAction(ctx, platform, check, app, &TestResult{Metrics: map[string]interface{}{}})
+	assert.EqualError(t, err, "no PDF to check: give a file or parameters.url, or print one with save_pdf first")
+}
+
+func TestExecutor_AssertPDF_Download(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/report.pdf" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write(invoicePDF("Monthly report"))
+	}))
+	defer server.Close()
+
+	executor := NewExecutor(&config.Config{}, t.TempDir(), logger.NewLogger(false))
+	platform := &MockPlatform{metrics: map[string]interface{}{}}
+	app := config.AppConfig{Name: "API", Type: "desktop"}
+	result := &TestResult{Metrics: map[string]interface{}{}}
+
+	action := config.Action{Name: "report", Type: "assert_pdf", Parameters: map[string]interface{}{
+		"url": server.URL + "/report.pdf", "contains": []interface{}{"Monthly report"},
+	}}
+	require.NoError(t, executor.executeAction(platform, action, app, result, nil))
+
+	action.Parameters["url"] = server.URL + "/missing.pdf"
+	err := executor.executeAction(platform, action, app, result, nil)
+	assert.EqualError(t, err, fmt.Sprintf("failed to fetch %s/missing.pdf: HTTP 404", server.URL))
+}
@@ -0,0 +1,174 @@
+package executor
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"panoptic/internal/config"
+	"panoptic/internal/platforms"
+)
+
+// webVitalsCollector is implemented by platforms that expose Core Web Vitals
+type webVitalsCollector interface {
+	CollectWebVitals() (*platforms.WebVitals, error)
+}
+
+// Performance budget keys accepted in settings.performance_budgets and in
+// performance_assert parameters. Times are milliseconds; cls is unitless.
+var performanceBudgetKeys = map[string]bool{
+	"lcp":         true,
+	"cls":         true,
+	"inp":         true,
+	"fid":         true,
+	"ttfb":        true,
+	"fcp":         true,
+	"load":        true,
+	"transfer_kb": true,
+}
+
+// PerformanceAssertion is recorded in the performance_assertions metric for
+// every performance_assert action
+type PerformanceAssertion struct {
+	Action     string              `json:"action"`
+	URL        string              `json:"url"`
+	Budgets    map[string]float64  `json:"budgets"`
+	Vitals     platforms.WebVitals `json:"vitals"`
+	Violations []string            `json:"violations,omitempty"`
+	Passed     bool                `json:"passed"`
+}
+
+// assertPerformance measures the current page and fails when a budget is exceeded
+func (e *Executor) assertPerformance(platform platforms.Platform, action config.Action, result *TestResult) error {
+	collector, ok := platform.(webVitalsCollector)
+	if !ok {
+		return fmt.Errorf("performance_assert is only supported on the web platform")
+	}
+	budgets, err := performanceBudgets(e.config.Settings.PerformanceBudgets, action.Parameters)
+	if err != nil {
+		return fmt.Errorf("performance_assert action '%s': %w", action.Name, err)
+	}
+	if len(budgets) == 0 {
+		return fmt.Errorf("performance_assert action '%s' has no budgets (set parameters or settings.performance_budgets)", action.Name)
+	}
+
+	vitals, err := collector.CollectWebVitals()
+	if err != nil {
+		return err
+	}
+	violations := checkPerformanceBudgets(*vitals, budgets)
+
+	assertion := PerformanceAssertion{
+		Action:     action.Name,
+		URL:        vitals.URL,
+		Budgets:    budgets,
+		Vitals:     *vitals,
+		Violations: violations,
+		Passed:     len(violations) == 0,
+	}
+	if result.Metrics == nil {
+		result.Metrics = make(map[string]interface{})
+	}
+	assertions, _ := result.Metrics["performance_assertions"].([]PerformanceAssertion)
+	result.Metrics["performance_assertions"] = append(assertions, assertion)
+
+	if len(violations) > 0 {
+		return fmt.Errorf("performance budget exceeded: %s", strings.Join(violations, "; "))
+	}
+	e.logger.Infof("Performance budgets met for %s", vitals.URL)
+	return nil
+}
+
+// performanceBudgets merges default budgets with action parameters, which win
+func performanceBudgets(defaults map[string]float64, params map[string]interface{}) (map[string]float64, error) {
+	budgets := make(map[string]float64)
+	for key, value := range defaults {
+		if !performanceBudgetKeys[key] {
+			return nil, fmt.Errorf("unknown performance budget %q", key)
+		}
+		budgets[key] = value
+	}
+	for key, raw := range params {
+		if !performanceBudgetKeys[key] {
+			return nil, fmt.Errorf("unknown performance budget %q", key)
+		}
+		value, ok := numberParam(raw)
+		if !ok {
+			return nil, fmt.Errorf("performance budget %q must be a number", key)
+		}
+		budgets[key] = value
+	}
+	return budgets, nil
+}
+
+// checkPerformanceBudgets lists exceeded budgets in key order. INP and FID
+// need a user interaction, so they pass when the browser reports none; any
+// other budgeted metric that is missing is a violation.
+func checkPerformanceBudgets(vitals platforms.WebVitals, budgets map[string]float64) []string {
+	keys := make([]string, 0, len(budgets))
+	for key := range budgets {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var violations []string
+	for _, key := range keys {
+		limit := budgets[key]
+		var value *float64
+		unit := "ms"
+		switch key {
+		case "lcp":
+			value = vitals.LCP
+		case "cls":
+			value, unit = vitals.CLS, ""
+		case "inp":
+			value = vitals.INP
+		case "fid":
+			value = vitals.FID
+		case "ttfb":
+			value = vitals.TTFB
+		case "fcp":
+			value = vitals.FCP
+		case "load":
+			value = vitals.Load
+		case "transfer_kb":
+			kb := float64(vitals.TransferBytes()) / 1024
+			value, unit = &kb, " KB"
+		}
+
+		name := strings.ToUpper(key)
+		if key == "transfer_kb" {
+			name = "transfer size"
+		}
+		if value == nil {
+			if key != "inp" && key != "fid" {
+				violations = append(violations, fmt.Sprintf("%s not reported by the browser", name))
+			}
+			continue
+		}
+		if *value > limit {
+			if unit == "" {
+				violations = append(violations, fmt.Sprintf("%s %.3f exceeds %.3f", name, *value, limit))
+			} else {
+				violations = append(violations, fmt.Sprintf("%s %.0f%s exceeds %.0f%s", name, *value, unit, limit, unit))
+			}
+		}
+	}
+	return violations
+}
+
+// numberParam converts a YAML scalar to float64
+func numberParam(value interface{}) (float64, bool) {
+	switch v := value.(type) {
+	case int:
+		return float64(v), true
+	case int64:
+		return float64(v), true
+	case float64:
+		return v, true
+	case float32:
+		return float64(v), true
+	default:
+		return 0, false
+	}
+}
@@ -0,0 +1,129 @@
+package executor
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"panoptic/internal/config"
+	"panoptic/internal/logger"
+	"panoptic/internal/platforms"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// vitalsPlatform is a MockPlatform reporting fixed Web Vitals
+type vitalsPlatform struct {
+	*MockPlatform
+	vitals platforms.WebVitals
+}
+
+func (p *vitalsPlatform) CollectWebVitals() (*platforms.WebVitals, error) {
+	v := p.vitals
+	return &v, nil
+}
+
+func ms(v float64) *float64 { return &v }
+
+func sampleVitals() platforms.WebVitals {
+	return platforms.WebVitals{
+		URL:  "https://shop.test/",
+		LCP:  ms(3100),
+		CLS:  ms(0.05),
+		TTFB: ms(300),
+		FCP:  ms(900),
+		Resources: []platforms.ResourceTiming{
+			{Name: "https://shop.test/app.js", TransferSize: 600 * 1024},
+		},
+	}
+}
+
+// TestExecutor_PerformanceAssert tests budgets from settings and parameters
+func TestExecutor_PerformanceAssert(t *testing.T) {
+	cfg := &config.Config{Settings: config.Settings{PerformanceBudgets: map[string]float64{"lcp": 4000, "cls": 0.1}}}
+	executor := NewExecutor(cfg, t.TempDir(), logger.NewLogger(false))
+	platform := &vitalsPlatform{MockPlatform: &MockPlatform{metrics: map[string]interface{}{}}, vitals: sampleVitals()}
+	result := &TestResult{Metrics: map[string]interface{}{}}
+
+	require.NoError(t, executor.assertPerformance(platform, config.Action{Name: "defaults", Type: "performance_assert"}, result))
+
+	err := executor.assertPerformance(platform, config.Action{
+		Name:       "strict",
+		Type:       "performance_assert",
+		Parameters: map[string]interface{}{"lcp": 2500, "inp": 200, "transfer_kb": 500.0},
+	}, result)
+	require.Error(t, err)
+	assert.Equal(t, "performance budget exceeded: LCP 3100ms exceeds 2500ms; transfer size 600 KB exceeds 500 KB", err.Error())
+
+	assertions := result.Metrics["performance_assertions"].([]PerformanceAssertion)
+	require.Len(t, assertions, 2)
+	assert.True(t, assertions[0].Passed)
+	assert.False(t, assertions[1].Passed)
+	assert.Equal(t, map[string]float64{"lcp": 2500, "cls": 0.1, "inp": 200, "transfer_kb": 500}, assertions[1].Budgets)
+	assert.Equal(t, "https://shop.test/", assertions[1].URL)
+}
+
+// TestExecutor_PerformanceAssert_Invalid tests rejected budgets and platforms
+func TestExecutor_PerformanceAssert_Invalid(t *testing.T) {
+	executor := NewExecutor(&config.Config{}, t.TempDir(), logger.NewLogger(false))
+	mock := &MockPlatform{metrics: map[string]interface{}{}}
+	platform := &vitalsPlatform{MockPlatform: mock, vitals: sampleVitals()}
+	result := &TestResult{}
+
+	err := executor.assertPerformance(mock, config.Action{Name: "p", Parameters: map[string]interface{}{"lcp": 1}}, result)
+	assert.ErrorContains(t, err, "only supported on the web platform")
+
+	err = executor.assertPerformance(platform, config.Action{Name: "p"}, result)
+	assert.ErrorContains(t, err, "has no budgets")
+
+	err = executor.assertPerformance(platform, config.Action{Name: "p", Parameters: map[string]interface{}{"speed": 1}}, result)
+	assert.ErrorContains(t, err, `unknown performance budget "speed"`)
+
+	err = executor.assertPerformance(platform, config.Action{Name: "p", Parameters: map[string]interface{}{"lcp": "fast"}}, result)
+	assert.ErrorContains(t, err, "must be a number")
+	assert.Nil(t, result.Metrics, "nothing recorded for invalid actions")
+}
+
+// TestCheckPerformanceBudgets_Missing tests metrics the browser did not report
+func TestCheckPerformanceBudgets_Missing(t *testing.T) {
+	violations := checkPerformanceBudgets(platforms.WebVitals{}, map[string]float64{"inp": 200, "fid": 100, "lcp": 2500, "cls": 0.1})
+	assert.Equal(t, []string{"CLS not reported by the browser", "LCP not reported by the browser"}, violations,
+		"INP and FID need an interaction")
+
+	assert.Equal(t, []string{"CLS 0.300 exceeds 0.100"},
+		checkPerformanceBudgets(platforms.WebVitals{CLS: ms(0.3)}, map[string]float64{"cls": 0.1}))
+}
+
+// TestGenerateComprehensiveReport_WebVitals tests the Web Vitals table from typed and JSON metrics
+func TestGenerateComprehensiveReport_WebVitals(t *testing.T) {
+	vitals := sampleVitals()
+	assertion := PerformanceAssertion{Action: "home budget", Violations: []string{"LCP 3100ms exceeds 2500ms"}}
+
+	// Results read back from a container hold generic JSON values
+	var generic interface{}
+	data, err := json.Marshal([]platforms.WebVitals{vitals})
+	require.NoError(t, err)
+	require.NoError(t, json.Unmarshal(data, &generic))
+
+	outputPath := filepath.Join(t.TempDir(), "report.html")
+	require.NoError(t, GenerateComprehensiveReport(outputPath, []TestResult{
+		{AppName: "Local", Metrics: map[string]interface{}{
+			"web_vitals":             []platforms.WebVitals{vitals},
+			"performance_assertions": []PerformanceAssertion{assertion},
+		}},
+		{AppName: "Container", Metrics: map[string]interface{}{"web_vitals": generic}},
+		{AppName: "NoVitals", Metrics: map[string]interface{}{}},
+	}))
+
+	content, err := os.ReadFile(outputPath)
+	require.NoError(t, err)
+	report := string(content)
+	assert.Equal(t, 2, strings.Count(report, "<h3>Web Vitals</h3>"))
+	assert.Contains(t, report, `<td class="needs-improvement">3100 ms</td>`)
+	assert.Contains(t, report, `<td class="good">0.050</td>`)
+	assert.Contains(t, report, `<td>600.0 KB</td>`)
+	assert.Contains(t, report, `Budget home budget: LCP 3100ms exceeds 2500ms`)
+}
@@ -0,0 +1,70 @@
+package executor
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"panoptic/internal/vision"
+)
+
+// ScreenHash is the perceptual hash of a screenshot, under the screen it
+// shows, so runs can be compared screen by screen
+type ScreenHash struct {
+	Screenshot string       `json:"screenshot"`
+	Screen     string       `json:"screen"` // the action that took it, or the custom file name
+	PHash      vision.PHash `json:"phash"`
+}
+
+// captureSuffix is the capture time appended to screenshot names
+var captureSuffix = regexp.MustCompile(`_\d+$`)
+
+// screenName names the screen a screenshot shows, the same in every run:
+// the file name without the app prefix, the capture time and the extension
+func screenName(appName, screenshot string) string {
+	name := strings.TrimSuffix(filepath.Base(screenshot), filepath.Ext(screenshot))
+	name = captureSuffix.ReplaceAllString(name, "")
+	return strings.TrimPrefix(name, appName+"_")
+}
+
+// hashScreenshots computes the perceptual hash of every screenshot of a
+// result; missing screenshots are skipped
+func (e *Executor) hashScreenshots(result *TestResult) []ScreenHash {
+	detector := vision.NewElementDetector(*e.componentLogger())
+	var hashes []ScreenHash
+	for _, s := range result.Screenshots {
+		if _, err := os.Stat(s); err != nil {
+			continue
+		}
+		hash, err := detector.PerceptualHashFile(s)
+		if err != nil {
+			e.logger.Warnf("Failed to hash %s: %v", s, err)
+			continue
+		}
+		hashes = append(hashes, ScreenHash{Screenshot: s, Screen: screenName(result.AppName, s), PHash: hash})
+	}
+	return hashes
+}
+
+// nearDuplicates finds the screenshots of a result that look like one taken
+// earlier in it, so the report can skip them
+func nearDuplicates(result *TestResult) map[string]bool {
+	duplicates := make(map[string]bool)
+	var shown []vision.PHash
+	for _, h := range result.ScreenHashes {
+		duplicate := false
+		for _, earlier := range shown {
+			if h.PHash.Distance(earlier) <= vision.NearDuplicateDistance {
+				duplicate = true
+				break
+			}
+		}
+		if duplicate {
+			duplicates[h.Screenshot] = true
+		} else {
+			shown = append(shown, h.PHash)
+		}
+	}
+	return duplicates
+}
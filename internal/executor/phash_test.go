@@ -0,0 +1,63 @@
+package executor
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"panoptic/internal/config"
+	"panoptic/internal/logger"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writeScreen saves a white screenshot with a header, a line of text and a
+// dark panel
+func writeScreen(t *testing.T, dir, name string, panel image.Rectangle) string {
+	img := image.NewRGBA(image.Rect(0, 0, 160, 120))
+	draw.Draw(img, img.Bounds(), &image.Uniform{color.White}, image.Point{}, draw.Src)
+	draw.Draw(img, image.Rect(0, 0, 160, 16), &image.Uniform{color.RGBA{30, 60, 120, 255}}, image.Point{}, draw.Src)
+	for x := 10; x < 90; x += 4 {
+		draw.Draw(img, image.Rect(x, 24, x+2, 32), &image.Uniform{color.Black}, image.Point{}, draw.Src)
+	}
+	draw.Draw(img, panel, &image.Uniform{color.RGBA{200, 40, 40, 255}}, image.Point{}, draw.Src)
+	path := filepath.Join(dir, name)
+	f, err := os.Create(path)
+	require.NoError(t, err)
+	defer f.Close()
+	require.NoError(t, png.Encode(f, img))
+	return path
+}
+
+// TestScreenName tests naming screens the same in every run
+func TestScreenName(t *testing.T) {
+	assert.Equal(t, "home", screenName("Shop", "out/screenshots/Shop_home_1767225600.png"))
+	assert.Equal(t, "checkout_step_2", screenName("Shop [firefox]", "Shop [firefox]_checkout_step_2_1767225600.png"))
+	assert.Equal(t, "custom", screenName("Shop", "custom.png"))
+}
+
+// TestExecutor_HashScreenshots tests hashing screenshots and finding
+// near-duplicates within a result
+func TestExecutor_HashScreenshots(t *testing.T) {
+	dir := t.TempDir()
+	executor := NewExecutor(&config.Config{}, t.TempDir(), logger.NewLogger(false))
+	result := &TestResult{AppName: "Shop", Screenshots: []string{
+		writeScreen(t, dir, "Shop_home_1.png", image.Rect(10, 60, 70, 80)),
+		writeScreen(t, dir, "Shop_home_again_2.png", image.Rect(10, 60, 71, 80)),
+		writeScreen(t, dir, "Shop_cart_3.png", image.Rect(40, 50, 150, 110)),
+		filepath.Join(dir, "Shop_missing_4.png"),
+	}}
+
+	hashes := executor.hashScreenshots(result)
+	require.Len(t, hashes, 3, "the missing screenshot is skipped")
+	assert.Equal(t, ScreenHash{Screenshot: result.Screenshots[0], Screen: "home", PHash: hashes[0].PHash}, hashes[0])
+	assert.Equal(t, "cart", hashes[2].Screen)
+
+	result.ScreenHashes = hashes
+	assert.Equal(t, map[string]bool{result.Screenshots[1]: true}, nearDuplicates(result))
+}
@@ -0,0 +1,99 @@
+package executor
+
+import (
+	"fmt"
+
+	"panoptic/internal/config"
+)
+
+// RunSummary counts app outcomes. Quarantined failures and failures whose
+// category has warning severity are counted apart from Failed and never fail
+// the run.
+type RunSummary struct {
+	Total       int `json:"total"`
+	Passed      int `json:"passed"`
+	Failed      int `json:"failed"`
+	Warnings    int `json:"warnings"`
+	Quarantined int `json:"quarantined"`
+}
+
+// FailurePercent is the share of apps that failed, in percent
+func (s RunSummary) FailurePercent() float64 {
+	if s.Total == 0 {
+		return 0
+	}
+	return float64(s.Failed) * 100 / float64(s.Total)
+}
+
+// Summary counts the outcomes of the apps run so far
+func (e *Executor) Summary() RunSummary {
+	return summarize(e.results)
+}
+
+// summarize counts the outcomes of results
+func summarize(results []TestResult) RunSummary {
+	summary := RunSummary{Total: len(results)}
+	for _, r := range results {
+		switch {
+		case r.Success:
+			summary.Passed++
+		case r.Quarantined:
+			summary.Quarantined++
+		case r.Severity == config.SeverityWarning:
+			summary.Warnings++
+		default:
+			summary.Failed++
+		}
+	}
+	return summary
+}
+
+// CheckFailurePolicy returns an error when the run's failures should fail the
+// process exit code under settings.failure_policy
+func (e *Executor) CheckFailurePolicy() error {
+	warning, err := e.policyVerdict()
+	if warning != "" {
+		e.logger.Warn(warning)
+	}
+	return err
+}
+
+// policyVerdict applies settings.failure_policy to the summary, returning a
+// warning when failures were tolerated and an error when they fail the run
+func (e *Executor) policyVerdict() (warning string, err error) {
+	summary := e.Summary()
+	if summary.Failed == 0 {
+		return "", nil
+	}
+	policy := e.config.Settings.FailurePolicy
+	mode := config.FailOnAny
+	if policy != nil && policy.Mode != "" {
+		mode = policy.Mode
+	}
+
+	switch mode {
+	case config.FailNever:
+		return fmt.Sprintf("%d of %d app(s) failed; failure_policy mode never keeps the run green", summary.Failed, summary.Total), nil
+	case config.FailOnThreshold:
+		if summary.FailurePercent() <= policy.MaxFailurePercent {
+			return fmt.Sprintf("%d of %d app(s) failed (%.1f%%), within the %.1f%% failure threshold",
+				summary.Failed, summary.Total, summary.FailurePercent(), policy.MaxFailurePercent), nil
+		}
+		return "", fmt.Errorf("%d of %d app(s) failed (%.1f%%), above the %.1f%% failure threshold",
+			summary.Failed, summary.Total, summary.FailurePercent(), policy.MaxFailurePercent)
+	default:
+		return "", fmt.Errorf("%d of %d app(s) failed", summary.Failed, summary.Total)
+	}
+}
+
+// applyFailurePolicy sets the severity of a failed result from its category;
+// uncategorized failures are functional
+func (e *Executor) applyFailurePolicy(result *TestResult) {
+	if result.Success {
+		return
+	}
+	if result.FailureCategory == "" {
+		result.FailureCategory = config.CategoryFunctional
+	}
+	result.Severity = e.config.Settings.FailurePolicy.SeverityOf(result.FailureCategory)
+}
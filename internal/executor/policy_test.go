@@ -0,0 +1,80 @@
+package executor
+
+import (
+	"testing"
+
+	"panoptic/internal/config"
+	"panoptic/internal/logger"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func policyExecutor(t *testing.T, policy *config.FailurePolicy, results ...TestResult) *Executor {
+	executor := NewExecutor(&config.Config{Settings: config.Settings{FailurePolicy: policy}}, t.TempDir(), logger.NewLogger(false))
+	for i := range results {
+		executor.applyFailurePolicy(&results[i])
+	}
+	executor.results = results
+	return executor
+}
+
+// TestExecutor_CheckFailurePolicy tests the any, threshold and never modes
+func TestExecutor_CheckFailurePolicy(t *testing.T) {
+	results := []TestResult{
+		{AppName: "a", Success: true}, {AppName: "b", Success: true}, {AppName: "c", Success: true},
+		{AppName: "d", Success: false, FailureCategory: config.CategoryFunctional},
+	}
+
+	assert.NoError(t, policyExecutor(t, nil, results[:3]...).CheckFailurePolicy())
+	assert.EqualError(t, policyExecutor(t, nil, results...).CheckFailurePolicy(), "1 of 4 app(s) failed")
+
+	threshold := &config.FailurePolicy{Mode: config.FailOnThreshold, MaxFailurePercent: 25}
+	assert.NoError(t, policyExecutor(t, threshold, results...).CheckFailurePolicy(), "25% is within the threshold")
+	threshold.MaxFailurePercent = 20
+	assert.ErrorContains(t, policyExecutor(t, threshold, results...).CheckFailurePolicy(), "above the 20.0% failure threshold")
+
+	assert.NoError(t, policyExecutor(t, &config.FailurePolicy{Mode: config.FailNever}, results...).CheckFailurePolicy())
+}
+
+// TestExecutor_FailureSeverities tests that warning categories don't fail the run
+func TestExecutor_FailureSeverities(t *testing.T) {
+	policy := &config.FailurePolicy{Severities: map[string]string{"accessibility": config.SeverityWarning}}
+	executor := policyExecutor(t, policy,
+		TestResult{AppName: "a11y", FailureCategory: "accessibility"},
+		TestResult{AppName: "flaky", Quarantined: true},
+		TestResult{AppName: "ok", Success: true},
+	)
+	assert.Equal(t, config.SeverityWarning, executor.results[0].Severity)
+	assert.Equal(t, RunSummary{Total: 3, Passed: 1, Warnings: 1, Quarantined: 1}, executor.Summary())
+	assert.NoError(t, executor.CheckFailurePolicy())
+
+	executor = policyExecutor(t, policy, TestResult{AppName: "broken"})
+	assert.Equal(t, config.CategoryFunctional, executor.results[0].FailureCategory, "uncategorized failures are functional")
+	assert.Equal(t, config.SeverityError, executor.results[0].Severity)
+	assert.Error(t, executor.CheckFailurePolicy())
+}
+
+// TestExecutor_FailureCategories tests the categories recorded by executeApp
+func TestExecutor_FailureCategories(t *testing.T) {
+	driver := scriptDriver(t, "")
+	cfg := &config.Config{
+		Apps: []config.AppConfig{
+			{Name: "Audit", Type: "driver", Driver: driver, Actions: []config.Action{{Name: "go", Type: "navigate", Value: "app://home", Category: "accessibility"}}},
+			{Name: "Nav", Type: "driver", Driver: driver, Actions: []config.Action{{Name: "go", Type: "navigate", Value: "app://home"}}},
+		},
+		Settings: config.Settings{FailurePolicy: &config.FailurePolicy{Severities: map[string]string{"accessibility": config.SeverityWarning}}},
+	}
+	executor := NewExecutor(cfg, t.TempDir(), logger.NewLogger(false))
+	require.NoError(t, executor.Run())
+	require.Len(t, executor.results, 2)
+
+	assert.Equal(t, "accessibility", executor.results[0].FailureCategory)
+	assert.Equal(t, config.SeverityWarning, executor.results[0].Severity)
+	assert.Equal(t, config.CategoryFunctional, executor.results[1].FailureCategory)
+	assert.EqualError(t, executor.CheckFailurePolicy(), "1 of 2 app(s) failed")
+
+	unknown := NewExecutor(&config.Config{}, t.TempDir(), logger.NewLogger(false))
+	result := unknown.executeApp(config.AppConfig{Name: "x", Type: "toaster"})
+	assert.Equal(t, config.CategoryInfrastructure, result.FailureCategory)
+}
@@ -0,0 +1,123 @@
+package executor
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"panoptic/internal/config"
+	"panoptic/internal/enterprise"
+)
+
+// projectRun is the enterprise project a run is attributed to
+type projectRun struct {
+	ID        string
+	Name      string
+	User      string // settings.enterprise.user, who starts the run
+	Retention int    // test_retention of the project, in days
+}
+
+// SetProject attributes the run to an enterprise project, by ID or name; it
+// overrides settings.enterprise.project. Must be called before Run.
+func (e *Executor) SetProject(project string) {
+	e.projectRef = project
+}
+
+// ProjectID is the enterprise project the run is attributed to, empty when
+// it isn't
+func (e *Executor) ProjectID() string {
+	if e.project == nil {
+		return ""
+	}
+	return e.project.ID
+}
+
+// startProject attributes the run to its enterprise project before any app
+// runs. The project must be active, open to settings.enterprise.user and
+// within its max_test_runs, or the run doesn't start.
+func (e *Executor) startProject(ctx context.Context) error {
+	ref := e.projectRef
+	if ref == "" {
+		ref = getStringFromMap(e.config.Settings.Enterprise, "project")
+	}
+	if ref == "" {
+		return nil
+	}
+	integration := e.getEnterpriseIntegration()
+	if integration == nil || !integration.Initialized {
+		return fmt.Errorf("project %s needs enterprise management enabled in settings.enterprise", ref)
+	}
+	user := getStringFromMap(e.config.Settings.Enterprise, "user")
+	project, err := integration.RunManagement.StartRun(ctx, enterprise.StartRunRequest{Project: ref, User: user})
+	if err != nil {
+		return fmt.Errorf("run refused for project %s: %w", ref, err)
+	}
+	e.project = &projectRun{ID: project.ID, Name: project.Name, User: user, Retention: project.Settings.TestRetention}
+	e.logger.Infof("Run attributed to project %s (%s)", project.Name, project.ID)
+	return nil
+}
+
+// recordProjectRun adds the finished run to the runs of its project, which
+// count against its max_test_runs
+func (e *Executor) recordProjectRun() {
+	integration := e.getEnterpriseIntegration()
+	summary := e.Summary()
+	_, policyErr := e.policyVerdict()
+	record := enterprise.RunRecord{
+		ID:         e.runID,
+		ProjectID:  e.project.ID,
+		Config:     e.config.Name,
+		StartedAt:  e.startedAt,
+		FinishedAt: time.Now(),
+		Total:      summary.Total,
+		Passed:     summary.Passed,
+		Failed:     summary.Failed,
+		Success:    policyErr == nil,
+	}
+	if e.project.User != "" {
+		if user, err := integration.UserManagement.GetUser(context.Background(), e.project.User); err == nil {
+			record.UserID = user.ID
+		}
+	}
+	if err := integration.RunManagement.RecordRun(context.Background(), record); err != nil {
+		e.logger.Warnf("Failed to record the run for project %s: %v", e.project.Name, err)
+	}
+}
+
+// retentionSettings is settings.retention, bounded by the project's
+// test_retention when the run has a project, and by the enterprise
+// compliance data_retention
+func (e *Executor) retentionSettings() *config.RetentionSettings {
+	settings := e.config.Settings.Retention
+	days := 0
+	if e.project != nil {
+		days = e.project.Retention
+	}
+	if limit := e.dataRetention(); limit > 0 && (days <= 0 || limit < days) {
+		days = limit
+	}
+	if days <= 0 {
+		return settings
+	}
+	bounded := config.RetentionSettings{}
+	if settings != nil {
+		bounded = *settings
+	}
+	if bounded.MaxAgeDays == 0 || bounded.MaxAgeDays > days {
+		bounded.MaxAgeDays = days
+	}
+	return &bounded
+}
+
+// dataRetention is the compliance data_retention of the enterprise
+// configuration, in days; 0 without one
+func (e *Executor) dataRetention() int {
+	if e.config.Settings.Enterprise == nil {
+		return 0
+	}
+	integration := e.getEnterpriseIntegration()
+	if integration == nil || !integration.Initialized {
+		return 0
+	}
+	return integration.Manager.Config.Compliance.DataRetention
+}
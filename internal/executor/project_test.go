@@ -0,0 +1,93 @@
+package executor
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"panoptic/internal/config"
+	"panoptic/internal/enterprise"
+	"panoptic/internal/logger"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// projectConfig is a run of the checkout project of an enterprise storage
+// holding it, allowed one run kept for 7 days
+func projectConfig(t *testing.T) *config.Config {
+	t.Helper()
+	dir := t.TempDir()
+	storage := filepath.Join(dir, "data")
+	require.NoError(t, os.MkdirAll(storage, 0755))
+	projects, err := json.Marshal(map[string]*enterprise.Project{"p-checkout": {
+		ID: "p-checkout", Name: "checkout", Status: "active",
+		Settings: enterprise.ProjectSettings{MaxTestRuns: 1, TestRetention: 7},
+	}})
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(filepath.Join(storage, "projects.json"), projects, 0644))
+	path := filepath.Join(dir, "enterprise.yaml")
+	require.NoError(t, os.WriteFile(path, []byte("enabled: true\norganization_name: Acme\nstorage_path: "+storage+"\n"), 0644))
+
+	return &config.Config{Name: "Shop", Settings: config.Settings{
+		Enterprise: map[string]interface{}{"config_path": path, "project": "checkout", "user": "admin"},
+	}}
+}
+
+func TestExecutor_StartProject(t *testing.T) {
+	cfg := projectConfig(t)
+	executor := NewExecutor(cfg, t.TempDir(), logger.NewLogger(false))
+	require.NoError(t, executor.startProject(context.Background()))
+	executor.recordProjectRun()
+	assert.Equal(t, "p-checkout", executor.ProjectID())
+	assert.Equal(t, "p-checkout", executor.historyRecord().ProjectID)
+
+	runs := executor.getEnterpriseIntegration().Manager.Runs
+	require.Len(t, runs, 1)
+	assert.Equal(t, executor.runID, runs[0].ID)
+	assert.Equal(t, "Shop", runs[0].Config)
+	assert.NotEmpty(t, runs[0].UserID, "the run is attributed to settings.enterprise.user")
+
+	err := NewExecutor(cfg, t.TempDir(), logger.NewLogger(false)).startProject(context.Background())
+	assert.ErrorContains(t, err, "run refused for project checkout: project checkout has used its quota of 1 test run(s)")
+
+	executor = NewExecutor(cfg, t.TempDir(), logger.NewLogger(false))
+	executor.SetProject("billing")
+	assert.EqualError(t, executor.startProject(context.Background()), "run refused for project billing: project not found: billing")
+}
+
+func TestExecutor_StartProject_WithoutEnterprise(t *testing.T) {
+	executor := NewExecutor(&config.Config{}, t.TempDir(), logger.NewLogger(false))
+	executor.SetProject("checkout")
+	assert.EqualError(t, executor.startProject(context.Background()), "project checkout needs enterprise management enabled in settings.enterprise")
+	assert.Empty(t, executor.ProjectID())
+}
+
+func TestExecutor_RetentionSettings(t *testing.T) {
+	cfg := &config.Config{Settings: config.Settings{Retention: &config.RetentionSettings{MaxRuns: 5, MaxAgeDays: 30}}}
+	executor := NewExecutor(cfg, t.TempDir(), logger.NewLogger(false))
+	assert.Same(t, cfg.Settings.Retention, executor.retentionSettings())
+
+	executor.project = &projectRun{ID: "p-checkout", Retention: 7}
+	assert.Equal(t, &config.RetentionSettings{MaxRuns: 5, MaxAgeDays: 7}, executor.retentionSettings())
+	executor.project.Retention = 90
+	assert.Equal(t, 30, executor.retentionSettings().MaxAgeDays, "the stricter of the two applies")
+
+	cfg.Settings.Retention = nil
+	executor.project.Retention = 7
+	assert.Equal(t, &config.RetentionSettings{MaxAgeDays: 7}, executor.retentionSettings())
+}
+
+func TestExecutor_RetentionSettings_DataRetention(t *testing.T) {
+	cfg := projectConfig(t)
+	path := cfg.Settings.Enterprise["config_path"].(string)
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(path, append(data, "compliance:\n  data_retention: 3\n"...), 0644))
+
+	executor := NewExecutor(cfg, t.TempDir(), logger.NewLogger(false))
+	require.NoError(t, executor.startProject(context.Background()))
+	assert.Equal(t, &config.RetentionSettings{MaxAgeDays: 3}, executor.retentionSettings(), "data_retention is stricter than the project's 7 days")
+}
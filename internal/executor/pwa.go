@@ -0,0 +1,273 @@
+package executor
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"panoptic/internal/config"
+	"panoptic/internal/platforms"
+)
+
+// pwaInspector is implemented by platforms that control the service
+// workers, caches and installability of their pages
+type pwaInspector interface {
+	ServiceWorkers() ([]platforms.ServiceWorker, error)
+	UnregisterServiceWorkers(scope string, clearCaches bool) (int, error)
+	UpdateServiceWorkers(scope string) (int, error)
+	CacheStorage() ([]platforms.Cache, error)
+	Installability() (*platforms.Installability, error)
+	CurrentURL() (string, error)
+	PageText() (string, error)
+	HasElement(selector string) (bool, error)
+}
+
+// CacheSummary is a Cache Storage cache and how many requests it holds
+type CacheSummary struct {
+	Name    string `json:"name"`
+	Entries int    `json:"entries"`
+}
+
+// PWARecord is recorded in the pwa metric for every service worker, cache,
+// installability and offline action, with what it found
+type PWARecord struct {
+	Action         string                    `json:"action"`
+	Type           string                    `json:"type"`
+	Changed        int                       `json:"changed,omitempty"` // service workers unregistered or updated
+	ServiceWorkers []platforms.ServiceWorker `json:"service_workers,omitempty"`
+	Caches         []CacheSummary            `json:"caches,omitempty"`
+	Installability *platforms.Installability `json:"installability,omitempty"`
+	URL            string                    `json:"url,omitempty"` // loaded offline
+}
+
+// runPWAAction runs the service worker, cache, installability and offline
+// fallback actions, recording each in the pwa metric
+func (e *Executor) runPWAAction(platform platforms.Platform, action config.Action, result *TestResult) error {
+	inspector, ok := platform.(pwaInspector)
+	if !ok {
+		return fmt.Errorf("%s is not supported on this platform", action.Type)
+	}
+	record := PWARecord{Action: action.Name, Type: action.Type}
+	var err error
+	switch action.Type {
+	case "unregister_service_worker", "update_service_worker":
+		err = e.controlServiceWorkers(inspector, action, &record)
+	case "assert_service_worker":
+		err = e.assertServiceWorker(inspector, action, &record)
+	case "assert_cache":
+		err = e.assertCache(inspector, action, &record)
+	case "assert_installable":
+		err = e.assertInstallable(inspector, action, &record)
+	case "assert_offline_fallback":
+		err = e.assertOfflineFallback(platform, inspector, action, &record)
+	}
+	if result.Metrics == nil {
+		result.Metrics = make(map[string]interface{})
+	}
+	records, _ := result.Metrics["pwa"].([]PWARecord)
+	result.Metrics["pwa"] = append(records, record)
+	return err
+}
+
+// controlServiceWorkers unregisters or updates the service workers of the
+// action's scope
+func (e *Executor) controlServiceWorkers(inspector pwaInspector, action config.Action, record *PWARecord) error {
+	control, err := action.ServiceWorkerControl()
+	if err != nil {
+		return err
+	}
+	if action.Type == "unregister_service_worker" {
+		record.Changed, err = inspector.UnregisterServiceWorkers(control.Scope, control.ClearCaches)
+		if err == nil {
+			e.logger.Infof("Unregistered %d service worker(s)", record.Changed)
+		}
+		return err
+	}
+	record.Changed, err = inspector.UpdateServiceWorkers(control.Scope)
+	if err == nil {
+		e.logger.Infof("Updated %d service worker(s)", record.Changed)
+	}
+	return err
+}
+
+// assertServiceWorker waits for a service worker of the action's scope and
+// script to reach its state or, when none may be registered, for every one
+// to be gone
+func (e *Executor) assertServiceWorker(inspector pwaInspector, action config.Action, record *PWARecord) error {
+	check, err := action.ServiceWorkerCheck()
+	if err != nil {
+		return err
+	}
+	deadline := time.Now().Add(check.Timeout)
+	for {
+		workers, err := inspector.ServiceWorkers()
+		if err != nil {
+			return err
+		}
+		var matching []platforms.ServiceWorker
+		for _, w := range workers {
+			if strings.Contains(w.Scope, check.Scope) && strings.Contains(w.ScriptURL, check.Script) {
+				matching = append(matching, w)
+			}
+		}
+		record.ServiceWorkers = matching
+		if !check.ExpectsRegistered() {
+			if len(matching) == 0 {
+				e.logger.Infof("No service worker is registered, as expected")
+				return nil
+			}
+		} else {
+			for _, w := range matching {
+				if check.Reached(w.State) {
+					e.logger.Infof("Service worker %s is %s", w.ScriptURL, w.State)
+					return nil
+				}
+			}
+		}
+		if time.Now().After(deadline) {
+			switch {
+			case !check.ExpectsRegistered():
+				return fmt.Errorf("service worker %s is still registered for %s", matching[0].ScriptURL, matching[0].Scope)
+			case len(matching) == 0:
+				return fmt.Errorf("no service worker registered within %s", check.Timeout)
+			}
+			return fmt.Errorf("service worker %s is %s, expected %s", matching[0].ScriptURL, matching[0].State, check.State)
+		}
+		time.Sleep(200 * time.Millisecond)
+	}
+}
+
+// assertCache checks the Cache Storage caches of the action's name hold
+// enough requests, and the ones it lists
+func (e *Executor) assertCache(inspector pwaInspector, action config.Action, record *PWARecord) error {
+	check, err := action.CacheCheck()
+	if err != nil {
+		return err
+	}
+	caches, err := inspector.CacheStorage()
+	if err != nil {
+		return err
+	}
+	var urls []string
+	for _, c := range caches {
+		if strings.Contains(c.Name, check.Cache) {
+			record.Caches = append(record.Caches, CacheSummary{Name: c.Name, Entries: len(c.URLs)})
+			urls = append(urls, c.URLs...)
+		}
+	}
+	switch {
+	case len(record.Caches) == 0 && check.Cache != "":
+		return fmt.Errorf("no cache is named like %q", check.Cache)
+	case len(record.Caches) == 0:
+		return fmt.Errorf("cache storage is empty")
+	case len(urls) < check.MinEntries:
+		return fmt.Errorf("caches hold %d request(s), expected at least %d", len(urls), check.MinEntries)
+	}
+	for _, want := range check.Contains {
+		found := false
+		for _, u := range urls {
+			if strings.Contains(u, want) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return fmt.Errorf("no cached request matches %q", want)
+		}
+	}
+	e.logger.Infof("%d cache(s) hold %d request(s)", len(record.Caches), len(urls))
+	return nil
+}
+
+// assertInstallable fails on the installability errors the action doesn't
+// ignore
+func (e *Executor) assertInstallable(inspector pwaInspector, action config.Action, record *PWARecord) error {
+	check, err := action.InstallabilityCheck()
+	if err != nil {
+		return err
+	}
+	install, err := inspector.Installability()
+	if err != nil {
+		return err
+	}
+	record.Installability = install
+	var failed []string
+	for _, msg := range install.Errors {
+		ignored := false
+		for _, id := range check.Ignore {
+			if msg == id || strings.HasPrefix(msg, id+" (") {
+				ignored = true
+				break
+			}
+		}
+		if !ignored {
+			failed = append(failed, msg)
+		}
+	}
+	if len(failed) > 0 {
+		return fmt.Errorf("not installable: %s", strings.Join(failed, "; "))
+	}
+	if check.Name != "" && !strings.Contains(install.Name, check.Name) {
+		return fmt.Errorf("manifest name %q doesn't contain %q", install.Name, check.Name)
+	}
+	e.logger.Infof("The page is installable as %q", install.Name)
+	return nil
+}
+
+// assertOfflineFallback loads the action's url, or reloads the page, with
+// the network offline and checks what it shows, then restores the network
+// conditions
+func (e *Executor) assertOfflineFallback(platform platforms.Platform, inspector pwaInspector, action config.Action, record *PWARecord) (err error) {
+	fallback, err := action.OfflineFallback()
+	if err != nil {
+		return err
+	}
+	emulator, ok := platform.(networkEmulator)
+	if !ok {
+		return fmt.Errorf("assert_offline_fallback is not supported on this platform")
+	}
+	url := fallback.URL
+	if url == "" {
+		if url, err = inspector.CurrentURL(); err != nil {
+			return err
+		}
+	}
+	record.URL = url
+
+	offline := e.network
+	offline.Offline = true
+	if err := emulator.EmulateNetwork(offline); err != nil {
+		return err
+	}
+	defer func() {
+		if restoreErr := emulator.EmulateNetwork(e.network); restoreErr != nil && err == nil {
+			err = fmt.Errorf("failed to restore network conditions: %w", restoreErr)
+		}
+	}()
+
+	if err := platform.Navigate(url); err != nil {
+		return fmt.Errorf("offline, %s didn't load: %w", url, err)
+	}
+	if fallback.Selector != "" {
+		found, err := inspector.HasElement(fallback.Selector)
+		if err != nil {
+			return err
+		}
+		if !found {
+			return fmt.Errorf("offline, %s doesn't show %s", url, fallback.Selector)
+		}
+	}
+	if len(fallback.Contains) > 0 {
+		text, err := inspector.PageText()
+		if err != nil {
+			return err
+		}
+		for _, want := range fallback.Contains {
+			if !strings.Contains(text, want) {
+				return fmt.Errorf("offline, %s doesn't contain %q", url, want)
+			}
+		}
+	}
+	e.logger.Infof("Offline, %s shows its fallback page", url)
+	return nil
+}
@@ -0,0 +1,142 @@
+package executor
+
+import (
+	"testing"
+
+	"panoptic/internal/config"
+	"panoptic/internal/logger"
+	"panoptic/internal/platforms"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// pwaPlatform is a mock platform with a news PWA: a service worker, its
+// caches and an offline page served while the network is offline
+type pwaPlatform struct {
+	*MockPlatform
+	workers  []platforms.ServiceWorker
+	caches   []platforms.Cache
+	install  platforms.Installability
+	offline  bool
+	network  []config.NetworkConditions
+	text     string
+	selector string
+}
+
+func (p *pwaPlatform) ServiceWorkers() ([]platforms.ServiceWorker, error) { return p.workers, nil }
+
+func (p *pwaPlatform) UnregisterServiceWorkers(scope string, clearCaches bool) (int, error) {
+	n := len(p.workers)
+	p.workers = nil
+	if clearCaches {
+		p.caches = nil
+	}
+	return n, nil
+}
+
+func (p *pwaPlatform) UpdateServiceWorkers(scope string) (int, error) { return len(p.workers), nil }
+
+func (p *pwaPlatform) CacheStorage() ([]platforms.Cache, error) { return p.caches, nil }
+
+func (p *pwaPlatform) Installability() (*platforms.Installability, error) {
+	install := p.install
+	return &install, nil
+}
+
+func (p *pwaPlatform) CurrentURL() (string, error) { return "https://news.test/today", nil }
+
+func (p *pwaPlatform) Navigate(url string) error {
+	p.text, p.selector = "Today's news", "#articles"
+	if p.offline {
+		p.text, p.selector = "You're offline. Showing saved articles.", "#offline"
+	}
+	return nil
+}
+
+func (p *pwaPlatform) PageText() (string, error) { return p.text, nil }
+
+func (p *pwaPlatform) HasElement(selector string) (bool, error) { return selector == p.selector, nil }
+
+func (p *pwaPlatform) EmulateNetwork(conditions config.NetworkConditions) error {
+	p.offline = conditions.Offline
+	p.network = append(p.network, conditions)
+	return nil
+}
+
+func TestExecutor_RunPWAAction(t *testing.T) {
+	executor := NewExecutor(&config.Config{}, t.TempDir(), logger.NewLogger(false))
+	platform := &pwaPlatform{
+		MockPlatform: &MockPlatform{metrics: map[string]interface{}{}},
+		workers:      []platforms.ServiceWorker{{Scope: "https://news.test/", ScriptURL: "https://news.test/sw.js", State: "activated"}},
+		caches: []platforms.Cache{
+			{Name: "static-v3", URLs: []string{"https://news.test/", "https://news.test/app.js", "https://news.test/offline.html"}},
+			{Name: "images", URLs: []string{"https://news.test/logo.png"}},
+		},
+		install: platforms.Installability{ManifestURL: "https://news.test/manifest.json", Name: "News", Errors: []string{"manifest-missing-suitable-icon (minimum-icon-size-in-pixels=144)"}},
+	}
+	app := config.AppConfig{Name: "News", Type: "web"}
+	result := &TestResult{Metrics: map[string]interface{}{}}
+	run := func(action config.Action) error {
+		action.Name = action.Type
+		return executor.executeAction(platform, action, app, result, nil)
+	}
+
+	require.NoError(t, run(config.Action{Type: "assert_service_worker", Parameters: map[string]interface{}{"script": "sw.js"}}))
+	require.NoError(t, run(config.Action{Type: "update_service_worker"}))
+	require.NoError(t, run(config.Action{Type: "assert_cache", Parameters: map[string]interface{}{
+		"cache": "static", "contains": []interface{}{"offline.html"}, "min_entries": 3,
+	}}))
+	err := run(config.Action{Type: "assert_cache", Parameters: map[string]interface{}{"contains": []interface{}{"fonts.woff2"}}})
+	assert.EqualError(t, err, `no cached request matches "fonts.woff2"`)
+	err = run(config.Action{Type: "assert_cache", Parameters: map[string]interface{}{"cache": "api"}})
+	assert.EqualError(t, err, `no cache is named like "api"`)
+
+	err = run(config.Action{Type: "assert_installable"})
+	assert.EqualError(t, err, "not installable: manifest-missing-suitable-icon (minimum-icon-size-in-pixels=144)")
+	require.NoError(t, run(config.Action{Type: "assert_installable", Parameters: map[string]interface{}{
+		"ignore": []interface{}{"manifest-missing-suitable-icon"}, "name": "News",
+	}}))
+
+	executor.network = config.NetworkConditions{LatencyMS: 100}
+	require.NoError(t, run(config.Action{Type: "assert_offline_fallback", Selector: "#offline", Parameters: map[string]interface{}{
+		"contains": []interface{}{"You're offline"},
+	}}))
+	require.Len(t, platform.network, 2)
+	assert.Equal(t, config.NetworkConditions{Offline: true, LatencyMS: 100}, platform.network[0])
+	assert.Equal(t, config.NetworkConditions{LatencyMS: 100}, platform.network[1], "the network conditions are restored")
+	assert.False(t, platform.offline)
+	err = run(config.Action{Type: "assert_offline_fallback", URL: "https://news.test/today", Selector: "#articles"})
+	assert.EqualError(t, err, "offline, https://news.test/today doesn't show #articles")
+	assert.False(t, platform.offline)
+
+	require.NoError(t, run(config.Action{Type: "unregister_service_worker", Parameters: map[string]interface{}{"clear_caches": true}}))
+	assert.Empty(t, platform.caches)
+	require.NoError(t, run(config.Action{Type: "assert_service_worker", Parameters: map[string]interface{}{"registered": false}}))
+	err = run(config.Action{Type: "assert_service_worker", Parameters: map[string]interface{}{"timeout": "1ms"}})
+	assert.EqualError(t, err, "no service worker registered within 1ms")
+	err = run(config.Action{Type: "assert_cache"})
+	assert.EqualError(t, err, "cache storage is empty")
+
+	records := result.Metrics["pwa"].([]PWARecord)
+	require.Len(t, records, 13)
+	assert.Equal(t, []CacheSummary{{Name: "static-v3", Entries: 3}}, records[2].Caches)
+	assert.Equal(t, 1, records[1].Changed)
+	assert.Equal(t, "https://news.test/today", records[7].URL)
+
+	err = executor.runPWAAction(&MockPlatform{metrics: map[string]interface{}{}}, config.Action{Name: "sw", Type: "assert_cache"}, result)
+	assert.EqualError(t, err, "assert_cache is not supported on this platform")
+}
+
+func TestExecutor_AssertServiceWorker_State(t *testing.T) {
+	executor := NewExecutor(&config.Config{}, t.TempDir(), logger.NewLogger(false))
+	platform := &pwaPlatform{
+		MockPlatform: &MockPlatform{metrics: map[string]interface{}{}},
+		workers:      []platforms.ServiceWorker{{Scope: "https://news.test/", ScriptURL: "https://news.test/sw.js", State: "installing"}},
+	}
+	result := &TestResult{Metrics: map[string]interface{}{}}
+	err := executor.runPWAAction(platform, config.Action{Name: "sw", Type: "assert_service_worker", Parameters: map[string]interface{}{"timeout": "1ms"}}, result)
+	assert.EqualError(t, err, "service worker https://news.test/sw.js is installing, expected activated")
+	err = executor.runPWAAction(platform, config.Action{Name: "gone", Type: "assert_service_worker", Parameters: map[string]interface{}{"registered": false, "timeout": "1ms"}}, result)
+	assert.EqualError(t, err, "service worker https://news.test/sw.js is still registered for https://news.test/")
+}
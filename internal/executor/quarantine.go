@@ -0,0 +1,42 @@
+package executor
+
+import (
+	"time"
+
+	"panoptic/internal/config"
+)
+
+// applyQuarantine marks a failed result as quarantined when the app, or the
+// action that failed, is under an active quarantine. Lapsed quarantines are
+// logged and recorded so they get renewed or removed.
+func (e *Executor) applyQuarantine(app config.AppConfig, result *TestResult) {
+	quarantine := app.Quarantine
+	if failed, ok := result.Metrics["failed_action"].(string); ok {
+		actions, _ := e.config.SelectActions(app, e.tagFilter)
+		for _, action := range actions {
+			if action.Name == failed && action.Quarantine != nil {
+				quarantine = action.Quarantine
+				break
+			}
+		}
+	}
+	if quarantine == nil {
+		return
+	}
+
+	if !quarantine.Active(time.Now()) {
+		e.logger.Warnf("Quarantine of %s lapsed after %s; its failures count again", app.Name, quarantine.Until)
+		if result.Metrics == nil {
+			result.Metrics = make(map[string]interface{})
+		}
+		result.Metrics["quarantine_expired"] = quarantine.Until
+		return
+	}
+	if result.Success {
+		e.logger.Infof("Quarantined %s passed; consider lifting its quarantine", app.Name)
+		return
+	}
+	result.Quarantined = true
+	result.QuarantineReason = quarantine.Reason
+	e.logger.Warnf("%s failed under quarantine until %s (%s); not failing the run", app.Name, quarantine.Until, quarantine.Reason)
+}
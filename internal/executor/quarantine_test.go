@@ -0,0 +1,86 @@
+package executor
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"panoptic/internal/config"
+	"panoptic/internal/logger"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestExecutor_Quarantine tests that quarantined failures are reported apart
+func TestExecutor_Quarantine(t *testing.T) {
+	appPath := filepath.Join(t.TempDir(), "app")
+	require.NoError(t, os.WriteFile(appPath, []byte("#!/bin/sh\n"), 0755))
+	tomorrow := time.Now().AddDate(0, 0, 1).Format("2006-01-02")
+	lapsed := time.Now().AddDate(0, 0, -2).Format("2006-01-02")
+
+	// The driver fails every navigate action
+	driver := scriptDriver(t, "")
+	navigate := config.Action{Name: "go", Type: "navigate", Value: "app://home"}
+	quarantinedNavigate := navigate
+	quarantinedNavigate.Quarantine = &config.Quarantine{Reason: "menu flake", Until: tomorrow}
+	cfg := &config.Config{Apps: []config.AppConfig{
+		{Name: "Quarantined", Type: "driver", Driver: driver, Actions: []config.Action{navigate},
+			Quarantine: &config.Quarantine{Reason: "known broken", Until: tomorrow}},
+		{Name: "ActionQuarantined", Type: "driver", Driver: driver, Actions: []config.Action{{Name: "hold", Type: "pause"}, quarantinedNavigate}},
+		{Name: "Lapsed", Type: "driver", Driver: driver, Actions: []config.Action{navigate},
+			Quarantine: &config.Quarantine{Reason: "old", Until: lapsed}},
+		{Name: "Passing", Type: "desktop", Path: appPath, Actions: []config.Action{{Name: "hold", Type: "pause"}},
+			Quarantine: &config.Quarantine{Reason: "fixed", Until: tomorrow}},
+	}}
+	executor := NewExecutor(cfg, t.TempDir(), logger.NewLogger(false))
+	require.NoError(t, executor.Run())
+	require.Len(t, executor.results, 4)
+
+	app := executor.results[0]
+	assert.False(t, app.Success)
+	assert.True(t, app.Quarantined)
+	assert.Equal(t, "known broken", app.QuarantineReason)
+
+	action := executor.results[1]
+	assert.True(t, action.Quarantined)
+	assert.Equal(t, "menu flake", action.QuarantineReason)
+	assert.Equal(t, "go", action.Metrics["failed_action"])
+
+	expired := executor.results[2]
+	assert.False(t, expired.Quarantined, "lapsed quarantines count as failures")
+	assert.Equal(t, lapsed, expired.Metrics["quarantine_expired"])
+
+	assert.True(t, executor.results[3].Success)
+	assert.False(t, executor.results[3].Quarantined)
+
+	assert.Equal(t, RunSummary{Total: 4, Passed: 1, Failed: 1, Quarantined: 2}, executor.Summary())
+
+	data, err := json.Marshal(&app)
+	require.NoError(t, err)
+	var decoded TestResult
+	require.NoError(t, json.Unmarshal(data, &decoded))
+	assert.True(t, decoded.Quarantined)
+	assert.Equal(t, "known broken", decoded.QuarantineReason)
+}
+
+// TestExecutor_Quarantine_OtherActionFails tests that an action quarantine
+// doesn't cover failures of other actions
+func TestExecutor_Quarantine_OtherActionFails(t *testing.T) {
+	tomorrow := time.Now().AddDate(0, 0, 1).Format("2006-01-02")
+	cfg := &config.Config{Actions: []config.Action{
+		{Name: "flaky", Type: "click", Quarantine: &config.Quarantine{Until: tomorrow}},
+		{Name: "broken", Type: "click"},
+	}}
+	executor := NewExecutor(cfg, t.TempDir(), logger.NewLogger(false))
+
+	result := TestResult{Metrics: map[string]interface{}{"failed_action": "broken"}}
+	executor.applyQuarantine(config.AppConfig{Name: "Shop"}, &result)
+	assert.False(t, result.Quarantined)
+
+	result = TestResult{Metrics: map[string]interface{}{"failed_action": "flaky"}}
+	executor.applyQuarantine(config.AppConfig{Name: "Shop"}, &result)
+	assert.True(t, result.Quarantined)
+}
@@ -0,0 +1,159 @@
+package executor
+
+import (
+	"context"
+	"net/url"
+	"sync"
+	"time"
+
+	"panoptic/internal/config"
+)
+
+// rateLimiter applies settings.rate_limit to a run
+type rateLimiter struct {
+	interval    time.Duration
+	hostDelay   time.Duration
+	navigations chan struct{} // a slot per navigation in flight; nil when uncapped
+
+	mu           sync.Mutex
+	nextAction   time.Time            // when the running app's next action may start
+	hostFinished map[string]time.Time // when the last app against each host finished
+}
+
+func newRateLimiter(settings *config.RateLimitSettings) *rateLimiter {
+	r := &rateLimiter{
+		interval:     settings.ActionInterval(),
+		hostDelay:    settings.HostDelay,
+		hostFinished: make(map[string]time.Time),
+	}
+	if settings.MaxNavigations > 0 {
+		r.navigations = make(chan struct{}, settings.MaxNavigations)
+	}
+	return r
+}
+
+// sleep waits for d, or until ctx is done
+func sleep(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return ctx.Err()
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// startApp starts pacing a new app's actions
+func (r *rateLimiter) startApp() {
+	r.mu.Lock()
+	r.nextAction = time.Time{}
+	r.mu.Unlock()
+}
+
+// waitAction waits for the app's next action slot. Slots are handed out in
+// turn, so actions run in parallel groups are paced too.
+func (r *rateLimiter) waitAction(ctx context.Context) error {
+	if r.interval <= 0 {
+		return nil
+	}
+	r.mu.Lock()
+	now := time.Now()
+	at := r.nextAction
+	if at.Before(now) {
+		at = now
+	}
+	r.nextAction = at.Add(r.interval)
+	r.mu.Unlock()
+	return sleep(ctx, at.Sub(now))
+}
+
+// navigate runs a navigation once one of the run's navigation slots is free
+func (r *rateLimiter) navigate(ctx context.Context, fn func() error) error {
+	if r.navigations == nil {
+		return fn()
+	}
+	select {
+	case r.navigations <- struct{}{}:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	defer func() { <-r.navigations }()
+	return fn()
+}
+
+// hostWait is how long the next app against host has to wait for the host
+// delay to pass since the last one finished
+func (r *rateLimiter) hostWait(host string) time.Duration {
+	if r.hostDelay <= 0 || host == "" {
+		return 0
+	}
+	r.mu.Lock()
+	finished, ok := r.hostFinished[host]
+	r.mu.Unlock()
+	if !ok {
+		return 0
+	}
+	return time.Until(finished.Add(r.hostDelay))
+}
+
+// hostDone records that an app against host finished
+func (r *rateLimiter) hostDone(host string) {
+	if host == "" {
+		return
+	}
+	r.mu.Lock()
+	r.hostFinished[host] = time.Now()
+	r.mu.Unlock()
+}
+
+// appHost is the host an app runs against, or "" for apps without a URL
+func appHost(app config.AppConfig) string {
+	if app.URL == "" {
+		return ""
+	}
+	u, err := url.Parse(app.URL)
+	if err != nil {
+		return ""
+	}
+	return u.Hostname()
+}
+
+// waitForHost holds an app back until settings.rate_limit.host_delay has
+// passed since the last app against its host finished
+func (e *Executor) waitForHost(ctx context.Context, app config.AppConfig) {
+	if e.rateLimits == nil {
+		return
+	}
+	host := appHost(app)
+	if wait := e.rateLimits.hostWait(host); wait > 0 {
+		e.logger.Infof("Waiting %s before the next app against %s", wait.Round(time.Millisecond), host)
+		sleep(ctx, wait)
+	}
+}
+
+// hostDone records that an app finished, for settings.rate_limit.host_delay
+func (e *Executor) hostDone(app config.AppConfig) {
+	if e.rateLimits != nil {
+		e.rateLimits.hostDone(appHost(app))
+	}
+}
+
+// throttleAction waits for the next action of the running app to be allowed
+func (e *Executor) throttleAction(ctx context.Context) error {
+	if e.rateLimits == nil {
+		return nil
+	}
+	return e.rateLimits.waitAction(ctx)
+}
+
+// throttleNavigation runs a navigation within settings.rate_limit.max_navigations
+func (e *Executor) throttleNavigation(ctx context.Context, fn func() error) error {
+	if e.rateLimits == nil {
+		return fn()
+	}
+	return e.rateLimits.navigate(ctx, fn)
+}
@@ -0,0 +1,100 @@
+package executor
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"panoptic/internal/config"
+	"panoptic/internal/logger"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRateLimiter_WaitAction(t *testing.T) {
+	r := newRateLimiter(&config.RateLimitSettings{ActionsPerSecond: 20})
+	r.startApp()
+	start := time.Now()
+	for i := 0; i < 5; i++ {
+		require.NoError(t, r.waitAction(context.Background()))
+	}
+	assert.GreaterOrEqual(t, time.Since(start), 200*time.Millisecond, "five actions at 20/s take four intervals")
+
+	// A new app starts without waiting on the last one's pace
+	r.startApp()
+	start = time.Now()
+	require.NoError(t, r.waitAction(context.Background()))
+	assert.Less(t, time.Since(start), 40*time.Millisecond)
+}
+
+func TestRateLimiter_WaitAction_Cancelled(t *testing.T) {
+	r := newRateLimiter(&config.RateLimitSettings{ActionsPerSecond: 0.1})
+	require.NoError(t, r.waitAction(context.Background()))
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	assert.ErrorIs(t, r.waitAction(ctx), context.Canceled)
+}
+
+func TestRateLimiter_Navigate(t *testing.T) {
+	r := newRateLimiter(&config.RateLimitSettings{MaxNavigations: 2})
+	var inFlight, peak atomic.Int32
+	var wg sync.WaitGroup
+	for i := 0; i < 6; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			r.navigate(context.Background(), func() error {
+				n := inFlight.Add(1)
+				for {
+					p := peak.Load()
+					if n <= p || peak.CompareAndSwap(p, n) {
+						break
+					}
+				}
+				time.Sleep(20 * time.Millisecond)
+				inFlight.Add(-1)
+				return nil
+			})
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, int32(2), peak.Load())
+}
+
+func TestRateLimiter_HostWait(t *testing.T) {
+	r := newRateLimiter(&config.RateLimitSettings{HostDelay: time.Minute})
+	assert.Zero(t, r.hostWait("staging.example.com"), "no app ran against the host yet")
+	r.hostDone("staging.example.com")
+	assert.Greater(t, r.hostWait("staging.example.com"), 59*time.Second)
+	assert.Zero(t, r.hostWait("other.example.com"))
+	assert.Zero(t, r.hostWait(""))
+}
+
+func TestAppHost(t *testing.T) {
+	assert.Equal(t, "staging.example.com", appHost(config.AppConfig{URL: "https://staging.example.com:8443/login"}))
+	assert.Empty(t, appHost(config.AppConfig{Type: "desktop", Path: "/usr/bin/app"}))
+}
+
+// TestExecutor_RateLimit_HostDelay tests that apps against the same host are
+// spaced out by the host delay
+func TestExecutor_RateLimit_HostDelay(t *testing.T) {
+	driver := scriptDriver(t, "")
+	cfg := &config.Config{Settings: config.Settings{RateLimit: &config.RateLimitSettings{HostDelay: 300 * time.Millisecond}}}
+	for _, name := range []string{"Login", "Search"} {
+		cfg.Apps = append(cfg.Apps, config.AppConfig{
+			Name: name, Type: "driver", Driver: driver, URL: "https://staging.example.com/" + name,
+			Actions: []config.Action{{Name: "settle", Type: "wait"}},
+		})
+	}
+	executor := NewExecutor(cfg, t.TempDir(), logger.NewLogger(false))
+	require.NoError(t, executor.Run())
+
+	require.Len(t, executor.results, 2)
+	first, second := executor.results[0], executor.results[1]
+	assert.True(t, first.Success, first.Error)
+	assert.GreaterOrEqual(t, second.StartTime.Sub(first.EndTime), 300*time.Millisecond)
+	assert.Nil(t, executor.rateLimits)
+}
@@ -0,0 +1,130 @@
+package executor
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"panoptic/internal/config"
+)
+
+// readinessAttemptTimeout bounds a single probe, so one hanging request can't
+// use up the whole wait
+const readinessAttemptTimeout = 10 * time.Second
+
+// preflight waits for the wait_for checks of all apps at once, before any app
+// runs, and returns the apps that never became ready with the reason
+func (e *Executor) preflight(ctx context.Context, apps []config.AppConfig) map[string]error {
+	notReady := make(map[string]error)
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	seen := make(map[string]bool)
+	for _, app := range apps {
+		if app.WaitFor == nil || seen[app.Name] {
+			continue
+		}
+		seen[app.Name] = true
+		wg.Add(1)
+		go func(app config.AppConfig) {
+			defer wg.Done()
+			start := time.Now()
+			e.logger.Infof("Waiting for %s to be ready (timeout %s)", app.Name, app.WaitFor.TimeoutOrDefault())
+			if err := waitForApp(ctx, app); err != nil {
+				e.logger.Errorf("%s is not ready: %v", app.Name, err)
+				mu.Lock()
+				notReady[app.Name] = err
+				mu.Unlock()
+				return
+			}
+			e.logger.Infof("%s is ready after %s", app.Name, time.Since(start).Round(time.Millisecond))
+		}(app)
+	}
+	wg.Wait()
+	return notReady
+}
+
+// waitForApp polls the app's checks in order until each is ready, all within
+// the wait_for timeout
+func waitForApp(ctx context.Context, app config.AppConfig) error {
+	timeout := app.WaitFor.TimeoutOrDefault()
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	for _, check := range app.WaitFor.ChecksFor(app) {
+		if err := pollReady(ctx, check, app.WaitFor.IntervalOrDefault()); err != nil {
+			return fmt.Errorf("%s not ready after %s: %w", checkTarget(check), timeout, err)
+		}
+	}
+	return nil
+}
+
+func pollReady(ctx context.Context, check config.ReadinessCheck, interval time.Duration) error {
+	var last error
+	for {
+		err := probe(ctx, check)
+		if err == nil {
+			return nil
+		}
+		// An attempt the timeout cut short says less than the one before it
+		if last == nil || ctx.Err() == nil {
+			last = err
+		}
+		select {
+		case <-ctx.Done():
+			return last
+		case <-time.After(interval):
+		}
+	}
+}
+
+// probe makes one attempt at a check
+func probe(ctx context.Context, check config.ReadinessCheck) error {
+	ctx, cancel := context.WithTimeout(ctx, readinessAttemptTimeout)
+	defer cancel()
+
+	if check.TCP != "" {
+		var dialer net.Dialer
+		conn, err := dialer.DialContext(ctx, "tcp", check.TCP)
+		if err != nil {
+			return err
+		}
+		return conn.Close()
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, check.URL, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if check.Status != 0 && resp.StatusCode != check.Status {
+		return fmt.Errorf("status %d, want %d", resp.StatusCode, check.Status)
+	}
+	if check.Status == 0 && resp.StatusCode >= 400 {
+		return fmt.Errorf("status %d", resp.StatusCode)
+	}
+	if check.Contains != "" {
+		body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+		if err != nil {
+			return err
+		}
+		if !strings.Contains(string(body), check.Contains) {
+			return fmt.Errorf("response doesn't contain %q", check.Contains)
+		}
+	}
+	return nil
+}
+
+func checkTarget(check config.ReadinessCheck) string {
+	if check.TCP != "" {
+		return "tcp " + check.TCP
+	}
+	return check.URL
+}
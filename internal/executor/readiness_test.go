@@ -0,0 +1,85 @@
+package executor
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"panoptic/internal/config"
+	"panoptic/internal/logger"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestWaitForApp_HTTP tests polling a health endpoint that comes up late
+func TestWaitForApp_HTTP(t *testing.T) {
+	var hits atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch hits.Add(1) {
+		case 1:
+			w.WriteHeader(http.StatusServiceUnavailable)
+		case 2:
+			w.Write([]byte(`{"status":"STARTING"}`))
+		default:
+			w.Write([]byte(`{"status":"UP"}`))
+		}
+	}))
+	defer server.Close()
+
+	app := config.AppConfig{Name: "Shop", URL: server.URL, WaitFor: &config.WaitFor{
+		Timeout: 5 * time.Second, Interval: 10 * time.Millisecond,
+		Checks: []config.ReadinessCheck{{URL: server.URL + "/health", Contains: `"UP"`}},
+	}}
+	require.NoError(t, waitForApp(context.Background(), app))
+	assert.Equal(t, int32(3), hits.Load())
+
+	app.WaitFor.Checks = []config.ReadinessCheck{{URL: server.URL, Status: http.StatusNoContent}}
+	app.WaitFor.Timeout = 50 * time.Millisecond
+	assert.ErrorContains(t, waitForApp(context.Background(), app), "status 200, want 204")
+}
+
+// TestWaitForApp_TCP tests port checks
+func TestWaitForApp_TCP(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	addr := listener.Addr().String()
+
+	app := config.AppConfig{Name: "DB", WaitFor: &config.WaitFor{Interval: 10 * time.Millisecond, Checks: []config.ReadinessCheck{{TCP: addr}}}}
+	require.NoError(t, waitForApp(context.Background(), app))
+
+	listener.Close()
+	app.WaitFor.Timeout = 50 * time.Millisecond
+	assert.ErrorContains(t, waitForApp(context.Background(), app), "tcp "+addr+" not ready after 50ms")
+}
+
+// TestExecutor_Preflight tests that apps that never become ready fail as
+// infrastructure without running
+func TestExecutor_Preflight(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer server.Close()
+	up := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer up.Close()
+
+	wait := &config.WaitFor{Timeout: 50 * time.Millisecond, Interval: 10 * time.Millisecond}
+	apps := []config.AppConfig{
+		{Name: "Down", Type: "web", URL: server.URL, WaitFor: wait},
+		{Name: "Up", Type: "web", URL: up.URL, WaitFor: wait},
+		{Name: "Unchecked", Type: "web", URL: server.URL},
+	}
+	executor := NewExecutor(&config.Config{}, t.TempDir(), logger.NewLogger(false))
+	executor.notReady = executor.preflight(context.Background(), apps)
+	require.Len(t, executor.notReady, 1)
+	assert.ErrorContains(t, executor.notReady["Down"], "status 502")
+
+	result := executor.runApp(apps[0])
+	assert.False(t, result.Success)
+	assert.Contains(t, result.Error, "Service not ready: "+server.URL+" not ready after 50ms")
+	assert.Equal(t, config.CategoryInfrastructure, result.FailureCategory)
+}
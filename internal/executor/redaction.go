@@ -0,0 +1,81 @@
+package executor
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"panoptic/internal/config"
+	"panoptic/internal/platforms"
+	"panoptic/internal/redact"
+)
+
+// startRedaction sets up settings.redaction as a run starts: the run's logs
+// are redacted from then on, and so are its traces and results as they're
+// written. Runs reading settings.secrets always redact, so the secrets they
+// fetch never reach what they write.
+func (e *Executor) startRedaction() error {
+	settings := e.config.Settings.Redaction
+	if !settings.Enabled() && e.config.Settings.Secrets == nil {
+		return nil
+	}
+	if e.redactor == nil {
+		var patterns []string
+		var replacement string
+		if settings != nil {
+			patterns, replacement = settings.Patterns, settings.Replacement
+		}
+		redactor, err := redact.New(patterns, replacement)
+		if err != nil {
+			return fmt.Errorf("settings.redaction: %w", err)
+		}
+		e.redactor = redactor
+	}
+	e.logger.SetRedactor(e.redactor.String)
+	if e.stream != nil {
+		e.stream.redactor = e.redactor
+	}
+	return nil
+}
+
+// redactPlatform has a web platform blur the masked selectors in its
+// screenshots and redact its page state
+func (e *Executor) redactPlatform(platform platforms.Platform) {
+	if e.redactor == nil {
+		return
+	}
+	if webPlatform, ok := platform.(*platforms.WebPlatform); ok {
+		var selectors []string
+		if settings := e.config.Settings.Redaction; settings != nil {
+			selectors = settings.Selectors
+		}
+		webPlatform.SetRedaction(selectors, e.redactor)
+	}
+}
+
+// maskFilledValue redacts the value a fill action types into a masked
+// field wherever it shows up later, before the action runs
+func (e *Executor) maskFilledValue(action config.Action) {
+	if e.redactor != nil && action.Type == "fill" && e.config.Settings.Redaction.Masks(action.Selector) {
+		e.redactor.AddValue(action.Value)
+	}
+}
+
+// redactResult redacts the error of a result that finished, which reports,
+// notifications and issues quote; its metrics are redacted as results are
+// written
+func (e *Executor) redactResult(result *TestResult) {
+	if e.redactor == nil {
+		return
+	}
+	result.Error = e.redactor.String(result.Error)
+}
+
+// writeRedactedJSON writes v as indented JSON, redacted
+func (e *Executor) writeRedactedJSON(path string, v interface{}) error {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal results: %w", err)
+	}
+	return os.WriteFile(path, e.redactor.JSON(data), 0600)
+}
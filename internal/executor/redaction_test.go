@@ -0,0 +1,62 @@
+package executor
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"panoptic/internal/config"
+	"panoptic/internal/logger"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestExecutor_Run_Redaction tests that pattern matches and values filled
+// into masked fields stay out of the logs, results and stream of a run
+func TestExecutor_Run_Redaction(t *testing.T) {
+	cfg := &config.Config{Name: "shop", Apps: []config.AppConfig{
+		{Name: "Shop", Type: "driver", Driver: scriptDriver(t, `"fill","navigate"`), Actions: []config.Action{
+			{Name: "card", Type: "fill", Selector: "#card", Value: "4111-1111"},
+			{Name: "echo 4111-1111", Type: "fill", Selector: "#note", Value: "note"},
+			{Name: "visit ada@example.com", Type: "navigate", Value: "https://shop.example.com"},
+		}},
+	}}
+	cfg.Settings.Redaction = &config.RedactionSettings{Patterns: []string{`[\w.]+@example\.com`}, Selectors: []string{"#card"}}
+	outputDir := t.TempDir()
+	logs := &bytes.Buffer{}
+	log := logger.NewLogger(true)
+	log.SetOutput(logs)
+	executor := NewExecutor(cfg, outputDir, log)
+	require.NoError(t, executor.StreamResults(filepath.Join(outputDir, ResultStreamFile)))
+	require.NoError(t, executor.Run())
+
+	require.Len(t, executor.results, 1)
+	assert.Equal(t, "Action 'visit [REDACTED]' failed: driver sh: navigate: no such screen (code 1)", executor.results[0].Error)
+	require.NoError(t, executor.SaveResults(filepath.Join(outputDir, "results.json")))
+	for _, name := range []string{"results.json", ResultStreamFile} {
+		data, err := os.ReadFile(filepath.Join(outputDir, name))
+		require.NoError(t, err)
+		assert.NotContains(t, string(data), "ada@example.com", name)
+		assert.NotContains(t, string(data), "4111-1111", name)
+		assert.Contains(t, string(data), "[REDACTED]", name)
+	}
+	assert.Contains(t, logs.String(), "echo [REDACTED]")
+	assert.NotContains(t, logs.String(), "ada@example.com")
+	assert.NotContains(t, logs.String(), "4111-1111")
+}
+
+// TestExecutor_Run_RedactionOff tests that runs without settings.redaction
+// write results as they are
+func TestExecutor_Run_RedactionOff(t *testing.T) {
+	cfg, _ := manifestConfig(t)
+	outputDir := t.TempDir()
+	executor := NewExecutor(cfg, outputDir, logger.NewLogger(false))
+	require.NoError(t, executor.Run())
+	assert.Nil(t, executor.redactor)
+	require.NoError(t, executor.SaveResults(filepath.Join(outputDir, "results.json")))
+	data, err := os.ReadFile(filepath.Join(outputDir, "results.json"))
+	require.NoError(t, err)
+	assert.Contains(t, string(data), `"app_name": "Kiosk"`)
+}
@@ -1,30 +1,96 @@
 package executor
 
 import (
+	"bufio"
+	"encoding/json"
 	"fmt"
 	"html"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
 	"time"
+
+	"panoptic/internal/config"
+	"panoptic/internal/history"
+	"panoptic/internal/pdf"
+	"panoptic/internal/platforms"
 )
 
 // GenerateComprehensiveReport creates a full HTML test report with results, screenshots, and video embeds.
 func GenerateComprehensiveReport(outputPath string, results []TestResult) error {
+	return GenerateThemedReport(outputPath, results, nil)
+}
+
+// GenerateThemedReport creates the HTML report of results with a theme's
+// branding; a nil theme is unbranded
+func GenerateThemedReport(outputPath string, results []TestResult, theme *ReportTheme) error {
+	return writeReport(outputPath, results, theme, encryptedArtifacts(results), func(card func(*TestResult) error) error {
+		for i := range results {
+			if err := card(&results[i]); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// printReport prints an HTML report to PDF; swapped in tests, which have no
+// browser
+var printReport = platforms.PrintPDF
+
+// GenerateReportPDF prints an HTML report to a paginated PDF on paper of a
+// format, letter when empty, keeping the report's colors and opening with
+// its cover page. It returns the number of pages printed.
+func GenerateReportPDF(reportPath, pdfPath, format string, landscape bool) (int, error) {
+	spec := &config.PDFPrint{Format: strings.ToLower(format), Landscape: landscape, PrintBackground: true, Scale: 1}
+	if spec.Format == "" {
+		spec.Format = "letter"
+	}
+	if _, ok := config.PaperSizes[spec.Format]; !ok {
+		return 0, fmt.Errorf("unknown paper format %q; use letter, legal, tabloid, a3, a4 or a5", format)
+	}
+	if err := printReport(reportPath, pdfPath, spec); err != nil {
+		return 0, err
+	}
+	doc, err := pdf.ReadFile(pdfPath)
+	if err != nil {
+		return 0, fmt.Errorf("the printed report is not a readable PDF: %w", err)
+	}
+	return len(doc.Pages), nil
+}
+
+// writeReport writes the report: its summary and tables from results,
+// which need no metrics or artifacts, then a card for every result cards
+// passes on, written as it comes so a streamed run's results never all
+// sit in memory. With encrypted, the report warns that its artifacts only
+// show when served with their key.
+func writeReport(outputPath string, results []TestResult, theme *ReportTheme, encrypted bool, cards func(card func(*TestResult) error) error) error {
 	if err := os.MkdirAll(filepath.Dir(outputPath), 0755); err != nil {
 		return fmt.Errorf("failed to create report directory: %w", err)
 	}
+	file, err := os.OpenFile(outputPath, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0644)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	w := bufio.NewWriter(file)
 
 	var b strings.Builder
 	b.Grow(8192)
 
 	// Count pass/fail
-	passed, failed := 0, 0
+	passed, failed, warnings, quarantined := 0, 0, 0, 0
 	var totalDuration time.Duration
 	for _, r := range results {
-		if r.Success {
+		switch {
+		case r.Success:
 			passed++
-		} else {
+		case r.Quarantined:
+			quarantined++
+		case r.Severity == config.SeverityWarning:
+			warnings++
+		default:
 			failed++
 		}
 		totalDuration += r.Duration
@@ -36,30 +102,43 @@ func GenerateComprehensiveReport(outputPath string, results []TestResult) error
 <head>
 <meta charset="UTF-8">
 <meta name="viewport" content="width=device-width, initial-scale=1.0">
-<title>Panoptic Test Report</title>
+<title>`)
+	b.WriteString(html.EscapeString(theme.title()))
+	b.WriteString(`</title>
 <style>
+:root{--primary:#e94560;--background:#1a1a2e;--surface:#16213e;--text:#e0e0e0}
 *{box-sizing:border-box;margin:0;padding:0}
-body{font-family:-apple-system,BlinkMacSystemFont,'Segoe UI',Roboto,sans-serif;background:#1a1a2e;color:#e0e0e0;padding:20px}
-.header{text-align:center;padding:30px 0;border-bottom:2px solid #16213e}
-.header h1{font-size:2em;color:#e94560}
+body{font-family:-apple-system,BlinkMacSystemFont,'Segoe UI',Roboto,sans-serif;background:var(--background);color:var(--text);padding:20px}
+.header{text-align:center;padding:30px 0;border-bottom:2px solid var(--surface)}
+.brand{display:flex;justify-content:center;align-items:center;gap:12px;margin-bottom:12px}
+.brand .logo{max-height:48px;max-width:240px}
+.brand .organization{font-size:1.1em;font-weight:bold}
+.header h1{font-size:2em;color:var(--primary)}
 .header .subtitle{color:#888;margin-top:8px}
 .summary{display:flex;justify-content:center;gap:30px;padding:20px 0;flex-wrap:wrap}
-.stat{text-align:center;padding:15px 25px;background:#16213e;border-radius:8px;min-width:120px}
+.stat{text-align:center;padding:15px 25px;background:var(--surface);border-radius:8px;min-width:120px}
 .stat .value{font-size:2em;font-weight:bold}
 .stat .label{font-size:0.85em;color:#888;margin-top:4px}
 .stat.pass .value{color:#4caf50}
 .stat.fail .value{color:#f44336}
 .stat.total .value{color:#2196f3}
+.stat.quarantined .value{color:#ffb300}
+.stat.warning .value{color:#ff9800}
 .stat.time .value{color:#ff9800;font-size:1.4em}
 .apps{padding:20px 0}
-.app-card{background:#16213e;border-radius:8px;margin:15px 0;padding:20px;border-left:4px solid #4caf50}
+.app-card{background:var(--surface);border-radius:8px;margin:15px 0;padding:20px;border-left:4px solid #4caf50}
 .app-card.failed{border-left-color:#f44336}
+.app-card.quarantined{border-left-color:#ffb300;border-left-style:dashed}
+.app-card.warning{border-left-color:#ff9800}
 .app-card .app-header{display:flex;justify-content:space-between;align-items:center;flex-wrap:wrap}
 .app-card .app-name{font-size:1.3em;font-weight:bold}
 .app-card .app-type{background:#0f3460;padding:3px 10px;border-radius:4px;font-size:0.8em}
 .app-card .app-status{padding:4px 12px;border-radius:4px;font-weight:bold;font-size:0.9em}
 .app-card .app-status.pass{background:#1b5e20;color:#a5d6a7}
 .app-card .app-status.fail{background:#b71c1c;color:#ef9a9a}
+.app-card .app-status.quarantined{background:#5d4037;color:#ffe082}
+.app-card .app-status.warning{background:#e65100;color:#ffe0b2}
+.app-card .app-quarantine{margin-top:10px;font-size:0.9em;color:#ffe082}
 .app-card .app-meta{margin-top:10px;font-size:0.9em;color:#888}
 .app-card .app-error{margin-top:10px;padding:10px;background:#2a0a0a;border-radius:4px;color:#ef9a9a;font-family:monospace;font-size:0.85em;white-space:pre-wrap;word-break:break-all}
 .screenshots{margin-top:15px}
@@ -68,18 +147,72 @@ body{font-family:-apple-system,BlinkMacSystemFont,'Segoe UI',Roboto,sans-serif;b
 .screenshot-grid img{max-width:200px;max-height:150px;border-radius:4px;border:1px solid #333;cursor:pointer;transition:transform 0.2s}
 .screenshot-grid img:hover{transform:scale(1.05)}
 .screenshot-grid a{color:#64b5f6;font-size:0.85em;text-decoration:none}
+.screenshot-grid figcaption{font-size:0.75em;text-align:center}
+.screenshot-note{margin-top:6px;font-size:0.8em;color:#888}
 .videos{margin-top:15px}
 .videos h3{font-size:1em;margin-bottom:8px;color:#aaa}
 .videos video{max-width:480px;border-radius:4px;border:1px solid #333}
 .videos .video-link{color:#64b5f6;font-size:0.85em;text-decoration:none;display:block;margin-top:4px}
-.footer{text-align:center;padding:30px 0;color:#555;font-size:0.85em;border-top:1px solid #16213e;margin-top:30px}
+.browsers{padding:10px 0}
+.browsers h2{font-size:1.2em;margin-bottom:10px;color:#aaa}
+.browsers table{width:100%;border-collapse:collapse;background:var(--surface);border-radius:8px}
+.browsers th,.browsers td{padding:8px 12px;text-align:left;border-bottom:1px solid #0f3460}
+.browsers td.fail{color:#f44336}
+.browsers td.pass{color:#4caf50}
+.app-card .app-tag{background:#1b2a4a;padding:3px 8px;border-radius:10px;font-size:0.75em;color:#b39ddb}
+.app-card .app-browser{background:#0f3460;padding:3px 10px;border-radius:4px;font-size:0.8em;color:#64b5f6}
+.scenario{margin-top:15px;font-size:0.9em}
+.scenario h3{font-size:1em;margin-bottom:8px;color:#aaa}
+.scenario .where{font-weight:normal;font-size:0.85em;color:#666}
+.scenario ol{list-style:none}
+.scenario li{padding:3px 0;font-family:monospace}
+.scenario .keyword{color:#b39ddb;font-weight:bold}
+.scenario li.passed::before{content:"\2713  ";color:#4caf50}
+.scenario li.failed{color:#ef9a9a}
+.scenario li.failed::before{content:"\2717  ";color:#f44336}
+.scenario li.skipped{color:#666}
+.scenario li.skipped::before{content:"\2012  "}
+.vitals{margin-top:15px}
+.vitals h3{font-size:1em;margin-bottom:8px;color:#aaa}
+.vitals table{width:100%;border-collapse:collapse;font-size:0.85em}
+.vitals th,.vitals td{padding:6px 10px;text-align:left;border-bottom:1px solid #0f3460}
+.vitals td.good{color:#4caf50}
+.vitals td.needs-improvement{color:#ff9800}
+.vitals td.poor{color:#f44336}
+.vitals .budget{margin-top:6px;font-size:0.85em}
+.vitals .budget.fail{color:#ef9a9a}
+.encrypted{margin:20px 0 0;padding:12px 16px;background:#3e2723;border-left:4px solid #ffb300;border-radius:4px;color:#ffe082}
+.encrypted code{background:var(--background);padding:1px 5px;border-radius:3px}
+.footer{text-align:center;padding:30px 0;color:#555;font-size:0.85em;border-top:1px solid var(--surface);margin-top:30px}
+.cover{display:none}
+.cover h1{font-size:2.6em;color:var(--primary);margin:20px 0 8px}
+.cover .subtitle{color:#888}
+.cover .verdict{font-size:1.6em;font-weight:bold;margin:40px 0 10px}
+.cover .verdict.pass{color:#4caf50}
+.cover .verdict.fail{color:#f44336}
+.cover .failures{margin-top:30px;text-align:left}
+.cover .failures h2{font-size:1.1em;color:#aaa;margin-bottom:8px}
+.cover .failures li{margin:4px 0 4px 20px}
+@media print{
+.cover{display:flex;flex-direction:column;justify-content:center;align-items:center;text-align:center;min-height:95vh;break-after:page}
+.app-card,.vitals,tr{break-inside:avoid}
+}
 </style>
-</head>
+`)
+	b.WriteString(theme.style())
+	generated := time.Now().Format("2006-01-02 15:04:05 MST")
+	b.WriteString(`</head>
 <body>
-<div class="header">
-<h1>Panoptic Test Report</h1>
+`)
+	writeCover(&b, theme, results, generated, passed, totalDuration)
+	b.WriteString(`<div class="header">
+`)
+	b.WriteString(theme.brand())
+	b.WriteString(`<h1>`)
+	b.WriteString(html.EscapeString(theme.title()))
+	b.WriteString(`</h1>
 <div class="subtitle">Generated: `)
-	b.WriteString(html.EscapeString(time.Now().Format("2006-01-02 15:04:05 MST")))
+	b.WriteString(html.EscapeString(generated))
 	b.WriteString(`</div>
 </div>
 
@@ -93,98 +226,504 @@ body{font-family:-apple-system,BlinkMacSystemFont,'Segoe UI',Roboto,sans-serif;b
 <div class="stat fail"><div class="value">`)
 	b.WriteString(fmt.Sprintf("%d", failed))
 	b.WriteString(`</div><div class="label">Failed</div></div>
-<div class="stat time"><div class="value">`)
+`)
+	if warnings > 0 {
+		b.WriteString(fmt.Sprintf(`<div class="stat warning"><div class="value">%d</div><div class="label">Warnings</div></div>
+`, warnings))
+	}
+	if quarantined > 0 {
+		b.WriteString(fmt.Sprintf(`<div class="stat quarantined"><div class="value">%d</div><div class="label">Quarantined</div></div>
+`, quarantined))
+	}
+	b.WriteString(`<div class="stat time"><div class="value">`)
 	b.WriteString(formatDuration(totalDuration))
 	b.WriteString(`</div><div class="label">Total Duration</div></div>
 </div>
+`)
+	if encrypted {
+		b.WriteString(`<div class="encrypted"><strong>Artifacts are encrypted.</strong> Screenshots, videos and traces of this run are encrypted at rest with AES-256-GCM and won't show when this file is opened directly. View the report with <code>panoptic report serve</code> and the artifact key to decrypt them.</div>
+`)
+	}
+
+	// Results grouped by browser, to surface engine-specific regressions
+	if groups := groupResultsByBrowser(results); len(groups) > 0 {
+		b.WriteString(`
+<div class="browsers">
+<h2>Results by Browser</h2>
+<table>
+<tr><th>Browser</th><th>Total</th><th>Passed</th><th>Failed</th><th>Failed Apps</th></tr>
+`)
+		for _, g := range groups {
+			failClass := "pass"
+			if g.Failed > 0 {
+				failClass = "fail"
+			}
+			b.WriteString(fmt.Sprintf("<tr><td>%s</td><td>%d</td><td class=\"pass\">%d</td><td class=\"%s\">%d</td><td>%s</td></tr>\n",
+				html.EscapeString(g.Browser), g.Passed+g.Failed, g.Passed, failClass, g.Failed,
+				html.EscapeString(strings.Join(g.FailedApps, ", "))))
+		}
+		b.WriteString(`</table>
+</div>
+`)
+	}
+
+	// Results grouped by tag, so subsets such as smoke or checkout get their own pass rate
+	if groups := groupResultsByTag(results); len(groups) > 0 {
+		b.WriteString(`
+<div class="browsers tags">
+<h2>Results by Tag</h2>
+<table>
+<tr><th>Tag</th><th>Total</th><th>Passed</th><th>Failed</th><th>Pass Rate</th><th>Failed Apps</th></tr>
+`)
+		for _, g := range groups {
+			failClass := "pass"
+			if g.Failed > 0 {
+				failClass = "fail"
+			}
+			total := g.Passed + g.Failed
+			b.WriteString(fmt.Sprintf("<tr><td>%s</td><td>%d</td><td class=\"pass\">%d</td><td class=\"%s\">%d</td><td>%.1f%%</td><td>%s</td></tr>\n",
+				html.EscapeString(g.Tag), total, g.Passed, failClass, g.Failed,
+				float64(g.Passed)*100/float64(total),
+				html.EscapeString(strings.Join(g.FailedApps, ", "))))
+		}
+		b.WriteString(`</table>
+</div>
+`)
+	}
+
+	// Results grouped by matrix dimension value, e.g. every locale=de instance
+	if groups := groupResultsByMatrix(results); len(groups) > 0 {
+		b.WriteString(`
+<div class="browsers matrix">
+<h2>Results by Matrix Dimension</h2>
+<table>
+<tr><th>Dimension</th><th>Value</th><th>Total</th><th>Passed</th><th>Failed</th><th>Pass Rate</th><th>Failed Apps</th></tr>
+`)
+		for _, g := range groups {
+			failClass := "pass"
+			if g.Failed > 0 {
+				failClass = "fail"
+			}
+			total := g.Passed + g.Failed
+			b.WriteString(fmt.Sprintf("<tr><td>%s</td><td>%s</td><td>%d</td><td class=\"pass\">%d</td><td class=\"%s\">%d</td><td>%.1f%%</td><td>%s</td></tr>\n",
+				html.EscapeString(g.Dimension), html.EscapeString(g.Value), total, g.Passed, failClass, g.Failed,
+				float64(g.Passed)*100/float64(total),
+				html.EscapeString(strings.Join(g.FailedApps, ", "))))
+		}
+		b.WriteString(`</table>
+</div>
+`)
+	}
+
+	// Results grouped by Gherkin feature, for suites written as scenarios
+	if groups := groupResultsByFeature(results); len(groups) > 0 {
+		b.WriteString(`
+<div class="browsers features">
+<h2>Results by Feature</h2>
+<table>
+<tr><th>Feature</th><th>Scenarios</th><th>Passed</th><th>Failed</th><th>Pass Rate</th><th>Failed Scenarios</th></tr>
+`)
+		for _, g := range groups {
+			failClass := "pass"
+			if g.Failed > 0 {
+				failClass = "fail"
+			}
+			total := g.Passed + g.Failed
+			b.WriteString(fmt.Sprintf("<tr><td>%s</td><td>%d</td><td class=\"pass\">%d</td><td class=\"%s\">%d</td><td>%.1f%%</td><td>%s</td></tr>\n",
+				html.EscapeString(g.Feature), total, g.Passed, failClass, g.Failed,
+				float64(g.Passed)*100/float64(total),
+				html.EscapeString(strings.Join(g.FailedApps, ", "))))
+		}
+		b.WriteString(`</table>
+</div>
+`)
+	}
 
+	b.WriteString(`
 <div class="apps">
 `)
 
+	w.WriteString(b.String())
+
 	// Per-app cards
+	err = cards(func(r *TestResult) error {
+		var b strings.Builder
+		writeAppCard(&b, r)
+		_, err := w.WriteString(b.String())
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	// Footer
+	w.WriteString(`</div>
+<div class="footer">
+` + theme.footer() + `
+</div>
+</body>
+</html>
+`)
+
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	return file.Close()
+}
+
+// writeAppCard writes the card of one app's result
+// maxCoverFailures bounds the failed apps the cover page lists
+const maxCoverFailures = 10
+
+// writeCover writes the cover page of the printed report: its branding,
+// the verdict and the summary, and the apps that failed. It only shows when
+// printed, as panoptic report --pdf does.
+func writeCover(b *strings.Builder, theme *ReportTheme, results []TestResult, generated string, passed int, duration time.Duration) {
+	var failures []TestResult
 	for _, r := range results {
-		statusClass := "pass"
-		statusText := "PASSED"
-		cardClass := ""
-		if !r.Success {
-			statusClass = "fail"
-			statusText = "FAILED"
-			cardClass = " failed"
+		if !r.Success && !r.Quarantined && r.Severity != config.SeverityWarning {
+			failures = append(failures, r)
 		}
+	}
+	verdict, verdictClass := "Passed", "pass"
+	if len(failures) > 0 {
+		verdict, verdictClass = "Failed", "fail"
+	}
+	rate := "-"
+	if len(results) > 0 {
+		rate = fmt.Sprintf("%.1f%%", float64(passed)*100/float64(len(results)))
+	}
 
-		b.WriteString(fmt.Sprintf(`<div class="app-card%s">
+	b.WriteString("<div class=\"cover\">\n")
+	b.WriteString(theme.brand())
+	b.WriteString(fmt.Sprintf(`<h1>%s</h1>
+<div class="subtitle">Generated: %s</div>
+<div class="verdict %s">%s</div>
+<div class="summary">
+<div class="stat pass"><div class="value">%d/%d</div><div class="label">Passed</div></div>
+<div class="stat total"><div class="value">%s</div><div class="label">Pass Rate</div></div>
+<div class="stat time"><div class="value">%s</div><div class="label">Total Duration</div></div>
+</div>
+`, html.EscapeString(theme.title()), html.EscapeString(generated), verdictClass, verdict, passed, len(results), rate, formatDuration(duration)))
+	if len(failures) > 0 {
+		b.WriteString("<div class=\"failures\">\n<h2>Failed Apps</h2>\n<ul>\n")
+		for i, r := range failures {
+			if i == maxCoverFailures {
+				b.WriteString(fmt.Sprintf("<li>&hellip;and %d more</li>\n", len(failures)-maxCoverFailures))
+				break
+			}
+			name := r.AppName
+			if r.Browser != "" {
+				name += " (" + r.Browser + ")"
+			}
+			b.WriteString("<li><strong>" + html.EscapeString(name) + "</strong>: " + html.EscapeString(abbreviate(r.Error)) + "</li>\n")
+		}
+		b.WriteString("</ul>\n</div>\n")
+	}
+	b.WriteString("</div>\n")
+}
+
+func writeAppCard(b *strings.Builder, r *TestResult) {
+	statusClass := "pass"
+	statusText := "PASSED"
+	cardClass := ""
+	if r.Quarantined {
+		statusClass = "quarantined"
+		statusText = "QUARANTINED"
+		cardClass = " quarantined"
+	} else if !r.Success && r.Severity == config.SeverityWarning {
+		statusClass = "warning"
+		statusText = "WARNING"
+		cardClass = " warning"
+	} else if !r.Success {
+		statusClass = "fail"
+		statusText = "FAILED"
+		cardClass = " failed"
+	}
+
+	b.WriteString(fmt.Sprintf(`<div class="app-card%s">
 <div class="app-header">
 <span class="app-name">%s</span>
-<span class="app-type">%s</span>
+<span class="app-type">%s</span>%s%s
 <span class="app-status %s">%s</span>
 </div>
-<div class="app-meta">Duration: %s | Start: %s</div>
+<div class="app-meta">Duration: %s | Start: %s%s</div>
 `,
-			cardClass,
-			html.EscapeString(r.AppName),
-			html.EscapeString(r.AppType),
-			statusClass, statusText,
-			formatDuration(r.Duration),
-			r.StartTime.Format("15:04:05"),
-		))
-
-		if r.Error != "" {
-			b.WriteString(fmt.Sprintf(`<div class="app-error">%s</div>
+		cardClass,
+		html.EscapeString(r.AppName),
+		html.EscapeString(r.AppType),
+		browserBadge(r.Browser),
+		tagBadges(r.Tags),
+		statusClass, statusText,
+		formatDuration(r.Duration),
+		r.StartTime.Format("15:04:05"),
+		failureCategory(*r),
+	))
+
+	if r.Quarantined {
+		b.WriteString(fmt.Sprintf(`<div class="app-quarantine">Quarantined: %s. This failure does not fail the run.</div>
+`, html.EscapeString(r.QuarantineReason)))
+	}
+
+	if r.Error != "" {
+		b.WriteString(fmt.Sprintf(`<div class="app-error">%s</div>
 `, html.EscapeString(r.Error)))
-		}
+	}
+
+	// Steps of the Gherkin scenario the app ran
+	writeScenario(b, r.Scenario)
+
+	// Core Web Vitals per navigation and performance budget results
+	writeWebVitals(b, r.Metrics)
 
-		// Screenshots
-		if len(r.Screenshots) > 0 {
-			b.WriteString(`<div class="screenshots"><h3>Screenshots</h3><div class="screenshot-grid">
+	// Numbers the app's actions recorded
+	writeCustomMetrics(b, r.CustomMetrics)
+
+	// Screenshots
+	if len(r.Screenshots) > 0 {
+		b.WriteString(`<div class="screenshots"><h3>Screenshots</h3><div class="screenshot-grid">
 `)
-			for _, s := range r.Screenshots {
-				relPath := filepath.Base(s)
-				// Check if file exists
-				if _, err := os.Stat(s); err == nil {
-					b.WriteString(fmt.Sprintf(`<a href="screenshots/%s" target="_blank"><img src="screenshots/%s" alt="%s" loading="lazy"></a>
+		annotated := make(map[string]AnnotatedScreenshot, len(r.Annotated))
+		for _, a := range r.Annotated {
+			annotated[a.Screenshot] = a
+		}
+		// Near-duplicates of an earlier screenshot are left out unless annotated
+		duplicates := nearDuplicates(r)
+		skipped := 0
+		for _, s := range r.Screenshots {
+			if _, ok := annotated[s]; duplicates[s] && !ok {
+				skipped++
+				continue
+			}
+			relPath := filepath.Base(s)
+			// Annotated copies replace their screenshot, linking to the original
+			if a, ok := annotated[s]; ok {
+				if _, err := os.Stat(a.Path); err == nil {
+					annotatedPath := filepath.Base(a.Path)
+					labels := html.EscapeString(strings.Join(a.Labels, "; "))
+					b.WriteString(fmt.Sprintf(`<figure><a href="screenshots/%s" target="_blank"><img src="screenshots/%s" alt="%s" title="%s" loading="lazy"></a>
+<figcaption><a href="screenshots/%s" target="_blank">original</a></figcaption></figure>
+`, html.EscapeString(annotatedPath), html.EscapeString(annotatedPath), labels, labels, html.EscapeString(relPath)))
+					continue
+				}
+			}
+			// Check if file exists
+			if _, err := os.Stat(s); err == nil {
+				b.WriteString(fmt.Sprintf(`<a href="screenshots/%s" target="_blank"><img src="screenshots/%s" alt="%s" loading="lazy"></a>
 `, html.EscapeString(relPath), html.EscapeString(relPath), html.EscapeString(relPath)))
-				} else {
-					b.WriteString(fmt.Sprintf(`<a href="#">%s (not found)</a>
+			} else {
+				b.WriteString(fmt.Sprintf(`<a href="#">%s (not found)</a>
 `, html.EscapeString(relPath)))
-				}
 			}
-			b.WriteString(`</div></div>
+		}
+		b.WriteString(`</div>
 `)
+		if skipped > 0 {
+			b.WriteString(fmt.Sprintf(`<p class="screenshot-note">%d near-duplicate screenshot(s) not shown</p>
+`, skipped))
 		}
+		b.WriteString(`</div>
+`)
+	}
 
-		// Videos
-		if len(r.Videos) > 0 {
-			b.WriteString(`<div class="videos"><h3>Videos</h3>
+	// Videos
+	if len(r.Videos) > 0 {
+		b.WriteString(`<div class="videos"><h3>Videos</h3>
 `)
-			for _, v := range r.Videos {
-				relPath := filepath.Base(v)
-				if _, err := os.Stat(v); err == nil {
-					b.WriteString(fmt.Sprintf(`<video controls preload="metadata"><source src="videos/%s" type="video/mp4">Your browser does not support video.</video>
+		for _, v := range r.Videos {
+			relPath := filepath.Base(v)
+			if _, err := os.Stat(v); err == nil {
+				b.WriteString(fmt.Sprintf(`<video controls preload="metadata"><source src="videos/%s" type="video/mp4">Your browser does not support video.</video>
 <a class="video-link" href="videos/%s" download>Download: %s</a>
 `, html.EscapeString(relPath), html.EscapeString(relPath), html.EscapeString(relPath)))
-				} else {
-					b.WriteString(fmt.Sprintf(`<a class="video-link" href="#">%s (not found)</a>
+			} else {
+				b.WriteString(fmt.Sprintf(`<a class="video-link" href="#">%s (not found)</a>
 `, html.EscapeString(relPath)))
-				}
 			}
-			b.WriteString(`</div>
-`)
 		}
-
 		b.WriteString(`</div>
 `)
 	}
 
-	// Footer
 	b.WriteString(`</div>
-<div class="footer">
-Panoptic Automated Testing Framework &mdash; Report generated automatically
-</div>
-</body>
-</html>
 `)
+}
+
+// browserStats aggregates results for a single browser
+type browserStats struct {
+	Browser    string
+	Passed     int
+	Failed     int
+	FailedApps []string
+}
+
+// groupResultsByBrowser aggregates results per browser label in first-seen
+// order; results without a browser are left out
+func groupResultsByBrowser(results []TestResult) []browserStats {
+	var groups []browserStats
+	index := make(map[string]int)
+	for _, r := range results {
+		if r.Browser == "" {
+			continue
+		}
+		i, ok := index[r.Browser]
+		if !ok {
+			i = len(groups)
+			index[r.Browser] = i
+			groups = append(groups, browserStats{Browser: r.Browser})
+		}
+		if r.Success {
+			groups[i].Passed++
+		} else {
+			groups[i].Failed++
+			groups[i].FailedApps = append(groups[i].FailedApps, r.AppName)
+		}
+	}
+	return groups
+}
+
+// failureCategory renders the category of a failed result for the app meta line
+func failureCategory(r TestResult) string {
+	if r.Success || r.FailureCategory == "" {
+		return ""
+	}
+	return " | Category: " + html.EscapeString(r.FailureCategory)
+}
+
+// tagStats aggregates results for a single tag
+type tagStats struct {
+	Tag        string
+	Passed     int
+	Failed     int
+	FailedApps []string
+}
+
+// groupResultsByTag aggregates results per tag in tag order; a result counts
+// once under each of its tags
+func groupResultsByTag(results []TestResult) []tagStats {
+	index := make(map[string]*tagStats)
+	for _, r := range results {
+		for _, tag := range r.Tags {
+			g, ok := index[tag]
+			if !ok {
+				g = &tagStats{Tag: tag}
+				index[tag] = g
+			}
+			if r.Success {
+				g.Passed++
+			} else {
+				g.Failed++
+				g.FailedApps = append(g.FailedApps, r.AppName)
+			}
+		}
+	}
+	groups := make([]tagStats, 0, len(index))
+	for _, g := range index {
+		groups = append(groups, *g)
+	}
+	sort.Slice(groups, func(i, j int) bool { return groups[i].Tag < groups[j].Tag })
+	return groups
+}
+
+// matrixStats aggregates the results of the matrix instances sharing a
+// dimension value
+type matrixStats struct {
+	Dimension  string
+	Value      string
+	Passed     int
+	Failed     int
+	FailedApps []string
+}
+
+// groupResultsByMatrix aggregates results per dimension value, dimensions in
+// name order and values in first-seen order
+func groupResultsByMatrix(results []TestResult) []matrixStats {
+	var groups []matrixStats
+	index := make(map[[2]string]int)
+	for _, r := range results {
+		for dim, value := range r.Matrix {
+			key := [2]string{dim, value}
+			i, ok := index[key]
+			if !ok {
+				i = len(groups)
+				index[key] = i
+				groups = append(groups, matrixStats{Dimension: dim, Value: value})
+			}
+			if r.Success {
+				groups[i].Passed++
+			} else {
+				groups[i].Failed++
+				groups[i].FailedApps = append(groups[i].FailedApps, r.AppName)
+			}
+		}
+	}
+	sort.SliceStable(groups, func(i, j int) bool { return groups[i].Dimension < groups[j].Dimension })
+	return groups
+}
+
+// featureStats aggregates the results of the scenarios of a Gherkin feature
+type featureStats struct {
+	Feature    string
+	Passed     int
+	Failed     int
+	FailedApps []string
+}
+
+// groupResultsByFeature aggregates scenario results per feature, in
+// first-seen order
+func groupResultsByFeature(results []TestResult) []featureStats {
+	var groups []featureStats
+	index := make(map[string]int)
+	for _, r := range results {
+		if r.Scenario == nil {
+			continue
+		}
+		i, ok := index[r.Scenario.Feature]
+		if !ok {
+			i = len(groups)
+			index[r.Scenario.Feature] = i
+			groups = append(groups, featureStats{Feature: r.Scenario.Feature})
+		}
+		if r.Success {
+			groups[i].Passed++
+		} else {
+			groups[i].Failed++
+			groups[i].FailedApps = append(groups[i].FailedApps, r.AppName)
+		}
+	}
+	return groups
+}
 
-	return os.WriteFile(outputPath, []byte(b.String()), 0644)
+// writeScenario writes the steps of a scenario with their status
+func writeScenario(b *strings.Builder, s *ScenarioResult) {
+	if s == nil {
+		return
+	}
+	b.WriteString(fmt.Sprintf(`<div class="scenario"><h3>Scenario: %s <span class="where">%s:%d</span></h3>
+<ol>
+`, html.EscapeString(s.Name), html.EscapeString(filepath.Base(s.File)), s.Line))
+	for _, step := range s.Steps {
+		b.WriteString(fmt.Sprintf(`<li class="%s"><span class="keyword">%s</span> %s</li>
+`, step.Status, html.EscapeString(step.Keyword), html.EscapeString(step.Text)))
+	}
+	b.WriteString("</ol></div>\n")
+}
+
+// tagBadges renders the tags shown on an app card
+func tagBadges(tags []string) string {
+	var b strings.Builder
+	for _, tag := range tags {
+		b.WriteString(fmt.Sprintf("\n<span class=\"app-tag\">%s</span>", html.EscapeString(tag)))
+	}
+	return b.String()
+}
+
+// browserBadge renders the browser label shown on an app card
+func browserBadge(browser string) string {
+	if browser == "" {
+		return ""
+	}
+	return fmt.Sprintf("\n<span class=\"app-browser\">%s</span>", html.EscapeString(browser))
 }
 
 // formatDuration formats a time.Duration into a human-readable string.
@@ -199,3 +738,109 @@ func formatDuration(d time.Duration) string {
 	s := int(d.Seconds()) % 60
 	return fmt.Sprintf("%dm %ds", m, s)
 }
+
+// vitalThresholds are the web.dev "good" and "poor" boundaries per metric
+var vitalThresholds = map[string][2]float64{
+	"lcp":  {2500, 4000},
+	"cls":  {0.1, 0.25},
+	"inp":  {200, 500},
+	"fid":  {100, 300},
+	"ttfb": {800, 1800},
+	"fcp":  {1800, 3000},
+}
+
+// vitalCell renders one metric colored by its rating
+func vitalCell(metric string, value *float64) string {
+	if value == nil {
+		return "<td>&ndash;</td>"
+	}
+	text := fmt.Sprintf("%.0f ms", *value)
+	if metric == "cls" {
+		text = fmt.Sprintf("%.3f", *value)
+	}
+	class := "good"
+	if t, ok := vitalThresholds[metric]; ok {
+		if *value > t[1] {
+			class = "poor"
+		} else if *value > t[0] {
+			class = "needs-improvement"
+		}
+	}
+	return fmt.Sprintf(`<td class="%s">%s</td>`, class, text)
+}
+
+// metricSlice decodes a metric recorded as a typed slice locally or as
+// generic JSON when results come back from a container or Kubernetes job
+func metricSlice[T any](metrics map[string]interface{}, key string) []T {
+	switch v := metrics[key].(type) {
+	case nil:
+		return nil
+	case []T:
+		return v
+	default:
+		data, err := json.Marshal(v)
+		if err != nil {
+			return nil
+		}
+		var out []T
+		if json.Unmarshal(data, &out) != nil {
+			return nil
+		}
+		return out
+	}
+}
+
+// writeWebVitals adds the Web Vitals table of an app card
+func writeWebVitals(b *strings.Builder, metrics map[string]interface{}) {
+	vitals := metricSlice[platforms.WebVitals](metrics, "web_vitals")
+	assertions := metricSlice[PerformanceAssertion](metrics, "performance_assertions")
+	if len(vitals) == 0 && len(assertions) == 0 {
+		return
+	}
+
+	b.WriteString(`<div class="vitals"><h3>Web Vitals</h3>
+`)
+	if len(vitals) > 0 {
+		b.WriteString(`<table>
+<tr><th>URL</th><th>LCP</th><th>CLS</th><th>INP</th><th>TTFB</th><th>FCP</th><th>Requests</th><th>Transferred</th></tr>
+`)
+		for _, v := range vitals {
+			b.WriteString(fmt.Sprintf("<tr><td>%s</td>%s%s%s%s%s<td>%d</td><td>%.1f KB</td></tr>\n",
+				html.EscapeString(v.URL),
+				vitalCell("lcp", v.LCP), vitalCell("cls", v.CLS), vitalCell("inp", v.INP),
+				vitalCell("ttfb", v.TTFB), vitalCell("fcp", v.FCP),
+				len(v.Resources), float64(v.TransferBytes())/1024))
+		}
+		b.WriteString(`</table>
+`)
+	}
+	for _, a := range assertions {
+		if a.Passed {
+			b.WriteString(fmt.Sprintf("<div class=\"budget\">Budget %s: met</div>\n", html.EscapeString(a.Action)))
+		} else {
+			b.WriteString(fmt.Sprintf("<div class=\"budget fail\">Budget %s: %s</div>\n",
+				html.EscapeString(a.Action), html.EscapeString(strings.Join(a.Violations, "; "))))
+		}
+	}
+	b.WriteString(`</div>
+`)
+}
+
+// writeCustomMetrics adds the table of the metrics actions recorded to an
+// app card
+func writeCustomMetrics(b *strings.Builder, metrics []history.Metric) {
+	if len(metrics) == 0 {
+		return
+	}
+	b.WriteString(`<div class="vitals"><h3>Metrics</h3>
+<table>
+<tr><th>Metric</th><th>Value</th><th>Action</th></tr>
+`)
+	for _, m := range metrics {
+		b.WriteString(fmt.Sprintf("<tr><td>%s</td><td>%s</td><td>%s</td></tr>\n",
+			html.EscapeString(m.Name), html.EscapeString(history.FormatMetric(m.Value, m.Unit)), html.EscapeString(m.Action)))
+	}
+	b.WriteString(`</table>
+</div>
+`)
+}
@@ -1,12 +1,16 @@
 package executor
 
 import (
+	"fmt"
 	"os"
 	"path/filepath"
 	"strings"
 	"testing"
 	"time"
 
+	"panoptic/internal/config"
+	"panoptic/internal/platforms"
+
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -207,3 +211,278 @@ func TestGenerateComprehensiveReport_LargeResultSet(t *testing.T) {
 	require.NoError(t, err)
 	assert.True(t, info.Size() > 0)
 }
+
+func TestGenerateComprehensiveReport_GroupsByBrowser(t *testing.T) {
+	tmpDir := t.TempDir()
+	outputPath := filepath.Join(tmpDir, "report.html")
+
+	results := []TestResult{
+		{AppName: "Shop [chromium]", AppType: "web", Browser: "chromium", Success: true},
+		{AppName: "Shop [edge-beta]", AppType: "web", Browser: "edge-beta", Success: false, Error: "layout broken"},
+		{AppName: "Docs [chromium]", AppType: "web", Browser: "chromium", Success: true},
+		{AppName: "Desktop", AppType: "desktop", Success: true},
+	}
+
+	groups := groupResultsByBrowser(results)
+	require.Len(t, groups, 2)
+	assert.Equal(t, browserStats{Browser: "chromium", Passed: 2}, groups[0])
+	assert.Equal(t, []string{"Shop [edge-beta]"}, groups[1].FailedApps)
+
+	require.NoError(t, GenerateComprehensiveReport(outputPath, results))
+	data, err := os.ReadFile(outputPath)
+	require.NoError(t, err)
+
+	html := string(data)
+	assert.Contains(t, html, "Results by Browser")
+	assert.Contains(t, html, `<span class="app-browser">edge-beta</span>`)
+	assert.Contains(t, html, "<td>edge-beta</td><td>1</td>")
+}
+
+func TestGenerateComprehensiveReport_NoBrowserSection(t *testing.T) {
+	outputPath := filepath.Join(t.TempDir(), "report.html")
+
+	require.NoError(t, GenerateComprehensiveReport(outputPath, []TestResult{{AppName: "Desktop", AppType: "desktop"}}))
+	data, err := os.ReadFile(outputPath)
+	require.NoError(t, err)
+	assert.NotContains(t, string(data), "Results by Browser")
+}
+
+func TestGenerateComprehensiveReport_GroupsByTag(t *testing.T) {
+	outputPath := filepath.Join(t.TempDir(), "report.html")
+
+	results := []TestResult{
+		{AppName: "Shop", AppType: "web", Tags: []string{"smoke", "checkout"}, Success: true},
+		{AppName: "Admin", AppType: "web", Tags: []string{"smoke"}, Success: false, Error: "login failed"},
+		{AppName: "Desktop", AppType: "desktop", Success: true},
+	}
+
+	groups := groupResultsByTag(results)
+	require.Len(t, groups, 2)
+	assert.Equal(t, tagStats{Tag: "checkout", Passed: 1}, groups[0])
+	assert.Equal(t, tagStats{Tag: "smoke", Passed: 1, Failed: 1, FailedApps: []string{"Admin"}}, groups[1])
+
+	require.NoError(t, GenerateComprehensiveReport(outputPath, results))
+	data, err := os.ReadFile(outputPath)
+	require.NoError(t, err)
+
+	html := string(data)
+	assert.Contains(t, html, "Results by Tag")
+	assert.Contains(t, html, `<span class="app-tag">checkout</span>`)
+	assert.Contains(t, html, "<td>smoke</td><td>2</td>")
+	assert.Contains(t, html, "<td>50.0%</td><td>Admin</td>")
+}
+
+func TestGenerateComprehensiveReport_GroupsByMatrix(t *testing.T) {
+	outputPath := filepath.Join(t.TempDir(), "report.html")
+
+	results := []TestResult{
+		{AppName: "Shop (env=qa, locale=en)", AppType: "web", Matrix: map[string]string{"env": "qa", "locale": "en"}, Success: true},
+		{AppName: "Shop (env=qa, locale=de)", AppType: "web", Matrix: map[string]string{"env": "qa", "locale": "de"}, Success: false},
+		{AppName: "Shop (env=prod, locale=en)", AppType: "web", Matrix: map[string]string{"env": "prod", "locale": "en"}, Success: true},
+		{AppName: "Desktop", AppType: "desktop", Success: true},
+	}
+
+	groups := groupResultsByMatrix(results)
+	assert.Equal(t, []matrixStats{
+		{Dimension: "env", Value: "qa", Passed: 1, Failed: 1, FailedApps: []string{"Shop (env=qa, locale=de)"}},
+		{Dimension: "env", Value: "prod", Passed: 1},
+		{Dimension: "locale", Value: "en", Passed: 2},
+		{Dimension: "locale", Value: "de", Failed: 1, FailedApps: []string{"Shop (env=qa, locale=de)"}},
+	}, groups)
+
+	require.NoError(t, GenerateComprehensiveReport(outputPath, results))
+	data, err := os.ReadFile(outputPath)
+	require.NoError(t, err)
+	html := string(data)
+	assert.Contains(t, html, "Results by Matrix Dimension")
+	assert.Contains(t, html, "<td>locale</td><td>de</td><td>1</td>")
+
+	require.NoError(t, GenerateComprehensiveReport(outputPath, results[3:]))
+	data, err = os.ReadFile(outputPath)
+	require.NoError(t, err)
+	assert.NotContains(t, string(data), "Results by Matrix Dimension")
+}
+
+func TestGenerateComprehensiveReport_Scenarios(t *testing.T) {
+	outputPath := filepath.Join(t.TempDir(), "report.html")
+
+	results := []TestResult{
+		{AppName: "Cart: Add socks", AppType: "web", Success: false, Scenario: &ScenarioResult{
+			Feature: "Cart", Name: "Add socks", File: "features/cart.feature", Line: 7, Steps: []StepResult{
+				{Keyword: "Given", Text: "I open the shop", Status: StepPassed},
+				{Keyword: "When", Text: `I add "socks" <b>`, Status: StepFailed},
+				{Keyword: "Then", Text: "I see the cart", Status: StepSkipped},
+			}}},
+		{AppName: "Cart: Empty", AppType: "web", Success: true, Scenario: &ScenarioResult{Feature: "Cart", Name: "Empty"}},
+		{AppName: "Desktop", AppType: "desktop", Success: true},
+	}
+	assert.Equal(t, []featureStats{{Feature: "Cart", Passed: 1, Failed: 1, FailedApps: []string{"Cart: Add socks"}}}, groupResultsByFeature(results))
+
+	require.NoError(t, GenerateComprehensiveReport(outputPath, results))
+	data, err := os.ReadFile(outputPath)
+	require.NoError(t, err)
+	html := string(data)
+	assert.Contains(t, html, "Results by Feature")
+	assert.Contains(t, html, "<td>Cart</td><td>2</td>")
+	assert.Contains(t, html, `Scenario: Add socks <span class="where">cart.feature:7</span>`)
+	assert.Contains(t, html, `<li class="passed"><span class="keyword">Given</span> I open the shop</li>`)
+	assert.Contains(t, html, `<li class="failed"><span class="keyword">When</span> I add &#34;socks&#34; &lt;b&gt;</li>`)
+	assert.Contains(t, html, `<li class="skipped"><span class="keyword">Then</span> I see the cart</li>`)
+
+	require.NoError(t, GenerateComprehensiveReport(outputPath, results[2:]))
+	data, err = os.ReadFile(outputPath)
+	require.NoError(t, err)
+	assert.NotContains(t, string(data), "Results by Feature")
+}
+
+func TestGenerateComprehensiveReport_Quarantined(t *testing.T) {
+	outputPath := filepath.Join(t.TempDir(), "report.html")
+
+	results := []TestResult{
+		{AppName: "Shop", AppType: "web", Success: false, Error: "timeout", Quarantined: true, QuarantineReason: "flaky <checkout>"},
+		{AppName: "Admin", AppType: "web", Success: false, Error: "login failed"},
+	}
+	require.NoError(t, GenerateComprehensiveReport(outputPath, results))
+	data, err := os.ReadFile(outputPath)
+	require.NoError(t, err)
+
+	html := string(data)
+	assert.Contains(t, html, `<div class="app-card quarantined">`)
+	assert.Contains(t, html, `<span class="app-status quarantined">QUARANTINED</span>`)
+	assert.Contains(t, html, "Quarantined: flaky &lt;checkout&gt;.")
+	assert.Contains(t, html, `<div class="stat quarantined"><div class="value">1</div>`)
+	assert.Contains(t, html, `<div class="stat fail"><div class="value">1</div>`)
+}
+
+func TestGenerateComprehensiveReport_WarningSeverity(t *testing.T) {
+	outputPath := filepath.Join(t.TempDir(), "report.html")
+
+	results := []TestResult{
+		{AppName: "Audit", AppType: "web", Error: "contrast too low", FailureCategory: "accessibility", Severity: "warning"},
+	}
+	require.NoError(t, GenerateComprehensiveReport(outputPath, results))
+	data, err := os.ReadFile(outputPath)
+	require.NoError(t, err)
+
+	html := string(data)
+	assert.Contains(t, html, `<span class="app-status warning">WARNING</span>`)
+	assert.Contains(t, html, "| Category: accessibility</div>")
+	assert.Contains(t, html, `<div class="stat warning"><div class="value">1</div>`)
+	assert.Contains(t, html, `<div class="stat fail"><div class="value">0</div>`)
+}
+
+func TestGenerateComprehensiveReport_AnnotatedScreenshots(t *testing.T) {
+	tmpDir := t.TempDir()
+	outputPath := filepath.Join(tmpDir, "report.html")
+	screenshot := filepath.Join(tmpDir, "screenshots", "checkout.png")
+	require.NoError(t, os.MkdirAll(filepath.Dir(screenshot), 0755))
+	require.NoError(t, os.WriteFile(screenshot, []byte("fake png"), 0644))
+	require.NoError(t, os.WriteFile(annotatedPath(screenshot), []byte("fake png"), 0644))
+
+	results := []TestResult{{
+		AppName: "Shop", AppType: "web", Success: true,
+		Screenshots: []string{screenshot, filepath.Join(tmpDir, "screenshots", "gone.png")},
+		Annotated: []AnnotatedScreenshot{
+			{Screenshot: screenshot, Path: annotatedPath(screenshot), Labels: []string{"#total 3.10:1 < 4.5:1", "<b>"}},
+			{Screenshot: filepath.Join(tmpDir, "screenshots", "gone.png"), Path: filepath.Join(tmpDir, "screenshots", "gone_annotated.png")},
+		},
+	}}
+	require.NoError(t, GenerateComprehensiveReport(outputPath, results))
+	data, err := os.ReadFile(outputPath)
+	require.NoError(t, err)
+
+	html := string(data)
+	assert.Contains(t, html, `<img src="screenshots/checkout_annotated.png" alt="#total 3.10:1 &lt; 4.5:1; &lt;b&gt;"`)
+	assert.Contains(t, html, `<figcaption><a href="screenshots/checkout.png" target="_blank">original</a></figcaption>`)
+	assert.Contains(t, html, "gone.png (not found)", "a missing annotated copy falls back to the screenshot")
+}
+
+// TestGenerateComprehensiveReport_NearDuplicateScreenshots tests that
+// screenshots looking like an earlier one are left out unless annotated
+func TestGenerateComprehensiveReport_NearDuplicateScreenshots(t *testing.T) {
+	tmpDir := t.TempDir()
+	outputPath := filepath.Join(tmpDir, "report.html")
+	shots := make([]string, 4)
+	for i := range shots {
+		shots[i] = filepath.Join(tmpDir, "screenshots", fmt.Sprintf("frame_%d.png", i))
+	}
+	require.NoError(t, os.MkdirAll(filepath.Dir(shots[0]), 0755))
+	for _, s := range shots {
+		require.NoError(t, os.WriteFile(s, []byte("fake png"), 0644))
+	}
+	require.NoError(t, os.WriteFile(annotatedPath(shots[3]), []byte("fake png"), 0644))
+
+	results := []TestResult{{
+		AppName: "Shop", AppType: "web", Success: true, Screenshots: shots,
+		ScreenHashes: []ScreenHash{
+			{Screenshot: shots[0], PHash: 0x00ff}, {Screenshot: shots[1], PHash: 0x01ff},
+			{Screenshot: shots[2], PHash: 0xff00}, {Screenshot: shots[3], PHash: 0x00fe},
+		},
+		Annotated: []AnnotatedScreenshot{{Screenshot: shots[3], Path: annotatedPath(shots[3])}},
+	}}
+	require.NoError(t, GenerateComprehensiveReport(outputPath, results))
+	data, err := os.ReadFile(outputPath)
+	require.NoError(t, err)
+
+	html := string(data)
+	assert.Contains(t, html, `src="screenshots/frame_0.png"`)
+	assert.NotContains(t, html, `src="screenshots/frame_1.png"`)
+	assert.Contains(t, html, `src="screenshots/frame_2.png"`)
+	assert.Contains(t, html, `src="screenshots/frame_3_annotated.png"`, "annotated copies are always shown")
+	assert.Contains(t, html, `<p class="screenshot-note">1 near-duplicate screenshot(s) not shown</p>`)
+}
+
+func TestGenerateComprehensiveReport_CoverPage(t *testing.T) {
+	outputPath := filepath.Join(t.TempDir(), "report.html")
+	results := []TestResult{
+		{AppName: "Shop", Success: true, Duration: 2 * time.Second},
+		{AppName: "Checkout", Browser: "chrome", Error: "Action 'pay' failed: <timeout>", Duration: time.Second},
+		{AppName: "Admin", Error: "SSO down", Quarantined: true},
+	}
+	require.NoError(t, GenerateThemedReport(outputPath, results, &ReportTheme{Title: "Acme QA", Organization: "Acme"}))
+
+	data, err := os.ReadFile(outputPath)
+	require.NoError(t, err)
+	html := string(data)
+	cover := html[strings.Index(html, `<div class="cover">`):strings.Index(html, `<div class="header">`)]
+	assert.Contains(t, cover, "<h1>Acme QA</h1>")
+	assert.Contains(t, cover, `<span class="organization">Acme</span>`)
+	assert.Contains(t, cover, `<div class="verdict fail">Failed</div>`)
+	assert.Contains(t, cover, `<div class="value">1/3</div>`)
+	assert.Contains(t, cover, `<div class="value">33.3%</div>`)
+	assert.Contains(t, cover, "<li><strong>Checkout (chrome)</strong>: Action &#39;pay&#39; failed: &lt;timeout&gt;</li>")
+	assert.NotContains(t, cover, "Admin", "quarantined apps don't fail the run")
+	assert.Contains(t, html, "@media print{\n.cover{display:flex", "the cover only shows when printed")
+}
+
+func TestGenerateReportPDF(t *testing.T) {
+	dir := t.TempDir()
+	reportPath := filepath.Join(dir, "report.html")
+	require.NoError(t, GenerateComprehensiveReport(reportPath, []TestResult{{AppName: "Shop", Success: true}}))
+
+	var printed *config.PDFPrint
+	printReport = func(htmlPath, path string, spec *config.PDFPrint) error {
+		assert.Equal(t, reportPath, htmlPath)
+		printed = spec
+		return os.WriteFile(path, invoicePDF("Panoptic Test Report", "Shop"), 0644)
+	}
+	defer func() { printReport = platforms.PrintPDF }()
+
+	pages, err := GenerateReportPDF(reportPath, filepath.Join(dir, "report.pdf"), "A4", true)
+	require.NoError(t, err)
+	assert.Equal(t, 2, pages)
+	assert.Equal(t, &config.PDFPrint{Format: "a4", Landscape: true, PrintBackground: true, Scale: 1}, printed)
+
+	_, err = GenerateReportPDF(reportPath, filepath.Join(dir, "report.pdf"), "", false)
+	require.NoError(t, err)
+	assert.Equal(t, "letter", printed.Format)
+
+	_, err = GenerateReportPDF(reportPath, filepath.Join(dir, "report.pdf"), "b5", false)
+	assert.EqualError(t, err, `unknown paper format "b5"; use letter, legal, tabloid, a3, a4 or a5`)
+
+	printReport = func(htmlPath, path string, spec *config.PDFPrint) error {
+		return os.WriteFile(path, []byte("<html>"), 0644)
+	}
+	_, err = GenerateReportPDF(reportPath, filepath.Join(dir, "report.pdf"), "", false)
+	assert.ErrorContains(t, err, "the printed report is not a readable PDF")
+}
@@ -0,0 +1,71 @@
+package executor
+
+import (
+	"fmt"
+	"strings"
+
+	"panoptic/internal/config"
+	"panoptic/internal/platforms"
+	"panoptic/internal/resources"
+)
+
+// processIdentifier is implemented by platforms that drive a local process
+type processIdentifier interface {
+	ProcessID() int
+}
+
+// startResourceMonitor samples the platform's process when settings.resources
+// is enabled. It returns nil (a no-op monitor) when sampling is off or the
+// platform has no local process, e.g. a remote WebDriver session.
+func (e *Executor) startResourceMonitor(platform platforms.Platform, app config.AppConfig) *resources.Monitor {
+	settings := e.config.Settings.Resources
+	if settings == nil || !settings.Enabled {
+		return nil
+	}
+	identifier, ok := platform.(processIdentifier)
+	if !ok || identifier.ProcessID() <= 0 {
+		e.logger.Warnf("Resource monitoring unavailable for %s: no local %s process", app.Name, app.Type)
+		return nil
+	}
+	monitor, err := resources.Start(identifier.ProcessID(), settings.Interval)
+	if err != nil {
+		e.logger.Warnf("Resource monitoring unavailable for %s: %v", app.Name, err)
+		return nil
+	}
+	if settings.MaxGPUMemoryMB > 0 {
+		e.logger.Debugf("GPU memory threshold for %s is enforced only when nvidia-smi reports usage", app.Name)
+	}
+	return monitor
+}
+
+// finishResourceMonitor stops sampling, attaches the time series to the
+// result metrics and returns the exceeded thresholds. Calling it again after
+// the monitor stopped re-attaches the same data.
+func (e *Executor) finishResourceMonitor(monitor *resources.Monitor, result *TestResult) []string {
+	if monitor == nil {
+		return nil
+	}
+	usage := monitor.Stop()
+	if result.Metrics == nil {
+		result.Metrics = make(map[string]interface{})
+	}
+	result.Metrics["resource_usage"] = usage
+	result.Metrics["resource_peak_cpu_percent"] = usage.PeakCPUPercent
+	result.Metrics["resource_avg_cpu_percent"] = usage.AvgCPUPercent
+	result.Metrics["resource_peak_rss_mb"] = float64(usage.PeakRSSBytes) / (1024 * 1024)
+	if usage.GPUAvailable {
+		result.Metrics["resource_peak_gpu_memory_mb"] = float64(usage.PeakGPUMemoryBytes) / (1024 * 1024)
+	}
+
+	settings := e.config.Settings.Resources
+	return usage.Violations(resources.Thresholds{
+		MaxCPUPercent:     settings.MaxCPUPercent,
+		MaxRSSBytes:       uint64(settings.MaxRSSMB) * 1024 * 1024,
+		MaxGPUMemoryBytes: uint64(settings.MaxGPUMemoryMB) * 1024 * 1024,
+	})
+}
+
+// resourceError formats threshold violations for TestResult.Error
+func resourceError(violations []string) string {
+	return fmt.Sprintf("Resource threshold exceeded: %s", strings.Join(violations, "; "))
+}
@@ -0,0 +1,80 @@
+package executor
+
+import (
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"panoptic/internal/config"
+	"panoptic/internal/logger"
+	"panoptic/internal/resources"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// processPlatform is a MockPlatform driving a local process
+type processPlatform struct {
+	*MockPlatform
+	pid int
+}
+
+func (p *processPlatform) ProcessID() int { return p.pid }
+
+func resourceExecutor(t *testing.T, settings *config.ResourceSettings) *Executor {
+	cfg := &config.Config{Settings: config.Settings{Resources: settings}}
+	return NewExecutor(cfg, t.TempDir(), logger.NewLogger(false))
+}
+
+// TestExecutor_ResourceMonitor tests attaching sampled usage to result metrics
+func TestExecutor_ResourceMonitor(t *testing.T) {
+	executor := resourceExecutor(t, &config.ResourceSettings{Enabled: true, Interval: 10 * time.Millisecond})
+	platform := &processPlatform{MockPlatform: &MockPlatform{metrics: map[string]interface{}{}}, pid: os.Getpid()}
+	app := config.AppConfig{Name: "Shop", Type: "web"}
+
+	monitor := executor.startResourceMonitor(platform, app)
+	require.NotNil(t, monitor)
+	time.Sleep(30 * time.Millisecond)
+
+	result := &TestResult{Metrics: map[string]interface{}{}}
+	assert.Empty(t, executor.finishResourceMonitor(monitor, result))
+
+	usage, ok := result.Metrics["resource_usage"].(resources.Usage)
+	require.True(t, ok)
+	assert.Equal(t, os.Getpid(), usage.PID)
+	assert.NotEmpty(t, usage.Samples)
+	assert.Greater(t, result.Metrics["resource_peak_rss_mb"], 0.0)
+	assert.Contains(t, result.Metrics, "resource_peak_cpu_percent")
+	assert.Contains(t, result.Metrics, "resource_avg_cpu_percent")
+}
+
+// TestExecutor_ResourceThresholds tests that exceeded thresholds are reported
+func TestExecutor_ResourceThresholds(t *testing.T) {
+	executor := resourceExecutor(t, &config.ResourceSettings{Enabled: true, Interval: 10 * time.Millisecond, MaxRSSMB: 1})
+	platform := &processPlatform{MockPlatform: &MockPlatform{metrics: map[string]interface{}{}}, pid: os.Getpid()}
+
+	monitor := executor.startResourceMonitor(platform, config.AppConfig{Name: "Shop", Type: "web"})
+	require.NotNil(t, monitor)
+
+	violations := executor.finishResourceMonitor(monitor, &TestResult{})
+	require.Len(t, violations, 1, "a Go test binary uses more than 1 MB")
+	assert.True(t, strings.HasPrefix(resourceError(violations), "Resource threshold exceeded: memory peaked at "))
+}
+
+// TestExecutor_ResourceMonitorUnavailable tests the no-op cases
+func TestExecutor_ResourceMonitorUnavailable(t *testing.T) {
+	mock := &MockPlatform{metrics: map[string]interface{}{}}
+	app := config.AppConfig{Name: "Shop", Type: "web"}
+
+	assert.Nil(t, resourceExecutor(t, nil).startResourceMonitor(&processPlatform{MockPlatform: mock, pid: os.Getpid()}, app))
+	assert.Nil(t, resourceExecutor(t, &config.ResourceSettings{}).startResourceMonitor(&processPlatform{MockPlatform: mock, pid: os.Getpid()}, app))
+
+	enabled := resourceExecutor(t, &config.ResourceSettings{Enabled: true})
+	assert.Nil(t, enabled.startResourceMonitor(mock, app), "platform without a process")
+	assert.Nil(t, enabled.startResourceMonitor(&processPlatform{MockPlatform: mock}, app), "remote session")
+
+	result := &TestResult{}
+	assert.Nil(t, enabled.finishResourceMonitor(nil, result))
+	assert.Nil(t, result.Metrics)
+}
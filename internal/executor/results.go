@@ -0,0 +1,281 @@
+package executor
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"runtime"
+	"time"
+
+	"panoptic/internal/selfupdate"
+)
+
+// ResultsSchemaVersion is the version of the results.json layout. It changes
+// only when a field is removed or changes meaning; fields may be added within
+// a version, so consumers should ignore fields they don't know.
+const ResultsSchemaVersion = 1
+
+// ResultsDocument is the canonical results.json written at the end of a run
+type ResultsDocument struct {
+	SchemaVersion int           `json:"schema_version"`
+	RunID         string        `json:"run_id"`
+	StartedAt     time.Time     `json:"started_at"`
+	FinishedAt    time.Time     `json:"finished_at"`
+	Duration      time.Duration `json:"duration"` // nanoseconds, like result durations
+	Config        ConfigInfo    `json:"config"`
+	Environment   Environment   `json:"environment"`
+	Summary       RunSummary    `json:"summary"`
+	Theme         *ReportTheme  `json:"theme,omitempty"` // branding of the run's reports
+	Results       []*TestResult `json:"results"`
+	Artifacts     []Artifact    `json:"artifacts"`
+}
+
+// ConfigInfo identifies the configuration a run used
+type ConfigInfo struct {
+	Name      string `json:"name"`
+	SHA256    string `json:"sha256,omitempty"` // of the configuration file as loaded
+	TagFilter string `json:"tag_filter,omitempty"`
+	FakeSeed  int64  `json:"fake_seed"`
+}
+
+// Environment describes the machine and build that produced the results
+type Environment struct {
+	PanopticVersion string `json:"panoptic_version"`
+	GoVersion       string `json:"go_version"`
+	OS              string `json:"os"`
+	Arch            string `json:"arch"`
+	Hostname        string `json:"hostname,omitempty"`
+	CPUs            int    `json:"cpus"`
+	CI              string `json:"ci,omitempty"` // CI provider detected from the environment
+}
+
+// Artifact is a file produced for an app
+type Artifact struct {
+	App  string `json:"app"`
+	Type string `json:"type"` // screenshot, annotated_screenshot, video, pdf, trace, websocket_log, container_log or kubernetes_log
+	Path string `json:"path"`
+}
+
+// SetConfigFile records the SHA-256 of the configuration file in results.json;
+// SARIF results point at the file
+func (e *Executor) SetConfigFile(path string) error {
+	e.configPath = path
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to hash configuration: %w", err)
+	}
+	sum := sha256.Sum256(data)
+	e.configSHA256 = hex.EncodeToString(sum[:])
+	return nil
+}
+
+// SaveResults writes the collected test results as a ResultsDocument
+func (e *Executor) SaveResults(path string) error {
+	if e.stream != nil {
+		return e.saveStreamedResults(path)
+	}
+	doc := e.resultsDocument()
+	if e.redactor != nil {
+		return e.writeRedactedJSON(path, &doc)
+	}
+	return doc.Save(path)
+}
+
+// Results is the results document of the run, as SaveResults writes it;
+// with StreamResults, its results are summaries without metrics or
+// artifacts
+func (e *Executor) Results() *ResultsDocument {
+	doc := e.resultsDocument()
+	return &doc
+}
+
+func (e *Executor) resultsDocument() ResultsDocument {
+	doc := ResultsDocument{
+		SchemaVersion: ResultsSchemaVersion,
+		RunID:         e.runID,
+		StartedAt:     e.startedAt,
+		FinishedAt:    e.finishedAt,
+		Config: ConfigInfo{
+			SHA256:    e.configSHA256,
+			TagFilter: e.tagFilter.String(),
+			FakeSeed:  e.fakeSeed,
+		},
+		Environment: currentEnvironment(),
+		Summary:     e.Summary(),
+		Results:     make([]*TestResult, len(e.results)),
+		Artifacts:   make([]Artifact, 0),
+	}
+	if e.config != nil {
+		doc.Config.Name = e.config.Name
+		doc.Theme = e.reportTheme()
+	}
+	if !doc.StartedAt.IsZero() && !doc.FinishedAt.IsZero() {
+		doc.Duration = doc.FinishedAt.Sub(doc.StartedAt)
+	}
+	for i := range e.results {
+		doc.Results[i] = &e.results[i]
+		doc.Artifacts = append(doc.Artifacts, e.results[i].Artifacts()...)
+	}
+	return doc
+}
+
+// Artifacts lists the files a result refers to
+func (r *TestResult) Artifacts() []Artifact {
+	var artifacts []Artifact
+	for _, p := range r.Screenshots {
+		artifacts = append(artifacts, Artifact{App: r.AppName, Type: "screenshot", Path: p})
+	}
+	for _, a := range r.Annotated {
+		artifacts = append(artifacts, Artifact{App: r.AppName, Type: "annotated_screenshot", Path: a.Path})
+	}
+	for _, p := range r.Videos {
+		artifacts = append(artifacts, Artifact{App: r.AppName, Type: "video", Path: p})
+	}
+	pdfs, _ := r.Metrics["pdfs"].([]PDFRecord)
+	for _, p := range pdfs {
+		artifacts = append(artifacts, Artifact{App: r.AppName, Type: "pdf", Path: p.Path})
+	}
+	for _, key := range []string{"trace", "websocket_log", "container_log", "kubernetes_log"} {
+		if p, ok := r.Metrics[key].(string); ok && p != "" {
+			artifacts = append(artifacts, Artifact{App: r.AppName, Type: key, Path: p})
+		}
+	}
+	return artifacts
+}
+
+// LoadResults reads a results.json. The bare result arrays of older versions
+// become documents with only their results, summary and artifacts.
+func LoadResults(path string) (*ResultsDocument, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var doc ResultsDocument
+	if err := json.Unmarshal(data, &doc); err != nil || doc.SchemaVersion == 0 {
+		results, err := parseResults(data)
+		if err != nil {
+			return nil, fmt.Errorf("invalid results %s: %w", path, err)
+		}
+		doc = ResultsDocument{SchemaVersion: ResultsSchemaVersion, Summary: summarize(results), Artifacts: make([]Artifact, 0)}
+		for i := range results {
+			doc.Results = append(doc.Results, &results[i])
+			doc.Artifacts = append(doc.Artifacts, results[i].Artifacts()...)
+		}
+		return &doc, nil
+	}
+	if doc.SchemaVersion > ResultsSchemaVersion {
+		return nil, fmt.Errorf("results %s: schema version %d is newer than supported version %d", path, doc.SchemaVersion, ResultsSchemaVersion)
+	}
+	return &doc, nil
+}
+
+// MergeResults combines the documents of runs split across machines, like
+// the shards of one run, into one: their results and artifacts in order,
+// the summed summary, and the span from the first start to the last finish.
+// The run ID, configuration and environment are the first document's.
+func MergeResults(docs ...*ResultsDocument) *ResultsDocument {
+	merged := &ResultsDocument{SchemaVersion: ResultsSchemaVersion, Results: make([]*TestResult, 0), Artifacts: make([]Artifact, 0)}
+	for i, doc := range docs {
+		if i == 0 {
+			merged.RunID, merged.Config, merged.Environment, merged.Theme = doc.RunID, doc.Config, doc.Environment, doc.Theme
+		}
+		if !doc.StartedAt.IsZero() && (merged.StartedAt.IsZero() || doc.StartedAt.Before(merged.StartedAt)) {
+			merged.StartedAt = doc.StartedAt
+		}
+		if doc.FinishedAt.After(merged.FinishedAt) {
+			merged.FinishedAt = doc.FinishedAt
+		}
+		merged.Summary.Total += doc.Summary.Total
+		merged.Summary.Passed += doc.Summary.Passed
+		merged.Summary.Failed += doc.Summary.Failed
+		merged.Summary.Warnings += doc.Summary.Warnings
+		merged.Summary.Quarantined += doc.Summary.Quarantined
+		merged.Results = append(merged.Results, doc.Results...)
+		merged.Artifacts = append(merged.Artifacts, doc.Artifacts...)
+	}
+	if !merged.StartedAt.IsZero() && !merged.FinishedAt.IsZero() {
+		merged.Duration = merged.FinishedAt.Sub(merged.StartedAt)
+	}
+	return merged
+}
+
+// Save writes the document as results.json
+func (d *ResultsDocument) Save(path string) error {
+	data, err := json.MarshalIndent(d, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal results: %w", err)
+	}
+	return os.WriteFile(path, data, 0600)
+}
+
+// TestResults are the document's results, as GenerateComprehensiveReport
+// takes them
+func (d *ResultsDocument) TestResults() []TestResult {
+	results := make([]TestResult, len(d.Results))
+	for i, r := range d.Results {
+		results[i] = *r
+	}
+	return results
+}
+
+// parseResults reads a results.json: a ResultsDocument, or the bare result
+// array written before schema versioning (older container agent images)
+func parseResults(data []byte) ([]TestResult, error) {
+	var legacy []TestResult
+	if err := json.Unmarshal(data, &legacy); err == nil {
+		return legacy, nil
+	}
+
+	var doc struct {
+		SchemaVersion int          `json:"schema_version"`
+		Results       []TestResult `json:"results"`
+	}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+	if doc.SchemaVersion > ResultsSchemaVersion {
+		return nil, fmt.Errorf("results schema version %d is newer than supported version %d", doc.SchemaVersion, ResultsSchemaVersion)
+	}
+	return doc.Results, nil
+}
+
+func currentEnvironment() Environment {
+	env := Environment{
+		PanopticVersion: "(devel)",
+		GoVersion:       runtime.Version(),
+		OS:              runtime.GOOS,
+		Arch:            runtime.GOARCH,
+		CPUs:            runtime.NumCPU(),
+		CI:              detectCI(),
+	}
+	if version := selfupdate.Version(); version != selfupdate.Devel {
+		env.PanopticVersion = version
+	}
+	if hostname, err := os.Hostname(); err == nil {
+		env.Hostname = hostname
+	}
+	return env
+}
+
+// ciProviders maps an environment variable set by a CI service to its name
+var ciProviders = []struct{ env, name string }{
+	{"GITHUB_ACTIONS", "github-actions"},
+	{"GITLAB_CI", "gitlab"},
+	{"JENKINS_URL", "jenkins"},
+	{"CIRCLECI", "circleci"},
+	{"BUILDKITE", "buildkite"},
+	{"TF_BUILD", "azure-pipelines"},
+	{"TEAMCITY_VERSION", "teamcity"},
+	{"CI", "generic"},
+}
+
+func detectCI() string {
+	for _, p := range ciProviders {
+		if os.Getenv(p.env) != "" {
+			return p.name
+		}
+	}
+	return ""
+}
@@ -0,0 +1,196 @@
+package executor
+
+import (
+	"encoding/json"
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"panoptic/internal/config"
+	"panoptic/internal/logger"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var updateGolden = flag.Bool("update", false, "rewrite golden files")
+
+// goldenExecutor returns an executor with fixed results for the golden file
+func goldenExecutor(t *testing.T) *Executor {
+	configPath := filepath.Join(t.TempDir(), "panoptic.yaml")
+	require.NoError(t, os.WriteFile(configPath, []byte("name: golden\n"), 0600))
+
+	start := time.Date(2026, 5, 4, 10, 0, 0, 0, time.UTC)
+	executor := NewExecutor(&config.Config{Name: "golden"}, t.TempDir(), logger.NewLogger(false))
+	executor.SetRunID("0b8d5b8e-6f2d-4bd4-9a51-6f46f3c1d3a1")
+	executor.SetFakeSeed(42)
+	require.NoError(t, executor.SetConfigFile(configPath))
+	filter, _ := config.ParseTagFilter("smoke,!slow")
+	executor.SetTagFilter(filter)
+	executor.startedAt = start
+	executor.finishedAt = start.Add(90 * time.Second)
+	executor.results = []TestResult{
+		{
+			AppName:      "Shop [chromium]",
+			AppType:      "web",
+			Browser:      "chromium",
+			Tags:         []string{"smoke", "checkout"},
+			Matrix:       map[string]string{"locale": "de"},
+			FeatureFlags: map[string]interface{}{"new-checkout": true},
+			StartTime:    start,
+			EndTime:      start.Add(time.Minute),
+			Duration:     time.Minute,
+			Metrics:      map[string]interface{}{"trace": "output/traces/Shop.zip"},
+			Screenshots:  []string{"output/screenshots/home.png"},
+			Videos:       []string{"output/videos/checkout.mp4"},
+			Success:      true,
+			Annotated: []AnnotatedScreenshot{{
+				Screenshot: "output/screenshots/home.png", Path: "output/screenshots/home_annotated.png", Labels: []string{"#total 3.10:1 < 4.5:1"},
+			}},
+			ScreenHashes: []ScreenHash{{Screenshot: "output/screenshots/home.png", Screen: "home", PHash: 0xc3a5f00f0ff0a53c}},
+		},
+		{
+			AppName:          "Admin",
+			AppType:          "web",
+			StartTime:        start.Add(time.Minute),
+			EndTime:          start.Add(90 * time.Second),
+			Duration:         30 * time.Second,
+			Metrics:          map[string]interface{}{"failed_action": "login"},
+			Screenshots:      []string{},
+			Videos:           []string{},
+			Error:            "Action 'login' failed: timeout",
+			FailureCategory:  config.CategoryFunctional,
+			Severity:         config.SeverityError,
+			Quarantined:      true,
+			QuarantineReason: "SSO outage",
+		},
+	}
+	return executor
+}
+
+// TestResultsDocument_Golden pins the results.json schema; run with -update
+// after an intentional change and bump ResultsSchemaVersion if a field was
+// removed or changed meaning
+func TestResultsDocument_Golden(t *testing.T) {
+	doc := goldenExecutor(t).resultsDocument()
+	doc.Environment = Environment{PanopticVersion: "v1.0.0", GoVersion: "go1.25.0", OS: "linux", Arch: "amd64", Hostname: "ci-runner", CPUs: 8, CI: "github-actions"}
+
+	got, err := json.MarshalIndent(doc, "", "  ")
+	require.NoError(t, err)
+	got = append(got, '\n')
+
+	golden := filepath.Join("testdata", "results_v1.golden.json")
+	if *updateGolden {
+		require.NoError(t, os.MkdirAll("testdata", 0755))
+		require.NoError(t, os.WriteFile(golden, got, 0644))
+	}
+	want, err := os.ReadFile(golden)
+	require.NoError(t, err)
+	assert.JSONEq(t, string(want), string(got))
+}
+
+// TestExecutor_SaveResults_Document tests the written document and reading it back
+func TestExecutor_SaveResults_Document(t *testing.T) {
+	executor := goldenExecutor(t)
+	path := filepath.Join(t.TempDir(), "results.json")
+	require.NoError(t, executor.SaveResults(path))
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	var doc ResultsDocument
+	require.NoError(t, json.Unmarshal(data, &doc))
+	assert.Equal(t, ResultsSchemaVersion, doc.SchemaVersion)
+	assert.Equal(t, RunSummary{Total: 2, Passed: 1, Quarantined: 1}, doc.Summary)
+	assert.Len(t, doc.Config.SHA256, 64)
+	assert.Equal(t, 90*time.Second, doc.Duration)
+	assert.NotEmpty(t, doc.Environment.GoVersion)
+	assert.Equal(t, []Artifact{
+		{App: "Shop [chromium]", Type: "screenshot", Path: "output/screenshots/home.png"},
+		{App: "Shop [chromium]", Type: "annotated_screenshot", Path: "output/screenshots/home_annotated.png"},
+		{App: "Shop [chromium]", Type: "video", Path: "output/videos/checkout.mp4"},
+		{App: "Shop [chromium]", Type: "trace", Path: "output/traces/Shop.zip"},
+	}, doc.Artifacts)
+
+	results, err := parseResults(data)
+	require.NoError(t, err)
+	require.Len(t, results, 2)
+	assert.Equal(t, "SSO outage", results[1].QuarantineReason)
+}
+
+// TestParseResults tests legacy arrays and newer schema versions
+func TestParseResults(t *testing.T) {
+	results, err := parseResults([]byte(`[{"app_name":"Web App","success":true}]`))
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+	assert.True(t, results[0].Success)
+
+	_, err = parseResults([]byte(`{"schema_version":99,"results":[]}`))
+	assert.ErrorContains(t, err, "newer than supported")
+
+	_, err = parseResults([]byte(`not json`))
+	assert.Error(t, err)
+}
+
+// TestLoadResults tests reading documents and legacy arrays
+func TestLoadResults(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "results.json")
+	require.NoError(t, goldenExecutor(t).SaveResults(path))
+	doc, err := LoadResults(path)
+	require.NoError(t, err)
+	assert.Equal(t, "0b8d5b8e-6f2d-4bd4-9a51-6f46f3c1d3a1", doc.RunID)
+	assert.Equal(t, RunSummary{Total: 2, Passed: 1, Quarantined: 1}, doc.Summary)
+	assert.Equal(t, "Admin", doc.TestResults()[1].AppName)
+
+	legacy := filepath.Join(dir, "legacy.json")
+	require.NoError(t, os.WriteFile(legacy, []byte(`[{"app_name":"Web App","success":true,"screenshots":["a.png"]},{"app_name":"API"}]`), 0600))
+	doc, err = LoadResults(legacy)
+	require.NoError(t, err)
+	assert.Equal(t, ResultsSchemaVersion, doc.SchemaVersion)
+	assert.Equal(t, RunSummary{Total: 2, Passed: 1, Failed: 1}, doc.Summary)
+	assert.Equal(t, []Artifact{{App: "Web App", Type: "screenshot", Path: "a.png"}}, doc.Artifacts)
+
+	require.NoError(t, os.WriteFile(legacy, []byte(`{"schema_version":99,"results":[]}`), 0600))
+	_, err = LoadResults(legacy)
+	assert.EqualError(t, err, "results "+legacy+": schema version 99 is newer than supported version 1")
+	require.NoError(t, os.WriteFile(legacy, []byte(`not json`), 0600))
+	_, err = LoadResults(legacy)
+	assert.ErrorContains(t, err, "invalid results "+legacy)
+}
+
+// TestMergeResults tests combining the documents of shards
+func TestMergeResults(t *testing.T) {
+	start := time.Date(2026, 5, 4, 10, 0, 0, 0, time.UTC)
+	first := goldenExecutor(t).resultsDocument()
+	second := ResultsDocument{
+		RunID:      "other",
+		StartedAt:  start.Add(-time.Minute),
+		FinishedAt: start.Add(5 * time.Minute),
+		Summary:    RunSummary{Total: 1, Failed: 1},
+		Results:    []*TestResult{{AppName: "Docs", Videos: []string{"docs.mp4"}}},
+		Artifacts:  []Artifact{{App: "Docs", Type: "video", Path: "docs.mp4"}},
+	}
+	merged := MergeResults(&first, &second)
+	assert.Equal(t, first.RunID, merged.RunID)
+	assert.Equal(t, first.Config, merged.Config)
+	assert.Equal(t, RunSummary{Total: 3, Passed: 1, Failed: 1, Quarantined: 1}, merged.Summary)
+	assert.Equal(t, start.Add(-time.Minute), merged.StartedAt)
+	assert.Equal(t, 6*time.Minute, merged.Duration)
+	require.Len(t, merged.Results, 3)
+	assert.Equal(t, "Docs", merged.Results[2].AppName)
+	assert.Len(t, merged.Artifacts, len(first.Artifacts)+1)
+}
+
+// TestDetectCI tests CI provider detection
+func TestDetectCI(t *testing.T) {
+	for _, p := range ciProviders {
+		t.Setenv(p.env, "")
+	}
+	assert.Empty(t, detectCI())
+	t.Setenv("CI", "true")
+	assert.Equal(t, "generic", detectCI())
+	t.Setenv("GITLAB_CI", "true")
+	assert.Equal(t, "gitlab", detectCI())
+}
@@ -0,0 +1,183 @@
+package executor
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"panoptic/internal/config"
+	"panoptic/internal/history"
+)
+
+// artifactDirs are the directories of the output directory holding the
+// files of past runs that retention removes. Results, reports, the run
+// history and baselines are never removed.
+var artifactDirs = []string{"screenshots", "videos", "traces", "bundles", "pdfs", "websockets", "containers", "kubernetes", "load", "soak"}
+
+// Why retention removes an artifact
+const (
+	RetentionAge  = "max_age_days"
+	RetentionRuns = "max_runs"
+	RetentionSize = "max_size_gb"
+)
+
+// RetentionReport is what enforcing settings.retention removed from an
+// output directory, or would remove in a dry run
+type RetentionReport struct {
+	DryRun       bool              `json:"dry_run"`
+	Removed      []RemovedArtifact `json:"removed"`
+	RemovedBytes int64             `json:"removed_bytes"`
+	KeptFiles    int               `json:"kept_files"`
+	KeptBytes    int64             `json:"kept_bytes"`
+}
+
+// RemovedArtifact is a file retention removed and the bound it exceeded
+type RemovedArtifact struct {
+	Path     string    `json:"path"`
+	Size     int64     `json:"size"`
+	Modified time.Time `json:"modified"`
+	Reason   string    `json:"reason"`
+}
+
+type artifactFile struct {
+	path     string
+	size     int64
+	modified time.Time
+}
+
+// ApplyRetention removes the artifacts of dir beyond the bounds of
+// settings, oldest first: those older than max_age_days, those of runs
+// before the max_runs most recent ones, told apart by the run history of
+// dir, and then the oldest until max_size_gb is met. With dryRun nothing is
+// removed. Runs count the one about to start, so max_runs 1 keeps nothing
+// of earlier runs.
+func ApplyRetention(dir string, settings *config.RetentionSettings, now time.Time, dryRun bool) (*RetentionReport, error) {
+	report := &RetentionReport{DryRun: dryRun, Removed: []RemovedArtifact{}}
+	if !settings.Enabled() {
+		return report, nil
+	}
+	files, err := listArtifacts(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	// A run's artifacts are those written after the previous run was
+	// recorded in the history
+	var runsCutoff time.Time
+	if settings.MaxRuns > 0 {
+		records, err := history.Load(filepath.Join(dir, history.FileName))
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			return nil, err
+		}
+		sort.Slice(records, func(i, j int) bool { return records[i].Time.Before(records[j].Time) })
+		if keep := settings.MaxRuns - 1; len(records) > keep {
+			runsCutoff = records[len(records)-1-keep].Time
+		}
+	}
+	var ageCutoff time.Time
+	if settings.MaxAgeDays > 0 {
+		ageCutoff = now.AddDate(0, 0, -settings.MaxAgeDays)
+	}
+
+	var kept []artifactFile
+	remove := func(f artifactFile, reason string) error {
+		if !dryRun {
+			if err := os.Remove(f.path); err != nil && !errors.Is(err, os.ErrNotExist) {
+				return fmt.Errorf("failed to remove artifact: %w", err)
+			}
+		}
+		report.Removed = append(report.Removed, RemovedArtifact{Path: f.path, Size: f.size, Modified: f.modified, Reason: reason})
+		report.RemovedBytes += f.size
+		return nil
+	}
+	for _, f := range files {
+		var err error
+		switch {
+		case !ageCutoff.IsZero() && f.modified.Before(ageCutoff):
+			err = remove(f, RetentionAge)
+		case !runsCutoff.IsZero() && !f.modified.After(runsCutoff):
+			err = remove(f, RetentionRuns)
+		default:
+			kept = append(kept, f)
+			report.KeptBytes += f.size
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	if settings.MaxSizeGB > 0 {
+		limit := int64(settings.MaxSizeGB * (1 << 30))
+		for len(kept) > 0 && report.KeptBytes > limit {
+			if err := remove(kept[0], RetentionSize); err != nil {
+				return nil, err
+			}
+			report.KeptBytes -= kept[0].size
+			kept = kept[1:]
+		}
+	}
+	report.KeptFiles = len(kept)
+	return report, nil
+}
+
+// listArtifacts lists the files of the artifact directories of dir, oldest
+// first
+func listArtifacts(dir string) ([]artifactFile, error) {
+	var files []artifactFile
+	for _, name := range artifactDirs {
+		err := filepath.WalkDir(filepath.Join(dir, name), func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				if errors.Is(err, fs.ErrNotExist) {
+					return nil
+				}
+				return err
+			}
+			if !d.Type().IsRegular() {
+				return nil
+			}
+			info, err := d.Info()
+			if err != nil {
+				return err
+			}
+			files = append(files, artifactFile{path: path, size: info.Size(), modified: info.ModTime()})
+			return nil
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to list artifacts: %w", err)
+		}
+	}
+	sort.Slice(files, func(i, j int) bool { return files[i].modified.Before(files[j].modified) })
+	return files, nil
+}
+
+// enforceRetention applies settings.retention to the output directory as a
+// run starts, logging what it removes
+func (e *Executor) enforceRetention() {
+	settings := e.retentionSettings()
+	if !settings.Enabled() {
+		return
+	}
+	if e.checkpoints != nil && e.checkpoints.resume != nil {
+		e.logger.Infof("Retention skipped: resuming run %s keeps its artifacts", e.checkpoints.resume.RunID)
+		return
+	}
+	report, err := ApplyRetention(e.outputDir, settings, time.Now(), settings.DryRun)
+	if err != nil {
+		e.logger.Warnf("Retention: %v", err)
+		return
+	}
+	verb := "removed"
+	if report.DryRun {
+		verb = "would remove"
+		for _, f := range report.Removed {
+			e.logger.Infof("Retention would remove %s (%s)", f.Path, f.Reason)
+		}
+	}
+	if len(report.Removed) > 0 {
+		e.logger.Infof("Retention %s %d artifact(s), %.1f MB; %d kept, %.1f MB",
+			verb, len(report.Removed), float64(report.RemovedBytes)/(1024*1024), report.KeptFiles, float64(report.KeptBytes)/(1024*1024))
+	}
+}
@@ -0,0 +1,108 @@
+package executor
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"panoptic/internal/config"
+	"panoptic/internal/history"
+	"panoptic/internal/logger"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writeArtifact writes a file of size bytes modified at a time
+func writeArtifact(t *testing.T, dir, name string, size int, modified time.Time) string {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+	require.NoError(t, os.WriteFile(path, make([]byte, size), 0644))
+	require.NoError(t, os.Chtimes(path, modified, modified))
+	return path
+}
+
+func removedPaths(report *RetentionReport) map[string]string {
+	paths := make(map[string]string, len(report.Removed))
+	for _, r := range report.Removed {
+		paths[filepath.Base(r.Path)] = r.Reason
+	}
+	return paths
+}
+
+func TestApplyRetention(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	day := 24 * time.Hour
+	dir := t.TempDir()
+	// Three runs recorded in the history, a day apart
+	for i, ago := range []time.Duration{3 * day, 2 * day, day} {
+		require.NoError(t, history.Append(filepath.Join(dir, history.FileName), history.Record{RunID: string(rune('a' + i)), Time: now.Add(-ago)}))
+	}
+	writeArtifact(t, dir, "screenshots/ancient.png", 10, now.Add(-40*day))
+	writeArtifact(t, dir, "screenshots/run_a.png", 10, now.Add(-3*day-time.Hour))
+	writeArtifact(t, dir, "videos/run_b.mp4", 10, now.Add(-2*day-time.Hour))
+	writeArtifact(t, dir, "traces/run_c.zip", 10, now.Add(-day-time.Hour))
+	writeArtifact(t, dir, "containers/Shop/run_c.log", 10, now.Add(-day-time.Hour))
+	results := writeArtifact(t, dir, "results.json", 10, now.Add(-40*day))
+	baseline := writeArtifact(t, dir, "baselines/home.png", 10, now.Add(-40*day))
+
+	// A dry run removes nothing
+	settings := &config.RetentionSettings{MaxRuns: 2, MaxAgeDays: 30}
+	report, err := ApplyRetention(dir, settings, now, true)
+	require.NoError(t, err)
+	assert.True(t, report.DryRun)
+	assert.Equal(t, map[string]string{"ancient.png": RetentionAge, "run_a.png": RetentionRuns, "run_b.mp4": RetentionRuns}, removedPaths(report))
+	assert.Equal(t, int64(30), report.RemovedBytes)
+	assert.Equal(t, 2, report.KeptFiles)
+	assert.FileExists(t, filepath.Join(dir, "screenshots", "ancient.png"))
+
+	report, err = ApplyRetention(dir, settings, now, false)
+	require.NoError(t, err)
+	assert.Len(t, report.Removed, 3)
+	assert.NoFileExists(t, filepath.Join(dir, "screenshots", "ancient.png"))
+	assert.NoFileExists(t, filepath.Join(dir, "videos", "run_b.mp4"))
+	assert.FileExists(t, filepath.Join(dir, "traces", "run_c.zip"))
+	assert.FileExists(t, results, "results are never removed")
+	assert.FileExists(t, baseline, "baselines are never removed")
+
+	// Over the size bound, the oldest go first
+	writeArtifact(t, dir, "screenshots/big.png", 1<<20, now)
+	report, err = ApplyRetention(dir, &config.RetentionSettings{MaxSizeGB: 1.0 / 1024}, now, false)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"run_c.zip": RetentionSize, "run_c.log": RetentionSize}, removedPaths(report))
+	assert.Equal(t, 1, report.KeptFiles)
+	assert.Equal(t, int64(1<<20), report.KeptBytes)
+
+	report, err = ApplyRetention(dir, nil, now, false)
+	require.NoError(t, err)
+	assert.Empty(t, report.Removed, "no bounds")
+}
+
+func TestApplyRetention_NoHistory(t *testing.T) {
+	dir := t.TempDir()
+	writeArtifact(t, dir, "screenshots/home.png", 10, time.Now())
+	report, err := ApplyRetention(dir, &config.RetentionSettings{MaxRuns: 1}, time.Now(), false)
+	require.NoError(t, err)
+	assert.Empty(t, report.Removed, "without a history no run is told apart")
+	assert.Equal(t, 1, report.KeptFiles)
+}
+
+// TestExecutor_Run_Retention tests that a run removes the artifacts of old
+// runs as it starts, and only logs them in a dry run
+func TestExecutor_Run_Retention(t *testing.T) {
+	for _, dryRun := range []bool{false, true} {
+		outputDir := t.TempDir()
+		old := writeArtifact(t, outputDir, "screenshots/old.png", 10, time.Now().AddDate(0, 0, -10))
+		cfg, _ := manifestConfig(t)
+		cfg.Settings.Retention = &config.RetentionSettings{MaxAgeDays: 7, DryRun: dryRun}
+		executor := NewExecutor(cfg, outputDir, logger.NewLogger(false))
+		require.NoError(t, executor.Run())
+		if dryRun {
+			assert.FileExists(t, old)
+		} else {
+			assert.NoFileExists(t, old)
+		}
+	}
+}
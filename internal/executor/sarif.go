@@ -0,0 +1,182 @@
+package executor
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"panoptic/internal/sarif"
+)
+
+// Finding is an error the smart error detector found on a page. Findings don't
+// fail the app; they are reported in the SARIF log and results.json.
+type Finding struct {
+	Action      string   `json:"action"`
+	Type        string   `json:"type"`
+	Category    string   `json:"category,omitempty"`
+	Severity    string   `json:"severity,omitempty"`
+	Message     string   `json:"message"`
+	Confidence  float64  `json:"confidence,omitempty"`
+	Suggestions []string `json:"suggestions,omitempty"`
+}
+
+// recordDetectedErrors adds the maps returned by the AI tester's DetectErrors
+// to the result's findings
+func recordDetectedErrors(result *TestResult, action string, detected []interface{}) {
+	for _, d := range detected {
+		m, ok := d.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		f := Finding{Action: action}
+		f.Type, _ = m["type"].(string)
+		f.Category, _ = m["category"].(string)
+		f.Severity, _ = m["severity"].(string)
+		f.Message, _ = m["description"].(string)
+		f.Confidence, _ = m["confidence"].(float64)
+		f.Suggestions, _ = m["suggestions"].([]string)
+		if f.Type == "" {
+			f.Type = "unknown"
+		}
+		result.Findings = append(result.Findings, f)
+	}
+}
+
+// SaveSARIF writes failed apps and detected errors as a SARIF 2.1.0 log.
+// Results point at the app or action in the configuration file given to
+// SetConfigFile, so code scanning annotates the test definition.
+func (e *Executor) SaveSARIF(path string) error {
+	log := sarif.NewLog("Panoptic", currentEnvironment().PanopticVersion, "https://github.com/vasic-digital/Panoptic")
+	locator := newConfigLocator(e.configPath)
+
+	for i := range e.results {
+		r := &e.results[i]
+		app := strings.TrimSuffix(r.AppName, " ["+r.Browser+"]")
+
+		if !r.Success {
+			category := r.FailureCategory
+			if category == "" {
+				category = "functional"
+			}
+			level := sarif.LevelFromSeverity(r.Severity)
+			if r.Quarantined {
+				level = sarif.LevelNote
+			}
+			action, _ := r.Metrics["failed_action"].(string)
+			ruleID := "panoptic/failure/" + category
+			log.AddRule(sarif.Rule{
+				ID:                   ruleID,
+				Name:                 "Failure" + strings.ToUpper(category[:1]) + category[1:],
+				ShortDescription:     &sarif.Message{Text: fmt.Sprintf("Panoptic %s failure", category)},
+				DefaultConfiguration: &sarif.RuleConfig{Level: sarif.LevelError},
+			})
+			message := fmt.Sprintf("%s failed: %s", r.AppName, r.Error)
+			if r.Quarantined {
+				message += fmt.Sprintf(" (quarantined: %s)", r.QuarantineReason)
+			}
+			if err := log.AddResult(sarif.Result{
+				RuleID:     ruleID,
+				Level:      level,
+				Message:    sarif.Message{Text: message},
+				Locations:  locator.locations(app, action),
+				Properties: map[string]interface{}{"app": r.AppName, "quarantined": r.Quarantined},
+			}); err != nil {
+				return err
+			}
+		}
+
+		for _, f := range r.Findings {
+			ruleID := "panoptic/detected/" + f.Type
+			rule := sarif.Rule{ID: ruleID, Name: f.Type, ShortDescription: &sarif.Message{Text: f.Type}}
+			if f.Category != "" {
+				rule.Properties = map[string]string{"category": f.Category}
+			}
+			if len(f.Suggestions) > 0 {
+				rule.Help = &sarif.Message{Text: strings.Join(f.Suggestions, "\n")}
+			}
+			log.AddRule(rule)
+			if err := log.AddResult(sarif.Result{
+				RuleID:     ruleID,
+				Level:      sarif.LevelFromSeverity(f.Severity),
+				Message:    sarif.Message{Text: fmt.Sprintf("%s / %s: %s", r.AppName, f.Action, f.Message)},
+				Locations:  locator.locations(app, f.Action),
+				Properties: map[string]interface{}{"app": r.AppName, "confidence": f.Confidence},
+			}); err != nil {
+				return err
+			}
+		}
+	}
+
+	if err := log.Write(path); err != nil {
+		return fmt.Errorf("failed to save SARIF log: %w", err)
+	}
+	return nil
+}
+
+// configLocator finds the lines of app and action names in the configuration
+// file. Apps and actions are matched by their "name:" line; an action is the
+// first one with that name after its app.
+type configLocator struct {
+	uri   string
+	lines []string
+}
+
+var nameLine = regexp.MustCompile(`^\s*(?:-\s+)?name:\s*["']?(.*?)["']?\s*$`)
+
+func newConfigLocator(path string) *configLocator {
+	l := &configLocator{}
+	if path == "" {
+		return l
+	}
+	l.uri = filepath.ToSlash(path)
+	if wd, err := os.Getwd(); err == nil {
+		if rel, err := filepath.Rel(wd, path); err == nil && !strings.HasPrefix(rel, "..") {
+			l.uri = filepath.ToSlash(rel)
+		}
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return l
+	}
+	defer f.Close()
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		l.lines = append(l.lines, scanner.Text())
+	}
+	return l
+}
+
+// line returns the 1-based line of name at or after line from, or 0
+func (l *configLocator) line(name string, from int) int {
+	for i := from; i < len(l.lines); i++ {
+		if m := nameLine.FindStringSubmatch(l.lines[i]); m != nil && m[1] == name {
+			return i + 1
+		}
+	}
+	return 0
+}
+
+func (l *configLocator) locations(app, action string) []sarif.Location {
+	fqn := app
+	if action != "" {
+		fqn += "/" + action
+	}
+	location := sarif.Location{LogicalLocations: []sarif.LogicalLocation{{Name: fqn, FullyQualifiedName: fqn, Kind: "test"}}}
+	if l.uri != "" {
+		physical := &sarif.PhysicalLocation{ArtifactLocation: sarif.ArtifactLocation{URI: l.uri}}
+		line := l.line(app, 0)
+		if action != "" && line > 0 {
+			if actionLine := l.line(action, line); actionLine > 0 {
+				line = actionLine
+			}
+		}
+		if line > 0 {
+			physical.Region = &sarif.Region{StartLine: line}
+		}
+		location.PhysicalLocation = physical
+	}
+	return []sarif.Location{location}
+}
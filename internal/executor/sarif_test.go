@@ -0,0 +1,114 @@
+package executor
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"panoptic/internal/config"
+	"panoptic/internal/logger"
+	"panoptic/internal/sarif"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const sarifConfig = `name: shop
+apps:
+  - name: Shop
+    type: web
+    actions:
+      - name: home
+        type: navigate
+      - name: checkout
+        type: click
+  - name: "Admin"
+    type: web
+`
+
+// TestRecordDetectedErrors tests conversion of the AI tester's error maps
+func TestRecordDetectedErrors(t *testing.T) {
+	var result TestResult
+	recordDetectedErrors(&result, "scan", []interface{}{
+		map[string]interface{}{"type": "ResourceError", "category": "network", "severity": "medium",
+			"confidence": 0.9, "description": "Resource failed to load", "suggestions": []string{"Check resource URL"}},
+		map[string]interface{}{"description": "untyped"},
+		"not a map",
+	})
+	require.Len(t, result.Findings, 2)
+	assert.Equal(t, Finding{Action: "scan", Type: "ResourceError", Category: "network", Severity: "medium",
+		Message: "Resource failed to load", Confidence: 0.9, Suggestions: []string{"Check resource URL"}}, result.Findings[0])
+	assert.Equal(t, "unknown", result.Findings[1].Type)
+
+	data, err := json.Marshal(&result)
+	require.NoError(t, err)
+	var back TestResult
+	require.NoError(t, json.Unmarshal(data, &back))
+	assert.Equal(t, result.Findings, back.Findings)
+}
+
+// TestExecutor_SaveSARIF tests rules, levels and config file locations
+func TestExecutor_SaveSARIF(t *testing.T) {
+	dir := t.TempDir()
+	configPath := filepath.Join(dir, "panoptic.yaml")
+	require.NoError(t, os.WriteFile(configPath, []byte(sarifConfig), 0600))
+
+	executor := NewExecutor(&config.Config{Name: "shop"}, dir, logger.NewLogger(false))
+	require.NoError(t, executor.SetConfigFile(configPath))
+	executor.results = []TestResult{
+		{AppName: "Shop [firefox]", Browser: "firefox", Metrics: map[string]interface{}{"failed_action": "checkout"},
+			Error: "Action 'checkout' failed", FailureCategory: config.CategoryFunctional, Severity: config.SeverityError},
+		{AppName: "Admin", Success: true, Findings: []Finding{{Action: "home", Type: "ResourceError", Severity: "low", Message: "404"}}},
+		{AppName: "Admin", Error: "slow", FailureCategory: config.CategoryPerformance, Severity: config.SeverityWarning, Quarantined: true, QuarantineReason: "CDN"},
+		{AppName: "Passing", Success: true},
+	}
+
+	path := filepath.Join(dir, "results.sarif")
+	require.NoError(t, executor.SaveSARIF(path))
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	var log sarif.Log
+	require.NoError(t, json.Unmarshal(data, &log))
+	require.Len(t, log.Runs, 1)
+	run := log.Runs[0]
+
+	var rules []string
+	for _, r := range run.Tool.Driver.Rules {
+		rules = append(rules, r.ID)
+	}
+	assert.Equal(t, []string{"panoptic/failure/functional", "panoptic/detected/ResourceError", "panoptic/failure/performance"}, rules)
+	require.Len(t, run.Results, 3)
+
+	checkout := run.Results[0]
+	assert.Equal(t, sarif.LevelError, checkout.Level)
+	assert.Equal(t, 8, checkout.Locations[0].PhysicalLocation.Region.StartLine, "points at the failed action")
+	assert.Equal(t, "Shop/checkout", checkout.Locations[0].LogicalLocations[0].FullyQualifiedName)
+
+	finding := run.Results[1]
+	assert.Equal(t, sarif.LevelNote, finding.Level)
+	assert.Equal(t, 1, finding.RuleIndex)
+	assert.Equal(t, 10, finding.Locations[0].PhysicalLocation.Region.StartLine, "falls back to the app when the action isn't under it")
+
+	quarantined := run.Results[2]
+	assert.Equal(t, sarif.LevelNote, quarantined.Level)
+	assert.Contains(t, quarantined.Message.Text, "quarantined: CDN")
+}
+
+// TestExecutor_SaveSARIF_NoConfigFile tests logical-only locations
+func TestExecutor_SaveSARIF_NoConfigFile(t *testing.T) {
+	executor := NewExecutor(&config.Config{}, t.TempDir(), logger.NewLogger(false))
+	executor.results = []TestResult{{AppName: "App", Error: "boom"}}
+	path := filepath.Join(t.TempDir(), "results.sarif")
+	require.NoError(t, executor.SaveSARIF(path))
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	var log sarif.Log
+	require.NoError(t, json.Unmarshal(data, &log))
+	require.Len(t, log.Runs[0].Results, 1)
+	assert.Nil(t, log.Runs[0].Results[0].Locations[0].PhysicalLocation)
+	assert.Equal(t, "panoptic/failure/functional", log.Runs[0].Results[0].RuleID)
+
+	assert.Error(t, executor.SaveSARIF(filepath.Join(t.TempDir(), "missing", "results.sarif")))
+}
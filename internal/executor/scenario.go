@@ -0,0 +1,72 @@
+package executor
+
+import (
+	"panoptic/internal/config"
+)
+
+// Statuses of a Gherkin step
+const (
+	StepPassed  = "passed"
+	StepFailed  = "failed"
+	StepSkipped = "skipped" // didn't run: after a failed step, or left out by --tags
+)
+
+// ScenarioResult is the outcome of the Gherkin scenario an app was expanded
+// from, step by step
+type ScenarioResult struct {
+	Feature string       `json:"feature"`
+	Name    string       `json:"name"`
+	File    string       `json:"file"`
+	Line    int          `json:"line"`
+	Steps   []StepResult `json:"steps"`
+}
+
+// StepResult is the outcome of a step of a scenario
+type StepResult struct {
+	Keyword string `json:"keyword"`
+	Text    string `json:"text"`
+	Line    int    `json:"line"`
+	Status  string `json:"status"`
+}
+
+// scenarioResult tells which steps of the app's scenario passed from the
+// action that failed and those --tags left out. The steps of an app that
+// failed before its first action are all skipped; one that failed on its
+// resource limits ran them all.
+func scenarioResult(app config.AppConfig, result *TestResult) *ScenarioResult {
+	s := app.Scenario
+	if s == nil {
+		return nil
+	}
+	failed, _ := result.Metrics["failed_action"].(string)
+	skipped := make(map[string]bool)
+	if names, ok := result.Metrics["skipped_actions"].([]string); ok {
+		for _, name := range names {
+			skipped[name] = true
+		}
+	}
+	ran := result.Success || failed != "" || result.FailureCategory == config.CategoryResources
+
+	scenario := &ScenarioResult{Feature: s.Feature, Name: s.Name, File: s.File, Line: s.Line}
+	for _, step := range s.Steps {
+		status := StepSkipped
+		if ran {
+			status = StepPassed
+			left := 0
+			for _, action := range step.Actions {
+				if action == failed {
+					status = StepFailed
+					ran = false
+				}
+				if skipped[action] {
+					left++
+				}
+			}
+			if left == len(step.Actions) {
+				status = StepSkipped
+			}
+		}
+		scenario.Steps = append(scenario.Steps, StepResult{Keyword: step.Keyword, Text: step.Text, Line: step.Line, Status: status})
+	}
+	return scenario
+}
@@ -0,0 +1,52 @@
+package executor
+
+import (
+	"testing"
+
+	"panoptic/internal/config"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func scenarioApp() config.AppConfig {
+	return config.AppConfig{Name: "Cart: Add socks", Scenario: &config.Scenario{
+		Feature: "Cart", Name: "Add socks", File: "cart.feature", Line: 3,
+		Steps: []config.ScenarioStep{
+			{Keyword: "Given", Text: "I open the shop", Line: 4, Actions: []string{"Given I open the shop"}},
+			{Keyword: "When", Text: "I add socks", Line: 5, Actions: []string{"When I add socks (search)", "When I add socks (click)"}},
+			{Keyword: "Then", Text: "I see the cart", Line: 6, Actions: []string{"Then I see the cart"}},
+		},
+	}}
+}
+
+func stepStatuses(s *ScenarioResult) []string {
+	var statuses []string
+	for _, step := range s.Steps {
+		statuses = append(statuses, step.Status)
+	}
+	return statuses
+}
+
+func TestScenarioResult(t *testing.T) {
+	app := scenarioApp()
+
+	passed := scenarioResult(app, &TestResult{Success: true, Metrics: map[string]interface{}{}})
+	assert.Equal(t, "Cart", passed.Feature)
+	assert.Equal(t, "cart.feature", passed.File)
+	assert.Equal(t, StepResult{Keyword: "When", Text: "I add socks", Line: 5, Status: StepPassed}, passed.Steps[1])
+	assert.Equal(t, []string{StepPassed, StepPassed, StepPassed}, stepStatuses(passed))
+
+	failed := scenarioResult(app, &TestResult{Metrics: map[string]interface{}{"failed_action": "When I add socks (click)"}})
+	assert.Equal(t, []string{StepPassed, StepFailed, StepSkipped}, stepStatuses(failed))
+
+	tagged := scenarioResult(app, &TestResult{Success: true, Metrics: map[string]interface{}{"skipped_actions": []string{"Then I see the cart", "When I add socks (click)"}}})
+	assert.Equal(t, []string{StepPassed, StepPassed, StepSkipped}, stepStatuses(tagged))
+
+	setup := scenarioResult(app, &TestResult{Error: "Failed to initialize platform", Metrics: map[string]interface{}{}})
+	assert.Equal(t, []string{StepSkipped, StepSkipped, StepSkipped}, stepStatuses(setup))
+
+	resources := scenarioResult(app, &TestResult{FailureCategory: config.CategoryResources, Metrics: map[string]interface{}{}})
+	assert.Equal(t, []string{StepPassed, StepPassed, StepPassed}, stepStatuses(resources))
+
+	assert.Nil(t, scenarioResult(config.AppConfig{Name: "Plain"}, &TestResult{Success: true}))
+}
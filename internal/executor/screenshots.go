@@ -0,0 +1,153 @@
+package executor
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"panoptic/internal/config"
+)
+
+// Screenshot writers, and the captures queued for them before a screenshot
+// action waits for one to be free
+const (
+	screenshotWorkers   = 4
+	screenshotQueueSize = 16
+)
+
+// screenshotEncoder is implemented by platforms that capture screenshots
+// into memory, in a chosen format
+type screenshotEncoder interface {
+	EncodeScreenshot(filename, format string, quality int) ([]byte, error)
+}
+
+// screenshotWriter writes captured screenshots from a pool of workers, so
+// screenshot actions only wait for the capture. Once its queue is full,
+// queueing waits for a worker, which keeps the captures held in memory
+// bounded however fast they are taken.
+type screenshotWriter struct {
+	jobs    chan screenshotJob
+	workers sync.WaitGroup
+
+	mu      sync.Mutex
+	pending map[string]chan struct{} // closed once the screenshot is written
+	errs    []error
+}
+
+// screenshotJob is a captured screenshot waiting to be written
+type screenshotJob struct {
+	path string
+	data []byte
+	done chan struct{}
+}
+
+func newScreenshotWriter(workers, queueSize int) *screenshotWriter {
+	w := &screenshotWriter{
+		jobs:    make(chan screenshotJob, queueSize),
+		pending: make(map[string]chan struct{}),
+	}
+	for i := 0; i < workers; i++ {
+		w.workers.Add(1)
+		go w.run()
+	}
+	return w
+}
+
+// write queues a screenshot, waiting for room in the queue when it's full
+func (w *screenshotWriter) write(path string, data []byte) {
+	job := screenshotJob{path: path, data: data, done: make(chan struct{})}
+	w.mu.Lock()
+	w.pending[path] = job.done
+	w.mu.Unlock()
+	w.jobs <- job
+}
+
+func (w *screenshotWriter) run() {
+	defer w.workers.Done()
+	for job := range w.jobs {
+		err := writeScreenshotFile(job.path, job.data)
+		w.mu.Lock()
+		if err != nil {
+			w.errs = append(w.errs, err)
+		}
+		if w.pending[job.path] == job.done {
+			delete(w.pending, job.path)
+		}
+		w.mu.Unlock()
+		close(job.done)
+	}
+}
+
+// wait returns once the screenshot queued for path, if any, is written
+func (w *screenshotWriter) wait(path string) {
+	w.mu.Lock()
+	done := w.pending[path]
+	w.mu.Unlock()
+	if done != nil {
+		<-done
+	}
+}
+
+// flush waits for every queued screenshot and returns the writes that
+// failed since the last flush
+func (w *screenshotWriter) flush() error {
+	w.mu.Lock()
+	pending := make([]chan struct{}, 0, len(w.pending))
+	for _, done := range w.pending {
+		pending = append(pending, done)
+	}
+	w.mu.Unlock()
+	for _, done := range pending {
+		<-done
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	errs := w.errs
+	w.errs = nil
+	return errors.Join(errs...)
+}
+
+// close flushes the queue and stops the workers
+func (w *screenshotWriter) close() error {
+	close(w.jobs)
+	w.workers.Wait()
+	return w.flush()
+}
+
+// writeScreenshotFile writes a screenshot through a temporary file, so
+// nothing reads it half written
+func writeScreenshotFile(path string, data []byte) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create screenshot directory: %w", err)
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return fmt.Errorf("failed to save screenshot: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("failed to save screenshot: %w", err)
+	}
+	return nil
+}
+
+// flushScreenshots waits for the app's screenshots to be written, failing
+// the app when one couldn't be
+func (e *Executor) flushScreenshots(result *TestResult) {
+	if e.screenshots == nil {
+		return
+	}
+	err := e.screenshots.flush()
+	if err == nil {
+		return
+	}
+	e.logger.Errorf("Failed to save screenshots of %s: %v", result.AppName, err)
+	if result.Success {
+		result.Success = false
+		result.Error = err.Error()
+		result.FailureCategory = config.CategoryInfrastructure
+	}
+}
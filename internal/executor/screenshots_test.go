@@ -0,0 +1,109 @@
+package executor
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"panoptic/internal/config"
+	"panoptic/internal/logger"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// encodingPlatform is a MockPlatform capturing screenshots into memory
+type encodingPlatform struct {
+	*MockPlatform
+	formats []string
+}
+
+func (p *encodingPlatform) EncodeScreenshot(filename, format string, quality int) ([]byte, error) {
+	p.formats = append(p.formats, format)
+	return []byte(format), nil
+}
+
+func TestScreenshotWriter_Flush(t *testing.T) {
+	dir := t.TempDir()
+	w := newScreenshotWriter(2, 1)
+	for i := 0; i < 10; i++ {
+		w.write(filepath.Join(dir, "shots", string(rune('a'+i))+".png"), []byte{byte(i)})
+	}
+	require.NoError(t, w.flush())
+
+	files, err := os.ReadDir(filepath.Join(dir, "shots"))
+	require.NoError(t, err)
+	assert.Len(t, files, 10, "every queued screenshot is written, and no temporary file is left")
+	data, err := os.ReadFile(filepath.Join(dir, "shots", "j.png"))
+	require.NoError(t, err)
+	assert.Equal(t, []byte{9}, data)
+	require.NoError(t, w.close())
+}
+
+func TestScreenshotWriter_Errors(t *testing.T) {
+	dir := t.TempDir()
+	blocker := filepath.Join(dir, "file")
+	require.NoError(t, os.WriteFile(blocker, nil, 0600))
+
+	w := newScreenshotWriter(1, 4)
+	w.write(filepath.Join(blocker, "shot.png"), []byte("png"))
+	w.write(filepath.Join(dir, "ok.png"), []byte("png"))
+	err := w.flush()
+	assert.ErrorContains(t, err, "failed to create screenshot directory")
+	assert.FileExists(t, filepath.Join(dir, "ok.png"))
+	assert.NoError(t, w.flush(), "errors are reported once")
+	assert.NoError(t, w.close())
+}
+
+func TestScreenshotWriter_Wait(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "shot.png")
+	w := newScreenshotWriter(1, 1)
+	defer w.close()
+	w.write(path, []byte("png"))
+	w.wait(path)
+	assert.FileExists(t, path)
+	w.wait(filepath.Join(t.TempDir(), "never.png"))
+}
+
+// TestExecutor_AsyncScreenshot tests that screenshot actions of platforms
+// capturing into memory are written by the workers in the configured format
+func TestExecutor_AsyncScreenshot(t *testing.T) {
+	cfg := &config.Config{Settings: config.Settings{ScreenshotFormat: "webp", Quality: 70}}
+	executor := NewExecutor(cfg, t.TempDir(), logger.NewLogger(false))
+	executor.screenshots = newScreenshotWriter(screenshotWorkers, screenshotQueueSize)
+	platform := &encodingPlatform{MockPlatform: &MockPlatform{metrics: map[string]interface{}{}}}
+	result := &TestResult{Success: true}
+	var recording string
+
+	action := config.Action{Name: "home", Type: "screenshot"}
+	require.NoError(t, executor.runAction(t.Context(), platform, action, config.AppConfig{Name: "Shop", Type: "web"}, result, &recording))
+	executor.flushScreenshots(result)
+	require.NoError(t, executor.screenshots.close())
+
+	require.Len(t, result.Screenshots, 1)
+	assert.True(t, strings.HasSuffix(result.Screenshots[0], ".webp"), result.Screenshots[0])
+	assert.Equal(t, []string{"webp"}, platform.formats)
+	data, err := os.ReadFile(result.Screenshots[0])
+	require.NoError(t, err)
+	assert.Equal(t, "webp", string(data))
+	assert.True(t, result.Success)
+}
+
+// TestExecutor_FlushScreenshots_Failure tests that a screenshot that
+// couldn't be written fails its app
+func TestExecutor_FlushScreenshots_Failure(t *testing.T) {
+	dir := t.TempDir()
+	blocker := filepath.Join(dir, "file")
+	require.NoError(t, os.WriteFile(blocker, nil, 0600))
+	executor := NewExecutor(&config.Config{}, dir, logger.NewLogger(false))
+	executor.screenshots = newScreenshotWriter(1, 1)
+	defer executor.screenshots.close()
+
+	executor.screenshots.write(filepath.Join(blocker, "shot.png"), []byte("png"))
+	result := &TestResult{AppName: "Shop", Success: true}
+	executor.flushScreenshots(result)
+	assert.False(t, result.Success)
+	assert.Contains(t, result.Error, "failed to create screenshot directory")
+	assert.Equal(t, config.CategoryInfrastructure, result.FailureCategory)
+}
@@ -0,0 +1,98 @@
+package executor
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"panoptic/internal/config"
+	"panoptic/internal/platforms"
+)
+
+// scroller is implemented by platforms whose pages scroll
+type scroller interface {
+	ScrollTo(selector string, x, y float64) (platforms.ScrollPosition, error)
+	ScrollBy(x, y float64) (platforms.ScrollPosition, error)
+	HasElement(selector string) (bool, error)
+}
+
+// ScrollRecord is recorded in the scroll_positions metric for every scroll
+// action, with where the page ended up
+type ScrollRecord struct {
+	Action     string `json:"action"`
+	Iterations int    `json:"iterations,omitempty"` // scrolls scroll_until took
+	platforms.ScrollPosition
+}
+
+// runScrollAction runs scroll_to, scroll_by and scroll_until, recording the
+// position the page is scrolled to
+func (e *Executor) runScrollAction(ctx context.Context, platform platforms.Platform, action config.Action, result *TestResult) error {
+	page, ok := platform.(scroller)
+	if !ok {
+		return fmt.Errorf("%s is not supported on this platform", action.Type)
+	}
+	scroll, err := action.Scroll()
+	if err != nil {
+		return err
+	}
+	var pos platforms.ScrollPosition
+	x, y := scroll.Coordinates()
+	switch action.Type {
+	case "scroll_to":
+		pos, err = page.ScrollTo(scroll.Selector, x, y)
+	case "scroll_by":
+		pos, err = page.ScrollBy(x, y)
+	case "scroll_until":
+		return e.scrollUntil(ctx, page, action, scroll, result)
+	}
+	if err != nil {
+		return err
+	}
+	e.recordScroll(action, pos, 0, result)
+	return nil
+}
+
+// scrollUntil scrolls until the element of the action's selector is on the
+// page, as lazy-loaded lists add it, then brings it into view
+func (e *Executor) scrollUntil(ctx context.Context, page scroller, action config.Action, scroll *config.Scroll, result *TestResult) error {
+	pos, err := page.ScrollBy(0, 0)
+	if err != nil {
+		return err
+	}
+	for i := 0; ; i++ {
+		found, err := page.HasElement(scroll.Selector)
+		if err != nil {
+			return err
+		}
+		if found {
+			if pos, err = page.ScrollTo(scroll.Selector, 0, 0); err != nil {
+				return err
+			}
+			e.recordScroll(action, pos, i, result)
+			return nil
+		}
+		if i == scroll.MaxIterations {
+			e.recordScroll(action, pos, i, result)
+			return fmt.Errorf("no element matches %s after %d scroll(s)", scroll.Selector, i)
+		}
+		if scroll.Step > 0 {
+			pos, err = page.ScrollBy(0, scroll.Step)
+		} else {
+			pos, err = page.ScrollTo("", pos.X, pos.PageHeight)
+		}
+		if err != nil {
+			return err
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(scroll.Interval):
+		}
+	}
+}
+
+func (e *Executor) recordScroll(action config.Action, pos platforms.ScrollPosition, iterations int, result *TestResult) {
+	e.logger.Infof("Scrolled to %.0f,%.0f of a %.0fpx page", pos.X, pos.Y, pos.PageHeight)
+	records, _ := result.Metrics["scroll_positions"].([]ScrollRecord)
+	result.Metrics["scroll_positions"] = append(records, ScrollRecord{Action: action.Name, Iterations: iterations, ScrollPosition: pos})
+}
@@ -0,0 +1,74 @@
+package executor
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"testing"
+
+	"panoptic/internal/config"
+	"panoptic/internal/logger"
+	"panoptic/internal/platforms"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// scrollPlatform is a mock platform with an infinite list loading 1000px
+// more whenever it's scrolled to the end, up to loaded pages
+type scrollPlatform struct {
+	*MockPlatform
+	pos    platforms.ScrollPosition
+	loaded int
+}
+
+func (p *scrollPlatform) ScrollTo(selector string, x, y float64) (platforms.ScrollPosition, error) {
+	if selector != "" {
+		p.pos.Y = p.pos.PageHeight - 500
+		return p.pos, nil
+	}
+	p.pos.X, p.pos.Y = x, math.Min(y, p.pos.PageHeight)
+	if p.pos.Y == p.pos.PageHeight && p.loaded > 0 {
+		p.loaded--
+		p.pos.PageHeight += 1000
+	}
+	return p.pos, nil
+}
+
+func (p *scrollPlatform) ScrollBy(x, y float64) (platforms.ScrollPosition, error) {
+	return p.ScrollTo("", p.pos.X+x, p.pos.Y+y)
+}
+
+func (p *scrollPlatform) HasElement(selector string) (bool, error) {
+	if selector == "#bad" {
+		return false, fmt.Errorf("failed to look for %s", selector)
+	}
+	return p.pos.PageHeight >= 3000, nil
+}
+
+func TestExecutor_RunScrollAction(t *testing.T) {
+	executor := NewExecutor(&config.Config{}, t.TempDir(), logger.NewLogger(false))
+	result := &TestResult{Metrics: map[string]interface{}{}}
+	platform := &scrollPlatform{MockPlatform: &MockPlatform{metrics: map[string]interface{}{}}, pos: platforms.ScrollPosition{PageHeight: 1000}, loaded: 2}
+	ctx := context.Background()
+
+	require.NoError(t, executor.runScrollAction(ctx, platform, config.Action{Name: "down", Type: "scroll_by", Parameters: map[string]interface{}{"y": 400}}, result))
+	until := config.Action{Name: "more", Type: "scroll_until", Selector: ".product:nth-child(60)", Parameters: map[string]interface{}{"interval": "1ms"}}
+	require.NoError(t, executor.runScrollAction(ctx, platform, until, result))
+	require.NoError(t, executor.runScrollAction(ctx, platform, config.Action{Name: "top", Type: "scroll_to", Parameters: map[string]interface{}{"y": 0}}, result))
+	assert.Equal(t, []ScrollRecord{
+		{Action: "down", ScrollPosition: platforms.ScrollPosition{Y: 400, PageHeight: 1000}},
+		{Action: "more", Iterations: 2, ScrollPosition: platforms.ScrollPosition{Y: 2500, PageHeight: 3000}},
+		{Action: "top", ScrollPosition: platforms.ScrollPosition{PageHeight: 3000}},
+	}, result.Metrics["scroll_positions"])
+
+	platform = &scrollPlatform{MockPlatform: &MockPlatform{metrics: map[string]interface{}{}}, pos: platforms.ScrollPosition{PageHeight: 1000}, loaded: 1}
+	until.Parameters["max_iterations"] = 3
+	err := executor.runScrollAction(ctx, platform, until, result)
+	assert.EqualError(t, err, "no element matches .product:nth-child(60) after 3 scroll(s)")
+	until.Selector = "#bad"
+	assert.EqualError(t, executor.runScrollAction(ctx, platform, until, result), "failed to look for #bad")
+
+	err = executor.runScrollAction(ctx, &MockPlatform{metrics: map[string]interface{}{}}, until, result)
+	assert.EqualError(t, err, "scroll_until is not supported on this platform")
+}
@@ -0,0 +1,153 @@
+package executor
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"panoptic/internal/config"
+	"panoptic/internal/platforms"
+	"panoptic/internal/secrets"
+)
+
+// newSecretsProvider connects to the provider in settings.secrets
+func newSecretsProvider(s *config.SecretsSettings) (secrets.Provider, error) {
+	switch s.Provider {
+	case "env":
+		return secrets.NewEnv(), nil
+	case "vault":
+		address := firstNonEmpty(s.URL, os.Getenv("VAULT_ADDR"))
+		if address == "" {
+			return nil, fmt.Errorf("settings.secrets: vault needs url or VAULT_ADDR")
+		}
+		token := os.ExpandEnv(firstNonEmpty(s.Token, "${VAULT_TOKEN}"))
+		return secrets.NewVault(address, token, s.Namespace, s.Mount, s.KVVersion), nil
+	case "aws":
+		region := firstNonEmpty(s.Region, os.Getenv("AWS_REGION"), os.Getenv("AWS_DEFAULT_REGION"))
+		if region == "" {
+			return nil, fmt.Errorf("settings.secrets: aws needs region or AWS_REGION")
+		}
+		creds := secrets.AWSCredentials{
+			AccessKeyID:     os.Getenv("AWS_ACCESS_KEY_ID"),
+			SecretAccessKey: os.Getenv("AWS_SECRET_ACCESS_KEY"),
+			SessionToken:    os.Getenv("AWS_SESSION_TOKEN"),
+		}
+		if creds.AccessKeyID == "" || creds.SecretAccessKey == "" {
+			return nil, fmt.Errorf("settings.secrets: aws needs AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY")
+		}
+		return secrets.NewAWS(region, s.URL, creds), nil
+	}
+	return nil, fmt.Errorf("unknown secrets provider %q", s.Provider)
+}
+
+// secretField fetches one field of a secret, redacting its value from
+// everything the run writes before it's used
+func (e *Executor) secretField(ctx context.Context, name, key string) (string, error) {
+	if e.config.Settings.Secrets == nil {
+		return "", fmt.Errorf("secret %s requires settings.secrets", name)
+	}
+	if e.secrets == nil {
+		provider, err := newSecretsProvider(e.config.Settings.Secrets)
+		if err != nil {
+			return "", err
+		}
+		e.secrets = provider
+	}
+	secret, err := e.secrets.Get(ctx, name)
+	if err != nil {
+		return "", err
+	}
+	value, err := secret.Field(key)
+	if err != nil {
+		return "", fmt.Errorf("secret %s: %w", name, err)
+	}
+	e.redactor.AddValue(value)
+	return value, nil
+}
+
+// interpolateSecrets fills {{secret.<name>.<field>}} placeholders in an
+// action's URL, target, value and selector
+func (e *Executor) interpolateSecrets(ctx context.Context, action config.Action) (config.Action, error) {
+	for _, field := range []*string{&action.URL, &action.Target, &action.Value, &action.Selector} {
+		var firstErr error
+		*field = config.SecretPattern.ReplaceAllStringFunc(*field, func(match string) string {
+			m := config.SecretPattern.FindStringSubmatch(match)
+			value, err := e.secretField(ctx, m[1], m[2])
+			if err != nil {
+				if firstErr == nil {
+					firstErr = err
+				}
+				return match
+			}
+			return value
+		})
+		if firstErr != nil {
+			return action, fmt.Errorf("action '%s': %w", action.Name, firstErr)
+		}
+	}
+	return action, nil
+}
+
+// login fills the username and password of a secret into a login form and
+// submits it. The credentials are redacted before they're typed and never
+// recorded in the result.
+func (e *Executor) login(ctx context.Context, platform platforms.Platform, action config.Action, app config.AppConfig) error {
+	login, err := action.Login()
+	if err != nil {
+		return err
+	}
+	username, err := e.secretField(ctx, login.Secret, login.UsernameKey)
+	if err != nil {
+		return err
+	}
+	password, err := e.secretField(ctx, login.Secret, login.PasswordKey)
+	if err != nil {
+		return err
+	}
+	e.logger.Infof("Logging in with secret %s", login.Secret)
+	if err := e.platformCall(ctx, app, "Fill", func() error { return platform.Fill(login.UsernameSelector, username) }); err != nil {
+		return fmt.Errorf("login username: %w", err)
+	}
+	if err := e.platformCall(ctx, app, "Fill", func() error { return platform.Fill(login.PasswordSelector, password) }); err != nil {
+		return fmt.Errorf("login password: %w", err)
+	}
+	if err := e.platformCall(ctx, app, "Submit", func() error { return platform.Submit(login.SubmitSelector) }); err != nil {
+		return fmt.Errorf("login submit: %w", err)
+	}
+	return nil
+}
+
+// generateTOTP stores the current code of a TOTP secret in a variable, for
+// a later fill of a two-factor prompt. A code about to expire is skipped
+// for the next one, so it's still valid when it's submitted.
+func (e *Executor) generateTOTP(ctx context.Context, action config.Action) error {
+	params, err := action.TOTP()
+	if err != nil {
+		return err
+	}
+	shared, err := e.secretField(ctx, params.Secret, params.Key)
+	if err != nil {
+		return err
+	}
+	totp, err := secrets.ParseTOTP(shared, params.Digits, params.Period, params.Algorithm)
+	if err != nil {
+		return fmt.Errorf("secret %s: %w", params.Secret, err)
+	}
+	code, expires := totp.Code(time.Now())
+	if wait := time.Until(expires); wait < params.MinValidity && params.MinValidity < totp.Period {
+		e.logger.Infof("TOTP code expires in %s, waiting for the next one", wait.Round(time.Second))
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(wait):
+		}
+		code, _ = totp.Code(expires)
+	}
+	if e.vars == nil {
+		e.vars = make(map[string]string)
+	}
+	e.vars[params.Variable] = code
+	e.logger.Infof("Generated a TOTP code of secret %s into {{var.%s}}", params.Secret, params.Variable)
+	return nil
+}
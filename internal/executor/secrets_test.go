@@ -0,0 +1,109 @@
+package executor
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"panoptic/internal/config"
+	"panoptic/internal/logger"
+	"panoptic/internal/secrets"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestExecutor_Run_Login tests that credentials fetched by login actions and
+// secret placeholders are typed but stay out of the logs and results
+func TestExecutor_Run_Login(t *testing.T) {
+	t.Setenv("SHOP_ADMIN_USERNAME", "ada@shop.test")
+	t.Setenv("SHOP_ADMIN_PASSWORD", "hunter22")
+	cfg := &config.Config{Name: "shop", Apps: []config.AppConfig{
+		{Name: "Shop", Type: "driver", Driver: scriptDriver(t, `"fill","submit","navigate"`), Actions: []config.Action{
+			{Name: "sign_in", Type: "login", Target: "shop/admin", Parameters: map[string]interface{}{
+				"username_selector": "#email", "password_selector": "#password", "submit_selector": "#sign-in",
+			}},
+			{Name: "confirm", Type: "fill", Selector: "#confirm", Value: "{{secret.shop/admin.password}}"},
+			{Name: "echo hunter22", Type: "fill", Selector: "#note", Value: "note"},
+			{Name: "account", Type: "navigate", Value: "https://shop.example.com/u/{{secret.shop/admin.username}}"},
+		}},
+	}}
+	cfg.Settings.Secrets = &config.SecretsSettings{Provider: "env"}
+	outputDir := t.TempDir()
+	logs := &bytes.Buffer{}
+	log := logger.NewLogger(true)
+	log.SetOutput(logs)
+	executor := NewExecutor(cfg, outputDir, log)
+	require.NoError(t, executor.Run())
+
+	require.Len(t, executor.results, 1)
+	assert.Equal(t, "Action 'account' failed: driver sh: navigate: no such screen (code 1)", executor.results[0].Error)
+	require.NoError(t, executor.SaveResults(filepath.Join(outputDir, "results.json")))
+	data, err := os.ReadFile(filepath.Join(outputDir, "results.json"))
+	require.NoError(t, err)
+	assert.NotContains(t, string(data), "hunter22")
+	assert.Contains(t, logs.String(), "echo [REDACTED]")
+	assert.Contains(t, logs.String(), "Logging in with secret shop/admin")
+	assert.NotContains(t, logs.String(), "hunter22")
+	assert.NotContains(t, logs.String(), "ada@shop.test")
+}
+
+// TestExecutor_SecretField tests the errors of secrets that can't be read,
+// which never quote secret values
+func TestExecutor_SecretField(t *testing.T) {
+	t.Setenv("SHOP_ADMIN_PASSWORD", "hunter22")
+	cfg, _ := manifestConfig(t)
+	executor := NewExecutor(cfg, t.TempDir(), logger.NewLogger(false))
+	ctx := context.Background()
+
+	_, err := executor.secretField(ctx, "shop/admin", "password")
+	assert.EqualError(t, err, "secret shop/admin requires settings.secrets")
+
+	cfg.Settings.Secrets = &config.SecretsSettings{Provider: "env"}
+	_, err = executor.secretField(ctx, "shop/admin", "username")
+	assert.EqualError(t, err, `secret shop/admin: secret has no field "username" (fields: password)`)
+
+	_, err = executor.interpolateSecrets(ctx, config.Action{Name: "enter", Value: "{{secret.shop/support.password}}"})
+	assert.EqualError(t, err, "action 'enter': secret shop/support: no SHOP_SUPPORT_* environment variables")
+
+	t.Setenv("VAULT_ADDR", "")
+	_, err = newSecretsProvider(&config.SecretsSettings{Provider: "vault"})
+	assert.EqualError(t, err, "settings.secrets: vault needs url or VAULT_ADDR")
+	t.Setenv("AWS_REGION", "eu-west-1")
+	t.Setenv("AWS_ACCESS_KEY_ID", "")
+	_, err = newSecretsProvider(&config.SecretsSettings{Provider: "aws"})
+	assert.EqualError(t, err, "settings.secrets: aws needs AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY")
+}
+
+// TestExecutor_GenerateTOTP tests that totp actions store the current code of
+// a secret for later fills, keeping the shared secret out of the logs
+func TestExecutor_GenerateTOTP(t *testing.T) {
+	t.Setenv("SHOP_ADMIN_TOTP", "otpauth://totp/Shop:ada?secret=GEZDGNBVGY3TQOJQ&issuer=Shop")
+	cfg, _ := manifestConfig(t)
+	cfg.Settings.Secrets = &config.SecretsSettings{Provider: "env"}
+	logs := &bytes.Buffer{}
+	log := logger.NewLogger(false)
+	log.SetOutput(logs)
+	executor := NewExecutor(cfg, t.TempDir(), log)
+	require.NoError(t, executor.startRedaction())
+
+	action := config.Action{Name: "otp", Type: "totp", Target: "shop/admin", Parameters: map[string]interface{}{"min_validity": "1ms"}}
+	require.NoError(t, executor.generateTOTP(context.Background(), action))
+	totp, err := secrets.ParseTOTP("GEZDGNBVGY3TQOJQ", 0, 0, "")
+	require.NoError(t, err)
+	now, _ := totp.Code(time.Now())
+	previous, _ := totp.Code(time.Now().Add(-totp.Period))
+	assert.Contains(t, []string{now, previous}, executor.vars["otp"])
+
+	fill, err := executor.interpolateAction(config.Action{Name: "enter", Type: "fill", Value: "{{var.otp}}"})
+	require.NoError(t, err)
+	assert.Len(t, fill.Value, 6)
+	assert.Contains(t, logs.String(), "Generated a TOTP code of secret shop/admin into {{var.otp}}")
+	assert.NotContains(t, logs.String(), "GEZDGNBVGY3TQOJQ")
+
+	t.Setenv("SHOP_ADMIN_TOTP", "not a key!")
+	assert.EqualError(t, executor.generateTOTP(context.Background(), action), "secret shop/admin: TOTP secret is not a base32 key")
+}
@@ -0,0 +1,255 @@
+package executor
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"panoptic/internal/config"
+	"panoptic/internal/history"
+)
+
+// SLO check statuses
+const (
+	SLOPassed     = "passed"
+	SLOFailed     = "failed"
+	SLOWarning    = "warning"     // regressed under an objective of severity warning
+	SLONoBaseline = "no baseline" // fewer recorded runs had the metric than min_runs
+)
+
+// SLOCheck is a metric of the run compared with its baseline
+type SLOCheck struct {
+	Metric        string  `json:"metric"`
+	App           string  `json:"app,omitempty"` // empty for success_rate over the whole run
+	Unit          string  `json:"unit,omitempty"`
+	Baseline      float64 `json:"baseline"`      // median over the baseline runs
+	BaselineRuns  int     `json:"baseline_runs"` // recorded runs the baseline was taken from
+	Current       float64 `json:"current"`
+	Change        float64 `json:"change"`     // percent of the baseline; percentage points for success_rate
+	Regression    float64 `json:"regression"` // the change for the worse, in the same terms; negative when better
+	MaxRegression float64 `json:"max_regression"`
+	Status        string  `json:"status"`
+}
+
+// SLOReport is the outcome of the SLO gate
+type SLOReport struct {
+	Checks   []SLOCheck `json:"checks"`
+	Failed   int        `json:"failed"`
+	Warnings int        `json:"warnings"`
+}
+
+// Err fails the run when an objective of severity error regressed
+func (r *SLOReport) Err() error {
+	if r == nil || r.Failed == 0 {
+		return nil
+	}
+	var regressed []string
+	for _, c := range r.Checks {
+		if c.Status == SLOFailed {
+			regressed = append(regressed, c.describe())
+		}
+	}
+	return fmt.Errorf("%d SLO(s) regressed: %s", r.Failed, strings.Join(regressed, "; "))
+}
+
+// describe tells how a check regressed, e.g. "duration of Shop is 33.3%
+// worse than its baseline (limit 20%)"
+func (c SLOCheck) describe() string {
+	of := "the run"
+	if c.App != "" {
+		of = c.App
+	}
+	amount := fmt.Sprintf("%.1f%%", c.Regression)
+	limit := fmt.Sprintf("%g%%", c.MaxRegression)
+	if c.Metric == config.SLOSuccessRate {
+		amount, limit = fmt.Sprintf("%.1f points", c.Regression), fmt.Sprintf("%g points", c.MaxRegression)
+	}
+	return fmt.Sprintf("%s of %s is %s worse than its baseline (limit %s)", c.Metric, of, amount, limit)
+}
+
+// CheckSLOs compares the run with the recent runs in a history file, before
+// the run is appended to it, as settings.slo sets out; nil without SLOs. A
+// missing history has no baselines yet.
+func (e *Executor) CheckSLOs(historyPath string) (*SLOReport, error) {
+	settings := e.config.Settings.SLO
+	if settings == nil {
+		return nil, nil
+	}
+	records, err := history.Load(historyPath)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+	report := checkSLOs(settings, records, e.historyRecord())
+	for _, c := range report.Checks {
+		if c.Status == SLOWarning {
+			e.logger.Warnf("SLO warning: %s", c.describe())
+		}
+	}
+	return report, nil
+}
+
+// checkSLOs checks every objective against the baselines of records, oldest
+// first
+func checkSLOs(settings *config.SLOSettings, records []history.Record, current history.Record) *SLOReport {
+	baselineRuns, minRuns := settings.Windows()
+	report := &SLOReport{Checks: make([]SLOCheck, 0, len(settings.Objectives))}
+	for _, o := range settings.Objectives {
+		for _, app := range sloApps(o, current) {
+			value, unit, ok := sloValue(current, o.Metric, app)
+			if !ok {
+				continue
+			}
+			check := SLOCheck{Metric: o.Metric, App: app, Unit: unit, Current: value, MaxRegression: o.MaxRegression}
+			var samples []float64
+			for i := len(records) - 1; i >= 0 && len(samples) < baselineRuns; i-- {
+				if v, _, ok := sloValue(records[i], o.Metric, app); ok {
+					samples = append(samples, v)
+				}
+			}
+			check.BaselineRuns = len(samples)
+			if len(samples) < minRuns {
+				check.Status = SLONoBaseline
+				report.Checks = append(report.Checks, check)
+				continue
+			}
+
+			check.Baseline = median(samples)
+			switch {
+			case o.Metric == config.SLOSuccessRate:
+				check.Change = value - check.Baseline
+			case check.Baseline != 0:
+				check.Change = (value - check.Baseline) * 100 / check.Baseline
+			case value > 0:
+				check.Change = 100 // any change from a baseline of 0
+			case value < 0:
+				check.Change = -100
+			}
+			check.Regression = check.Change
+			if o.HigherIsBetter() {
+				check.Regression = -check.Change
+			}
+			check.Status = SLOPassed
+			if check.Regression > o.MaxRegression {
+				if o.Severity == config.SeverityWarning {
+					check.Status = SLOWarning
+					report.Warnings++
+				} else {
+					check.Status = SLOFailed
+					report.Failed++
+				}
+			}
+			report.Checks = append(report.Checks, check)
+		}
+	}
+	return report
+}
+
+// sloApps are the apps an objective is checked for: its own, the apps of
+// the run, or "" for the whole run's success rate
+func sloApps(o config.SLOObjective, current history.Record) []string {
+	if o.App != "" {
+		return []string{o.App}
+	}
+	if o.Metric == config.SLOSuccessRate {
+		return []string{""}
+	}
+	var apps []string
+	for _, entry := range current.Entries {
+		if !containsString(apps, entry.App) {
+			apps = append(apps, entry.App)
+		}
+	}
+	return apps
+}
+
+// sloValue is a metric of an app in a recorded run, or of the whole run
+// for app "": the mean duration or custom metric over its results, or the
+// percent of them that passed
+func sloValue(record history.Record, metric, app string) (value float64, unit string, ok bool) {
+	var total float64
+	n := 0
+	for _, entry := range record.Entries {
+		if app != "" && entry.App != app {
+			continue
+		}
+		switch metric {
+		case config.SLODuration:
+			total += float64(entry.Duration.Microseconds()) / 1000
+			n++
+		case config.SLOSuccessRate:
+			if entry.Success {
+				total += 100
+			}
+			n++
+		default:
+			for _, m := range entry.Metrics {
+				if m.Name == metric {
+					total += m.Value
+					n++
+					if m.Unit != "" {
+						unit = m.Unit
+					}
+				}
+			}
+		}
+	}
+	if n == 0 {
+		return 0, "", false
+	}
+	switch metric {
+	case config.SLODuration:
+		unit = "ms"
+	case config.SLOSuccessRate:
+		unit = "%"
+	}
+	return total / float64(n), unit, true
+}
+
+func median(values []float64) float64 {
+	sorted := append([]float64(nil), values...)
+	sort.Float64s(sorted)
+	mid := len(sorted) / 2
+	if len(sorted)%2 == 0 {
+		return (sorted[mid-1] + sorted[mid]) / 2
+	}
+	return sorted[mid]
+}
+
+// WriteSLOReport prints the checks as an aligned table of the baselines
+// and the run's values
+func WriteSLOReport(w io.Writer, report *SLOReport) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "SLO\tAPP\tBASELINE\tRUNS\tCURRENT\tCHANGE\tLIMIT\tSTATUS")
+	for _, c := range report.Checks {
+		app := c.App
+		if app == "" {
+			app = "(run)"
+		}
+		baseline, change, limit := "-", "-", fmt.Sprintf("%g%%", c.MaxRegression)
+		if c.Metric == config.SLOSuccessRate {
+			limit = fmt.Sprintf("%g pts", c.MaxRegression)
+		}
+		if c.Status != SLONoBaseline {
+			baseline = sloFormat(c, c.Baseline)
+			change = fmt.Sprintf("%+.1f%%", c.Change)
+			if c.Metric == config.SLOSuccessRate {
+				change = fmt.Sprintf("%+.1f pts", c.Change)
+			}
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%d\t%s\t%s\t%s\t%s\n", c.Metric, app, baseline, c.BaselineRuns,
+			sloFormat(c, c.Current), change, limit, strings.ToUpper(c.Status))
+	}
+	return tw.Flush()
+}
+
+// sloFormat prints a value of a check's metric
+func sloFormat(c SLOCheck, value float64) string {
+	if c.Metric == config.SLOSuccessRate {
+		return fmt.Sprintf("%.1f%%", value)
+	}
+	return history.FormatMetric(value, c.Unit)
+}
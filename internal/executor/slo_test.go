@@ -0,0 +1,92 @@
+package executor
+
+import (
+	"bytes"
+	"fmt"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"panoptic/internal/config"
+	"panoptic/internal/history"
+	"panoptic/internal/logger"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExecutor_CheckSLOs(t *testing.T) {
+	path := filepath.Join(t.TempDir(), history.FileName)
+	for i, ms := range []int{1100, 1000, 900, 1200} {
+		require.NoError(t, history.Append(path, history.Record{RunID: fmt.Sprint(i + 1), Time: time.Now(), Entries: []history.Entry{
+			{App: "Shop", Success: true, Duration: time.Duration(ms) * time.Millisecond, Metrics: []history.Metric{{Name: "items_listed", Value: 20}}},
+			{App: "Admin", Success: i != 0, Duration: time.Second},
+		}}))
+	}
+
+	cfg := &config.Config{Settings: config.Settings{SLO: &config.SLOSettings{BaselineRuns: 3, MinRuns: 2, Objectives: []config.SLOObjective{
+		{Metric: config.SLODuration, MaxRegression: 20},
+		{Metric: config.SLOSuccessRate, MaxRegression: 10, Severity: config.SeverityWarning},
+		{Metric: "items_listed", App: "Shop", Better: "higher", MaxRegression: 10},
+		{Metric: "cart_render", MaxRegression: 10},
+	}}}}
+	executor := NewExecutor(cfg, t.TempDir(), logger.NewLogger(false))
+	executor.results = []TestResult{
+		{AppName: "Shop", Success: true, Duration: 1500 * time.Millisecond, CustomMetrics: []history.Metric{
+			{Name: "items_listed", Value: 19}, {Name: "cart_render", Value: 80, Unit: "ms"},
+		}},
+		{AppName: "Admin", Success: false, Duration: 900 * time.Millisecond},
+	}
+
+	report, err := executor.CheckSLOs(path)
+	require.NoError(t, err)
+	require.Len(t, report.Checks, 5)
+	shop := report.Checks[0]
+	assert.Equal(t, SLOCheck{
+		Metric: "duration", App: "Shop", Unit: "ms", Baseline: 1000, BaselineRuns: 3, Current: 1500,
+		Change: 50, Regression: 50, MaxRegression: 20, Status: SLOFailed,
+	}, shop, "the baseline is the median of the last 3 runs")
+	assert.Equal(t, SLOPassed, report.Checks[1].Status, "Admin got faster")
+	assert.Equal(t, -10.0, report.Checks[1].Regression)
+	rate := report.Checks[2]
+	assert.Equal(t, "", rate.App)
+	assert.Equal(t, 100.0, rate.Baseline)
+	assert.Equal(t, -50.0, rate.Change)
+	assert.Equal(t, SLOWarning, rate.Status)
+	items := report.Checks[3]
+	assert.Equal(t, -5.0, items.Change)
+	assert.Equal(t, 5.0, items.Regression)
+	assert.Equal(t, SLOPassed, items.Status)
+	assert.Equal(t, SLOCheck{Metric: "cart_render", App: "Shop", Unit: "ms", Current: 80, MaxRegression: 10, Status: SLONoBaseline}, report.Checks[4])
+	assert.Equal(t, 1, report.Failed)
+	assert.Equal(t, 1, report.Warnings)
+	assert.EqualError(t, report.Err(), "1 SLO(s) regressed: duration of Shop is 50.0% worse than its baseline (limit 20%)")
+
+	var out bytes.Buffer
+	require.NoError(t, WriteSLOReport(&out, report))
+	assert.Regexp(t, `duration\s+Shop\s+1000 ms\s+3\s+1500 ms\s+\+50\.0%\s+20%\s+FAILED`, out.String())
+	assert.Regexp(t, `success_rate\s+\(run\)\s+100\.0%\s+3\s+50\.0%\s+-50\.0 pts\s+10 pts\s+WARNING`, out.String())
+	assert.Regexp(t, `cart_render\s+Shop\s+-\s+0\s+80 ms\s+-\s+10%\s+NO BASELINE`, out.String())
+
+	// Without a history yet, nothing has a baseline
+	report, err = executor.CheckSLOs(filepath.Join(t.TempDir(), history.FileName))
+	require.NoError(t, err)
+	assert.NoError(t, report.Err())
+	for _, c := range report.Checks {
+		assert.Equal(t, SLONoBaseline, c.Status)
+	}
+
+	off := NewExecutor(&config.Config{}, t.TempDir(), logger.NewLogger(false))
+	report, err = off.CheckSLOs(path)
+	require.NoError(t, err)
+	assert.Nil(t, report)
+	assert.NoError(t, report.Err())
+}
+
+func TestCheckSLOs_ZeroBaseline(t *testing.T) {
+	settings := &config.SLOSettings{MinRuns: 1, Objectives: []config.SLOObjective{{Metric: "errors", MaxRegression: 50}}}
+	zero := history.Record{Entries: []history.Entry{{App: "Shop", Metrics: []history.Metric{{Name: "errors", Value: 0}}}}}
+	some := history.Record{Entries: []history.Entry{{App: "Shop", Metrics: []history.Metric{{Name: "errors", Value: 2}}}}}
+	assert.Equal(t, SLOPassed, checkSLOs(settings, []history.Record{zero}, zero).Checks[0].Status)
+	assert.Equal(t, SLOFailed, checkSLOs(settings, []history.Record{zero}, some).Checks[0].Status)
+}
@@ -0,0 +1,484 @@
+package executor
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"panoptic/internal/config"
+	"panoptic/internal/logger"
+	"panoptic/internal/platforms"
+	"panoptic/internal/resources"
+)
+
+// Files a soak run writes to its output directory, apart from the
+// functional results
+const (
+	SoakResultsFile = "soak.json"
+	SoakReportFile  = "soak.html"
+)
+
+// Defaults of a soak run: how often to take a health snapshot, and how much
+// memory and latency may grow over the run before it counts as degraded
+const (
+	DefaultSoakSnapshotInterval = 5 * time.Minute
+	DefaultSoakMemoryGrowth     = 20.0 // percent
+	DefaultSoakLatencyGrowth    = 50.0 // percent
+)
+
+// minTrendSnapshots is how many snapshots a trend is fitted to at least
+const minTrendSnapshots = 3
+
+// SoakOptions shapes a soak run
+type SoakOptions struct {
+	Duration         time.Duration // replay the actions until this long has passed
+	SnapshotInterval time.Duration // between health snapshots; 0 for DefaultSoakSnapshotInterval
+	MaxMemoryGrowth  float64       // percent RSS or JavaScript heap may grow; 0 to not check
+	MaxLatencyGrowth float64       // percent a pass or an action may slow down; 0 to not check
+}
+
+// Validate requires a duration and no negative bounds
+func (o SoakOptions) Validate() error {
+	if o.Duration <= 0 {
+		return fmt.Errorf("a soak run needs a duration, got %s", o.Duration)
+	}
+	if o.SnapshotInterval < 0 {
+		return fmt.Errorf("the snapshot interval must not be negative")
+	}
+	if o.MaxMemoryGrowth < 0 || o.MaxLatencyGrowth < 0 {
+		return fmt.Errorf("memory and latency growth bounds must not be negative")
+	}
+	return nil
+}
+
+// SoakReport is the layout of soak.json
+type SoakReport struct {
+	RunID              string       `json:"run_id"`
+	StartedAt          time.Time    `json:"started_at"`
+	FinishedAt         time.Time    `json:"finished_at"`
+	DurationMs         int64        `json:"duration_ms"` // the --duration asked for
+	SnapshotIntervalMs int64        `json:"snapshot_interval_ms"`
+	Apps               []SoakResult `json:"apps"`
+}
+
+// Degraded reports whether an app degraded over the run
+func (r *SoakReport) Degraded() bool {
+	for _, app := range r.Apps {
+		if app.Degraded {
+			return true
+		}
+	}
+	return false
+}
+
+// SoakResult is how an app held up over a soak run
+type SoakResult struct {
+	App         string         `json:"app"`
+	Passes      int            `json:"passes"`
+	DurationMs  int64          `json:"duration_ms"`
+	Error       string         `json:"error,omitempty"` // why the run ended before its duration
+	Snapshots   []SoakSnapshot `json:"snapshots"`
+	Memory      *Trend         `json:"memory,omitempty"`       // resident memory of the process tree, bytes
+	JSHeap      *Trend         `json:"js_heap,omitempty"`      // JavaScript heap of the page, bytes
+	PassLatency *Trend         `json:"pass_latency,omitempty"` // milliseconds a pass took
+	Actions     []ActionTrend  `json:"actions,omitempty"`      // mean milliseconds per action
+	Degraded    bool           `json:"degraded"`
+	Findings    []string       `json:"findings,omitempty"`
+}
+
+// SoakSnapshot is the health of an app at a point of the run. Latencies are
+// the means since the previous snapshot.
+type SoakSnapshot struct {
+	Time        time.Time          `json:"time"`
+	ElapsedMs   int64              `json:"elapsed_ms"`
+	Passes      int                `json:"passes"`
+	RSSBytes    uint64             `json:"rss_bytes,omitempty"`
+	Processes   int                `json:"processes,omitempty"`
+	JSHeapBytes uint64             `json:"js_heap_bytes,omitempty"`
+	PassMs      float64            `json:"pass_ms"`
+	Actions     map[string]float64 `json:"actions"`
+}
+
+// Trend is a least-squares line through a series of snapshots: its values
+// at the first and last snapshot, its slope and the change between them
+type Trend struct {
+	Start         float64 `json:"start"`
+	End           float64 `json:"end"`
+	SlopePerHour  float64 `json:"slope_per_hour"`
+	ChangePercent float64 `json:"change_percent"`
+}
+
+// ActionTrend is how the latency of an action moved over the run
+type ActionTrend struct {
+	Action string `json:"action"`
+	Trend
+}
+
+// trendPoint is a value at an elapsed time
+type trendPoint struct {
+	elapsed time.Duration
+	value   float64
+}
+
+// fitTrend fits a line to the points; nil with too few points, or when
+// they all fall at once
+func fitTrend(points []trendPoint) *Trend {
+	if len(points) < minTrendSnapshots {
+		return nil
+	}
+	n := float64(len(points))
+	var sx, sy, sxx, sxy float64
+	for _, p := range points {
+		x := p.elapsed.Hours()
+		sx += x
+		sy += p.value
+		sxx += x * x
+		sxy += x * p.value
+	}
+	d := n*sxx - sx*sx
+	if d == 0 {
+		return nil
+	}
+	slope := (n*sxy - sx*sy) / d
+	intercept := (sy - slope*sx) / n
+	first, last := points[0].elapsed.Hours(), points[len(points)-1].elapsed.Hours()
+	t := &Trend{
+		Start:        intercept + slope*first,
+		End:          intercept + slope*last,
+		SlopePerHour: slope,
+	}
+	if t.Start > 0 {
+		t.ChangePercent = (t.End - t.Start) / t.Start * 100
+	}
+	return t
+}
+
+// heapReader is implemented by platforms that can tell the JavaScript heap
+// size of their page
+type heapReader interface {
+	JSHeapUsage() (uint64, error)
+}
+
+// soakSession loops the actions of an app and takes its health snapshots
+type soakSession struct {
+	ctx       context.Context
+	opts      SoakOptions
+	logger    *logger.Logger
+	started   time.Time
+	passes    int
+	snapshots []SoakSnapshot
+	last      time.Time // of the latest snapshot
+
+	// Latencies since the latest snapshot
+	order    []string
+	actions  map[string][]time.Duration
+	passTook time.Duration   // of the pass running
+	passDurs []time.Duration // of the passes that finished
+}
+
+func newSoakSession(ctx context.Context, opts SoakOptions, log *logger.Logger) *soakSession {
+	return &soakSession{ctx: ctx, opts: opts, logger: log, started: time.Now(), actions: make(map[string][]time.Duration)}
+}
+
+// action records how long an action of the pass took
+func (s *soakSession) action(action config.Action, took time.Duration, _ error) {
+	if _, ok := s.actions[action.Name]; !ok {
+		s.order = append(s.order, action.Name)
+	}
+	s.actions[action.Name] = append(s.actions[action.Name], took)
+	s.passTook += took
+}
+
+// passDone ends a pass, taking a snapshot after the first pass, every
+// interval and at the end, and reports whether to replay the actions again
+func (s *soakSession) passDone(platform platforms.Platform) bool {
+	s.passes++
+	s.passDurs = append(s.passDurs, s.passTook)
+	s.passTook = 0
+	done := s.ctx.Err() != nil || time.Since(s.started) >= s.opts.Duration
+	interval := s.opts.SnapshotInterval
+	if interval == 0 {
+		interval = DefaultSoakSnapshotInterval
+	}
+	if s.passes == 1 || done || time.Since(s.last) >= interval {
+		s.snapshot(platform)
+	}
+	return !done
+}
+
+// snapshot measures the app and the latencies since the previous snapshot
+func (s *soakSession) snapshot(platform platforms.Platform) {
+	now := time.Now()
+	snap := SoakSnapshot{Time: now, ElapsedMs: now.Sub(s.started).Milliseconds(), Passes: s.passes, Actions: make(map[string]float64)}
+	if identifier, ok := platform.(processIdentifier); ok && identifier.ProcessID() > 0 {
+		if sample, err := resources.Measure(identifier.ProcessID()); err == nil {
+			snap.RSSBytes, snap.Processes = sample.RSSBytes, sample.Processes
+		} else {
+			s.logger.Debugf("Soak snapshot without process memory: %v", err)
+		}
+	}
+	if reader, ok := platform.(heapReader); ok {
+		if heap, err := reader.JSHeapUsage(); err == nil {
+			snap.JSHeapBytes = heap
+		} else {
+			s.logger.Debugf("Soak snapshot without JavaScript heap: %v", err)
+		}
+	}
+	snap.PassMs = millis(meanDuration(s.passDurs))
+	for _, name := range s.order {
+		if durations := s.actions[name]; len(durations) > 0 {
+			snap.Actions[name] = millis(meanDuration(durations))
+		}
+	}
+	s.snapshots = append(s.snapshots, snap)
+	s.last = now
+	s.passDurs = nil
+	for name := range s.actions {
+		s.actions[name] = nil
+	}
+	s.logger.Infof("Soak snapshot after %d pass(es): %.1f MB resident, %.1f MB JavaScript heap, %.0f ms per pass",
+		snap.Passes, float64(snap.RSSBytes)/(1024*1024), float64(snap.JSHeapBytes)/(1024*1024), snap.PassMs)
+}
+
+func meanDuration(durations []time.Duration) time.Duration {
+	if len(durations) == 0 {
+		return 0
+	}
+	var total time.Duration
+	for _, d := range durations {
+		total += d
+	}
+	return total / time.Duration(len(durations))
+}
+
+// RunSoak replays the actions of every app in a loop for opts.Duration,
+// in one session of each, taking health snapshots of process memory, the
+// JavaScript heap of web pages and latencies along the way. Growth beyond
+// the options' bounds marks the app degraded. Record actions are left out;
+// screenshots go to soak/<app> in the output directory. Results are
+// returned for WriteSoakReport, not kept with the functional results.
+func (e *Executor) RunSoak(ctx context.Context, opts SoakOptions) (*SoakReport, error) {
+	if err := opts.Validate(); err != nil {
+		return nil, err
+	}
+	if opts.SnapshotInterval == 0 {
+		opts.SnapshotInterval = DefaultSoakSnapshotInterval
+	}
+	if err := e.config.Validate(); err != nil {
+		return nil, fmt.Errorf("configuration validation failed: %w", err)
+	}
+	var apps []config.AppConfig
+	for _, app := range e.config.Apps {
+		actions, _ := e.config.SelectActions(app, e.tagFilter)
+		app.Actions = nil
+		for _, action := range actions {
+			if action.Type == "record" {
+				continue
+			}
+			action.Record = false
+			app.Actions = append(app.Actions, action)
+		}
+		if len(app.Actions) == 0 {
+			e.logger.Infof("Skipping app %s: no actions to replay", app.Name)
+			continue
+		}
+		app.Browsers = nil
+		apps = append(apps, app)
+	}
+	if len(apps) == 0 {
+		return nil, errors.New("no app has actions to soak test")
+	}
+	if err := e.checkCapabilities(apps); err != nil {
+		return nil, fmt.Errorf("configuration validation failed: %w", err)
+	}
+
+	report := &SoakReport{RunID: e.runID, StartedAt: time.Now(), DurationMs: opts.Duration.Milliseconds(), SnapshotIntervalMs: opts.SnapshotInterval.Milliseconds()}
+	for _, app := range apps {
+		if ctx.Err() != nil {
+			break
+		}
+		e.logger.Infof("Soak testing %s for %s", app.Name, opts.Duration)
+		report.Apps = append(report.Apps, e.soakApp(ctx, app, opts))
+	}
+	report.FinishedAt = time.Now()
+	return report, ctx.Err()
+}
+
+// soakApp loops the actions of an app and summarizes its snapshots
+func (e *Executor) soakApp(ctx context.Context, app config.AppConfig, opts SoakOptions) SoakResult {
+	dirName := strings.Trim(unsafeDirChars.ReplaceAllString(app.Name, "_"), "_")
+	if dirName == "" {
+		dirName = "app"
+	}
+	log := e.logger.WithFields(logger.Fields{"app": app.Name})
+	soaker := NewExecutor(e.config, filepath.Join(e.outputDir, "soak", dirName), log)
+	soaker.SetRunID(e.runID)
+	soaker.SetFakeSeed(e.fakeSeed)
+	soaker.tracer = e.tracer
+	session := newSoakSession(ctx, opts, log)
+	soaker.soak = session
+	soaker.onAction = session.action
+
+	run := soaker.executeApp(app)
+	result := SoakResult{App: app.Name, Passes: session.passes, DurationMs: time.Since(session.started).Milliseconds(), Snapshots: session.snapshots}
+	if !run.Success {
+		result.Error = run.Error
+		result.Degraded = true
+		result.Findings = append(result.Findings, fmt.Sprintf("stopped after %d pass(es): %s", session.passes, run.Error))
+	}
+	if result.Snapshots == nil {
+		result.Snapshots = []SoakSnapshot{}
+	}
+	result.summarize(session.order, opts)
+	return result
+}
+
+// summarize fits the trends of the snapshots and flags growth beyond the
+// bounds as degradation
+func (r *SoakResult) summarize(actions []string, opts SoakOptions) {
+	series := func(value func(SoakSnapshot) (float64, bool)) *Trend {
+		var points []trendPoint
+		for _, snap := range r.Snapshots {
+			if v, ok := value(snap); ok {
+				points = append(points, trendPoint{time.Duration(snap.ElapsedMs) * time.Millisecond, v})
+			}
+		}
+		return fitTrend(points)
+	}
+	r.Memory = series(func(s SoakSnapshot) (float64, bool) { return float64(s.RSSBytes), s.RSSBytes > 0 })
+	r.JSHeap = series(func(s SoakSnapshot) (float64, bool) { return float64(s.JSHeapBytes), s.JSHeapBytes > 0 })
+	r.PassLatency = series(func(s SoakSnapshot) (float64, bool) { return s.PassMs, true })
+	for _, name := range actions {
+		trend := series(func(s SoakSnapshot) (float64, bool) {
+			v, ok := s.Actions[name]
+			return v, ok
+		})
+		if trend != nil {
+			r.Actions = append(r.Actions, ActionTrend{Action: name, Trend: *trend})
+		}
+	}
+
+	const mb = 1024 * 1024
+	grew := func(what string, t *Trend) {
+		if t != nil && opts.MaxMemoryGrowth > 0 && t.ChangePercent > opts.MaxMemoryGrowth {
+			r.Degraded = true
+			r.Findings = append(r.Findings, fmt.Sprintf("%s grew %.1f%% (%.1f MB to %.1f MB, %+.1f MB per hour; limit %.0f%%)",
+				what, t.ChangePercent, t.Start/mb, t.End/mb, t.SlopePerHour/mb, opts.MaxMemoryGrowth))
+		}
+	}
+	grew("resident memory", r.Memory)
+	grew("JavaScript heap", r.JSHeap)
+	slowed := func(what string, t *Trend) {
+		if t != nil && opts.MaxLatencyGrowth > 0 && t.ChangePercent > opts.MaxLatencyGrowth {
+			r.Degraded = true
+			r.Findings = append(r.Findings, fmt.Sprintf("%s slowed %.1f%% (%.0f ms to %.0f ms; limit %.0f%%)",
+				what, t.ChangePercent, t.Start, t.End, opts.MaxLatencyGrowth))
+		}
+	}
+	slowed("a pass", r.PassLatency)
+	for _, a := range r.Actions {
+		slowed(fmt.Sprintf("action '%s'", a.Action), &a.Trend)
+	}
+}
+
+// WriteSoakReport writes soak.json and soak.html to dir
+func WriteSoakReport(dir string, report *SoakReport) error {
+	data, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal soak results: %w", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, SoakResultsFile), data, 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", SoakResultsFile, err)
+	}
+
+	var b strings.Builder
+	b.WriteString(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="UTF-8">
+<meta name="viewport" content="width=device-width, initial-scale=1.0">
+<title>Panoptic Soak Report</title>
+<style>
+*{margin:0;padding:0;box-sizing:border-box}
+body{font-family:-apple-system,BlinkMacSystemFont,'Segoe UI',Roboto,sans-serif;background:#1a1a2e;color:#e0e0e0;padding:20px}
+.header{padding:30px 0;border-bottom:1px solid #16213e;margin-bottom:20px}
+.header h1{font-size:2em;color:#64b5f6}
+.header .subtitle{color:#888;margin-top:6px}
+.soak-app{background:#16213e;border-radius:8px;padding:20px;margin-bottom:20px}
+.soak-app h2{font-size:1.2em;margin-bottom:8px}
+.soak-app h3{font-size:0.95em;color:#aaa;margin:14px 0 6px}
+.soak-app .summary{color:#aaa;font-size:0.9em;margin-bottom:12px}
+.steady{color:#4caf50}
+.fail{color:#f44336}
+table{width:100%;border-collapse:collapse;font-size:0.85em}
+th,td{padding:6px 10px;text-align:right;border-bottom:1px solid #0f3460}
+th:first-child,td:first-child{text-align:left}
+.findings{font-size:0.85em;color:#ef9a9a}
+.findings li{margin-left:20px;white-space:pre-wrap;word-break:break-all}
+</style>
+</head>
+<body>
+<div class="header">
+<h1>Panoptic Soak Report</h1>
+`)
+	b.WriteString(fmt.Sprintf(`<div class="subtitle">%s per app, a snapshot every %s, run %s, %s to %s</div>
+</div>
+`, formatDuration(time.Duration(report.DurationMs)*time.Millisecond), formatDuration(time.Duration(report.SnapshotIntervalMs)*time.Millisecond),
+		html.EscapeString(report.RunID), report.StartedAt.Format("2006-01-02 15:04:05"), report.FinishedAt.Format("15:04:05")))
+	const mb = 1024 * 1024
+	for _, app := range report.Apps {
+		verdict := `<span class="steady">steady</span>`
+		if app.Degraded {
+			verdict = `<span class="fail">degraded</span>`
+		}
+		b.WriteString(fmt.Sprintf(`<div class="soak-app">
+<h2>%s</h2>
+<div class="summary">%d pass(es) in %s, %s</div>
+`, html.EscapeString(app.App), app.Passes, formatDuration(time.Duration(app.DurationMs)*time.Millisecond), verdict))
+		if len(app.Findings) > 0 {
+			b.WriteString(`<ul class="findings">` + "\n")
+			for _, f := range app.Findings {
+				b.WriteString(fmt.Sprintf("<li>%s</li>\n", html.EscapeString(f)))
+			}
+			b.WriteString("</ul>\n")
+		}
+
+		type row struct {
+			name  string
+			trend *Trend
+			unit  float64
+			label string
+		}
+		rows := []row{{"Resident memory", app.Memory, mb, "MB"}, {"JavaScript heap", app.JSHeap, mb, "MB"}, {"Pass", app.PassLatency, 1, "ms"}}
+		for i := range app.Actions {
+			rows = append(rows, row{app.Actions[i].Action, &app.Actions[i].Trend, 1, "ms"})
+		}
+		b.WriteString("<h3>Trends</h3>\n<table>\n<tr><th>Measure</th><th>Start</th><th>End</th><th>Per hour</th><th>Change</th></tr>\n")
+		for _, r := range rows {
+			if r.trend == nil {
+				continue
+			}
+			b.WriteString(fmt.Sprintf("<tr><td>%s</td><td>%.1f %s</td><td>%.1f %s</td><td>%+.1f %s</td><td>%+.1f%%</td></tr>\n",
+				html.EscapeString(r.name), r.trend.Start/r.unit, r.label, r.trend.End/r.unit, r.label, r.trend.SlopePerHour/r.unit, r.label, r.trend.ChangePercent))
+		}
+		b.WriteString("</table>\n")
+
+		b.WriteString("<h3>Snapshots</h3>\n<table>\n<tr><th>Elapsed</th><th>Passes</th><th>Resident</th><th>Processes</th><th>JavaScript heap</th><th>Pass</th></tr>\n")
+		for _, s := range app.Snapshots {
+			b.WriteString(fmt.Sprintf("<tr><td>%s</td><td>%d</td><td>%.1f MB</td><td>%d</td><td>%.1f MB</td><td>%.0f ms</td></tr>\n",
+				formatDuration(time.Duration(s.ElapsedMs)*time.Millisecond), s.Passes, float64(s.RSSBytes)/mb, s.Processes, float64(s.JSHeapBytes)/mb, s.PassMs))
+		}
+		b.WriteString("</table>\n</div>\n")
+	}
+	b.WriteString("</body>\n</html>\n")
+	if err := os.WriteFile(filepath.Join(dir, SoakReportFile), []byte(b.String()), 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", SoakReportFile, err)
+	}
+	return nil
+}
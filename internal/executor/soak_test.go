@@ -0,0 +1,144 @@
+package executor
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"panoptic/internal/config"
+	"panoptic/internal/logger"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestExecutor_RunSoak tests that the actions loop until the duration passed
+// with snapshots along the way, and that a failing pass ends the soak of an
+// app as degraded
+func TestExecutor_RunSoak(t *testing.T) {
+	driver := scriptDriver(t, `"navigate","click"`)
+	cfg := &config.Config{Apps: []config.AppConfig{
+		{Name: "Kiosk", Type: "driver", Driver: driver, Actions: []config.Action{
+			{Name: "tap", Type: "click", Selector: "#start"},
+			{Name: "film", Type: "record", Duration: 1},
+		}},
+		{Name: "Broken", Type: "driver", Driver: driver, Actions: []config.Action{
+			{Name: "go", Type: "navigate", URL: "app://home"},
+		}},
+	}}
+	outputDir := t.TempDir()
+	executor := NewExecutor(cfg, outputDir, logger.NewLogger(false))
+	report, err := executor.RunSoak(context.Background(), SoakOptions{Duration: 300 * time.Millisecond, SnapshotInterval: 50 * time.Millisecond})
+	require.NoError(t, err)
+	assert.Equal(t, executor.RunID(), report.RunID)
+	assert.Equal(t, int64(50), report.SnapshotIntervalMs)
+	require.Len(t, report.Apps, 2)
+
+	kiosk := report.Apps[0]
+	assert.Empty(t, kiosk.Error)
+	assert.Greater(t, kiosk.Passes, 1)
+	assert.GreaterOrEqual(t, kiosk.DurationMs, int64(300))
+	require.GreaterOrEqual(t, len(kiosk.Snapshots), minTrendSnapshots)
+	assert.Equal(t, 1, kiosk.Snapshots[0].Passes, "a snapshot after the first pass")
+	assert.Equal(t, kiosk.Passes, kiosk.Snapshots[len(kiosk.Snapshots)-1].Passes, "a snapshot at the end")
+	assert.Contains(t, kiosk.Snapshots[0].Actions, "tap")
+	assert.NotContains(t, kiosk.Snapshots[0].Actions, "film", "record actions are left out")
+	assert.NotNil(t, kiosk.PassLatency)
+	require.Len(t, kiosk.Actions, 1)
+	assert.Equal(t, "tap", kiosk.Actions[0].Action)
+
+	broken := report.Apps[1]
+	assert.Zero(t, broken.Passes)
+	assert.Empty(t, broken.Snapshots)
+	assert.True(t, broken.Degraded)
+	assert.Contains(t, broken.Error, "no such screen")
+	assert.True(t, report.Degraded())
+	assert.Empty(t, executor.results, "soak runs keep no functional results")
+
+	require.NoError(t, WriteSoakReport(outputDir, report))
+	data, err := os.ReadFile(filepath.Join(outputDir, SoakResultsFile))
+	require.NoError(t, err)
+	var written SoakReport
+	require.NoError(t, json.Unmarshal(data, &written))
+	assert.Equal(t, kiosk.Passes, written.Apps[0].Passes)
+	page, err := os.ReadFile(filepath.Join(outputDir, SoakReportFile))
+	require.NoError(t, err)
+	assert.Contains(t, string(page), "<h2>Broken</h2>")
+	assert.Contains(t, string(page), `<span class="fail">degraded</span>`)
+	assert.Contains(t, string(page), "<td>tap</td>")
+}
+
+// TestExecutor_RunSoak_Cancel tests that a cancelled soak ends after the pass
+// running
+func TestExecutor_RunSoak_Cancel(t *testing.T) {
+	cfg := &config.Config{Apps: []config.AppConfig{
+		{Name: "Kiosk", Type: "driver", Driver: scriptDriver(t, `"click"`), Actions: []config.Action{{Name: "tap", Type: "click", Selector: "#start"}}},
+	}}
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	executor := NewExecutor(cfg, t.TempDir(), logger.NewLogger(false))
+	report, err := executor.RunSoak(ctx, SoakOptions{Duration: time.Hour})
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+	require.Len(t, report.Apps, 1)
+	assert.Less(t, report.Apps[0].DurationMs, int64(10000))
+	assert.Len(t, report.Apps[0].Snapshots, 2, "after the first pass and at the end")
+}
+
+func TestSoakOptions_Validate(t *testing.T) {
+	assert.NoError(t, SoakOptions{Duration: time.Hour}.Validate())
+	assert.Error(t, SoakOptions{}.Validate())
+	assert.Error(t, SoakOptions{Duration: time.Hour, SnapshotInterval: -time.Second}.Validate())
+	assert.Error(t, SoakOptions{Duration: time.Hour, MaxMemoryGrowth: -1}.Validate())
+}
+
+func TestFitTrend(t *testing.T) {
+	assert.Nil(t, fitTrend([]trendPoint{{0, 1}, {time.Hour, 2}}), "too few points")
+	assert.Nil(t, fitTrend([]trendPoint{{time.Hour, 1}, {time.Hour, 2}, {time.Hour, 3}}), "points at once")
+
+	trend := fitTrend([]trendPoint{{0, 100}, {time.Hour, 110}, {2 * time.Hour, 120}})
+	require.NotNil(t, trend)
+	assert.InDelta(t, 100, trend.Start, 1e-9)
+	assert.InDelta(t, 120, trend.End, 1e-9)
+	assert.InDelta(t, 10, trend.SlopePerHour, 1e-9)
+	assert.InDelta(t, 20, trend.ChangePercent, 1e-9)
+
+	// A spike in the middle moves the line less than the ends
+	noisy := fitTrend([]trendPoint{{0, 100}, {time.Hour, 200}, {2 * time.Hour, 100}})
+	require.NotNil(t, noisy)
+	assert.InDelta(t, 0, noisy.ChangePercent, 1e-9)
+}
+
+// TestSoakResult_Summarize tests that memory and latency growth beyond the
+// bounds is reported as degradation, and steady measures are not
+func TestSoakResult_Summarize(t *testing.T) {
+	const mb = 1024 * 1024
+	result := SoakResult{App: "Shop"}
+	for i := 0; i < 4; i++ {
+		result.Snapshots = append(result.Snapshots, SoakSnapshot{
+			ElapsedMs:   int64(i) * time.Hour.Milliseconds(),
+			RSSBytes:    uint64(100+10*i) * mb, // +30% over the run
+			JSHeapBytes: 20 * mb,
+			PassMs:      1000,
+			Actions:     map[string]float64{"login": 200 + 100*float64(i), "search": 50},
+		})
+	}
+	result.summarize([]string{"login", "search"}, SoakOptions{MaxMemoryGrowth: 20, MaxLatencyGrowth: 50})
+	assert.True(t, result.Degraded)
+	require.NotNil(t, result.Memory)
+	assert.InDelta(t, 30, result.Memory.ChangePercent, 1e-9)
+	assert.InDelta(t, 10, result.Memory.SlopePerHour/mb, 1e-9)
+	assert.InDelta(t, 0, result.JSHeap.ChangePercent, 1e-9)
+	require.Len(t, result.Actions, 2)
+	assert.InDelta(t, 150, result.Actions[0].ChangePercent, 1e-9)
+	require.Len(t, result.Findings, 2)
+	assert.Contains(t, result.Findings[0], "resident memory grew 30.0% (100.0 MB to 130.0 MB, +10.0 MB per hour")
+	assert.Contains(t, result.Findings[1], "action 'login' slowed 150.0% (200 ms to 500 ms")
+
+	steady := SoakResult{Snapshots: result.Snapshots}
+	steady.summarize([]string{"login", "search"}, SoakOptions{})
+	assert.False(t, steady.Degraded, "no bounds to check")
+	assert.Empty(t, steady.Findings)
+}
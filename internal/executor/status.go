@@ -0,0 +1,130 @@
+package executor
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// StatusFile is the progress file a run keeps up to date in its output
+// directory while it runs, for following a long run or seeing how far one
+// that crashed got
+const StatusFile = "status.json"
+
+// maxStatusFailures bounds the failures status.json lists, so rewriting it
+// stays cheap however many apps fail
+const maxStatusFailures = 50
+
+// States of a run in status.json. A crashed run stays running; its
+// updated_at tells when it was last heard from.
+const (
+	RunStateRunning  = "running"
+	RunStateFinished = "finished"
+	RunStateStopped  = "stopped" // cancelled before all apps ran
+)
+
+// RunStatus is the layout of status.json
+type RunStatus struct {
+	RunID     string      `json:"run_id"`
+	State     string      `json:"state"`
+	StartedAt time.Time   `json:"started_at"`
+	UpdatedAt time.Time   `json:"updated_at"`
+	Apps      int         `json:"apps"`              // apps the run will run
+	Summary   RunSummary  `json:"summary"`           // of the apps finished so far
+	Running   string      `json:"running,omitempty"` // app running now
+	Last      *AppStatus  `json:"last,omitempty"`    // app finished last
+	Failures  []AppStatus `json:"failures"`          // the latest failed apps, oldest first
+}
+
+// AppStatus is the outcome of a finished app in status.json
+type AppStatus struct {
+	App      string        `json:"app"`
+	Success  bool          `json:"success"`
+	Error    string        `json:"error,omitempty"`
+	Duration time.Duration `json:"duration"`
+}
+
+// startStatus begins the status of a run of apps
+func (e *Executor) startStatus(apps int) {
+	e.status = &RunStatus{
+		RunID:     e.runID,
+		State:     RunStateRunning,
+		StartedAt: e.startedAt,
+		Apps:      apps,
+		Failures:  []AppStatus{},
+	}
+	e.writeStatus()
+}
+
+// statusRunning records the app that is starting
+func (e *Executor) statusRunning(app string) {
+	e.status.Running = app
+	e.writeStatus()
+}
+
+// statusFinished records the result of the app that finished
+func (e *Executor) statusFinished(result *TestResult) {
+	app := AppStatus{App: result.AppName, Success: result.Success, Error: result.Error, Duration: result.Duration}
+	e.status.Running = ""
+	e.status.Last = &app
+	if !result.Success {
+		if len(e.status.Failures) == maxStatusFailures {
+			e.status.Failures = append(e.status.Failures[:0], e.status.Failures[1:]...)
+		}
+		e.status.Failures = append(e.status.Failures, app)
+	}
+	e.writeStatus()
+}
+
+// endStatus records how the run ended
+func (e *Executor) endStatus(state string) {
+	if e.status == nil {
+		return
+	}
+	e.status.State = state
+	e.status.Running = ""
+	e.writeStatus()
+	e.status = nil
+}
+
+// writeStatus replaces status.json, through a temporary file so readers
+// never see it half written. Runs without an output directory have none.
+func (e *Executor) writeStatus() {
+	if e.outputDir == "" {
+		return
+	}
+	if _, err := os.Stat(e.outputDir); err != nil {
+		return
+	}
+	e.status.UpdatedAt = time.Now()
+	e.status.Summary = e.Summary()
+	data, err := json.MarshalIndent(e.status, "", "  ")
+	if err != nil {
+		e.logger.Warnf("Failed to update %s: %v", StatusFile, err)
+		return
+	}
+	path := filepath.Join(e.outputDir, StatusFile)
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		e.logger.Warnf("Failed to update %s: %v", StatusFile, err)
+		return
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		os.Remove(tmp)
+		e.logger.Warnf("Failed to update %s: %v", StatusFile, err)
+	}
+}
+
+// ReadStatus reads the status.json of an output directory
+func ReadStatus(outputDir string) (*RunStatus, error) {
+	data, err := os.ReadFile(filepath.Join(outputDir, StatusFile))
+	if err != nil {
+		return nil, err
+	}
+	var status RunStatus
+	if err := json.Unmarshal(data, &status); err != nil {
+		return nil, err
+	}
+	return &status, nil
+}
@@ -0,0 +1,76 @@
+package executor
+
+import (
+	"fmt"
+	"testing"
+
+	"panoptic/internal/config"
+	"panoptic/internal/logger"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestExecutor_Status tests that status.json follows the run app by app
+func TestExecutor_Status(t *testing.T) {
+	outputDir := t.TempDir()
+	executor := NewExecutor(&config.Config{}, outputDir, logger.NewLogger(false))
+	executor.SetRunID("run-1")
+
+	executor.startStatus(2)
+	status, err := ReadStatus(outputDir)
+	require.NoError(t, err)
+	assert.Equal(t, RunStateRunning, status.State)
+	assert.Equal(t, 2, status.Apps)
+	assert.Equal(t, "run-1", status.RunID)
+
+	executor.statusRunning("Shop")
+	status, err = ReadStatus(outputDir)
+	require.NoError(t, err)
+	assert.Equal(t, "Shop", status.Running)
+
+	result := TestResult{AppName: "Shop", Error: "timeout"}
+	executor.results = append(executor.results, result)
+	executor.statusFinished(&result)
+	status, err = ReadStatus(outputDir)
+	require.NoError(t, err)
+	assert.Empty(t, status.Running)
+	assert.Equal(t, 1, status.Summary.Failed)
+	require.NotNil(t, status.Last)
+	assert.Equal(t, "Shop", status.Last.App)
+	require.Len(t, status.Failures, 1)
+	assert.Equal(t, "timeout", status.Failures[0].Error)
+
+	executor.endStatus(RunStateFinished)
+	status, err = ReadStatus(outputDir)
+	require.NoError(t, err)
+	assert.Equal(t, RunStateFinished, status.State)
+	assert.NoFileExists(t, outputDir+"/"+StatusFile+".tmp")
+}
+
+func TestExecutor_Status_Failures(t *testing.T) {
+	executor := NewExecutor(&config.Config{}, t.TempDir(), logger.NewLogger(false))
+	executor.startStatus(maxStatusFailures + 5)
+	for i := 0; i < maxStatusFailures+5; i++ {
+		executor.statusFinished(&TestResult{AppName: fmt.Sprintf("app-%d", i)})
+	}
+	require.Len(t, executor.status.Failures, maxStatusFailures)
+	assert.Equal(t, "app-5", executor.status.Failures[0].App, "the oldest failures make room")
+}
+
+// TestExecutor_Run_Status tests the status of a whole run
+func TestExecutor_Run_Status(t *testing.T) {
+	outputDir := t.TempDir()
+	cfg := &config.Config{Apps: []config.AppConfig{{
+		Name: "Kiosk", Type: "driver", Driver: scriptDriver(t, ""),
+		Actions: []config.Action{{Name: "open", Type: "navigate", Value: "home"}},
+	}}}
+	executor := NewExecutor(cfg, outputDir, logger.NewLogger(false))
+	require.NoError(t, executor.Run())
+
+	status, err := ReadStatus(outputDir)
+	require.NoError(t, err)
+	assert.Equal(t, RunStateFinished, status.State)
+	assert.Equal(t, 1, status.Summary.Total)
+	assert.Equal(t, 1, status.Summary.Failed, "the script driver has no screens")
+}
@@ -0,0 +1,314 @@
+package executor
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+
+	"panoptic/internal/redact"
+)
+
+// ResultStreamFile is the stream file panoptic run --stream-results writes
+// in the output directory
+const ResultStreamFile = "results.ndjson"
+
+// ResultStream appends results to an NDJSON file, one line per app as it
+// finishes, so a run's results needn't stay in memory until it ends and
+// what finished survives a crash
+type ResultStream struct {
+	path     string
+	file     *os.File
+	w        *bufio.Writer
+	redactor *redact.Redactor // settings.redaction of the run writing it
+}
+
+// CreateResultStream creates, or truncates, the stream file at path
+func CreateResultStream(path string) (*ResultStream, error) {
+	return openResultStream(path, os.O_TRUNC)
+}
+
+// appendResultStream opens the stream file at path to add results after
+// those it holds
+func appendResultStream(path string) (*ResultStream, error) {
+	return openResultStream(path, os.O_APPEND)
+}
+
+func openResultStream(path string, flag int) (*ResultStream, error) {
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|flag, 0600)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create result stream: %w", err)
+	}
+	return &ResultStream{path: path, file: file, w: bufio.NewWriter(file)}, nil
+}
+
+// Path is the stream's file
+func (s *ResultStream) Path() string {
+	return s.path
+}
+
+// Append writes a result as one line and flushes it to the file
+func (s *ResultStream) Append(result *TestResult) error {
+	data, err := json.Marshal(result)
+	if err != nil {
+		return fmt.Errorf("failed to marshal result of %s: %w", result.AppName, err)
+	}
+	s.w.Write(s.redactor.JSON(data))
+	s.w.WriteByte('\n')
+	if err := s.w.Flush(); err != nil {
+		return fmt.Errorf("failed to write result stream: %w", err)
+	}
+	return nil
+}
+
+// Close closes the stream's file
+func (s *ResultStream) Close() error {
+	if err := s.w.Flush(); err != nil {
+		s.file.Close()
+		return err
+	}
+	return s.file.Close()
+}
+
+// ReadResultStream calls fn with each result of a stream file in order,
+// decoding one at a time. A last line cut short, by a run that crashed
+// while writing it, is ignored.
+func ReadResultStream(path string, fn func(*TestResult) error) error {
+	reader, err := openResultStreamReader(path)
+	if err != nil {
+		return err
+	}
+	defer reader.Close()
+	for {
+		result, err := reader.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if err := fn(result); err != nil {
+			return err
+		}
+	}
+}
+
+// resultStreamReader decodes the results of a stream file one at a time
+type resultStreamReader struct {
+	path string
+	file *os.File
+	r    *bufio.Reader
+	line int
+}
+
+func openResultStreamReader(path string) (*resultStreamReader, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open result stream: %w", err)
+	}
+	return &resultStreamReader{path: path, file: file, r: bufio.NewReader(file)}, nil
+}
+
+// Next returns the next result, or io.EOF after the last one, a last line
+// cut short included
+func (rd *resultStreamReader) Next() (*TestResult, error) {
+	for {
+		rd.line++
+		data, err := rd.r.ReadBytes('\n')
+		if len(bytes.TrimSpace(data)) > 0 {
+			complete := err == nil
+			var result TestResult
+			if jsonErr := json.Unmarshal(data, &result); jsonErr != nil {
+				if !complete {
+					return nil, io.EOF
+				}
+				return nil, fmt.Errorf("%s:%d: invalid result: %w", rd.path, rd.line, jsonErr)
+			}
+			return &result, nil
+		}
+		if err == io.EOF {
+			return nil, io.EOF
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read result stream: %w", err)
+		}
+	}
+}
+
+// Close closes the stream's file
+func (rd *resultStreamReader) Close() error {
+	return rd.file.Close()
+}
+
+// summary is what a streamed run keeps in memory of a result: all but its
+// metrics and artifacts, except the failed action and the last screenshot
+// of a failure, which notifications, SARIF and issues report
+func (r *TestResult) summary() TestResult {
+	slim := *r
+	slim.Metrics = nil
+	if action, ok := r.Metrics["failed_action"]; ok {
+		slim.Metrics = map[string]interface{}{"failed_action": action}
+	}
+	slim.Screenshots = nil
+	if !r.Success && len(r.Screenshots) > 0 {
+		slim.Screenshots = []string{r.Screenshots[len(r.Screenshots)-1]}
+	}
+	slim.Videos = nil
+	slim.Annotated = nil
+	return slim
+}
+
+// StreamResults appends every result to an NDJSON stream file as its app
+// finishes and keeps only its summary in memory. The report and
+// results.json are then written from the stream, one result at a time.
+func (e *Executor) StreamResults(path string) error {
+	stream, err := CreateResultStream(path)
+	if err != nil {
+		return err
+	}
+	e.stream = stream
+	return nil
+}
+
+// addResult keeps a finished result: appended to the stream, when there
+// is one, and its summary in memory. A result the stream fails to take is
+// kept in full.
+func (e *Executor) addResult(result TestResult) {
+	if e.stream == nil {
+		e.results = append(e.results, result)
+		return
+	}
+	if err := e.stream.Append(&result); err != nil {
+		e.logger.Warnf("Keeping result of %s in memory: %v", result.AppName, err)
+		if e.unstreamed == nil {
+			e.unstreamed = make(map[int]bool)
+		}
+		e.unstreamed[len(e.results)] = true
+		e.results = append(e.results, result)
+		return
+	}
+	e.results = append(e.results, result.summary())
+}
+
+// eachResult calls fn with every full result of the run, in order: those in
+// memory, or those read back from the stream. Metrics added to a summary
+// after it was streamed, such as the issue filed for it, are carried over.
+func (e *Executor) eachResult(fn func(*TestResult) error) error {
+	if e.stream == nil {
+		for i := range e.results {
+			if err := fn(&e.results[i]); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	reader, err := openResultStreamReader(e.stream.Path())
+	if err != nil {
+		return err
+	}
+	defer reader.Close()
+	for i := range e.results {
+		kept := &e.results[i]
+		if e.unstreamed[i] {
+			if err := fn(kept); err != nil {
+				return err
+			}
+			continue
+		}
+		r, err := reader.Next()
+		if err == io.EOF {
+			return fmt.Errorf("result of %s is missing from the result stream", kept.AppName)
+		}
+		if err != nil {
+			return err
+		}
+		if r.AppName != kept.AppName {
+			return fmt.Errorf("result stream holds %s where %s was expected", r.AppName, kept.AppName)
+		}
+		for k, v := range kept.Metrics {
+			if r.Metrics == nil {
+				r.Metrics = make(map[string]interface{})
+			}
+			r.Metrics[k] = v
+		}
+		if err := fn(r); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// saveStreamedResults writes results.json from the stream, as
+// ResultsDocument.Save would have written it, without holding the results
+func (e *Executor) saveStreamedResults(path string) error {
+	doc := e.resultsDocument()
+	doc.Results, doc.Artifacts = []*TestResult{}, []Artifact{}
+	head, err := json.MarshalIndent(&doc, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal results: %w", err)
+	}
+	tail := []byte(`"results": [],
+  "artifacts": []
+}`)
+	if !bytes.HasSuffix(head, tail) {
+		return fmt.Errorf("failed to marshal results: unexpected document layout")
+	}
+
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	w := bufio.NewWriter(file)
+	w.Write(e.redactor.JSON(head[:len(head)-len(tail)]))
+
+	// Results, then their artifacts from a second pass
+	writeArray := func(name string, each func(func(interface{}) error) error) error {
+		w.WriteString(`"` + name + `": [`)
+		n := 0
+		err := each(func(v interface{}) error {
+			data, err := json.MarshalIndent(v, "    ", "  ")
+			if err != nil {
+				return fmt.Errorf("failed to marshal results: %w", err)
+			}
+			if n > 0 {
+				w.WriteByte(',')
+			}
+			w.WriteString("\n    ")
+			_, err = w.Write(e.redactor.JSON(data))
+			n++
+			return err
+		})
+		if n > 0 {
+			w.WriteString("\n  ")
+		}
+		w.WriteString("]")
+		return err
+	}
+	err = writeArray("results", func(add func(interface{}) error) error {
+		return e.eachResult(func(r *TestResult) error { return add(r) })
+	})
+	if err == nil {
+		w.WriteString(",\n  ")
+		err = writeArray("artifacts", func(add func(interface{}) error) error {
+			return e.eachResult(func(r *TestResult) error {
+				for _, a := range r.Artifacts() {
+					if err := add(a); err != nil {
+						return err
+					}
+				}
+				return nil
+			})
+		})
+	}
+	if err != nil {
+		return err
+	}
+	w.WriteString("\n}")
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	return file.Close()
+}
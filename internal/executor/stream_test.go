@@ -0,0 +1,172 @@
+package executor
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"panoptic/internal/config"
+	"panoptic/internal/logger"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestResultStream_RoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ResultStreamFile)
+	stream, err := CreateResultStream(path)
+	require.NoError(t, err)
+	for _, name := range []string{"Shop", "Admin"} {
+		require.NoError(t, stream.Append(&TestResult{AppName: name, Screenshots: []string{name + ".png"}}))
+	}
+	require.NoError(t, stream.Close())
+
+	var got []TestResult
+	require.NoError(t, ReadResultStream(path, func(r *TestResult) error {
+		got = append(got, *r)
+		return nil
+	}))
+	require.Len(t, got, 2)
+	assert.Equal(t, "Admin", got[1].AppName)
+	assert.Equal(t, []string{"Admin.png"}, got[1].Screenshots)
+}
+
+// TestReadResultStream_Truncated tests that the last line of a crashed run
+// is ignored while a broken line before it is not
+func TestReadResultStream_Truncated(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ResultStreamFile)
+	require.NoError(t, os.WriteFile(path, []byte(`{"app_name":"Shop"}`+"\n"+`{"app_na`), 0600))
+
+	var names []string
+	require.NoError(t, ReadResultStream(path, func(r *TestResult) error {
+		names = append(names, r.AppName)
+		return nil
+	}))
+	assert.Equal(t, []string{"Shop"}, names)
+
+	require.NoError(t, os.WriteFile(path, []byte(`{"app_na`+"\n"+`{"app_name":"Shop"}`+"\n"), 0600))
+	err := ReadResultStream(path, func(*TestResult) error { return nil })
+	assert.ErrorContains(t, err, ResultStreamFile+":1: invalid result")
+}
+
+func TestTestResult_Summary(t *testing.T) {
+	failed := TestResult{
+		AppName:     "Admin",
+		Metrics:     map[string]interface{}{"failed_action": "login", "trace": "Admin.zip"},
+		Screenshots: []string{"home.png", "login.png"},
+		Videos:      []string{"checkout.mp4"},
+		Error:       "timeout",
+	}
+	slim := failed.summary()
+	assert.Equal(t, map[string]interface{}{"failed_action": "login"}, slim.Metrics)
+	assert.Equal(t, []string{"login.png"}, slim.Screenshots)
+	assert.Empty(t, slim.Videos)
+	assert.Equal(t, "timeout", slim.Error)
+	assert.Len(t, failed.Screenshots, 2, "the full result is left as is")
+
+	passed := TestResult{AppName: "Shop", Success: true, Screenshots: []string{"home.png"}}
+	assert.Empty(t, passed.summary().Screenshots)
+	assert.Nil(t, passed.summary().Metrics)
+}
+
+// TestExecutor_StreamResults tests that a streamed run writes the same
+// results.json as one holding its results in memory
+func TestExecutor_StreamResults(t *testing.T) {
+	held := goldenExecutor(t)
+	heldPath := filepath.Join(t.TempDir(), "results.json")
+	require.NoError(t, held.SaveResults(heldPath))
+
+	streamed := goldenExecutor(t)
+	streamed.configSHA256 = held.configSHA256
+	require.NoError(t, streamed.StreamResults(filepath.Join(t.TempDir(), ResultStreamFile)))
+	results := streamed.results
+	streamed.results = nil
+	for _, r := range results {
+		streamed.addResult(r)
+	}
+	require.NoError(t, streamed.stream.Close())
+	assert.Empty(t, streamed.results[0].Screenshots)
+	assert.Nil(t, streamed.results[0].Metrics)
+
+	streamedPath := filepath.Join(t.TempDir(), "results.json")
+	require.NoError(t, streamed.SaveResults(streamedPath))
+	want, err := os.ReadFile(heldPath)
+	require.NoError(t, err)
+	got, err := os.ReadFile(streamedPath)
+	require.NoError(t, err)
+	assert.Equal(t, string(want), string(got))
+
+	reportPath := filepath.Join(t.TempDir(), "report.html")
+	require.NoError(t, streamed.GenerateReport(reportPath))
+	report, err := os.ReadFile(reportPath)
+	require.NoError(t, err)
+	assert.Contains(t, string(report), "checkout.mp4")
+}
+
+// TestExecutor_StreamResults_AppendFailure tests that results the stream
+// failed to take still reach the report, each with its own metrics
+func TestExecutor_StreamResults_AppendFailure(t *testing.T) {
+	executor := NewExecutor(&config.Config{Name: "stream"}, t.TempDir(), logger.NewLogger(false))
+	path := filepath.Join(t.TempDir(), ResultStreamFile)
+	require.NoError(t, executor.StreamResults(path))
+	result := func(name string) TestResult {
+		return TestResult{AppName: name, AppType: "web", Success: true,
+			Metrics: map[string]interface{}{"owner": name}, Videos: []string{name + ".mp4"}}
+	}
+
+	executor.addResult(result("Shop"))
+	// The file goes away under the stream for Admin only
+	require.NoError(t, executor.stream.file.Close())
+	executor.addResult(result("Admin"))
+	var err error
+	executor.stream.file, err = os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0600)
+	require.NoError(t, err)
+	executor.stream.w.Reset(executor.stream.file)
+	executor.addResult(result("Search"))
+	require.NoError(t, executor.stream.Close())
+	assert.Equal(t, map[int]bool{1: true}, executor.unstreamed)
+
+	var names []string
+	require.NoError(t, executor.eachResult(func(r *TestResult) error {
+		names = append(names, r.AppName)
+		assert.Equal(t, r.AppName, r.Metrics["owner"])
+		return nil
+	}))
+	assert.Equal(t, []string{"Shop", "Admin", "Search"}, names)
+
+	reportPath := filepath.Join(t.TempDir(), "report.html")
+	require.NoError(t, executor.GenerateReport(reportPath))
+	report, err := os.ReadFile(reportPath)
+	require.NoError(t, err)
+	for _, name := range names {
+		assert.Contains(t, string(report), name+".mp4")
+	}
+}
+
+// TestExecutor_StreamResults_Run tests streaming during a run, including
+// metrics added to a summary after the app finished
+func TestExecutor_StreamResults_Run(t *testing.T) {
+	outputDir := t.TempDir()
+	cfg := &config.Config{Apps: []config.AppConfig{{
+		Name: "Kiosk", Type: "driver", Driver: scriptDriver(t, ""),
+		Actions: []config.Action{{Name: "shot", Type: "screenshot"}},
+	}}}
+	executor := NewExecutor(cfg, outputDir, logger.NewLogger(false))
+	streamPath := filepath.Join(outputDir, ResultStreamFile)
+	require.NoError(t, executor.StreamResults(streamPath))
+	require.NoError(t, executor.Run())
+
+	require.Len(t, executor.results, 1)
+	assert.Contains(t, executor.results[0].Error, "took no screenshot")
+	executor.results[0].Metrics["issue"] = "https://example.com/issues/1"
+
+	var streamed []*TestResult
+	require.NoError(t, executor.eachResult(func(r *TestResult) error {
+		streamed = append(streamed, r)
+		return nil
+	}))
+	require.Len(t, streamed, 1)
+	assert.Equal(t, executor.results[0].Error, streamed[0].Error)
+	assert.Equal(t, "shot", streamed[0].Metrics["failed_action"])
+	assert.Equal(t, "https://example.com/issues/1", streamed[0].Metrics["issue"])
+}
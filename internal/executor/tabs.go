@@ -0,0 +1,59 @@
+package executor
+
+import (
+	"fmt"
+	"time"
+
+	"panoptic/internal/config"
+	"panoptic/internal/platforms"
+)
+
+// tabSwitcher is a platform whose app can open tabs and popup windows
+type tabSwitcher interface {
+	WaitForPopup(timeout time.Duration) (platforms.Tab, error)
+	SwitchTab(index int, title string) (platforms.Tab, error)
+	CloseTab(index int) error
+}
+
+// runTabAction runs wait_for_popup, switch_tab and close_tab. The tab
+// switched to is the one later actions, screenshots and recordings act on.
+func (e *Executor) runTabAction(platform platforms.Platform, action config.Action) error {
+	switcher, ok := platform.(tabSwitcher)
+	if !ok {
+		return fmt.Errorf("%s is not supported on this platform", action.Type)
+	}
+	if action.Type == "close_tab" {
+		pick, err := action.Tab()
+		if err != nil {
+			return err
+		}
+		if err := switcher.CloseTab(pick.Index); err != nil {
+			return err
+		}
+		e.logger.Infof("Closed tab %s", tabLabel(pick.Index))
+		return nil
+	}
+
+	var tab platforms.Tab
+	var err error
+	if action.Type == "wait_for_popup" {
+		tab, err = switcher.WaitForPopup(action.PopupTimeout())
+	} else {
+		var pick *config.Tab
+		if pick, err = action.Tab(); err == nil {
+			tab, err = switcher.SwitchTab(pick.Index, pick.Title)
+		}
+	}
+	if err != nil {
+		return err
+	}
+	e.logger.Infof("Switched to tab %d: %q (%s)", tab.Index, tab.Title, tab.URL)
+	return nil
+}
+
+func tabLabel(index int) string {
+	if index < 0 {
+		return "active"
+	}
+	return fmt.Sprint(index)
+}
@@ -0,0 +1,68 @@
+package executor
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+	"time"
+
+	"panoptic/internal/config"
+	"panoptic/internal/logger"
+	"panoptic/internal/platforms"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// tabsPlatform is a mock platform with an OAuth popup open
+type tabsPlatform struct {
+	*MockPlatform
+	tabs   []platforms.Tab
+	closed []int
+}
+
+func (p *tabsPlatform) WaitForPopup(timeout time.Duration) (platforms.Tab, error) {
+	if len(p.tabs) < 2 {
+		return platforms.Tab{}, fmt.Errorf("no popup or tab opened within %s", timeout)
+	}
+	return p.tabs[1], nil
+}
+
+func (p *tabsPlatform) SwitchTab(index int, title string) (platforms.Tab, error) {
+	if index < 0 {
+		index = 0
+	}
+	return p.tabs[index], nil
+}
+
+func (p *tabsPlatform) CloseTab(index int) error {
+	p.closed = append(p.closed, index)
+	p.tabs = p.tabs[:1]
+	return nil
+}
+
+func TestExecutor_RunTabAction(t *testing.T) {
+	logs := &bytes.Buffer{}
+	log := logger.NewLogger(false)
+	log.SetOutput(logs)
+	executor := NewExecutor(&config.Config{}, t.TempDir(), log)
+	platform := &tabsPlatform{MockPlatform: &MockPlatform{metrics: map[string]interface{}{}}, tabs: []platforms.Tab{
+		{Index: 0, Title: "Shop", URL: "https://shop.test/login"},
+		{Index: 1, Title: "Sign in - Accounts", URL: "https://accounts.test/oauth"},
+	}}
+
+	require.NoError(t, executor.runTabAction(platform, config.Action{Name: "oauth", Type: "wait_for_popup"}))
+	assert.Contains(t, logs.String(), `Switched to tab 1: "Sign in - Accounts" (https://accounts.test/oauth)`)
+	require.NoError(t, executor.runTabAction(platform, config.Action{Name: "done", Type: "close_tab"}))
+	assert.Equal(t, []int{-1}, platform.closed)
+	assert.Contains(t, logs.String(), "Closed tab active")
+	require.NoError(t, executor.runTabAction(platform, config.Action{Name: "back", Type: "switch_tab", Target: "Shop"}))
+
+	err := executor.runTabAction(platform, config.Action{Name: "again", Type: "wait_for_popup", Duration: 1})
+	assert.EqualError(t, err, "no popup or tab opened within 1s")
+	err = executor.runTabAction(platform, config.Action{Name: "bad", Type: "switch_tab"})
+	assert.ErrorContains(t, err, "switch_tab needs parameters.index")
+
+	err = executor.runTabAction(&MockPlatform{metrics: map[string]interface{}{}}, config.Action{Name: "tab", Type: "switch_tab", Target: "Shop"})
+	assert.EqualError(t, err, "switch_tab is not supported on this platform")
+}
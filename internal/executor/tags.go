@@ -0,0 +1,19 @@
+package executor
+
+import "panoptic/internal/config"
+
+// SetTagFilter limits the run to apps and actions selected by filter
+func (e *Executor) SetTagFilter(filter config.TagFilter) {
+	e.tagFilter = filter
+}
+
+// resultTags are the app's tags plus those of the actions selected to run, so
+// per-tag pass rates count an app under every tag it exercised
+func (e *Executor) resultTags(app config.AppConfig) []string {
+	actions, _ := e.config.SelectActions(app, e.tagFilter)
+	lists := [][]string{app.Tags}
+	for _, action := range actions {
+		lists = append(lists, action.Tags)
+	}
+	return config.MergeTags(lists...)
+}
@@ -0,0 +1,77 @@
+package executor
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"panoptic/internal/config"
+	"panoptic/internal/logger"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func taggedConfig(t *testing.T) *config.Config {
+	appPath := filepath.Join(t.TempDir(), "app")
+	require.NoError(t, os.WriteFile(appPath, []byte("#!/bin/sh\n"), 0755))
+	return &config.Config{
+		Apps: []config.AppConfig{
+			{Name: "Checkout", Type: "desktop", Path: appPath, Tags: []string{"checkout"}},
+			{Name: "Admin", Type: "desktop", Path: appPath},
+		},
+		Actions: []config.Action{
+			{Name: "hold", Type: "pause", Tags: []string{"smoke"}},
+			{Name: "soak", Type: "pause", Tags: []string{"slow"}},
+		},
+	}
+}
+
+// TestExecutor_TagFilter tests that --tags selects apps and actions
+func TestExecutor_TagFilter(t *testing.T) {
+	executor := NewExecutor(taggedConfig(t), t.TempDir(), logger.NewLogger(false))
+	filter, err := config.ParseTagFilter("smoke,!slow")
+	require.NoError(t, err)
+	executor.SetTagFilter(filter)
+	require.NoError(t, executor.Run())
+
+	require.Len(t, executor.results, 2)
+	checkout := executor.results[0]
+	assert.True(t, checkout.Success, checkout.Error)
+	assert.Equal(t, []string{"checkout", "smoke"}, checkout.Tags, "skipped actions don't tag the result")
+	assert.Equal(t, []string{"soak"}, checkout.Metrics["skipped_actions"])
+	assert.Equal(t, []string{"smoke"}, executor.results[1].Tags)
+
+	data, err := json.Marshal(&checkout)
+	require.NoError(t, err)
+	var decoded TestResult
+	require.NoError(t, json.Unmarshal(data, &decoded))
+	assert.Equal(t, checkout.Tags, decoded.Tags)
+}
+
+// TestExecutor_TagFilter_SkipsApps tests that apps with no selected action don't run
+func TestExecutor_TagFilter_SkipsApps(t *testing.T) {
+	executor := NewExecutor(taggedConfig(t), t.TempDir(), logger.NewLogger(false))
+	filter, _ := config.ParseTagFilter("checkout")
+	executor.SetTagFilter(filter)
+	require.NoError(t, executor.Run())
+
+	require.Len(t, executor.results, 1)
+	assert.Equal(t, "Checkout", executor.results[0].AppName)
+	assert.Equal(t, []string{"checkout", "smoke", "slow"}, executor.results[0].Tags)
+	assert.NotContains(t, executor.results[0].Metrics, "skipped_actions")
+}
+
+// TestExecutor_SingleAppConfig_Tags tests that agents receive only selected actions
+func TestExecutor_SingleAppConfig_Tags(t *testing.T) {
+	cfg := taggedConfig(t)
+	executor := NewExecutor(cfg, t.TempDir(), logger.NewLogger(false))
+	filter, _ := config.ParseTagFilter("!slow")
+	executor.SetTagFilter(filter)
+
+	single := executor.singleAppConfig(cfg.Apps[0])
+	require.Len(t, single.Actions, 1)
+	assert.Equal(t, "hold", single.Actions[0].Name)
+	assert.Equal(t, []string{"checkout"}, single.Apps[0].Tags)
+}
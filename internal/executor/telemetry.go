@@ -0,0 +1,60 @@
+package executor
+
+import (
+	"context"
+
+	"panoptic/internal/config"
+	"panoptic/internal/telemetry"
+)
+
+// EnableTelemetry exports spans for the run, each app, each action, platform
+// calls and cloud uploads. traceParent (a W3C traceparent, usually from the
+// TRACEPARENT environment variable) makes the run a child of a remote span,
+// e.g. the host run that launched this agent in a container.
+func (e *Executor) EnableTelemetry(tracer *telemetry.Tracer, traceParent string) {
+	e.tracer = tracer
+	e.rootSpanCtx = context.Background()
+	if traceParent == "" {
+		return
+	}
+	ctx, err := telemetry.ContextWithRemoteParent(e.rootSpanCtx, traceParent)
+	if err != nil {
+		e.logger.Warnf("Ignoring %s: %v", telemetry.TraceParentEnv, err)
+		return
+	}
+	e.rootSpanCtx = ctx
+}
+
+// spanContext returns the context of the innermost run or app span
+func (e *Executor) spanContext() context.Context {
+	if e.spanCtx != nil {
+		return e.spanCtx
+	}
+	if e.rootSpanCtx != nil {
+		return e.rootSpanCtx
+	}
+	return context.Background()
+}
+
+// traceParent returns the W3C traceparent of the current span, or "" when
+// telemetry is disabled
+func (e *Executor) traceParent() string {
+	if e.tracer == nil {
+		return ""
+	}
+	sc, ok := telemetry.SpanContextFromContext(e.spanContext())
+	if !ok || !sc.IsValid() {
+		return ""
+	}
+	return sc.TraceParent()
+}
+
+// platformCall runs a platform method inside a span
+func (e *Executor) platformCall(ctx context.Context, app config.AppConfig, method string, fn func() error) error {
+	_, span := e.tracer.Start(ctx, "platform."+method,
+		telemetry.String("panoptic.platform", app.Type),
+		telemetry.String("panoptic.app.name", app.Name))
+	err := fn()
+	span.End(err)
+	return err
+}
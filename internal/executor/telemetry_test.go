@@ -0,0 +1,130 @@
+package executor
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"panoptic/internal/config"
+	"panoptic/internal/logger"
+	"panoptic/internal/telemetry"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type exportedSpan struct {
+	TraceID      string `json:"traceId"`
+	SpanID       string `json:"spanId"`
+	ParentSpanID string `json:"parentSpanId"`
+	Name         string `json:"name"`
+	Status       struct {
+		Code int `json:"code"`
+	} `json:"status"`
+}
+
+// spanCollector is a minimal OTLP/HTTP receiver
+func spanCollector(t *testing.T) (*httptest.Server, func() map[string]exportedSpan) {
+	var mu sync.Mutex
+	spans := make(map[string]exportedSpan)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		var req struct {
+			ResourceSpans []struct {
+				ScopeSpans []struct {
+					Spans []exportedSpan `json:"spans"`
+				} `json:"scopeSpans"`
+			} `json:"resourceSpans"`
+		}
+		require.NoError(t, json.Unmarshal(body, &req))
+		mu.Lock()
+		defer mu.Unlock()
+		for _, rs := range req.ResourceSpans {
+			for _, ss := range rs.ScopeSpans {
+				for _, span := range ss.Spans {
+					spans[span.Name] = span
+				}
+			}
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server, func() map[string]exportedSpan {
+		mu.Lock()
+		defer mu.Unlock()
+		return spans
+	}
+}
+
+// TestExecutor_Run_Telemetry tests the span tree exported for a run
+func TestExecutor_Run_Telemetry(t *testing.T) {
+	server, spans := spanCollector(t)
+	tracer, err := telemetry.NewTracer(telemetry.Config{Endpoint: server.URL})
+	require.NoError(t, err)
+
+	cfg := &config.Config{
+		Apps:    []config.AppConfig{{Name: "Kiosk", Type: "driver", Driver: scriptDriver(t, "")}},
+		Actions: []config.Action{{Name: "hold", Type: "pause"}, {Name: "go", Type: "navigate", Value: "app://home"}},
+	}
+	executor := NewExecutor(cfg, t.TempDir(), logger.NewLogger(false))
+	remoteParent := "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+	executor.EnableTelemetry(tracer, remoteParent)
+
+	require.NoError(t, executor.Run())
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	require.NoError(t, tracer.Shutdown(ctx))
+
+	got := spans()
+	run, app, initialize := got["panoptic.run"], got["panoptic.app"], got["platform.Initialize"]
+	navigate, closeSpan := got["platform.Navigate"], got["platform.Close"]
+	require.NotEmpty(t, run.SpanID)
+
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", run.TraceID, "the run joins the remote trace")
+	assert.Equal(t, "00f067aa0ba902b7", run.ParentSpanID)
+	assert.Equal(t, run.SpanID, app.ParentSpanID)
+	assert.Equal(t, app.SpanID, initialize.ParentSpanID)
+	assert.Equal(t, app.SpanID, closeSpan.ParentSpanID)
+	assert.Equal(t, got["panoptic.action"].TraceID, run.TraceID)
+	assert.NotEqual(t, app.SpanID, navigate.ParentSpanID, "platform calls are children of their action")
+
+	// The driver fails navigation, so the app fails and its span says so
+	assert.Equal(t, telemetry.StatusError, navigate.Status.Code)
+	assert.Equal(t, telemetry.StatusError, app.Status.Code)
+	assert.Nil(t, executor.spanCtx, "Run clears the span context")
+}
+
+// TestExecutor_TelemetryDisabled tests that no trace context is propagated without a tracer
+func TestExecutor_TelemetryDisabled(t *testing.T) {
+	executor := NewExecutor(&config.Config{}, t.TempDir(), logger.NewLogger(false))
+	assert.Empty(t, executor.traceParent())
+
+	calls := 0
+	err := executor.platformCall(executor.spanContext(), config.AppConfig{}, "Navigate", func() error { calls++; return nil })
+	assert.NoError(t, err)
+	assert.Equal(t, 1, calls)
+}
+
+// TestExecutor_ContainerRunArgs_TraceParent tests handing trace context to containerized agents
+func TestExecutor_ContainerRunArgs_TraceParent(t *testing.T) {
+	server, _ := spanCollector(t)
+	tracer, err := telemetry.NewTracer(telemetry.Config{Endpoint: server.URL})
+	require.NoError(t, err)
+	defer tracer.Shutdown(context.Background())
+
+	executor := NewExecutor(containerTestConfig(), t.TempDir(), logger.NewLogger(false))
+	executor.EnableContainerMode(ContainerOptions{})
+	executor.EnableTelemetry(tracer, "not-a-traceparent")
+
+	ctx, span := tracer.Start(executor.spanContext(), "panoptic.app")
+	executor.spanCtx = ctx
+	args := strings.Join(executor.containerRunArgs("/host/out"), " ")
+	span.End(nil)
+
+	assert.Contains(t, args, "-e TRACEPARENT="+span.SpanContext().TraceParent())
+}
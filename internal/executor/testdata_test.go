@@ -0,0 +1,86 @@
+package executor
+
+import (
+	"strings"
+	"testing"
+
+	"panoptic/internal/config"
+	"panoptic/internal/logger"
+	"panoptic/internal/testdata"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestExecutor_FakeDataInterpolation tests {{fake.*}} placeholders in actions
+func TestExecutor_FakeDataInterpolation(t *testing.T) {
+	seed := int64(1234)
+	cfg := &config.Config{Settings: config.Settings{FakeSeed: &seed}}
+	executor := NewExecutor(cfg, t.TempDir(), logger.NewLogger(false))
+	assert.Equal(t, seed, executor.FakeSeed())
+
+	platform := &MockPlatform{metrics: map[string]interface{}{}}
+	result := &TestResult{}
+	actions := []config.Action{
+		{Name: "email", Type: "fill", Selector: "#email", Value: "{{fake.email:signup}}"},
+		{Name: "name", Type: "fill", Selector: "#name", Value: "{{fake.first_name}} {{fake.last_name}}"},
+		{Name: "confirm", Type: "fill", Selector: "#confirm", Value: "{{fake.email:signup}}"},
+		{Name: "order", Type: "navigate", URL: "https://shop.test/orders/{{fake.uuid}}"},
+	}
+	for _, action := range actions {
+		require.NoError(t, executor.executeAction(platform, action, config.AppConfig{Name: "Shop"}, result, new(string)))
+	}
+
+	executed := platform.executedActions
+	require.Len(t, executed, 4)
+	assert.Equal(t, executed[0].Value, executed[2].Value, "labeled values repeat")
+	assert.Contains(t, executed[0].Value, "@example.")
+	assert.NotContains(t, executed[1].Value, "{{")
+	assert.Regexp(t, `^https://shop\.test/orders/[0-9a-f-]{36}$`, executed[3].Value)
+
+	// The same seed reproduces the same data
+	replay := NewExecutor(&config.Config{}, t.TempDir(), logger.NewLogger(false))
+	replay.SetFakeSeed(seed)
+	replayPlatform := &MockPlatform{metrics: map[string]interface{}{}}
+	for _, action := range actions {
+		require.NoError(t, replay.executeAction(replayPlatform, action, config.AppConfig{Name: "Shop"}, result, new(string)))
+	}
+	assert.Equal(t, executed, replayPlatform.executedActions)
+	assert.Equal(t, "{{fake.email:signup}}", actions[0].Value, "the configured action is not modified")
+}
+
+// TestExecutor_InterpolateAction tests parameters and unknown placeholders
+func TestExecutor_InterpolateAction(t *testing.T) {
+	executor := NewExecutor(&config.Config{}, t.TempDir(), logger.NewLogger(false))
+
+	action, err := executor.interpolateAction(config.Action{
+		Name:       "upload",
+		Parameters: map[string]interface{}{"filename": "{{fake.word}}.png", "retries": 3},
+	})
+	require.NoError(t, err)
+	assert.True(t, strings.HasSuffix(action.Parameters["filename"].(string), ".png"))
+	assert.NotContains(t, action.Parameters["filename"], "{{")
+	assert.Equal(t, 3, action.Parameters["retries"])
+
+	_, err = executor.interpolateAction(config.Action{Name: "bad", Value: "{{fake.ssn}}"})
+	assert.ErrorContains(t, err, "action 'bad': unknown fake value")
+
+	platform := &MockPlatform{metrics: map[string]interface{}{}}
+	err = executor.executeAction(platform, config.Action{Name: "bad", Type: "fill", Selector: "#x", Value: "{{fake.ssn}}"}, config.AppConfig{}, &TestResult{}, new(string))
+	assert.Error(t, err)
+	assert.Empty(t, platform.executedActions, "the action does not run with an unresolved placeholder")
+}
+
+// TestExecutor_FakeDataPerApp tests that every app gets its own sequence
+func TestExecutor_FakeDataPerApp(t *testing.T) {
+	executor := NewExecutor(&config.Config{}, t.TempDir(), logger.NewLogger(false))
+	executor.SetFakeSeed(99)
+
+	shop, _ := testdata.New(testdata.DeriveSeed(99, "Shop")).Generate("email")
+	admin, _ := testdata.New(testdata.DeriveSeed(99, "Admin")).Generate("email")
+	assert.NotEqual(t, shop, admin)
+
+	runFake := executor.fake
+	executor.executeApp(config.AppConfig{Name: "Shop", Type: "unknown"})
+	assert.Same(t, runFake, executor.fake, "the run-level faker is restored after each app")
+}
@@ -0,0 +1,171 @@
+package executor
+
+import (
+	"encoding/base64"
+	"fmt"
+	"html"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"panoptic/internal/config"
+
+	"gopkg.in/yaml.v3"
+)
+
+// DefaultReportTitle is the title of unbranded reports
+const DefaultReportTitle = "Panoptic Test Report"
+
+// maxLogoSize bounds the logo files embedded in reports
+const maxLogoSize = 1 << 20
+
+// ReportTheme is the branding of a report as settings.report resolves it.
+// results.json keeps it, so reports regenerated from it look the same.
+type ReportTheme struct {
+	Title           string `json:"title,omitempty"`
+	Organization    string `json:"organization,omitempty"`
+	Logo            string `json:"logo,omitempty"` // URL, or data URI of the logo file
+	PrimaryColor    string `json:"primary_color,omitempty"`
+	BackgroundColor string `json:"background_color,omitempty"`
+	SurfaceColor    string `json:"surface_color,omitempty"`
+	TextColor       string `json:"text_color,omitempty"`
+	Footer          string `json:"footer,omitempty"`
+}
+
+// title is the report title
+func (t *ReportTheme) title() string {
+	if t == nil || t.Title == "" {
+		return DefaultReportTitle
+	}
+	return t.Title
+}
+
+// style overrides the colors of the default theme
+func (t *ReportTheme) style() string {
+	if t == nil {
+		return ""
+	}
+	var vars []string
+	for _, v := range []struct{ name, value string }{
+		{"--primary", t.PrimaryColor}, {"--background", t.BackgroundColor},
+		{"--surface", t.SurfaceColor}, {"--text", t.TextColor},
+	} {
+		if v.value != "" {
+			vars = append(vars, v.name+":"+v.value)
+		}
+	}
+	if len(vars) == 0 {
+		return ""
+	}
+	return "<style>:root{" + strings.Join(vars, ";") + "}</style>\n"
+}
+
+// brand is the logo and organization name shown above the title
+func (t *ReportTheme) brand() string {
+	if t == nil || (t.Logo == "" && t.Organization == "") {
+		return ""
+	}
+	var b strings.Builder
+	b.WriteString(`<div class="brand">`)
+	if t.Logo != "" {
+		alt := t.Organization
+		if alt == "" {
+			alt = "Logo"
+		}
+		b.WriteString(fmt.Sprintf(`<img class="logo" src="%s" alt="%s">`, html.EscapeString(t.Logo), html.EscapeString(alt)))
+	}
+	if t.Organization != "" {
+		b.WriteString(`<span class="organization">` + html.EscapeString(t.Organization) + `</span>`)
+	}
+	b.WriteString("</div>\n")
+	return b.String()
+}
+
+// footer is the footer line of the report
+func (t *ReportTheme) footer() string {
+	if t == nil || t.Footer == "" {
+		return "Panoptic Automated Testing Framework &mdash; Report generated automatically"
+	}
+	return html.EscapeString(t.Footer)
+}
+
+// reportTheme resolves settings.report, embedding a logo file; nil when the
+// run has no branding
+func (e *Executor) reportTheme() *ReportTheme {
+	settings := e.config.Settings.Report
+	organization := e.reportOrganization()
+	if settings == nil && organization == "" {
+		return nil
+	}
+	theme := &ReportTheme{Organization: organization}
+	if settings == nil {
+		return theme
+	}
+	theme.Title, theme.Footer = settings.Title, settings.Footer
+	theme.PrimaryColor, theme.BackgroundColor = settings.PrimaryColor, settings.BackgroundColor
+	theme.SurfaceColor, theme.TextColor = settings.SurfaceColor, settings.TextColor
+	switch {
+	case settings.Logo == "":
+	case settings.LogoIsURL():
+		theme.Logo = settings.Logo
+	default:
+		logo, err := logoDataURI(settings.Logo)
+		if err != nil {
+			e.logger.Warnf("Leaving the logo out of the report: %v", err)
+		}
+		theme.Logo = logo
+	}
+	return theme
+}
+
+// reportOrganization is the organization reports are branded for
+func (e *Executor) reportOrganization() string {
+	if settings := e.config.Settings.Report; settings != nil && settings.Organization != "" {
+		return settings.Organization
+	}
+	return enterpriseOrganization(e.config.Settings.Enterprise)
+}
+
+// logoDataURI reads a logo file as a data URI, so the report shows it
+// wherever it's opened
+func logoDataURI(path string) (string, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return "", err
+	}
+	if info.Size() > maxLogoSize {
+		return "", fmt.Errorf("logo %s is larger than 1MB", path)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	mime := config.LogoTypes[strings.ToLower(filepath.Ext(path))]
+	return "data:" + mime + ";base64," + base64.StdEncoding.EncodeToString(data), nil
+}
+
+// enterpriseOrganization is the organization_name of settings.enterprise,
+// inline or in the file its config_path names
+func enterpriseOrganization(settings map[string]interface{}) string {
+	if settings == nil {
+		return ""
+	}
+	if name := getStringFromMap(settings, "organization_name"); name != "" {
+		return name
+	}
+	path := getStringFromMap(settings, "config_path")
+	if path == "" {
+		return ""
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return ""
+	}
+	var file struct {
+		OrganizationName string `yaml:"organization_name"`
+	}
+	if yaml.Unmarshal(data, &file) != nil {
+		return ""
+	}
+	return file.OrganizationName
+}
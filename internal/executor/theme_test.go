@@ -0,0 +1,91 @@
+package executor
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"panoptic/internal/config"
+	"panoptic/internal/logger"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExecutor_ReportTheme(t *testing.T) {
+	dir := t.TempDir()
+	logo := filepath.Join(dir, "logo.png")
+	require.NoError(t, os.WriteFile(logo, []byte("png"), 0644))
+
+	cfg := &config.Config{Settings: config.Settings{
+		Report:     &config.ReportSettings{Title: "Acme QA", Logo: logo, PrimaryColor: "#1a73e8", Footer: "Internal use only"},
+		Enterprise: map[string]interface{}{"organization_name": "Acme Corp"},
+	}}
+	executor := NewExecutor(cfg, dir, logger.NewLogger(false))
+	theme := executor.reportTheme()
+	assert.Equal(t, &ReportTheme{
+		Title: "Acme QA", Organization: "Acme Corp", Logo: "data:image/png;base64,cG5n",
+		PrimaryColor: "#1a73e8", Footer: "Internal use only",
+	}, theme)
+	run := executor.notifyRun(true, "")
+	assert.Equal(t, "Acme Corp", run.Organization)
+	assert.Equal(t, "Internal use only", run.Footer)
+
+	cfg.Settings.Report = &config.ReportSettings{Organization: "Acme QA Team", Logo: "https://cdn.example.com/logo.svg"}
+	theme = NewExecutor(cfg, dir, logger.NewLogger(false)).reportTheme()
+	assert.Equal(t, "Acme QA Team", theme.Organization, "settings.report overrides the enterprise organization")
+	assert.Equal(t, "https://cdn.example.com/logo.svg", theme.Logo)
+
+	cfg.Settings.Report.Logo = filepath.Join(dir, "missing.png")
+	theme = NewExecutor(cfg, dir, logger.NewLogger(false)).reportTheme()
+	assert.Empty(t, theme.Logo, "a missing logo file is left out")
+
+	assert.Nil(t, NewExecutor(&config.Config{}, dir, logger.NewLogger(false)).reportTheme())
+}
+
+func TestEnterpriseOrganization(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "enterprise.yaml")
+	require.NoError(t, os.WriteFile(path, []byte("organization_name: Acme Corp\n"), 0644))
+
+	assert.Equal(t, "Acme Corp", enterpriseOrganization(map[string]interface{}{"config_path": path}))
+	assert.Equal(t, "Inline", enterpriseOrganization(map[string]interface{}{"organization_name": "Inline", "config_path": path}))
+	assert.Empty(t, enterpriseOrganization(map[string]interface{}{"config_path": filepath.Join(t.TempDir(), "missing.yaml")}))
+	assert.Empty(t, enterpriseOrganization(nil))
+}
+
+func TestGenerateThemedReport(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "report.html")
+	theme := &ReportTheme{
+		Title: "Acme <QA>", Organization: "Acme & Co", Logo: "data:image/png;base64,cG5n",
+		PrimaryColor: "#1a73e8", TextColor: "#222", Footer: "Confidential <internal>",
+	}
+	require.NoError(t, GenerateThemedReport(path, []TestResult{{AppName: "Shop", Success: true}}, theme))
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	html := string(data)
+	assert.Contains(t, html, "<title>Acme &lt;QA&gt;</title>")
+	assert.Contains(t, html, "<style>:root{--primary:#1a73e8;--text:#222}</style>")
+	assert.Contains(t, html, `<img class="logo" src="data:image/png;base64,cG5n" alt="Acme &amp; Co">`)
+	assert.Contains(t, html, `<span class="organization">Acme &amp; Co</span>`)
+	assert.Contains(t, html, "Confidential &lt;internal&gt;")
+	assert.NotContains(t, html, "Report generated automatically")
+
+	require.NoError(t, GenerateThemedReport(path, nil, nil))
+	data, err = os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), "<title>"+DefaultReportTitle+"</title>")
+	assert.NotContains(t, string(data), `class="brand"`)
+}
+
+func TestResultsDocument_Theme(t *testing.T) {
+	cfg := &config.Config{Name: "shop", Settings: config.Settings{Report: &config.ReportSettings{Title: "Acme QA"}}}
+	executor := NewExecutor(cfg, t.TempDir(), logger.NewLogger(false))
+	path := filepath.Join(t.TempDir(), "results.json")
+	require.NoError(t, executor.SaveResults(path))
+
+	doc, err := LoadResults(path)
+	require.NoError(t, err)
+	assert.Equal(t, &ReportTheme{Title: "Acme QA"}, doc.Theme)
+	assert.Equal(t, doc.Theme, MergeResults(doc, doc).Theme)
+}
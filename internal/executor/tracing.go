@@ -0,0 +1,83 @@
+package executor
+
+import (
+	"path/filepath"
+	"strings"
+
+	"panoptic/internal/config"
+	"panoptic/internal/platforms"
+	"panoptic/internal/trace"
+)
+
+// screenshotCapturer is implemented by platforms that can capture in-memory screenshots
+type screenshotCapturer interface {
+	CaptureScreenshot() ([]byte, error)
+}
+
+// networkObserver is implemented by platforms that can report network activity
+type networkObserver interface {
+	ObserveNetwork(fn func(trace.NetworkEvent)) (func(), error)
+}
+
+// EnableTracing records a trace archive per app with action timing, network
+// activity and before/after screenshots (view with `panoptic trace show`)
+func (e *Executor) EnableTracing() {
+	e.tracing = true
+}
+
+// startTrace creates a recorder for app and subscribes it to the platform's
+// network events. The returned stop function is never nil.
+func (e *Executor) startTrace(platform platforms.Platform, app config.AppConfig) (*trace.Recorder, func()) {
+	recorder := trace.NewRecorder(app.Name, app.Type)
+	recorder.SetRedactor(e.redactor)
+	observer, ok := platform.(networkObserver)
+	if !ok {
+		return recorder, func() {}
+	}
+	stop, err := observer.ObserveNetwork(recorder.RecordNetwork)
+	if err != nil {
+		e.logger.Warnf("Network tracing unavailable for %s: %v", app.Name, err)
+		return recorder, func() {}
+	}
+	return recorder, stop
+}
+
+// finishTrace saves the archive. executeApp defers it, so it runs after the
+// returned result is final; the trace metric lands in the result's metrics
+// map, which the returned copy shares.
+func (e *Executor) finishTrace(recorder *trace.Recorder, stopNetwork func(), app config.AppConfig, result *TestResult) {
+	stopNetwork()
+	recorder.Finish(result.Success, result.Error)
+
+	path := filepath.Join(e.outputDir, "traces", traceFileName(app.Name))
+	if err := recorder.Save(path); err != nil {
+		e.logger.Errorf("Failed to save trace for %s: %v", app.Name, err)
+		return
+	}
+	if result.Metrics != nil {
+		result.Metrics["trace"] = path
+	}
+	e.logger.Infof("Trace saved: %s", path)
+}
+
+// traceFileName returns a filesystem-safe archive name for an app
+func traceFileName(appName string) string {
+	name := strings.Trim(unsafeDirChars.ReplaceAllString(appName, "_"), "_")
+	if name == "" {
+		name = "app"
+	}
+	return name + ".zip"
+}
+
+// traceScreenshot captures a screenshot for the trace, or nil when unsupported
+func traceScreenshot(platform platforms.Platform) []byte {
+	capturer, ok := platform.(screenshotCapturer)
+	if !ok {
+		return nil
+	}
+	data, err := capturer.CaptureScreenshot()
+	if err != nil {
+		return nil
+	}
+	return data
+}
@@ -0,0 +1,86 @@
+package executor
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"panoptic/internal/config"
+	"panoptic/internal/logger"
+	"panoptic/internal/trace"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// tracingPlatform is a MockPlatform that supports screenshots and network observation
+type tracingPlatform struct {
+	*MockPlatform
+	observe  func(trace.NetworkEvent)
+	stopped  bool
+	shotErr  error
+	shotData []byte
+}
+
+func (p *tracingPlatform) CaptureScreenshot() ([]byte, error) {
+	return p.shotData, p.shotErr
+}
+
+func (p *tracingPlatform) ObserveNetwork(fn func(trace.NetworkEvent)) (func(), error) {
+	p.observe = fn
+	return func() { p.stopped = true }, nil
+}
+
+// TestExecutor_TraceLifecycle tests recording and saving a trace archive for an app
+func TestExecutor_TraceLifecycle(t *testing.T) {
+	executor := NewExecutor(&config.Config{}, t.TempDir(), logger.NewLogger(false))
+	executor.EnableTracing()
+	platform := &tracingPlatform{MockPlatform: &MockPlatform{metrics: map[string]interface{}{}}, shotData: []byte("png")}
+	app := config.AppConfig{Name: "My Shop/EU", Type: "web"}
+
+	recorder, stop := executor.startTrace(platform, app)
+	require.NotNil(t, platform.observe)
+
+	recorder.BeginAction(0, "open", "navigate", nil, traceScreenshot(platform))
+	platform.observe(trace.NetworkEvent{Method: "GET", URL: "https://example.com", Status: 200})
+	recorder.EndAction(nil, traceScreenshot(platform))
+
+	result := &TestResult{Success: true, Metrics: map[string]interface{}{}}
+	executor.finishTrace(recorder, stop, app, result)
+
+	assert.True(t, platform.stopped)
+	path, ok := result.Metrics["trace"].(string)
+	require.True(t, ok)
+	assert.Equal(t, filepath.Join(executor.outputDir, "traces", "My_Shop_EU.zip"), path)
+
+	archive, err := trace.Load(path)
+	require.NoError(t, err)
+	assert.True(t, archive.Trace.Success)
+	require.Len(t, archive.Trace.Actions, 1)
+	assert.Equal(t, []int{0}, archive.Trace.Actions[0].NetworkRefs)
+	assert.Len(t, archive.Files, 2)
+}
+
+// TestTraceScreenshot tests that unsupported or failing screenshots are omitted
+func TestTraceScreenshot(t *testing.T) {
+	mock := &MockPlatform{metrics: map[string]interface{}{}}
+	assert.Nil(t, traceScreenshot(mock))
+
+	failing := &tracingPlatform{MockPlatform: mock, shotErr: fmt.Errorf("no page")}
+	assert.Nil(t, traceScreenshot(failing))
+}
+
+// TestExecutor_StartTraceWithoutNetwork tests tracing platforms without network observation
+func TestExecutor_StartTraceWithoutNetwork(t *testing.T) {
+	executor := NewExecutor(&config.Config{}, t.TempDir(), logger.NewLogger(false))
+	recorder, stop := executor.startTrace(&MockPlatform{metrics: map[string]interface{}{}}, config.AppConfig{Name: "desk", Type: "desktop"})
+
+	require.NotNil(t, recorder)
+	require.NotNil(t, stop)
+	stop()
+
+	executor.finishTrace(recorder, stop, config.AppConfig{Name: "desk"}, &TestResult{Error: "boom"})
+	_, err := os.Stat(filepath.Join(executor.outputDir, "traces", "desk.zip"))
+	assert.NoError(t, err)
+}
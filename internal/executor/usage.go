@@ -0,0 +1,59 @@
+package executor
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// usageRun is the enterprise user and project a run is metered for
+type usageRun struct {
+	UserID    string
+	ProjectID string
+}
+
+// startUsage checks the user and project of an enterprise run have test
+// runs and storage left in their subscriptions this month
+func (e *Executor) startUsage(ctx context.Context) error {
+	user := getStringFromMap(e.config.Settings.Enterprise, "user")
+	if user == "" && e.project == nil {
+		return nil
+	}
+	integration := e.getEnterpriseIntegration()
+	if integration == nil || !integration.Initialized {
+		return nil
+	}
+	usage := &usageRun{ProjectID: e.ProjectID()}
+	if user != "" {
+		found, err := integration.UserManagement.GetUser(ctx, user)
+		if err != nil {
+			return fmt.Errorf("settings.enterprise.user: %w", err)
+		}
+		usage.UserID = found.ID
+	}
+	if err := integration.UsageManagement.CheckRun(ctx, usage.UserID, usage.ProjectID); err != nil {
+		return fmt.Errorf("run refused: %w", err)
+	}
+	e.usage = usage
+	return nil
+}
+
+// recordUsage meters the finished run, its minutes on each node it ran on,
+// the local one when it ran on none, and the artifacts it wrote
+func (e *Executor) recordUsage() {
+	files, err := listArtifacts(e.outputDir)
+	if err != nil {
+		e.logger.Warnf("Failed to meter the run's storage: %v", err)
+	}
+	var written int64
+	for _, f := range files {
+		if !f.modified.Before(e.startedAt.Truncate(time.Second)) {
+			written += f.size
+		}
+	}
+	nodeMinutes := time.Since(e.startedAt).Minutes() * float64(max(len(e.nodes), 1))
+	integration := e.getEnterpriseIntegration()
+	if err := integration.UsageManagement.RecordRun(context.Background(), e.usage.UserID, e.usage.ProjectID, written, nodeMinutes); err != nil {
+		e.logger.Warnf("Failed to meter the run: %v", err)
+	}
+}
@@ -0,0 +1,63 @@
+package executor
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"panoptic/internal/config"
+	"panoptic/internal/enterprise"
+	"panoptic/internal/logger"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExecutor_MeterUsage(t *testing.T) {
+	dir := t.TempDir()
+	storage := filepath.Join(dir, "data")
+	require.NoError(t, os.MkdirAll(storage, 0755))
+	for name, data := range map[string]interface{}{
+		"users.json": map[string]*enterprise.User{"ana": {ID: "u-ana", Username: "ana", Active: true}},
+		"subscriptions.json": map[string]*enterprise.Subscription{"s-ana": {ID: "s-ana", UserID: "u-ana", Plan: "trial", Status: "active",
+			StartDate: time.Now().AddDate(0, 0, -1), Limits: enterprise.SubscriptionLimits{MaxTestRuns: 1}}},
+	} {
+		encoded, err := json.Marshal(data)
+		require.NoError(t, err)
+		require.NoError(t, os.WriteFile(filepath.Join(storage, name), encoded, 0644))
+	}
+	path := filepath.Join(dir, "enterprise.yaml")
+	require.NoError(t, os.WriteFile(path, []byte("enabled: true\nstorage_path: "+storage+"\n"), 0644))
+	cfg := &config.Config{Settings: config.Settings{Enterprise: map[string]interface{}{"config_path": path, "user": "ana"}}}
+	ctx := context.Background()
+
+	output := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(output, "screenshots"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(output, "screenshots", "old.png"), make([]byte, 10), 0644))
+	old := time.Now().Add(-time.Hour)
+	require.NoError(t, os.Chtimes(filepath.Join(output, "screenshots", "old.png"), old, old))
+
+	executor := NewExecutor(cfg, output, logger.NewLogger(false))
+	executor.startedAt = time.Now()
+	require.NoError(t, executor.startUsage(ctx))
+	require.NoError(t, os.WriteFile(filepath.Join(output, "screenshots", "home.png"), make([]byte, 64), 0644))
+	executor.recordUsage()
+
+	report, err := executor.getEnterpriseIntegration().UsageManagement.Report(ctx, enterprise.UsageReportRequest{User: "ana"})
+	require.NoError(t, err)
+	assert.Equal(t, 1, report.Entries[0].TestRuns)
+	assert.Equal(t, int64(64), report.Entries[0].StorageBytes, "only the artifacts of the run are metered")
+	assert.Greater(t, report.Entries[0].NodeMinutes, 0.0)
+
+	err = NewExecutor(cfg, t.TempDir(), logger.NewLogger(false)).startUsage(ctx)
+	assert.ErrorContains(t, err, "run refused: user ana has used the 1 test run(s) of their trial plan")
+
+	cfg.Settings.Enterprise["user"] = "eve"
+	err = NewExecutor(cfg, t.TempDir(), logger.NewLogger(false)).startUsage(ctx)
+	assert.EqualError(t, err, "settings.enterprise.user: user not found: eve")
+
+	assert.NoError(t, NewExecutor(&config.Config{}, t.TempDir(), logger.NewLogger(false)).startUsage(ctx))
+}
@@ -0,0 +1,61 @@
+package executor
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// variablePattern matches {{var.<name>}}
+var variablePattern = regexp.MustCompile(`\{\{\s*var\.([A-Za-z0-9_.-]+)\s*\}\}`)
+
+// interpolateVars replaces {{var.<name>}} with the running app's variables,
+// set by earlier actions such as wait_for_email and vision_decode_qr
+func (e *Executor) interpolateVars(s string) (string, error) {
+	if !strings.Contains(s, "{{") {
+		return s, nil
+	}
+	var firstErr error
+	out := variablePattern.ReplaceAllStringFunc(s, func(match string) string {
+		name := variablePattern.FindStringSubmatch(match)[1]
+		value, ok := e.vars[name]
+		if !ok {
+			if firstErr == nil {
+				firstErr = fmt.Errorf("variable %s is not set; an earlier action must extract it", name)
+			}
+			return match
+		}
+		return value
+	})
+	return out, firstErr
+}
+
+// interpolateVarsValue interpolates strings inside YAML-decoded values
+func (e *Executor) interpolateVarsValue(v interface{}) (interface{}, error) {
+	switch value := v.(type) {
+	case string:
+		return e.interpolateVars(value)
+	case map[string]interface{}:
+		out := make(map[string]interface{}, len(value))
+		for k, item := range value {
+			interpolated, err := e.interpolateVarsValue(item)
+			if err != nil {
+				return nil, err
+			}
+			out[k] = interpolated
+		}
+		return out, nil
+	case []interface{}:
+		out := make([]interface{}, len(value))
+		for i, item := range value {
+			interpolated, err := e.interpolateVarsValue(item)
+			if err != nil {
+				return nil, err
+			}
+			out[i] = interpolated
+		}
+		return out, nil
+	default:
+		return v, nil
+	}
+}
@@ -0,0 +1,60 @@
+package executor
+
+import (
+	"panoptic/internal/config"
+	"panoptic/internal/vision"
+)
+
+// getVisionModel loads the ONNX model of settings.vision once per executor.
+// It's nil when no model is configured or when the model or its runtime is
+// missing, in which case element detection keeps its heuristics.
+func (e *Executor) getVisionModel() *vision.Model {
+	e.visionModelOnce.Do(func() {
+		settings := e.config.Settings.Vision
+		if settings == nil || settings.ModelPath == "" {
+			return
+		}
+		model, err := vision.LoadModel(VisionModelConfig(settings))
+		if err != nil {
+			e.logger.Warnf("Using heuristic element detection: %v", err)
+			return
+		}
+		e.logger.Infof("Using ONNX model %s for element detection", settings.ModelPath)
+		e.visionModel = model
+	})
+	return e.visionModel
+}
+
+// VisionModelConfig is the model of settings.vision. Boxes scoring below
+// every threshold are discarded as the model output is read.
+func VisionModelConfig(settings *config.VisionSettings) vision.ModelConfig {
+	return vision.ModelConfig{
+		Path:          settings.ModelPath,
+		Runtime:       settings.Runtime,
+		InputSize:     settings.InputSize,
+		Labels:        settings.Labels,
+		MinConfidence: VisionThresholds(settings).Lowest(),
+	}
+}
+
+// VisionThresholds are the confidence thresholds and non-max suppression of
+// settings.vision; nil settings keep every detection
+func VisionThresholds(settings *config.VisionSettings) vision.Thresholds {
+	if settings == nil {
+		return vision.Thresholds{}
+	}
+	thresholds := vision.Thresholds{MinConfidence: settings.MinConfidence, ByType: settings.Thresholds}
+	if settings.NMS != nil {
+		thresholds.NMS = &vision.NMS{IoU: settings.NMS.IoU, AcrossTypes: settings.NMS.AcrossTypes}
+	}
+	return thresholds
+}
+
+// newElementDetector creates a detector with the model and thresholds of
+// settings.vision
+func (e *Executor) newElementDetector() *vision.ElementDetector {
+	detector := vision.NewElementDetector(*e.componentLogger())
+	detector.SetModel(e.getVisionModel())
+	detector.SetThresholds(VisionThresholds(e.config.Settings.Vision))
+	return detector
+}
@@ -0,0 +1,57 @@
+package executor
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"panoptic/internal/config"
+	"panoptic/internal/logger"
+	"panoptic/internal/vision"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestExecutor_VisionModel tests that settings.vision loads its model once,
+// and that a missing model leaves the heuristics in place
+func TestExecutor_VisionModel(t *testing.T) {
+	executor := NewExecutor(&config.Config{}, t.TempDir(), logger.NewLogger(false))
+	assert.Nil(t, executor.getVisionModel(), "no model configured")
+
+	missing := &config.Config{Settings: config.Settings{Vision: &config.VisionSettings{ModelPath: filepath.Join(t.TempDir(), "ui.onnx")}}}
+	executor = NewExecutor(missing, t.TempDir(), logger.NewLogger(false))
+	assert.Nil(t, executor.getVisionModel())
+	assert.NotNil(t, executor.getTestGen(), "test generation still works")
+
+	dir := t.TempDir()
+	model := filepath.Join(dir, "ui.onnx")
+	require.NoError(t, os.WriteFile(model, []byte("onnx"), 0644))
+	runtime := filepath.Join(dir, "python")
+	require.NoError(t, os.WriteFile(runtime, []byte("#!/bin/sh\nexit 0\n"), 0755))
+	cfg := &config.Config{Settings: config.Settings{Vision: &config.VisionSettings{ModelPath: model, Runtime: runtime}}}
+	executor = NewExecutor(cfg, t.TempDir(), logger.NewLogger(false))
+	loaded := executor.getVisionModel()
+	require.NotNil(t, loaded)
+	require.NoError(t, os.Remove(model))
+	assert.Same(t, loaded, executor.getVisionModel(), "loaded once")
+}
+
+// TestVisionThresholds tests that settings.vision thresholds reach the
+// detector, and that the model reads boxes down to the lowest of them
+func TestVisionThresholds(t *testing.T) {
+	assert.Equal(t, vision.Thresholds{}, VisionThresholds(nil))
+
+	settings := &config.VisionSettings{
+		ModelPath:     "ui.onnx",
+		MinConfidence: 0.4,
+		Thresholds:    map[string]float64{"link": 0.3},
+		NMS:           &config.NMSSettings{IoU: 0.5, AcrossTypes: true},
+	}
+	assert.Equal(t, vision.Thresholds{
+		MinConfidence: 0.4,
+		ByType:        map[string]float64{"link": 0.3},
+		NMS:           &vision.NMS{IoU: 0.5, AcrossTypes: true},
+	}, VisionThresholds(settings))
+	assert.Equal(t, vision.ModelConfig{Path: "ui.onnx", MinConfidence: 0.3}, VisionModelConfig(settings))
+}
@@ -0,0 +1,147 @@
+package executor
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"panoptic/internal/config"
+	"panoptic/internal/jsonpath"
+	"panoptic/internal/platforms"
+)
+
+// webSocketReader is implemented by platforms that capture the WebSocket
+// messages their pages send and receive
+type webSocketReader interface {
+	TakeWebSocketFrames() ([]platforms.WebSocketFrame, error)
+}
+
+// WebSocketMessage is a line of the websocket_log NDJSON artifact: a
+// message the app's pages sent or received while an action ran
+type WebSocketMessage struct {
+	Action string `json:"action"`
+	platforms.WebSocketFrame
+}
+
+// collectWebSockets appends the WebSocket messages captured during the
+// action to the app's websocket_log, keeping them for assert_websocket
+func (e *Executor) collectWebSockets(platform platforms.Platform, action config.Action, app config.AppConfig, result *TestResult) {
+	reader, ok := platform.(webSocketReader)
+	if !ok {
+		return
+	}
+	frames, err := reader.TakeWebSocketFrames()
+	if err != nil || len(frames) == 0 {
+		return
+	}
+	path, _ := result.Metrics["websocket_log"].(string)
+	if path == "" {
+		path = filepath.Join(e.outputDir, "websockets", fmt.Sprintf("%s_%d.ndjson", app.Name, result.StartTime.Unix()))
+	}
+	var lines strings.Builder
+	for _, frame := range frames {
+		message := WebSocketMessage{Action: action.Name, WebSocketFrame: frame}
+		e.webSockets = append(e.webSockets, message)
+		data, _ := json.Marshal(message)
+		lines.Write(append(data, '\n'))
+	}
+	if result.Metrics == nil {
+		result.Metrics = make(map[string]interface{})
+	}
+	result.Metrics["websocket_messages"] = len(e.webSockets)
+	if err := appendFile(path, lines.String()); err != nil {
+		e.logger.Warnf("Failed to write the WebSocket log %s: %v", path, err)
+		return
+	}
+	result.Metrics["websocket_log"] = path
+}
+
+func appendFile(path, text string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	if _, err := f.WriteString(text); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// assertWebSocket counts the WebSocket messages the app's pages sent or
+// received that match the action, waiting up to its timeout for as many as
+// it expects
+func (e *Executor) assertWebSocket(platform platforms.Platform, action config.Action, app config.AppConfig, result *TestResult) error {
+	check, err := action.WebSocketCheck()
+	if err != nil {
+		return err
+	}
+	if _, ok := platform.(webSocketReader); !ok {
+		return fmt.Errorf("assert_websocket is not supported on this platform")
+	}
+	deadline := time.Now().Add(check.Timeout)
+	var matched []string
+	for {
+		e.collectWebSockets(platform, action, app, result)
+		matched = matchWebSockets(e.webSockets, check)
+		if check.Counts(len(matched)) {
+			break
+		}
+		if time.Now().After(deadline) {
+			return fmt.Errorf("%d WebSocket message(s) matched, expected %s", len(matched), check.Expected())
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+	if check.Variable != "" && len(matched) > 0 {
+		if e.vars == nil {
+			e.vars = make(map[string]string)
+		}
+		e.vars[check.Variable] = matched[len(matched)-1]
+	}
+	e.logger.Infof("%d WebSocket message(s) matched, as expected", len(matched))
+	return nil
+}
+
+// matchWebSockets returns the payloads, or json_path values, of the
+// messages that match the check
+func matchWebSockets(messages []WebSocketMessage, check *config.WebSocketCheck) []string {
+	var matches *regexp.Regexp
+	if check.Matches != "" {
+		matches = regexp.MustCompile(check.Matches) // compiled by WebSocketCheck
+	}
+	var matched []string
+	for _, m := range messages {
+		if (check.Direction != "" && m.Direction != check.Direction) ||
+			!strings.Contains(m.URL, check.URL) || !strings.Contains(m.Payload, check.Contains) {
+			continue
+		}
+		if check.Path == nil {
+			if matches == nil || matches.MatchString(m.Payload) {
+				matched = append(matched, m.Payload)
+			}
+			continue
+		}
+		if m.Binary {
+			continue
+		}
+		values, err := check.Path.Lookup([]byte(m.Payload))
+		if err != nil {
+			continue
+		}
+		for _, v := range values {
+			if (check.Equals == nil || jsonpath.Equal(v, check.Equals)) &&
+				(matches == nil || matches.MatchString(jsonpath.Text(v))) {
+				matched = append(matched, jsonpath.Text(v))
+				break
+			}
+		}
+	}
+	return matched
+}
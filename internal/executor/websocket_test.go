@@ -0,0 +1,102 @@
+package executor
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"testing"
+	"time"
+
+	"panoptic/internal/config"
+	"panoptic/internal/logger"
+	"panoptic/internal/platforms"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// chatPlatform is a mock platform whose clicks send a chat message; the
+// reply arrives with the next frames taken
+type chatPlatform struct {
+	*MockPlatform
+	pending []platforms.WebSocketFrame
+	replies []platforms.WebSocketFrame
+}
+
+func (p *chatPlatform) Click(selector string) error {
+	p.pending = append(p.pending, platforms.WebSocketFrame{Time: time.Now(), URL: "wss://chat.test/socket", Direction: "sent", Payload: `{"type":"message","text":"hi"}`})
+	p.replies = append(p.replies, platforms.WebSocketFrame{Time: time.Now(), URL: "wss://chat.test/socket", Direction: "received", Payload: `{"type":"message","text":"hello ada","from":"bob"}`})
+	return nil
+}
+
+func (p *chatPlatform) TakeWebSocketFrames() ([]platforms.WebSocketFrame, error) {
+	frames := p.pending
+	p.pending, p.replies = p.replies, nil
+	return frames, nil
+}
+
+func TestExecutor_AssertWebSocket(t *testing.T) {
+	executor := NewExecutor(&config.Config{}, t.TempDir(), logger.NewLogger(false))
+	platform := &chatPlatform{MockPlatform: &MockPlatform{metrics: map[string]interface{}{}}}
+	platform.pending = []platforms.WebSocketFrame{{URL: "wss://chat.test/socket", Direction: "received", Payload: `{"type":"welcome"}`}}
+	app := config.AppConfig{Name: "Chat", Type: "web"}
+	result := &TestResult{AppName: "Chat", StartTime: time.Now(), Metrics: map[string]interface{}{}}
+
+	require.NoError(t, executor.executeAction(platform, config.Action{Name: "send", Type: "click", Selector: "#send"}, app, result, nil))
+	reply := config.Action{Name: "reply", Type: "assert_websocket", Parameters: map[string]interface{}{
+		"direction": "received", "url": "/socket", "json_path": "$.text", "matches": "^hello", "count": 1, "variable": "reply",
+	}}
+	require.NoError(t, executor.executeAction(platform, reply, app, result, nil))
+	assert.Equal(t, "hello ada", executor.vars["reply"])
+	assert.Equal(t, 3, result.Metrics["websocket_messages"])
+
+	path := result.Metrics["websocket_log"].(string)
+	assert.Contains(t, result.Artifacts(), Artifact{App: "Chat", Type: "websocket_log", Path: path})
+	f, err := os.Open(path)
+	require.NoError(t, err)
+	defer f.Close()
+	var logged []WebSocketMessage
+	for scanner := bufio.NewScanner(f); scanner.Scan(); {
+		var m WebSocketMessage
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), &m))
+		logged = append(logged, m)
+	}
+	require.Len(t, logged, 3)
+	assert.Equal(t, "send", logged[0].Action)
+	assert.Equal(t, "sent", logged[1].Direction)
+	assert.Equal(t, "reply", logged[2].Action)
+
+	sent := config.Action{Name: "sent", Type: "assert_websocket", Parameters: map[string]interface{}{
+		"direction": "sent", "json_path": "$.type", "equals": "message",
+	}}
+	require.NoError(t, executor.executeAction(platform, sent, app, result, nil))
+	errorsAction := config.Action{Name: "no_errors", Type: "assert_websocket", Parameters: map[string]interface{}{"contains": `"error"`, "max_count": 0}}
+	require.NoError(t, executor.executeAction(platform, errorsAction, app, result, nil))
+
+	twice := config.Action{Name: "twice", Type: "assert_websocket", Parameters: map[string]interface{}{
+		"json_path": "$.type", "equals": "message", "min_count": 3, "timeout": "200ms",
+	}}
+	err = executor.executeAction(platform, twice, app, result, nil)
+	assert.EqualError(t, err, "2 WebSocket message(s) matched, expected at least 3")
+
+	err = executor.assertWebSocket(&MockPlatform{metrics: map[string]interface{}{}}, reply, app, result)
+	assert.EqualError(t, err, "assert_websocket is not supported on this platform")
+}
+
+func TestMatchWebSockets(t *testing.T) {
+	messages := []WebSocketMessage{
+		{Action: "a", WebSocketFrame: platforms.WebSocketFrame{URL: "wss://chat.test/socket", Direction: "received", Payload: `{"users":[{"name":"ada"},{"name":"bob"}]}`}},
+		{Action: "a", WebSocketFrame: platforms.WebSocketFrame{URL: "wss://feed.test/", Direction: "received", Payload: "AAE=", Binary: true}},
+		{Action: "a", WebSocketFrame: platforms.WebSocketFrame{URL: "wss://feed.test/", Direction: "sent", Payload: "ping"}},
+	}
+	check := func(params map[string]interface{}) []string {
+		c, err := (&config.Action{Type: "assert_websocket", Parameters: params}).WebSocketCheck()
+		require.NoError(t, err)
+		return matchWebSockets(messages, c)
+	}
+	assert.Equal(t, []string{"bob"}, check(map[string]interface{}{"json_path": "$.users[*].name", "equals": "bob"}))
+	assert.Equal(t, []string{`[{"name":"ada"},{"name":"bob"}]`}, check(map[string]interface{}{"json_path": "$.users"}))
+	assert.Equal(t, []string{"AAE=", "ping"}, check(map[string]interface{}{"url": "feed.test"}))
+	assert.Equal(t, []string{"ping"}, check(map[string]interface{}{"matches": "^p"}))
+	assert.Empty(t, check(map[string]interface{}{"json_path": "$.missing"}))
+}
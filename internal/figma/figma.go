@@ -0,0 +1,116 @@
+// Package figma fetches renders of design components from the Figma API, so
+// screenshots of the implemented components can be compared against them.
+package figma
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultURL is the Figma REST API
+const DefaultURL = "https://api.figma.com"
+
+// maxRenderSize bounds a downloaded render, well above what a component
+// renders to
+const maxRenderSize = 64 << 20
+
+// Client renders the nodes of Figma files with a personal access token
+type Client struct {
+	baseURL    string
+	token      string
+	httpClient *http.Client
+}
+
+// NewClient returns a client of the API at baseURL, Figma's when empty
+func NewClient(token, baseURL string) *Client {
+	if baseURL == "" {
+		baseURL = DefaultURL
+	}
+	return &Client{
+		baseURL:    strings.TrimRight(baseURL, "/"),
+		token:      token,
+		httpClient: &http.Client{Timeout: 60 * time.Second},
+	}
+}
+
+// imagesResponse is the response of GET /v1/images/:file_key
+type imagesResponse struct {
+	Err    *string            `json:"err"`
+	Status int                `json:"status"`
+	Images map[string]*string `json:"images"`
+}
+
+// RenderURLs has Figma render the nodes of a file as PNGs at scale, from
+// 0.01 to 4, returning the URL of each node's render by node ID. The URLs
+// expire after a while, so download them right away.
+func (c *Client) RenderURLs(ctx context.Context, fileKey string, nodeIDs []string, scale float64) (map[string]string, error) {
+	query := url.Values{
+		"ids":    {strings.Join(nodeIDs, ",")},
+		"format": {"png"},
+		"scale":  {strconv.FormatFloat(scale, 'f', -1, 64)},
+	}
+	endpoint := fmt.Sprintf("%s/v1/images/%s?%s", c.baseURL, url.PathEscape(fileKey), query.Encode())
+	body, err := c.get(ctx, endpoint, true)
+	if err != nil {
+		return nil, err
+	}
+	var resp imagesResponse
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return nil, fmt.Errorf("figma: invalid images response: %w", err)
+	}
+	if resp.Err != nil && *resp.Err != "" {
+		return nil, fmt.Errorf("figma: %s", *resp.Err)
+	}
+	urls := make(map[string]string, len(nodeIDs))
+	for _, id := range nodeIDs {
+		u := resp.Images[id]
+		if u == nil || *u == "" {
+			return nil, fmt.Errorf("figma: node %s of file %s has no render; is it a visible frame or component?", id, fileKey)
+		}
+		urls[id] = *u
+	}
+	return urls, nil
+}
+
+// Render returns the PNG render of a node of a file at scale
+func (c *Client) Render(ctx context.Context, fileKey, nodeID string, scale float64) ([]byte, error) {
+	urls, err := c.RenderURLs(ctx, fileKey, []string{nodeID}, scale)
+	if err != nil {
+		return nil, err
+	}
+	return c.get(ctx, urls[nodeID], false)
+}
+
+// get fetches a URL, with the token when it's the API's
+func (c *Client) get(ctx context.Context, target string, authenticated bool) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, target, nil)
+	if err != nil {
+		return nil, fmt.Errorf("figma: %w", err)
+	}
+	if authenticated {
+		req.Header.Set("X-Figma-Token", c.token)
+	}
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("figma: %w", err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxRenderSize))
+	if err != nil {
+		return nil, fmt.Errorf("figma: failed to read response: %w", err)
+	}
+	switch {
+	case resp.StatusCode == http.StatusForbidden:
+		return nil, fmt.Errorf("figma: access denied (HTTP 403); check the token can read the file")
+	case resp.StatusCode != http.StatusOK:
+		return nil, fmt.Errorf("figma: HTTP %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+	return body, nil
+}
@@ -0,0 +1,59 @@
+package figma
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClient_Render(t *testing.T) {
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v1/images/FiLeKeY":
+			assert.Equal(t, "figd_token", r.Header.Get("X-Figma-Token"))
+			assert.Equal(t, "12:34", r.URL.Query().Get("ids"))
+			assert.Equal(t, "png", r.URL.Query().Get("format"))
+			assert.Equal(t, "2", r.URL.Query().Get("scale"))
+			w.Write([]byte(`{"err": null, "images": {"12:34": "` + server.URL + `/renders/12-34.png"}}`))
+		case "/renders/12-34.png":
+			assert.Empty(t, r.Header.Get("X-Figma-Token"), "the token isn't sent to the render's host")
+			w.Write([]byte("png bytes"))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	png, err := NewClient("figd_token", server.URL+"/").Render(context.Background(), "FiLeKeY", "12:34", 2)
+	require.NoError(t, err)
+	assert.Equal(t, "png bytes", string(png))
+}
+
+func TestClient_Render_Errors(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v1/images/private":
+			w.WriteHeader(http.StatusForbidden)
+		case "/v1/images/hidden":
+			w.Write([]byte(`{"err": null, "images": {"1:2": null}}`))
+		default:
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"status": 400, "err": "Invalid parameter"}`))
+		}
+	}))
+	defer server.Close()
+	client := NewClient("figd_token", server.URL)
+	ctx := context.Background()
+
+	_, err := client.Render(ctx, "private", "1:2", 1)
+	assert.EqualError(t, err, "figma: access denied (HTTP 403); check the token can read the file")
+	_, err = client.Render(ctx, "hidden", "1:2", 1)
+	assert.EqualError(t, err, "figma: node 1:2 of file hidden has no render; is it a visible frame or component?")
+	_, err = client.Render(ctx, "other", "1:2", 1)
+	assert.EqualError(t, err, `figma: HTTP 400: {"status": 400, "err": "Invalid parameter"}`)
+}
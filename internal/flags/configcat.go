@@ -0,0 +1,80 @@
+package flags
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// DefaultConfigCatURL is the ConfigCat Public Management API
+const DefaultConfigCatURL = "https://api.configcat.com"
+
+// ConfigCat replaces the default value of settings in one config and
+// environment. Targeting and percentage rules still apply.
+type ConfigCat struct {
+	client      *client
+	config      string
+	environment string
+}
+
+// NewConfigCat returns a provider for a config ID and environment ID;
+// credentials are the management API "username:password"
+func NewConfigCat(credentials, baseURL, configID, environmentID string) *ConfigCat {
+	if baseURL == "" {
+		baseURL = DefaultConfigCatURL
+	}
+	auth := "Basic " + base64.StdEncoding.EncodeToString([]byte(credentials))
+	return &ConfigCat{
+		client:      newClient(strings.TrimRight(baseURL, "/"), map[string]string{"Authorization": auth}),
+		config:      configID,
+		environment: environmentID,
+	}
+}
+
+func (c *ConfigCat) valuePath(key string) string {
+	return fmt.Sprintf("/v1/environments/%s/settings/%s/value?configId=%s",
+		url.PathEscape(c.environment), url.PathEscape(key), url.QueryEscape(c.config))
+}
+
+func (c *ConfigCat) replace(ctx context.Context, key string, value interface{}) error {
+	body := []map[string]interface{}{{"op": "replace", "path": "/value", "value": value}}
+	return c.client.do(ctx, http.MethodPatch, c.valuePath(key), "", body, nil)
+}
+
+// Set replaces the setting's default value
+func (c *ConfigCat) Set(ctx context.Context, key string, value interface{}) (func(context.Context) error, error) {
+	var current struct {
+		Value interface{} `json:"value"`
+	}
+	if err := c.client.do(ctx, http.MethodGet, c.valuePath(key), "", nil, &current); err != nil {
+		return nil, err
+	}
+	if err := c.replace(ctx, key, value); err != nil {
+		return nil, err
+	}
+	return func(ctx context.Context) error { return c.replace(ctx, key, current.Value) }, nil
+}
+
+// Values returns the default value of every setting in the config
+func (c *ConfigCat) Values(ctx context.Context) (map[string]interface{}, error) {
+	var list struct {
+		SettingValues []struct {
+			Setting struct {
+				Key string `json:"key"`
+			} `json:"setting"`
+			Value interface{} `json:"value"`
+		} `json:"settingValues"`
+	}
+	path := fmt.Sprintf("/v1/configs/%s/environments/%s/values", url.PathEscape(c.config), url.PathEscape(c.environment))
+	if err := c.client.do(ctx, http.MethodGet, path, "", nil, &list); err != nil {
+		return nil, err
+	}
+	values := make(map[string]interface{}, len(list.SettingValues))
+	for _, s := range list.SettingValues {
+		values[s.Setting.Key] = s.Value
+	}
+	return values, nil
+}
@@ -0,0 +1,39 @@
+package flags
+
+import (
+	"encoding/base64"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestConfigCat_Set tests replacing and restoring a setting's default value
+func TestConfigCat_Set(t *testing.T) {
+	path := "/v1/environments/env-1/settings/theme/value?configId=cfg-1"
+	server, requests := fakeAPI(t, map[string]string{
+		"GET " + path:   `{"value":"light","rolloutRules":[]}`,
+		"PATCH " + path: `{}`,
+	})
+	configcat := NewConfigCat("user:secret", server.URL, "cfg-1", "env-1")
+
+	restore, err := configcat.Set(t.Context(), "theme", "dark")
+	require.NoError(t, err)
+	require.NoError(t, restore(t.Context()))
+	require.Len(t, *requests, 3)
+
+	assert.Equal(t, "Basic "+base64.StdEncoding.EncodeToString([]byte("user:secret")), (*requests)[1].Auth)
+	assert.Equal(t, []interface{}{map[string]interface{}{"op": "replace", "path": "/value", "value": "dark"}}, (*requests)[1].Body)
+	assert.Equal(t, []interface{}{map[string]interface{}{"op": "replace", "path": "/value", "value": "light"}}, (*requests)[2].Body)
+}
+
+// TestConfigCat_Values tests reading the default values of a config
+func TestConfigCat_Values(t *testing.T) {
+	server, _ := fakeAPI(t, map[string]string{
+		"GET /v1/configs/cfg-1/environments/env-1/values": `{"settingValues":[
+			{"setting":{"key":"theme"},"value":"dark"},{"setting":{"key":"retries"},"value":3}]}`,
+	})
+	values, err := NewConfigCat("user:secret", server.URL, "cfg-1", "env-1").Values(t.Context())
+	require.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"theme": "dark", "retries": float64(3)}, values)
+}
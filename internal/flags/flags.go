@@ -0,0 +1,97 @@
+// Package flags overrides and reads feature flags in LaunchDarkly, Unleash
+// and ConfigCat through their management APIs, so tests can run an app with
+// specific flags and record the flags a run saw.
+package flags
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+// Provider changes and reads the flags of one project environment
+type Provider interface {
+	// Set makes the flag serve value and returns a function restoring the
+	// flag's previous state
+	Set(ctx context.Context, key string, value interface{}) (restore func(context.Context) error, err error)
+	// Values returns the value each flag serves by default, before targeting
+	// rules
+	Values(ctx context.Context) (map[string]interface{}, error)
+}
+
+// client is the JSON HTTP client shared by the providers
+type client struct {
+	baseURL    string
+	headers    map[string]string
+	httpClient *http.Client
+}
+
+func newClient(baseURL string, headers map[string]string) *client {
+	return &client{baseURL: baseURL, headers: headers, httpClient: &http.Client{Timeout: 30 * time.Second}}
+}
+
+// do sends body as JSON, with contentType when set, and decodes the response
+// into out when it is non-nil
+func (c *client) do(ctx context.Context, method, path, contentType string, body, out interface{}) error {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return fmt.Errorf("failed to encode request: %w", err)
+		}
+		reader = bytes.NewReader(data)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, c.baseURL+path, reader)
+	if err != nil {
+		return err
+	}
+	if body != nil {
+		if contentType == "" {
+			contentType = "application/json"
+		}
+		req.Header.Set("Content-Type", contentType)
+	}
+	req.Header.Set("Accept", "application/json")
+	for k, v := range c.headers {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("%s %s: %w", method, path, err)
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 10<<20))
+	if err != nil {
+		return fmt.Errorf("%s %s: %w", method, path, err)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("%s %s: status %d: %s", method, path, resp.StatusCode, bytes.TrimSpace(data))
+	}
+	if out != nil && len(data) > 0 {
+		if err := json.Unmarshal(data, out); err != nil {
+			return fmt.Errorf("%s %s: invalid response: %w", method, path, err)
+		}
+	}
+	return nil
+}
+
+// sameValue compares flag values by their JSON form, so 1 and 1.0 match
+func sameValue(a, b interface{}) bool {
+	x, errA := json.Marshal(a)
+	y, errB := json.Marshal(b)
+	if errA != nil || errB != nil {
+		return false
+	}
+	var nx, ny interface{}
+	if json.Unmarshal(x, &nx) != nil || json.Unmarshal(y, &ny) != nil {
+		return false
+	}
+	nxJSON, _ := json.Marshal(nx)
+	nyJSON, _ := json.Marshal(ny)
+	return bytes.Equal(nxJSON, nyJSON)
+}
@@ -0,0 +1,56 @@
+package flags
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type request struct {
+	Method      string
+	Path        string
+	Auth        string
+	ContentType string
+	Body        interface{}
+}
+
+// fakeAPI answers "METHOD /path?query" with the JSON in responses, 404 for
+// anything else, and records the requests
+func fakeAPI(t *testing.T, responses map[string]string) (*httptest.Server, *[]request) {
+	var requests []request
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		req := request{Method: r.Method, Path: r.URL.RequestURI(), Auth: r.Header.Get("Authorization"), ContentType: r.Header.Get("Content-Type")}
+		if len(body) > 0 {
+			json.Unmarshal(body, &req.Body)
+		}
+		requests = append(requests, req)
+		response, ok := responses[r.Method+" "+r.URL.RequestURI()]
+		if !ok {
+			http.Error(w, `{"message":"not found"}`, http.StatusNotFound)
+			return
+		}
+		w.Write([]byte(response))
+	}))
+	t.Cleanup(server.Close)
+	return server, &requests
+}
+
+// TestClient_Error tests that API errors carry the status and body
+func TestClient_Error(t *testing.T) {
+	server, _ := fakeAPI(t, nil)
+	err := newClient(server.URL, nil).do(t.Context(), http.MethodGet, "/missing", "", nil, nil)
+	assert.ErrorContains(t, err, "GET /missing: status 404")
+	assert.ErrorContains(t, err, "not found")
+}
+
+// TestSameValue tests value comparison across JSON number types
+func TestSameValue(t *testing.T) {
+	assert.True(t, sameValue(1, 1.0))
+	assert.True(t, sameValue(map[string]interface{}{"a": 1}, map[string]int{"a": 1}))
+	assert.False(t, sameValue(true, "true"))
+}
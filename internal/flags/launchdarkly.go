@@ -0,0 +1,177 @@
+package flags
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// DefaultLaunchDarklyURL is the LaunchDarkly REST API
+const DefaultLaunchDarklyURL = "https://app.launchdarkly.com"
+
+// LaunchDarkly overrides flags by turning them on and pointing their default
+// (fallthrough) rule at the variation serving the wanted value. Individual
+// targets and targeting rules still apply.
+type LaunchDarkly struct {
+	client      *client
+	project     string
+	environment string
+}
+
+// NewLaunchDarkly returns a provider for one project environment; token is an
+// API access token with writer access
+func NewLaunchDarkly(token, baseURL, project, environment string) *LaunchDarkly {
+	if baseURL == "" {
+		baseURL = DefaultLaunchDarklyURL
+	}
+	return &LaunchDarkly{
+		client:      newClient(strings.TrimRight(baseURL, "/"), map[string]string{"Authorization": token}),
+		project:     project,
+		environment: environment,
+	}
+}
+
+type ldVariation struct {
+	ID    string      `json:"_id"`
+	Value interface{} `json:"value"`
+}
+
+type ldWeightedVariation struct {
+	Variation int `json:"variation"`
+	Weight    int `json:"weight"` // in thousandths of a percent
+}
+
+type ldRollout struct {
+	Variations []ldWeightedVariation `json:"variations"`
+}
+
+type ldEnvironment struct {
+	On          bool `json:"on"`
+	Fallthrough struct {
+		Variation *int       `json:"variation"`
+		Rollout   *ldRollout `json:"rollout"`
+	} `json:"fallthrough"`
+	OffVariation *int `json:"offVariation"`
+}
+
+type ldFlag struct {
+	Key          string                   `json:"key"`
+	Variations   []ldVariation            `json:"variations"`
+	Environments map[string]ldEnvironment `json:"environments"`
+}
+
+// variation returns the value of variation index i, or nil when it is unset
+func (f *ldFlag) variation(i *int) interface{} {
+	if i == nil || *i < 0 || *i >= len(f.Variations) {
+		return nil
+	}
+	return f.Variations[*i].Value
+}
+
+// value is what the flag serves to contexts no rule matches; a percentage
+// rollout has no single value and is reported as "rollout"
+func (f *ldFlag) value(environment string) interface{} {
+	env, ok := f.Environments[environment]
+	if !ok {
+		return nil
+	}
+	if !env.On {
+		return f.variation(env.OffVariation)
+	}
+	if env.Fallthrough.Rollout != nil {
+		return "rollout"
+	}
+	return f.variation(env.Fallthrough.Variation)
+}
+
+func (l *LaunchDarkly) flag(ctx context.Context, key string) (*ldFlag, error) {
+	var flag ldFlag
+	path := fmt.Sprintf("/api/v2/flags/%s/%s?env=%s", url.PathEscape(l.project), url.PathEscape(key), url.QueryEscape(l.environment))
+	if err := l.client.do(ctx, http.MethodGet, path, "", nil, &flag); err != nil {
+		return nil, err
+	}
+	if _, ok := flag.Environments[l.environment]; !ok {
+		return nil, fmt.Errorf("flag %s has no environment %s", key, l.environment)
+	}
+	return &flag, nil
+}
+
+// patch applies semantic patch instructions to the flag in the environment
+func (l *LaunchDarkly) patch(ctx context.Context, key string, instructions []map[string]interface{}) error {
+	body := map[string]interface{}{
+		"environmentKey": l.environment,
+		"comment":        "Panoptic test override",
+		"instructions":   instructions,
+	}
+	path := fmt.Sprintf("/api/v2/flags/%s/%s", url.PathEscape(l.project), url.PathEscape(key))
+	return l.client.do(ctx, http.MethodPatch, path, "application/json; domain-model=launchdarkly.semanticpatch", body, nil)
+}
+
+// Set serves the variation whose value equals value
+func (l *LaunchDarkly) Set(ctx context.Context, key string, value interface{}) (func(context.Context) error, error) {
+	flag, err := l.flag(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	var variationID string
+	for _, v := range flag.Variations {
+		if sameValue(v.Value, value) {
+			variationID = v.ID
+			break
+		}
+	}
+	if variationID == "" {
+		return nil, fmt.Errorf("flag %s has no variation with value %v", key, value)
+	}
+
+	err = l.patch(ctx, key, []map[string]interface{}{
+		{"kind": "turnFlagOn"},
+		{"kind": "updateFallthroughVariationOrRollout", "variationId": variationID},
+	})
+	if err != nil {
+		return nil, err
+	}
+	restore := restoreInstructions(flag, flag.Environments[l.environment])
+	return func(ctx context.Context) error { return l.patch(ctx, key, restore) }, nil
+}
+
+// restoreInstructions put the flag's on state and default rule back
+func restoreInstructions(flag *ldFlag, env ldEnvironment) []map[string]interface{} {
+	instructions := []map[string]interface{}{{"kind": "turnFlagOff"}}
+	if env.On {
+		instructions[0]["kind"] = "turnFlagOn"
+	}
+	switch {
+	case env.Fallthrough.Rollout != nil:
+		weights := map[string]int{}
+		for _, v := range env.Fallthrough.Rollout.Variations {
+			if v.Variation >= 0 && v.Variation < len(flag.Variations) {
+				weights[flag.Variations[v.Variation].ID] = v.Weight
+			}
+		}
+		instructions = append(instructions, map[string]interface{}{"kind": "updateFallthroughVariationOrRollout", "rolloutWeights": weights})
+	case env.Fallthrough.Variation != nil && *env.Fallthrough.Variation < len(flag.Variations):
+		instructions = append(instructions, map[string]interface{}{
+			"kind": "updateFallthroughVariationOrRollout", "variationId": flag.Variations[*env.Fallthrough.Variation].ID,
+		})
+	}
+	return instructions
+}
+
+// Values returns the fallthrough or off value of every flag in the project
+func (l *LaunchDarkly) Values(ctx context.Context) (map[string]interface{}, error) {
+	var list struct {
+		Items []ldFlag `json:"items"`
+	}
+	path := fmt.Sprintf("/api/v2/flags/%s?env=%s&summary=0", url.PathEscape(l.project), url.QueryEscape(l.environment))
+	if err := l.client.do(ctx, http.MethodGet, path, "", nil, &list); err != nil {
+		return nil, err
+	}
+	values := make(map[string]interface{}, len(list.Items))
+	for i := range list.Items {
+		values[list.Items[i].Key] = list.Items[i].value(l.environment)
+	}
+	return values, nil
+}
@@ -0,0 +1,69 @@
+package flags
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const ldCheckoutFlag = `{"key":"new-checkout","variations":[{"_id":"v-off","value":false},{"_id":"v-on","value":true}],
+	"environments":{"staging":{"on":false,"offVariation":0,"fallthrough":{"variation":0}}}}`
+
+// TestLaunchDarkly_Set tests the semantic patch and restoring the previous state
+func TestLaunchDarkly_Set(t *testing.T) {
+	server, requests := fakeAPI(t, map[string]string{
+		"GET /api/v2/flags/web/new-checkout?env=staging": ldCheckoutFlag,
+		"PATCH /api/v2/flags/web/new-checkout":           `{}`,
+	})
+	ld := NewLaunchDarkly("api-token", server.URL, "web", "staging")
+
+	restore, err := ld.Set(t.Context(), "new-checkout", true)
+	require.NoError(t, err)
+	require.NoError(t, restore(t.Context()))
+	require.Len(t, *requests, 3)
+
+	set := (*requests)[1]
+	assert.Equal(t, "api-token", set.Auth)
+	assert.Equal(t, "application/json; domain-model=launchdarkly.semanticpatch", set.ContentType)
+	body := set.Body.(map[string]interface{})
+	assert.Equal(t, "staging", body["environmentKey"])
+	assert.Equal(t, []interface{}{
+		map[string]interface{}{"kind": "turnFlagOn"},
+		map[string]interface{}{"kind": "updateFallthroughVariationOrRollout", "variationId": "v-on"},
+	}, body["instructions"])
+
+	assert.Equal(t, []interface{}{
+		map[string]interface{}{"kind": "turnFlagOff"},
+		map[string]interface{}{"kind": "updateFallthroughVariationOrRollout", "variationId": "v-off"},
+	}, (*requests)[2].Body.(map[string]interface{})["instructions"])
+
+	_, err = ld.Set(t.Context(), "new-checkout", "beta")
+	assert.ErrorContains(t, err, "no variation with value beta")
+}
+
+// TestLaunchDarkly_RestoreRollout tests restoring a percentage rollout
+func TestLaunchDarkly_RestoreRollout(t *testing.T) {
+	flag := &ldFlag{Variations: []ldVariation{{ID: "a"}, {ID: "b"}}}
+	env := ldEnvironment{On: true}
+	env.Fallthrough.Rollout = &ldRollout{Variations: []ldWeightedVariation{{Variation: 0, Weight: 25000}, {Variation: 1, Weight: 75000}}}
+
+	assert.Equal(t, []map[string]interface{}{
+		{"kind": "turnFlagOn"},
+		{"kind": "updateFallthroughVariationOrRollout", "rolloutWeights": map[string]int{"a": 25000, "b": 75000}},
+	}, restoreInstructions(flag, env))
+}
+
+// TestLaunchDarkly_Values tests the values of off, on and rolled-out flags
+func TestLaunchDarkly_Values(t *testing.T) {
+	server, _ := fakeAPI(t, map[string]string{
+		"GET /api/v2/flags/web?env=staging&summary=0": `{"items":[` + ldCheckoutFlag + `,
+			{"key":"theme","variations":[{"_id":"1","value":"light"},{"_id":"2","value":"dark"}],
+			 "environments":{"staging":{"on":true,"offVariation":0,"fallthrough":{"variation":1}}}},
+			{"key":"search","variations":[{"_id":"1","value":false},{"_id":"2","value":true}],
+			 "environments":{"staging":{"on":true,"fallthrough":{"rollout":{"variations":[{"variation":0,"weight":50000},{"variation":1,"weight":50000}]}}}}}]}`,
+	})
+	values, err := NewLaunchDarkly("api-token", server.URL, "web", "staging").Values(t.Context())
+	require.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"new-checkout": false, "theme": "dark", "search": "rollout"}, values)
+}
@@ -0,0 +1,98 @@
+package flags
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// Unleash turns feature toggles on or off in one environment through the
+// Admin API. Toggles are booleans; variants and strategies are left alone.
+type Unleash struct {
+	client      *client
+	project     string
+	environment string
+}
+
+// NewUnleash returns a provider for a project environment of the Unleash
+// server at baseURL; token is an admin API token
+func NewUnleash(token, baseURL, project, environment string) *Unleash {
+	if project == "" {
+		project = "default"
+	}
+	return &Unleash{
+		client:      newClient(strings.TrimRight(baseURL, "/"), map[string]string{"Authorization": token}),
+		project:     project,
+		environment: environment,
+	}
+}
+
+type unleashFeature struct {
+	Name         string `json:"name"`
+	Environments []struct {
+		Name    string `json:"name"`
+		Enabled bool   `json:"enabled"`
+	} `json:"environments"`
+}
+
+func (f *unleashFeature) enabled(environment string) (bool, bool) {
+	for _, env := range f.Environments {
+		if env.Name == environment {
+			return env.Enabled, true
+		}
+	}
+	return false, false
+}
+
+func (u *Unleash) featurePath(key string) string {
+	return fmt.Sprintf("/api/admin/projects/%s/features/%s", url.PathEscape(u.project), url.PathEscape(key))
+}
+
+func (u *Unleash) toggle(ctx context.Context, key string, on bool) error {
+	state := "off"
+	if on {
+		state = "on"
+	}
+	path := fmt.Sprintf("%s/environments/%s/%s", u.featurePath(key), url.PathEscape(u.environment), state)
+	return u.client.do(ctx, http.MethodPost, path, "", nil, nil)
+}
+
+// Set enables or disables the toggle; value must be a boolean
+func (u *Unleash) Set(ctx context.Context, key string, value interface{}) (func(context.Context) error, error) {
+	on, ok := value.(bool)
+	if !ok {
+		return nil, fmt.Errorf("unleash toggle %s can only be set to true or false, not %v", key, value)
+	}
+	var feature unleashFeature
+	if err := u.client.do(ctx, http.MethodGet, u.featurePath(key), "", nil, &feature); err != nil {
+		return nil, err
+	}
+	previous, found := feature.enabled(u.environment)
+	if !found {
+		return nil, fmt.Errorf("toggle %s has no environment %s", key, u.environment)
+	}
+	if err := u.toggle(ctx, key, on); err != nil {
+		return nil, err
+	}
+	return func(ctx context.Context) error { return u.toggle(ctx, key, previous) }, nil
+}
+
+// Values returns whether each toggle of the project is enabled
+func (u *Unleash) Values(ctx context.Context) (map[string]interface{}, error) {
+	var list struct {
+		Features []unleashFeature `json:"features"`
+	}
+	path := fmt.Sprintf("/api/admin/projects/%s/features", url.PathEscape(u.project))
+	if err := u.client.do(ctx, http.MethodGet, path, "", nil, &list); err != nil {
+		return nil, err
+	}
+	values := make(map[string]interface{}, len(list.Features))
+	for i := range list.Features {
+		if enabled, ok := list.Features[i].enabled(u.environment); ok {
+			values[list.Features[i].Name] = enabled
+		}
+	}
+	return values, nil
+}
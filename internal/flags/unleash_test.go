@@ -0,0 +1,44 @@
+package flags
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestUnleash_Set tests toggling and restoring a feature in an environment
+func TestUnleash_Set(t *testing.T) {
+	server, requests := fakeAPI(t, map[string]string{
+		"GET /api/admin/projects/default/features/new-checkout": `{"name":"new-checkout","environments":[
+			{"name":"development","enabled":true},{"name":"staging","enabled":false}]}`,
+		"POST /api/admin/projects/default/features/new-checkout/environments/staging/on":  `{}`,
+		"POST /api/admin/projects/default/features/new-checkout/environments/staging/off": `{}`,
+	})
+	unleash := NewUnleash("admin-token", server.URL+"/", "", "staging")
+
+	restore, err := unleash.Set(t.Context(), "new-checkout", true)
+	require.NoError(t, err)
+	require.NoError(t, restore(t.Context()))
+	require.Len(t, *requests, 3)
+	assert.Equal(t, "admin-token", (*requests)[1].Auth)
+	assert.Equal(t, "/api/admin/projects/default/features/new-checkout/environments/staging/on", (*requests)[1].Path)
+	assert.Equal(t, "/api/admin/projects/default/features/new-checkout/environments/staging/off", (*requests)[2].Path)
+
+	_, err = unleash.Set(t.Context(), "new-checkout", "dark")
+	assert.ErrorContains(t, err, "can only be set to true or false")
+	_, err = NewUnleash("admin-token", server.URL, "", "production").Set(t.Context(), "new-checkout", true)
+	assert.ErrorContains(t, err, "has no environment production")
+}
+
+// TestUnleash_Values tests reading the toggles of a project
+func TestUnleash_Values(t *testing.T) {
+	server, _ := fakeAPI(t, map[string]string{
+		"GET /api/admin/projects/web/features": `{"features":[
+			{"name":"new-checkout","environments":[{"name":"staging","enabled":true}]},
+			{"name":"legacy","environments":[{"name":"development","enabled":true}]}]}`,
+	})
+	values, err := NewUnleash("admin-token", server.URL, "web", "staging").Values(t.Context())
+	require.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"new-checkout": true}, values)
+}
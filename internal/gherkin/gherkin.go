@@ -0,0 +1,397 @@
+// Package gherkin reads Gherkin .feature files: features, their rules and
+// backgrounds, and scenarios, with scenario outlines expanded into one
+// scenario per example.
+package gherkin
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// Feature is a parsed .feature file
+type Feature struct {
+	Name      string
+	File      string
+	Tags      []string // without the @
+	Scenarios []Scenario
+}
+
+// Scenario is a scenario, or an example of a scenario outline. Its steps
+// begin with those of the backgrounds that apply to it.
+type Scenario struct {
+	Name  string
+	Line  int
+	Tags  []string // of the feature, rule, scenario and examples
+	Steps []Step
+}
+
+// Step is a Given, When, Then, And, But or * step
+type Step struct {
+	Keyword    string // as written
+	Text       string
+	Line       int
+	DocString  string     // the """ block below the step
+	Table      [][]string // the data table below the step
+	Background bool       // the step comes from a background
+}
+
+// Keywords of the English dialect
+var (
+	stepKeywords     = []string{"Given ", "When ", "Then ", "And ", "But ", "* "}
+	scenarioKeywords = []string{"Scenario:", "Example:"}
+	outlineKeywords  = []string{"Scenario Outline:", "Scenario Template:"}
+	examplesKeywords = []string{"Examples:", "Scenarios:"}
+)
+
+// ParseFile reads a .feature file
+func ParseFile(path string) (*Feature, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return Parse(f, path)
+}
+
+// parser is the state of reading one file
+type parser struct {
+	file    string
+	feature *Feature
+	tags    []string // read above the next feature, rule, scenario or examples
+
+	featureBackground []Step
+	rule              *block // nil outside rules
+	scenario          *block // scenario, outline or background being read
+	steps             *[]Step
+	examples          *examples // examples table being read
+	table             *[][]string
+	doc               *docString
+}
+
+// block is a rule, background, scenario or outline
+type block struct {
+	kind       string // rule, background, scenario or outline
+	name       string
+	line       int
+	tags       []string
+	steps      []Step
+	background []Step // of a rule
+	examples   []*examples
+}
+
+type examples struct {
+	line int
+	tags []string
+	rows [][]string
+}
+
+type docString struct {
+	delimiter string
+	indent    int
+	lines     []string
+}
+
+// Parse reads a .feature file from r, naming it file in errors
+func Parse(r io.Reader, file string) (*Feature, error) {
+	p := &parser{file: file}
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	n := 0
+	for scanner.Scan() {
+		n++
+		if err := p.line(scanner.Text(), n); err != nil {
+			return nil, fmt.Errorf("%s:%d: %w", file, n, err)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", file, err)
+	}
+	if p.doc != nil {
+		return nil, fmt.Errorf("%s: doc string not closed", file)
+	}
+	if p.feature == nil {
+		return nil, fmt.Errorf("%s: no Feature", file)
+	}
+	if err := p.endScenario(); err != nil {
+		return nil, fmt.Errorf("%s: %w", file, err)
+	}
+	return p.feature, nil
+}
+
+func (p *parser) line(raw string, n int) error {
+	text := strings.TrimSpace(raw)
+	if p.doc != nil {
+		if text == p.doc.delimiter {
+			last := &(*p.steps)[len(*p.steps)-1]
+			last.DocString = strings.Join(p.doc.lines, "\n")
+			p.doc = nil
+			return nil
+		}
+		// Lines keep their indentation beyond that of the opening delimiter
+		line := strings.TrimRight(raw, " \t")
+		strip := min(p.doc.indent, len(line)-len(strings.TrimLeft(line, " \t")))
+		p.doc.lines = append(p.doc.lines, line[strip:])
+		return nil
+	}
+	if text == "" || strings.HasPrefix(text, "#") {
+		return nil
+	}
+	if strings.HasPrefix(text, "|") {
+		return p.tableRow(text)
+	}
+	p.table = nil
+	if text == `"""` || text == "```" {
+		if p.steps == nil || len(*p.steps) == 0 {
+			return fmt.Errorf("doc string outside a step")
+		}
+		p.doc = &docString{delimiter: text, indent: len(raw) - len(strings.TrimLeft(raw, " \t"))}
+		return nil
+	}
+	if strings.HasPrefix(text, "@") {
+		for _, tag := range strings.Fields(text) {
+			if strings.HasPrefix(tag, "#") {
+				break
+			}
+			p.tags = append(p.tags, strings.TrimPrefix(tag, "@"))
+		}
+		return nil
+	}
+
+	if name, ok := cutKeyword(text, "Feature:"); ok {
+		if p.feature != nil {
+			return fmt.Errorf("a file has one Feature")
+		}
+		p.feature = &Feature{Name: name, File: p.file, Tags: p.takeTags()}
+		return nil
+	}
+	if p.feature == nil {
+		return fmt.Errorf("expected Feature, got %q", text)
+	}
+	if name, ok := cutKeyword(text, "Rule:"); ok {
+		if err := p.endScenario(); err != nil {
+			return err
+		}
+		p.rule = &block{kind: "rule", name: name, line: n, tags: p.takeTags()}
+		return nil
+	}
+	if _, ok := cutKeyword(text, "Background:"); ok {
+		if err := p.endScenario(); err != nil {
+			return err
+		}
+		p.scenario = &block{kind: "background", line: n}
+		p.steps = &p.scenario.steps
+		return nil
+	}
+	for _, keyword := range scenarioKeywords {
+		if name, ok := cutKeyword(text, keyword); ok {
+			return p.startScenario("scenario", name, n)
+		}
+	}
+	for _, keyword := range outlineKeywords {
+		if name, ok := cutKeyword(text, keyword); ok {
+			return p.startScenario("outline", name, n)
+		}
+	}
+	for _, keyword := range examplesKeywords {
+		if _, ok := cutKeyword(text, keyword); ok {
+			if p.scenario == nil || p.scenario.kind != "outline" {
+				return fmt.Errorf("Examples outside a Scenario Outline")
+			}
+			p.examples = &examples{line: n, tags: p.takeTags()}
+			p.scenario.examples = append(p.scenario.examples, p.examples)
+			p.steps = nil
+			return nil
+		}
+	}
+	for _, keyword := range stepKeywords {
+		if strings.HasPrefix(text, keyword) {
+			if p.steps == nil {
+				return fmt.Errorf("step outside a scenario or background: %q", text)
+			}
+			*p.steps = append(*p.steps, Step{
+				Keyword: strings.TrimSpace(keyword),
+				Text:    strings.TrimSpace(text[len(keyword):]),
+				Line:    n,
+			})
+			return nil
+		}
+	}
+	// Free-form description of a feature, rule or scenario
+	if p.steps != nil && len(*p.steps) > 0 || p.examples != nil {
+		return fmt.Errorf("expected a step, got %q", text)
+	}
+	return nil
+}
+
+// cutKeyword returns the name after a keyword that starts text
+func cutKeyword(text, keyword string) (string, bool) {
+	if !strings.HasPrefix(text, keyword) {
+		return "", false
+	}
+	return strings.TrimSpace(text[len(keyword):]), true
+}
+
+func (p *parser) takeTags() []string {
+	tags := p.tags
+	p.tags = nil
+	return tags
+}
+
+func (p *parser) startScenario(kind, name string, n int) error {
+	if err := p.endScenario(); err != nil {
+		return err
+	}
+	p.scenario = &block{kind: kind, name: name, line: n, tags: p.takeTags()}
+	p.steps = &p.scenario.steps
+	return nil
+}
+
+// tableRow adds a row to the data table of the last step or to examples
+func (p *parser) tableRow(text string) error {
+	if !strings.HasSuffix(text, "|") || len(text) < 2 {
+		return fmt.Errorf("table row must end with |")
+	}
+	var cells []string
+	for _, cell := range splitRow(text[1 : len(text)-1]) {
+		cells = append(cells, strings.TrimSpace(cell))
+	}
+	switch {
+	case p.examples != nil && p.steps == nil:
+		if len(p.examples.rows) > 0 && len(cells) != len(p.examples.rows[0]) {
+			return fmt.Errorf("examples row has %d cells, the header %d", len(cells), len(p.examples.rows[0]))
+		}
+		p.examples.rows = append(p.examples.rows, cells)
+	case p.steps != nil && len(*p.steps) > 0:
+		if p.table == nil {
+			p.table = &(*p.steps)[len(*p.steps)-1].Table
+		}
+		if len(*p.table) > 0 && len(cells) != len((*p.table)[0]) {
+			return fmt.Errorf("table row has %d cells, the first row %d", len(cells), len((*p.table)[0]))
+		}
+		*p.table = append(*p.table, cells)
+	default:
+		return fmt.Errorf("table outside a step or Examples")
+	}
+	return nil
+}
+
+// splitRow splits the cells of a table row at |, keeping \| and \\ escapes
+func splitRow(row string) []string {
+	var cells []string
+	var cell strings.Builder
+	for i := 0; i < len(row); i++ {
+		switch {
+		case row[i] == '\\' && i+1 < len(row) && (row[i+1] == '|' || row[i+1] == '\\'):
+			i++
+			cell.WriteByte(row[i])
+		case row[i] == '|':
+			cells = append(cells, cell.String())
+			cell.Reset()
+		default:
+			cell.WriteByte(row[i])
+		}
+	}
+	return append(cells, cell.String())
+}
+
+// endScenario adds the scenario or outline being read to the feature, or
+// keeps the background being read for the scenarios after it
+func (p *parser) endScenario() error {
+	b := p.scenario
+	p.scenario, p.steps, p.examples, p.table = nil, nil, nil, nil
+	if b == nil {
+		return nil
+	}
+	background := p.featureBackground
+	if p.rule != nil {
+		background = append(append([]Step(nil), background...), p.rule.background...)
+	}
+	var tags []string
+	tags = append(tags, p.feature.Tags...)
+	if p.rule != nil {
+		tags = append(tags, p.rule.tags...)
+	}
+	tags = append(tags, b.tags...)
+
+	switch b.kind {
+	case "background":
+		for i := range b.steps {
+			b.steps[i].Background = true
+		}
+		if p.rule != nil {
+			p.rule.background = b.steps
+		} else {
+			p.featureBackground = b.steps
+		}
+	case "scenario":
+		p.feature.Scenarios = append(p.feature.Scenarios, Scenario{
+			Name: b.name, Line: b.line, Tags: tags, Steps: append(append([]Step(nil), background...), b.steps...),
+		})
+	case "outline":
+		if len(b.examples) == 0 {
+			return fmt.Errorf("line %d: Scenario Outline %q has no Examples", b.line, b.name)
+		}
+		for _, ex := range b.examples {
+			if len(ex.rows) == 0 {
+				return fmt.Errorf("line %d: Examples have no header row", ex.line)
+			}
+			header := ex.rows[0]
+			for _, row := range ex.rows[1:] {
+				values := make(map[string]string, len(header))
+				for i, name := range header {
+					values[name] = row[i]
+				}
+				scenario := Scenario{
+					Name:  fmt.Sprintf("%s (%s)", fill(b.name, values), exampleLabel(header, row)),
+					Line:  b.line,
+					Tags:  append(append([]string(nil), tags...), ex.tags...),
+					Steps: append([]Step(nil), background...),
+				}
+				for _, step := range b.steps {
+					scenario.Steps = append(scenario.Steps, fillStep(step, values))
+				}
+				p.feature.Scenarios = append(p.feature.Scenarios, scenario)
+			}
+		}
+	}
+	return nil
+}
+
+// exampleLabel names an example by its values, e.g. "size=M, color=red"
+func exampleLabel(header, row []string) string {
+	parts := make([]string, len(header))
+	for i, name := range header {
+		parts[i] = name + "=" + row[i]
+	}
+	return strings.Join(parts, ", ")
+}
+
+// fill replaces the <name> placeholders of an outline with example values
+func fill(text string, values map[string]string) string {
+	if !strings.Contains(text, "<") {
+		return text
+	}
+	for name, value := range values {
+		text = strings.ReplaceAll(text, "<"+name+">", value)
+	}
+	return text
+}
+
+func fillStep(step Step, values map[string]string) Step {
+	step.Text = fill(step.Text, values)
+	step.DocString = fill(step.DocString, values)
+	if step.Table != nil {
+		table := make([][]string, len(step.Table))
+		for i, row := range step.Table {
+			table[i] = make([]string, len(row))
+			for j, cell := range row {
+				table[i][j] = fill(cell, values)
+			}
+		}
+		step.Table = table
+	}
+	return step
+}
@@ -0,0 +1,114 @@
+package gherkin
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const checkoutFeature = `# language: en
+@shop
+Feature: Checkout
+  Customers pay for what is in their cart.
+
+  Background:
+    Given I open the shop
+
+  @smoke
+  Scenario: Pay by card
+    When I add "socks" to the cart
+    And I pay with:
+      | card   | 4242 4242 |
+      | expiry | 12/30     |
+    Then I see "Thank you"
+
+  Rule: Coupons
+    Background:
+      Given I am signed in
+
+    Scenario Outline: Apply <code>
+      When I enter the coupon "<code>"
+      Then the total is <total>
+      """
+      Total: <total>
+        (taxes included)
+      """
+
+      @regression
+      Examples: valid
+        | code | total |
+        | HALF | 5     |
+        | FREE | 0     |
+`
+
+func TestParse(t *testing.T) {
+	f, err := Parse(strings.NewReader(checkoutFeature), "checkout.feature")
+	require.NoError(t, err)
+	assert.Equal(t, "Checkout", f.Name)
+	assert.Equal(t, "checkout.feature", f.File)
+	assert.Equal(t, []string{"shop"}, f.Tags)
+	require.Len(t, f.Scenarios, 3)
+
+	card := f.Scenarios[0]
+	assert.Equal(t, "Pay by card", card.Name)
+	assert.Equal(t, 10, card.Line)
+	assert.Equal(t, []string{"shop", "smoke"}, card.Tags)
+	require.Len(t, card.Steps, 4)
+	assert.Equal(t, Step{Keyword: "Given", Text: "I open the shop", Line: 7, Background: true}, card.Steps[0])
+	assert.Equal(t, "When", card.Steps[1].Keyword)
+	assert.Equal(t, `I add "socks" to the cart`, card.Steps[1].Text)
+	assert.Equal(t, [][]string{{"card", "4242 4242"}, {"expiry", "12/30"}}, card.Steps[2].Table)
+	assert.Equal(t, "Then", card.Steps[3].Keyword)
+
+	half := f.Scenarios[1]
+	assert.Equal(t, "Apply HALF (code=HALF, total=5)", half.Name)
+	assert.Equal(t, []string{"shop", "regression"}, half.Tags)
+	require.Len(t, half.Steps, 4)
+	assert.Equal(t, "I open the shop", half.Steps[0].Text)
+	assert.Equal(t, "I am signed in", half.Steps[1].Text)
+	assert.True(t, half.Steps[1].Background)
+	assert.Equal(t, `I enter the coupon "HALF"`, half.Steps[2].Text)
+	assert.Equal(t, "the total is 5", half.Steps[3].Text)
+	assert.Equal(t, "Total: 5\n  (taxes included)", half.Steps[3].DocString)
+
+	free := f.Scenarios[2]
+	assert.Equal(t, "Apply FREE (code=FREE, total=0)", free.Name)
+	assert.Equal(t, "the total is 0", free.Steps[3].Text)
+}
+
+func TestParse_Escapes(t *testing.T) {
+	f, err := Parse(strings.NewReader(`Feature: Tables
+  Scenario: Pipes
+    * I see
+      | a \| b | c\\ |
+`), "tables.feature")
+	require.NoError(t, err)
+	assert.Equal(t, "*", f.Scenarios[0].Steps[0].Keyword)
+	assert.Equal(t, [][]string{{"a | b", `c\`}}, f.Scenarios[0].Steps[0].Table)
+}
+
+func TestParse_Errors(t *testing.T) {
+	tests := []struct {
+		name, feature, err string
+	}{
+		{"no feature", "# nothing\n", "f.feature: no Feature"},
+		{"before feature", "Scenario: x\n", `f.feature:1: expected Feature, got "Scenario: x"`},
+		{"two features", "Feature: a\nFeature: b\n", "f.feature:2: a file has one Feature"},
+		{"step outside", "Feature: a\n  Given x\n", "f.feature:2: step outside a scenario or background"},
+		{"examples outside", "Feature: a\n  Scenario: b\n    Given x\n  Examples:\n", "f.feature:4: Examples outside a Scenario Outline"},
+		{"no examples", "Feature: a\n  Scenario Outline: b\n    Given <x>\n", `Scenario Outline "b" has no Examples`},
+		{"ragged examples", "Feature: a\n  Scenario Outline: b\n    Given <x>\n  Examples:\n    | x | y |\n    | 1 |\n", "f.feature:6: examples row has 1 cells, the header 2"},
+		{"unclosed doc", "Feature: a\n  Scenario: b\n    Given x\n    \"\"\"\n    text\n", "f.feature: doc string not closed"},
+		{"text after steps", "Feature: a\n  Scenario: b\n    Given x\n    Whenever y\n", `f.feature:4: expected a step, got "Whenever y"`},
+		{"table outside", "Feature: a\n  | x |\n", "f.feature:2: table outside a step or Examples"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Parse(strings.NewReader(tt.feature), "f.feature")
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.err)
+		})
+	}
+}
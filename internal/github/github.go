@@ -0,0 +1,204 @@
+// Package github is a minimal GitHub REST client for reporting test runs as
+// check runs and commit statuses.
+package github
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// DefaultAPIURL is the REST endpoint of github.com
+const DefaultAPIURL = "https://api.github.com"
+
+// MaxAnnotations is the number of annotations GitHub accepts per request;
+// CreateCheckRun sends the rest in follow-up updates
+const MaxAnnotations = 50
+
+// Check run conclusions
+const (
+	ConclusionSuccess = "success"
+	ConclusionFailure = "failure"
+	ConclusionNeutral = "neutral"
+)
+
+// Annotation levels
+const (
+	LevelNotice  = "notice"
+	LevelWarning = "warning"
+	LevelFailure = "failure"
+)
+
+// Commit status states
+const (
+	StateSuccess = "success"
+	StateFailure = "failure"
+	StateError   = "error"
+)
+
+// CheckRun is a completed check run
+type CheckRun struct {
+	Name        string     `json:"name"`
+	HeadSHA     string     `json:"head_sha"`
+	Status      string     `json:"status"`
+	Conclusion  string     `json:"conclusion"`
+	DetailsURL  string     `json:"details_url,omitempty"`
+	ExternalID  string     `json:"external_id,omitempty"`
+	StartedAt   *time.Time `json:"started_at,omitempty"`
+	CompletedAt *time.Time `json:"completed_at,omitempty"`
+	Output      *Output    `json:"output,omitempty"`
+}
+
+// Output is the summary shown on the check run page
+type Output struct {
+	Title       string       `json:"title"`
+	Summary     string       `json:"summary"` // Markdown, at most 65535 characters
+	Text        string       `json:"text,omitempty"`
+	Annotations []Annotation `json:"annotations,omitempty"`
+}
+
+// Annotation marks a line of a file in the pull request diff
+type Annotation struct {
+	Path            string `json:"path"`
+	StartLine       int    `json:"start_line"`
+	EndLine         int    `json:"end_line"`
+	AnnotationLevel string `json:"annotation_level"`
+	Title           string `json:"title,omitempty"`
+	Message         string `json:"message"`
+}
+
+// Status is a commit status
+type Status struct {
+	State       string `json:"state"`
+	TargetURL   string `json:"target_url,omitempty"`
+	Description string `json:"description,omitempty"` // truncated to 140 characters
+	Context     string `json:"context"`
+}
+
+// Client calls the GitHub REST API with a token
+type Client struct {
+	BaseURL    string
+	Token      string
+	HTTPClient *http.Client
+}
+
+// NewClient creates a client; an empty baseURL uses DefaultAPIURL
+func NewClient(baseURL, token string) *Client {
+	if baseURL == "" {
+		baseURL = DefaultAPIURL
+	}
+	return &Client{
+		BaseURL:    strings.TrimRight(baseURL, "/"),
+		Token:      token,
+		HTTPClient: &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+// PullRequestHead returns the head commit SHA of a pull request
+func (c *Client) PullRequestHead(ctx context.Context, repo string, number int) (string, error) {
+	var pr struct {
+		Head struct {
+			SHA string `json:"sha"`
+		} `json:"head"`
+	}
+	if err := c.do(ctx, http.MethodGet, fmt.Sprintf("/repos/%s/pulls/%d", repo, number), nil, &pr); err != nil {
+		return "", err
+	}
+	if pr.Head.SHA == "" {
+		return "", fmt.Errorf("github: pull request %s#%d has no head commit", repo, number)
+	}
+	return pr.Head.SHA, nil
+}
+
+// CreateCheckRun creates a check run and returns its HTML URL. Annotations
+// beyond MaxAnnotations are added by updating the run in batches.
+func (c *Client) CreateCheckRun(ctx context.Context, repo string, run CheckRun) (string, error) {
+	var rest []Annotation
+	if run.Output != nil && len(run.Output.Annotations) > MaxAnnotations {
+		output := *run.Output
+		rest = output.Annotations[MaxAnnotations:]
+		output.Annotations = output.Annotations[:MaxAnnotations]
+		run.Output = &output
+	}
+
+	var created struct {
+		ID      int64  `json:"id"`
+		HTMLURL string `json:"html_url"`
+	}
+	if err := c.do(ctx, http.MethodPost, "/repos/"+repo+"/check-runs", run, &created); err != nil {
+		return "", err
+	}
+
+	for len(rest) > 0 {
+		n := min(len(rest), MaxAnnotations)
+		update := map[string]interface{}{"output": Output{
+			Title:       run.Output.Title,
+			Summary:     run.Output.Summary,
+			Annotations: rest[:n],
+		}}
+		if err := c.do(ctx, http.MethodPatch, fmt.Sprintf("/repos/%s/check-runs/%d", repo, created.ID), update, nil); err != nil {
+			return created.HTMLURL, fmt.Errorf("check run created but adding annotations failed: %w", err)
+		}
+		rest = rest[n:]
+	}
+	return created.HTMLURL, nil
+}
+
+// CreateStatus sets a commit status
+func (c *Client) CreateStatus(ctx context.Context, repo, sha string, status Status) error {
+	if len(status.Description) > 140 {
+		status.Description = status.Description[:137] + "..."
+	}
+	return c.do(ctx, http.MethodPost, fmt.Sprintf("/repos/%s/statuses/%s", repo, sha), status, nil)
+}
+
+func (c *Client) do(ctx context.Context, method, path string, body, out interface{}) error {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return fmt.Errorf("github: failed to encode request: %w", err)
+		}
+		reader = bytes.NewReader(data)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, c.BaseURL+path, reader)
+	if err != nil {
+		return fmt.Errorf("github: failed to build request: %w", err)
+	}
+	req.Header.Set("Accept", "application/vnd.github+json")
+	req.Header.Set("X-GitHub-Api-Version", "2022-11-28")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if c.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.Token)
+	}
+
+	resp, err := c.HTTPClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("github: %s %s: %w", method, path, err)
+	}
+	defer resp.Body.Close()
+	data, _ := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		var apiErr struct {
+			Message string `json:"message"`
+		}
+		if json.Unmarshal(data, &apiErr) == nil && apiErr.Message != "" {
+			return fmt.Errorf("github: %s %s: status %d: %s", method, path, resp.StatusCode, apiErr.Message)
+		}
+		return fmt.Errorf("github: %s %s: status %d", method, path, resp.StatusCode)
+	}
+	if out != nil && len(data) > 0 {
+		if err := json.Unmarshal(data, out); err != nil {
+			return fmt.Errorf("github: failed to decode response: %w", err)
+		}
+	}
+	return nil
+}
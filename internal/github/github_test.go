@@ -0,0 +1,94 @@
+package github
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type request struct {
+	Method, Path, Auth string
+	Body               map[string]interface{}
+}
+
+// fakeGitHub records requests and answers them with handler
+func fakeGitHub(t *testing.T, handler func(w http.ResponseWriter, r *http.Request)) (*Client, *[]request) {
+	var requests []request
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		req := request{Method: r.Method, Path: r.URL.Path, Auth: r.Header.Get("Authorization")}
+		_ = json.NewDecoder(r.Body).Decode(&req.Body)
+		requests = append(requests, req)
+		handler(w, r)
+	}))
+	t.Cleanup(server.Close)
+	return NewClient(server.URL+"/", "secret"), &requests
+}
+
+// TestClient_CreateCheckRun tests annotation batching
+func TestClient_CreateCheckRun(t *testing.T) {
+	client, requests := fakeGitHub(t, func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"id":7,"html_url":"https://github.com/acme/shop/runs/7"}`)
+	})
+
+	annotations := make([]Annotation, 120)
+	for i := range annotations {
+		annotations[i] = Annotation{Path: "panoptic.yaml", StartLine: i + 1, EndLine: i + 1, AnnotationLevel: LevelFailure, Message: "failed"}
+	}
+	url, err := client.CreateCheckRun(context.Background(), "acme/shop", CheckRun{
+		Name: "Panoptic", HeadSHA: "abc", Status: "completed", Conclusion: ConclusionFailure,
+		Output: &Output{Title: "1 failed", Summary: "s", Annotations: annotations},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "https://github.com/acme/shop/runs/7", url)
+
+	require.Len(t, *requests, 3)
+	create := (*requests)[0]
+	assert.Equal(t, "POST /repos/acme/shop/check-runs", create.Method+" "+create.Path)
+	assert.Equal(t, "Bearer secret", create.Auth)
+	assert.Equal(t, "abc", create.Body["head_sha"])
+	assert.NotContains(t, create.Body, "started_at", "unset times are omitted")
+	assert.Len(t, create.Body["output"].(map[string]interface{})["annotations"], MaxAnnotations)
+
+	update := (*requests)[2]
+	assert.Equal(t, "PATCH /repos/acme/shop/check-runs/7", update.Method+" "+update.Path)
+	assert.Len(t, update.Body["output"].(map[string]interface{})["annotations"], 20)
+	assert.Len(t, annotations, 120, "the caller's annotations are not truncated")
+}
+
+// TestClient_CreateStatus tests description truncation
+func TestClient_CreateStatus(t *testing.T) {
+	client, requests := fakeGitHub(t, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusCreated)
+	})
+	require.NoError(t, client.CreateStatus(context.Background(), "acme/shop", "abc", Status{
+		State: StateFailure, Context: "Panoptic", Description: strings.Repeat("x", 200),
+	}))
+	require.Len(t, *requests, 1)
+	assert.Equal(t, "/repos/acme/shop/statuses/abc", (*requests)[0].Path)
+	assert.Len(t, (*requests)[0].Body["description"], 140)
+}
+
+// TestClient_PullRequestHead tests head resolution and API errors
+func TestClient_PullRequestHead(t *testing.T) {
+	client, _ := fakeGitHub(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/repos/acme/shop/pulls/3" {
+			fmt.Fprint(w, `{"head":{"sha":"def"}}`)
+			return
+		}
+		w.WriteHeader(http.StatusNotFound)
+		fmt.Fprint(w, `{"message":"Not Found"}`)
+	})
+	sha, err := client.PullRequestHead(context.Background(), "acme/shop", 3)
+	require.NoError(t, err)
+	assert.Equal(t, "def", sha)
+
+	_, err = client.PullRequestHead(context.Background(), "acme/shop", 4)
+	assert.EqualError(t, err, "github: GET /repos/acme/shop/pulls/4: status 404: Not Found")
+}
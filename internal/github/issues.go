@@ -0,0 +1,59 @@
+package github
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// maxIssuePages bounds the open issues FindIssue looks through
+const maxIssuePages = 10
+
+// Issue is a GitHub issue
+type Issue struct {
+	Number  int    `json:"number"`
+	HTMLURL string `json:"html_url"`
+	Body    string `json:"body"`
+}
+
+// FindIssue returns the first open issue with label whose body contains
+// marker, or nil when there is none
+func (c *Client) FindIssue(ctx context.Context, repo, label, marker string) (*Issue, error) {
+	for page := 1; page <= maxIssuePages; page++ {
+		query := url.Values{"state": {"open"}, "labels": {label}, "per_page": {"100"}, "page": {fmt.Sprint(page)}}
+		var issues []struct {
+			Issue
+			PullRequest *struct{} `json:"pull_request"`
+		}
+		if err := c.do(ctx, http.MethodGet, "/repos/"+repo+"/issues?"+query.Encode(), nil, &issues); err != nil {
+			return nil, err
+		}
+		for _, issue := range issues {
+			if issue.PullRequest == nil && strings.Contains(issue.Body, marker) {
+				found := issue.Issue
+				return &found, nil
+			}
+		}
+		if len(issues) < 100 {
+			break
+		}
+	}
+	return nil, nil
+}
+
+// CreateIssue opens an issue
+func (c *Client) CreateIssue(ctx context.Context, repo, title, body string, labels []string) (*Issue, error) {
+	var issue Issue
+	request := map[string]interface{}{"title": title, "body": body, "labels": labels}
+	if err := c.do(ctx, http.MethodPost, "/repos/"+repo+"/issues", request, &issue); err != nil {
+		return nil, err
+	}
+	return &issue, nil
+}
+
+// CommentIssue adds a comment to an issue
+func (c *Client) CommentIssue(ctx context.Context, repo string, number int, body string) error {
+	return c.do(ctx, http.MethodPost, fmt.Sprintf("/repos/%s/issues/%d/comments", repo, number), map[string]string{"body": body}, nil)
+}
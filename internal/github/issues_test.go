@@ -0,0 +1,55 @@
+package github
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestClient_FindIssue tests marker matching, pull request skipping and paging
+func TestClient_FindIssue(t *testing.T) {
+	client, requests := fakeGitHub(t, func(w http.ResponseWriter, r *http.Request) {
+		var page []map[string]interface{}
+		if r.URL.Query().Get("page") == "1" {
+			for i := 0; i < 100; i++ {
+				page = append(page, map[string]interface{}{"number": i, "body": "other"})
+			}
+			page[5] = map[string]interface{}{"number": 5, "body": "<!-- fp:abc -->", "pull_request": map[string]string{}}
+		} else {
+			page = []map[string]interface{}{{"number": 101, "html_url": "https://github.com/acme/shop/issues/101", "body": "x <!-- fp:abc -->"}}
+		}
+		_ = json.NewEncoder(w).Encode(page)
+	})
+
+	issue, err := client.FindIssue(context.Background(), "acme/shop", "panoptic", "<!-- fp:abc -->")
+	require.NoError(t, err)
+	require.NotNil(t, issue)
+	assert.Equal(t, 101, issue.Number)
+	require.Len(t, *requests, 2)
+	assert.Contains(t, (*requests)[0].Path, "/repos/acme/shop/issues")
+
+	issue, err = client.FindIssue(context.Background(), "acme/shop", "panoptic", "<!-- fp:none -->")
+	require.NoError(t, err)
+	assert.Nil(t, issue)
+}
+
+// TestClient_CreateAndCommentIssue tests issue creation and comments
+func TestClient_CreateAndCommentIssue(t *testing.T) {
+	client, requests := fakeGitHub(t, func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"number":3,"html_url":"https://github.com/acme/shop/issues/3"}`)
+	})
+	issue, err := client.CreateIssue(context.Background(), "acme/shop", "title", "body", []string{"panoptic"})
+	require.NoError(t, err)
+	assert.Equal(t, 3, issue.Number)
+	require.NoError(t, client.CommentIssue(context.Background(), "acme/shop", 3, "again"))
+
+	require.Len(t, *requests, 2)
+	assert.Equal(t, []interface{}{"panoptic"}, (*requests)[0].Body["labels"])
+	assert.Equal(t, "/repos/acme/shop/issues/3/comments", (*requests)[1].Path)
+	assert.Equal(t, "again", (*requests)[1].Body["body"])
+}
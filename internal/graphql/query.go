@@ -0,0 +1,369 @@
+// Package graphql parses GraphQL queries, reads the schemas endpoints
+// describe by introspection and checks responses against them: the fields
+// a query selects exist, and the values returned have their types.
+package graphql
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Document is a parsed GraphQL query document
+type Document struct {
+	Operations []*Operation
+	Fragments  map[string]*Fragment
+}
+
+// Operation is a query, mutation or subscription of a document
+type Operation struct {
+	Type       string // query, mutation or subscription
+	Name       string
+	Selections []Selection
+}
+
+// Fragment is a named fragment of a document
+type Fragment struct {
+	Name       string
+	On         string // the type condition
+	Selections []Selection
+}
+
+// Selection is a field, a fragment spread or an inline fragment of a
+// selection set
+type Selection struct {
+	Alias       string
+	Name        string // the field's name
+	Spread      string // the fragment spread, for "...Name"
+	Inline      bool   // an inline fragment, "... on Type { }"
+	On          string // the inline fragment's type condition, if any
+	Conditional bool   // has @skip or @include, so it may be missing from responses
+	Selections  []Selection
+}
+
+// Key is the field's name in responses: its alias, else its name
+func (s Selection) Key() string {
+	if s.Alias != "" {
+		return s.Alias
+	}
+	return s.Name
+}
+
+// Operation returns the operation of the document a request runs: the one
+// named, or its only one
+func (d *Document) Operation(name string) (*Operation, error) {
+	if name == "" {
+		if len(d.Operations) != 1 {
+			return nil, fmt.Errorf("the document has %d operations; name the one to run", len(d.Operations))
+		}
+		return d.Operations[0], nil
+	}
+	for _, op := range d.Operations {
+		if op.Name == name {
+			return op, nil
+		}
+	}
+	return nil, fmt.Errorf("the document has no operation named %q", name)
+}
+
+type tokenKind int
+
+const (
+	tokenPunct tokenKind = iota
+	tokenName
+	tokenValue // a string or number
+)
+
+type token struct {
+	kind  tokenKind
+	value string
+	pos   int
+}
+
+// lex splits a document into its tokens, dropping whitespace, commas and
+// comments
+func lex(src string) ([]token, error) {
+	var tokens []token
+	for i := 0; i < len(src); {
+		c := src[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == ',':
+			i++
+		case c == '#':
+			for i < len(src) && src[i] != '\n' {
+				i++
+			}
+		case strings.HasPrefix(src[i:], "..."):
+			tokens = append(tokens, token{tokenPunct, "...", i})
+			i += 3
+		case strings.ContainsRune("!$&()-:=@[]{}|", rune(c)) && !(c == '-' && i+1 < len(src) && isDigit(src[i+1])):
+			tokens = append(tokens, token{tokenPunct, string(c), i})
+			i++
+		case c == '_' || isLetter(c):
+			start := i
+			for i < len(src) && (src[i] == '_' || isLetter(src[i]) || isDigit(src[i])) {
+				i++
+			}
+			tokens = append(tokens, token{tokenName, src[start:i], start})
+		case c == '-' || isDigit(c):
+			start := i
+			i++
+			for i < len(src) && (isDigit(src[i]) || strings.ContainsRune(".eE+-", rune(src[i]))) {
+				i++
+			}
+			tokens = append(tokens, token{tokenValue, src[start:i], start})
+		case strings.HasPrefix(src[i:], `"""`):
+			end := strings.Index(src[i+3:], `"""`)
+			for end >= 0 && src[i+3+end-1] == '\\' {
+				next := strings.Index(src[i+3+end+3:], `"""`)
+				if next < 0 {
+					end = -1
+					break
+				}
+				end += 3 + next
+			}
+			if end < 0 {
+				return nil, fmt.Errorf("unterminated block string at offset %d", i)
+			}
+			tokens = append(tokens, token{tokenValue, src[i : i+3+end+3], i})
+			i += 3 + end + 3
+		case c == '"':
+			start := i
+			i++
+			for i < len(src) && src[i] != '"' {
+				if src[i] == '\\' {
+					i++
+				}
+				if i < len(src) && src[i] == '\n' {
+					return nil, fmt.Errorf("unterminated string at offset %d", start)
+				}
+				i++
+			}
+			if i >= len(src) {
+				return nil, fmt.Errorf("unterminated string at offset %d", start)
+			}
+			i++
+			tokens = append(tokens, token{tokenValue, src[start:i], start})
+		default:
+			return nil, fmt.Errorf("unexpected character %q at offset %d", c, i)
+		}
+	}
+	return tokens, nil
+}
+
+func isLetter(c byte) bool { return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' }
+
+func isDigit(c byte) bool { return c >= '0' && c <= '9' }
+
+// parser reads a document from its tokens
+type parser struct {
+	tokens []token
+	pos    int
+}
+
+// ParseQuery parses a query document: its operations, fragments and the
+// fields they select. Arguments, variables and directives are skipped,
+// apart from noting @skip and @include.
+func ParseQuery(src string) (*Document, error) {
+	tokens, err := lex(src)
+	if err != nil {
+		return nil, err
+	}
+	p := &parser{tokens: tokens}
+	doc := &Document{Fragments: make(map[string]*Fragment)}
+	for !p.done() {
+		switch t := p.peek(); {
+		case t.value == "{":
+			sels, err := p.selectionSet()
+			if err != nil {
+				return nil, err
+			}
+			doc.Operations = append(doc.Operations, &Operation{Type: "query", Selections: sels})
+		case t.kind == tokenName && (t.value == "query" || t.value == "mutation" || t.value == "subscription"):
+			p.pos++
+			op := &Operation{Type: t.value}
+			if p.peek().kind == tokenName {
+				op.Name = p.next().value
+			}
+			if p.peek().value == "(" {
+				if err := p.skipBalanced("(", ")"); err != nil {
+					return nil, err
+				}
+			}
+			if _, err := p.directives(); err != nil {
+				return nil, err
+			}
+			if op.Selections, err = p.selectionSet(); err != nil {
+				return nil, err
+			}
+			doc.Operations = append(doc.Operations, op)
+		case t.kind == tokenName && t.value == "fragment":
+			p.pos++
+			name, err := p.name()
+			if err != nil {
+				return nil, err
+			}
+			if on := p.next(); on.value != "on" {
+				return nil, p.errorAt(on, "expected on")
+			}
+			frag := &Fragment{Name: name}
+			if frag.On, err = p.name(); err != nil {
+				return nil, err
+			}
+			if _, err := p.directives(); err != nil {
+				return nil, err
+			}
+			if frag.Selections, err = p.selectionSet(); err != nil {
+				return nil, err
+			}
+			doc.Fragments[name] = frag
+		default:
+			return nil, p.errorAt(t, "expected an operation or fragment")
+		}
+	}
+	if len(doc.Operations) == 0 {
+		return nil, fmt.Errorf("the document has no operation")
+	}
+	return doc, nil
+}
+
+func (p *parser) done() bool { return p.pos >= len(p.tokens) }
+
+func (p *parser) peek() token {
+	if p.done() {
+		return token{kind: tokenPunct, pos: -1}
+	}
+	return p.tokens[p.pos]
+}
+
+func (p *parser) next() token {
+	t := p.peek()
+	p.pos++
+	return t
+}
+
+func (p *parser) errorAt(t token, msg string) error {
+	if t.pos < 0 {
+		return fmt.Errorf("%s at the end of the document", msg)
+	}
+	return fmt.Errorf("%s at offset %d, found %q", msg, t.pos, t.value)
+}
+
+func (p *parser) name() (string, error) {
+	t := p.next()
+	if t.kind != tokenName {
+		return "", p.errorAt(t, "expected a name")
+	}
+	return t.value, nil
+}
+
+// skipBalanced skips from an open token to its close, as arguments and
+// variable definitions are
+func (p *parser) skipBalanced(open, close string) error {
+	start := p.next()
+	depth := 1
+	for depth > 0 {
+		if p.done() {
+			return p.errorAt(token{pos: -1}, fmt.Sprintf("unclosed %s opened at offset %d", open, start.pos))
+		}
+		switch t := p.next(); {
+		case t.kind == tokenPunct && t.value == open:
+			depth++
+		case t.kind == tokenPunct && t.value == close:
+			depth--
+		}
+	}
+	return nil
+}
+
+// directives skips the directives at the position, telling whether one is
+// @skip or @include
+func (p *parser) directives() (bool, error) {
+	conditional := false
+	for p.peek().value == "@" {
+		p.pos++
+		name, err := p.name()
+		if err != nil {
+			return false, err
+		}
+		conditional = conditional || name == "skip" || name == "include"
+		if p.peek().value == "(" {
+			if err := p.skipBalanced("(", ")"); err != nil {
+				return false, err
+			}
+		}
+	}
+	return conditional, nil
+}
+
+func (p *parser) selectionSet() ([]Selection, error) {
+	if t := p.next(); t.value != "{" {
+		return nil, p.errorAt(t, "expected {")
+	}
+	var sels []Selection
+	for p.peek().value != "}" {
+		if p.done() {
+			return nil, p.errorAt(p.peek(), "unclosed {")
+		}
+		sel, err := p.selection()
+		if err != nil {
+			return nil, err
+		}
+		sels = append(sels, sel)
+	}
+	p.pos++
+	if len(sels) == 0 {
+		return nil, fmt.Errorf("empty selection set")
+	}
+	return sels, nil
+}
+
+func (p *parser) selection() (Selection, error) {
+	var sel Selection
+	var err error
+	if p.peek().value == "..." {
+		p.pos++
+		t := p.peek()
+		switch {
+		case t.kind == tokenName && t.value == "on":
+			p.pos++
+			if sel.On, err = p.name(); err != nil {
+				return sel, err
+			}
+			sel.Inline = true
+		case t.kind == tokenName:
+			sel.Spread = p.next().value
+			sel.Conditional, err = p.directives()
+			return sel, err
+		default:
+			sel.Inline = true
+		}
+		if sel.Conditional, err = p.directives(); err != nil {
+			return sel, err
+		}
+		sel.Selections, err = p.selectionSet()
+		return sel, err
+	}
+
+	if sel.Name, err = p.name(); err != nil {
+		return sel, err
+	}
+	if p.peek().value == ":" {
+		p.pos++
+		sel.Alias = sel.Name
+		if sel.Name, err = p.name(); err != nil {
+			return sel, err
+		}
+	}
+	if p.peek().value == "(" {
+		if err := p.skipBalanced("(", ")"); err != nil {
+			return sel, err
+		}
+	}
+	if sel.Conditional, err = p.directives(); err != nil {
+		return sel, err
+	}
+	if p.peek().value == "{" {
+		sel.Selections, err = p.selectionSet()
+	}
+	return sel, err
+}
@@ -0,0 +1,75 @@
+package graphql
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseQuery(t *testing.T) {
+	doc, err := ParseQuery(`
+		# an order and its items
+		query Order($id: ID!, $first: Int = 10) @cached(ttl: 60) {
+			order(id: $id) {
+				id
+				state: status
+				items(first: $first, filter: {sku: "a)b", tags: ["x", "y"]}) { sku price }
+				...Customer
+				... on DigitalOrder { downloadUrl }
+				note @include(if: false)
+			}
+		}
+		mutation Cancel { cancelOrder(id: "1", reason: """won't "ship" """) { id } }
+		fragment Customer on Order { customer { name } }
+	`)
+	require.NoError(t, err)
+	require.Len(t, doc.Operations, 2)
+	op := doc.Operations[0]
+	assert.Equal(t, "query", op.Type)
+	assert.Equal(t, "Order", op.Name)
+	require.Len(t, op.Selections, 1)
+	order := op.Selections[0]
+	assert.Equal(t, "order", order.Key())
+	require.Len(t, order.Selections, 6)
+	assert.Equal(t, Selection{Alias: "state", Name: "status"}, order.Selections[1])
+	assert.Equal(t, "state", order.Selections[1].Key())
+	assert.Equal(t, []Selection{{Name: "sku"}, {Name: "price"}}, order.Selections[2].Selections)
+	assert.Equal(t, Selection{Spread: "Customer"}, order.Selections[3])
+	assert.Equal(t, "DigitalOrder", order.Selections[4].On)
+	assert.True(t, order.Selections[4].Inline)
+	assert.True(t, order.Selections[5].Conditional)
+	assert.Equal(t, "mutation", doc.Operations[1].Type)
+	assert.Equal(t, "Order", doc.Fragments["Customer"].On)
+
+	got, err := doc.Operation("Cancel")
+	require.NoError(t, err)
+	assert.Equal(t, "cancelOrder", got.Selections[0].Name)
+	_, err = doc.Operation("")
+	assert.EqualError(t, err, "the document has 2 operations; name the one to run")
+	_, err = doc.Operation("Refund")
+	assert.EqualError(t, err, `the document has no operation named "Refund"`)
+
+	doc, err = ParseQuery(`{ me { id } }`)
+	require.NoError(t, err)
+	got, err = doc.Operation("")
+	require.NoError(t, err)
+	assert.Equal(t, "query", got.Type)
+}
+
+func TestParseQuery_Errors(t *testing.T) {
+	cases := map[string]string{
+		``:                        "the document has no operation",
+		`{ me { id }`:             "unclosed { at the end of the document",
+		`{ me { } }`:              "empty selection set",
+		`query { me(id: "1) }`:    "unterminated string at offset 15",
+		`select orders`:           `expected an operation or fragment at offset 0, found "select"`,
+		`{ me(id: 1 { id } }`:     "unclosed ( opened at offset 4 at the end of the document",
+		`fragment F Order { id }`: `expected on at offset 11, found "Order"`,
+		`{ me % }`:                `unexpected character '%' at offset 5`,
+	}
+	for src, want := range cases {
+		_, err := ParseQuery(src)
+		assert.EqualError(t, err, want, src)
+	}
+}
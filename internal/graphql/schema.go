@@ -0,0 +1,219 @@
+package graphql
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// IntrospectionQuery asks an endpoint for the types of its schema, with
+// what's needed to check responses: fields, their types, enum values and
+// the types of interfaces and unions
+const IntrospectionQuery = `query IntrospectionQuery {
+  __schema {
+    queryType { name }
+    mutationType { name }
+    subscriptionType { name }
+    types {
+      kind
+      name
+      fields(includeDeprecated: true) { name type { ...TypeRef } }
+      enumValues(includeDeprecated: true) { name }
+      possibleTypes { name }
+    }
+  }
+}
+fragment TypeRef on __Type {
+  kind name
+  ofType { kind name ofType { kind name ofType { kind name ofType { kind name
+    ofType { kind name ofType { kind name ofType { kind name } } } } } } }
+}`
+
+// Type kinds, as introspection reports them
+const (
+	KindScalar      = "SCALAR"
+	KindObject      = "OBJECT"
+	KindInterface   = "INTERFACE"
+	KindUnion       = "UNION"
+	KindEnum        = "ENUM"
+	KindInputObject = "INPUT_OBJECT"
+	KindList        = "LIST"
+	KindNonNull     = "NON_NULL"
+)
+
+// Schema is the schema of an endpoint, read from its introspection
+type Schema struct {
+	Query        string // the root type names
+	Mutation     string
+	Subscription string
+	Types        map[string]*Type
+}
+
+// Type is a named type of a schema
+type Type struct {
+	Kind          string
+	Name          string
+	Fields        map[string]*TypeRef // of objects and interfaces: the field's type
+	EnumValues    map[string]bool
+	PossibleTypes map[string]bool // of interfaces and unions: the object types they can be
+}
+
+// TypeRef is a field's type: a named type, or a list or non-null wrapping
+// of one
+type TypeRef struct {
+	Kind   string   `json:"kind"`
+	Name   string   `json:"name"`
+	OfType *TypeRef `json:"ofType"`
+}
+
+// String writes the type in GraphQL's notation, e.g. [Order!]!
+func (r *TypeRef) String() string {
+	switch {
+	case r == nil:
+		return "?"
+	case r.Kind == KindNonNull:
+		return r.OfType.String() + "!"
+	case r.Kind == KindList:
+		return "[" + r.OfType.String() + "]"
+	}
+	return r.Name
+}
+
+type introspection struct {
+	Schema *struct {
+		QueryType        *struct{ Name string } `json:"queryType"`
+		MutationType     *struct{ Name string } `json:"mutationType"`
+		SubscriptionType *struct{ Name string } `json:"subscriptionType"`
+		Types            []struct {
+			Kind   string `json:"kind"`
+			Name   string `json:"name"`
+			Fields []struct {
+				Name string   `json:"name"`
+				Type *TypeRef `json:"type"`
+			} `json:"fields"`
+			EnumValues    []struct{ Name string } `json:"enumValues"`
+			PossibleTypes []struct{ Name string } `json:"possibleTypes"`
+		} `json:"types"`
+	} `json:"__schema"`
+}
+
+// ParseIntrospection reads a schema from the response to IntrospectionQuery:
+// the whole response, its data, or the __schema inside
+func ParseIntrospection(data []byte) (*Schema, error) {
+	var wrapped struct {
+		Data   *json.RawMessage `json:"data"`
+		Errors []Error          `json:"errors"`
+	}
+	if err := json.Unmarshal(data, &wrapped); err != nil {
+		return nil, fmt.Errorf("invalid introspection result: %w", err)
+	}
+	if len(wrapped.Errors) > 0 {
+		return nil, fmt.Errorf("introspection failed: %s", JoinErrors(wrapped.Errors))
+	}
+	if wrapped.Data != nil {
+		data = *wrapped.Data
+	}
+	var result introspection
+	if err := json.Unmarshal(data, &result); err != nil {
+		return nil, fmt.Errorf("invalid introspection result: %w", err)
+	}
+	if result.Schema == nil {
+		if err := json.Unmarshal([]byte(`{"__schema":`+string(data)+`}`), &result); err != nil || result.Schema == nil || len(result.Schema.Types) == 0 {
+			return nil, fmt.Errorf("invalid introspection result: no __schema")
+		}
+	}
+
+	s := &Schema{Types: make(map[string]*Type, len(result.Schema.Types))}
+	if result.Schema.QueryType != nil {
+		s.Query = result.Schema.QueryType.Name
+	}
+	if result.Schema.MutationType != nil {
+		s.Mutation = result.Schema.MutationType.Name
+	}
+	if result.Schema.SubscriptionType != nil {
+		s.Subscription = result.Schema.SubscriptionType.Name
+	}
+	for _, t := range result.Schema.Types {
+		typ := &Type{Kind: t.Kind, Name: t.Name}
+		if len(t.Fields) > 0 {
+			typ.Fields = make(map[string]*TypeRef, len(t.Fields))
+			for _, f := range t.Fields {
+				typ.Fields[f.Name] = f.Type
+			}
+		}
+		if len(t.EnumValues) > 0 {
+			typ.EnumValues = make(map[string]bool, len(t.EnumValues))
+			for _, v := range t.EnumValues {
+				typ.EnumValues[v.Name] = true
+			}
+		}
+		if len(t.PossibleTypes) > 0 {
+			typ.PossibleTypes = make(map[string]bool, len(t.PossibleTypes))
+			for _, p := range t.PossibleTypes {
+				typ.PossibleTypes[p.Name] = true
+			}
+		}
+		s.Types[t.Name] = typ
+	}
+	if s.Query == "" {
+		return nil, fmt.Errorf("invalid introspection result: no query type")
+	}
+	return s, nil
+}
+
+// LoadSchema reads a schema from a file holding an introspection result,
+// for endpoints that have introspection turned off
+func LoadSchema(path string) (*Schema, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read schema: %w", err)
+	}
+	s, err := ParseIntrospection(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return s, nil
+}
+
+// Root is the type an operation of the kind starts from
+func (s *Schema) Root(operation string) (*Type, error) {
+	name := s.Query
+	switch operation {
+	case "mutation":
+		name = s.Mutation
+	case "subscription":
+		name = s.Subscription
+	}
+	if t := s.Types[name]; name != "" && t != nil {
+		return t, nil
+	}
+	return nil, fmt.Errorf("the schema has no %s type", operation)
+}
+
+// Error is an entry of a response's errors
+type Error struct {
+	Message string        `json:"message"`
+	Path    []interface{} `json:"path,omitempty"`
+}
+
+// String is the error's message, after its path when it has one
+func (e Error) String() string {
+	if len(e.Path) == 0 {
+		return e.Message
+	}
+	parts := make([]string, len(e.Path))
+	for i, p := range e.Path {
+		parts[i] = fmt.Sprint(p)
+	}
+	return strings.Join(parts, ".") + ": " + e.Message
+}
+
+// JoinErrors lists a response's errors
+func JoinErrors(errs []Error) string {
+	msgs := make([]string, len(errs))
+	for i, e := range errs {
+		msgs[i] = e.String()
+	}
+	return strings.Join(msgs, "; ")
+}
@@ -0,0 +1,91 @@
+package graphql
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// shopIntrospection is the introspection result of a small shop API
+const shopIntrospection = `{"data": {"__schema": {
+	"queryType": {"name": "Query"},
+	"mutationType": {"name": "Mutation"},
+	"subscriptionType": null,
+	"types": [
+		{"kind": "OBJECT", "name": "Query", "fields": [
+			{"name": "order", "type": {"kind": "OBJECT", "name": "Order"}},
+			{"name": "orders", "type": {"kind": "NON_NULL", "ofType": {"kind": "LIST", "ofType": {"kind": "NON_NULL", "ofType": {"kind": "OBJECT", "name": "Order"}}}}},
+			{"name": "search", "type": {"kind": "LIST", "ofType": {"kind": "UNION", "name": "SearchResult"}}},
+			{"name": "node", "type": {"kind": "INTERFACE", "name": "Node"}}
+		]},
+		{"kind": "OBJECT", "name": "Mutation", "fields": [
+			{"name": "cancelOrder", "type": {"kind": "NON_NULL", "ofType": {"kind": "OBJECT", "name": "Order"}}}
+		]},
+		{"kind": "OBJECT", "name": "Order", "fields": [
+			{"name": "id", "type": {"kind": "NON_NULL", "ofType": {"kind": "SCALAR", "name": "ID"}}},
+			{"name": "status", "type": {"kind": "NON_NULL", "ofType": {"kind": "ENUM", "name": "OrderStatus"}}},
+			{"name": "total", "type": {"kind": "SCALAR", "name": "Float"}},
+			{"name": "quantity", "type": {"kind": "SCALAR", "name": "Int"}},
+			{"name": "placedAt", "type": {"kind": "SCALAR", "name": "DateTime"}}
+		]},
+		{"kind": "OBJECT", "name": "Product", "fields": [
+			{"name": "id", "type": {"kind": "NON_NULL", "ofType": {"kind": "SCALAR", "name": "ID"}}},
+			{"name": "title", "type": {"kind": "SCALAR", "name": "String"}},
+			{"name": "inStock", "type": {"kind": "SCALAR", "name": "Boolean"}}
+		]},
+		{"kind": "INTERFACE", "name": "Node", "fields": [
+			{"name": "id", "type": {"kind": "NON_NULL", "ofType": {"kind": "SCALAR", "name": "ID"}}}
+		], "possibleTypes": [{"name": "Order"}, {"name": "Product"}]},
+		{"kind": "UNION", "name": "SearchResult", "possibleTypes": [{"name": "Order"}, {"name": "Product"}]},
+		{"kind": "ENUM", "name": "OrderStatus", "enumValues": [{"name": "PENDING"}, {"name": "PAID"}, {"name": "SHIPPED"}]},
+		{"kind": "SCALAR", "name": "ID"},
+		{"kind": "SCALAR", "name": "String"},
+		{"kind": "SCALAR", "name": "Int"},
+		{"kind": "SCALAR", "name": "Float"},
+		{"kind": "SCALAR", "name": "Boolean"},
+		{"kind": "SCALAR", "name": "DateTime"}
+	]
+}}}`
+
+func TestParseIntrospection(t *testing.T) {
+	s, err := ParseIntrospection([]byte(shopIntrospection))
+	require.NoError(t, err)
+	assert.Equal(t, "Query", s.Query)
+	assert.Equal(t, "Mutation", s.Mutation)
+	assert.Empty(t, s.Subscription)
+	assert.Equal(t, "[Order!]!", s.Types["Query"].Fields["orders"].String())
+	assert.True(t, s.Types["OrderStatus"].EnumValues["PAID"])
+	assert.True(t, s.Types["SearchResult"].PossibleTypes["Product"])
+
+	root, err := s.Root("mutation")
+	require.NoError(t, err)
+	assert.Equal(t, "Mutation", root.Name)
+	_, err = s.Root("subscription")
+	assert.EqualError(t, err, "the schema has no subscription type")
+
+	path := filepath.Join(t.TempDir(), "schema.json")
+	require.NoError(t, os.WriteFile(path, []byte(shopIntrospection), 0644))
+	loaded, err := LoadSchema(path)
+	require.NoError(t, err)
+	assert.Equal(t, s, loaded)
+}
+
+func TestParseIntrospection_Errors(t *testing.T) {
+	_, err := ParseIntrospection([]byte(`{"errors": [{"message": "introspection is disabled"}]}`))
+	assert.EqualError(t, err, "introspection failed: introspection is disabled")
+	_, err = ParseIntrospection([]byte(`{"data": {}}`))
+	assert.EqualError(t, err, "invalid introspection result: no __schema")
+	_, err = ParseIntrospection([]byte(`<html>`))
+	assert.ErrorContains(t, err, "invalid introspection result")
+}
+
+func TestJoinErrors(t *testing.T) {
+	errs := []Error{
+		{Message: "Not authorized", Path: []interface{}{"order", float64(0), "total"}},
+		{Message: "Rate limited"},
+	}
+	assert.Equal(t, "order.0.total: Not authorized; Rate limited", JoinErrors(errs))
+}
@@ -0,0 +1,279 @@
+package graphql
+
+import (
+	"fmt"
+	"math"
+)
+
+// Validate checks data, the data of a response to an operation of doc,
+// against the schema: the fields the operation selects exist on their
+// types, non-null fields aren't null, and values are of the field's type.
+// It returns the problems found, each after the path of the value, e.g.
+// "order.items[0].price: expected Float, got string".
+func (s *Schema) Validate(doc *Document, operation string, data interface{}) ([]string, error) {
+	op, err := doc.Operation(operation)
+	if err != nil {
+		return nil, err
+	}
+	root, err := s.Root(op.Type)
+	if err != nil {
+		return nil, err
+	}
+	v := &validator{schema: s, doc: doc}
+	if data == nil {
+		// the request failed before running; the query is still checked
+		v.checkSelections(root, op.Selections, "")
+		return v.problems, nil
+	}
+	obj, ok := data.(map[string]interface{})
+	if !ok {
+		return []string{fmt.Sprintf("data: expected an object, got %s", jsonKind(data))}, nil
+	}
+	v.object(root, op.Selections, obj, "")
+	return v.problems, nil
+}
+
+type validator struct {
+	schema   *Schema
+	doc      *Document
+	problems []string
+}
+
+func (v *validator) problem(path, format string, args ...interface{}) {
+	if path == "" {
+		path = "data"
+	}
+	v.problems = append(v.problems, path+": "+fmt.Sprintf(format, args...))
+}
+
+// field is a field selected on an object, with the type it's selected on
+type field struct {
+	Selection
+	on *Type
+}
+
+// fields flattens the selections made of an object of type t, whose
+// concrete type is known when concrete isn't empty, following fragments
+// whose type condition applies
+func (v *validator) fields(t *Type, concrete string, sels []Selection, path string, out []field) []field {
+	for _, sel := range sels {
+		switch {
+		case sel.Spread != "":
+			frag, ok := v.doc.Fragments[sel.Spread]
+			if !ok {
+				v.problem(path, "unknown fragment %s", sel.Spread)
+				continue
+			}
+			if on, ok := v.applies(frag.On, concrete, path); ok {
+				out = v.fields(on, concrete, frag.Selections, path, out)
+			}
+		case sel.Inline:
+			on := t
+			if sel.On != "" {
+				var ok bool
+				if on, ok = v.applies(sel.On, concrete, path); !ok {
+					continue
+				}
+			}
+			out = v.fields(on, concrete, sel.Selections, path, out)
+		default:
+			out = append(out, field{Selection: sel, on: t})
+		}
+	}
+	return out
+}
+
+// applies tells whether a fragment on the named type applies to an object
+// of the concrete type, returning the type its fields are selected on.
+// When the concrete type isn't known every fragment applies.
+func (v *validator) applies(name, concrete, path string) (*Type, bool) {
+	t, ok := v.schema.Types[name]
+	if !ok {
+		v.problem(path, "unknown type %s", name)
+		return nil, false
+	}
+	return t, concrete == "" || name == concrete || t.PossibleTypes[concrete]
+}
+
+// object checks an object of type t against the fields selected on it
+func (v *validator) object(t *Type, sels []Selection, obj map[string]interface{}, path string) {
+	concrete := ""
+	if t.Kind == KindObject {
+		concrete = t.Name
+	} else if name, ok := obj["__typename"].(string); ok {
+		if ct, ok := v.schema.Types[name]; !ok || !t.PossibleTypes[name] {
+			v.problem(join(path, "__typename"), "%s isn't a type of %s", name, t.Name)
+		} else {
+			t, concrete = ct, name
+		}
+	}
+	for _, f := range v.fields(t, concrete, sels, path, nil) {
+		key := f.Key()
+		value, present := obj[key]
+		if f.Name == "__typename" {
+			if _, ok := value.(string); present && !ok {
+				v.problem(join(path, key), "expected String, got %s", jsonKind(value))
+			}
+			continue
+		}
+		ref, known := f.on.Fields[f.Name]
+		if !known {
+			v.problem(join(path, key), "%s has no field %s", f.on.Name, f.Name)
+			continue
+		}
+		if !present {
+			// without __typename, fragments on the other types a union or
+			// interface can be apply too
+			if !f.Conditional && (concrete != "" || f.on == t) {
+				v.problem(join(path, key), "missing from the response")
+			}
+			continue
+		}
+		v.value(ref, f.Selections, value, join(path, key))
+	}
+}
+
+// value checks a field's value against its type
+func (v *validator) value(ref *TypeRef, sels []Selection, value interface{}, path string) {
+	if ref == nil {
+		return
+	}
+	switch ref.Kind {
+	case KindNonNull:
+		if value == nil {
+			v.problem(path, "is null, but %s is non-null", ref)
+			return
+		}
+		v.value(ref.OfType, sels, value, path)
+		return
+	case KindList:
+		if value == nil {
+			return
+		}
+		items, ok := value.([]interface{})
+		if !ok {
+			v.problem(path, "expected %s, got %s", ref, jsonKind(value))
+			return
+		}
+		for i, item := range items {
+			v.value(ref.OfType, sels, item, fmt.Sprintf("%s[%d]", path, i))
+		}
+		return
+	}
+	if value == nil {
+		return
+	}
+	t, ok := v.schema.Types[ref.Name]
+	if !ok {
+		v.problem(path, "unknown type %s", ref.Name)
+		return
+	}
+	switch t.Kind {
+	case KindObject, KindInterface, KindUnion:
+		if len(sels) == 0 {
+			v.problem(path, "%s needs a selection of its fields", t.Name)
+			return
+		}
+		obj, ok := value.(map[string]interface{})
+		if !ok {
+			v.problem(path, "expected %s, got %s", t.Name, jsonKind(value))
+			return
+		}
+		v.object(t, sels, obj, path)
+	case KindEnum, KindScalar:
+		if len(sels) > 0 {
+			v.problem(path, "%s has no fields to select", t.Name)
+			return
+		}
+	}
+	switch t.Kind {
+	case KindEnum:
+		s, ok := value.(string)
+		if !ok {
+			v.problem(path, "expected %s, got %s", t.Name, jsonKind(value))
+		} else if !t.EnumValues[s] {
+			v.problem(path, "%q isn't a value of %s", s, t.Name)
+		}
+	case KindScalar:
+		if !scalarMatches(t.Name, value) {
+			v.problem(path, "expected %s, got %s", t.Name, jsonKind(value))
+		}
+	}
+}
+
+// checkSelections checks the fields selected on t exist, for requests that
+// returned no data
+func (v *validator) checkSelections(t *Type, sels []Selection, path string) {
+	for _, f := range v.fields(t, "", sels, path, nil) {
+		if f.Name == "__typename" {
+			continue
+		}
+		ref, ok := f.on.Fields[f.Name]
+		if !ok {
+			if f.on.Kind == KindObject || f.on.Kind == KindInterface {
+				v.problem(join(path, f.Key()), "%s has no field %s", f.on.Name, f.Name)
+			}
+			continue
+		}
+		for ref.OfType != nil {
+			ref = ref.OfType
+		}
+		if next, ok := v.schema.Types[ref.Name]; ok && len(f.Selections) > 0 {
+			v.checkSelections(next, f.Selections, join(path, f.Key()))
+		}
+	}
+}
+
+// scalarMatches tells whether a JSON value is of a built-in scalar type;
+// custom scalars take any value
+func scalarMatches(scalar string, value interface{}) bool {
+	switch scalar {
+	case "Int":
+		n, ok := value.(float64)
+		return ok && n == math.Trunc(n) && n >= math.MinInt32 && n <= math.MaxInt32
+	case "Float":
+		_, ok := value.(float64)
+		return ok
+	case "String":
+		_, ok := value.(string)
+		return ok
+	case "Boolean":
+		_, ok := value.(bool)
+		return ok
+	case "ID":
+		switch n := value.(type) {
+		case string:
+			return true
+		case float64:
+			return n == math.Trunc(n)
+		}
+		return false
+	}
+	return true
+}
+
+// jsonKind names the kind of a decoded JSON value
+func jsonKind(value interface{}) string {
+	switch value.(type) {
+	case nil:
+		return "null"
+	case string:
+		return "string"
+	case float64:
+		return "number"
+	case bool:
+		return "boolean"
+	case []interface{}:
+		return "list"
+	case map[string]interface{}:
+		return "object"
+	}
+	return fmt.Sprintf("%T", value)
+}
+
+func join(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
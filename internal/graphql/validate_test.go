@@ -0,0 +1,95 @@
+package graphql
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func validate(t *testing.T, query, data string) []string {
+	t.Helper()
+	s, err := ParseIntrospection([]byte(shopIntrospection))
+	require.NoError(t, err)
+	doc, err := ParseQuery(query)
+	require.NoError(t, err)
+	var decoded interface{}
+	require.NoError(t, json.Unmarshal([]byte(data), &decoded))
+	problems, err := s.Validate(doc, "", decoded)
+	require.NoError(t, err)
+	return problems
+}
+
+func TestSchema_Validate(t *testing.T) {
+	query := `query { order(id: 1) { id state: status total quantity placedAt } orders { id } }`
+	assert.Empty(t, validate(t, query, `{
+		"order": {"id": "1", "state": "PAID", "total": 9.5, "quantity": 2, "placedAt": "2026-10-14T09:00:00Z"},
+		"orders": [{"id": 1}]
+	}`))
+	assert.Empty(t, validate(t, query, `{"order": null, "orders": []}`), "nullable fields may be null")
+
+	assert.Equal(t, []string{
+		`order.id: is null, but ID! is non-null`,
+		`order.state: "LOST" isn't a value of OrderStatus`,
+		`order.total: expected Float, got string`,
+		`order.quantity: expected Int, got number`,
+		`order.placedAt: missing from the response`,
+		`orders[0]: is null, but Order! is non-null`,
+		`orders[1].id: expected ID, got boolean`,
+	}, validate(t, query, `{
+		"order": {"id": null, "state": "LOST", "total": "9.50", "quantity": 2.5},
+		"orders": [null, {"id": true}]
+	}`))
+	assert.Equal(t, []string{"orders: expected [Order!], got object"}, validate(t, `{ orders { id } }`, `{"orders": {"id": "1"}}`))
+	assert.Equal(t, []string{"data: expected an object, got list"}, validate(t, `{ orders { id } }`, `[]`))
+}
+
+func TestSchema_Validate_Fragments(t *testing.T) {
+	query := `{
+		search {
+			__typename
+			... on Order { id status }
+			...ProductFields
+		}
+		node { id ... on Product { title } }
+	}
+	fragment ProductFields on Product { title inStock }`
+	assert.Empty(t, validate(t, query, `{
+		"search": [{"__typename": "Order", "id": "1", "status": "PAID"}, {"__typename": "Product", "title": "Mug", "inStock": true}],
+		"node": {"id": "7", "title": "Mug"}
+	}`))
+	assert.Equal(t, []string{
+		"search[0].status: missing from the response",
+		"search[1].inStock: expected Boolean, got string",
+		"search[2].__typename: Customer isn't a type of SearchResult",
+	}, validate(t, query, `{
+		"search": [{"__typename": "Order", "id": "1"}, {"__typename": "Product", "title": "Mug", "inStock": "yes"}, {"__typename": "Customer"}],
+		"node": {"id": "7"}
+	}`))
+	assert.Empty(t, validate(t, `{ search { ... on Order { id } ... on Product { title } } }`, `{"search": [{"id": "1"}, {"title": "Mug"}]}`),
+		"without __typename every fragment may apply")
+}
+
+func TestSchema_Validate_Query(t *testing.T) {
+	assert.Equal(t, []string{
+		"order: unknown fragment Missing",
+		"order.owner: Order has no field owner",
+	}, validate(t, `{ order { id owner ...Missing } }`, `{"order": {"id": "1"}}`))
+	assert.Equal(t, []string{"order.id: ID has no fields to select"}, validate(t, `{ order { id { value } } }`, `{"order": {"id": "1"}}`))
+	assert.Equal(t, []string{"order: Order needs a selection of its fields"}, validate(t, `{ order }`, `{"order": {"id": "1"}}`))
+	assert.Equal(t, []string{"node.title: Node has no field title"}, validate(t, `{ node { id title } }`, `{"node": {"id": "1", "title": "Mug"}}`))
+	assert.Empty(t, validate(t, `{ order { id note: total @skip(if: true) } }`, `{"order": {"id": "1"}}`))
+
+	s, err := ParseIntrospection([]byte(shopIntrospection))
+	require.NoError(t, err)
+	doc, err := ParseQuery(`mutation { cancelOrder(id: 1) { id reason } }`)
+	require.NoError(t, err)
+	problems, err := s.Validate(doc, "", nil)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"cancelOrder.reason: Order has no field reason"}, problems, "queries are checked when no data came back")
+	doc, err = ParseQuery(`subscription { orderPlaced { id } }`)
+	require.NoError(t, err)
+	_, err = s.Validate(doc, "", nil)
+	assert.EqualError(t, err, "the schema has no subscription type")
+}
@@ -0,0 +1,190 @@
+package history
+
+import (
+	"fmt"
+	"io"
+	"net/url"
+	"sort"
+	"strings"
+	"text/tabwriter"
+)
+
+// Element is an element an app interacted with, by the selector an action
+// used and the route of the page it was on
+type Element struct {
+	Route    string `json:"route"`
+	Selector string `json:"selector"`
+}
+
+// Route is the path of a URL, without a trailing slash, by which runs are
+// compared; "" for a URL that isn't a web page, such as a desktop app's
+func Route(rawURL string) string {
+	if rawURL == "" {
+		return ""
+	}
+	u, err := url.Parse(rawURL)
+	if err != nil || (u.Scheme != "" && u.Scheme != "http" && u.Scheme != "https") {
+		return ""
+	}
+	route := strings.TrimRight(u.Path, "/")
+	if route == "" {
+		return "/"
+	}
+	return route
+}
+
+// Coverage maps each app to the routes it opened in the last n records (all
+// when n <= 0), sorted, across all of its browsers
+func Coverage(records []Record, n int) map[string][]string {
+	if n > 0 && len(records) > n {
+		records = records[len(records)-n:]
+	}
+	seen := make(map[string]map[string]bool)
+	for _, record := range records {
+		for _, entry := range record.Entries {
+			if len(entry.Routes) == 0 {
+				continue
+			}
+			if seen[entry.App] == nil {
+				seen[entry.App] = make(map[string]bool)
+			}
+			for _, route := range entry.Routes {
+				seen[entry.App][route] = true
+			}
+		}
+	}
+	coverage := make(map[string][]string, len(seen))
+	for app, routes := range seen {
+		coverage[app] = sortedKeys(routes)
+	}
+	return coverage
+}
+
+// RouteUse is how the runs exercised a route
+type RouteUse struct {
+	Route    string   `json:"route"`
+	Runs     int      `json:"runs"` // runs that opened the route
+	Apps     []string `json:"apps"`
+	Elements []string `json:"elements,omitempty"` // selectors interacted with on the route
+}
+
+// CoverageReport is which routes a number of runs exercised and which known
+// routes, such as those of a sitemap, none of them opened
+type CoverageReport struct {
+	Runs      int        `json:"runs"`
+	Routes    []RouteUse `json:"routes"`
+	Known     int        `json:"known,omitempty"`     // known routes given
+	Untouched []string   `json:"untouched,omitempty"` // known routes never opened
+}
+
+// Covered returns the share of the known routes the runs opened, in percent
+func (r *CoverageReport) Covered() float64 {
+	if r.Known == 0 {
+		return 0
+	}
+	return float64(r.Known-len(r.Untouched)) * 100 / float64(r.Known)
+}
+
+// CoverageOf reports the routes and elements the records exercised, in
+// route order, against known routes
+func CoverageOf(records []Record, known []string) CoverageReport {
+	type use struct {
+		runs     int
+		apps     map[string]bool
+		elements map[string]bool
+	}
+	index := make(map[string]*use)
+	get := func(route string) *use {
+		u, ok := index[route]
+		if !ok {
+			u = &use{apps: make(map[string]bool), elements: make(map[string]bool)}
+			index[route] = u
+		}
+		return u
+	}
+	for _, record := range records {
+		opened := make(map[string]bool)
+		for _, entry := range record.Entries {
+			for _, route := range entry.Routes {
+				get(route).apps[entry.App] = true
+				opened[route] = true
+			}
+			for _, element := range entry.Elements {
+				u := get(element.Route)
+				u.apps[entry.App] = true
+				u.elements[element.Selector] = true
+				opened[element.Route] = true
+			}
+		}
+		for route := range opened {
+			index[route].runs++
+		}
+	}
+
+	report := CoverageReport{Runs: len(records), Routes: make([]RouteUse, 0, len(index))}
+	for route, u := range index {
+		report.Routes = append(report.Routes, RouteUse{
+			Route:    route,
+			Runs:     u.runs,
+			Apps:     sortedKeys(u.apps),
+			Elements: sortedKeys(u.elements),
+		})
+	}
+	sort.Slice(report.Routes, func(i, j int) bool { return report.Routes[i].Route < report.Routes[j].Route })
+
+	knownRoutes := make(map[string]bool)
+	for _, k := range known {
+		if route := Route(k); route != "" {
+			knownRoutes[route] = true
+		}
+	}
+	report.Known = len(knownRoutes)
+	for _, route := range sortedKeys(knownRoutes) {
+		if index[route] == nil {
+			report.Untouched = append(report.Untouched, route)
+		}
+	}
+	return report
+}
+
+// WriteCoverage prints a coverage report as an aligned table followed by
+// the known routes no run opened
+func WriteCoverage(w io.Writer, report CoverageReport) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "ROUTE\tRUNS\tAPPS\tELEMENTS")
+	for _, r := range report.Routes {
+		elements := strings.Join(r.Elements, " ")
+		if elements == "" {
+			elements = "-"
+		}
+		fmt.Fprintf(tw, "%s\t%d\t%s\t%s\n", r.Route, r.Runs, strings.Join(r.Apps, ", "), elements)
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+	if report.Known == 0 {
+		return nil
+	}
+	fmt.Fprintf(w, "\n%.1f%% of %d known route(s) exercised", report.Covered(), report.Known)
+	if len(report.Untouched) == 0 {
+		_, err := fmt.Fprintln(w)
+		return err
+	}
+	fmt.Fprintln(w, "; never exercised:")
+	for _, route := range report.Untouched {
+		fmt.Fprintf(w, "  %s\n", route)
+	}
+	return nil
+}
+
+func sortedKeys(set map[string]bool) []string {
+	if len(set) == 0 {
+		return nil
+	}
+	keys := make([]string, 0, len(set))
+	for key := range set {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
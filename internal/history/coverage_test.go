@@ -0,0 +1,62 @@
+package history
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRoute(t *testing.T) {
+	assert.Equal(t, "/cart", Route("https://shop.example.com/cart/?id=1"))
+	assert.Equal(t, "/", Route("https://shop.example.com"))
+	assert.Equal(t, "/admin/*", Route("/admin/*"))
+	assert.Empty(t, Route(""))
+	assert.Empty(t, Route("file:///tmp/page.html"))
+}
+
+func TestCoverage(t *testing.T) {
+	records := []Record{
+		run("a", Entry{App: "Shop", Browser: "chromium", Routes: []string{"/", "/legacy"}}),
+		run("b",
+			Entry{App: "Shop", Browser: "chromium", Routes: []string{"/", "/cart"}},
+			Entry{App: "Shop", Browser: "firefox", Routes: []string{"/checkout"}},
+			Entry{App: "Desktop", AppType: "desktop"}),
+	}
+	assert.Equal(t, map[string][]string{"Shop": {"/", "/cart", "/checkout"}}, Coverage(records, 1))
+	assert.Equal(t, []string{"/", "/cart", "/checkout", "/legacy"}, Coverage(records, 0)["Shop"])
+}
+
+func TestCoverageOf(t *testing.T) {
+	records := []Record{
+		run("a", Entry{App: "Shop", Routes: []string{"/", "/cart"}, Elements: []Element{{Route: "/cart", Selector: "#checkout"}}}),
+		run("b",
+			Entry{App: "Shop", Routes: []string{"/"}},
+			Entry{App: "Admin", Routes: []string{"/"}, Elements: []Element{{Route: "/", Selector: "#login"}}}),
+	}
+	report := CoverageOf(records, []string{"https://shop.example.com/", "https://shop.example.com/cart", "https://shop.example.com/about/"})
+
+	assert.Equal(t, 2, report.Runs)
+	assert.Equal(t, []RouteUse{
+		{Route: "/", Runs: 2, Apps: []string{"Admin", "Shop"}, Elements: []string{"#login"}},
+		{Route: "/cart", Runs: 1, Apps: []string{"Shop"}, Elements: []string{"#checkout"}},
+	}, report.Routes)
+	assert.Equal(t, 3, report.Known)
+	assert.Equal(t, []string{"/about"}, report.Untouched)
+	assert.InDelta(t, 66.7, report.Covered(), 0.1)
+
+	var out bytes.Buffer
+	assert.NoError(t, WriteCoverage(&out, report))
+	assert.Contains(t, out.String(), "/cart  1     Shop         #checkout")
+	assert.Contains(t, out.String(), "66.7% of 3 known route(s) exercised; never exercised:\n  /about\n")
+}
+
+func TestCoverageOf_NoKnownRoutes(t *testing.T) {
+	report := CoverageOf([]Record{run("a", Entry{App: "Shop", Routes: []string{"/"}})}, nil)
+	assert.Zero(t, report.Known)
+	assert.Empty(t, report.Untouched)
+
+	var out bytes.Buffer
+	assert.NoError(t, WriteCoverage(&out, report))
+	assert.NotContains(t, out.String(), "known route")
+}
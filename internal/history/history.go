@@ -0,0 +1,252 @@
+// Package history keeps a run-by-run log of app outcomes in a JSON Lines file,
+// so pass rates can be tracked across runs per tag, screens compared by
+// their perceptual hashes and the metrics actions record followed.
+package history
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"text/tabwriter"
+	"time"
+
+	"panoptic/internal/vision"
+)
+
+// FileName is the history file kept in the output directory
+const FileName = "history.jsonl"
+
+// Entry is the outcome of one app (or browser variant) in a run
+type Entry struct {
+	App         string        `json:"app"`
+	AppType     string        `json:"app_type"`
+	Browser     string        `json:"browser,omitempty"`
+	Tags        []string      `json:"tags,omitempty"`
+	Success     bool          `json:"success"`
+	Quarantined bool          `json:"quarantined,omitempty"`
+	Duration    time.Duration `json:"duration"`
+	Error       string        `json:"error,omitempty"`
+	Screens     []Screen      `json:"screens,omitempty"`
+	Routes      []string      `json:"routes,omitempty"`   // URL paths the app opened
+	Elements    []Element     `json:"elements,omitempty"` // elements the app interacted with
+	Metrics     []Metric      `json:"metrics,omitempty"`  // numbers the app's actions recorded
+}
+
+// Screen is the perceptual hash of a screenshot an app took, named for the
+// screen it shows
+type Screen struct {
+	Name       string       `json:"name"`
+	Screenshot string       `json:"screenshot"`
+	PHash      vision.PHash `json:"phash"`
+}
+
+// Record is one line of the history file
+type Record struct {
+	RunID     string    `json:"run_id"`
+	ProjectID string    `json:"project_id,omitempty"` // enterprise project the run is attributed to
+	Time      time.Time `json:"time"`
+	Entries   []Entry   `json:"entries"`
+}
+
+// Append adds a record to the history file, creating it if needed
+func Append(path string, record Record) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create history directory: %w", err)
+	}
+	line, err := json.Marshal(record)
+	if err != nil {
+		return fmt.Errorf("failed to marshal history record: %w", err)
+	}
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to open history file: %w", err)
+	}
+	defer f.Close()
+	if _, err := f.Write(append(line, '\n')); err != nil {
+		return fmt.Errorf("failed to write history file: %w", err)
+	}
+	return nil
+}
+
+// Load reads all records of a history file, oldest first
+func Load(path string) ([]Record, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open history file: %w", err)
+	}
+	defer f.Close()
+
+	var records []Record
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		var record Record
+		if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+			return nil, fmt.Errorf("invalid history record on line %d: %w", line, err)
+		}
+		records = append(records, record)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read history file: %w", err)
+	}
+	return records, nil
+}
+
+// TagStat is the pass rate of one tag over a number of runs
+type TagStat struct {
+	Tag      string  `json:"tag"`
+	Runs     int     `json:"runs"` // runs in which the tag was exercised
+	Total    int     `json:"total"`
+	Passed   int     `json:"passed"`
+	Failed   int     `json:"failed"`
+	PassRate float64 `json:"pass_rate"` // percent
+	LastRun  string  `json:"last_run"`  // "passed" or "failed" in the most recent run with the tag
+}
+
+// TagStats aggregates the last n records (all when n <= 0) per tag, in tag
+// order. An entry counts once under each of its tags.
+func TagStats(records []Record, n int) []TagStat {
+	if n > 0 && len(records) > n {
+		records = records[len(records)-n:]
+	}
+
+	index := make(map[string]*TagStat)
+	for _, record := range records {
+		seen := make(map[string]bool)
+		failedInRun := make(map[string]bool)
+		for _, entry := range record.Entries {
+			for _, tag := range entry.Tags {
+				stat, ok := index[tag]
+				if !ok {
+					stat = &TagStat{Tag: tag}
+					index[tag] = stat
+				}
+				if !seen[tag] {
+					seen[tag] = true
+					stat.Runs++
+				}
+				stat.Total++
+				if entry.Success {
+					stat.Passed++
+				} else {
+					stat.Failed++
+					failedInRun[tag] = true
+				}
+			}
+		}
+		for tag := range seen {
+			index[tag].LastRun = "passed"
+			if failedInRun[tag] {
+				index[tag].LastRun = "failed"
+			}
+		}
+	}
+
+	stats := make([]TagStat, 0, len(index))
+	for _, stat := range index {
+		stat.PassRate = float64(stat.Passed) * 100 / float64(stat.Total)
+		stats = append(stats, *stat)
+	}
+	sort.Slice(stats, func(i, j int) bool { return stats[i].Tag < stats[j].Tag })
+	return stats
+}
+
+// WriteTagStats prints stats as an aligned table
+func WriteTagStats(w io.Writer, stats []TagStat) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "TAG\tRUNS\tRESULTS\tPASSED\tFAILED\tPASS RATE\tLAST RUN")
+	for _, s := range stats {
+		fmt.Fprintf(tw, "%s\t%d\t%d\t%d\t%d\t%.1f%%\t%s\n", s.Tag, s.Runs, s.Total, s.Passed, s.Failed, s.PassRate, s.LastRun)
+	}
+	return tw.Flush()
+}
+
+// ScreenChange is a run in which a screen looked different from its
+// reference: the previous capture of the screen, or a given look
+type ScreenChange struct {
+	RunID        string       `json:"run_id"`
+	Time         time.Time    `json:"time"`
+	App          string       `json:"app"`
+	Browser      string       `json:"browser,omitempty"`
+	Screen       string       `json:"screen"`
+	Screenshot   string       `json:"screenshot"`
+	PHash        vision.PHash `json:"phash"`
+	Reference    vision.PHash `json:"reference"`
+	ReferenceRun string       `json:"reference_run,omitempty"` // run of the previous capture; empty for a given look
+	Distance     int          `json:"distance"`                // bits the hashes differ in
+}
+
+// ScreenQuery selects the screens ScreenChanges compares. Empty App and
+// Screen match all; with Like set, every capture is compared with it instead
+// of the previous capture of the screen.
+type ScreenQuery struct {
+	App      string
+	Screen   string
+	Like     *vision.PHash
+	Distance int // largest distance still the same look
+}
+
+// ScreenChanges finds the runs in which a screen looked different, oldest
+// first. A screen is tracked per app and browser, and a screen captured more
+// than once in a run is compared capture by capture.
+func ScreenChanges(records []Record, query ScreenQuery) []ScreenChange {
+	type capture struct {
+		run  string
+		hash vision.PHash
+	}
+	previous := make(map[string]capture)
+	var changes []ScreenChange
+	for _, record := range records {
+		for _, entry := range record.Entries {
+			if query.App != "" && entry.App != query.App {
+				continue
+			}
+			for _, screen := range entry.Screens {
+				if query.Screen != "" && screen.Name != query.Screen {
+					continue
+				}
+				change := ScreenChange{
+					RunID: record.RunID, Time: record.Time, App: entry.App, Browser: entry.Browser,
+					Screen: screen.Name, Screenshot: screen.Screenshot, PHash: screen.PHash,
+				}
+				key := entry.App + "\x00" + entry.Browser + "\x00" + screen.Name
+				if query.Like != nil {
+					change.Reference = *query.Like
+				} else {
+					last, ok := previous[key]
+					previous[key] = capture{record.RunID, screen.PHash}
+					if !ok {
+						continue
+					}
+					change.Reference, change.ReferenceRun = last.hash, last.run
+				}
+				change.Distance = change.PHash.Distance(change.Reference)
+				if change.Distance > query.Distance {
+					changes = append(changes, change)
+				}
+			}
+		}
+	}
+	return changes
+}
+
+// WriteScreenChanges prints changes as an aligned table
+func WriteScreenChanges(w io.Writer, changes []ScreenChange) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "RUN\tTIME\tAPP\tSCREEN\tDISTANCE\tSINCE\tSCREENSHOT")
+	for _, c := range changes {
+		since := c.ReferenceRun
+		if since == "" {
+			since = "-"
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%d\t%s\t%s\n", c.RunID, c.Time.Format(time.RFC3339), c.App, c.Screen, c.Distance, since, c.Screenshot)
+	}
+	return tw.Flush()
+}
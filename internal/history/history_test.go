@@ -0,0 +1,106 @@
+package history
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"panoptic/internal/vision"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func run(id string, entries ...Entry) Record {
+	return Record{RunID: id, Time: time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC), Entries: entries}
+}
+
+// TestAppendLoad tests that records round-trip through the history file
+func TestAppendLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "nested", FileName)
+	first := run("a", Entry{App: "Shop", AppType: "web", Browser: "chromium", Tags: []string{"smoke"}, Success: true, Duration: time.Second})
+	second := run("b", Entry{App: "Shop", AppType: "web", Success: false, Error: "boom"})
+	require.NoError(t, Append(path, first))
+	require.NoError(t, Append(path, second))
+
+	records, err := Load(path)
+	require.NoError(t, err)
+	assert.Equal(t, []Record{first, second}, records)
+
+	_, err = Load(filepath.Join(t.TempDir(), "missing.jsonl"))
+	assert.ErrorIs(t, err, os.ErrNotExist)
+
+	bad := filepath.Join(t.TempDir(), "bad.jsonl")
+	require.NoError(t, os.WriteFile(bad, []byte("{\"run_id\":\"a\"}\n\nnot json\n"), 0600))
+	_, err = Load(bad)
+	assert.ErrorContains(t, err, "line 3")
+}
+
+// TestTagStats tests per-tag pass rates across runs
+func TestTagStats(t *testing.T) {
+	records := []Record{
+		run("1",
+			Entry{App: "Shop", Tags: []string{"smoke", "checkout"}, Success: false},
+			Entry{App: "Admin", Tags: []string{"smoke"}, Success: true}),
+		run("2",
+			Entry{App: "Shop", Tags: []string{"smoke", "checkout"}, Success: true},
+			Entry{App: "Desktop", Success: true}),
+		run("3",
+			Entry{App: "Admin", Tags: []string{"smoke"}, Success: true}),
+	}
+
+	stats := TagStats(records, 0)
+	require.Len(t, stats, 2)
+	assert.Equal(t, TagStat{Tag: "checkout", Runs: 2, Total: 2, Passed: 1, Failed: 1, PassRate: 50, LastRun: "passed"}, stats[0])
+	assert.Equal(t, TagStat{Tag: "smoke", Runs: 3, Total: 4, Passed: 3, Failed: 1, PassRate: 75, LastRun: "passed"}, stats[1])
+
+	recent := TagStats(records, 1)
+	require.Len(t, recent, 1)
+	assert.Equal(t, "smoke", recent[0].Tag)
+	assert.Equal(t, 100.0, recent[0].PassRate)
+
+	out := &bytes.Buffer{}
+	require.NoError(t, WriteTagStats(out, stats))
+	assert.Contains(t, out.String(), "TAG")
+	assert.Regexp(t, `smoke\s+3\s+4\s+3\s+1\s+75\.0%\s+passed`, out.String())
+}
+
+func screens(name string, hashes ...vision.PHash) []Screen {
+	var out []Screen
+	for _, h := range hashes {
+		out = append(out, Screen{Name: name, Screenshot: name + ".png", PHash: h})
+	}
+	return out
+}
+
+// TestScreenChanges tests finding the runs where a screen looked different
+// from its previous capture or from a given look
+func TestScreenChanges(t *testing.T) {
+	records := []Record{
+		run("1", Entry{App: "Shop", Screens: screens("home", 0x0f)}, Entry{App: "Admin", Screens: screens("home", 0xff)}),
+		run("2", Entry{App: "Shop", Screens: screens("home", 0x1f)}, Entry{App: "Admin", Screens: screens("home", 0xff)}),
+		run("3", Entry{App: "Shop", Screens: screens("home", 0xff0f)}),
+		run("4", Entry{App: "Shop", Screens: append(screens("home", 0xff0f), screens("cart", 0x01)...)}),
+	}
+
+	changes := ScreenChanges(records, ScreenQuery{Distance: 2})
+	require.Len(t, changes, 1, "one bit in run 2 is the same look")
+	assert.Equal(t, ScreenChange{RunID: "3", Time: records[2].Time, App: "Shop", Screen: "home", Screenshot: "home.png",
+		PHash: 0xff0f, Reference: 0x1f, ReferenceRun: "2", Distance: 9}, changes[0])
+
+	assert.Len(t, ScreenChanges(records, ScreenQuery{}), 2, "any bit")
+	assert.Empty(t, ScreenChanges(records, ScreenQuery{App: "Admin"}))
+
+	like := vision.PHash(0x0f)
+	changes = ScreenChanges(records, ScreenQuery{App: "Shop", Screen: "home", Like: &like, Distance: 2})
+	require.Len(t, changes, 2)
+	assert.Equal(t, []string{"3", "4"}, []string{changes[0].RunID, changes[1].RunID})
+	assert.Empty(t, changes[0].ReferenceRun)
+
+	out := &bytes.Buffer{}
+	require.NoError(t, WriteScreenChanges(out, changes))
+	assert.Regexp(t, `RUN\s+TIME\s+APP\s+SCREEN\s+DISTANCE`, out.String())
+	assert.Regexp(t, `3\s+2026-01-01T00:00:00Z\s+Shop\s+home\s+8\s+-\s+home\.png`, out.String())
+}
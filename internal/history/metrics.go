@@ -0,0 +1,155 @@
+package history
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"text/tabwriter"
+	"time"
+)
+
+// Metric is a number an action of an app recorded under a name
+type Metric struct {
+	Name   string  `json:"name"`
+	Value  float64 `json:"value"`
+	Unit   string  `json:"unit,omitempty"`
+	Action string  `json:"action,omitempty"`
+}
+
+// MetricPoint is the value a metric had in a run: the mean of the values
+// recorded in it
+type MetricPoint struct {
+	RunID   string    `json:"run_id"`
+	Time    time.Time `json:"time"`
+	Value   float64   `json:"value"`
+	Samples int       `json:"samples"`
+}
+
+// MetricTrend is how a metric of an app changed over a number of runs
+type MetricTrend struct {
+	App     string        `json:"app"`
+	Browser string        `json:"browser,omitempty"`
+	Metric  string        `json:"metric"`
+	Unit    string        `json:"unit,omitempty"`
+	Runs    int           `json:"runs"` // runs that recorded the metric
+	First   float64       `json:"first"`
+	Last    float64       `json:"last"`
+	Min     float64       `json:"min"`
+	Max     float64       `json:"max"`
+	Mean    float64       `json:"mean"`
+	Change  float64       `json:"change"` // percent from the first run to the last; 0 when the first was 0
+	Points  []MetricPoint `json:"points"`
+}
+
+// MetricQuery selects the metrics MetricTrends follows; empty fields match
+// all
+type MetricQuery struct {
+	App    string
+	Metric string
+}
+
+// MetricTrends follows every metric per app and browser across records,
+// sorted by app, browser and metric. Values a metric was recorded with in
+// the same run, say on every pass of a soak test, are averaged.
+func MetricTrends(records []Record, query MetricQuery) []MetricTrend {
+	type key struct{ app, browser, metric string }
+	index := make(map[key]*MetricTrend)
+	for _, record := range records {
+		type sum struct {
+			total float64
+			n     int
+		}
+		sums := make(map[key]*sum)
+		var order []key
+		for _, entry := range record.Entries {
+			if query.App != "" && entry.App != query.App {
+				continue
+			}
+			for _, m := range entry.Metrics {
+				if query.Metric != "" && m.Name != query.Metric {
+					continue
+				}
+				k := key{entry.App, entry.Browser, m.Name}
+				trend, ok := index[k]
+				if !ok {
+					trend = &MetricTrend{App: entry.App, Browser: entry.Browser, Metric: m.Name}
+					index[k] = trend
+				}
+				if m.Unit != "" {
+					trend.Unit = m.Unit
+				}
+				s, ok := sums[k]
+				if !ok {
+					s = &sum{}
+					sums[k] = s
+					order = append(order, k)
+				}
+				s.total += m.Value
+				s.n++
+			}
+		}
+		for _, k := range order {
+			s := sums[k]
+			index[k].Points = append(index[k].Points, MetricPoint{RunID: record.RunID, Time: record.Time, Value: s.total / float64(s.n), Samples: s.n})
+		}
+	}
+
+	trends := make([]MetricTrend, 0, len(index))
+	for _, trend := range index {
+		trend.Runs = len(trend.Points)
+		trend.First, trend.Last = trend.Points[0].Value, trend.Points[len(trend.Points)-1].Value
+		trend.Min, trend.Max = math.Inf(1), math.Inf(-1)
+		total := 0.0
+		for _, p := range trend.Points {
+			trend.Min, trend.Max = math.Min(trend.Min, p.Value), math.Max(trend.Max, p.Value)
+			total += p.Value
+		}
+		trend.Mean = total / float64(trend.Runs)
+		if trend.First != 0 {
+			trend.Change = (trend.Last - trend.First) * 100 / math.Abs(trend.First)
+		}
+		trends = append(trends, *trend)
+	}
+	sort.Slice(trends, func(i, j int) bool {
+		a, b := trends[i], trends[j]
+		if a.App != b.App {
+			return a.App < b.App
+		}
+		if a.Browser != b.Browser {
+			return a.Browser < b.Browser
+		}
+		return a.Metric < b.Metric
+	})
+	return trends
+}
+
+// FormatMetric prints a metric value with its unit, and up to two decimals
+// when it isn't whole
+func FormatMetric(value float64, unit string) string {
+	text := strconv.FormatFloat(value, 'f', 0, 64)
+	if value != math.Trunc(value) {
+		text = strconv.FormatFloat(value, 'f', 2, 64)
+	}
+	if unit != "" {
+		text += " " + unit
+	}
+	return text
+}
+
+// WriteMetricTrends prints trends as an aligned table
+func WriteMetricTrends(w io.Writer, trends []MetricTrend) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "APP\tMETRIC\tRUNS\tFIRST\tLAST\tMIN\tMAX\tMEAN\tCHANGE")
+	for _, t := range trends {
+		app := t.App
+		if t.Browser != "" {
+			app += " (" + t.Browser + ")"
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%d\t%s\t%s\t%s\t%s\t%s\t%+.1f%%\n", app, t.Metric, t.Runs,
+			FormatMetric(t.First, t.Unit), FormatMetric(t.Last, t.Unit), FormatMetric(t.Min, t.Unit),
+			FormatMetric(t.Max, t.Unit), FormatMetric(t.Mean, t.Unit), t.Change)
+	}
+	return tw.Flush()
+}
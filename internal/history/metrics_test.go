@@ -0,0 +1,60 @@
+package history
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMetricTrends(t *testing.T) {
+	day := time.Date(2026, 10, 1, 9, 0, 0, 0, time.UTC)
+	records := []Record{
+		{RunID: "1", Time: day, Entries: []Entry{
+			{App: "Shop", Metrics: []Metric{{Name: "cart_render", Value: 200, Unit: "ms"}, {Name: "items", Value: 12}}},
+			{App: "Admin", Metrics: []Metric{{Name: "users", Value: 3}}},
+		}},
+		{RunID: "2", Time: day.Add(24 * time.Hour), Entries: []Entry{
+			{App: "Shop", Metrics: []Metric{{Name: "cart_render", Value: 240, Unit: "ms"}, {Name: "cart_render", Value: 260, Unit: "ms"}}},
+		}},
+		{RunID: "3", Time: day.Add(48 * time.Hour), Entries: []Entry{
+			{App: "Shop", Metrics: []Metric{{Name: "cart_render", Value: 300, Unit: "ms"}, {Name: "items", Value: 12.5}}},
+			{App: "Shop", Browser: "firefox", Metrics: []Metric{{Name: "cart_render", Value: 400, Unit: "ms"}}},
+		}},
+	}
+
+	trends := MetricTrends(records, MetricQuery{})
+	require.Len(t, trends, 4)
+	assert.Equal(t, "Admin", trends[0].App)
+	render := trends[1]
+	assert.Equal(t, "cart_render", render.Metric)
+	assert.Equal(t, "ms", render.Unit)
+	assert.Equal(t, 3, render.Runs)
+	assert.Equal(t, []MetricPoint{
+		{RunID: "1", Time: day, Value: 200, Samples: 1},
+		{RunID: "2", Time: day.Add(24 * time.Hour), Value: 250, Samples: 2},
+		{RunID: "3", Time: day.Add(48 * time.Hour), Value: 300, Samples: 1},
+	}, render.Points)
+	assert.Equal(t, 200.0, render.Min)
+	assert.Equal(t, 300.0, render.Max)
+	assert.Equal(t, 250.0, render.Mean)
+	assert.Equal(t, 50.0, render.Change)
+	assert.Equal(t, "items", trends[2].Metric)
+	assert.Equal(t, "firefox", trends[3].Browser)
+
+	trends = MetricTrends(records, MetricQuery{App: "Shop", Metric: "items"})
+	require.Len(t, trends, 1)
+	assert.Equal(t, 2, trends[0].Runs)
+
+	var out bytes.Buffer
+	require.NoError(t, WriteMetricTrends(&out, trends))
+	assert.Regexp(t, `Shop\s+items\s+2\s+12\s+12\.50\s+12\s+12\.50\s+12\.25\s+\+4\.2%`, out.String())
+}
+
+func TestFormatMetric(t *testing.T) {
+	assert.Equal(t, "250 ms", FormatMetric(250, "ms"))
+	assert.Equal(t, "0.33", FormatMetric(1.0/3, ""))
+	assert.Equal(t, "-3", FormatMetric(-3, ""))
+}
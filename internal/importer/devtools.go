@@ -0,0 +1,129 @@
+package importer
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"panoptic/internal/config"
+)
+
+// devToolsRecording is a Chrome DevTools Recorder export
+type devToolsRecording struct {
+	Title string         `json:"title"`
+	Steps []devToolsStep `json:"steps"`
+}
+
+type devToolsStep struct {
+	Type      string            `json:"type"`
+	URL       string            `json:"url"`
+	Value     string            `json:"value"`
+	Key       string            `json:"key"`
+	Selectors []json.RawMessage `json:"selectors"` // each a selector, or a chain of them through frames and shadow roots
+}
+
+// devToolsIgnored are steps with nothing to replay: the browser window and
+// the moves between what the other steps act on
+var devToolsIgnored = map[string]bool{
+	"setViewport": true, "scroll": true, "hover": true, "keyUp": true,
+	"waitForElement": true, "waitForExpression": true, "close": true,
+}
+
+// DevTools converts a Chrome DevTools Recorder export: navigations become
+// navigate actions, clicks click actions, typed values fill actions and
+// Enter a submit of the field typed last. Steps are located by their first
+// CSS selector; steps only located by ARIA, XPath or text, and steps with
+// no Panoptic action, are left out with a warning.
+func DevTools(data []byte) (*Result, error) {
+	var recording devToolsRecording
+	if err := json.Unmarshal(data, &recording); err != nil {
+		return nil, fmt.Errorf("invalid DevTools recording: %w", err)
+	}
+	result := &Result{Name: recording.Title}
+	test := Test{}
+	names := namer{}
+	var field string // selector of the field typed in last, for Enter
+	for i, step := range recording.Steps {
+		if devToolsIgnored[step.Type] {
+			continue
+		}
+		warn := func(why string) {
+			result.Warnings = append(result.Warnings, fmt.Sprintf("step %d (%s): %s", i+1, step.Type, why))
+		}
+		var action config.Action
+		switch step.Type {
+		case "navigate":
+			if step.URL == "" {
+				warn("no url")
+				continue
+			}
+			if test.URL == "" {
+				test.URL = step.URL
+			}
+			action = config.Action{Name: names.name("open", pageName(step.URL)), Type: "navigate", URL: step.URL}
+		case "click", "doubleClick":
+			selector, ok := cssSelector(step.Selectors)
+			if !ok {
+				warn("no CSS selector")
+				continue
+			}
+			action = config.Action{Name: names.name("click", selector), Type: "click", Selector: selector}
+		case "change":
+			selector, ok := cssSelector(step.Selectors)
+			if !ok {
+				warn("no CSS selector")
+				continue
+			}
+			if step.Value == "" {
+				warn("clears the field")
+				continue
+			}
+			field = selector
+			action = config.Action{Name: names.name("fill", selector), Type: "fill", Selector: selector, Value: step.Value}
+		case "keyDown":
+			if step.Key != "Enter" {
+				warn(fmt.Sprintf("key %s is not supported", step.Key))
+				continue
+			}
+			if field == "" {
+				warn("Enter outside a field")
+				continue
+			}
+			action = config.Action{Name: names.name("submit", field), Type: "submit", Selector: field}
+		default:
+			warn("not supported")
+			continue
+		}
+		test.Actions = append(test.Actions, action)
+	}
+	result.Tests = []Test{test}
+	return result, nil
+}
+
+// cssSelector picks the first selector that is plain CSS in the page
+// itself: not a chain into a frame or shadow root, and not one of the
+// Recorder's aria/, xpath/, pierce/ or text/ selectors
+func cssSelector(selectors []json.RawMessage) (string, bool) {
+	for _, raw := range selectors {
+		var chain []string
+		if err := json.Unmarshal(raw, &chain); err != nil {
+			var single string
+			if err := json.Unmarshal(raw, &single); err != nil {
+				continue
+			}
+			chain = []string{single}
+		}
+		if len(chain) != 1 {
+			continue
+		}
+		selector := chain[0]
+		switch {
+		case selector == "",
+			strings.HasPrefix(selector, "aria/"), strings.HasPrefix(selector, "xpath/"),
+			strings.HasPrefix(selector, "pierce/"), strings.HasPrefix(selector, "text/"):
+			continue
+		}
+		return selector, true
+	}
+	return "", false
+}
@@ -0,0 +1,61 @@
+package importer
+
+import (
+	"testing"
+
+	"panoptic/internal/config"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const devToolsLogin = `{
+  "title": "Log in",
+  "steps": [
+    {"type": "setViewport", "width": 1280, "height": 800},
+    {"type": "navigate", "url": "https://shop.example.com/login"},
+    {"type": "click", "selectors": [["aria/Email"], ["#email"]], "offsetX": 10, "offsetY": 5},
+    {"type": "change", "value": "ada@example.com", "selectors": [["aria/Email"], ["#email"]]},
+    {"type": "change", "value": "", "selectors": [["#coupon"]]},
+    {"type": "keyDown", "key": "Tab"},
+    {"type": "change", "value": "hunter2", "selectors": ["input[name=password]"]},
+    {"type": "keyDown", "key": "Enter"},
+    {"type": "keyUp", "key": "Enter"},
+    {"type": "click", "selectors": [["pierce/#menu"], ["iframe", "#inner"]]},
+    {"type": "doubleClick", "selectors": [[".account a"]]},
+    {"type": "customStep", "name": "audit"},
+    {"type": "click", "selectors": [["text/Log out"], ["xpath//button"], ["#logout"]]}
+  ]
+}`
+
+func TestDevTools(t *testing.T) {
+	result, err := DevTools([]byte(devToolsLogin))
+	require.NoError(t, err)
+	assert.Equal(t, "Log in", result.Name)
+	assert.Equal(t, "https://shop.example.com/login", result.Tests[0].URL)
+	assert.Equal(t, []config.Action{
+		{Name: "open_login", Type: "navigate", URL: "https://shop.example.com/login"},
+		{Name: "click_email", Type: "click", Selector: "#email"},
+		{Name: "fill_email", Type: "fill", Selector: "#email", Value: "ada@example.com"},
+		{Name: "fill_input_name_password", Type: "fill", Selector: "input[name=password]", Value: "hunter2"},
+		{Name: "submit_input_name_password", Type: "submit", Selector: "input[name=password]"},
+		{Name: "click_account_a", Type: "click", Selector: ".account a"},
+		{Name: "click_logout", Type: "click", Selector: "#logout"},
+	}, result.Tests[0].Actions)
+	assert.Equal(t, []string{
+		"step 5 (change): clears the field",
+		"step 6 (keyDown): key Tab is not supported",
+		"step 10 (click): no CSS selector",
+		"step 12 (customStep): not supported",
+	}, result.Warnings)
+}
+
+func TestDevTools_Errors(t *testing.T) {
+	_, err := DevTools([]byte(`{"steps": {}}`))
+	assert.ErrorContains(t, err, "invalid DevTools recording")
+
+	result, err := DevTools([]byte(`{"steps": [{"type": "navigate"}, {"type": "keyDown", "key": "Enter"}]}`))
+	require.NoError(t, err)
+	assert.Empty(t, result.Tests[0].Actions)
+	assert.Equal(t, []string{"step 1 (navigate): no url", "step 2 (keyDown): Enter outside a field"}, result.Warnings)
+}
@@ -0,0 +1,159 @@
+package importer
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"panoptic/internal/config"
+)
+
+// harLog is the part of a HAR file the import reads
+type harLog struct {
+	Log struct {
+		Entries []harEntry `json:"entries"`
+	} `json:"log"`
+}
+
+type harEntry struct {
+	StartedDateTime time.Time `json:"startedDateTime"`
+	ResourceType    string    `json:"_resourceType"` // Chrome only: document, xhr, fetch, script, ...
+	Request         struct {
+		Method   string      `json:"method"`
+		URL      string      `json:"url"`
+		Headers  []harHeader `json:"headers"`
+		PostData *struct {
+			MimeType string      `json:"mimeType"`
+			Text     string      `json:"text"`
+			Params   []harHeader `json:"params"`
+		} `json:"postData"`
+	} `json:"request"`
+	Response struct {
+		Status  int `json:"status"`
+		Content struct {
+			MimeType string `json:"mimeType"`
+		} `json:"content"`
+	} `json:"response"`
+}
+
+// harHeader is a name and value: a header, or a form parameter
+type harHeader struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+// HAR converts the requests of a HAR file: pages opened become navigate
+// actions and requests sending data, like form posts, http_request actions
+// expecting the recorded status. Only requests to the host of the first
+// page are converted; images, scripts and other subresources are left out,
+// and so are cookies and credentials, which belong to the recorded session.
+func HAR(data []byte) (*Result, error) {
+	var har harLog
+	if err := json.Unmarshal(data, &har); err != nil {
+		return nil, fmt.Errorf("invalid HAR: %w", err)
+	}
+	entries := har.Log.Entries
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].StartedDateTime.Before(entries[j].StartedDateTime)
+	})
+
+	result := &Result{}
+	test := Test{}
+	names := namer{}
+	var host string
+	for _, entry := range entries {
+		req := entry.Request
+		u, err := url.Parse(req.URL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+			continue
+		}
+		u.Fragment = ""
+		method := strings.ToUpper(req.Method)
+		page := harPage(entry, method)
+		sends := method != http.MethodGet && method != http.MethodHead && method != http.MethodOptions
+		if !page && !sends {
+			continue
+		}
+		if host == "" {
+			host = u.Host
+		}
+		if u.Host != host {
+			if sends {
+				result.Warnings = append(result.Warnings, fmt.Sprintf("%s %s: not on %s", method, u, host))
+			}
+			continue
+		}
+
+		if !sends {
+			if n := len(test.Actions); n > 0 && test.Actions[n-1].Type == "navigate" && test.Actions[n-1].URL == u.String() {
+				continue // reloaded
+			}
+			if test.URL == "" {
+				test.URL = u.String()
+			}
+			test.Actions = append(test.Actions, config.Action{
+				Name: names.name("open", pageName(u.String())),
+				Type: "navigate",
+				URL:  u.String(),
+			})
+			continue
+		}
+		test.Actions = append(test.Actions, config.Action{
+			Name:       names.name(strings.ToLower(method), pageName(u.String())),
+			Type:       "http_request",
+			Parameters: harRequest(entry, method, u.String()),
+		})
+	}
+	result.Tests = []Test{test}
+	return result, nil
+}
+
+// harPage reports whether an entry opened a page: a document, or an HTML
+// response where the HAR doesn't say. Redirects are left to their target.
+func harPage(entry harEntry, method string) bool {
+	if method != http.MethodGet || entry.Response.Status < 200 || entry.Response.Status > 299 {
+		return false
+	}
+	if entry.ResourceType != "" {
+		return entry.ResourceType == "document"
+	}
+	return strings.HasPrefix(entry.Response.Content.MimeType, "text/html")
+}
+
+// harRequest is the http_request parameters replaying an entry
+func harRequest(entry harEntry, method, rawURL string) map[string]interface{} {
+	params := map[string]interface{}{"method": method, "url": rawURL}
+	contentType := ""
+	for _, h := range entry.Request.Headers {
+		if strings.EqualFold(h.Name, "Content-Type") {
+			contentType = h.Value
+		}
+	}
+	if post := entry.Request.PostData; post != nil {
+		if contentType == "" {
+			contentType = post.MimeType
+		}
+		body := post.Text
+		if body == "" && len(post.Params) > 0 {
+			form := url.Values{}
+			for _, p := range post.Params {
+				form.Add(p.Name, p.Value)
+			}
+			body = form.Encode()
+		}
+		if body != "" {
+			params["body"] = body
+		}
+	}
+	if contentType != "" {
+		params["headers"] = map[string]interface{}{"Content-Type": contentType}
+	}
+	if entry.Response.Status > 0 {
+		params["expect_status"] = entry.Response.Status
+	}
+	return params
+}
@@ -0,0 +1,88 @@
+package importer
+
+import (
+	"testing"
+
+	"panoptic/internal/config"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// harCheckout opens the shop, adds to the cart with a form post that
+// redirects, and checks out through the API; entries are out of order, as
+// HAR files may list them
+const harCheckout = `{"log": {"version": "1.2", "entries": [
+  {"startedDateTime": "2026-10-01T10:00:03Z", "_resourceType": "document",
+   "request": {"method": "POST", "url": "https://shop.example.com/cart",
+     "headers": [{"name": "Cookie", "value": "session=abc"}, {"name": "Content-Type", "value": "application/x-www-form-urlencoded"}],
+     "postData": {"mimeType": "application/x-www-form-urlencoded", "params": [{"name": "sku", "value": "42"}, {"name": "qty", "value": "1"}]}},
+   "response": {"status": 302, "content": {"mimeType": "text/html"}}},
+  {"startedDateTime": "2026-10-01T10:00:00Z", "_resourceType": "document",
+   "request": {"method": "GET", "url": "https://shop.example.com/#top"},
+   "response": {"status": 200, "content": {"mimeType": "text/html"}}},
+  {"startedDateTime": "2026-10-01T10:00:01Z", "_resourceType": "script",
+   "request": {"method": "GET", "url": "https://shop.example.com/app.js"},
+   "response": {"status": 200, "content": {"mimeType": "text/javascript"}}},
+  {"startedDateTime": "2026-10-01T10:00:02Z", "_resourceType": "document",
+   "request": {"method": "GET", "url": "https://shop.example.com/"},
+   "response": {"status": 200, "content": {"mimeType": "text/html"}}},
+  {"startedDateTime": "2026-10-01T10:00:02.5Z", "_resourceType": "ping",
+   "request": {"method": "POST", "url": "https://analytics.example.net/collect", "postData": {"mimeType": "text/plain", "text": "e=view"}},
+   "response": {"status": 204, "content": {}}},
+  {"startedDateTime": "2026-10-01T10:00:04Z", "_resourceType": "document",
+   "request": {"method": "GET", "url": "https://shop.example.com/cart"},
+   "response": {"status": 200, "content": {"mimeType": "text/html"}}},
+  {"startedDateTime": "2026-10-01T10:00:05Z", "_resourceType": "fetch",
+   "request": {"method": "POST", "url": "https://shop.example.com/api/checkout",
+     "headers": [{"name": "Authorization", "value": "Bearer t0ken"}],
+     "postData": {"mimeType": "application/json", "text": "{\"cart\":1}"}},
+   "response": {"status": 201, "content": {"mimeType": "application/json"}}},
+  {"startedDateTime": "2026-10-01T10:00:06Z", "_resourceType": "xhr",
+   "request": {"method": "GET", "url": "https://shop.example.com/api/orders"},
+   "response": {"status": 200, "content": {"mimeType": "application/json"}}}
+]}}`
+
+func TestHAR(t *testing.T) {
+	result, err := HAR([]byte(harCheckout))
+	require.NoError(t, err)
+	assert.Equal(t, "https://shop.example.com/", result.Tests[0].URL)
+	assert.Equal(t, []config.Action{
+		{Name: "open_home", Type: "navigate", URL: "https://shop.example.com/"},
+		{Name: "post_cart", Type: "http_request", Parameters: map[string]interface{}{
+			"method": "POST", "url": "https://shop.example.com/cart", "body": "qty=1&sku=42",
+			"headers":       map[string]interface{}{"Content-Type": "application/x-www-form-urlencoded"},
+			"expect_status": 302,
+		}},
+		{Name: "open_cart", Type: "navigate", URL: "https://shop.example.com/cart"},
+		{Name: "post_api_checkout", Type: "http_request", Parameters: map[string]interface{}{
+			"method": "POST", "url": "https://shop.example.com/api/checkout", "body": `{"cart":1}`,
+			"headers":       map[string]interface{}{"Content-Type": "application/json"},
+			"expect_status": 201,
+		}},
+	}, result.Tests[0].Actions)
+	assert.Equal(t, []string{"POST https://analytics.example.net/collect: not on shop.example.com"}, result.Warnings)
+
+	// What's imported is a configuration run accepts
+	cfg := &config.Config{Apps: []config.AppConfig{{Name: "shop", Type: "web", URL: result.Tests[0].URL}}, Actions: result.Tests[0].Actions}
+	assert.NoError(t, cfg.Validate())
+}
+
+func TestHAR_WithoutResourceTypes(t *testing.T) {
+	result, err := HAR([]byte(`{"log": {"entries": [
+	  {"request": {"method": "GET", "url": "http://localhost:3000/"}, "response": {"status": 200, "content": {"mimeType": "text/html; charset=utf-8"}}},
+	  {"request": {"method": "GET", "url": "http://localhost:3000/logo.png"}, "response": {"status": 200, "content": {"mimeType": "image/png"}}},
+	  {"request": {"method": "GET", "url": "http://localhost:3000/old"}, "response": {"status": 301, "content": {"mimeType": "text/html"}}},
+	  {"request": {"method": "DELETE", "url": "http://localhost:3000/api/items/1"}, "response": {"status": 0}}
+	]}}`))
+	require.NoError(t, err)
+	assert.Equal(t, []config.Action{
+		{Name: "open_home", Type: "navigate", URL: "http://localhost:3000/"},
+		{Name: "delete_api_items_1", Type: "http_request", Parameters: map[string]interface{}{"method": "DELETE", "url": "http://localhost:3000/api/items/1"}},
+	}, result.Tests[0].Actions)
+}
+
+func TestHAR_Invalid(t *testing.T) {
+	_, err := HAR([]byte(`{"log": {"entries": "none"}}`))
+	assert.ErrorContains(t, err, "invalid HAR")
+}
@@ -0,0 +1,149 @@
+// Package importer converts recorded sessions and the test suites of other
+// tools into Panoptic actions, so they can be run and committed as checks.
+package importer
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"panoptic/internal/config"
+)
+
+// Formats Import reads
+const (
+	FormatHAR        = "har"        // HTTP Archive, as saved by browser developer tools
+	FormatDevTools   = "devtools"   // Chrome DevTools Recorder JSON export
+	FormatSelenium   = "side"       // Selenium IDE project
+	FormatPlaywright = "playwright" // Playwright test script
+)
+
+// Formats lists the formats Import reads
+var Formats = []string{FormatHAR, FormatDevTools, FormatSelenium, FormatPlaywright}
+
+// Result is what an import produced
+type Result struct {
+	Format   string
+	Name     string // title of the recording or project, when it has one
+	Tests    []Test
+	Warnings []string // what was left out, and why
+}
+
+// Test is a test of a suite, or the whole of a recorded session
+type Test struct {
+	Name    string // empty for a recorded session
+	URL     string // first page opened
+	Actions []config.Action
+}
+
+// Import converts data in format, or in the format it looks like when
+// format is empty
+func Import(data []byte, format string) (*Result, error) {
+	if format == "" {
+		var err error
+		if format, err = Detect(data); err != nil {
+			return nil, err
+		}
+	}
+	var result *Result
+	var err error
+	switch format {
+	case FormatHAR:
+		result, err = HAR(data)
+	case FormatDevTools:
+		result, err = DevTools(data)
+	case FormatSelenium:
+		result, err = Selenium(data)
+	case FormatPlaywright:
+		result, err = Playwright(data)
+	default:
+		return nil, fmt.Errorf("unknown format %q: want %s", format, strings.Join(Formats, ", "))
+	}
+	if err != nil {
+		return nil, err
+	}
+	tests := result.Tests[:0]
+	for _, test := range result.Tests {
+		if len(test.Actions) == 0 {
+			result.Warnings = append(result.Warnings, fmt.Sprintf("test %s: no step converts to an action", test.Name))
+			continue
+		}
+		tests = append(tests, test)
+	}
+	if len(tests) == 0 {
+		return nil, fmt.Errorf("nothing to import: no %s entry converts to an action", format)
+	}
+	result.Tests = tests
+	result.Format = format
+	return result, nil
+}
+
+// Detect tells the format of data from its top-level fields, or for
+// scripts, from the Playwright calls in them
+func Detect(data []byte) (string, error) {
+	var top map[string]json.RawMessage
+	if err := json.Unmarshal(data, &top); err != nil {
+		if playwrightScript.Match(data) {
+			return FormatPlaywright, nil
+		}
+		return "", fmt.Errorf("unknown format: not a HAR, DevTools recording, Selenium IDE project or Playwright script")
+	}
+	switch {
+	case top["log"] != nil:
+		return FormatHAR, nil
+	case top["steps"] != nil:
+		return FormatDevTools, nil
+	case top["tests"] != nil && top["url"] != nil:
+		return FormatSelenium, nil
+	}
+	return "", fmt.Errorf("unknown format: not a HAR (no log), DevTools recording (no steps) or Selenium IDE project (no tests and url)")
+}
+
+// playwrightScript matches what Playwright scripts import or call
+var playwrightScript = regexp.MustCompile(`@playwright/test|require\(['"]playwright['"]\)|\bpage\.goto\(`)
+
+// namer names actions after what they act on, numbering the names two
+// actions would share
+type namer map[string]bool
+
+func (n namer) name(prefix, subject string) string {
+	base := prefix
+	if s := slug(subject); s != "" {
+		base += "_" + s
+	}
+	name := base
+	for i := 2; n[name]; i++ {
+		name = fmt.Sprintf("%s_%d", base, i)
+	}
+	n[name] = true
+	return name
+}
+
+// slug turns text into lower case words joined by underscores
+func slug(text string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(text) {
+		switch {
+		case r >= 'a' && r <= 'z', r >= '0' && r <= '9':
+			b.WriteRune(r)
+		case b.Len() > 0 && !strings.HasSuffix(b.String(), "_"):
+			b.WriteByte('_')
+		}
+	}
+	return strings.TrimSuffix(b.String(), "_")
+}
+
+// pageName is what actions on a page are named after: its route and query,
+// or home for the root
+func pageName(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return ""
+	}
+	if name := slug(u.Path + " " + u.RawQuery); name != "" {
+		return name
+	}
+	return "home"
+}
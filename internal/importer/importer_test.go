@@ -0,0 +1,63 @@
+package importer
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDetect(t *testing.T) {
+	format, err := Detect([]byte(harCheckout))
+	require.NoError(t, err)
+	assert.Equal(t, FormatHAR, format)
+	format, err = Detect([]byte(devToolsLogin))
+	require.NoError(t, err)
+	assert.Equal(t, FormatDevTools, format)
+
+	format, err = Detect([]byte(seleniumLogin))
+	require.NoError(t, err)
+	assert.Equal(t, FormatSelenium, format)
+	format, err = Detect([]byte(playwrightCheckout))
+	require.NoError(t, err)
+	assert.Equal(t, FormatPlaywright, format)
+
+	_, err = Detect([]byte(`{"tests": []}`))
+	assert.ErrorContains(t, err, "unknown format")
+	_, err = Detect([]byte(`Feature: Checkout`))
+	assert.ErrorContains(t, err, "unknown format")
+}
+
+func TestImport(t *testing.T) {
+	result, err := Import([]byte(devToolsLogin), "")
+	require.NoError(t, err)
+	assert.Equal(t, FormatDevTools, result.Format)
+	assert.Len(t, result.Tests[0].Actions, 7)
+
+	_, err = Import([]byte(devToolsLogin), "cypress")
+	assert.ErrorContains(t, err, `unknown format "cypress"`)
+	_, err = Import([]byte(`{"log": {"entries": []}}`), FormatHAR)
+	assert.ErrorContains(t, err, "nothing to import")
+
+	// Tests with nothing to import are left out
+	result, err = Import([]byte(`{"url": "https://shop.example.com", "tests": [
+	  {"name": "asserts", "commands": [{"command": "assertTitle", "target": "Shop"}]},
+	  {"name": "opens", "commands": [{"command": "open", "target": "/"}]}
+	]}`), FormatSelenium)
+	require.NoError(t, err)
+	require.Len(t, result.Tests, 1)
+	assert.Equal(t, "opens", result.Tests[0].Name)
+	assert.Equal(t, []string{
+		"test asserts, command 1 (assertTitle): not supported",
+		"test asserts: no step converts to an action",
+	}, result.Warnings)
+}
+
+func TestNamer(t *testing.T) {
+	names := namer{}
+	assert.Equal(t, "open_home", names.name("open", pageName("https://shop.example.com")))
+	assert.Equal(t, "open_home_2", names.name("open", pageName("https://shop.example.com/#top")))
+	assert.Equal(t, "open_search_q_red_hat", names.name("open", pageName("https://shop.example.com/Search?q=red+hat")))
+	assert.Equal(t, "click", names.name("click", "**"))
+	assert.Equal(t, "click_2", names.name("click", ""))
+}
@@ -0,0 +1,239 @@
+package importer
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"panoptic/internal/config"
+)
+
+// Statements of a Playwright script, once await and the closing semicolon
+// are trimmed
+var (
+	// test('name', async ({ page }) => {
+	playwrightTest = regexp.MustCompile(`^test(?:\.only)?\(\s*(` + jsString + `)\s*,`)
+	// test.beforeEach(async ({ page }) => {
+	playwrightHook = regexp.MustCompile(`^test\.beforeEach\(`)
+	// page.click('#a'), page.locator('#a').fill('x'), page.getByTestId('a').click()
+	playwrightCall = regexp.MustCompile(`^page\.(?:(locator|getBy\w+)\((.*?)\)\.)?(\w+)\((.*)\)$`)
+	// page.screenshot({ path: 'home.png' })
+	playwrightPath = regexp.MustCompile(`\bpath:\s*(` + jsString + `)`)
+	// text=, xpath=, role= and the other selector engines besides css=
+	playwrightEngine = regexp.MustCompile(`^[\w-]+=`)
+)
+
+// jsString matches a JavaScript string literal without interpolation
+const jsString = `'(?:[^'\\]|\\.)*'|"(?:[^"\\]|\\.)*"|` + "`[^`$\\\\]*`"
+
+// jsArg matches the first argument of a call and what separates it from
+// the next
+var jsArg = regexp.MustCompile(`^\s*(` + jsString + `|-?\d+(?:\.\d+)?)\s*(?:,|$)`)
+
+// playwrightIgnored are page methods with nothing to replay: the window,
+// and the waits for what the next statement acts on, which Panoptic does
+var playwrightIgnored = map[string]bool{
+	"setViewportSize": true, "waitForLoadState": true, "waitForURL": true,
+	"waitForSelector": true, "waitForNavigation": true, "close": true,
+	"hover": true, "focus": true,
+}
+
+// Playwright converts the tests of a Playwright script, as written by
+// playwright codegen: page.goto becomes a navigate action, click a click,
+// fill and type a fill, pressing Enter a submit, waitForTimeout a wait and
+// screenshot a screenshot. The steps of test.beforeEach open every test.
+// Elements are located by CSS selectors or test IDs; statements using
+// other locators, assertions and other page methods are left out with a
+// warning, and statements spanning lines aren't read.
+func Playwright(data []byte) (*Result, error) {
+	result := &Result{}
+	var before, test *Test
+	var names namer
+	for n, line := range strings.Split(string(data), "\n") {
+		stmt := strings.TrimSpace(line)
+		stmt = strings.TrimSuffix(strings.TrimPrefix(stmt, "await "), ";")
+		warn := func(why string) {
+			result.Warnings = append(result.Warnings, fmt.Sprintf("line %d (%s): %s", n+1, stmt, why))
+		}
+		if m := playwrightTest.FindStringSubmatch(stmt); m != nil {
+			name, _ := jsUnquote(m[1])
+			result.Tests = append(result.Tests, Test{Name: name})
+			test = &result.Tests[len(result.Tests)-1]
+			names = namer{}
+			if before != nil {
+				test.URL = before.URL
+				for _, a := range before.Actions {
+					names[a.Name] = true
+					test.Actions = append(test.Actions, a)
+				}
+			}
+			continue
+		}
+		if playwrightHook.MatchString(stmt) {
+			before, test, names = &Test{}, nil, namer{}
+			continue
+		}
+		target := test
+		if target == nil {
+			target = before
+		}
+		if strings.HasPrefix(stmt, "expect(") {
+			warn("assertions are not supported")
+			continue
+		}
+		m := playwrightCall.FindStringSubmatch(stmt)
+		if m == nil {
+			continue // setup, comments and closing braces
+		}
+		if target == nil {
+			// A script without tests, like one playwright codegen writes
+			result.Tests = append(result.Tests, Test{})
+			test = &result.Tests[0]
+			target, names = test, namer{}
+		}
+		locator, locatorArgs, method, rawArgs := m[1], m[2], m[3], m[4]
+		if playwrightIgnored[method] {
+			continue
+		}
+		args, argsOK := jsArgs(rawArgs)
+		var action config.Action
+		switch method {
+		case "goto":
+			if !argsOK || len(args) == 0 {
+				warn("the url is not a string")
+				continue
+			}
+			if target.URL == "" {
+				target.URL = args[0]
+			}
+			action = config.Action{Name: names.name("open", pageName(args[0])), Type: "navigate", URL: args[0]}
+		case "waitForTimeout":
+			ms, err := strconv.ParseFloat(firstOf(args), 64)
+			if !argsOK || err != nil || ms < 0 {
+				warn("the timeout is not a number")
+				continue
+			}
+			action = config.Action{Name: names.name("wait", ""), Type: "wait", WaitTime: max(1, int((ms+999)/1000))}
+		case "screenshot":
+			p := playwrightPath.FindStringSubmatch(rawArgs)
+			if p == nil {
+				action = config.Action{Name: names.name("screenshot", ""), Type: "screenshot"}
+				break
+			}
+			file, _ := jsUnquote(p[1])
+			file = path.Base(file)
+			action = config.Action{
+				Name: names.name("screenshot", strings.TrimSuffix(file, path.Ext(file))), Type: "screenshot",
+				Parameters: map[string]interface{}{"filename": file},
+			}
+		case "click", "dblclick", "check", "fill", "type", "pressSequentially", "press":
+			selector, ok := "", false
+			if locator == "" {
+				// page.click(selector, ...): the selector leads the arguments
+				if len(args) > 0 {
+					selector, ok = playwrightSelector("locator", args[0])
+					args = args[1:]
+				}
+			} else if located, parsed := jsArgs(locatorArgs); parsed && len(located) == 1 {
+				selector, ok = playwrightSelector(locator, located[0])
+			}
+			if !ok {
+				warn("no CSS selector or test id")
+				continue
+			}
+			switch method {
+			case "click", "dblclick", "check":
+				action = config.Action{Name: names.name("click", selector), Type: "click", Selector: selector}
+			case "press":
+				if firstOf(args) != "Enter" {
+					warn(fmt.Sprintf("key %s is not supported", firstOf(args)))
+					continue
+				}
+				action = config.Action{Name: names.name("submit", selector), Type: "submit", Selector: selector}
+			default:
+				if !argsOK || firstOf(args) == "" {
+					warn("the value is not a string")
+					continue
+				}
+				action = config.Action{Name: names.name("fill", selector), Type: "fill", Selector: selector, Value: args[0]}
+			}
+		default:
+			warn(fmt.Sprintf("page.%s is not supported", method))
+			continue
+		}
+		target.Actions = append(target.Actions, action)
+	}
+	return result, nil
+}
+
+// playwrightSelector turns a locator into a CSS selector: a locator() of
+// plain CSS, or a getByTestId(); other getBy locators, and text, XPath and
+// chained selectors, have none
+func playwrightSelector(locator, arg string) (string, bool) {
+	switch locator {
+	case "getByTestId":
+		return fmt.Sprintf("[data-testid=%q]", arg), true
+	case "locator":
+		arg = strings.TrimPrefix(arg, "css=")
+		if arg == "" || strings.Contains(arg, ">>") || strings.HasPrefix(arg, "//") || strings.HasPrefix(arg, "(") ||
+			playwrightEngine.MatchString(arg) || strings.Contains(arg, ":has-text(") || strings.Contains(arg, ":text(") {
+			return "", false
+		}
+		return arg, true
+	}
+	return "", false
+}
+
+// jsArgs reads the arguments of a call that are string or number literals,
+// failing at the first that is anything else, like a variable or an object,
+// with the arguments before it
+func jsArgs(s string) ([]string, bool) {
+	var args []string
+	for strings.TrimSpace(s) != "" {
+		m := jsArg.FindStringSubmatchIndex(s)
+		if m == nil {
+			return args, false
+		}
+		arg := s[m[2]:m[3]]
+		if value, ok := jsUnquote(arg); ok {
+			arg = value
+		}
+		args = append(args, arg)
+		s = s[m[1]:]
+	}
+	return args, true
+}
+
+// jsUnquote returns the value of a string literal
+func jsUnquote(s string) (string, bool) {
+	if len(s) < 2 || !strings.ContainsRune(`'"`+"`", rune(s[0])) || s[len(s)-1] != s[0] {
+		return s, false
+	}
+	body := s[1 : len(s)-1]
+	var b strings.Builder
+	for i := 0; i < len(body); i++ {
+		if body[i] == '\\' && i+1 < len(body) {
+			i++
+			switch body[i] {
+			case 'n':
+				b.WriteByte('\n')
+			case 't':
+				b.WriteByte('\t')
+			default:
+				b.WriteByte(body[i])
+			}
+			continue
+		}
+		b.WriteByte(body[i])
+	}
+	return b.String(), true
+}
+
+func firstOf(args []string) string {
+	if len(args) == 0 {
+		return ""
+	}
+	return args[0]
+}
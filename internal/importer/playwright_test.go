@@ -0,0 +1,113 @@
+package importer
+
+import (
+	"testing"
+
+	"panoptic/internal/config"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const playwrightCheckout = `import { test, expect } from '@playwright/test';
+
+test.beforeEach(async ({ page }) => {
+  await page.goto('https://shop.example.com/');
+  await page.setViewportSize({ width: 1280, height: 800 });
+});
+
+test('add to cart', async ({ page }) => {
+  await page.locator('#product-42 .add').click();
+  await page.getByTestId('cart-count').click();
+  await page.waitForTimeout(500);
+  await expect(page.locator('.cart')).toHaveCount(1);
+  await page.screenshot({ path: 'shots/cart.png', fullPage: true });
+});
+
+test("check out", async ({ page }) => {
+  await page.fill('input[name="email"]', "ada@example.com");
+  await page.locator('#card').type('4242 4242 4242 4242');
+  await page.locator('#card').press('Enter');
+  await page.getByRole('button', { name: 'Pay' }).click();
+  await page.locator('text=Thanks').click();
+  await page.press('#card', 'Tab');
+  await page.fill('#name', name);
+  await page.evaluate(() => window.scrollTo(0, 0));
+});
+`
+
+func TestPlaywright(t *testing.T) {
+	result, err := Playwright([]byte(playwrightCheckout))
+	require.NoError(t, err)
+	require.Len(t, result.Tests, 2)
+
+	open := config.Action{Name: "open_home", Type: "navigate", URL: "https://shop.example.com/"}
+	cart := result.Tests[0]
+	assert.Equal(t, "add to cart", cart.Name)
+	assert.Equal(t, "https://shop.example.com/", cart.URL)
+	assert.Equal(t, []config.Action{
+		open,
+		{Name: "click_product_42_add", Type: "click", Selector: "#product-42 .add"},
+		{Name: "click_data_testid_cart_count", Type: "click", Selector: `[data-testid="cart-count"]`},
+		{Name: "wait", Type: "wait", WaitTime: 1},
+		{Name: "screenshot_cart", Type: "screenshot", Parameters: map[string]interface{}{"filename": "cart.png"}},
+	}, cart.Actions)
+
+	checkout := result.Tests[1]
+	assert.Equal(t, "check out", checkout.Name)
+	assert.Equal(t, []config.Action{
+		open,
+		{Name: "fill_input_name_email", Type: "fill", Selector: `input[name="email"]`, Value: "ada@example.com"},
+		{Name: "fill_card", Type: "fill", Selector: "#card", Value: "4242 4242 4242 4242"},
+		{Name: "submit_card", Type: "submit", Selector: "#card"},
+	}, checkout.Actions)
+
+	assert.Equal(t, []string{
+		"line 12 (expect(page.locator('.cart')).toHaveCount(1)): assertions are not supported",
+		"line 20 (page.getByRole('button', { name: 'Pay' }).click()): no CSS selector or test id",
+		"line 21 (page.locator('text=Thanks').click()): no CSS selector or test id",
+		"line 22 (page.press('#card', 'Tab')): key Tab is not supported",
+		"line 23 (page.fill('#name', name)): the value is not a string",
+		"line 24 (page.evaluate(() => window.scrollTo(0, 0))): page.evaluate is not supported",
+	}, result.Warnings)
+}
+
+func TestPlaywright_Codegen(t *testing.T) {
+	// A script as playwright codegen --target javascript writes it
+	result, err := Playwright([]byte(`const { chromium } = require('playwright');
+
+(async () => {
+  const browser = await chromium.launch({ headless: false });
+  const context = await browser.newContext();
+  const page = await context.newPage();
+  await page.goto('http://localhost:3000/search?q=hats');
+  await page.click('css=.result >> nth=0');
+  await page.dblclick('.result:first-child');
+  await page.waitForTimeout(wait);
+  await context.close();
+  await browser.close();
+})();
+`))
+	require.NoError(t, err)
+	require.Len(t, result.Tests, 1)
+	assert.Empty(t, result.Tests[0].Name)
+	assert.Equal(t, "http://localhost:3000/search?q=hats", result.Tests[0].URL)
+	assert.Equal(t, []config.Action{
+		{Name: "open_search_q_hats", Type: "navigate", URL: "http://localhost:3000/search?q=hats"},
+		{Name: "click_result_first_child", Type: "click", Selector: ".result:first-child"},
+	}, result.Tests[0].Actions)
+	assert.Equal(t, []string{
+		"line 8 (page.click('css=.result >> nth=0')): no CSS selector or test id",
+		"line 10 (page.waitForTimeout(wait)): the timeout is not a number",
+	}, result.Warnings)
+}
+
+func TestJSArgs(t *testing.T) {
+	args, ok := jsArgs(` 'it\'s', "say \"hi\"", ` + "`tpl`" + `, 42 `)
+	assert.True(t, ok)
+	assert.Equal(t, []string{"it's", `say "hi"`, "tpl", "42"}, args)
+	_, ok = jsArgs("`${name}`")
+	assert.False(t, ok)
+	_, ok = jsArgs(`'#a', { force: true }`)
+	assert.False(t, ok)
+}
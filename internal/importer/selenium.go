@@ -0,0 +1,157 @@
+package importer
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"panoptic/internal/config"
+)
+
+// seleniumProject is a Selenium IDE .side project
+type seleniumProject struct {
+	Name  string `json:"name"`
+	URL   string `json:"url"`
+	Tests []struct {
+		Name     string            `json:"name"`
+		Commands []seleniumCommand `json:"commands"`
+	} `json:"tests"`
+}
+
+type seleniumCommand struct {
+	Command string     `json:"command"`
+	Target  string     `json:"target"`
+	Targets [][]string `json:"targets"` // alternative locators of target, each with its kind
+	Value   string     `json:"value"`
+}
+
+// seleniumIgnored are commands with nothing to replay: the browser window
+// and the moves between what the other commands act on
+var seleniumIgnored = map[string]bool{
+	"setWindowSize": true, "close": true, "echo": true,
+	"mouseOver": true, "mouseOut": true, "mouseMove": true, "mouseDown": true, "mouseUp": true,
+}
+
+// cssID matches ids usable in a #id selector as is
+var cssID = regexp.MustCompile(`^[A-Za-z][\w-]*$`)
+
+// Selenium converts the tests of a Selenium IDE project: open becomes a
+// navigate action, click a click, type a fill, submit and Enter a submit
+// and pause a wait. Elements are located by id, name or CSS locators,
+// whichever the command has; commands only located by XPath or link text,
+// and assertions, waits and other commands with no Panoptic action, are
+// left out with a warning.
+func Selenium(data []byte) (*Result, error) {
+	var project seleniumProject
+	if err := json.Unmarshal(data, &project); err != nil {
+		return nil, fmt.Errorf("invalid Selenium IDE project: %w", err)
+	}
+	base, _ := url.Parse(project.URL)
+	result := &Result{Name: project.Name}
+	for _, t := range project.Tests {
+		test := Test{Name: t.Name}
+		names := namer{}
+		for i, c := range t.Commands {
+			// Commands starting with // are disabled
+			if c.Command == "" || strings.HasPrefix(c.Command, "//") || seleniumIgnored[c.Command] {
+				continue
+			}
+			warn := func(why string) {
+				result.Warnings = append(result.Warnings, fmt.Sprintf("test %s, command %d (%s): %s", t.Name, i+1, c.Command, why))
+			}
+			var actions []config.Action
+			switch c.Command {
+			case "open":
+				target, err := url.Parse(c.Target)
+				if err != nil || base == nil {
+					warn("invalid url")
+					continue
+				}
+				page := base.ResolveReference(target).String()
+				if test.URL == "" {
+					test.URL = page
+				}
+				actions = append(actions, config.Action{Name: names.name("open", pageName(page)), Type: "navigate", URL: page})
+			case "click", "clickAt", "doubleClick", "doubleClickAt", "submit", "type", "sendKeys":
+				selector, ok := seleniumSelector(c)
+				if !ok {
+					warn("no id, name or CSS locator")
+					continue
+				}
+				actions = seleniumInput(names, c, selector)
+				if len(actions) == 0 {
+					warn("no text to type")
+					continue
+				}
+			case "pause":
+				ms, err := strconv.Atoi(c.Target)
+				if err != nil || ms < 0 {
+					warn("invalid duration")
+					continue
+				}
+				actions = append(actions, config.Action{Name: names.name("wait", ""), Type: "wait", WaitTime: max(1, (ms+999)/1000)})
+			default:
+				warn("not supported")
+				continue
+			}
+			test.Actions = append(test.Actions, actions...)
+		}
+		if test.URL == "" && base != nil {
+			test.URL = base.String()
+		}
+		result.Tests = append(result.Tests, test)
+	}
+	return result, nil
+}
+
+// seleniumInput converts a command acting on an element: typing Enter, as
+// ${KEY_ENTER}, submits after filling in what was typed before it
+func seleniumInput(names namer, c seleniumCommand, selector string) []config.Action {
+	switch c.Command {
+	case "click", "clickAt", "doubleClick", "doubleClickAt":
+		return []config.Action{{Name: names.name("click", selector), Type: "click", Selector: selector}}
+	case "submit":
+		return []config.Action{{Name: names.name("submit", selector), Type: "submit", Selector: selector}}
+	}
+	text, _, enter := strings.Cut(c.Value, "${KEY_ENTER}")
+	var actions []config.Action
+	if text != "" {
+		actions = append(actions, config.Action{Name: names.name("fill", selector), Type: "fill", Selector: selector, Value: text})
+	}
+	if enter {
+		actions = append(actions, config.Action{Name: names.name("submit", selector), Type: "submit", Selector: selector})
+	}
+	return actions
+}
+
+// seleniumSelector turns the first id, name or CSS locator of a command
+// into a CSS selector
+func seleniumSelector(c seleniumCommand) (string, bool) {
+	locators := []string{c.Target}
+	for _, alt := range c.Targets {
+		if len(alt) > 0 {
+			locators = append(locators, alt[0])
+		}
+	}
+	for _, locator := range locators {
+		kind, value, ok := strings.Cut(locator, "=")
+		if !ok || value == "" {
+			continue
+		}
+		switch kind {
+		case "id":
+			if cssID.MatchString(value) {
+				return "#" + value, true
+			}
+			return fmt.Sprintf("[id=%q]", value), true
+		case "name":
+			return fmt.Sprintf("[name=%q]", value), true
+		case "css":
+			return value, true
+		}
+	}
+	return "", false
+}
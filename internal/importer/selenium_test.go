@@ -0,0 +1,92 @@
+package importer
+
+import (
+	"testing"
+
+	"panoptic/internal/config"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const seleniumLogin = `{
+  "id": "0b1c", "version": "2.0", "name": "Shop", "url": "https://shop.example.com",
+  "tests": [{
+    "id": "t1", "name": "Log in",
+    "commands": [
+      {"command": "open", "target": "/login", "targets": [], "value": ""},
+      {"command": "setWindowSize", "target": "1280x800", "value": ""},
+      {"command": "click", "target": "linkText=Sign in", "targets": [["linkText=Sign in", "linkText"], ["css=a.sign-in", "css:finder"]], "value": ""},
+      {"command": "type", "target": "id=email", "targets": [], "value": "ada@example.com"},
+      {"command": "type", "target": "name=pass word", "targets": [], "value": "hunter2"},
+      {"command": "sendKeys", "target": "id=user.code", "targets": [], "value": "42${KEY_ENTER}"},
+      {"command": "//click", "target": "id=disabled", "value": ""},
+      {"command": "pause", "target": "1500", "value": ""},
+      {"command": "assertText", "target": "css=h1", "value": "Welcome"},
+      {"command": "click", "target": "xpath=//button[2]", "targets": [["xpath=//button[2]", "xpath:position"]], "value": ""},
+      {"command": "submit", "target": "css=form#login", "value": ""},
+      {"command": "type", "target": "id=coupon", "value": ""}
+    ]
+  }, {
+    "id": "t2", "name": "Browse",
+    "commands": [
+      {"command": "mouseOver", "target": "css=.menu", "value": ""},
+      {"command": "clickAt", "target": "css=.menu a", "value": "10,10"},
+      {"command": "open", "target": "https://help.example.com/faq", "value": ""},
+      {"command": "pause", "target": "soon", "value": ""}
+    ]
+  }],
+  "suites": [{"id": "s1", "name": "Default Suite", "tests": ["t1", "t2"]}]
+}`
+
+func TestSelenium(t *testing.T) {
+	result, err := Selenium([]byte(seleniumLogin))
+	require.NoError(t, err)
+	assert.Equal(t, "Shop", result.Name)
+	require.Len(t, result.Tests, 2)
+
+	login := result.Tests[0]
+	assert.Equal(t, "Log in", login.Name)
+	assert.Equal(t, "https://shop.example.com/login", login.URL)
+	assert.Equal(t, []config.Action{
+		{Name: "open_login", Type: "navigate", URL: "https://shop.example.com/login"},
+		{Name: "click_a_sign_in", Type: "click", Selector: "a.sign-in"},
+		{Name: "fill_email", Type: "fill", Selector: "#email", Value: "ada@example.com"},
+		{Name: "fill_name_pass_word", Type: "fill", Selector: `[name="pass word"]`, Value: "hunter2"},
+		{Name: "fill_id_user_code", Type: "fill", Selector: `[id="user.code"]`, Value: "42"},
+		{Name: "submit_id_user_code", Type: "submit", Selector: `[id="user.code"]`},
+		{Name: "wait", Type: "wait", WaitTime: 2},
+		{Name: "submit_form_login", Type: "submit", Selector: "form#login"},
+	}, login.Actions)
+
+	browse := result.Tests[1]
+	assert.Equal(t, "https://help.example.com/faq", browse.URL, "tests start at the page they open first")
+	assert.Equal(t, []config.Action{
+		{Name: "click_menu_a", Type: "click", Selector: ".menu a"},
+		{Name: "open_faq", Type: "navigate", URL: "https://help.example.com/faq"},
+	}, browse.Actions)
+
+	assert.Equal(t, []string{
+		"test Log in, command 9 (assertText): not supported",
+		"test Log in, command 10 (click): no id, name or CSS locator",
+		"test Log in, command 12 (type): no text to type",
+		"test Browse, command 4 (pause): invalid duration",
+	}, result.Warnings)
+
+	cfg := &config.Config{Apps: []config.AppConfig{
+		{Name: login.Name, Type: "web", URL: login.URL, Actions: login.Actions},
+		{Name: browse.Name, Type: "web", URL: browse.URL, Actions: browse.Actions},
+	}}
+	assert.NoError(t, cfg.Validate())
+}
+
+func TestSelenium_ProjectURL(t *testing.T) {
+	result, err := Selenium([]byte(`{"url": "https://shop.example.com/", "tests": [
+	  {"name": "cart", "commands": [{"command": "click", "target": "id=cart"}]}
+	]}`))
+	require.NoError(t, err)
+	assert.Equal(t, "https://shop.example.com/", result.Tests[0].URL, "tests that open no page start at the project URL")
+
+	_, err = Selenium([]byte(`{"tests": {}}`))
+	assert.ErrorContains(t, err, "invalid Selenium IDE project")
+}
@@ -0,0 +1,114 @@
+// Package inbox reads test mailboxes, MailHog and Mailosaur, so verification
+// emails and SMS codes sent by an app under test can be waited for and mined
+// for links and one-time codes.
+package inbox
+
+import (
+	"context"
+	"fmt"
+	"html"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// Message is an email or SMS as received by the inbox
+type Message struct {
+	ID       string
+	From     string
+	To       []string
+	Subject  string
+	Text     string
+	HTML     string
+	Received time.Time
+}
+
+// Query selects messages; From, To and Subject match case-insensitive
+// substrings, and only messages received after Since and not in Seen match
+type Query struct {
+	From    string
+	To      string
+	Subject string
+	Since   time.Time
+	Seen    map[string]bool // IDs of messages already consumed
+}
+
+// Matches reports whether m satisfies q
+func (q Query) Matches(m Message) bool {
+	if (!q.Since.IsZero() && m.Received.Before(q.Since)) || q.Seen[m.ID] {
+		return false
+	}
+	if !containsFold(m.From, q.From) || !containsFold(m.Subject, q.Subject) {
+		return false
+	}
+	if q.To == "" {
+		return true
+	}
+	for _, to := range m.To {
+		if containsFold(to, q.To) {
+			return true
+		}
+	}
+	return false
+}
+
+func containsFold(s, substr string) bool {
+	return strings.Contains(strings.ToLower(s), strings.ToLower(substr))
+}
+
+// Inbox lists received messages
+type Inbox interface {
+	// Messages returns the messages matching q, newest first
+	Messages(ctx context.Context, q Query) ([]Message, error)
+}
+
+// Wait polls the inbox every interval until a message matches q, returning
+// the newest match, or errors when ctx ends first
+func Wait(ctx context.Context, inbox Inbox, q Query, interval time.Duration) (*Message, error) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	var lastErr error
+	for {
+		messages, err := inbox.Messages(ctx, q)
+		switch {
+		case err == nil:
+			for i := range messages {
+				if q.Matches(messages[i]) {
+					return &messages[i], nil
+				}
+			}
+			lastErr = nil
+		case ctx.Err() == nil: // a request cut short by the timeout says nothing about the inbox
+			lastErr = err
+		}
+
+		select {
+		case <-ctx.Done():
+			if lastErr != nil {
+				return nil, fmt.Errorf("no matching message: %w", lastErr)
+			}
+			return nil, fmt.Errorf("no matching message before the timeout")
+		case <-ticker.C:
+		}
+	}
+}
+
+// Extract matches each named pattern against the message's subject, text and
+// HTML (with entities decoded, so links keep their & separators). A pattern's
+// first capture group is the value, or the whole match without groups.
+func Extract(m *Message, patterns map[string]*regexp.Regexp) (map[string]string, error) {
+	content := strings.Join([]string{m.Subject, m.Text, html.UnescapeString(m.HTML)}, "\n")
+	values := make(map[string]string, len(patterns))
+	for name, pattern := range patterns {
+		match := pattern.FindStringSubmatch(content)
+		if match == nil {
+			return nil, fmt.Errorf("%s: pattern %q not found in message %q", name, pattern, m.Subject)
+		}
+		if len(match) > 1 {
+			values[name] = match[1]
+		} else {
+			values[name] = match[0]
+		}
+	}
+	return values, nil
+}
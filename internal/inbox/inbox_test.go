@@ -0,0 +1,78 @@
+package inbox
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestQuery_Matches tests sender, recipient, subject and time matching
+func TestQuery_Matches(t *testing.T) {
+	now := time.Now()
+	msg := Message{ID: "m1", From: "no-reply@shop.test", To: []string{"Alice@Example.com"}, Subject: "Verify your email", Received: now}
+
+	assert.True(t, Query{}.Matches(msg))
+	assert.True(t, Query{From: "shop.test", To: "alice@example.com", Subject: "verify"}.Matches(msg))
+	assert.False(t, Query{To: "bob@example.com"}.Matches(msg))
+	assert.False(t, Query{Subject: "reset"}.Matches(msg))
+	assert.False(t, Query{Since: now.Add(time.Second)}.Matches(msg))
+	assert.False(t, Query{Seen: map[string]bool{"m1": true}}.Matches(msg))
+}
+
+type fakeInbox struct {
+	calls    int
+	arriveAt int
+	err      error
+}
+
+func (f *fakeInbox) Messages(context.Context, Query) ([]Message, error) {
+	f.calls++
+	if f.err != nil {
+		return nil, f.err
+	}
+	if f.calls < f.arriveAt {
+		return []Message{{Subject: "Newsletter"}}, nil
+	}
+	return []Message{{Subject: "Newsletter"}, {Subject: "Your code"}}, nil
+}
+
+// TestWait tests polling until a message arrives and timing out
+func TestWait(t *testing.T) {
+	inbox := &fakeInbox{arriveAt: 3}
+	msg, err := Wait(t.Context(), inbox, Query{Subject: "code"}, time.Millisecond)
+	require.NoError(t, err)
+	assert.Equal(t, "Your code", msg.Subject)
+	assert.Equal(t, 3, inbox.calls)
+
+	ctx, cancel := context.WithTimeout(t.Context(), 20*time.Millisecond)
+	defer cancel()
+	_, err = Wait(ctx, &fakeInbox{arriveAt: 1000}, Query{Subject: "code"}, time.Millisecond)
+	assert.EqualError(t, err, "no matching message before the timeout")
+
+	ctx, cancel = context.WithTimeout(t.Context(), 20*time.Millisecond)
+	defer cancel()
+	_, err = Wait(ctx, &fakeInbox{err: errors.New("connection refused")}, Query{}, time.Millisecond)
+	assert.EqualError(t, err, "no matching message: connection refused")
+}
+
+// TestExtract tests capture groups, whole matches and HTML entities
+func TestExtract(t *testing.T) {
+	msg := &Message{
+		Subject: "Your code is 482913",
+		HTML:    `<a href="https://shop.test/verify?token=abc&amp;user=7">Verify</a>`,
+	}
+	values, err := Extract(msg, map[string]*regexp.Regexp{
+		"otp":  regexp.MustCompile(`\b(\d{6})\b`),
+		"link": regexp.MustCompile(`https://shop\.test/verify\?[^"]+`),
+	})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"otp": "482913", "link": "https://shop.test/verify?token=abc&user=7"}, values)
+
+	_, err = Extract(msg, map[string]*regexp.Regexp{"reset": regexp.MustCompile(`/reset/\w+`)})
+	assert.ErrorContains(t, err, `reset: pattern "/reset/\\w+" not found in message "Your code is 482913"`)
+}
@@ -0,0 +1,147 @@
+package inbox
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/http"
+	"net/mail"
+	"sort"
+	"strings"
+	"time"
+)
+
+// MailHog reads the messages caught by a MailHog server. It holds only
+// email; there is no SMS.
+type MailHog struct {
+	baseURL    string
+	httpClient *http.Client
+}
+
+// NewMailHog returns an inbox for the MailHog API at baseURL, e.g.
+// http://localhost:8025
+func NewMailHog(baseURL string) *MailHog {
+	return &MailHog{baseURL: strings.TrimRight(baseURL, "/"), httpClient: &http.Client{Timeout: 30 * time.Second}}
+}
+
+type mailhogMessage struct {
+	ID  string `json:"ID"`
+	Raw struct {
+		From string   `json:"From"`
+		To   []string `json:"To"`
+		Data string   `json:"Data"`
+	} `json:"Raw"`
+	Created time.Time `json:"Created"`
+}
+
+// Messages returns the latest 50 messages; the caller filters them with
+// Query.Matches
+func (m *MailHog) Messages(ctx context.Context, q Query) ([]Message, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, m.baseURL+"/api/v2/messages?limit=50", nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := m.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("mailhog: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		return nil, fmt.Errorf("mailhog: status %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+	var list struct {
+		Items []mailhogMessage `json:"items"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&list); err != nil {
+		return nil, fmt.Errorf("mailhog: invalid response: %w", err)
+	}
+
+	messages := make([]Message, 0, len(list.Items))
+	for _, item := range list.Items {
+		msg, err := parseRaw(item.Raw.Data)
+		if err != nil {
+			continue // not every caught message is well-formed; skip it rather than fail the wait
+		}
+		msg.ID, msg.Received = item.ID, item.Created
+		if msg.From == "" {
+			msg.From = item.Raw.From
+		}
+		if len(msg.To) == 0 {
+			msg.To = item.Raw.To
+		}
+		messages = append(messages, *msg)
+	}
+	sort.SliceStable(messages, func(i, j int) bool { return messages[i].Received.After(messages[j].Received) })
+	return messages, nil
+}
+
+// parseRaw parses an RFC 5322 message with its text and HTML parts
+func parseRaw(raw string) (*Message, error) {
+	parsed, err := mail.ReadMessage(strings.NewReader(raw))
+	if err != nil {
+		return nil, err
+	}
+	decoder := new(mime.WordDecoder)
+	msg := &Message{}
+	if msg.Subject, err = decoder.DecodeHeader(parsed.Header.Get("Subject")); err != nil {
+		msg.Subject = parsed.Header.Get("Subject")
+	}
+	if from, err := mail.ParseAddress(parsed.Header.Get("From")); err == nil {
+		msg.From = from.Address
+	}
+	if to, err := parsed.Header.AddressList("To"); err == nil {
+		for _, addr := range to {
+			msg.To = append(msg.To, addr.Address)
+		}
+	}
+	err = readPart(msg, parsed.Header.Get("Content-Type"), parsed.Header.Get("Content-Transfer-Encoding"), parsed.Body)
+	return msg, err
+}
+
+// readPart stores text/plain and text/html bodies, descending into multipart
+// containers
+func readPart(msg *Message, contentType, encoding string, body io.Reader) error {
+	mediaType, params, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		mediaType = "text/plain"
+	}
+	if strings.HasPrefix(mediaType, "multipart/") {
+		reader := multipart.NewReader(body, params["boundary"])
+		for {
+			part, err := reader.NextRawPart()
+			if err == io.EOF {
+				return nil
+			}
+			if err != nil {
+				return err
+			}
+			if err := readPart(msg, part.Header.Get("Content-Type"), part.Header.Get("Content-Transfer-Encoding"), part); err != nil {
+				return err
+			}
+		}
+	}
+
+	switch strings.ToLower(encoding) {
+	case "quoted-printable":
+		body = quotedprintable.NewReader(body)
+	case "base64":
+		body = base64.NewDecoder(base64.StdEncoding, body) // ignores line breaks
+	}
+	data, err := io.ReadAll(body)
+	if err != nil {
+		return err
+	}
+	switch mediaType {
+	case "text/plain":
+		msg.Text += string(data)
+	case "text/html":
+		msg.HTML += string(data)
+	}
+	return nil
+}
@@ -0,0 +1,60 @@
+package inbox
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const multipartEmail = "From: Shop <no-reply@shop.test>\r\n" +
+	"To: alice@example.com\r\n" +
+	"Subject: =?UTF-8?Q?Best=C3=A4tige_deine_E-Mail?=\r\n" +
+	"MIME-Version: 1.0\r\n" +
+	"Content-Type: multipart/alternative; boundary=b1\r\n" +
+	"\r\n" +
+	"--b1\r\n" +
+	"Content-Type: text/plain; charset=utf-8\r\n" +
+	"Content-Transfer-Encoding: quoted-printable\r\n" +
+	"\r\n" +
+	"Your code: 482913. Open https://shop.test/verify?token=3D=\r\nabc\r\n" +
+	"--b1\r\n" +
+	"Content-Type: text/html; charset=utf-8\r\n" +
+	"Content-Transfer-Encoding: base64\r\n" +
+	"\r\n" +
+	"PGEgaHJlZj0iaHR0cHM6Ly9zaG9wLnRlc3QvdmVyaWZ5P3Rva2VuPWFiYyI+\r\nVmVyaWZ5PC9hPg==\r\n" +
+	"--b1--\r\n"
+
+// TestParseRaw tests decoding headers and multipart bodies
+func TestParseRaw(t *testing.T) {
+	msg, err := parseRaw(multipartEmail)
+	require.NoError(t, err)
+	assert.Equal(t, "no-reply@shop.test", msg.From)
+	assert.Equal(t, []string{"alice@example.com"}, msg.To)
+	assert.Equal(t, "Bestätige deine E-Mail", msg.Subject)
+	assert.Equal(t, "Your code: 482913. Open https://shop.test/verify?token=abc", strings.TrimSpace(msg.Text))
+	assert.Equal(t, `<a href="https://shop.test/verify?token=abc">Verify</a>`, msg.HTML)
+}
+
+// TestMailHog_Messages tests reading the v2 messages API
+func TestMailHog_Messages(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/api/v2/messages?limit=50", r.URL.RequestURI())
+		w.Write([]byte(`{"items":[
+			{"ID":"old","Created":"2026-05-04T10:00:00Z","Raw":{"From":"a@shop.test","To":["bob@example.com"],"Data":"Subject: Old\r\n\r\nold"}},
+			{"ID":"new","Created":"2026-05-04T10:05:00Z","Raw":{"From":"no-reply@shop.test","To":["alice@example.com"],"Data":` +
+			strings.ReplaceAll(strings.ReplaceAll(`"`+multipartEmail+`"`, "\r", `\r`), "\n", `\n`) + `}}]}`))
+	}))
+	defer server.Close()
+
+	messages, err := NewMailHog(server.URL+"/").Messages(t.Context(), Query{})
+	require.NoError(t, err)
+	require.Len(t, messages, 2)
+	assert.Equal(t, "new", messages[0].ID, "newest first")
+	assert.Equal(t, "Bestätige deine E-Mail", messages[0].Subject)
+	assert.Equal(t, "a@shop.test", messages[1].From, "envelope sender when the header has none")
+	assert.Equal(t, []string{"bob@example.com"}, messages[1].To)
+}
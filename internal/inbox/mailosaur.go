@@ -0,0 +1,146 @@
+package inbox
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// DefaultMailosaurURL is the Mailosaur API
+const DefaultMailosaurURL = "https://mailosaur.com"
+
+// Mailosaur reads the emails and SMS messages of one Mailosaur server. SMS
+// messages are matched on the receiving phone number with Query.To.
+type Mailosaur struct {
+	baseURL    string
+	apiKey     string
+	server     string
+	httpClient *http.Client
+}
+
+// NewMailosaur returns an inbox for a server ID
+func NewMailosaur(apiKey, baseURL, server string) *Mailosaur {
+	if baseURL == "" {
+		baseURL = DefaultMailosaurURL
+	}
+	return &Mailosaur{
+		baseURL:    strings.TrimRight(baseURL, "/"),
+		apiKey:     apiKey,
+		server:     server,
+		httpClient: &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+type mailosaurAddress struct {
+	Email string `json:"email"`
+	Phone string `json:"phone"`
+}
+
+func (a mailosaurAddress) String() string {
+	if a.Email != "" {
+		return a.Email
+	}
+	return a.Phone
+}
+
+type mailosaurMessage struct {
+	ID       string             `json:"id"`
+	From     []mailosaurAddress `json:"from"`
+	To       []mailosaurAddress `json:"to"`
+	Subject  string             `json:"subject"`
+	Received time.Time          `json:"received"`
+	Text     struct {
+		Body string `json:"body"`
+	} `json:"text"`
+	HTML struct {
+		Body string `json:"body"`
+	} `json:"html"`
+}
+
+func (m mailosaurMessage) message() Message {
+	msg := Message{ID: m.ID, Subject: m.Subject, Received: m.Received, Text: m.Text.Body, HTML: m.HTML.Body}
+	if len(m.From) > 0 {
+		msg.From = m.From[0].String()
+	}
+	for _, to := range m.To {
+		msg.To = append(msg.To, to.String())
+	}
+	return msg
+}
+
+// Messages searches the server and fetches the full content of each match;
+// Mailosaur matches From, To and Subject itself
+func (m *Mailosaur) Messages(ctx context.Context, q Query) ([]Message, error) {
+	criteria := map[string]string{}
+	if q.From != "" {
+		criteria["sentFrom"] = q.From
+	}
+	if q.To != "" {
+		criteria["sentTo"] = q.To
+	}
+	if q.Subject != "" {
+		criteria["subject"] = q.Subject
+	}
+	params := url.Values{"server": {m.server}, "itemsPerPage": {"10"}}
+	if !q.Since.IsZero() {
+		params.Set("receivedAfter", q.Since.UTC().Format(time.RFC3339))
+	}
+
+	var summaries struct {
+		Items []mailosaurMessage `json:"items"`
+	}
+	if err := m.do(ctx, http.MethodPost, "/api/messages/search?"+params.Encode(), criteria, &summaries); err != nil {
+		return nil, err
+	}
+	messages := make([]Message, 0, len(summaries.Items))
+	for _, summary := range summaries.Items {
+		var full mailosaurMessage
+		if err := m.do(ctx, http.MethodGet, "/api/messages/"+url.PathEscape(summary.ID), nil, &full); err != nil {
+			return nil, err
+		}
+		messages = append(messages, full.message())
+	}
+	return messages, nil
+}
+
+func (m *Mailosaur) do(ctx context.Context, method, path string, body, out interface{}) error {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reader = bytes.NewReader(data)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, m.baseURL+path, reader)
+	if err != nil {
+		return err
+	}
+	req.SetBasicAuth(m.apiKey, "")
+	req.Header.Set("Accept", "application/json")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	resp, err := m.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("mailosaur: %w", err)
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 10<<20))
+	if err != nil {
+		return fmt.Errorf("mailosaur: %w", err)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("mailosaur: status %d: %s", resp.StatusCode, bytes.TrimSpace(data))
+	}
+	if err := json.Unmarshal(data, out); err != nil {
+		return fmt.Errorf("mailosaur: invalid response: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,57 @@
+package inbox
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestMailosaur_Messages tests the search criteria and fetching full messages
+func TestMailosaur_Messages(t *testing.T) {
+	var criteria map[string]string
+	var searchQuery string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		user, password, _ := r.BasicAuth()
+		assert.Equal(t, "api-key", user)
+		assert.Empty(t, password)
+		switch r.URL.Path {
+		case "/api/messages/search":
+			searchQuery = r.URL.RawQuery
+			json.NewDecoder(r.Body).Decode(&criteria)
+			w.Write([]byte(`{"items":[{"id":"m1"}]}`))
+		case "/api/messages/m1":
+			w.Write([]byte(`{"id":"m1","from":[{"phone":"+15550100"}],"to":[{"phone":"+15550199"}],
+				"received":"2026-05-04T10:05:00Z","text":{"body":"Your Shop code is 482913"},"html":{"body":""}}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	since := time.Date(2026, 5, 4, 10, 0, 0, 0, time.UTC)
+	messages, err := NewMailosaur("api-key", server.URL, "srv1").Messages(t.Context(), Query{To: "+15550199", Since: since})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"sentTo": "+15550199"}, criteria)
+	assert.Equal(t, "itemsPerPage=10&receivedAfter=2026-05-04T10%3A00%3A00Z&server=srv1", searchQuery)
+	require.Len(t, messages, 1)
+	assert.Equal(t, Message{
+		ID: "m1", From: "+15550100", To: []string{"+15550199"},
+		Text: "Your Shop code is 482913", Received: time.Date(2026, 5, 4, 10, 5, 0, 0, time.UTC),
+	}, messages[0])
+}
+
+// TestMailosaur_Error tests that API errors carry the status and body
+func TestMailosaur_Error(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, `{"type":"authentication_error"}`, http.StatusUnauthorized)
+	}))
+	defer server.Close()
+
+	_, err := NewMailosaur("bad", server.URL, "srv1").Messages(t.Context(), Query{})
+	assert.ErrorContains(t, err, "mailosaur: status 401")
+}
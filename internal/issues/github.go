@@ -0,0 +1,57 @@
+package issues
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"panoptic/internal/github"
+)
+
+// GitHub files GitHub issues. The fingerprint is hidden in the issue body.
+// GitHub has no API for attaching files to issues, so the body names the
+// bundle's path in the run's output instead.
+type GitHub struct {
+	Repo   string // owner/name
+	Labels []string
+	client *github.Client
+}
+
+// NewGitHub creates a GitHub Issues tracker
+func NewGitHub(apiURL, repo, token string, labels []string) *GitHub {
+	return &GitHub{Repo: repo, Labels: labels, client: github.NewClient(apiURL, token)}
+}
+
+// Find looks through open Panoptic issues for the fingerprint marker
+func (g *GitHub) Find(ctx context.Context, fingerprint string) (*Issue, error) {
+	issue, err := g.client.FindIssue(ctx, g.Repo, Label, marker(fingerprint))
+	if err != nil || issue == nil {
+		return nil, err
+	}
+	return &Issue{ID: strconv.Itoa(issue.Number), URL: issue.HTMLURL}, nil
+}
+
+// Create opens an issue
+func (g *GitHub) Create(ctx context.Context, f Failure) (*Issue, error) {
+	issue, err := g.client.CreateIssue(ctx, g.Repo, f.Title(), markdownBody(f, bundleNote(f)), append([]string{Label}, g.Labels...))
+	if err != nil {
+		return nil, err
+	}
+	return &Issue{ID: strconv.Itoa(issue.Number), URL: issue.HTMLURL}, nil
+}
+
+// Update comments on the issue
+func (g *GitHub) Update(ctx context.Context, issue *Issue, f Failure) error {
+	number, err := strconv.Atoi(issue.ID)
+	if err != nil {
+		return fmt.Errorf("invalid GitHub issue number %q", issue.ID)
+	}
+	return g.client.CommentIssue(ctx, g.Repo, number, markdownComment(f, bundleNote(f)))
+}
+
+func bundleNote(f Failure) string {
+	if f.Bundle == "" {
+		return ""
+	}
+	return "`" + f.Bundle + "` in the run's output"
+}
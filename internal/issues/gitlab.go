@@ -0,0 +1,105 @@
+package issues
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// DefaultGitLabURL is gitlab.com
+const DefaultGitLabURL = "https://gitlab.com"
+
+// GitLab files issues through the GitLab REST API v4. The fingerprint is
+// hidden in the description and found with the issue search.
+type GitLab struct {
+	BaseURL string // instance, e.g. https://gitlab.com
+	Project string // numeric ID or full path such as acme/shop
+	Labels  []string
+	http    httpClient
+}
+
+// NewGitLab creates a GitLab tracker authenticated with a personal, project
+// or group access token
+func NewGitLab(baseURL, project, token string, labels []string) *GitLab {
+	if baseURL == "" {
+		baseURL = DefaultGitLabURL
+	}
+	return &GitLab{
+		BaseURL: strings.TrimRight(baseURL, "/"),
+		Project: project,
+		Labels:  labels,
+		http:    httpClient{client: &http.Client{Timeout: 60 * time.Second}, headers: map[string]string{"PRIVATE-TOKEN": token}},
+	}
+}
+
+func (g *GitLab) projectURL() string {
+	return g.BaseURL + "/api/v4/projects/" + url.PathEscape(g.Project)
+}
+
+type gitlabIssue struct {
+	IID         int    `json:"iid"`
+	WebURL      string `json:"web_url"`
+	Description string `json:"description"`
+}
+
+// Find searches open Panoptic issues for the fingerprint marker
+func (g *GitLab) Find(ctx context.Context, fingerprint string) (*Issue, error) {
+	query := url.Values{"state": {"opened"}, "labels": {Label}, "search": {fingerprint}, "in": {"description"}, "per_page": {"100"}}
+	var found []gitlabIssue
+	if err := g.http.do(ctx, http.MethodGet, g.projectURL()+"/issues?"+query.Encode(), nil, &found); err != nil {
+		return nil, fmt.Errorf("gitlab: %w", err)
+	}
+	for _, issue := range found {
+		if strings.Contains(issue.Description, marker(fingerprint)) {
+			return &Issue{ID: fmt.Sprint(issue.IID), URL: issue.WebURL}, nil
+		}
+	}
+	return nil, nil
+}
+
+// Create uploads the failure bundle and opens an issue linking it
+func (g *GitLab) Create(ctx context.Context, f Failure) (*Issue, error) {
+	bundle, err := g.uploadBundle(ctx, f)
+	if err != nil {
+		return nil, err
+	}
+	request := map[string]string{
+		"title":       f.Title(),
+		"description": markdownBody(f, bundle),
+		"labels":      strings.Join(append([]string{Label}, g.Labels...), ","),
+	}
+	var created gitlabIssue
+	if err := g.http.do(ctx, http.MethodPost, g.projectURL()+"/issues", request, &created); err != nil {
+		return nil, fmt.Errorf("gitlab: %w", err)
+	}
+	return &Issue{ID: fmt.Sprint(created.IID), URL: created.WebURL}, nil
+}
+
+// Update uploads this run's bundle and comments on the issue
+func (g *GitLab) Update(ctx context.Context, issue *Issue, f Failure) error {
+	bundle, err := g.uploadBundle(ctx, f)
+	if err != nil {
+		return err
+	}
+	if err := g.http.do(ctx, http.MethodPost, g.projectURL()+"/issues/"+issue.ID+"/notes", map[string]string{"body": markdownComment(f, bundle)}, nil); err != nil {
+		return fmt.Errorf("gitlab: %w", err)
+	}
+	return nil
+}
+
+// uploadBundle returns the Markdown link of the uploaded bundle
+func (g *GitLab) uploadBundle(ctx context.Context, f Failure) (string, error) {
+	if f.Bundle == "" {
+		return "", nil
+	}
+	var uploaded struct {
+		Markdown string `json:"markdown"`
+	}
+	if err := g.http.upload(ctx, g.projectURL()+"/uploads", "file", f.Bundle, &uploaded, nil); err != nil {
+		return "", fmt.Errorf("gitlab: failed to upload failure bundle: %w", err)
+	}
+	return uploaded.Markdown, nil
+}
@@ -0,0 +1,71 @@
+package issues
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestGitLab tests search by marker, bundle upload, creation and notes
+func TestGitLab(t *testing.T) {
+	var description, note, token, search string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token = r.Header.Get("PRIVATE-TOKEN")
+		switch {
+		case r.URL.RawPath == "/api/v4/projects/acme%2Fshop/issues" && r.Method == http.MethodGet:
+			search = r.URL.Query().Get("search")
+			issues := []map[string]interface{}{{"iid": 1, "description": "mentions abc123 without the marker"}}
+			if description != "" {
+				issues = append(issues, map[string]interface{}{"iid": 2, "web_url": "https://gitlab.com/acme/shop/-/issues/2", "description": description})
+			}
+			_ = json.NewEncoder(w).Encode(issues)
+		case r.URL.RawPath == "/api/v4/projects/acme%2Fshop/uploads":
+			_, header, err := r.FormFile("file")
+			require.NoError(t, err)
+			fmt.Fprintf(w, `{"markdown":"[%s](/uploads/x/%s)"}`, header.Filename, header.Filename)
+		case r.URL.RawPath == "/api/v4/projects/acme%2Fshop/issues":
+			var body map[string]string
+			_ = json.NewDecoder(r.Body).Decode(&body)
+			description = body["description"]
+			assert.Equal(t, "panoptic,e2e", body["labels"])
+			fmt.Fprint(w, `{"iid":2,"web_url":"https://gitlab.com/acme/shop/-/issues/2"}`)
+		case r.URL.RawPath == "/api/v4/projects/acme%2Fshop/issues/2/notes":
+			var body map[string]string
+			_ = json.NewDecoder(r.Body).Decode(&body)
+			note = body["body"]
+		default:
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.RawPath)
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	bundle := filepath.Join(t.TempDir(), "abc123.zip")
+	require.NoError(t, os.WriteFile(bundle, []byte("zip"), 0600))
+	failure := testFailure
+	failure.Bundle = bundle
+
+	gitlab := NewGitLab(server.URL, "acme/shop", "glpat", []string{"e2e"})
+	issue, created, err := File(context.Background(), gitlab, failure)
+	require.NoError(t, err)
+	assert.True(t, created, "an issue mentioning the fingerprint without the marker doesn't match")
+	assert.Equal(t, "2", issue.ID)
+	assert.Equal(t, "glpat", token)
+	assert.Equal(t, "abc123", search)
+	assert.Contains(t, description, "Failure bundle: [abc123.zip](/uploads/x/abc123.zip)")
+
+	issue, created, err = File(context.Background(), gitlab, failure)
+	require.NoError(t, err)
+	assert.False(t, created)
+	assert.Equal(t, "https://gitlab.com/acme/shop/-/issues/2", issue.URL)
+	assert.Contains(t, note, "Failed again in run `run-1`")
+	assert.Contains(t, note, "/uploads/x/abc123.zip")
+}
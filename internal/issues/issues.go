@@ -0,0 +1,198 @@
+// Package issues files test failures in issue trackers (Jira, GitHub Issues,
+// GitLab), keeping one open issue per failure fingerprint: a new failure opens
+// an issue and later runs with the same fingerprint comment on it.
+package issues
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Label marks issues filed by Panoptic
+const Label = "panoptic"
+
+// Failure is a unique failure of a run; results of browser variants with the
+// same fingerprint are filed together
+type Failure struct {
+	Fingerprint string
+	App         string
+	Action      string // empty when the app failed outside an action
+	Category    string
+	Error       string
+	Browsers    []string
+	RunID       string
+	ReportURL   string
+	Bundle      string // local path of the failure bundle zip; empty if none
+}
+
+// Title is the issue title
+func (f Failure) Title() string {
+	if f.Action != "" {
+		return fmt.Sprintf("Panoptic: %s fails at %s", f.App, f.Action)
+	}
+	return fmt.Sprintf("Panoptic: %s fails", f.App)
+}
+
+// Issue is an issue in a tracker
+type Issue struct {
+	ID  string // Jira key, GitHub number or GitLab IID
+	URL string
+}
+
+// Tracker finds, opens and updates issues by fingerprint
+type Tracker interface {
+	// Find returns the open issue for a fingerprint, or nil
+	Find(ctx context.Context, fingerprint string) (*Issue, error)
+	// Create opens an issue carrying the fingerprint
+	Create(ctx context.Context, f Failure) (*Issue, error)
+	// Update records a repeat of the failure on an open issue
+	Update(ctx context.Context, issue *Issue, f Failure) error
+}
+
+// File opens an issue for the failure, or updates the open one with the same
+// fingerprint; created reports which happened
+func File(ctx context.Context, tracker Tracker, f Failure) (issue *Issue, created bool, err error) {
+	issue, err = tracker.Find(ctx, f.Fingerprint)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to look up issue for %s: %w", f.Fingerprint, err)
+	}
+	if issue != nil {
+		return issue, false, tracker.Update(ctx, issue, f)
+	}
+	issue, err = tracker.Create(ctx, f)
+	return issue, true, err
+}
+
+// marker is the fingerprint tag hidden in Markdown issue bodies
+func marker(fingerprint string) string {
+	return "<!-- panoptic-fingerprint: " + fingerprint + " -->"
+}
+
+// markdownBody renders a new issue; bundle is a link or note for the failure bundle
+func markdownBody(f Failure, bundle string) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Panoptic found a new failure in run `%s`.\n\n", f.RunID)
+	fmt.Fprintf(&b, "| | |\n|---|---|\n| App | %s |\n", f.App)
+	if f.Action != "" {
+		fmt.Fprintf(&b, "| Action | %s |\n", f.Action)
+	}
+	if f.Category != "" {
+		fmt.Fprintf(&b, "| Category | %s |\n", f.Category)
+	}
+	if len(f.Browsers) > 0 {
+		fmt.Fprintf(&b, "| Browsers | %s |\n", strings.Join(f.Browsers, ", "))
+	}
+	fmt.Fprintf(&b, "| Fingerprint | `%s` |\n\n```\n%s\n```\n", f.Fingerprint, f.Error)
+	if f.ReportURL != "" {
+		fmt.Fprintf(&b, "\n[Report](%s)\n", f.ReportURL)
+	}
+	if bundle != "" {
+		fmt.Fprintf(&b, "\nFailure bundle: %s\n", bundle)
+	}
+	fmt.Fprintf(&b, "\n%s\n", marker(f.Fingerprint))
+	return b.String()
+}
+
+// markdownComment renders a repeat of the failure
+func markdownComment(f Failure, bundle string) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Failed again in run `%s`", f.RunID)
+	if len(f.Browsers) > 0 {
+		fmt.Fprintf(&b, " (%s)", strings.Join(f.Browsers, ", "))
+	}
+	fmt.Fprintf(&b, ".\n\n```\n%s\n```\n", f.Error)
+	if f.ReportURL != "" {
+		fmt.Fprintf(&b, "\n[Report](%s)\n", f.ReportURL)
+	}
+	if bundle != "" {
+		fmt.Fprintf(&b, "\nFailure bundle: %s\n", bundle)
+	}
+	return b.String()
+}
+
+// httpClient is the shared JSON-over-HTTP plumbing of the trackers
+type httpClient struct {
+	client  *http.Client
+	headers map[string]string
+}
+
+func (c *httpClient) do(ctx context.Context, method, url string, body, out interface{}) error {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return fmt.Errorf("failed to encode request: %w", err)
+		}
+		reader = bytes.NewReader(data)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, url, reader)
+	if err != nil {
+		return fmt.Errorf("failed to build request: %w", err)
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	req.Header.Set("Accept", "application/json")
+	return c.send(req, out)
+}
+
+// upload posts a file as multipart form field
+func (c *httpClient) upload(ctx context.Context, url, field, path string, out interface{}, headers map[string]string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("failed to open %s: %w", path, err)
+	}
+	defer f.Close()
+
+	var buf bytes.Buffer
+	mw := multipart.NewWriter(&buf)
+	part, err := mw.CreateFormFile(field, filepath.Base(path))
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(part, f); err != nil {
+		return fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	if err := mw.Close(); err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, &buf)
+	if err != nil {
+		return fmt.Errorf("failed to build request: %w", err)
+	}
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+	return c.send(req, out)
+}
+
+func (c *httpClient) send(req *http.Request, out interface{}) error {
+	for k, v := range c.headers {
+		req.Header.Set(k, v)
+	}
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	data, _ := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("%s %s: status %d: %s", req.Method, req.URL.Path, resp.StatusCode, bytes.TrimSpace(data))
+	}
+	if out != nil && len(data) > 0 {
+		if err := json.Unmarshal(data, out); err != nil {
+			return fmt.Errorf("failed to decode response: %w", err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,70 @@
+package issues
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// memoryTracker is a Tracker keeping issues in memory
+type memoryTracker struct {
+	open    map[string]*Issue
+	updates int
+	findErr error
+}
+
+func (m *memoryTracker) Find(ctx context.Context, fingerprint string) (*Issue, error) {
+	return m.open[fingerprint], m.findErr
+}
+
+func (m *memoryTracker) Create(ctx context.Context, f Failure) (*Issue, error) {
+	issue := &Issue{ID: f.Fingerprint}
+	m.open[f.Fingerprint] = issue
+	return issue, nil
+}
+
+func (m *memoryTracker) Update(ctx context.Context, issue *Issue, f Failure) error {
+	m.updates++
+	return nil
+}
+
+var testFailure = Failure{
+	Fingerprint: "abc123", App: "Shop", Action: "pay", Category: "functional", Error: "timeout",
+	Browsers: []string{"chromium", "firefox"}, RunID: "run-1", ReportURL: "https://r.example.com/run-1",
+}
+
+// TestFile tests that a fingerprint gets one issue across runs
+func TestFile(t *testing.T) {
+	tracker := &memoryTracker{open: make(map[string]*Issue)}
+	issue, created, err := File(context.Background(), tracker, testFailure)
+	require.NoError(t, err)
+	assert.True(t, created)
+
+	again, created, err := File(context.Background(), tracker, testFailure)
+	require.NoError(t, err)
+	assert.False(t, created)
+	assert.Same(t, issue, again)
+	assert.Equal(t, 1, tracker.updates)
+
+	tracker.findErr = errors.New("unauthorized")
+	_, _, err = File(context.Background(), tracker, testFailure)
+	assert.ErrorContains(t, err, "failed to look up issue for abc123: unauthorized")
+}
+
+// TestMarkdown tests issue bodies and comments
+func TestMarkdown(t *testing.T) {
+	assert.Equal(t, "Panoptic: Shop fails at pay", testFailure.Title())
+	assert.Equal(t, "Panoptic: Shop fails", Failure{App: "Shop"}.Title())
+
+	body := markdownBody(testFailure, "[bundle.zip](/uploads/x/bundle.zip)")
+	assert.Contains(t, body, "| Browsers | chromium, firefox |")
+	assert.Contains(t, body, "Failure bundle: [bundle.zip](/uploads/x/bundle.zip)")
+	assert.Contains(t, body, marker("abc123"))
+
+	comment := markdownComment(testFailure, "")
+	assert.Contains(t, comment, "Failed again in run `run-1` (chromium, firefox)")
+	assert.NotContains(t, comment, "Failure bundle")
+}
@@ -0,0 +1,137 @@
+package issues
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Jira files issues through the Jira REST API v2, which Jira Cloud, Server
+// and Data Center all serve. The fingerprint is kept as an issue label.
+type Jira struct {
+	BaseURL   string // site, e.g. https://acme.atlassian.net
+	Project   string // project key
+	IssueType string // default Bug
+	Labels    []string
+	http      httpClient
+}
+
+// NewJira creates a Jira tracker. With a username (Jira Cloud account email)
+// the token is an API token sent with basic auth; without one it is a
+// personal access token (Server and Data Center).
+func NewJira(baseURL, project, issueType, username, token string, labels []string) *Jira {
+	auth := "Bearer " + token
+	if username != "" {
+		auth = "Basic " + base64.StdEncoding.EncodeToString([]byte(username+":"+token))
+	}
+	if issueType == "" {
+		issueType = "Bug"
+	}
+	return &Jira{
+		BaseURL:   strings.TrimRight(baseURL, "/"),
+		Project:   project,
+		IssueType: issueType,
+		Labels:    labels,
+		http:      httpClient{client: &http.Client{Timeout: 60 * time.Second}, headers: map[string]string{"Authorization": auth}},
+	}
+}
+
+func jiraLabel(fingerprint string) string {
+	return Label + "-" + fingerprint
+}
+
+// Find searches unresolved issues of the project for the fingerprint label
+func (j *Jira) Find(ctx context.Context, fingerprint string) (*Issue, error) {
+	jql := fmt.Sprintf(`project = "%s" AND labels = "%s" AND statusCategory != Done ORDER BY created DESC`, j.Project, jiraLabel(fingerprint))
+	var resp struct {
+		Issues []struct {
+			Key string `json:"key"`
+		} `json:"issues"`
+	}
+	query := url.Values{"jql": {jql}, "maxResults": {"1"}, "fields": {"key"}}
+	if err := j.http.do(ctx, http.MethodGet, j.BaseURL+"/rest/api/2/search?"+query.Encode(), nil, &resp); err != nil {
+		return nil, fmt.Errorf("jira: %w", err)
+	}
+	if len(resp.Issues) == 0 {
+		return nil, nil
+	}
+	return j.issue(resp.Issues[0].Key), nil
+}
+
+// Create opens an issue and attaches the failure bundle
+func (j *Jira) Create(ctx context.Context, f Failure) (*Issue, error) {
+	fields := map[string]interface{}{
+		"project":     map[string]string{"key": j.Project},
+		"issuetype":   map[string]string{"name": j.IssueType},
+		"summary":     f.Title(),
+		"description": jiraDescription(f),
+		"labels":      append([]string{Label, jiraLabel(f.Fingerprint)}, j.Labels...),
+	}
+	var created struct {
+		Key string `json:"key"`
+	}
+	if err := j.http.do(ctx, http.MethodPost, j.BaseURL+"/rest/api/2/issue", map[string]interface{}{"fields": fields}, &created); err != nil {
+		return nil, fmt.Errorf("jira: %w", err)
+	}
+	issue := j.issue(created.Key)
+	return issue, j.attach(ctx, issue, f)
+}
+
+// Update comments on the issue and attaches this run's bundle
+func (j *Jira) Update(ctx context.Context, issue *Issue, f Failure) error {
+	body := fmt.Sprintf("Failed again in run {{%s}}", f.RunID)
+	if len(f.Browsers) > 0 {
+		body += " (" + strings.Join(f.Browsers, ", ") + ")"
+	}
+	body += ".\n{noformat}\n" + f.Error + "\n{noformat}"
+	if f.ReportURL != "" {
+		body += "\n[Report|" + f.ReportURL + "]"
+	}
+	if err := j.http.do(ctx, http.MethodPost, j.BaseURL+"/rest/api/2/issue/"+issue.ID+"/comment", map[string]string{"body": body}, nil); err != nil {
+		return fmt.Errorf("jira: %w", err)
+	}
+	return j.attach(ctx, issue, f)
+}
+
+func (j *Jira) attach(ctx context.Context, issue *Issue, f Failure) error {
+	if f.Bundle == "" {
+		return nil
+	}
+	url := j.BaseURL + "/rest/api/2/issue/" + issue.ID + "/attachments"
+	if err := j.http.upload(ctx, url, "file", f.Bundle, nil, map[string]string{"X-Atlassian-Token": "no-check"}); err != nil {
+		return fmt.Errorf("jira: issue %s filed but attaching the failure bundle failed: %w", issue.ID, err)
+	}
+	return nil
+}
+
+func (j *Jira) issue(key string) *Issue {
+	return &Issue{ID: key, URL: j.BaseURL + "/browse/" + key}
+}
+
+// jiraDescription renders a new issue in Jira wiki markup
+func jiraDescription(f Failure) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Panoptic found a new failure in run {{%s}}.\n\n", f.RunID)
+	fmt.Fprintf(&b, "||App|%s|\n", f.App)
+	if f.Action != "" {
+		fmt.Fprintf(&b, "||Action|%s|\n", f.Action)
+	}
+	if f.Category != "" {
+		fmt.Fprintf(&b, "||Category|%s|\n", f.Category)
+	}
+	if len(f.Browsers) > 0 {
+		fmt.Fprintf(&b, "||Browsers|%s|\n", strings.Join(f.Browsers, ", "))
+	}
+	fmt.Fprintf(&b, "||Fingerprint|{{%s}}|\n\n{noformat}\n%s\n{noformat}\n", f.Fingerprint, f.Error)
+	if f.ReportURL != "" {
+		fmt.Fprintf(&b, "\n[Report|%s]\n", f.ReportURL)
+	}
+	if f.Bundle != "" {
+		b.WriteString("\nThe failure bundle (results, screenshots, trace and logs) is attached.\n")
+	}
+	return b.String()
+}
@@ -0,0 +1,83 @@
+package issues
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestJira tests search, creation, comments and bundle attachment
+func TestJira(t *testing.T) {
+	var jql, auth, atlassianToken, attached string
+	var created map[string]interface{}
+	var comment string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		auth = r.Header.Get("Authorization")
+		switch r.URL.Path {
+		case "/rest/api/2/search":
+			jql = r.URL.Query().Get("jql")
+			if created == nil {
+				fmt.Fprint(w, `{"issues":[]}`)
+			} else {
+				fmt.Fprint(w, `{"issues":[{"key":"SHOP-7"}]}`)
+			}
+		case "/rest/api/2/issue":
+			_ = json.NewDecoder(r.Body).Decode(&created)
+			fmt.Fprint(w, `{"key":"SHOP-7"}`)
+		case "/rest/api/2/issue/SHOP-7/comment":
+			var body map[string]string
+			_ = json.NewDecoder(r.Body).Decode(&body)
+			comment = body["body"]
+		case "/rest/api/2/issue/SHOP-7/attachments":
+			atlassianToken = r.Header.Get("X-Atlassian-Token")
+			file, header, err := r.FormFile("file")
+			require.NoError(t, err)
+			data, _ := io.ReadAll(file)
+			attached = header.Filename + ":" + string(data)
+			fmt.Fprint(w, `[]`)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	bundle := filepath.Join(t.TempDir(), "abc123.zip")
+	require.NoError(t, os.WriteFile(bundle, []byte("zip"), 0600))
+	failure := testFailure
+	failure.Bundle = bundle
+
+	jira := NewJira(server.URL, "SHOP", "", "qa@acme.com", "api-token", []string{"e2e"})
+	tracker := Tracker(jira)
+	issue, created1, err := File(context.Background(), tracker, failure)
+	require.NoError(t, err)
+	assert.True(t, created1)
+	assert.Equal(t, &Issue{ID: "SHOP-7", URL: server.URL + "/browse/SHOP-7"}, issue)
+	assert.Equal(t, `project = "SHOP" AND labels = "panoptic-abc123" AND statusCategory != Done ORDER BY created DESC`, jql)
+	assert.Equal(t, "Basic cWFAYWNtZS5jb206YXBpLXRva2Vu", auth)
+
+	fields := created["fields"].(map[string]interface{})
+	assert.Equal(t, "Bug", fields["issuetype"].(map[string]interface{})["name"])
+	assert.Equal(t, []interface{}{"panoptic", "panoptic-abc123", "e2e"}, fields["labels"])
+	assert.Contains(t, fields["description"], "||Browsers|chromium, firefox|")
+	assert.Equal(t, "no-check", atlassianToken)
+	assert.Equal(t, "abc123.zip:zip", attached)
+
+	_, created2, err := File(context.Background(), tracker, failure)
+	require.NoError(t, err)
+	assert.False(t, created2)
+	assert.Contains(t, comment, "Failed again in run {{run-1}}")
+
+	pat := NewJira(server.URL, "SHOP", "Task", "", "pat", nil)
+	_, err = pat.Find(context.Background(), "abc123")
+	require.NoError(t, err)
+	assert.Equal(t, "Bearer pat", auth)
+}
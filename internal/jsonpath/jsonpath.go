@@ -0,0 +1,176 @@
+// Package jsonpath selects values of decoded JSON documents with the paths
+// assertions name them by: $.messages[0].text, $.users[*].name or
+// $["content-type"]. A path is a chain of fields, indexes, counted from the
+// end when negative, and * for every item or field.
+package jsonpath
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Path is a parsed JSON path
+type Path struct {
+	expr  string
+	steps []step
+}
+
+type step struct {
+	field    string
+	index    int
+	isIndex  bool
+	wildcard bool
+}
+
+// Parse reads a path; the leading $ is optional
+func Parse(expr string) (*Path, error) {
+	p := &Path{expr: expr}
+	s := strings.TrimSpace(expr)
+	s = strings.TrimPrefix(s, "$")
+	for s != "" {
+		switch s[0] {
+		case '.':
+			s = s[1:]
+			end := strings.IndexAny(s, ".[")
+			if end < 0 {
+				end = len(s)
+			}
+			field := s[:end]
+			if field == "" {
+				return nil, fmt.Errorf("invalid JSON path %q: empty field", expr)
+			}
+			if field == "*" {
+				p.steps = append(p.steps, step{wildcard: true})
+			} else {
+				p.steps = append(p.steps, step{field: field})
+			}
+			s = s[end:]
+		case '[':
+			end := strings.IndexByte(s, ']')
+			if end < 0 {
+				return nil, fmt.Errorf("invalid JSON path %q: unclosed [", expr)
+			}
+			inner := strings.TrimSpace(s[1:end])
+			switch {
+			case inner == "*":
+				p.steps = append(p.steps, step{wildcard: true})
+			case len(inner) >= 2 && (inner[0] == '"' || inner[0] == '\'') && inner[len(inner)-1] == inner[0]:
+				p.steps = append(p.steps, step{field: inner[1 : len(inner)-1]})
+			default:
+				i, err := strconv.Atoi(inner)
+				if err != nil {
+					return nil, fmt.Errorf("invalid JSON path %q: bad index %q", expr, inner)
+				}
+				p.steps = append(p.steps, step{index: i, isIndex: true})
+			}
+			s = s[end+1:]
+		default:
+			if len(p.steps) > 0 {
+				return nil, fmt.Errorf("invalid JSON path %q: expected . or [ at %q", expr, s)
+			}
+			s = "." + s // a path may start with its first field
+		}
+	}
+	return p, nil
+}
+
+// MustParse parses a path known to be valid, panicking otherwise
+func MustParse(expr string) *Path {
+	p, err := Parse(expr)
+	if err != nil {
+		panic(err)
+	}
+	return p
+}
+
+// String is the path as written
+func (p *Path) String() string {
+	return p.expr
+}
+
+// Select returns the values the path names in doc, a document decoded by
+// encoding/json, in document order; none when it names nothing
+func (p *Path) Select(doc interface{}) []interface{} {
+	values := []interface{}{doc}
+	for _, st := range p.steps {
+		var next []interface{}
+		for _, v := range values {
+			switch node := v.(type) {
+			case map[string]interface{}:
+				switch {
+				case st.wildcard:
+					keys := make([]string, 0, len(node))
+					for k := range node {
+						keys = append(keys, k)
+					}
+					sort.Strings(keys)
+					for _, k := range keys {
+						next = append(next, node[k])
+					}
+				case !st.isIndex:
+					if child, ok := node[st.field]; ok {
+						next = append(next, child)
+					}
+				}
+			case []interface{}:
+				switch {
+				case st.wildcard:
+					next = append(next, node...)
+				case st.isIndex:
+					i := st.index
+					if i < 0 {
+						i += len(node)
+					}
+					if i >= 0 && i < len(node) {
+						next = append(next, node[i])
+					}
+				}
+			}
+		}
+		values = next
+		if len(values) == 0 {
+			return nil
+		}
+	}
+	return values
+}
+
+// Lookup decodes the JSON document data and returns the values the path
+// names in it
+func (p *Path) Lookup(data []byte) ([]interface{}, error) {
+	var doc interface{}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("not JSON: %w", err)
+	}
+	return p.Select(doc), nil
+}
+
+// Text is how a selected value compares to expected text: strings as they
+// are, other values as JSON
+func Text(v interface{}) string {
+	if s, ok := v.(string); ok {
+		return s
+	}
+	data, _ := json.Marshal(v)
+	return string(data)
+}
+
+// Equal tells whether a selected value equals want, a value decoded from
+// YAML or JSON; numbers compare by value
+func Equal(v, want interface{}) bool {
+	a, err1 := json.Marshal(v)
+	b, err2 := json.Marshal(want)
+	if err1 != nil || err2 != nil {
+		return false
+	}
+	var x, y interface{}
+	if json.Unmarshal(a, &x) != nil || json.Unmarshal(b, &y) != nil {
+		return false
+	}
+	a, _ = json.Marshal(x)
+	b, _ = json.Marshal(y)
+	return string(a) == string(b)
+}
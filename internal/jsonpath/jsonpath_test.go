@@ -0,0 +1,76 @@
+package jsonpath
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const chat = `{
+	"type": "message",
+	"room": {"id": 7, "content-type": "text"},
+	"messages": [
+		{"user": "ada", "text": "hi"},
+		{"user": "bob", "text": "hello", "read": true}
+	]
+}`
+
+func TestPath_Lookup(t *testing.T) {
+	tests := []struct {
+		path string
+		want []interface{}
+	}{
+		{"$.type", []interface{}{"message"}},
+		{"type", []interface{}{"message"}},
+		{"$.room.id", []interface{}{float64(7)}},
+		{`$.room["content-type"]`, []interface{}{"text"}},
+		{"$.room['id']", []interface{}{float64(7)}},
+		{"$.messages[0].user", []interface{}{"ada"}},
+		{"$.messages[-1].text", []interface{}{"hello"}},
+		{"$.messages[*].user", []interface{}{"ada", "bob"}},
+		{"$.room.*", []interface{}{"text", float64(7)}},
+		{"$.messages[1].read", []interface{}{true}},
+		{"$.messages[5].user", nil},
+		{"$.missing.field", nil},
+		{"$.type.length", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			got, err := MustParse(tt.path).Lookup([]byte(chat))
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+
+	whole, err := MustParse("$").Lookup([]byte(`[1, 2]`))
+	require.NoError(t, err)
+	assert.Equal(t, []interface{}{[]interface{}{float64(1), float64(2)}}, whole)
+
+	_, err = MustParse("$.type").Lookup([]byte("hello"))
+	assert.ErrorContains(t, err, "not JSON")
+}
+
+func TestParse_Errors(t *testing.T) {
+	for expr, want := range map[string]string{
+		"$.":      `invalid JSON path "$.": empty field`,
+		"$.a[0":   `invalid JSON path "$.a[0": unclosed [`,
+		"$.a[x]":  `invalid JSON path "$.a[x]": bad index "x"`,
+		"$.a..b":  `invalid JSON path "$.a..b": empty field`,
+		"$.a[0]b": `invalid JSON path "$.a[0]b": expected . or [ at "b"`,
+	} {
+		_, err := Parse(expr)
+		assert.EqualError(t, err, want, expr)
+	}
+	assert.Panics(t, func() { MustParse("$.a[") })
+}
+
+func TestEqual(t *testing.T) {
+	assert.True(t, Equal(float64(7), 7))
+	assert.True(t, Equal("ada", "ada"))
+	assert.True(t, Equal(map[string]interface{}{"b": float64(1), "a": true}, map[string]interface{}{"a": true, "b": 1}))
+	assert.False(t, Equal("7", 7))
+	assert.False(t, Equal(nil, false))
+	assert.Equal(t, "hi", Text("hi"))
+	assert.Equal(t, `{"a":[1,2]}`, Text(map[string]interface{}{"a": []interface{}{1, 2}}))
+}
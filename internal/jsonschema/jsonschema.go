@@ -0,0 +1,211 @@
+// Package jsonschema infers JSON Schemas from the JSON values an API
+// returns and checks later values against them. Only what responses
+// alone can tell is inferred: types, object properties and the ones
+// always present, and the items of arrays.
+package jsonschema
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// JSON Schema type names
+const (
+	TypeObject  = "object"
+	TypeArray   = "array"
+	TypeString  = "string"
+	TypeNumber  = "number"
+	TypeBoolean = "boolean"
+	TypeNull    = "null"
+)
+
+// Schema is the JSON Schema subset inferred from values
+type Schema struct {
+	Type       Types              `json:"type,omitempty"`
+	Properties map[string]*Schema `json:"properties,omitempty"`
+	Required   []string           `json:"required,omitempty"`
+	Items      *Schema            `json:"items,omitempty"` // unset for arrays only seen empty
+}
+
+// Types are the types a schema allows, written as a string when it's one
+type Types []string
+
+// MarshalJSON writes one type as a string, others as an array
+func (t Types) MarshalJSON() ([]byte, error) {
+	if len(t) == 1 {
+		return json.Marshal(t[0])
+	}
+	return json.Marshal([]string(t))
+}
+
+// UnmarshalJSON reads a type or an array of them
+func (t *Types) UnmarshalJSON(data []byte) error {
+	var one string
+	if json.Unmarshal(data, &one) == nil {
+		*t = Types{one}
+		return nil
+	}
+	var many []string
+	if err := json.Unmarshal(data, &many); err != nil {
+		return fmt.Errorf("type must be a string or an array of strings")
+	}
+	*t = many
+	return nil
+}
+
+// Has tells whether the types include name
+func (t Types) Has(name string) bool {
+	for _, n := range t {
+		if n == name {
+			return true
+		}
+	}
+	return false
+}
+
+// TypeOf is the JSON Schema type of a decoded JSON value
+func TypeOf(v interface{}) string {
+	switch v.(type) {
+	case map[string]interface{}:
+		return TypeObject
+	case []interface{}:
+		return TypeArray
+	case string:
+		return TypeString
+	case float64, json.Number:
+		return TypeNumber
+	case bool:
+		return TypeBoolean
+	}
+	return TypeNull
+}
+
+// Infer is the schema of a decoded JSON value: every property of objects
+// is required, and the items of arrays are merged
+func Infer(v interface{}) *Schema {
+	s := &Schema{Type: Types{TypeOf(v)}}
+	switch value := v.(type) {
+	case map[string]interface{}:
+		s.Properties = make(map[string]*Schema, len(value))
+		for k, item := range value {
+			s.Properties[k] = Infer(item)
+			s.Required = append(s.Required, k)
+		}
+		sort.Strings(s.Required)
+	case []interface{}:
+		for _, item := range value {
+			s.Items = Merge(s.Items, Infer(item))
+		}
+	}
+	return s
+}
+
+// Merge is the schema of the values of both a and b: it allows the types
+// of either, has their properties and requires those both require. Either
+// may be nil.
+func Merge(a, b *Schema) *Schema {
+	switch {
+	case a == nil:
+		return b
+	case b == nil:
+		return a
+	}
+	out := &Schema{Type: append(Types(nil), a.Type...)}
+	for _, t := range b.Type {
+		if !out.Type.Has(t) {
+			out.Type = append(out.Type, t)
+		}
+	}
+	sort.Strings(out.Type)
+
+	if a.Properties != nil || b.Properties != nil {
+		out.Properties = make(map[string]*Schema)
+		for k, p := range a.Properties {
+			out.Properties[k] = Merge(p, b.Properties[k])
+		}
+		for k, p := range b.Properties {
+			if _, ok := out.Properties[k]; !ok {
+				out.Properties[k] = p
+			}
+		}
+	}
+	// Objects seen only on one side keep its required properties
+	switch {
+	case !a.Type.Has(TypeObject):
+		out.Required = b.Required
+	case !b.Type.Has(TypeObject):
+		out.Required = a.Required
+	default:
+		required := make(map[string]bool, len(b.Required))
+		for _, k := range b.Required {
+			required[k] = true
+		}
+		for _, k := range a.Required {
+			if required[k] {
+				out.Required = append(out.Required, k)
+			}
+		}
+	}
+	out.Items = Merge(a.Items, b.Items)
+	return out
+}
+
+// Check lists how a decoded JSON value breaks the schema, each after the
+// JSON path of the value, e.g. "$.items[0].price: expected number, got
+// string". Properties the schema doesn't have are fine, as clients ignore
+// them; so is any value where the schema only ever saw null.
+func (s *Schema) Check(v interface{}) []string {
+	var problems []string
+	s.check(v, "$", &problems)
+	return problems
+}
+
+func (s *Schema) check(v interface{}, path string, problems *[]string) {
+	if s == nil || len(s.Type) == 0 || (len(s.Type) == 1 && s.Type[0] == TypeNull) {
+		return
+	}
+	got := TypeOf(v)
+	if !s.Type.Has(got) {
+		*problems = append(*problems, fmt.Sprintf("%s: expected %s, got %s", path, strings.Join(s.Type, " or "), got))
+		return
+	}
+	switch value := v.(type) {
+	case map[string]interface{}:
+		for _, k := range s.Required {
+			if _, ok := value[k]; !ok {
+				*problems = append(*problems, fmt.Sprintf("%s: missing", propertyPath(path, k)))
+			}
+		}
+		keys := make([]string, 0, len(value))
+		for k := range value {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			if p, ok := s.Properties[k]; ok {
+				p.check(value[k], propertyPath(path, k), problems)
+			}
+		}
+	case []interface{}:
+		for i, item := range value {
+			s.Items.check(item, fmt.Sprintf("%s[%d]", path, i), problems)
+		}
+	}
+}
+
+// propertyPath appends a property to a JSON path, quoting names that
+// aren't identifiers
+func propertyPath(path, name string) string {
+	for i, c := range name {
+		if !(c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || i > 0 && c >= '0' && c <= '9') {
+			quoted, _ := json.Marshal(name)
+			return path + "[" + string(quoted) + "]"
+		}
+	}
+	if name == "" {
+		return path + `[""]`
+	}
+	return path + "." + name
+}
@@ -0,0 +1,77 @@
+package jsonschema
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func decode(t *testing.T, s string) interface{} {
+	t.Helper()
+	var v interface{}
+	require.NoError(t, json.Unmarshal([]byte(s), &v))
+	return v
+}
+
+func TestInfer(t *testing.T) {
+	s := Infer(decode(t, `{"id": 7, "name": "Mug", "tags": ["a"], "price": null, "lines": [{"sku": "a", "qty": 1}, {"sku": "b"}], "empty": []}`))
+	data, err := json.Marshal(s)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{
+		"type": "object",
+		"properties": {
+			"id": {"type": "number"},
+			"name": {"type": "string"},
+			"tags": {"type": "array", "items": {"type": "string"}},
+			"price": {"type": "null"},
+			"lines": {"type": "array", "items": {"type": "object", "properties": {"sku": {"type": "string"}, "qty": {"type": "number"}}, "required": ["sku"]}},
+			"empty": {"type": "array"}
+		},
+		"required": ["empty", "id", "lines", "name", "price", "tags"]
+	}`, string(data))
+
+	var back Schema
+	require.NoError(t, json.Unmarshal(data, &back))
+	assert.Equal(t, s, &back)
+}
+
+func TestMerge(t *testing.T) {
+	s := Merge(Infer(decode(t, `{"id": 1, "coupon": null, "note": "x"}`)), Infer(decode(t, `{"id": 2, "coupon": "SAVE"}`)))
+	assert.Equal(t, Types{TypeObject}, s.Type)
+	assert.Equal(t, []string{"coupon", "id"}, s.Required)
+	assert.Equal(t, Types{TypeNull, TypeString}, s.Properties["coupon"].Type)
+	assert.Equal(t, Types{TypeString}, s.Properties["note"].Type)
+
+	either := Merge(Infer(decode(t, `null`)), Infer(decode(t, `{"id": 1}`)))
+	assert.Equal(t, Types{TypeNull, TypeObject}, either.Type)
+	assert.Equal(t, []string{"id"}, either.Required)
+	assert.Nil(t, Merge(nil, nil))
+
+	data, err := json.Marshal(either)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), `"type":["null","object"]`)
+}
+
+func TestSchema_Check(t *testing.T) {
+	baseline := Merge(
+		Infer(decode(t, `{"id": 1, "total": 9.5, "coupon": null, "items": [{"sku": "a", "qty": 1}], "ship-to": {"city": "Oslo"}}`)),
+		Infer(decode(t, `{"id": 2, "total": 3, "coupon": "SAVE", "items": [], "ship-to": {"city": "Rome"}}`)),
+	)
+	assert.Empty(t, baseline.Check(decode(t, `{"id": 3, "total": 1, "coupon": null, "items": [{"sku": "b", "qty": 2, "gift": true}], "ship-to": {"city": "Kyiv"}, "new": 1}`)),
+		"added properties don't break clients")
+	assert.Equal(t, []string{
+		"$.id: missing",
+		"$.coupon: expected null or string, got number",
+		"$.items[1].qty: missing",
+		"$.items[1].sku: expected string, got number",
+		`$["ship-to"].city: expected string, got null`,
+		"$.total: expected number, got string",
+	}, baseline.Check(decode(t, `{"total": "9.50", "coupon": 5, "items": [{"sku": "a", "qty": 1}, {"sku": 7}], "ship-to": {"city": null}}`)))
+	assert.Equal(t, []string{"$: expected object, got array"}, baseline.Check(decode(t, `[]`)))
+
+	nullOnly := Infer(decode(t, `{"deleted_at": null}`))
+	assert.Empty(t, nullOnly.Check(decode(t, `{"deleted_at": "2026-10-14"}`)))
+	assert.Equal(t, []string{"$.deleted_at: missing"}, nullOnly.Check(decode(t, `{}`)))
+}
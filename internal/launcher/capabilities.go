@@ -0,0 +1,113 @@
+package launcher
+
+import (
+	"fmt"
+	"os/exec"
+	"runtime"
+)
+
+// Platforms are the platforms the launcher knows
+var Platforms = []string{"windows", "macos", "linux", "android", "ios"}
+
+// SetPlatform overrides the detected platform, so icons and splash screens
+// are chosen, and capabilities probed, for another one. darwin is accepted
+// for macos.
+func (l *Launcher) SetPlatform(platform string) error {
+	if platform == "darwin" {
+		platform = "macos"
+	}
+	for _, p := range Platforms {
+		if p == platform {
+			l.platform = platform
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown platform %q: want windows, macos, linux, android or ios", platform)
+}
+
+// GetPlatform returns the platform the launcher targets
+func (l *Launcher) GetPlatform() string {
+	return l.platform
+}
+
+// Capabilities are what the launcher can do for its platform on this
+// system, so packaging scripts can decide per OS
+type Capabilities struct {
+	Platform string            `json:"platform"`
+	Native   bool              `json:"native"`            // the platform is the running OS
+	AppIcon  bool              `json:"app_icon"`          // DisplayIcon can install the icon for the target
+	Splash   bool              `json:"splash"`            // ShowSplashScreen can open its window
+	TrayIcon bool              `json:"tray_icon"`         // a system tray or menu bar takes status icons
+	Missing  map[string]string `json:"missing,omitempty"` // why capabilities are missing, by their JSON names
+}
+
+// Capabilities probes what the launcher can do for its platform. Linux
+// capabilities are probed on the X display: the splash screen needs one,
+// and a tray icon needs a system tray owning its XEmbed selection.
+func (l *Launcher) Capabilities() *Capabilities {
+	c := &Capabilities{Platform: l.platform, Native: platformFor(runtime.GOOS) == l.platform, Missing: map[string]string{}}
+	needsNative := fmt.Sprintf("needs %s, running on %s", l.platform, platformFor(runtime.GOOS))
+	switch l.platform {
+	case "linux":
+		if _, err := dataHome(); err != nil {
+			c.Missing["app_icon"] = err.Error()
+		} else {
+			c.AppIcon = true
+		}
+		x, err := openX11()
+		if err != nil {
+			c.Missing["splash"] = err.Error()
+			c.Missing["tray_icon"] = err.Error()
+			break
+		}
+		defer x.conn.Close()
+		c.Splash = true
+		tray, err := x.hasTray()
+		switch {
+		case err != nil:
+			c.Missing["tray_icon"] = err.Error()
+		case !tray:
+			c.Missing["tray_icon"] = "no system tray: nothing owns _NET_SYSTEM_TRAY_S0"
+		default:
+			c.TrayIcon = true
+		}
+	case "macos":
+		// Icons are written into the bundle, which works from any OS
+		if l.target.Bundle == "" {
+			c.Missing["app_icon"] = "no target bundle set"
+		} else {
+			c.AppIcon = true
+		}
+		if !c.Native {
+			c.Missing["splash"] = needsNative
+			c.Missing["tray_icon"] = needsNative
+		} else if _, err := exec.LookPath("osascript"); err != nil {
+			c.Missing["splash"] = err.Error()
+			c.TrayIcon = true
+		} else {
+			c.Splash, c.TrayIcon = true, true
+		}
+	case "windows":
+		switch {
+		case !c.Native:
+			c.Missing["app_icon"] = needsNative
+		case l.target.Exec == "":
+			c.Missing["app_icon"] = "no target executable set"
+		default:
+			c.AppIcon = true
+		}
+		if c.Native {
+			c.Splash, c.TrayIcon = true, true
+		} else {
+			c.Missing["splash"] = needsNative
+			c.Missing["tray_icon"] = needsNative
+		}
+	default:
+		unsupported := "not supported on platform: " + l.platform
+		c.Missing["app_icon"], c.Missing["splash"], c.Missing["tray_icon"] = unsupported, unsupported, unsupported
+	}
+	if len(c.Missing) == 0 {
+		c.Missing = nil
+	}
+	return c
+}
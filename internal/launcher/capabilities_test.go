@@ -0,0 +1,73 @@
+package launcher
+
+import (
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestSetPlatform tests overriding the detected platform
+func TestSetPlatform(t *testing.T) {
+	l := NewLauncher(t.TempDir())
+	require.NoError(t, l.SetPlatform("ios"))
+	assert.Equal(t, "ios", l.GetPlatform())
+	assert.Equal(t, filepath.Join(l.iconDir, "ios", "appstore", "icon_appstore.png"), l.GetPlatformIcon())
+	require.NoError(t, l.SetPlatform("darwin"))
+	assert.Equal(t, "macos", l.GetPlatform())
+	assert.EqualError(t, l.SetPlatform("beos"), `unknown platform "beos": want windows, macos, linux, android or ios`)
+	assert.Equal(t, "macos", l.GetPlatform(), "unchanged")
+}
+
+// TestCapabilities_Linux tests probing the X display for the splash screen
+// and a system tray
+func TestCapabilities_Linux(t *testing.T) {
+	t.Setenv("XDG_DATA_HOME", t.TempDir())
+	l := NewLauncher(t.TempDir())
+	require.NoError(t, l.SetPlatform("linux"))
+
+	t.Setenv("DISPLAY", "")
+	c := l.Capabilities()
+	assert.Equal(t, runtime.GOOS == "linux", c.Native)
+	assert.True(t, c.AppIcon)
+	assert.False(t, c.Splash)
+	assert.Equal(t, map[string]string{
+		"splash":    "no X display: DISPLAY is not set",
+		"tray_icon": "no X display: DISPLAY is not set",
+	}, c.Missing)
+
+	display, sessions := fakeXServer(t)
+	t.Setenv("DISPLAY", display)
+	c = l.Capabilities()
+	assert.True(t, c.Splash)
+	assert.True(t, c.TrayIcon)
+	assert.Nil(t, c.Missing)
+	assert.Equal(t, []string{"_NET_SYSTEM_TRAY_S0"}, (<-sessions).atoms)
+}
+
+// TestCapabilities_Others tests the capabilities of the other platforms
+// from whichever OS runs the tests
+func TestCapabilities_Others(t *testing.T) {
+	l := NewLauncher(t.TempDir())
+	require.NoError(t, l.SetPlatform("android"))
+	c := l.Capabilities()
+	assert.False(t, c.AppIcon || c.Splash || c.TrayIcon)
+	assert.Equal(t, "not supported on platform: android", c.Missing["splash"])
+
+	require.NoError(t, l.SetPlatform("macos"))
+	assert.Equal(t, "no target bundle set", l.Capabilities().Missing["app_icon"])
+	l.SetTarget(IconTarget{Bundle: "/Applications/Panoptic.app", Exec: `C:\panoptic.exe`})
+	assert.True(t, l.Capabilities().AppIcon, "bundles can be written from any OS")
+
+	require.NoError(t, l.SetPlatform("windows"))
+	c = l.Capabilities()
+	if runtime.GOOS == "windows" {
+		assert.True(t, c.AppIcon && c.Splash && c.TrayIcon)
+	} else {
+		assert.False(t, c.Native)
+		assert.False(t, c.AppIcon)
+		assert.Equal(t, "needs windows, running on "+detectPlatform(), c.Missing["app_icon"])
+	}
+}
@@ -0,0 +1,522 @@
+package launcher
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// IconManifest describes the icons and splash screens generated from one
+// master image
+type IconManifest struct {
+	Source                string       `yaml:"source" json:"source"`                                                     // PNG, JPEG or SVG master image
+	DarkSource            string       `yaml:"dark_source,omitempty" json:"dark_source,omitempty"`                       // master of the dark variants
+	LightSource           string       `yaml:"light_source,omitempty" json:"light_source,omitempty"`                     // master of the light variants
+	TransparentBackground bool         `yaml:"transparent_background,omitempty" json:"transparent_background,omitempty"` // clear the background around the logo
+	Index                 string       `yaml:"index,omitempty" json:"index,omitempty"`                                   // JSON list of the generated files
+	Outputs               []IconOutput `yaml:"outputs" json:"outputs"`
+}
+
+// IconOutput is one generated file. Its kind follows from the extension of
+// its path (.png, .ico, .icns or .appiconset) unless set; splash screens
+// and Android adaptive icons set it. Dark and light variants are drawn from
+// the dark and light masters, and skipped when the manifest has none.
+type IconOutput struct {
+	Path       string `yaml:"path" json:"path"`
+	Kind       string `yaml:"kind,omitempty" json:"kind,omitempty"`             // png, ico, icns, appiconset, splash or adaptive
+	Variant    string `yaml:"variant,omitempty" json:"variant,omitempty"`       // dark or light
+	Size       int    `yaml:"size,omitempty" json:"size,omitempty"`             // png
+	Sizes      []int  `yaml:"sizes,omitempty" json:"sizes,omitempty"`           // ico and icns; every size of the format by default
+	Width      int    `yaml:"width,omitempty" json:"width,omitempty"`           // splash
+	Height     int    `yaml:"height,omitempty" json:"height,omitempty"`         // splash
+	Logo       int    `yaml:"logo,omitempty" json:"logo,omitempty"`             // splash logo size; 2/5 of the shorter side by default
+	Background string `yaml:"background,omitempty" json:"background,omitempty"` // #rrggbb to flatten onto; App Store icons and adaptive icon backgrounds default to white
+}
+
+// GeneratedIcon is a file GenerateIcons wrote
+type GeneratedIcon struct {
+	Path   string `json:"path"`
+	Kind   string `json:"kind"`
+	Width  int    `json:"width"`
+	Height int    `json:"height"`
+}
+
+// DefaultIconManifest is the layout of Assets: Android launcher icons per
+// density and an adaptive icon, iOS icons and an AppIcon set, favicons,
+// desktop PNGs with a Windows .ico and a macOS .icns and their dark and
+// light variants, and Android and iOS splash screens
+func DefaultIconManifest(source string) *IconManifest {
+	m := &IconManifest{Source: source, TransparentBackground: true, Index: "icons/manifest.json"}
+	png := func(path string, size int) {
+		m.Outputs = append(m.Outputs, IconOutput{Path: path, Size: size})
+	}
+	for _, d := range []struct {
+		density string
+		size    int
+	}{{"ldpi", 36}, {"mdpi", 48}, {"hdpi", 72}, {"xhdpi", 96}, {"xxhdpi", 144}, {"xxxhdpi", 192}} {
+		png(fmt.Sprintf("icons/android/%s/icon_%s.png", d.density, d.density), d.size)
+	}
+	m.Outputs = append(m.Outputs, IconOutput{Path: "icons/android/adaptive", Kind: "adaptive"})
+	png("icons/ios/iphone/icon_iphone.png", 60)
+	png("icons/ios/iphone/icon_iphone_retina.png", 120)
+	png("icons/ios/ipad/icon_ipad.png", 76)
+	png("icons/ios/ipad/icon_ipad_retina.png", 152)
+	png("icons/ios/appstore/icon_appstore.png", 1024)
+	m.Outputs = append(m.Outputs, IconOutput{Path: "icons/ios/AppIcon.appiconset"})
+	m.Outputs = append(m.Outputs, IconOutput{Path: "icons/web/favicon.ico", Sizes: []int{16, 32, 48}})
+	png("icons/web/favicon-16.png", 16)
+	png("icons/web/favicon-32.png", 32)
+	png("icons/web/icon.png", 32)
+	png("icons/web/apple-touch-icon.png", 180)
+	png("icons/web/icon-192.png", 192)
+	png("icons/web/icon-512.png", 512)
+	png("icons/desktop/icon.png", 256)
+	png("icons/desktop/large.png", 512)
+	m.Outputs = append(m.Outputs, IconOutput{Path: "icons/desktop/icon.ico"}, IconOutput{Path: "icons/desktop/icon.icns"})
+	for _, variant := range []string{AppearanceDark, AppearanceLight} {
+		m.Outputs = append(m.Outputs,
+			IconOutput{Path: "icons/desktop/icon_" + variant + ".png", Size: 256, Variant: variant},
+			IconOutput{Path: "icons/desktop/large_" + variant + ".png", Size: 512, Variant: variant},
+			IconOutput{Path: "icons/desktop/icon_" + variant + ".ico", Variant: variant},
+			IconOutput{Path: "icons/desktop/icon_" + variant + ".icns", Variant: variant})
+	}
+
+	splash := func(path string, width, height int) {
+		m.Outputs = append(m.Outputs, IconOutput{Path: path, Kind: "splash", Width: width, Height: height})
+	}
+	for _, d := range []struct {
+		density       string
+		width, height int
+	}{{"ldpi", 200, 320}, {"mdpi", 320, 480}, {"hdpi", 480, 800}, {"xhdpi", 720, 1280}, {"xxhdpi", 1080, 1920}, {"xxxhdpi", 1440, 2560}} {
+		splash(fmt.Sprintf("splash/android/portrait/%s/splash_%s_portrait.png", d.density, d.density), d.width, d.height)
+		splash(fmt.Sprintf("splash/android/landscape/%s/splash_%s_landscape.png", d.density, d.density), d.height, d.width)
+	}
+	splash("splash/ios/iphone/splash_iphone_portrait.png", 375, 667)
+	splash("splash/ios/iphone_plus/splash_iphone_plus_portrait.png", 414, 736)
+	splash("splash/ios/iphone_x/splash_iphone_x_portrait.png", 375, 812)
+	splash("splash/ios/ipad/splash_ipad_portrait.png", 768, 1024)
+	splash("splash/ios/ipad/splash_ipad_landscape.png", 1024, 768)
+	return m
+}
+
+// LoadIconManifest reads a YAML or JSON icon manifest. Relative sources are
+// resolved against the directory of the manifest.
+func LoadIconManifest(path string) (*IconManifest, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var m IconManifest
+	if err := yaml.Unmarshal(data, &m); err != nil {
+		return nil, fmt.Errorf("invalid icon manifest %s: %w", path, err)
+	}
+	for _, source := range []*string{&m.Source, &m.DarkSource, &m.LightSource} {
+		if *source != "" && !filepath.IsAbs(*source) {
+			*source = filepath.Join(filepath.Dir(path), *source)
+		}
+	}
+	if err := m.Validate(); err != nil {
+		return nil, fmt.Errorf("icon manifest %s: %w", path, err)
+	}
+	return &m, nil
+}
+
+// kind is the kind of an output, from its extension unless set
+func (o IconOutput) kind() string {
+	if o.Kind != "" {
+		return o.Kind
+	}
+	return strings.TrimPrefix(strings.ToLower(filepath.Ext(o.Path)), ".")
+}
+
+// Validate checks that every output can be generated
+func (m *IconManifest) Validate() error {
+	if m.Source == "" {
+		return fmt.Errorf("source is required")
+	}
+	if len(m.Outputs) == 0 {
+		return fmt.Errorf("outputs are required")
+	}
+	for i, o := range m.Outputs {
+		if o.Path == "" {
+			return fmt.Errorf("outputs[%d]: path is required", i)
+		}
+		if _, err := parseColor(o.Background); err != nil {
+			return fmt.Errorf("outputs[%d] %s: %w", i, o.Path, err)
+		}
+		if o.Variant != "" && o.Variant != AppearanceDark && o.Variant != AppearanceLight {
+			return fmt.Errorf("outputs[%d] %s: unknown variant %q: want dark or light", i, o.Path, o.Variant)
+		}
+		switch o.kind() {
+		case "png":
+			if o.Size <= 0 {
+				return fmt.Errorf("outputs[%d] %s: size is required", i, o.Path)
+			}
+		case "splash":
+			if o.Width <= 0 || o.Height <= 0 {
+				return fmt.Errorf("outputs[%d] %s: width and height are required", i, o.Path)
+			}
+		case "ico", "icns", "appiconset", "adaptive":
+		default:
+			return fmt.Errorf("outputs[%d] %s: unknown kind %q: want png, ico, icns, appiconset, splash or adaptive", i, o.Path, o.kind())
+		}
+	}
+	return nil
+}
+
+// parseColor parses #rrggbb; an empty color is nil
+func parseColor(value string) (color.Color, error) {
+	if value == "" {
+		return nil, nil
+	}
+	hex, ok := strings.CutPrefix(value, "#")
+	v, err := strconv.ParseUint(hex, 16, 32)
+	if !ok || len(hex) != 6 || err != nil {
+		return nil, fmt.Errorf("background %q is not a #rrggbb color", value)
+	}
+	return color.NRGBA{R: uint8(v >> 16), G: uint8(v >> 8), B: uint8(v), A: 255}, nil
+}
+
+// appIconImages are the images of an iOS AppIcon set
+var appIconImages = []struct {
+	idiom string
+	size  float64 // points
+	scale int
+}{
+	{"iphone", 20, 2}, {"iphone", 20, 3}, {"iphone", 29, 2}, {"iphone", 29, 3},
+	{"iphone", 40, 2}, {"iphone", 40, 3}, {"iphone", 60, 2}, {"iphone", 60, 3},
+	{"ipad", 20, 1}, {"ipad", 20, 2}, {"ipad", 29, 1}, {"ipad", 29, 2},
+	{"ipad", 40, 1}, {"ipad", 40, 2}, {"ipad", 76, 1}, {"ipad", 76, 2}, {"ipad", 83.5, 2},
+	{"ios-marketing", 1024, 1},
+}
+
+// GenerateIcons writes the outputs of a manifest under outDir, then its
+// index when it names one
+func GenerateIcons(m *IconManifest, outDir string) ([]GeneratedIcon, error) {
+	if err := m.Validate(); err != nil {
+		return nil, err
+	}
+	sources := map[string]string{"": m.Source, AppearanceDark: m.DarkSource, AppearanceLight: m.LightSource}
+	masters := map[string]image.Image{}
+	for variant, source := range sources {
+		if source == "" {
+			continue
+		}
+		master, err := loadMaster(source, m.largest())
+		if err != nil {
+			return nil, err
+		}
+		if m.TransparentBackground {
+			master = clearBackground(master)
+		}
+		masters[variant] = master
+	}
+
+	var generated []GeneratedIcon
+	write := func(path, kind string, width, height int, data []byte) error {
+		full := filepath.Join(outDir, path)
+		if err := os.MkdirAll(filepath.Dir(full), 0755); err != nil {
+			return err
+		}
+		if err := os.WriteFile(full, data, 0644); err != nil {
+			return err
+		}
+		generated = append(generated, GeneratedIcon{Path: filepath.ToSlash(path), Kind: kind, Width: width, Height: height})
+		return nil
+	}
+	for _, o := range m.Outputs {
+		master, ok := masters[o.Variant]
+		if !ok {
+			continue
+		}
+		background, _ := parseColor(o.Background)
+		var data []byte
+		var err error
+		switch kind := o.kind(); kind {
+		case "png":
+			if data, err = encodePNG(squareImage(master, o.Size, background)); err == nil {
+				err = write(o.Path, kind, o.Size, o.Size, data)
+			}
+		case "ico":
+			sizes := o.Sizes
+			if len(sizes) == 0 {
+				sizes = icoSizes
+			}
+			if data, err = encodeICO(master, sizes); err == nil {
+				err = write(o.Path, kind, slices.Max(sizes), slices.Max(sizes), data)
+			}
+		case "icns":
+			sizes := o.Sizes
+			if len(sizes) == 0 {
+				sizes = icnsSizes
+			}
+			if data, err = encodeICNS(master, sizes); err == nil {
+				err = write(o.Path, kind, slices.Max(sizes), slices.Max(sizes), data)
+			}
+		case "appiconset":
+			err = writeAppIconSet(master, o, background, write)
+		case "splash":
+			if data, err = encodePNG(splashCanvas(master, o, background)); err == nil {
+				err = write(o.Path, kind, o.Width, o.Height, data)
+			}
+		case "adaptive":
+			err = writeAdaptiveIcon(master, o, write)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", o.Path, err)
+		}
+	}
+
+	if m.Index != "" {
+		index, err := json.MarshalIndent(struct {
+			Generated string          `json:"generated"`
+			Source    string          `json:"source"`
+			Files     []GeneratedIcon `json:"files"`
+		}{time.Now().UTC().Format(time.RFC3339), m.Source, generated}, "", "  ")
+		if err != nil {
+			return nil, err
+		}
+		full := filepath.Join(outDir, m.Index)
+		if err := os.MkdirAll(filepath.Dir(full), 0755); err != nil {
+			return nil, err
+		}
+		if err := os.WriteFile(full, append(index, '\n'), 0644); err != nil {
+			return nil, err
+		}
+	}
+	return generated, nil
+}
+
+// writeAppIconSet writes an Xcode AppIcon set: every iPhone, iPad and App
+// Store image and the Contents.json naming them. The images are flattened,
+// white by default, since the App Store rejects icons with transparency.
+func writeAppIconSet(master image.Image, o IconOutput, background color.Color, write func(path, kind string, width, height int, data []byte) error) error {
+	if background == nil {
+		background = color.White
+	}
+	type entry struct {
+		Size     string `json:"size"`
+		Idiom    string `json:"idiom"`
+		Filename string `json:"filename"`
+		Scale    string `json:"scale"`
+	}
+	contents := struct {
+		Images []entry `json:"images"`
+		Info   struct {
+			Version int    `json:"version"`
+			Author  string `json:"author"`
+		} `json:"info"`
+	}{}
+	contents.Info.Version, contents.Info.Author = 1, "panoptic"
+	written := map[string]bool{}
+	for _, i := range appIconImages {
+		points := strconv.FormatFloat(i.size, 'f', -1, 64)
+		pixels := int(i.size * float64(i.scale))
+		name := fmt.Sprintf("icon-%s@%dx.png", points, i.scale)
+		contents.Images = append(contents.Images, entry{Size: points + "x" + points, Idiom: i.idiom, Filename: name, Scale: fmt.Sprintf("%dx", i.scale)})
+		if written[name] {
+			continue
+		}
+		written[name] = true
+		data, err := encodePNG(squareImage(master, pixels, background))
+		if err != nil {
+			return err
+		}
+		if err := write(filepath.Join(o.Path, name), "png", pixels, pixels, data); err != nil {
+			return err
+		}
+	}
+	data, err := json.MarshalIndent(contents, "", "  ")
+	if err != nil {
+		return err
+	}
+	return write(filepath.Join(o.Path, "Contents.json"), "appiconset", 0, 0, append(data, '\n'))
+}
+
+// splashCanvas centers the logo on a splash screen of the output's size
+func splashCanvas(master image.Image, o IconOutput, background color.Color) *image.NRGBA {
+	canvas := image.NewNRGBA(image.Rect(0, 0, o.Width, o.Height))
+	if background != nil {
+		draw.Draw(canvas, canvas.Bounds(), image.NewUniform(background), image.Point{}, draw.Src)
+	}
+	logo := o.Logo
+	if logo <= 0 {
+		logo = min(o.Width, o.Height) * 2 / 5
+	}
+	at := image.Pt((o.Width-logo)/2, (o.Height-logo)/2)
+	draw.Draw(canvas, image.Rectangle{Min: at, Max: at.Add(image.Pt(logo, logo))}, squareImage(master, logo, nil), image.Point{}, draw.Over)
+	return canvas
+}
+
+// adaptiveDensities are the sizes of the 108dp adaptive icon layers
+var adaptiveDensities = []struct {
+	density string
+	size    int
+}{{"mdpi", 108}, {"hdpi", 162}, {"xhdpi", 216}, {"xxhdpi", 324}, {"xxxhdpi", 432}}
+
+// adaptiveIconXML is the mipmap-anydpi-v26 definition of the layers
+const adaptiveIconXML = `<?xml version="1.0" encoding="utf-8"?>
+<adaptive-icon xmlns:android="http://schemas.android.com/apk/res/android">
+    <background android:drawable="@color/ic_launcher_background"/>
+    <foreground android:drawable="@mipmap/ic_launcher_foreground"/>
+    <monochrome android:drawable="@mipmap/ic_launcher_monochrome"/>
+</adaptive-icon>
+`
+
+// writeAdaptiveIcon writes an Android adaptive icon resource directory: the
+// foreground layer per density with the logo inside the 66dp safe zone
+// launchers never mask, a white monochrome layer of the same shape that
+// Android 13 tints for themed icons, the background color, white by
+// default, and the definition combining them
+func writeAdaptiveIcon(master image.Image, o IconOutput, write func(path, kind string, width, height int, data []byte) error) error {
+	background := o.Background
+	if background == "" {
+		background = "#ffffff"
+	}
+	for _, d := range adaptiveDensities {
+		foreground := splashCanvas(master, IconOutput{Width: d.size, Height: d.size, Logo: d.size * 66 / 108}, nil)
+		data, err := encodePNG(foreground)
+		if err != nil {
+			return err
+		}
+		if err := write(filepath.Join(o.Path, "mipmap-"+d.density, "ic_launcher_foreground.png"), "png", d.size, d.size, data); err != nil {
+			return err
+		}
+		for i := 0; i < len(foreground.Pix); i += 4 {
+			foreground.Pix[i], foreground.Pix[i+1], foreground.Pix[i+2] = 255, 255, 255
+		}
+		if data, err = encodePNG(foreground); err != nil {
+			return err
+		}
+		if err := write(filepath.Join(o.Path, "mipmap-"+d.density, "ic_launcher_monochrome.png"), "png", d.size, d.size, data); err != nil {
+			return err
+		}
+	}
+	colors := fmt.Sprintf("<?xml version=\"1.0\" encoding=\"utf-8\"?>\n<resources>\n    <color name=\"ic_launcher_background\">%s</color>\n</resources>\n", strings.ToUpper(background))
+	if err := write(filepath.Join(o.Path, "values", "ic_launcher_background.xml"), "adaptive", 0, 0, []byte(colors)); err != nil {
+		return err
+	}
+	return write(filepath.Join(o.Path, "mipmap-anydpi-v26", "ic_launcher.xml"), "adaptive", 0, 0, []byte(adaptiveIconXML))
+}
+
+// largest is the largest image size the outputs need, which an SVG master
+// is rasterized at
+func (m *IconManifest) largest() int {
+	largest := 0
+	for _, o := range m.Outputs {
+		switch o.kind() {
+		case "png":
+			largest = max(largest, o.Size)
+		case "ico":
+			largest = max(largest, 256)
+		case "icns", "appiconset":
+			largest = max(largest, 1024)
+		case "adaptive":
+			largest = max(largest, 432*66/108)
+		case "splash":
+			if o.Logo > 0 {
+				largest = max(largest, o.Logo)
+			} else {
+				largest = max(largest, min(o.Width, o.Height)*2/5)
+			}
+		}
+	}
+	return largest
+}
+
+// svgRasterizers render an SVG file to a size x size PNG
+var svgRasterizers = []struct {
+	name string
+	args func(svg, out string, size int) []string
+}{
+	{"rsvg-convert", func(svg, out string, size int) []string {
+		return []string{"-w", strconv.Itoa(size), "-h", strconv.Itoa(size), "-a", "-o", out, svg}
+	}},
+	{"inkscape", func(svg, out string, size int) []string {
+		return []string{"--export-type=png", "--export-filename=" + out, "-w", strconv.Itoa(size), svg}
+	}},
+	{"magick", func(svg, out string, size int) []string {
+		return []string{"-background", "none", svg, "-resize", fmt.Sprintf("%dx%d", size, size), out}
+	}},
+}
+
+// loadMaster decodes a PNG or JPEG master, or rasterizes an SVG one at size
+// with the first rasterizer installed
+func loadMaster(path string, size int) (image.Image, error) {
+	if !strings.EqualFold(filepath.Ext(path), ".svg") {
+		return loadImage(path)
+	}
+	if _, err := os.Stat(path); err != nil {
+		return nil, err
+	}
+	for _, r := range svgRasterizers {
+		bin, err := exec.LookPath(r.name)
+		if err != nil {
+			continue
+		}
+		dir, err := os.MkdirTemp("", "panoptic-icon-")
+		if err != nil {
+			return nil, err
+		}
+		defer os.RemoveAll(dir)
+		out := filepath.Join(dir, "master.png")
+		var stderr bytes.Buffer
+		cmd := exec.Command(bin, r.args(path, out, size)...)
+		cmd.Stderr = &stderr
+		if err := cmd.Run(); err != nil {
+			return nil, fmt.Errorf("%s failed to rasterize %s: %w: %s", r.name, path, err, strings.TrimSpace(stderr.String()))
+		}
+		return loadImage(out)
+	}
+	return nil, fmt.Errorf("no SVG rasterizer found for %s: install rsvg-convert (librsvg), Inkscape or ImageMagick, or use a PNG master", path)
+}
+
+// backgroundFuzz is how far, per channel, a pixel may be from the corner
+// color and still count as background
+const backgroundFuzz = 38 // 15%
+
+// clearBackground makes the background transparent: the pixels connected
+// to the corners that are within backgroundFuzz of the top-left corner's
+// color
+func clearBackground(img image.Image) image.Image {
+	bounds := img.Bounds()
+	out := image.NewNRGBA(image.Rect(0, 0, bounds.Dx(), bounds.Dy()))
+	draw.Draw(out, out.Bounds(), img, bounds.Min, draw.Src)
+	width, height := bounds.Dx(), bounds.Dy()
+	if width == 0 || height == 0 {
+		return out
+	}
+	key := out.NRGBAAt(0, 0)
+	near := func(c color.NRGBA) bool {
+		diff := func(a, b uint8) int { return max(int(a)-int(b), int(b)-int(a)) }
+		return c.A > 0 && diff(c.R, key.R) <= backgroundFuzz && diff(c.G, key.G) <= backgroundFuzz && diff(c.B, key.B) <= backgroundFuzz
+	}
+	seen := make([]bool, width*height)
+	queue := []image.Point{{0, 0}, {width - 1, 0}, {0, height - 1}, {width - 1, height - 1}}
+	for len(queue) > 0 {
+		p := queue[len(queue)-1]
+		queue = queue[:len(queue)-1]
+		if p.X < 0 || p.Y < 0 || p.X >= width || p.Y >= height || seen[p.Y*width+p.X] {
+			continue
+		}
+		seen[p.Y*width+p.X] = true
+		if !near(out.NRGBAAt(p.X, p.Y)) {
+			continue
+		}
+		out.SetNRGBA(p.X, p.Y, color.NRGBA{})
+		queue = append(queue, image.Pt(p.X+1, p.Y), image.Pt(p.X-1, p.Y), image.Pt(p.X, p.Y+1), image.Pt(p.X, p.Y-1))
+	}
+	return out
+}
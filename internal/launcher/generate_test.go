@@ -0,0 +1,208 @@
+package launcher
+
+import (
+	"encoding/json"
+	"image"
+	"image/color"
+	"image/png"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writeLogo saves a red square on a white background, like a scanned logo
+func writeLogo(t *testing.T, path string, size int) string {
+	t.Helper()
+	img := image.NewNRGBA(image.Rect(0, 0, size, size))
+	for y := 0; y < size; y++ {
+		for x := 0; x < size; x++ {
+			c := color.NRGBA{250, 250, 250, 255}
+			if x >= size/4 && x < size*3/4 && y >= size/4 && y < size*3/4 {
+				c = color.NRGBA{200, 30, 30, 255}
+			}
+			img.SetNRGBA(x, y, c)
+		}
+	}
+	f, err := os.Create(path)
+	require.NoError(t, err)
+	require.NoError(t, png.Encode(f, img))
+	require.NoError(t, f.Close())
+	return path
+}
+
+func decodePNG(t *testing.T, path string) image.Image {
+	t.Helper()
+	f, err := os.Open(path)
+	require.NoError(t, err)
+	defer f.Close()
+	img, err := png.Decode(f)
+	require.NoError(t, err)
+	return img
+}
+
+// TestGenerateIcons tests every output kind and the index
+func TestGenerateIcons(t *testing.T) {
+	dir := t.TempDir()
+	manifest := &IconManifest{
+		Source:                writeLogo(t, filepath.Join(dir, "logo.png"), 128),
+		TransparentBackground: true,
+		Index:                 "manifest.json",
+		Outputs: []IconOutput{
+			{Path: "android/mdpi/icon.png", Size: 48},
+			{Path: "web/favicon.ico", Sizes: []int{16, 32}},
+			{Path: "mac/icon.icns", Sizes: []int{16, 32}},
+			{Path: "ios/AppIcon.appiconset"},
+			{Path: "splash/portrait.png", Kind: "splash", Width: 100, Height: 200, Background: "#102030"},
+		},
+	}
+	out := filepath.Join(dir, "out")
+	generated, err := GenerateIcons(manifest, out)
+	require.NoError(t, err)
+
+	icon := decodePNG(t, filepath.Join(out, "android/mdpi/icon.png"))
+	assert.Equal(t, 48, icon.Bounds().Dx())
+	_, _, _, a := icon.At(2, 2).RGBA()
+	assert.Zero(t, a, "background cleared")
+	r, _, _, _ := icon.At(24, 24).RGBA()
+	assert.Equal(t, uint32(200*0x101), r)
+
+	ico, err := os.ReadFile(filepath.Join(out, "web/favicon.ico"))
+	require.NoError(t, err)
+	entries, err := parseICO(ico)
+	require.NoError(t, err)
+	assert.Len(t, entries, 2)
+	icns, err := os.ReadFile(filepath.Join(out, "mac/icon.icns"))
+	require.NoError(t, err)
+	assert.Equal(t, "icns", string(icns[:4]))
+
+	var contents struct {
+		Images []struct{ Size, Idiom, Filename, Scale string }
+	}
+	data, err := os.ReadFile(filepath.Join(out, "ios/AppIcon.appiconset/Contents.json"))
+	require.NoError(t, err)
+	require.NoError(t, json.Unmarshal(data, &contents))
+	require.Len(t, contents.Images, len(appIconImages))
+	last := contents.Images[len(contents.Images)-1]
+	assert.Equal(t, "ios-marketing", last.Idiom)
+	assert.Equal(t, "icon-1024@1x.png", last.Filename)
+	ipadPro := decodePNG(t, filepath.Join(out, "ios/AppIcon.appiconset/icon-83.5@2x.png"))
+	assert.Equal(t, 167, ipadPro.Bounds().Dx())
+	_, _, _, a = ipadPro.At(0, 0).RGBA()
+	assert.Equal(t, uint32(0xffff), a, "App Store icons are opaque")
+
+	splash := decodePNG(t, filepath.Join(out, "splash/portrait.png"))
+	assert.Equal(t, image.Rect(0, 0, 100, 200), splash.Bounds())
+	assert.Equal(t, color.NRGBA{0x10, 0x20, 0x30, 255}, color.NRGBAModel.Convert(splash.At(0, 0)))
+	assert.Equal(t, color.NRGBA{200, 30, 30, 255}, color.NRGBAModel.Convert(splash.At(50, 100)), "logo centered")
+
+	var index struct {
+		Source string
+		Files  []GeneratedIcon
+	}
+	data, err = os.ReadFile(filepath.Join(out, "manifest.json"))
+	require.NoError(t, err)
+	require.NoError(t, json.Unmarshal(data, &index))
+	assert.Equal(t, generated, index.Files)
+	assert.Equal(t, GeneratedIcon{Path: "web/favicon.ico", Kind: "ico", Width: 32, Height: 32}, generated[1])
+	assert.Len(t, generated, 3+15+1+1, "images the iPhone and iPad share are written once")
+}
+
+// TestDefaultIconManifest tests that the default manifest generates the
+// icons the launcher looks for
+func TestDefaultIconManifest(t *testing.T) {
+	dir := t.TempDir()
+	manifest := DefaultIconManifest(writeLogo(t, filepath.Join(dir, "Logo.png"), 256))
+	require.NoError(t, manifest.Validate())
+	_, err := GenerateIcons(manifest, dir)
+	require.NoError(t, err)
+
+	for _, platform := range []string{"windows", "macos", "linux", "android", "ios"} {
+		l := NewLauncher(filepath.Join(dir, "icons"))
+		l.platform = platform
+		assert.FileExists(t, l.GetPlatformIcon(), platform)
+	}
+	assert.FileExists(t, filepath.Join(dir, "splash/android/portrait/xxxhdpi/splash_xxxhdpi_portrait.png"))
+	assert.FileExists(t, filepath.Join(dir, "icons/manifest.json"))
+	assert.NoFileExists(t, filepath.Join(dir, "icons/desktop/icon_dark.png"), "no dark master")
+
+	manifest.DarkSource = writePNG(t, filepath.Join(dir, "LogoDark.png"), 256)
+	_, err = GenerateIcons(manifest, dir)
+	require.NoError(t, err)
+	l := NewLauncher(filepath.Join(dir, "icons"))
+	require.NoError(t, l.SetPlatform("macos"))
+	require.NoError(t, l.SetAppearance(AppearanceDark))
+	assert.Equal(t, filepath.Join(dir, "icons/desktop/large_dark.png"), l.GetPlatformIcon())
+	assert.FileExists(t, filepath.Join(dir, "icons/desktop/icon_dark.icns"))
+	assert.NoFileExists(t, filepath.Join(dir, "icons/desktop/icon_light.png"))
+}
+
+// TestLoadIconManifest tests reading and validating manifests
+func TestLoadIconManifest(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "icons.yaml")
+	require.NoError(t, os.WriteFile(path, []byte("source: logo.svg\ndark_source: dark.svg\noutputs:\n  - path: a.png\n    size: 16\n  - path: b.icns\n"), 0644))
+	m, err := LoadIconManifest(path)
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(dir, "logo.svg"), m.Source)
+	assert.Equal(t, filepath.Join(dir, "dark.svg"), m.DarkSource)
+	assert.Equal(t, 1024, m.largest())
+
+	for manifest, want := range map[string]string{
+		"outputs:\n  - path: a.png\n    size: 16\n":                       "source is required",
+		"source: l.png\noutputs:\n  - path: a.png\n":                      "outputs[0] a.png: size is required",
+		"source: l.png\noutputs:\n  - path: a.gif\n":                      `outputs[0] a.gif: unknown kind "gif": want png, ico, icns, appiconset, splash or adaptive`,
+		"source: l.png\noutputs:\n  - path: a.ico\n    variant: dim\n":    `outputs[0] a.ico: unknown variant "dim": want dark or light`,
+		"source: l.png\noutputs:\n  - path: s.png\n    kind: splash\n":    "outputs[0] s.png: width and height are required",
+		"source: l.png\noutputs:\n  - path: a.ico\n    background: red\n": `outputs[0] a.ico: background "red" is not a #rrggbb color`,
+	} {
+		require.NoError(t, os.WriteFile(path, []byte(manifest), 0644))
+		_, err := LoadIconManifest(path)
+		assert.EqualError(t, err, "icon manifest "+path+": "+want)
+	}
+}
+
+// TestLoadMaster_SVG tests rasterizing SVG masters with an installed tool
+func TestLoadMaster_SVG(t *testing.T) {
+	dir := t.TempDir()
+	svg := filepath.Join(dir, "logo.svg")
+	require.NoError(t, os.WriteFile(svg, []byte("<svg/>"), 0644))
+
+	bin, path := t.TempDir(), os.Getenv("PATH")
+	t.Setenv("PATH", bin)
+	_, err := loadMaster(svg, 64)
+	assert.EqualError(t, err, "no SVG rasterizer found for "+svg+": install rsvg-convert (librsvg), Inkscape or ImageMagick, or use a PNG master")
+
+	// A stand-in rsvg-convert copying a prepared PNG to its -o argument
+	rendered := writePNG(t, filepath.Join(dir, "rendered.png"), 64)
+	script := "#!/bin/sh\n[ \"$2\" = 64 ] || exit 3\ncat " + rendered + " > \"$7\"\n"
+	require.NoError(t, os.WriteFile(filepath.Join(bin, "rsvg-convert"), []byte(script), 0755))
+	t.Setenv("PATH", bin+string(os.PathListSeparator)+path)
+	img, err := loadMaster(svg, 64)
+	require.NoError(t, err)
+	assert.Equal(t, 64, img.Bounds().Dx())
+	_, err = loadMaster(svg, 32)
+	assert.ErrorContains(t, err, "rsvg-convert failed to rasterize")
+}
+
+// TestClearBackground tests that only the background connected to the
+// corners is cleared
+func TestClearBackground(t *testing.T) {
+	img := image.NewNRGBA(image.Rect(0, 0, 9, 9))
+	for i := range img.Pix {
+		img.Pix[i] = 255
+	}
+	// A red ring enclosing white
+	for i := 2; i <= 6; i++ {
+		for _, p := range []image.Point{{i, 2}, {i, 6}, {2, i}, {6, i}} {
+			img.SetNRGBA(p.X, p.Y, color.NRGBA{200, 0, 0, 255})
+		}
+	}
+	cleared := clearBackground(img).(*image.NRGBA)
+	assert.Zero(t, cleared.NRGBAAt(0, 0).A)
+	assert.Zero(t, cleared.NRGBAAt(8, 4).A)
+	assert.Equal(t, uint8(255), cleared.NRGBAAt(2, 2).A, "ring kept")
+	assert.Equal(t, color.NRGBA{255, 255, 255, 255}, cleared.NRGBAAt(4, 4), "enclosed white kept")
+}
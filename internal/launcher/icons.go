@@ -0,0 +1,236 @@
+package launcher
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	_ "image/jpeg"
+	"image/png"
+	"os"
+	"slices"
+
+	xdraw "golang.org/x/image/draw"
+)
+
+// IconTarget names what an installed icon belongs to: the desktop entry and
+// icon theme name on Linux, the app bundle on macOS, and the executable
+// whose resources hold it on Windows
+type IconTarget struct {
+	AppID  string // desktop entry and icon name, e.g. panoptic
+	Name   string // name shown by the desktop; defaults to AppID
+	Exec   string // command a Linux desktop entry runs, or the Windows executable
+	Bundle string // macOS .app bundle
+}
+
+// SetTarget sets what DisplayIcon installs the icon for
+func (l *Launcher) SetTarget(target IconTarget) {
+	l.target = target
+}
+
+// loadImage decodes a PNG or JPEG icon or splash screen
+func loadImage(path string) (image.Image, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	img, _, err := image.Decode(f)
+	if err != nil {
+		return nil, fmt.Errorf("%s is not a PNG or JPEG image: %w", path, err)
+	}
+	return img, nil
+}
+
+// iconSizes keeps the sizes no larger than the icon, so installed icons are
+// never upscaled; an icon smaller than them all is installed at the smallest
+func iconSizes(img image.Image, sizes []int) []int {
+	longer := img.Bounds().Dx()
+	if img.Bounds().Dy() > longer {
+		longer = img.Bounds().Dy()
+	}
+	var kept []int
+	for _, size := range sizes {
+		if size <= longer {
+			kept = append(kept, size)
+		}
+	}
+	if len(kept) == 0 {
+		kept = sizes[:1]
+	}
+	return kept
+}
+
+// squareImage renders an image centered in a size square, keeping its
+// aspect ratio, over a background color or transparency when it is nil
+func squareImage(img image.Image, size int, background color.Color) *image.NRGBA {
+	bounds := img.Bounds()
+	width, height := size, size
+	if bounds.Dx() > bounds.Dy() {
+		height = (size*bounds.Dy() + bounds.Dx()/2) / bounds.Dx()
+	} else if bounds.Dy() > bounds.Dx() {
+		width = (size*bounds.Dx() + bounds.Dy()/2) / bounds.Dy()
+	}
+	canvas := image.NewNRGBA(image.Rect(0, 0, size, size))
+	if background != nil {
+		draw.Draw(canvas, canvas.Bounds(), image.NewUniform(background), image.Point{}, draw.Src)
+	}
+	at := image.Pt((size-width)/2, (size-height)/2)
+	xdraw.CatmullRom.Scale(canvas, image.Rectangle{Min: at, Max: at.Add(image.Pt(width, height))}, img, bounds, xdraw.Over, nil)
+	return canvas
+}
+
+// encodePNG encodes an image as PNG
+func encodePNG(img image.Image) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// squarePNG renders an icon centered in a transparent size square, keeping
+// its aspect ratio
+func squarePNG(img image.Image, size int) ([]byte, error) {
+	return encodePNG(squareImage(img, size, nil))
+}
+
+// icnsTypes are the PNG-compressed ICNS entry types by pixel size
+var icnsTypes = []struct {
+	size int
+	kind string
+}{
+	{16, "icp4"}, {32, "icp5"}, {64, "icp6"}, {128, "ic07"}, {256, "ic08"}, {512, "ic09"}, {1024, "ic10"},
+}
+
+// icnsSizes are the sizes of a macOS icon
+var icnsSizes = func() []int {
+	var sizes []int
+	for _, t := range icnsTypes {
+		sizes = append(sizes, t.size)
+	}
+	return sizes
+}()
+
+// encodeICNS builds a macOS icon family of PNG entries at the given sizes
+func encodeICNS(img image.Image, sizes []int) ([]byte, error) {
+	keep := map[int]bool{}
+	for _, size := range sizes {
+		if !slices.Contains(icnsSizes, size) {
+			return nil, fmt.Errorf("macOS icons have no %dpx image, only %v", size, icnsSizes)
+		}
+		keep[size] = true
+	}
+
+	var body bytes.Buffer
+	for _, t := range icnsTypes {
+		if !keep[t.size] {
+			continue
+		}
+		data, err := squarePNG(img, t.size)
+		if err != nil {
+			return nil, err
+		}
+		body.WriteString(t.kind)
+		binary.Write(&body, binary.BigEndian, uint32(8+len(data)))
+		body.Write(data)
+	}
+	var icns bytes.Buffer
+	icns.WriteString("icns")
+	binary.Write(&icns, binary.BigEndian, uint32(8+body.Len()))
+	icns.Write(body.Bytes())
+	return icns.Bytes(), nil
+}
+
+// icoSizes are the sizes of a Windows icon
+var icoSizes = []int{16, 24, 32, 48, 64, 128, 256}
+
+// icoImage is one image of a Windows icon
+type icoImage struct {
+	width, height int // 0 for 256
+	data          []byte
+}
+
+// encodeICO builds a Windows icon of PNG-compressed images at the given
+// sizes, which Windows Vista and later read
+func encodeICO(img image.Image, sizes []int) ([]byte, error) {
+	var images []icoImage
+	for _, size := range sizes {
+		if size < 1 || size > 256 {
+			return nil, fmt.Errorf("Windows icons have images from 1 to 256px, not %dpx", size)
+		}
+		data, err := squarePNG(img, size)
+		if err != nil {
+			return nil, err
+		}
+		images = append(images, icoImage{width: size % 256, height: size % 256, data: data})
+	}
+
+	var buf bytes.Buffer
+	binary.Write(&buf, binary.LittleEndian, [3]uint16{0, 1, uint16(len(images))})
+	offset := 6 + 16*len(images)
+	for _, i := range images {
+		binary.Write(&buf, binary.LittleEndian, struct {
+			Width, Height, Colors, Reserved uint8
+			Planes, BitCount                uint16
+			Size, Offset                    uint32
+		}{uint8(i.width), uint8(i.height), 0, 0, 1, 32, uint32(len(i.data)), uint32(offset)})
+		offset += len(i.data)
+	}
+	for _, i := range images {
+		buf.Write(i.data)
+	}
+	return buf.Bytes(), nil
+}
+
+// icoEntry is an image of a parsed Windows icon with its directory entry,
+// which becomes a group icon entry when embedded as a resource
+type icoEntry struct {
+	Width, Height, Colors, Reserved uint8
+	Planes, BitCount                uint16
+	Size                            uint32
+	data                            []byte
+}
+
+// parseICO reads the images of a Windows icon
+func parseICO(data []byte) ([]icoEntry, error) {
+	if len(data) < 6 || binary.LittleEndian.Uint16(data[0:]) != 0 || binary.LittleEndian.Uint16(data[2:]) != 1 {
+		return nil, fmt.Errorf("not a Windows icon")
+	}
+	count := int(binary.LittleEndian.Uint16(data[4:]))
+	if count == 0 || len(data) < 6+16*count {
+		return nil, fmt.Errorf("icon directory is truncated")
+	}
+	entries := make([]icoEntry, count)
+	for i := range entries {
+		d := data[6+16*i:]
+		e := icoEntry{Width: d[0], Height: d[1], Colors: d[2], Reserved: d[3],
+			Planes: binary.LittleEndian.Uint16(d[4:]), BitCount: binary.LittleEndian.Uint16(d[6:]),
+			Size: binary.LittleEndian.Uint32(d[8:])}
+		offset := binary.LittleEndian.Uint32(d[12:])
+		if uint64(offset)+uint64(e.Size) > uint64(len(data)) {
+			return nil, fmt.Errorf("icon image %d lies outside the file", i)
+		}
+		e.data = data[offset : offset+e.Size]
+		entries[i] = e
+	}
+	return entries, nil
+}
+
+// groupIcon is the RT_GROUP_ICON resource naming the RT_ICON resources
+// firstID onwards
+func groupIcon(entries []icoEntry, firstID uint16) []byte {
+	var buf bytes.Buffer
+	binary.Write(&buf, binary.LittleEndian, [3]uint16{0, 1, uint16(len(entries))})
+	for i, e := range entries {
+		binary.Write(&buf, binary.LittleEndian, struct {
+			Width, Height, Colors, Reserved uint8
+			Planes, BitCount                uint16
+			Size                            uint32
+			ID                              uint16
+		}{e.Width, e.Height, e.Colors, e.Reserved, e.Planes, e.BitCount, e.Size, firstID + uint16(i)})
+	}
+	return buf.Bytes()
+}
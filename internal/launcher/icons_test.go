@@ -0,0 +1,101 @@
+package launcher
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image"
+	"image/color"
+	"image/png"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writePNG saves a size x size icon with an opaque square in the middle
+func writePNG(t *testing.T, path string, size int) string {
+	t.Helper()
+	img := image.NewNRGBA(image.Rect(0, 0, size, size))
+	for y := size / 4; y < size*3/4; y++ {
+		for x := size / 4; x < size*3/4; x++ {
+			img.SetNRGBA(x, y, color.NRGBA{200, 30, 30, 255})
+		}
+	}
+	f, err := os.Create(path)
+	require.NoError(t, err)
+	require.NoError(t, png.Encode(f, img))
+	require.NoError(t, f.Close())
+	return path
+}
+
+// TestIconSizes tests that icons are never upscaled
+func TestIconSizes(t *testing.T) {
+	assert.Equal(t, []int{16, 24, 32, 48}, iconSizes(image.NewNRGBA(image.Rect(0, 0, 50, 40)), linuxIconSizes))
+	assert.Equal(t, []int{16}, iconSizes(image.NewNRGBA(image.Rect(0, 0, 8, 8)), linuxIconSizes), "tiny icons get the smallest size")
+}
+
+// TestSquarePNG tests that wide icons are centered in a transparent square
+func TestSquarePNG(t *testing.T) {
+	wide := image.NewNRGBA(image.Rect(0, 0, 100, 50))
+	for i := range wide.Pix {
+		wide.Pix[i] = 0xff
+	}
+	data, err := squarePNG(wide, 32)
+	require.NoError(t, err)
+	img, err := png.Decode(bytes.NewReader(data))
+	require.NoError(t, err)
+	assert.Equal(t, image.Rect(0, 0, 32, 32), img.Bounds())
+	_, _, _, a := img.At(16, 2).RGBA()
+	assert.Zero(t, a, "padding above")
+	_, _, _, a = img.At(16, 16).RGBA()
+	assert.Equal(t, uint32(0xffff), a)
+}
+
+// TestEncodeICNS tests the icon family layout
+func TestEncodeICNS(t *testing.T) {
+	icns, err := encodeICNS(image.NewNRGBA(image.Rect(0, 0, 128, 128)), []int{16, 32, 64, 128})
+	require.NoError(t, err)
+	assert.Equal(t, "icns", string(icns[:4]))
+	assert.Equal(t, uint32(len(icns)), binary.BigEndian.Uint32(icns[4:]))
+
+	var kinds []string
+	for at := 8; at < len(icns); {
+		kinds = append(kinds, string(icns[at:at+4]))
+		size := int(binary.BigEndian.Uint32(icns[at+4:]))
+		_, err := png.Decode(bytes.NewReader(icns[at+8 : at+size]))
+		require.NoError(t, err)
+		at += size
+	}
+	assert.Equal(t, []string{"icp4", "icp5", "icp6", "ic07"}, kinds)
+
+	_, err = encodeICNS(image.NewNRGBA(image.Rect(0, 0, 128, 128)), []int{48})
+	assert.EqualError(t, err, "macOS icons have no 48px image, only [16 32 64 128 256 512 1024]")
+}
+
+// TestEncodeICO tests that icons round-trip through the ICO directory and
+// become a group icon resource
+func TestEncodeICO(t *testing.T) {
+	ico, err := encodeICO(image.NewNRGBA(image.Rect(0, 0, 256, 256)), icoSizes)
+	require.NoError(t, err)
+	entries, err := parseICO(ico)
+	require.NoError(t, err)
+	require.Len(t, entries, len(icoSizes))
+	assert.Equal(t, uint8(16), entries[0].Width)
+	assert.Equal(t, uint8(0), entries[6].Width, "256 is stored as 0")
+	assert.Equal(t, uint16(32), entries[6].BitCount)
+	img, err := png.Decode(bytes.NewReader(entries[6].data))
+	require.NoError(t, err)
+	assert.Equal(t, 256, img.Bounds().Dx())
+
+	group := groupIcon(entries, 1)
+	assert.Len(t, group, 6+14*len(entries))
+	assert.Equal(t, uint16(7), binary.LittleEndian.Uint16(group[6+14*6+12:]), "the last image is icon 7")
+
+	_, err = parseICO([]byte("\x89PNG"))
+	assert.EqualError(t, err, "not a Windows icon")
+	_, err = parseICO(ico[:20])
+	assert.EqualError(t, err, "icon directory is truncated")
+	_, err = encodeICO(image.NewNRGBA(image.Rect(0, 0, 16, 16)), []int{512})
+	assert.EqualError(t, err, "Windows icons have images from 1 to 256px, not 512px")
+}
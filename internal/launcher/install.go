@@ -0,0 +1,224 @@
+package launcher
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// linuxIconSizes are the hicolor theme sizes icons are installed at
+var linuxIconSizes = []int{16, 24, 32, 48, 64, 128, 256, 512}
+
+// appID is the name the icon is installed under
+func (l *Launcher) appID() string {
+	if l.target.AppID != "" {
+		return l.target.AppID
+	}
+	if l.target.Bundle != "" {
+		return strings.TrimSuffix(filepath.Base(l.target.Bundle), ".app")
+	}
+	return "panoptic"
+}
+
+// dataHome is the user's XDG data directory
+func dataHome() (string, error) {
+	if dir := os.Getenv("XDG_DATA_HOME"); dir != "" {
+		return dir, nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, ".local", "share"), nil
+}
+
+// installLinuxIcon installs the icon into the user's hicolor icon theme at
+// every standard size up to its own, and points the app's desktop entry at
+// it, creating the entry when there is none
+func (l *Launcher) installLinuxIcon(iconPath string) error {
+	img, err := loadImage(iconPath)
+	if err != nil {
+		return err
+	}
+	data, err := dataHome()
+	if err != nil {
+		return err
+	}
+	id := l.appID()
+	theme := filepath.Join(data, "icons", "hicolor")
+	for _, size := range iconSizes(img, linuxIconSizes) {
+		icon, err := squarePNG(img, size)
+		if err != nil {
+			return err
+		}
+		dir := filepath.Join(theme, fmt.Sprintf("%dx%d", size, size), "apps")
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return err
+		}
+		if err := os.WriteFile(filepath.Join(dir, id+".png"), icon, 0644); err != nil {
+			return err
+		}
+	}
+
+	applications := filepath.Join(data, "applications")
+	if err := os.MkdirAll(applications, 0755); err != nil {
+		return err
+	}
+	entryPath := filepath.Join(applications, id+".desktop")
+	entry, err := os.ReadFile(entryPath)
+	if os.IsNotExist(err) {
+		entry, err = l.desktopEntry()
+	}
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(entryPath, setDesktopIcon(entry, id), 0644); err != nil {
+		return err
+	}
+
+	// Refresh the caches when their tools are installed; desktops rescan
+	// the directories without them
+	refresh(exec.Command("gtk-update-icon-cache", "--force", "--ignore-theme-index", theme))
+	refresh(exec.Command("update-desktop-database", applications))
+	l.installed = entryPath
+	return nil
+}
+
+// desktopEntry is a new desktop entry launching the target
+func (l *Launcher) desktopEntry() ([]byte, error) {
+	name := l.target.Name
+	if name == "" {
+		name = l.appID()
+	}
+	command := l.target.Exec
+	if command == "" {
+		exe, err := os.Executable()
+		if err != nil {
+			return nil, err
+		}
+		command = exe
+	}
+	return []byte(fmt.Sprintf("[Desktop Entry]\nType=Application\nName=%s\nExec=%s\nIcon=\nTerminal=false\n", name, command)), nil
+}
+
+var desktopIconLine = regexp.MustCompile(`(?m)^Icon=.*$`)
+
+// setDesktopIcon sets the Icon key of the [Desktop Entry] group
+func setDesktopIcon(entry []byte, icon string) []byte {
+	if desktopIconLine.Match(entry) {
+		return desktopIconLine.ReplaceAll(entry, []byte("Icon="+icon))
+	}
+	group := []byte("[Desktop Entry]\n")
+	if at := bytes.Index(entry, group); at >= 0 {
+		at += len(group)
+		return append(append(append([]byte{}, entry[:at]...), []byte("Icon="+icon+"\n")...), entry[at:]...)
+	}
+	return append([]byte("[Desktop Entry]\nIcon="+icon+"\n"), entry...)
+}
+
+// refresh runs a cache update command when it is installed
+func refresh(cmd *exec.Cmd) {
+	if cmd.Err == nil {
+		_ = cmd.Run()
+	}
+}
+
+var plistIconFile = regexp.MustCompile(`(<key>CFBundleIconFile</key>\s*<string>)[^<]*(</string>)`)
+
+// installMacOSIcon writes the icon as an .icns file into the target app
+// bundle's resources and names it in the bundle's Info.plist
+func (l *Launcher) installMacOSIcon(iconPath string) error {
+	bundle := l.target.Bundle
+	if bundle == "" {
+		return fmt.Errorf("macOS application icons belong to an app bundle: set the target bundle")
+	}
+	plistPath := filepath.Join(bundle, "Contents", "Info.plist")
+	plist, err := os.ReadFile(plistPath)
+	if err != nil {
+		return fmt.Errorf("%s is not an app bundle: %w", bundle, err)
+	}
+	if bytes.HasPrefix(plist, []byte("bplist")) {
+		return fmt.Errorf("%s is a binary property list; convert it with plutil -convert xml1 first", plistPath)
+	}
+
+	var icns []byte
+	if strings.EqualFold(filepath.Ext(iconPath), ".icns") {
+		icns, err = os.ReadFile(iconPath)
+	} else {
+		img, loadErr := loadImage(iconPath)
+		if loadErr != nil {
+			return loadErr
+		}
+		icns, err = encodeICNS(img, iconSizes(img, icnsSizes))
+	}
+	if err != nil {
+		return err
+	}
+
+	name := l.appID() + ".icns"
+	resources := filepath.Join(bundle, "Contents", "Resources")
+	if err := os.MkdirAll(resources, 0755); err != nil {
+		return err
+	}
+	if err := os.WriteFile(filepath.Join(resources, name), icns, 0644); err != nil {
+		return err
+	}
+	if plistIconFile.Match(plist) {
+		plist = plistIconFile.ReplaceAll(plist, []byte("${1}"+name+"${2}"))
+	} else if at := bytes.LastIndex(plist, []byte("</dict>")); at >= 0 {
+		plist = append(append(append([]byte{}, plist[:at]...), []byte("\t<key>CFBundleIconFile</key>\n\t<string>"+name+"</string>\n")...), plist[at:]...)
+	} else {
+		return fmt.Errorf("%s has no top-level dictionary", plistPath)
+	}
+	if err := os.WriteFile(plistPath, plist, 0644); err != nil {
+		return err
+	}
+
+	// Finder and the Dock reread the icon of a bundle that changed
+	now := time.Now()
+	if err := os.Chtimes(bundle, now, now); err != nil {
+		return err
+	}
+	l.installed = filepath.Join(resources, name)
+	return nil
+}
+
+// installWindowsIcon embeds the icon as the first icon group of the target
+// executable, which Explorer and the taskbar show
+func (l *Launcher) installWindowsIcon(iconPath string) error {
+	exe := l.target.Exec
+	if exe == "" {
+		return fmt.Errorf("Windows application icons are embedded in an executable: set the target executable")
+	}
+	if _, err := os.Stat(exe); err != nil {
+		return err
+	}
+	var ico []byte
+	var err error
+	if strings.EqualFold(filepath.Ext(iconPath), ".ico") {
+		ico, err = os.ReadFile(iconPath)
+	} else {
+		img, loadErr := loadImage(iconPath)
+		if loadErr != nil {
+			return loadErr
+		}
+		ico, err = encodeICO(img, iconSizes(img, icoSizes))
+	}
+	if err != nil {
+		return err
+	}
+	entries, err := parseICO(ico)
+	if err != nil {
+		return fmt.Errorf("%s: %w", iconPath, err)
+	}
+	if err := embedIcon(exe, entries); err != nil {
+		return err
+	}
+	l.installed = exe
+	return nil
+}
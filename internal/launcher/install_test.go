@@ -0,0 +1,123 @@
+package launcher
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestInstallLinuxIcon tests installing theme icons and pointing an
+// existing desktop entry at them
+func TestInstallLinuxIcon(t *testing.T) {
+	dir := t.TempDir()
+	data := filepath.Join(dir, "share")
+	t.Setenv("XDG_DATA_HOME", data)
+	icon := writePNG(t, filepath.Join(dir, "icon.png"), 128)
+
+	entry := filepath.Join(data, "applications", "shop.desktop")
+	require.NoError(t, os.MkdirAll(filepath.Dir(entry), 0755))
+	require.NoError(t, os.WriteFile(entry, []byte("[Desktop Entry]\nType=Application\nName=Shop\nExec=shop %U\nIcon=old-shop\n"), 0644))
+
+	l := NewLauncher(dir)
+	l.platform = "linux"
+	l.SetTarget(IconTarget{AppID: "shop"})
+	require.NoError(t, l.SetIcon(icon))
+	require.NoError(t, l.DisplayIcon())
+
+	for _, size := range []string{"16x16", "48x48", "128x128"} {
+		assert.FileExists(t, filepath.Join(data, "icons", "hicolor", size, "apps", "shop.png"))
+	}
+	assert.NoFileExists(t, filepath.Join(data, "icons", "hicolor", "256x256", "apps", "shop.png"), "not upscaled")
+	content, err := os.ReadFile(entry)
+	require.NoError(t, err)
+	assert.Equal(t, "[Desktop Entry]\nType=Application\nName=Shop\nExec=shop %U\nIcon=shop\n", string(content))
+
+	// A new entry launches the target
+	l.SetTarget(IconTarget{AppID: "panoptic-dashboard", Name: "Panoptic Dashboard", Exec: "/opt/panoptic/panoptic dashboard"})
+	require.NoError(t, l.DisplayIcon())
+	content, err = os.ReadFile(l.InstalledPath())
+	require.NoError(t, err)
+	assert.Equal(t, "[Desktop Entry]\nType=Application\nName=Panoptic Dashboard\nExec=/opt/panoptic/panoptic dashboard\nIcon=panoptic-dashboard\nTerminal=false\n", string(content))
+}
+
+// TestSetDesktopIcon tests adding the Icon key to entries without one
+func TestSetDesktopIcon(t *testing.T) {
+	assert.Equal(t, "[Desktop Entry]\nIcon=app\nName=App\n", string(setDesktopIcon([]byte("[Desktop Entry]\nName=App\n"), "app")))
+	assert.Equal(t, "[Desktop Entry]\nIcon=app\n", string(setDesktopIcon(nil, "app")))
+}
+
+const infoPlist = `<?xml version="1.0" encoding="UTF-8"?>
+<plist version="1.0">
+<dict>
+	<key>CFBundleName</key>
+	<string>Shop</string>
+</dict>
+</plist>
+`
+
+// TestInstallMacOSIcon tests writing the bundle's .icns and naming it in
+// Info.plist
+func TestInstallMacOSIcon(t *testing.T) {
+	dir := t.TempDir()
+	bundle := filepath.Join(dir, "Shop.app")
+	require.NoError(t, os.MkdirAll(filepath.Join(bundle, "Contents"), 0755))
+	plistPath := filepath.Join(bundle, "Contents", "Info.plist")
+	require.NoError(t, os.WriteFile(plistPath, []byte(infoPlist), 0644))
+
+	l := NewLauncher(dir)
+	l.platform = "macos"
+	require.NoError(t, l.SetIcon(writePNG(t, filepath.Join(dir, "icon.png"), 64)))
+	err := l.DisplayIcon()
+	assert.EqualError(t, err, "macOS application icons belong to an app bundle: set the target bundle")
+
+	l.SetTarget(IconTarget{Bundle: bundle})
+	require.NoError(t, l.DisplayIcon())
+	icns := filepath.Join(bundle, "Contents", "Resources", "Shop.icns")
+	assert.Equal(t, icns, l.InstalledPath())
+	data, err := os.ReadFile(icns)
+	require.NoError(t, err)
+	assert.Equal(t, "icns", string(data[:4]))
+	plist, err := os.ReadFile(plistPath)
+	require.NoError(t, err)
+	assert.Contains(t, string(plist), "\t<key>CFBundleIconFile</key>\n\t<string>Shop.icns</string>\n</dict>")
+
+	// An existing icon file entry is replaced
+	l.SetTarget(IconTarget{Bundle: bundle, AppID: "ShopBeta"})
+	require.NoError(t, l.DisplayIcon())
+	plist, err = os.ReadFile(plistPath)
+	require.NoError(t, err)
+	assert.Contains(t, string(plist), "<string>ShopBeta.icns</string>")
+	assert.NotContains(t, string(plist), "<string>Shop.icns</string>")
+
+	require.NoError(t, os.WriteFile(plistPath, []byte("bplist00binary"), 0644))
+	assert.ErrorContains(t, l.DisplayIcon(), "plutil -convert xml1")
+	l.SetTarget(IconTarget{Bundle: filepath.Join(dir, "Missing.app")})
+	assert.ErrorContains(t, l.DisplayIcon(), "is not an app bundle")
+}
+
+// TestInstallWindowsIcon tests the checks made before the resource update,
+// which needs Windows
+func TestInstallWindowsIcon(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("embeds into a real executable on Windows")
+	}
+	dir := t.TempDir()
+	l := NewLauncher(dir)
+	l.platform = "windows"
+	require.NoError(t, l.SetIcon(writePNG(t, filepath.Join(dir, "icon.png"), 32)))
+	assert.EqualError(t, l.DisplayIcon(), "Windows application icons are embedded in an executable: set the target executable")
+
+	exe := filepath.Join(dir, "shop.exe")
+	l.SetTarget(IconTarget{Exec: exe})
+	assert.Error(t, l.DisplayIcon(), "missing executable")
+	require.NoError(t, os.WriteFile(exe, []byte("MZ"), 0755))
+	assert.ErrorContains(t, l.DisplayIcon(), "needs Windows")
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "broken.ico"), []byte("nope"), 0644))
+	require.NoError(t, l.SetIcon("broken.ico"))
+	assert.ErrorContains(t, l.DisplayIcon(), "not a Windows icon")
+}
@@ -2,6 +2,7 @@ package launcher
 
 import (
 	"fmt"
+	"image"
 	"os"
 	"path/filepath"
 	"runtime"
@@ -12,6 +13,10 @@ type Launcher struct {
 	iconDir      string
 	currentIcon  string
 	platform     string
+	target       IconTarget
+	installed    string // desktop entry, .icns or executable DisplayIcon last wrote
+	splash       SplashOptions
+	appearance   string // light or dark icon variants, detected when empty
 }
 
 // NewLauncher creates a new launcher icon manager
@@ -24,7 +29,12 @@ func NewLauncher(iconDir string) *Launcher {
 
 // detectPlatform detects the current platform
 func detectPlatform() string {
-	switch runtime.GOOS {
+	return platformFor(runtime.GOOS)
+}
+
+// platformFor is the launcher platform of a GOOS
+func platformFor(goos string) string {
+	switch goos {
 	case "windows":
 		return "windows"
 	case "darwin":
@@ -59,25 +69,35 @@ func (l *Launcher) GetIconPath() string {
 	return l.currentIcon
 }
 
-// GetPlatformIcon returns the appropriate icon for the current platform
+// GetPlatformIcon returns the appropriate icon for the current platform:
+// the adaptive icon definition on Android when there is one, and the
+// variant for the system appearance, like icon_dark.png, when there is one
 func (l *Launcher) GetPlatformIcon() string {
+	var icon string
 	switch l.platform {
 	case "windows":
-		return filepath.Join(l.iconDir, "desktop", "icon.png")
+		icon = filepath.Join(l.iconDir, "desktop", "icon.png")
 	case "macos":
-		return filepath.Join(l.iconDir, "desktop", "large.png")
+		icon = filepath.Join(l.iconDir, "desktop", "large.png")
 	case "linux":
-		return filepath.Join(l.iconDir, "desktop", "icon.png")
+		icon = filepath.Join(l.iconDir, "desktop", "icon.png")
 	case "android":
-		return filepath.Join(l.iconDir, "android", "xxxhdpi", "icon_xxxhdpi.png")
+		if _, err := os.Stat(l.adaptiveIconPath()); err == nil {
+			return l.adaptiveIconPath()
+		}
+		icon = filepath.Join(l.iconDir, "android", "xxxhdpi", "icon_xxxhdpi.png")
 	case "ios":
-		return filepath.Join(l.iconDir, "ios", "appstore", "icon_appstore.png")
+		icon = filepath.Join(l.iconDir, "ios", "appstore", "icon_appstore.png")
 	default:
-		return filepath.Join(l.iconDir, "desktop", "icon.png")
+		icon = filepath.Join(l.iconDir, "desktop", "icon.png")
 	}
+	return l.variant(icon)
 }
 
-// DisplayIcon displays the launcher icon (platform-specific implementation)
+// DisplayIcon sets the current icon, or the platform icon, as the OS-level
+// icon of the target application: a hicolor theme icon and desktop entry on
+// Linux, the .icns of the app bundle on macOS, and the icon resource of the
+// executable on Windows
 func (l *Launcher) DisplayIcon() error {
 	iconPath := l.currentIcon
 	if iconPath == "" {
@@ -87,51 +107,29 @@ func (l *Launcher) DisplayIcon() error {
 	if iconPath == "" {
 		return fmt.Errorf("no icon available to display")
 	}
-	
+	var install func(string) error
 	switch l.platform {
 	case "windows":
-		return l.displayWindowsIcon(iconPath)
+		install = l.installWindowsIcon
 	case "macos":
-		return l.displayMacOSIcon(iconPath)
+		install = l.installMacOSIcon
 	case "linux":
-		return l.displayLinuxIcon(iconPath)
+		install = l.installLinuxIcon
 	default:
 		return fmt.Errorf("icon display not supported on platform: %s", l.platform)
 	}
+	if _, err := os.Stat(iconPath); err != nil {
+		return fmt.Errorf("icon file not found: %s", iconPath)
+	}
+	return install(iconPath)
 }
 
-// displayWindowsIcon would display the icon on Windows via WinAPI
-// SetConsoleIcon. Not wired; returns ErrIconDisplayNotWired. Previous
-// behavior printed a 🎯-prefixed log line and returned nil, letting
-// any test assertion of "icon displayed" PASS without any platform
-// API call — §11.4 stub-interface bluff.
-func (l *Launcher) displayWindowsIcon(iconPath string) error {
-	fmt.Printf("[§11.4 / CONST-035] displayWindowsIcon: icon=%s but WinAPI SetConsoleIcon dispatch is not wired; returning ErrIconDisplayNotWired\n", iconPath)
-	return ErrIconDisplayNotWired
-}
-
-// displayMacOSIcon would display the icon on macOS via NSApplication
-// .setApplicationIconImage. Not wired; returns ErrIconDisplayNotWired.
-func (l *Launcher) displayMacOSIcon(iconPath string) error {
-	fmt.Printf("[§11.4 / CONST-035] displayMacOSIcon: icon=%s but NSApplication.setApplicationIconImage dispatch is not wired; returning ErrIconDisplayNotWired\n", iconPath)
-	return ErrIconDisplayNotWired
-}
-
-// displayLinuxIcon would display the icon on Linux via GTK
-// gtk_window_set_icon_from_file or freedesktop .desktop entry. Not
-// wired; returns ErrIconDisplayNotWired.
-func (l *Launcher) displayLinuxIcon(iconPath string) error {
-	fmt.Printf("[§11.4 / CONST-035] displayLinuxIcon: icon=%s but GTK gtk_window_set_icon_from_file dispatch is not wired; returning ErrIconDisplayNotWired\n", iconPath)
-	return ErrIconDisplayNotWired
+// InstalledPath is the desktop entry, .icns file or executable the last
+// DisplayIcon call wrote
+func (l *Launcher) InstalledPath() string {
+	return l.installed
 }
 
-// ErrIconDisplayNotWired is returned by displayWindowsIcon /
-// displayMacOSIcon / displayLinuxIcon until per-platform native APIs
-// are wired. The previous print-and-return-nil pattern was a §11.4
-// PASS-bluff — callers asserting "icon displayed" got nil for a
-// function that did nothing.
-var ErrIconDisplayNotWired = fmt.Errorf("launcher icon display: per-platform native API dispatch is not wired (Windows: WinAPI SetConsoleIcon; macOS: NSApplication.setApplicationIconImage; Linux: GTK gtk_window_set_icon_from_file); the previous print-and-return-nil path was a §11.4 PASS-bluff and is now removed")
-
 // GetAvailableIcons returns a list of available icons
 func (l *Launcher) GetAvailableIcons() ([]string, error) {
 	var icons []string
@@ -173,7 +171,9 @@ func (l *Launcher) GetAvailableIcons() ([]string, error) {
 	return icons, nil
 }
 
-// ShowSplashScreen displays a splash screen with the launcher icon
+// ShowSplashScreen opens a borderless window centered on the screen showing
+// the splash image, fades it in, and closes it after fading it out at the
+// timeout of the splash options. It blocks until the window is closed.
 func (l *Launcher) ShowSplashScreen(splashPath string) error {
 	if splashPath == "" {
 		// Get default splash screen for platform
@@ -194,28 +194,32 @@ func (l *Launcher) ShowSplashScreen(splashPath string) error {
 	if _, err := os.Stat(splashPath); os.IsNotExist(err) {
 		return fmt.Errorf("splash screen file not found: %s", splashPath)
 	}
-	
-	// Display splash screen (platform-specific implementation)
+	var show func(image.Image, SplashOptions) error
 	switch l.platform {
 	case "windows":
-		fmt.Printf("🖼️  Displaying splash screen: %s\n", splashPath)
+		show = showSplashWindows
 	case "macos":
-		fmt.Printf("🖼️  Displaying splash screen: %s\n", splashPath)
+		show = func(_ image.Image, options SplashOptions) error { return showSplashMacOS(splashPath, options) }
 	case "linux":
-		fmt.Printf("🖼️  Displaying splash screen: %s\n", splashPath)
+		show = showSplashX11
 	default:
-		fmt.Printf("🖼️  Displaying splash screen: %s\n", splashPath)
+		return fmt.Errorf("splash screen display not supported on platform: %s", l.platform)
 	}
-	
-	return nil
+	img, err := loadImage(splashPath)
+	if err != nil {
+		return err
+	}
+	return show(img, l.splashOptions())
 }
 
 // LauncherInfo contains information about the launcher
 type LauncherInfo struct {
-	Platform    string   `json:"platform"`
-	IconPath    string   `json:"icon_path"`
-	Available   []string `json:"available_icons"`
-	SplashPath  string   `json:"splash_path,omitempty"`
+	Platform     string        `json:"platform"`
+	Appearance   string        `json:"appearance"`
+	IconPath     string        `json:"icon_path"`
+	Available    []string      `json:"available_icons"`
+	SplashPath   string        `json:"splash_path,omitempty"`
+	Capabilities *Capabilities `json:"capabilities,omitempty"`
 }
 
 // GetInfo returns launcher information
@@ -226,9 +230,11 @@ func (l *Launcher) GetInfo() (*LauncherInfo, error) {
 	}
 	
 	info := &LauncherInfo{
-		Platform:  l.platform,
-		IconPath:  l.GetPlatformIcon(),
-		Available: available,
+		Platform:     l.platform,
+		Appearance:   l.Appearance(),
+		IconPath:     l.GetPlatformIcon(),
+		Available:    available,
+		Capabilities: l.Capabilities(),
 	}
 	
 	return info, nil
@@ -5,6 +5,7 @@ import (
 	"path/filepath"
 	"runtime"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -103,31 +104,31 @@ func TestGetPlatformIcon(t *testing.T) {
 	}
 }
 
-// TestDisplayIcon tests displaying launcher icon
-// TestDisplayIcon verifies the §11.4 sentinel "not wired" error
-// returned by display{Windows,MacOS,Linux}Icon (round-17 fix). The
-// previous version asserted NoError on a print-and-return-nil stub —
-// that assertion was CERTIFYING the bluff. The unsupported-platform
-// path retains its own "icon display not supported" error (see
-// TestDisplayIcon_UnsupportedPlatform below).
+// TestDisplayIcon tests that the icon is installed for the detected
+// platform, and that a missing icon is an error rather than a silent pass
 func TestDisplayIcon(t *testing.T) {
 	tempDir := t.TempDir()
+	t.Setenv("XDG_DATA_HOME", filepath.Join(tempDir, "share"))
 	launcher := NewLauncher(tempDir)
+	launcher.platform = "linux"
 
 	err := launcher.DisplayIcon()
-	require.Error(t, err, "Should return ErrIconDisplayNotWired on supported platforms")
-	assert.Contains(t, err.Error(), "not wired")
-
-	iconPath := filepath.Join(tempDir, "custom.png")
-	err = os.WriteFile(iconPath, []byte("fake png data"), 0644)
-	require.NoError(t, err, "Should create custom icon")
+	require.Error(t, err, "Should fail without a platform icon")
+	assert.Contains(t, err.Error(), "icon file not found")
 
+	iconPath := writePNG(t, filepath.Join(tempDir, "custom.png"), 64)
 	err = launcher.SetIcon(iconPath)
 	require.NoError(t, err, "Should set custom icon")
 
 	err = launcher.DisplayIcon()
-	require.Error(t, err, "Should return ErrIconDisplayNotWired even with custom icon set")
-	assert.Contains(t, err.Error(), "not wired")
+	require.NoError(t, err, "Should install the custom icon")
+	assert.Equal(t, filepath.Join(tempDir, "share", "applications", "panoptic.desktop"), launcher.InstalledPath())
+	assert.FileExists(t, filepath.Join(tempDir, "share", "icons", "hicolor", "64x64", "apps", "panoptic.png"))
+
+	require.NoError(t, os.WriteFile(iconPath, []byte("fake png data"), 0644))
+	err = launcher.DisplayIcon()
+	require.Error(t, err, "Should reject an icon that isn't an image")
+	assert.Contains(t, err.Error(), "is not a PNG or JPEG image")
 }
 
 // TestDisplayIcon_UnsupportedPlatform tests display on unsupported platform
@@ -191,53 +192,52 @@ func TestGetAvailableIcons_EmptyDirectory(t *testing.T) {
 	assert.Empty(t, available, "Should return empty list for empty directory")
 }
 
-// TestShowSplashScreen tests showing splash screen
+// testSplashOptions keep splash screens in tests short
+var testSplashOptions = SplashOptions{FadeIn: 20 * time.Millisecond, FadeOut: 20 * time.Millisecond, Timeout: 60 * time.Millisecond}
+
+// TestShowSplashScreen tests showing the default splash screen in a window
+// on a fake X display
 func TestShowSplashScreen(t *testing.T) {
 	tempDir := t.TempDir()
 	launcher := NewLauncher(tempDir)
+	launcher.platform = "linux"
+	require.NoError(t, launcher.SetSplashOptions(testSplashOptions))
+	display, sessions := fakeXServer(t)
+	t.Setenv("DISPLAY", display)
 
 	// Create splash directory structure
-	splashDir := filepath.Join(tempDir, "..", "splash")
-	switch launcher.platform {
-	case "android":
-		splashDir = filepath.Join(splashDir, "android", "portrait", "xxxhdpi")
-	case "ios":
-		splashDir = filepath.Join(splashDir, "ios", "iphone")
-	default:
-		splashDir = filepath.Join(splashDir, "android", "portrait", "xxxhdpi")
-	}
+	splashDir := filepath.Join(tempDir, "..", "splash", "android", "portrait", "xxxhdpi")
 	err := os.MkdirAll(splashDir, 0755)
 	require.NoError(t, err, "Should create splash directory")
-
-	// Create splash file with the correct name
-	var splashFile string
-	switch launcher.platform {
-	case "android", "unknown":
-		splashFile = filepath.Join(splashDir, "splash_xxxhdpi_portrait.png")
-	case "ios":
-		splashFile = filepath.Join(splashDir, "splash_iphone_portrait.png")
-	default:
-		splashFile = filepath.Join(splashDir, "splash_xxxhdpi_portrait.png")
-	}
-	err = os.WriteFile(splashFile, []byte("fake splash data"), 0644)
-	require.NoError(t, err, "Should create splash file")
+	writePNG(t, filepath.Join(splashDir, "splash_xxxhdpi_portrait.png"), 96)
 
 	err = launcher.ShowSplashScreen("")
 	assert.NoError(t, err, "Should show default splash screen successfully")
+	session := <-sessions
+	assert.Equal(t, [4]int16{912, 492, 96, 96}, session.window, "Should center the splash window")
+	assert.Equal(t, 96*96, session.pixels, "Should draw the splash screen")
 }
 
 // TestShowSplashScreen_CustomPath tests showing custom splash screen
 func TestShowSplashScreen_CustomPath(t *testing.T) {
 	tempDir := t.TempDir()
 	launcher := NewLauncher(tempDir)
+	launcher.platform = "linux"
+	require.NoError(t, launcher.SetSplashOptions(testSplashOptions))
+	display, sessions := fakeXServer(t)
+	t.Setenv("DISPLAY", display)
 
 	// Create custom splash file
-	customSplash := filepath.Join(tempDir, "custom_splash.png")
-	err := os.WriteFile(customSplash, []byte("fake custom splash data"), 0644)
-	require.NoError(t, err, "Should create custom splash file")
-
-	err = launcher.ShowSplashScreen(customSplash)
+	customSplash := writePNG(t, filepath.Join(tempDir, "custom_splash.png"), 48)
+	err := launcher.ShowSplashScreen(customSplash)
 	assert.NoError(t, err, "Should show custom splash screen successfully")
+	assert.Equal(t, 48*48, (<-sessions).pixels, "Should draw the custom splash screen")
+
+	// A file that isn't an image can't be shown
+	notImage := filepath.Join(tempDir, "fake_splash.png")
+	require.NoError(t, os.WriteFile(notImage, []byte("fake custom splash data"), 0644))
+	err = launcher.ShowSplashScreen(notImage)
+	assert.ErrorContains(t, err, "is not a PNG or JPEG image")
 }
 
 // TestShowSplashScreen_NonExistentFile tests showing non-existent splash
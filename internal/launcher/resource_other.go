@@ -0,0 +1,10 @@
+//go:build !windows
+
+package launcher
+
+import "fmt"
+
+// embedIcon needs the resource update API of Windows
+func embedIcon(exe string, entries []icoEntry) error {
+	return fmt.Errorf("embedding an icon in %s needs Windows: the resource update API is not available on this system", exe)
+}
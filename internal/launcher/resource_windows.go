@@ -0,0 +1,55 @@
+package launcher
+
+import (
+	"fmt"
+	"syscall"
+	"unsafe"
+)
+
+var (
+	kernel32            = syscall.NewLazyDLL("kernel32.dll")
+	beginUpdateResource = kernel32.NewProc("BeginUpdateResourceW")
+	updateResource      = kernel32.NewProc("UpdateResourceW")
+	endUpdateResource   = kernel32.NewProc("EndUpdateResourceW")
+)
+
+// Resource types and the language of the resources written
+const (
+	rtIcon      = 3
+	rtGroupIcon = 14
+	langNeutral = 0
+)
+
+// embedIcon replaces the first icon group of an executable through the
+// UpdateResource API, writing its images as icons 1 onwards
+func embedIcon(exe string, entries []icoEntry) error {
+	path, err := syscall.UTF16PtrFromString(exe)
+	if err != nil {
+		return err
+	}
+	handle, _, callErr := beginUpdateResource.Call(uintptr(unsafe.Pointer(path)), 0)
+	if handle == 0 {
+		return fmt.Errorf("BeginUpdateResource %s: %v", exe, callErr)
+	}
+	update := func(kind, id uintptr, data []byte) error {
+		if ok, _, callErr := updateResource.Call(handle, kind, id, langNeutral, uintptr(unsafe.Pointer(&data[0])), uintptr(len(data))); ok == 0 {
+			return fmt.Errorf("UpdateResource %s: %v", exe, callErr)
+		}
+		return nil
+	}
+
+	for i, e := range entries {
+		if err := update(rtIcon, uintptr(1+i), e.data); err != nil {
+			endUpdateResource.Call(handle, 1) // discard
+			return err
+		}
+	}
+	if err := update(rtGroupIcon, 1, groupIcon(entries, 1)); err != nil {
+		endUpdateResource.Call(handle, 1)
+		return err
+	}
+	if ok, _, callErr := endUpdateResource.Call(handle, 0); ok == 0 {
+		return fmt.Errorf("EndUpdateResource %s: %v", exe, callErr)
+	}
+	return nil
+}
@@ -0,0 +1,89 @@
+package launcher
+
+import (
+	"fmt"
+	"image"
+	"time"
+
+	xdraw "golang.org/x/image/draw"
+)
+
+// SplashOptions control how long the splash screen window is shown
+type SplashOptions struct {
+	FadeIn  time.Duration // from transparent to opaque after opening
+	FadeOut time.Duration // back to transparent before closing
+	Timeout time.Duration // from opening to closing, fades included
+}
+
+// DefaultSplashOptions fade the splash screen in and out over 300ms and
+// close it after 3s
+var DefaultSplashOptions = SplashOptions{FadeIn: 300 * time.Millisecond, FadeOut: 300 * time.Millisecond, Timeout: 3 * time.Second}
+
+// splashFrame is the time between opacity updates during a fade
+const splashFrame = 16 * time.Millisecond
+
+// splashScreenShare is the largest part of the screen a splash screen
+// covers; larger images are scaled down to fit
+const splashScreenShare = 0.8
+
+// SetSplashOptions sets the fades and timeout of ShowSplashScreen
+func (l *Launcher) SetSplashOptions(options SplashOptions) error {
+	if options.Timeout <= 0 || options.FadeIn < 0 || options.FadeOut < 0 {
+		return fmt.Errorf("splash timeout must be positive and fades not negative")
+	}
+	if options.FadeIn+options.FadeOut > options.Timeout {
+		return fmt.Errorf("splash fades of %s and %s don't fit in its %s timeout", options.FadeIn, options.FadeOut, options.Timeout)
+	}
+	l.splash = options
+	return nil
+}
+
+// splashOptions are the options set, or the defaults
+func (l *Launcher) splashOptions() SplashOptions {
+	if l.splash.Timeout <= 0 {
+		return DefaultSplashOptions
+	}
+	return l.splash
+}
+
+// splashOpacity is the opacity of the splash screen window elapsed after
+// it opened, fading in linearly from 0 and out linearly to 0 at the timeout
+func splashOpacity(elapsed time.Duration, options SplashOptions) float64 {
+	if elapsed < 0 || elapsed >= options.Timeout {
+		return 0
+	}
+	if elapsed < options.FadeIn {
+		return float64(elapsed) / float64(options.FadeIn)
+	}
+	if remaining := options.Timeout - elapsed; remaining < options.FadeOut {
+		return float64(remaining) / float64(options.FadeOut)
+	}
+	return 1
+}
+
+// runSplash calls setOpacity every frame until the timeout
+func runSplash(options SplashOptions, setOpacity func(float64) error) error {
+	start := time.Now()
+	for {
+		elapsed := time.Since(start)
+		if elapsed >= options.Timeout {
+			return nil
+		}
+		if err := setOpacity(splashOpacity(elapsed, options)); err != nil {
+			return err
+		}
+		time.Sleep(splashFrame)
+	}
+}
+
+// fitSplash scales a splash screen down to cover at most splashScreenShare
+// of a screen, keeping its aspect ratio; smaller images keep their size
+func fitSplash(img image.Image, screenWidth, screenHeight int) *image.RGBA {
+	bounds := img.Bounds()
+	scale := min(1, splashScreenShare*float64(screenWidth)/float64(bounds.Dx()), splashScreenShare*float64(screenHeight)/float64(bounds.Dy()))
+	width := max(1, int(float64(bounds.Dx())*scale+0.5))
+	height := max(1, int(float64(bounds.Dy())*scale+0.5))
+	fitted := image.NewRGBA(image.Rect(0, 0, width, height))
+	xdraw.CatmullRom.Scale(fitted, fitted.Bounds(), img, bounds, xdraw.Src, nil)
+	return fitted
+}
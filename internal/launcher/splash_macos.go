@@ -0,0 +1,65 @@
+package launcher
+
+import (
+	"bytes"
+	"fmt"
+	"os/exec"
+	"strconv"
+	"strings"
+)
+
+// splashScript is a JavaScript for Automation script opening a borderless
+// Cocoa window with the splash image and animating its alpha value. Its
+// arguments are the image path, the fade-in, fade-out and timeout in
+// seconds, and the share of the screen the image may cover.
+const splashScript = `ObjC.import('Cocoa');
+function run(argv) {
+  var fadeIn = +argv[1], fadeOut = +argv[2], timeout = +argv[3], share = +argv[4];
+  var app = $.NSApplication.sharedApplication;
+  app.setActivationPolicy($.NSApplicationActivationPolicyAccessory);
+  var image = $.NSImage.alloc.initWithContentsOfFile(argv[0]);
+  if (image.isNil()) throw new Error('cannot read splash image ' + argv[0]);
+  var screen = $.NSScreen.mainScreen.visibleFrame;
+  var scale = Math.min(1, share * screen.size.width / image.size.width, share * screen.size.height / image.size.height);
+  var rect = $.NSMakeRect(0, 0, Math.round(image.size.width * scale), Math.round(image.size.height * scale));
+  var win = $.NSWindow.alloc.initWithContentRectStyleMaskBackingDefer(rect, $.NSWindowStyleMaskBorderless, $.NSBackingStoreBuffered, false);
+  win.level = $.NSFloatingWindowLevel;
+  win.opaque = false;
+  win.backgroundColor = $.NSColor.clearColor;
+  win.alphaValue = 0;
+  var view = $.NSImageView.alloc.initWithFrame(rect);
+  view.imageScaling = $.NSImageScaleProportionallyUpOrDown;
+  view.image = image;
+  win.contentView = view;
+  win.center;
+  win.orderFrontRegardless;
+  var start = $.NSDate.date;
+  for (;;) {
+    var elapsed = -start.timeIntervalSinceNow;
+    if (elapsed >= timeout) break;
+    var alpha = 1;
+    if (elapsed < fadeIn) alpha = elapsed / fadeIn;
+    else if (timeout - elapsed < fadeOut) alpha = (timeout - elapsed) / fadeOut;
+    win.alphaValue = alpha;
+    $.NSRunLoop.currentRunLoop.runUntilDate($.NSDate.dateWithTimeIntervalSinceNow(0.016));
+  }
+  win.orderOut($());
+}`
+
+// showSplashMacOS shows the splash screen in a Cocoa window driven by
+// osascript, which every macOS ships
+func showSplashMacOS(splashPath string, options SplashOptions) error {
+	seconds := func(v float64) string { return strconv.FormatFloat(v, 'f', 3, 64) }
+	cmd := exec.Command("osascript", "-l", "JavaScript", "-e", splashScript, splashPath,
+		seconds(options.FadeIn.Seconds()), seconds(options.FadeOut.Seconds()), seconds(options.Timeout.Seconds()),
+		seconds(splashScreenShare))
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return fmt.Errorf("failed to show splash screen: %w: %s", err, msg)
+		}
+		return fmt.Errorf("failed to show splash screen: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,13 @@
+//go:build !windows
+
+package launcher
+
+import (
+	"fmt"
+	"image"
+)
+
+// showSplashWindows needs the window APIs of Windows
+func showSplashWindows(image.Image, SplashOptions) error {
+	return fmt.Errorf("showing a Windows splash screen needs Windows: the window APIs are not available on this system")
+}
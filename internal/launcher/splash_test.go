@@ -0,0 +1,297 @@
+package launcher
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image"
+	"io"
+	"net"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// xSession is what a fake X server saw a client do
+type xSession struct {
+	auth        string
+	atoms       []string
+	overrideRed bool // override-redirect set on the created window
+	window      [4]int16
+	pixels      int // pixels drawn by PutImage
+	firstPixel  uint32
+	opacities   []uint32
+	windowType  uint32
+	destroyed   bool
+}
+
+// fakeXServer serves one X11 client on a Unix socket with a 1920x1080
+// TrueColor screen. Requests are at most 256KiB, so splash screens are
+// drawn in strips. It returns the DISPLAY to set, and the session once the
+// client disconnects.
+func fakeXServer(t *testing.T) (string, <-chan xSession) {
+	t.Helper()
+	dir := t.TempDir()
+	listener, err := net.Listen("unix", filepath.Join(dir, "X:7"))
+	require.NoError(t, err)
+	t.Cleanup(func() { listener.Close() })
+	sessions := make(chan xSession, 1)
+
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		var s xSession
+		defer func() { sessions <- s }()
+
+		setup := make([]byte, 12)
+		if _, err := io.ReadFull(conn, setup); err != nil {
+			return
+		}
+		nameLen, dataLen := int(binary.LittleEndian.Uint16(setup[6:])), int(binary.LittleEndian.Uint16(setup[8:]))
+		auth := make([]byte, nameLen+pad4(nameLen)+dataLen+pad4(dataLen))
+		io.ReadFull(conn, auth)
+		s.auth = string(auth[:nameLen])
+
+		var body bytes.Buffer
+		binary.Write(&body, binary.LittleEndian, struct {
+			Release, IDBase, IDMask, Motion   uint32
+			VendorLen, MaxRequest             uint16
+			Screens, Formats                  uint8
+			ImageOrder, BitmapOrder           uint8
+			ScanUnit, ScanPad, MinKey, MaxKey uint8
+			Pad                               uint32
+		}{0, 0x00400000, 0x001fffff, 0, 4, 65535, 1, 1, 0, 0, 32, 32, 8, 255, 0})
+		body.WriteString("Fake")
+		body.Write([]byte{24, 32, 32, 0, 0, 0, 0, 0})
+		binary.Write(&body, binary.LittleEndian, struct {
+			Root, Colormap, White, Black, Masks uint32
+			Width, Height, WidthMM, HeightMM    uint16
+			MinMaps, MaxMaps                    uint16
+			Visual                              uint32
+			Backing, SaveUnders, Depth, Depths  uint8
+		}{0x100, 0x20, 0xffffff, 0, 0, 1920, 1080, 500, 280, 1, 1, 0x21, 0, 0, 24, 1})
+		binary.Write(&body, binary.LittleEndian, struct {
+			Depth, Pad uint8
+			Visuals    uint16
+			Pad2       uint32
+		}{24, 0, 1, 0})
+		binary.Write(&body, binary.LittleEndian, struct {
+			ID                uint32
+			Class, BitsPerRGB uint8
+			Entries           uint16
+			Red, Green, Blue  uint32
+			Pad               uint32
+		}{0x21, 4, 8, 256, 0xff0000, 0xff00, 0xff, 0})
+		header := []byte{1, 0, 11, 0, 0, 0, 0, 0}
+		binary.LittleEndian.PutUint16(header[6:], uint16(body.Len()/4))
+		conn.Write(append(header, body.Bytes()...))
+
+		atoms := map[uint32]string{4: "ATOM", 6: "CARDINAL"}
+		reply := func(data uint32) {
+			packet := make([]byte, 32)
+			packet[0] = 1
+			binary.LittleEndian.PutUint32(packet[8:], data)
+			conn.Write(packet)
+		}
+		for {
+			head := make([]byte, 4)
+			if _, err := io.ReadFull(conn, head); err != nil {
+				return
+			}
+			req := make([]byte, int(binary.LittleEndian.Uint16(head[2:]))*4-4)
+			if _, err := io.ReadFull(conn, req); err != nil {
+				return
+			}
+			switch head[0] {
+			case x11InternAtom:
+				name := string(req[4 : 4+binary.LittleEndian.Uint16(req)])
+				s.atoms = append(s.atoms, name)
+				atom := uint32(100 + len(s.atoms))
+				atoms[atom] = name
+				reply(atom)
+			case x11GetInputFocus:
+				reply(0)
+			case x11GetSelectionOwner:
+				// A system tray owns every selection
+				reply(0x600)
+			case x11CreateWindow:
+				for i := range s.window {
+					s.window[i] = int16(binary.LittleEndian.Uint16(req[8+2*i:]))
+				}
+				s.overrideRed = binary.LittleEndian.Uint32(req[24:])&0x200 != 0 && binary.LittleEndian.Uint32(req[32:]) == 1
+			case x11MapWindow:
+				expose := make([]byte, 32)
+				expose[0] = x11Expose
+				conn.Write(expose)
+			case x11ChangeProperty:
+				value := binary.LittleEndian.Uint32(req[20:])
+				switch atoms[binary.LittleEndian.Uint32(req[4:])] {
+				case "_NET_WM_WINDOW_OPACITY":
+					s.opacities = append(s.opacities, value)
+				case "_NET_WM_WINDOW_TYPE":
+					s.windowType = value
+				}
+			case x11PutImage:
+				width, height := int(binary.LittleEndian.Uint16(req[8:])), int(binary.LittleEndian.Uint16(req[10:]))
+				if s.pixels == 0 {
+					s.firstPixel = binary.LittleEndian.Uint32(req[20:])
+				}
+				s.pixels += width * height
+			case x11DestroyWindow:
+				s.destroyed = true
+			}
+		}
+	}()
+	return filepath.Join(dir, "X") + ":7.0", sessions
+}
+
+// splashImage is a 3000x1000 red splash screen, scaled to 1536x512 on the
+// fake screen
+func splashImage() image.Image {
+	img := image.NewNRGBA(image.Rect(0, 0, 3000, 1000))
+	for i := 0; i < len(img.Pix); i += 4 {
+		copy(img.Pix[i:], []byte{255, 0, 0, 255})
+	}
+	return img
+}
+
+// TestSplashOpacity tests the fade curve
+func TestSplashOpacity(t *testing.T) {
+	options := SplashOptions{FadeIn: 100 * time.Millisecond, FadeOut: 200 * time.Millisecond, Timeout: time.Second}
+	assert.Equal(t, 0.0, splashOpacity(0, options))
+	assert.InDelta(t, 0.5, splashOpacity(50*time.Millisecond, options), 1e-9)
+	assert.Equal(t, 1.0, splashOpacity(500*time.Millisecond, options))
+	assert.InDelta(t, 0.25, splashOpacity(950*time.Millisecond, options), 1e-9)
+	assert.Equal(t, 0.0, splashOpacity(time.Second, options))
+	assert.Equal(t, 1.0, splashOpacity(0, SplashOptions{Timeout: time.Second}), "no fade")
+}
+
+// TestSetSplashOptions tests option validation and the defaults
+func TestSetSplashOptions(t *testing.T) {
+	l := NewLauncher(t.TempDir())
+	assert.Equal(t, DefaultSplashOptions, l.splashOptions())
+	assert.EqualError(t, l.SetSplashOptions(SplashOptions{FadeIn: time.Second, FadeOut: time.Second, Timeout: time.Second}),
+		"splash fades of 1s and 1s don't fit in its 1s timeout")
+	assert.Error(t, l.SetSplashOptions(SplashOptions{}))
+	options := SplashOptions{FadeIn: time.Millisecond, Timeout: time.Second}
+	require.NoError(t, l.SetSplashOptions(options))
+	assert.Equal(t, options, l.splashOptions())
+}
+
+// TestFitSplash tests that splash screens are scaled down to fit the
+// screen and never up
+func TestFitSplash(t *testing.T) {
+	assert.Equal(t, image.Rect(0, 0, 1536, 512), fitSplash(splashImage(), 1920, 1080).Bounds())
+	assert.Equal(t, image.Rect(0, 0, 640, 213), fitSplash(splashImage(), 800, 600).Bounds())
+	small := image.NewNRGBA(image.Rect(0, 0, 200, 100))
+	assert.Equal(t, image.Rect(0, 0, 200, 100), fitSplash(small, 1920, 1080).Bounds())
+}
+
+// TestParseDisplay tests DISPLAY values
+func TestParseDisplay(t *testing.T) {
+	for display, want := range map[string][3]string{
+		":0":                {"unix", "/tmp/.X11-unix/X0", "0"},
+		"unix:1.0":          {"unix", "/tmp/.X11-unix/X1", "1"},
+		"localhost:10.0":    {"tcp", "localhost:6010", "10"},
+		"/private/tmp/xq:0": {"unix", "/private/tmp/xq:0", "0"},
+	} {
+		network, address, number, err := parseDisplay(display)
+		require.NoError(t, err, display)
+		assert.Equal(t, want, [3]string{network, address, number}, display)
+	}
+	_, _, _, err := parseDisplay("wayland-0")
+	assert.EqualError(t, err, `invalid DISPLAY "wayland-0": want [host]:display[.screen]`)
+}
+
+// TestParseXauthority tests picking the cookie of a display
+func TestParseXauthority(t *testing.T) {
+	entry := func(family uint16, address, number, name, cookie string) []byte {
+		var b bytes.Buffer
+		binary.Write(&b, binary.BigEndian, family)
+		for _, field := range []string{address, number, name, cookie} {
+			binary.Write(&b, binary.BigEndian, uint16(len(field)))
+			b.WriteString(field)
+		}
+		return b.Bytes()
+	}
+	data := bytes.Join([][]byte{
+		entry(256, "other", "0", "MIT-MAGIC-COOKIE-1", "other-host"),
+		entry(256, "box", "1", "MIT-MAGIC-COOKIE-1", "display-1"),
+		entry(256, "box", "0", "XDM-AUTHORIZATION-1", "xdm"),
+		entry(256, "box", "0", "MIT-MAGIC-COOKIE-1", "this-host"),
+	}, nil)
+
+	name, cookie := parseXauthority(data, "0", "box")
+	assert.Equal(t, "MIT-MAGIC-COOKIE-1", name)
+	assert.Equal(t, "this-host", string(cookie))
+	_, cookie = parseXauthority(data, "0", "elsewhere")
+	assert.Equal(t, "other-host", string(cookie))
+	name, cookie = parseXauthority(data, "2", "box")
+	assert.Empty(t, name)
+	assert.Nil(t, cookie)
+	_, cookie = parseXauthority(data[:10], "0", "box")
+	assert.Nil(t, cookie, "truncated")
+}
+
+// TestShowSplashX11 tests the splash window on a fake X server: an
+// override-redirect splash window centered on the screen, drawn in strips,
+// faded in and out, then destroyed
+func TestShowSplashX11(t *testing.T) {
+	display, sessions := fakeXServer(t)
+	t.Setenv("DISPLAY", display)
+	t.Setenv("XAUTHORITY", filepath.Join(t.TempDir(), "missing"))
+
+	options := SplashOptions{FadeIn: 50 * time.Millisecond, FadeOut: 50 * time.Millisecond, Timeout: 200 * time.Millisecond}
+	start := time.Now()
+	require.NoError(t, showSplashX11(splashImage(), options))
+	assert.GreaterOrEqual(t, time.Since(start), options.Timeout)
+
+	s := <-sessions
+	assert.Empty(t, s.auth)
+	assert.Equal(t, []string{"_NET_WM_WINDOW_OPACITY", "_NET_WM_WINDOW_TYPE", "_NET_WM_WINDOW_TYPE_SPLASH"}, s.atoms)
+	assert.True(t, s.overrideRed)
+	assert.Equal(t, [4]int16{192, 284, 1536, 512}, s.window)
+	assert.Equal(t, uint32(103), s.windowType)
+	assert.Equal(t, 1536*512, s.pixels)
+	assert.Equal(t, uint32(0xff0000), s.firstPixel)
+	assert.True(t, s.destroyed)
+
+	require.Greater(t, len(s.opacities), 3)
+	assert.Equal(t, uint32(0), s.opacities[0], "mapped transparent")
+	peak := uint32(0)
+	for _, o := range s.opacities {
+		peak = max(peak, o)
+	}
+	assert.Equal(t, uint32(0xffffffff), peak)
+	assert.Less(t, s.opacities[len(s.opacities)-1], peak, "faded out")
+}
+
+// TestShowSplashX11_Errors tests reporting a missing display and a refused
+// connection
+func TestShowSplashX11_Errors(t *testing.T) {
+	t.Setenv("DISPLAY", "")
+	assert.EqualError(t, showSplashX11(splashImage(), DefaultSplashOptions), "no X display: DISPLAY is not set")
+
+	server, client := net.Pipe()
+	go func() {
+		io.ReadFull(server, make([]byte, 12))
+		reason := "No protocol specified"
+		header := []byte{0, byte(len(reason)), 11, 0, 0, 0, 6, 0}
+		server.Write(append(header, []byte(reason+"\x00\x00\x00")...))
+	}()
+	_, err := x11Handshake(client, "", nil)
+	assert.EqualError(t, err, "X server refused the connection: No protocol specified")
+}
+
+// TestShowSplashScreen_Unsupported tests platforms without splash windows
+func TestShowSplashScreen_Unsupported(t *testing.T) {
+	l := NewLauncher(t.TempDir())
+	l.platform = "android"
+	path := writePNG(t, filepath.Join(t.TempDir(), "splash.png"), 64)
+	assert.EqualError(t, l.ShowSplashScreen(path), "splash screen display not supported on platform: android")
+}
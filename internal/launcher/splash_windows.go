@@ -0,0 +1,194 @@
+package launcher
+
+import (
+	"fmt"
+	"image"
+	"runtime"
+	"sync"
+	"syscall"
+	"unsafe"
+)
+
+var (
+	user32              = syscall.NewLazyDLL("user32.dll")
+	gdi32               = syscall.NewLazyDLL("gdi32.dll")
+	getModuleHandle     = kernel32.NewProc("GetModuleHandleW")
+	registerClassEx     = user32.NewProc("RegisterClassExW")
+	createWindowEx      = user32.NewProc("CreateWindowExW")
+	defWindowProc       = user32.NewProc("DefWindowProcW")
+	destroyWindow       = user32.NewProc("DestroyWindow")
+	showWindow          = user32.NewProc("ShowWindow")
+	updateLayeredWindow = user32.NewProc("UpdateLayeredWindow")
+	peekMessage         = user32.NewProc("PeekMessageW")
+	translateMessage    = user32.NewProc("TranslateMessage")
+	dispatchMessage     = user32.NewProc("DispatchMessageW")
+	getSystemMetrics    = user32.NewProc("GetSystemMetrics")
+	getDC               = user32.NewProc("GetDC")
+	releaseDC           = user32.NewProc("ReleaseDC")
+	createCompatibleDC  = gdi32.NewProc("CreateCompatibleDC")
+	createDIBSection    = gdi32.NewProc("CreateDIBSection")
+	selectObject        = gdi32.NewProc("SelectObject")
+	deleteObject        = gdi32.NewProc("DeleteObject")
+	deleteDC            = gdi32.NewProc("DeleteDC")
+)
+
+// Window styles, flags and metrics of the layered splash window
+const (
+	wsExLayered      = 0x00080000
+	wsExTopmost      = 0x00000008
+	wsExToolWindow   = 0x00000080
+	wsExNoActivate   = 0x08000000
+	wsPopup          = 0x80000000
+	swShowNoActivate = 4
+	ulwAlpha         = 2
+	acSrcAlpha       = 1
+	pmRemove         = 1
+	smCxScreen       = 0
+	smCyScreen       = 1
+)
+
+type wndClassEx struct {
+	Size       uint32
+	Style      uint32
+	WndProc    uintptr
+	ClsExtra   int32
+	WndExtra   int32
+	Instance   uintptr
+	Icon       uintptr
+	Cursor     uintptr
+	Background uintptr
+	MenuName   *uint16
+	ClassName  *uint16
+	IconSm     uintptr
+}
+
+type bitmapInfoHeader struct {
+	Size          uint32
+	Width         int32
+	Height        int32
+	Planes        uint16
+	BitCount      uint16
+	Compression   uint32
+	SizeImage     uint32
+	XPelsPerMeter int32
+	YPelsPerMeter int32
+	ClrUsed       uint32
+	ClrImportant  uint32
+}
+
+type winPoint struct{ X, Y int32 }
+
+type winSize struct{ CX, CY int32 }
+
+type blendFunction struct {
+	BlendOp, BlendFlags, SourceConstantAlpha, AlphaFormat byte
+}
+
+type winMsg struct {
+	Hwnd    uintptr
+	Message uint32
+	WParam  uintptr
+	LParam  uintptr
+	Time    uint32
+	Pt      winPoint
+	Private uint32
+}
+
+var (
+	splashClass     = syscall.StringToUTF16Ptr("PanopticSplash")
+	splashClassOnce sync.Once
+	splashClassErr  error
+)
+
+// registerSplashClass registers the window class of splash windows once
+func registerSplashClass(instance uintptr) error {
+	splashClassOnce.Do(func() {
+		class := wndClassEx{
+			WndProc: syscall.NewCallback(func(hwnd, msg, wParam, lParam uintptr) uintptr {
+				r, _, _ := defWindowProc.Call(hwnd, msg, wParam, lParam)
+				return r
+			}),
+			Instance:  instance,
+			ClassName: splashClass,
+		}
+		class.Size = uint32(unsafe.Sizeof(class))
+		if atom, _, err := registerClassEx.Call(uintptr(unsafe.Pointer(&class))); atom == 0 {
+			splashClassErr = fmt.Errorf("RegisterClassEx: %v", err)
+		}
+	})
+	return splashClassErr
+}
+
+// showSplashWindows shows the splash screen in a layered popup window,
+// keeping the image's transparency and fading it through the window's
+// constant alpha
+func showSplashWindows(img image.Image, options SplashOptions) error {
+	// Windows belong to the thread that created them
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
+	screenWidth, _, _ := getSystemMetrics.Call(smCxScreen)
+	screenHeight, _, _ := getSystemMetrics.Call(smCyScreen)
+	fitted := fitSplash(img, int(screenWidth), int(screenHeight))
+	width, height := fitted.Bounds().Dx(), fitted.Bounds().Dy()
+
+	instance, _, _ := getModuleHandle.Call(0)
+	if err := registerSplashClass(instance); err != nil {
+		return err
+	}
+	left, top := (int(screenWidth)-width)/2, (int(screenHeight)-height)/2
+	hwnd, _, err := createWindowEx.Call(wsExLayered|wsExTopmost|wsExToolWindow|wsExNoActivate,
+		uintptr(unsafe.Pointer(splashClass)), 0, wsPopup,
+		uintptr(left), uintptr(top), uintptr(width), uintptr(height), 0, 0, instance, 0)
+	if hwnd == 0 {
+		return fmt.Errorf("CreateWindowEx: %v", err)
+	}
+	defer destroyWindow.Call(hwnd)
+
+	// A top-down 32-bit DIB of premultiplied BGRA pixels
+	screenDC, _, _ := getDC.Call(0)
+	defer releaseDC.Call(0, screenDC)
+	memDC, _, _ := createCompatibleDC.Call(screenDC)
+	if memDC == 0 {
+		return fmt.Errorf("CreateCompatibleDC failed")
+	}
+	defer deleteDC.Call(memDC)
+	header := bitmapInfoHeader{Width: int32(width), Height: -int32(height), Planes: 1, BitCount: 32}
+	header.Size = uint32(unsafe.Sizeof(header))
+	var bits unsafe.Pointer
+	bitmap, _, err := createDIBSection.Call(screenDC, uintptr(unsafe.Pointer(&header)), 0, uintptr(unsafe.Pointer(&bits)), 0, 0)
+	if bitmap == 0 {
+		return fmt.Errorf("CreateDIBSection: %v", err)
+	}
+	defer deleteObject.Call(bitmap)
+	pixels := unsafe.Slice((*byte)(bits), width*height*4)
+	for i := 0; i < len(pixels); i += 4 {
+		pixels[i], pixels[i+1], pixels[i+2], pixels[i+3] = fitted.Pix[i+2], fitted.Pix[i+1], fitted.Pix[i], fitted.Pix[i+3]
+	}
+	previous, _, _ := selectObject.Call(memDC, bitmap)
+	defer selectObject.Call(memDC, previous)
+
+	position := winPoint{X: int32(left), Y: int32(top)}
+	size := winSize{CX: int32(width), CY: int32(height)}
+	var origin winPoint
+	update := func(opacity float64) error {
+		blend := blendFunction{SourceConstantAlpha: byte(opacity * 255), AlphaFormat: acSrcAlpha}
+		if ok, _, err := updateLayeredWindow.Call(hwnd, screenDC, uintptr(unsafe.Pointer(&position)), uintptr(unsafe.Pointer(&size)),
+			memDC, uintptr(unsafe.Pointer(&origin)), 0, uintptr(unsafe.Pointer(&blend)), ulwAlpha); ok == 0 {
+			return fmt.Errorf("UpdateLayeredWindow: %v", err)
+		}
+		var msg winMsg
+		for {
+			if got, _, _ := peekMessage.Call(uintptr(unsafe.Pointer(&msg)), 0, 0, 0, pmRemove); got == 0 {
+				return nil
+			}
+			translateMessage.Call(uintptr(unsafe.Pointer(&msg)))
+			dispatchMessage.Call(uintptr(unsafe.Pointer(&msg)))
+		}
+	}
+	if err := update(0); err != nil {
+		return err
+	}
+	showWindow.Call(hwnd, swShowNoActivate)
+	return runSplash(options, update)
+}
@@ -0,0 +1,472 @@
+package launcher
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"image"
+	"io"
+	"math/bits"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// X11 request opcodes, event codes and predefined atoms used by the splash
+// screen
+const (
+	x11CreateWindow      = 1
+	x11DestroyWindow     = 4
+	x11MapWindow         = 8
+	x11InternAtom        = 16
+	x11ChangeProperty    = 18
+	x11GetSelectionOwner = 23
+	x11GetInputFocus     = 43
+	x11CreateGC          = 55
+	x11PutImage          = 72
+
+	x11Expose = 12
+
+	x11AtomAtom     = 4
+	x11AtomCardinal = 6
+)
+
+// x11Conn is a minimal X11 protocol client: enough to open a borderless
+// window, draw an image into it and set its opacity, without linking Xlib
+type x11Conn struct {
+	conn       io.ReadWriteCloser
+	idBase     uint32
+	idMask     uint32
+	nextID     uint32
+	maxRequest int // bytes
+	root       uint32
+	depth      byte
+	width      int
+	height     int
+	red        uint32 // pixel masks of the root visual
+	green      uint32
+	blue       uint32
+	msbFirst   bool // image byte order
+	exposed    bool // an Expose event arrived since the last redraw
+}
+
+// showSplashX11 shows the splash screen on the X display named by DISPLAY
+func showSplashX11(img image.Image, options SplashOptions) error {
+	x, err := openX11()
+	if err != nil {
+		return err
+	}
+	defer x.conn.Close()
+	return x.splash(img, options)
+}
+
+// openX11 connects to the X display named by DISPLAY
+func openX11() (*x11Conn, error) {
+	display := os.Getenv("DISPLAY")
+	if display == "" {
+		return nil, fmt.Errorf("no X display: DISPLAY is not set")
+	}
+	network, address, number, err := parseDisplay(display)
+	if err != nil {
+		return nil, err
+	}
+	conn, err := net.DialTimeout(network, address, 5*time.Second)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to X display %s: %w", display, err)
+	}
+	name, data := xauthCookie(number)
+	x, err := x11Handshake(conn, name, data)
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("X display %s: %w", display, err)
+	}
+	return x, nil
+}
+
+// parseDisplay splits a DISPLAY value into the address of its server and
+// its display number. An empty host or "unix" is the local socket, and a
+// host that is a path, as XQuartz sets, is the socket itself.
+func parseDisplay(display string) (network, address, number string, err error) {
+	colon := strings.LastIndex(display, ":")
+	if colon < 0 {
+		return "", "", "", fmt.Errorf("invalid DISPLAY %q: want [host]:display[.screen]", display)
+	}
+	host := display[:colon]
+	number, _, _ = strings.Cut(display[colon+1:], ".")
+	if _, err := strconv.Atoi(number); err != nil {
+		return "", "", "", fmt.Errorf("invalid DISPLAY %q: want [host]:display[.screen]", display)
+	}
+	switch {
+	case strings.HasPrefix(host, "/"):
+		return "unix", host + ":" + number, number, nil
+	case host == "" || host == "unix":
+		return "unix", "/tmp/.X11-unix/X" + number, number, nil
+	}
+	n, _ := strconv.Atoi(number)
+	return "tcp", net.JoinHostPort(host, strconv.Itoa(6000+n)), number, nil
+}
+
+// xauthCookie is the MIT-MAGIC-COOKIE-1 of a display in the Xauthority
+// file, preferring this host's entry; servers without access control
+// accept a connection without one
+func xauthCookie(number string) (string, []byte) {
+	path := os.Getenv("XAUTHORITY")
+	if path == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", nil
+		}
+		path = filepath.Join(home, ".Xauthority")
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", nil
+	}
+	hostname, _ := os.Hostname()
+	name, cookie := parseXauthority(data, number, hostname)
+	return name, cookie
+}
+
+// parseXauthority finds the cookie of a display in Xauthority entries
+func parseXauthority(data []byte, number, hostname string) (string, []byte) {
+	const cookieName = "MIT-MAGIC-COOKIE-1"
+	field := func() ([]byte, bool) {
+		if len(data) < 2 {
+			return nil, false
+		}
+		n := int(binary.BigEndian.Uint16(data))
+		if len(data) < 2+n {
+			return nil, false
+		}
+		value := data[2 : 2+n]
+		data = data[2+n:]
+		return value, true
+	}
+	var fallback []byte
+	for len(data) >= 2 {
+		family := binary.BigEndian.Uint16(data)
+		data = data[2:]
+		address, ok1 := field()
+		display, ok2 := field()
+		name, ok3 := field()
+		cookie, ok4 := field()
+		if !ok1 || !ok2 || !ok3 || !ok4 {
+			break
+		}
+		if string(name) != cookieName || (len(display) > 0 && string(display) != number) {
+			continue
+		}
+		// FamilyLocal entries name the host; FamilyWild ones match any
+		if family == 0xffff || (family == 256 && string(address) == hostname) {
+			return cookieName, cookie
+		}
+		if fallback == nil {
+			fallback = cookie
+		}
+	}
+	if fallback != nil {
+		return cookieName, fallback
+	}
+	return "", nil
+}
+
+// pad4 is the padding after n bytes to a multiple of four
+func pad4(n int) int {
+	return (4 - n%4) % 4
+}
+
+// x11Handshake sets up a connection and reads the first screen's root
+// window and TrueColor visual
+func x11Handshake(conn io.ReadWriteCloser, authName string, authData []byte) (*x11Conn, error) {
+	var req bytes.Buffer
+	req.WriteString("l\x00")
+	binary.Write(&req, binary.LittleEndian, [4]uint16{11, 0, uint16(len(authName)), uint16(len(authData))})
+	req.Write([]byte{0, 0})
+	req.WriteString(authName)
+	req.Write(make([]byte, pad4(len(authName))))
+	req.Write(authData)
+	req.Write(make([]byte, pad4(len(authData))))
+	if _, err := conn.Write(req.Bytes()); err != nil {
+		return nil, err
+	}
+
+	header := make([]byte, 8)
+	if _, err := io.ReadFull(conn, header); err != nil {
+		return nil, fmt.Errorf("failed to read the connection setup: %w", err)
+	}
+	body := make([]byte, int(binary.LittleEndian.Uint16(header[6:]))*4)
+	if _, err := io.ReadFull(conn, body); err != nil {
+		return nil, fmt.Errorf("failed to read the connection setup: %w", err)
+	}
+	switch header[0] {
+	case 0:
+		reason := body[:min(int(header[1]), len(body))]
+		return nil, fmt.Errorf("X server refused the connection: %s", strings.TrimSpace(string(reason)))
+	case 2:
+		return nil, fmt.Errorf("X server wants further authentication: %s", strings.TrimSpace(string(bytes.TrimRight(body, "\x00"))))
+	}
+
+	truncated := fmt.Errorf("X server sent a truncated connection setup")
+	if len(body) < 32 {
+		return nil, truncated
+	}
+	x := &x11Conn{
+		conn:       conn,
+		idBase:     binary.LittleEndian.Uint32(body[4:]),
+		idMask:     binary.LittleEndian.Uint32(body[8:]),
+		maxRequest: int(binary.LittleEndian.Uint16(body[18:])) * 4,
+		msbFirst:   body[22] == 1,
+	}
+	vendor := int(binary.LittleEndian.Uint16(body[16:]))
+	screens, formats := body[20], int(body[21])
+	offset := 32 + vendor + pad4(vendor)
+	if screens == 0 || len(body) < offset+8*formats+40 {
+		return nil, truncated
+	}
+	bitsPerPixel := map[byte]byte{}
+	for i := 0; i < formats; i++ {
+		f := body[offset+8*i:]
+		bitsPerPixel[f[0]] = f[1]
+	}
+	screen := body[offset+8*formats:]
+	x.root = binary.LittleEndian.Uint32(screen[0:])
+	x.width = int(binary.LittleEndian.Uint16(screen[20:]))
+	x.height = int(binary.LittleEndian.Uint16(screen[22:]))
+	rootVisual := binary.LittleEndian.Uint32(screen[32:])
+	x.depth = screen[38]
+	if bpp := bitsPerPixel[x.depth]; bpp != 32 {
+		return nil, fmt.Errorf("X screens with %d bits per pixel are not supported", bpp)
+	}
+
+	depths := screen[40:]
+	for d := 0; d < int(screen[39]) && len(depths) >= 8; d++ {
+		visuals := int(binary.LittleEndian.Uint16(depths[2:]))
+		if len(depths) < 8+24*visuals {
+			return nil, truncated
+		}
+		for v := 0; v < visuals; v++ {
+			visual := depths[8+24*v:]
+			if binary.LittleEndian.Uint32(visual) != rootVisual {
+				continue
+			}
+			// TrueColor or DirectColor
+			if visual[4] != 4 && visual[4] != 5 {
+				return nil, fmt.Errorf("X screens without a TrueColor visual are not supported")
+			}
+			x.red = binary.LittleEndian.Uint32(visual[8:])
+			x.green = binary.LittleEndian.Uint32(visual[12:])
+			x.blue = binary.LittleEndian.Uint32(visual[16:])
+			return x, nil
+		}
+		depths = depths[8+24*visuals:]
+	}
+	return nil, fmt.Errorf("X server didn't describe the root visual")
+}
+
+// newID allocates a resource id
+func (x *x11Conn) newID() uint32 {
+	x.nextID++
+	return x.idBase | (x.nextID & x.idMask)
+}
+
+// send writes a request, padding it to a multiple of four bytes
+func (x *x11Conn) send(opcode, detail byte, fields ...any) error {
+	var body bytes.Buffer
+	for _, f := range fields {
+		binary.Write(&body, binary.LittleEndian, f)
+	}
+	body.Write(make([]byte, pad4(body.Len())))
+	var req bytes.Buffer
+	req.WriteByte(opcode)
+	req.WriteByte(detail)
+	binary.Write(&req, binary.LittleEndian, uint16(1+body.Len()/4))
+	req.Write(body.Bytes())
+	_, err := x.conn.Write(req.Bytes())
+	return err
+}
+
+// reply reads the reply to the last request, noting Expose events on the
+// way and failing on any error the server reported
+func (x *x11Conn) reply() ([]byte, error) {
+	for {
+		packet := make([]byte, 32)
+		if _, err := io.ReadFull(x.conn, packet); err != nil {
+			return nil, fmt.Errorf("X server closed the connection: %w", err)
+		}
+		switch code := packet[0] & 0x7f; code {
+		case 0:
+			return nil, fmt.Errorf("X server error %d in request %d", packet[1], packet[10])
+		case 1:
+			extra := make([]byte, int(binary.LittleEndian.Uint32(packet[4:]))*4)
+			if _, err := io.ReadFull(x.conn, extra); err != nil {
+				return nil, fmt.Errorf("X server closed the connection: %w", err)
+			}
+			return append(packet, extra...), nil
+		case x11Expose:
+			x.exposed = true
+		}
+	}
+}
+
+// sync waits for the server to handle every request sent
+func (x *x11Conn) sync() error {
+	if err := x.send(x11GetInputFocus, 0); err != nil {
+		return err
+	}
+	_, err := x.reply()
+	return err
+}
+
+// atom interns an atom name
+func (x *x11Conn) atom(name string) (uint32, error) {
+	return x.internAtom(name, false)
+}
+
+// internAtom is the atom of a name, or 0 when onlyIfExists and no client
+// interned it yet
+func (x *x11Conn) internAtom(name string, onlyIfExists bool) (uint32, error) {
+	var detail byte
+	if onlyIfExists {
+		detail = 1
+	}
+	if err := x.send(x11InternAtom, detail, uint16(len(name)), uint16(0), []byte(name)); err != nil {
+		return 0, err
+	}
+	reply, err := x.reply()
+	if err != nil {
+		return 0, err
+	}
+	return binary.LittleEndian.Uint32(reply[8:]), nil
+}
+
+// selectionOwner is the window owning a selection, 0 for none
+func (x *x11Conn) selectionOwner(selection uint32) (uint32, error) {
+	if err := x.send(x11GetSelectionOwner, 0, selection); err != nil {
+		return 0, err
+	}
+	reply, err := x.reply()
+	if err != nil {
+		return 0, err
+	}
+	return binary.LittleEndian.Uint32(reply[8:]), nil
+}
+
+// hasTray reports whether a system tray owns the XEmbed tray selection of
+// the first screen
+func (x *x11Conn) hasTray() (bool, error) {
+	selection, err := x.internAtom("_NET_SYSTEM_TRAY_S0", true)
+	if err != nil || selection == 0 {
+		return false, err
+	}
+	owner, err := x.selectionOwner(selection)
+	return owner != 0, err
+}
+
+// setProperty replaces a window property of 32-bit values
+func (x *x11Conn) setProperty(window, property, kind uint32, values ...uint32) error {
+	return x.send(x11ChangeProperty, 0, window, property, kind, [4]byte{32}, uint32(len(values)), values)
+}
+
+// pixels packs image rows in the layout of the root visual
+func (x *x11Conn) pixels(img *image.RGBA, rows image.Rectangle) []byte {
+	channel := func(v uint8, mask uint32) uint32 {
+		shift, width := bits.TrailingZeros32(mask), bits.OnesCount32(mask)
+		return (uint32(v) >> max(0, 8-width)) << shift & mask
+	}
+	order := binary.AppendByteOrder(binary.LittleEndian)
+	if x.msbFirst {
+		order = binary.BigEndian
+	}
+	data := make([]byte, 0, rows.Dx()*rows.Dy()*4)
+	for y := rows.Min.Y; y < rows.Max.Y; y++ {
+		for px := rows.Min.X; px < rows.Max.X; px++ {
+			// Premultiplied colors are the image over the black background
+			c := img.RGBAAt(px, y)
+			data = order.AppendUint32(data, channel(c.R, x.red)|channel(c.G, x.green)|channel(c.B, x.blue))
+		}
+	}
+	return data
+}
+
+// draw puts an image into a window in strips that fit a request
+func (x *x11Conn) draw(window, gc uint32, img *image.RGBA) error {
+	bounds := img.Bounds()
+	rows := (x.maxRequest - 24) / (4 * bounds.Dx())
+	if rows < 1 {
+		return fmt.Errorf("X server requests are too small for a %dpx wide splash screen", bounds.Dx())
+	}
+	for y := bounds.Min.Y; y < bounds.Max.Y; y += rows {
+		strip := image.Rect(bounds.Min.X, y, bounds.Max.X, min(y+rows, bounds.Max.Y))
+		if err := x.send(x11PutImage, 2, window, gc, uint16(strip.Dx()), uint16(strip.Dy()),
+			int16(0), int16(y-bounds.Min.Y), [4]byte{0, x.depth}, x.pixels(img, strip)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// splash shows the image centered in an override-redirect window, fading it
+// through _NET_WM_WINDOW_OPACITY. Without a compositing manager the window
+// appears and closes without fading.
+func (x *x11Conn) splash(img image.Image, options SplashOptions) error {
+	fitted := fitSplash(img, x.width, x.height)
+	width, height := fitted.Bounds().Dx(), fitted.Bounds().Dy()
+	opacity, err := x.atom("_NET_WM_WINDOW_OPACITY")
+	if err != nil {
+		return err
+	}
+	windowType, err := x.atom("_NET_WM_WINDOW_TYPE")
+	if err != nil {
+		return err
+	}
+	splashType, err := x.atom("_NET_WM_WINDOW_TYPE_SPLASH")
+	if err != nil {
+		return err
+	}
+
+	window := x.newID()
+	// Background pixel black, override-redirect, Exposure events
+	if err := x.send(x11CreateWindow, 0, window, x.root,
+		int16((x.width-width)/2), int16((x.height-height)/2), uint16(width), uint16(height),
+		uint16(0), uint16(1), uint32(0), uint32(0x2|0x200|0x800), [3]uint32{0, 1, 1 << 15}); err != nil {
+		return err
+	}
+	defer func() {
+		x.send(x11DestroyWindow, 0, window)
+		x.sync()
+	}()
+	if err := x.setProperty(window, windowType, x11AtomAtom, splashType); err != nil {
+		return err
+	}
+	if err := x.setProperty(window, opacity, x11AtomCardinal, 0); err != nil {
+		return err
+	}
+	gc := x.newID()
+	if err := x.send(x11CreateGC, 0, gc, window, uint32(0)); err != nil {
+		return err
+	}
+	if err := x.send(x11MapWindow, 0, window); err != nil {
+		return err
+	}
+	if err := x.sync(); err != nil {
+		return err
+	}
+
+	// Draw once mapped, and again whenever the window is exposed
+	x.exposed = true
+	return runSplash(options, func(o float64) error {
+		if x.exposed {
+			x.exposed = false
+			if err := x.draw(window, gc, fitted); err != nil {
+				return err
+			}
+		}
+		if err := x.setProperty(window, opacity, x11AtomCardinal, uint32(o*0xffffffff)); err != nil {
+			return err
+		}
+		return x.sync()
+	})
+}
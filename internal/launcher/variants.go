@@ -0,0 +1,180 @@
+package launcher
+
+import (
+	"encoding/xml"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+// Appearances an icon variant is drawn for
+const (
+	AppearanceLight = "light"
+	AppearanceDark  = "dark"
+)
+
+// SetAppearance overrides the detected system appearance icon variants are
+// chosen for: light, dark, or empty to detect it again
+func (l *Launcher) SetAppearance(appearance string) error {
+	switch appearance {
+	case "", AppearanceLight, AppearanceDark:
+		l.appearance = appearance
+		return nil
+	}
+	return fmt.Errorf("unknown appearance %q: want light or dark", appearance)
+}
+
+// Appearance is the appearance set, or the one the running system uses
+// when the platform is native: the macOS interface style, whether the
+// Windows taskbar uses the light theme, or the GNOME color scheme. It is
+// light when it can't be told.
+func (l *Launcher) Appearance() string {
+	if l.appearance != "" {
+		return l.appearance
+	}
+	if platformFor(runtime.GOOS) != l.platform {
+		return AppearanceLight
+	}
+	return detectAppearance(l.platform)
+}
+
+// detectAppearance asks the system for its appearance
+func detectAppearance(platform string) string {
+	switch platform {
+	case "macos":
+		// Unset, and the command fails, in light mode
+		out, _ := exec.Command("defaults", "read", "-g", "AppleInterfaceStyle").Output()
+		if strings.TrimSpace(string(out)) == "Dark" {
+			return AppearanceDark
+		}
+	case "windows":
+		out, err := exec.Command("reg", "query", `HKCU\Software\Microsoft\Windows\CurrentVersion\Themes\Personalize`, "/v", "SystemUsesLightTheme").Output()
+		if err == nil && strings.HasSuffix(strings.TrimSpace(string(out)), "0x0") {
+			return AppearanceDark
+		}
+	case "linux":
+		out, _ := exec.Command("gsettings", "get", "org.gnome.desktop.interface", "color-scheme").Output()
+		if strings.Contains(string(out), "prefer-dark") {
+			return AppearanceDark
+		}
+	}
+	return AppearanceLight
+}
+
+// variant is the icon drawn for the appearance, like desktop/icon_dark.png
+// for desktop/icon.png in dark mode, when there is one. Windows 11 themed
+// icons also come as _light variants for the light taskbar.
+func (l *Launcher) variant(icon string) string {
+	ext := filepath.Ext(icon)
+	candidate := strings.TrimSuffix(icon, ext) + "_" + l.Appearance() + ext
+	if _, err := os.Stat(candidate); err == nil {
+		return candidate
+	}
+	return icon
+}
+
+// adaptiveIconPath is where an Android adaptive icon definition is read
+func (l *Launcher) adaptiveIconPath() string {
+	return filepath.Join(l.iconDir, "android", "adaptive", "mipmap-anydpi-v26", "ic_launcher.xml")
+}
+
+// AdaptiveIcon is an Android adaptive icon: layers the launcher masks to
+// its icon shape, with a monochrome one for themed icons on Android 13
+type AdaptiveIcon struct {
+	Definition      string `json:"definition"`                 // mipmap-anydpi-v26/ic_launcher.xml
+	Foreground      string `json:"foreground"`                 // highest density layer
+	Background      string `json:"background,omitempty"`       // background layer image, if it isn't a color
+	BackgroundColor string `json:"background_color,omitempty"` // #rrggbb
+	Monochrome      string `json:"monochrome,omitempty"`
+}
+
+// GetAdaptiveIcon reads the adaptive icon of the icon directory, resolving
+// its layers to the highest density mipmaps and its colors to their values
+func (l *Launcher) GetAdaptiveIcon() (*AdaptiveIcon, error) {
+	path := l.adaptiveIconPath()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("no adaptive icon: %w", err)
+	}
+	var doc struct {
+		XMLName    xml.Name
+		Background struct {
+			Drawable string `xml:"drawable,attr"`
+		} `xml:"background"`
+		Foreground struct {
+			Drawable string `xml:"drawable,attr"`
+		} `xml:"foreground"`
+		Monochrome struct {
+			Drawable string `xml:"drawable,attr"`
+		} `xml:"monochrome"`
+	}
+	if err := xml.Unmarshal(data, &doc); err != nil || doc.XMLName.Local != "adaptive-icon" {
+		return nil, fmt.Errorf("%s is not an adaptive icon definition", path)
+	}
+	res := filepath.Dir(filepath.Dir(path))
+	icon := &AdaptiveIcon{Definition: path}
+	if icon.Foreground, err = resolveMipmap(res, doc.Foreground.Drawable); err != nil {
+		return nil, fmt.Errorf("%s foreground: %w", path, err)
+	}
+	if name, ok := strings.CutPrefix(doc.Background.Drawable, "@color/"); ok {
+		if icon.BackgroundColor, err = resolveColor(res, name); err != nil {
+			return nil, fmt.Errorf("%s background: %w", path, err)
+		}
+	} else if icon.Background, err = resolveMipmap(res, doc.Background.Drawable); err != nil {
+		return nil, fmt.Errorf("%s background: %w", path, err)
+	}
+	if doc.Monochrome.Drawable != "" {
+		if icon.Monochrome, err = resolveMipmap(res, doc.Monochrome.Drawable); err != nil {
+			return nil, fmt.Errorf("%s monochrome: %w", path, err)
+		}
+	}
+	return icon, nil
+}
+
+// androidDensities are the mipmap densities, highest first
+var androidDensities = []string{"xxxhdpi", "xxhdpi", "xhdpi", "hdpi", "mdpi"}
+
+// resolveMipmap finds the highest density PNG of a @mipmap/ or
+// @drawable/ reference
+func resolveMipmap(res, ref string) (string, error) {
+	kind, name, ok := strings.Cut(strings.TrimPrefix(ref, "@"), "/")
+	if !strings.HasPrefix(ref, "@") || !ok || (kind != "mipmap" && kind != "drawable") {
+		return "", fmt.Errorf("unsupported drawable %q: want @mipmap/name or @drawable/name", ref)
+	}
+	for _, density := range androidDensities {
+		path := filepath.Join(res, kind+"-"+density, name+".png")
+		if _, err := os.Stat(path); err == nil {
+			return path, nil
+		}
+	}
+	return "", fmt.Errorf("no %s-<density>/%s.png for %s", kind, name, ref)
+}
+
+// resolveColor finds a color resource in the values directory
+func resolveColor(res, name string) (string, error) {
+	files, _ := filepath.Glob(filepath.Join(res, "values", "*.xml"))
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return "", err
+		}
+		var values struct {
+			Colors []struct {
+				Name  string `xml:"name,attr"`
+				Value string `xml:",chardata"`
+			} `xml:"color"`
+		}
+		if xml.Unmarshal(data, &values) != nil {
+			continue
+		}
+		for _, c := range values.Colors {
+			if c.Name == name {
+				return strings.TrimSpace(c.Value), nil
+			}
+		}
+	}
+	return "", fmt.Errorf("no color resource %s", name)
+}
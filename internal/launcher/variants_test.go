@@ -0,0 +1,86 @@
+package launcher
+
+import (
+	"image/color"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestSetAppearance tests overriding the detected appearance
+func TestSetAppearance(t *testing.T) {
+	l := NewLauncher(t.TempDir())
+	require.NoError(t, l.SetPlatform("ios"))
+	assert.Equal(t, AppearanceLight, l.Appearance(), "light off the native platform")
+	require.NoError(t, l.SetAppearance(AppearanceDark))
+	assert.Equal(t, AppearanceDark, l.Appearance())
+	assert.EqualError(t, l.SetAppearance("sepia"), `unknown appearance "sepia": want light or dark`)
+	assert.Equal(t, AppearanceDark, l.Appearance(), "unchanged")
+}
+
+// TestGetPlatformIcon_Variants tests choosing the dark and light variants,
+// falling back to the plain icon
+func TestGetPlatformIcon_Variants(t *testing.T) {
+	dir := t.TempDir()
+	desktop := filepath.Join(dir, "desktop")
+	require.NoError(t, os.MkdirAll(desktop, 0755))
+	for _, name := range []string{"icon.png", "icon_dark.png", "large.png"} {
+		writePNG(t, filepath.Join(desktop, name), 16)
+	}
+	l := NewLauncher(dir)
+	require.NoError(t, l.SetPlatform("windows"))
+	require.NoError(t, l.SetAppearance(AppearanceDark))
+	assert.Equal(t, filepath.Join(desktop, "icon_dark.png"), l.GetPlatformIcon())
+	require.NoError(t, l.SetAppearance(AppearanceLight))
+	assert.Equal(t, filepath.Join(desktop, "icon.png"), l.GetPlatformIcon(), "no light variant")
+
+	require.NoError(t, l.SetPlatform("macos"))
+	require.NoError(t, l.SetAppearance(AppearanceDark))
+	assert.Equal(t, filepath.Join(desktop, "large.png"), l.GetPlatformIcon(), "no dark variant")
+}
+
+// TestAdaptiveIcon tests generating an adaptive icon and reading it back
+func TestAdaptiveIcon(t *testing.T) {
+	dir := t.TempDir()
+	l := NewLauncher(filepath.Join(dir, "icons"))
+	require.NoError(t, l.SetPlatform("android"))
+	_, err := l.GetAdaptiveIcon()
+	assert.ErrorContains(t, err, "no adaptive icon")
+	assert.Equal(t, filepath.Join(l.iconDir, "android", "xxxhdpi", "icon_xxxhdpi.png"), l.GetPlatformIcon())
+
+	manifest := &IconManifest{
+		Source:                writeLogo(t, filepath.Join(dir, "logo.png"), 216),
+		TransparentBackground: true,
+		Outputs:               []IconOutput{{Path: "icons/android/adaptive", Kind: "adaptive", Background: "#10a0f0"}},
+	}
+	generated, err := GenerateIcons(manifest, dir)
+	require.NoError(t, err)
+	assert.Len(t, generated, 2*len(adaptiveDensities)+2)
+
+	res := filepath.Join(l.iconDir, "android", "adaptive")
+	icon, err := l.GetAdaptiveIcon()
+	require.NoError(t, err)
+	assert.Equal(t, &AdaptiveIcon{
+		Definition:      filepath.Join(res, "mipmap-anydpi-v26", "ic_launcher.xml"),
+		Foreground:      filepath.Join(res, "mipmap-xxxhdpi", "ic_launcher_foreground.png"),
+		BackgroundColor: "#10A0F0",
+		Monochrome:      filepath.Join(res, "mipmap-xxxhdpi", "ic_launcher_monochrome.png"),
+	}, icon)
+	assert.Equal(t, icon.Definition, l.GetPlatformIcon())
+
+	// The 432px canvas keeps the logo inside the 264px safe zone
+	foreground := decodePNG(t, icon.Foreground)
+	assert.Equal(t, 432, foreground.Bounds().Dx())
+	assert.Zero(t, color.NRGBAModel.Convert(foreground.At(80, 80)).(color.NRGBA).A, "outside the safe zone")
+	assert.Equal(t, color.NRGBA{200, 30, 30, 255}, color.NRGBAModel.Convert(foreground.At(216, 216)))
+	monochrome := decodePNG(t, icon.Monochrome)
+	assert.Equal(t, color.NRGBA{255, 255, 255, 255}, color.NRGBAModel.Convert(monochrome.At(216, 216)))
+	assert.Zero(t, color.NRGBAModel.Convert(monochrome.At(80, 80)).(color.NRGBA).A)
+
+	require.NoError(t, os.WriteFile(icon.Definition, []byte("<vector/>"), 0644))
+	_, err = l.GetAdaptiveIcon()
+	assert.EqualError(t, err, icon.Definition+" is not an adaptive icon definition")
+}
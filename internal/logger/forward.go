@@ -0,0 +1,499 @@
+package logger
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// Log forwarding backends
+const (
+	ForwardLoki          = "loki"
+	ForwardElasticsearch = "elasticsearch"
+	ForwardSyslog        = "syslog"
+)
+
+// Defaults for log forwarding
+const (
+	DefaultForwardBatchSize     = 100
+	DefaultForwardBufferSize    = 1000
+	DefaultForwardFlushInterval = 2 * time.Second
+	DefaultForwardTimeout       = 10 * time.Second
+	DefaultElasticsearchIndex   = "panoptic-logs"
+)
+
+// ForwardConfig configures shipping log entries to an external aggregator
+type ForwardConfig struct {
+	Type          string            // loki, elasticsearch or syslog
+	URL           string            // base URL (loki, elasticsearch) or udp://host:port / tcp://host:port (syslog)
+	Index         string            // elasticsearch index
+	Labels        map[string]string // loki stream labels (default job=panoptic)
+	Username      string            // basic auth for loki and elasticsearch
+	Password      string
+	Token         string // bearer token for loki and elasticsearch
+	BatchSize     int
+	BufferSize    int // entries queued before new ones are dropped
+	FlushInterval time.Duration
+	Timeout       time.Duration
+}
+
+// ForwardStats counts what a forwarder did with the entries it received
+type ForwardStats struct {
+	Sent    int64
+	Dropped int64 // buffer full
+	Failed  int64 // backend rejected or unreachable
+}
+
+// forwardEntry is a log entry detached from logrus so it can cross goroutines
+type forwardEntry struct {
+	Time    time.Time
+	Level   logrus.Level
+	Message string
+	Fields  map[string]interface{}
+}
+
+// forwardSender delivers a batch of entries to one backend
+type forwardSender interface {
+	send(entries []forwardEntry) error
+	Close() error
+}
+
+// Forwarder is a logrus hook that ships entries to an aggregator from a
+// background goroutine. Logging never blocks on the network: when the buffer
+// is full, entries are dropped and counted.
+type Forwarder struct {
+	cfg     ForwardConfig
+	sender  forwardSender
+	entries chan forwardEntry
+	done    chan struct{}
+
+	mu     sync.RWMutex
+	closed bool
+
+	sent    atomic.Int64
+	dropped atomic.Int64
+	failed  atomic.Int64
+	warned  atomic.Bool
+}
+
+// NewForwarder validates cfg and starts the background shipper
+func NewForwarder(cfg ForwardConfig) (*Forwarder, error) {
+	if cfg.URL == "" {
+		return nil, fmt.Errorf("log forwarding to %s requires a url", cfg.Type)
+	}
+	if cfg.BatchSize <= 0 {
+		cfg.BatchSize = DefaultForwardBatchSize
+	}
+	if cfg.BufferSize <= 0 {
+		cfg.BufferSize = DefaultForwardBufferSize
+	}
+	if cfg.FlushInterval <= 0 {
+		cfg.FlushInterval = DefaultForwardFlushInterval
+	}
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = DefaultForwardTimeout
+	}
+
+	var sender forwardSender
+	var err error
+	switch strings.ToLower(cfg.Type) {
+	case ForwardLoki:
+		sender, err = newLokiSender(cfg)
+	case ForwardElasticsearch, "es":
+		sender, err = newElasticsearchSender(cfg)
+	case ForwardSyslog:
+		sender, err = newSyslogSender(cfg)
+	default:
+		return nil, fmt.Errorf("unsupported log forwarder %q (expected loki, elasticsearch or syslog)", cfg.Type)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	f := &Forwarder{
+		cfg:     cfg,
+		sender:  sender,
+		entries: make(chan forwardEntry, cfg.BufferSize),
+		done:    make(chan struct{}),
+	}
+	go f.run()
+	return f, nil
+}
+
+// Levels implements logrus.Hook
+func (f *Forwarder) Levels() []logrus.Level {
+	return logrus.AllLevels
+}
+
+// Fire implements logrus.Hook by queueing the entry without blocking
+func (f *Forwarder) Fire(entry *logrus.Entry) error {
+	fields := make(map[string]interface{}, len(entry.Data))
+	for k, v := range entry.Data {
+		if err, ok := v.(error); ok {
+			v = err.Error()
+		}
+		fields[k] = v
+	}
+
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	if f.closed {
+		return nil
+	}
+	select {
+	case f.entries <- forwardEntry{Time: entry.Time, Level: entry.Level, Message: entry.Message, Fields: fields}:
+	default:
+		f.dropped.Add(1)
+	}
+	return nil
+}
+
+// Stats returns delivery counters
+func (f *Forwarder) Stats() ForwardStats {
+	return ForwardStats{Sent: f.sent.Load(), Dropped: f.dropped.Load(), Failed: f.failed.Load()}
+}
+
+// Close flushes queued entries and releases the backend connection
+func (f *Forwarder) Close() error {
+	f.mu.Lock()
+	if f.closed {
+		f.mu.Unlock()
+		return nil
+	}
+	f.closed = true
+	close(f.entries)
+	f.mu.Unlock()
+
+	<-f.done
+	return f.sender.Close()
+}
+
+func (f *Forwarder) run() {
+	defer close(f.done)
+
+	ticker := time.NewTicker(f.cfg.FlushInterval)
+	defer ticker.Stop()
+
+	batch := make([]forwardEntry, 0, f.cfg.BatchSize)
+	flush := func() {
+		if len(batch) == 0 {
+			return
+		}
+		if err := f.sender.send(batch); err != nil {
+			f.failed.Add(int64(len(batch)))
+			// Report the first failure on stderr; logging it would loop back here
+			if !f.warned.Swap(true) {
+				fmt.Fprintf(os.Stderr, "panoptic: log forwarding to %s failed: %v\n", f.cfg.Type, err)
+			}
+		} else {
+			f.sent.Add(int64(len(batch)))
+		}
+		batch = batch[:0]
+	}
+
+	for {
+		select {
+		case entry, ok := <-f.entries:
+			if !ok {
+				flush()
+				return
+			}
+			batch = append(batch, entry)
+			if len(batch) >= f.cfg.BatchSize {
+				flush()
+			}
+		case <-ticker.C:
+			flush()
+		}
+	}
+}
+
+// AddForwarder ships this logger's entries (and those of loggers later derived
+// with WithFields) to an aggregator. Close flushes and stops forwarders.
+func (l *Logger) AddForwarder(cfg ForwardConfig) (*Forwarder, error) {
+	forwarder, err := NewForwarder(cfg)
+	if err != nil {
+		return nil, err
+	}
+	l.AddHook(forwarder)
+	l.forwarders = append(l.forwarders, forwarder)
+	return forwarder, nil
+}
+
+// entryDocument is the JSON shape shared by the Loki and Elasticsearch senders
+func entryDocument(entry forwardEntry, timeKey string) map[string]interface{} {
+	doc := make(map[string]interface{}, len(entry.Fields)+3)
+	for k, v := range entry.Fields {
+		doc[k] = v
+	}
+	if timeKey != "" {
+		doc[timeKey] = entry.Time.Format(time.RFC3339Nano)
+	}
+	doc["level"] = entry.Level.String()
+	doc["message"] = entry.Message
+	return doc
+}
+
+// httpSender posts batches to an HTTP aggregator
+type httpSender struct {
+	cfg    ForwardConfig
+	client *http.Client
+}
+
+func (h *httpSender) post(endpoint, contentType string, body []byte) ([]byte, error) {
+	req, err := http.NewRequest(http.MethodPost, endpoint, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", contentType)
+	switch {
+	case h.cfg.Token != "":
+		req.Header.Set("Authorization", "Bearer "+h.cfg.Token)
+	case h.cfg.Username != "":
+		req.SetBasicAuth(h.cfg.Username, h.cfg.Password)
+	}
+
+	resp, err := h.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	respBody, _ := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if resp.StatusCode >= 300 {
+		return nil, fmt.Errorf("%s returned %d: %s", endpoint, resp.StatusCode, strings.TrimSpace(string(respBody)))
+	}
+	return respBody, nil
+}
+
+func (h *httpSender) Close() error {
+	h.client.CloseIdleConnections()
+	return nil
+}
+
+// lokiSender pushes to Loki's /loki/api/v1/push, one stream per level
+type lokiSender struct {
+	httpSender
+	endpoint string
+	labels   map[string]string
+}
+
+func newLokiSender(cfg ForwardConfig) (*lokiSender, error) {
+	base, err := url.Parse(cfg.URL)
+	if err != nil || base.Scheme == "" || base.Host == "" {
+		return nil, fmt.Errorf("invalid loki url %q", cfg.URL)
+	}
+	labels := map[string]string{"job": "panoptic"}
+	for k, v := range cfg.Labels {
+		labels[k] = v
+	}
+	return &lokiSender{
+		httpSender: httpSender{cfg: cfg, client: &http.Client{Timeout: cfg.Timeout}},
+		endpoint:   strings.TrimRight(cfg.URL, "/") + "/loki/api/v1/push",
+		labels:     labels,
+	}, nil
+}
+
+type lokiStream struct {
+	Stream map[string]string `json:"stream"`
+	Values [][2]string       `json:"values"`
+}
+
+func (l *lokiSender) send(entries []forwardEntry) error {
+	streams := make(map[logrus.Level]*lokiStream)
+	order := make([]logrus.Level, 0)
+	for _, entry := range entries {
+		stream, ok := streams[entry.Level]
+		if !ok {
+			labels := make(map[string]string, len(l.labels)+1)
+			for k, v := range l.labels {
+				labels[k] = v
+			}
+			labels["level"] = entry.Level.String()
+			stream = &lokiStream{Stream: labels}
+			streams[entry.Level] = stream
+			order = append(order, entry.Level)
+		}
+		line, err := json.Marshal(entryDocument(entry, ""))
+		if err != nil {
+			return fmt.Errorf("failed to encode log entry: %w", err)
+		}
+		stream.Values = append(stream.Values, [2]string{strconv.FormatInt(entry.Time.UnixNano(), 10), string(line)})
+	}
+
+	payload := struct {
+		Streams []*lokiStream `json:"streams"`
+	}{}
+	for _, level := range order {
+		payload.Streams = append(payload.Streams, streams[level])
+	}
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("failed to encode loki push: %w", err)
+	}
+	_, err = l.post(l.endpoint, "application/json", body)
+	return err
+}
+
+// elasticsearchSender indexes entries through the _bulk API
+type elasticsearchSender struct {
+	httpSender
+	endpoint string
+	index    string
+}
+
+func newElasticsearchSender(cfg ForwardConfig) (*elasticsearchSender, error) {
+	base, err := url.Parse(cfg.URL)
+	if err != nil || base.Scheme == "" || base.Host == "" {
+		return nil, fmt.Errorf("invalid elasticsearch url %q", cfg.URL)
+	}
+	index := cfg.Index
+	if index == "" {
+		index = DefaultElasticsearchIndex
+	}
+	return &elasticsearchSender{
+		httpSender: httpSender{cfg: cfg, client: &http.Client{Timeout: cfg.Timeout}},
+		endpoint:   strings.TrimRight(cfg.URL, "/") + "/_bulk",
+		index:      index,
+	}, nil
+}
+
+func (e *elasticsearchSender) send(entries []forwardEntry) error {
+	action, err := json.Marshal(map[string]interface{}{"index": map[string]string{"_index": e.index}})
+	if err != nil {
+		return err
+	}
+	var body bytes.Buffer
+	for _, entry := range entries {
+		doc, err := json.Marshal(entryDocument(entry, "@timestamp"))
+		if err != nil {
+			return fmt.Errorf("failed to encode log entry: %w", err)
+		}
+		body.Write(action)
+		body.WriteByte('\n')
+		body.Write(doc)
+		body.WriteByte('\n')
+	}
+
+	respBody, err := e.post(e.endpoint, "application/x-ndjson", body.Bytes())
+	if err != nil {
+		return err
+	}
+	// _bulk reports per-document failures with a 200 status
+	var result struct {
+		Errors bool `json:"errors"`
+	}
+	if json.Unmarshal(respBody, &result) == nil && result.Errors {
+		return fmt.Errorf("elasticsearch rejected some log entries")
+	}
+	return nil
+}
+
+// syslogSender writes RFC 5424 messages over UDP or TCP (octet-counted framing)
+type syslogSender struct {
+	network  string
+	address  string
+	timeout  time.Duration
+	hostname string
+	conn     net.Conn
+}
+
+func newSyslogSender(cfg ForwardConfig) (*syslogSender, error) {
+	u, err := url.Parse(cfg.URL)
+	if err != nil || u.Host == "" || (u.Scheme != "udp" && u.Scheme != "tcp") {
+		return nil, fmt.Errorf("invalid syslog url %q (expected udp://host:port or tcp://host:port)", cfg.URL)
+	}
+	hostname, _ := os.Hostname()
+	if hostname == "" {
+		hostname = "-"
+	}
+	return &syslogSender{network: u.Scheme, address: u.Host, timeout: cfg.Timeout, hostname: hostname}, nil
+}
+
+// syslogFacility is local0; severities follow RFC 5424 section 6.2.1
+const syslogFacility = 16
+
+func syslogSeverity(level logrus.Level) int {
+	switch level {
+	case logrus.PanicLevel, logrus.FatalLevel:
+		return 2
+	case logrus.ErrorLevel:
+		return 3
+	case logrus.WarnLevel:
+		return 4
+	case logrus.InfoLevel:
+		return 6
+	default:
+		return 7
+	}
+}
+
+var sdEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, `]`, `\]`)
+
+// formatSyslog renders one RFC 5424 message with fields as structured data
+func (s *syslogSender) formatSyslog(entry forwardEntry) string {
+	sd := "-"
+	if len(entry.Fields) > 0 {
+		keys := make([]string, 0, len(entry.Fields))
+		for k := range entry.Fields {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		var b strings.Builder
+		b.WriteString("[panoptic@32473")
+		for _, k := range keys {
+			fmt.Fprintf(&b, ` %s="%s"`, k, sdEscaper.Replace(fmt.Sprint(entry.Fields[k])))
+		}
+		b.WriteString("]")
+		sd = b.String()
+	}
+	return fmt.Sprintf("<%d>1 %s %s panoptic %d - %s %s",
+		syslogFacility*8+syslogSeverity(entry.Level),
+		entry.Time.UTC().Format(time.RFC3339Nano), s.hostname, os.Getpid(), sd, entry.Message)
+}
+
+func (s *syslogSender) send(entries []forwardEntry) error {
+	if s.conn == nil {
+		conn, err := net.DialTimeout(s.network, s.address, s.timeout)
+		if err != nil {
+			return err
+		}
+		s.conn = conn
+	}
+
+	for _, entry := range entries {
+		msg := s.formatSyslog(entry)
+		if s.network == "tcp" {
+			msg = strconv.Itoa(len(msg)) + " " + msg
+		}
+		s.conn.SetWriteDeadline(time.Now().Add(s.timeout))
+		if _, err := io.WriteString(s.conn, msg); err != nil {
+			// Reconnect on the next batch
+			s.conn.Close()
+			s.conn = nil
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *syslogSender) Close() error {
+	if s.conn == nil {
+		return nil
+	}
+	err := s.conn.Close()
+	s.conn = nil
+	return err
+}
@@ -0,0 +1,232 @@
+package logger
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// captureServer records request bodies sent to an aggregator
+type captureServer struct {
+	mu       sync.Mutex
+	paths    []string
+	bodies   []string
+	auth     []string
+	response string
+	status   int
+}
+
+func newCaptureServer(t *testing.T) (*captureServer, *httptest.Server) {
+	c := &captureServer{status: http.StatusNoContent}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		c.mu.Lock()
+		c.paths = append(c.paths, r.URL.Path)
+		c.bodies = append(c.bodies, string(body))
+		c.auth = append(c.auth, r.Header.Get("Authorization"))
+		status, response := c.status, c.response
+		c.mu.Unlock()
+		w.WriteHeader(status)
+		io.WriteString(w, response)
+	}))
+	t.Cleanup(server.Close)
+	return c, server
+}
+
+func quietLogger() *Logger {
+	log := NewLogger(true)
+	log.SetOutput(io.Discard)
+	return log
+}
+
+func TestForwarder_Loki(t *testing.T) {
+	capture, server := newCaptureServer(t)
+	log := quietLogger()
+	forwarder, err := log.AddForwarder(ForwardConfig{Type: ForwardLoki, URL: server.URL + "/", Token: "secret", Labels: map[string]string{"env": "ci"}})
+	require.NoError(t, err)
+
+	log.WithFields(Fields{"run_id": "r-1", "app": "shop"}).Info("app started")
+	log.Error("boom")
+	require.NoError(t, log.Close())
+
+	assert.Equal(t, ForwardStats{Sent: 2}, forwarder.Stats())
+	require.Len(t, capture.bodies, 1, "entries are batched")
+	assert.Equal(t, "/loki/api/v1/push", capture.paths[0])
+	assert.Equal(t, "Bearer secret", capture.auth[0])
+
+	var push struct {
+		Streams []struct {
+			Stream map[string]string `json:"stream"`
+			Values [][2]string       `json:"values"`
+		} `json:"streams"`
+	}
+	require.NoError(t, json.Unmarshal([]byte(capture.bodies[0]), &push))
+	require.Len(t, push.Streams, 2, "one stream per level")
+	assert.Equal(t, map[string]string{"job": "panoptic", "env": "ci", "level": "info"}, push.Streams[0].Stream)
+
+	var line map[string]interface{}
+	require.NoError(t, json.Unmarshal([]byte(push.Streams[0].Values[0][1]), &line))
+	assert.Equal(t, "app started", line["message"])
+	assert.Equal(t, "r-1", line["run_id"])
+	assert.Equal(t, "shop", line["app"])
+}
+
+func TestForwarder_Elasticsearch(t *testing.T) {
+	capture, server := newCaptureServer(t)
+	capture.status = http.StatusOK
+	capture.response = `{"errors":false}`
+	log := quietLogger()
+	forwarder, err := log.AddForwarder(ForwardConfig{Type: ForwardElasticsearch, URL: server.URL, Username: "elastic", Password: "pw", Index: "qa-logs"})
+	require.NoError(t, err)
+
+	log.WithFields(Fields{"action": "login"}).Warn("slow step")
+	require.NoError(t, log.Close())
+
+	assert.Equal(t, int64(1), forwarder.Stats().Sent)
+	require.Len(t, capture.bodies, 1)
+	assert.Equal(t, "/_bulk", capture.paths[0])
+	assert.True(t, strings.HasPrefix(capture.auth[0], "Basic "))
+
+	lines := strings.Split(strings.TrimSpace(capture.bodies[0]), "\n")
+	require.Len(t, lines, 2)
+	assert.JSONEq(t, `{"index":{"_index":"qa-logs"}}`, lines[0])
+	var doc map[string]interface{}
+	require.NoError(t, json.Unmarshal([]byte(lines[1]), &doc))
+	assert.Equal(t, "slow step", doc["message"])
+	assert.Equal(t, "warning", doc["level"])
+	assert.Equal(t, "login", doc["action"])
+	assert.NotEmpty(t, doc["@timestamp"])
+}
+
+func TestForwarder_BackendFailures(t *testing.T) {
+	capture, server := newCaptureServer(t)
+	capture.status = http.StatusOK
+	capture.response = `{"errors":true}`
+	log := quietLogger()
+	forwarder, err := log.AddForwarder(ForwardConfig{Type: ForwardElasticsearch, URL: server.URL})
+	require.NoError(t, err)
+
+	log.Info("rejected")
+	require.NoError(t, log.Close())
+	assert.Equal(t, ForwardStats{Failed: 1}, forwarder.Stats(), "bulk item errors are failures, not deliveries")
+}
+
+func TestForwarder_SyslogUDP(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer conn.Close()
+
+	log := quietLogger()
+	_, err = log.AddForwarder(ForwardConfig{Type: ForwardSyslog, URL: "udp://" + conn.LocalAddr().String()})
+	require.NoError(t, err)
+
+	log.WithFields(Fields{"app": `we"ird]`}).Error("failed")
+	require.NoError(t, log.Close())
+
+	buf := make([]byte, 2048)
+	require.NoError(t, conn.SetReadDeadline(time.Now().Add(2*time.Second)))
+	n, _, err := conn.ReadFrom(buf)
+	require.NoError(t, err)
+
+	msg := string(buf[:n])
+	assert.True(t, strings.HasPrefix(msg, "<131>1 "), msg) // local0.err
+	assert.Contains(t, msg, ` panoptic `)
+	assert.Contains(t, msg, `[panoptic@32473 app="we\"ird\]"]`)
+	assert.True(t, strings.HasSuffix(msg, " failed"), msg)
+}
+
+func TestForwarder_SyslogTCPFraming(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer listener.Close()
+
+	received := make(chan string, 1)
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		data, _ := io.ReadAll(bufio.NewReader(conn))
+		received <- string(data)
+	}()
+
+	log := quietLogger()
+	_, err = log.AddForwarder(ForwardConfig{Type: ForwardSyslog, URL: "tcp://" + listener.Addr().String()})
+	require.NoError(t, err)
+	log.Info("one")
+	require.NoError(t, log.Close())
+
+	select {
+	case data := <-received:
+		length, msg, ok := strings.Cut(data, " ")
+		require.True(t, ok)
+		assert.Equal(t, length, strconv.Itoa(len(msg)), "octet-counted framing")
+		assert.True(t, strings.HasPrefix(msg, "<134>1 "), msg) // local0.info
+	case <-time.After(2 * time.Second):
+		t.Fatal("no syslog message received")
+	}
+}
+
+// blockingSender never completes a send until released
+type blockingSender struct {
+	release chan struct{}
+}
+
+func (b *blockingSender) send(entries []forwardEntry) error {
+	<-b.release
+	return nil
+}
+
+func (b *blockingSender) Close() error { return nil }
+
+func TestForwarder_DropsWhenBufferFull(t *testing.T) {
+	sender := &blockingSender{release: make(chan struct{})}
+	f := &Forwarder{
+		cfg:     ForwardConfig{BatchSize: 1, FlushInterval: time.Hour},
+		sender:  sender,
+		entries: make(chan forwardEntry, 2),
+		done:    make(chan struct{}),
+	}
+	go f.run()
+
+	log := quietLogger()
+	log.AddHook(f)
+
+	start := time.Now()
+	for i := 0; i < 10; i++ {
+		log.Info("flood")
+	}
+	assert.Less(t, time.Since(start), time.Second, "logging never blocks on the backend")
+	assert.GreaterOrEqual(t, f.Stats().Dropped, int64(7))
+
+	close(sender.release)
+	require.NoError(t, f.Close())
+	assert.Equal(t, int64(10), f.Stats().Sent+f.Stats().Dropped)
+	assert.NoError(t, f.Fire(logrus.NewEntry(log.Logger)), "firing after close is a no-op")
+}
+
+func TestNewForwarder_Invalid(t *testing.T) {
+	for _, cfg := range []ForwardConfig{
+		{Type: ForwardLoki},
+		{Type: "splunk", URL: "http://splunk"},
+		{Type: ForwardLoki, URL: "not a url"},
+		{Type: ForwardElasticsearch, URL: "/relative"},
+		{Type: ForwardSyslog, URL: "http://syslog:514"},
+	} {
+		_, err := NewForwarder(cfg)
+		assert.Error(t, err, "%+v", cfg)
+	}
+}
@@ -2,6 +2,7 @@ package logger
 
 import (
 	"bufio"
+	"io"
 	"os"
 	"path/filepath"
 	"time"
@@ -15,8 +16,11 @@ type flusher interface {
 
 type Logger struct {
 	*logrus.Logger
-	outputDir string
-	flusher   flusher
+	outputDir  string
+	flusher    flusher
+	fields     Fields    // context added by WithFields
+	closer     io.Closer // log file opened by Configure
+	forwarders []*Forwarder
 }
 
 func NewLogger(verbose bool) *Logger {
@@ -33,7 +37,7 @@ func NewLogger(verbose bool) *Logger {
 		ForceColors:   true,
 	})
 	
-	log.SetOutput(os.Stdout)
+	log.SetOutput(&syncWriter{w: os.Stdout})
 	
 	return &Logger{
 		Logger: log,
@@ -75,7 +79,7 @@ func (l *Logger) SetOutputDirectory(outputDir string) {
 		}
 	}()
 	
-	l.SetOutput(bufferedWriter)
+	l.SetOutput(&syncWriter{w: bufferedWriter})
 	l.Infof("Log file: %s (buffered)", logFile)
 	
 	// Store flusher for testing
@@ -0,0 +1,49 @@
+package logger
+
+import (
+	"fmt"
+
+	"github.com/sirupsen/logrus"
+)
+
+// SetRedactor has every entry's message and text fields passed through
+// redact before any output or forwarder sees them. Child loggers created by
+// WithFields afterwards redact too. A nil redact removes it.
+func (l *Logger) SetRedactor(redact func(string) string) {
+	for _, level := range logrus.AllLevels {
+		hooks := make([]logrus.Hook, 0, len(l.Hooks[level])+1)
+		if redact != nil {
+			hooks = append(hooks, &redactHook{redact: redact})
+		}
+		for _, hook := range l.Hooks[level] {
+			if _, ok := hook.(*redactHook); !ok {
+				hooks = append(hooks, hook)
+			}
+		}
+		l.Hooks[level] = hooks
+	}
+}
+
+// redactHook redacts entries; it runs before the hooks forwarding them
+type redactHook struct {
+	redact func(string) string
+}
+
+func (h *redactHook) Levels() []logrus.Level {
+	return logrus.AllLevels
+}
+
+func (h *redactHook) Fire(entry *logrus.Entry) error {
+	entry.Message = h.redact(entry.Message)
+	for k, v := range entry.Data {
+		switch value := v.(type) {
+		case string:
+			entry.Data[k] = h.redact(value)
+		case error:
+			entry.Data[k] = h.redact(value.Error())
+		case fmt.Stringer:
+			entry.Data[k] = h.redact(value.String())
+		}
+	}
+	return nil
+}
@@ -0,0 +1,33 @@
+package logger
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLogger_SetRedactor(t *testing.T) {
+	buf := &bytes.Buffer{}
+	log := NewLogger(false)
+	require.NoError(t, log.Configure(Options{Format: FormatJSON}))
+	log.SetOutput(buf)
+	redact := func(s string) string { return strings.ReplaceAll(s, "ada@example.com", "[REDACTED]") }
+	log.SetRedactor(redact)
+	log.SetRedactor(redact) // replaces, never stacks
+
+	log.WithFields(Fields{"user": "ada@example.com"}).WithField("error", errors.New("no ada@example.com")).Infof("login as %s", "ada@example.com")
+	entries := decodeJSONLines(t, buf.Bytes())
+	require.Len(t, entries, 1)
+	assert.Equal(t, "login as [REDACTED]", entries[0]["msg"])
+	assert.Equal(t, "[REDACTED]", entries[0]["user"])
+	assert.Equal(t, "no [REDACTED]", entries[0]["error"])
+
+	buf.Reset()
+	log.SetRedactor(nil)
+	log.Info("ada@example.com")
+	assert.Contains(t, buf.String(), "ada@example.com")
+}
@@ -0,0 +1,287 @@
+package logger
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// Fields are key/value pairs attached to every entry of a child logger
+type Fields = logrus.Fields
+
+// Log output formats
+const (
+	FormatText = "text"
+	FormatJSON = "json"
+)
+
+// Log sinks
+const (
+	SinkStdout = "stdout"
+	SinkStderr = "stderr"
+	SinkFile   = "file"
+	SinkBoth   = "both" // stderr and file
+)
+
+// Defaults for log file rotation
+const (
+	DefaultMaxSizeMB  = 100
+	DefaultMaxBackups = 5
+)
+
+// Options configures log format, destination and rotation
+type Options struct {
+	Format     string // text (default) or json
+	Sink       string // stdout (default), stderr, file or both
+	File       string // log file for the file and both sinks
+	MaxSizeMB  int    // rotate the log file once it exceeds this size
+	MaxBackups int    // rotated files kept as <file>.1 ... <file>.N
+	Forward    []ForwardConfig
+}
+
+// Configure applies format, sink and rotation options. Loggers derived with
+// WithFields afterwards share the new configuration.
+func (l *Logger) Configure(opts Options) error {
+	var formatter logrus.Formatter
+	switch strings.ToLower(opts.Format) {
+	case "", FormatText:
+		formatter = &logrus.TextFormatter{FullTimestamp: true, ForceColors: opts.Sink == "" || opts.Sink == SinkStdout}
+	case FormatJSON:
+		formatter = &logrus.JSONFormatter{TimestampFormat: time.RFC3339Nano}
+	default:
+		return fmt.Errorf("unsupported log format %q (expected text or json)", opts.Format)
+	}
+
+	var out io.Writer
+	switch strings.ToLower(opts.Sink) {
+	case "", SinkStdout:
+		out = os.Stdout
+	case SinkStderr:
+		out = os.Stderr
+	case SinkFile, SinkBoth:
+		if opts.File == "" {
+			return fmt.Errorf("log sink %q requires a log file", opts.Sink)
+		}
+		file, err := newRotatingFile(opts.File, opts.MaxSizeMB, opts.MaxBackups)
+		if err != nil {
+			return err
+		}
+		if l.closer != nil {
+			l.closer.Close()
+		}
+		l.closer = file
+		out = file
+		if strings.ToLower(opts.Sink) == SinkBoth {
+			out = io.MultiWriter(os.Stderr, file)
+		}
+	default:
+		return fmt.Errorf("unsupported log sink %q (expected stdout, stderr, file or both)", opts.Sink)
+	}
+
+	l.SetFormatter(formatter)
+	l.SetOutput(&syncWriter{w: out})
+
+	for _, cfg := range opts.Forward {
+		if _, err := l.AddForwarder(cfg); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// WithFields returns a child logger that adds fields to every entry. The child
+// shares the parent's output, format and hooks, and inherits its fields.
+func (l *Logger) WithFields(fields Fields) *Logger {
+	merged := make(Fields, len(l.fields)+len(fields))
+	for k, v := range l.fields {
+		merged[k] = v
+	}
+	for k, v := range fields {
+		merged[k] = v
+	}
+
+	child := &logrus.Logger{
+		Out:          l.sharedOutput(),
+		Formatter:    l.Formatter,
+		Hooks:        make(logrus.LevelHooks),
+		Level:        l.GetLevel(),
+		ExitFunc:     l.ExitFunc,
+		ReportCaller: l.ReportCaller,
+	}
+	// The fields hook runs first so the parent's hooks see the context fields
+	child.AddHook(&fieldsHook{fields: merged})
+	for level, hooks := range l.Hooks {
+		for _, hook := range hooks {
+			if _, ok := hook.(*fieldsHook); !ok {
+				child.Hooks[level] = append(child.Hooks[level], hook)
+			}
+		}
+	}
+
+	return &Logger{
+		Logger:    child,
+		outputDir: l.outputDir,
+		flusher:   l.flusher,
+		fields:    merged,
+	}
+}
+
+// Fields returns the context fields carried by this logger
+func (l *Logger) Fields() Fields {
+	fields := make(Fields, len(l.fields))
+	for k, v := range l.fields {
+		fields[k] = v
+	}
+	return fields
+}
+
+// Close flushes log forwarders and releases the log file opened by Configure
+func (l *Logger) Close() error {
+	var firstErr error
+	for _, forwarder := range l.forwarders {
+		if err := forwarder.Close(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	l.forwarders = nil
+
+	if l.closer != nil {
+		if err := l.closer.Close(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+		l.closer = nil
+	}
+	return firstErr
+}
+
+// sharedOutput wraps the output so parent and children serialize their writes
+func (l *Logger) sharedOutput() io.Writer {
+	if _, ok := l.Out.(*syncWriter); !ok {
+		l.SetOutput(&syncWriter{w: l.Out})
+	}
+	return l.Out
+}
+
+// fieldsHook adds a child logger's fields to entries that don't set them
+type fieldsHook struct {
+	fields Fields
+}
+
+func (h *fieldsHook) Levels() []logrus.Level {
+	return logrus.AllLevels
+}
+
+func (h *fieldsHook) Fire(entry *logrus.Entry) error {
+	for k, v := range h.fields {
+		if _, ok := entry.Data[k]; !ok {
+			entry.Data[k] = v
+		}
+	}
+	return nil
+}
+
+// syncWriter serializes writes from loggers sharing one destination
+type syncWriter struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+func (s *syncWriter) Write(p []byte) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.w.Write(p)
+}
+
+// rotatingFile is a log file that is renamed to <path>.1 once it grows past
+// maxSize, shifting older backups up and dropping the oldest
+type rotatingFile struct {
+	mu         sync.Mutex
+	path       string
+	maxSize    int64
+	maxBackups int
+	file       *os.File
+	size       int64
+}
+
+func newRotatingFile(path string, maxSizeMB, maxBackups int) (*rotatingFile, error) {
+	if maxSizeMB <= 0 {
+		maxSizeMB = DefaultMaxSizeMB
+	}
+	if maxBackups <= 0 {
+		maxBackups = DefaultMaxBackups
+	}
+	r := &rotatingFile{path: path, maxSize: int64(maxSizeMB) << 20, maxBackups: maxBackups}
+	if err := r.open(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+func (r *rotatingFile) open() error {
+	if err := os.MkdirAll(filepath.Dir(r.path), 0755); err != nil {
+		return fmt.Errorf("failed to create log directory: %w", err)
+	}
+	file, err := os.OpenFile(r.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to open log file: %w", err)
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return fmt.Errorf("failed to stat log file: %w", err)
+	}
+	r.file = file
+	r.size = info.Size()
+	return nil
+}
+
+func (r *rotatingFile) Write(p []byte) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.file == nil {
+		return 0, fmt.Errorf("log file %s is closed", r.path)
+	}
+	if r.size > 0 && r.size+int64(len(p)) > r.maxSize {
+		if err := r.rotate(); err != nil {
+			return 0, err
+		}
+	}
+	n, err := r.file.Write(p)
+	r.size += int64(n)
+	return n, err
+}
+
+func (r *rotatingFile) rotate() error {
+	if err := r.file.Close(); err != nil {
+		return fmt.Errorf("failed to close log file for rotation: %w", err)
+	}
+	r.file = nil
+
+	os.Remove(fmt.Sprintf("%s.%d", r.path, r.maxBackups))
+	for i := r.maxBackups - 1; i >= 1; i-- {
+		os.Rename(fmt.Sprintf("%s.%d", r.path, i), fmt.Sprintf("%s.%d", r.path, i+1))
+	}
+	if err := os.Rename(r.path, r.path+".1"); err != nil {
+		return fmt.Errorf("failed to rotate log file: %w", err)
+	}
+	return r.open()
+}
+
+func (r *rotatingFile) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.file == nil {
+		return nil
+	}
+	err := r.file.Close()
+	r.file = nil
+	return err
+}
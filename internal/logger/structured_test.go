@@ -0,0 +1,143 @@
+package logger
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func decodeJSONLines(t *testing.T, data []byte) []map[string]interface{} {
+	t.Helper()
+	entries := make([]map[string]interface{}, 0)
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		var entry map[string]interface{}
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), &entry), scanner.Text())
+		entries = append(entries, entry)
+	}
+	return entries
+}
+
+func TestLogger_ConfigureJSONFile(t *testing.T) {
+	logFile := filepath.Join(t.TempDir(), "logs", "run.log")
+	log := NewLogger(false)
+	require.NoError(t, log.Configure(Options{Format: FormatJSON, Sink: SinkFile, File: logFile}))
+	defer log.Close()
+
+	log.WithFields(Fields{"run_id": "r-1"}).Infof("starting %s", "run")
+	log.Warn("plain")
+
+	content, err := os.ReadFile(logFile)
+	require.NoError(t, err)
+	entries := decodeJSONLines(t, content)
+	require.Len(t, entries, 2)
+	assert.Equal(t, "starting run", entries[0]["msg"])
+	assert.Equal(t, "info", entries[0]["level"])
+	assert.Equal(t, "r-1", entries[0]["run_id"])
+	assert.NotEmpty(t, entries[0]["time"])
+	assert.Equal(t, "warning", entries[1]["level"])
+	assert.NotContains(t, entries[1], "run_id", "parent entries carry no child fields")
+}
+
+func TestLogger_ConfigureInvalid(t *testing.T) {
+	log := NewLogger(false)
+	assert.Error(t, log.Configure(Options{Format: "xml"}))
+	assert.Error(t, log.Configure(Options{Sink: "kafka"}))
+	assert.Error(t, log.Configure(Options{Sink: SinkBoth}), "file sinks need a path")
+	assert.IsType(t, &logrus.TextFormatter{}, log.Formatter, "failed configuration leaves the logger unchanged")
+}
+
+func TestLogger_WithFieldsNested(t *testing.T) {
+	buf := &bytes.Buffer{}
+	log := NewLogger(true)
+	log.SetFormatter(&logrus.JSONFormatter{})
+	log.SetOutput(buf)
+
+	run := log.WithFields(Fields{"run_id": "r-2"})
+	app := run.WithFields(Fields{"app": "shop"})
+	action := app.WithFields(Fields{"action": "login"})
+
+	action.Debug("clicking")
+	app.WithFields(Fields{"app": "override"}).Info("renamed")
+
+	entries := decodeJSONLines(t, buf.Bytes())
+	require.Len(t, entries, 2)
+	assert.Equal(t, "r-2", entries[0]["run_id"])
+	assert.Equal(t, "shop", entries[0]["app"])
+	assert.Equal(t, "login", entries[0]["action"])
+	assert.Equal(t, "debug", entries[0]["level"], "children inherit the parent's level")
+	assert.Equal(t, "override", entries[1]["app"])
+	assert.NotContains(t, entries[1], "action")
+
+	assert.Equal(t, Fields{"run_id": "r-2", "app": "shop"}, app.Fields())
+}
+
+func TestLogger_WithFieldsConcurrent(t *testing.T) {
+	logFile := filepath.Join(t.TempDir(), "panoptic.log")
+	log := NewLogger(false)
+	require.NoError(t, log.Configure(Options{Format: FormatJSON, Sink: SinkFile, File: logFile}))
+	defer log.Close()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func(id int) {
+			defer wg.Done()
+			log.WithFields(Fields{"worker": id}).Info("tick")
+			log.Info("parent")
+		}(i)
+	}
+	wg.Wait()
+
+	content, err := os.ReadFile(logFile)
+	require.NoError(t, err)
+	assert.Len(t, decodeJSONLines(t, content), 40, "parent and children share one serialized writer")
+}
+
+func TestRotatingFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "panoptic.log")
+	file, err := newRotatingFile(path, 1, 2)
+	require.NoError(t, err)
+	file.maxSize = 10
+
+	for _, line := range []string{"first\n", "second\n", "third\n", "fourth\n"} {
+		_, err := file.Write([]byte(line))
+		require.NoError(t, err)
+	}
+	require.NoError(t, file.Close())
+
+	read := func(name string) string {
+		data, err := os.ReadFile(name)
+		require.NoError(t, err)
+		return string(data)
+	}
+	assert.Equal(t, "fourth\n", read(path))
+	assert.Equal(t, "third\n", read(path+".1"))
+	assert.Equal(t, "second\n", read(path+".2"))
+	assert.NoFileExists(t, path+".3", "backups beyond max_backups are dropped")
+
+	_, err = file.Write([]byte("late"))
+	assert.Error(t, err)
+}
+
+func TestRotatingFile_AppendsExisting(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "panoptic.log")
+	require.NoError(t, os.WriteFile(path, []byte(strings.Repeat("x", 8)), 0644))
+
+	file, err := newRotatingFile(path, 0, 0)
+	require.NoError(t, err)
+	defer file.Close()
+
+	assert.Equal(t, int64(8), file.size)
+	assert.Equal(t, int64(DefaultMaxSizeMB)<<20, file.maxSize)
+	assert.Equal(t, DefaultMaxBackups, file.maxBackups)
+}
@@ -0,0 +1,76 @@
+package notify
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/smtp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultSMTPPort is the submission port used when none is set
+const DefaultSMTPPort = 587
+
+// Email sends plain text mail through an SMTP server, authenticating when a
+// username is set. net/smtp upgrades to TLS when the server offers STARTTLS.
+type Email struct {
+	Host     string
+	Port     int
+	Username string
+	Password string
+	From     string
+	SendMail func(addr string, a smtp.Auth, from string, to []string, msg []byte) error
+}
+
+// NewEmail creates an SMTP mailer; port 0 is the submission port
+func NewEmail(host string, port int, username, password, from string) *Email {
+	if port == 0 {
+		port = DefaultSMTPPort
+	}
+	return &Email{
+		Host:     host,
+		Port:     port,
+		Username: username,
+		Password: password,
+		From:     from,
+		SendMail: smtp.SendMail,
+	}
+}
+
+// Send mails a message to the recipients
+func (e *Email) Send(ctx context.Context, to []string, subject, body string) error {
+	if e.Host == "" || e.From == "" {
+		return fmt.Errorf("email: host and from are required")
+	}
+	if len(to) == 0 {
+		return fmt.Errorf("email: no recipients")
+	}
+	for _, header := range append([]string{e.From, subject}, to...) {
+		if strings.ContainsAny(header, "\r\n") {
+			return fmt.Errorf("email: line break in header %q", header)
+		}
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	var msg strings.Builder
+	fmt.Fprintf(&msg, "From: %s\r\n", e.From)
+	fmt.Fprintf(&msg, "To: %s\r\n", strings.Join(to, ", "))
+	fmt.Fprintf(&msg, "Subject: %s\r\n", subject)
+	fmt.Fprintf(&msg, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	msg.WriteString("MIME-Version: 1.0\r\nContent-Type: text/plain; charset=UTF-8\r\n\r\n")
+	msg.WriteString(strings.ReplaceAll(strings.ReplaceAll(body, "\r\n", "\n"), "\n", "\r\n"))
+
+	var auth smtp.Auth
+	if e.Username != "" {
+		auth = smtp.PlainAuth("", e.Username, e.Password, e.Host)
+	}
+	addr := net.JoinHostPort(e.Host, strconv.Itoa(e.Port))
+	if err := e.SendMail(addr, auth, e.From, to, []byte(msg.String())); err != nil {
+		return fmt.Errorf("email to %s: %w", strings.Join(to, ", "), err)
+	}
+	return nil
+}
@@ -0,0 +1,49 @@
+package notify
+
+import (
+	"context"
+	"errors"
+	"net/smtp"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestEmail_Send tests the message sent and the SMTP address and auth
+func TestEmail_Send(t *testing.T) {
+	var addr, from, msg string
+	var auth smtp.Auth
+	var to []string
+	email := NewEmail("smtp.example.com", 0, "panoptic", "secret", "panoptic@example.com")
+	email.SendMail = func(a string, au smtp.Auth, f string, t []string, m []byte) error {
+		addr, auth, from, to, msg = a, au, f, t, string(m)
+		return nil
+	}
+
+	require.NoError(t, email.Send(context.Background(), []string{"ana@example.com"}, "Reset your password", "Use this token:\nabc"))
+	assert.Equal(t, "smtp.example.com:587", addr)
+	assert.NotNil(t, auth)
+	assert.Equal(t, "panoptic@example.com", from)
+	assert.Equal(t, []string{"ana@example.com"}, to)
+	assert.Contains(t, msg, "To: ana@example.com\r\nSubject: Reset your password\r\n")
+	assert.Contains(t, msg, "\r\n\r\nUse this token:\r\nabc")
+
+	email.Username = ""
+	require.NoError(t, email.Send(context.Background(), []string{"ana@example.com"}, "Hi", ""))
+	assert.Nil(t, auth, "servers without a username are used unauthenticated")
+}
+
+// TestEmail_SendErrors tests refused messages and server errors
+func TestEmail_SendErrors(t *testing.T) {
+	email := NewEmail("smtp.example.com", 25, "", "", "panoptic@example.com")
+	email.SendMail = func(string, smtp.Auth, string, []string, []byte) error { return errors.New("550 mailbox unavailable") }
+
+	assert.EqualError(t, email.Send(context.Background(), nil, "Hi", ""), "email: no recipients")
+	assert.EqualError(t, email.Send(context.Background(), []string{"ana@example.com"}, "Hi\r\nBcc: eve@example.com", ""),
+		"email: line break in header \"Hi\\r\\nBcc: eve@example.com\"")
+	assert.EqualError(t, email.Send(context.Background(), []string{"ana@example.com"}, "Hi", ""),
+		"email to ana@example.com: 550 mailbox unavailable")
+	assert.EqualError(t, NewEmail("", 0, "", "", "").Send(context.Background(), []string{"ana@example.com"}, "Hi", ""),
+		"email: host and from are required")
+}
@@ -0,0 +1,170 @@
+// Package notify formats run summaries for Slack and Microsoft Teams and posts
+// them, keeping the thread of repeated runs in a small state file. It also
+// sends plain text email through SMTP.
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// StateFileName is the thread state file kept in the output directory
+const StateFileName = "notify_state.json"
+
+// DefaultMaxFailures is the number of failures listed in a message
+const DefaultMaxFailures = 5
+
+// Run is the outcome of a run as shown in a notification
+type Run struct {
+	Name        string // configuration name
+	RunID       string
+	Total       int
+	Passed      int
+	Failed      int
+	Warnings    int
+	Quarantined int
+	Success     bool // the run passed its failure policy
+	Duration    time.Duration
+	ReportURL   string
+	Failures    []Failure
+
+	Organization string // settings.report branding
+	Footer       string
+}
+
+// Failure is a failed app
+type Failure struct {
+	App         string
+	Error       string
+	Category    string
+	Quarantined bool
+	Warning     bool   // failed with warning severity
+	Screenshot  string // public URL of a thumbnail, if one was uploaded
+}
+
+// headline is the one-line summary used as title and notification fallback text
+func (r Run) headline() string {
+	name := r.Name
+	if name == "" {
+		name = "Panoptic run"
+	}
+	if r.Failed == 0 {
+		return fmt.Sprintf("%s passed: %d of %d app(s) in %s", name, r.Passed, r.Total, r.Duration.Round(time.Second))
+	}
+	return fmt.Sprintf("%s failed: %d of %d app(s) in %s", name, r.Failed, r.Total, r.Duration.Round(time.Second))
+}
+
+// signature is the organization and footer line closing a message
+func (r Run) signature() string {
+	switch {
+	case r.Organization != "" && r.Footer != "":
+		return r.Organization + " · " + r.Footer
+	case r.Organization != "":
+		return r.Organization
+	default:
+		return r.Footer
+	}
+}
+
+// status is success, warning (failures that don't fail the run) or failure
+func (r Run) status() string {
+	switch {
+	case !r.Success:
+		return "failure"
+	case r.Total != r.Passed:
+		return "warning"
+	default:
+		return "success"
+	}
+}
+
+// Thread is the message repeated runs reply to
+type Thread struct {
+	TS        string    `json:"ts"`
+	StartedAt time.Time `json:"started_at"`
+}
+
+// Target is the notification state of one destination
+type Target struct {
+	Thread      *Thread `json:"thread,omitempty"`
+	LastSuccess *bool   `json:"last_success,omitempty"`
+}
+
+// State holds the per-destination state across runs
+type State struct {
+	Targets map[string]*Target `json:"targets"`
+}
+
+// LoadState reads a state file; a missing file is an empty state
+func LoadState(path string) (*State, error) {
+	state := &State{Targets: make(map[string]*Target)}
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return state, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read notification state: %w", err)
+	}
+	if err := json.Unmarshal(data, state); err != nil {
+		return nil, fmt.Errorf("invalid notification state %s: %w", path, err)
+	}
+	if state.Targets == nil {
+		state.Targets = make(map[string]*Target)
+	}
+	return state, nil
+}
+
+// Target returns the state of a destination, creating it if needed
+func (s *State) Target(key string) *Target {
+	t, ok := s.Targets[key]
+	if !ok {
+		t = &Target{}
+		s.Targets[key] = t
+	}
+	return t
+}
+
+// Save writes the state file
+func (s *State) Save(path string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create notification state directory: %w", err)
+	}
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal notification state: %w", err)
+	}
+	return os.WriteFile(path, data, 0600)
+}
+
+// postJSON posts body and returns the response body of a 2xx response
+func postJSON(ctx context.Context, client *http.Client, url, token string, body interface{}) ([]byte, error) {
+	data, err := json.Marshal(body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode message: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("failed to build request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json; charset=utf-8")
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	respBody, _ := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return nil, fmt.Errorf("status %d: %s", resp.StatusCode, bytes.TrimSpace(respBody))
+	}
+	return respBody, nil
+}
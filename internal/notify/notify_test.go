@@ -0,0 +1,102 @@
+package notify
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func failedRun() Run {
+	return Run{
+		Name: "shop", RunID: "run-1", Total: 4, Passed: 1, Failed: 2, Quarantined: 1,
+		Duration: 90 * time.Second, ReportURL: "https://reports.example.com/run-1",
+		Failures: []Failure{
+			{App: "Checkout", Error: "Action 'pay' failed: <timeout>", Category: "functional", Screenshot: "https://cdn.example.com/1.png"},
+			{App: "Search", Error: "slow", Category: "performance"},
+			{App: "Admin", Error: "SSO down", Quarantined: true},
+		},
+	}
+}
+
+// TestRun_Status tests the success, warning and failure statuses
+func TestRun_Status(t *testing.T) {
+	assert.Equal(t, "failure", failedRun().status())
+	assert.Equal(t, "warning", Run{Total: 2, Passed: 1, Quarantined: 1, Success: true}.status())
+	assert.Equal(t, "success", Run{Total: 2, Passed: 2, Success: true}.status())
+	assert.Equal(t, "shop failed: 2 of 4 app(s) in 1m30s", failedRun().headline())
+}
+
+// TestState tests loading, updating and saving the state file
+func TestState(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "nested", StateFileName)
+	state, err := LoadState(path)
+	require.NoError(t, err)
+	assert.Empty(t, state.Targets)
+
+	passed := true
+	target := state.Target("slack/abc/shop")
+	target.LastSuccess = &passed
+	target.Thread = &Thread{TS: "1700000000.000100", StartedAt: time.Date(2026, 5, 4, 10, 0, 0, 0, time.UTC)}
+	assert.Same(t, target, state.Target("slack/abc/shop"))
+	require.NoError(t, state.Save(path))
+
+	loaded, err := LoadState(path)
+	require.NoError(t, err)
+	assert.Equal(t, "1700000000.000100", loaded.Target("slack/abc/shop").Thread.TS)
+	assert.True(t, *loaded.Target("slack/abc/shop").LastSuccess)
+
+	require.NoError(t, os.WriteFile(path, []byte("{"), 0600))
+	_, err = LoadState(path)
+	assert.ErrorContains(t, err, "invalid notification state")
+}
+
+// TestFormatTeams tests the Adaptive Card layout
+func TestFormatTeams(t *testing.T) {
+	msg := FormatTeams(failedRun(), 2)
+	data, err := json.Marshal(msg)
+	require.NoError(t, err)
+
+	var decoded struct {
+		Attachments []struct {
+			ContentType string `json:"contentType"`
+			Content     struct {
+				Type    string                   `json:"type"`
+				Body    []map[string]interface{} `json:"body"`
+				Actions []map[string]string      `json:"actions"`
+			} `json:"content"`
+		} `json:"attachments"`
+	}
+	require.NoError(t, json.Unmarshal(data, &decoded))
+	require.Len(t, decoded.Attachments, 1)
+	card := decoded.Attachments[0].Content
+	assert.Equal(t, "application/vnd.microsoft.card.adaptive", decoded.Attachments[0].ContentType)
+	assert.Equal(t, "AdaptiveCard", card.Type)
+	assert.Equal(t, "Attention", card.Body[0]["color"])
+	assert.Equal(t, "https://reports.example.com/run-1", card.Actions[0]["url"])
+
+	// headline, facts, two failures, "more", run ID
+	require.Len(t, card.Body, 6)
+	assert.Contains(t, string(data), `"url":"https://cdn.example.com/1.png"`)
+	assert.Equal(t, "…and 1 more failure(s)", card.Body[4]["text"])
+}
+
+// TestRun_Signature tests the branding line closing Slack and Teams messages
+func TestRun_Signature(t *testing.T) {
+	run := failedRun()
+	run.Organization, run.Footer = "Acme <QA>", "Internal use only"
+	assert.Equal(t, "Acme <QA> · Internal use only", run.signature())
+
+	blocks := FormatSlack(run, 2).Attachments[0].Blocks
+	assert.Equal(t, "Acme &lt;QA&gt; · Internal use only", blocks[len(blocks)-1]["elements"].([]map[string]string)[0]["text"])
+	body := FormatTeams(run, 2)["attachments"].([]map[string]interface{})[0]["content"].(map[string]interface{})["body"].([]map[string]interface{})
+	assert.Equal(t, "Acme <QA> · Internal use only", body[len(body)-1]["text"])
+
+	assert.Equal(t, "Acme", Run{Organization: "Acme"}.signature())
+	assert.Equal(t, "Internal use only", Run{Footer: "Internal use only"}.signature())
+	assert.Empty(t, failedRun().signature())
+}
@@ -0,0 +1,181 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// DefaultSlackAPIURL is the Slack Web API endpoint
+const DefaultSlackAPIURL = "https://slack.com/api"
+
+// Slack attachment colors by run status
+var slackColors = map[string]string{
+	"success": "#2eb886",
+	"warning": "#ecb22e",
+	"failure": "#e01e5a",
+}
+
+// SlackMessage is a chat.postMessage or incoming webhook payload
+type SlackMessage struct {
+	Channel        string            `json:"channel,omitempty"`
+	Text           string            `json:"text"`
+	ThreadTS       string            `json:"thread_ts,omitempty"`
+	ReplyBroadcast bool              `json:"reply_broadcast,omitempty"`
+	Attachments    []SlackAttachment `json:"attachments"`
+}
+
+// SlackAttachment carries the color bar and the Block Kit blocks
+type SlackAttachment struct {
+	Color  string                   `json:"color"`
+	Blocks []map[string]interface{} `json:"blocks"`
+}
+
+// FormatSlack builds a color-coded summary listing up to maxFailures failures
+func FormatSlack(run Run, maxFailures int) SlackMessage {
+	if maxFailures <= 0 {
+		maxFailures = DefaultMaxFailures
+	}
+	icon := map[string]string{"success": ":white_check_mark:", "warning": ":warning:", "failure": ":x:"}[run.status()]
+	blocks := []map[string]interface{}{
+		slackSection(fmt.Sprintf("%s *%s*", icon, slackEscape(run.headline()))),
+		{"type": "section", "fields": []map[string]string{
+			slackText(fmt.Sprintf("*Passed*\n%d", run.Passed)),
+			slackText(fmt.Sprintf("*Failed*\n%d", run.Failed)),
+			slackText(fmt.Sprintf("*Warnings*\n%d", run.Warnings)),
+			slackText(fmt.Sprintf("*Quarantined*\n%d", run.Quarantined)),
+		}},
+	}
+
+	for i, f := range run.Failures {
+		if i == maxFailures {
+			blocks = append(blocks, slackContext(fmt.Sprintf("…and %d more failure(s)", len(run.Failures)-maxFailures)))
+			break
+		}
+		text := "*" + slackEscape(f.App) + "*" + failureNote(f) + "\n```" + slackEscape(truncate(f.Error, 500)) + "```"
+		section := slackSection(text)
+		if f.Screenshot != "" {
+			section["accessory"] = map[string]string{"type": "image", "image_url": f.Screenshot, "alt_text": "Screenshot of " + f.App}
+		}
+		blocks = append(blocks, section)
+	}
+
+	if run.ReportURL != "" {
+		blocks = append(blocks, map[string]interface{}{"type": "actions", "elements": []map[string]interface{}{{
+			"type": "button",
+			"text": map[string]string{"type": "plain_text", "text": "Open report"},
+			"url":  run.ReportURL,
+		}}})
+	}
+	if run.RunID != "" {
+		blocks = append(blocks, slackContext("Run ID `"+run.RunID+"`"))
+	}
+	if signature := run.signature(); signature != "" {
+		blocks = append(blocks, slackContext(slackEscape(signature)))
+	}
+
+	return SlackMessage{
+		Text:        run.headline(),
+		Attachments: []SlackAttachment{{Color: slackColors[run.status()], Blocks: blocks}},
+	}
+}
+
+func slackText(text string) map[string]string {
+	return map[string]string{"type": "mrkdwn", "text": text}
+}
+
+func slackSection(text string) map[string]interface{} {
+	return map[string]interface{}{"type": "section", "text": slackText(text)}
+}
+
+func slackContext(text string) map[string]interface{} {
+	return map[string]interface{}{"type": "context", "elements": []map[string]string{slackText(text)}}
+}
+
+// slackEscape escapes the characters Slack treats as control sequences
+func slackEscape(s string) string {
+	return strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;").Replace(s)
+}
+
+func failureNote(f Failure) string {
+	var notes []string
+	if f.Category != "" {
+		notes = append(notes, f.Category)
+	}
+	if f.Quarantined {
+		notes = append(notes, "quarantined")
+	} else if f.Warning {
+		notes = append(notes, "warning")
+	}
+	if len(notes) == 0 {
+		return ""
+	}
+	return " (" + strings.Join(notes, ", ") + ")"
+}
+
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	return s[:n] + "…"
+}
+
+// Slack posts messages through an incoming webhook, or with a bot token via
+// chat.postMessage. Only token posting returns the message timestamp that
+// threads replies.
+type Slack struct {
+	WebhookURL string
+	Token      string
+	Channel    string
+	APIURL     string
+	HTTPClient *http.Client
+}
+
+// NewSlack creates a Slack poster; set either webhookURL, or token and channel
+func NewSlack(webhookURL, token, channel string) *Slack {
+	return &Slack{
+		WebhookURL: webhookURL,
+		Token:      token,
+		Channel:    channel,
+		APIURL:     DefaultSlackAPIURL,
+		HTTPClient: &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+// CanThread reports whether posted messages can be replied to
+func (s *Slack) CanThread() bool {
+	return s.Token != ""
+}
+
+// Post sends a message and returns its timestamp (empty for webhooks)
+func (s *Slack) Post(ctx context.Context, msg SlackMessage) (string, error) {
+	if s.Token == "" {
+		msg.ThreadTS = ""
+		msg.ReplyBroadcast = false
+		if _, err := postJSON(ctx, s.HTTPClient, s.WebhookURL, "", msg); err != nil {
+			return "", fmt.Errorf("slack webhook: %w", err)
+		}
+		return "", nil
+	}
+
+	msg.Channel = s.Channel
+	body, err := postJSON(ctx, s.HTTPClient, strings.TrimRight(s.APIURL, "/")+"/chat.postMessage", s.Token, msg)
+	if err != nil {
+		return "", fmt.Errorf("slack chat.postMessage: %w", err)
+	}
+	var resp struct {
+		OK    bool   `json:"ok"`
+		TS    string `json:"ts"`
+		Error string `json:"error"`
+	}
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return "", fmt.Errorf("slack chat.postMessage: invalid response: %w", err)
+	}
+	if !resp.OK {
+		return "", fmt.Errorf("slack chat.postMessage: %s", resp.Error)
+	}
+	return resp.TS, nil
+}
@@ -0,0 +1,94 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestFormatSlack tests colors, failure sections and thumbnails
+func TestFormatSlack(t *testing.T) {
+	msg := FormatSlack(failedRun(), 2)
+	assert.Equal(t, "shop failed: 2 of 4 app(s) in 1m30s", msg.Text)
+	require.Len(t, msg.Attachments, 1)
+	assert.Equal(t, slackColors["failure"], msg.Attachments[0].Color)
+
+	data, err := json.Marshal(msg)
+	require.NoError(t, err)
+	text := string(data)
+	assert.Contains(t, text, `"image_url":"https://cdn.example.com/1.png"`)
+	assert.Contains(t, msg.Attachments[0].Blocks[2]["text"].(map[string]string)["text"], "&lt;timeout&gt;", "Slack control characters are escaped")
+	assert.Contains(t, text, "…and 1 more failure(s)")
+	assert.Contains(t, text, `"url":"https://reports.example.com/run-1"`)
+	assert.Contains(t, text, "(performance)")
+
+	ok := FormatSlack(Run{Total: 1, Passed: 1, Success: true}, 0)
+	assert.Equal(t, slackColors["success"], ok.Attachments[0].Color)
+	assert.NotContains(t, mustJSON(t, ok), "actions", "no report button without a report URL")
+}
+
+func mustJSON(t *testing.T, v interface{}) string {
+	data, err := json.Marshal(v)
+	require.NoError(t, err)
+	return string(data)
+}
+
+// TestSlack_Post tests webhook and chat.postMessage posting
+func TestSlack_Post(t *testing.T) {
+	var got []map[string]interface{}
+	var auth []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]interface{}
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		got = append(got, body)
+		auth = append(auth, r.Header.Get("Authorization"))
+		switch r.URL.Path {
+		case "/hook":
+			fmt.Fprint(w, "ok")
+		case "/api/chat.postMessage":
+			if body["channel"] == "C404" {
+				fmt.Fprint(w, `{"ok":false,"error":"channel_not_found"}`)
+				return
+			}
+			fmt.Fprint(w, `{"ok":true,"ts":"1700000000.000100"}`)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			fmt.Fprint(w, "no_service")
+		}
+	}))
+	defer server.Close()
+
+	msg := FormatSlack(failedRun(), 0)
+	msg.ThreadTS = "1.2"
+
+	webhook := NewSlack(server.URL+"/hook", "", "")
+	assert.False(t, webhook.CanThread())
+	ts, err := webhook.Post(context.Background(), msg)
+	require.NoError(t, err)
+	assert.Empty(t, ts)
+	assert.NotContains(t, got[0], "thread_ts", "webhooks can't thread")
+
+	bot := NewSlack("", "xoxb-1", "C123")
+	bot.APIURL = server.URL + "/api"
+	ts, err = bot.Post(context.Background(), msg)
+	require.NoError(t, err)
+	assert.Equal(t, "1700000000.000100", ts)
+	assert.Equal(t, "C123", got[1]["channel"])
+	assert.Equal(t, "1.2", got[1]["thread_ts"])
+	assert.Equal(t, "Bearer xoxb-1", auth[1])
+
+	bot.Channel = "C404"
+	_, err = bot.Post(context.Background(), msg)
+	assert.EqualError(t, err, "slack chat.postMessage: channel_not_found")
+
+	_, err = NewSlack(server.URL+"/gone", "", "").Post(context.Background(), msg)
+	require.Error(t, err)
+	assert.True(t, strings.HasPrefix(err.Error(), "slack webhook: status 404"))
+}
@@ -0,0 +1,95 @@
+package notify
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// Adaptive Card text colors by run status
+var teamsColors = map[string]string{
+	"success": "Good",
+	"warning": "Warning",
+	"failure": "Attention",
+}
+
+// FormatTeams builds an Adaptive Card message listing up to maxFailures
+// failures, for Teams incoming webhooks and Workflows
+func FormatTeams(run Run, maxFailures int) map[string]interface{} {
+	if maxFailures <= 0 {
+		maxFailures = DefaultMaxFailures
+	}
+	body := []map[string]interface{}{
+		{"type": "TextBlock", "text": run.headline(), "weight": "Bolder", "size": "Medium", "wrap": true, "color": teamsColors[run.status()]},
+		{"type": "FactSet", "facts": []map[string]string{
+			{"title": "Passed", "value": fmt.Sprint(run.Passed)},
+			{"title": "Failed", "value": fmt.Sprint(run.Failed)},
+			{"title": "Warnings", "value": fmt.Sprint(run.Warnings)},
+			{"title": "Quarantined", "value": fmt.Sprint(run.Quarantined)},
+		}},
+	}
+
+	for i, f := range run.Failures {
+		if i == maxFailures {
+			body = append(body, map[string]interface{}{"type": "TextBlock", "text": fmt.Sprintf("…and %d more failure(s)", len(run.Failures)-maxFailures), "isSubtle": true})
+			break
+		}
+		text := []map[string]interface{}{
+			{"type": "TextBlock", "text": f.App + failureNote(f), "weight": "Bolder", "wrap": true},
+			{"type": "TextBlock", "text": truncate(f.Error, 500), "wrap": true, "fontType": "Monospace", "size": "Small"},
+		}
+		columns := []map[string]interface{}{{"type": "Column", "width": "stretch", "items": text}}
+		if f.Screenshot != "" {
+			columns = append([]map[string]interface{}{{"type": "Column", "width": "auto", "items": []map[string]interface{}{
+				{"type": "Image", "url": f.Screenshot, "size": "Medium", "altText": "Screenshot of " + f.App},
+			}}}, columns...)
+		}
+		body = append(body, map[string]interface{}{"type": "ColumnSet", "separator": true, "columns": columns})
+	}
+	if run.RunID != "" {
+		body = append(body, map[string]interface{}{"type": "TextBlock", "text": "Run ID " + run.RunID, "isSubtle": true, "size": "Small"})
+	}
+	if signature := run.signature(); signature != "" {
+		body = append(body, map[string]interface{}{"type": "TextBlock", "text": signature, "isSubtle": true, "size": "Small", "wrap": true})
+	}
+
+	card := map[string]interface{}{
+		"$schema": "http://adaptivecards.io/schemas/adaptive-card.json",
+		"type":    "AdaptiveCard",
+		"version": "1.4",
+		"msteams": map[string]string{"width": "Full"},
+		"body":    body,
+	}
+	if run.ReportURL != "" {
+		card["actions"] = []map[string]string{{"type": "Action.OpenUrl", "title": "Open report", "url": run.ReportURL}}
+	}
+	return map[string]interface{}{
+		"type":    "message",
+		"summary": run.headline(),
+		"attachments": []map[string]interface{}{{
+			"contentType": "application/vnd.microsoft.card.adaptive",
+			"content":     card,
+		}},
+	}
+}
+
+// Teams posts cards to an incoming webhook or Workflows URL. Webhooks can't
+// reply to earlier messages, so Teams notifications are not threaded.
+type Teams struct {
+	WebhookURL string
+	HTTPClient *http.Client
+}
+
+// NewTeams creates a Teams poster
+func NewTeams(webhookURL string) *Teams {
+	return &Teams{WebhookURL: webhookURL, HTTPClient: &http.Client{Timeout: 30 * time.Second}}
+}
+
+// Post sends a message built by FormatTeams
+func (t *Teams) Post(ctx context.Context, msg map[string]interface{}) error {
+	if _, err := postJSON(ctx, t.HTTPClient, t.WebhookURL, "", msg); err != nil {
+		return fmt.Errorf("teams webhook: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,28 @@
+package notify
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestTeams_Post tests webhook posting and errors
+func TestTeams_Post(t *testing.T) {
+	var contentType string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		contentType = r.Header.Get("Content-Type")
+		if r.URL.Path == "/bad" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer server.Close()
+
+	assert.NoError(t, NewTeams(server.URL+"/hook").Post(context.Background(), FormatTeams(failedRun(), 0)))
+	assert.Contains(t, contentType, "application/json")
+	assert.ErrorContains(t, NewTeams(server.URL+"/bad").Post(context.Background(), FormatTeams(failedRun(), 0)), "teams webhook: status 400")
+}
@@ -0,0 +1,54 @@
+package pdf
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLexer_Object(t *testing.T) {
+	l := &lexer{data: []byte(`<< /Name#20One (a\(b\)\n\101\
+c) /Hex <48 656C6C6F> /Ref 12 0 R /List [1 -2.5 /X true null] % comment
+/Nested << /K (v) >> >> 7 Tj`)}
+	obj, err := l.object()
+	require.NoError(t, err)
+	assert.Equal(t, dict{
+		"Name One": "a(b)\nAc",
+		"Hex":      "Hello",
+		"Ref":      ref{12},
+		"List":     array{1.0, -2.5, name("X"), true, nil},
+		"Nested":   dict{"K": "v"},
+	}, obj)
+
+	obj, err = l.object()
+	require.NoError(t, err)
+	assert.Equal(t, 7.0, obj)
+	obj, err = l.object()
+	require.NoError(t, err)
+	assert.Equal(t, keyword("Tj"), obj)
+	_, err = l.object()
+	assert.Equal(t, errEOF, err)
+}
+
+// TestLexer_Stream tests streams with a correct Length, and with a wrong or
+// indirect one that makes the lexer search for endstream
+func TestLexer_Stream(t *testing.T) {
+	for _, data := range []string{
+		"<< /Length 5 >>\nstream\nhello\nendstream",
+		"<< /Length 3 >>\r\nstream\r\nhello\r\nendstream",
+		"<< /Length 9 0 R >>\nstream\nhello\nendstream",
+	} {
+		l := &lexer{data: []byte(data)}
+		obj, err := l.object()
+		require.NoError(t, err, data)
+		s, ok := obj.(*stream)
+		require.True(t, ok, data)
+		assert.Equal(t, "hello", string(s.data), data)
+	}
+
+	_, err := (&lexer{data: []byte("<< /Length 5 >>\nstream\nhello")}).object()
+	assert.EqualError(t, err, "unterminated stream")
+	_, err = (&lexer{data: []byte("(open")}).object()
+	assert.EqualError(t, err, "unterminated string")
+}
@@ -0,0 +1,296 @@
+// Package pdf reads the pages and text of PDF documents, enough to assert on
+// the invoices and reports a run prints or downloads. It reads classic files
+// and files with object streams, Flate, ASCIIHex and ASCII85 streams, and
+// text in fonts with ToUnicode maps or single-byte encodings. Encrypted
+// documents aren't supported.
+package pdf
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/ascii85"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// maxDepth bounds the nesting of page trees and form XObjects followed
+const maxDepth = 32
+
+var errEOF = errors.New("unexpected end of data")
+
+// objectHeader matches "n g obj"
+var objectHeader = regexp.MustCompile(`(\d+)\s+\d+\s+obj\b`)
+
+// Document is a parsed PDF
+type Document struct {
+	Pages []Page
+}
+
+// Page is a page's text, lines separated by newlines
+type Page struct {
+	Text string
+}
+
+// Text is the text of every page, separated by form feeds
+func (d *Document) Text() string {
+	texts := make([]string, len(d.Pages))
+	for i, p := range d.Pages {
+		texts[i] = p.Text
+	}
+	return strings.Join(texts, "\f")
+}
+
+// ReadFile parses the PDF at path
+func ReadFile(path string) (*Document, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	doc, err := Parse(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return doc, nil
+}
+
+// file is the objects of a PDF by number
+type file struct {
+	objects map[int]interface{}
+	root    interface{} // the trailer's Root
+	fonts   map[int]*font
+}
+
+// Parse reads a PDF document
+func Parse(data []byte) (*Document, error) {
+	if !bytes.HasPrefix(bytes.TrimLeft(data, " \r\n\t"), []byte("%PDF-")) {
+		return nil, fmt.Errorf("not a PDF document")
+	}
+	f, err := parseFile(data)
+	if err != nil {
+		return nil, err
+	}
+	catalog, _ := f.resolve(f.root).(dict)
+	if catalog["Pages"] == nil {
+		catalog = nil
+		for _, obj := range f.objects {
+			if d, ok := obj.(dict); ok && d["Type"] == name("Catalog") {
+				catalog = d
+				break
+			}
+		}
+	}
+	if catalog == nil {
+		return nil, fmt.Errorf("no document catalog")
+	}
+	var pages []dict
+	f.collectPages(catalog["Pages"], nil, &pages, 0, make(map[int]bool))
+	doc := &Document{Pages: make([]Page, len(pages))}
+	for i, page := range pages {
+		text, err := f.pageText(page)
+		if err != nil {
+			return nil, fmt.Errorf("page %d: %w", i+1, err)
+		}
+		doc.Pages[i] = Page{Text: text}
+	}
+	return doc, nil
+}
+
+// parseFile reads every "n g obj" of the file in order, so objects of later
+// incremental updates replace earlier ones, then the objects in its object
+// streams that aren't defined directly
+func parseFile(data []byte) (*file, error) {
+	f := &file{objects: make(map[int]interface{})}
+	end := 0
+	for _, m := range objectHeader.FindAllSubmatchIndex(data, -1) {
+		if m[0] < end || (m[0] > 0 && !isSpace(data[m[0]-1]) && !isDelimiter(data[m[0]-1])) {
+			continue // inside the previous object, or part of a longer number
+		}
+		var n int
+		fmt.Sscan(string(data[m[2]:m[3]]), &n)
+		l := &lexer{data: data, pos: m[1]}
+		obj, err := l.object()
+		if err != nil {
+			continue
+		}
+		f.objects[n] = obj
+		end = l.pos
+		if s, ok := obj.(*stream); ok {
+			if s.dict["Type"] == name("XRef") {
+				if s.dict["Encrypt"] != nil {
+					return nil, fmt.Errorf("encrypted documents are not supported")
+				}
+				if s.dict["Root"] != nil {
+					f.root = s.dict["Root"]
+				}
+			}
+		}
+	}
+	if i := bytes.LastIndex(data, []byte("trailer")); i >= 0 {
+		l := &lexer{data: data, pos: i + len("trailer")}
+		if trailer, err := l.object(); err == nil {
+			if d, ok := trailer.(dict); ok {
+				if d["Encrypt"] != nil {
+					return nil, fmt.Errorf("encrypted documents are not supported")
+				}
+				if d["Root"] != nil {
+					f.root = d["Root"]
+				}
+			}
+		}
+	}
+
+	direct := make(map[int]bool, len(f.objects))
+	numbers := make([]int, 0, len(f.objects))
+	for n := range f.objects {
+		direct[n] = true
+		numbers = append(numbers, n)
+	}
+	sort.Ints(numbers)
+	for _, n := range numbers {
+		s, ok := f.objects[n].(*stream)
+		if !ok || s.dict["Type"] != name("ObjStm") {
+			continue
+		}
+		data, err := f.decode(s)
+		if err != nil {
+			continue
+		}
+		count, _ := f.resolve(s.dict["N"]).(float64)
+		first, _ := f.resolve(s.dict["First"]).(float64)
+		header := &lexer{data: data}
+		for i := 0; i < int(count); i++ {
+			num, err1 := header.object()
+			offset, err2 := header.object()
+			n, ok1 := num.(float64)
+			o, ok2 := offset.(float64)
+			if err1 != nil || err2 != nil || !ok1 || !ok2 {
+				break
+			}
+			if direct[int(n)] || int(first+o) >= len(data) {
+				continue
+			}
+			l := &lexer{data: data, pos: int(first + o)}
+			if obj, err := l.object(); err == nil {
+				f.objects[int(n)] = obj
+			}
+		}
+	}
+	return f, nil
+}
+
+// resolve follows references
+func (f *file) resolve(obj interface{}) interface{} {
+	for i := 0; i < maxDepth; i++ {
+		r, ok := obj.(ref)
+		if !ok {
+			return obj
+		}
+		obj = f.objects[r.n]
+	}
+	return nil
+}
+
+// dictOf resolves obj to a dictionary, or a stream's dictionary
+func (f *file) dictOf(obj interface{}) dict {
+	switch v := f.resolve(obj).(type) {
+	case dict:
+		return v
+	case *stream:
+		return v.dict
+	}
+	return nil
+}
+
+// collectPages walks the page tree in order, passing inherited resources
+// down to the pages
+func (f *file) collectPages(node interface{}, resources interface{}, pages *[]dict, depth int, seen map[int]bool) {
+	if r, ok := node.(ref); ok {
+		if seen[r.n] {
+			return
+		}
+		seen[r.n] = true
+	}
+	d := f.dictOf(node)
+	if d == nil || depth > maxDepth {
+		return
+	}
+	if d["Resources"] != nil {
+		resources = d["Resources"]
+	}
+	kids, isTree := f.resolve(d["Kids"]).(array)
+	if d["Type"] == name("Page") || !isTree {
+		if d["Type"] == name("Page") || d["Contents"] != nil {
+			page := make(dict, len(d)+1)
+			for k, v := range d {
+				page[k] = v
+			}
+			page["Resources"] = resources
+			*pages = append(*pages, page)
+		}
+		return
+	}
+	for _, kid := range kids {
+		f.collectPages(kid, resources, pages, depth+1, seen)
+	}
+}
+
+// decode applies a stream's filters
+func (f *file) decode(s *stream) ([]byte, error) {
+	var filters []interface{}
+	switch v := f.resolve(s.dict["Filter"]).(type) {
+	case name:
+		filters = []interface{}{v}
+	case array:
+		filters = v
+	}
+	data := s.data
+	for _, filter := range filters {
+		var err error
+		switch f.resolve(filter) {
+		case name("FlateDecode"), name("Fl"):
+			data, err = inflate(data)
+		case name("ASCIIHexDecode"), name("AHx"):
+			hexDigits := bytes.Map(func(r rune) rune {
+				if isSpace(byte(r)) {
+					return -1
+				}
+				return r
+			}, bytes.TrimSuffix(bytes.TrimSpace(data), []byte(">")))
+			if len(hexDigits)%2 == 1 {
+				hexDigits = append(hexDigits, '0')
+			}
+			data, err = hex.DecodeString(string(hexDigits))
+		case name("ASCII85Decode"), name("A85"):
+			encoded := bytes.TrimSuffix(bytes.TrimSpace(bytes.TrimPrefix(bytes.TrimSpace(data), []byte("<~"))), []byte("~>"))
+			decoded := make([]byte, 4*len(encoded)+4) // z expands to four bytes
+			var n int
+			n, _, err = ascii85.Decode(decoded, encoded, true)
+			data = decoded[:n]
+		default:
+			return nil, fmt.Errorf("unsupported stream filter %v", filter)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	return data, nil
+}
+
+// inflate decompresses zlib data, keeping what was read when it's truncated
+func inflate(data []byte) ([]byte, error) {
+	r, err := zlib.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("invalid Flate stream: %w", err)
+	}
+	out, err := io.ReadAll(r)
+	if err != nil && len(out) == 0 {
+		return nil, fmt.Errorf("invalid Flate stream: %w", err)
+	}
+	return out, nil
+}
@@ -0,0 +1,135 @@
+package pdf
+
+import (
+	"bytes"
+	"compress/zlib"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// buildPDF writes objects numbered from 1, the catalog first, with a
+// cross-reference table and trailer
+func buildPDF(objects ...string) []byte {
+	var b bytes.Buffer
+	b.WriteString("%PDF-1.7\n%\xe2\xe3\xcf\xd3\n")
+	offsets := make([]int, len(objects))
+	for i, obj := range objects {
+		offsets[i] = b.Len()
+		fmt.Fprintf(&b, "%d 0 obj\n%s\nendobj\n", i+1, obj)
+	}
+	xref := b.Len()
+	fmt.Fprintf(&b, "xref\n0 %d\n0000000000 65535 f \n", len(objects)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(&b, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(&b, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(objects)+1, xref)
+	return b.Bytes()
+}
+
+// flateStream is a Flate-compressed stream of data with extra dictionary
+// entries
+func flateStream(data, entries string) string {
+	var b bytes.Buffer
+	w := zlib.NewWriter(&b)
+	w.Write([]byte(data))
+	w.Close()
+	return fmt.Sprintf("<< /Length %d /Filter /FlateDecode %s >>\nstream\n%s\nendstream", b.Len(), entries, b.String())
+}
+
+const toUnicode = `/CIDInit /ProcSet findresource begin
+12 dict begin
+begincmap
+1 begincodespacerange
+<0000> <FFFF>
+endcodespacerange
+2 beginbfchar
+<0003> <0020>
+<0010> <00DC>
+endbfchar
+1 beginbfrange
+<0020> <0039> <0061>
+endbfrange
+endcmap
+end end`
+
+// invoicePDF has two pages in a nested page tree: one in a standard font
+// and one in a composite font with a ToUnicode map, drawn by a form XObject
+func invoicePDF() []byte {
+	return buildPDF(
+		"<< /Type /Catalog /Pages 2 0 R >>",
+		"<< /Type /Pages /Kids [3 0 R 4 0 R] /Count 2 /Resources << /Font << /F1 6 0 R /F2 7 0 R >> >> >>",
+		"<< /Type /Page /Parent 2 0 R /MediaBox [0 0 612 792] /Contents 5 0 R >>",
+		"<< /Type /Pages /Parent 2 0 R /Kids [8 0 R] /Count 1 >>",
+		flateStream("BT /F1 18 Tf 72 720 Td (Invoice #1042) Tj 0 -24 Td [(T) 80 (otal:) -250 (\\20042.50)] TJ ET\n"+
+			"BT /F1 12 Tf 1 0 0 1 72 600 Tm (Thanks \\(really\\)) Tj T* (Caf\\351 \\226 paid) Tj ET", ""),
+		"<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica >>",
+		"<< /Type /Font /Subtype /Type0 /BaseFont /Roboto /Encoding /Identity-H /ToUnicode 10 0 R >>",
+		"<< /Type /Page /Parent 4 0 R /Contents [9 0 R] /Resources << /Font << /F2 7 0 R >> /XObject << /X1 11 0 R >> >> >>",
+		flateStream("q /X1 Do Q BI /W 2 /H 1 /BPC 8 /CS /G ID \x00EI EI\nBT /F2 12 Tf 72 700 Td <00100021002400310003002000 2B002B> Tj ET", ""),
+		flateStream(toUnicode, ""),
+		flateStream("BT /F2 10 Tf 72 740 Td <0027002000320027> Tj ET", "/Type /XObject /Subtype /Form /BBox [0 0 612 792]"),
+	)
+}
+
+func TestParse(t *testing.T) {
+	doc, err := Parse(invoicePDF())
+	require.NoError(t, err)
+	require.Len(t, doc.Pages, 2)
+	assert.Equal(t, "Invoice #1042\nTotal: €42.50\nThanks (really)\nCafé – paid", doc.Pages[0].Text)
+	assert.Equal(t, "hash\nÜber all", doc.Pages[1].Text)
+	assert.Contains(t, doc.Text(), "paid\fhash")
+}
+
+// TestParse_ObjectStreams tests documents whose objects are compressed into
+// object streams, with the root in a cross-reference stream
+func TestParse_ObjectStreams(t *testing.T) {
+	objects := []string{
+		"<< /Type /Catalog /Pages 3 0 R >>",
+		"<< /Type /Pages /Kids [4 0 R] /Count 1 >>",
+		"<< /Type /Page /Parent 3 0 R /Contents 5 0 R /Resources << >> >>",
+	}
+	var header, body string
+	for i, obj := range objects {
+		header += fmt.Sprintf("%d %d ", i+2, len(body))
+		body += obj + " "
+	}
+	data := buildPDF(
+		flateStream(header+body, fmt.Sprintf("/Type /ObjStm /N 3 /First %d", len(header))),
+		"null",
+		"null",
+		"null",
+		flateStream("BT (Report ready) Tj ET", ""),
+		"<< /Type /XRef /Size 7 /Root 2 0 R /W [1 2 1] /Length 0 >>\nstream\n\nendstream", // stands in for the real table
+	)
+	for _, n := range []string{"2", "3", "4"} {
+		data = bytes.Replace(data, []byte(n+" 0 obj\nnull"), []byte("% 0 obj\nnull"), 1)
+	}
+	data = bytes.Replace(data, []byte("trailer"), []byte("%"), 1)
+
+	doc, err := Parse(data)
+	require.NoError(t, err)
+	require.Len(t, doc.Pages, 1)
+	assert.Equal(t, "Report ready", doc.Pages[0].Text)
+}
+
+func TestParse_Errors(t *testing.T) {
+	_, err := Parse([]byte("<html></html>"))
+	assert.EqualError(t, err, "not a PDF document")
+
+	encrypted := bytes.Replace(invoicePDF(), []byte("/Root 1 0 R"), []byte("/Root 1 0 R /Encrypt << /Filter /Standard >>"), 1)
+	_, err = Parse(encrypted)
+	assert.EqualError(t, err, "encrypted documents are not supported")
+
+	_, err = Parse(buildPDF("<< /Type /Pages /Kids [] >>"))
+	assert.EqualError(t, err, "no document catalog")
+
+	path := filepath.Join(t.TempDir(), "invoice.pdf")
+	require.NoError(t, os.WriteFile(path, []byte("%PDF-1.4\n1 0 obj << /Type /Catalog /Pages 2 0 R >> endobj\n2 0 obj << /Type /Pages /Kids [3 0 R] >> endobj\n3 0 obj << /Type /Page /Contents 4 0 R >> endobj\n4 0 obj << /Filter /LZWDecode /Length 1 >> stream\nx\nendstream endobj"), 0644))
+	_, err = ReadFile(path)
+	assert.ErrorContains(t, err, "invoice.pdf: page 1: unsupported stream filter LZWDecode")
+}
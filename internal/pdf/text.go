@@ -0,0 +1,365 @@
+package pdf
+
+import (
+	"bytes"
+	"sort"
+	"strings"
+	"unicode/utf16"
+)
+
+// tjSpace is how far, in thousandths of an em, a TJ adjustment moves the
+// next glyph before it counts as a space between words
+const tjSpace = -200
+
+// winAnsi maps the bytes 0x80 to 0x9f of WinAnsiEncoding; the others are
+// Latin-1
+var winAnsi = []rune("€\u0081‚ƒ„…†‡ˆ‰Š‹Œ\u008dŽ\u008f\u0090‘’“”•–—˜™š›œ\u009džŸ")
+
+// font decodes the strings shown in one font
+type font struct {
+	toUnicode *cmap
+	composite bool // a Type0 font, whose codes are glyph IDs unless mapped
+}
+
+// cmap is a ToUnicode map from character codes to text
+type cmap struct {
+	widths []int // code lengths of the codespace ranges, ascending
+	chars  map[string]string
+}
+
+func (f *font) decode(s string) string {
+	if f == nil {
+		return latin1(s)
+	}
+	var out strings.Builder
+	if m := f.toUnicode; m != nil {
+		widths := m.widths
+		if len(widths) == 0 {
+			widths = []int{1}
+			if f.composite {
+				widths = []int{2}
+			}
+		}
+		for i := 0; i < len(s); {
+			step := widths[0]
+			for _, w := range widths {
+				if i+w > len(s) {
+					break
+				}
+				if text, ok := m.chars[s[i:i+w]]; ok {
+					out.WriteString(text)
+					step = w
+					break
+				}
+			}
+			i += step
+		}
+		return out.String()
+	}
+	if f.composite {
+		return "" // glyph IDs without a map can't be read
+	}
+	return latin1(s)
+}
+
+// latin1 reads a single-byte string as WinAnsiEncoding
+func latin1(s string) string {
+	runes := make([]rune, 0, len(s))
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case c >= 0x80 && c <= 0x9f:
+			runes = append(runes, winAnsi[c-0x80])
+		default:
+			runes = append(runes, rune(c))
+		}
+	}
+	return string(runes)
+}
+
+// utf16Text reads the UTF-16BE text a map assigns a code
+func utf16Text(s string) string {
+	units := make([]uint16, len(s)/2)
+	for i := range units {
+		units[i] = uint16(s[2*i])<<8 | uint16(s[2*i+1])
+	}
+	return string(utf16.Decode(units))
+}
+
+// parseCMap reads the codespace ranges and bfchar and bfrange mappings of a
+// ToUnicode map
+func parseCMap(data []byte) *cmap {
+	m := &cmap{chars: make(map[string]string)}
+	widths := make(map[int]bool)
+	l := &lexer{data: data}
+	var operands []interface{}
+	for {
+		obj, err := l.object()
+		if err != nil {
+			break
+		}
+		op, ok := obj.(keyword)
+		if !ok {
+			operands = append(operands, obj)
+			continue
+		}
+		switch op {
+		case "endcodespacerange":
+			for i := 0; i+1 < len(operands); i += 2 {
+				if lo, ok := operands[i].(string); ok && lo != "" {
+					widths[len(lo)] = true
+				}
+			}
+		case "endbfchar":
+			for i := 0; i+1 < len(operands); i += 2 {
+				src, ok1 := operands[i].(string)
+				dst, ok2 := operands[i+1].(string)
+				if ok1 && ok2 {
+					m.chars[src] = utf16Text(dst)
+				}
+			}
+		case "endbfrange":
+			for i := 0; i+2 < len(operands); i += 3 {
+				lo, ok1 := operands[i].(string)
+				hi, ok2 := operands[i+1].(string)
+				if !ok1 || !ok2 || len(lo) != len(hi) || lo == "" || len(lo) > 4 {
+					continue
+				}
+				start, end := codeValue(lo), codeValue(hi)
+				for c := start; c <= end && c-start < 1<<16; c++ {
+					code := codeString(c, len(lo))
+					switch dst := operands[i+2].(type) {
+					case string:
+						if dst == "" {
+							continue
+						}
+						// the last byte counts up through the range
+						next := []byte(dst)
+						next[len(next)-1] += byte(c - start)
+						m.chars[code] = utf16Text(string(next))
+					case array:
+						if int(c-start) < len(dst) {
+							if s, ok := dst[c-start].(string); ok {
+								m.chars[code] = utf16Text(s)
+							}
+						}
+					}
+				}
+			}
+		}
+		operands = operands[:0]
+	}
+	for w := range widths {
+		m.widths = append(m.widths, w)
+	}
+	sort.Ints(m.widths)
+	return m
+}
+
+func codeValue(s string) uint32 {
+	var v uint32
+	for i := 0; i < len(s); i++ {
+		v = v<<8 | uint32(s[i])
+	}
+	return v
+}
+
+func codeString(v uint32, width int) string {
+	b := make([]byte, width)
+	for i := width - 1; i >= 0; i-- {
+		b[i] = byte(v)
+		v >>= 8
+	}
+	return string(b)
+}
+
+// textWriter collects the text shown, separating words and lines
+type textWriter struct {
+	b    strings.Builder
+	y    float64
+	hasY bool
+}
+
+func (w *textWriter) separate(sep byte) {
+	s := w.b.String()
+	if s == "" || s[len(s)-1] == '\n' || (sep == ' ' && s[len(s)-1] == ' ') {
+		return
+	}
+	w.b.WriteByte(sep)
+}
+
+// moveTo starts text at the vertical position y: on a new line when it
+// differs from the last one, otherwise as a new word
+func (w *textWriter) moveTo(y float64) {
+	if w.hasY && (y-w.y > 0.5 || w.y-y > 0.5) {
+		w.separate('\n')
+	} else {
+		w.separate(' ')
+	}
+	w.y, w.hasY = y, true
+}
+
+// fontOf reads the font a resource dictionary names, once per font object
+func (f *file) fontOf(resources dict, fontName name) *font {
+	fonts := f.dictOf(resources["Font"])
+	obj := fonts[string(fontName)]
+	r, isRef := obj.(ref)
+	if isRef {
+		if cached, ok := f.fonts[r.n]; ok {
+			return cached
+		}
+	}
+	d := f.dictOf(obj)
+	if d == nil {
+		return nil
+	}
+	fnt := &font{composite: d["Subtype"] == name("Type0")}
+	if s, ok := f.resolve(d["ToUnicode"]).(*stream); ok {
+		if data, err := f.decode(s); err == nil {
+			fnt.toUnicode = parseCMap(data)
+		}
+	}
+	if isRef {
+		if f.fonts == nil {
+			f.fonts = make(map[int]*font)
+		}
+		f.fonts[r.n] = fnt
+	}
+	return fnt
+}
+
+// pageText reads the text a page's content streams show
+func (f *file) pageText(page dict) (string, error) {
+	var content []byte
+	contents := f.resolve(page["Contents"])
+	parts, ok := contents.(array)
+	if !ok {
+		parts = array{contents}
+	}
+	for _, part := range parts {
+		s, ok := f.resolve(part).(*stream)
+		if !ok {
+			continue
+		}
+		data, err := f.decode(s)
+		if err != nil {
+			return "", err
+		}
+		content = append(append(content, data...), '\n')
+	}
+	w := &textWriter{}
+	f.showText(content, f.dictOf(page["Resources"]), w, 0)
+
+	var lines []string
+	for _, line := range strings.Split(w.b.String(), "\n") {
+		if line = strings.Join(strings.Fields(line), " "); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return strings.Join(lines, "\n"), nil
+}
+
+// showText interprets the text operators of a content stream, following the
+// form XObjects it draws. Content it can't read ends it, keeping the text
+// read so far.
+func (f *file) showText(content []byte, resources dict, w *textWriter, depth int) {
+	if depth > maxDepth {
+		return
+	}
+	var current *font
+	var operands []interface{}
+	number := func(i int) float64 {
+		if i < len(operands) {
+			if n, ok := operands[i].(float64); ok {
+				return n
+			}
+		}
+		return 0
+	}
+	show := func(obj interface{}) {
+		switch v := obj.(type) {
+		case string:
+			w.b.WriteString(current.decode(v))
+		case array:
+			for _, item := range v {
+				switch x := item.(type) {
+				case string:
+					w.b.WriteString(current.decode(x))
+				case float64:
+					if x < tjSpace {
+						w.separate(' ')
+					}
+				}
+			}
+		}
+	}
+	last := func() interface{} {
+		if len(operands) == 0 {
+			return nil
+		}
+		return operands[len(operands)-1]
+	}
+
+	l := &lexer{data: content}
+	for {
+		obj, err := l.object()
+		if err != nil {
+			return
+		}
+		op, ok := obj.(keyword)
+		if !ok {
+			operands = append(operands, obj)
+			continue
+		}
+		switch op {
+		case "BT":
+			w.separate(' ')
+		case "Tf":
+			if len(operands) > 0 {
+				if n, ok := operands[0].(name); ok {
+					current = f.fontOf(resources, n)
+				}
+			}
+		case "Tm":
+			w.moveTo(number(5))
+		case "Td", "TD":
+			w.moveTo(w.y + number(1))
+		case "T*":
+			w.separate('\n')
+		case "Tj", "TJ":
+			show(last())
+		case "'", "\"":
+			w.separate('\n')
+			show(last())
+		case "Do":
+			if n, ok := last().(name); ok {
+				xobjects := f.dictOf(resources["XObject"])
+				if form, ok := f.resolve(xobjects[string(n)]).(*stream); ok && form.dict["Subtype"] == name("Form") {
+					if data, err := f.decode(form); err == nil {
+						inner := resources
+						if d := f.dictOf(form.dict["Resources"]); d != nil {
+							inner = d
+						}
+						f.showText(data, inner, w, depth+1)
+					}
+				}
+			}
+		case "BI":
+			// skip the binary data of inline images, up to EI
+			if i := bytes.Index(l.data[l.pos:], []byte("ID")); i >= 0 {
+				l.pos += i + 2
+				for l.pos < len(l.data) {
+					j := bytes.Index(l.data[l.pos:], []byte("EI"))
+					if j < 0 {
+						return
+					}
+					l.pos += j + 2
+					if isSpace(l.data[l.pos-3]) && (l.pos >= len(l.data) || isSpace(l.data[l.pos])) {
+						break
+					}
+				}
+			}
+		}
+		operands = operands[:0]
+	}
+}
@@ -0,0 +1,23 @@
+package pdf
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseCMap(t *testing.T) {
+	m := parseCMap([]byte(`begincmap
+2 begincodespacerange <00> <7F> <8000> <FFFF> endcodespacerange
+2 beginbfchar <41> <0041> <8001> <D83DDE00> endbfchar
+1 beginbfrange <8010> <8012> [<0066006C> <00E9> <0021>] endbfrange
+endcmap`))
+	assert.Equal(t, []int{1, 2}, m.widths)
+	f := &font{toUnicode: m, composite: true}
+	assert.Equal(t, "A😀fléA!", f.decode("A\x80\x01\x80\x10\x80\x11A\x80\x12\x80\x99"))
+
+	assert.Equal(t, "", (&font{composite: true}).decode("\x00\x10"))
+	assert.Equal(t, "“Naïve” — ok", (&font{}).decode("\x93Na\xefve\x94 \x97 ok"))
+	var unknown *font
+	assert.Equal(t, "plain", unknown.decode("plain"))
+}
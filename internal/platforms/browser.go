@@ -0,0 +1,134 @@
+package platforms
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strconv"
+	"strings"
+
+	"github.com/go-rod/rod/lib/launcher"
+)
+
+// Browser engines accepted in AppConfig.Browser
+const (
+	BrowserChromium = "chromium"
+	BrowserChrome   = "chrome"
+	BrowserEdge     = "edge"
+	BrowserFirefox  = "firefox"
+	BrowserWebKit   = "webkit"
+)
+
+// ErrBrowserEngineNotSupported is returned for engines the web platform cannot
+// drive. The web platform speaks the Chrome DevTools Protocol through go-rod,
+// so only Chromium-family browsers (chromium, chrome, edge) are launched.
+// Configuration validation rejects firefox and webkit; failing here too keeps
+// apps that skip validation from silently running them on Chromium.
+var ErrBrowserEngineNotSupported = errors.New("browser engine not supported by the CDP web platform")
+
+// browserBinaryCandidates lists executable names and install paths per engine and channel
+var browserBinaryCandidates = map[string]map[string][]string{
+	BrowserChrome: {
+		"stable": {"google-chrome", "google-chrome-stable", "chrome",
+			"/Applications/Google Chrome.app/Contents/MacOS/Google Chrome",
+			`C:\Program Files\Google\Chrome\Application\chrome.exe`},
+		"beta": {"google-chrome-beta",
+			"/Applications/Google Chrome Beta.app/Contents/MacOS/Google Chrome Beta",
+			`C:\Program Files\Google\Chrome Beta\Application\chrome.exe`},
+		"dev": {"google-chrome-unstable",
+			"/Applications/Google Chrome Dev.app/Contents/MacOS/Google Chrome Dev",
+			`C:\Program Files\Google\Chrome Dev\Application\chrome.exe`},
+		"canary": {
+			"/Applications/Google Chrome Canary.app/Contents/MacOS/Google Chrome Canary",
+			os.Getenv("LOCALAPPDATA") + `\Google\Chrome SxS\Application\chrome.exe`},
+	},
+	BrowserEdge: {
+		"stable": {"microsoft-edge", "microsoft-edge-stable",
+			"/Applications/Microsoft Edge.app/Contents/MacOS/Microsoft Edge",
+			`C:\Program Files (x86)\Microsoft\Edge\Application\msedge.exe`},
+		"beta": {"microsoft-edge-beta",
+			"/Applications/Microsoft Edge Beta.app/Contents/MacOS/Microsoft Edge Beta",
+			`C:\Program Files (x86)\Microsoft\Edge Beta\Application\msedge.exe`},
+		"dev": {"microsoft-edge-dev",
+			"/Applications/Microsoft Edge Dev.app/Contents/MacOS/Microsoft Edge Dev",
+			`C:\Program Files (x86)\Microsoft\Edge Dev\Application\msedge.exe`},
+		"canary": {
+			"/Applications/Microsoft Edge Canary.app/Contents/MacOS/Microsoft Edge Canary",
+			os.Getenv("LOCALAPPDATA") + `\Microsoft\Edge SxS\Application\msedge.exe`},
+	},
+}
+
+// lookPath is swapped in tests
+var lookPath = exec.LookPath
+
+// NormalizeBrowserEngine maps engine aliases to a canonical name; empty means chromium
+func NormalizeBrowserEngine(engine string) string {
+	switch e := strings.ToLower(strings.TrimSpace(engine)); e {
+	case "", "chromium", "chromium-browser":
+		return BrowserChromium
+	case "chrome", "google-chrome":
+		return BrowserChrome
+	case "edge", "msedge", "microsoft-edge":
+		return BrowserEdge
+	case "safari":
+		return BrowserWebKit
+	default:
+		return e
+	}
+}
+
+// ResolveBrowserBinary returns the executable for an engine/channel/version. An
+// empty path with a nil error means go-rod's default lookup (and download) applies.
+// For chromium, version is a Chromium snapshot revision that is downloaded on demand.
+func ResolveBrowserBinary(engine, channel, version string) (string, error) {
+	engine = NormalizeBrowserEngine(engine)
+	channel = strings.ToLower(strings.TrimSpace(channel))
+	if channel == "" {
+		channel = "stable"
+	}
+
+	switch engine {
+	case BrowserChromium:
+		if version == "" {
+			return "", nil
+		}
+		revision, err := strconv.Atoi(version)
+		if err != nil {
+			return "", fmt.Errorf("chromium version must be a snapshot revision number, got %q", version)
+		}
+		b := launcher.NewBrowser()
+		b.Revision = revision
+		path, err := b.Get()
+		if err != nil {
+			return "", fmt.Errorf("failed to fetch chromium revision %d: %w", revision, err)
+		}
+		return path, nil
+	case BrowserChrome, BrowserEdge:
+		if version != "" {
+			return "", fmt.Errorf("%s does not support pinning a version; select a channel instead", engine)
+		}
+		channels := browserBinaryCandidates[engine]
+		candidates, ok := channels[channel]
+		if !ok {
+			return "", fmt.Errorf("unknown %s channel: %s", engine, channel)
+		}
+		for _, candidate := range candidates {
+			if strings.ContainsAny(candidate, `/\`) {
+				if info, err := os.Stat(candidate); err == nil && !info.IsDir() {
+					return candidate, nil
+				}
+				continue
+			}
+			if path, err := lookPath(candidate); err == nil {
+				return path, nil
+			}
+		}
+		return "", fmt.Errorf("%s (%s channel) not found on this %s host", engine, channel, runtime.GOOS)
+	case BrowserFirefox, BrowserWebKit:
+		return "", fmt.Errorf("%s: %w", engine, ErrBrowserEngineNotSupported)
+	default:
+		return "", fmt.Errorf("unknown browser engine: %s", engine)
+	}
+}
@@ -0,0 +1,140 @@
+package platforms
+
+import (
+	"sync"
+
+	"github.com/go-rod/rod"
+	"github.com/go-rod/rod/lib/launcher"
+	"github.com/go-rod/rod/lib/proto"
+)
+
+// BrowserPool keeps launched browsers warm between web apps. An app takes
+// an idle browser of its engine, channel and version, or launches one, and
+// opens its own incognito context in it; closing the app hands the browser
+// back. A browser is relaunched after a number of apps so anything leaking
+// across contexts is bounded.
+type BrowserPool struct {
+	mu           sync.Mutex
+	size         int
+	recycleAfter int
+	idle         []*pooledBrowser
+	closed       bool
+	launches     int
+	reuses       int
+}
+
+// pooledBrowser is a browser process the pool owns
+type pooledBrowser struct {
+	key      string
+	browser  *rod.Browser
+	launcher *launcher.Launcher // nil for browsers connected to an existing process
+	uses     int
+	alive    func() bool
+	close    func()
+}
+
+// NewBrowserPool keeps up to size idle browsers and relaunches a browser
+// once it has run recycleAfter apps
+func NewBrowserPool(size, recycleAfter int) *BrowserPool {
+	return &BrowserPool{size: size, recycleAfter: recycleAfter}
+}
+
+// newPooledBrowser wraps a connected browser and the launcher that started it
+func newPooledBrowser(key string, browser *rod.Browser, l *launcher.Launcher) *pooledBrowser {
+	return &pooledBrowser{
+		key:      key,
+		browser:  browser,
+		launcher: l,
+		alive: func() bool {
+			_, err := proto.BrowserGetVersion{}.Call(browser)
+			return err == nil
+		},
+		close: func() {
+			browser.Close()
+			if l != nil {
+				l.Kill()
+			}
+		},
+	}
+}
+
+// acquire returns an idle browser for key that still answers, or one
+// launched with launch, and whether it was reused
+func (p *BrowserPool) acquire(key string, launch func() (*pooledBrowser, error)) (*pooledBrowser, bool, error) {
+	p.mu.Lock()
+	for i := len(p.idle) - 1; i >= 0; i-- {
+		b := p.idle[i]
+		if b.key != key {
+			continue
+		}
+		p.idle = append(p.idle[:i], p.idle[i+1:]...)
+		p.mu.Unlock()
+		// A browser can die while idle, or be killed by restart_app
+		if b.alive() {
+			p.mu.Lock()
+			p.reuses++
+			p.mu.Unlock()
+			return b, true, nil
+		}
+		b.close()
+		p.mu.Lock()
+	}
+	p.mu.Unlock()
+
+	b, err := launch()
+	if err != nil {
+		return nil, false, err
+	}
+	p.mu.Lock()
+	p.launches++
+	p.mu.Unlock()
+	return b, false, nil
+}
+
+// release hands a browser back after an app, closing it when it has run
+// its share of apps, the pool is full or the pool was closed. Browsers of
+// other engines are evicted, oldest first, to make room.
+func (p *BrowserPool) release(b *pooledBrowser) {
+	b.uses++
+	p.mu.Lock()
+	if p.closed || b.uses >= p.recycleAfter || p.size <= 0 {
+		p.mu.Unlock()
+		b.close()
+		return
+	}
+	var evicted *pooledBrowser
+	if len(p.idle) >= p.size {
+		evicted, p.idle = p.idle[0], p.idle[1:]
+	}
+	p.idle = append(p.idle, b)
+	p.mu.Unlock()
+	if evicted != nil {
+		evicted.close()
+	}
+}
+
+// discard closes a browser that failed while an app held it
+func (p *BrowserPool) discard(b *pooledBrowser) {
+	b.close()
+}
+
+// Stats returns how many browsers the pool launched and how many times an
+// app reused one
+func (p *BrowserPool) Stats() (launches, reuses int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.launches, p.reuses
+}
+
+// Close closes the idle browsers; browsers still in use are closed when
+// their apps release them
+func (p *BrowserPool) Close() {
+	p.mu.Lock()
+	idle := p.idle
+	p.idle = nil
+	p.closed = true
+	p.mu.Unlock()
+	for _, b := range idle {
+		b.close()
+	}
+}
@@ -0,0 +1,148 @@
+package platforms
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeBrowser is a pooled browser that records whether it was closed
+type fakeBrowser struct {
+	*pooledBrowser
+	dead   bool
+	closed bool
+}
+
+func newFakeBrowser(key string) *fakeBrowser {
+	f := &fakeBrowser{}
+	f.pooledBrowser = &pooledBrowser{
+		key:   key,
+		alive: func() bool { return !f.dead },
+		close: func() { f.closed = true },
+	}
+	return f
+}
+
+// launcherOf returns a launch function handing out fake browsers, and the
+// browsers it launched
+func launcherOf(key string) (func() (*pooledBrowser, error), *[]*fakeBrowser) {
+	var launched []*fakeBrowser
+	return func() (*pooledBrowser, error) {
+		f := newFakeBrowser(key)
+		launched = append(launched, f)
+		return f.pooledBrowser, nil
+	}, &launched
+}
+
+func TestBrowserPool_Reuse(t *testing.T) {
+	pool := NewBrowserPool(2, 50)
+	launch, launched := launcherOf("chromium")
+
+	first, reused, err := pool.acquire("chromium", launch)
+	require.NoError(t, err)
+	assert.False(t, reused)
+	pool.release(first)
+
+	second, reused, err := pool.acquire("chromium", launch)
+	require.NoError(t, err)
+	assert.True(t, reused)
+	assert.Same(t, first, second)
+	pool.release(second)
+
+	launches, reuses := pool.Stats()
+	assert.Equal(t, 1, launches)
+	assert.Equal(t, 1, reuses)
+
+	pool.Close()
+	require.Len(t, *launched, 1)
+	assert.True(t, (*launched)[0].closed)
+}
+
+func TestBrowserPool_Recycle(t *testing.T) {
+	pool := NewBrowserPool(2, 2)
+	launch, launched := launcherOf("chromium")
+
+	for i := 0; i < 3; i++ {
+		b, _, err := pool.acquire("chromium", launch)
+		require.NoError(t, err)
+		pool.release(b)
+	}
+	require.Len(t, *launched, 2, "the first browser is relaunched after two apps")
+	assert.True(t, (*launched)[0].closed)
+	assert.False(t, (*launched)[1].closed)
+}
+
+func TestBrowserPool_DeadBrowser(t *testing.T) {
+	pool := NewBrowserPool(2, 50)
+	launch, launched := launcherOf("chromium")
+
+	b, _, err := pool.acquire("chromium", launch)
+	require.NoError(t, err)
+	pool.release(b)
+	(*launched)[0].dead = true
+
+	_, reused, err := pool.acquire("chromium", launch)
+	require.NoError(t, err)
+	assert.False(t, reused)
+	assert.True(t, (*launched)[0].closed)
+	assert.Len(t, *launched, 2)
+}
+
+func TestBrowserPool_Keys(t *testing.T) {
+	pool := NewBrowserPool(1, 50)
+	launchChromium, chromium := launcherOf("chromium")
+	launchEdge, edge := launcherOf("edge")
+
+	b, _, err := pool.acquire("chromium", launchChromium)
+	require.NoError(t, err)
+	pool.release(b)
+
+	_, reused, err := pool.acquire("edge", launchEdge)
+	require.NoError(t, err)
+	assert.False(t, reused, "browsers aren't shared across engines")
+	pool.release((*edge)[0].pooledBrowser)
+	assert.True(t, (*chromium)[0].closed, "a full pool evicts its oldest browser")
+	assert.False(t, (*edge)[0].closed)
+}
+
+func TestBrowserPool_LaunchError(t *testing.T) {
+	pool := NewBrowserPool(2, 50)
+	_, _, err := pool.acquire("chromium", func() (*pooledBrowser, error) {
+		return nil, errors.New("no chromium")
+	})
+	assert.EqualError(t, err, "no chromium")
+	launches, _ := pool.Stats()
+	assert.Zero(t, launches)
+}
+
+func TestBrowserPool_ReleaseAfterClose(t *testing.T) {
+	pool := NewBrowserPool(2, 50)
+	launch, launched := launcherOf("chromium")
+	b, _, err := pool.acquire("chromium", launch)
+	require.NoError(t, err)
+
+	pool.Close()
+	pool.release(b)
+	assert.True(t, (*launched)[0].closed)
+}
+
+// TestWebPlatform_Close_Pooled tests that closing a web app hands its
+// browser back to the pool rather than closing it
+func TestWebPlatform_Close_Pooled(t *testing.T) {
+	pool := NewBrowserPool(2, 50)
+	f := newFakeBrowser("chromium")
+	w := NewWebPlatform()
+	w.SetBrowserPool(pool)
+	w.pooled = f.pooledBrowser
+
+	require.NoError(t, w.Close())
+	assert.False(t, f.closed)
+	assert.Nil(t, w.pooled)
+
+	b, reused, err := pool.acquire("chromium", nil)
+	require.NoError(t, err)
+	assert.True(t, reused)
+	assert.Same(t, f.pooledBrowser, b)
+}
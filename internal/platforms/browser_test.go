@@ -0,0 +1,93 @@
+package platforms
+
+import (
+	"errors"
+	"os/exec"
+	"testing"
+
+	"panoptic/internal/config"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// stubLookPath makes only the given executable names resolvable
+func stubLookPath(t *testing.T, found ...string) {
+	t.Helper()
+	original := lookPath
+	t.Cleanup(func() { lookPath = original })
+
+	lookPath = func(name string) (string, error) {
+		for _, f := range found {
+			if f == name {
+				return "/usr/bin/" + name, nil
+			}
+		}
+		return "", exec.ErrNotFound
+	}
+}
+
+// TestNormalizeBrowserEngine tests engine aliases
+func TestNormalizeBrowserEngine(t *testing.T) {
+	assert.Equal(t, BrowserChromium, NormalizeBrowserEngine(""))
+	assert.Equal(t, BrowserChrome, NormalizeBrowserEngine("Google-Chrome"))
+	assert.Equal(t, BrowserEdge, NormalizeBrowserEngine("msedge"))
+	assert.Equal(t, BrowserWebKit, NormalizeBrowserEngine("safari"))
+	assert.Equal(t, "opera", NormalizeBrowserEngine("opera"))
+}
+
+// TestResolveBrowserBinary_Channels tests channel-specific binary lookup
+func TestResolveBrowserBinary_Channels(t *testing.T) {
+	stubLookPath(t, "google-chrome-beta", "microsoft-edge")
+
+	path, err := ResolveBrowserBinary("chrome", "beta", "")
+	require.NoError(t, err)
+	assert.Equal(t, "/usr/bin/google-chrome-beta", path)
+
+	path, err = ResolveBrowserBinary("edge", "", "")
+	require.NoError(t, err)
+	assert.Equal(t, "/usr/bin/microsoft-edge", path)
+
+	_, err = ResolveBrowserBinary("edge", "dev", "")
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "not found")
+
+	_, err = ResolveBrowserBinary("chrome", "nightly", "")
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "unknown chrome channel")
+
+	_, err = ResolveBrowserBinary("chrome", "stable", "120")
+	assert.Error(t, err)
+}
+
+// TestResolveBrowserBinary_Chromium tests the default engine and revision parsing
+func TestResolveBrowserBinary_Chromium(t *testing.T) {
+	path, err := ResolveBrowserBinary("", "", "")
+	assert.NoError(t, err)
+	assert.Empty(t, path, "default chromium defers to go-rod's lookup")
+
+	_, err = ResolveBrowserBinary("chromium", "", "latest")
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "snapshot revision")
+}
+
+// TestResolveBrowserBinary_Unsupported tests that non-CDP engines fail loudly
+func TestResolveBrowserBinary_Unsupported(t *testing.T) {
+	for _, engine := range []string{"firefox", "webkit", "safari"} {
+		_, err := ResolveBrowserBinary(engine, "", "")
+		assert.True(t, errors.Is(err, ErrBrowserEngineNotSupported), engine)
+	}
+
+	_, err := ResolveBrowserBinary("opera", "", "")
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "unknown browser engine")
+}
+
+// TestWebPlatform_Initialize_UnsupportedEngine tests that Initialize rejects engines it cannot drive
+func TestWebPlatform_Initialize_UnsupportedEngine(t *testing.T) {
+	platform := NewWebPlatform()
+
+	err := platform.Initialize(config.AppConfig{Name: "ff", Type: "web", Timeout: 5, Browser: "firefox"})
+
+	assert.ErrorIs(t, err, ErrBrowserEngineNotSupported)
+}
@@ -0,0 +1,59 @@
+package platforms
+
+import (
+	"sort"
+	"strings"
+)
+
+// Capability is something a platform can do, which the actions needing it
+// require of the platform of their app
+type Capability string
+
+const (
+	CapabilityNavigate         Capability = "navigate"
+	CapabilityClick            Capability = "click"
+	CapabilityFill             Capability = "fill"
+	CapabilitySubmit           Capability = "submit"
+	CapabilityScreenshot       Capability = "screenshot"
+	CapabilityRecording        Capability = "recording"
+	CapabilityVision           Capability = "vision"            // vision_click, vision_report and the AI page analyses
+	CapabilityScriptEval       Capability = "js_eval"           // evaluating JavaScript in the page
+	CapabilityWebVitals        Capability = "web_vitals"        // Core Web Vitals of the current page
+	CapabilityNetworkEmulation Capability = "network_emulation" // throttling and disconnecting the network
+	CapabilityBrowserStorage   Capability = "browser_storage"   // clearing the cache, cookies and storage
+	CapabilityConsole          Capability = "console"           // reading the errors pages log to their console
+	CapabilityTabs             Capability = "tabs"              // switching between tabs and popup windows
+	CapabilityClipboard        Capability = "clipboard"         // reading and writing the clipboard
+	CapabilityScroll           Capability = "scroll"            // scrolling the page and looking for elements
+	CapabilityPDF              Capability = "pdf"               // printing the page to PDF
+	CapabilityWebSockets       Capability = "websockets"        // capturing WebSocket messages
+	CapabilityPWA              Capability = "pwa"               // service workers, cache storage and installability
+)
+
+// coreCapabilities are those of the Platform interface's own actions
+var coreCapabilities = Capabilities{
+	CapabilityNavigate, CapabilityClick, CapabilityFill, CapabilitySubmit,
+	CapabilityScreenshot, CapabilityRecording,
+}
+
+// Capabilities is the set of capabilities of a platform
+type Capabilities []Capability
+
+// Has reports whether c is among the capabilities
+func (c Capabilities) Has(capability Capability) bool {
+	for _, have := range c {
+		if have == capability {
+			return true
+		}
+	}
+	return false
+}
+
+func (c Capabilities) String() string {
+	names := make([]string, len(c))
+	for i, capability := range c {
+		names[i] = string(capability)
+	}
+	sort.Strings(names)
+	return strings.Join(names, ", ")
+}
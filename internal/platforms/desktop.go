@@ -47,6 +47,15 @@ func (d *DesktopPlatform) Initialize(app config.AppConfig) error {
 	return nil
 }
 
+// ProcessID returns the PID of the app process, or 0 when the platform has not
+// started one. Initialize only verifies the app path; it does not launch it.
+func (d *DesktopPlatform) ProcessID() int {
+	if d.process == nil {
+		return 0
+	}
+	return d.process.Pid
+}
+
 func (d *DesktopPlatform) Navigate(url string) error {
 	// Input validation
 	if url == "" {
@@ -304,6 +313,12 @@ func (d *DesktopPlatform) StartRecording(filename string) error {
 	return nil
 }
 
+// Capabilities of the desktop platform, which can't navigate, fill or
+// submit until accessibility-API dispatch is wired
+func (d *DesktopPlatform) Capabilities() Capabilities {
+	return Capabilities{CapabilityClick, CapabilityScreenshot, CapabilityRecording}
+}
+
 func (d *DesktopPlatform) StopRecording() error {
 	if !d.recording {
 		return fmt.Errorf("no recording in progress")
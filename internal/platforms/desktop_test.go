@@ -685,3 +685,12 @@ func TestDesktopPlatform_VideosTakenTracking(t *testing.T) {
 	assert.Equal(t, 1, len(videos))
 	assert.Equal(t, videoPath, videos[0])
 }
+
+// Test ProcessID reflects whether an app process was started
+func TestDesktopPlatform_ProcessID(t *testing.T) {
+	platform := NewDesktopPlatform()
+	assert.Equal(t, 0, platform.ProcessID())
+
+	platform.process = &os.Process{Pid: 4321}
+	assert.Equal(t, 4321, platform.ProcessID())
+}
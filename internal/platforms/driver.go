@@ -0,0 +1,483 @@
+package platforms
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"panoptic/internal/config"
+)
+
+// DriverProtocolVersion is the version of the driver protocol this Panoptic
+// speaks; drivers answering initialize with a newer one are refused
+const DriverProtocolVersion = 1
+
+// DefaultDriverCallTimeout bounds a driver call without driver.call_timeout
+const DefaultDriverCallTimeout = 60 * time.Second
+
+// driverShutdownTimeout bounds shutdown before the driver is killed
+const driverShutdownTimeout = 5 * time.Second
+
+// JSON-RPC 2.0 error codes
+const (
+	RPCParseError     = -32700
+	RPCInvalidRequest = -32600
+	RPCMethodNotFound = -32601
+	RPCInvalidParams  = -32602
+	RPCInternalError  = -32603
+)
+
+// RPCError is the error of a JSON-RPC response
+type RPCError struct {
+	Code    int             `json:"code"`
+	Message string          `json:"message"`
+	Data    json.RawMessage `json:"data,omitempty"`
+}
+
+func (e *RPCError) Error() string {
+	return fmt.Sprintf("%s (code %d)", e.Message, e.Code)
+}
+
+// ErrDriverUnsupported is returned for calls a driver doesn't implement
+var ErrDriverUnsupported = errors.New("not supported by the driver")
+
+// rpcMessage is any JSON-RPC message on the driver's stdout: a response to
+// one of our requests, or a request or notification of the driver
+type rpcMessage struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      *int64          `json:"id,omitempty"`
+	Method  string          `json:"method,omitempty"`
+	Params  json.RawMessage `json:"params,omitempty"`
+	Result  json.RawMessage `json:"result,omitempty"`
+	Error   *RPCError       `json:"error,omitempty"`
+}
+
+// DriverInfo is what a driver tells about itself in reply to initialize
+type DriverInfo struct {
+	Name            string   `json:"name"`
+	Version         string   `json:"version"`
+	ProtocolVersion int      `json:"protocol_version"`
+	Capabilities    []string `json:"capabilities"`
+}
+
+// driverApp is the app a driver is initialized for
+type driverApp struct {
+	Name        string            `json:"name"`
+	URL         string            `json:"url,omitempty"`
+	Path        string            `json:"path,omitempty"`
+	Platform    string            `json:"platform,omitempty"`
+	Device      string            `json:"device,omitempty"`
+	Emulator    bool              `json:"emulator,omitempty"`
+	Environment map[string]string `json:"environment,omitempty"`
+}
+
+// maxDriverLog is how many log notifications of a driver are kept
+const maxDriverLog = 100
+
+// DriverPlatform implements Platform by proxying every call to an external
+// driver process over newline-delimited JSON-RPC 2.0 on its stdin and
+// stdout. Its stderr is kept for error messages.
+type DriverPlatform struct {
+	cmd       *exec.Cmd
+	stdin     io.WriteCloser
+	stderr    *tailBuffer
+	responses chan rpcMessage
+	done      chan struct{} // closed once the driver's stdout ends
+	exited    chan struct{} // closed once the driver exited, after done
+	timeout   time.Duration
+	command   string
+
+	mu     sync.Mutex // one call at a time
+	nextID int64
+	info   DriverInfo
+
+	logMu   sync.Mutex
+	log     []string // log notifications, last maxDriverLog
+	metrics map[string]interface{}
+}
+
+func NewDriverPlatform() *DriverPlatform {
+	return &DriverPlatform{
+		metrics: map[string]interface{}{
+			"start_time": time.Now(),
+		},
+	}
+}
+
+// Initialize starts the driver and sends it initialize with the app and
+// the driver options
+func (d *DriverPlatform) Initialize(app config.AppConfig) error {
+	d.metrics["start_time"] = time.Now()
+	if app.Driver == nil || app.Driver.Command == "" {
+		return fmt.Errorf("driver.command is required for driver applications")
+	}
+	driver := app.Driver
+	d.command = filepath.Base(driver.Command)
+	d.timeout = DefaultDriverCallTimeout
+	if driver.CallTimeout > 0 {
+		d.timeout = time.Duration(driver.CallTimeout) * time.Second
+	}
+
+	cmd := exec.Command(driver.Command, driver.Args...)
+	cmd.Dir = driver.Dir
+	cmd.Env = os.Environ()
+	for k, v := range app.Environment {
+		cmd.Env = append(cmd.Env, k+"="+v)
+	}
+	for k, v := range driver.Env {
+		cmd.Env = append(cmd.Env, k+"="+v)
+	}
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return err
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return err
+	}
+	d.stderr = &tailBuffer{max: 4096}
+	cmd.Stderr = d.stderr
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("failed to start driver %s: %w", driver.Command, err)
+	}
+	d.cmd, d.stdin = cmd, stdin
+	d.responses = make(chan rpcMessage, 1)
+	d.done = make(chan struct{})
+	d.exited = make(chan struct{})
+	go d.read(stdout)
+	go func() {
+		// Waiting closes stdout, so only once it has been read to the end
+		<-d.done
+		cmd.Wait()
+		close(d.exited)
+	}()
+
+	params := map[string]interface{}{
+		"protocol_version": DriverProtocolVersion,
+		"app": driverApp{
+			Name: app.Name, URL: app.URL, Path: app.Path, Platform: app.Platform,
+			Device: app.Device, Emulator: app.Emulator, Environment: app.Environment,
+		},
+		"options": driver.Options,
+	}
+	if err := d.Call("initialize", params, &d.info); err != nil {
+		d.kill()
+		return err
+	}
+	if d.info.ProtocolVersion > DriverProtocolVersion {
+		d.kill()
+		return fmt.Errorf("driver %s speaks protocol version %d, newer than supported version %d", d.name(), d.info.ProtocolVersion, DriverProtocolVersion)
+	}
+	d.metrics["driver"] = d.name()
+	d.metrics["driver_version"] = d.info.Version
+	d.metrics["driver_capabilities"] = d.info.Capabilities
+	return nil
+}
+
+// Info is what the driver told about itself when it was initialized
+func (d *DriverPlatform) Info() DriverInfo {
+	return d.info
+}
+
+// Capabilities of a driver are those it announced when it was initialized;
+// before, and for drivers announcing none, those of the protocol's methods
+func (d *DriverPlatform) Capabilities() Capabilities {
+	if len(d.info.Capabilities) == 0 {
+		return append(Capabilities(nil), coreCapabilities...)
+	}
+	capabilities := make(Capabilities, len(d.info.Capabilities))
+	for i, name := range d.info.Capabilities {
+		capabilities[i] = Capability(name)
+	}
+	return capabilities
+}
+
+// name is the driver's name, or its command before it told it
+func (d *DriverPlatform) name() string {
+	if d.info.Name != "" {
+		return d.info.Name
+	}
+	return d.command
+}
+
+// read dispatches the driver's messages until its stdout ends
+func (d *DriverPlatform) read(stdout io.Reader) {
+	defer close(d.done)
+	scanner := bufio.NewScanner(stdout)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		var msg rpcMessage
+		if err := json.Unmarshal(scanner.Bytes(), &msg); err != nil {
+			d.appendLog(fmt.Sprintf("invalid message: %s", scanner.Text()))
+			continue
+		}
+		switch {
+		case msg.Method == "" && msg.ID != nil:
+			select {
+			case d.responses <- msg:
+			default:
+				// A late response to a call that timed out
+			}
+		case msg.Method == "log":
+			var entry struct {
+				Level   string `json:"level"`
+				Message string `json:"message"`
+			}
+			json.Unmarshal(msg.Params, &entry)
+			d.appendLog(entry.Level + ": " + entry.Message)
+		case msg.ID != nil:
+			// Panoptic serves no methods to drivers
+			d.write(rpcMessage{JSONRPC: "2.0", ID: msg.ID, Error: &RPCError{Code: RPCMethodNotFound, Message: "method not found: " + msg.Method}})
+		}
+	}
+}
+
+func (d *DriverPlatform) appendLog(line string) {
+	d.logMu.Lock()
+	defer d.logMu.Unlock()
+	d.log = append(d.log, line)
+	if len(d.log) > maxDriverLog {
+		d.log = d.log[len(d.log)-maxDriverLog:]
+	}
+}
+
+func (d *DriverPlatform) write(msg interface{}) error {
+	data, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+	_, err = d.stdin.Write(append(data, '\n'))
+	return err
+}
+
+// Call sends a request to the driver and decodes the result of its
+// response into result, which may be nil. Methods the driver doesn't
+// implement fail with ErrDriverUnsupported.
+func (d *DriverPlatform) Call(method string, params, result interface{}) error {
+	return d.call(method, params, result, d.timeout)
+}
+
+func (d *DriverPlatform) call(method string, params, result interface{}, timeout time.Duration) error {
+	if d.cmd == nil {
+		return fmt.Errorf("driver is not running")
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.nextID++
+	id := d.nextID
+	if err := d.write(struct {
+		JSONRPC string      `json:"jsonrpc"`
+		ID      int64       `json:"id"`
+		Method  string      `json:"method"`
+		Params  interface{} `json:"params,omitempty"`
+	}{"2.0", id, method, params}); err != nil {
+		return d.exitError(method, err)
+	}
+
+	deadline := time.After(timeout)
+	for {
+		select {
+		case msg := <-d.responses:
+			if msg.ID == nil || *msg.ID != id {
+				continue // answers a call that timed out
+			}
+			if msg.Error != nil {
+				if msg.Error.Code == RPCMethodNotFound {
+					return fmt.Errorf("driver %s: %s: %w", d.name(), method, ErrDriverUnsupported)
+				}
+				return fmt.Errorf("driver %s: %s: %w", d.name(), method, msg.Error)
+			}
+			if result == nil || len(msg.Result) == 0 || string(msg.Result) == "null" {
+				return nil
+			}
+			if err := json.Unmarshal(msg.Result, result); err != nil {
+				return fmt.Errorf("driver %s: %s: invalid result: %w", d.name(), method, err)
+			}
+			return nil
+		case <-d.done:
+			return d.exitError(method, io.ErrUnexpectedEOF)
+		case <-deadline:
+			return fmt.Errorf("driver %s: %s timed out after %s", d.name(), method, timeout)
+		}
+	}
+}
+
+// exitError describes a driver that went away during a call, with the end
+// of its stderr
+func (d *DriverPlatform) exitError(method string, err error) error {
+	select {
+	case <-d.exited:
+	case <-time.After(time.Second):
+	}
+	msg := fmt.Sprintf("driver %s exited during %s: %v", d.name(), method, err)
+	if tail := d.stderr.String(); tail != "" {
+		msg += "; stderr: " + tail
+	}
+	return errors.New(msg)
+}
+
+func (d *DriverPlatform) Navigate(url string) error {
+	if url == "" {
+		return fmt.Errorf("url cannot be empty")
+	}
+	return d.Call("navigate", map[string]string{"url": url}, nil)
+}
+
+func (d *DriverPlatform) Click(selector string) error {
+	if selector == "" {
+		return fmt.Errorf("selector cannot be empty")
+	}
+	return d.Call("click", map[string]string{"selector": selector}, nil)
+}
+
+func (d *DriverPlatform) Fill(selector, value string) error {
+	if selector == "" {
+		return fmt.Errorf("selector cannot be empty")
+	}
+	return d.Call("fill", map[string]string{"selector": selector, "value": value}, nil)
+}
+
+func (d *DriverPlatform) Submit(selector string) error {
+	if selector == "" {
+		return fmt.Errorf("selector cannot be empty")
+	}
+	return d.Call("submit", map[string]string{"selector": selector}, nil)
+}
+
+// Wait pauses without involving the driver
+func (d *DriverPlatform) Wait(duration int) error {
+	time.Sleep(time.Duration(duration) * time.Second)
+	return nil
+}
+
+// Screenshot asks the driver to write a PNG to the absolute path of filename
+func (d *DriverPlatform) Screenshot(filename string) error {
+	if filename == "" {
+		return fmt.Errorf("filename cannot be empty")
+	}
+	path, err := filepath.Abs(filename)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create screenshot directory: %w", err)
+	}
+	if err := d.Call("screenshot", map[string]string{"path": path}, nil); err != nil {
+		return err
+	}
+	if _, err := os.Stat(path); err != nil {
+		return fmt.Errorf("driver %s took no screenshot: %w", d.name(), err)
+	}
+	return nil
+}
+
+// StartRecording asks the driver to record a video to the absolute path
+// of filename until StopRecording
+func (d *DriverPlatform) StartRecording(filename string) error {
+	if filename == "" {
+		return fmt.Errorf("filename cannot be empty")
+	}
+	path, err := filepath.Abs(filename)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create video directory: %w", err)
+	}
+	return d.Call("start_recording", map[string]string{"path": path}, nil)
+}
+
+func (d *DriverPlatform) StopRecording() error {
+	return d.Call("stop_recording", nil, nil)
+}
+
+// GetMetrics adds the metrics the driver reports, when it reports any, to
+// the driver's name, version, capabilities and log
+func (d *DriverPlatform) GetMetrics() map[string]interface{} {
+	if d.running() {
+		var reported map[string]interface{}
+		if err := d.Call("metrics", nil, &reported); err == nil {
+			for k, v := range reported {
+				d.metrics[k] = v
+			}
+		}
+	}
+	d.logMu.Lock()
+	if len(d.log) > 0 {
+		d.metrics["driver_log"] = append([]string(nil), d.log...)
+	}
+	d.logMu.Unlock()
+	d.metrics["end_time"] = time.Now()
+	d.metrics["total_duration"] = d.metrics["end_time"].(time.Time).Sub(d.metrics["start_time"].(time.Time))
+	return d.metrics
+}
+
+// Close sends shutdown, closes the driver's stdin and waits for it to
+// exit, killing it when it doesn't in time
+func (d *DriverPlatform) Close() error {
+	if !d.running() {
+		return nil
+	}
+	err := d.call("shutdown", nil, nil, driverShutdownTimeout)
+	if errors.Is(err, ErrDriverUnsupported) {
+		err = nil
+	}
+	d.stdin.Close()
+	select {
+	case <-d.exited:
+	case <-time.After(driverShutdownTimeout):
+		d.kill()
+	}
+	return err
+}
+
+// running reports whether the driver was started and hasn't exited
+func (d *DriverPlatform) running() bool {
+	if d.cmd == nil {
+		return false
+	}
+	select {
+	case <-d.exited:
+		return false
+	default:
+		return true
+	}
+}
+
+// kill stops the driver and waits for it to exit
+func (d *DriverPlatform) kill() {
+	d.stdin.Close()
+	d.cmd.Process.Kill()
+	<-d.exited
+}
+
+// tailBuffer keeps the last max bytes written to it
+type tailBuffer struct {
+	mu  sync.Mutex
+	max int
+	buf []byte
+}
+
+func (b *tailBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.buf = append(b.buf, p...)
+	if len(b.buf) > b.max {
+		b.buf = b.buf[len(b.buf)-b.max:]
+	}
+	return len(p), nil
+}
+
+func (b *tailBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return string(bytes.TrimSpace(b.buf))
+}
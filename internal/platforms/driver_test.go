@@ -0,0 +1,165 @@
+package platforms
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"panoptic/internal/config"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestDriverHelperProcess is the fake driver the driver tests spawn; it
+// does nothing when run as a test
+func TestDriverHelperProcess(t *testing.T) {
+	if os.Getenv("PANOPTIC_TEST_DRIVER") != "1" {
+		return
+	}
+	out := json.NewEncoder(os.Stdout)
+	reply := func(id *int64, result interface{}, err *RPCError) {
+		out.Encode(struct {
+			JSONRPC string      `json:"jsonrpc"`
+			ID      *int64      `json:"id"`
+			Result  interface{} `json:"result,omitempty"`
+			Error   *RPCError   `json:"error,omitempty"`
+		}{"2.0", id, result, err})
+	}
+	scanner := bufio.NewScanner(os.Stdin)
+	for scanner.Scan() {
+		var req struct {
+			ID     *int64          `json:"id"`
+			Method string          `json:"method"`
+			Params json.RawMessage `json:"params"`
+		}
+		json.Unmarshal(scanner.Bytes(), &req)
+		var params map[string]string
+		json.Unmarshal(req.Params, &params)
+		switch req.Method {
+		case "initialize":
+			fmt.Fprintln(os.Stderr, "fake driver starting")
+			out.Encode(map[string]interface{}{"jsonrpc": "2.0", "method": "log", "params": map[string]string{"level": "info", "message": "ready for " + os.Getenv("FAKE_APP")}})
+			reply(req.ID, DriverInfo{Name: "fake", Version: "1.2.3", ProtocolVersion: 1, Capabilities: []string{"navigate", "click", "screenshot"}}, nil)
+		case "navigate":
+			reply(req.ID, nil, nil)
+		case "click":
+			if params["selector"] == "#missing" {
+				reply(req.ID, nil, &RPCError{Code: 1, Message: "element not found: #missing"})
+				continue
+			}
+			reply(req.ID, nil, nil)
+		case "screenshot":
+			os.WriteFile(params["path"], []byte("\x89PNG\r\n\x1a\n"), 0644)
+			reply(req.ID, nil, nil)
+		case "metrics":
+			reply(req.ID, map[string]interface{}{"frames": 60}, nil)
+		case "crash":
+			fmt.Fprintln(os.Stderr, "fake driver crashed")
+			os.Exit(3)
+		case "shutdown":
+			reply(req.ID, nil, nil)
+		default:
+			reply(req.ID, nil, &RPCError{Code: RPCMethodNotFound, Message: "method not found: " + req.Method})
+		}
+	}
+	os.Exit(0)
+}
+
+func fakeDriverApp() config.AppConfig {
+	return config.AppConfig{
+		Name:        "Kiosk",
+		Type:        "driver",
+		Environment: map[string]string{"FAKE_APP": "kiosk"},
+		Driver: &config.DriverConfig{
+			Command: os.Args[0],
+			Args:    []string{"-test.run=^TestDriverHelperProcess$"},
+			Env:     map[string]string{"PANOPTIC_TEST_DRIVER": "1"},
+		},
+	}
+}
+
+func TestDriverPlatform(t *testing.T) {
+	d := NewDriverPlatform()
+	require.NoError(t, d.Initialize(fakeDriverApp()))
+	defer d.Close()
+
+	info := d.Info()
+	assert.Equal(t, "fake", info.Name)
+	assert.Equal(t, "1.2.3", info.Version)
+	assert.Equal(t, []string{"navigate", "click", "screenshot"}, info.Capabilities)
+
+	assert.NoError(t, d.Navigate("kiosk://home"))
+	assert.NoError(t, d.Click("#start"))
+
+	err := d.Click("#missing")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "driver fake: click: element not found: #missing")
+
+	err = d.Fill("#name", "Ada")
+	assert.ErrorIs(t, err, ErrDriverUnsupported)
+
+	path := filepath.Join(t.TempDir(), "shots", "home.png")
+	require.NoError(t, d.Screenshot(path))
+	assert.FileExists(t, path)
+
+	metrics := d.GetMetrics()
+	assert.Equal(t, "fake", metrics["driver"])
+	assert.Equal(t, float64(60), metrics["frames"])
+	assert.Equal(t, []string{"info: ready for kiosk"}, metrics["driver_log"])
+
+	assert.NoError(t, d.Close())
+	assert.NoError(t, d.Close(), "closing twice")
+}
+
+func TestDriverPlatform_Exit(t *testing.T) {
+	d := NewDriverPlatform()
+	require.NoError(t, d.Initialize(fakeDriverApp()))
+	defer d.Close()
+
+	err := d.Call("crash", nil, nil)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "driver fake exited during crash")
+	assert.Contains(t, err.Error(), "fake driver crashed")
+
+	assert.Error(t, d.Navigate("kiosk://home"))
+}
+
+func TestDriverPlatform_Initialize(t *testing.T) {
+	t.Run("no command", func(t *testing.T) {
+		err := NewDriverPlatform().Initialize(config.AppConfig{Name: "Kiosk", Type: "driver"})
+		assert.EqualError(t, err, "driver.command is required for driver applications")
+	})
+
+	t.Run("missing binary", func(t *testing.T) {
+		app := fakeDriverApp()
+		app.Driver.Command = filepath.Join(t.TempDir(), "no-such-driver")
+		err := NewDriverPlatform().Initialize(app)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "failed to start driver")
+	})
+
+	t.Run("driver exits", func(t *testing.T) {
+		app := fakeDriverApp()
+		app.Driver.Env = nil // runs as a plain test binary, which exits at once
+		err := NewDriverPlatform().Initialize(app)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "exited during initialize")
+	})
+
+	t.Run("not running", func(t *testing.T) {
+		d := NewDriverPlatform()
+		assert.EqualError(t, d.Navigate("kiosk://home"), "driver is not running")
+		assert.NoError(t, d.Close())
+	})
+}
+
+func TestTailBuffer(t *testing.T) {
+	b := &tailBuffer{max: 6}
+	fmt.Fprint(b, "hello ")
+	fmt.Fprint(b, "world\n")
+	assert.Equal(t, "world", b.String())
+}
@@ -359,6 +359,11 @@ func (m *MobilePlatform) StartRecording(filename string) error {
 	return nil
 }
 
+// Capabilities of the mobile platform: those of the Platform methods
+func (m *MobilePlatform) Capabilities() Capabilities {
+	return append(Capabilities(nil), coreCapabilities...)
+}
+
 func (m *MobilePlatform) StopRecording() error {
 	if !m.recording {
 		return fmt.Errorf("no recording in progress")
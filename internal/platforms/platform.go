@@ -19,6 +19,10 @@ type Platform interface {
 	StopRecording() error
 	GetMetrics() map[string]interface{}
 	Close() error
+
+	// Capabilities tells what the platform supports, so actions it can't
+	// run are rejected before the run starts
+	Capabilities() Capabilities
 }
 
 type PlatformFactory struct{}
@@ -35,6 +39,8 @@ func (f *PlatformFactory) CreatePlatform(appType string) (Platform, error) {
 		return NewDesktopPlatform(), nil
 	case "mobile":
 		return NewMobilePlatform(), nil
+	case "driver":
+		return NewDriverPlatform(), nil
 	default:
 		return nil, fmt.Errorf("unsupported platform type: %s", appType)
 	}
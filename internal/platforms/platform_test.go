@@ -39,6 +39,12 @@ func TestPlatformFactory_CreatePlatform(t *testing.T) {
 			expectError: false,
 			expectType:  "*platforms.MobilePlatform",
 		},
+		{
+			name:        "Create driver platform",
+			platformType: "driver",
+			expectError: false,
+			expectType:  "*platforms.DriverPlatform",
+		},
 		{
 			name:        "Unsupported platform type",
 			platformType: "unsupported",
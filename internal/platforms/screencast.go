@@ -89,18 +89,36 @@ func (r *ScreencastRecorder) Start(filename string) error {
 	}
 
 	// Start CDP screencast - captures rendered frames directly from the compositor
-	go r.captureFrames()
+	go r.captureFrames(r.page, r.done)
 
 	r.logger.Infof("Screencast recording started: %s", filename)
 	return nil
 }
 
-// captureFrames runs the CDP screencast event loop in a goroutine.
-func (r *ScreencastRecorder) captureFrames() {
+// SwitchPage carries the recording on in page, such as a tab switched to,
+// appending its frames to those of the previous page
+func (r *ScreencastRecorder) SwitchPage(page *rod.Page) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if page == nil || page == r.page {
+		return
+	}
+	r.page = page
+	if !r.recording {
+		return
+	}
+	close(r.done)
+	r.done = make(chan struct{})
+	go r.captureFrames(r.page, r.done)
+}
+
+// captureFrames runs the CDP screencast event loop of page in a goroutine
+// until done is closed.
+func (r *ScreencastRecorder) captureFrames(page *rod.Page, done chan struct{}) {
 	// Use page events to capture screencast frames
-	go r.page.EachEvent(func(e *proto.PageScreencastFrame) {
+	go page.EachEvent(func(e *proto.PageScreencastFrame) {
 		r.mu.Lock()
-		if !r.recording {
+		if !r.recording || r.page != page {
 			r.mu.Unlock()
 			return
 		}
@@ -117,7 +135,7 @@ func (r *ScreencastRecorder) captureFrames() {
 		}
 
 		// Acknowledge the frame so CDP sends the next one
-		_ = proto.PageScreencastFrameAck{SessionID: e.SessionID}.Call(r.page)
+		_ = proto.PageScreencastFrameAck{SessionID: e.SessionID}.Call(page)
 	})()
 
 	// Start the screencast via CDP
@@ -131,29 +149,29 @@ func (r *ScreencastRecorder) captureFrames() {
 		MaxWidth:      &maxWidth,
 		MaxHeight:     &maxHeight,
 		EveryNthFrame: &everyNth,
-	}.Call(r.page)
+	}.Call(page)
 
 	if err != nil {
 		r.logger.Warnf("CDP screencast start failed: %v, falling back to screenshot loop", err)
-		r.screenshotLoop()
+		r.screenshotLoop(page, done)
 		return
 	}
 
-	// Wait until Stop is called
-	<-r.done
+	// Wait until Stop is called or the recording moves to another page
+	<-done
 
 	// Stop CDP screencast
-	_ = proto.PageStopScreencast{}.Call(r.page)
+	_ = proto.PageStopScreencast{}.Call(page)
 }
 
 // screenshotLoop is a fallback that takes periodic screenshots when CDP screencast is unavailable.
-func (r *ScreencastRecorder) screenshotLoop() {
+func (r *ScreencastRecorder) screenshotLoop(page *rod.Page, done chan struct{}) {
 	ticker := time.NewTicker(time.Second / time.Duration(r.fps))
 	defer ticker.Stop()
 
 	for {
 		select {
-		case <-r.done:
+		case <-done:
 			return
 		case <-ticker.C:
 			r.mu.Lock()
@@ -165,7 +183,7 @@ func (r *ScreencastRecorder) screenshotLoop() {
 			r.frameCount++
 			r.mu.Unlock()
 
-			img, err := r.page.Screenshot(false, nil)
+			img, err := page.Screenshot(false, nil)
 			if err != nil {
 				continue
 			}
@@ -0,0 +1,31 @@
+package platforms
+
+import "strings"
+
+// Screenshot formats of settings.screenshot_format
+const (
+	ScreenshotPNG  = "png"
+	ScreenshotJPEG = "jpeg"
+	ScreenshotWebP = "webp"
+)
+
+// NormalizeScreenshotFormat maps a screenshot format and its aliases to
+// png, jpeg or webp; empty and unknown formats are png
+func NormalizeScreenshotFormat(format string) string {
+	switch strings.ToLower(strings.TrimSpace(format)) {
+	case "jpg", "jpeg":
+		return ScreenshotJPEG
+	case "webp":
+		return ScreenshotWebP
+	default:
+		return ScreenshotPNG
+	}
+}
+
+// ScreenshotExtension is the file extension of a screenshot format
+func ScreenshotExtension(format string) string {
+	if f := NormalizeScreenshotFormat(format); f != ScreenshotJPEG {
+		return "." + f
+	}
+	return ".jpg"
+}
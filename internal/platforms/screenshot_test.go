@@ -0,0 +1,23 @@
+package platforms
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNormalizeScreenshotFormat(t *testing.T) {
+	for format, want := range map[string]string{
+		"": ScreenshotPNG, "png": ScreenshotPNG, "PNG": ScreenshotPNG,
+		"jpg": ScreenshotJPEG, "jpeg": ScreenshotJPEG,
+		" webp ": ScreenshotWebP, "tiff": ScreenshotPNG,
+	} {
+		assert.Equal(t, want, NormalizeScreenshotFormat(format), format)
+	}
+}
+
+func TestScreenshotExtension(t *testing.T) {
+	assert.Equal(t, ".png", ScreenshotExtension(""))
+	assert.Equal(t, ".jpg", ScreenshotExtension("jpeg"))
+	assert.Equal(t, ".webp", ScreenshotExtension("webp"))
+}
@@ -10,9 +10,11 @@ import (
 
 	"panoptic/internal/config"
 	"panoptic/internal/logger"
+	"panoptic/internal/redact"
 	"panoptic/internal/vision"
 
 	"github.com/go-rod/rod"
+	"github.com/go-rod/rod/lib/launcher"
 	"github.com/go-rod/rod/lib/proto"
 )
 
@@ -23,8 +25,28 @@ type WebPlatform struct {
 	cancel    context.CancelFunc
 	recording bool
 	recorder  *ScreencastRecorder
+	remote    *remoteSession
+	launched  *launcher.Launcher
+	pool      *BrowserPool
+	pooled    *pooledBrowser // taken from pool for the current app
+	headed    bool
 	metrics   map[string]interface{}
 	vision    *vision.ElementDetector
+	console   *consoleLog // errors the page logged, until taken
+	dialogs   *dialogLog  // dialogs the page opened, until taken
+
+	websockets *webSocketLog // WebSocket messages the page sent and received, until taken
+
+	workerSessions map[proto.TargetTargetID]proto.TargetSessionID // service workers network conditions apply to
+
+	maskedSelectors []string         // blurred in screenshots, see SetRedaction
+	redactor        *redact.Redactor // redacts the page state
+
+	tabs       *tabList                      // open tabs, once a tab action ran; page is the active one
+	tabContext proto.BrowserBrowserContextID // browser context the tabs are in
+
+	clipboardGranted bool   // the clipboard permissions were granted, see grantClipboard
+	locale           string // language tag the tabs are shown in, see applyLocale
 }
 
 func NewWebPlatform() *WebPlatform {
@@ -51,13 +73,50 @@ func (w *WebPlatform) Initialize(app config.AppConfig) error {
 	// Update start time to actual initialization time
 	w.metrics["start_time"] = time.Now()
 	
-	// Launch browser using rod with error handling
-	browser := rod.New().MustConnect()
+	engine := NormalizeBrowserEngine(app.Browser)
+	started := time.Now()
+	browser, err := w.startBrowser(app, engine)
+	if err != nil {
+		return err
+	}
 	w.browser = browser
+	browserStarted := time.Now()
 	
 	// Create page with error handling
-	page := browser.MustPage("")
+	page, err := browser.Page(proto.TargetCreateTarget{})
+	if err != nil {
+		w.Close()
+		return fmt.Errorf("failed to open page: %w", err)
+	}
 	w.page = page
+	w.locale = app.Locale
+	if err := w.applyLocale(page); err != nil {
+		w.Close()
+		return err
+	}
+	if err := w.watchConsole(); err != nil {
+		w.Close()
+		return err
+	}
+	w.watchDialogs()
+	if err := w.watchWebSockets(); err != nil {
+		w.Close()
+		return err
+	}
+	if usesClipboard(app) {
+		if err := w.grantClipboard(); err != nil {
+			w.Close()
+			return err
+		}
+	}
+	w.metrics["phase_timings"] = map[string]float64{
+		"browser_start": float64(browserStarted.Sub(started).Microseconds()) / 1000,
+		"page_open":     float64(time.Since(browserStarted).Microseconds()) / 1000,
+	}
+	w.metrics["browser"] = app.BrowserLabel()
+	if w.remote != nil {
+		w.metrics["remote_session"] = w.remote.id
+	}
 	
 	// Setup context with timeout
 	w.context, w.cancel = context.WithTimeout(context.Background(), time.Duration(app.Timeout)*time.Second)
@@ -66,6 +125,154 @@ func (w *WebPlatform) Initialize(app config.AppConfig) error {
 	return nil
 }
 
+// startBrowser connects to the app's browser: a pooled one in a fresh
+// incognito context when there is a pool, otherwise a newly launched or
+// remote one
+func (w *WebPlatform) startBrowser(app config.AppConfig, engine string) (*rod.Browser, error) {
+	if w.pool == nil || app.Remote != nil {
+		return w.connectBrowser(app, engine)
+	}
+
+	key := strings.Join([]string{engine, app.BrowserChannel, app.BrowserVersion, fmt.Sprint(w.headed)}, "|")
+	pooled, reused, err := w.pool.acquire(key, func() (*pooledBrowser, error) {
+		browser, err := w.connectBrowser(app, engine)
+		if err != nil {
+			return nil, err
+		}
+		return newPooledBrowser(key, browser, w.launched), nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	incognito, err := pooled.browser.Incognito()
+	if err != nil {
+		w.pool.discard(pooled)
+		return nil, fmt.Errorf("failed to open browser context: %w", err)
+	}
+	w.pooled = pooled
+	w.launched = pooled.launcher
+	w.metrics["browser_reused"] = reused
+	return incognito, nil
+}
+
+// connectBrowser launches, or starts a remote session of, the app's browser
+// and connects to it
+func (w *WebPlatform) connectBrowser(app config.AppConfig, engine string) (*rod.Browser, error) {
+	controlURL, err := w.browserControlURL(app, engine)
+	if err != nil {
+		return nil, err
+	}
+	
+	browser := rod.New().ControlURL(controlURL)
+	if err := browser.Connect(); err != nil {
+		w.closeRemote()
+		return nil, fmt.Errorf("failed to connect to %s: %w", engine, err)
+	}
+	return browser, nil
+}
+
+// SetBrowserPool takes browsers from pool instead of launching one. Must be
+// called before Initialize; remote sessions aren't pooled.
+func (w *WebPlatform) SetBrowserPool(pool *BrowserPool) {
+	w.pool = pool
+}
+
+// browserControlURL returns the CDP websocket to drive: a remote WebDriver
+// session's se:cdp endpoint when app.Remote is set, otherwise a locally
+// launched browser for the requested engine/channel/version
+func (w *WebPlatform) browserControlURL(app config.AppConfig, engine string) (string, error) {
+	if app.Remote != nil {
+		session, err := newRemoteSession(context.Background(), app)
+		if err != nil {
+			return "", fmt.Errorf("failed to start remote %s session: %w", engine, err)
+		}
+		w.remote = session
+		return session.cdpURL, nil
+	}
+
+	// Resolve the requested engine/channel/version to a browser binary
+	bin, err := ResolveBrowserBinary(engine, app.BrowserChannel, app.BrowserVersion)
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve browser: %w", err)
+	}
+
+	// Launch browser using rod with error handling
+	l := launcher.New().Headless(!w.headed)
+	if bin != "" {
+		l = l.Bin(bin)
+	}
+	controlURL, err := l.Launch()
+	if err != nil {
+		return "", fmt.Errorf("failed to launch %s: %w", engine, err)
+	}
+	w.launched = l
+	return controlURL, nil
+}
+
+// ProcessID returns the PID of the locally launched browser, or 0 for remote
+// WebDriver sessions and before Initialize
+func (w *WebPlatform) ProcessID() int {
+	if w.launched == nil {
+		return 0
+	}
+	return w.launched.PID()
+}
+
+// BrowserVersion returns the product and version the browser reports, e.g.
+// HeadlessChrome/120.0.6099.109
+func (w *WebPlatform) BrowserVersion() (string, error) {
+	if w.browser == nil {
+		return "", fmt.Errorf("browser not initialized")
+	}
+	version, err := proto.BrowserGetVersion{}.Call(w.browser)
+	if err != nil {
+		return "", fmt.Errorf("failed to read browser version: %w", err)
+	}
+	return version.Product, nil
+}
+
+// SetHeaded launches a visible browser window instead of a headless one.
+// Must be called before Initialize; has no effect on remote sessions.
+func (w *WebPlatform) SetHeaded(headed bool) {
+	w.headed = headed
+}
+
+// SetVision makes vision clicks and reports detect elements with an ONNX
+// model instead of the heuristic detectors when model isn't nil, keeping
+// the detections that pass thresholds
+func (w *WebPlatform) SetVision(model *vision.Model, thresholds vision.Thresholds) {
+	if w.vision != nil {
+		w.vision.SetModel(model)
+		w.vision.SetThresholds(thresholds)
+	}
+}
+
+// Evaluate runs a JavaScript expression or function in the current page and returns its value
+func (w *WebPlatform) Evaluate(js string) (interface{}, error) {
+	if w.page == nil {
+		return nil, fmt.Errorf("web page not initialized")
+	}
+	if !strings.HasPrefix(strings.TrimSpace(js), "(") && !strings.HasPrefix(strings.TrimSpace(js), "function") {
+		js = "() => (" + js + ")"
+	}
+	res, err := w.page.Eval(js)
+	if err != nil {
+		return nil, fmt.Errorf("failed to evaluate script: %w", err)
+	}
+	return res.Value.Val(), nil
+}
+
+// closeRemote deletes the remote WebDriver session, if any
+func (w *WebPlatform) closeRemote() {
+	if w.remote == nil {
+		return
+	}
+	if err := w.remote.Close(); err != nil {
+		logger.NewLogger(false).Warnf("Failed to delete remote webdriver session: %v", err)
+	}
+	w.remote = nil
+}
+
 func (w *WebPlatform) Navigate(url string) error {
 	// Input validation
 	if url == "" {
@@ -93,6 +300,8 @@ func (w *WebPlatform) Navigate(url string) error {
 		w.metrics["navigate_actions"] = append(navigateActions, url)
 	}
 	
+	w.recordWebVitals(url)
+	
 	return nil
 }
 
@@ -439,25 +648,42 @@ func (w *WebPlatform) Wait(duration int) error {
 }
 
 func (w *WebPlatform) Screenshot(filename string) error {
+	screenshotData, err := w.EncodeScreenshot(filename, ScreenshotPNG, 0)
+	if err != nil {
+		return err
+	}
+	
+	if err := os.MkdirAll(filepath.Dir(filename), 0755); err != nil {
+		return fmt.Errorf("failed to create screenshot directory: %w", err)
+	}
+	if err := os.WriteFile(filename, screenshotData, 0600); err != nil {
+		return fmt.Errorf("failed to save screenshot: %w", err)
+	}
+	return nil
+}
+
+// EncodeScreenshot has the browser capture the full page as a PNG, JPEG or
+// WebP image for filename, without writing it: that's left to the caller. Quality, from 1
+// to 100, applies to JPEG and WebP; 0 means the browser's default.
+func (w *WebPlatform) EncodeScreenshot(filename, format string, quality int) ([]byte, error) {
 	// Input validation
 	if filename == "" {
-		return fmt.Errorf("filename cannot be empty")
+		return nil, fmt.Errorf("filename cannot be empty")
 	}
 	if w.page == nil {
-		return fmt.Errorf("web page not initialized")
+		return nil, fmt.Errorf("web page not initialized")
 	}
 	
-	if err := os.MkdirAll(filepath.Dir(filename), 0755); err != nil {
-		return fmt.Errorf("failed to create screenshot directory: %w", err)
+	req := &proto.PageCaptureScreenshot{Format: proto.PageCaptureScreenshotFormat(NormalizeScreenshotFormat(format))}
+	if req.Format != proto.PageCaptureScreenshotFormatPng && quality > 0 {
+		req.Quality = &quality
 	}
-	
-	screenshotData, err := w.page.Screenshot(true, nil)
+	screenshotData, err := w.page.Screenshot(true, req)
 	if err != nil {
-		return fmt.Errorf("failed to capture screenshot: %w", err)
+		return nil, fmt.Errorf("failed to capture screenshot: %w", err)
 	}
-	
-	if err := os.WriteFile(filename, screenshotData, 0600); err != nil {
-		return fmt.Errorf("failed to save screenshot: %w", err)
+	if screenshotData, err = w.redactScreenshot(screenshotData, true); err != nil {
+		return nil, err
 	}
 	
 	// Safe slice append
@@ -465,7 +691,7 @@ func (w *WebPlatform) Screenshot(filename string) error {
 		w.metrics["screenshots_taken"] = append(screenshotsTaken, filename)
 	}
 	
-	return nil
+	return screenshotData, nil
 }
 
 func (w *WebPlatform) StartRecording(filename string) error {
@@ -498,6 +724,15 @@ func (w *WebPlatform) StartRecording(filename string) error {
 	return nil
 }
 
+// Capabilities of the web platform: all of them
+func (w *WebPlatform) Capabilities() Capabilities {
+	return append(Capabilities{
+		CapabilityVision, CapabilityScriptEval, CapabilityWebVitals,
+		CapabilityNetworkEmulation, CapabilityBrowserStorage, CapabilityConsole, CapabilityTabs,
+		CapabilityClipboard, CapabilityScroll, CapabilityPDF, CapabilityWebSockets, CapabilityPWA,
+	}, coreCapabilities...)
+}
+
 func (w *WebPlatform) StopRecording() error {
 	if !w.recording {
 		return fmt.Errorf("no recording in progress")
@@ -566,10 +801,23 @@ func (w *WebPlatform) Close() error {
 	if w.page != nil {
 		w.page.Close()
 	}
+	w.tabs = nil
+	w.clipboardGranted = false
+	w.locale = ""
+	w.workerSessions = nil
 
+	// A pooled browser only loses the app's context
 	if w.browser != nil {
 		w.browser.Close()
+		w.browser = nil
 	}
+	if w.pooled != nil {
+		w.pool.release(w.pooled)
+		w.pooled = nil
+		w.launched = nil
+	}
+
+	w.closeRemote()
 
 	return nil
 }
@@ -580,14 +828,14 @@ func (w *WebPlatform) GetPageState() (interface{}, error) {
 		return nil, fmt.Errorf("web platform not initialized")
 	}
 
-	// Get page HTML
-	html, err := w.page.HTML()
+	// Get page HTML, masked elements and redacted text replaced
+	html, err := w.pageHTML()
 	if err != nil {
 		return nil, fmt.Errorf("failed to get page HTML: %w", err)
 	}
 
 	// Get page URL
-	url := w.page.MustInfo().URL
+	url := w.redactor.String(w.page.MustInfo().URL)
 
 	// Return page state as a map
 	pageState := map[string]interface{}{
@@ -0,0 +1,99 @@
+package platforms
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"panoptic/internal/captcha"
+)
+
+// captchaResponseFields are where reCAPTCHA, hCaptcha and Turnstile put
+// their tokens once solved
+const captchaResponseFields = `[name="g-recaptcha-response"], [name="h-captcha-response"], [name="cf-turnstile-response"]`
+
+// detectCaptchaScript returns the first visible captcha matching selectors
+// whose response field is still empty, or null
+const detectCaptchaScript = `(selectors, fields) => {
+	const kindOf = (el) => {
+		const hint = (el.className || '') + ' ' + (el.getAttribute('src') || '');
+		if (/recaptcha/.test(hint)) return 'recaptcha';
+		if (/h-captcha|hcaptcha/.test(hint)) return 'hcaptcha';
+		if (/turnstile|challenges\.cloudflare\.com/.test(hint)) return 'turnstile';
+		return '';
+	};
+	const siteKeyOf = (el) => {
+		const keyed = el.closest('[data-sitekey]') || el.querySelector('[data-sitekey]');
+		if (keyed) return keyed.getAttribute('data-sitekey');
+		const m = (el.getAttribute('src') || '').match(/[?&](?:k|sitekey)=([^&]+)/);
+		return m ? decodeURIComponent(m[1]) : '';
+	};
+	const solved = (el) => {
+		const scope = el.closest('form') || document;
+		return Array.from(scope.querySelectorAll(fields)).some((f) => f.value);
+	};
+	for (const s of selectors) {
+		let found = [];
+		try { found = document.querySelectorAll(s); } catch (e) { continue; }
+		for (const el of found) {
+			const r = el.getBoundingClientRect();
+			if (r.width === 0 || r.height === 0 || solved(el)) continue;
+			return {kind: kindOf(el), selector: s, site_key: siteKeyOf(el), page_url: location.href};
+		}
+	}
+	return null;
+}`
+
+// injectCaptchaScript fills every captcha response field with token and
+// calls the callbacks captchas were rendered with, returning how many fields
+// it filled
+const injectCaptchaScript = `(token, fields) => {
+	const found = document.querySelectorAll(fields);
+	for (const f of found) {
+		f.value = token;
+		f.dispatchEvent(new Event('input', {bubbles: true}));
+		f.dispatchEvent(new Event('change', {bubbles: true}));
+	}
+	for (const el of document.querySelectorAll('[data-callback]')) {
+		const cb = window[el.getAttribute('data-callback')];
+		if (typeof cb === 'function') { try { cb(token); } catch (e) {} }
+	}
+	return found.length;
+}`
+
+var _ captcha.Page = (*WebPlatform)(nil)
+
+// DetectCaptcha returns the first unsolved captcha on the page matching
+// one of selectors, or nil
+func (w *WebPlatform) DetectCaptcha(selectors []string) (*captcha.Challenge, error) {
+	if w.page == nil {
+		return nil, fmt.Errorf("web page not initialized")
+	}
+	res, err := w.page.Eval(detectCaptchaScript, selectors, captchaResponseFields)
+	if err != nil {
+		return nil, fmt.Errorf("failed to detect captchas: %w", err)
+	}
+	if res.Value.Nil() {
+		return nil, nil
+	}
+	var challenge captcha.Challenge
+	if err := json.Unmarshal([]byte(res.Value.JSON("", "")), &challenge); err != nil {
+		return nil, fmt.Errorf("failed to detect captchas: %w", err)
+	}
+	return &challenge, nil
+}
+
+// InjectCaptchaToken fills the page's captcha response fields with token
+// and calls the captchas' callbacks
+func (w *WebPlatform) InjectCaptchaToken(token string) error {
+	if w.page == nil {
+		return fmt.Errorf("web page not initialized")
+	}
+	res, err := w.page.Eval(injectCaptchaScript, token, captchaResponseFields)
+	if err != nil {
+		return fmt.Errorf("failed to inject captcha token: %w", err)
+	}
+	if res.Value.Int() == 0 {
+		return fmt.Errorf("no captcha response field to inject the token into")
+	}
+	return nil
+}
@@ -0,0 +1,16 @@
+package platforms
+
+import (
+	"testing"
+
+	"panoptic/internal/captcha"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWebPlatform_Captcha_NotInitialized(t *testing.T) {
+	w := NewWebPlatform()
+	_, err := w.DetectCaptcha(captcha.DefaultSelectors)
+	assert.EqualError(t, err, "web page not initialized")
+	assert.EqualError(t, w.InjectCaptchaToken("token"), "web page not initialized")
+}
@@ -0,0 +1,82 @@
+package platforms
+
+import (
+	"fmt"
+	"net/url"
+
+	"github.com/go-rod/rod/lib/proto"
+)
+
+// CurrentURL returns the URL of the page
+func (w *WebPlatform) CurrentURL() (string, error) {
+	if w.page == nil {
+		return "", fmt.Errorf("web page not initialized")
+	}
+	info, err := w.page.Info()
+	if err != nil {
+		return "", fmt.Errorf("failed to read page URL: %w", err)
+	}
+	return info.URL, nil
+}
+
+// ClearBrowserCache empties the HTTP cache, and the Cache Storage and service
+// workers of the current page's origin
+func (w *WebPlatform) ClearBrowserCache() error {
+	if w.page == nil {
+		return fmt.Errorf("web page not initialized")
+	}
+	if err := (proto.NetworkClearBrowserCache{}).Call(w.page); err != nil {
+		return fmt.Errorf("failed to clear browser cache: %w", err)
+	}
+	origin, err := w.currentOrigin()
+	if err != nil || origin == "" {
+		return err
+	}
+	if err := (proto.StorageClearDataForOrigin{Origin: origin, StorageTypes: "cache_storage,service_workers"}).Call(w.page); err != nil {
+		return fmt.Errorf("failed to clear cache storage of %s: %w", origin, err)
+	}
+	return nil
+}
+
+// ExpireSession deletes the named cookies of the current page, or all
+// cookies when none are named, and optionally its local and session storage
+func (w *WebPlatform) ExpireSession(cookies []string, storage bool) error {
+	if w.page == nil {
+		return fmt.Errorf("web page not initialized")
+	}
+	if len(cookies) == 0 {
+		if err := (proto.NetworkClearBrowserCookies{}).Call(w.page); err != nil {
+			return fmt.Errorf("failed to clear cookies: %w", err)
+		}
+	} else {
+		pageURL, err := w.CurrentURL()
+		if err != nil {
+			return err
+		}
+		for _, name := range cookies {
+			if err := (proto.NetworkDeleteCookies{Name: name, URL: pageURL}).Call(w.page); err != nil {
+				return fmt.Errorf("failed to delete cookie %s: %w", name, err)
+			}
+		}
+	}
+	if storage {
+		if _, err := w.Evaluate("() => { localStorage.clear(); sessionStorage.clear() }"); err != nil {
+			return fmt.Errorf("failed to clear web storage: %w", err)
+		}
+	}
+	return nil
+}
+
+// currentOrigin returns the page's origin, or "" for pages without one such
+// as about:blank
+func (w *WebPlatform) currentOrigin() (string, error) {
+	pageURL, err := w.CurrentURL()
+	if err != nil {
+		return "", err
+	}
+	u, err := url.Parse(pageURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+		return "", nil
+	}
+	return u.Scheme + "://" + u.Host, nil
+}
@@ -0,0 +1,16 @@
+package platforms
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestWebPlatform_Chaos_NilPage tests the chaos helpers before initialization
+func TestWebPlatform_Chaos_NilPage(t *testing.T) {
+	platform := NewWebPlatform()
+	_, err := platform.CurrentURL()
+	assert.ErrorContains(t, err, "web page not initialized")
+	assert.ErrorContains(t, platform.ClearBrowserCache(), "web page not initialized")
+	assert.ErrorContains(t, platform.ExpireSession(nil, true), "web page not initialized")
+}
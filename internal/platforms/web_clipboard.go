@@ -0,0 +1,101 @@
+package platforms
+
+import (
+	"fmt"
+
+	"panoptic/internal/config"
+
+	"github.com/go-rod/rod/lib/proto"
+)
+
+// clipboardPermissions let the app's pages read and write the clipboard
+// without asking, as a person would once they allowed it
+var clipboardPermissions = []proto.BrowserPermissionType{
+	proto.BrowserPermissionTypeClipboardReadWrite,
+	proto.BrowserPermissionTypeClipboardSanitizedWrite,
+}
+
+// writeClipboardScript puts the text on the clipboard, falling back to a
+// copy command where the Clipboard API isn't available, such as on http
+const writeClipboardScript = `async (text) => {
+	if (navigator.clipboard && window.isSecureContext) {
+		await navigator.clipboard.writeText(text)
+		return
+	}
+	const area = document.createElement('textarea')
+	area.value = text
+	area.style.position = 'fixed'
+	area.style.opacity = '0'
+	document.body.appendChild(area)
+	area.select()
+	const copied = document.execCommand('copy')
+	area.remove()
+	if (!copied) throw new Error('the page refused to copy')
+}`
+
+// readClipboardScript returns the text on the clipboard
+const readClipboardScript = `async () => {
+	if (!navigator.clipboard || !window.isSecureContext) {
+		throw new Error('the Clipboard API needs an https or localhost page')
+	}
+	return await navigator.clipboard.readText()
+}`
+
+// usesClipboard tells whether an app has clipboard actions, whose pages
+// get the clipboard permissions as it starts
+func usesClipboard(app config.AppConfig) bool {
+	for _, action := range app.Actions {
+		if action.Type == "set_clipboard" || action.Type == "assert_clipboard" {
+			return true
+		}
+	}
+	return false
+}
+
+// grantClipboard grants the clipboard permissions to every origin of the
+// app's browser context, so copy buttons work in tabs opened later too, and
+// keeps the page focused, as the Clipboard API asks
+func (w *WebPlatform) grantClipboard() error {
+	if w.page == nil || w.browser == nil {
+		return fmt.Errorf("web page not initialized")
+	}
+	if !w.clipboardGranted {
+		info, err := w.page.Info()
+		if err != nil {
+			return fmt.Errorf("failed to read the page's tab: %w", err)
+		}
+		grant := proto.BrowserGrantPermissions{Permissions: clipboardPermissions, BrowserContextID: info.BrowserContextID}
+		if err := grant.Call(w.browser); err != nil {
+			return fmt.Errorf("failed to grant clipboard permissions: %w", err)
+		}
+		w.clipboardGranted = true
+	}
+	if err := (proto.EmulationSetFocusEmulationEnabled{Enabled: true}).Call(w.page); err != nil {
+		return fmt.Errorf("failed to focus the page: %w", err)
+	}
+	return nil
+}
+
+// WriteClipboard puts text on the clipboard, as pasting it would expect
+func (w *WebPlatform) WriteClipboard(text string) error {
+	if err := w.grantClipboard(); err != nil {
+		return err
+	}
+	if _, err := w.page.Eval(writeClipboardScript, text); err != nil {
+		return fmt.Errorf("failed to write the clipboard: %w", err)
+	}
+	return nil
+}
+
+// ReadClipboard returns the text on the clipboard, such as what a copy
+// button put there
+func (w *WebPlatform) ReadClipboard() (string, error) {
+	if err := w.grantClipboard(); err != nil {
+		return "", err
+	}
+	res, err := w.page.Eval(readClipboardScript)
+	if err != nil {
+		return "", fmt.Errorf("failed to read the clipboard: %w", err)
+	}
+	return res.Value.Str(), nil
+}
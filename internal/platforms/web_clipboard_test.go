@@ -0,0 +1,25 @@
+package platforms
+
+import (
+	"testing"
+
+	"panoptic/internal/config"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUsesClipboard(t *testing.T) {
+	app := config.AppConfig{Actions: []config.Action{{Name: "home", Type: "navigate"}}}
+	assert.False(t, usesClipboard(app))
+	app.Actions = append(app.Actions, config.Action{Name: "copied", Type: "assert_clipboard", Value: "https://shop.example.com/p/1"})
+	assert.True(t, usesClipboard(app))
+}
+
+func TestWebPlatform_Clipboard_NotInitialized(t *testing.T) {
+	w := NewWebPlatform()
+	_, err := w.ReadClipboard()
+	require.Error(t, err)
+	require.Error(t, w.WriteClipboard("code"))
+	assert.True(t, w.Capabilities().Has(CapabilityClipboard))
+}
@@ -0,0 +1,107 @@
+package platforms
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/go-rod/rod"
+	"github.com/go-rod/rod/lib/proto"
+)
+
+// ConsoleError is an error a page logged to its console, or an exception
+// it didn't catch
+type ConsoleError struct {
+	Message string `json:"message"`
+	Source  string `json:"source"`        // console or exception
+	URL     string `json:"url,omitempty"` // script it came from
+}
+
+// consoleLog collects the console errors of a page until they're taken
+type consoleLog struct {
+	mu     sync.Mutex
+	errors []ConsoleError
+}
+
+func (l *consoleLog) add(err ConsoleError) {
+	l.mu.Lock()
+	l.errors = append(l.errors, err)
+	l.mu.Unlock()
+}
+
+func (l *consoleLog) take() []ConsoleError {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	errs := l.errors
+	l.errors = nil
+	return errs
+}
+
+// watchConsole collects the page's console errors and uncaught exceptions
+// for as long as it's open
+func (w *WebPlatform) watchConsole() error {
+	w.console = &consoleLog{}
+	return w.watchPageConsole(w.page)
+}
+
+// watchPageConsole adds the console errors of page, such as a tab opened
+// later, to those collected
+func (w *WebPlatform) watchPageConsole(page *rod.Page) error {
+	if err := (proto.RuntimeEnable{}).Call(page); err != nil {
+		return fmt.Errorf("failed to enable console events: %w", err)
+	}
+	log := w.console
+	wait := page.EachEvent(
+		func(e *proto.RuntimeConsoleAPICalled) {
+			if e.Type != proto.RuntimeConsoleAPICalledTypeError {
+				return
+			}
+			err := ConsoleError{Message: consoleText(e.Args), Source: "console"}
+			if e.StackTrace != nil && len(e.StackTrace.CallFrames) > 0 {
+				err.URL = e.StackTrace.CallFrames[0].URL
+			}
+			log.add(err)
+		},
+		func(e *proto.RuntimeExceptionThrown) {
+			details := e.ExceptionDetails
+			if details == nil {
+				return
+			}
+			message := details.Text
+			if details.Exception != nil && details.Exception.Description != "" {
+				message = details.Exception.Description
+			}
+			log.add(ConsoleError{Message: message, Source: "exception", URL: details.URL})
+		},
+	)
+	go wait()
+	return nil
+}
+
+// consoleText joins the arguments of a console call the way the console
+// prints them
+func consoleText(args []*proto.RuntimeRemoteObject) string {
+	parts := make([]string, 0, len(args))
+	for _, arg := range args {
+		switch {
+		case arg.Type == proto.RuntimeRemoteObjectTypeString:
+			parts = append(parts, arg.Value.Str())
+		case arg.Description != "":
+			parts = append(parts, arg.Description)
+		case !arg.Value.Nil():
+			parts = append(parts, arg.Value.String())
+		default:
+			parts = append(parts, string(arg.Type))
+		}
+	}
+	return strings.Join(parts, " ")
+}
+
+// TakeConsoleErrors returns the console errors the page logged since the
+// app started or the last call
+func (w *WebPlatform) TakeConsoleErrors() ([]ConsoleError, error) {
+	if w.console == nil {
+		return nil, fmt.Errorf("web page not initialized")
+	}
+	return w.console.take(), nil
+}
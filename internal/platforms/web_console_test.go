@@ -0,0 +1,32 @@
+package platforms
+
+import (
+	"testing"
+
+	"github.com/go-rod/rod/lib/proto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/ysmood/gson"
+)
+
+func TestConsoleText(t *testing.T) {
+	args := []*proto.RuntimeRemoteObject{
+		{Type: proto.RuntimeRemoteObjectTypeString, Value: gson.New("Failed to load")},
+		{Type: proto.RuntimeRemoteObjectTypeNumber, Value: gson.New(404)},
+		{Type: proto.RuntimeRemoteObjectTypeObject, Description: "Error: boom\n    at app.js:1"},
+		{Type: proto.RuntimeRemoteObjectTypeUndefined},
+	}
+	assert.Equal(t, "Failed to load 404 Error: boom\n    at app.js:1 undefined", consoleText(args))
+}
+
+func TestConsoleLog_Take(t *testing.T) {
+	log := &consoleLog{}
+	log.add(ConsoleError{Message: "boom", Source: "exception"})
+	assert.Equal(t, []ConsoleError{{Message: "boom", Source: "exception"}}, log.take())
+	assert.Empty(t, log.take(), "errors are only taken once")
+}
+
+func TestWebPlatform_TakeConsoleErrors_NotInitialized(t *testing.T) {
+	_, err := NewWebPlatform().TakeConsoleErrors()
+	require.Error(t, err)
+}
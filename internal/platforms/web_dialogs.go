@@ -0,0 +1,86 @@
+package platforms
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/go-rod/rod"
+	"github.com/go-rod/rod/lib/proto"
+)
+
+// Dialog is a native alert, confirm, prompt or beforeunload dialog a page
+// opened, and how it was answered
+type Dialog struct {
+	Type     string `json:"type"`
+	Message  string `json:"message"`
+	URL      string `json:"url,omitempty"`
+	Accepted bool   `json:"accepted"`
+}
+
+// dialogLog answers the dialogs of a page under the current policy and
+// collects them until they're taken
+type dialogLog struct {
+	mu         sync.Mutex
+	accept     bool
+	promptText string
+	dialogs    []Dialog
+}
+
+// answer records a dialog and returns how to answer it
+func (l *dialogLog) answer(e *proto.PageJavascriptDialogOpening) proto.PageHandleJavaScriptDialog {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.dialogs = append(l.dialogs, Dialog{Type: string(e.Type), Message: e.Message, URL: e.URL, Accepted: l.accept})
+	reply := proto.PageHandleJavaScriptDialog{Accept: l.accept}
+	if l.accept && e.Type == proto.PageDialogTypePrompt {
+		reply.PromptText = l.promptText
+	}
+	return reply
+}
+
+func (l *dialogLog) take() []Dialog {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	dialogs := l.dialogs
+	l.dialogs = nil
+	return dialogs
+}
+
+// watchDialogs answers the page's dialogs for as long as it's open; until a
+// policy is set they're dismissed, so a dialog never blocks the page
+func (w *WebPlatform) watchDialogs() {
+	w.dialogs = &dialogLog{}
+	w.watchPageDialogs(w.page)
+}
+
+// watchPageDialogs answers the dialogs of page, such as a tab opened later,
+// with those of the app's page
+func (w *WebPlatform) watchPageDialogs(page *rod.Page) {
+	log := w.dialogs
+	wait := page.EachEvent(func(e *proto.PageJavascriptDialogOpening) {
+		_ = log.answer(e).Call(page)
+	})
+	go wait()
+}
+
+// SetDialogPolicy sets how the dialogs opened from now on are answered:
+// accepted, with promptText typed into prompts, or dismissed
+func (w *WebPlatform) SetDialogPolicy(accept bool, promptText string) error {
+	if w.dialogs == nil {
+		return fmt.Errorf("web page not initialized")
+	}
+	w.dialogs.mu.Lock()
+	w.dialogs.accept = accept
+	w.dialogs.promptText = promptText
+	w.dialogs.mu.Unlock()
+	return nil
+}
+
+// TakeDialogs returns the dialogs the page opened since the app started or
+// the last call
+func (w *WebPlatform) TakeDialogs() ([]Dialog, error) {
+	if w.dialogs == nil {
+		return nil, fmt.Errorf("web page not initialized")
+	}
+	return w.dialogs.take(), nil
+}
@@ -0,0 +1,35 @@
+package platforms
+
+import (
+	"testing"
+
+	"github.com/go-rod/rod/lib/proto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDialogLog_Answer(t *testing.T) {
+	log := &dialogLog{}
+	confirm := &proto.PageJavascriptDialogOpening{Type: proto.PageDialogTypeConfirm, Message: "Delete this order?", URL: "https://shop.example.com/orders"}
+	assert.Equal(t, proto.PageHandleJavaScriptDialog{Accept: false}, log.answer(confirm), "dialogs are dismissed until a policy is set")
+
+	log.accept, log.promptText = true, "Ada"
+	prompt := &proto.PageJavascriptDialogOpening{Type: proto.PageDialogTypePrompt, Message: "Your name?"}
+	assert.Equal(t, proto.PageHandleJavaScriptDialog{Accept: true, PromptText: "Ada"}, log.answer(prompt))
+	alert := &proto.PageJavascriptDialogOpening{Type: proto.PageDialogTypeAlert, Message: "Saved"}
+	assert.Equal(t, proto.PageHandleJavaScriptDialog{Accept: true}, log.answer(alert), "only prompts get the text")
+
+	assert.Equal(t, []Dialog{
+		{Type: "confirm", Message: "Delete this order?", URL: "https://shop.example.com/orders"},
+		{Type: "prompt", Message: "Your name?", Accepted: true},
+		{Type: "alert", Message: "Saved", Accepted: true},
+	}, log.take())
+	assert.Empty(t, log.take(), "dialogs are only taken once")
+}
+
+func TestWebPlatform_Dialogs_NotInitialized(t *testing.T) {
+	w := NewWebPlatform()
+	_, err := w.TakeDialogs()
+	require.Error(t, err)
+	require.Error(t, w.SetDialogPolicy(true, ""))
+}
@@ -0,0 +1,125 @@
+package platforms
+
+import (
+	"encoding/json"
+	"fmt"
+	"image"
+
+	"github.com/go-rod/rod"
+	"github.com/go-rod/rod/lib/proto"
+)
+
+// maxTextElements caps how many elements VisibleText returns, so pages with
+// huge lists stay cheap to audit
+const maxTextElements = 2000
+
+// TextElement is an element showing text of its own, with its boxes in
+// full-page screenshot pixels: Content is the box its text takes, which
+// exceeds Bounds when the text overflows, and Clip the box of the nearest
+// element cutting overflowing content off, empty when none does
+type TextElement struct {
+	Text     string          `json:"text"`
+	Selector string          `json:"selector"`
+	Bounds   image.Rectangle `json:"bounds"`
+	Content  image.Rectangle `json:"content"`
+	Clip     image.Rectangle `json:"clip"`
+}
+
+// visibleTextScript lists the visible elements with text nodes of their own,
+// leaving out those inside an excluded selector. Only overflow hidden and
+// clip cut text off; scrollable boxes let it be scrolled to.
+const visibleTextScript = `(exclude) => {
+	const d = window.devicePixelRatio || 1, sx = window.scrollX, sy = window.scrollY;
+	const box = (l, t, r, b) => [Math.floor((l + sx) * d), Math.floor((t + sy) * d), Math.ceil((r + sx) * d), Math.ceil((b + sy) * d)];
+	const clips = s => s.overflowX === 'hidden' || s.overflowX === 'clip' || s.overflowY === 'hidden' || s.overflowY === 'clip';
+	const path = el => {
+		const parts = [];
+		for (; el && el.nodeType === 1 && el !== document.body && parts.length < 5; el = el.parentElement) {
+			if (el.id) { parts.unshift('#' + CSS.escape(el.id)); break; }
+			let part = el.tagName.toLowerCase();
+			const same = el.parentElement ? [...el.parentElement.children].filter(c => c.tagName === el.tagName) : [];
+			if (same.length > 1) part += ':nth-of-type(' + (same.indexOf(el) + 1) + ')';
+			parts.unshift(part);
+		}
+		return parts.join(' > ');
+	};
+	const out = [];
+	for (const el of document.body.querySelectorAll('*')) {
+		if (out.length >= %d) break;
+		if (['SCRIPT', 'STYLE', 'NOSCRIPT', 'TEMPLATE'].includes(el.tagName)) continue;
+		if (exclude.some(sel => el.closest(sel))) continue;
+		let text = '';
+		for (const n of el.childNodes) if (n.nodeType === 3) text += n.textContent;
+		text = text.replace(/\s+/g, ' ').trim();
+		if (!text) continue;
+		const r = el.getBoundingClientRect(), style = getComputedStyle(el);
+		if (r.width === 0 || r.height === 0 || style.visibility === 'hidden' || style.opacity === '0') continue;
+		const item = {
+			text, selector: path(el),
+			bounds: box(r.left, r.top, r.right, r.bottom),
+			content: box(r.left, r.top, r.left + Math.max(r.width, el.scrollWidth), r.top + Math.max(r.height, el.scrollHeight)),
+		};
+		for (let c = el; c && c !== document.body && c !== document.documentElement; c = c.parentElement) {
+			if (clips(getComputedStyle(c))) {
+				const cr = c.getBoundingClientRect();
+				item.clip = box(cr.left, cr.top, cr.right, cr.bottom);
+				break;
+			}
+		}
+		out.push(item);
+	}
+	return out;
+}`
+
+// applyLocale shows page in the app's locale: the JavaScript Intl APIs and
+// navigator.language follow it, and requests ask for it in Accept-Language
+func (w *WebPlatform) applyLocale(page *rod.Page) error {
+	if w.locale == "" {
+		return nil
+	}
+	if err := (proto.EmulationSetLocaleOverride{Locale: w.locale}).Call(page); err != nil {
+		return fmt.Errorf("failed to set locale %s: %w", w.locale, err)
+	}
+	if _, err := page.SetExtraHeaders([]string{"Accept-Language", w.locale}); err != nil {
+		return fmt.Errorf("failed to set locale %s: %w", w.locale, err)
+	}
+	return nil
+}
+
+// VisibleText lists the elements of the active tab showing text, leaving out
+// those inside an element matching one of exclude
+func (w *WebPlatform) VisibleText(exclude []string) ([]TextElement, error) {
+	if w.page == nil {
+		return nil, fmt.Errorf("web page not initialized")
+	}
+	if exclude == nil {
+		exclude = []string{}
+	}
+	res, err := w.page.Eval(fmt.Sprintf(visibleTextScript, maxTextElements), exclude)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the page text: %w", err)
+	}
+	var raw []struct {
+		Text     string `json:"text"`
+		Selector string `json:"selector"`
+		Bounds   []int  `json:"bounds"`
+		Content  []int  `json:"content"`
+		Clip     []int  `json:"clip"`
+	}
+	if err := json.Unmarshal([]byte(res.Value.JSON("", "")), &raw); err != nil {
+		return nil, fmt.Errorf("failed to read the page text: %w", err)
+	}
+	elements := make([]TextElement, len(raw))
+	for i, r := range raw {
+		elements[i] = TextElement{Text: r.Text, Selector: r.Selector, Bounds: boxRect(r.Bounds), Content: boxRect(r.Content), Clip: boxRect(r.Clip)}
+	}
+	return elements, nil
+}
+
+// boxRect reads an [x0, y0, x1, y1] box
+func boxRect(box []int) image.Rectangle {
+	if len(box) != 4 {
+		return image.Rectangle{}
+	}
+	return image.Rect(box[0], box[1], box[2], box[3])
+}
@@ -0,0 +1,18 @@
+package platforms
+
+import (
+	"image"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWebPlatform_VisibleText_NotInitialized(t *testing.T) {
+	w := NewWebPlatform()
+	_, err := w.VisibleText(nil)
+	require.Error(t, err)
+
+	assert.Equal(t, image.Rect(1, 2, 30, 40), boxRect([]int{1, 2, 30, 40}))
+	assert.True(t, boxRect(nil).Empty())
+}
@@ -0,0 +1,20 @@
+package platforms
+
+import (
+	"fmt"
+
+	"github.com/go-rod/rod/lib/proto"
+)
+
+// JSHeapUsage returns the bytes the JavaScript heap of the page's isolate
+// uses, for spotting pages that leak memory over a long session
+func (w *WebPlatform) JSHeapUsage() (uint64, error) {
+	if w.page == nil {
+		return 0, fmt.Errorf("web page not initialized")
+	}
+	usage, err := proto.RuntimeGetHeapUsage{}.Call(w.page)
+	if err != nil {
+		return 0, fmt.Errorf("failed to read JavaScript heap usage: %w", err)
+	}
+	return uint64(usage.UsedSize), nil
+}
@@ -0,0 +1,12 @@
+package platforms
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestWebPlatform_JSHeapUsage_NotInitialized(t *testing.T) {
+	_, err := NewWebPlatform().JSHeapUsage()
+	require.Error(t, err)
+}
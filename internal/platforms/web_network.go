@@ -0,0 +1,52 @@
+package platforms
+
+import (
+	"fmt"
+
+	"panoptic/internal/config"
+
+	"github.com/go-rod/rod/lib/proto"
+)
+
+// networkConditionsParams extends Network.emulateNetworkConditions with the
+// packet loss parameter of newer Chromium releases, which rod's protocol
+// bindings predate
+type networkConditionsParams struct {
+	proto.NetworkEmulateNetworkConditions
+	PacketLoss float64 `json:"packetLoss,omitempty"`
+}
+
+// EmulateNetwork throttles or disconnects the network of the page, and of
+// its service workers, through CDP. Conditions must be resolved; zero
+// throughputs disable throttling.
+func (w *WebPlatform) EmulateNetwork(conditions config.NetworkConditions) error {
+	if w.page == nil {
+		return fmt.Errorf("web page not initialized")
+	}
+	if err := (proto.NetworkEnable{}).Call(w.page); err != nil {
+		return fmt.Errorf("failed to enable network domain: %w", err)
+	}
+
+	params := networkConditionsParams{
+		NetworkEmulateNetworkConditions: proto.NetworkEmulateNetworkConditions{
+			Offline:            conditions.Offline,
+			Latency:            conditions.LatencyMS,
+			DownloadThroughput: throughput(conditions.DownloadKbps),
+			UploadThroughput:   throughput(conditions.UploadKbps),
+		},
+		PacketLoss: conditions.PacketLoss,
+	}
+	if _, err := w.page.Call(w.page.GetContext(), string(w.page.SessionID), params.ProtoReq(), params); err != nil {
+		return fmt.Errorf("failed to emulate network conditions: %w", err)
+	}
+	return w.emulateWorkerNetwork(params)
+}
+
+// throughput converts kilobits per second to the bytes per second CDP takes;
+// -1 disables throttling
+func throughput(kbps float64) float64 {
+	if kbps <= 0 {
+		return -1
+	}
+	return kbps * 1000 / 8
+}
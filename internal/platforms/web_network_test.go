@@ -0,0 +1,34 @@
+package platforms
+
+import (
+	"encoding/json"
+	"testing"
+
+	"panoptic/internal/config"
+
+	"github.com/go-rod/rod/lib/proto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestWebPlatform_EmulateNetwork_NilPage tests emulation before initialization
+func TestWebPlatform_EmulateNetwork_NilPage(t *testing.T) {
+	err := NewWebPlatform().EmulateNetwork(config.NetworkConditions{Offline: true})
+	assert.ErrorContains(t, err, "web page not initialized")
+}
+
+// TestNetworkConditionsParams tests the CDP parameters, including packet loss
+func TestNetworkConditionsParams(t *testing.T) {
+	params := networkConditionsParams{
+		NetworkEmulateNetworkConditions: proto.NetworkEmulateNetworkConditions{
+			Latency:            2000,
+			DownloadThroughput: throughput(400),
+			UploadThroughput:   throughput(0),
+		},
+		PacketLoss: 5,
+	}
+	data, err := json.Marshal(params)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"offline":false,"latency":2000,"downloadThroughput":50000,"uploadThroughput":-1,"packetLoss":5}`, string(data))
+	assert.Equal(t, "Network.emulateNetworkConditions", params.ProtoReq())
+}
@@ -0,0 +1,144 @@
+package platforms
+
+import (
+	"encoding/base64"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"time"
+
+	"panoptic/internal/config"
+
+	"github.com/go-rod/rod"
+	"github.com/go-rod/rod/lib/launcher"
+	"github.com/go-rod/rod/lib/proto"
+)
+
+// maxFetchedDocument bounds what FetchDocument reads
+const maxFetchedDocument = 50 << 20
+
+// fetchScript downloads a URL with the page's cookies, returning the body
+// base64-encoded
+const fetchScript = `async (url) => {
+	const res = await fetch(url, {credentials: 'include'});
+	if (!res.ok) throw new Error('HTTP ' + res.status);
+	const bytes = new Uint8Array(await res.arrayBuffer());
+	if (bytes.length > %d) throw new Error('document is larger than %d bytes');
+	let binary = '';
+	for (let i = 0; i < bytes.length; i += 0x8000) binary += String.fromCharCode.apply(null, bytes.subarray(i, i + 0x8000));
+	return btoa(binary);
+}`
+
+// SavePDF prints the active tab to a PDF file, as the browser's print
+// dialog would. Chromium prints to PDF only when headless.
+func (w *WebPlatform) SavePDF(path string, spec *config.PDFPrint) error {
+	if w.page == nil {
+		return fmt.Errorf("web page not initialized")
+	}
+	width, height := spec.Paper()
+	scale := spec.Scale
+	stream, err := w.page.PDF(&proto.PagePrintToPDF{
+		Landscape:       spec.Landscape,
+		PrintBackground: spec.PrintBackground,
+		Scale:           &scale,
+		PaperWidth:      &width,
+		PaperHeight:     &height,
+		PageRanges:      spec.PageRanges,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to print the page to PDF: %w", err)
+	}
+	defer stream.Close()
+	return savePDFStream(path, stream)
+}
+
+// printTimeout bounds loading and printing a file with PrintPDF
+const printTimeout = 2 * time.Minute
+
+// pageNumberFooter numbers the pages PrintPDF prints
+const pageNumberFooter = `<div style="width:100%;text-align:center;font-size:8px;color:#888"><span class="pageNumber"></span> / <span class="totalPages"></span></div>`
+
+// PrintPDF prints a local HTML file to a PDF with numbered pages, in a
+// headless Chromium of its own: a report rather than an app under test
+func PrintPDF(htmlPath, path string, spec *config.PDFPrint) error {
+	abs, err := filepath.Abs(htmlPath)
+	if err != nil {
+		return err
+	}
+	if _, err := os.Stat(abs); err != nil {
+		return err
+	}
+	l := launcher.New().Headless(true)
+	controlURL, err := l.Launch()
+	if err != nil {
+		return fmt.Errorf("failed to launch chromium: %w", err)
+	}
+	defer l.Kill()
+	browser := rod.New().ControlURL(controlURL)
+	if err := browser.Connect(); err != nil {
+		return fmt.Errorf("failed to connect to chromium: %w", err)
+	}
+	defer browser.Close()
+
+	fileURL := (&url.URL{Scheme: "file", Path: filepath.ToSlash(abs)}).String()
+	page, err := browser.Timeout(printTimeout).Page(proto.TargetCreateTarget{URL: fileURL})
+	if err != nil {
+		return fmt.Errorf("failed to open %s: %w", htmlPath, err)
+	}
+	if err := page.WaitLoad(); err != nil {
+		return fmt.Errorf("failed to load %s: %w", htmlPath, err)
+	}
+	width, height := spec.Paper()
+	scale := spec.Scale
+	stream, err := page.PDF(&proto.PagePrintToPDF{
+		Landscape:           spec.Landscape,
+		PrintBackground:     spec.PrintBackground,
+		Scale:               &scale,
+		PaperWidth:          &width,
+		PaperHeight:         &height,
+		PageRanges:          spec.PageRanges,
+		DisplayHeaderFooter: true,
+		HeaderTemplate:      "<span></span>",
+		FooterTemplate:      pageNumberFooter,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to print %s to PDF: %w", htmlPath, err)
+	}
+	defer stream.Close()
+	return savePDFStream(path, stream)
+}
+
+// savePDFStream writes a printed PDF to path
+func savePDFStream(path string, stream io.Reader) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	out, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, stream); err != nil {
+		out.Close()
+		return fmt.Errorf("failed to save %s: %w", path, err)
+	}
+	return out.Close()
+}
+
+// FetchDocument downloads url from the active tab, with its cookies, as a
+// link the user follows would
+func (w *WebPlatform) FetchDocument(url string) ([]byte, error) {
+	if w.page == nil {
+		return nil, fmt.Errorf("web page not initialized")
+	}
+	res, err := w.page.Eval(fmt.Sprintf(fetchScript, maxFetchedDocument, maxFetchedDocument), url)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch %s: %w", url, err)
+	}
+	data, err := base64.StdEncoding.DecodeString(res.Value.Str())
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch %s: %w", url, err)
+	}
+	return data, nil
+}
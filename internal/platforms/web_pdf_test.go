@@ -0,0 +1,18 @@
+package platforms
+
+import (
+	"testing"
+
+	"panoptic/internal/config"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWebPlatform_PDF_NotInitialized(t *testing.T) {
+	w := NewWebPlatform()
+	require.Error(t, w.SavePDF(t.TempDir()+"/page.pdf", &config.PDFPrint{Format: "a4", Scale: 1}))
+	_, err := w.FetchDocument("https://shop.test/invoice.pdf")
+	require.Error(t, err)
+	assert.True(t, w.Capabilities().Has(CapabilityPDF))
+}
@@ -0,0 +1,240 @@
+package platforms
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/go-rod/rod/lib/proto"
+)
+
+// maxCachedURLs bounds the URLs listed of each cache
+const maxCachedURLs = 1000
+
+// ServiceWorker is a service worker registered for the page's origin
+type ServiceWorker struct {
+	Scope     string `json:"scope"`
+	ScriptURL string `json:"script_url"`
+	State     string `json:"state"` // of its active worker, else the waiting or installing one
+}
+
+// Cache is a Cache Storage cache of the page's origin
+type Cache struct {
+	Name string   `json:"name"`
+	URLs []string `json:"urls"` // of the cached requests, at most 1000
+}
+
+// Installability is whether the browser offers to install the page as an
+// app, and why not
+type Installability struct {
+	ManifestURL string   `json:"manifest_url,omitempty"`
+	Name        string   `json:"name,omitempty"` // the manifest's name or short_name
+	Errors      []string `json:"errors,omitempty"`
+}
+
+// serviceWorkersScript lists the registrations of the page's origin
+const serviceWorkersScript = `async () => {
+	if (!('serviceWorker' in navigator)) return []
+	const regs = await navigator.serviceWorker.getRegistrations()
+	return regs.map(r => {
+		const w = r.active || r.waiting || r.installing
+		return {scope: r.scope, script_url: w ? w.scriptURL : '', state: w ? w.state : ''}
+	})
+}`
+
+// unregisterScript unregisters the registrations whose scope contains
+// scope, and deletes the origin's caches when asked
+const unregisterScript = `async (scope, clearCaches) => {
+	let n = 0
+	if ('serviceWorker' in navigator) {
+		for (const r of await navigator.serviceWorker.getRegistrations()) {
+			if (r.scope.includes(scope) && await r.unregister()) n++
+		}
+	}
+	if (clearCaches && 'caches' in window) {
+		for (const name of await caches.keys()) await caches.delete(name)
+	}
+	return n
+}`
+
+// updateScript checks the registrations whose scope contains scope for a
+// new worker script
+const updateScript = `async (scope) => {
+	if (!('serviceWorker' in navigator)) return 0
+	const regs = (await navigator.serviceWorker.getRegistrations()).filter(r => r.scope.includes(scope))
+	await Promise.all(regs.map(r => r.update()))
+	return regs.length
+}`
+
+// cachesScript lists the caches of the page's origin and their requests
+const cachesScript = `async (max) => {
+	if (!('caches' in window)) return []
+	const out = []
+	for (const name of await caches.keys()) {
+		const reqs = await (await caches.open(name)).keys()
+		out.push({name, urls: reqs.slice(0, max).map(r => r.url)})
+	}
+	return out
+}`
+
+// ServiceWorkers lists the service workers registered for the origin of
+// the active tab
+func (w *WebPlatform) ServiceWorkers() ([]ServiceWorker, error) {
+	if w.page == nil {
+		return nil, fmt.Errorf("web page not initialized")
+	}
+	res, err := w.page.Eval(serviceWorkersScript)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list service workers: %w", err)
+	}
+	var workers []ServiceWorker
+	if err := json.Unmarshal([]byte(res.Value.JSON("", "")), &workers); err != nil {
+		return nil, fmt.Errorf("failed to list service workers: %w", err)
+	}
+	return workers, nil
+}
+
+// UnregisterServiceWorkers unregisters the service workers whose scope
+// contains scope, every one when it's empty, and clears the origin's Cache
+// Storage when asked. It returns how many it unregistered.
+func (w *WebPlatform) UnregisterServiceWorkers(scope string, clearCaches bool) (int, error) {
+	if w.page == nil {
+		return 0, fmt.Errorf("web page not initialized")
+	}
+	res, err := w.page.Eval(unregisterScript, scope, clearCaches)
+	if err != nil {
+		return 0, fmt.Errorf("failed to unregister service workers: %w", err)
+	}
+	return res.Value.Int(), nil
+}
+
+// UpdateServiceWorkers has the service workers whose scope contains scope
+// fetch their script again, installing a changed one. It returns how many
+// it checked.
+func (w *WebPlatform) UpdateServiceWorkers(scope string) (int, error) {
+	if w.page == nil {
+		return 0, fmt.Errorf("web page not initialized")
+	}
+	res, err := w.page.Eval(updateScript, scope)
+	if err != nil {
+		return 0, fmt.Errorf("failed to update service workers: %w", err)
+	}
+	return res.Value.Int(), nil
+}
+
+// CacheStorage lists the Cache Storage caches of the active tab's origin
+func (w *WebPlatform) CacheStorage() ([]Cache, error) {
+	if w.page == nil {
+		return nil, fmt.Errorf("web page not initialized")
+	}
+	res, err := w.page.Eval(cachesScript, maxCachedURLs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read cache storage: %w", err)
+	}
+	var caches []Cache
+	if err := json.Unmarshal([]byte(res.Value.JSON("", "")), &caches); err != nil {
+		return nil, fmt.Errorf("failed to read cache storage: %w", err)
+	}
+	return caches, nil
+}
+
+// Installability asks the browser whether it would offer to install the
+// active tab as an app
+func (w *WebPlatform) Installability() (*Installability, error) {
+	if w.page == nil {
+		return nil, fmt.Errorf("web page not initialized")
+	}
+	res, err := proto.PageGetInstallabilityErrors{}.Call(w.page)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check installability: %w", err)
+	}
+	install := &Installability{Errors: installabilityErrors(res.InstallabilityErrors)}
+	if manifest, err := (proto.PageGetAppManifest{}).Call(w.page); err == nil {
+		install.ManifestURL = manifest.URL
+		var data struct {
+			Name      string `json:"name"`
+			ShortName string `json:"short_name"`
+		}
+		if json.Unmarshal([]byte(manifest.Data), &data) == nil {
+			install.Name = data.Name
+			if install.Name == "" {
+				install.Name = data.ShortName
+			}
+		}
+	}
+	return install, nil
+}
+
+// installabilityErrors describes the errors as their IDs with their
+// arguments, e.g. "manifest-missing-suitable-icon (minimum-icon-size-in-pixels=144)"
+func installabilityErrors(errs []*proto.PageInstallabilityError) []string {
+	var out []string
+	for _, e := range errs {
+		var args []string
+		for _, a := range e.ErrorArguments {
+			args = append(args, a.Name+"="+a.Value)
+		}
+		sort.Strings(args)
+		if len(args) > 0 {
+			out = append(out, fmt.Sprintf("%s (%s)", e.ErrorID, strings.Join(args, ", ")))
+		} else {
+			out = append(out, e.ErrorID)
+		}
+	}
+	return out
+}
+
+// PageText is the text the active tab shows
+func (w *WebPlatform) PageText() (string, error) {
+	if w.page == nil {
+		return "", fmt.Errorf("web page not initialized")
+	}
+	res, err := w.page.Eval(`() => document.body ? document.body.innerText : ''`)
+	if err != nil {
+		return "", fmt.Errorf("failed to read the page text: %w", err)
+	}
+	return res.Value.Str(), nil
+}
+
+// emulateWorkerNetwork applies network conditions to the service workers of
+// the app's browser context, whose requests the page's conditions don't
+// cover. Sessions stay attached, as conditions end with them; workers
+// started later run unthrottled.
+func (w *WebPlatform) emulateWorkerNetwork(params networkConditionsParams) error {
+	if w.browser == nil {
+		return nil
+	}
+	info, err := proto.TargetGetTargetInfo{TargetID: w.page.TargetID}.Call(w.browser)
+	if err != nil {
+		return fmt.Errorf("failed to find the page's service workers: %w", err)
+	}
+	targets, err := proto.TargetGetTargets{}.Call(w.browser)
+	if err != nil {
+		return fmt.Errorf("failed to find the page's service workers: %w", err)
+	}
+	for _, target := range targets.TargetInfos {
+		if target.Type != proto.TargetTargetInfoTypeServiceWorker || target.BrowserContextID != info.TargetInfo.BrowserContextID {
+			continue
+		}
+		session, ok := w.workerSessions[target.TargetID]
+		if !ok {
+			attached, err := proto.TargetAttachToTarget{TargetID: target.TargetID, Flatten: true}.Call(w.browser)
+			if err != nil {
+				continue // the worker stopped
+			}
+			session = attached.SessionID
+			if w.workerSessions == nil {
+				w.workerSessions = make(map[proto.TargetTargetID]proto.TargetSessionID)
+			}
+			w.workerSessions[target.TargetID] = session
+			if _, err := w.browser.Call(w.page.GetContext(), string(session), proto.NetworkEnable{}.ProtoReq(), proto.NetworkEnable{}); err != nil {
+				continue
+			}
+		}
+		if _, err := w.browser.Call(w.page.GetContext(), string(session), params.ProtoReq(), params); err != nil {
+			delete(w.workerSessions, target.TargetID)
+		}
+	}
+	return nil
+}
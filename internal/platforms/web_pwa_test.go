@@ -0,0 +1,37 @@
+package platforms
+
+import (
+	"testing"
+
+	"github.com/go-rod/rod/lib/proto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInstallabilityErrors(t *testing.T) {
+	errs := []*proto.PageInstallabilityError{
+		{ErrorID: "no-manifest"},
+		{ErrorID: "manifest-missing-suitable-icon", ErrorArguments: []*proto.PageInstallabilityErrorArgument{
+			{Name: "minimum-icon-size-in-pixels", Value: "144"},
+		}},
+	}
+	assert.Equal(t, []string{"no-manifest", "manifest-missing-suitable-icon (minimum-icon-size-in-pixels=144)"}, installabilityErrors(errs))
+	assert.Empty(t, installabilityErrors(nil))
+}
+
+func TestWebPlatform_PWA_NotInitialized(t *testing.T) {
+	w := NewWebPlatform()
+	_, err := w.ServiceWorkers()
+	require.Error(t, err)
+	_, err = w.UnregisterServiceWorkers("", true)
+	require.Error(t, err)
+	_, err = w.UpdateServiceWorkers("")
+	require.Error(t, err)
+	_, err = w.CacheStorage()
+	require.Error(t, err)
+	_, err = w.Installability()
+	require.Error(t, err)
+	_, err = w.PageText()
+	require.Error(t, err)
+	assert.True(t, w.Capabilities().Has(CapabilityPWA))
+}
@@ -0,0 +1,94 @@
+package platforms
+
+import (
+	"encoding/json"
+	"fmt"
+	"image"
+	"math"
+
+	"panoptic/internal/redact"
+	"panoptic/internal/vision"
+)
+
+// maskedBoxesScript returns the boxes of the elements matching selectors in
+// screenshot pixels: of the full page, or of the viewport
+const maskedBoxesScript = `(selectors, fullPage) => {
+	const d = window.devicePixelRatio || 1;
+	const sx = fullPage ? window.scrollX : 0, sy = fullPage ? window.scrollY : 0;
+	const boxes = [];
+	for (const s of selectors) {
+		let found = [];
+		try { found = document.querySelectorAll(s); } catch (e) { continue; }
+		for (const el of found) {
+			const r = el.getBoundingClientRect();
+			if (r.width > 0 && r.height > 0) boxes.push({x: (r.left + sx) * d, y: (r.top + sy) * d, width: r.width * d, height: r.height * d});
+		}
+	}
+	return boxes;
+}`
+
+// maskedHTMLScript returns the page's HTML with the contents and values of
+// the elements matching selectors replaced
+const maskedHTMLScript = `(selectors, replacement) => {
+	const root = document.documentElement.cloneNode(true);
+	for (const s of selectors) {
+		let found = [];
+		try { found = root.querySelectorAll(s); } catch (e) { continue; }
+		for (const el of found) {
+			el.textContent = replacement;
+			if (el.hasAttribute('value')) el.setAttribute('value', replacement);
+		}
+	}
+	return '<!DOCTYPE html>' + root.outerHTML;
+}`
+
+// SetRedaction blurs the elements matching selectors in every screenshot
+// and masks them in the page state, whose text redactor redacts too
+func (w *WebPlatform) SetRedaction(selectors []string, redactor *redact.Redactor) {
+	w.maskedSelectors = selectors
+	w.redactor = redactor
+}
+
+// maskedRegions are the boxes of the masked elements on the page
+func (w *WebPlatform) maskedRegions(fullPage bool) ([]image.Rectangle, error) {
+	res, err := w.page.Eval(maskedBoxesScript, w.maskedSelectors, fullPage)
+	if err != nil {
+		return nil, fmt.Errorf("failed to locate masked elements: %w", err)
+	}
+	var boxes []struct{ X, Y, Width, Height float64 }
+	if err := json.Unmarshal([]byte(res.Value.JSON("", "")), &boxes); err != nil {
+		return nil, fmt.Errorf("failed to locate masked elements: %w", err)
+	}
+	regions := make([]image.Rectangle, len(boxes))
+	for i, b := range boxes {
+		regions[i] = image.Rect(int(math.Floor(b.X)), int(math.Floor(b.Y)), int(math.Ceil(b.X+b.Width)), int(math.Ceil(b.Y+b.Height)))
+	}
+	return regions, nil
+}
+
+// redactScreenshot blurs the masked elements in a screenshot. A screenshot
+// that can't be redacted is never returned.
+func (w *WebPlatform) redactScreenshot(data []byte, fullPage bool) ([]byte, error) {
+	if len(w.maskedSelectors) == 0 {
+		return data, nil
+	}
+	regions, err := w.maskedRegions(fullPage)
+	if err != nil {
+		return nil, err
+	}
+	return vision.RedactImage(data, regions)
+}
+
+// pageHTML is the page's HTML, with masked elements and redacted text
+// replaced
+func (w *WebPlatform) pageHTML() (string, error) {
+	if len(w.maskedSelectors) == 0 {
+		html, err := w.page.HTML()
+		return w.redactor.String(html), err
+	}
+	res, err := w.page.Eval(maskedHTMLScript, w.maskedSelectors, w.redactor.Replacement())
+	if err != nil {
+		return "", err
+	}
+	return w.redactor.String(res.Value.Str()), nil
+}
@@ -0,0 +1,24 @@
+package platforms
+
+import (
+	"testing"
+
+	"panoptic/internal/redact"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWebPlatform_SetRedaction(t *testing.T) {
+	w := NewWebPlatform()
+	data, err := w.redactScreenshot([]byte("png"), true)
+	require.NoError(t, err)
+	assert.Equal(t, []byte("png"), data, "nothing masked leaves screenshots as captured")
+
+	redactor, err := redact.New(nil, "")
+	require.NoError(t, err)
+	w.SetRedaction([]string{"#card"}, redactor)
+	assert.Equal(t, []string{"#card"}, w.maskedSelectors)
+	_, err = w.GetPageState()
+	assert.Error(t, err)
+}
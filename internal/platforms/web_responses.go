@@ -0,0 +1,95 @@
+package platforms
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"strings"
+
+	"github.com/go-rod/rod/lib/proto"
+)
+
+// maxAPIResponseBody bounds the response bodies ObserveResponses reads
+const maxAPIResponseBody = 1 << 20
+
+// APIResponse is a JSON response to a fetch or XHR request of the page
+type APIResponse struct {
+	Method   string
+	URL      string
+	Status   int
+	MimeType string
+	Body     []byte
+}
+
+// isJSONMimeType tells whether a response's MIME type is JSON, e.g.
+// application/json or application/problem+json
+func isJSONMimeType(mime string) bool {
+	mime = strings.ToLower(strings.TrimSpace(strings.SplitN(mime, ";", 2)[0]))
+	return mime == "application/json" || strings.HasSuffix(mime, "+json")
+}
+
+// ObserveResponses reports the JSON responses to the fetch and XHR
+// requests of the active tab whose URLs covers accepts, with their bodies,
+// until the returned stop function is called. Bodies over 1MB are left
+// out. fn is invoked from a single background goroutine.
+func (w *WebPlatform) ObserveResponses(covers func(url string) bool, fn func(APIResponse)) (func(), error) {
+	if w.page == nil {
+		return nil, fmt.Errorf("web page not initialized")
+	}
+
+	ctx, cancel := context.WithCancel(w.page.GetContext())
+	page := w.page.Context(ctx)
+	if err := (proto.NetworkEnable{}).Call(page); err != nil {
+		cancel()
+		return nil, fmt.Errorf("failed to enable network events: %w", err)
+	}
+
+	pending := make(map[proto.NetworkRequestID]*APIResponse)
+	wait := page.EachEvent(
+		func(e *proto.NetworkRequestWillBeSent) {
+			if e.Request == nil || (e.Type != proto.NetworkResourceTypeFetch && e.Type != proto.NetworkResourceTypeXHR) {
+				return
+			}
+			if covers(e.Request.URL) {
+				pending[e.RequestID] = &APIResponse{Method: e.Request.Method, URL: e.Request.URL}
+			}
+		},
+		func(e *proto.NetworkResponseReceived) {
+			resp, ok := pending[e.RequestID]
+			if !ok || e.Response == nil {
+				return
+			}
+			if !isJSONMimeType(e.Response.MIMEType) {
+				delete(pending, e.RequestID)
+				return
+			}
+			resp.Status, resp.MimeType = e.Response.Status, e.Response.MIMEType
+		},
+		func(e *proto.NetworkLoadingFinished) {
+			resp, ok := pending[e.RequestID]
+			delete(pending, e.RequestID)
+			if !ok || resp.Status == 0 || e.EncodedDataLength > maxAPIResponseBody {
+				return
+			}
+			body, err := proto.NetworkGetResponseBody{RequestID: e.RequestID}.Call(page)
+			if err != nil {
+				return // evicted, or the page navigated away
+			}
+			resp.Body = []byte(body.Body)
+			if body.Base64Encoded {
+				if resp.Body, err = base64.StdEncoding.DecodeString(body.Body); err != nil {
+					return
+				}
+			}
+			if len(resp.Body) <= maxAPIResponseBody {
+				fn(*resp)
+			}
+		},
+		func(e *proto.NetworkLoadingFailed) {
+			delete(pending, e.RequestID)
+		},
+	)
+	go wait()
+
+	return cancel, nil
+}
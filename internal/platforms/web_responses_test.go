@@ -0,0 +1,23 @@
+package platforms
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIsJSONMimeType(t *testing.T) {
+	for mime, want := range map[string]bool{
+		"application/json":                true,
+		"application/json; charset=utf-8": true,
+		"Application/JSON":                true,
+		"application/problem+json":        true,
+		"application/vnd.api+json":        true,
+		"text/json":                       false,
+		"text/html":                       false,
+		"application/javascript":          false,
+		"":                                false,
+	} {
+		assert.Equal(t, want, isJSONMimeType(mime), mime)
+	}
+}
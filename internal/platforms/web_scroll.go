@@ -0,0 +1,71 @@
+package platforms
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// ScrollPosition is where a page is scrolled to, and how tall it is, which
+// grows as infinite lists load more
+type ScrollPosition struct {
+	X          float64 `json:"x"`
+	Y          float64 `json:"y"`
+	PageHeight float64 `json:"page_height"`
+}
+
+// scrollScript scrolls the element matching selector into view, or the
+// window to or by x and y, without smooth scrolling, and returns where the
+// page ends up
+const scrollScript = `(selector, by, x, y) => {
+	if (selector) {
+		const el = document.querySelector(selector)
+		if (!el) throw new Error('no element matches ' + selector)
+		el.scrollIntoView({block: 'center', inline: 'nearest', behavior: 'instant'})
+	} else if (by) {
+		window.scrollBy({left: x, top: y, behavior: 'instant'})
+	} else {
+		window.scrollTo({left: x, top: y, behavior: 'instant'})
+	}
+	const root = document.scrollingElement || document.documentElement
+	return {x: window.scrollX, y: window.scrollY, page_height: root.scrollHeight}
+}`
+
+// scroll runs scrollScript on the active tab
+func (w *WebPlatform) scroll(selector string, by bool, x, y float64) (ScrollPosition, error) {
+	if w.page == nil {
+		return ScrollPosition{}, fmt.Errorf("web page not initialized")
+	}
+	res, err := w.page.Eval(scrollScript, selector, by, x, y)
+	if err != nil {
+		return ScrollPosition{}, fmt.Errorf("failed to scroll: %w", err)
+	}
+	var pos ScrollPosition
+	if err := json.Unmarshal([]byte(res.Value.JSON("", "")), &pos); err != nil {
+		return ScrollPosition{}, fmt.Errorf("failed to scroll: %w", err)
+	}
+	return pos, nil
+}
+
+// ScrollTo scrolls the element matching selector into view or, without a
+// selector, the page to x and y
+func (w *WebPlatform) ScrollTo(selector string, x, y float64) (ScrollPosition, error) {
+	return w.scroll(selector, false, x, y)
+}
+
+// ScrollBy scrolls the page by x and y pixels
+func (w *WebPlatform) ScrollBy(x, y float64) (ScrollPosition, error) {
+	return w.scroll("", true, x, y)
+}
+
+// HasElement tells whether an element matching selector is on the page,
+// without waiting for one
+func (w *WebPlatform) HasElement(selector string) (bool, error) {
+	if w.page == nil {
+		return false, fmt.Errorf("web page not initialized")
+	}
+	res, err := w.page.Eval(`(selector) => document.querySelector(selector) !== null`, selector)
+	if err != nil {
+		return false, fmt.Errorf("failed to look for %s: %w", selector, err)
+	}
+	return res.Value.Bool(), nil
+}
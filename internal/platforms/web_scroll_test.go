@@ -0,0 +1,19 @@
+package platforms
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWebPlatform_Scroll_NotInitialized(t *testing.T) {
+	w := NewWebPlatform()
+	_, err := w.ScrollTo("#reviews", 0, 0)
+	require.Error(t, err)
+	_, err = w.ScrollBy(0, 600)
+	require.Error(t, err)
+	_, err = w.HasElement("footer")
+	require.Error(t, err)
+	assert.True(t, w.Capabilities().Has(CapabilityScroll))
+}
@@ -0,0 +1,288 @@
+package platforms
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/go-rod/rod/lib/proto"
+)
+
+// Tab is a tab or popup window of a web app's browser; Index counts the
+// tabs in the order they opened, from 0 for the app's own
+type Tab struct {
+	Index  int    `json:"index"`
+	Title  string `json:"title"`
+	URL    string `json:"url"`
+	Active bool   `json:"active"`
+}
+
+// tabList tracks the tabs of an app's browser context in the order they
+// opened, the tab actions act on and the popups not yet waited for
+type tabList struct {
+	open     []proto.TargetTargetID
+	active   proto.TargetTargetID
+	previous proto.TargetTargetID
+	popups   []proto.TargetTargetID
+	watched  map[proto.TargetTargetID]bool // whose console errors are collected
+}
+
+func newTabList(first proto.TargetTargetID) *tabList {
+	return &tabList{open: []proto.TargetTargetID{first}, active: first, watched: map[proto.TargetTargetID]bool{first: true}}
+}
+
+// sync adds the tabs opened since it last ran, as popups, and drops those
+// closed. Browsers list targets newest first.
+func (t *tabList) sync(ids []proto.TargetTargetID) {
+	present := make(map[proto.TargetTargetID]bool, len(ids))
+	for _, id := range ids {
+		present[id] = true
+	}
+	t.open = keepPresent(t.open, present)
+	t.popups = keepPresent(t.popups, present)
+	for i := len(ids) - 1; i >= 0; i-- {
+		if t.index(ids[i]) < 0 {
+			t.open = append(t.open, ids[i])
+			t.popups = append(t.popups, ids[i])
+		}
+	}
+}
+
+func keepPresent(ids []proto.TargetTargetID, present map[proto.TargetTargetID]bool) []proto.TargetTargetID {
+	kept := ids[:0]
+	for _, id := range ids {
+		if present[id] {
+			kept = append(kept, id)
+		}
+	}
+	return kept
+}
+
+// index is the position of the tab, or -1 when it isn't open
+func (t *tabList) index(id proto.TargetTargetID) int {
+	for i, open := range t.open {
+		if open == id {
+			return i
+		}
+	}
+	return -1
+}
+
+// remove drops a tab that closed
+func (t *tabList) remove(id proto.TargetTargetID) {
+	present := make(map[proto.TargetTargetID]bool, len(t.open))
+	for _, open := range t.open {
+		present[open] = open != id
+	}
+	t.open = keepPresent(t.open, present)
+	t.popups = keepPresent(t.popups, present)
+}
+
+// claimPopup takes the oldest popup not yet waited for
+func (t *tabList) claimPopup() (proto.TargetTargetID, bool) {
+	if len(t.popups) == 0 {
+		return "", false
+	}
+	id := t.popups[0]
+	t.popups = t.popups[1:]
+	return id, true
+}
+
+func (t *tabList) setActive(id proto.TargetTargetID) {
+	if id != t.active {
+		t.previous, t.active = t.active, id
+	}
+	for i, popup := range t.popups {
+		if popup == id {
+			t.popups = append(t.popups[:i], t.popups[i+1:]...)
+			break
+		}
+	}
+}
+
+// fallback is the tab to switch to once the active one closed: the one
+// active before it, or the first
+func (t *tabList) fallback() proto.TargetTargetID {
+	if t.index(t.previous) >= 0 {
+		return t.previous
+	}
+	return t.open[0]
+}
+
+// targets syncs the tab list with the pages of the app's browser context and
+// returns them by ID
+func (w *WebPlatform) targets() (map[proto.TargetTargetID]*proto.TargetTargetInfo, error) {
+	if w.page == nil || w.browser == nil {
+		return nil, fmt.Errorf("web page not initialized")
+	}
+	if w.tabs == nil {
+		info, err := w.page.Info()
+		if err != nil {
+			return nil, fmt.Errorf("failed to read the page's tab: %w", err)
+		}
+		w.tabs = newTabList(w.page.TargetID)
+		w.tabContext = info.BrowserContextID
+	}
+	list, err := proto.TargetGetTargets{}.Call(w.browser)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list tabs: %w", err)
+	}
+	infos := make(map[proto.TargetTargetID]*proto.TargetTargetInfo)
+	var ids []proto.TargetTargetID
+	for _, info := range list.TargetInfos {
+		if info.Type != proto.TargetTargetInfoTypePage || info.BrowserContextID != w.tabContext {
+			continue
+		}
+		infos[info.TargetID] = info
+		ids = append(ids, info.TargetID)
+	}
+	w.tabs.sync(ids)
+	return infos, nil
+}
+
+func (w *WebPlatform) tab(id proto.TargetTargetID, infos map[proto.TargetTargetID]*proto.TargetTargetInfo) Tab {
+	tab := Tab{Index: w.tabs.index(id), Active: id == w.tabs.active}
+	if info := infos[id]; info != nil {
+		tab.Title, tab.URL = info.Title, info.URL
+	}
+	return tab
+}
+
+// Tabs lists the app's open tabs in the order they opened
+func (w *WebPlatform) Tabs() ([]Tab, error) {
+	infos, err := w.targets()
+	if err != nil {
+		return nil, err
+	}
+	tabs := make([]Tab, len(w.tabs.open))
+	for i, id := range w.tabs.open {
+		tabs[i] = w.tab(id, infos)
+	}
+	return tabs, nil
+}
+
+// activate makes a tab the one actions, screenshots and recordings act on,
+// and brings it to the front
+func (w *WebPlatform) activate(id proto.TargetTargetID) error {
+	page, err := w.browser.PageFromTarget(id)
+	if err != nil {
+		return fmt.Errorf("failed to attach to tab: %w", err)
+	}
+	if _, err := page.Activate(); err != nil {
+		return fmt.Errorf("failed to bring tab to the front: %w", err)
+	}
+	if !w.tabs.watched[id] && w.console != nil {
+		if err := w.watchPageConsole(page); err != nil {
+			return err
+		}
+		w.watchPageDialogs(page)
+		if err := w.watchPageWebSockets(page); err != nil {
+			return err
+		}
+		if err := w.applyLocale(page); err != nil {
+			return err
+		}
+		w.tabs.watched[id] = true
+	}
+	if w.recorder != nil {
+		w.recorder.SwitchPage(page)
+	}
+	w.page = page
+	w.tabs.setActive(id)
+	return nil
+}
+
+// WaitForPopup switches to the oldest tab or popup opened since the last
+// tab action that no earlier WaitForPopup took, waiting up to timeout for one
+// to open, then for it to load
+func (w *WebPlatform) WaitForPopup(timeout time.Duration) (Tab, error) {
+	deadline := time.Now().Add(timeout)
+	for {
+		infos, err := w.targets()
+		if err != nil {
+			return Tab{}, err
+		}
+		if id, ok := w.tabs.claimPopup(); ok {
+			if err := w.activate(id); err != nil {
+				return Tab{}, err
+			}
+			if remaining := time.Until(deadline); remaining > 0 {
+				// a popup still loading is left to the next action to wait for
+				_ = w.page.Timeout(remaining).WaitLoad()
+			}
+			if infos, err = w.targets(); err != nil {
+				return Tab{}, err
+			}
+			return w.tab(id, infos), nil
+		}
+		if time.Now().After(deadline) {
+			return Tab{}, fmt.Errorf("no popup or tab opened within %s", timeout)
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+}
+
+// SwitchTab switches to the tab at index, or when index is negative to the
+// first whose title contains title, ignoring case
+func (w *WebPlatform) SwitchTab(index int, title string) (Tab, error) {
+	infos, err := w.targets()
+	if err != nil {
+		return Tab{}, err
+	}
+	var id proto.TargetTargetID
+	switch {
+	case index >= 0:
+		if index >= len(w.tabs.open) {
+			return Tab{}, fmt.Errorf("no tab %d: %d tab(s) open", index, len(w.tabs.open))
+		}
+		id = w.tabs.open[index]
+	default:
+		var titles []string
+		for _, open := range w.tabs.open {
+			tabTitle := w.tab(open, infos).Title
+			if strings.Contains(strings.ToLower(tabTitle), strings.ToLower(title)) {
+				id = open
+				break
+			}
+			titles = append(titles, fmt.Sprintf("%q", tabTitle))
+		}
+		if id == "" {
+			return Tab{}, fmt.Errorf("no tab titled %q; open tabs: %s", title, strings.Join(titles, ", "))
+		}
+	}
+	if err := w.activate(id); err != nil {
+		return Tab{}, err
+	}
+	return w.tab(id, infos), nil
+}
+
+// CloseTab closes the tab at index, or the active tab when index is
+// negative. Closing the active tab switches back to the tab active before
+// it; the last tab can't be closed.
+func (w *WebPlatform) CloseTab(index int) error {
+	if _, err := w.targets(); err != nil {
+		return err
+	}
+	id := w.tabs.active
+	if index >= 0 {
+		if index >= len(w.tabs.open) {
+			return fmt.Errorf("no tab %d: %d tab(s) open", index, len(w.tabs.open))
+		}
+		id = w.tabs.open[index]
+	}
+	if len(w.tabs.open) == 1 {
+		return fmt.Errorf("can't close the app's last tab")
+	}
+	page, err := w.browser.PageFromTarget(id)
+	if err != nil {
+		return fmt.Errorf("failed to attach to tab: %w", err)
+	}
+	if err := page.Close(); err != nil {
+		return fmt.Errorf("failed to close tab: %w", err)
+	}
+	w.tabs.remove(id)
+	if id != w.tabs.active {
+		return nil
+	}
+	return w.activate(w.tabs.fallback())
+}
@@ -0,0 +1,48 @@
+package platforms
+
+import (
+	"testing"
+	"time"
+
+	"github.com/go-rod/rod/lib/proto"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestTabList tests that tabs keep the order they opened in, and that popups
+// are claimed oldest first, once
+func TestTabList(t *testing.T) {
+	tabs := newTabList("app")
+	tabs.sync([]proto.TargetTargetID{"oauth", "app"})
+	tabs.sync([]proto.TargetTargetID{"help", "docs", "oauth", "app"}) // newest first
+	assert.Equal(t, []proto.TargetTargetID{"app", "oauth", "docs", "help"}, tabs.open)
+	assert.Equal(t, 2, tabs.index("docs"))
+	assert.Equal(t, -1, tabs.index("closed"))
+
+	popup, ok := tabs.claimPopup()
+	assert.True(t, ok)
+	assert.Equal(t, proto.TargetTargetID("oauth"), popup)
+	tabs.setActive(popup)
+	assert.Equal(t, proto.TargetTargetID("app"), tabs.previous)
+
+	tabs.setActive("help") // switched to without waiting for it
+	tabs.sync([]proto.TargetTargetID{"help", "oauth", "app"})
+	_, ok = tabs.claimPopup()
+	assert.False(t, ok, "docs closed and help was switched to")
+
+	tabs.remove("oauth")
+	assert.Equal(t, []proto.TargetTargetID{"app", "help"}, tabs.open)
+	assert.Equal(t, proto.TargetTargetID("app"), tabs.fallback(), "the tab active before closed")
+	tabs.setActive("app")
+	assert.Equal(t, proto.TargetTargetID("help"), tabs.fallback())
+}
+
+func TestWebPlatform_Tabs_NotInitialized(t *testing.T) {
+	w := NewWebPlatform()
+	_, err := w.Tabs()
+	assert.EqualError(t, err, "web page not initialized")
+	_, err = w.WaitForPopup(time.Millisecond)
+	assert.EqualError(t, err, "web page not initialized")
+	_, err = w.SwitchTab(1, "")
+	assert.EqualError(t, err, "web page not initialized")
+	assert.EqualError(t, w.CloseTab(-1), "web page not initialized")
+}
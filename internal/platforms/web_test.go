@@ -692,3 +692,15 @@ func TestWebPlatform_ContextCancellation(t *testing.T) {
 		}
 	}
 }
+
+// Test ProcessID before a browser is launched
+func TestWebPlatform_ProcessID_NotLaunched(t *testing.T) {
+	platform := NewWebPlatform()
+	assert.Equal(t, 0, platform.ProcessID())
+}
+
+// Test BrowserVersion before a browser is launched
+func TestWebPlatform_BrowserVersion_NotLaunched(t *testing.T) {
+	_, err := NewWebPlatform().BrowserVersion()
+	assert.Error(t, err)
+}
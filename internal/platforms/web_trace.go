@@ -0,0 +1,93 @@
+package platforms
+
+import (
+	"context"
+	"fmt"
+
+	"panoptic/internal/trace"
+
+	"github.com/go-rod/rod/lib/proto"
+)
+
+// CaptureScreenshot returns a PNG of the current viewport without saving it
+func (w *WebPlatform) CaptureScreenshot() ([]byte, error) {
+	if w.page == nil {
+		return nil, fmt.Errorf("web page not initialized")
+	}
+	data, err := w.page.Screenshot(false, nil)
+	if err != nil {
+		return nil, err
+	}
+	return w.redactScreenshot(data, false)
+}
+
+// ObserveNetwork enables CDP network events and reports every finished or
+// failed request to fn until the returned stop function is called. fn is
+// invoked from a single background goroutine.
+func (w *WebPlatform) ObserveNetwork(fn func(trace.NetworkEvent)) (func(), error) {
+	if w.page == nil {
+		return nil, fmt.Errorf("web page not initialized")
+	}
+
+	ctx, cancel := context.WithCancel(w.page.GetContext())
+	page := w.page.Context(ctx)
+	if err := (proto.NetworkEnable{}).Call(page); err != nil {
+		cancel()
+		return nil, fmt.Errorf("failed to enable network events: %w", err)
+	}
+
+	type pendingRequest struct {
+		event trace.NetworkEvent
+		start proto.MonotonicTime
+	}
+	pending := make(map[proto.NetworkRequestID]*pendingRequest)
+
+	finish := func(id proto.NetworkRequestID, end proto.MonotonicTime) *trace.NetworkEvent {
+		req, ok := pending[id]
+		if !ok {
+			return nil
+		}
+		delete(pending, id)
+		req.event.Duration = end.Duration() - req.start.Duration()
+		return &req.event
+	}
+
+	wait := page.EachEvent(
+		func(e *proto.NetworkRequestWillBeSent) {
+			if e.Request == nil {
+				return
+			}
+			pending[e.RequestID] = &pendingRequest{
+				event: trace.NetworkEvent{
+					RequestID: string(e.RequestID),
+					Method:    e.Request.Method,
+					URL:       e.Request.URL,
+					Type:      string(e.Type),
+					StartTime: e.WallTime.Time(),
+				},
+				start: e.Timestamp,
+			}
+		},
+		func(e *proto.NetworkResponseReceived) {
+			if req, ok := pending[e.RequestID]; ok && e.Response != nil {
+				req.event.Status = e.Response.Status
+				req.event.MimeType = e.Response.MIMEType
+			}
+		},
+		func(e *proto.NetworkLoadingFinished) {
+			if event := finish(e.RequestID, e.Timestamp); event != nil {
+				fn(*event)
+			}
+		},
+		func(e *proto.NetworkLoadingFailed) {
+			if event := finish(e.RequestID, e.Timestamp); event != nil {
+				event.Failed = true
+				event.ErrorText = e.ErrorText
+				fn(*event)
+			}
+		},
+	)
+	go wait()
+
+	return cancel, nil
+}
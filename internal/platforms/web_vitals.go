@@ -0,0 +1,156 @@
+package platforms
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// WebVitals are the Core Web Vitals and navigation timings of a page, in
+// milliseconds except CLS. Nil metrics were not reported by the browser, e.g.
+// INP and FID before the user interacted with the page.
+type WebVitals struct {
+	URL              string           `json:"url"`
+	CollectedAt      time.Time        `json:"collected_at"`
+	LCP              *float64         `json:"lcp_ms,omitempty"`
+	CLS              *float64         `json:"cls,omitempty"`
+	INP              *float64         `json:"inp_ms,omitempty"`
+	FID              *float64         `json:"fid_ms,omitempty"`
+	TTFB             *float64         `json:"ttfb_ms,omitempty"`
+	FCP              *float64         `json:"fcp_ms,omitempty"`
+	DOMContentLoaded *float64         `json:"dom_content_loaded_ms,omitempty"`
+	Load             *float64         `json:"load_ms,omitempty"`
+	Resources        []ResourceTiming `json:"resources,omitempty"`
+}
+
+// ResourceTiming is one entry of the page's resource timing buffer
+type ResourceTiming struct {
+	Name          string  `json:"name"`
+	InitiatorType string  `json:"initiator_type"`
+	StartTime     float64 `json:"start_ms"`
+	Duration      float64 `json:"duration_ms"`
+	TransferSize  int64   `json:"transfer_size"` // 0 for cached or cross-origin resources without Timing-Allow-Origin
+}
+
+// TransferBytes sums the transfer size of all resources
+func (v WebVitals) TransferBytes() int64 {
+	var total int64
+	for _, r := range v.Resources {
+		total += r.TransferSize
+	}
+	return total
+}
+
+// maxResourceTimings caps the resource entries kept per navigation
+const maxResourceTimings = 250
+
+// webVitalsScript reads buffered performance entries synchronously:
+// observe({buffered: true}) fills the observer's buffer immediately, so
+// takeRecords returns every entry recorded so far. CLS uses the largest
+// session window (shifts less than 1s apart, at most 5s long) and INP the
+// slowest interaction.
+const webVitalsScript = `() => {
+	const records = (type, opts) => {
+		try {
+			const observer = new PerformanceObserver(() => {});
+			observer.observe(Object.assign({type: type, buffered: true}, opts || {}));
+			const entries = observer.takeRecords();
+			observer.disconnect();
+			return entries;
+		} catch (e) {
+			return [];
+		}
+	};
+	const out = {};
+
+	const lcp = records('largest-contentful-paint');
+	if (lcp.length) {
+		const last = lcp[lcp.length - 1];
+		out.lcp_ms = last.renderTime || last.loadTime || last.startTime;
+	}
+
+	let cls = 0, windowValue = 0, windowStart = 0, previous = -Infinity, supported = false;
+	for (const e of records('layout-shift')) {
+		supported = true;
+		if (e.hadRecentInput) continue;
+		if (e.startTime - previous > 1000 || e.startTime - windowStart > 5000) {
+			windowValue = 0;
+			windowStart = e.startTime;
+		}
+		windowValue += e.value;
+		previous = e.startTime;
+		cls = Math.max(cls, windowValue);
+	}
+	if (supported || PerformanceObserver.supportedEntryTypes.includes('layout-shift')) out.cls = cls;
+
+	const firstInput = records('first-input');
+	if (firstInput.length) out.fid_ms = firstInput[0].processingStart - firstInput[0].startTime;
+
+	for (const e of records('event', {durationThreshold: 16})) {
+		if (e.interactionId) out.inp_ms = Math.max(out.inp_ms || 0, e.duration);
+	}
+
+	const nav = performance.getEntriesByType('navigation')[0];
+	if (nav) {
+		out.ttfb_ms = Math.max(0, nav.responseStart - (nav.activationStart || 0));
+		if (nav.domContentLoadedEventEnd) out.dom_content_loaded_ms = nav.domContentLoadedEventEnd;
+		if (nav.loadEventEnd) out.load_ms = nav.loadEventEnd;
+	}
+	const fcp = performance.getEntriesByName('first-contentful-paint')[0];
+	if (fcp) out.fcp_ms = fcp.startTime;
+
+	out.resources = performance.getEntriesByType('resource').slice(0, %d).map(r => ({
+		name: r.name,
+		initiator_type: r.initiatorType,
+		start_ms: r.startTime,
+		duration_ms: r.duration,
+		transfer_size: r.transferSize || 0
+	}));
+	return JSON.stringify(out);
+}`
+
+// CollectWebVitals reads the current page's Core Web Vitals, navigation timing
+// and resource timing. CLS and INP keep growing while the page is used, so a
+// later call reflects the interactions since navigation.
+func (w *WebPlatform) CollectWebVitals() (*WebVitals, error) {
+	if w.page == nil {
+		return nil, fmt.Errorf("web page not initialized")
+	}
+	res, err := w.page.Eval(fmt.Sprintf(webVitalsScript, maxResourceTimings))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read performance entries: %w", err)
+	}
+	vitals, err := parseWebVitals(res.Value.Str())
+	if err != nil {
+		return nil, err
+	}
+	if info, err := w.page.Info(); err == nil {
+		vitals.URL = info.URL
+	}
+	return vitals, nil
+}
+
+func parseWebVitals(raw string) (*WebVitals, error) {
+	var vitals WebVitals
+	if err := json.Unmarshal([]byte(raw), &vitals); err != nil {
+		return nil, fmt.Errorf("invalid performance entries: %w", err)
+	}
+	vitals.CollectedAt = time.Now()
+	return &vitals, nil
+}
+
+// recordWebVitals appends the page's vitals to the web_vitals metric after a
+// navigation. Collection problems are recorded, not returned: a page that
+// loaded must not fail navigation because its timings could not be read.
+func (w *WebPlatform) recordWebVitals(url string) {
+	vitals, err := w.CollectWebVitals()
+	if err != nil {
+		w.metrics["web_vitals_error"] = err.Error()
+		return
+	}
+	if vitals.URL == "" {
+		vitals.URL = url
+	}
+	all, _ := w.metrics["web_vitals"].([]WebVitals)
+	w.metrics["web_vitals"] = append(all, *vitals)
+}
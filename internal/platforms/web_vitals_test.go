@@ -0,0 +1,52 @@
+package platforms
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Test decoding the performance entries returned by the page script
+func TestParseWebVitals(t *testing.T) {
+	vitals, err := parseWebVitals(`{"lcp_ms":1250.5,"cls":0.02,"ttfb_ms":120,"fcp_ms":600,"load_ms":1400,
+		"resources":[{"name":"https://shop.test/app.js","initiator_type":"script","start_ms":200,"duration_ms":80,"transfer_size":2048},
+		{"name":"https://cdn.test/logo.png","initiator_type":"img","start_ms":210,"duration_ms":40,"transfer_size":1024}]}`)
+	require.NoError(t, err)
+
+	assert.Equal(t, 1250.5, *vitals.LCP)
+	assert.Equal(t, 0.02, *vitals.CLS)
+	assert.Nil(t, vitals.INP, "no interaction yet")
+	assert.Nil(t, vitals.FID)
+	assert.Equal(t, 120.0, *vitals.TTFB)
+	require.Len(t, vitals.Resources, 2)
+	assert.Equal(t, "script", vitals.Resources[0].InitiatorType)
+	assert.Equal(t, int64(3072), vitals.TransferBytes())
+	assert.False(t, vitals.CollectedAt.IsZero())
+
+	_, err = parseWebVitals("undefined")
+	assert.Error(t, err)
+}
+
+// Test the collection script keeps its resource cap after formatting
+func TestWebVitalsScript(t *testing.T) {
+	script := fmt.Sprintf(webVitalsScript, maxResourceTimings)
+	assert.Contains(t, script, ".slice(0, 250)")
+	assert.NotContains(t, script, "%!")
+	for _, entryType := range []string{"largest-contentful-paint", "layout-shift", "first-input", "'event'"} {
+		assert.True(t, strings.Contains(script, entryType), entryType)
+	}
+}
+
+// Test collecting vitals without a page
+func TestWebPlatform_CollectWebVitals_NoPage(t *testing.T) {
+	platform := NewWebPlatform()
+	_, err := platform.CollectWebVitals()
+	assert.Error(t, err)
+
+	platform.recordWebVitals("https://shop.test")
+	assert.Contains(t, platform.metrics["web_vitals_error"], "not initialized")
+	assert.Nil(t, platform.metrics["web_vitals"])
+}
@@ -0,0 +1,101 @@
+package platforms
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/go-rod/rod"
+	"github.com/go-rod/rod/lib/proto"
+)
+
+// maxWebSocketPayload bounds the payload kept of each frame
+const maxWebSocketPayload = 64 << 10
+
+// WebSocketFrame is a message a page sent or received over a WebSocket
+type WebSocketFrame struct {
+	Time      time.Time `json:"time"`
+	URL       string    `json:"url"`
+	Direction string    `json:"direction"` // sent or received
+	Binary    bool      `json:"binary,omitempty"`
+	Payload   string    `json:"payload"` // base64 when binary
+	Truncated bool      `json:"truncated,omitempty"`
+}
+
+// webSocketLog collects the frames of a page's WebSockets until they're
+// taken
+type webSocketLog struct {
+	mu     sync.Mutex
+	urls   map[proto.NetworkRequestID]string
+	frames []WebSocketFrame
+}
+
+func (l *webSocketLog) open(id proto.NetworkRequestID, url string) {
+	l.mu.Lock()
+	l.urls[id] = url
+	l.mu.Unlock()
+}
+
+func (l *webSocketLog) add(id proto.NetworkRequestID, direction string, frame *proto.NetworkWebSocketFrame) {
+	if frame == nil {
+		return
+	}
+	// opcodes 1 and 2 carry text and binary data; the others are control frames
+	if frame.Opcode != 1 && frame.Opcode != 2 {
+		return
+	}
+	f := WebSocketFrame{Time: time.Now(), Direction: direction, Binary: frame.Opcode == 2, Payload: frame.PayloadData}
+	if len(f.Payload) > maxWebSocketPayload {
+		f.Payload, f.Truncated = f.Payload[:maxWebSocketPayload], true
+	}
+	l.mu.Lock()
+	f.URL = l.urls[id]
+	l.frames = append(l.frames, f)
+	l.mu.Unlock()
+}
+
+func (l *webSocketLog) take() []WebSocketFrame {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	frames := l.frames
+	l.frames = nil
+	return frames
+}
+
+// watchWebSockets collects the frames of the page's WebSockets for as long
+// as it's open
+func (w *WebPlatform) watchWebSockets() error {
+	w.websockets = &webSocketLog{urls: make(map[proto.NetworkRequestID]string)}
+	return w.watchPageWebSockets(w.page)
+}
+
+// watchPageWebSockets adds the frames of page, such as a tab opened later,
+// to those collected
+func (w *WebPlatform) watchPageWebSockets(page *rod.Page) error {
+	if err := (proto.NetworkEnable{}).Call(page); err != nil {
+		return fmt.Errorf("failed to enable WebSocket events: %w", err)
+	}
+	log := w.websockets
+	wait := page.EachEvent(
+		func(e *proto.NetworkWebSocketCreated) {
+			log.open(e.RequestID, e.URL)
+		},
+		func(e *proto.NetworkWebSocketFrameSent) {
+			log.add(e.RequestID, "sent", e.Response)
+		},
+		func(e *proto.NetworkWebSocketFrameReceived) {
+			log.add(e.RequestID, "received", e.Response)
+		},
+	)
+	go wait()
+	return nil
+}
+
+// TakeWebSocketFrames returns the WebSocket messages the page sent and
+// received since the app started or the last call
+func (w *WebPlatform) TakeWebSocketFrames() ([]WebSocketFrame, error) {
+	if w.websockets == nil {
+		return nil, fmt.Errorf("web page not initialized")
+	}
+	return w.websockets.take(), nil
+}
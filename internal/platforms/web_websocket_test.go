@@ -0,0 +1,37 @@
+package platforms
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/go-rod/rod/lib/proto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWebSocketLog_Take(t *testing.T) {
+	log := &webSocketLog{urls: make(map[proto.NetworkRequestID]string)}
+	log.open("1", "wss://chat.test/socket")
+	log.add("1", "sent", &proto.NetworkWebSocketFrame{Opcode: 1, PayloadData: `{"type":"join"}`})
+	log.add("1", "received", &proto.NetworkWebSocketFrame{Opcode: 9}) // ping
+	log.add("1", "received", &proto.NetworkWebSocketFrame{Opcode: 2, PayloadData: "AAE="})
+	log.add("2", "received", &proto.NetworkWebSocketFrame{Opcode: 1, PayloadData: strings.Repeat("x", maxWebSocketPayload+1)})
+	log.add("1", "received", nil)
+
+	frames := log.take()
+	require.Len(t, frames, 3)
+	assert.Equal(t, "wss://chat.test/socket", frames[0].URL)
+	assert.Equal(t, "sent", frames[0].Direction)
+	assert.Equal(t, `{"type":"join"}`, frames[0].Payload)
+	assert.False(t, frames[0].Time.IsZero())
+	assert.True(t, frames[1].Binary)
+	assert.Equal(t, "", frames[2].URL, "sockets opened before the page was watched have no URL")
+	assert.True(t, frames[2].Truncated)
+	assert.Len(t, frames[2].Payload, maxWebSocketPayload)
+	assert.Empty(t, log.take(), "frames are only taken once")
+}
+
+func TestWebPlatform_TakeWebSocketFrames_NotInitialized(t *testing.T) {
+	_, err := NewWebPlatform().TakeWebSocketFrames()
+	require.Error(t, err)
+}
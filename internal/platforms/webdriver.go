@@ -0,0 +1,237 @@
+package platforms
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"panoptic/internal/config"
+)
+
+// Remote WebDriver providers understood by RemoteWebDriverConfig.Provider
+const (
+	RemoteProviderSelenium     = "selenium"
+	RemoteProviderMoon         = "moon"
+	RemoteProviderBrowserStack = "browserstack"
+	RemoteProviderSauceLabs    = "saucelabs"
+)
+
+// ErrRemoteCDPUnavailable is returned when a remote WebDriver session was
+// created but the endpoint did not advertise a Chrome DevTools websocket
+// (the "se:cdp" capability). The web platform drives pages over CDP, so it
+// cannot use a plain W3C WebDriver session; the session is deleted and the
+// app fails instead of falling back to a local browser.
+var ErrRemoteCDPUnavailable = errors.New("remote webdriver session does not expose a CDP endpoint (se:cdp)")
+
+// remoteSession is a W3C WebDriver session whose browser is driven over CDP
+type remoteSession struct {
+	endpoint *url.URL
+	client   *http.Client
+	id       string
+	cdpURL   string
+}
+
+// remoteBrowserName maps a panoptic engine to the W3C browserName capability
+func remoteBrowserName(engine string) string {
+	switch NormalizeBrowserEngine(engine) {
+	case BrowserEdge:
+		return "MicrosoftEdge"
+	case BrowserChrome, BrowserChromium:
+		return "chrome"
+	default:
+		return NormalizeBrowserEngine(engine)
+	}
+}
+
+// buildRemoteCapabilities assembles the alwaysMatch capabilities for a new
+// session: browser selection, provider credentials and tunnel, then the
+// user's passthrough capabilities on top
+func buildRemoteCapabilities(app config.AppConfig) map[string]interface{} {
+	remote := app.Remote
+	caps := map[string]interface{}{
+		"browserName": remoteBrowserName(app.Browser),
+	}
+	if app.BrowserVersion != "" {
+		caps["browserVersion"] = app.BrowserVersion
+	}
+
+	username := os.ExpandEnv(remote.Username)
+	accessKey := os.ExpandEnv(remote.AccessKey)
+	tunnel := os.ExpandEnv(remote.Tunnel)
+
+	switch strings.ToLower(remote.Provider) {
+	case RemoteProviderBrowserStack:
+		opts := map[string]interface{}{}
+		if username != "" {
+			opts["userName"] = username
+		}
+		if accessKey != "" {
+			opts["accessKey"] = accessKey
+		}
+		if tunnel != "" {
+			opts["local"] = true
+			opts["localIdentifier"] = tunnel
+		}
+		caps["bstack:options"] = opts
+	case RemoteProviderSauceLabs:
+		opts := map[string]interface{}{}
+		if username != "" {
+			opts["username"] = username
+		}
+		if accessKey != "" {
+			opts["accessKey"] = accessKey
+		}
+		if tunnel != "" {
+			opts["tunnelName"] = tunnel
+		}
+		caps["sauce:options"] = opts
+	case RemoteProviderMoon:
+		caps["moon:options"] = map[string]interface{}{}
+	}
+
+	for k, v := range remote.Capabilities {
+		if existing, ok := caps[k].(map[string]interface{}); ok {
+			if extra, ok := v.(map[string]interface{}); ok {
+				for ek, ev := range extra {
+					existing[ek] = ev
+				}
+				continue
+			}
+		}
+		caps[k] = v
+	}
+
+	if opts, ok := caps["moon:options"].(map[string]interface{}); ok && len(opts) == 0 {
+		delete(caps, "moon:options")
+	}
+	return caps
+}
+
+// newRemoteSession creates a WebDriver session and returns its CDP websocket
+func newRemoteSession(ctx context.Context, app config.AppConfig) (*remoteSession, error) {
+	remote := app.Remote
+	if remote.URL == "" {
+		return nil, fmt.Errorf("remote webdriver url is required")
+	}
+
+	endpoint, err := url.Parse(strings.TrimRight(remote.URL, "/"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid remote webdriver url: %w", err)
+	}
+	if username := os.ExpandEnv(remote.Username); username != "" && endpoint.User == nil {
+		endpoint.User = url.UserPassword(username, os.ExpandEnv(remote.AccessKey))
+	}
+
+	session := &remoteSession{
+		endpoint: endpoint,
+		client:   &http.Client{Timeout: 2 * time.Minute},
+	}
+
+	payload := map[string]interface{}{
+		"capabilities": map[string]interface{}{
+			"alwaysMatch": buildRemoteCapabilities(app),
+		},
+	}
+
+	var resp struct {
+		Value struct {
+			SessionID    string                 `json:"sessionId"`
+			Capabilities map[string]interface{} `json:"capabilities"`
+			Error        string                 `json:"error"`
+			Message      string                 `json:"message"`
+		} `json:"value"`
+	}
+	if err := session.do(ctx, http.MethodPost, "/session", payload, &resp); err != nil {
+		return nil, fmt.Errorf("failed to create remote webdriver session: %w", err)
+	}
+	if resp.Value.Error != "" {
+		return nil, fmt.Errorf("remote webdriver rejected session: %s: %s", resp.Value.Error, resp.Value.Message)
+	}
+	session.id = resp.Value.SessionID
+
+	cdpURL, _ := resp.Value.Capabilities["se:cdp"].(string)
+	if cdpURL == "" {
+		session.Close()
+		return nil, ErrRemoteCDPUnavailable
+	}
+	session.cdpURL = session.resolveCDPURL(cdpURL)
+
+	return session, nil
+}
+
+// resolveCDPURL rewrites grid-internal CDP URLs (e.g. ws://172.18.0.3:4444/...)
+// to the host the session was requested from, keeping credentials
+func (s *remoteSession) resolveCDPURL(cdpURL string) string {
+	u, err := url.Parse(cdpURL)
+	if err != nil {
+		return cdpURL
+	}
+	u.Host = s.endpoint.Host
+	if s.endpoint.Scheme == "https" {
+		u.Scheme = "wss"
+	} else {
+		u.Scheme = "ws"
+	}
+	if s.endpoint.User != nil {
+		u.User = s.endpoint.User
+	}
+	return u.String()
+}
+
+// Close deletes the remote session, releasing the grid slot
+func (s *remoteSession) Close() error {
+	if s == nil || s.id == "" {
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	err := s.do(ctx, http.MethodDelete, "/session/"+url.PathEscape(s.id), nil, nil)
+	s.id = ""
+	return err
+}
+
+func (s *remoteSession) do(ctx context.Context, method, path string, body, out interface{}) error {
+	var payload io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		payload = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, s.endpoint.String()+path, payload)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json; charset=utf-8")
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("%s %s returned status %d: %s", method, path, resp.StatusCode, strings.TrimSpace(string(data)))
+	}
+	if out != nil && len(data) > 0 {
+		if err := json.Unmarshal(data, out); err != nil {
+			return fmt.Errorf("failed to decode webdriver response: %w", err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,172 @@
+package platforms
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"panoptic/internal/config"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeGrid records WebDriver session traffic and answers with the given se:cdp value
+type fakeGrid struct {
+	mu      sync.Mutex
+	caps    map[string]interface{}
+	auth    string
+	deleted []string
+	cdp     string
+}
+
+func (g *fakeGrid) server(t *testing.T) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		g.mu.Lock()
+		defer g.mu.Unlock()
+
+		switch {
+		case r.Method == http.MethodPost && r.URL.Path == "/wd/hub/session":
+			var body struct {
+				Capabilities struct {
+					AlwaysMatch map[string]interface{} `json:"alwaysMatch"`
+				} `json:"capabilities"`
+			}
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+			g.caps = body.Capabilities.AlwaysMatch
+			if user, pass, ok := r.BasicAuth(); ok {
+				g.auth = user + ":" + pass
+			}
+			caps := map[string]interface{}{"browserName": "chrome"}
+			if g.cdp != "" {
+				caps["se:cdp"] = g.cdp
+			}
+			_ = json.NewEncoder(w).Encode(map[string]interface{}{
+				"value": map[string]interface{}{"sessionId": "sess-1", "capabilities": caps},
+			})
+		case r.Method == http.MethodDelete && strings.HasPrefix(r.URL.Path, "/wd/hub/session/"):
+			g.deleted = append(g.deleted, strings.TrimPrefix(r.URL.Path, "/wd/hub/session/"))
+			fmt.Fprint(w, `{"value":null}`)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+}
+
+// TestBuildRemoteCapabilities tests provider options, tunnels and passthrough
+func TestBuildRemoteCapabilities(t *testing.T) {
+	t.Setenv("BS_KEY", "secret-key")
+
+	caps := buildRemoteCapabilities(config.AppConfig{
+		Browser:        "edge",
+		BrowserVersion: "120",
+		Remote: &config.RemoteWebDriverConfig{
+			Provider:  "browserstack",
+			Username:  "alice",
+			AccessKey: "${BS_KEY}",
+			Tunnel:    "ci-tunnel",
+			Capabilities: map[string]interface{}{
+				"bstack:options":      map[string]interface{}{"os": "Windows"},
+				"acceptInsecureCerts": true,
+			},
+		},
+	})
+
+	assert.Equal(t, "MicrosoftEdge", caps["browserName"])
+	assert.Equal(t, "120", caps["browserVersion"])
+	assert.Equal(t, true, caps["acceptInsecureCerts"])
+
+	opts, ok := caps["bstack:options"].(map[string]interface{})
+	require.True(t, ok)
+	assert.Equal(t, "alice", opts["userName"])
+	assert.Equal(t, "secret-key", opts["accessKey"])
+	assert.Equal(t, true, opts["local"])
+	assert.Equal(t, "ci-tunnel", opts["localIdentifier"])
+	assert.Equal(t, "Windows", opts["os"], "passthrough options should merge into provider options")
+
+	sauce := buildRemoteCapabilities(config.AppConfig{
+		Remote: &config.RemoteWebDriverConfig{Provider: "saucelabs", Tunnel: "sc-1"},
+	})
+	assert.Equal(t, "chrome", sauce["browserName"])
+	assert.Equal(t, "sc-1", sauce["sauce:options"].(map[string]interface{})["tunnelName"])
+
+	grid := buildRemoteCapabilities(config.AppConfig{Remote: &config.RemoteWebDriverConfig{Provider: "moon"}})
+	assert.NotContains(t, grid, "moon:options")
+}
+
+// TestNewRemoteSession tests session creation, credentials and CDP URL rewriting
+func TestNewRemoteSession(t *testing.T) {
+	grid := &fakeGrid{cdp: "ws://172.18.0.3:4444/session/sess-1/se/cdp"}
+	server := grid.server(t)
+	defer server.Close()
+
+	session, err := newRemoteSession(t.Context(), config.AppConfig{
+		Remote: &config.RemoteWebDriverConfig{URL: server.URL + "/wd/hub/", Username: "u", AccessKey: "k"},
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, "sess-1", session.id)
+	assert.Equal(t, "u:k", grid.auth)
+	host := strings.TrimPrefix(server.URL, "http://")
+	assert.Equal(t, "ws://u:k@"+host+"/session/sess-1/se/cdp", session.cdpURL)
+
+	require.NoError(t, session.Close())
+	assert.Equal(t, []string{"sess-1"}, grid.deleted)
+	assert.NoError(t, session.Close(), "closing twice should be a no-op")
+}
+
+// TestNewRemoteSession_NoCDP tests that sessions without se:cdp are released and rejected
+func TestNewRemoteSession_NoCDP(t *testing.T) {
+	grid := &fakeGrid{}
+	server := grid.server(t)
+	defer server.Close()
+
+	_, err := newRemoteSession(t.Context(), config.AppConfig{
+		Browser: "firefox",
+		Remote:  &config.RemoteWebDriverConfig{URL: server.URL + "/wd/hub"},
+	})
+
+	assert.True(t, errors.Is(err, ErrRemoteCDPUnavailable))
+	assert.Equal(t, []string{"sess-1"}, grid.deleted)
+	assert.Equal(t, "firefox", grid.caps["browserName"])
+}
+
+// TestNewRemoteSession_Errors tests validation and endpoint failures
+func TestNewRemoteSession_Errors(t *testing.T) {
+	_, err := newRemoteSession(t.Context(), config.AppConfig{Remote: &config.RemoteWebDriverConfig{}})
+	assert.Error(t, err)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+		fmt.Fprint(w, `{"value":{"error":"session not created","message":"no free slots"}}`)
+	}))
+	defer server.Close()
+
+	_, err = newRemoteSession(t.Context(), config.AppConfig{Remote: &config.RemoteWebDriverConfig{URL: server.URL}})
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "no free slots")
+}
+
+// TestWebPlatform_Initialize_RemoteReleasesSession tests that a failed CDP connection deletes the session
+func TestWebPlatform_Initialize_RemoteReleasesSession(t *testing.T) {
+	grid := &fakeGrid{cdp: "ws://127.0.0.1:1/session/sess-1/se/cdp"}
+	server := grid.server(t)
+	defer server.Close()
+
+	platform := NewWebPlatform()
+	err := platform.Initialize(config.AppConfig{
+		Name:    "remote",
+		Type:    "web",
+		Timeout: 5,
+		Remote:  &config.RemoteWebDriverConfig{URL: server.URL + "/wd/hub"},
+	})
+
+	assert.Error(t, err)
+	assert.Equal(t, []string{"sess-1"}, grid.deleted)
+	assert.Nil(t, platform.remote)
+}
@@ -0,0 +1,130 @@
+// Package redact masks personal data in what a run writes: strings matching
+// configured patterns, and values typed into masked fields, are replaced
+// before they reach logs, traces and results.
+package redact
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// DefaultReplacement replaces redacted text when none is configured
+const DefaultReplacement = "[REDACTED]"
+
+// minValueLength is the shortest value AddValue masks; shorter ones would
+// mask unrelated text
+const minValueLength = 3
+
+// Redactor replaces the matches of its patterns and the values added to it.
+// A nil Redactor redacts nothing. It's safe for concurrent use.
+type Redactor struct {
+	patterns    []*regexp.Regexp
+	replacement string
+
+	mu     sync.RWMutex
+	values []string // longest first, so values containing others go whole
+}
+
+// New compiles patterns into a Redactor replacing their matches with
+// replacement, DefaultReplacement when empty
+func New(patterns []string, replacement string) (*Redactor, error) {
+	r := &Redactor{replacement: replacement}
+	if r.replacement == "" {
+		r.replacement = DefaultReplacement
+	}
+	for _, p := range patterns {
+		re, err := regexp.Compile(p)
+		if err != nil {
+			return nil, fmt.Errorf("invalid pattern %q: %w", p, err)
+		}
+		r.patterns = append(r.patterns, re)
+	}
+	return r, nil
+}
+
+// Replacement is what redacted text is replaced with
+func (r *Redactor) Replacement() string {
+	if r == nil {
+		return DefaultReplacement
+	}
+	return r.replacement
+}
+
+// AddValue masks every later occurrence of value, such as what was typed
+// into a masked field
+func (r *Redactor) AddValue(value string) {
+	if r == nil || len(strings.TrimSpace(value)) < minValueLength {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, v := range r.values {
+		if v == value {
+			return
+		}
+	}
+	r.values = append(r.values, value)
+	sort.SliceStable(r.values, func(i, j int) bool { return len(r.values[i]) > len(r.values[j]) })
+}
+
+// String redacts s
+func (r *Redactor) String(s string) string {
+	if r == nil || s == "" {
+		return s
+	}
+	r.mu.RLock()
+	for _, v := range r.values {
+		s = strings.ReplaceAll(s, v, r.replacement)
+	}
+	r.mu.RUnlock()
+	for _, re := range r.patterns {
+		s = re.ReplaceAllLiteralString(s, r.replacement)
+	}
+	return s
+}
+
+// JSON redacts the string literals of a JSON document, keeping its layout
+func (r *Redactor) JSON(data []byte) []byte {
+	if r == nil {
+		return data
+	}
+	var out bytes.Buffer
+	out.Grow(len(data))
+	for i := 0; i < len(data); {
+		if data[i] != '"' {
+			out.WriteByte(data[i])
+			i++
+			continue
+		}
+		end := literalEnd(data, i)
+		literal := data[i:end]
+		var s string
+		if err := json.Unmarshal(literal, &s); err == nil {
+			if redacted := r.String(s); redacted != s {
+				literal, _ = json.Marshal(redacted)
+			}
+		}
+		out.Write(literal)
+		i = end
+	}
+	return out.Bytes()
+}
+
+// literalEnd is the index after the closing quote of the string literal
+// opened at start, or the end of data when it isn't closed
+func literalEnd(data []byte, start int) int {
+	for i := start + 1; i < len(data); i++ {
+		switch data[i] {
+		case '\\':
+			i++
+		case '"':
+			return i + 1
+		}
+	}
+	return len(data)
+}
@@ -0,0 +1,65 @@
+package redact
+
+import (
+	"encoding/json"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRedactor_String(t *testing.T) {
+	r, err := New([]string{`[\w.+-]+@[\w-]+\.\w+`, `\b\d{4}(?: ?\d{4}){3}\b`}, "")
+	require.NoError(t, err)
+	assert.Equal(t, "mail [REDACTED] paid with [REDACTED]", r.String("mail ada@example.com paid with 4111 1111 1111 1111"))
+	assert.Equal(t, "nothing personal", r.String("nothing personal"))
+
+	r.AddValue("hunter2")
+	r.AddValue("hunter2 again")
+	r.AddValue("ab") // too short to mask safely
+	assert.Equal(t, "typed [REDACTED], then [REDACTED], ab", r.String("typed hunter2 again, then hunter2, ab"))
+
+	masked, err := New(nil, "***")
+	require.NoError(t, err)
+	masked.AddValue("secret")
+	assert.Equal(t, "***", masked.String("secret"))
+	assert.Equal(t, "***", masked.Replacement())
+
+	var unset *Redactor
+	assert.Equal(t, "ada@example.com", unset.String("ada@example.com"))
+	unset.AddValue("secret")
+	assert.Equal(t, []byte(`{"a":"b"}`), unset.JSON([]byte(`{"a":"b"}`)))
+
+	_, err = New([]string{"("}, "")
+	assert.ErrorContains(t, err, `invalid pattern "("`)
+}
+
+// TestRedactor_JSON tests that string literals are redacted in place and
+// the document stays valid
+func TestRedactor_JSON(t *testing.T) {
+	r, err := New([]string{`ada@example\.com`}, "")
+	require.NoError(t, err)
+	r.AddValue(`pa"ss`)
+	doc := []byte("{\n  \"error\": \"login as ada@example.com failed\",\n  \"typed\": \"pa\\\"ss\",\n  \"count\": 3,\n  \"ok\": \"a \\\\ b\"\n}")
+
+	out := r.JSON(doc)
+	assert.Equal(t, "{\n  \"error\": \"login as [REDACTED] failed\",\n  \"typed\": \"[REDACTED]\",\n  \"count\": 3,\n  \"ok\": \"a \\\\ b\"\n}", string(out))
+	assert.True(t, json.Valid(out))
+}
+
+func TestRedactor_Concurrent(t *testing.T) {
+	r, err := New(nil, "")
+	require.NoError(t, err)
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			r.AddValue("value-of-field")
+			r.String("value-of-field")
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, "[REDACTED]", r.String("value-of-field"))
+}
@@ -0,0 +1,174 @@
+package resources
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// clockTicks is USER_HZ, the unit of utime/stime in /proc/<pid>/stat. It is
+// 100 on every mainstream Linux architecture.
+const clockTicks = 100
+
+// readProcesses lists all processes: /proc on Linux, ps(1) on macOS and the BSDs
+func readProcesses() (map[int]procStat, error) {
+	switch runtime.GOOS {
+	case "linux":
+		return readProcFS("/proc")
+	case "darwin", "freebsd", "openbsd", "netbsd":
+		out, err := exec.Command("ps", "-A", "-o", "pid=,ppid=,rss=,time=").Output()
+		if err != nil {
+			return nil, fmt.Errorf("ps failed: %w", err)
+		}
+		return parsePS(out)
+	default:
+		return nil, fmt.Errorf("%s: %w", runtime.GOOS, ErrUnsupported)
+	}
+}
+
+// readProcFS reads every /proc/<pid>/stat under root
+func readProcFS(root string) (map[int]procStat, error) {
+	entries, err := os.ReadDir(root)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", root, err)
+	}
+	pageSize := uint64(os.Getpagesize())
+	procs := make(map[int]procStat)
+	for _, entry := range entries {
+		pid, err := strconv.Atoi(entry.Name())
+		if err != nil {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(root, entry.Name(), "stat"))
+		if err != nil {
+			continue // exited while listing
+		}
+		if p, ok := parseProcStat(data, pageSize); ok {
+			procs[pid] = p
+		}
+	}
+	return procs, nil
+}
+
+// parseProcStat parses /proc/<pid>/stat. The command name is parenthesised and
+// may contain spaces, so fields are counted from the last ')'.
+func parseProcStat(data []byte, pageSize uint64) (procStat, bool) {
+	end := bytes.LastIndexByte(data, ')')
+	if end < 0 {
+		return procStat{}, false
+	}
+	// Fields after the name start at field 3 (state)
+	fields := strings.Fields(string(data[end+1:]))
+	if len(fields) < 22 {
+		return procStat{}, false
+	}
+	ppid, err1 := strconv.Atoi(fields[1])
+	utime, err2 := strconv.ParseUint(fields[11], 10, 64)
+	stime, err3 := strconv.ParseUint(fields[12], 10, 64)
+	rss, err4 := strconv.ParseInt(fields[21], 10, 64)
+	if err1 != nil || err2 != nil || err3 != nil || err4 != nil {
+		return procStat{}, false
+	}
+	if rss < 0 {
+		rss = 0
+	}
+	return procStat{
+		ppid:     ppid,
+		cpu:      time.Duration(utime+stime) * time.Second / clockTicks,
+		rssBytes: uint64(rss) * pageSize,
+	}, true
+}
+
+// parsePS parses "pid ppid rss(KiB) cputime" lines
+func parsePS(out []byte) (map[int]procStat, error) {
+	procs := make(map[int]procStat)
+	for _, line := range strings.Split(string(out), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) != 4 {
+			continue
+		}
+		pid, err1 := strconv.Atoi(fields[0])
+		ppid, err2 := strconv.Atoi(fields[1])
+		rss, err3 := strconv.ParseUint(fields[2], 10, 64)
+		cpu, err4 := parseCPUTime(fields[3])
+		if err1 != nil || err2 != nil || err3 != nil || err4 != nil {
+			continue
+		}
+		procs[pid] = procStat{ppid: ppid, cpu: cpu, rssBytes: rss * 1024}
+	}
+	if len(procs) == 0 {
+		return nil, fmt.Errorf("ps returned no processes")
+	}
+	return procs, nil
+}
+
+// parseCPUTime parses ps cputime values: [[dd-]hh:]mm:ss[.cc]
+func parseCPUTime(value string) (time.Duration, error) {
+	var days int
+	if d, rest, ok := strings.Cut(value, "-"); ok {
+		n, err := strconv.Atoi(d)
+		if err != nil {
+			return 0, fmt.Errorf("invalid cpu time %q", value)
+		}
+		days, value = n, rest
+	}
+	parts := strings.Split(value, ":")
+	if len(parts) < 2 || len(parts) > 3 {
+		return 0, fmt.Errorf("invalid cpu time %q", value)
+	}
+	seconds, err := strconv.ParseFloat(parts[len(parts)-1], 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid cpu time %q", value)
+	}
+	total := time.Duration(days)*24*time.Hour + time.Duration(seconds*float64(time.Second))
+	for i, unit := range []time.Duration{time.Minute, time.Hour} {
+		idx := len(parts) - 2 - i
+		if idx < 0 {
+			break
+		}
+		n, err := strconv.Atoi(parts[idx])
+		if err != nil {
+			return 0, fmt.Errorf("invalid cpu time %q", value)
+		}
+		total += time.Duration(n) * unit
+	}
+	return total, nil
+}
+
+// readGPUMemory returns GPU memory per PID from nvidia-smi. Other vendors
+// expose no per-process counter through a common tool, so their GPU memory is
+// reported as unavailable rather than zero.
+func readGPUMemory() (map[int]uint64, bool) {
+	if _, err := exec.LookPath("nvidia-smi"); err != nil {
+		return nil, false
+	}
+	out, err := exec.Command("nvidia-smi", "--query-compute-apps=pid,used_memory", "--format=csv,noheader,nounits").Output()
+	if err != nil {
+		return nil, false
+	}
+	return parseNvidiaSMI(out), true
+}
+
+// parseNvidiaSMI parses "pid, used_memory(MiB)" lines
+func parseNvidiaSMI(out []byte) map[int]uint64 {
+	usage := make(map[int]uint64)
+	for _, line := range strings.Split(string(out), "\n") {
+		pidField, memField, ok := strings.Cut(line, ",")
+		if !ok {
+			continue
+		}
+		pid, err1 := strconv.Atoi(strings.TrimSpace(pidField))
+		mib, err2 := strconv.ParseUint(strings.TrimSpace(memField), 10, 64)
+		if err1 != nil || err2 != nil {
+			continue
+		}
+		usage[pid] += mib * 1024 * 1024
+	}
+	return usage
+}
@@ -0,0 +1,269 @@
+// Package resources samples the CPU, resident memory and GPU memory of a
+// process tree (a browser and its renderer/GPU helpers, or a desktop app) at
+// intervals and summarizes the time series for test results.
+package resources
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// DefaultInterval is the sampling interval used when none is configured
+const DefaultInterval = time.Second
+
+// ErrUnsupported is returned on hosts where process statistics cannot be read
+var ErrUnsupported = errors.New("process resource sampling is not supported on this host")
+
+// Sample is one measurement of the whole process tree
+type Sample struct {
+	Time           time.Time `json:"time"`
+	CPUPercent     float64   `json:"cpu_percent"` // share of one core; above 100 when several cores are busy
+	RSSBytes       uint64    `json:"rss_bytes"`
+	GPUMemoryBytes uint64    `json:"gpu_memory_bytes,omitempty"`
+	Processes      int       `json:"processes"`
+}
+
+// Usage is the sampled time series and its summary
+type Usage struct {
+	PID                int           `json:"pid"`
+	Interval           time.Duration `json:"interval"`
+	Samples            []Sample      `json:"samples"`
+	PeakCPUPercent     float64       `json:"peak_cpu_percent"`
+	AvgCPUPercent      float64       `json:"avg_cpu_percent"`
+	PeakRSSBytes       uint64        `json:"peak_rss_bytes"`
+	PeakGPUMemoryBytes uint64        `json:"peak_gpu_memory_bytes"`
+	GPUAvailable       bool          `json:"gpu_available"` // false when no GPU memory source (nvidia-smi) was found
+	Error              string        `json:"error,omitempty"`
+}
+
+// Thresholds are upper bounds for a Usage; zero disables a bound
+type Thresholds struct {
+	MaxCPUPercent     float64
+	MaxRSSBytes       uint64
+	MaxGPUMemoryBytes uint64
+}
+
+// Violations describes every threshold the peaks exceeded. GPU bounds are
+// skipped when GPU memory could not be measured.
+func (u Usage) Violations(t Thresholds) []string {
+	var out []string
+	if t.MaxCPUPercent > 0 && u.PeakCPUPercent > t.MaxCPUPercent {
+		out = append(out, fmt.Sprintf("CPU peaked at %.1f%% (limit %.1f%%)", u.PeakCPUPercent, t.MaxCPUPercent))
+	}
+	if t.MaxRSSBytes > 0 && u.PeakRSSBytes > t.MaxRSSBytes {
+		out = append(out, fmt.Sprintf("memory peaked at %s (limit %s)", formatBytes(u.PeakRSSBytes), formatBytes(t.MaxRSSBytes)))
+	}
+	if t.MaxGPUMemoryBytes > 0 && u.GPUAvailable && u.PeakGPUMemoryBytes > t.MaxGPUMemoryBytes {
+		out = append(out, fmt.Sprintf("GPU memory peaked at %s (limit %s)", formatBytes(u.PeakGPUMemoryBytes), formatBytes(t.MaxGPUMemoryBytes)))
+	}
+	return out
+}
+
+func formatBytes(b uint64) string {
+	return fmt.Sprintf("%.1f MB", float64(b)/(1024*1024))
+}
+
+// procStat is one process as seen by a procReader
+type procStat struct {
+	ppid     int
+	cpu      time.Duration // user + system time consumed so far
+	rssBytes uint64
+}
+
+// procReader lists all processes on the host; gpuReader returns GPU memory per
+// PID and whether a GPU source exists. Both are swapped in tests.
+type (
+	procReader func() (map[int]procStat, error)
+	gpuReader  func() (map[int]uint64, bool)
+)
+
+// Monitor samples a process tree in the background
+type Monitor struct {
+	pid      int
+	interval time.Duration
+	readProc procReader
+	readGPU  gpuReader
+
+	mu       sync.Mutex
+	usage    Usage
+	lastCPU  time.Duration
+	lastTime time.Time
+	cpuSum   float64
+	cpuCount int
+
+	stop    chan struct{}
+	done    chan struct{}
+	stopped sync.Once
+}
+
+// Start samples pid and its descendants every interval until Stop is called.
+// It returns an error when the process cannot be read at all.
+func Start(pid int, interval time.Duration) (*Monitor, error) {
+	return start(pid, interval, readProcesses, readGPUMemory)
+}
+
+func start(pid int, interval time.Duration, readProc procReader, readGPU gpuReader) (*Monitor, error) {
+	if pid <= 0 {
+		return nil, fmt.Errorf("invalid process id %d", pid)
+	}
+	if interval <= 0 {
+		interval = DefaultInterval
+	}
+	m := &Monitor{
+		pid:      pid,
+		interval: interval,
+		readProc: readProc,
+		readGPU:  readGPU,
+		usage:    Usage{PID: pid, Interval: interval, Samples: []Sample{}},
+		stop:     make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+
+	// The first reading only establishes the CPU baseline
+	procs, err := readProc()
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := procs[pid]; !ok {
+		return nil, fmt.Errorf("process %d not found", pid)
+	}
+	m.lastCPU, _, _ = tree(procs, pid)
+	m.lastTime = time.Now()
+
+	go m.run()
+	return m, nil
+}
+
+// Measure takes one sample of pid and its descendants, without CPU usage,
+// which needs two readings
+func Measure(pid int) (Sample, error) {
+	return measure(pid, readProcesses)
+}
+
+func measure(pid int, readProc procReader) (Sample, error) {
+	procs, err := readProc()
+	if err != nil {
+		return Sample{}, err
+	}
+	if _, ok := procs[pid]; !ok {
+		return Sample{}, fmt.Errorf("process %d not found", pid)
+	}
+	_, rss, count := tree(procs, pid)
+	return Sample{Time: time.Now(), RSSBytes: rss, Processes: count}, nil
+}
+
+func (m *Monitor) run() {
+	defer close(m.done)
+	ticker := time.NewTicker(m.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-m.stop:
+			return
+		case <-ticker.C:
+			if !m.sample() {
+				return
+			}
+		}
+	}
+}
+
+// sample records one measurement; it returns false once the process is gone
+func (m *Monitor) sample() bool {
+	procs, err := m.readProc()
+	now := time.Now()
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if err != nil {
+		m.usage.Error = err.Error()
+		return false
+	}
+	if _, ok := procs[m.pid]; !ok {
+		return false
+	}
+
+	cpu, rss, count := tree(procs, m.pid)
+	s := Sample{Time: now, RSSBytes: rss, Processes: count}
+	if wall := now.Sub(m.lastTime); wall > 0 && cpu >= m.lastCPU {
+		s.CPUPercent = float64(cpu-m.lastCPU) / float64(wall) * 100
+	}
+	m.lastCPU, m.lastTime = cpu, now
+
+	if gpu, ok := m.readGPU(); ok {
+		m.usage.GPUAvailable = true
+		for pid := range descendants(procs, m.pid) {
+			s.GPUMemoryBytes += gpu[pid]
+		}
+	}
+
+	m.usage.Samples = append(m.usage.Samples, s)
+	m.cpuSum += s.CPUPercent
+	m.cpuCount++
+	m.usage.AvgCPUPercent = m.cpuSum / float64(m.cpuCount)
+	if s.CPUPercent > m.usage.PeakCPUPercent {
+		m.usage.PeakCPUPercent = s.CPUPercent
+	}
+	if s.RSSBytes > m.usage.PeakRSSBytes {
+		m.usage.PeakRSSBytes = s.RSSBytes
+	}
+	if s.GPUMemoryBytes > m.usage.PeakGPUMemoryBytes {
+		m.usage.PeakGPUMemoryBytes = s.GPUMemoryBytes
+	}
+	return true
+}
+
+// Stop takes a final sample, stops sampling and returns the collected usage.
+// It is safe to call more than once and on a nil Monitor.
+func (m *Monitor) Stop() Usage {
+	if m == nil {
+		return Usage{}
+	}
+	m.stopped.Do(func() {
+		close(m.stop)
+		<-m.done
+		m.sample()
+	})
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	usage := m.usage
+	usage.Samples = append([]Sample(nil), m.usage.Samples...)
+	return usage
+}
+
+// descendants returns root and every process below it
+func descendants(procs map[int]procStat, root int) map[int]bool {
+	children := make(map[int][]int)
+	for pid, p := range procs {
+		children[p.ppid] = append(children[p.ppid], pid)
+	}
+	set := map[int]bool{root: true}
+	queue := []int{root}
+	for len(queue) > 0 {
+		pid := queue[0]
+		queue = queue[1:]
+		for _, child := range children[pid] {
+			if !set[child] {
+				set[child] = true
+				queue = append(queue, child)
+			}
+		}
+	}
+	return set
+}
+
+// tree sums CPU time and RSS over root and its descendants
+func tree(procs map[int]procStat, root int) (cpu time.Duration, rss uint64, count int) {
+	for pid := range descendants(procs, root) {
+		p, ok := procs[pid]
+		if !ok {
+			continue
+		}
+		cpu += p.cpu
+		rss += p.rssBytes
+		count++
+	}
+	return cpu, rss, count
+}
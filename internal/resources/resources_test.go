@@ -0,0 +1,204 @@
+package resources
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeHost serves a scripted process table
+type fakeHost struct {
+	mu    sync.Mutex
+	procs map[int]procStat
+	gpu   map[int]uint64
+}
+
+func (h *fakeHost) read() (map[int]procStat, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	out := make(map[int]procStat, len(h.procs))
+	for pid, p := range h.procs {
+		out[pid] = p
+	}
+	return out, nil
+}
+
+func (h *fakeHost) readGPU() (map[int]uint64, bool) {
+	return h.gpu, h.gpu != nil
+}
+
+func (h *fakeHost) burn(pid int, cpu time.Duration, rss uint64) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	p := h.procs[pid]
+	p.cpu += cpu
+	p.rssBytes = rss
+	h.procs[pid] = p
+}
+
+func TestMonitor_AggregatesProcessTree(t *testing.T) {
+	host := &fakeHost{
+		procs: map[int]procStat{
+			1:  {ppid: 0, rssBytes: 1 << 20},
+			10: {ppid: 1, rssBytes: 100 << 20}, // browser
+			11: {ppid: 10, rssBytes: 50 << 20}, // renderer
+			12: {ppid: 11, rssBytes: 25 << 20}, // nested helper
+			20: {ppid: 1, rssBytes: 900 << 20}, // unrelated process
+		},
+		gpu: map[int]uint64{11: 64 << 20, 20: 512 << 20},
+	}
+	monitor, err := start(10, time.Hour, host.read, host.readGPU)
+	require.NoError(t, err)
+
+	host.burn(11, 500*time.Millisecond, 80<<20)
+	usage := monitor.Stop()
+
+	require.Len(t, usage.Samples, 1, "Stop takes a final sample")
+	s := usage.Samples[0]
+	assert.Equal(t, 3, s.Processes)
+	assert.Equal(t, uint64(205<<20), s.RSSBytes)
+	assert.Equal(t, uint64(64<<20), s.GPUMemoryBytes, "unrelated GPU users are excluded")
+	assert.Greater(t, s.CPUPercent, 0.0)
+	assert.Equal(t, s.CPUPercent, usage.PeakCPUPercent)
+	assert.Equal(t, uint64(205<<20), usage.PeakRSSBytes)
+	assert.True(t, usage.GPUAvailable)
+	assert.Equal(t, 10, usage.PID)
+
+	again := monitor.Stop()
+	assert.Len(t, again.Samples, 1, "Stop is idempotent")
+}
+
+func TestMeasure(t *testing.T) {
+	host := &fakeHost{procs: map[int]procStat{
+		10: {ppid: 1, rssBytes: 100 << 20},
+		11: {ppid: 10, rssBytes: 50 << 20},
+		20: {ppid: 1, rssBytes: 900 << 20},
+	}}
+	s, err := measure(10, host.read)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(150<<20), s.RSSBytes)
+	assert.Equal(t, 2, s.Processes)
+	assert.False(t, s.Time.IsZero())
+
+	_, err = measure(30, host.read)
+	assert.EqualError(t, err, "process 30 not found")
+}
+
+func TestMonitor_SamplesAtInterval(t *testing.T) {
+	host := &fakeHost{procs: map[int]procStat{42: {ppid: 1, rssBytes: 10 << 20}}}
+	monitor, err := start(42, 5*time.Millisecond, host.read, host.readGPU)
+	require.NoError(t, err)
+
+	time.Sleep(40 * time.Millisecond)
+	usage := monitor.Stop()
+	assert.GreaterOrEqual(t, len(usage.Samples), 3)
+	assert.False(t, usage.GPUAvailable)
+	for i := 1; i < len(usage.Samples); i++ {
+		assert.True(t, usage.Samples[i].Time.After(usage.Samples[i-1].Time))
+	}
+}
+
+func TestMonitor_ProcessExit(t *testing.T) {
+	host := &fakeHost{procs: map[int]procStat{7: {ppid: 1}}}
+	monitor, err := start(7, 5*time.Millisecond, host.read, host.readGPU)
+	require.NoError(t, err)
+
+	host.mu.Lock()
+	delete(host.procs, 7)
+	host.mu.Unlock()
+	time.Sleep(20 * time.Millisecond)
+	assert.Empty(t, monitor.Stop().Samples, "sampling ends with the process")
+
+	_, err = start(7, time.Second, host.read, host.readGPU)
+	assert.ErrorContains(t, err, "not found")
+	_, err = Start(0, time.Second)
+	assert.Error(t, err)
+
+	var nilMonitor *Monitor
+	assert.Equal(t, Usage{}, nilMonitor.Stop())
+}
+
+func TestUsage_Violations(t *testing.T) {
+	usage := Usage{PeakCPUPercent: 180, PeakRSSBytes: 600 << 20, PeakGPUMemoryBytes: 300 << 20}
+
+	assert.Empty(t, usage.Violations(Thresholds{}))
+	assert.Empty(t, usage.Violations(Thresholds{MaxCPUPercent: 200, MaxRSSBytes: 1 << 30}))
+
+	got := usage.Violations(Thresholds{MaxCPUPercent: 150, MaxRSSBytes: 512 << 20, MaxGPUMemoryBytes: 256 << 20})
+	require.Len(t, got, 2, "GPU bounds need a GPU memory source")
+	assert.Equal(t, "CPU peaked at 180.0% (limit 150.0%)", got[0])
+	assert.Equal(t, "memory peaked at 600.0 MB (limit 512.0 MB)", got[1])
+
+	usage.GPUAvailable = true
+	got = usage.Violations(Thresholds{MaxGPUMemoryBytes: 256 << 20})
+	assert.Equal(t, []string{"GPU memory peaked at 300.0 MB (limit 256.0 MB)"}, got)
+}
+
+func TestParseProcStat(t *testing.T) {
+	line := "1234 (Web Content (x)) S 1200 1234 1200 0 -1 4194560 100 0 0 0 250 50 0 0 20 0 30 0 9000 400000000 2048 18446744073709551615"
+	p, ok := parseProcStat([]byte(line), 4096)
+	require.True(t, ok)
+	assert.Equal(t, 1200, p.ppid)
+	assert.Equal(t, 3*time.Second, p.cpu)
+	assert.Equal(t, uint64(2048*4096), p.rssBytes)
+
+	_, ok = parseProcStat([]byte("1 (init) S 0"), 4096)
+	assert.False(t, ok)
+}
+
+func TestReadProcFS(t *testing.T) {
+	root := t.TempDir()
+	write := func(pid, stat string) {
+		require.NoError(t, os.MkdirAll(filepath.Join(root, pid), 0755))
+		require.NoError(t, os.WriteFile(filepath.Join(root, pid, "stat"), []byte(stat), 0644))
+	}
+	write("1", "1 (init) S 0 1 1 0 -1 0 0 0 0 0 10 10 0 0 20 0 1 0 1 1000 10 0")
+	write("2", "2 (chrome) S 1 2 2 0 -1 0 0 0 0 0 100 0 0 0 20 0 1 0 1 1000 20 0")
+	write("self", "ignored")
+	require.NoError(t, os.MkdirAll(filepath.Join(root, "3"), 0755)) // exited
+
+	procs, err := readProcFS(root)
+	require.NoError(t, err)
+	require.Len(t, procs, 2)
+	assert.Equal(t, 1, procs[2].ppid)
+	assert.Equal(t, time.Second, procs[2].cpu)
+}
+
+func TestParsePS(t *testing.T) {
+	procs, err := parsePS([]byte("  1     0  1024   0:01.50\n 55     1 20480 1-02:03:04\nbogus\n"))
+	require.NoError(t, err)
+	assert.Equal(t, procStat{ppid: 0, rssBytes: 1 << 20, cpu: 1500 * time.Millisecond}, procs[1])
+	assert.Equal(t, 26*time.Hour+3*time.Minute+4*time.Second, procs[55].cpu)
+
+	_, err = parsePS([]byte(""))
+	assert.Error(t, err)
+	_, err = parseCPUTime("12")
+	assert.Error(t, err)
+}
+
+func TestParseNvidiaSMI(t *testing.T) {
+	usage := parseNvidiaSMI([]byte("1234, 256\n1234, 128\n99, [N/A]\n"))
+	assert.Equal(t, map[int]uint64{1234: 384 << 20}, usage)
+}
+
+func TestStart_CurrentProcess(t *testing.T) {
+	if runtime.GOOS != "linux" && runtime.GOOS != "darwin" {
+		t.Skip("process sampling not supported on " + runtime.GOOS)
+	}
+	monitor, err := Start(os.Getpid(), 10*time.Millisecond)
+	require.NoError(t, err)
+
+	deadline := time.Now().Add(50 * time.Millisecond)
+	for time.Now().Before(deadline) {
+	}
+	usage := monitor.Stop()
+	require.NotEmpty(t, usage.Samples)
+	assert.Greater(t, usage.PeakRSSBytes, uint64(0))
+	assert.Greater(t, usage.PeakCPUPercent, 0.0)
+}
@@ -0,0 +1,177 @@
+// Package sarif writes Static Analysis Results Interchange Format (SARIF)
+// 2.1.0 logs, the format GitHub code scanning and Azure DevOps ingest to show
+// findings as pull request annotations. Only the subset of the schema those
+// consumers read is modelled.
+package sarif
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// Version is the SARIF specification version written to every log
+const Version = "2.1.0"
+
+// SchemaURI is the JSON schema of the SARIF version written
+const SchemaURI = "https://json.schemastore.org/sarif-2.1.0.json"
+
+// Result levels; SARIF has no "critical", so it maps onto LevelError
+const (
+	LevelError   = "error"
+	LevelWarning = "warning"
+	LevelNote    = "note"
+)
+
+// Log is the top-level SARIF document
+type Log struct {
+	Schema  string `json:"$schema"`
+	Version string `json:"version"`
+	Runs    []*Run `json:"runs"`
+}
+
+// Run is the output of a single invocation of a tool
+type Run struct {
+	Tool    Tool      `json:"tool"`
+	Results []*Result `json:"results"`
+}
+
+// Tool describes the analysis tool that produced a run
+type Tool struct {
+	Driver Driver `json:"driver"`
+}
+
+// Driver is the tool component, with the rules its results refer to
+type Driver struct {
+	Name           string  `json:"name"`
+	Version        string  `json:"version,omitempty"`
+	InformationURI string  `json:"informationUri,omitempty"`
+	Rules          []*Rule `json:"rules"`
+}
+
+// Rule describes one kind of finding
+type Rule struct {
+	ID                   string            `json:"id"`
+	Name                 string            `json:"name,omitempty"`
+	ShortDescription     *Message          `json:"shortDescription,omitempty"`
+	Help                 *Message          `json:"help,omitempty"`
+	DefaultConfiguration *RuleConfig       `json:"defaultConfiguration,omitempty"`
+	Properties           map[string]string `json:"properties,omitempty"`
+}
+
+// RuleConfig holds the default level of a rule
+type RuleConfig struct {
+	Level string `json:"level"`
+}
+
+// Result is a single finding
+type Result struct {
+	RuleID     string                 `json:"ruleId"`
+	RuleIndex  int                    `json:"ruleIndex"`
+	Level      string                 `json:"level"`
+	Message    Message                `json:"message"`
+	Locations  []Location             `json:"locations,omitempty"`
+	Properties map[string]interface{} `json:"properties,omitempty"`
+}
+
+// Message is a plain-text message
+type Message struct {
+	Text string `json:"text"`
+}
+
+// Location is where a result was found
+type Location struct {
+	PhysicalLocation *PhysicalLocation `json:"physicalLocation,omitempty"`
+	LogicalLocations []LogicalLocation `json:"logicalLocations,omitempty"`
+}
+
+// PhysicalLocation is a region of a file in the repository
+type PhysicalLocation struct {
+	ArtifactLocation ArtifactLocation `json:"artifactLocation"`
+	Region           *Region          `json:"region,omitempty"`
+}
+
+// ArtifactLocation is a file, relative to the repository root
+type ArtifactLocation struct {
+	URI string `json:"uri"`
+}
+
+// Region is a line range; lines are 1-based
+type Region struct {
+	StartLine int `json:"startLine"`
+}
+
+// LogicalLocation names the app or action a result belongs to
+type LogicalLocation struct {
+	Name               string `json:"name"`
+	FullyQualifiedName string `json:"fullyQualifiedName,omitempty"`
+	Kind               string `json:"kind,omitempty"`
+}
+
+// NewLog returns a log with one run for the named tool
+func NewLog(toolName, toolVersion, informationURI string) *Log {
+	return &Log{
+		Schema:  SchemaURI,
+		Version: Version,
+		Runs: []*Run{{
+			Tool:    Tool{Driver: Driver{Name: toolName, Version: toolVersion, InformationURI: informationURI, Rules: []*Rule{}}},
+			Results: []*Result{},
+		}},
+	}
+}
+
+// AddRule registers a rule, returning its index; adding an ID twice keeps
+// the first definition
+func (l *Log) AddRule(rule Rule) int {
+	driver := &l.Runs[0].Tool.Driver
+	for i, r := range driver.Rules {
+		if r.ID == rule.ID {
+			return i
+		}
+	}
+	driver.Rules = append(driver.Rules, &rule)
+	return len(driver.Rules) - 1
+}
+
+// AddResult appends a result for a rule registered with AddRule
+func (l *Log) AddResult(result Result) error {
+	index := -1
+	for i, r := range l.Runs[0].Tool.Driver.Rules {
+		if r.ID == result.RuleID {
+			index = i
+			break
+		}
+	}
+	if index < 0 {
+		return fmt.Errorf("result refers to unknown rule %q", result.RuleID)
+	}
+	result.RuleIndex = index
+	if result.Level == "" {
+		result.Level = LevelWarning
+	}
+	l.Runs[0].Results = append(l.Runs[0].Results, &result)
+	return nil
+}
+
+// Write saves the log as indented JSON
+func (l *Log) Write(path string) error {
+	data, err := json.MarshalIndent(l, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal SARIF log: %w", err)
+	}
+	return os.WriteFile(path, data, 0600)
+}
+
+// LevelFromSeverity maps a Panoptic severity (critical, high, medium, low,
+// info, or the failure policy's error and warning) to a SARIF level
+func LevelFromSeverity(severity string) string {
+	switch strings.ToLower(severity) {
+	case "critical", "high", "error":
+		return LevelError
+	case "low", "info":
+		return LevelNote
+	default:
+		return LevelWarning
+	}
+}
@@ -0,0 +1,69 @@
+package sarif
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestLog_AddRuleAndResult tests rule deduplication and rule indexes
+func TestLog_AddRuleAndResult(t *testing.T) {
+	log := NewLog("Panoptic", "v1.0.0", "")
+	assert.Equal(t, 0, log.AddRule(Rule{ID: "a"}))
+	assert.Equal(t, 1, log.AddRule(Rule{ID: "b"}))
+	assert.Equal(t, 0, log.AddRule(Rule{ID: "a", Name: "ignored"}))
+	assert.Len(t, log.Runs[0].Tool.Driver.Rules, 2)
+	assert.Empty(t, log.Runs[0].Tool.Driver.Rules[0].Name)
+
+	require.NoError(t, log.AddResult(Result{RuleID: "b", Message: Message{Text: "found"}}))
+	result := log.Runs[0].Results[0]
+	assert.Equal(t, 1, result.RuleIndex)
+	assert.Equal(t, LevelWarning, result.Level, "level defaults to warning")
+
+	assert.ErrorContains(t, log.AddResult(Result{RuleID: "missing"}), "unknown rule")
+}
+
+// TestLog_Write tests the written document layout
+func TestLog_Write(t *testing.T) {
+	log := NewLog("Panoptic", "", "https://example.com")
+	log.AddRule(Rule{ID: "r", DefaultConfiguration: &RuleConfig{Level: LevelError}})
+	require.NoError(t, log.AddResult(Result{
+		RuleID:  "r",
+		Level:   LevelError,
+		Message: Message{Text: "broken"},
+		Locations: []Location{{
+			PhysicalLocation: &PhysicalLocation{ArtifactLocation: ArtifactLocation{URI: "tests/panoptic.yaml"}, Region: &Region{StartLine: 7}},
+		}},
+	}))
+
+	path := filepath.Join(t.TempDir(), "results.sarif")
+	require.NoError(t, log.Write(path))
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+
+	var doc map[string]interface{}
+	require.NoError(t, json.Unmarshal(data, &doc))
+	assert.Equal(t, "2.1.0", doc["version"])
+	assert.Equal(t, SchemaURI, doc["$schema"])
+
+	var parsed Log
+	require.NoError(t, json.Unmarshal(data, &parsed))
+	require.Len(t, parsed.Runs, 1)
+	assert.Equal(t, "Panoptic", parsed.Runs[0].Tool.Driver.Name)
+	assert.Equal(t, 7, parsed.Runs[0].Results[0].Locations[0].PhysicalLocation.Region.StartLine)
+}
+
+// TestLevelFromSeverity tests severity mapping
+func TestLevelFromSeverity(t *testing.T) {
+	for severity, level := range map[string]string{
+		"critical": LevelError, "HIGH": LevelError, "error": LevelError,
+		"medium": LevelWarning, "warning": LevelWarning, "": LevelWarning,
+		"low": LevelNote, "info": LevelNote,
+	} {
+		assert.Equal(t, level, LevelFromSeverity(severity), severity)
+	}
+}
@@ -0,0 +1,51 @@
+package secrets
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// Env reads secrets from environment variables: the fields of secret
+// shop/admin are the variables starting SHOP_ADMIN_, so SHOP_ADMIN_PASSWORD
+// is its password field. Names are upper-cased and every character other
+// than a letter or digit becomes an underscore.
+type Env struct {
+	environ func() []string
+}
+
+// NewEnv returns a provider reading the process environment
+func NewEnv() *Env {
+	return &Env{environ: os.Environ}
+}
+
+// EnvPrefix is the prefix of the variables holding the fields of secret name
+func EnvPrefix(name string) string {
+	var b strings.Builder
+	for _, r := range strings.ToUpper(name) {
+		if (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') {
+			b.WriteRune(r)
+		} else {
+			b.WriteByte('_')
+		}
+	}
+	b.WriteByte('_')
+	return b.String()
+}
+
+// Get collects the variables of the secret, with lower-cased field names
+func (e *Env) Get(_ context.Context, name string) (Secret, error) {
+	prefix := EnvPrefix(name)
+	secret := Secret{}
+	for _, kv := range e.environ() {
+		key, value, _ := strings.Cut(kv, "=")
+		if strings.HasPrefix(key, prefix) && len(key) > len(prefix) {
+			secret[strings.ToLower(key[len(prefix):])] = value
+		}
+	}
+	if len(secret) == 0 {
+		return nil, fmt.Errorf("secret %s: no %s* environment variables", name, prefix)
+	}
+	return secret, nil
+}
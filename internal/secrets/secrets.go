@@ -0,0 +1,85 @@
+// Package secrets reads credentials from HashiCorp Vault, AWS Secrets
+// Manager or the environment when an action needs them, so they never have
+// to be written into test configurations, and generates the one-time codes
+// of the TOTP secrets guarding two-factor logins.
+package secrets
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Secret is the fields of a secret, such as its username and password
+type Secret map[string]string
+
+// Field returns the value of key, failing when the secret has no such field.
+// The error names the fields the secret has, never their values.
+func (s Secret) Field(key string) (string, error) {
+	value, ok := s[key]
+	if !ok {
+		keys := make([]string, 0, len(s))
+		for k := range s {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		return "", fmt.Errorf("secret has no field %q (fields: %s)", key, strings.Join(keys, ", "))
+	}
+	return value, nil
+}
+
+// Provider fetches secrets by name
+type Provider interface {
+	Get(ctx context.Context, name string) (Secret, error)
+}
+
+// fields turns the fields of a secret decoded from JSON into strings; a
+// secret that's a plain string becomes its value field
+func fields(data []byte) (Secret, error) {
+	var raw map[string]interface{}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return Secret{"value": string(data)}, nil
+	}
+	secret := make(Secret, len(raw))
+	for k, v := range raw {
+		switch value := v.(type) {
+		case string:
+			secret[k] = value
+		case nil:
+			secret[k] = ""
+		default:
+			encoded, err := json.Marshal(value)
+			if err != nil {
+				return nil, err
+			}
+			secret[k] = string(encoded)
+		}
+	}
+	return secret, nil
+}
+
+var httpClient = &http.Client{Timeout: 30 * time.Second}
+
+// send sends req and returns the response body, failing on non-2xx statuses
+// without quoting the body, which may echo the request
+func send(req *http.Request) ([]byte, error) {
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return nil, fmt.Errorf("status %d", resp.StatusCode)
+	}
+	return bytes.TrimSpace(data), nil
+}
@@ -0,0 +1,45 @@
+package secrets
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSecret_Field(t *testing.T) {
+	secret := Secret{"username": "ada", "password": "hunter22"}
+	value, err := secret.Field("username")
+	require.NoError(t, err)
+	assert.Equal(t, "ada", value)
+
+	_, err = secret.Field("token")
+	assert.EqualError(t, err, `secret has no field "token" (fields: password, username)`)
+	assert.NotContains(t, err.Error(), "hunter22")
+}
+
+func TestFields(t *testing.T) {
+	secret, err := fields([]byte(`{"username":"ada","pin":1234,"otp":null}`))
+	require.NoError(t, err)
+	assert.Equal(t, Secret{"username": "ada", "pin": "1234", "otp": ""}, secret)
+
+	secret, err = fields([]byte("plain-token"))
+	require.NoError(t, err)
+	assert.Equal(t, Secret{"value": "plain-token"}, secret)
+}
+
+func TestEnv_Get(t *testing.T) {
+	env := &Env{environ: func() []string {
+		return []string{"SHOP_ADMIN_USERNAME=ada", "SHOP_ADMIN_PASSWORD=a=b", "SHOP_ADMIN_=x", "SHOP_USER_PASSWORD=other"}
+	}}
+	assert.Equal(t, "SHOP_ADMIN_", EnvPrefix("shop/admin"))
+	assert.Equal(t, "SHOP_ADMIN_", EnvPrefix("shop-admin"))
+
+	secret, err := env.Get(context.Background(), "shop/admin")
+	require.NoError(t, err)
+	assert.Equal(t, Secret{"username": "ada", "password": "a=b"}, secret)
+
+	_, err = env.Get(context.Background(), "billing")
+	assert.EqualError(t, err, "secret billing: no BILLING_* environment variables")
+}
@@ -0,0 +1,127 @@
+package secrets
+
+import (
+	"crypto/hmac"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base32"
+	"encoding/binary"
+	"fmt"
+	"hash"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// TOTP generates the time-based one-time passwords of RFC 6238 that
+// authenticator apps show
+type TOTP struct {
+	Key       []byte
+	Digits    int           // default 6
+	Period    time.Duration // default 30s
+	Algorithm string        // SHA1 (default), SHA256 or SHA512
+}
+
+// ParseTOTP reads a shared secret as authenticators are given it: a base32
+// key, spaces and case ignored, or an otpauth:// URI whose digits, period
+// and algorithm apply unless overridden by non-zero arguments
+func ParseTOTP(secret string, digits int, period time.Duration, algorithm string) (*TOTP, error) {
+	totp := &TOTP{}
+	key := secret
+	if strings.HasPrefix(secret, "otpauth://") {
+		u, err := url.Parse(secret)
+		if err != nil {
+			return nil, fmt.Errorf("invalid otpauth URI")
+		}
+		q := u.Query()
+		key = q.Get("secret")
+		if v := q.Get("digits"); v != "" {
+			if totp.Digits, err = strconv.Atoi(v); err != nil {
+				return nil, fmt.Errorf("invalid otpauth digits %q", v)
+			}
+		}
+		if v := q.Get("period"); v != "" {
+			seconds, err := strconv.Atoi(v)
+			if err != nil {
+				return nil, fmt.Errorf("invalid otpauth period %q", v)
+			}
+			totp.Period = time.Duration(seconds) * time.Second
+		}
+		totp.Algorithm = q.Get("algorithm")
+	}
+	if digits != 0 {
+		totp.Digits = digits
+	}
+	if period != 0 {
+		totp.Period = period
+	}
+	if algorithm != "" {
+		totp.Algorithm = algorithm
+	}
+
+	key = strings.ToUpper(strings.NewReplacer(" ", "", "-", "").Replace(key))
+	decoded, err := base32.StdEncoding.WithPadding(base32.NoPadding).DecodeString(strings.TrimRight(key, "="))
+	if err != nil || len(decoded) == 0 {
+		// the error would quote the secret
+		return nil, fmt.Errorf("TOTP secret is not a base32 key")
+	}
+	totp.Key = decoded
+	return totp, totp.validate()
+}
+
+func (t *TOTP) validate() error {
+	if t.Digits == 0 {
+		t.Digits = 6
+	}
+	if t.Period == 0 {
+		t.Period = 30 * time.Second
+	}
+	if t.Algorithm == "" {
+		t.Algorithm = "SHA1"
+	}
+	t.Algorithm = strings.ToUpper(t.Algorithm)
+	if t.Digits < 6 || t.Digits > 10 {
+		return fmt.Errorf("TOTP digits must be between 6 and 10, got %d", t.Digits)
+	}
+	if t.Period < time.Second {
+		return fmt.Errorf("TOTP period must be at least 1s, got %s", t.Period)
+	}
+	if t.hash() == nil {
+		return fmt.Errorf("TOTP algorithm must be SHA1, SHA256 or SHA512, got %q", t.Algorithm)
+	}
+	return nil
+}
+
+func (t *TOTP) hash() func() hash.Hash {
+	switch t.Algorithm {
+	case "SHA1":
+		return sha1.New
+	case "SHA256":
+		return sha256.New
+	case "SHA512":
+		return sha512.New
+	}
+	return nil
+}
+
+// Code returns the code valid at now and when it stops being valid
+func (t *TOTP) Code(now time.Time) (string, time.Time) {
+	period := int64(t.Period / time.Second)
+	counter := now.Unix() / period
+	var msg [8]byte
+	binary.BigEndian.PutUint64(msg[:], uint64(counter))
+	mac := hmac.New(t.hash(), t.Key)
+	mac.Write(msg[:])
+	sum := mac.Sum(nil)
+
+	offset := sum[len(sum)-1] & 0x0f
+	value := uint64(binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff)
+	mod := uint64(1)
+	for i := 0; i < t.Digits; i++ {
+		mod *= 10
+	}
+	code := fmt.Sprintf("%0*d", t.Digits, value%mod)
+	return code, time.Unix((counter+1)*period, 0)
+}
@@ -0,0 +1,67 @@
+package secrets
+
+import (
+	"encoding/base32"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestTOTP_Code checks the test vectors of RFC 6238, appendix B
+func TestTOTP_Code(t *testing.T) {
+	keys := map[string]string{
+		"SHA1":   "12345678901234567890",
+		"SHA256": "12345678901234567890123456789012",
+		"SHA512": "1234567890123456789012345678901234567890123456789012345678901234",
+	}
+	vectors := []struct {
+		unix      int64
+		algorithm string
+		code      string
+	}{
+		{59, "SHA1", "94287082"},
+		{59, "SHA256", "46119246"},
+		{59, "SHA512", "90693936"},
+		{1111111109, "SHA1", "07081804"},
+		{1234567890, "SHA256", "91819424"},
+		{2000000000, "SHA512", "38618901"},
+		{20000000000, "SHA1", "65353130"},
+	}
+	for _, v := range vectors {
+		secret := base32.StdEncoding.EncodeToString([]byte(keys[v.algorithm]))
+		totp, err := ParseTOTP(secret, 8, 0, v.algorithm)
+		require.NoError(t, err)
+		code, expires := totp.Code(time.Unix(v.unix, 0))
+		assert.Equal(t, v.code, code, "%s at %d", v.algorithm, v.unix)
+		assert.Equal(t, time.Unix((v.unix/30+1)*30, 0), expires)
+	}
+}
+
+func TestParseTOTP(t *testing.T) {
+	totp, err := ParseTOTP("gezd gnbv gy3t qojq", 0, 0, "")
+	require.NoError(t, err)
+	assert.Equal(t, []byte("1234567890"), totp.Key)
+	assert.Equal(t, 6, totp.Digits)
+	assert.Equal(t, 30*time.Second, totp.Period)
+	assert.Equal(t, "SHA1", totp.Algorithm)
+
+	totp, err = ParseTOTP("otpauth://totp/Shop:ada?secret=GEZDGNBVGY3TQOJQ&issuer=Shop&digits=8&period=60&algorithm=SHA256", 0, 0, "")
+	require.NoError(t, err)
+	assert.Equal(t, 8, totp.Digits)
+	assert.Equal(t, time.Minute, totp.Period)
+	assert.Equal(t, "SHA256", totp.Algorithm)
+
+	totp, err = ParseTOTP("otpauth://totp/Shop:ada?secret=GEZDGNBVGY3TQOJQ&digits=8", 7, 0, "sha512")
+	require.NoError(t, err)
+	assert.Equal(t, 7, totp.Digits)
+	assert.Equal(t, "SHA512", totp.Algorithm)
+
+	_, err = ParseTOTP("not base32!", 0, 0, "")
+	assert.EqualError(t, err, "TOTP secret is not a base32 key")
+	_, err = ParseTOTP("GEZDGNBVGY3TQOJQ", 4, 0, "")
+	assert.EqualError(t, err, "TOTP digits must be between 6 and 10, got 4")
+	_, err = ParseTOTP("GEZDGNBVGY3TQOJQ", 0, 0, "MD5")
+	assert.EqualError(t, err, `TOTP algorithm must be SHA1, SHA256 or SHA512, got "MD5"`)
+}
@@ -0,0 +1,77 @@
+package secrets
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// Vault reads secrets from a key/value secrets engine of HashiCorp Vault
+type Vault struct {
+	address   string
+	token     string
+	namespace string
+	mount     string
+	version   int
+}
+
+// NewVault returns a provider for the KV engine mounted at mount (default
+// "secret") of the Vault server at address. Version is the engine's
+// version, 1 or 2 (the default); namespace is for Vault Enterprise.
+func NewVault(address, token, namespace, mount string, version int) *Vault {
+	if mount == "" {
+		mount = "secret"
+	}
+	if version == 0 {
+		version = 2
+	}
+	return &Vault{
+		address:   strings.TrimRight(address, "/"),
+		token:     token,
+		namespace: namespace,
+		mount:     strings.Trim(mount, "/"),
+		version:   version,
+	}
+}
+
+// Get reads the latest version of the secret at path name
+func (v *Vault) Get(ctx context.Context, name string) (Secret, error) {
+	path := "/v1/" + v.mount + "/" + strings.Trim(name, "/")
+	if v.version == 2 {
+		path = "/v1/" + v.mount + "/data/" + strings.Trim(name, "/")
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, v.address+path, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("X-Vault-Token", v.token)
+	if v.namespace != "" {
+		req.Header.Set("X-Vault-Namespace", v.namespace)
+	}
+	data, err := send(req)
+	if err != nil {
+		return nil, fmt.Errorf("vault secret %s: %w", name, err)
+	}
+
+	var resp struct {
+		Data json.RawMessage `json:"data"`
+	}
+	if err := json.Unmarshal(data, &resp); err != nil {
+		return nil, fmt.Errorf("vault secret %s: invalid response: %w", name, err)
+	}
+	if v.version == 2 {
+		var inner struct {
+			Data json.RawMessage `json:"data"`
+		}
+		if err := json.Unmarshal(resp.Data, &inner); err != nil {
+			return nil, fmt.Errorf("vault secret %s: invalid response: %w", name, err)
+		}
+		resp.Data = inner.Data
+	}
+	if len(resp.Data) == 0 || string(resp.Data) == "null" {
+		return nil, fmt.Errorf("vault secret %s: no data, is it deleted?", name)
+	}
+	return fields(resp.Data)
+}
@@ -0,0 +1,47 @@
+package secrets
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestVault_Get(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Vault-Token") != "s.token" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		switch r.URL.Path {
+		case "/v1/secret/data/shop/admin":
+			assert.Equal(t, "team-a", r.Header.Get("X-Vault-Namespace"))
+			w.Write([]byte(`{"data":{"data":{"username":"ada","password":"hunter22"},"metadata":{"version":3}}}`))
+		case "/v1/kv/shop/admin":
+			w.Write([]byte(`{"data":{"username":"bob","password":"swordfish"}}`))
+		case "/v1/secret/data/deleted":
+			w.Write([]byte(`{"data":{"data":null,"metadata":{"deletion_time":"2026-01-01T00:00:00Z"}}}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+	ctx := context.Background()
+
+	secret, err := NewVault(server.URL+"/", "s.token", "team-a", "", 0).Get(ctx, "shop/admin")
+	require.NoError(t, err)
+	assert.Equal(t, Secret{"username": "ada", "password": "hunter22"}, secret)
+
+	secret, err = NewVault(server.URL, "s.token", "", "/kv/", 1).Get(ctx, "/shop/admin")
+	require.NoError(t, err)
+	assert.Equal(t, Secret{"username": "bob", "password": "swordfish"}, secret)
+
+	_, err = NewVault(server.URL, "s.token", "", "", 2).Get(ctx, "deleted")
+	assert.EqualError(t, err, "vault secret deleted: no data, is it deleted?")
+
+	_, err = NewVault(server.URL, "wrong", "", "", 2).Get(ctx, "shop/admin")
+	assert.EqualError(t, err, "vault secret shop/admin: status 403")
+}
@@ -0,0 +1,11 @@
+//go:build !windows
+
+package selfupdate
+
+import "os"
+
+// replaceExecutable moves a staged binary over the executable; the running
+// process keeps the old file open until it restarts
+func replaceExecutable(staged, executable string) error {
+	return os.Rename(staged, executable)
+}
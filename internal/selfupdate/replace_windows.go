@@ -0,0 +1,10 @@
+//go:build windows
+
+package selfupdate
+
+// replaceExecutable moves the executable aside before the staged binary
+// takes its place: Windows lets a running executable be renamed but not
+// replaced
+func replaceExecutable(staged, executable string) error {
+	return renameAside(staged, executable)
+}
@@ -0,0 +1,14 @@
+//go:build !windows
+
+package selfupdate
+
+import (
+	"os"
+	"syscall"
+)
+
+// Restart replaces the running process with the executable, given the same
+// arguments and environment, so an installed update takes over at once
+func Restart(executable string) error {
+	return syscall.Exec(executable, os.Args, os.Environ())
+}
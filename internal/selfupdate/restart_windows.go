@@ -0,0 +1,11 @@
+//go:build windows
+
+package selfupdate
+
+import "fmt"
+
+// Restart can't replace the running process on Windows: the update runs
+// once the agent is restarted, as by its service manager
+func Restart(executable string) error {
+	return fmt.Errorf("restarting in place needs exec, which Windows doesn't have: restart the agent to run %s", executable)
+}
@@ -0,0 +1,444 @@
+// Package selfupdate keeps distributed agents on the version of their
+// release channel.
+//
+// A channel is a JSON manifest naming a version and its binaries, one per
+// platform, each with its SHA-256 and an ed25519 signature over the version,
+// platform and digest. Agents download the binary of their platform, check
+// both against the release key they trust, and replace their executable.
+package selfupdate
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"runtime"
+	"runtime/debug"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultKeyEnv holds the private release key of release sign
+const DefaultKeyEnv = "PANOPTIC_RELEASE_KEY"
+
+// Devel is the version of a build without one
+const Devel = "(devel)"
+
+// Download limits, so a channel can't fill the agent's memory before its
+// signature is checked
+const (
+	MaxManifestSize = 1 << 20   // 1 MiB
+	MaxBinarySize   = 512 << 20 // 512 MiB
+)
+
+// version is set at build time with
+// -ldflags "-X panoptic/internal/selfupdate.version=v1.4.0"
+var version string
+
+// Version is the version of the running panoptic: the one set at build
+// time, or else that of the module build info
+func Version() string {
+	if version != "" {
+		return version
+	}
+	if info, ok := debug.ReadBuildInfo(); ok && info.Main.Version != "" {
+		return info.Main.Version
+	}
+	return Devel
+}
+
+// Executable is the file of the running panoptic, symbolic links resolved
+// so an update replaces the binary itself
+func Executable() (string, error) {
+	exe, err := os.Executable()
+	if err != nil {
+		return "", fmt.Errorf("failed to find the panoptic executable: %w", err)
+	}
+	return filepath.EvalSymlinks(exe)
+}
+
+// Manifest is the release a channel points agents to
+type Manifest struct {
+	Channel   string    `json:"channel"`
+	Version   string    `json:"version"`
+	Published time.Time `json:"published"`
+	Binaries  []Binary  `json:"binaries"`
+}
+
+// Binary is the executable of a release for a platform
+type Binary struct {
+	OS        string `json:"os"`
+	Arch      string `json:"arch"`
+	URL       string `json:"url"` // relative to the manifest, or absolute
+	SHA256    string `json:"sha256"`
+	Signature string `json:"signature"` // base64 ed25519 signature of the version, platform and SHA-256
+}
+
+// Binary finds the binary of a platform
+func (m *Manifest) Binary(goos, goarch string) *Binary {
+	for i := range m.Binaries {
+		if m.Binaries[i].OS == goos && m.Binaries[i].Arch == goarch {
+			return &m.Binaries[i]
+		}
+	}
+	return nil
+}
+
+// GenerateKey makes a release key pair, both base64 encoded: the public
+// key agents verify releases with, and the private one release sign uses
+func GenerateKey() (public, private string, err error) {
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return "", "", err
+	}
+	return base64.StdEncoding.EncodeToString(pub), base64.StdEncoding.EncodeToString(priv.Seed()), nil
+}
+
+// ParsePublicKey decodes a public release key given as base64 or hex
+func ParsePublicKey(s string) (ed25519.PublicKey, error) {
+	key, err := parseKey(s)
+	if err != nil {
+		return nil, fmt.Errorf("public release key %w", err)
+	}
+	return ed25519.PublicKey(key), nil
+}
+
+// ParsePrivateKey decodes a private release key given as base64 or hex
+func ParsePrivateKey(s string) (ed25519.PrivateKey, error) {
+	key, err := parseKey(s)
+	if err != nil {
+		return nil, fmt.Errorf("private release key %w", err)
+	}
+	return ed25519.NewKeyFromSeed(key), nil
+}
+
+// Sign records the SHA-256 of a binary's contents and signs it for the
+// version
+func Sign(key ed25519.PrivateKey, version string, binary *Binary, contents []byte) {
+	digest := sha256.Sum256(contents)
+	binary.SHA256 = hex.EncodeToString(digest[:])
+	binary.Signature = base64.StdEncoding.EncodeToString(ed25519.Sign(key, signedMessage(version, *binary)))
+}
+
+// Verify checks the contents of a binary match its SHA-256 and that the
+// key signed it for the version
+func Verify(key ed25519.PublicKey, version string, binary Binary, contents []byte) error {
+	digest := sha256.Sum256(contents)
+	if hex.EncodeToString(digest[:]) != strings.ToLower(binary.SHA256) {
+		return fmt.Errorf("binary for %s/%s doesn't match its SHA-256", binary.OS, binary.Arch)
+	}
+	signature, err := base64.StdEncoding.DecodeString(binary.Signature)
+	if err != nil || !ed25519.Verify(key, signedMessage(version, binary), signature) {
+		return fmt.Errorf("binary for %s/%s of %s isn't signed by the release key", binary.OS, binary.Arch, version)
+	}
+	return nil
+}
+
+// Newer tells whether version candidate is newer than current. Versions are
+// compared as vMAJOR.MINOR.PATCH, a pre-release being older than its
+// release; a development build is older than any version.
+func Newer(candidate, current string) bool {
+	c, ok := parseVersion(candidate)
+	if !ok {
+		return false
+	}
+	v, ok := parseVersion(current)
+	if !ok {
+		return true
+	}
+	for i := range c.numbers {
+		if c.numbers[i] != v.numbers[i] {
+			return c.numbers[i] > v.numbers[i]
+		}
+	}
+	switch {
+	case c.pre == v.pre:
+		return false
+	case c.pre == "":
+		return true
+	case v.pre == "":
+		return false
+	}
+	return c.pre > v.pre
+}
+
+// Updater replaces the executable of an agent with the newer release of
+// its channel
+type Updater struct {
+	ManifestURL string
+	APIKey      string // bearer token sent for the manifest and binaries on its host
+	PublicKey   ed25519.PublicKey
+	Current     string // the version running, Version by default
+	Executable  string // the file replaced, os.Executable by default
+	Client      *http.Client
+}
+
+// Check fetches the manifest of the channel and returns it with the binary
+// of this platform when it names a newer version; the binary is nil when
+// the agent is up to date
+func (u *Updater) Check(ctx context.Context) (*Manifest, *Binary, error) {
+	data, err := u.get(ctx, u.ManifestURL, MaxManifestSize)
+	if err != nil {
+		return nil, nil, err
+	}
+	var manifest Manifest
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return nil, nil, fmt.Errorf("invalid release manifest %s: %w", u.ManifestURL, err)
+	}
+	if !Newer(manifest.Version, u.current()) {
+		return &manifest, nil, nil
+	}
+	binary := manifest.Binary(runtime.GOOS, runtime.GOARCH)
+	if binary == nil {
+		return &manifest, nil, fmt.Errorf("release %s has no binary for %s/%s", manifest.Version, runtime.GOOS, runtime.GOARCH)
+	}
+	return &manifest, binary, nil
+}
+
+// Download fetches and verifies a binary of the release, staging it next
+// to the executable; it returns the staged file for Install
+func (u *Updater) Download(ctx context.Context, manifest *Manifest, binary *Binary) (string, error) {
+	if len(u.PublicKey) != ed25519.PublicKeySize {
+		return "", errors.New("a public release key is required to verify releases")
+	}
+	location, err := u.resolve(binary.URL)
+	if err != nil {
+		return "", err
+	}
+	contents, err := u.get(ctx, location, MaxBinarySize)
+	if err != nil {
+		return "", err
+	}
+	if err := Verify(u.PublicKey, manifest.Version, *binary, contents); err != nil {
+		return "", err
+	}
+	exe, err := u.executable()
+	if err != nil {
+		return "", err
+	}
+	staged, err := os.CreateTemp(filepath.Dir(exe), ".panoptic-update-*")
+	if err != nil {
+		return "", fmt.Errorf("failed to stage the update: %w", err)
+	}
+	defer staged.Close()
+	if _, err := staged.Write(contents); err != nil {
+		os.Remove(staged.Name())
+		return "", fmt.Errorf("failed to stage the update: %w", err)
+	}
+	return staged.Name(), nil
+}
+
+// Install moves a staged binary over the executable, as the version it
+// now runs when restarted. On Windows the executable is renamed aside
+// first, to be removed by RemoveReplaced once the agent has restarted.
+func (u *Updater) Install(staged, version string) error {
+	exe, err := u.executable()
+	if err != nil {
+		return err
+	}
+	if err := os.Chmod(staged, 0755); err != nil {
+		os.Remove(staged)
+		return err
+	}
+	if err := replaceExecutable(staged, exe); err != nil {
+		os.Remove(staged)
+		return fmt.Errorf("failed to replace %s: %w", exe, err)
+	}
+	u.Current = version
+	return nil
+}
+
+// RemoveReplaced removes the executable an update moved aside, which can
+// only be done once the process running it has exited
+func RemoveReplaced(executable string) error {
+	if err := os.Remove(executable + replacedSuffix); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+// replacedSuffix is added to the name of an executable moved aside
+const replacedSuffix = ".old"
+
+// renameAside moves the executable aside, dropping the one a previous
+// update moved there, and the staged binary in its place. The executable is
+// put back when the staged binary can't be moved.
+func renameAside(staged, executable string) error {
+	old := executable + replacedSuffix
+	if err := RemoveReplaced(executable); err != nil {
+		return err
+	}
+	if err := os.Rename(executable, old); err != nil {
+		return err
+	}
+	if err := os.Rename(staged, executable); err != nil {
+		os.Rename(old, executable)
+		return err
+	}
+	return nil
+}
+
+// Window is the time of day updates may be installed, as "02:00-05:00"; it
+// may wrap past midnight, and the zero Window is always open
+type Window struct {
+	start, end time.Duration // since midnight
+	set        bool
+}
+
+// ParseWindow parses a window given as HH:MM-HH:MM; an empty one is always
+// open
+func ParseWindow(s string) (Window, error) {
+	if s == "" {
+		return Window{}, nil
+	}
+	from, to, ok := strings.Cut(s, "-")
+	if !ok {
+		return Window{}, fmt.Errorf("update window %q must be HH:MM-HH:MM", s)
+	}
+	start, err := time.Parse("15:04", strings.TrimSpace(from))
+	if err != nil {
+		return Window{}, fmt.Errorf("update window %q must be HH:MM-HH:MM", s)
+	}
+	end, err := time.Parse("15:04", strings.TrimSpace(to))
+	if err != nil {
+		return Window{}, fmt.Errorf("update window %q must be HH:MM-HH:MM", s)
+	}
+	return Window{start: sinceMidnight(start), end: sinceMidnight(end), set: true}, nil
+}
+
+// Contains tells whether the window is open at t, in t's location
+func (w Window) Contains(t time.Time) bool {
+	if !w.set {
+		return true
+	}
+	now := sinceMidnight(t)
+	if w.start <= w.end {
+		return now >= w.start && now < w.end
+	}
+	return now >= w.start || now < w.end
+}
+
+func (w Window) String() string {
+	if !w.set {
+		return "any time"
+	}
+	format := func(d time.Duration) string { return fmt.Sprintf("%02d:%02d", int(d.Hours()), int(d.Minutes())%60) }
+	return format(w.start) + "-" + format(w.end)
+}
+
+// signedMessage is what the signature of a binary covers
+func signedMessage(version string, binary Binary) []byte {
+	return []byte(fmt.Sprintf("panoptic release %s %s/%s %s", version, binary.OS, binary.Arch, strings.ToLower(binary.SHA256)))
+}
+
+func (u *Updater) current() string {
+	if u.Current == "" {
+		return Version()
+	}
+	return u.Current
+}
+
+func (u *Updater) executable() (string, error) {
+	if u.Executable != "" {
+		return u.Executable, nil
+	}
+	return Executable()
+}
+
+// resolve makes the URL of a binary absolute, relative to the manifest
+func (u *Updater) resolve(ref string) (string, error) {
+	base, err := url.Parse(u.ManifestURL)
+	if err != nil {
+		return "", err
+	}
+	location, err := base.Parse(ref)
+	if err != nil {
+		return "", fmt.Errorf("invalid binary URL %q: %w", ref, err)
+	}
+	return location.String(), nil
+}
+
+// get fetches a URL of at most limit bytes, with the API key only on the
+// host of the manifest
+func (u *Updater) get(ctx context.Context, location string, limit int64) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, location, nil)
+	if err != nil {
+		return nil, err
+	}
+	if manifest, err := url.Parse(u.ManifestURL); err == nil && u.APIKey != "" && manifest.Host == req.URL.Host {
+		req.Header.Set("Authorization", "Bearer "+u.APIKey)
+	}
+	client := u.Client
+	if client == nil {
+		client = &http.Client{Timeout: 5 * time.Minute}
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch %s: %w", location, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to fetch %s: status %d", location, resp.StatusCode)
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, limit+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch %s: %w", location, err)
+	}
+	if int64(len(data)) > limit {
+		return nil, fmt.Errorf("failed to fetch %s: larger than %d bytes", location, limit)
+	}
+	return data, nil
+}
+
+type parsedVersion struct {
+	numbers [3]int
+	pre     string
+}
+
+func parseVersion(s string) (parsedVersion, bool) {
+	var v parsedVersion
+	s = strings.TrimPrefix(strings.TrimSpace(s), "v")
+	s, v.pre, _ = strings.Cut(s, "-")
+	s, _, _ = strings.Cut(s, "+")
+	parts := strings.Split(s, ".")
+	if len(parts) == 0 || len(parts) > 3 {
+		return v, false
+	}
+	for i, part := range parts {
+		n, err := strconv.Atoi(part)
+		if err != nil || n < 0 {
+			return v, false
+		}
+		v.numbers[i] = n
+	}
+	return v, true
+}
+
+func parseKey(s string) ([]byte, error) {
+	s = strings.TrimSpace(s)
+	for _, decode := range []func(string) ([]byte, error){
+		base64.StdEncoding.DecodeString,
+		base64.RawStdEncoding.DecodeString,
+		hex.DecodeString,
+	} {
+		if key, err := decode(s); err == nil && len(key) == ed25519.SeedSize {
+			return key, nil
+		}
+	}
+	return nil, fmt.Errorf("must be %d bytes, base64 or hex encoded", ed25519.SeedSize)
+}
+
+func sinceMidnight(t time.Time) time.Duration {
+	return time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute
+}
@@ -0,0 +1,221 @@
+package selfupdate
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// release serves a channel publishing v1.2.0, its binary signed for this
+// platform, and the updater of an agent running v1.1.0, its executable a
+// temporary file
+func release(t *testing.T, contents string) (*Updater, *httptest.Server, map[string]string) {
+	t.Helper()
+	public, private, err := GenerateKey()
+	require.NoError(t, err)
+	publicKey, err := ParsePublicKey(public)
+	require.NoError(t, err)
+	privateKey, err := ParsePrivateKey(private)
+	require.NoError(t, err)
+
+	binary := Binary{OS: runtime.GOOS, Arch: runtime.GOARCH, URL: "panoptic-v1.2.0"}
+	Sign(privateKey, "v1.2.0", &binary, []byte(contents))
+	manifest := Manifest{Channel: "stable", Version: "v1.2.0", Binaries: []Binary{binary}}
+	headers := map[string]string{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		headers[r.URL.Path] = r.Header.Get("Authorization")
+		switch r.URL.Path {
+		case "/releases/stable.json":
+			json.NewEncoder(w).Encode(manifest)
+		case "/releases/panoptic-v1.2.0":
+			w.Write([]byte(contents))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	exe := filepath.Join(t.TempDir(), "panoptic")
+	require.NoError(t, os.WriteFile(exe, []byte("v1.1.0"), 0755))
+	return &Updater{ManifestURL: server.URL + "/releases/stable.json", APIKey: "secret", PublicKey: publicKey,
+		Current: "v1.1.0", Executable: exe}, server, headers
+}
+
+func TestUpdater(t *testing.T) {
+	updater, _, headers := release(t, "v1.2.0 binary")
+	ctx := context.Background()
+
+	manifest, binary, err := updater.Check(ctx)
+	require.NoError(t, err)
+	require.NotNil(t, binary)
+	assert.Equal(t, "v1.2.0", manifest.Version)
+
+	staged, err := updater.Download(ctx, manifest, binary)
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Dir(updater.Executable), filepath.Dir(staged), "updates are staged next to the executable")
+	assert.Equal(t, "Bearer secret", headers["/releases/panoptic-v1.2.0"])
+	require.NoError(t, updater.Install(staged, manifest.Version))
+	data, err := os.ReadFile(updater.Executable)
+	require.NoError(t, err)
+	assert.Equal(t, "v1.2.0 binary", string(data))
+	info, err := os.Stat(updater.Executable)
+	require.NoError(t, err)
+	if runtime.GOOS != "windows" {
+		assert.Equal(t, os.FileMode(0755), info.Mode().Perm())
+	}
+
+	_, binary, err = updater.Check(ctx)
+	require.NoError(t, err)
+	assert.Nil(t, binary, "the installed version is current")
+}
+
+func TestRenameAside(t *testing.T) {
+	dir := t.TempDir()
+	exe := filepath.Join(dir, "panoptic.exe")
+	staged := filepath.Join(dir, ".panoptic-update-1")
+	require.NoError(t, os.WriteFile(exe, []byte("v1.1.0"), 0755))
+	require.NoError(t, os.WriteFile(exe+".old", []byte("v1.0.0"), 0755))
+	require.NoError(t, os.WriteFile(staged, []byte("v1.2.0"), 0755))
+
+	require.NoError(t, renameAside(staged, exe))
+	data, err := os.ReadFile(exe)
+	require.NoError(t, err)
+	assert.Equal(t, "v1.2.0", string(data))
+	data, err = os.ReadFile(exe + ".old")
+	require.NoError(t, err)
+	assert.Equal(t, "v1.1.0", string(data), "the previous executable is moved aside")
+
+	// The executable is put back when the staged binary is missing
+	assert.Error(t, renameAside(staged, exe))
+	data, err = os.ReadFile(exe)
+	require.NoError(t, err)
+	assert.Equal(t, "v1.2.0", string(data))
+
+	require.NoError(t, RemoveReplaced(exe))
+	assert.NoFileExists(t, exe+".old")
+	assert.NoError(t, RemoveReplaced(exe), "nothing to remove")
+}
+
+func TestUpdater_Verification(t *testing.T) {
+	updater, _, _ := release(t, "v1.2.0 binary")
+	ctx := context.Background()
+	manifest, binary, err := updater.Check(ctx)
+	require.NoError(t, err)
+
+	tampered := *binary
+	tampered.SHA256 = strings.Repeat("0", 64)
+	_, err = updater.Download(ctx, manifest, &tampered)
+	assert.EqualError(t, err, "binary for "+runtime.GOOS+"/"+runtime.GOARCH+" doesn't match its SHA-256")
+
+	// A binary signed for another version can't be passed off as this one
+	relabeled := *manifest
+	relabeled.Version = "v9.0.0"
+	_, err = updater.Download(ctx, &relabeled, binary)
+	assert.EqualError(t, err, "binary for "+runtime.GOOS+"/"+runtime.GOARCH+" of v9.0.0 isn't signed by the release key")
+
+	other, _, err := GenerateKey()
+	require.NoError(t, err)
+	updater.PublicKey, err = ParsePublicKey(other)
+	require.NoError(t, err)
+	_, err = updater.Download(ctx, manifest, binary)
+	assert.ErrorContains(t, err, "isn't signed by the release key")
+	updater.PublicKey = nil
+	_, err = updater.Download(ctx, manifest, binary)
+	assert.EqualError(t, err, "a public release key is required to verify releases")
+
+	entries, err := os.ReadDir(filepath.Dir(updater.Executable))
+	require.NoError(t, err)
+	assert.Len(t, entries, 1, "nothing unverified is staged")
+}
+
+func TestUpdater_Check(t *testing.T) {
+	updater, server, headers := release(t, "v1.2.0 binary")
+	ctx := context.Background()
+
+	updater.Current = "v1.3.0"
+	_, binary, err := updater.Check(ctx)
+	require.NoError(t, err)
+	assert.Nil(t, binary, "releases older than the agent aren't installed")
+	assert.Equal(t, "Bearer secret", headers["/releases/stable.json"])
+
+	updater.Current = "v1.1.0"
+	updater.ManifestURL = server.URL + "/releases/beta.json"
+	_, _, err = updater.Check(ctx)
+	assert.EqualError(t, err, "failed to fetch "+updater.ManifestURL+": status 404")
+}
+
+func TestUpdater_SizeLimit(t *testing.T) {
+	updater, server, _ := release(t, "v1.2.0 binary")
+	ctx := context.Background()
+
+	data, err := updater.get(ctx, server.URL+"/releases/panoptic-v1.2.0", int64(len("v1.2.0 binary")))
+	require.NoError(t, err)
+	assert.Equal(t, "v1.2.0 binary", string(data))
+
+	_, err = updater.get(ctx, server.URL+"/releases/panoptic-v1.2.0", 4)
+	assert.ErrorContains(t, err, "larger than 4 bytes")
+}
+
+func TestParseKey(t *testing.T) {
+	public, private, err := GenerateKey()
+	require.NoError(t, err)
+	_, err = ParsePublicKey(public)
+	assert.NoError(t, err)
+	_, err = ParsePrivateKey(private)
+	assert.NoError(t, err)
+	_, err = ParsePublicKey("c2hvcnQ=")
+	assert.EqualError(t, err, "public release key must be 32 bytes, base64 or hex encoded")
+}
+
+func TestNewer(t *testing.T) {
+	for _, c := range []struct {
+		candidate, current string
+		newer              bool
+	}{
+		{"v1.2.0", "v1.1.9", true},
+		{"v1.10.0", "v1.9.0", true},
+		{"v1.2.0", "v1.2.0", false},
+		{"v1.1.0", "v1.2.0", false},
+		{"v1.2.0", "v1.2.0-rc.1", true},
+		{"v1.2.0-rc.2", "v1.2.0-rc.1", true},
+		{"v1.2.0-rc.1", "v1.2.0", false},
+		{"2.0", "v1.9.9", true},
+		{"v1.0.0", Devel, true},
+		{"latest", "v1.0.0", false},
+	} {
+		assert.Equal(t, c.newer, Newer(c.candidate, c.current), "%s over %s", c.candidate, c.current)
+	}
+}
+
+func TestWindow(t *testing.T) {
+	at := func(clock string) time.Time {
+		parsed, err := time.Parse("15:04", clock)
+		require.NoError(t, err)
+		return parsed
+	}
+	night, err := ParseWindow("22:00-04:30")
+	require.NoError(t, err)
+	assert.True(t, night.Contains(at("23:15")))
+	assert.True(t, night.Contains(at("03:00")))
+	assert.False(t, night.Contains(at("04:30")))
+	assert.False(t, night.Contains(at("12:00")))
+	assert.Equal(t, "22:00-04:30", night.String())
+
+	always, err := ParseWindow("")
+	require.NoError(t, err)
+	assert.True(t, always.Contains(at("12:00")))
+	assert.Equal(t, "any time", always.String())
+
+	_, err = ParseWindow("2am-4am")
+	assert.EqualError(t, err, `update window "2am-4am" must be HH:MM-HH:MM`)
+}
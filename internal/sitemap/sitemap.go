@@ -0,0 +1,86 @@
+// Package sitemap reads sitemap.xml files: the page URLs of a urlset and the
+// nested sitemaps of a sitemap index.
+package sitemap
+
+import (
+	"context"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+)
+
+// maxSize bounds the sitemaps read; the protocol allows 50 MB uncompressed
+const maxSize = 50 << 20
+
+// Sitemap is what a sitemap.xml lists
+type Sitemap struct {
+	URLs     []string // pages of a urlset
+	Sitemaps []string // sitemaps of a sitemap index
+}
+
+// document matches both a urlset and a sitemapindex; only one of the lists
+// is filled
+type document struct {
+	XMLName  xml.Name
+	URLs     []location `xml:"url"`
+	Sitemaps []location `xml:"sitemap"`
+}
+
+type location struct {
+	Loc string `xml:"loc"`
+}
+
+// Parse reads a sitemap or sitemap index
+func Parse(r io.Reader) (*Sitemap, error) {
+	var doc document
+	if err := xml.NewDecoder(r).Decode(&doc); err != nil {
+		return nil, fmt.Errorf("invalid sitemap: %w", err)
+	}
+	switch doc.XMLName.Local {
+	case "urlset", "sitemapindex":
+	default:
+		return nil, fmt.Errorf("invalid sitemap: unexpected <%s> element", doc.XMLName.Local)
+	}
+	s := &Sitemap{}
+	for _, u := range doc.URLs {
+		if loc := strings.TrimSpace(u.Loc); loc != "" {
+			s.URLs = append(s.URLs, loc)
+		}
+	}
+	for _, m := range doc.Sitemaps {
+		if loc := strings.TrimSpace(m.Loc); loc != "" {
+			s.Sitemaps = append(s.Sitemaps, loc)
+		}
+	}
+	return s, nil
+}
+
+// ParseFile reads a sitemap file
+func ParseFile(path string) (*Sitemap, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open sitemap: %w", err)
+	}
+	defer f.Close()
+	return Parse(f)
+}
+
+// Fetch downloads and reads the sitemap at url
+func Fetch(ctx context.Context, client *http.Client, url string) (*Sitemap, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("invalid sitemap URL: %w", err)
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch sitemap: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to fetch sitemap %s: %s", url, resp.Status)
+	}
+	return Parse(io.LimitReader(resp.Body, maxSize))
+}
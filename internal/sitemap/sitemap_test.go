@@ -0,0 +1,74 @@
+package sitemap
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParse_URLSet(t *testing.T) {
+	s, err := Parse(strings.NewReader(`<?xml version="1.0" encoding="UTF-8"?>
+<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">
+  <url><loc>https://shop.example.com/</loc><lastmod>2026-01-01</lastmod></url>
+  <url><loc>
+    https://shop.example.com/cart
+  </loc></url>
+  <url><loc></loc></url>
+</urlset>`))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"https://shop.example.com/", "https://shop.example.com/cart"}, s.URLs)
+	assert.Empty(t, s.Sitemaps)
+}
+
+func TestParse_Index(t *testing.T) {
+	s, err := Parse(strings.NewReader(`<sitemapindex xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">
+  <sitemap><loc>https://shop.example.com/sitemap-products.xml</loc></sitemap>
+</sitemapindex>`))
+	require.NoError(t, err)
+	assert.Empty(t, s.URLs)
+	assert.Equal(t, []string{"https://shop.example.com/sitemap-products.xml"}, s.Sitemaps)
+}
+
+func TestParse_Invalid(t *testing.T) {
+	_, err := Parse(strings.NewReader(`<html><body>Not found</body></html>`))
+	assert.ErrorContains(t, err, "unexpected <html> element")
+
+	_, err = Parse(strings.NewReader(`not xml`))
+	assert.ErrorContains(t, err, "invalid sitemap")
+}
+
+func TestParseFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "sitemap.xml")
+	require.NoError(t, os.WriteFile(path, []byte(`<urlset><url><loc>https://shop.example.com/</loc></url></urlset>`), 0644))
+	s, err := ParseFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"https://shop.example.com/"}, s.URLs)
+
+	_, err = ParseFile(filepath.Join(t.TempDir(), "missing.xml"))
+	assert.ErrorContains(t, err, "failed to open sitemap")
+}
+
+func TestFetch(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/sitemap.xml" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte(`<urlset><url><loc>https://shop.example.com/</loc></url></urlset>`))
+	}))
+	defer server.Close()
+
+	s, err := Fetch(context.Background(), server.Client(), server.URL+"/sitemap.xml")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"https://shop.example.com/"}, s.URLs)
+
+	_, err = Fetch(context.Background(), server.Client(), server.URL+"/missing.xml")
+	assert.ErrorContains(t, err, "404 Not Found")
+}
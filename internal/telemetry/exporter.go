@@ -0,0 +1,338 @@
+package telemetry
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Defaults for span export
+const (
+	DefaultBatchSize     = 256
+	DefaultBufferSize    = 2048
+	DefaultFlushInterval = 5 * time.Second
+	DefaultTimeout       = 10 * time.Second
+	DefaultServiceName   = "panoptic"
+)
+
+// ExportStats counts exported spans
+type ExportStats struct {
+	Exported int64
+	Dropped  int64 // buffer full
+	Failed   int64 // collector rejected or unreachable
+}
+
+// exporter batches ended spans and posts them to <endpoint>/v1/traces
+type exporter struct {
+	cfg      Config
+	endpoint string
+	resource []otlpKeyValue
+	client   *http.Client
+
+	spans chan *Span
+	done  chan struct{}
+
+	mu     sync.RWMutex
+	closed bool
+
+	exported atomic.Int64
+	dropped  atomic.Int64
+	failed   atomic.Int64
+	warned   atomic.Bool
+}
+
+func newExporter(cfg Config) (*exporter, error) {
+	if cfg.Endpoint == "" {
+		return nil, fmt.Errorf("telemetry requires an OTLP endpoint (settings.telemetry.endpoint or OTEL_EXPORTER_OTLP_ENDPOINT)")
+	}
+	base, err := url.Parse(cfg.Endpoint)
+	if err != nil || base.Scheme == "" || base.Host == "" {
+		return nil, fmt.Errorf("invalid OTLP endpoint %q", cfg.Endpoint)
+	}
+	if cfg.ServiceName == "" {
+		cfg.ServiceName = DefaultServiceName
+	}
+	if cfg.BatchSize <= 0 {
+		cfg.BatchSize = DefaultBatchSize
+	}
+	if cfg.BufferSize <= 0 {
+		cfg.BufferSize = DefaultBufferSize
+	}
+	if cfg.FlushInterval <= 0 {
+		cfg.FlushInterval = DefaultFlushInterval
+	}
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = DefaultTimeout
+	}
+
+	resource := map[string]string{"service.name": cfg.ServiceName}
+	if hostname, err := os.Hostname(); err == nil {
+		resource["host.name"] = hostname
+	}
+	for k, v := range cfg.Attributes {
+		resource[k] = v
+	}
+	keys := make([]string, 0, len(resource))
+	for k := range resource {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	resourceAttrs := make([]otlpKeyValue, 0, len(keys))
+	for _, k := range keys {
+		resourceAttrs = append(resourceAttrs, otlpAttribute(String(k, resource[k])))
+	}
+
+	e := &exporter{
+		cfg:      cfg,
+		endpoint: strings.TrimRight(cfg.Endpoint, "/") + "/v1/traces",
+		resource: resourceAttrs,
+		client:   &http.Client{Timeout: cfg.Timeout},
+		spans:    make(chan *Span, cfg.BufferSize),
+		done:     make(chan struct{}),
+	}
+	go e.run()
+	return e, nil
+}
+
+// enqueue hands an ended span to the background exporter without blocking
+func (e *exporter) enqueue(span *Span) {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	if e.closed {
+		e.dropped.Add(1)
+		return
+	}
+	select {
+	case e.spans <- span:
+	default:
+		e.dropped.Add(1)
+	}
+}
+
+func (e *exporter) stats() ExportStats {
+	return ExportStats{Exported: e.exported.Load(), Dropped: e.dropped.Load(), Failed: e.failed.Load()}
+}
+
+func (e *exporter) shutdown(ctx context.Context) error {
+	e.mu.Lock()
+	if !e.closed {
+		e.closed = true
+		close(e.spans)
+	}
+	e.mu.Unlock()
+
+	select {
+	case <-e.done:
+		e.client.CloseIdleConnections()
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("telemetry shutdown: %w", ctx.Err())
+	}
+}
+
+func (e *exporter) run() {
+	defer close(e.done)
+
+	ticker := time.NewTicker(e.cfg.FlushInterval)
+	defer ticker.Stop()
+
+	batch := make([]*Span, 0, e.cfg.BatchSize)
+	flush := func() {
+		if len(batch) == 0 {
+			return
+		}
+		if err := e.export(batch); err != nil {
+			e.failed.Add(int64(len(batch)))
+			if !e.warned.Swap(true) {
+				fmt.Fprintf(os.Stderr, "panoptic: exporting spans to %s failed: %v\n", e.endpoint, err)
+			}
+		} else {
+			e.exported.Add(int64(len(batch)))
+		}
+		batch = batch[:0]
+	}
+
+	for {
+		select {
+		case span, ok := <-e.spans:
+			if !ok {
+				flush()
+				return
+			}
+			batch = append(batch, span)
+			if len(batch) >= e.cfg.BatchSize {
+				flush()
+			}
+		case <-ticker.C:
+			flush()
+		}
+	}
+}
+
+func (e *exporter) export(spans []*Span) error {
+	body, err := json.Marshal(e.request(spans))
+	if err != nil {
+		return fmt.Errorf("failed to encode spans: %w", err)
+	}
+
+	req, err := http.NewRequest(http.MethodPost, e.endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range e.cfg.Headers {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := e.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		return fmt.Errorf("collector returned %d: %s", resp.StatusCode, strings.TrimSpace(string(msg)))
+	}
+	io.Copy(io.Discard, resp.Body)
+	return nil
+}
+
+// OTLP/JSON wire types (opentelemetry-proto, JSON mapping)
+
+type otlpRequest struct {
+	ResourceSpans []otlpResourceSpans `json:"resourceSpans"`
+}
+
+type otlpResourceSpans struct {
+	Resource   otlpResource     `json:"resource"`
+	ScopeSpans []otlpScopeSpans `json:"scopeSpans"`
+}
+
+type otlpResource struct {
+	Attributes []otlpKeyValue `json:"attributes"`
+}
+
+type otlpScopeSpans struct {
+	Scope otlpScope  `json:"scope"`
+	Spans []otlpSpan `json:"spans"`
+}
+
+type otlpScope struct {
+	Name string `json:"name"`
+}
+
+type otlpSpan struct {
+	TraceID           string         `json:"traceId"`
+	SpanID            string         `json:"spanId"`
+	ParentSpanID      string         `json:"parentSpanId,omitempty"`
+	Name              string         `json:"name"`
+	Kind              int            `json:"kind"`
+	StartTimeUnixNano string         `json:"startTimeUnixNano"`
+	EndTimeUnixNano   string         `json:"endTimeUnixNano"`
+	Attributes        []otlpKeyValue `json:"attributes,omitempty"`
+	Events            []otlpEvent    `json:"events,omitempty"`
+	Status            otlpStatus     `json:"status"`
+}
+
+type otlpEvent struct {
+	TimeUnixNano string         `json:"timeUnixNano"`
+	Name         string         `json:"name"`
+	Attributes   []otlpKeyValue `json:"attributes,omitempty"`
+}
+
+type otlpStatus struct {
+	Code    int    `json:"code,omitempty"`
+	Message string `json:"message,omitempty"`
+}
+
+type otlpKeyValue struct {
+	Key   string    `json:"key"`
+	Value otlpValue `json:"value"`
+}
+
+type otlpValue struct {
+	StringValue *string  `json:"stringValue,omitempty"`
+	IntValue    *string  `json:"intValue,omitempty"` // int64 is a string in OTLP/JSON
+	BoolValue   *bool    `json:"boolValue,omitempty"`
+	DoubleValue *float64 `json:"doubleValue,omitempty"`
+}
+
+func otlpAttribute(attr Attribute) otlpKeyValue {
+	kv := otlpKeyValue{Key: attr.Key}
+	switch v := attr.Value.(type) {
+	case string:
+		kv.Value.StringValue = &v
+	case int64:
+		s := strconv.FormatInt(v, 10)
+		kv.Value.IntValue = &s
+	case bool:
+		kv.Value.BoolValue = &v
+	case float64:
+		kv.Value.DoubleValue = &v
+	default:
+		s := fmt.Sprint(v)
+		kv.Value.StringValue = &s
+	}
+	return kv
+}
+
+func otlpAttributes(attrs []Attribute) []otlpKeyValue {
+	if len(attrs) == 0 {
+		return nil
+	}
+	out := make([]otlpKeyValue, 0, len(attrs))
+	for _, attr := range attrs {
+		out = append(out, otlpAttribute(attr))
+	}
+	return out
+}
+
+func unixNano(t time.Time) string {
+	return strconv.FormatInt(t.UnixNano(), 10)
+}
+
+func (e *exporter) request(spans []*Span) otlpRequest {
+	out := make([]otlpSpan, 0, len(spans))
+	for _, span := range spans {
+		span.mu.Lock()
+		s := otlpSpan{
+			TraceID:           hex.EncodeToString(span.context.TraceID[:]),
+			SpanID:            hex.EncodeToString(span.context.SpanID[:]),
+			Name:              span.name,
+			Kind:              span.kind,
+			StartTimeUnixNano: unixNano(span.start),
+			EndTimeUnixNano:   unixNano(span.end),
+			Attributes:        otlpAttributes(span.attributes),
+			Status:            otlpStatus{Code: span.status, Message: span.statusMsg},
+		}
+		if span.parentID != [8]byte{} {
+			s.ParentSpanID = hex.EncodeToString(span.parentID[:])
+		}
+		for _, event := range span.events {
+			s.Events = append(s.Events, otlpEvent{
+				TimeUnixNano: unixNano(event.time),
+				Name:         event.name,
+				Attributes:   otlpAttributes(event.attributes),
+			})
+		}
+		span.mu.Unlock()
+		out = append(out, s)
+	}
+
+	return otlpRequest{ResourceSpans: []otlpResourceSpans{{
+		Resource:   otlpResource{Attributes: e.resource},
+		ScopeSpans: []otlpScopeSpans{{Scope: otlpScope{Name: ScopeName}, Spans: out}},
+	}}}
+}
@@ -0,0 +1,326 @@
+// Package telemetry records OpenTelemetry-compatible trace spans for runs, apps,
+// actions and platform calls and exports them over OTLP/HTTP (JSON encoding),
+// so runs show up in Jaeger, Tempo or any OTLP collector. Trace context crosses
+// process boundaries as a W3C traceparent.
+package telemetry
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ScopeName is the instrumentation scope reported with every span
+const ScopeName = "panoptic"
+
+// TraceParentEnv is the environment variable used to hand trace context to
+// agents started in containers or Kubernetes jobs
+const TraceParentEnv = "TRACEPARENT"
+
+// SpanKind values from the OTLP specification
+const (
+	SpanKindInternal = 1
+	SpanKindClient   = 3
+)
+
+// Status codes from the OTLP specification
+const (
+	StatusUnset = 0
+	StatusOK    = 1
+	StatusError = 2
+)
+
+// Attribute is a span attribute; build with String, Int, Bool or Float
+type Attribute struct {
+	Key   string
+	Value interface{}
+}
+
+// String returns a string attribute
+func String(key, value string) Attribute { return Attribute{Key: key, Value: value} }
+
+// Int returns an integer attribute
+func Int(key string, value int) Attribute { return Attribute{Key: key, Value: int64(value)} }
+
+// Bool returns a boolean attribute
+func Bool(key string, value bool) Attribute { return Attribute{Key: key, Value: value} }
+
+// Float returns a floating point attribute
+func Float(key string, value float64) Attribute { return Attribute{Key: key, Value: value} }
+
+// SpanContext identifies a span within a trace
+type SpanContext struct {
+	TraceID [16]byte
+	SpanID  [8]byte
+	Sampled bool
+}
+
+// IsValid reports whether both IDs are non-zero
+func (sc SpanContext) IsValid() bool {
+	return sc.TraceID != [16]byte{} && sc.SpanID != [8]byte{}
+}
+
+// TraceIDString returns the trace ID as 32 hex characters, as shown by trace backends
+func (sc SpanContext) TraceIDString() string {
+	return hex.EncodeToString(sc.TraceID[:])
+}
+
+// TraceParent formats the span context as a W3C traceparent header value
+func (sc SpanContext) TraceParent() string {
+	flags := "00"
+	if sc.Sampled {
+		flags = "01"
+	}
+	return fmt.Sprintf("00-%s-%s-%s", hex.EncodeToString(sc.TraceID[:]), hex.EncodeToString(sc.SpanID[:]), flags)
+}
+
+// ParseTraceParent parses a W3C traceparent header value
+func ParseTraceParent(value string) (SpanContext, error) {
+	parts := strings.Split(strings.TrimSpace(value), "-")
+	if len(parts) != 4 || parts[0] != "00" || len(parts[1]) != 32 || len(parts[2]) != 16 || len(parts[3]) != 2 {
+		return SpanContext{}, fmt.Errorf("invalid traceparent %q", value)
+	}
+	var sc SpanContext
+	if _, err := hex.Decode(sc.TraceID[:], []byte(parts[1])); err != nil {
+		return SpanContext{}, fmt.Errorf("invalid traceparent trace id: %w", err)
+	}
+	if _, err := hex.Decode(sc.SpanID[:], []byte(parts[2])); err != nil {
+		return SpanContext{}, fmt.Errorf("invalid traceparent span id: %w", err)
+	}
+	flags, err := hex.DecodeString(parts[3])
+	if err != nil {
+		return SpanContext{}, fmt.Errorf("invalid traceparent flags: %w", err)
+	}
+	sc.Sampled = flags[0]&1 == 1
+	if !sc.IsValid() {
+		return SpanContext{}, fmt.Errorf("invalid traceparent %q: zero id", value)
+	}
+	return sc, nil
+}
+
+type spanContextKey struct{}
+
+// ContextWithRemoteParent makes spans started from ctx children of a span in
+// another process, identified by a W3C traceparent
+func ContextWithRemoteParent(ctx context.Context, traceParent string) (context.Context, error) {
+	sc, err := ParseTraceParent(traceParent)
+	if err != nil {
+		return ctx, err
+	}
+	return context.WithValue(ctx, spanContextKey{}, sc), nil
+}
+
+// SpanContextFromContext returns the span context of the active span, if any
+func SpanContextFromContext(ctx context.Context) (SpanContext, bool) {
+	if ctx == nil {
+		return SpanContext{}, false
+	}
+	sc, ok := ctx.Value(spanContextKey{}).(SpanContext)
+	return sc, ok
+}
+
+// Span is one timed operation. A nil *Span is a valid no-op, so call sites
+// don't need to check whether telemetry is enabled.
+type Span struct {
+	tracer *Tracer
+
+	mu         sync.Mutex
+	name       string
+	kind       int
+	context    SpanContext
+	parentID   [8]byte
+	start      time.Time
+	end        time.Time
+	attributes []Attribute
+	events     []spanEvent
+	status     int
+	statusMsg  string
+	ended      bool
+}
+
+type spanEvent struct {
+	name       string
+	time       time.Time
+	attributes []Attribute
+}
+
+// SpanContext returns the IDs of the span
+func (s *Span) SpanContext() SpanContext {
+	if s == nil {
+		return SpanContext{}
+	}
+	return s.context
+}
+
+// SetAttributes adds or replaces attributes
+func (s *Span) SetAttributes(attrs ...Attribute) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, attr := range attrs {
+		replaced := false
+		for i := range s.attributes {
+			if s.attributes[i].Key == attr.Key {
+				s.attributes[i] = attr
+				replaced = true
+				break
+			}
+		}
+		if !replaced {
+			s.attributes = append(s.attributes, attr)
+		}
+	}
+}
+
+// End finishes the span; a non-nil err marks it failed and records an
+// exception event. Only the first call has an effect.
+func (s *Span) End(err error) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	if s.ended {
+		s.mu.Unlock()
+		return
+	}
+	s.ended = true
+	s.end = time.Now()
+	if err != nil {
+		s.status = StatusError
+		s.statusMsg = err.Error()
+		s.events = append(s.events, spanEvent{
+			name:       "exception",
+			time:       s.end,
+			attributes: []Attribute{String("exception.message", err.Error())},
+		})
+	}
+	s.mu.Unlock()
+
+	s.tracer.exporter.enqueue(s)
+}
+
+// Config configures span export
+type Config struct {
+	Endpoint      string            // OTLP/HTTP base URL, e.g. http://localhost:4318
+	ServiceName   string            // service.name resource attribute (default panoptic)
+	Headers       map[string]string // extra request headers, e.g. authentication
+	Attributes    map[string]string // additional resource attributes
+	BatchSize     int
+	BufferSize    int
+	FlushInterval time.Duration
+	Timeout       time.Duration
+}
+
+// ConfigFromEnv fills unset fields from the standard OTEL_* environment variables
+func ConfigFromEnv(cfg Config) Config {
+	if cfg.Endpoint == "" {
+		if endpoint := os.Getenv("OTEL_EXPORTER_OTLP_TRACES_ENDPOINT"); endpoint != "" {
+			cfg.Endpoint = strings.TrimSuffix(strings.TrimRight(endpoint, "/"), "/v1/traces")
+		} else {
+			cfg.Endpoint = os.Getenv("OTEL_EXPORTER_OTLP_ENDPOINT")
+		}
+	}
+	if cfg.ServiceName == "" {
+		cfg.ServiceName = os.Getenv("OTEL_SERVICE_NAME")
+	}
+	if headers := os.Getenv("OTEL_EXPORTER_OTLP_HEADERS"); headers != "" {
+		if cfg.Headers == nil {
+			cfg.Headers = make(map[string]string)
+		}
+		for _, pair := range strings.Split(headers, ",") {
+			key, value, ok := strings.Cut(pair, "=")
+			key = strings.TrimSpace(key)
+			if _, set := cfg.Headers[key]; ok && key != "" && !set {
+				cfg.Headers[key] = strings.TrimSpace(value)
+			}
+		}
+	}
+	return cfg
+}
+
+// Tracer creates spans and exports them in the background. A nil *Tracer is a
+// valid no-op tracer.
+type Tracer struct {
+	exporter *exporter
+}
+
+// NewTracer starts a tracer exporting to cfg.Endpoint
+func NewTracer(cfg Config) (*Tracer, error) {
+	exp, err := newExporter(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return &Tracer{exporter: exp}, nil
+}
+
+// Start begins a span as a child of the span in ctx (or a new trace) and
+// returns a context carrying it
+func (t *Tracer) Start(ctx context.Context, name string, attrs ...Attribute) (context.Context, *Span) {
+	return t.start(ctx, name, SpanKindInternal, attrs)
+}
+
+// StartClient begins a span for an outgoing call to another service
+func (t *Tracer) StartClient(ctx context.Context, name string, attrs ...Attribute) (context.Context, *Span) {
+	return t.start(ctx, name, SpanKindClient, attrs)
+}
+
+func (t *Tracer) start(ctx context.Context, name string, kind int, attrs []Attribute) (context.Context, *Span) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	if t == nil {
+		return ctx, nil
+	}
+
+	span := &Span{tracer: t, name: name, kind: kind, start: time.Now()}
+	span.SetAttributes(attrs...)
+	if parent, ok := SpanContextFromContext(ctx); ok && parent.IsValid() {
+		span.context.TraceID = parent.TraceID
+		span.parentID = parent.SpanID
+	} else {
+		span.context.TraceID = randomTraceID()
+	}
+	span.context.SpanID = randomSpanID()
+	span.context.Sampled = true
+
+	return context.WithValue(ctx, spanContextKey{}, span.context), span
+}
+
+// Stats returns export counters
+func (t *Tracer) Stats() ExportStats {
+	if t == nil {
+		return ExportStats{}
+	}
+	return t.exporter.stats()
+}
+
+// Shutdown exports pending spans and stops the exporter
+func (t *Tracer) Shutdown(ctx context.Context) error {
+	if t == nil {
+		return nil
+	}
+	return t.exporter.shutdown(ctx)
+}
+
+func randomTraceID() [16]byte {
+	var id [16]byte
+	for id == [16]byte{} {
+		rand.Read(id[:])
+	}
+	return id
+}
+
+func randomSpanID() [8]byte {
+	var id [8]byte
+	for id == [8]byte{} {
+		rand.Read(id[:])
+	}
+	return id
+}
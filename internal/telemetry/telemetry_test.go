@@ -0,0 +1,214 @@
+package telemetry
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeCollector decodes OTLP/JSON trace exports
+type fakeCollector struct {
+	mu      sync.Mutex
+	spans   []otlpSpan
+	headers []http.Header
+	status  int
+}
+
+func newFakeCollector(t *testing.T) (*fakeCollector, *httptest.Server) {
+	c := &fakeCollector{status: http.StatusOK}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/traces" {
+			http.NotFound(w, r)
+			return
+		}
+		body, _ := io.ReadAll(r.Body)
+		var req otlpRequest
+		if err := json.Unmarshal(body, &req); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		c.headers = append(c.headers, r.Header.Clone())
+		for _, rs := range req.ResourceSpans {
+			for _, ss := range rs.ScopeSpans {
+				c.spans = append(c.spans, ss.Spans...)
+			}
+		}
+		w.WriteHeader(c.status)
+	}))
+	t.Cleanup(server.Close)
+	return c, server
+}
+
+func (c *fakeCollector) byName(name string) *otlpSpan {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for i := range c.spans {
+		if c.spans[i].Name == name {
+			return &c.spans[i]
+		}
+	}
+	return nil
+}
+
+func attr(span *otlpSpan, key string) *otlpValue {
+	for _, kv := range span.Attributes {
+		if kv.Key == key {
+			return &kv.Value
+		}
+	}
+	return nil
+}
+
+func shutdown(t *testing.T, tracer *Tracer) {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	require.NoError(t, tracer.Shutdown(ctx))
+}
+
+func TestTracer_ExportsSpanTree(t *testing.T) {
+	collector, server := newFakeCollector(t)
+	tracer, err := NewTracer(Config{Endpoint: server.URL, ServiceName: "qa", Headers: map[string]string{"X-Api-Key": "k"}})
+	require.NoError(t, err)
+
+	ctx, run := tracer.Start(context.Background(), "run", String("run_id", "r-1"))
+	actionCtx, action := tracer.Start(ctx, "action", Int("index", 2), Bool("retry", false), Float("score", 0.5))
+	_, upload := tracer.StartClient(actionCtx, "upload")
+	upload.End(nil)
+	action.SetAttributes(String("result", "failed"), Int("index", 3))
+	action.End(errors.New("element not found"))
+	action.End(nil)
+	run.End(nil)
+	shutdown(t, tracer)
+
+	assert.Equal(t, ExportStats{Exported: 3}, tracer.Stats())
+	require.Len(t, collector.spans, 3, "repeated End calls export once")
+	assert.Equal(t, "k", collector.headers[0].Get("X-Api-Key"))
+	assert.Equal(t, "application/json", collector.headers[0].Get("Content-Type"))
+
+	runSpan, actionSpan, uploadSpan := collector.byName("run"), collector.byName("action"), collector.byName("upload")
+	require.NotNil(t, runSpan)
+	require.NotNil(t, actionSpan)
+	require.NotNil(t, uploadSpan)
+
+	assert.Empty(t, runSpan.ParentSpanID)
+	assert.Equal(t, runSpan.TraceID, actionSpan.TraceID)
+	assert.Equal(t, runSpan.SpanID, actionSpan.ParentSpanID)
+	assert.Equal(t, actionSpan.SpanID, uploadSpan.ParentSpanID)
+	assert.Equal(t, SpanKindClient, uploadSpan.Kind)
+	assert.Equal(t, SpanKindInternal, runSpan.Kind)
+
+	assert.Equal(t, StatusError, actionSpan.Status.Code)
+	assert.Equal(t, "element not found", actionSpan.Status.Message)
+	require.Len(t, actionSpan.Events, 1)
+	assert.Equal(t, "exception", actionSpan.Events[0].Name)
+	assert.Equal(t, "3", *attr(actionSpan, "index").IntValue, "SetAttributes replaces existing keys")
+	assert.False(t, *attr(actionSpan, "retry").BoolValue)
+	assert.Equal(t, 0.5, *attr(actionSpan, "score").DoubleValue)
+	assert.Equal(t, "r-1", *attr(runSpan, "run_id").StringValue)
+	assert.Equal(t, StatusUnset, runSpan.Status.Code)
+}
+
+func TestTracer_RemoteParent(t *testing.T) {
+	collector, server := newFakeCollector(t)
+	tracer, err := NewTracer(Config{Endpoint: server.URL})
+	require.NoError(t, err)
+
+	parent := "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+	ctx, err := ContextWithRemoteParent(context.Background(), parent)
+	require.NoError(t, err)
+
+	_, span := tracer.Start(ctx, "agent")
+	span.End(nil)
+	shutdown(t, tracer)
+
+	got := collector.byName("agent")
+	require.NotNil(t, got)
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", got.TraceID)
+	assert.Equal(t, "00f067aa0ba902b7", got.ParentSpanID)
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", span.SpanContext().TraceIDString())
+}
+
+func TestParseTraceParent(t *testing.T) {
+	sc, err := ParseTraceParent("00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	require.NoError(t, err)
+	assert.True(t, sc.Sampled)
+	assert.Equal(t, "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", sc.TraceParent())
+
+	for _, bad := range []string{
+		"",
+		"01-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7",
+		"00-zzf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+		"00-00000000000000000000000000000000-00f067aa0ba902b7-01",
+	} {
+		_, err := ParseTraceParent(bad)
+		assert.Error(t, err, bad)
+	}
+}
+
+func TestTracer_NilIsNoop(t *testing.T) {
+	var tracer *Tracer
+	ctx, span := tracer.Start(context.Background(), "noop")
+	assert.Nil(t, span)
+	_, ok := SpanContextFromContext(ctx)
+	assert.False(t, ok)
+
+	span.SetAttributes(String("k", "v"))
+	span.End(errors.New("ignored"))
+	assert.False(t, span.SpanContext().IsValid())
+	assert.NoError(t, tracer.Shutdown(context.Background()))
+	assert.Equal(t, ExportStats{}, tracer.Stats())
+}
+
+func TestTracer_CollectorFailure(t *testing.T) {
+	collector, server := newFakeCollector(t)
+	collector.status = http.StatusServiceUnavailable
+	tracer, err := NewTracer(Config{Endpoint: server.URL})
+	require.NoError(t, err)
+
+	_, span := tracer.Start(context.Background(), "lost")
+	span.End(nil)
+	shutdown(t, tracer)
+
+	assert.Equal(t, ExportStats{Failed: 1}, tracer.Stats())
+
+	_, late := tracer.Start(context.Background(), "late")
+	late.End(nil)
+	assert.Equal(t, int64(1), tracer.Stats().Dropped, "spans ending after shutdown are dropped")
+}
+
+func TestNewTracer_Invalid(t *testing.T) {
+	_, err := NewTracer(Config{})
+	assert.ErrorContains(t, err, "OTLP endpoint")
+
+	_, err = NewTracer(Config{Endpoint: "localhost:4318"})
+	assert.Error(t, err)
+}
+
+func TestConfigFromEnv(t *testing.T) {
+	t.Setenv("OTEL_EXPORTER_OTLP_TRACES_ENDPOINT", "http://collector:4318/v1/traces")
+	t.Setenv("OTEL_EXPORTER_OTLP_ENDPOINT", "http://ignored:4318")
+	t.Setenv("OTEL_SERVICE_NAME", "panoptic-ci")
+	t.Setenv("OTEL_EXPORTER_OTLP_HEADERS", "authorization=Bearer abc, x-team=qa,broken")
+
+	cfg := ConfigFromEnv(Config{Headers: map[string]string{"x-team": "web"}})
+	assert.Equal(t, "http://collector:4318", cfg.Endpoint)
+	assert.Equal(t, "panoptic-ci", cfg.ServiceName)
+	assert.Equal(t, map[string]string{"authorization": "Bearer abc", "x-team": "web"}, cfg.Headers, "explicit headers win")
+
+	cfg = ConfigFromEnv(Config{Endpoint: "http://mine:4318", ServiceName: "svc"})
+	assert.Equal(t, "http://mine:4318", cfg.Endpoint)
+	assert.Equal(t, "svc", cfg.ServiceName)
+}
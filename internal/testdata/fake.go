@@ -0,0 +1,201 @@
+// Package testdata generates fake test data (names, emails, UUIDs, ...) for
+// action interpolation. A Faker is deterministic for a given seed, so a run can
+// be reproduced exactly, and unique across runs when seeded randomly.
+package testdata
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"fmt"
+	"hash/fnv"
+	mathrand "math/rand"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Faker produces fake values from a seeded random source. It is safe for
+// concurrent use.
+type Faker struct {
+	seed int64
+
+	mu    sync.Mutex
+	rng   *mathrand.Rand
+	named map[string]string
+}
+
+// New returns a Faker seeded with seed
+func New(seed int64) *Faker {
+	return &Faker{
+		seed:  seed,
+		rng:   mathrand.New(mathrand.NewSource(seed)),
+		named: make(map[string]string),
+	}
+}
+
+// Seed returns the seed the Faker was created with
+func (f *Faker) Seed() int64 {
+	return f.seed
+}
+
+// RandomSeed returns a seed from the system's secure random source, falling
+// back to the clock
+func RandomSeed() int64 {
+	var b [8]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return time.Now().UnixNano()
+	}
+	return int64(binary.LittleEndian.Uint64(b[:]) >> 1)
+}
+
+// DeriveSeed combines a run seed with a name (e.g. the app), so every app gets
+// its own reproducible sequence regardless of the order apps run in
+func DeriveSeed(seed int64, name string) int64 {
+	h := fnv.New64a()
+	h.Write([]byte(name))
+	return seed ^ int64(h.Sum64()>>1)
+}
+
+// generators maps each fake.<kind> to its value source
+var generators = map[string]func(f *Faker) string{
+	"first_name": func(f *Faker) string { return f.pick(firstNames) },
+	"last_name":  func(f *Faker) string { return f.pick(lastNames) },
+	"name":       func(f *Faker) string { return f.pick(firstNames) + " " + f.pick(lastNames) },
+	"username": func(f *Faker) string {
+		return strings.ToLower(f.pick(firstNames)) + "_" + f.digits(6)
+	},
+	"email": func(f *Faker) string {
+		return strings.ToLower(f.pick(firstNames)+"."+f.pick(lastNames)) + "." + f.digits(6) + "@" + f.pick(emailDomains)
+	},
+	"password": func(f *Faker) string { return f.password(16) },
+	"phone":    func(f *Faker) string { return "+1-555-" + f.digits(3) + "-" + f.digits(4) },
+	"uuid":     func(f *Faker) string { return f.uuid() },
+	"company":  func(f *Faker) string { return f.pick(lastNames) + " " + f.pick(companySuffixes) },
+	"street": func(f *Faker) string {
+		return fmt.Sprintf("%d %s %s", 1+f.rng.Intn(9999), f.pick(lastNames), f.pick(streetSuffixes))
+	},
+	"city":    func(f *Faker) string { return f.pick(cities) },
+	"zip":     func(f *Faker) string { return f.digits(5) },
+	"country": func(f *Faker) string { return f.pick(countries) },
+	"word":    func(f *Faker) string { return f.pick(words) },
+	"sentence": func(f *Faker) string {
+		n := 4 + f.rng.Intn(5)
+		parts := make([]string, n)
+		for i := range parts {
+			parts[i] = f.pick(words)
+		}
+		s := strings.Join(parts, " ")
+		return strings.ToUpper(s[:1]) + s[1:] + "."
+	},
+	"number": func(f *Faker) string { return fmt.Sprintf("%d", 1+f.rng.Intn(1000000)) },
+	"date": func(f *Faker) string {
+		start := time.Date(1970, 1, 1, 0, 0, 0, 0, time.UTC)
+		return start.AddDate(0, 0, f.rng.Intn(365*50)).Format("2006-01-02")
+	},
+	"order_id": func(f *Faker) string { return "ORD-" + strings.ToUpper(f.alnum(10)) },
+}
+
+// Kinds lists the supported value kinds
+func Kinds() []string {
+	kinds := make([]string, 0, len(generators))
+	for kind := range generators {
+		kinds = append(kinds, kind)
+	}
+	sort.Strings(kinds)
+	return kinds
+}
+
+// Generate returns a new value of the given kind
+func (f *Faker) Generate(kind string) (string, error) {
+	gen, ok := generators[kind]
+	if !ok {
+		return "", fmt.Errorf("unknown fake value %q (supported: %s)", kind, strings.Join(Kinds(), ", "))
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return gen(f), nil
+}
+
+// Named returns the value of kind remembered under label, generating it on
+// first use, so one value (e.g. a sign-up email) can be reused across actions
+func (f *Faker) Named(kind, label string) (string, error) {
+	key := kind + ":" + label
+	f.mu.Lock()
+	value, ok := f.named[key]
+	f.mu.Unlock()
+	if ok {
+		return value, nil
+	}
+	value, err := f.Generate(kind)
+	if err != nil {
+		return "", err
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if existing, ok := f.named[key]; ok {
+		return existing, nil
+	}
+	f.named[key] = value
+	return value, nil
+}
+
+func (f *Faker) pick(values []string) string {
+	return values[f.rng.Intn(len(values))]
+}
+
+func (f *Faker) digits(n int) string {
+	b := make([]byte, n)
+	for i := range b {
+		b[i] = byte('0' + f.rng.Intn(10))
+	}
+	return string(b)
+}
+
+func (f *Faker) alnum(n int) string {
+	const chars = "abcdefghijklmnopqrstuvwxyz0123456789"
+	b := make([]byte, n)
+	for i := range b {
+		b[i] = chars[f.rng.Intn(len(chars))]
+	}
+	return string(b)
+}
+
+// password always contains upper and lower case letters, a digit and a symbol,
+// so it satisfies common password policies
+func (f *Faker) password(n int) string {
+	classes := []string{"ABCDEFGHJKLMNPQRSTUVWXYZ", "abcdefghijkmnopqrstuvwxyz", "23456789", "!@#$%&*?"}
+	b := make([]byte, n)
+	for i := range b {
+		class := classes[f.rng.Intn(len(classes))]
+		if i < len(classes) {
+			class = classes[i]
+		}
+		b[i] = class[f.rng.Intn(len(class))]
+	}
+	f.rng.Shuffle(len(b), func(i, j int) { b[i], b[j] = b[j], b[i] })
+	return string(b)
+}
+
+// uuid returns an RFC 4122 version 4 UUID drawn from the seeded source
+func (f *Faker) uuid() string {
+	var b [16]byte
+	f.rng.Read(b[:])
+	b[6] = b[6]&0x0f | 0x40
+	b[8] = b[8]&0x3f | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16])
+}
+
+var (
+	firstNames = []string{"Alice", "Bob", "Carmen", "Dmitri", "Elena", "Farah", "George", "Hana", "Ivan", "Julia",
+		"Kenji", "Lucia", "Marko", "Nadia", "Omar", "Priya", "Quinn", "Rosa", "Sven", "Tara", "Uma", "Victor", "Wen", "Yusuf", "Zoe"}
+	lastNames = []string{"Anders", "Becker", "Costa", "Dubois", "Evans", "Fischer", "Garcia", "Horvat", "Ito", "Jensen",
+		"Kowalski", "Larsen", "Moreau", "Novak", "Okafor", "Petrov", "Quinto", "Rossi", "Silva", "Tanaka", "Ueda", "Vasic", "Weber", "Yilmaz", "Zhang"}
+	emailDomains    = []string{"example.com", "example.net", "example.org"} // reserved by RFC 2606, never deliverable
+	companySuffixes = []string{"Ltd", "Inc", "GmbH", "Group", "Labs", "Systems"}
+	streetSuffixes  = []string{"Street", "Avenue", "Road", "Lane", "Boulevard"}
+	cities          = []string{"Amsterdam", "Belgrade", "Chicago", "Dublin", "Edinburgh", "Florence", "Geneva", "Helsinki", "Istanbul", "Kyoto", "Lisbon", "Montreal"}
+	countries       = []string{"Canada", "Croatia", "Denmark", "France", "Germany", "Italy", "Japan", "Netherlands", "Portugal", "Serbia", "Spain", "United States"}
+	words           = []string{"alpha", "bright", "cloud", "delta", "ember", "forest", "glass", "harbor", "island", "jade", "kite", "lumen",
+		"meadow", "north", "orbit", "pixel", "quartz", "river", "signal", "timber", "umbra", "vector", "willow", "zenith"}
+)
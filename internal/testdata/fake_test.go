@@ -0,0 +1,126 @@
+package testdata
+
+import (
+	"regexp"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFaker_Deterministic(t *testing.T) {
+	a, b := New(42), New(42)
+	for _, kind := range Kinds() {
+		va, err := a.Generate(kind)
+		require.NoError(t, err)
+		vb, err := b.Generate(kind)
+		require.NoError(t, err)
+		assert.Equal(t, va, vb, kind)
+		assert.NotEmpty(t, va, kind)
+	}
+
+	other, _ := New(43).Generate("email")
+	first, _ := New(42).Generate("email")
+	assert.NotEqual(t, first, other, "different seeds give different data")
+	assert.Equal(t, int64(42), a.Seed())
+}
+
+func TestFaker_Formats(t *testing.T) {
+	f := New(7)
+	formats := map[string]*regexp.Regexp{
+		"email":    regexp.MustCompile(`^[a-z]+\.[a-z]+\.\d{6}@example\.(com|net|org)$`),
+		"uuid":     regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`),
+		"phone":    regexp.MustCompile(`^\+1-555-\d{3}-\d{4}$`),
+		"zip":      regexp.MustCompile(`^\d{5}$`),
+		"date":     regexp.MustCompile(`^\d{4}-\d{2}-\d{2}$`),
+		"name":     regexp.MustCompile(`^[A-Z][a-z]+ [A-Z][a-z]+$`),
+		"order_id": regexp.MustCompile(`^ORD-[A-Z0-9]{10}$`),
+	}
+	for kind, pattern := range formats {
+		for i := 0; i < 20; i++ {
+			value, err := f.Generate(kind)
+			require.NoError(t, err)
+			assert.Regexp(t, pattern, value, kind)
+		}
+	}
+
+	for i := 0; i < 20; i++ {
+		password, _ := f.Generate("password")
+		assert.Len(t, password, 16)
+		for _, class := range []string{"ABCDEFGHJKLMNPQRSTUVWXYZ", "abcdefghijkmnopqrstuvwxyz", "23456789", "!@#$%&*?"} {
+			assert.True(t, strings.ContainsAny(password, class), "%s lacks one of %s", password, class)
+		}
+	}
+
+	_, err := f.Generate("ssn")
+	assert.ErrorContains(t, err, `unknown fake value "ssn"`)
+}
+
+func TestFaker_Interpolate(t *testing.T) {
+	f := New(1)
+	out, err := f.Interpolate("{{fake.email:signup}} / {{ fake.email:signup }} / {{fake.email}}")
+	require.NoError(t, err)
+	parts := strings.Split(out, " / ")
+	require.Len(t, parts, 3)
+	assert.Equal(t, parts[0], parts[1], "labeled values are reused")
+	assert.NotEqual(t, parts[0], parts[2], "unlabeled values are fresh")
+
+	out, err = f.Interpolate("Hello {{user}} {{fake.first_name}}")
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(out, "Hello {{user}} "), "other expressions are untouched")
+
+	out, err = f.Interpolate("{{fake.nope}} and {{fake.uuid}}")
+	assert.ErrorContains(t, err, "nope")
+	assert.Contains(t, out, "{{fake.nope}}")
+
+	plain, err := f.Interpolate("no placeholders")
+	require.NoError(t, err)
+	assert.Equal(t, "no placeholders", plain)
+}
+
+func TestFaker_InterpolateValue(t *testing.T) {
+	f := New(3)
+	in := map[string]interface{}{
+		"user":  map[string]interface{}{"email": "{{fake.email:u}}", "age": 30},
+		"tags":  []interface{}{"{{fake.word}}", true},
+		"email": "{{fake.email:u}}",
+	}
+	out, err := f.InterpolateValue(in)
+	require.NoError(t, err)
+
+	m := out.(map[string]interface{})
+	user := m["user"].(map[string]interface{})
+	assert.Equal(t, m["email"], user["email"])
+	assert.Equal(t, 30, user["age"])
+	assert.NotContains(t, m["tags"].([]interface{})[0], "{{")
+	assert.Equal(t, "{{fake.email:u}}", in["email"], "input is not modified")
+
+	_, err = f.InterpolateValue([]interface{}{"{{fake.bogus}}"})
+	assert.Error(t, err)
+}
+
+func TestFaker_Concurrent(t *testing.T) {
+	f := New(9)
+	var wg sync.WaitGroup
+	values := make([]string, 50)
+	for i := range values {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			values[i], _ = f.Named("uuid", "shared")
+		}(i)
+	}
+	wg.Wait()
+	for _, v := range values {
+		assert.Equal(t, values[0], v)
+	}
+}
+
+func TestDeriveSeed(t *testing.T) {
+	assert.Equal(t, DeriveSeed(5, "shop"), DeriveSeed(5, "shop"))
+	assert.NotEqual(t, DeriveSeed(5, "shop"), DeriveSeed(5, "admin"))
+	assert.NotEqual(t, DeriveSeed(5, "shop"), DeriveSeed(6, "shop"))
+	assert.GreaterOrEqual(t, RandomSeed(), int64(0))
+}
@@ -0,0 +1,68 @@
+package testdata
+
+import (
+	"regexp"
+	"strings"
+)
+
+// placeholderPattern matches {{fake.<kind>}} and {{fake.<kind>:<label>}}
+var placeholderPattern = regexp.MustCompile(`\{\{\s*fake\.([a-z_]+)(?::([A-Za-z0-9_.-]+))?\s*\}\}`)
+
+// Interpolate replaces fake placeholders in s. {{fake.email}} yields a new
+// value at every occurrence; {{fake.email:signup}} yields the same value
+// wherever the label is reused. Other {{...}} expressions are left untouched.
+func (f *Faker) Interpolate(s string) (string, error) {
+	if !strings.Contains(s, "{{") {
+		return s, nil
+	}
+	var firstErr error
+	out := placeholderPattern.ReplaceAllStringFunc(s, func(match string) string {
+		groups := placeholderPattern.FindStringSubmatch(match)
+		var value string
+		var err error
+		if groups[2] != "" {
+			value, err = f.Named(groups[1], groups[2])
+		} else {
+			value, err = f.Generate(groups[1])
+		}
+		if err != nil {
+			if firstErr == nil {
+				firstErr = err
+			}
+			return match
+		}
+		return value
+	})
+	return out, firstErr
+}
+
+// InterpolateValue interpolates strings inside YAML-decoded values (strings,
+// maps and slices); other values are returned unchanged
+func (f *Faker) InterpolateValue(v interface{}) (interface{}, error) {
+	switch value := v.(type) {
+	case string:
+		return f.Interpolate(value)
+	case map[string]interface{}:
+		out := make(map[string]interface{}, len(value))
+		for k, item := range value {
+			interpolated, err := f.InterpolateValue(item)
+			if err != nil {
+				return nil, err
+			}
+			out[k] = interpolated
+		}
+		return out, nil
+	case []interface{}:
+		out := make([]interface{}, len(value))
+		for i, item := range value {
+			interpolated, err := f.InterpolateValue(item)
+			if err != nil {
+				return nil, err
+			}
+			out[i] = interpolated
+		}
+		return out, nil
+	default:
+		return v, nil
+	}
+}
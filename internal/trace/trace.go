@@ -0,0 +1,298 @@
+// Package trace records per-action execution traces (timing, network activity,
+// before/after screenshots) into a zip archive for post-mortem debugging, and
+// renders them as text or a local web page.
+package trace
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"panoptic/internal/redact"
+)
+
+// FormatVersion is the version of the trace.json layout inside trace archives
+const FormatVersion = 1
+
+// manifestName is the trace document stored at the root of every archive
+const manifestName = "trace.json"
+
+// NetworkEvent is a completed (or failed) network request observed during a run
+type NetworkEvent struct {
+	RequestID   string        `json:"request_id"`
+	Method      string        `json:"method"`
+	URL         string        `json:"url"`
+	Type        string        `json:"type,omitempty"` // Document, XHR, Fetch, Image, ...
+	Status      int           `json:"status,omitempty"`
+	MimeType    string        `json:"mime_type,omitempty"`
+	Failed      bool          `json:"failed,omitempty"`
+	ErrorText   string        `json:"error_text,omitempty"`
+	StartTime   time.Time     `json:"start_time"`
+	Duration    time.Duration `json:"duration"`
+	ActionIndex int           `json:"action_index"` // -1 when outside any action
+}
+
+// Action is the trace of a single executed action
+type Action struct {
+	Index       int                    `json:"index"`
+	Name        string                 `json:"name"`
+	Type        string                 `json:"type"`
+	Parameters  map[string]interface{} `json:"parameters,omitempty"`
+	StartTime   time.Time              `json:"start_time"`
+	EndTime     time.Time              `json:"end_time"`
+	Duration    time.Duration          `json:"duration"`
+	Error       string                 `json:"error,omitempty"`
+	Before      string                 `json:"before,omitempty"` // screenshot path inside the archive
+	After       string                 `json:"after,omitempty"`
+	NetworkRefs []int                  `json:"network_refs,omitempty"` // indexes into Trace.Network
+}
+
+// Trace is the document stored as trace.json
+type Trace struct {
+	Version   int            `json:"version"`
+	App       string         `json:"app"`
+	AppType   string         `json:"app_type"`
+	StartTime time.Time      `json:"start_time"`
+	EndTime   time.Time      `json:"end_time"`
+	Success   bool           `json:"success"`
+	Error     string         `json:"error,omitempty"`
+	Actions   []Action       `json:"actions"`
+	Network   []NetworkEvent `json:"network"`
+}
+
+// Recorder collects a trace while an app runs. It is safe for concurrent use;
+// network events typically arrive from a separate goroutine.
+type Recorder struct {
+	mu       sync.Mutex
+	trace    Trace
+	files    map[string][]byte
+	current  int
+	redactor *redact.Redactor
+}
+
+// NewRecorder starts a trace for an app
+func NewRecorder(app, appType string) *Recorder {
+	return &Recorder{
+		trace: Trace{
+			Version:   FormatVersion,
+			App:       app,
+			AppType:   appType,
+			StartTime: time.Now(),
+			Actions:   make([]Action, 0),
+			Network:   make([]NetworkEvent, 0),
+		},
+		files:   make(map[string][]byte),
+		current: -1,
+	}
+}
+
+// SetRedactor has Save redact the parameters, errors and requests of the
+// trace with redactor
+func (r *Recorder) SetRedactor(redactor *redact.Redactor) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.redactor = redactor
+}
+
+// BeginAction marks the start of an action; before is an optional PNG of the page
+func (r *Recorder) BeginAction(index int, name, actionType string, parameters map[string]interface{}, before []byte) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	action := Action{
+		Index:      index,
+		Name:       name,
+		Type:       actionType,
+		Parameters: parameters,
+		StartTime:  time.Now(),
+	}
+	if len(before) > 0 {
+		action.Before = r.addFile(fmt.Sprintf("screenshots/%03d_before.png", len(r.trace.Actions)), before)
+	}
+	r.trace.Actions = append(r.trace.Actions, action)
+	r.current = len(r.trace.Actions) - 1
+}
+
+// EndAction completes the current action; after is an optional PNG of the page
+func (r *Recorder) EndAction(err error, after []byte) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.current < 0 {
+		return
+	}
+	action := &r.trace.Actions[r.current]
+	action.EndTime = time.Now()
+	action.Duration = action.EndTime.Sub(action.StartTime)
+	if err != nil {
+		action.Error = err.Error()
+	}
+	if len(after) > 0 {
+		action.After = r.addFile(fmt.Sprintf("screenshots/%03d_after.png", r.current), after)
+	}
+	r.current = -1
+}
+
+// RecordNetwork adds a network event, attributing it to the running action
+func (r *Recorder) RecordNetwork(event NetworkEvent) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	event.ActionIndex = r.current
+	r.trace.Network = append(r.trace.Network, event)
+	if r.current >= 0 {
+		action := &r.trace.Actions[r.current]
+		action.NetworkRefs = append(action.NetworkRefs, len(r.trace.Network)-1)
+	}
+}
+
+// Finish records the overall outcome of the app
+func (r *Recorder) Finish(success bool, errMsg string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.trace.EndTime = time.Now()
+	r.trace.Success = success
+	r.trace.Error = errMsg
+}
+
+// Trace returns a copy of the trace recorded so far
+func (r *Recorder) Trace() Trace {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	t := r.trace
+	t.Actions = append([]Action(nil), r.trace.Actions...)
+	t.Network = append([]NetworkEvent(nil), r.trace.Network...)
+	return t
+}
+
+func (r *Recorder) addFile(name string, data []byte) string {
+	r.files[name] = data
+	return name
+}
+
+// Save writes the trace archive (trace.json plus screenshots) to filename
+func (r *Recorder) Save(filename string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if err := os.MkdirAll(filepath.Dir(filename), 0755); err != nil {
+		return fmt.Errorf("failed to create trace directory: %w", err)
+	}
+
+	f, err := os.Create(filename)
+	if err != nil {
+		return fmt.Errorf("failed to create trace file: %w", err)
+	}
+	defer f.Close()
+
+	zw := zip.NewWriter(f)
+
+	manifest, err := json.MarshalIndent(r.trace, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal trace: %w", err)
+	}
+	if err := writeZipFile(zw, manifestName, r.redactor.JSON(manifest)); err != nil {
+		return err
+	}
+
+	names := make([]string, 0, len(r.files))
+	for name := range r.files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if err := writeZipFile(zw, name, r.files[name]); err != nil {
+			return err
+		}
+	}
+
+	if err := zw.Close(); err != nil {
+		return fmt.Errorf("failed to finalize trace archive: %w", err)
+	}
+	return nil
+}
+
+func writeZipFile(zw *zip.Writer, name string, data []byte) error {
+	w, err := zw.Create(name)
+	if err != nil {
+		return fmt.Errorf("failed to add %s to trace: %w", name, err)
+	}
+	if _, err := w.Write(data); err != nil {
+		return fmt.Errorf("failed to write %s to trace: %w", name, err)
+	}
+	return nil
+}
+
+// Archive is a loaded trace file
+type Archive struct {
+	Trace Trace
+	Files map[string][]byte
+}
+
+// Load reads a trace archive written by Recorder.Save
+func Load(filename string) (*Archive, error) {
+	zr, err := zip.OpenReader(filename)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open trace: %w", err)
+	}
+	defer zr.Close()
+	return readArchive(&zr.Reader, filename)
+}
+
+// LoadBytes reads a trace archive held in memory, such as one decrypted
+// after it was encrypted at rest
+func LoadBytes(data []byte, name string) (*Archive, error) {
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, fmt.Errorf("failed to open trace: %w", err)
+	}
+	return readArchive(zr, name)
+}
+
+func readArchive(zr *zip.Reader, filename string) (*Archive, error) {
+	archive := &Archive{Files: make(map[string][]byte)}
+	found := false
+	for _, file := range zr.File {
+		name := path.Clean(file.Name)
+		if file.FileInfo().IsDir() || path.IsAbs(name) || name == ".." || len(name) > 2 && name[:3] == "../" {
+			continue
+		}
+
+		rc, err := file.Open()
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", name, err)
+		}
+		data, err := io.ReadAll(io.LimitReader(rc, 64<<20))
+		rc.Close()
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", name, err)
+		}
+
+		if name == manifestName {
+			if err := json.Unmarshal(data, &archive.Trace); err != nil {
+				return nil, fmt.Errorf("invalid trace.json: %w", err)
+			}
+			found = true
+			continue
+		}
+		archive.Files[name] = data
+	}
+
+	if !found {
+		return nil, fmt.Errorf("%s is not a panoptic trace (missing %s)", filename, manifestName)
+	}
+	if archive.Trace.Version > FormatVersion {
+		return nil, fmt.Errorf("trace format version %d is newer than supported version %d", archive.Trace.Version, FormatVersion)
+	}
+	return archive, nil
+}
@@ -0,0 +1,187 @@
+package trace
+
+import (
+	"archive/zip"
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"panoptic/internal/redact"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// recordSample records a two-action trace where the second action fails
+func recordSample(t *testing.T) string {
+	t.Helper()
+	r := NewRecorder("shop", "web")
+
+	r.RecordNetwork(NetworkEvent{RequestID: "0", Method: "GET", URL: "https://example.com/early", Status: 200})
+
+	r.BeginAction(0, "open", "navigate", map[string]interface{}{"url": "https://example.com"}, []byte("before-0"))
+	r.RecordNetwork(NetworkEvent{RequestID: "1", Method: "GET", URL: "https://example.com/", Status: 200, Type: "Document"})
+	r.RecordNetwork(NetworkEvent{RequestID: "2", Method: "GET", URL: "https://example.com/missing.js", Status: 404})
+	r.EndAction(nil, []byte("after-0"))
+
+	r.BeginAction(1, "login", "click", nil, nil)
+	r.RecordNetwork(NetworkEvent{RequestID: "3", Method: "POST", URL: "https://api.example.com/login", Failed: true, ErrorText: "net::ERR_CONNECTION_REFUSED"})
+	r.EndAction(fmt.Errorf("element #login not found"), []byte("after-1"))
+
+	r.Finish(false, "Action 'login' failed")
+
+	path := filepath.Join(t.TempDir(), "traces", "shop.zip")
+	require.NoError(t, r.Save(path))
+	return path
+}
+
+// TestRecorder_SaveLoadRoundTrip tests that a saved archive loads with actions, network and screenshots
+func TestRecorder_SaveLoadRoundTrip(t *testing.T) {
+	archive, err := Load(recordSample(t))
+	require.NoError(t, err)
+
+	tr := archive.Trace
+	assert.Equal(t, FormatVersion, tr.Version)
+	assert.Equal(t, "shop", tr.App)
+	assert.False(t, tr.Success)
+	require.Len(t, tr.Actions, 2)
+	require.Len(t, tr.Network, 4)
+
+	assert.Equal(t, -1, tr.Network[0].ActionIndex, "requests before the first action are unattributed")
+	assert.Equal(t, []int{1, 2}, tr.Actions[0].NetworkRefs)
+	assert.Equal(t, []int{3}, tr.Actions[1].NetworkRefs)
+	assert.Equal(t, "element #login not found", tr.Actions[1].Error)
+	assert.False(t, tr.Actions[0].EndTime.Before(tr.Actions[0].StartTime))
+
+	assert.Equal(t, "screenshots/000_before.png", tr.Actions[0].Before)
+	assert.Equal(t, "screenshots/001_after.png", tr.Actions[1].After)
+	assert.Empty(t, tr.Actions[1].Before)
+	assert.Equal(t, []byte("before-0"), archive.Files["screenshots/000_before.png"])
+	assert.Equal(t, []byte("after-1"), archive.Files["screenshots/001_after.png"])
+}
+
+// TestLoadBytes tests reading an archive held in memory
+func TestLoadBytes(t *testing.T) {
+	data, err := os.ReadFile(recordSample(t))
+	require.NoError(t, err)
+	archive, err := LoadBytes(data, "trace.zip")
+	require.NoError(t, err)
+	assert.Equal(t, "shop", archive.Trace.App)
+	assert.Equal(t, []byte("before-0"), archive.Files["screenshots/000_before.png"])
+
+	_, err = LoadBytes([]byte("not a zip"), "trace.zip")
+	assert.ErrorContains(t, err, "failed to open trace")
+}
+
+// TestRecorder_SetRedactor tests that saved traces are redacted
+func TestRecorder_SetRedactor(t *testing.T) {
+	redactor, err := redact.New([]string{`ada@example\.com`}, "")
+	require.NoError(t, err)
+	recorder := NewRecorder("shop", "web")
+	recorder.SetRedactor(redactor)
+	recorder.BeginAction(0, "email", "fill", map[string]interface{}{"value": "ada@example.com"}, nil)
+	recorder.EndAction(nil, nil)
+	recorder.RecordNetwork(NetworkEvent{Method: "GET", URL: "https://shop.example.com/?email=ada@example.com"})
+	recorder.Finish(false, "no account for ada@example.com")
+	path := filepath.Join(t.TempDir(), "shop.zip")
+	require.NoError(t, recorder.Save(path))
+
+	archive, err := Load(path)
+	require.NoError(t, err)
+	assert.Equal(t, "[REDACTED]", archive.Trace.Actions[0].Parameters["value"])
+	assert.Equal(t, "https://shop.example.com/?email=[REDACTED]", archive.Trace.Network[0].URL)
+	assert.Equal(t, "no account for [REDACTED]", archive.Trace.Error)
+}
+
+// TestRecorder_EndActionWithoutBegin tests that unmatched EndAction calls are ignored
+func TestRecorder_EndActionWithoutBegin(t *testing.T) {
+	r := NewRecorder("app", "desktop")
+	r.EndAction(fmt.Errorf("ignored"), []byte("png"))
+
+	assert.Empty(t, r.Trace().Actions)
+}
+
+func writeZip(t *testing.T, files map[string]string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "trace.zip")
+	buf := &bytes.Buffer{}
+	zw := zip.NewWriter(buf)
+	for name, content := range files {
+		w, err := zw.Create(name)
+		require.NoError(t, err)
+		_, err = io.WriteString(w, content)
+		require.NoError(t, err)
+	}
+	require.NoError(t, zw.Close())
+	require.NoError(t, os.WriteFile(path, buf.Bytes(), 0644))
+	return path
+}
+
+// TestLoad_Invalid tests rejecting non-trace, future-version and unsafe archives
+func TestLoad_Invalid(t *testing.T) {
+	_, err := Load(filepath.Join(t.TempDir(), "missing.zip"))
+	assert.Error(t, err)
+
+	_, err = Load(writeZip(t, map[string]string{"readme.txt": "hi"}))
+	assert.ErrorContains(t, err, "missing trace.json")
+
+	_, err = Load(writeZip(t, map[string]string{"trace.json": `{"version": 99}`}))
+	assert.ErrorContains(t, err, "newer than supported")
+
+	archive, err := Load(writeZip(t, map[string]string{"trace.json": `{"version": 1}`, "../escape.png": "x"}))
+	require.NoError(t, err)
+	assert.Empty(t, archive.Files, "paths outside the archive root are dropped")
+}
+
+// TestRenderText tests the terminal summary
+func TestRenderText(t *testing.T) {
+	archive, err := Load(recordSample(t))
+	require.NoError(t, err)
+
+	out := &bytes.Buffer{}
+	require.NoError(t, RenderText(out, archive))
+
+	text := out.String()
+	assert.Contains(t, text, "Trace: shop (web) FAILED")
+	assert.Contains(t, text, "Actions: 2, network requests: 4")
+	assert.Contains(t, text, "error: element #login not found")
+	assert.Contains(t, text, "GET https://example.com/missing.js -> 404")
+	assert.Contains(t, text, "POST https://api.example.com/login -> net::ERR_CONNECTION_REFUSED")
+	assert.NotContains(t, text, "example.com/early")
+}
+
+// TestHandler tests the web viewer page and screenshot serving
+func TestHandler(t *testing.T) {
+	archive, err := Load(recordSample(t))
+	require.NoError(t, err)
+
+	server := httptest.NewServer(Handler(archive))
+	defer server.Close()
+
+	resp, err := http.Get(server.URL + "/")
+	require.NoError(t, err)
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	page := string(body)
+	assert.Contains(t, page, "Trace: shop")
+	assert.Contains(t, page, `src="files/screenshots/000_before.png"`)
+	assert.Contains(t, page, "net::ERR_CONNECTION_REFUSED")
+
+	resp, err = http.Get(server.URL + "/files/screenshots/000_after.png")
+	require.NoError(t, err)
+	body, _ = io.ReadAll(resp.Body)
+	resp.Body.Close()
+	assert.Equal(t, "image/png", resp.Header.Get("Content-Type"))
+	assert.Equal(t, "after-0", string(body))
+
+	resp, err = http.Get(server.URL + "/files/trace.json")
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+}
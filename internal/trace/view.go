@@ -0,0 +1,156 @@
+package trace
+
+import (
+	"fmt"
+	"html/template"
+	"io"
+	"mime"
+	"net/http"
+	"path"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// RenderText writes a terminal-friendly summary of the trace
+func RenderText(w io.Writer, a *Archive) error {
+	t := a.Trace
+	status := "PASSED"
+	if !t.Success {
+		status = "FAILED"
+	}
+
+	fmt.Fprintf(w, "Trace: %s (%s) %s in %s\n", t.App, t.AppType, status, roundDuration(t.EndTime.Sub(t.StartTime)))
+	if t.Error != "" {
+		fmt.Fprintf(w, "Error: %s\n", t.Error)
+	}
+	fmt.Fprintf(w, "Actions: %d, network requests: %d\n\n", len(t.Actions), len(t.Network))
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "#\tACTION\tTYPE\tDURATION\tREQUESTS\tSTATUS")
+	for _, action := range t.Actions {
+		result := "ok"
+		if action.Error != "" {
+			result = "error: " + action.Error
+		}
+		fmt.Fprintf(tw, "%d\t%s\t%s\t%s\t%d\t%s\n",
+			action.Index+1, action.Name, action.Type, roundDuration(action.Duration), len(action.NetworkRefs), result)
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	var failed []NetworkEvent
+	for _, event := range t.Network {
+		if event.Failed || event.Status >= 400 {
+			failed = append(failed, event)
+		}
+	}
+	if len(failed) > 0 {
+		fmt.Fprintf(w, "\nFailed requests:\n")
+		for _, event := range failed {
+			detail := fmt.Sprintf("%d", event.Status)
+			if event.Failed {
+				detail = event.ErrorText
+			}
+			fmt.Fprintf(w, "  %s %s -> %s\n", event.Method, event.URL, detail)
+		}
+	}
+	return nil
+}
+
+func roundDuration(d time.Duration) time.Duration {
+	if d < time.Second {
+		return d.Round(time.Millisecond)
+	}
+	return d.Round(10 * time.Millisecond)
+}
+
+var pageTemplate = template.Must(template.New("trace").Funcs(template.FuncMap{
+	"dur": roundDuration,
+	"inc": func(i int) int { return i + 1 },
+	"requests": func(t Trace, refs []int) []NetworkEvent {
+		events := make([]NetworkEvent, 0, len(refs))
+		for _, i := range refs {
+			if i >= 0 && i < len(t.Network) {
+				events = append(events, t.Network[i])
+			}
+		}
+		return events
+	},
+	"total": func(t Trace) time.Duration { return roundDuration(t.EndTime.Sub(t.StartTime)) },
+}).Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="UTF-8">
+<title>Trace: {{.App}}</title>
+<style>
+body{font-family:-apple-system,BlinkMacSystemFont,'Segoe UI',Roboto,sans-serif;background:#1a1a2e;color:#e0e0e0;margin:0;padding:20px}
+h1{color:#e94560;font-size:1.6em}
+.meta{color:#888;margin-bottom:20px}
+.action{background:#16213e;border-radius:8px;margin:12px 0;padding:15px;border-left:4px solid #4caf50}
+.action.failed{border-left-color:#f44336}
+.action h2{font-size:1.1em;margin:0 0 6px 0}
+.action .error{color:#ef9a9a;font-family:monospace;white-space:pre-wrap}
+.shots{display:flex;gap:12px;margin-top:10px;flex-wrap:wrap}
+.shots figure{margin:0}
+.shots img{max-width:420px;border:1px solid #333;border-radius:4px}
+.shots figcaption{color:#888;font-size:0.8em}
+table{border-collapse:collapse;margin-top:10px;font-size:0.85em;width:100%}
+td,th{padding:3px 8px;text-align:left;border-bottom:1px solid #0f3460;word-break:break-all}
+td.bad{color:#f44336}
+</style>
+</head>
+<body>
+<h1>Trace: {{.App}}</h1>
+<div class="meta">{{.AppType}} &middot; {{if .Success}}passed{{else}}failed{{end}} in {{total .}} &middot; {{len .Actions}} actions &middot; {{len .Network}} requests{{if .Error}}<br>{{.Error}}{{end}}</div>
+{{$t := .}}{{range .Actions}}
+<div class="action{{if .Error}} failed{{end}}">
+<h2>{{inc .Index}}. {{.Name}} <small>({{.Type}}, {{dur .Duration}})</small></h2>
+{{if .Error}}<div class="error">{{.Error}}</div>{{end}}
+{{if or .Before .After}}<div class="shots">
+{{if .Before}}<figure><img src="files/{{.Before}}" alt="before"><figcaption>before</figcaption></figure>{{end}}
+{{if .After}}<figure><img src="files/{{.After}}" alt="after"><figcaption>after</figcaption></figure>{{end}}
+</div>{{end}}
+{{with requests $t .NetworkRefs}}<table>
+<tr><th>Method</th><th>URL</th><th>Status</th><th>Type</th><th>Time</th></tr>
+{{range .}}<tr><td>{{.Method}}</td><td>{{.URL}}</td><td{{if or .Failed (ge .Status 400)}} class="bad"{{end}}>{{if .Failed}}{{.ErrorText}}{{else}}{{.Status}}{{end}}</td><td>{{.Type}}</td><td>{{dur .Duration}}</td></tr>
+{{end}}</table>{{end}}
+</div>
+{{end}}
+</body>
+</html>
+`))
+
+// RenderHTML writes the trace as a standalone page whose images resolve under files/
+func RenderHTML(w io.Writer, a *Archive) error {
+	return pageTemplate.Execute(w, a.Trace)
+}
+
+// Handler serves the trace viewer page and the archive's screenshots
+func Handler(a *Archive) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		if err := RenderHTML(w, a); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+	})
+	mux.HandleFunc("/files/", func(w http.ResponseWriter, r *http.Request) {
+		name := strings.TrimPrefix(path.Clean(r.URL.Path), "/files/")
+		data, ok := a.Files[name]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		if ct := mime.TypeByExtension(path.Ext(name)); ct != "" {
+			w.Header().Set("Content-Type", ct)
+		}
+		_, _ = w.Write(data)
+	})
+	return mux
+}
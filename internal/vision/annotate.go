@@ -0,0 +1,106 @@
+package vision
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"os"
+	"path/filepath"
+
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/basicfont"
+	"golang.org/x/image/math/fixed"
+)
+
+// Annotation marks a region of a screenshot with an outline and a label
+type Annotation struct {
+	Bounds image.Rectangle `json:"bounds"`
+	Label  string          `json:"label"`
+	Color  color.RGBA      `json:"-"` // AnnotationRed when zero
+}
+
+// Annotation colors
+var (
+	AnnotationRed    = color.RGBA{R: 0xe9, G: 0x45, B: 0x60, A: 0xff} // failures and violations
+	AnnotationOrange = color.RGBA{R: 0xff, G: 0x98, B: 0x00, A: 0xff} // warnings
+	AnnotationBlue   = color.RGBA{R: 0x21, G: 0x96, B: 0xf3, A: 0xff} // targeted elements
+)
+
+// Outline and label geometry
+const (
+	outlineWidth = 3
+	labelPadding = 3
+)
+
+// Annotate returns a copy of an image with each annotation's bounds
+// outlined and its label drawn on a tab above them, or inside their top edge
+// when there is no room above
+func Annotate(img image.Image, annotations []Annotation) *image.RGBA {
+	out := image.NewRGBA(img.Bounds())
+	draw.Draw(out, out.Bounds(), img, img.Bounds().Min, draw.Src)
+	face := basicfont.Face7x13
+	for _, a := range annotations {
+		c := a.Color
+		if c == (color.RGBA{}) {
+			c = AnnotationRed
+		}
+		fill := &image.Uniform{c}
+		r := a.Bounds.Inset(-outlineWidth).Intersect(out.Bounds())
+		if r.Empty() {
+			continue
+		}
+		for _, edge := range []image.Rectangle{
+			image.Rect(r.Min.X, r.Min.Y, r.Max.X, r.Min.Y+outlineWidth),
+			image.Rect(r.Min.X, r.Max.Y-outlineWidth, r.Max.X, r.Max.Y),
+			image.Rect(r.Min.X, r.Min.Y, r.Min.X+outlineWidth, r.Max.Y),
+			image.Rect(r.Max.X-outlineWidth, r.Min.Y, r.Max.X, r.Max.Y),
+		} {
+			draw.Draw(out, edge.Intersect(r), fill, image.Point{}, draw.Src)
+		}
+		if a.Label == "" {
+			continue
+		}
+
+		width := font.MeasureString(face, a.Label).Ceil() + 2*labelPadding
+		height := face.Height + 2*labelPadding
+		tab := image.Rect(r.Min.X, r.Min.Y-height, r.Min.X+width, r.Min.Y)
+		if tab.Min.Y < out.Bounds().Min.Y {
+			tab = tab.Add(image.Pt(0, height))
+		}
+		if tab.Max.X > out.Bounds().Max.X {
+			tab = tab.Add(image.Pt(max(out.Bounds().Max.X-tab.Max.X, out.Bounds().Min.X-tab.Min.X), 0))
+		}
+		draw.Draw(out, tab.Intersect(out.Bounds()), fill, image.Point{}, draw.Src)
+		drawer := font.Drawer{
+			Dst:  out,
+			Src:  image.White,
+			Face: face,
+			Dot:  fixed.P(tab.Min.X+labelPadding, tab.Min.Y+labelPadding+face.Ascent),
+		}
+		drawer.DrawString(a.Label)
+	}
+	return out
+}
+
+// AnnotateFile writes an annotated copy of a screenshot as a PNG
+func (ed *ElementDetector) AnnotateFile(imagePath, outputPath string, annotations []Annotation) error {
+	img, err := ed.loadImage(imagePath)
+	if err != nil {
+		return fmt.Errorf("failed to load image: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(outputPath), 0755); err != nil {
+		return err
+	}
+	f, err := os.Create(outputPath)
+	if err != nil {
+		return err
+	}
+	if err := png.Encode(f, Annotate(img, annotations)); err != nil {
+		f.Close()
+		return fmt.Errorf("failed to encode %s: %w", outputPath, err)
+	}
+	ed.logger.Debugf("Annotated %d region(s) of %s in %s", len(annotations), imagePath, outputPath)
+	return f.Close()
+}
@@ -0,0 +1,56 @@
+package vision
+
+import (
+	"image"
+	"image/color"
+	"path/filepath"
+	"testing"
+
+	"panoptic/internal/logger"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestAnnotate tests outlines around bounds and label tabs
+func TestAnnotate(t *testing.T) {
+	img := createTestImage(200, 100, color.White)
+	out := Annotate(img, []Annotation{
+		{Bounds: image.Rect(50, 40, 150, 80), Label: "#buy"},
+		{Bounds: image.Rect(0, 0, 40, 20), Label: "top", Color: AnnotationBlue},
+	})
+
+	assert.Equal(t, AnnotationRed, out.RGBAAt(48, 60), "left outline outside the bounds")
+	assert.Equal(t, AnnotationRed, out.RGBAAt(100, 81), "bottom outline")
+	assert.Equal(t, color.RGBA{255, 255, 255, 255}, out.RGBAAt(100, 60), "inside is untouched")
+	assert.Equal(t, AnnotationRed, out.RGBAAt(48, 20), "label tab above the box")
+	assert.Equal(t, AnnotationBlue, out.RGBAAt(1, 1), "clipped outline at the image corner")
+	assert.Equal(t, AnnotationBlue, out.RGBAAt(2, 16), "label tab inside when there is no room above")
+
+	white := 0
+	for y := 17; y < 37; y++ {
+		for x := 47; x < 85; x++ {
+			if out.RGBAAt(x, y) == (color.RGBA{255, 255, 255, 255}) {
+				white++
+			}
+		}
+	}
+	assert.Greater(t, white, 20, "label text is drawn in white on the tab")
+	assert.Equal(t, color.RGBA{255, 255, 255, 255}, img.At(48, 60), "the source is not modified")
+}
+
+// TestElementDetector_AnnotateFile tests writing an annotated copy
+func TestElementDetector_AnnotateFile(t *testing.T) {
+	source := saveTestImage(t, createTestImage(120, 80, color.White), "screen.png")
+	output := filepath.Join(t.TempDir(), "annotated", "screen.png")
+	detector := NewElementDetector(*logger.NewLogger(false))
+
+	require.NoError(t, detector.AnnotateFile(source, output, []Annotation{{Bounds: image.Rect(10, 30, 60, 50), Label: "contrast 2.1:1"}}))
+	annotated, err := detector.loadImage(output)
+	require.NoError(t, err)
+	assert.Equal(t, image.Rect(0, 0, 120, 80), annotated.Bounds())
+	r, g, b, _ := annotated.At(8, 40).RGBA()
+	assert.Equal(t, [3]uint32{0xe9e9, 0x4545, 0x6060}, [3]uint32{r, g, b})
+
+	assert.Error(t, detector.AnnotateFile("/nonexistent/screen.png", output, nil))
+}
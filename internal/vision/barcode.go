@@ -0,0 +1,301 @@
+package vision
+
+import (
+	"image"
+	"math"
+	"strings"
+)
+
+// eanDigits are the space-bar-space-bar module widths of the L-code digits;
+// G-codes are the same widths reversed, and R-codes start with a bar
+var eanDigits = [10][4]int{
+	{3, 2, 1, 1}, {2, 2, 2, 1}, {2, 1, 2, 2}, {1, 4, 1, 1}, {1, 1, 3, 2},
+	{1, 2, 3, 1}, {1, 1, 1, 4}, {1, 3, 1, 2}, {1, 2, 1, 3}, {3, 1, 1, 2},
+}
+
+// eanParity is the L/G pattern of the left half (bit 5 is the first digit,
+// set for G) that encodes the leading digit of an EAN-13
+var eanParity = [10]int{0x00, 0x0B, 0x0D, 0x0E, 0x13, 0x19, 0x1C, 0x15, 0x16, 0x1A}
+
+// code128Patterns are the bar-space widths of Code 128 symbols 0-105;
+// 103-105 are start A, B and C
+var code128Patterns = [106][6]int{
+	{2, 1, 2, 2, 2, 2}, {2, 2, 2, 1, 2, 2}, {2, 2, 2, 2, 2, 1}, {1, 2, 1, 2, 2, 3}, {1, 2, 1, 3, 2, 2},
+	{1, 3, 1, 2, 2, 2}, {1, 2, 2, 2, 1, 3}, {1, 2, 2, 3, 1, 2}, {1, 3, 2, 2, 1, 2}, {2, 2, 1, 2, 1, 3},
+	{2, 2, 1, 3, 1, 2}, {2, 3, 1, 2, 1, 2}, {1, 1, 2, 2, 3, 2}, {1, 2, 2, 1, 3, 2}, {1, 2, 2, 2, 3, 1},
+	{1, 1, 3, 2, 2, 2}, {1, 2, 3, 1, 2, 2}, {1, 2, 3, 2, 2, 1}, {2, 2, 3, 2, 1, 1}, {2, 2, 1, 1, 3, 2},
+	{2, 2, 1, 2, 3, 1}, {2, 1, 3, 2, 1, 2}, {2, 2, 3, 1, 1, 2}, {3, 1, 2, 1, 3, 1}, {3, 1, 1, 2, 2, 2},
+	{3, 2, 1, 1, 2, 2}, {3, 2, 1, 2, 2, 1}, {3, 1, 2, 2, 1, 2}, {3, 2, 2, 1, 1, 2}, {3, 2, 2, 2, 1, 1},
+	{2, 1, 2, 1, 2, 3}, {2, 1, 2, 3, 2, 1}, {2, 3, 2, 1, 2, 1}, {1, 1, 1, 3, 2, 3}, {1, 3, 1, 1, 2, 3},
+	{1, 3, 1, 3, 2, 1}, {1, 1, 2, 3, 1, 3}, {1, 3, 2, 1, 1, 3}, {1, 3, 2, 3, 1, 1}, {2, 1, 1, 3, 1, 3},
+	{2, 3, 1, 1, 1, 3}, {2, 3, 1, 3, 1, 1}, {1, 1, 2, 1, 3, 3}, {1, 1, 2, 3, 3, 1}, {1, 3, 2, 1, 3, 1},
+	{1, 1, 3, 1, 2, 3}, {1, 1, 3, 3, 2, 1}, {1, 3, 3, 1, 2, 1}, {3, 1, 3, 1, 2, 1}, {2, 1, 1, 3, 3, 1},
+	{2, 3, 1, 1, 3, 1}, {2, 1, 3, 1, 1, 3}, {2, 1, 3, 3, 1, 1}, {2, 1, 3, 1, 3, 1}, {3, 1, 1, 1, 2, 3},
+	{3, 1, 1, 3, 2, 1}, {3, 3, 1, 1, 2, 1}, {3, 1, 2, 1, 1, 3}, {3, 1, 2, 3, 1, 1}, {3, 3, 2, 1, 1, 1},
+	{3, 1, 4, 1, 1, 1}, {2, 2, 1, 4, 1, 1}, {4, 3, 1, 1, 1, 1}, {1, 1, 1, 2, 2, 4}, {1, 1, 1, 4, 2, 2},
+	{1, 2, 1, 1, 2, 4}, {1, 2, 1, 4, 2, 1}, {1, 4, 1, 1, 2, 2}, {1, 4, 1, 2, 2, 1}, {1, 1, 2, 2, 1, 4},
+	{1, 1, 2, 4, 1, 2}, {1, 2, 2, 1, 1, 4}, {1, 2, 2, 4, 1, 1}, {1, 4, 2, 1, 1, 2}, {1, 4, 2, 2, 1, 1},
+	{2, 4, 1, 2, 1, 1}, {2, 2, 1, 1, 1, 4}, {4, 1, 3, 1, 1, 1}, {2, 4, 1, 1, 1, 2}, {1, 3, 4, 1, 1, 1},
+	{1, 1, 1, 2, 4, 2}, {1, 2, 1, 1, 4, 2}, {1, 2, 1, 2, 4, 1}, {1, 1, 4, 2, 1, 2}, {1, 2, 4, 1, 1, 2},
+	{1, 2, 4, 2, 1, 1}, {4, 1, 1, 2, 1, 2}, {4, 2, 1, 1, 1, 2}, {4, 2, 1, 2, 1, 1}, {2, 1, 2, 1, 4, 1},
+	{2, 1, 4, 1, 2, 1}, {4, 1, 2, 1, 2, 1}, {1, 1, 1, 1, 4, 3}, {1, 1, 1, 3, 4, 1}, {1, 3, 1, 1, 4, 1},
+	{1, 1, 4, 1, 1, 3}, {1, 1, 4, 3, 1, 1}, {4, 1, 1, 1, 1, 3}, {4, 1, 1, 3, 1, 1}, {1, 1, 3, 1, 4, 1},
+	{1, 1, 4, 1, 3, 1}, {3, 1, 1, 1, 4, 1}, {4, 1, 1, 1, 3, 1}, {2, 1, 1, 4, 1, 2}, {2, 1, 1, 2, 1, 4},
+	{2, 1, 1, 2, 3, 2},
+}
+
+// code128Stop is the stop pattern, including its final bar
+var code128Stop = []int{2, 3, 3, 1, 1, 1, 2}
+
+// patternError compares measured run lengths, scaled to modules, with a
+// pattern; lower is closer
+func patternError(runs []int, pattern []int) float64 {
+	total, modules := 0, 0
+	for i := range pattern {
+		total += runs[i]
+		modules += pattern[i]
+	}
+	scale := float64(modules) / float64(total)
+	var err float64
+	for i, p := range pattern {
+		err += math.Abs(float64(runs[i])*scale - float64(p))
+	}
+	return err
+}
+
+// rowRuns returns the run lengths of a row starting with a light run, and
+// the x where each run starts
+func rowRuns(b *binaryImage, y int) (runs, starts []int) {
+	dark := false
+	length, start := 0, 0
+	for x := 0; x < b.width; x++ {
+		if b.at(x, y) == dark {
+			length++
+			continue
+		}
+		runs, starts = append(runs, length), append(starts, start)
+		dark, length, start = !dark, 1, x
+	}
+	return append(runs, length), append(starts, start)
+}
+
+// findBarcodes decodes EAN-13, UPC-A and Code 128 symbols crossed by the
+// scanned rows; odd indexes of runs are bars
+func findBarcodes(b *binaryImage) []Code {
+	var codes []Code
+	seen := map[string]bool{}
+	step := max(1, b.height/200)
+	for y := 0; y < b.height; y += step {
+		runs, starts := rowRuns(b, y)
+		for i := 1; i < len(runs); i += 2 {
+			var code *Code
+			var width int
+			if text, n, ok := decodeEAN13(runs, i); ok {
+				code, width = &Code{Format: "ean13", Text: text}, n
+				if strings.HasPrefix(text, "0") {
+					code.Format, code.Text = "upc_a", text[1:]
+				}
+			} else if text, n, ok := decodeCode128(runs, i); ok {
+				code, width = &Code{Format: "code128", Text: text}, n
+			}
+			if code == nil {
+				continue
+			}
+			end := starts[i+width-1] + runs[i+width-1]
+			code.Bounds = image.Rect(starts[i], y, end, y+1)
+			key := code.Format + "\x00" + code.Text
+			if !seen[key] {
+				seen[key] = true
+				codes = append(codes, *code)
+			} else {
+				for k := range codes {
+					if codes[k].Format+"\x00"+codes[k].Text == key {
+						codes[k].Bounds = codes[k].Bounds.Union(code.Bounds)
+					}
+				}
+			}
+			i += width - 1
+		}
+	}
+	return codes
+}
+
+// quietBefore reports whether the light run before bar i is at least
+// modules wide
+func quietBefore(runs []int, i int, module float64, modules float64) bool {
+	return i == 1 && runs[0] == 0 || float64(runs[i-1]) >= module*modules
+}
+
+// decodeEAN13 reads an EAN-13 whose start guard is the bar at runs[i],
+// returning the 13 digits and the number of runs used
+func decodeEAN13(runs []int, i int) (string, int, bool) {
+	const length = 3 + 24 + 5 + 24 + 3
+	if i+length > len(runs) {
+		return "", 0, false
+	}
+	total := 0
+	for _, r := range runs[i : i+length] {
+		total += r
+	}
+	module := float64(total) / 95
+	if patternError(runs[i:i+3], []int{1, 1, 1}) > 1 || !quietBefore(runs, i, module, 5) {
+		return "", 0, false
+	}
+	middle := i + 3 + 24
+	if patternError(runs[middle:middle+5], []int{1, 1, 1, 1, 1}) > 1.5 || patternError(runs[middle+29:middle+32], []int{1, 1, 1}) > 1 {
+		return "", 0, false
+	}
+
+	digits := make([]byte, 0, 13)
+	parity := 0
+	for d := 0; d < 12; d++ {
+		pos := i + 3 + 4*d
+		if d >= 6 {
+			pos = middle + 5 + 4*(d-6)
+		}
+		best, bestErr, bestG := -1, 1.5, false
+		for digit, widths := range eanDigits {
+			if e := patternError(runs[pos:pos+4], widths[:]); e < bestErr {
+				best, bestErr, bestG = digit, e, false
+			}
+			if d < 6 {
+				reversed := []int{widths[3], widths[2], widths[1], widths[0]}
+				if e := patternError(runs[pos:pos+4], reversed); e < bestErr {
+					best, bestErr, bestG = digit, e, true
+				}
+			}
+		}
+		if best < 0 {
+			return "", 0, false
+		}
+		if bestG {
+			parity |= 1 << (5 - d)
+		}
+		digits = append(digits, byte('0'+best))
+	}
+
+	first := -1
+	for digit, p := range eanParity {
+		if p == parity {
+			first = digit
+		}
+	}
+	if first < 0 {
+		return "", 0, false
+	}
+	text := string(rune('0'+first)) + string(digits)
+	if !eanChecksum(text) {
+		return "", 0, false
+	}
+	return text, length, true
+}
+
+// eanChecksum verifies the check digit of an EAN-13
+func eanChecksum(text string) bool {
+	sum := 0
+	for k := 0; k < 12; k++ {
+		weight := 1
+		if k%2 == 1 {
+			weight = 3
+		}
+		sum += int(text[k]-'0') * weight
+	}
+	return (10-sum%10)%10 == int(text[12]-'0')
+}
+
+// decodeCode128 reads a Code 128 whose start symbol begins with the bar at
+// runs[i], returning the text and the number of runs used
+func decodeCode128(runs []int, i int) (string, int, bool) {
+	matchSymbol := func(pos int) (int, float64) {
+		if pos+6 > len(runs) {
+			return -1, 0
+		}
+		best, bestErr := -1, 1.2
+		for value, pattern := range code128Patterns {
+			if e := patternError(runs[pos:pos+6], pattern[:]); e < bestErr {
+				best, bestErr = value, e
+			}
+		}
+		return best, bestErr
+	}
+
+	start, _ := matchSymbol(i)
+	if start < 103 {
+		return "", 0, false
+	}
+	module := 0.0
+	for _, r := range runs[i : i+6] {
+		module += float64(r)
+	}
+	if !quietBefore(runs, i, module/11, 5) {
+		return "", 0, false
+	}
+
+	values := []int{start}
+	pos := i + 6
+	for {
+		if pos+7 <= len(runs) && patternError(runs[pos:pos+7], code128Stop) < 1.4 {
+			break
+		}
+		value, _ := matchSymbol(pos)
+		if value < 0 || value >= 103 {
+			return "", 0, false
+		}
+		values = append(values, value)
+		pos += 6
+	}
+	if len(values) < 2 {
+		return "", 0, false
+	}
+
+	sum := values[0]
+	for k := 1; k < len(values)-1; k++ {
+		sum += k * values[k]
+	}
+	if sum%103 != values[len(values)-1] {
+		return "", 0, false
+	}
+	text, ok := code128Text(values[:len(values)-1])
+	return text, pos + 7 - i, ok
+}
+
+// code128Text interprets symbol values, starting with the start symbol, in
+// code sets A, B and C
+func code128Text(values []int) (string, bool) {
+	set := values[0] - 103 // 0 A, 1 B, 2 C
+	var out strings.Builder
+	shift := false
+	for k, v := range values[1:] {
+		current := set
+		if shift {
+			current, shift = 1-set, false
+		}
+		switch {
+		case current == 2 && v < 100:
+			out.WriteString(string([]byte{byte('0' + v/10), byte('0' + v%10)}))
+		case current != 2 && v < 96:
+			if current == 0 && v >= 64 {
+				out.WriteByte(byte(v - 64))
+			} else {
+				out.WriteByte(byte(32 + v))
+			}
+		case v == 102: // FNC1: GS1 field separator after the first position
+			if k > 0 {
+				out.WriteByte(0x1D)
+			}
+		case v == 98 && current != 2:
+			shift = true
+		case v == 99 && current != 2:
+			set = 2
+		case v == 100 && current == 0, v == 100 && current == 2:
+			set = 1
+		case v == 101 && current == 1, v == 101 && current == 2:
+			set = 0
+		case v == 96 || v == 97 || v == 100 || v == 101:
+			// FNC2, FNC3 and FNC4 carry no text
+		default:
+			return "", false
+		}
+	}
+	return out.String(), true
+}
@@ -0,0 +1,103 @@
+package vision
+
+import (
+	"image"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// renderBars draws alternating bar and space widths, starting with a bar,
+// between 10-module quiet zones
+func renderBars(widths []int, module, height int) *image.Gray {
+	total := 20
+	for _, w := range widths {
+		total += w
+	}
+	img := image.NewGray(image.Rect(0, 0, total*module, height))
+	for i := range img.Pix {
+		img.Pix[i] = 255
+	}
+	x := 10 * module
+	for k, w := range widths {
+		if k%2 == 0 {
+			for px := x; px < x+w*module; px++ {
+				for y := 0; y < height; y++ {
+					img.Pix[y*img.Stride+px] = 0
+				}
+			}
+		}
+		x += w * module
+	}
+	return img
+}
+
+// encodeEAN13 returns the bar-space widths of a 13-digit code
+func encodeEAN13(code string) []int {
+	widths := []int{1, 1, 1}
+	parity := eanParity[code[0]-'0']
+	for k := 1; k <= 6; k++ {
+		d := eanDigits[code[k]-'0']
+		if parity>>(6-k)&1 == 1 {
+			d = [4]int{d[3], d[2], d[1], d[0]}
+		}
+		widths = append(widths, d[:]...)
+	}
+	widths = append(widths, 1, 1, 1, 1, 1)
+	for k := 7; k <= 12; k++ {
+		d := eanDigits[code[k]-'0']
+		widths = append(widths, d[:]...)
+	}
+	return append(widths, 1, 1, 1)
+}
+
+// encodeCode128 returns the widths of symbol values, adding the checksum
+// and stop
+func encodeCode128(values ...int) []int {
+	sum := values[0]
+	for k := 1; k < len(values); k++ {
+		sum += k * values[k]
+	}
+	var widths []int
+	for _, v := range append(values, sum%103) {
+		widths = append(widths, code128Patterns[v][:]...)
+	}
+	return append(widths, code128Stop...)
+}
+
+// TestCode128Patterns tests the symbol table's invariants
+func TestCode128Patterns(t *testing.T) {
+	seen := map[[6]int]bool{}
+	for value, p := range code128Patterns {
+		assert.False(t, seen[p], "symbol %d repeats a pattern", value)
+		seen[p] = true
+		assert.Equal(t, 11, p[0]+p[1]+p[2]+p[3]+p[4]+p[5], "symbol %d", value)
+		assert.Equal(t, 0, (p[0]+p[2]+p[4])%2, "symbol %d bars have an even module count", value)
+	}
+	for _, d := range eanDigits {
+		assert.Equal(t, 7, d[0]+d[1]+d[2]+d[3])
+	}
+}
+
+// TestDecodeCodes_Barcodes tests EAN-13, UPC-A and Code 128 round trips
+func TestDecodeCodes_Barcodes(t *testing.T) {
+	codes := DecodeCodes(renderBars(encodeEAN13("4006381333931"), 2, 60))
+	require.Len(t, codes, 1)
+	assert.Equal(t, Code{Format: "ean13", Text: "4006381333931", Bounds: image.Rect(20, 0, 210, 60)}, codes[0])
+
+	codes = DecodeCodes(renderBars(encodeEAN13("0036000291452"), 3, 40))
+	require.Len(t, codes, 1)
+	assert.Equal(t, "upc_a", codes[0].Format)
+	assert.Equal(t, "036000291452", codes[0].Text)
+
+	// Start B "Ord-", code C "1234", FNC1, then B "x"
+	values := []int{104, 'O' - 32, 'r' - 32, 'd' - 32, '-' - 32, 99, 12, 34, 102, 100, 'x' - 32}
+	codes = DecodeCodes(renderBars(encodeCode128(values...), 2, 30))
+	require.Len(t, codes, 1)
+	assert.Equal(t, "code128", codes[0].Format)
+	assert.Equal(t, "Ord-1234\x1dx", codes[0].Text)
+
+	bad := encodeEAN13("4006381333932")
+	assert.Empty(t, DecodeCodes(renderBars(bad, 2, 20)), "a wrong check digit is rejected")
+}
@@ -0,0 +1,249 @@
+package vision
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"gopkg.in/yaml.v3"
+)
+
+// DefaultMatchIoU is the overlap a detection needs with a labeled element
+// of its type to count as finding it
+const DefaultMatchIoU = 0.5
+
+// LabeledScreenshot is a screenshot with the elements a person marked in it
+type LabeledScreenshot struct {
+	Screenshot string           `yaml:"screenshot" json:"screenshot"`
+	Elements   []LabeledElement `yaml:"elements" json:"elements"`
+}
+
+// LabeledElement is the box of an element in screenshot pixels
+type LabeledElement struct {
+	Type   string `yaml:"type" json:"type"`
+	X      int    `yaml:"x" json:"x"`
+	Y      int    `yaml:"y" json:"y"`
+	Width  int    `yaml:"width" json:"width"`
+	Height int    `yaml:"height" json:"height"`
+}
+
+// LoadLabeledSet reads a YAML or JSON list of labeled screenshots. Relative
+// screenshot paths are resolved against the directory of the file.
+func LoadLabeledSet(path string) ([]LabeledScreenshot, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var set []LabeledScreenshot
+	if err := yaml.Unmarshal(data, &set); err != nil {
+		return nil, fmt.Errorf("invalid labeled screenshot set %s: %w", path, err)
+	}
+	if len(set) == 0 {
+		return nil, fmt.Errorf("labeled screenshot set %s is empty", path)
+	}
+	for i := range set {
+		s := &set[i]
+		if s.Screenshot == "" {
+			return nil, fmt.Errorf("%s: entry %d has no screenshot", path, i)
+		}
+		if !filepath.IsAbs(s.Screenshot) {
+			s.Screenshot = filepath.Join(filepath.Dir(path), s.Screenshot)
+		}
+		for j, e := range s.Elements {
+			if e.Type == "" || e.Width <= 0 || e.Height <= 0 {
+				return nil, fmt.Errorf("%s: elements[%d] of %s needs a type, width and height", path, j, s.Screenshot)
+			}
+		}
+	}
+	return set, nil
+}
+
+// CalibrationPoint is how well detections of a type match the labels at a
+// confidence threshold
+type CalibrationPoint struct {
+	Threshold float64 `json:"threshold"`
+	Detected  int     `json:"detected"`
+	Matched   int     `json:"matched"`
+	Precision float64 `json:"precision"`
+	Recall    float64 `json:"recall"`
+	F1        float64 `json:"f1"`
+}
+
+// TypeCalibration is the precision and recall of one element type at the
+// detector's thresholds, and the threshold with the best F1 score
+type TypeCalibration struct {
+	Type    string             `json:"type"`
+	Labeled int                `json:"labeled"`
+	Current CalibrationPoint   `json:"current"`
+	Best    CalibrationPoint   `json:"best"`
+	Curve   []CalibrationPoint `json:"curve"`
+}
+
+// Calibration measures a detector against a labeled screenshot set
+type Calibration struct {
+	Screenshots int               `json:"screenshots"`
+	MatchIoU    float64           `json:"match_iou"`
+	Types       []TypeCalibration `json:"types"`
+}
+
+// calibrationThresholds are the confidences a curve is measured at
+var calibrationThresholds = func() []float64 {
+	var steps []float64
+	for t := 5; t <= 95; t += 5 {
+		steps = append(steps, float64(t)/100)
+	}
+	return steps
+}()
+
+// Calibrate detects the elements of every labeled screenshot and measures,
+// per element type, the precision and recall at the detector's thresholds
+// and across thresholds from 0.05 to 0.95. A detection matches an
+// unmatched labeled element of its type overlapping it by matchIoU or more,
+// most confident detections first. The heuristic detectors give each type a
+// fixed confidence, so their curves only move at that confidence.
+func (ed *ElementDetector) Calibrate(set []LabeledScreenshot, matchIoU float64) (*Calibration, error) {
+	if matchIoU <= 0 {
+		matchIoU = DefaultMatchIoU
+	}
+	type screen struct {
+		labels     []LabeledElement
+		candidates []ElementInfo
+		fromModel  bool
+	}
+	screens := make([]screen, 0, len(set))
+	types := map[string]bool{}
+	for _, s := range set {
+		img, err := ed.loadImage(s.Screenshot)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load image: %w", err)
+		}
+		// Keep every candidate; thresholds are applied per curve point
+		candidates, fromModel := ed.detect(img, s.Screenshot)
+		screens = append(screens, screen{labels: s.Elements, candidates: candidates, fromModel: fromModel})
+		for _, l := range s.Elements {
+			types[l.Type] = true
+		}
+		for _, c := range candidates {
+			types[c.Type] = true
+		}
+	}
+
+	// measure scores one type at the detector's thresholds with that type's
+	// minimum replaced
+	measure := func(elementType string, threshold float64) CalibrationPoint {
+		point := CalibrationPoint{Threshold: threshold}
+		labeled := 0
+		thresholds := ed.thresholds
+		thresholds.ByType = map[string]float64{elementType: threshold}
+		for t, v := range ed.thresholds.ByType {
+			if t != elementType {
+				thresholds.ByType[t] = v
+			}
+		}
+		for _, s := range screens {
+			var labels []LabeledElement
+			for _, l := range s.labels {
+				if l.Type == elementType {
+					labels = append(labels, l)
+				}
+			}
+			labeled += len(labels)
+			var detections []ElementInfo
+			for _, e := range thresholds.filter(s.candidates, s.fromModel) {
+				if e.Type == elementType {
+					detections = append(detections, e)
+				}
+			}
+			point.Detected += len(detections)
+			point.Matched += matchLabels(detections, labels, matchIoU)
+		}
+		if point.Detected > 0 {
+			point.Precision = float64(point.Matched) / float64(point.Detected)
+		}
+		if labeled > 0 {
+			point.Recall = float64(point.Matched) / float64(labeled)
+		}
+		if point.Precision+point.Recall > 0 {
+			point.F1 = 2 * point.Precision * point.Recall / (point.Precision + point.Recall)
+		}
+		return point
+	}
+
+	calibration := &Calibration{Screenshots: len(set), MatchIoU: matchIoU}
+	names := make([]string, 0, len(types))
+	for t := range types {
+		names = append(names, t)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		tc := TypeCalibration{Type: name, Current: measure(name, ed.thresholds.Min(name))}
+		for _, s := range set {
+			for _, l := range s.Elements {
+				if l.Type == name {
+					tc.Labeled++
+				}
+			}
+		}
+		for _, threshold := range calibrationThresholds {
+			point := measure(name, threshold)
+			tc.Curve = append(tc.Curve, point)
+			// The lowest threshold with the best F1
+			if point.F1 > tc.Best.F1 || len(tc.Curve) == 1 {
+				tc.Best = point
+			}
+		}
+		calibration.Types = append(calibration.Types, tc)
+	}
+	return calibration, nil
+}
+
+// matchLabels counts the detections matching a distinct labeled element,
+// most confident first, each taking the unmatched label it overlaps most
+func matchLabels(detections []ElementInfo, labels []LabeledElement, matchIoU float64) int {
+	sorted := append([]ElementInfo(nil), detections...)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].Confidence > sorted[j].Confidence })
+	taken := make([]bool, len(labels))
+	matched := 0
+	for _, d := range sorted {
+		best, bestIoU := -1, matchIoU
+		for i, l := range labels {
+			if taken[i] {
+				continue
+			}
+			box := ElementInfo{Position: Point{X: l.X, Y: l.Y}, Size: Size{Width: l.Width, Height: l.Height}}
+			if overlap := elementIoU(d, box); overlap >= bestIoU {
+				best, bestIoU = i, overlap
+			}
+		}
+		if best >= 0 {
+			taken[best] = true
+			matched++
+		}
+	}
+	return matched
+}
+
+// WriteCalibration prints a calibration as a table, one row per element type
+func WriteCalibration(w io.Writer, c *Calibration) error {
+	if _, err := fmt.Fprintf(w, "%d screenshot(s), detections match labels overlapping them by %.2f or more\n\n", c.Screenshots, c.MatchIoU); err != nil {
+		return err
+	}
+	if _, err := fmt.Fprintf(w, "%-12s %7s %9s %9s %7s %6s   %s\n", "TYPE", "LABELED", "THRESHOLD", "PRECISION", "RECALL", "F1", "BEST THRESHOLD (PRECISION/RECALL/F1)"); err != nil {
+		return err
+	}
+	for _, t := range c.Types {
+		if _, err := fmt.Fprintf(w, "%-12s %7d %9.2f %9s %7s %6s   %.2f (%s/%s/%s)\n", t.Type, t.Labeled, t.Current.Threshold,
+			percent(t.Current.Precision), percent(t.Current.Recall), percent(t.Current.F1),
+			t.Best.Threshold, percent(t.Best.Precision), percent(t.Best.Recall), percent(t.Best.F1)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func percent(v float64) string {
+	return fmt.Sprintf("%d%%", int(math.Round(v*100)))
+}
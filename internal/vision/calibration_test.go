@@ -0,0 +1,102 @@
+package vision
+
+import (
+	"bytes"
+	"image/color"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"panoptic/internal/logger"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestLoadLabeledSet tests reading a labeled set and resolving its paths
+func TestLoadLabeledSet(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "labels.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(`
+- screenshot: shots/home.png
+  elements:
+    - {type: button, x: 10, y: 20, width: 80, height: 30}
+- screenshot: /abs/login.png
+`), 0644))
+	set, err := LoadLabeledSet(path)
+	require.NoError(t, err)
+	require.Len(t, set, 2)
+	assert.Equal(t, filepath.Join(dir, "shots", "home.png"), set[0].Screenshot)
+	assert.Equal(t, []LabeledElement{{Type: "button", X: 10, Y: 20, Width: 80, Height: 30}}, set[0].Elements)
+	assert.Equal(t, "/abs/login.png", set[1].Screenshot)
+
+	require.NoError(t, os.WriteFile(path, []byte("- screenshot: a.png\n  elements: [{type: link, x: 1, y: 1}]\n"), 0644))
+	_, err = LoadLabeledSet(path)
+	assert.ErrorContains(t, err, "elements[0] of "+filepath.Join(dir, "a.png")+" needs a type, width and height")
+	require.NoError(t, os.WriteFile(path, []byte("[]"), 0644))
+	_, err = LoadLabeledSet(path)
+	assert.ErrorContains(t, err, "is empty")
+}
+
+// modelBox is a v8 output column for an element of a 200x100 screenshot
+// letterboxed into 64 pixels (scale 0.32, 16px bars)
+func modelBox(x, y, w, h int, scores ...float32) []float32 {
+	return append([]float32{
+		float32(x+w/2) * 0.32, float32(y+h/2)*0.32 + 16, float32(w) * 0.32, float32(h) * 0.32,
+	}, scores...)
+}
+
+// TestCalibrate tests precision and recall per type against labels, at the
+// detector's thresholds and along the curve
+func TestCalibrate(t *testing.T) {
+	boxes := [][]float32{
+		modelBox(25, 25, 50, 25, 0.9, 0),  // labeled button
+		modelBox(125, 25, 50, 25, 0.3, 0), // labeled button, unsure
+		modelBox(25, 60, 50, 25, 0.6, 0),  // not a button
+		modelBox(125, 60, 50, 25, 0, 0.8), // labeled link
+	}
+	var output []float32
+	for f := 0; f < 6; f++ {
+		for _, b := range boxes {
+			output = append(output, b[f])
+		}
+	}
+	model := writeModelOutput(t, output, "[1,6,4]")
+	screenshot := saveTestImage(t, createTestImage(200, 100, color.White), "home.png")
+	set := []LabeledScreenshot{{Screenshot: screenshot, Elements: []LabeledElement{
+		{Type: "button", X: 25, Y: 25, Width: 50, Height: 25},
+		{Type: "button", X: 125, Y: 25, Width: 50, Height: 25},
+		{Type: "link", X: 125, Y: 60, Width: 50, Height: 25},
+		{Type: "link", X: 100, Y: 0, Width: 20, Height: 10}, // missed
+	}}}
+
+	detector := NewElementDetector(*logger.NewLogger(false))
+	require.NoError(t, detector.UseModel(ModelConfig{Path: model, Runtime: stubRuntime(t, inferenceStub), InputSize: 64, Labels: []string{"button", "link"}}))
+	detector.SetThresholds(Thresholds{ByType: map[string]float64{"button": 0.7}})
+	calibration, err := detector.Calibrate(set, 0)
+	require.NoError(t, err)
+	assert.Equal(t, DefaultMatchIoU, calibration.MatchIoU)
+	require.Len(t, calibration.Types, 2)
+
+	button := calibration.Types[0]
+	assert.Equal(t, "button", button.Type)
+	assert.Equal(t, 2, button.Labeled)
+	assert.Equal(t, CalibrationPoint{Threshold: 0.7, Detected: 1, Matched: 1, Precision: 1, Recall: 0.5, F1: 2.0 / 3}, button.Current)
+	assert.Equal(t, 0.05, button.Best.Threshold, "the lowest threshold finding both")
+	assert.Equal(t, 3, button.Best.Detected)
+	assert.InDelta(t, 0.8, button.Best.F1, 1e-9)
+	require.Len(t, button.Curve, 19)
+	assert.Equal(t, 2, button.Curve[6].Detected, "0.35 drops the unsure button")
+	assert.Zero(t, button.Curve[18].Detected)
+
+	link := calibration.Types[1]
+	assert.Equal(t, CalibrationPoint{Threshold: 0, Detected: 1, Matched: 1, Precision: 1, Recall: 0.5, F1: 2.0 / 3}, link.Current)
+
+	var out bytes.Buffer
+	require.NoError(t, WriteCalibration(&out, calibration))
+	assert.Contains(t, out.String(), "1 screenshot(s)")
+	assert.Contains(t, out.String(), "button             2      0.70      100%     50%    67%   0.05 (67%/100%/80%)")
+
+	_, err = detector.Calibrate([]LabeledScreenshot{{Screenshot: filepath.Join(t.TempDir(), "missing.png")}}, 0.5)
+	assert.ErrorContains(t, err, "failed to load image")
+}
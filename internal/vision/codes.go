@@ -0,0 +1,66 @@
+package vision
+
+import (
+	"image"
+	"math"
+)
+
+// Code is a QR code or barcode decoded from an image
+type Code struct {
+	Format string          `json:"format"` // qr, ean13, upc_a or code128
+	Text   string          `json:"text"`
+	Bounds image.Rectangle `json:"bounds"` // pixels; for barcodes, the scanned rows that crossed it
+}
+
+// DecodeCodes finds and decodes the QR codes (versions 1-10), EAN-13, UPC-A
+// and Code 128 barcodes in an image. Codes are expected upright or rotated in
+// the image plane, dark on a light background, as rendered on screen.
+func DecodeCodes(img image.Image) []Code {
+	b := binarize(img)
+	codes := findQRCodes(b)
+	return append(codes, findBarcodes(b)...)
+}
+
+// DecodeCodesFromFile decodes the codes in an image file
+func (ed *ElementDetector) DecodeCodesFromFile(imagePath string) ([]Code, error) {
+	img, err := ed.loadImage(imagePath)
+	if err != nil {
+		return nil, err
+	}
+	codes := DecodeCodes(img)
+	ed.logger.Debugf("Decoded %d code(s) in %s", len(codes), imagePath)
+	return codes, nil
+}
+
+// findQRCodes decodes every symbol framed by three finder patterns
+func findQRCodes(b *binaryImage) []Code {
+	var codes []Code
+	for _, candidate := range groupFinders(b.findFinderPatterns()) {
+		for _, size := range candidate.sizes() {
+			if size < 21 {
+				continue
+			}
+			text, err := candidate.sample(b, size).decode()
+			if err != nil {
+				continue
+			}
+			codes = append(codes, Code{Format: "qr", Text: text, Bounds: candidate.bounds(size)})
+			break
+		}
+	}
+	return codes
+}
+
+// bounds is the pixel rectangle covering the symbol's four corners
+func (c qrCandidate) bounds(size int) image.Rectangle {
+	span := float64(size - 7)
+	ux, uy := (c.topRight.x-c.topLeft.x)/span, (c.topRight.y-c.topLeft.y)/span
+	vx, vy := (c.bottomLeft.x-c.topLeft.x)/span, (c.bottomLeft.y-c.topLeft.y)/span
+	minX, minY, maxX, maxY := math.Inf(1), math.Inf(1), math.Inf(-1), math.Inf(-1)
+	for _, corner := range [][2]float64{{-3.5, -3.5}, {float64(size) - 3.5, -3.5}, {-3.5, float64(size) - 3.5}, {float64(size) - 3.5, float64(size) - 3.5}} {
+		x := c.topLeft.x + corner[0]*ux + corner[1]*vx
+		y := c.topLeft.y + corner[0]*uy + corner[1]*vy
+		minX, minY, maxX, maxY = math.Min(minX, x), math.Min(minY, y), math.Max(maxX, x), math.Max(maxY, y)
+	}
+	return image.Rect(int(math.Floor(minX)), int(math.Floor(minY)), int(math.Ceil(maxX)), int(math.Ceil(maxY)))
+}
@@ -0,0 +1,41 @@
+package vision
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"testing"
+
+	"panoptic/internal/logger"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestElementDetector_DecodeCodesFromFile tests a screenshot holding a QR
+// code beside a barcode
+func TestElementDetector_DecodeCodesFromFile(t *testing.T) {
+	qr := renderQR(encodeQR(t, "PAIR-4821", 1, "M", 0), 4)
+	bars := renderBars(encodeEAN13("4006381333931"), 2, 60)
+
+	img := createTestImage(480, 200, color.White).(*image.RGBA)
+	draw.Draw(img, qr.Bounds().Add(image.Pt(20, 20)), qr, image.Point{}, draw.Src)
+	draw.Draw(img, bars.Bounds().Add(image.Pt(220, 60)), bars, image.Point{}, draw.Src)
+	path := saveTestImage(t, img, "screen.png")
+
+	detector := NewElementDetector(*logger.NewLogger(false))
+	codes, err := detector.DecodeCodesFromFile(path)
+	require.NoError(t, err)
+	require.Len(t, codes, 2)
+	assert.Equal(t, Code{Format: "qr", Text: "PAIR-4821", Bounds: image.Rect(36, 36, 120, 120)}, codes[0])
+	assert.Equal(t, "ean13", codes[1].Format)
+	assert.Equal(t, "4006381333931", codes[1].Text)
+
+	_, err = detector.DecodeCodesFromFile("/nonexistent/screen.png")
+	assert.Error(t, err)
+}
+
+// TestDecodeCodes_None tests an image without codes
+func TestDecodeCodes_None(t *testing.T) {
+	assert.Empty(t, DecodeCodes(createTestImage(120, 80, color.White)))
+}
@@ -0,0 +1,152 @@
+package vision
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"math"
+	"sort"
+)
+
+// ColorShare is a dominant color of a region and the share of its pixels
+type ColorShare struct {
+	Color color.RGBA `json:"color"`
+	Share float64    `json:"share"`
+}
+
+// Contrast is the text and background color measured in a region and the
+// WCAG contrast ratio between them
+type Contrast struct {
+	Region     image.Rectangle `json:"region"`
+	Foreground color.RGBA      `json:"foreground"`
+	Background color.RGBA      `json:"background"`
+	Ratio      float64         `json:"ratio"`
+}
+
+// minTextShare is the smallest share of a region's pixels taken for text
+const minTextShare = 0.01
+
+// DominantColors returns the n most common colors of a region, the most
+// common first. Colors are grouped 16 levels per channel and reported as the
+// mean of their group, so anti-aliasing and compression noise don't split a
+// color.
+func DominantColors(img image.Image, r image.Rectangle, n int) []ColorShare {
+	r = r.Intersect(img.Bounds())
+	if r.Empty() || n <= 0 {
+		return nil
+	}
+	type bin struct {
+		count      int
+		r, g, b, a int
+	}
+	bins := map[int]*bin{}
+	for y := r.Min.Y; y < r.Max.Y; y++ {
+		for x := r.Min.X; x < r.Max.X; x++ {
+			c := color.RGBAModel.Convert(img.At(x, y)).(color.RGBA)
+			key := int(c.R>>4)<<8 | int(c.G>>4)<<4 | int(c.B>>4)
+			b := bins[key]
+			if b == nil {
+				b = &bin{}
+				bins[key] = b
+			}
+			b.count++
+			b.r, b.g, b.b, b.a = b.r+int(c.R), b.g+int(c.G), b.b+int(c.B), b.a+int(c.A)
+		}
+	}
+
+	keys := make([]int, 0, len(bins))
+	for key := range bins {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if bins[keys[i]].count != bins[keys[j]].count {
+			return bins[keys[i]].count > bins[keys[j]].count
+		}
+		return keys[i] < keys[j]
+	})
+	total := float64(r.Dx() * r.Dy())
+	shares := make([]ColorShare, 0, min(n, len(keys)))
+	for _, key := range keys[:min(n, len(keys))] {
+		b := bins[key]
+		shares = append(shares, ColorShare{
+			Color: color.RGBA{R: uint8(b.r / b.count), G: uint8(b.g / b.count), B: uint8(b.b / b.count), A: uint8(b.a / b.count)},
+			Share: float64(b.count) / total,
+		})
+	}
+	return shares
+}
+
+// RelativeLuminance is the WCAG relative luminance of a color, from 0 for
+// black to 1 for white
+func RelativeLuminance(c color.Color) float64 {
+	rgba := color.RGBAModel.Convert(c).(color.RGBA)
+	linear := func(v uint8) float64 {
+		s := float64(v) / 255
+		if s <= 0.04045 {
+			return s / 12.92
+		}
+		return math.Pow((s+0.055)/1.055, 2.4)
+	}
+	return 0.2126*linear(rgba.R) + 0.7152*linear(rgba.G) + 0.0722*linear(rgba.B)
+}
+
+// ContrastRatio is the WCAG contrast ratio of two colors, from 1 to 21
+func ContrastRatio(a, b color.Color) float64 {
+	la, lb := RelativeLuminance(a), RelativeLuminance(b)
+	if la < lb {
+		la, lb = lb, la
+	}
+	return (la + 0.05) / (lb + 0.05)
+}
+
+// RequiredContrast is the minimum WCAG 2 contrast ratio of text at level AA
+// or AAA; large text is at least 18pt, or 14pt bold
+func RequiredContrast(level string, large bool) (float64, error) {
+	switch {
+	case level == "AA" && large:
+		return 3, nil
+	case level == "AA", level == "AAA" && large:
+		return 4.5, nil
+	case level == "AAA":
+		return 7, nil
+	}
+	return 0, fmt.Errorf("WCAG level must be AA or AAA, got %q", level)
+}
+
+// TextContrast measures the text in a region: the background is its most
+// common color, and the text the color furthest from it in contrast among
+// those covering at least 1% of the region, as anti-aliased glyph edges fall
+// between the two. A region of a single color holds no text to measure and
+// is an error.
+func TextContrast(img image.Image, r image.Rectangle) (Contrast, error) {
+	colors := DominantColors(img, r, 16)
+	if len(colors) == 0 {
+		return Contrast{}, fmt.Errorf("region %v is outside the %v image", r, img.Bounds())
+	}
+	contrast := Contrast{Region: r.Intersect(img.Bounds()), Background: colors[0].Color}
+	for _, c := range colors[1:] {
+		if ratio := ContrastRatio(c.Color, contrast.Background); c.Share >= minTextShare && ratio > contrast.Ratio {
+			contrast.Foreground, contrast.Ratio = c.Color, ratio
+		}
+	}
+	if contrast.Ratio == 0 {
+		return Contrast{}, fmt.Errorf("region %v has a single color; no text to measure", r)
+	}
+	return contrast, nil
+}
+
+// TextContrastFromFile measures the text contrast of regions of an image file
+func (ed *ElementDetector) TextContrastFromFile(imagePath string, regions []image.Rectangle) ([]Contrast, error) {
+	img, err := ed.loadImage(imagePath)
+	if err != nil {
+		return nil, err
+	}
+	contrasts := make([]Contrast, len(regions))
+	for i, r := range regions {
+		if contrasts[i], err = TextContrast(img, r); err != nil {
+			return nil, err
+		}
+	}
+	ed.logger.Debugf("Measured contrast of %d region(s) in %s", len(regions), imagePath)
+	return contrasts, nil
+}
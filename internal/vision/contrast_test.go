@@ -0,0 +1,109 @@
+package vision
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"testing"
+
+	"panoptic/internal/logger"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// drawText fills a background with stripes of text color, blending the
+// stripe edges like anti-aliased glyphs
+func drawText(img *image.RGBA, r image.Rectangle, text, bg color.RGBA) {
+	draw.Draw(img, r, &image.Uniform{bg}, image.Point{}, draw.Src)
+	mid := color.RGBA{R: uint8((int(text.R) + int(bg.R)) / 2), G: uint8((int(text.G) + int(bg.G)) / 2), B: uint8((int(text.B) + int(bg.B)) / 2), A: 255}
+	for x := r.Min.X + 2; x+3 < r.Max.X; x += 6 {
+		for y := r.Min.Y + 4; y < r.Max.Y-4; y++ {
+			img.SetRGBA(x, y, mid)
+			img.SetRGBA(x+1, y, text)
+			img.SetRGBA(x+2, y, mid)
+		}
+	}
+}
+
+// TestContrastRatio tests values from the WCAG definition
+func TestContrastRatio(t *testing.T) {
+	assert.InDelta(t, 21, ContrastRatio(color.Black, color.White), 0.001)
+	assert.InDelta(t, 1, ContrastRatio(color.White, color.White), 0.001)
+	assert.InDelta(t, 4.48, ContrastRatio(color.RGBA{0x77, 0x77, 0x77, 255}, color.White), 0.01, "#777 on white just fails AA")
+	assert.InDelta(t, 4.54, ContrastRatio(color.RGBA{0x76, 0x76, 0x76, 255}, color.White), 0.01)
+	assert.Equal(t, ContrastRatio(color.White, color.Black), ContrastRatio(color.Black, color.White))
+	assert.InDelta(t, 0.2126, RelativeLuminance(color.RGBA{255, 0, 0, 255}), 0.0001)
+}
+
+// TestRequiredContrast tests the WCAG 2 minimums
+func TestRequiredContrast(t *testing.T) {
+	for _, c := range []struct {
+		level string
+		large bool
+		want  float64
+	}{{"AA", false, 4.5}, {"AA", true, 3}, {"AAA", false, 7}, {"AAA", true, 4.5}} {
+		got, err := RequiredContrast(c.level, c.large)
+		require.NoError(t, err)
+		assert.Equal(t, c.want, got, "%s large=%v", c.level, c.large)
+	}
+	_, err := RequiredContrast("A", false)
+	assert.Error(t, err)
+}
+
+// TestDominantColors tests shares and the order of colors
+func TestDominantColors(t *testing.T) {
+	img := image.NewRGBA(image.Rect(0, 0, 10, 10))
+	draw.Draw(img, img.Bounds(), &image.Uniform{color.RGBA{0, 0, 255, 255}}, image.Point{}, draw.Src)
+	draw.Draw(img, image.Rect(0, 0, 10, 3), &image.Uniform{color.RGBA{250, 250, 250, 255}}, image.Point{}, draw.Src)
+	img.SetRGBA(9, 9, color.RGBA{252, 252, 252, 255})
+
+	colors := DominantColors(img, img.Bounds(), 5)
+	require.Len(t, colors, 2, "near-identical colors are grouped")
+	assert.Equal(t, color.RGBA{0, 0, 255, 255}, colors[0].Color)
+	assert.InDelta(t, 0.69, colors[0].Share, 0.001)
+	assert.Equal(t, color.RGBA{250, 250, 250, 255}, colors[1].Color, "the mean of the group")
+	assert.InDelta(t, 0.31, colors[1].Share, 0.001)
+
+	assert.Empty(t, DominantColors(img, image.Rect(20, 20, 30, 30), 5))
+}
+
+// TestTextContrast tests measuring anti-aliased text on backgrounds
+func TestTextContrast(t *testing.T) {
+	img := image.NewRGBA(image.Rect(0, 0, 200, 40))
+	gray := color.RGBA{0x77, 0x77, 0x77, 255}
+	drawText(img, image.Rect(0, 0, 100, 40), gray, color.RGBA{255, 255, 255, 255})
+	drawText(img, image.Rect(100, 0, 200, 40), color.RGBA{255, 255, 255, 255}, color.RGBA{0x1a, 0x73, 0xe8, 255})
+
+	c, err := TextContrast(img, image.Rect(0, 0, 100, 40))
+	require.NoError(t, err)
+	assert.Equal(t, gray, c.Foreground)
+	assert.Equal(t, color.RGBA{255, 255, 255, 255}, c.Background)
+	assert.InDelta(t, 4.48, c.Ratio, 0.01)
+
+	c, err = TextContrast(img, image.Rect(100, 0, 200, 40))
+	require.NoError(t, err)
+	assert.Equal(t, color.RGBA{255, 255, 255, 255}, c.Foreground)
+	assert.InDelta(t, 4.5, c.Ratio, 0.1)
+
+	_, err = TextContrast(img, image.Rect(0, 0, 100, 3))
+	assert.ErrorContains(t, err, "single color")
+	_, err = TextContrast(img, image.Rect(300, 0, 400, 40))
+	assert.ErrorContains(t, err, "outside")
+}
+
+// TestElementDetector_TextContrastFromFile tests measuring a saved screenshot
+func TestElementDetector_TextContrastFromFile(t *testing.T) {
+	img := image.NewRGBA(image.Rect(0, 0, 100, 40))
+	drawText(img, img.Bounds(), color.RGBA{0, 0, 0, 255}, color.RGBA{255, 255, 255, 255})
+	path := saveTestImage(t, img, "text.png")
+
+	detector := NewElementDetector(*logger.NewLogger(false))
+	contrasts, err := detector.TextContrastFromFile(path, []image.Rectangle{img.Bounds()})
+	require.NoError(t, err)
+	require.Len(t, contrasts, 1)
+	assert.InDelta(t, 21, contrasts[0].Ratio, 0.001)
+
+	_, err = detector.TextContrastFromFile("/nonexistent/text.png", nil)
+	assert.Error(t, err)
+}
@@ -15,6 +15,11 @@ import (
 type ElementDetector struct {
 	logger  logger.Logger
 	enabled bool
+	workers int // goroutines processing image tiles; 0 for one per CPU
+
+	maxDimension int    // longer side DetectElements downscales to; 0 for full resolution
+	model        *Model // replaces the heuristic detectors when set
+	thresholds   Thresholds
 }
 
 // NewElementDetector creates a new visual element detector
@@ -71,32 +76,33 @@ func (ed *ElementDetector) DetectElements(imagePath string) ([]ElementInfo, erro
 		return nil, fmt.Errorf("failed to load image: %w", err)
 	}
 
-	// Convert to grayscale for processing
-	grayImg := ed.convertToGrayscale(img)
-
-	// Detect different types of elements
-	var elements []ElementInfo
-
-	// Detect buttons
-	buttons := ed.detectButtons(grayImg, img)
-	elements = append(elements, buttons...)
-
-	// Detect text fields
-	textFields := ed.detectTextFields(grayImg, img)
-	elements = append(elements, textFields...)
-
-	// Detect images
-	images := ed.detectImages(grayImg, img)
-	elements = append(elements, images...)
-
-	// Detect links
-	links := ed.detectLinks(grayImg, img)
-	elements = append(elements, links...)
+	elements, fromModel := ed.detect(img, imagePath)
+	elements = ed.thresholds.filter(elements, fromModel)
 
 	ed.logger.Infof("Detected %d visual elements", len(elements))
 	return elements, nil
 }
 
+// detect finds elements with the model, or with the heuristics when there is
+// none or it fails, before thresholds apply
+func (ed *ElementDetector) detect(img image.Image, imagePath string) (elements []ElementInfo, fromModel bool) {
+	if ed.model != nil {
+		elements, err := ed.model.Detect(img)
+		if err == nil {
+			ed.logger.Debugf("The ONNX model found %d candidate elements", len(elements))
+			return elements, true
+		}
+		ed.logger.Warnf("Falling back to heuristic element detection: %v", err)
+	}
+
+	// Detect buttons, text fields, images and links tile by tile
+	scaled, factor := downscale(img, ed.maxDimension)
+	if factor != 1 {
+		ed.logger.Debugf("Downscaled %s by %.2f to %dx%d", imagePath, factor, scaled.Bounds().Dx(), scaled.Bounds().Dy())
+	}
+	return ed.detectScaled(scaled, factor), false
+}
+
 // FindElementByType finds elements of a specific type
 func (ed *ElementDetector) FindElementByType(elements []ElementInfo, elementType string) []ElementInfo {
 	var result []ElementInfo
@@ -130,172 +136,97 @@ func (ed *ElementDetector) FindElementByPosition(elements []ElementInfo, x, y in
 	return result
 }
 
-// loadImage loads an image from file
-func (ed *ElementDetector) loadImage(imagePath string) (image.Image, error) {
-	file, err := os.Open(imagePath)
-	if err != nil {
-		return nil, err
-	}
-	defer file.Close()
-
-	img, _, err := image.Decode(file)
-	if err != nil {
-		return nil, err
-	}
-
-	return img, nil
-}
-
 // convertToGrayscale converts image to grayscale
 func (ed *ElementDetector) convertToGrayscale(img image.Image) *image.Gray {
-	bounds := img.Bounds()
-	gray := image.NewGray(bounds)
-
-	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
-		for x := bounds.Min.X; x < bounds.Max.X; x++ {
-			gray.Set(x, y, img.At(x, y))
-		}
-	}
-
-	return gray
+	return ed.grayscale(img, make([]uint8, img.Bounds().Dx()*img.Bounds().Dy()))
 }
 
 // detectButtons finds button-like elements
 func (ed *ElementDetector) detectButtons(grayImg *image.Gray, originalImg image.Image) []ElementInfo {
-	var buttons []ElementInfo
-	
-	bounds := grayImg.Bounds()
-	width := bounds.Dx()
-	height := bounds.Dy()
+	return ed.scan(ed.buttonScan(), grayImg, originalImg, 1)
+}
 
+// buttonScan probes every 10 pixels for button-like elements
+func (ed *ElementDetector) buttonScan() gridScan {
 	// Simple button detection using edge detection and shape analysis
-	for y := 20; y < height-20; y += 10 {
-		for x := 20; x < width-20; x += 10 {
-			if ed.isButtonLike(grayImg, x, y) {
-				button := ElementInfo{
-					Type:       "button",
-					Position:   Point{X: x, Y: y},
-					Size:       Size{Width: 80, Height: 30}, // Estimated size
-					Confidence: 0.75,
-					Attributes: map[string]string{
-						"clickable": "true",
-					},
-					Color: ed.convertToRGBA(originalImg.At(x, y)),
-				}
-				
-				// Generate selector
-				button.Selector = fmt.Sprintf("button[%d,%d]", x, y)
-				
-				buttons = append(buttons, button)
-			}
+	return gridScan{margin: 20, step: 10, match: ed.isButtonLike, element: func(x, y int) ElementInfo {
+		return ElementInfo{
+			Type:       "button",
+			Selector:   fmt.Sprintf("button[%d,%d]", x, y),
+			Position:   Point{X: x, Y: y},
+			Size:       Size{Width: 80, Height: 30}, // Estimated size
+			Confidence: 0.75,
+			Attributes: map[string]string{
+				"clickable": "true",
+			},
 		}
-	}
-
-	return buttons
+	}}
 }
 
 // detectTextFields finds input field-like elements
 func (ed *ElementDetector) detectTextFields(grayImg *image.Gray, originalImg image.Image) []ElementInfo {
-	var textFields []ElementInfo
-	
-	bounds := grayImg.Bounds()
-	width := bounds.Dx()
-	height := bounds.Dy()
-
-	// Simple text field detection
-	for y := 20; y < height-20; y += 15 {
-		for x := 20; x < width-20; x += 15 {
-			if ed.isTextFieldLike(grayImg, x, y) {
-				textField := ElementInfo{
-					Type:       "textfield",
-					Position:   Point{X: x, Y: y},
-					Size:       Size{Width: 120, Height: 25}, // Estimated size
-					Confidence: 0.80,
-					Attributes: map[string]string{
-						"input":    "true",
-						"type":     "text",
-					},
-					Color: ed.convertToRGBA(originalImg.At(x, y)),
-				}
-				
-				// Generate selector
-				textField.Selector = fmt.Sprintf("input[type=text][%d,%d]", x, y)
-				
-				textFields = append(textFields, textField)
-			}
-		}
-	}
+	return ed.scan(ed.textFieldScan(), grayImg, originalImg, 1)
+}
 
-	return textFields
+// textFieldScan probes every 15 pixels for input field-like elements
+func (ed *ElementDetector) textFieldScan() gridScan {
+	return gridScan{margin: 20, step: 15, match: ed.isTextFieldLike, element: func(x, y int) ElementInfo {
+		return ElementInfo{
+			Type:       "textfield",
+			Selector:   fmt.Sprintf("input[type=text][%d,%d]", x, y),
+			Position:   Point{X: x, Y: y},
+			Size:       Size{Width: 120, Height: 25}, // Estimated size
+			Confidence: 0.80,
+			Attributes: map[string]string{
+				"input": "true",
+				"type":  "text",
+			},
+		}
+	}}
 }
 
 // detectImages finds image elements
 func (ed *ElementDetector) detectImages(grayImg *image.Gray, originalImg image.Image) []ElementInfo {
-	var images []ElementInfo
-	
-	bounds := grayImg.Bounds()
-	width := bounds.Dx()
-	height := bounds.Dy()
-
-	// Simple image detection
-	for y := 10; y < height-10; y += 20 {
-		for x := 10; x < width-10; x += 20 {
-			if ed.isImageLike(grayImg, x, y) {
-				img := ElementInfo{
-					Type:       "image",
-					Position:   Point{X: x, Y: y},
-					Size:       Size{Width: 100, Height: 100}, // Estimated size
-					Confidence: 0.70,
-					Attributes: map[string]string{
-						"src": fmt.Sprintf("detected_image_%d_%d", x, y),
-					},
-					Color: ed.convertToRGBA(originalImg.At(x, y)),
-				}
-				
-				// Generate selector
-				img.Selector = fmt.Sprintf("img[%d,%d]", x, y)
-				
-				images = append(images, img)
-			}
-		}
-	}
+	return ed.scan(ed.imageScan(), grayImg, originalImg, 1)
+}
 
-	return images
+// imageScan probes every 20 pixels for image elements
+func (ed *ElementDetector) imageScan() gridScan {
+	return gridScan{margin: 10, step: 20, match: ed.isImageLike, element: func(x, y int) ElementInfo {
+		return ElementInfo{
+			Type:       "image",
+			Selector:   fmt.Sprintf("img[%d,%d]", x, y),
+			Position:   Point{X: x, Y: y},
+			Size:       Size{Width: 100, Height: 100}, // Estimated size
+			Confidence: 0.70,
+			Attributes: map[string]string{
+				"src": fmt.Sprintf("detected_image_%d_%d", x, y),
+			},
+		}
+	}}
 }
 
 // detectLinks finds link-like elements
 func (ed *ElementDetector) detectLinks(grayImg *image.Gray, originalImg image.Image) []ElementInfo {
-	var links []ElementInfo
-	
-	bounds := grayImg.Bounds()
-	width := bounds.Dx()
-	height := bounds.Dy()
+	return ed.scan(ed.linkScan(), grayImg, originalImg, 1)
+}
 
+// linkScan probes every 12 pixels for link-like elements
+func (ed *ElementDetector) linkScan() gridScan {
 	// Simple link detection (text-like elements that are clickable)
-	for y := 15; y < height-15; y += 12 {
-		for x := 15; x < width-15; x += 12 {
-			if ed.isLinkLike(grayImg, x, y) {
-				link := ElementInfo{
-					Type:       "link",
-					Position:   Point{X: x, Y: y},
-					Size:       Size{Width: 60, Height: 15}, // Estimated size
-					Confidence: 0.65,
-					Attributes: map[string]string{
-						"href":     "#",
-						"clickable": "true",
-					},
-					Color: ed.convertToRGBA(originalImg.At(x, y)),
-				}
-				
-				// Generate selector
-				link.Selector = fmt.Sprintf("a[%d,%d]", x, y)
-				
-				links = append(links, link)
-			}
+	return gridScan{margin: 15, step: 12, match: ed.isLinkLike, element: func(x, y int) ElementInfo {
+		return ElementInfo{
+			Type:       "link",
+			Selector:   fmt.Sprintf("a[%d,%d]", x, y),
+			Position:   Point{X: x, Y: y},
+			Size:       Size{Width: 60, Height: 15}, // Estimated size
+			Confidence: 0.65,
+			Attributes: map[string]string{
+				"href":      "#",
+				"clickable": "true",
+			},
 		}
-	}
-
-	return links
+	}}
 }
 
 // isButtonLike determines if a region looks like a button
@@ -349,43 +280,46 @@ func (ed *ElementDetector) isLinkLike(img *image.Gray, x, y int) bool {
 
 // calculateColorVariance calculates color variance in a region
 func (ed *ElementDetector) calculateColorVariance(img *image.Gray, x, y, width, height int) float64 {
-	if x+width >= img.Bounds().Dx() || y+height >= img.Bounds().Dy() {
+	if x+width >= img.Bounds().Dx() || y+height >= img.Bounds().Dy() || width <= 0 || height <= 0 {
 		return 0
 	}
 
-	var sum float64
-	var count int
-
+	// Integer sums of bytes are exact, like the float sum they replace
+	sum := 0
 	for dy := 0; dy < height; dy++ {
-		for dx := 0; dx < width; dx++ {
-			if x+dx < img.Bounds().Dx() && y+dy < img.Bounds().Dy() {
-				gray := img.GrayAt(x+dx, y+dy).Y
-				sum += float64(gray)
-				count++
-			}
+		for _, gray := range grayRow(img, x, y+dy, width) {
+			sum += int(gray)
 		}
 	}
+	count := width * height
 
-	if count == 0 {
-		return 0
-	}
-
-	mean := sum / float64(count)
+	mean := float64(sum) / float64(count)
 	var variance float64
 
 	for dy := 0; dy < height; dy++ {
-		for dx := 0; dx < width; dx++ {
-			if x+dx < img.Bounds().Dx() && y+dy < img.Bounds().Dy() {
-				gray := img.GrayAt(x+dx, y+dy).Y
-				diff := float64(gray) - mean
-				variance += diff * diff
-			}
+		for _, gray := range grayRow(img, x, y+dy, width) {
+			diff := float64(gray) - mean
+			variance += diff * diff
 		}
 	}
 
 	return variance / float64(count)
 }
 
+// grayRow returns width pixels of row y from x, reading pixels outside the
+// image as black like GrayAt
+func grayRow(img *image.Gray, x, y, width int) []uint8 {
+	if (image.Rect(x, y, x+width, y+1)).In(img.Rect) {
+		offset := img.PixOffset(x, y)
+		return img.Pix[offset : offset+width]
+	}
+	row := make([]uint8, width)
+	for dx := range row {
+		row[dx] = img.GrayAt(x+dx, y).Y
+	}
+	return row
+}
+
 // isPointInRectangle checks if a point is within a rectangle
 func (ed *ElementDetector) isPointInRectangle(point Point, rect Rectangle, tolerance int) bool {
 	return point.X >= rect.TopLeft.X-tolerance &&
@@ -0,0 +1,91 @@
+package vision
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+
+	xdraw "golang.org/x/image/draw"
+)
+
+// DefaultDiffThreshold is the color distance, from 0 to 1, below which two
+// pixels count as the same, absorbing antialiasing and color profiles
+const DefaultDiffThreshold = 0.1
+
+// maxYIQDelta is the squared YIQ distance between black and white
+const maxYIQDelta = 35215.0
+
+// ImageDiff is how an image differs from the one it's expected to look
+// like, pixel by pixel after scaling it to the expected size
+type ImageDiff struct {
+	Pixels   int         // pixels differing beyond the threshold
+	Ratio    float64     // of the expected image's pixels
+	Distance int         // between the images' perceptual hashes
+	Scaled   bool        // the actual image had another size
+	Image    *image.RGBA // the expected image faded, differing pixels in red
+}
+
+// CompareImages compares actual to expected by perceived color: pixels are
+// blended onto white, so transparent design renders compare as displayed,
+// and differ when their YIQ distance exceeds threshold, from 0 to 1
+func CompareImages(expected, actual image.Image, threshold float64) ImageDiff {
+	bounds := expected.Bounds()
+	diff := ImageDiff{Distance: PerceptualHash(expected).Distance(PerceptualHash(actual)), Image: image.NewRGBA(bounds)}
+	if bounds.Empty() {
+		return diff
+	}
+	if actual.Bounds().Size() != bounds.Size() {
+		scaled := image.NewRGBA(bounds)
+		xdraw.ApproxBiLinear.Scale(scaled, bounds, actual, actual.Bounds(), xdraw.Src, nil)
+		actual, diff.Scaled = scaled, true
+	}
+	offset := actual.Bounds().Min.Sub(bounds.Min)
+	limit := maxYIQDelta * threshold * threshold
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			e := onWhite(expected.At(x, y))
+			if yiqDelta(e, onWhite(actual.At(x+offset.X, y+offset.Y))) > limit {
+				diff.Pixels++
+				diff.Image.SetRGBA(x, y, color.RGBA{R: 255, A: 255})
+				continue
+			}
+			gray := uint8(255 - (255-luma(e))/4)
+			diff.Image.SetRGBA(x, y, color.RGBA{R: gray, G: gray, B: gray, A: 255})
+		}
+	}
+	diff.Ratio = float64(diff.Pixels) / float64(bounds.Dx()*bounds.Dy())
+	return diff
+}
+
+// onWhite blends a color onto a white background
+func onWhite(c color.Color) [3]float64 {
+	r, g, b, a := c.RGBA()
+	white := float64(0xffff - a)
+	return [3]float64{
+		(float64(r) + white) / 257,
+		(float64(g) + white) / 257,
+		(float64(b) + white) / 257,
+	}
+}
+
+func luma(c [3]float64) uint8 {
+	return uint8(0.299*c[0] + 0.587*c[1] + 0.114*c[2])
+}
+
+// yiqDelta is the squared perceived distance of two colors, weighting the
+// YIQ channels by how much each is noticed
+func yiqDelta(a, b [3]float64) float64 {
+	dr, dg, db := a[0]-b[0], a[1]-b[1], a[2]-b[2]
+	y := 0.29889531*dr + 0.58662247*dg + 0.11448223*db
+	i := 0.59597799*dr - 0.27417610*dg - 0.32180189*db
+	q := 0.21147017*dr - 0.52261711*dg + 0.31114694*db
+	return 0.5053*y*y + 0.299*i*i + 0.1957*q*q
+}
+
+// Crop copies a region of an image
+func Crop(img image.Image, region image.Rectangle) *image.RGBA {
+	region = region.Intersect(img.Bounds())
+	out := image.NewRGBA(image.Rect(0, 0, region.Dx(), region.Dy()))
+	draw.Draw(out, out.Bounds(), img, region.Min, draw.Src)
+	return out
+}
@@ -0,0 +1,47 @@
+package vision
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func filled(w, h int, c color.Color) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	draw.Draw(img, img.Bounds(), &image.Uniform{c}, image.Point{}, draw.Src)
+	return img
+}
+
+func TestCompareImages(t *testing.T) {
+	button := filled(40, 20, color.RGBA{R: 0x1a, G: 0x73, B: 0xe8, A: 255})
+	same := CompareImages(button, filled(40, 20, color.RGBA{R: 0x1b, G: 0x74, B: 0xe8, A: 255}), DefaultDiffThreshold)
+	assert.Zero(t, same.Pixels, "near colors are the same")
+	assert.False(t, same.Scaled)
+
+	drifted := filled(40, 20, color.RGBA{R: 0x1a, G: 0x73, B: 0xe8, A: 255})
+	draw.Draw(drifted, image.Rect(0, 0, 10, 20), &image.Uniform{color.RGBA{R: 0xd9, G: 0x30, B: 0x25, A: 255}}, image.Point{}, draw.Src)
+	diff := CompareImages(button, drifted, DefaultDiffThreshold)
+	assert.Equal(t, 200, diff.Pixels)
+	assert.InDelta(t, 0.25, diff.Ratio, 1e-9)
+	assert.Equal(t, color.RGBA{R: 255, A: 255}, diff.Image.RGBAAt(5, 5))
+	assert.NotEqual(t, color.RGBA{R: 255, A: 255}, diff.Image.RGBAAt(30, 5))
+
+	retina := CompareImages(button, filled(80, 40, color.RGBA{R: 0x1a, G: 0x73, B: 0xe8, A: 255}), DefaultDiffThreshold)
+	assert.True(t, retina.Scaled)
+	assert.Zero(t, retina.Pixels, "a 2x capture compares at the design's size")
+
+	transparent := CompareImages(filled(10, 10, color.RGBA{}), filled(10, 10, color.White), DefaultDiffThreshold)
+	assert.Zero(t, transparent.Pixels, "transparent design pixels show the white page")
+}
+
+func TestCrop(t *testing.T) {
+	img := filled(100, 50, color.White)
+	draw.Draw(img, image.Rect(20, 10, 30, 20), &image.Uniform{color.Black}, image.Point{}, draw.Src)
+	crop := Crop(img, image.Rect(20, 10, 30, 20))
+	assert.Equal(t, image.Rect(0, 0, 10, 10), crop.Bounds())
+	assert.Equal(t, color.RGBA{A: 255}, crop.RGBAAt(0, 0))
+	assert.Equal(t, image.Rect(0, 0, 10, 10), Crop(img, image.Rect(90, 40, 120, 60)).Bounds(), "clipped to the image")
+}
@@ -0,0 +1,299 @@
+package vision
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"math"
+	"sort"
+)
+
+// Box is a named element's bounding box in screenshot pixels
+type Box struct {
+	Name   string          `json:"name"`
+	Bounds image.Rectangle `json:"bounds"`
+}
+
+// LayoutShift is an element that moved or resized between two screenshots
+type LayoutShift struct {
+	Name     string          `json:"name"`
+	Before   image.Rectangle `json:"before"`
+	After    image.Rectangle `json:"after"`
+	DX       int             `json:"dx"`
+	DY       int             `json:"dy"`
+	Severity string          `json:"severity"` // high, medium or low
+}
+
+// Overlap is two elements whose boxes partly cover each other
+type Overlap struct {
+	A        string          `json:"a"`
+	B        string          `json:"b"`
+	Area     image.Rectangle `json:"area"`
+	Share    float64         `json:"share"` // of the smaller box that is covered
+	Severity string          `json:"severity"`
+}
+
+// Detection tuning: ink differs from the background by more than
+// inkThreshold in a channel, and ink cells up to boxGap cells apart join one
+// box, so the glyphs of a line of text become a single element
+const (
+	inkThreshold  = 32
+	boxCell       = 4
+	boxGap        = 2
+	minBoxSide    = 4
+	maxThumbDiff  = 12.0
+	thumbnailSide = 8
+	shiftHighPx   = 100
+	shiftMediumPx = 25
+	overlapHigh   = 0.5
+	overlapMedium = 0.1
+)
+
+// DetectBoxes finds the elements of a screenshot as the bounding boxes of
+// connected areas that differ from its background color. Nested content
+// merges into its container when the container has a fill or border.
+func DetectBoxes(img image.Image) []Box {
+	bounds := img.Bounds()
+	colors := DominantColors(img, bounds, 1)
+	if len(colors) == 0 {
+		return nil
+	}
+	bg := colors[0].Color
+
+	cols, rows := (bounds.Dx()+boxCell-1)/boxCell, (bounds.Dy()+boxCell-1)/boxCell
+	cells := make([]image.Rectangle, cols*rows) // ink bounds of each cell
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			if !isInk(img.At(x, y), bg) {
+				continue
+			}
+			i := (y-bounds.Min.Y)/boxCell*cols + (x-bounds.Min.X)/boxCell
+			cells[i] = cells[i].Union(image.Rect(x, y, x+1, y+1))
+		}
+	}
+
+	var boxes []Box
+	seen := make([]bool, len(cells))
+	for start := range cells {
+		if seen[start] || cells[start].Empty() {
+			continue
+		}
+		seen[start] = true
+		box := cells[start]
+		queue := []int{start}
+		for len(queue) > 0 {
+			i := queue[0]
+			queue = queue[1:]
+			cx, cy := i%cols, i/cols
+			for dy := -boxGap; dy <= boxGap; dy++ {
+				for dx := -boxGap; dx <= boxGap; dx++ {
+					nx, ny := cx+dx, cy+dy
+					if nx < 0 || ny < 0 || nx >= cols || ny >= rows {
+						continue
+					}
+					n := ny*cols + nx
+					if !seen[n] && !cells[n].Empty() {
+						seen[n] = true
+						box = box.Union(cells[n])
+						queue = append(queue, n)
+					}
+				}
+			}
+		}
+		if box.Dx() >= minBoxSide && box.Dy() >= minBoxSide {
+			boxes = append(boxes, Box{Name: fmt.Sprintf("element at %d,%d (%dx%d)", box.Min.X, box.Min.Y, box.Dx(), box.Dy()), Bounds: box})
+		}
+	}
+	sort.Slice(boxes, func(i, j int) bool {
+		a, b := boxes[i].Bounds.Min, boxes[j].Bounds.Min
+		return a.Y < b.Y || a.Y == b.Y && a.X < b.X
+	})
+	return boxes
+}
+
+func isInk(c color.Color, bg color.RGBA) bool {
+	p := color.RGBAModel.Convert(c).(color.RGBA)
+	diff := func(a, b uint8) int {
+		if a > b {
+			return int(a - b)
+		}
+		return int(b - a)
+	}
+	return max(diff(p.R, bg.R), diff(p.G, bg.G), diff(p.B, bg.B)) > inkThreshold
+}
+
+// CompareLayouts reports the named boxes that moved or resized by more than
+// tolerance pixels; boxes missing from either side are skipped
+func CompareLayouts(before, after []Box, tolerance int) []LayoutShift {
+	index := make(map[string]image.Rectangle, len(after))
+	for _, b := range after {
+		index[b.Name] = b.Bounds
+	}
+	var shifts []LayoutShift
+	for _, b := range before {
+		a, ok := index[b.Name]
+		if !ok {
+			continue
+		}
+		if shift, moved := layoutShift(b.Name, b.Bounds, a, tolerance); moved {
+			shifts = append(shifts, shift)
+		}
+	}
+	return shifts
+}
+
+// ShiftsBetween detects the elements of two screenshots and reports those
+// that moved. Elements are matched by size and appearance, first with an
+// element at the same place, then with the nearest one; elements without a
+// match changed content rather than position and aren't reported.
+func ShiftsBetween(before, after image.Image, tolerance int) []LayoutShift {
+	type element struct {
+		box   Box
+		thumb []float64
+	}
+	detect := func(img image.Image) []element {
+		var elements []element
+		for _, b := range DetectBoxes(img) {
+			elements = append(elements, element{box: b, thumb: thumbnail(img, b.Bounds)})
+		}
+		return elements
+	}
+	old, current := detect(before), detect(after)
+	similar := func(a, b element) bool {
+		return abs(a.box.Bounds.Dx()-b.box.Bounds.Dx()) <= tolerance &&
+			abs(a.box.Bounds.Dy()-b.box.Bounds.Dy()) <= tolerance &&
+			thumbDiff(a.thumb, b.thumb) <= maxThumbDiff
+	}
+
+	matched := make([]bool, len(current))
+	pending := make([]int, 0, len(old))
+	for i, o := range old {
+		found := false
+		for j, c := range current {
+			if !matched[j] && similar(o, c) {
+				if _, moved := layoutShift("", o.box.Bounds, c.box.Bounds, tolerance); !moved {
+					matched[j], found = true, true
+					break
+				}
+			}
+		}
+		if !found {
+			pending = append(pending, i)
+		}
+	}
+
+	var shifts []LayoutShift
+	for _, i := range pending {
+		o := old[i]
+		best, bestDistance := -1, math.Inf(1)
+		for j, c := range current {
+			if matched[j] || !similar(o, c) {
+				continue
+			}
+			dx, dy := float64(c.box.Bounds.Min.X-o.box.Bounds.Min.X), float64(c.box.Bounds.Min.Y-o.box.Bounds.Min.Y)
+			if d := math.Hypot(dx, dy); d < bestDistance {
+				best, bestDistance = j, d
+			}
+		}
+		if best < 0 {
+			continue
+		}
+		matched[best] = true
+		shift, _ := layoutShift(o.box.Name, o.box.Bounds, current[best].box.Bounds, tolerance)
+		shifts = append(shifts, shift)
+	}
+	return shifts
+}
+
+// FindOverlaps reports pairs of boxes that partly cover each other by more
+// than tolerance pixels each way. A box inside another is nesting, not an
+// overlap.
+func FindOverlaps(boxes []Box, tolerance int) []Overlap {
+	var overlaps []Overlap
+	for i := range boxes {
+		for j := i + 1; j < len(boxes); j++ {
+			a, b := boxes[i].Bounds, boxes[j].Bounds
+			area := a.Intersect(b)
+			if area.Dx() <= tolerance || area.Dy() <= tolerance || a.In(b) || b.In(a) {
+				continue
+			}
+			smaller := min(a.Dx()*a.Dy(), b.Dx()*b.Dy())
+			share := float64(area.Dx()*area.Dy()) / float64(smaller)
+			severity := "low"
+			switch {
+			case share >= overlapHigh:
+				severity = "high"
+			case share >= overlapMedium:
+				severity = "medium"
+			}
+			overlaps = append(overlaps, Overlap{A: boxes[i].Name, B: boxes[j].Name, Area: area, Share: math.Round(share*1000) / 1000, Severity: severity})
+		}
+	}
+	return overlaps
+}
+
+// layoutShift describes the change from before to after, reporting whether
+// a corner moved by more than tolerance pixels
+func layoutShift(name string, before, after image.Rectangle, tolerance int) (LayoutShift, bool) {
+	shift := LayoutShift{Name: name, Before: before, After: after, DX: after.Min.X - before.Min.X, DY: after.Min.Y - before.Min.Y}
+	moved := max(abs(shift.DX), abs(shift.DY), abs(after.Max.X-before.Max.X), abs(after.Max.Y-before.Max.Y))
+	switch {
+	case moved >= shiftHighPx:
+		shift.Severity = "high"
+	case moved >= shiftMediumPx:
+		shift.Severity = "medium"
+	default:
+		shift.Severity = "low"
+	}
+	return shift, moved > tolerance
+}
+
+// thumbnail is the mean gray level of an 8x8 grid over a box
+func thumbnail(img image.Image, r image.Rectangle) []float64 {
+	thumb := make([]float64, thumbnailSide*thumbnailSide)
+	counts := make([]int, len(thumb))
+	for y := r.Min.Y; y < r.Max.Y; y++ {
+		for x := r.Min.X; x < r.Max.X; x++ {
+			i := (y-r.Min.Y)*thumbnailSide/r.Dy()*thumbnailSide + (x-r.Min.X)*thumbnailSide/r.Dx()
+			thumb[i] += float64(color.GrayModel.Convert(img.At(x, y)).(color.Gray).Y)
+			counts[i]++
+		}
+	}
+	for i := range thumb {
+		if counts[i] > 0 {
+			thumb[i] /= float64(counts[i])
+		}
+	}
+	return thumb
+}
+
+func thumbDiff(a, b []float64) float64 {
+	var sum float64
+	for i := range a {
+		sum += math.Abs(a[i] - b[i])
+	}
+	return sum / float64(len(a))
+}
+
+// ShiftsBetweenFiles detects the elements that moved between two screenshot
+// files
+func (ed *ElementDetector) ShiftsBetweenFiles(beforePath, afterPath string, tolerance int) ([]LayoutShift, error) {
+	before, err := ed.loadImage(beforePath)
+	if err != nil {
+		return nil, err
+	}
+	after, err := ed.loadImage(afterPath)
+	if err != nil {
+		return nil, err
+	}
+	shifts := ShiftsBetween(before, after, tolerance)
+	ed.logger.Debugf("Found %d layout shift(s) between %s and %s", len(shifts), beforePath, afterPath)
+	return shifts, nil
+}
+
+func abs(v int) int {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
@@ -0,0 +1,94 @@
+package vision
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"testing"
+
+	"panoptic/internal/logger"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// drawPage renders a light page with a header bar, a card with a line of
+// "text" and a button at the given vertical offset
+func drawPage(offset int) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, 300, 240))
+	draw.Draw(img, img.Bounds(), &image.Uniform{color.RGBA{250, 250, 250, 255}}, image.Point{}, draw.Src)
+	draw.Draw(img, image.Rect(0, 0, 300, 30), &image.Uniform{color.RGBA{30, 60, 120, 255}}, image.Point{}, draw.Src)
+	for x := 20; x < 120; x += 5 { // glyphs 3px apart join into one line
+		draw.Draw(img, image.Rect(x, 60+offset, x+2, 70+offset), &image.Uniform{color.Black}, image.Point{}, draw.Src)
+	}
+	draw.Draw(img, image.Rect(20, 120+offset, 100, 150+offset), &image.Uniform{color.RGBA{200, 40, 40, 255}}, image.Point{}, draw.Src)
+	return img
+}
+
+// TestDetectBoxes tests that glyphs merge into lines and blocks stay apart
+func TestDetectBoxes(t *testing.T) {
+	boxes := DetectBoxes(drawPage(0))
+	require.Len(t, boxes, 3)
+	assert.Equal(t, image.Rect(0, 0, 300, 30), boxes[0].Bounds)
+	assert.Equal(t, image.Rect(20, 60, 117, 70), boxes[1].Bounds)
+	assert.Equal(t, Box{Name: "element at 20,120 (80x30)", Bounds: image.Rect(20, 120, 100, 150)}, boxes[2])
+
+	assert.Empty(t, DetectBoxes(createTestImage(50, 50, color.White)))
+}
+
+// TestShiftsBetween tests detecting content pushed down between screenshots
+func TestShiftsBetween(t *testing.T) {
+	assert.Empty(t, ShiftsBetween(drawPage(0), drawPage(0), 2))
+	assert.Empty(t, ShiftsBetween(drawPage(0), drawPage(2), 2), "within the tolerance")
+
+	shifts := ShiftsBetween(drawPage(0), drawPage(40), 2)
+	require.Len(t, shifts, 2, "the header stays")
+	assert.Equal(t, LayoutShift{Name: "element at 20,60 (97x10)", Before: image.Rect(20, 60, 117, 70), After: image.Rect(20, 100, 117, 110), DY: 40, Severity: "medium"}, shifts[0])
+	assert.Equal(t, 40, shifts[1].DY)
+
+	changed := drawPage(0)
+	draw.Draw(changed, image.Rect(20, 120, 100, 150), &image.Uniform{color.RGBA{40, 160, 40, 255}}, image.Point{}, draw.Src)
+	assert.Empty(t, ShiftsBetween(drawPage(0), changed, 2), "a recolored button changed content, not layout")
+}
+
+// TestCompareLayouts tests named boxes and shift severities
+func TestCompareLayouts(t *testing.T) {
+	before := []Box{{"#hero", image.Rect(0, 0, 800, 400)}, {"#buy", image.Rect(10, 420, 110, 460)}, {"#gone", image.Rect(0, 0, 5, 5)}}
+	after := []Box{{"#hero", image.Rect(0, 0, 800, 403)}, {"#buy", image.Rect(10, 540, 110, 580)}}
+
+	shifts := CompareLayouts(before, after, 4)
+	require.Len(t, shifts, 1)
+	assert.Equal(t, LayoutShift{Name: "#buy", Before: before[1].Bounds, After: after[1].Bounds, DY: 120, Severity: "high"}, shifts[0])
+	assert.Len(t, CompareLayouts(before, after, 0), 2, "the hero grew by 3px")
+	assert.Equal(t, "low", CompareLayouts(before, after, 0)[0].Severity)
+}
+
+// TestFindOverlaps tests partial overlaps, nesting and the tolerance
+func TestFindOverlaps(t *testing.T) {
+	boxes := []Box{
+		{"#card", image.Rect(0, 0, 200, 100)},
+		{"#title", image.Rect(10, 10, 190, 30)},   // inside the card
+		{"#badge", image.Rect(180, 80, 240, 120)}, // over the card's corner
+		{"#price", image.Rect(230, 90, 300, 110)}, // touches the badge
+		{"#footer", image.Rect(0, 99, 100, 140)},  // 1px into the card
+	}
+	overlaps := FindOverlaps(boxes, 2)
+	require.Len(t, overlaps, 2)
+	assert.Equal(t, Overlap{A: "#card", B: "#badge", Area: image.Rect(180, 80, 200, 100), Share: 0.167, Severity: "medium"}, overlaps[0])
+	assert.Equal(t, Overlap{A: "#badge", B: "#price", Area: image.Rect(230, 90, 240, 110), Share: 0.143, Severity: "medium"}, overlaps[1])
+	assert.Len(t, FindOverlaps(boxes, 0), 3)
+}
+
+// TestElementDetector_ShiftsBetweenFiles tests comparing saved screenshots
+func TestElementDetector_ShiftsBetweenFiles(t *testing.T) {
+	before := saveTestImage(t, drawPage(0), "before.png")
+	after := saveTestImage(t, drawPage(30), "after.png")
+
+	detector := NewElementDetector(*logger.NewLogger(false))
+	shifts, err := detector.ShiftsBetweenFiles(before, after, 4)
+	require.NoError(t, err)
+	assert.Len(t, shifts, 2)
+
+	_, err = detector.ShiftsBetweenFiles(before, "/nonexistent/after.png", 4)
+	assert.Error(t, err)
+}
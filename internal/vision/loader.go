@@ -0,0 +1,173 @@
+package vision
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"image"
+	_ "image/jpeg" // mobile platforms often capture JPEG
+	"image/png"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	xdraw "golang.org/x/image/draw"
+	_ "golang.org/x/image/webp" // lossy, lossless and alpha WebP
+)
+
+// ErrAVIFUnsupported is returned for AVIF screenshots when none of the
+// avifDecoders is installed: Go has no AV1 decoder, so AVIF is converted to
+// PNG by an external tool
+var ErrAVIFUnsupported = errors.New("AVIF images can't be decoded: no AVIF decoder found; install avifdec (libavif), ImageMagick or ffmpeg, or capture the screenshot as PNG, JPEG or WebP")
+
+// avifDecoder converts an AVIF file to a PNG one with an external tool
+type avifDecoder struct {
+	name string
+	args func(avif, out string) []string
+}
+
+// avifDecoders are tried in order until one succeeds
+var avifDecoders = []avifDecoder{
+	{"avifdec", func(avif, out string) []string { return []string{avif, out} }},
+	{"magick", func(avif, out string) []string { return []string{avif, out} }},
+	{"ffmpeg", func(avif, out string) []string {
+		return []string{"-v", "error", "-y", "-i", avif, "-frames:v", "1", out}
+	}},
+}
+
+// SetMaxDimension makes DetectElements downscale screenshots whose longer
+// side exceeds px before scanning them, which is faster on very large
+// captures at the cost of detail; positions and sizes are reported in the
+// screenshot's own pixels. Zero or less scans at full resolution. Codes,
+// contrast, layout and hashes always use the full resolution.
+func (ed *ElementDetector) SetMaxDimension(px int) {
+	ed.maxDimension = px
+}
+
+// loadImage decodes a PNG, JPEG or WebP file, whatever its extension, or an
+// AVIF one with the first of the avifDecoders installed
+func (ed *ElementDetector) loadImage(imagePath string) (image.Image, error) {
+	file, err := os.Open(imagePath)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	reader := bufio.NewReader(file)
+	img, format, err := image.Decode(reader)
+	if errors.Is(err, image.ErrFormat) {
+		if header, _ := reader.Peek(64); isAVIF(header) {
+			return ed.decodeAVIF(imagePath)
+		}
+		return nil, fmt.Errorf("%s is not a PNG, JPEG or WebP image: %w", imagePath, err)
+	}
+	if err != nil {
+		return nil, err
+	}
+	ed.logger.Debugf("Loaded %s image %s (%dx%d)", format, imagePath, img.Bounds().Dx(), img.Bounds().Dy())
+	return img, nil
+}
+
+// decodeAVIF converts an AVIF file to PNG with the avifDecoders installed,
+// in order, and decodes that. A decoder that fails, such as an ImageMagick
+// built without AVIF support, is logged and the next one tried; the errors
+// of all of them are returned when none succeeds.
+func (ed *ElementDetector) decodeAVIF(imagePath string) (image.Image, error) {
+	var errs []error
+	for _, d := range avifDecoders {
+		bin, err := exec.LookPath(d.name)
+		if err != nil {
+			continue
+		}
+		img, err := runAVIFDecoder(d, bin, imagePath)
+		if err != nil {
+			ed.logger.Debugf("AVIF decoder %s failed, trying the next one: %v", d.name, err)
+			errs = append(errs, err)
+			continue
+		}
+		ed.logger.Debugf("Loaded avif image %s with %s (%dx%d)", imagePath, d.name, img.Bounds().Dx(), img.Bounds().Dy())
+		return img, nil
+	}
+	if len(errs) == 0 {
+		return nil, fmt.Errorf("%s: %w", imagePath, ErrAVIFUnsupported)
+	}
+	return nil, fmt.Errorf("no AVIF decoder could decode %s: %w", imagePath, errors.Join(errs...))
+}
+
+// runAVIFDecoder converts an AVIF file to PNG in a temporary directory with
+// the decoder at bin and decodes the PNG
+func runAVIFDecoder(d avifDecoder, bin, imagePath string) (image.Image, error) {
+	dir, err := os.MkdirTemp("", "panoptic-avif-")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(dir)
+	out := filepath.Join(dir, "screenshot.png")
+	var stderr bytes.Buffer
+	cmd := exec.Command(bin, d.args(imagePath, out)...)
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("%s failed to decode %s: %w: %s", d.name, imagePath, err, strings.TrimSpace(stderr.String()))
+	}
+	file, err := os.Open(out)
+	if err != nil {
+		return nil, fmt.Errorf("%s wrote no PNG for %s: %w", d.name, imagePath, err)
+	}
+	defer file.Close()
+	img, err := png.Decode(file)
+	if err != nil {
+		return nil, fmt.Errorf("%s wrote no PNG for %s: %w", d.name, imagePath, err)
+	}
+	return img, nil
+}
+
+// isAVIF reports whether a file starts with an ISO media ftyp box naming an
+// AVIF brand
+func isAVIF(header []byte) bool {
+	if len(header) < 16 || !bytes.Equal(header[4:8], []byte("ftyp")) {
+		return false
+	}
+	size := int(binary.BigEndian.Uint32(header))
+	if size > len(header) || size < 16 {
+		size = len(header)
+	}
+	// The major brand, then the compatible brands after the minor version
+	avif := func(brand []byte) bool { return string(brand) == "avif" || string(brand) == "avis" }
+	if avif(header[8:12]) {
+		return true
+	}
+	for at := 16; at+4 <= size; at += 4 {
+		if avif(header[at : at+4]) {
+			return true
+		}
+	}
+	return false
+}
+
+// downscale shrinks an image so its longer side is at most maxDimension,
+// returning it with the number of source pixels per scaled pixel
+func downscale(img image.Image, maxDimension int) (image.Image, float64) {
+	bounds := img.Bounds()
+	longer := bounds.Dx()
+	if bounds.Dy() > longer {
+		longer = bounds.Dy()
+	}
+	if maxDimension <= 0 || longer <= maxDimension {
+		return img, 1
+	}
+	factor := float64(longer) / float64(maxDimension)
+	width := int(float64(bounds.Dx())/factor + 0.5)
+	height := int(float64(bounds.Dy())/factor + 0.5)
+	if width < 1 {
+		width = 1
+	}
+	if height < 1 {
+		height = 1
+	}
+	scaled := image.NewRGBA(image.Rect(0, 0, width, height))
+	xdraw.ApproxBiLinear.Scale(scaled, scaled.Bounds(), img, bounds, xdraw.Src, nil)
+	return scaled, factor
+}
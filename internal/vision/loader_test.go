@@ -0,0 +1,127 @@
+package vision
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"image/jpeg"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"panoptic/internal/logger"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestLoadImage_Formats tests decoding JPEG and WebP screenshots whatever
+// their extension, and the errors for AVIF without a decoder and unknown files
+func TestLoadImage_Formats(t *testing.T) {
+	detector := NewElementDetector(*logger.NewLogger(false))
+
+	// Mobile screenshots are often JPEG saved under a .png name
+	path := filepath.Join(t.TempDir(), "device.png")
+	f, err := os.Create(path)
+	require.NoError(t, err)
+	require.NoError(t, jpeg.Encode(f, createTestImage(64, 48, color.RGBA{200, 40, 40, 255}), &jpeg.Options{Quality: 90}))
+	require.NoError(t, f.Close())
+	img, err := detector.loadImage(path)
+	require.NoError(t, err)
+	assert.Equal(t, image.Rect(0, 0, 64, 48), img.Bounds())
+	r, _, _, _ := img.At(32, 24).RGBA()
+	assert.InDelta(t, 200, r>>8, 4)
+
+	img, err = detector.loadImage(filepath.Join("testdata", "screen.lossy.webp"))
+	require.NoError(t, err)
+	assert.Equal(t, image.Rect(0, 0, 150, 100), img.Bounds())
+	_, err = detector.DetectElements(filepath.Join("testdata", "screen.lossy.webp"))
+	assert.NoError(t, err)
+
+	decoders := avifDecoders
+	defer func() { avifDecoders = decoders }()
+	avifDecoders = nil
+	avif := writeTestAVIF(t)
+	_, err = detector.loadImage(avif)
+	assert.ErrorIs(t, err, ErrAVIFUnsupported)
+
+	heic := filepath.Join(t.TempDir(), "shot.heic")
+	require.NoError(t, os.WriteFile(heic, []byte("\x00\x00\x00\x18ftypheic\x00\x00\x00\x00mif1heic"), 0644))
+	_, err = detector.loadImage(heic)
+	assert.ErrorContains(t, err, "is not a PNG, JPEG or WebP image")
+	assert.NotErrorIs(t, err, ErrAVIFUnsupported)
+}
+
+// writeTestAVIF writes the header of an AVIF file
+func writeTestAVIF(t *testing.T) string {
+	path := filepath.Join(t.TempDir(), "shot.avif")
+	require.NoError(t, os.WriteFile(path, []byte("\x00\x00\x00\x1cftypmif1\x00\x00\x00\x00mif1avifmiaf\x00\x00\x00\x00meta"), 0644))
+	return path
+}
+
+// TestLoadImage_AVIFDecoder tests decoding AVIF through the installed
+// decoders, moving past one that fails, and reporting them all failing
+func TestLoadImage_AVIFDecoder(t *testing.T) {
+	if _, err := exec.LookPath("cp"); err != nil {
+		t.Skip("cp not available")
+	}
+	decoders := avifDecoders
+	defer func() { avifDecoders = decoders }()
+
+	// cp stands in for a decoder, copying a PNG to the output path; copying
+	// a missing file stands in for a broken one
+	png := saveTestImage(t, createTestImage(40, 30, color.RGBA{20, 180, 60, 255}), "decoded.png")
+	broken := func(avif, out string) []string { return []string{filepath.Join(t.TempDir(), "missing.png"), out} }
+	avifDecoders = []avifDecoder{
+		{"panoptic-missing-decoder", func(avif, out string) []string { return nil }},
+		{"cp", broken},
+		{"cp", func(avif, out string) []string { return []string{png, out} }},
+	}
+	detector := NewElementDetector(*logger.NewLogger(false))
+	img, err := detector.loadImage(writeTestAVIF(t))
+	require.NoError(t, err)
+	assert.Equal(t, image.Rect(0, 0, 40, 30), img.Bounds())
+	_, g, _, _ := img.At(20, 15).RGBA()
+	assert.Equal(t, uint32(180), g>>8)
+
+	avifDecoders[2].args = broken
+	_, err = detector.loadImage(writeTestAVIF(t))
+	assert.ErrorContains(t, err, "no AVIF decoder could decode")
+	assert.Equal(t, 2, strings.Count(err.Error(), "cp failed to decode"), "every decoder's error")
+	assert.NotErrorIs(t, err, ErrAVIFUnsupported)
+}
+
+// TestDetectElements_MaxDimension tests that downscaled detection reports
+// elements in the screenshot's own pixels
+func TestDetectElements_MaxDimension(t *testing.T) {
+	img := image.NewRGBA(image.Rect(0, 0, 800, 400))
+	draw.Draw(img, img.Bounds(), &image.Uniform{color.RGBA{150, 150, 150, 255}}, image.Point{}, draw.Src)
+	draw.Draw(img, image.Rect(400, 200, 800, 400), &image.Uniform{color.RGBA{250, 250, 250, 255}}, image.Point{}, draw.Src)
+	path := saveTestImage(t, img, "large.png")
+
+	detector := NewElementDetector(*logger.NewLogger(false))
+	full, err := detector.DetectElements(path)
+	require.NoError(t, err)
+
+	detector.SetMaxDimension(200)
+	scaled, err := detector.DetectElements(path)
+	require.NoError(t, err)
+	require.NotEmpty(t, scaled)
+	assert.Less(t, len(scaled), len(full), "a coarser grid")
+
+	fields := detector.FindElementByType(scaled, "textfield")
+	require.NotEmpty(t, fields)
+	for _, field := range fields {
+		assert.GreaterOrEqual(t, field.Position.X, 400, "only the light quarter is field-like")
+		assert.GreaterOrEqual(t, field.Position.Y, 200)
+		assert.Equal(t, Size{Width: 480, Height: 100}, field.Size)
+	}
+	assert.Equal(t, "input[type=text][440,200]", fields[0].Selector, "110,50 in the 200x100 scan")
+
+	detector.SetMaxDimension(1000)
+	same, err := detector.DetectElements(path)
+	require.NoError(t, err)
+	assert.Equal(t, full, same, "smaller screenshots aren't scaled")
+}
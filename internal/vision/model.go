@@ -0,0 +1,290 @@
+package vision
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"math"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	xdraw "golang.org/x/image/draw"
+)
+
+// ErrModelUnavailable is returned (wrapped) when an ONNX model can't be
+// used: the model file is missing, or the runtime interpreter or its
+// onnxruntime package isn't installed. DetectElements then keeps using the
+// heuristic detectors.
+var ErrModelUnavailable = errors.New("ONNX model unavailable")
+
+// DefaultModelLabels are the element types of a model's classes when none
+// are configured
+var DefaultModelLabels = []string{"button", "textfield", "image", "link"}
+
+// ModelConfig describes a user-provided UI element detection model in ONNX
+// format. The model takes one float32 NCHW tensor of RGB values from 0 to 1,
+// letterboxed into an InputSize square, and returns boxes in YOLO layout:
+// [1, 4+classes, boxes] (YOLOv8 and later) or [1, boxes, 5+classes] (YOLOv5,
+// with an objectness score). Labels name the element type of each class.
+type ModelConfig struct {
+	Path          string
+	Runtime       string   // Python interpreter with onnxruntime and numpy; default python3
+	InputSize     int      // default 640
+	Labels        []string // default DefaultModelLabels
+	MinConfidence float64  // boxes scoring less are discarded before thresholds apply; default 0.25
+}
+
+// Model runs an ONNX model through ONNX Runtime. Inference happens in a
+// Python process using the onnxruntime package, so no cgo is needed and the
+// execution providers installed with onnxruntime (CPU, CUDA, CoreML...) are
+// used as they are.
+type Model struct {
+	config ModelConfig
+}
+
+// onnxRunner runs the model on the tensor file and writes the first output
+// as little-endian float32, printing its shape as JSON
+const onnxRunner = `import json, sys
+import numpy as np
+import onnxruntime as ort
+model, tensor, size, output = sys.argv[1:5]
+size = int(size)
+session = ort.InferenceSession(model, providers=ort.get_available_providers())
+x = np.fromfile(tensor, dtype="<f4").reshape(1, 3, size, size)
+y = np.asarray(session.run(None, {session.get_inputs()[0].name: x})[0], dtype="<f4")
+y.tofile(output)
+json.dump({"shape": list(y.shape)}, sys.stdout)
+`
+
+// LoadModel checks that the model file exists and that the runtime can
+// import onnxruntime, returning an error wrapping ErrModelUnavailable when
+// either is missing
+func LoadModel(config ModelConfig) (*Model, error) {
+	if config.Runtime == "" {
+		config.Runtime = "python3"
+	}
+	if config.InputSize <= 0 {
+		config.InputSize = 640
+	}
+	if len(config.Labels) == 0 {
+		config.Labels = DefaultModelLabels
+	}
+	if config.MinConfidence <= 0 {
+		config.MinConfidence = 0.25
+	}
+	if _, err := os.Stat(config.Path); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrModelUnavailable, err)
+	}
+	if _, err := exec.LookPath(config.Runtime); err != nil {
+		return nil, fmt.Errorf("%w: runtime %s not found: %v", ErrModelUnavailable, config.Runtime, err)
+	}
+	if out, err := exec.Command(config.Runtime, "-c", "import numpy, onnxruntime").CombinedOutput(); err != nil {
+		return nil, fmt.Errorf("%w: %s can't import onnxruntime (pip install onnxruntime): %s", ErrModelUnavailable, config.Runtime, strings.TrimSpace(string(out)))
+	}
+	return &Model{config: config}, nil
+}
+
+// SetModel makes DetectElements find elements with an ONNX model instead of
+// the heuristic detectors; nil restores the heuristics. When inference
+// fails, DetectElements logs the error and falls back to the heuristics.
+func (ed *ElementDetector) SetModel(model *Model) {
+	ed.model = model
+}
+
+// UseModel loads a model for DetectElements, logging a warning and keeping
+// the heuristic detectors when it is unavailable
+func (ed *ElementDetector) UseModel(config ModelConfig) error {
+	model, err := LoadModel(config)
+	if err != nil {
+		ed.logger.Warnf("Using heuristic element detection: %v", err)
+		return err
+	}
+	ed.logger.Infof("Using ONNX model %s for element detection", config.Path)
+	ed.SetModel(model)
+	return nil
+}
+
+// letterbox fits an image into a size square, keeping its aspect ratio and
+// padding with gray as YOLO models are trained, and returns the placement
+type letterbox struct {
+	scale      float64
+	padX, padY float64
+}
+
+// tensor letterboxes an image into the model's input tensor
+func (m *Model) tensor(img image.Image) ([]byte, letterbox) {
+	size := m.config.InputSize
+	bounds := img.Bounds()
+	scale := math.Min(float64(size)/float64(bounds.Dx()), float64(size)/float64(bounds.Dy()))
+	width, height := int(float64(bounds.Dx())*scale+0.5), int(float64(bounds.Dy())*scale+0.5)
+	box := letterbox{scale: scale, padX: float64(size-width) / 2, padY: float64(size-height) / 2}
+
+	canvas := image.NewRGBA(image.Rect(0, 0, size, size))
+	xdraw.Draw(canvas, canvas.Bounds(), &image.Uniform{color.RGBA{114, 114, 114, 255}}, image.Point{}, xdraw.Src)
+	at := image.Pt(int(box.padX), int(box.padY))
+	xdraw.ApproxBiLinear.Scale(canvas, image.Rectangle{Min: at, Max: at.Add(image.Pt(width, height))}, img, bounds, xdraw.Src, nil)
+
+	plane := size * size
+	data := make([]byte, 3*plane*4)
+	for i := 0; i < plane; i++ {
+		for c := 0; c < 3; c++ {
+			binary.LittleEndian.PutUint32(data[(c*plane+i)*4:], math.Float32bits(float32(canvas.Pix[i*4+c])/255))
+		}
+	}
+	return data, box
+}
+
+// Detect runs the model on an image and returns the elements it finds, in
+// the image's pixels
+func (m *Model) Detect(img image.Image) ([]ElementInfo, error) {
+	dir, err := os.MkdirTemp("", "panoptic-onnx-")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(dir)
+
+	data, box := m.tensor(img)
+	input, output := filepath.Join(dir, "input.f32"), filepath.Join(dir, "output.f32")
+	if err := os.WriteFile(input, data, 0600); err != nil {
+		return nil, err
+	}
+	var stdout, stderr bytes.Buffer
+	cmd := exec.Command(m.config.Runtime, "-c", onnxRunner, m.config.Path, input, fmt.Sprint(m.config.InputSize), output)
+	cmd.Stdout, cmd.Stderr = &stdout, &stderr
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("ONNX inference failed: %v: %s", err, strings.TrimSpace(stderr.String()))
+	}
+	var result struct {
+		Shape []int `json:"shape"`
+	}
+	if err := json.Unmarshal(stdout.Bytes(), &result); err != nil {
+		return nil, fmt.Errorf("ONNX inference printed no output shape: %w", err)
+	}
+	raw, err := os.ReadFile(output)
+	if err != nil {
+		return nil, err
+	}
+	values := make([]float32, len(raw)/4)
+	for i := range values {
+		values[i] = math.Float32frombits(binary.LittleEndian.Uint32(raw[i*4:]))
+	}
+	return m.decode(values, result.Shape, box, img.Bounds())
+}
+
+// detection is one box of a model's output, in input tensor pixels
+type detection struct {
+	class  int
+	score  float64
+	x0, y0 float64
+	x1, y1 float64
+}
+
+// decode turns a YOLO output tensor into elements, dropping boxes below the
+// minimum confidence. Overlapping boxes are left for Thresholds.Apply to
+// suppress.
+func (m *Model) decode(values []float32, shape []int, box letterbox, bounds image.Rectangle) ([]ElementInfo, error) {
+	classes := len(m.config.Labels)
+	if len(shape) == 3 && shape[0] == 1 {
+		shape = shape[1:]
+	}
+	if len(shape) != 2 || shape[0]*shape[1] != len(values) {
+		return nil, fmt.Errorf("model output shape %v isn't [1, features, boxes] or [1, boxes, features]", shape)
+	}
+
+	// feature f of box b, with objectness scores when the model has them
+	var boxes int
+	var feature func(b, f int) float64
+	objectness := false
+	switch {
+	case shape[0] == 4+classes:
+		boxes = shape[1]
+		feature = func(b, f int) float64 { return float64(values[f*boxes+b]) }
+	case shape[1] == 4+classes:
+		boxes = shape[0]
+		feature = func(b, f int) float64 { return float64(values[b*shape[1]+f]) }
+	case shape[1] == 5+classes:
+		boxes, objectness = shape[0], true
+		feature = func(b, f int) float64 { return float64(values[b*shape[1]+f]) }
+	default:
+		return nil, fmt.Errorf("model output shape %v doesn't fit %d labels: want [1, %d, boxes] or [1, boxes, %d]", shape, classes, 4+classes, 5+classes)
+	}
+
+	var found []detection
+	for b := 0; b < boxes; b++ {
+		first := 4
+		weight := 1.0
+		if objectness {
+			first, weight = 5, feature(b, 4)
+		}
+		best, score := 0, -1.0
+		for c := 0; c < classes; c++ {
+			if s := feature(b, first+c) * weight; s > score {
+				best, score = c, s
+			}
+		}
+		if score < m.config.MinConfidence {
+			continue
+		}
+		cx, cy, w, h := feature(b, 0), feature(b, 1), feature(b, 2), feature(b, 3)
+		found = append(found, detection{class: best, score: score, x0: cx - w/2, y0: cy - h/2, x1: cx + w/2, y1: cy + h/2})
+	}
+
+	elements := make([]ElementInfo, 0, len(found))
+	for _, d := range found {
+		// Undo the letterbox and clamp to the image
+		toImage := func(v, pad float64, lo, hi int) int {
+			p := int(math.Round((v-pad)/box.scale)) + lo
+			if p < lo {
+				return lo
+			}
+			if p > hi {
+				return hi
+			}
+			return p
+		}
+		x0, y0 := toImage(d.x0, box.padX, bounds.Min.X, bounds.Max.X), toImage(d.y0, box.padY, bounds.Min.Y, bounds.Max.Y)
+		x1, y1 := toImage(d.x1, box.padX, bounds.Min.X, bounds.Max.X), toImage(d.y1, box.padY, bounds.Min.Y, bounds.Max.Y)
+		if x1 <= x0 || y1 <= y0 {
+			continue
+		}
+		label := m.config.Labels[d.class]
+		elements = append(elements, ElementInfo{
+			Type:       label,
+			Selector:   fmt.Sprintf("%s[%d,%d]", selectorTag(label), x0, y0),
+			Position:   Point{X: x0, Y: y0},
+			Size:       Size{Width: x1 - x0, Height: y1 - y0},
+			Confidence: math.Round(d.score*1000) / 1000,
+			Attributes: map[string]string{"detector": "onnx"},
+		})
+	}
+
+	// Reading order, like the heuristic scans
+	sort.SliceStable(elements, func(i, j int) bool {
+		if elements[i].Position.Y != elements[j].Position.Y {
+			return elements[i].Position.Y < elements[j].Position.Y
+		}
+		return elements[i].Position.X < elements[j].Position.X
+	})
+	return elements, nil
+}
+
+// selectorTag is the selector prefix the heuristic detectors use for an
+// element type
+func selectorTag(label string) string {
+	switch label {
+	case "textfield":
+		return "input[type=text]"
+	case "image":
+		return "img"
+	case "link":
+		return "a"
+	}
+	return label
+}
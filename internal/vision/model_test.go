@@ -0,0 +1,155 @@
+package vision
+
+import (
+	"encoding/binary"
+	"image"
+	"image/color"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"panoptic/internal/logger"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// stubRuntime writes a script standing in for a Python interpreter with
+// onnxruntime: the import probe succeeds, and inference checks the size of
+// the tensor, then returns the "model" file as the output with the shape in
+// model.shape
+func stubRuntime(t *testing.T, script string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "python")
+	require.NoError(t, os.WriteFile(path, []byte("#!/bin/sh\n"+script), 0755))
+	return path
+}
+
+const inferenceStub = `[ "$2" = "import numpy, onnxruntime" ] && exit 0
+[ "$(wc -c < "$4")" -eq $((3 * $5 * $5 * 4)) ] || { echo "bad tensor size" >&2; exit 1; }
+cp "$3" "$6" && cat "$3.shape"
+`
+
+// writeModelOutput saves float32 values as a stub model's output
+func writeModelOutput(t *testing.T, values []float32, shape string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "ui.onnx")
+	data := make([]byte, len(values)*4)
+	for i, v := range values {
+		binary.LittleEndian.PutUint32(data[i*4:], math.Float32bits(v))
+	}
+	require.NoError(t, os.WriteFile(path, data, 0644))
+	require.NoError(t, os.WriteFile(path+".shape", []byte(`{"shape":`+shape+`}`), 0644))
+	return path
+}
+
+// TestLoadModel_Unavailable tests that a missing model, interpreter or
+// onnxruntime package is reported as ErrModelUnavailable
+func TestLoadModel_Unavailable(t *testing.T) {
+	model := writeModelOutput(t, nil, "[1,8,0]")
+
+	_, err := LoadModel(ModelConfig{Path: filepath.Join(t.TempDir(), "missing.onnx"), Runtime: stubRuntime(t, inferenceStub)})
+	assert.ErrorIs(t, err, ErrModelUnavailable)
+	_, err = LoadModel(ModelConfig{Path: model, Runtime: "panoptic-no-such-python"})
+	assert.ErrorIs(t, err, ErrModelUnavailable)
+	assert.ErrorContains(t, err, "runtime panoptic-no-such-python not found")
+
+	noPackage := stubRuntime(t, "echo \"ModuleNotFoundError: No module named 'onnxruntime'\" >&2; exit 1\n")
+	_, err = LoadModel(ModelConfig{Path: model, Runtime: noPackage})
+	assert.ErrorIs(t, err, ErrModelUnavailable)
+	assert.ErrorContains(t, err, "No module named 'onnxruntime'")
+
+	detector := NewElementDetector(*logger.NewLogger(false))
+	assert.ErrorIs(t, detector.UseModel(ModelConfig{Path: model, Runtime: noPackage}), ErrModelUnavailable)
+	assert.Nil(t, detector.model, "heuristics kept")
+
+	loaded, err := LoadModel(ModelConfig{Path: model, Runtime: stubRuntime(t, inferenceStub)})
+	require.NoError(t, err)
+	assert.Equal(t, 640, loaded.config.InputSize)
+	assert.Equal(t, DefaultModelLabels, loaded.config.Labels)
+}
+
+// TestModel_Decode tests both YOLO output layouts, the confidence cut-off
+// and undoing the letterbox
+func TestModel_Decode(t *testing.T) {
+	m := &Model{config: ModelConfig{Labels: []string{"button", "link"}, MinConfidence: 0.25}}
+	box := letterbox{scale: 0.5, padY: 10}
+	bounds := image.Rect(0, 0, 200, 100)
+
+	// Boxes as cx, cy, w, h, then a score per class
+	boxes := [][]float32{
+		{50, 30, 40, 20, 0.9, 0.1},  // button at 30,20 in tensor pixels
+		{52, 31, 40, 20, 0.8, 0.05}, // the same button again
+		{20, 20, 10, 10, 0.1, 0.6},  // a link above it
+		{80, 40, 10, 10, 0.2, 0.1},  // too unsure
+	}
+	v8 := make([]float32, 6*len(boxes))
+	for b, features := range boxes {
+		for f, v := range features {
+			v8[f*len(boxes)+b] = v
+		}
+	}
+	elements, err := m.decode(v8, []int{1, 6, len(boxes)}, box, bounds)
+	require.NoError(t, err)
+	require.Len(t, elements, 3)
+	assert.Equal(t, ElementInfo{
+		Type: "link", Selector: "a[30,10]", Position: Point{X: 30, Y: 10}, Size: Size{Width: 20, Height: 20},
+		Confidence: 0.6, Attributes: map[string]string{"detector": "onnx"},
+	}, elements[0])
+	assert.Equal(t, "button[60,20]", elements[1].Selector)
+	assert.Equal(t, Size{Width: 80, Height: 40}, elements[1].Size)
+	assert.InDelta(t, 0.9, elements[1].Confidence, 1e-9)
+	assert.Equal(t, "button[64,22]", elements[2].Selector)
+
+	// YOLOv5 rows carry an objectness score that weighs the class scores
+	var v5 []float32
+	for _, features := range boxes {
+		v5 = append(v5, features[:4]...)
+		v5 = append(v5, 0.5)
+		v5 = append(v5, features[4:]...)
+	}
+	elements, err = m.decode(v5, []int{1, len(boxes), 7}, box, bounds)
+	require.NoError(t, err)
+	require.Len(t, elements, 3)
+	assert.InDelta(t, 0.3, elements[0].Confidence, 1e-9)
+	assert.InDelta(t, 0.45, elements[1].Confidence, 1e-9)
+	assert.InDelta(t, 0.4, elements[2].Confidence, 1e-9)
+
+	_, err = m.decode(make([]float32, 10*5), []int{1, 10, 5}, box, bounds)
+	assert.EqualError(t, err, "model output shape [10 5] doesn't fit 2 labels: want [1, 6, boxes] or [1, boxes, 7]")
+	_, err = m.decode(make([]float32, 3), []int{1, 6, 2}, box, bounds)
+	assert.ErrorContains(t, err, "isn't [1, features, boxes]")
+}
+
+// TestDetectElements_Model tests detection through the model runtime, and
+// the fallback to heuristics when inference fails
+func TestDetectElements_Model(t *testing.T) {
+	path := saveTestImage(t, createTestImage(200, 100, color.RGBA{240, 240, 240, 255}), "wide.png")
+
+	// A 64px input letterboxes 200x100 at 0.32 with 16px bars above and below
+	model := writeModelOutput(t, []float32{32, 32, 32, 16, 0.7}, "[1,5,1]")
+	detector := NewElementDetector(*logger.NewLogger(false))
+	require.NoError(t, detector.UseModel(ModelConfig{Path: model, Runtime: stubRuntime(t, inferenceStub), InputSize: 64, Labels: []string{"button"}}))
+	elements, err := detector.DetectElements(path)
+	require.NoError(t, err)
+	require.Len(t, elements, 1)
+	assert.Equal(t, Point{X: 50, Y: 25}, elements[0].Position)
+	assert.Equal(t, Size{Width: 100, Height: 50}, elements[0].Size)
+	assert.Equal(t, "onnx", elements[0].Attributes["detector"])
+
+	heuristic, err := NewElementDetector(*logger.NewLogger(false)).DetectElements(path)
+	require.NoError(t, err)
+	failing := stubRuntime(t, "[ \"$2\" = \"import numpy, onnxruntime\" ] && exit 0\necho 'InvalidGraph' >&2; exit 1\n")
+	require.NoError(t, detector.UseModel(ModelConfig{Path: model, Runtime: failing}))
+	elements, err = detector.DetectElements(path)
+	require.NoError(t, err)
+	assert.Equal(t, heuristic, elements)
+
+	_, err = detector.model.Detect(createTestImage(10, 10, color.White))
+	assert.EqualError(t, err, "ONNX inference failed: exit status 1: InvalidGraph")
+	detector.SetModel(nil)
+	elements, err = detector.DetectElements(path)
+	require.NoError(t, err)
+	assert.Equal(t, heuristic, elements)
+}
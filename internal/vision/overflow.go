@@ -0,0 +1,57 @@
+package vision
+
+import (
+	"image"
+	"math"
+)
+
+// Share of a text's box that is cut off from which its overflow is high or
+// medium severity
+const (
+	overflowHigh   = 0.25
+	overflowMedium = 0.05
+)
+
+// TextBox is the box a named text takes and the box that clips it, empty
+// when nothing does
+type TextBox struct {
+	Name    string          `json:"name"`
+	Content image.Rectangle `json:"content"`
+	Clip    image.Rectangle `json:"clip"`
+}
+
+// TextOverflow is text cut off by the box clipping it
+type TextOverflow struct {
+	Name     string          `json:"name"`
+	Content  image.Rectangle `json:"content"`
+	Clip     image.Rectangle `json:"clip"`
+	Cut      int             `json:"cut"`   // pixels the text exceeds the clip by on its worst side
+	Share    float64         `json:"share"` // of the text's box that is cut off
+	Severity string          `json:"severity"`
+}
+
+// FindTextOverflows reports the texts exceeding their clip by more than
+// tolerance pixels on a side
+func FindTextOverflows(boxes []TextBox, tolerance int) []TextOverflow {
+	var overflows []TextOverflow
+	for _, b := range boxes {
+		if b.Clip.Empty() || b.Content.Empty() {
+			continue
+		}
+		cut := max(b.Clip.Min.X-b.Content.Min.X, b.Clip.Min.Y-b.Content.Min.Y, b.Content.Max.X-b.Clip.Max.X, b.Content.Max.Y-b.Clip.Max.Y)
+		if cut <= tolerance {
+			continue
+		}
+		visible := b.Content.Intersect(b.Clip)
+		share := 1 - float64(visible.Dx()*visible.Dy())/float64(b.Content.Dx()*b.Content.Dy())
+		severity := "low"
+		switch {
+		case share >= overflowHigh:
+			severity = "high"
+		case share >= overflowMedium:
+			severity = "medium"
+		}
+		overflows = append(overflows, TextOverflow{Name: b.Name, Content: b.Content, Clip: b.Clip, Cut: cut, Share: math.Round(share*1000) / 1000, Severity: severity})
+	}
+	return overflows
+}
@@ -0,0 +1,29 @@
+package vision
+
+import (
+	"image"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestFindTextOverflows tests that text cut off beyond the tolerance is
+// reported with its worst side and hidden share
+func TestFindTextOverflows(t *testing.T) {
+	boxes := []TextBox{
+		{Name: "fits", Content: image.Rect(0, 0, 100, 20), Clip: image.Rect(0, 0, 120, 20)},
+		{Name: "unclipped", Content: image.Rect(0, 0, 300, 20)},
+		{Name: "within tolerance", Content: image.Rect(0, 0, 102, 20), Clip: image.Rect(0, 0, 100, 20)},
+		{Name: "button", Content: image.Rect(10, 0, 170, 20), Clip: image.Rect(10, 0, 110, 20)},
+		{Name: "label", Content: image.Rect(0, 0, 100, 24), Clip: image.Rect(0, 0, 100, 20)},
+	}
+	overflows := FindTextOverflows(boxes, 2)
+	require.Len(t, overflows, 2)
+	assert.Equal(t, TextOverflow{Name: "button", Content: boxes[3].Content, Clip: boxes[3].Clip, Cut: 60, Share: 0.375, Severity: "high"}, overflows[0])
+	assert.Equal(t, "label", overflows[1].Name)
+	assert.Equal(t, 4, overflows[1].Cut)
+	assert.Equal(t, "medium", overflows[1].Severity)
+
+	assert.Empty(t, FindTextOverflows(boxes, 100))
+}
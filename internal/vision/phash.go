@@ -0,0 +1,144 @@
+package vision
+
+import (
+	"fmt"
+	"image"
+	"math"
+	"math/bits"
+	"sort"
+	"strconv"
+)
+
+// PHash is a 64-bit perceptual hash of an image: images that look alike have
+// hashes a few bits apart, whatever their size or encoding
+type PHash uint64
+
+const (
+	// NearDuplicateDistance is the largest distance between the hashes of two
+	// screenshots still treated as the same screen, absorbing scaling,
+	// antialiasing or a blinking caret
+	NearDuplicateDistance = 4
+
+	phashSample = 32 // side of the gray image the DCT runs on
+	phashSide   = 8  // side of the low frequency block kept
+)
+
+// PerceptualHash computes the DCT hash of an image: its luminance is scaled
+// to 32x32, transformed, and each of the 8x8 lowest frequencies sets a bit
+// when above their median
+func PerceptualHash(img image.Image) PHash {
+	bounds := img.Bounds()
+	if bounds.Empty() {
+		return 0
+	}
+	var gray [phashSample][phashSample]float64
+	for sy := 0; sy < phashSample; sy++ {
+		y0, y1 := sampleSpan(bounds.Min.Y, bounds.Dy(), sy)
+		for sx := 0; sx < phashSample; sx++ {
+			x0, x1 := sampleSpan(bounds.Min.X, bounds.Dx(), sx)
+			var sum float64
+			for y := y0; y < y1; y++ {
+				for x := x0; x < x1; x++ {
+					r, g, b, _ := img.At(x, y).RGBA()
+					sum += float64(299*r+587*g+114*b) / 1000
+				}
+			}
+			gray[sy][sx] = sum / float64((y1-y0)*(x1-x0))
+		}
+	}
+
+	// Separable DCT-II, keeping only the low frequencies
+	var cosines [phashSide][phashSample]float64
+	for u := 0; u < phashSide; u++ {
+		for x := 0; x < phashSample; x++ {
+			cosines[u][x] = math.Cos(float64(2*x+1) * float64(u) * math.Pi / (2 * phashSample))
+		}
+	}
+	var rows [phashSample][phashSide]float64
+	for y := 0; y < phashSample; y++ {
+		for u := 0; u < phashSide; u++ {
+			for x := 0; x < phashSample; x++ {
+				rows[y][u] += gray[y][x] * cosines[u][x]
+			}
+		}
+	}
+	coefficients := make([]float64, 0, phashSide*phashSide)
+	for v := 0; v < phashSide; v++ {
+		for u := 0; u < phashSide; u++ {
+			var sum float64
+			for y := 0; y < phashSample; y++ {
+				sum += rows[y][u] * cosines[v][y]
+			}
+			coefficients = append(coefficients, sum)
+		}
+	}
+
+	// The DC term is the mean brightness; it is left out of the median so a
+	// uniformly brighter screen hashes alike
+	sorted := append([]float64(nil), coefficients[1:]...)
+	sort.Float64s(sorted)
+	median := (sorted[len(sorted)/2-1] + sorted[len(sorted)/2]) / 2
+
+	var hash PHash
+	for i, c := range coefficients {
+		if c > median {
+			hash |= 1 << uint(i)
+		}
+	}
+	return hash
+}
+
+// sampleSpan is the range of source pixels averaged into sample i; images
+// smaller than the sample repeat pixels
+func sampleSpan(min, size, i int) (int, int) {
+	lo := min + i*size/phashSample
+	hi := min + (i+1)*size/phashSample
+	if hi <= lo {
+		hi = lo + 1
+	}
+	return lo, hi
+}
+
+// Distance is the number of bits two hashes differ in, from 0 for the same
+// look to 64
+func (h PHash) Distance(other PHash) int {
+	return bits.OnesCount64(uint64(h ^ other))
+}
+
+// String formats the hash as 16 hex digits
+func (h PHash) String() string {
+	return fmt.Sprintf("%016x", uint64(h))
+}
+
+// ParsePHash reads a hash formatted by String
+func ParsePHash(s string) (PHash, error) {
+	v, err := strconv.ParseUint(s, 16, 64)
+	if err != nil || len(s) != 16 {
+		return 0, fmt.Errorf("perceptual hash must be 16 hex digits, got %q", s)
+	}
+	return PHash(v), nil
+}
+
+// MarshalText stores the hash as hex, so JSON keeps all 64 bits
+func (h PHash) MarshalText() ([]byte, error) {
+	return []byte(h.String()), nil
+}
+
+// UnmarshalText reads a hash stored by MarshalText
+func (h *PHash) UnmarshalText(text []byte) error {
+	v, err := ParsePHash(string(text))
+	if err != nil {
+		return err
+	}
+	*h = v
+	return nil
+}
+
+// PerceptualHashFile computes the perceptual hash of an image file
+func (ed *ElementDetector) PerceptualHashFile(imagePath string) (PHash, error) {
+	img, err := ed.loadImage(imagePath)
+	if err != nil {
+		return 0, err
+	}
+	return PerceptualHash(img), nil
+}
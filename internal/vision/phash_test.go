@@ -0,0 +1,88 @@
+package vision
+
+import (
+	"encoding/json"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"panoptic/internal/logger"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// scaled renders img at factor times its size, nearest neighbour
+func scaled(img image.Image, factor int) *image.RGBA {
+	b := img.Bounds()
+	out := image.NewRGBA(image.Rect(0, 0, b.Dx()*factor, b.Dy()*factor))
+	for y := 0; y < out.Bounds().Dy(); y++ {
+		for x := 0; x < out.Bounds().Dx(); x++ {
+			out.Set(x, y, img.At(b.Min.X+x/factor, b.Min.Y+y/factor))
+		}
+	}
+	return out
+}
+
+// TestPerceptualHash tests that the same screen hashes alike across sizes and
+// small changes, and that a different layout doesn't
+func TestPerceptualHash(t *testing.T) {
+	page := PerceptualHash(drawPage(0))
+	assert.Equal(t, page, PerceptualHash(drawPage(0)))
+	assert.LessOrEqual(t, page.Distance(PerceptualHash(scaled(drawPage(0), 2))), 2, "a retina screenshot")
+
+	caret := drawPage(0)
+	draw.Draw(caret, image.Rect(118, 60, 119, 70), &image.Uniform{color.Black}, image.Point{}, draw.Src)
+	assert.LessOrEqual(t, page.Distance(PerceptualHash(caret)), NearDuplicateDistance, "a blinking caret")
+
+	assert.Greater(t, page.Distance(PerceptualHash(drawPage(80))), NearDuplicateDistance, "content pushed down")
+	dialog := drawPage(0)
+	draw.Draw(dialog, image.Rect(20, 170, 280, 230), &image.Uniform{color.RGBA{40, 40, 40, 255}}, image.Point{}, draw.Src)
+	assert.Greater(t, page.Distance(PerceptualHash(dialog)), NearDuplicateDistance, "a dialog opened")
+
+	assert.Equal(t, PHash(0), PerceptualHash(image.NewRGBA(image.Rectangle{})))
+	assert.NotPanics(t, func() { PerceptualHash(createTestImage(3, 2, color.White)) }, "smaller than the sample")
+}
+
+// TestPHash_Text tests the hex form used in history records
+func TestPHash_Text(t *testing.T) {
+	hash := PHash(0x00ff00ff00ff00ff)
+	assert.Equal(t, "00ff00ff00ff00ff", hash.String())
+	assert.Equal(t, 32, hash.Distance(0))
+
+	parsed, err := ParsePHash("00ff00ff00ff00ff")
+	require.NoError(t, err)
+	assert.Equal(t, hash, parsed)
+	_, err = ParsePHash("ff")
+	assert.EqualError(t, err, `perceptual hash must be 16 hex digits, got "ff"`)
+	_, err = ParsePHash("zzzzzzzzzzzzzzzz")
+	assert.Error(t, err)
+
+	data, err := json.Marshal(map[string]PHash{"phash": hash})
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"phash":"00ff00ff00ff00ff"}`, string(data))
+	var back map[string]PHash
+	require.NoError(t, json.Unmarshal(data, &back))
+	assert.Equal(t, hash, back["phash"])
+}
+
+// TestPerceptualHashFile tests hashing a screenshot file
+func TestPerceptualHashFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "page.png")
+	f, err := os.Create(path)
+	require.NoError(t, err)
+	require.NoError(t, png.Encode(f, drawPage(0)))
+	require.NoError(t, f.Close())
+
+	ed := NewElementDetector(*logger.NewLogger(false))
+	hash, err := ed.PerceptualHashFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, PerceptualHash(drawPage(0)), hash)
+
+	_, err = ed.PerceptualHashFile(filepath.Join(t.TempDir(), "missing.png"))
+	assert.Error(t, err)
+}
@@ -0,0 +1,191 @@
+package vision
+
+import (
+	"image"
+	"image/color"
+	"runtime"
+	"sync"
+	"sync/atomic"
+)
+
+// tileRows is the number of pixel or grid rows in a tile of work
+const tileRows = 32
+
+// grayBuffers recycles the grayscale pixels of the images DetectElements
+// scans, which are as large as the screenshot
+var grayBuffers = sync.Pool{New: func() interface{} { return new([]uint8) }}
+
+// SetWorkers sets how many goroutines process the tiles of an image; zero or
+// less uses one per CPU
+func (ed *ElementDetector) SetWorkers(n int) {
+	ed.workers = n
+}
+
+func (ed *ElementDetector) workerCount() int {
+	if ed.workers > 0 {
+		return ed.workers
+	}
+	return runtime.GOMAXPROCS(0)
+}
+
+// forEachTile splits rows 0..n into tiles and processes them on the worker
+// pool, returning when all are done. Tiles are handed out in order, so
+// workers that finish early take the remaining ones.
+func (ed *ElementDetector) forEachTile(n int, process func(tile, lo, hi int)) {
+	tiles := (n + tileRows - 1) / tileRows
+	workers := ed.workerCount()
+	if workers > tiles {
+		workers = tiles
+	}
+	run := func(tile int) {
+		lo := tile * tileRows
+		hi := lo + tileRows
+		if hi > n {
+			hi = n
+		}
+		process(tile, lo, hi)
+	}
+	if workers <= 1 {
+		for tile := 0; tile < tiles; tile++ {
+			run(tile)
+		}
+		return
+	}
+
+	var next int64 = -1
+	var wg sync.WaitGroup
+	wg.Add(workers)
+	for w := 0; w < workers; w++ {
+		go func() {
+			defer wg.Done()
+			for tile := int(atomic.AddInt64(&next, 1)); tile < tiles; tile = int(atomic.AddInt64(&next, 1)) {
+				run(tile)
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+// grayscale converts an image to grayscale into pix, reading the pixels of
+// RGBA, NRGBA and Gray images directly; the result matches color.GrayModel
+func (ed *ElementDetector) grayscale(img image.Image, pix []uint8) *image.Gray {
+	bounds := img.Bounds()
+	gray := &image.Gray{Pix: pix[:bounds.Dx()*bounds.Dy()], Stride: bounds.Dx(), Rect: bounds}
+	width := bounds.Dx()
+
+	ed.forEachTile(bounds.Dy(), func(_, lo, hi int) {
+		for row := lo; row < hi; row++ {
+			y := bounds.Min.Y + row
+			out := gray.Pix[row*gray.Stride : row*gray.Stride+width]
+			switch src := img.(type) {
+			case *image.RGBA:
+				in := src.Pix[src.PixOffset(bounds.Min.X, y):]
+				for x := range out {
+					p := in[x*4 : x*4+3]
+					out[x] = uint8((lumaR[p[0]] + lumaG[p[1]] + lumaB[p[2]] + 1<<15) >> 24)
+				}
+			case *image.NRGBA:
+				in := src.Pix[src.PixOffset(bounds.Min.X, y):]
+				for x := range out {
+					p := in[x*4 : x*4+4]
+					if p[3] == 0xff {
+						out[x] = uint8((lumaR[p[0]] + lumaG[p[1]] + lumaB[p[2]] + 1<<15) >> 24)
+						continue
+					}
+					a := uint32(p[3])
+					out[x] = grayLevel(uint32(p[0])*0x101*a/0xff, uint32(p[1])*0x101*a/0xff, uint32(p[2])*0x101*a/0xff)
+				}
+			case *image.Gray:
+				copy(out, src.Pix[src.PixOffset(bounds.Min.X, y):])
+			default:
+				for x := range out {
+					out[x] = color.GrayModel.Convert(img.At(bounds.Min.X+x, y)).(color.Gray).Y
+				}
+			}
+		}
+	})
+	return gray
+}
+
+// grayLevel is the luminance color.GrayModel gives 16-bit premultiplied
+// channels
+func grayLevel(r, g, b uint32) uint8 {
+	return uint8((19595*r + 38470*g + 7471*b + 1<<15) >> 24)
+}
+
+// lumaR, lumaG and lumaB are the weighted terms of grayLevel for opaque 8-bit
+// channels
+var lumaR, lumaG, lumaB = lumaTable(19595), lumaTable(38470), lumaTable(7471)
+
+func lumaTable(weight uint32) (table [256]uint32) {
+	for v := range table {
+		table[v] = weight * uint32(v) * 0x101
+	}
+	return table
+}
+
+// gridScan is a detector probing a grid of points margin pixels inside the
+// image, step pixels apart
+type gridScan struct {
+	margin, step int
+	match        func(img *image.Gray, x, y int) bool
+	element      func(x, y int) ElementInfo
+}
+
+// scan probes the grid of a detector tile by tile, keeping the row-major
+// order of a single pass. Elements of an image downscaled by factor are
+// placed and sized in the pixels of the original.
+func (ed *ElementDetector) scan(s gridScan, grayImg *image.Gray, originalImg image.Image, factor float64) []ElementInfo {
+	bounds := grayImg.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+	rows := 0
+	if height-2*s.margin > 0 {
+		rows = (height - 2*s.margin + s.step - 1) / s.step
+	}
+
+	found := make([][]ElementInfo, (rows+tileRows-1)/tileRows)
+	ed.forEachTile(rows, func(tile, lo, hi int) {
+		for row := lo; row < hi; row++ {
+			y := s.margin + row*s.step
+			for x := s.margin; x < width-s.margin; x += s.step {
+				if s.match(grayImg, x, y) {
+					element := s.element(int(float64(x)*factor+0.5), int(float64(y)*factor+0.5))
+					if factor != 1 {
+						element.Size.Width = int(float64(element.Size.Width)*factor + 0.5)
+						element.Size.Height = int(float64(element.Size.Height)*factor + 0.5)
+					}
+					element.Color = ed.convertToRGBA(originalImg.At(x, y))
+					found[tile] = append(found[tile], element)
+				}
+			}
+		}
+	})
+
+	var elements []ElementInfo
+	for _, tile := range found {
+		elements = append(elements, tile...)
+	}
+	return elements
+}
+
+// detectImage runs every detector over an image, in the order buttons, text
+// fields, images and links
+func (ed *ElementDetector) detectImage(img image.Image) []ElementInfo {
+	return ed.detectScaled(img, 1)
+}
+
+// detectScaled runs every detector over an image downscaled by factor
+func (ed *ElementDetector) detectScaled(img image.Image, factor float64) []ElementInfo {
+	buffer := grayBuffers.Get().(*[]uint8)
+	if size := img.Bounds().Dx() * img.Bounds().Dy(); cap(*buffer) < size {
+		*buffer = make([]uint8, size)
+	}
+	grayImg := ed.grayscale(img, *buffer)
+	defer grayBuffers.Put(buffer)
+
+	var elements []ElementInfo
+	for _, s := range []gridScan{ed.buttonScan(), ed.textFieldScan(), ed.imageScan(), ed.linkScan()} {
+		elements = append(elements, ed.scan(s, grayImg, img, factor)...)
+	}
+	return elements
+}
@@ -0,0 +1,67 @@
+package vision
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"math/rand"
+	"testing"
+
+	"panoptic/internal/logger"
+)
+
+// screenshot4K renders a 3840x2160 page with a header, buttons, fields, text
+// and a photo, decoded as PNG screenshots are
+func screenshot4K() *image.NRGBA {
+	img := image.NewNRGBA(image.Rect(0, 0, 3840, 2160))
+	draw.Draw(img, img.Bounds(), &image.Uniform{color.RGBA{245, 245, 245, 255}}, image.Point{}, draw.Src)
+	draw.Draw(img, image.Rect(0, 0, 3840, 160), &image.Uniform{color.RGBA{30, 60, 120, 255}}, image.Point{}, draw.Src)
+	for i := 0; i < 12; i++ {
+		x := 200 + i*300
+		draw.Draw(img, image.Rect(x, 300, x+240, 380), &image.Uniform{color.RGBA{40, 90, 200, 255}}, image.Point{}, draw.Src)
+		draw.Draw(img, image.Rect(x, 500, x+240, 560), &image.Uniform{color.White}, image.Point{}, draw.Src)
+	}
+	for y := 700; y < 1400; y += 40 {
+		for x := 200; x < 3600; x += 14 {
+			draw.Draw(img, image.Rect(x, y, x+8, y+20), &image.Uniform{color.RGBA{120, 120, 160, 255}}, image.Point{}, draw.Src)
+		}
+	}
+	rng := rand.New(rand.NewSource(1))
+	for y := 1500; y < 2100; y++ {
+		for x := 200; x < 1400; x++ {
+			img.SetNRGBA(x, y, color.NRGBA{uint8(rng.Intn(256)), uint8(rng.Intn(256)), uint8(rng.Intn(256)), 255})
+		}
+	}
+	return img
+}
+
+// benchmarkDetect runs the detectors over a 4K screenshot; compare runs with
+// -cpu 1,4 to see the tiles spread across workers
+func benchmarkDetect(b *testing.B, workers int) {
+	img := screenshot4K()
+	detector := NewElementDetector(*logger.NewLogger(false))
+	detector.SetWorkers(workers)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_ = detector.detectImage(img)
+	}
+}
+
+// BenchmarkDetectElements_4K_Serial is the baseline of one worker
+func BenchmarkDetectElements_4K_Serial(b *testing.B) { benchmarkDetect(b, 1) }
+
+// BenchmarkDetectElements_4K_Parallel uses a worker per CPU
+func BenchmarkDetectElements_4K_Parallel(b *testing.B) { benchmarkDetect(b, 0) }
+
+// BenchmarkGrayscale_4K converts a 4K screenshot into a recycled buffer
+func BenchmarkGrayscale_4K(b *testing.B) {
+	img := screenshot4K()
+	detector := NewElementDetector(*logger.NewLogger(false))
+	pix := make([]uint8, img.Bounds().Dx()*img.Bounds().Dy())
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_ = detector.grayscale(img, pix)
+	}
+}
@@ -0,0 +1,132 @@
+package vision
+
+import (
+	"image"
+	"image/color"
+	"math/rand"
+	"testing"
+
+	"panoptic/internal/logger"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// noisyImage fills an image of the given model with random pixels
+func noisyImage(bounds image.Rectangle, seed int64, set func(x, y int, c color.NRGBA)) {
+	rng := rand.New(rand.NewSource(seed))
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			set(x, y, color.NRGBA{uint8(rng.Intn(256)), uint8(rng.Intn(256)), uint8(rng.Intn(256)), uint8(rng.Intn(256))})
+		}
+	}
+}
+
+// TestGrayscale tests that every pixel format converts as color.GrayModel does
+func TestGrayscale(t *testing.T) {
+	bounds := image.Rect(0, 0, 97, 71)
+	rgba, nrgba := image.NewRGBA(bounds), image.NewNRGBA(bounds)
+	noisyImage(bounds, 1, func(x, y int, c color.NRGBA) { rgba.Set(x, y, c) })
+	noisyImage(bounds, 2, func(x, y int, c color.NRGBA) { nrgba.SetNRGBA(x, y, c) })
+	ycbcr := image.NewYCbCr(bounds, image.YCbCrSubsampleRatio420)
+	for i := range ycbcr.Y {
+		ycbcr.Y[i] = uint8(i * 7)
+	}
+
+	detector := NewElementDetector(*logger.NewLogger(false))
+	detector.SetWorkers(3)
+	for name, img := range map[string]image.Image{
+		"rgba": rgba, "nrgba": nrgba, "ycbcr": ycbcr, "gray": detector.convertToGrayscale(rgba),
+		"sub-image": nrgba.SubImage(image.Rect(13, 9, 80, 60)),
+	} {
+		gray := detector.convertToGrayscale(img)
+		require.Equal(t, img.Bounds(), gray.Bounds(), name)
+		for y := img.Bounds().Min.Y; y < img.Bounds().Max.Y; y++ {
+			for x := img.Bounds().Min.X; x < img.Bounds().Max.X; x++ {
+				want := color.GrayModel.Convert(img.At(x, y)).(color.Gray)
+				if gray.GrayAt(x, y) != want {
+					t.Fatalf("%s: pixel %d,%d is %d, want %d", name, x, y, gray.GrayAt(x, y).Y, want.Y)
+				}
+			}
+		}
+	}
+}
+
+// TestForEachTile tests that every row is processed once, whatever the
+// number of workers
+func TestForEachTile(t *testing.T) {
+	detector := NewElementDetector(*logger.NewLogger(false))
+	for _, workers := range []int{0, 1, 4, 64} {
+		detector.SetWorkers(workers)
+		for _, n := range []int{0, 1, tileRows, tileRows*3 + 5} {
+			seen := make([]int, n)
+			detector.forEachTile(n, func(tile, lo, hi int) {
+				assert.Equal(t, tile*tileRows, lo)
+				for row := lo; row < hi; row++ {
+					seen[row]++
+				}
+			})
+			for row, count := range seen {
+				require.Equal(t, 1, count, "row %d of %d with %d workers", row, n, workers)
+			}
+		}
+	}
+}
+
+// TestDetectImage_Workers tests that tiling across workers finds the same
+// elements in the same order as a single worker
+func TestDetectImage_Workers(t *testing.T) {
+	page := image.NewNRGBA(image.Rect(0, 0, 640, 480))
+	noisyImage(image.Rect(0, 0, 320, 240), 3, func(x, y int, c color.NRGBA) { page.SetNRGBA(x, y, c) })
+	for y := 240; y < 480; y++ {
+		for x := 0; x < 640; x++ {
+			page.SetNRGBA(x, y, color.NRGBA{uint8(x), uint8(y), 200, 255})
+		}
+	}
+
+	detector := NewElementDetector(*logger.NewLogger(false))
+	detector.SetWorkers(1)
+	serial := detector.detectImage(page)
+	require.NotEmpty(t, serial)
+	detector.SetWorkers(8)
+	assert.Equal(t, serial, detector.detectImage(page))
+
+	small := page.SubImage(image.Rect(0, 0, 100, 80))
+	detector.SetWorkers(1)
+	want := detector.detectImage(small)
+	detector.SetWorkers(8)
+	assert.Equal(t, want, detector.detectImage(small), "a recycled larger buffer")
+	assert.Equal(t, serial, detector.detectImage(page))
+}
+
+// TestCalculateColorVariance_Reference tests the row-wise variance against
+// the per-pixel definition, including regions reaching outside the image
+func TestCalculateColorVariance_Reference(t *testing.T) {
+	detector := NewElementDetector(*logger.NewLogger(false))
+	nrgba := image.NewNRGBA(image.Rect(0, 0, 60, 50))
+	noisyImage(nrgba.Bounds(), 4, func(x, y int, c color.NRGBA) { nrgba.SetNRGBA(x, y, c) })
+	gray := detector.convertToGrayscale(nrgba)
+	shifted := detector.convertToGrayscale(nrgba.SubImage(image.Rect(5, 5, 60, 50)))
+
+	reference := func(img *image.Gray, x, y, width, height int) float64 {
+		var sum, variance float64
+		for dy := 0; dy < height; dy++ {
+			for dx := 0; dx < width; dx++ {
+				sum += float64(img.GrayAt(x+dx, y+dy).Y)
+			}
+		}
+		mean := sum / float64(width*height)
+		for dy := 0; dy < height; dy++ {
+			for dx := 0; dx < width; dx++ {
+				diff := float64(img.GrayAt(x+dx, y+dy).Y) - mean
+				variance += diff * diff
+			}
+		}
+		return variance / float64(width*height)
+	}
+	for _, r := range []image.Rectangle{image.Rect(0, 0, 20, 20), image.Rect(17, 3, 27, 8), image.Rect(2, 2, 22, 22)} {
+		assert.Equal(t, reference(gray, r.Min.X, r.Min.Y, r.Dx(), r.Dy()), detector.calculateColorVariance(gray, r.Min.X, r.Min.Y, r.Dx(), r.Dy()))
+		assert.Equal(t, reference(shifted, r.Min.X, r.Min.Y, r.Dx(), r.Dy()), detector.calculateColorVariance(shifted, r.Min.X, r.Min.Y, r.Dx(), r.Dy()), "outside pixels read as black")
+	}
+	assert.Zero(t, detector.calculateColorVariance(gray, 0, 0, 0, 5))
+}
@@ -0,0 +1,408 @@
+package vision
+
+import (
+	"fmt"
+	"math/bits"
+	"strings"
+	"unicode/utf8"
+)
+
+// qrMaxVersion is the largest QR code version decoded; version 10 holds up
+// to 271 bytes at level L, enough for pairing codes and payment links
+const qrMaxVersion = 10
+
+// qrBlocks describes the error correction blocks of a version and level:
+// EC codewords per block, then the count and data codewords of each group
+type qrBlocks struct {
+	ec            int
+	count1, data1 int
+	count2, data2 int
+}
+
+// qrECLevelNames are indexed by the two error correction bits of the format
+// information: M=00, L=01, H=10, Q=11
+var qrECLevelNames = [4]string{"M", "L", "H", "Q"}
+
+// qrBlockTable[version-1][level] with levels ordered as qrECLevelNames
+var qrBlockTable = [qrMaxVersion][4]qrBlocks{
+	{{10, 1, 16, 0, 0}, {7, 1, 19, 0, 0}, {17, 1, 9, 0, 0}, {13, 1, 13, 0, 0}},
+	{{16, 1, 28, 0, 0}, {10, 1, 34, 0, 0}, {28, 1, 16, 0, 0}, {22, 1, 22, 0, 0}},
+	{{26, 1, 44, 0, 0}, {15, 1, 55, 0, 0}, {22, 2, 13, 0, 0}, {18, 2, 17, 0, 0}},
+	{{18, 2, 32, 0, 0}, {20, 1, 80, 0, 0}, {16, 4, 9, 0, 0}, {26, 2, 24, 0, 0}},
+	{{24, 2, 43, 0, 0}, {26, 1, 108, 0, 0}, {22, 2, 11, 2, 12}, {18, 2, 15, 2, 16}},
+	{{16, 4, 27, 0, 0}, {18, 2, 68, 0, 0}, {28, 4, 15, 0, 0}, {24, 4, 19, 0, 0}},
+	{{18, 4, 31, 0, 0}, {20, 2, 78, 0, 0}, {26, 4, 13, 1, 14}, {18, 2, 14, 4, 15}},
+	{{22, 2, 38, 2, 39}, {24, 2, 97, 0, 0}, {26, 4, 14, 2, 15}, {22, 4, 18, 2, 19}},
+	{{22, 3, 36, 2, 37}, {30, 2, 116, 0, 0}, {24, 4, 12, 4, 13}, {20, 4, 16, 4, 17}},
+	{{26, 4, 43, 1, 44}, {18, 2, 68, 2, 69}, {28, 6, 15, 2, 16}, {24, 6, 19, 2, 20}},
+}
+
+// qrAlignment lists the alignment pattern center coordinates per version
+var qrAlignment = [qrMaxVersion][]int{
+	nil, {6, 18}, {6, 22}, {6, 26}, {6, 30}, {6, 34},
+	{6, 22, 38}, {6, 24, 42}, {6, 26, 46}, {6, 28, 50},
+}
+
+// qrFormatCode returns the 15 format information bits, BCH(15,5) with mask,
+// for the EC level bits and mask pattern
+func qrFormatCode(level, mask int) int {
+	data := level<<3 | mask
+	rem := data << 10
+	for i := 14; i >= 10; i-- {
+		if rem&(1<<i) != 0 {
+			rem ^= 0x537 << (i - 10)
+		}
+	}
+	return (data<<10 | rem) ^ 0x5412
+}
+
+// qrVersionCode returns the 18 version information bits, BCH(18,6)
+func qrVersionCode(version int) int {
+	rem := version << 12
+	for i := 17; i >= 12; i-- {
+		if rem&(1<<i) != 0 {
+			rem ^= 0x1F25 << (i - 12)
+		}
+	}
+	return version<<12 | rem
+}
+
+// qrMasked reports whether mask pattern flips the module at row, col
+func qrMasked(mask, row, col int) bool {
+	switch mask {
+	case 0:
+		return (row+col)%2 == 0
+	case 1:
+		return row%2 == 0
+	case 2:
+		return col%3 == 0
+	case 3:
+		return (row+col)%3 == 0
+	case 4:
+		return (row/2+col/3)%2 == 0
+	case 5:
+		return row*col%2+row*col%3 == 0
+	case 6:
+		return (row*col%2+row*col%3)%2 == 0
+	default:
+		return ((row+col)%2+row*col%3)%2 == 0
+	}
+}
+
+// qrGrid is a sampled symbol, dark modules true, indexed [row][col]
+type qrGrid [][]bool
+
+// qrFunctionModules marks the finder, separator, timing, alignment, format
+// and version modules, which carry no data
+func qrFunctionModules(version int) [][]bool {
+	size := 17 + 4*version
+	grid := make([][]bool, size)
+	for i := range grid {
+		grid[i] = make([]bool, size)
+	}
+	fill := func(row, col, height, width int) {
+		for r := row; r < row+height; r++ {
+			for c := col; c < col+width; c++ {
+				grid[r][c] = true
+			}
+		}
+	}
+	fill(0, 0, 9, 9)
+	fill(0, size-8, 9, 8)
+	fill(size-8, 0, 8, 9)
+	fill(6, 9, 1, size-17)
+	fill(9, 6, size-17, 1)
+	centers := qrAlignment[version-1]
+	for i, row := range centers {
+		for j, col := range centers {
+			// Skip the three corners occupied by finder patterns
+			if (i == 0 && j == 0) || (i == 0 && j == len(centers)-1) || (i == len(centers)-1 && j == 0) {
+				continue
+			}
+			fill(row-2, col-2, 5, 5)
+		}
+	}
+	if version >= 7 {
+		fill(0, size-11, 6, 3)
+		fill(size-11, 0, 3, 6)
+	}
+	return grid
+}
+
+// readFormat returns the EC level bits and mask from the closer of the two
+// format information copies, correcting up to 3 bit errors
+func (g qrGrid) readFormat() (level, mask int, err error) {
+	size := len(g)
+	bit := func(row, col int) int {
+		if g[row][col] {
+			return 1
+		}
+		return 0
+	}
+	var first, second int
+	for col := 0; col <= 5; col++ {
+		first = first<<1 | bit(8, col)
+	}
+	first = first<<1 | bit(8, 7)
+	first = first<<1 | bit(8, 8)
+	first = first<<1 | bit(7, 8)
+	for row := 5; row >= 0; row-- {
+		first = first<<1 | bit(row, 8)
+	}
+	for row := size - 1; row >= size-7; row-- {
+		second = second<<1 | bit(row, 8)
+	}
+	for col := size - 8; col < size; col++ {
+		second = second<<1 | bit(8, col)
+	}
+
+	best, bestDistance := -1, 4
+	for data := 0; data < 32; data++ {
+		code := qrFormatCode(data>>3, data&7)
+		for _, read := range []int{first, second} {
+			if d := bits.OnesCount(uint(code ^ read)); d < bestDistance {
+				best, bestDistance = data, d
+			}
+		}
+	}
+	if best < 0 {
+		return 0, 0, fmt.Errorf("unreadable format information")
+	}
+	return best >> 3, best & 7, nil
+}
+
+// decode reads the symbol's data codewords, corrects them and decodes the
+// text they hold
+func (g qrGrid) decode() (string, error) {
+	size := len(g)
+	version := (size - 17) / 4
+	if size < 21 || (size-17)%4 != 0 {
+		return "", fmt.Errorf("invalid QR size %d", size)
+	}
+	if version > qrMaxVersion {
+		return "", fmt.Errorf("QR version %d is not supported (1-%d are)", version, qrMaxVersion)
+	}
+	level, mask, err := g.readFormat()
+	if err != nil {
+		return "", err
+	}
+
+	// Read codewords in the two-column zigzag from the bottom right,
+	// skipping the vertical timing column
+	function := qrFunctionModules(version)
+	var codewords []byte
+	var current byte
+	count := 0
+	up := true
+	for col := size - 1; col > 0; col -= 2 {
+		if col == 6 {
+			col--
+		}
+		for i := 0; i < size; i++ {
+			row := i
+			if up {
+				row = size - 1 - i
+			}
+			for c := col; c > col-2; c-- {
+				if function[row][c] {
+					continue
+				}
+				current <<= 1
+				if g[row][c] != qrMasked(mask, row, c) {
+					current |= 1
+				}
+				if count++; count%8 == 0 {
+					codewords = append(codewords, current)
+					current = 0
+				}
+			}
+		}
+		up = !up
+	}
+
+	data, err := qrDeinterleave(codewords, qrBlockTable[version-1][level])
+	if err != nil {
+		return "", err
+	}
+	return qrDecodeSegments(data, version)
+}
+
+// qrDeinterleave splits the codewords into blocks, corrects each and joins
+// their data codewords
+func qrDeinterleave(codewords []byte, layout qrBlocks) ([]byte, error) {
+	type block struct{ data, all []byte }
+	var blocks []*block
+	for i := 0; i < layout.count1+layout.count2; i++ {
+		dataLen := layout.data1
+		if i >= layout.count1 {
+			dataLen = layout.data2
+		}
+		blocks = append(blocks, &block{data: make([]byte, 0, dataLen), all: make([]byte, 0, dataLen+layout.ec)})
+	}
+	total := layout.count1*(layout.data1+layout.ec) + layout.count2*(layout.data2+layout.ec)
+	if len(codewords) < total {
+		return nil, fmt.Errorf("read %d codewords, expected %d", len(codewords), total)
+	}
+
+	next := 0
+	maxData := max(layout.data1, layout.data2)
+	for i := 0; i < maxData; i++ {
+		for _, b := range blocks {
+			if len(b.data) < cap(b.data) {
+				b.data = append(b.data, codewords[next])
+				next++
+			}
+		}
+	}
+	for _, b := range blocks {
+		b.all = append(b.all, b.data...)
+	}
+	for i := 0; i < layout.ec; i++ {
+		for _, b := range blocks {
+			b.all = append(b.all, codewords[next])
+			next++
+		}
+	}
+
+	var data []byte
+	for _, b := range blocks {
+		if _, err := rsCorrect(b.all, layout.ec); err != nil {
+			return nil, err
+		}
+		data = append(data, b.all[:len(b.data)]...)
+	}
+	return data, nil
+}
+
+// bitReader reads big-endian bit fields
+type bitReader struct {
+	data []byte
+	pos  int
+}
+
+func (r *bitReader) remaining() int {
+	return len(r.data)*8 - r.pos
+}
+
+func (r *bitReader) read(n int) (int, error) {
+	if n > r.remaining() {
+		return 0, fmt.Errorf("data ends inside a segment")
+	}
+	v := 0
+	for i := 0; i < n; i++ {
+		b := r.data[r.pos/8] >> (7 - r.pos%8) & 1
+		v = v<<1 | int(b)
+		r.pos++
+	}
+	return v, nil
+}
+
+const qrAlphanumeric = "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZ $%*+-./:"
+
+// qrDecodeSegments decodes numeric, alphanumeric and byte segments. Byte
+// segments are UTF-8 when valid, or under ECI 26, and ISO-8859-1 otherwise.
+func qrDecodeSegments(data []byte, version int) (string, error) {
+	countBits := [3]int{10, 9, 8} // numeric, alphanumeric, byte
+	if version >= 10 {
+		countBits = [3]int{12, 11, 16}
+	}
+
+	r := &bitReader{data: data}
+	var out strings.Builder
+	eci := -1
+	for r.remaining() >= 4 {
+		mode, _ := r.read(4)
+		switch mode {
+		case 0: // terminator
+			return out.String(), nil
+		case 1: // numeric
+			n, err := r.read(countBits[0])
+			if err != nil {
+				return "", err
+			}
+			for ; n > 0; n -= 3 {
+				digits, width := min(n, 3), [4]int{0, 4, 7, 10}[min(n, 3)]
+				v, err := r.read(width)
+				if err != nil {
+					return "", err
+				}
+				out.WriteString(fmt.Sprintf("%0*d", digits, v))
+			}
+		case 2: // alphanumeric
+			n, err := r.read(countBits[1])
+			if err != nil {
+				return "", err
+			}
+			for ; n >= 2; n -= 2 {
+				v, err := r.read(11)
+				if err != nil || v >= 45*45 {
+					return "", fmt.Errorf("invalid alphanumeric segment")
+				}
+				out.WriteByte(qrAlphanumeric[v/45])
+				out.WriteByte(qrAlphanumeric[v%45])
+			}
+			if n == 1 {
+				v, err := r.read(6)
+				if err != nil || v >= 45 {
+					return "", fmt.Errorf("invalid alphanumeric segment")
+				}
+				out.WriteByte(qrAlphanumeric[v])
+			}
+		case 4: // byte
+			n, err := r.read(countBits[2])
+			if err != nil {
+				return "", err
+			}
+			segment := make([]byte, n)
+			for i := range segment {
+				v, err := r.read(8)
+				if err != nil {
+					return "", err
+				}
+				segment[i] = byte(v)
+			}
+			if eci == 26 || (eci < 0 && utf8.Valid(segment)) {
+				out.Write(segment)
+			} else {
+				for _, b := range segment {
+					out.WriteRune(rune(b))
+				}
+			}
+		case 7: // ECI designator
+			first, err := r.read(8)
+			if err != nil {
+				return "", err
+			}
+			switch {
+			case first&0x80 == 0:
+				eci = first
+			case first&0xC0 == 0x80:
+				rest, err := r.read(8)
+				if err != nil {
+					return "", err
+				}
+				eci = (first&0x3F)<<8 | rest
+			default:
+				rest, err := r.read(16)
+				if err != nil {
+					return "", err
+				}
+				eci = (first&0x1F)<<16 | rest
+			}
+		case 3: // structured append: sequence and parity
+			if _, err := r.read(16); err != nil {
+				return "", err
+			}
+		case 5: // FNC1 in first position
+		case 9: // FNC1 in second position: application indicator
+			if _, err := r.read(8); err != nil {
+				return "", err
+			}
+		case 8:
+			return "", fmt.Errorf("kanji segments are not supported")
+		default:
+			return "", fmt.Errorf("unknown segment mode %d", mode)
+		}
+	}
+	return out.String(), nil
+}
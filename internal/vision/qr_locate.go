@@ -0,0 +1,321 @@
+package vision
+
+import (
+	"image"
+	"math"
+	"sort"
+)
+
+// binaryImage is a thresholded image, dark pixels true
+type binaryImage struct {
+	width, height int
+	dark          []bool
+}
+
+func (b *binaryImage) at(x, y int) bool {
+	if x < 0 || y < 0 || x >= b.width || y >= b.height {
+		return false
+	}
+	return b.dark[y*b.width+x]
+}
+
+// binarize thresholds the image's luminance with Otsu's method, which splits
+// codes from their background in flat UI screenshots
+func binarize(img image.Image) *binaryImage {
+	bounds := img.Bounds()
+	w, h := bounds.Dx(), bounds.Dy()
+	luma := make([]uint8, w*h)
+	var histogram [256]int
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			r, g, b, _ := img.At(bounds.Min.X+x, bounds.Min.Y+y).RGBA()
+			l := uint8((299*r + 587*g + 114*b) / 1000 >> 8)
+			luma[y*w+x] = l
+			histogram[l]++
+		}
+	}
+
+	total := w * h
+	var sum float64
+	for i, n := range histogram {
+		sum += float64(i * n)
+	}
+	var sumBackground, best float64
+	var weightBackground int
+	threshold := 128
+	for i, n := range histogram {
+		weightBackground += n
+		if weightBackground == 0 {
+			continue
+		}
+		weightForeground := total - weightBackground
+		if weightForeground == 0 {
+			break
+		}
+		sumBackground += float64(i * n)
+		meanBackground := sumBackground / float64(weightBackground)
+		meanForeground := (sum - sumBackground) / float64(weightForeground)
+		between := float64(weightBackground) * float64(weightForeground) * (meanBackground - meanForeground) * (meanBackground - meanForeground)
+		if between > best {
+			best, threshold = between, i
+		}
+	}
+
+	out := &binaryImage{width: w, height: h, dark: make([]bool, w*h)}
+	for i, l := range luma {
+		out.dark[i] = int(l) <= threshold
+	}
+	return out
+}
+
+// finderPattern is a candidate center of one of the three corner squares
+type finderPattern struct {
+	x, y, module float64
+	hits         int
+}
+
+// finderRatio reports whether five run lengths look like the 1:1:3:1:1
+// dark-light-dark-light-dark profile of a finder pattern
+func finderRatio(runs [5]int) bool {
+	total := 0
+	for _, r := range runs {
+		if r == 0 {
+			return false
+		}
+		total += r
+	}
+	if total < 7 {
+		return false
+	}
+	module := float64(total) / 7
+	tolerance := module / 2
+	return math.Abs(module-float64(runs[0])) < tolerance &&
+		math.Abs(module-float64(runs[1])) < tolerance &&
+		math.Abs(3*module-float64(runs[2])) < 3*tolerance &&
+		math.Abs(module-float64(runs[3])) < tolerance &&
+		math.Abs(module-float64(runs[4])) < tolerance
+}
+
+// crossCheck measures the finder profile through (x, y) along (dx, dy),
+// returning the refined center coordinate along that axis and the total
+// length, or ok false when the profile doesn't match
+func (b *binaryImage) crossCheck(x, y, dx, dy int) (center float64, total int, ok bool) {
+	if !b.at(x, y) {
+		return 0, 0, false
+	}
+	var runs [5]int
+	// Backwards from the center: the center run, its light ring, its dark ring
+	cx, cy := x, y
+	for state := 2; state >= 0; state-- {
+		want := state != 1
+		for b.at(cx, cy) == want && inside(b, cx, cy) {
+			runs[state]++
+			cx, cy = cx-dx, cy-dy
+		}
+	}
+	// Forwards
+	cx, cy = x+dx, y+dy
+	for state := 2; state <= 4; state++ {
+		want := state != 3
+		for b.at(cx, cy) == want && inside(b, cx, cy) {
+			runs[state]++
+			cx, cy = cx+dx, cy+dy
+		}
+	}
+	if !finderRatio(runs) {
+		return 0, 0, false
+	}
+	end := cx // just past the profile along the axis
+	if dx == 0 {
+		end = cy
+	}
+	center = float64(end) - float64(runs[4]+runs[3]) - float64(runs[2])/2
+	for _, r := range runs {
+		total += r
+	}
+	return center, total, true
+}
+
+func inside(b *binaryImage, x, y int) bool {
+	return x >= 0 && y >= 0 && x < b.width && y < b.height
+}
+
+// findFinderPatterns scans rows for finder profiles and confirms each
+// vertically and horizontally
+func (b *binaryImage) findFinderPatterns() []*finderPattern {
+	var found []*finderPattern
+	for y := 0; y < b.height; y++ {
+		var runs [5]int
+		state := 0 // even states are dark runs, odd states light ones
+		for x := 0; x <= b.width; x++ {
+			dark := x < b.width && b.at(x, y)
+			if state == 0 && runs[0] == 0 && !dark {
+				continue
+			}
+			if x < b.width && dark == (state%2 == 0) {
+				runs[state]++
+				continue
+			}
+			if state < 4 {
+				state++
+				runs[state] = 1
+				continue
+			}
+			// A dark-light-dark-light-dark profile ended at x
+			if finderRatio(runs) {
+				centerX := float64(x-runs[4]-runs[3]) - float64(runs[2])/2
+				if p := b.confirmFinder(int(centerX), y); p != nil {
+					found = mergeFinder(found, p)
+				}
+			}
+			// Keep the last dark-light pair as the start of the next profile
+			runs = [5]int{runs[2], runs[3], runs[4], 1, 0}
+			state = 3
+		}
+	}
+	return found
+}
+
+// confirmFinder cross-checks a horizontal candidate vertically, then
+// horizontally through the refined center
+func (b *binaryImage) confirmFinder(x, y int) *finderPattern {
+	centerY, totalY, ok := b.crossCheck(x, y, 0, 1)
+	if !ok {
+		return nil
+	}
+	centerX, totalX, ok := b.crossCheck(x, int(centerY), 1, 0)
+	if !ok {
+		return nil
+	}
+	if ratio := float64(totalX) / float64(totalY); ratio < 0.6 || ratio > 1.6 {
+		return nil
+	}
+	return &finderPattern{x: centerX, y: centerY, module: float64(totalX+totalY) / 14, hits: 1}
+}
+
+// mergeFinder averages p into a pattern found earlier at the same spot
+func mergeFinder(found []*finderPattern, p *finderPattern) []*finderPattern {
+	for _, f := range found {
+		if math.Abs(f.x-p.x) <= f.module*2 && math.Abs(f.y-p.y) <= f.module*2 && math.Abs(f.module-p.module) <= f.module {
+			n := float64(f.hits)
+			f.x = (f.x*n + p.x) / (n + 1)
+			f.y = (f.y*n + p.y) / (n + 1)
+			f.module = (f.module*n + p.module) / (n + 1)
+			f.hits++
+			return found
+		}
+	}
+	return append(found, p)
+}
+
+// qrCandidate is three finder patterns that may form a symbol
+type qrCandidate struct {
+	topLeft, topRight, bottomLeft *finderPattern
+	score                         float64
+}
+
+// groupFinders picks disjoint triples of finder patterns of similar size
+// forming a right isosceles triangle, best fitting first
+func groupFinders(patterns []*finderPattern) []qrCandidate {
+	var candidates []qrCandidate
+	for i := 0; i < len(patterns); i++ {
+		for j := i + 1; j < len(patterns); j++ {
+			for k := j + 1; k < len(patterns); k++ {
+				if c, ok := triangle(patterns[i], patterns[j], patterns[k]); ok {
+					candidates = append(candidates, c)
+				}
+			}
+		}
+	}
+	sort.Slice(candidates, func(a, b int) bool { return candidates[a].score < candidates[b].score })
+
+	used := map[*finderPattern]bool{}
+	var chosen []qrCandidate
+	for _, c := range candidates {
+		if used[c.topLeft] || used[c.topRight] || used[c.bottomLeft] {
+			continue
+		}
+		used[c.topLeft], used[c.topRight], used[c.bottomLeft] = true, true, true
+		chosen = append(chosen, c)
+	}
+	return chosen
+}
+
+func distance(a, b *finderPattern) float64 {
+	return math.Hypot(a.x-b.x, a.y-b.y)
+}
+
+// triangle orders three patterns as top-left (the right angle), top-right
+// and bottom-left, scoring how far they are from an ideal symbol
+func triangle(a, b, c *finderPattern) (qrCandidate, bool) {
+	sizes := []float64{a.module, b.module, c.module}
+	sort.Float64s(sizes)
+	if sizes[2] > sizes[0]*1.5 {
+		return qrCandidate{}, false
+	}
+	ab, bc, ca := distance(a, b), distance(b, c), distance(c, a)
+	// The right angle is opposite the longest side
+	var corner, p, q *finderPattern
+	var legs [2]float64
+	var hypotenuse float64
+	switch {
+	case bc >= ab && bc >= ca:
+		corner, p, q, legs, hypotenuse = a, b, c, [2]float64{ab, ca}, bc
+	case ca >= ab && ca >= bc:
+		corner, p, q, legs, hypotenuse = b, c, a, [2]float64{ab, bc}, ca
+	default:
+		corner, p, q, legs, hypotenuse = c, a, b, [2]float64{bc, ca}, ab
+	}
+	module := (a.module + b.module + c.module) / 3
+	if legs[0] < 7*module || legs[1] < 7*module {
+		return qrCandidate{}, false
+	}
+	legRatio := math.Abs(legs[0]-legs[1]) / math.Max(legs[0], legs[1])
+	hypotenuseError := math.Abs(hypotenuse-math.Sqrt2*(legs[0]+legs[1])/2) / hypotenuse
+	if legRatio > 0.15 || hypotenuseError > 0.1 {
+		return qrCandidate{}, false
+	}
+	// With y pointing down, top-right to bottom-left turns clockwise
+	cross := (p.x-corner.x)*(q.y-corner.y) - (p.y-corner.y)*(q.x-corner.x)
+	if cross < 0 {
+		p, q = q, p
+	}
+	return qrCandidate{topLeft: corner, topRight: p, bottomLeft: q, score: legRatio + hypotenuseError}, true
+}
+
+// sizes returns the symbol sizes to try: the one estimated from the finder
+// distance, then its neighbours
+func (c qrCandidate) sizes() []int {
+	module := (c.topLeft.module + c.topRight.module + c.bottomLeft.module) / 3
+	estimate := (distance(c.topLeft, c.topRight)+distance(c.topLeft, c.bottomLeft))/2/module + 7
+	size := int(math.Round(estimate))
+	// Valid sizes are 17+4v, i.e. 1 mod 4
+	switch size % 4 {
+	case 0:
+		size++
+	case 2:
+		size--
+	case 3:
+		size += 2
+	}
+	return []int{size, size - 4, size + 4}
+}
+
+// sample reads a size×size module grid, mapping module centers through the
+// affine transform fixed by the three finder centers
+func (c qrCandidate) sample(b *binaryImage, size int) qrGrid {
+	span := float64(size - 7) // modules between finder centers
+	ux, uy := (c.topRight.x-c.topLeft.x)/span, (c.topRight.y-c.topLeft.y)/span
+	vx, vy := (c.bottomLeft.x-c.topLeft.x)/span, (c.bottomLeft.y-c.topLeft.y)/span
+	grid := make(qrGrid, size)
+	for row := range grid {
+		grid[row] = make([]bool, size)
+		for col := range grid[row] {
+			mx, my := float64(col)-3, float64(row)-3
+			x := c.topLeft.x + mx*ux + my*vx
+			y := c.topLeft.y + mx*uy + my*vy
+			grid[row][col] = b.at(int(math.Floor(x)), int(math.Floor(y)))
+		}
+	}
+	return grid
+}
@@ -0,0 +1,336 @@
+package vision
+
+import (
+	"image"
+	"image/color"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// qrLevel maps level names to their format bits
+var qrLevel = map[string]int{"M": 0, "L": 1, "H": 2, "Q": 3}
+
+// encodeQR builds a byte-mode symbol the way an encoder would, placing the
+// format bits by the positions of the specification
+func encodeQR(t *testing.T, text string, version int, level string, mask int) qrGrid {
+	t.Helper()
+	layout := qrBlockTable[version-1][qrLevel[level]]
+	capacity := layout.count1*layout.data1 + layout.count2*layout.data2
+
+	// Mode, count, bytes, terminator and padding
+	var bitsOut []bool
+	put := func(v, n int) {
+		for i := n - 1; i >= 0; i-- {
+			bitsOut = append(bitsOut, v>>i&1 == 1)
+		}
+	}
+	put(4, 4)
+	countBits := 8
+	if version >= 10 {
+		countBits = 16
+	}
+	put(len(text), countBits)
+	for i := 0; i < len(text); i++ {
+		put(int(text[i]), 8)
+	}
+	require.LessOrEqual(t, len(bitsOut), capacity*8, "text too long for %d-%s", version, level)
+	put(0, min(4, capacity*8-len(bitsOut)))
+	for len(bitsOut)%8 != 0 {
+		bitsOut = append(bitsOut, false)
+	}
+	var data []byte
+	for i := 0; i < len(bitsOut); i += 8 {
+		var b byte
+		for _, bit := range bitsOut[i : i+8] {
+			b <<= 1
+			if bit {
+				b |= 1
+			}
+		}
+		data = append(data, b)
+	}
+	for pad := 0; len(data) < capacity; pad++ {
+		data = append(data, []byte{0xEC, 0x11}[pad%2])
+	}
+
+	// Blocks, EC and interleaving
+	var blocks, ecs [][]byte
+	offset := 0
+	for i := 0; i < layout.count1+layout.count2; i++ {
+		n := layout.data1
+		if i >= layout.count1 {
+			n = layout.data2
+		}
+		blocks = append(blocks, data[offset:offset+n])
+		ecs = append(ecs, rsEncode(data[offset:offset+n], layout.ec))
+		offset += n
+	}
+	var codewords []byte
+	for i := 0; i < max(layout.data1, layout.data2); i++ {
+		for _, b := range blocks {
+			if i < len(b) {
+				codewords = append(codewords, b[i])
+			}
+		}
+	}
+	for i := 0; i < layout.ec; i++ {
+		for _, ec := range ecs {
+			codewords = append(codewords, ec[i])
+		}
+	}
+
+	// Function patterns
+	size := 17 + 4*version
+	grid := make(qrGrid, size)
+	for i := range grid {
+		grid[i] = make([]bool, size)
+	}
+	finder := func(row, col int) {
+		for r := 0; r < 7; r++ {
+			for c := 0; c < 7; c++ {
+				grid[row+r][col+c] = r == 0 || r == 6 || c == 0 || c == 6 || (r >= 2 && r <= 4 && c >= 2 && c <= 4)
+			}
+		}
+	}
+	finder(0, 0)
+	finder(0, size-7)
+	finder(size-7, 0)
+	for i := 8; i < size-8; i++ {
+		grid[6][i] = i%2 == 0
+		grid[i][6] = i%2 == 0
+	}
+	centers := qrAlignment[version-1]
+	for i, row := range centers {
+		for j, col := range centers {
+			if (i == 0 && j == 0) || (i == 0 && j == len(centers)-1) || (i == len(centers)-1 && j == 0) {
+				continue
+			}
+			for r := -2; r <= 2; r++ {
+				for c := -2; c <= 2; c++ {
+					grid[row+r][col+c] = max(abs(r), abs(c)) != 1
+				}
+			}
+		}
+	}
+	grid[size-8][8] = true // dark module
+
+	// Data in the zigzag, masked
+	function := qrFunctionModules(version)
+	bit := 0
+	up := true
+	for col := size - 1; col > 0; col -= 2 {
+		if col == 6 {
+			col--
+		}
+		for i := 0; i < size; i++ {
+			row := i
+			if up {
+				row = size - 1 - i
+			}
+			for c := col; c > col-2; c-- {
+				if function[row][c] {
+					continue
+				}
+				dark := false
+				if bit < len(codewords)*8 {
+					dark = codewords[bit/8]>>(7-bit%8)&1 == 1
+				}
+				bit++
+				grid[row][c] = dark != qrMasked(mask, row, c)
+			}
+		}
+		up = !up
+	}
+
+	// Format information: bit 14 first along each copy
+	format := qrFormatCode(qrLevel[level], mask)
+	formatBit := func(i int) bool { return format>>(14-i)&1 == 1 }
+	firstCopy := [][2]int{{8, 0}, {8, 1}, {8, 2}, {8, 3}, {8, 4}, {8, 5}, {8, 7}, {8, 8}, {7, 8}, {5, 8}, {4, 8}, {3, 8}, {2, 8}, {1, 8}, {0, 8}}
+	for i, rc := range firstCopy {
+		grid[rc[0]][rc[1]] = formatBit(i)
+	}
+	for i := 0; i < 7; i++ {
+		grid[size-1-i][8] = formatBit(i)
+	}
+	for i := 7; i < 15; i++ {
+		grid[8][size-15+i] = formatBit(i)
+	}
+
+	// Version information, least significant bit nearest the corner
+	if version >= 7 {
+		code := qrVersionCode(version)
+		for i := 0; i < 18; i++ {
+			dark := code>>i&1 == 1
+			grid[i/3][size-11+i%3] = dark
+			grid[size-11+i%3][i/3] = dark
+		}
+	}
+	return grid
+}
+
+// renderQR draws a grid with a 4-module quiet zone at scale pixels a module
+func renderQR(grid qrGrid, scale int) *image.Gray {
+	size := len(grid) + 8
+	img := image.NewGray(image.Rect(0, 0, size*scale, size*scale))
+	for y := 0; y < size*scale; y++ {
+		for x := 0; x < size*scale; x++ {
+			row, col := y/scale-4, x/scale-4
+			c := color.Gray{Y: 255}
+			if row >= 0 && col >= 0 && row < len(grid) && col < len(grid) && grid[row][col] {
+				c = color.Gray{Y: 0}
+			}
+			img.SetGray(x, y, c)
+		}
+	}
+	return img
+}
+
+// TestQRFormatCode tests BCH codes against values of the specification tables
+func TestQRFormatCode(t *testing.T) {
+	assert.Equal(t, 0b111011111000100, qrFormatCode(1, 0), "L, mask 0")
+	assert.Equal(t, 0b101010000010010, qrFormatCode(0, 0), "M, mask 0")
+	assert.Equal(t, 0b110011000101111, qrFormatCode(1, 4), "L, mask 4")
+	assert.Equal(t, 0x07C94, qrVersionCode(7))
+	assert.Equal(t, 0x0A4D3, qrVersionCode(10))
+}
+
+// TestQRBlockTable tests that every layout fills its version's codewords
+func TestQRBlockTable(t *testing.T) {
+	totals := []int{26, 44, 70, 100, 134, 172, 196, 242, 292, 346}
+	for v, levels := range qrBlockTable {
+		for level, b := range levels {
+			assert.Equal(t, totals[v], b.count1*(b.data1+b.ec)+b.count2*(b.data2+b.ec), "version %d level %s", v+1, qrECLevelNames[level])
+		}
+	}
+}
+
+// TestDecodeCodes_QR tests decoding symbols of several versions, levels and
+// masks from rendered images
+func TestDecodeCodes_QR(t *testing.T) {
+	cases := []struct {
+		text    string
+		version int
+		level   string
+		mask    int
+	}{
+		{"PAIR-4821", 1, "M", 0},
+		{"https://pay.example.com/i/8f3b2c?amount=12.50&currency=EUR", 4, "L", 3},
+		{"Grüße aus Köln", 2, "Q", 5},
+		{strings.Repeat("otpauth://totp/Shop:alice?secret=JBSWY3DPEHPK3PXP&", 3), 8, "M", 6},
+		{strings.Repeat("0123456789", 20), 10, "L", 7},
+	}
+	for _, c := range cases {
+		grid := encodeQR(t, c.text, c.version, c.level, c.mask)
+		codes := DecodeCodes(renderQR(grid, 3))
+		require.Len(t, codes, 1, "%d-%s %q", c.version, c.level, c.text)
+		assert.Equal(t, "qr", codes[0].Format)
+		assert.Equal(t, c.text, codes[0].Text)
+	}
+}
+
+// TestDecodeCodes_QRDamaged tests error correction of smudged modules
+func TestDecodeCodes_QRDamaged(t *testing.T) {
+	grid := encodeQR(t, "https://shop.test/pair?code=771204", 5, "H", 2)
+	for row := 12; row < 18; row++ {
+		for col := 12; col < 18; col++ {
+			grid[row][col] = true
+		}
+	}
+	codes := DecodeCodes(renderQR(grid, 4))
+	require.Len(t, codes, 1)
+	assert.Equal(t, "https://shop.test/pair?code=771204", codes[0].Text)
+}
+
+// TestDecodeCodes_QRPlacement tests a rotated symbol, two symbols in one
+// image, and the reported bounds
+func TestDecodeCodes_QRPlacement(t *testing.T) {
+	first := renderQR(encodeQR(t, "left", 1, "L", 1), 4)
+	second := renderQR(encodeQR(t, "right", 2, "M", 4), 3)
+
+	canvas := image.NewGray(image.Rect(0, 0, 400, 220))
+	for i := range canvas.Pix {
+		canvas.Pix[i] = 255
+	}
+	// The first symbol rotated by 90 degrees at (10, 10)
+	fb := first.Bounds()
+	for y := 0; y < fb.Dy(); y++ {
+		for x := 0; x < fb.Dx(); x++ {
+			canvas.SetGray(10+fb.Dy()-1-y, 10+x, first.GrayAt(x, y))
+		}
+	}
+	sb := second.Bounds()
+	for y := 0; y < sb.Dy(); y++ {
+		for x := 0; x < sb.Dx(); x++ {
+			canvas.SetGray(250+x, 60+y, second.GrayAt(x, y))
+		}
+	}
+
+	codes := DecodeCodes(canvas)
+	texts := map[string]image.Rectangle{}
+	for _, c := range codes {
+		texts[c.Text] = c.Bounds
+	}
+	require.Len(t, texts, 2)
+	assert.Equal(t, image.Rect(26, 26, 110, 110), texts["left"], "21 modules at 4px inside the 16px quiet zone")
+	assert.Equal(t, image.Rect(262, 72, 337, 147), texts["right"])
+}
+
+// TestQRDecodeSegments tests numeric, alphanumeric and ECI segments
+func TestQRDecodeSegments(t *testing.T) {
+	// Numeric "01234567", alphanumeric "AC-42", then a Latin-1 byte after ECI 3
+	var bitsOut []bool
+	put := func(v, n int) {
+		for i := n - 1; i >= 0; i-- {
+			bitsOut = append(bitsOut, v>>i&1 == 1)
+		}
+	}
+	put(1, 4)
+	put(8, 10)
+	put(12, 10)
+	put(345, 10)
+	put(67, 7)
+	put(2, 4)
+	put(5, 9)
+	put(10*45+12, 11)
+	put(41*45+4, 11)
+	put(2, 6)
+	put(7, 4)
+	put(3, 8) // ECI 3, ISO-8859-1
+	put(4, 4)
+	put(1, 8)
+	put(0xE9, 8)
+	put(0, 4)
+	for len(bitsOut)%8 != 0 {
+		bitsOut = append(bitsOut, false)
+	}
+	packed := make([]byte, len(bitsOut)/8)
+	for i, b := range bitsOut {
+		if b {
+			packed[i/8] |= 1 << (7 - i%8)
+		}
+	}
+
+	text, err := qrDecodeSegments(packed, 1)
+	require.NoError(t, err)
+	assert.Equal(t, "01234567AC-42é", text)
+
+	_, err = qrDecodeSegments([]byte{0x80}, 1)
+	assert.ErrorContains(t, err, "kanji")
+}
+
+// TestQRGrid_DecodeUnsupported tests the honest errors for symbols the
+// decoder doesn't read
+func TestQRGrid_DecodeUnsupported(t *testing.T) {
+	grid := make(qrGrid, 61)
+	for i := range grid {
+		grid[i] = make([]bool, 61)
+	}
+	_, err := grid.decode()
+	assert.EqualError(t, err, "QR version 11 is not supported (1-10 are)")
+
+	_, err = make(qrGrid, 20).decode()
+	assert.ErrorContains(t, err, "invalid QR size")
+}
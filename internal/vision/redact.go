@@ -0,0 +1,74 @@
+package vision
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/jpeg"
+	"image/png"
+)
+
+// RedactBlockSize is the side of the blocks Pixelate averages regions into,
+// coarse enough that text in them can't be read back
+const RedactBlockSize = 16
+
+// Pixelate returns a copy of img with every region blurred into blocks of
+// block pixels of their average color
+func Pixelate(img image.Image, regions []image.Rectangle, block int) *image.RGBA {
+	if block < 1 {
+		block = RedactBlockSize
+	}
+	out := image.NewRGBA(img.Bounds())
+	draw.Draw(out, out.Bounds(), img, img.Bounds().Min, draw.Src)
+	for _, region := range regions {
+		region = region.Intersect(out.Bounds())
+		for y := region.Min.Y; y < region.Max.Y; y += block {
+			for x := region.Min.X; x < region.Max.X; x += block {
+				cell := image.Rect(x, y, x+block, y+block).Intersect(region)
+				draw.Draw(out, cell, &image.Uniform{averageColor(out, cell)}, image.Point{}, draw.Src)
+			}
+		}
+	}
+	return out
+}
+
+func averageColor(img *image.RGBA, r image.Rectangle) color.RGBA {
+	var sr, sg, sb, sa, n uint64
+	for y := r.Min.Y; y < r.Max.Y; y++ {
+		for x := r.Min.X; x < r.Max.X; x++ {
+			c := img.RGBAAt(x, y)
+			sr, sg, sb, sa = sr+uint64(c.R), sg+uint64(c.G), sb+uint64(c.B), sa+uint64(c.A)
+			n++
+		}
+	}
+	if n == 0 {
+		return color.RGBA{}
+	}
+	return color.RGBA{R: uint8(sr / n), G: uint8(sg / n), B: uint8(sb / n), A: uint8(sa / n)}
+}
+
+// RedactImage pixelates regions of an encoded PNG, JPEG or WebP image and
+// encodes it again in its format. WebP, which can't be encoded here, comes
+// back as PNG; browsers and the detector tell images apart by content.
+func RedactImage(data []byte, regions []image.Rectangle) ([]byte, error) {
+	if len(regions) == 0 {
+		return data, nil
+	}
+	img, format, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode screenshot to redact: %w", err)
+	}
+	redacted := Pixelate(img, regions, RedactBlockSize)
+	var out bytes.Buffer
+	if format == "jpeg" {
+		err = jpeg.Encode(&out, redacted, &jpeg.Options{Quality: 90})
+	} else {
+		err = png.Encode(&out, redacted)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode redacted screenshot: %w", err)
+	}
+	return out.Bytes(), nil
+}
@@ -0,0 +1,67 @@
+package vision
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// stripes is an image of alternating black and white columns
+func stripes(w, h int) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			if x%2 == 0 {
+				img.Set(x, y, color.White)
+			} else {
+				img.Set(x, y, color.Black)
+			}
+		}
+	}
+	return img
+}
+
+func TestPixelate(t *testing.T) {
+	img := stripes(64, 32)
+	out := Pixelate(img, []image.Rectangle{image.Rect(0, 0, 32, 32), image.Rect(60, 30, 100, 100)}, 16)
+
+	inside := out.RGBAAt(0, 0)
+	assert.Equal(t, inside, out.RGBAAt(1, 0), "stripes in a block are averaged")
+	assert.InDelta(t, 127, int(inside.R), 1)
+	assert.Equal(t, color.RGBA{R: 255, G: 255, B: 255, A: 255}, out.RGBAAt(40, 0), "outside regions is untouched")
+	assert.Equal(t, out.RGBAAt(60, 30), out.RGBAAt(61, 30), "regions are clipped to the image")
+	assert.Equal(t, color.RGBA{R: 255, G: 255, B: 255, A: 255}, img.RGBAAt(0, 0), "the original is left alone")
+}
+
+func TestRedactImage(t *testing.T) {
+	var encoded bytes.Buffer
+	require.NoError(t, png.Encode(&encoded, stripes(32, 32)))
+	data, err := RedactImage(encoded.Bytes(), []image.Rectangle{image.Rect(0, 0, 16, 16)})
+	require.NoError(t, err)
+	img, format, err := image.Decode(bytes.NewReader(data))
+	require.NoError(t, err)
+	assert.Equal(t, "png", format)
+	r0, _, _, _ := img.At(0, 0).RGBA()
+	r1, _, _, _ := img.At(1, 0).RGBA()
+	assert.Equal(t, r0, r1)
+
+	encoded.Reset()
+	require.NoError(t, jpeg.Encode(&encoded, stripes(32, 32), nil))
+	data, err = RedactImage(encoded.Bytes(), []image.Rectangle{image.Rect(0, 0, 16, 16)})
+	require.NoError(t, err)
+	_, format, err = image.Decode(bytes.NewReader(data))
+	require.NoError(t, err)
+	assert.Equal(t, "jpeg", format)
+
+	unchanged, err := RedactImage([]byte("not an image"), nil)
+	require.NoError(t, err)
+	assert.Equal(t, []byte("not an image"), unchanged)
+	_, err = RedactImage([]byte("not an image"), []image.Rectangle{image.Rect(0, 0, 1, 1)})
+	assert.ErrorContains(t, err, "failed to decode")
+}
@@ -0,0 +1,146 @@
+package vision
+
+import "errors"
+
+// GF(256) arithmetic with the QR code primitive polynomial x^8+x^4+x^3+x^2+1
+var (
+	gfExp [512]byte
+	gfLog [256]byte
+)
+
+func init() {
+	x := 1
+	for i := 0; i < 255; i++ {
+		gfExp[i] = byte(x)
+		gfLog[x] = byte(i)
+		x <<= 1
+		if x&0x100 != 0 {
+			x ^= 0x11D
+		}
+	}
+	for i := 255; i < len(gfExp); i++ {
+		gfExp[i] = gfExp[i-255]
+	}
+}
+
+func gfMul(a, b byte) byte {
+	if a == 0 || b == 0 {
+		return 0
+	}
+	return gfExp[int(gfLog[a])+int(gfLog[b])]
+}
+
+func gfDiv(a, b byte) byte {
+	if a == 0 {
+		return 0
+	}
+	return gfExp[int(gfLog[a])+255-int(gfLog[b])]
+}
+
+// gfEval evaluates a polynomial with coefficients lowest degree first
+func gfEval(poly []byte, x byte) byte {
+	var y byte
+	for i := len(poly) - 1; i >= 0; i-- {
+		y = gfMul(y, x) ^ poly[i]
+	}
+	return y
+}
+
+var errTooManyErrors = errors.New("too many errors to correct")
+
+// rsCorrect corrects a Reed-Solomon block in place, data codewords followed
+// by ecLen error correction codewords, highest degree first as read from the
+// symbol. It returns the number of corrected codewords.
+func rsCorrect(block []byte, ecLen int) (int, error) {
+	n := len(block)
+	syndromes, clean := rsSyndromes(block, ecLen)
+	if clean {
+		return 0, nil
+	}
+
+	// Berlekamp-Massey: error locator Λ, lowest degree first
+	locator := []byte{1}
+	prev := []byte{1}
+	length, shift := 0, 1
+	prevDiscrepancy := byte(1)
+	for i := 0; i < ecLen; i++ {
+		d := syndromes[i]
+		for k := 1; k <= length && k < len(locator); k++ {
+			d ^= gfMul(locator[k], syndromes[i-k])
+		}
+		if d == 0 {
+			shift++
+			continue
+		}
+		scale := gfDiv(d, prevDiscrepancy)
+		next := make([]byte, max(len(locator), len(prev)+shift))
+		copy(next, locator)
+		for k, c := range prev {
+			next[k+shift] ^= gfMul(scale, c)
+		}
+		if 2*length <= i {
+			prev, length, prevDiscrepancy, shift = locator, i+1-length, d, 1
+		} else {
+			shift++
+		}
+		locator = next
+	}
+	if length > ecLen/2 {
+		return 0, errTooManyErrors
+	}
+
+	// Ω = S·Λ mod x^ecLen and the formal derivative Λ'
+	evaluator := make([]byte, ecLen)
+	for i, s := range syndromes {
+		for k, c := range locator {
+			if i+k < ecLen {
+				evaluator[i+k] ^= gfMul(s, c)
+			}
+		}
+	}
+	derivative := make([]byte, len(locator))
+	for k := 1; k < len(locator); k += 2 {
+		derivative[k-1] = locator[k]
+	}
+
+	// Chien search for the roots X⁻¹ of Λ, then Forney for the magnitudes
+	found := 0
+	for degree := 0; degree < n; degree++ {
+		xInv := gfExp[(255-degree)%255]
+		if gfEval(locator, xInv) != 0 {
+			continue
+		}
+		denominator := gfEval(derivative, xInv)
+		if denominator == 0 {
+			return 0, errTooManyErrors
+		}
+		magnitude := gfMul(gfExp[degree], gfDiv(gfEval(evaluator, xInv), denominator))
+		block[n-1-degree] ^= magnitude
+		found++
+	}
+	if found != length {
+		return 0, errTooManyErrors
+	}
+	if _, clean := rsSyndromes(block, ecLen); !clean {
+		return 0, errTooManyErrors
+	}
+	return found, nil
+}
+
+// rsSyndromes evaluates the block at α^0..α^(ecLen-1), with block[0] as the
+// highest degree coefficient; all are zero for a valid block
+func rsSyndromes(block []byte, ecLen int) ([]byte, bool) {
+	syndromes := make([]byte, ecLen)
+	clean := true
+	for j := range syndromes {
+		var s byte
+		for _, c := range block {
+			s = gfMul(s, gfExp[j]) ^ c
+		}
+		syndromes[j] = s
+		if s != 0 {
+			clean = false
+		}
+	}
+	return syndromes, clean
+}
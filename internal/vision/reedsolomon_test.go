@@ -0,0 +1,69 @@
+package vision
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// rsEncode returns the ecLen error correction codewords of data, the
+// remainder of data·x^ecLen divided by the generator Π(x - α^i)
+func rsEncode(data []byte, ecLen int) []byte {
+	generator := []byte{1} // highest degree first
+	for i := 0; i < ecLen; i++ {
+		next := make([]byte, len(generator)+1)
+		for k, c := range generator {
+			next[k] ^= c
+			next[k+1] ^= gfMul(c, gfExp[i])
+		}
+		generator = next
+	}
+	remainder := make([]byte, len(data)+ecLen)
+	copy(remainder, data)
+	for i := range data {
+		factor := remainder[i]
+		if factor == 0 {
+			continue
+		}
+		for k, c := range generator {
+			remainder[i+k] ^= gfMul(c, factor)
+		}
+	}
+	return remainder[len(data):]
+}
+
+// TestRSEncode_Known tests the EC codewords of the "HELLO WORLD" 1-M example
+// from the QR code specification's walkthroughs
+func TestRSEncode_Known(t *testing.T) {
+	data := []byte{32, 91, 11, 120, 209, 114, 220, 77, 67, 64, 236, 17, 236, 17, 236, 17}
+	assert.Equal(t, []byte{196, 35, 39, 119, 235, 215, 231, 226, 93, 23}, rsEncode(data, 10))
+}
+
+// TestRSCorrect tests correcting up to ecLen/2 errors and refusing more
+func TestRSCorrect(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	for trial := 0; trial < 200; trial++ {
+		data := make([]byte, 10+rng.Intn(40))
+		rng.Read(data)
+		ecLen := 2 * (1 + rng.Intn(12))
+		block := append(append([]byte{}, data...), rsEncode(data, ecLen)...)
+		want := append([]byte{}, block...)
+
+		errors := rng.Intn(ecLen/2 + 1)
+		for _, pos := range rng.Perm(len(block))[:errors] {
+			block[pos] ^= byte(1 + rng.Intn(255))
+		}
+		n, err := rsCorrect(block, ecLen)
+		require.NoError(t, err, "trial %d", trial)
+		assert.Equal(t, errors, n)
+		assert.Equal(t, want, block)
+	}
+
+	data := []byte("0123456789")
+	block := append(append([]byte{}, data...), rsEncode(data, 4)...)
+	block[0], block[3], block[7] = block[0]^1, block[3]^2, block[7]^3
+	_, err := rsCorrect(block, 4)
+	assert.Error(t, err)
+}
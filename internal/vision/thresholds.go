@@ -0,0 +1,112 @@
+package vision
+
+import (
+	"sort"
+)
+
+// DefaultModelIoU is the overlap above which non-max suppression drops the
+// weaker of two model detections of a type, unless Thresholds.NMS is set
+const DefaultModelIoU = 0.45
+
+// Thresholds decide which detected elements DetectElements keeps. Elements
+// scoring below the minimum confidence of their type are dropped, then
+// non-max suppression drops elements overlapping a more confident one.
+type Thresholds struct {
+	MinConfidence float64            // every element type
+	ByType        map[string]float64 // overrides MinConfidence per element type
+	NMS           *NMS               // nil suppresses model detections at DefaultModelIoU and keeps every heuristic one
+}
+
+// NMS configures non-max suppression
+type NMS struct {
+	IoU         float64 // intersection over union above which the weaker element is dropped
+	AcrossTypes bool    // also suppress overlapping elements of different types
+}
+
+// SetThresholds sets the confidence thresholds and non-max suppression
+// DetectElements applies to both the heuristic and model detectors
+func (ed *ElementDetector) SetThresholds(thresholds Thresholds) {
+	ed.thresholds = thresholds
+}
+
+// Min is the confidence an element of a type needs
+func (t Thresholds) Min(elementType string) float64 {
+	if v, ok := t.ByType[elementType]; ok {
+		return v
+	}
+	return t.MinConfidence
+}
+
+// Lowest is the smallest confidence any element type needs
+func (t Thresholds) Lowest() float64 {
+	lowest := t.MinConfidence
+	for _, v := range t.ByType {
+		if v < lowest {
+			lowest = v
+		}
+	}
+	return lowest
+}
+
+// Apply filters elements by confidence and suppresses overlaps, keeping the
+// order of the elements it keeps
+func (t Thresholds) Apply(elements []ElementInfo) []ElementInfo {
+	kept := make([]ElementInfo, 0, len(elements))
+	for _, e := range elements {
+		if e.Confidence >= t.Min(e.Type) {
+			kept = append(kept, e)
+		}
+	}
+	nms := t.NMS
+	if nms == nil || nms.IoU <= 0 {
+		return kept
+	}
+
+	// Greedily keep the most confident of overlapping elements
+	order := make([]int, len(kept))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(i, j int) bool { return kept[order[i]].Confidence > kept[order[j]].Confidence })
+	suppressed := make([]bool, len(kept))
+	chosen := make([]ElementInfo, 0, len(kept))
+	for n, i := range order {
+		if suppressed[i] {
+			continue
+		}
+		for _, j := range order[n+1:] {
+			if !suppressed[j] && (nms.AcrossTypes || kept[i].Type == kept[j].Type) && elementIoU(kept[i], kept[j]) > nms.IoU {
+				suppressed[j] = true
+			}
+		}
+	}
+	for i, e := range kept {
+		if !suppressed[i] {
+			chosen = append(chosen, e)
+		}
+	}
+	return chosen
+}
+
+// filter applies the thresholds to what a detector found. Model detections
+// are always suppressed, at DefaultModelIoU unless NMS is set.
+func (t Thresholds) filter(elements []ElementInfo, fromModel bool) []ElementInfo {
+	if fromModel && t.NMS == nil {
+		t.NMS = &NMS{IoU: DefaultModelIoU}
+	}
+	if !fromModel && t.MinConfidence <= 0 && len(t.ByType) == 0 && t.NMS == nil {
+		return elements
+	}
+	return t.Apply(elements)
+}
+
+// elementIoU is the intersection over union of two elements' boxes
+func elementIoU(a, b ElementInfo) float64 {
+	w := min(a.Position.X+a.Size.Width, b.Position.X+b.Size.Width) - max(a.Position.X, b.Position.X)
+	h := min(a.Position.Y+a.Size.Height, b.Position.Y+b.Size.Height) - max(a.Position.Y, b.Position.Y)
+	if w <= 0 || h <= 0 {
+		return 0
+	}
+	inter := float64(w * h)
+	return inter / (float64(a.Size.Width*a.Size.Height+b.Size.Width*b.Size.Height) - inter)
+}
@@ -0,0 +1,70 @@
+package vision
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"testing"
+
+	"panoptic/internal/logger"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func box(elementType string, x, y, w, h int, confidence float64) ElementInfo {
+	return ElementInfo{Type: elementType, Position: Point{X: x, Y: y}, Size: Size{Width: w, Height: h}, Confidence: confidence}
+}
+
+// TestThresholds_Apply tests per-type minimums and suppression within and
+// across types, keeping the input order
+func TestThresholds_Apply(t *testing.T) {
+	elements := []ElementInfo{
+		box("button", 0, 0, 100, 40, 0.6),
+		box("button", 5, 2, 100, 40, 0.9), // overlaps the first
+		box("link", 0, 0, 100, 40, 0.7),   // same box, another type
+		box("link", 200, 0, 50, 20, 0.3),
+	}
+
+	thresholds := Thresholds{MinConfidence: 0.5, ByType: map[string]float64{"link": 0.2}}
+	assert.Equal(t, 0.2, thresholds.Min("link"))
+	assert.Equal(t, 0.5, thresholds.Min("image"))
+	assert.Equal(t, 0.2, thresholds.Lowest())
+	assert.Equal(t, elements, thresholds.Apply(elements))
+	thresholds.ByType["link"] = 0.5
+	assert.Equal(t, elements[:3], thresholds.Apply(elements))
+
+	thresholds = Thresholds{NMS: &NMS{IoU: 0.5}}
+	assert.Equal(t, []ElementInfo{elements[1], elements[2], elements[3]}, thresholds.Apply(elements))
+	thresholds.NMS.AcrossTypes = true
+	assert.Equal(t, []ElementInfo{elements[1], elements[3]}, thresholds.Apply(elements))
+	thresholds.NMS.IoU = 0.95
+	assert.Equal(t, elements[1:], thresholds.Apply(elements), "only the identical boxes overlap enough")
+
+	assert.InDelta(t, 1.0/3, elementIoU(box("", 0, 0, 10, 10, 0), box("", 5, 0, 10, 10, 0)), 1e-9)
+	assert.Zero(t, elementIoU(box("", 0, 0, 10, 10, 0), box("", 10, 0, 10, 10, 0)), "touching")
+}
+
+// TestDetectElements_Thresholds tests that thresholds filter the heuristic
+// detectors, which are left alone by default
+func TestDetectElements_Thresholds(t *testing.T) {
+	img := image.NewRGBA(image.Rect(0, 0, 400, 200))
+	draw.Draw(img, img.Bounds(), &image.Uniform{color.RGBA{150, 150, 150, 255}}, image.Point{}, draw.Src)
+	draw.Draw(img, image.Rect(200, 100, 400, 200), &image.Uniform{color.RGBA{250, 250, 250, 255}}, image.Point{}, draw.Src)
+	path := saveTestImage(t, img, "page.png")
+	detector := NewElementDetector(*logger.NewLogger(false))
+	all, err := detector.DetectElements(path)
+	require.NoError(t, err)
+	require.NotEmpty(t, detector.FindElementByType(all, "textfield"))
+
+	detector.SetThresholds(Thresholds{ByType: map[string]float64{"textfield": 0.9}})
+	filtered, err := detector.DetectElements(path)
+	require.NoError(t, err)
+	assert.Empty(t, detector.FindElementByType(filtered, "textfield"), "textfield heuristics score 0.80")
+	assert.Equal(t, len(all)-len(detector.FindElementByType(all, "textfield")), len(filtered))
+
+	detector.SetThresholds(Thresholds{NMS: &NMS{IoU: 0.3}})
+	suppressed, err := detector.DetectElements(path)
+	require.NoError(t, err)
+	assert.Less(t, len(suppressed), len(all), "grid neighbours overlap")
+}
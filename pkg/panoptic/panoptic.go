@@ -0,0 +1,93 @@
+// Package panoptic runs Panoptic configurations from Go programs, such as
+// a project's own test harness, without shelling out to the panoptic
+// command. A run writes the same screenshots, videos, results.json and
+// report as panoptic run, and returns its results:
+//
+//	cfg, err := panoptic.LoadConfig("smoke.yaml")
+//	if err != nil {
+//		t.Fatal(err)
+//	}
+//	runner, err := panoptic.NewRunner(cfg, panoptic.WithOutputDir(t.TempDir()), panoptic.WithTags("smoke"))
+//	if err != nil {
+//		t.Fatal(err)
+//	}
+//	run, err := runner.Run(ctx)
+//	if err != nil {
+//		t.Fatal(err)
+//	}
+//	if !run.Passed() {
+//		t.Errorf("%d of %d app(s) failed: %v", run.Summary.Failed, run.Summary.Total, run.Failure)
+//	}
+//
+// Config, Result and the other types are those of the panoptic command, so
+// a configuration built in Go has every field a YAML one has.
+package panoptic
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"panoptic/internal/config"
+	"panoptic/internal/executor"
+)
+
+// Configuration types, as in the YAML configuration
+type (
+	Config   = config.Config
+	App      = config.AppConfig
+	Action   = config.Action
+	Settings = config.Settings
+)
+
+// Result types, as in results.json
+type (
+	Result          = executor.TestResult
+	Summary         = executor.RunSummary
+	Artifact        = executor.Artifact
+	ResultsDocument = executor.ResultsDocument
+	Manifest        = executor.Manifest
+	ManifestApp     = executor.ManifestApp
+)
+
+// ManifestFile is the reproducibility manifest every run writes to its
+// output directory
+const ManifestFile = executor.ManifestFile
+
+// LoadManifest reads the manifest.json of a run, to replay it with
+// WithManifest or compare it with another
+func LoadManifest(path string) (*Manifest, error) {
+	return executor.LoadManifest(path)
+}
+
+// LoadConfig reads and validates a YAML configuration file, reading the
+// Gherkin features it names relative to it
+func LoadConfig(path string) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config file: %w", err)
+	}
+	cfg, err := parseConfig(data, filepath.Dir(path))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return cfg, nil
+}
+
+// ParseConfig reads and validates a YAML configuration, filling in setting
+// defaults and expanding Gherkin features and app matrices as panoptic run
+// does. Feature files are read relative to the working directory.
+func ParseConfig(data []byte) (*Config, error) {
+	return parseConfig(data, ".")
+}
+
+func parseConfig(data []byte, dir string) (*Config, error) {
+	cfg, err := config.ParseRelative(data, dir)
+	if err != nil {
+		return nil, err
+	}
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
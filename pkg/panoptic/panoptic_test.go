@@ -0,0 +1,47 @@
+package panoptic
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadConfig(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "smoke.yaml")
+	require.NoError(t, os.WriteFile(path, []byte("name: Smoke\napps:\n  - name: Site\n    type: web\n    url: https://example.com\n"), 0644))
+	cfg, err := LoadConfig(path)
+	require.NoError(t, err)
+	assert.Equal(t, "Smoke", cfg.Name)
+	assert.Equal(t, 80, cfg.Settings.Quality, "defaults filled in")
+
+	require.NoError(t, os.WriteFile(path, []byte("name: Empty\n"), 0644))
+	_, err = LoadConfig(path)
+	assert.EqualError(t, err, path+": at least one application must be configured")
+
+	_, err = ParseConfig([]byte("apps: ["))
+	assert.ErrorContains(t, err, "failed to parse config file")
+}
+
+func TestLoadConfig_Gherkin(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "home.feature"), []byte("Feature: Home\n  Scenario: Open\n    Given I open the site\n"), 0644))
+	path := filepath.Join(dir, "smoke.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(`apps:
+  - name: Site
+    type: web
+    url: https://example.com
+gherkin:
+  features: [home.feature]
+  steps:
+    - step: I open the site
+      actions: [{type: navigate, url: "https://example.com"}]
+`), 0644))
+	cfg, err := LoadConfig(path)
+	require.NoError(t, err)
+	require.Len(t, cfg.Apps, 1)
+	assert.Equal(t, "Home: Open", cfg.Apps[0].Name)
+	assert.Equal(t, "Given I open the site", cfg.Apps[0].Actions[0].Name)
+}
@@ -0,0 +1,134 @@
+// Package panoptictest runs Panoptic configurations as Go tests, so end to
+// end suites run with go test ./e2e/... next to the unit tests:
+//
+//	func TestSmoke(t *testing.T) {
+//		panoptictest.Run(t, "smoke.yaml", panoptic.WithTags("smoke"))
+//	}
+//
+// Every app of the run becomes a subtest, like TestSmoke/Shop_edge,
+// that fails when the app failed and logs the files it produced. Run
+// with -v to see the run's log.
+package panoptictest
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+
+	"panoptic/internal/config"
+	"panoptic/pkg/panoptic"
+)
+
+// Run loads a configuration file and runs it with RunConfig
+func Run(t *testing.T, path string, opts ...panoptic.Option) *panoptic.Run {
+	t.Helper()
+	cfg, err := panoptic.LoadConfig(path)
+	if err != nil {
+		t.Fatalf("panoptic: %v", err)
+	}
+	return RunConfig(t, cfg, append([]panoptic.Option{panoptic.WithConfigFile(path)}, opts...)...)
+}
+
+// RunConfig runs a configuration in the test and reports each app as a
+// subtest. The run stops when the test's context is done; a run that
+// can't be started or finished fails the test at once. Options are those
+// of panoptic.NewRunner; with -v the run logs to the test unless
+// WithLogOutput sends the log elsewhere.
+func RunConfig(t *testing.T, cfg *panoptic.Config, opts ...panoptic.Option) *panoptic.Run {
+	t.Helper()
+	logs := &logWriter{t: t}
+	defer logs.Flush()
+	if testing.Verbose() {
+		opts = append([]panoptic.Option{panoptic.WithLogOutput(logs)}, opts...)
+	}
+	runner, err := panoptic.NewRunner(cfg, opts...)
+	if err != nil {
+		t.Fatalf("panoptic: %v", err)
+	}
+	run, err := runner.Run(t.Context())
+	if err != nil {
+		t.Fatalf("panoptic: %v", err)
+	}
+	logs.Flush()
+
+	failed := false
+	for _, result := range run.Results {
+		if !t.Run(SubtestName(result), func(t *testing.T) { reportResult(t, result) }) {
+			failed = true
+		}
+	}
+	if run.Failure != nil && !failed {
+		// The failure policy failed the run without a failing app, e.g.
+		// on its failure rate
+		t.Errorf("panoptic: %v", run.Failure)
+	}
+	t.Logf("panoptic: run %s: results in %s", run.RunID, run.ResultsFile)
+	return run
+}
+
+// SubtestName is the subtest of a result: the app name, which tells app
+// matrix instances apart, followed by the browser of web apps
+func SubtestName(result *panoptic.Result) string {
+	if result.Browser != "" {
+		return result.AppName + " " + result.Browser
+	}
+	return result.AppName
+}
+
+// reportResult logs the files of a result and fails the subtest when the
+// app failed. Quarantined failures skip it and warnings only log.
+func reportResult(t *testing.T, result *panoptic.Result) {
+	for _, a := range result.Artifacts() {
+		t.Logf("%s: %s", a.Type, a.Path)
+	}
+	if result.Success {
+		return
+	}
+	switch {
+	case result.Quarantined:
+		t.Skipf("quarantined failure: %s", result.Error)
+	case result.Severity == config.SeverityWarning:
+		t.Logf("warning (%s): %s", result.FailureCategory, result.Error)
+	default:
+		msg := result.Error
+		if result.FailureCategory != "" {
+			msg = fmt.Sprintf("%s failure: %s", result.FailureCategory, msg)
+		}
+		t.Error(msg)
+	}
+}
+
+// logWriter passes the run's log to the test line by line
+type logWriter struct {
+	t   *testing.T
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (w *logWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.buf.Write(p)
+	for {
+		line, err := w.buf.ReadString('\n')
+		if err != nil {
+			// Keep the partial line for the next write
+			w.buf.Reset()
+			w.buf.WriteString(line)
+			return len(p), nil
+		}
+		w.t.Log(strings.TrimSuffix(line, "\n"))
+	}
+}
+
+// Flush logs a last partial line
+func (w *logWriter) Flush() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.buf.Len() > 0 {
+		w.t.Log(w.buf.String())
+		w.buf.Reset()
+	}
+}
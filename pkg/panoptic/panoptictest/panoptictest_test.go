@@ -0,0 +1,75 @@
+package panoptictest
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"panoptic/pkg/panoptic"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// helperEnv makes the test binary run the suites below, whose failures are
+// checked from the outside
+const helperEnv = "PANOPTICTEST_HELPER"
+
+func suiteConfig(t *testing.T, missing bool) *panoptic.Config {
+	t.Helper()
+	appPath := filepath.Join(t.TempDir(), "app")
+	require.NoError(t, os.WriteFile(appPath, []byte("#!/bin/sh\n"), 0755))
+	cfg := &panoptic.Config{
+		Name:    "Suite",
+		Apps:    []panoptic.App{{Name: "Desk", Type: "desktop", Path: appPath}},
+		Actions: []panoptic.Action{{Name: "hold", Type: "pause"}},
+	}
+	if missing {
+		cfg.Apps = append(cfg.Apps, panoptic.App{Name: "Missing", Type: "desktop", Path: filepath.Join(t.TempDir(), "missing")})
+	}
+	return cfg
+}
+
+func TestRunConfig(t *testing.T) {
+	dir := t.TempDir()
+	run := RunConfig(t, suiteConfig(t, false), panoptic.WithOutputDir(dir), panoptic.WithReport(false))
+	assert.True(t, run.Passed())
+	require.Len(t, run.Results, 1)
+	assert.FileExists(t, filepath.Join(dir, "results.json"))
+}
+
+func TestRun(t *testing.T) {
+	dir := t.TempDir()
+	appPath := filepath.Join(dir, "app")
+	require.NoError(t, os.WriteFile(appPath, []byte("#!/bin/sh\n"), 0755))
+	path := filepath.Join(dir, "suite.yaml")
+	require.NoError(t, os.WriteFile(path, []byte("name: Suite\napps:\n  - name: Desk\n    type: desktop\n    path: "+appPath+"\nactions:\n  - name: hold\n    type: pause\n"), 0644))
+	run := Run(t, path, panoptic.WithOutputDir(filepath.Join(dir, "output")))
+	assert.NotEmpty(t, run.Config.SHA256, "the configuration file is hashed")
+}
+
+func TestSubtestName(t *testing.T) {
+	assert.Equal(t, "Shop", SubtestName(&panoptic.Result{AppName: "Shop"}))
+	assert.Equal(t, "Shop firefox", SubtestName(&panoptic.Result{AppName: "Shop", Browser: "firefox"}))
+}
+
+// TestFailingSuite is run by TestRunConfig_Failure in a child process
+func TestFailingSuite(t *testing.T) {
+	if os.Getenv(helperEnv) == "" {
+		t.Skip("run by TestRunConfig_Failure")
+	}
+	RunConfig(t, suiteConfig(t, true), panoptic.WithOutputDir(t.TempDir()))
+}
+
+func TestRunConfig_Failure(t *testing.T) {
+	cmd := exec.Command(os.Args[0], "-test.run=^TestFailingSuite$", "-test.v")
+	cmd.Env = append(os.Environ(), helperEnv+"=1")
+	out, err := cmd.CombinedOutput()
+	require.Error(t, err, "the suite fails")
+	output := string(out)
+	assert.Contains(t, output, "--- PASS: TestFailingSuite/Desk")
+	assert.Contains(t, output, "--- FAIL: TestFailingSuite/Missing")
+	assert.Contains(t, output, "Ignoring pause action 'hold'", "the run's log with -v")
+	assert.Contains(t, output, "results in ")
+}
@@ -0,0 +1,269 @@
+package panoptic
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"panoptic/internal/config"
+	"panoptic/internal/executor"
+	"panoptic/internal/logger"
+
+	"github.com/sirupsen/logrus"
+)
+
+// Option configures a Runner
+type Option func(*options)
+
+type options struct {
+	outputDir  string
+	logOutput  io.Writer
+	verbose    bool
+	runID      string
+	fakeSeed   *int64
+	tags       string
+	configFile string
+	report     bool
+	history    string
+	sarif      string
+	tracing    bool
+	stream     bool
+	manifest   string
+}
+
+// WithOutputDir sets the directory screenshots, videos, results.json and
+// the report are written to. It defaults to the configuration's output, or
+// ./output as for panoptic run.
+func WithOutputDir(dir string) Option {
+	return func(o *options) { o.outputDir = dir }
+}
+
+// WithLogOutput writes the run's log to w; it is discarded by default
+func WithLogOutput(w io.Writer) Option {
+	return func(o *options) { o.logOutput = w }
+}
+
+// WithVerbose logs debug entries too
+func WithVerbose() Option {
+	return func(o *options) { o.verbose = true }
+}
+
+// WithRunID sets the correlation ID of the run's log entries and results,
+// generated when unset
+func WithRunID(id string) Option {
+	return func(o *options) { o.runID = id }
+}
+
+// WithFakeSeed seeds the {{fake.*}} test data, to replay a previous run
+func WithFakeSeed(seed int64) Option {
+	return func(o *options) { o.fakeSeed = &seed }
+}
+
+// WithTags runs only apps and actions with these comma-separated tags, as
+// panoptic run --tags does; prefix a tag with ! to exclude it
+func WithTags(tags string) Option {
+	return func(o *options) { o.tags = tags }
+}
+
+// WithConfigFile names the file the configuration was loaded from, so
+// results.json records its hash and SARIF results point at it
+func WithConfigFile(path string) Option {
+	return func(o *options) { o.configFile = path }
+}
+
+// WithReport sets whether runs write report.html, which they do by default
+func WithReport(enabled bool) Option {
+	return func(o *options) { o.report = enabled }
+}
+
+// WithHistory appends the outcome of every run to a run history, the
+// history.jsonl panoptic history reads
+func WithHistory(path string) Option {
+	return func(o *options) { o.history = path }
+}
+
+// WithSARIF also writes the failures of every run as a SARIF 2.1.0 log
+func WithSARIF(path string) Option {
+	return func(o *options) { o.sarif = path }
+}
+
+// WithTracing records a trace archive per app, as panoptic run --trace does
+func WithTracing() Option {
+	return func(o *options) { o.tracing = true }
+}
+
+// WithStreamedResults writes each app's result to results.ndjson in the
+// output directory as it finishes, as panoptic run --stream-results does,
+// for suites too large to hold in memory. Run.Results then holds summaries
+// without metrics or artifacts; results.json has them all.
+func WithStreamedResults() Option {
+	return func(o *options) { o.stream = true }
+}
+
+// WithManifest replays the inputs of the run that wrote a manifest.json, as
+// panoptic run --manifest does: its test data seed and tags. Runs fail when
+// the configuration file changed since.
+func WithManifest(path string) Option {
+	return func(o *options) { o.manifest = path }
+}
+
+// Runner runs a configuration. It can run it any number of times, one run
+// at a time.
+type Runner struct {
+	config    *Config
+	options   options
+	tagFilter config.TagFilter
+	replay    *executor.Manifest
+}
+
+// NewRunner validates a configuration and the options to run it with
+func NewRunner(cfg *Config, opts ...Option) (*Runner, error) {
+	if cfg == nil {
+		return nil, errors.New("configuration is required")
+	}
+	if err := cfg.Validate(); err != nil {
+		return nil, fmt.Errorf("configuration validation failed: %w", err)
+	}
+	r := &Runner{config: cfg, options: options{logOutput: io.Discard, report: true}}
+	for _, opt := range opts {
+		opt(&r.options)
+	}
+	if r.options.tags != "" {
+		filter, err := config.ParseTagFilter(r.options.tags)
+		if err != nil {
+			return nil, fmt.Errorf("invalid tags: %w", err)
+		}
+		r.tagFilter = filter
+	}
+	if r.options.configFile != "" {
+		if _, err := os.Stat(r.options.configFile); err != nil {
+			return nil, fmt.Errorf("configuration file: %w", err)
+		}
+	}
+	if r.options.manifest != "" {
+		if r.options.fakeSeed != nil {
+			return nil, errors.New("a manifest replays the seed of its run; drop WithFakeSeed")
+		}
+		manifest, err := executor.LoadManifest(r.options.manifest)
+		if err != nil {
+			return nil, err
+		}
+		r.replay = manifest
+	}
+	return r, nil
+}
+
+// Run is a finished run
+type Run struct {
+	*ResultsDocument
+	OutputDir    string
+	ResultsFile  string // results.json
+	ManifestFile string // manifest.json
+	ReportFile   string // report.html; empty when reports are off
+
+	// Failure is why the run failed under settings.failure_policy; nil
+	// when it passed
+	Failure error
+}
+
+// Passed reports whether the run passed under settings.failure_policy
+func (r *Run) Passed() bool {
+	return r.Failure == nil
+}
+
+// outputDir is where a run writes its files
+func (r *Runner) outputDir() string {
+	switch {
+	case r.options.outputDir != "":
+		return r.options.outputDir
+	case r.config.Output != "":
+		return r.config.Output
+	}
+	return "./output"
+}
+
+// Run runs the configuration and writes its files. Once ctx is done the
+// run stops before its next app; Run then returns the apps that ran with
+// the error. Failing apps don't make Run fail: see Run.Passed.
+func (r *Runner) Run(ctx context.Context) (*Run, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	outputDir := r.outputDir()
+	for _, dir := range []string{"screenshots", "videos", "logs"} {
+		if err := os.MkdirAll(filepath.Join(outputDir, dir), 0755); err != nil {
+			return nil, fmt.Errorf("failed to create output directory: %w", err)
+		}
+	}
+
+	log := logger.NewLogger(r.options.verbose)
+	log.SetFormatter(&logrus.TextFormatter{FullTimestamp: true})
+	log.SetOutput(r.options.logOutput)
+	defer log.Close()
+
+	exec := executor.NewExecutor(r.config, outputDir, log)
+	if r.options.runID != "" {
+		exec.SetRunID(r.options.runID)
+	}
+	if r.options.fakeSeed != nil {
+		exec.SetFakeSeed(*r.options.fakeSeed)
+	}
+	exec.SetTagFilter(r.tagFilter)
+	if r.options.configFile != "" {
+		if err := exec.SetConfigFile(r.options.configFile); err != nil {
+			log.Warnf("Configuration hash unavailable in results.json: %v", err)
+		}
+	}
+	if r.replay != nil {
+		if err := exec.Replay(r.replay); err != nil {
+			return nil, err
+		}
+	}
+	if r.options.tracing {
+		exec.EnableTracing()
+	}
+	if r.options.stream {
+		if err := exec.StreamResults(filepath.Join(outputDir, executor.ResultStreamFile)); err != nil {
+			return nil, err
+		}
+	}
+	runErr := exec.RunContext(ctx)
+
+	run := &Run{
+		ResultsDocument: exec.Results(),
+		OutputDir:       outputDir,
+		ResultsFile:     filepath.Join(outputDir, "results.json"),
+		ManifestFile:    filepath.Join(outputDir, executor.ManifestFile),
+		Failure:         exec.CheckFailurePolicy(),
+	}
+	errs := []error{runErr}
+	if err := exec.SaveResults(run.ResultsFile); err != nil {
+		errs = append(errs, fmt.Errorf("failed to save results: %w", err))
+	}
+	if err := exec.SaveManifest(run.ManifestFile); err != nil {
+		errs = append(errs, err)
+	}
+	if r.options.sarif != "" {
+		if err := exec.SaveSARIF(r.options.sarif); err != nil {
+			errs = append(errs, fmt.Errorf("failed to save SARIF log: %w", err))
+		}
+	}
+	if r.options.history != "" {
+		if err := exec.AppendHistory(r.options.history); err != nil {
+			errs = append(errs, fmt.Errorf("failed to update run history: %w", err))
+		}
+	}
+	if r.options.report {
+		run.ReportFile = filepath.Join(outputDir, "report.html")
+		if err := exec.GenerateReport(run.ReportFile); err != nil {
+			errs = append(errs, fmt.Errorf("failed to generate report: %w", err))
+		}
+	}
+	if _, err := exec.EncryptArtifacts(); err != nil {
+		errs = append(errs, fmt.Errorf("failed to encrypt artifacts: %w", err))
+	}
+	return run, errors.Join(errs...)
+}
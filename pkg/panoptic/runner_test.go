@@ -0,0 +1,122 @@
+package panoptic
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// desktopConfig is a configuration of a desktop app that starts and exits
+// at once, with one action per tag
+func desktopConfig(t *testing.T) *Config {
+	t.Helper()
+	appPath := filepath.Join(t.TempDir(), "app")
+	require.NoError(t, os.WriteFile(appPath, []byte("#!/bin/sh\n"), 0755))
+	return &Config{
+		Name: "Embedded",
+		Apps: []App{{Name: "Desk", Type: "desktop", Path: appPath}},
+		Actions: []Action{
+			{Name: "hold", Type: "pause", Tags: []string{"smoke"}},
+			{Name: "slow_hold", Type: "pause", Tags: []string{"slow"}},
+		},
+	}
+}
+
+func TestRunner_Run(t *testing.T) {
+	dir := t.TempDir()
+	var logs bytes.Buffer
+	history := filepath.Join(dir, "history.jsonl")
+	runner, err := NewRunner(desktopConfig(t), WithOutputDir(dir), WithRunID("run-7"), WithTags("smoke"),
+		WithFakeSeed(42), WithLogOutput(&logs), WithHistory(history))
+	require.NoError(t, err)
+
+	run, err := runner.Run(context.Background())
+	require.NoError(t, err)
+	assert.True(t, run.Passed())
+	assert.Equal(t, "run-7", run.RunID)
+	assert.Equal(t, Summary{Total: 1, Passed: 1}, run.Summary)
+	require.Len(t, run.Results, 1)
+	assert.Equal(t, "Desk", run.Results[0].AppName)
+	assert.Equal(t, "smoke", run.Config.TagFilter)
+	assert.Equal(t, int64(42), run.Config.FakeSeed)
+	assert.Contains(t, logs.String(), "Ignoring pause action 'hold'")
+	assert.NotContains(t, logs.String(), "slow_hold")
+
+	assert.Equal(t, filepath.Join(dir, "results.json"), run.ResultsFile)
+	assert.FileExists(t, run.ResultsFile)
+	assert.FileExists(t, run.ReportFile)
+	assert.FileExists(t, history)
+	assert.DirExists(t, filepath.Join(dir, "screenshots"))
+
+	// A runner runs its configuration again with a new run ID
+	runner, err = NewRunner(desktopConfig(t), WithOutputDir(dir), WithReport(false))
+	require.NoError(t, err)
+	again, err := runner.Run(context.Background())
+	require.NoError(t, err)
+	assert.NotEqual(t, run.RunID, again.RunID)
+	assert.Empty(t, again.ReportFile)
+}
+
+func TestRunner_Run_Manifest(t *testing.T) {
+	dir := t.TempDir()
+	runner, err := NewRunner(desktopConfig(t), WithOutputDir(dir), WithTags("smoke"), WithFakeSeed(42), WithReport(false))
+	require.NoError(t, err)
+	run, err := runner.Run(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(dir, ManifestFile), run.ManifestFile)
+	require.FileExists(t, run.ManifestFile)
+
+	// The replay runs with the seed and tags of the manifest
+	runner, err = NewRunner(desktopConfig(t), WithOutputDir(t.TempDir()), WithManifest(run.ManifestFile), WithReport(false))
+	require.NoError(t, err)
+	replay, err := runner.Run(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, int64(42), replay.Config.FakeSeed)
+	assert.Equal(t, "smoke", replay.Config.TagFilter)
+	manifest, err := LoadManifest(replay.ManifestFile)
+	require.NoError(t, err)
+	assert.Equal(t, run.RunID, manifest.ReplayOf)
+
+	_, err = NewRunner(desktopConfig(t), WithManifest(run.ManifestFile), WithFakeSeed(1))
+	assert.Error(t, err)
+	_, err = NewRunner(desktopConfig(t), WithManifest(filepath.Join(dir, "missing.json")))
+	assert.Error(t, err)
+}
+
+func TestRunner_Run_Failure(t *testing.T) {
+	cfg := desktopConfig(t)
+	cfg.Apps = append(cfg.Apps, App{Name: "Missing", Type: "desktop", Path: filepath.Join(t.TempDir(), "missing")})
+	runner, err := NewRunner(cfg, WithOutputDir(t.TempDir()), WithReport(false))
+	require.NoError(t, err)
+	run, err := runner.Run(context.Background())
+	require.NoError(t, err, "failing apps don't fail Run")
+	assert.False(t, run.Passed())
+	assert.Error(t, run.Failure)
+	assert.Equal(t, 1, run.Summary.Failed)
+}
+
+func TestRunner_Run_Cancelled(t *testing.T) {
+	runner, err := NewRunner(desktopConfig(t), WithOutputDir(t.TempDir()))
+	require.NoError(t, err)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	run, err := runner.Run(ctx)
+	assert.Nil(t, run)
+	assert.ErrorIs(t, err, context.Canceled)
+}
+
+func TestNewRunner_Errors(t *testing.T) {
+	_, err := NewRunner(nil)
+	assert.EqualError(t, err, "configuration is required")
+	_, err = NewRunner(&Config{})
+	assert.EqualError(t, err, "configuration validation failed: at least one application must be configured")
+	_, err = NewRunner(desktopConfig(t), WithTags("smoke,!"))
+	assert.ErrorContains(t, err, "invalid tags")
+	_, err = NewRunner(desktopConfig(t), WithConfigFile(filepath.Join(t.TempDir(), "gone.yaml")))
+	assert.ErrorContains(t, err, "configuration file")
+}
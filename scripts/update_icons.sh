@@ -23,7 +23,6 @@ echo ""
 echo "📁 Generated files:"
 echo "   - Icons: Assets/icons/"
 echo "   - Splash screens: Assets/splash/"
-echo "   - README updated with logo"
 echo ""
 echo "💡 Tip: You can view the icon manifest at Assets/icons/manifest.json"
-echo "💡 Tip: Usage documentation is available at Assets/icons/README.md"
\ No newline at end of file
+echo "💡 Tip: Run ./scripts/fix_readme_logo.sh to refresh the README logo"
\ No newline at end of file